@@ -45,12 +45,27 @@ const (
 	// RestoreRequestServiceApproveRequestProcedure is the fully-qualified name of the
 	// RestoreRequestService's ApproveRequest RPC.
 	RestoreRequestServiceApproveRequestProcedure = "/airgapper.v1.RestoreRequestService/ApproveRequest"
+	// RestoreRequestServiceIssueChallengeProcedure is the fully-qualified name of the
+	// RestoreRequestService's IssueChallenge RPC.
+	RestoreRequestServiceIssueChallengeProcedure = "/airgapper.v1.RestoreRequestService/IssueChallenge"
 	// RestoreRequestServiceSignRequestProcedure is the fully-qualified name of the
 	// RestoreRequestService's SignRequest RPC.
 	RestoreRequestServiceSignRequestProcedure = "/airgapper.v1.RestoreRequestService/SignRequest"
 	// RestoreRequestServiceDenyRequestProcedure is the fully-qualified name of the
 	// RestoreRequestService's DenyRequest RPC.
 	RestoreRequestServiceDenyRequestProcedure = "/airgapper.v1.RestoreRequestService/DenyRequest"
+	// RestoreRequestServiceRequestExtensionProcedure is the fully-qualified name of the
+	// RestoreRequestService's RequestExtension RPC.
+	RestoreRequestServiceRequestExtensionProcedure = "/airgapper.v1.RestoreRequestService/RequestExtension"
+	// RestoreRequestServiceApproveExtensionProcedure is the fully-qualified name of the
+	// RestoreRequestService's ApproveExtension RPC.
+	RestoreRequestServiceApproveExtensionProcedure = "/airgapper.v1.RestoreRequestService/ApproveExtension"
+	// RestoreRequestServiceVetoRequestProcedure is the fully-qualified name of the
+	// RestoreRequestService's VetoRequest RPC.
+	RestoreRequestServiceVetoRequestProcedure = "/airgapper.v1.RestoreRequestService/VetoRequest"
+	// RestoreRequestServiceReportRestoreProgressProcedure is the fully-qualified name of the
+	// RestoreRequestService's ReportRestoreProgress RPC.
+	RestoreRequestServiceReportRestoreProgressProcedure = "/airgapper.v1.RestoreRequestService/ReportRestoreProgress"
 )
 
 // RestoreRequestServiceClient is a client for the airgapper.v1.RestoreRequestService service.
@@ -63,10 +78,21 @@ type RestoreRequestServiceClient interface {
 	CreateRequest(context.Context, *connect.Request[v1.CreateRequestRequest]) (*connect.Response[v1.CreateRequestResponse], error)
 	// ApproveRequest approves a restore request (legacy SSS mode)
 	ApproveRequest(context.Context, *connect.Request[v1.ApproveRequestRequest]) (*connect.Response[v1.ApproveRequestResponse], error)
+	// IssueChallenge issues a key holder a nonce to sign their approval, or
+	// veto, with
+	IssueChallenge(context.Context, *connect.Request[v1.IssueChallengeRequest]) (*connect.Response[v1.IssueChallengeResponse], error)
 	// SignRequest signs a restore request (consensus mode)
 	SignRequest(context.Context, *connect.Request[v1.SignRequestRequest]) (*connect.Response[v1.SignRequestResponse], error)
 	// DenyRequest denies a restore request
 	DenyRequest(context.Context, *connect.Request[v1.DenyRequestRequest]) (*connect.Response[v1.DenyRequestResponse], error)
+	// RequestExtension asks for a pending request's expiry to be pushed back
+	RequestExtension(context.Context, *connect.Request[v1.RequestExtensionRequest]) (*connect.Response[v1.RequestExtensionResponse], error)
+	// ApproveExtension approves a request's pending extension as a key holder
+	ApproveExtension(context.Context, *connect.Request[v1.ApproveExtensionRequest]) (*connect.Response[v1.ApproveExtensionResponse], error)
+	// VetoRequest stops a pending request, or an approved one still cooling off
+	VetoRequest(context.Context, *connect.Request[v1.VetoRequestRequest]) (*connect.Response[v1.VetoRequestResponse], error)
+	// ReportRestoreProgress records how far an approved request's restore has got
+	ReportRestoreProgress(context.Context, *connect.Request[v1.ReportRestoreProgressRequest]) (*connect.Response[v1.ReportRestoreProgressResponse], error)
 }
 
 // NewRestoreRequestServiceClient constructs a client for the airgapper.v1.RestoreRequestService
@@ -104,6 +130,12 @@ func NewRestoreRequestServiceClient(httpClient connect.HTTPClient, baseURL strin
 			connect.WithSchema(restoreRequestServiceMethods.ByName("ApproveRequest")),
 			connect.WithClientOptions(opts...),
 		),
+		issueChallenge: connect.NewClient[v1.IssueChallengeRequest, v1.IssueChallengeResponse](
+			httpClient,
+			baseURL+RestoreRequestServiceIssueChallengeProcedure,
+			connect.WithSchema(restoreRequestServiceMethods.ByName("IssueChallenge")),
+			connect.WithClientOptions(opts...),
+		),
 		signRequest: connect.NewClient[v1.SignRequestRequest, v1.SignRequestResponse](
 			httpClient,
 			baseURL+RestoreRequestServiceSignRequestProcedure,
@@ -116,17 +148,46 @@ func NewRestoreRequestServiceClient(httpClient connect.HTTPClient, baseURL strin
 			connect.WithSchema(restoreRequestServiceMethods.ByName("DenyRequest")),
 			connect.WithClientOptions(opts...),
 		),
+		requestExtension: connect.NewClient[v1.RequestExtensionRequest, v1.RequestExtensionResponse](
+			httpClient,
+			baseURL+RestoreRequestServiceRequestExtensionProcedure,
+			connect.WithSchema(restoreRequestServiceMethods.ByName("RequestExtension")),
+			connect.WithClientOptions(opts...),
+		),
+		approveExtension: connect.NewClient[v1.ApproveExtensionRequest, v1.ApproveExtensionResponse](
+			httpClient,
+			baseURL+RestoreRequestServiceApproveExtensionProcedure,
+			connect.WithSchema(restoreRequestServiceMethods.ByName("ApproveExtension")),
+			connect.WithClientOptions(opts...),
+		),
+		vetoRequest: connect.NewClient[v1.VetoRequestRequest, v1.VetoRequestResponse](
+			httpClient,
+			baseURL+RestoreRequestServiceVetoRequestProcedure,
+			connect.WithSchema(restoreRequestServiceMethods.ByName("VetoRequest")),
+			connect.WithClientOptions(opts...),
+		),
+		reportRestoreProgress: connect.NewClient[v1.ReportRestoreProgressRequest, v1.ReportRestoreProgressResponse](
+			httpClient,
+			baseURL+RestoreRequestServiceReportRestoreProgressProcedure,
+			connect.WithSchema(restoreRequestServiceMethods.ByName("ReportRestoreProgress")),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
 // restoreRequestServiceClient implements RestoreRequestServiceClient.
 type restoreRequestServiceClient struct {
-	listRequests   *connect.Client[v1.ListRequestsRequest, v1.ListRequestsResponse]
-	getRequest     *connect.Client[v1.GetRequestRequest, v1.GetRequestResponse]
-	createRequest  *connect.Client[v1.CreateRequestRequest, v1.CreateRequestResponse]
-	approveRequest *connect.Client[v1.ApproveRequestRequest, v1.ApproveRequestResponse]
-	signRequest    *connect.Client[v1.SignRequestRequest, v1.SignRequestResponse]
-	denyRequest    *connect.Client[v1.DenyRequestRequest, v1.DenyRequestResponse]
+	listRequests          *connect.Client[v1.ListRequestsRequest, v1.ListRequestsResponse]
+	getRequest            *connect.Client[v1.GetRequestRequest, v1.GetRequestResponse]
+	createRequest         *connect.Client[v1.CreateRequestRequest, v1.CreateRequestResponse]
+	approveRequest        *connect.Client[v1.ApproveRequestRequest, v1.ApproveRequestResponse]
+	issueChallenge        *connect.Client[v1.IssueChallengeRequest, v1.IssueChallengeResponse]
+	signRequest           *connect.Client[v1.SignRequestRequest, v1.SignRequestResponse]
+	denyRequest           *connect.Client[v1.DenyRequestRequest, v1.DenyRequestResponse]
+	requestExtension      *connect.Client[v1.RequestExtensionRequest, v1.RequestExtensionResponse]
+	approveExtension      *connect.Client[v1.ApproveExtensionRequest, v1.ApproveExtensionResponse]
+	vetoRequest           *connect.Client[v1.VetoRequestRequest, v1.VetoRequestResponse]
+	reportRestoreProgress *connect.Client[v1.ReportRestoreProgressRequest, v1.ReportRestoreProgressResponse]
 }
 
 // ListRequests calls airgapper.v1.RestoreRequestService.ListRequests.
@@ -149,6 +210,11 @@ func (c *restoreRequestServiceClient) ApproveRequest(ctx context.Context, req *c
 	return c.approveRequest.CallUnary(ctx, req)
 }
 
+// IssueChallenge calls airgapper.v1.RestoreRequestService.IssueChallenge.
+func (c *restoreRequestServiceClient) IssueChallenge(ctx context.Context, req *connect.Request[v1.IssueChallengeRequest]) (*connect.Response[v1.IssueChallengeResponse], error) {
+	return c.issueChallenge.CallUnary(ctx, req)
+}
+
 // SignRequest calls airgapper.v1.RestoreRequestService.SignRequest.
 func (c *restoreRequestServiceClient) SignRequest(ctx context.Context, req *connect.Request[v1.SignRequestRequest]) (*connect.Response[v1.SignRequestResponse], error) {
 	return c.signRequest.CallUnary(ctx, req)
@@ -159,6 +225,26 @@ func (c *restoreRequestServiceClient) DenyRequest(ctx context.Context, req *conn
 	return c.denyRequest.CallUnary(ctx, req)
 }
 
+// RequestExtension calls airgapper.v1.RestoreRequestService.RequestExtension.
+func (c *restoreRequestServiceClient) RequestExtension(ctx context.Context, req *connect.Request[v1.RequestExtensionRequest]) (*connect.Response[v1.RequestExtensionResponse], error) {
+	return c.requestExtension.CallUnary(ctx, req)
+}
+
+// ApproveExtension calls airgapper.v1.RestoreRequestService.ApproveExtension.
+func (c *restoreRequestServiceClient) ApproveExtension(ctx context.Context, req *connect.Request[v1.ApproveExtensionRequest]) (*connect.Response[v1.ApproveExtensionResponse], error) {
+	return c.approveExtension.CallUnary(ctx, req)
+}
+
+// VetoRequest calls airgapper.v1.RestoreRequestService.VetoRequest.
+func (c *restoreRequestServiceClient) VetoRequest(ctx context.Context, req *connect.Request[v1.VetoRequestRequest]) (*connect.Response[v1.VetoRequestResponse], error) {
+	return c.vetoRequest.CallUnary(ctx, req)
+}
+
+// ReportRestoreProgress calls airgapper.v1.RestoreRequestService.ReportRestoreProgress.
+func (c *restoreRequestServiceClient) ReportRestoreProgress(ctx context.Context, req *connect.Request[v1.ReportRestoreProgressRequest]) (*connect.Response[v1.ReportRestoreProgressResponse], error) {
+	return c.reportRestoreProgress.CallUnary(ctx, req)
+}
+
 // RestoreRequestServiceHandler is an implementation of the airgapper.v1.RestoreRequestService
 // service.
 type RestoreRequestServiceHandler interface {
@@ -170,10 +256,21 @@ type RestoreRequestServiceHandler interface {
 	CreateRequest(context.Context, *connect.Request[v1.CreateRequestRequest]) (*connect.Response[v1.CreateRequestResponse], error)
 	// ApproveRequest approves a restore request (legacy SSS mode)
 	ApproveRequest(context.Context, *connect.Request[v1.ApproveRequestRequest]) (*connect.Response[v1.ApproveRequestResponse], error)
+	// IssueChallenge issues a key holder a nonce to sign their approval, or
+	// veto, with
+	IssueChallenge(context.Context, *connect.Request[v1.IssueChallengeRequest]) (*connect.Response[v1.IssueChallengeResponse], error)
 	// SignRequest signs a restore request (consensus mode)
 	SignRequest(context.Context, *connect.Request[v1.SignRequestRequest]) (*connect.Response[v1.SignRequestResponse], error)
 	// DenyRequest denies a restore request
 	DenyRequest(context.Context, *connect.Request[v1.DenyRequestRequest]) (*connect.Response[v1.DenyRequestResponse], error)
+	// RequestExtension asks for a pending request's expiry to be pushed back
+	RequestExtension(context.Context, *connect.Request[v1.RequestExtensionRequest]) (*connect.Response[v1.RequestExtensionResponse], error)
+	// ApproveExtension approves a request's pending extension as a key holder
+	ApproveExtension(context.Context, *connect.Request[v1.ApproveExtensionRequest]) (*connect.Response[v1.ApproveExtensionResponse], error)
+	// VetoRequest stops a pending request, or an approved one still cooling off
+	VetoRequest(context.Context, *connect.Request[v1.VetoRequestRequest]) (*connect.Response[v1.VetoRequestResponse], error)
+	// ReportRestoreProgress records how far an approved request's restore has got
+	ReportRestoreProgress(context.Context, *connect.Request[v1.ReportRestoreProgressRequest]) (*connect.Response[v1.ReportRestoreProgressResponse], error)
 }
 
 // NewRestoreRequestServiceHandler builds an HTTP handler from the service implementation. It
@@ -207,6 +304,12 @@ func NewRestoreRequestServiceHandler(svc RestoreRequestServiceHandler, opts ...c
 		connect.WithSchema(restoreRequestServiceMethods.ByName("ApproveRequest")),
 		connect.WithHandlerOptions(opts...),
 	)
+	restoreRequestServiceIssueChallengeHandler := connect.NewUnaryHandler(
+		RestoreRequestServiceIssueChallengeProcedure,
+		svc.IssueChallenge,
+		connect.WithSchema(restoreRequestServiceMethods.ByName("IssueChallenge")),
+		connect.WithHandlerOptions(opts...),
+	)
 	restoreRequestServiceSignRequestHandler := connect.NewUnaryHandler(
 		RestoreRequestServiceSignRequestProcedure,
 		svc.SignRequest,
@@ -219,6 +322,30 @@ func NewRestoreRequestServiceHandler(svc RestoreRequestServiceHandler, opts ...c
 		connect.WithSchema(restoreRequestServiceMethods.ByName("DenyRequest")),
 		connect.WithHandlerOptions(opts...),
 	)
+	restoreRequestServiceRequestExtensionHandler := connect.NewUnaryHandler(
+		RestoreRequestServiceRequestExtensionProcedure,
+		svc.RequestExtension,
+		connect.WithSchema(restoreRequestServiceMethods.ByName("RequestExtension")),
+		connect.WithHandlerOptions(opts...),
+	)
+	restoreRequestServiceApproveExtensionHandler := connect.NewUnaryHandler(
+		RestoreRequestServiceApproveExtensionProcedure,
+		svc.ApproveExtension,
+		connect.WithSchema(restoreRequestServiceMethods.ByName("ApproveExtension")),
+		connect.WithHandlerOptions(opts...),
+	)
+	restoreRequestServiceVetoRequestHandler := connect.NewUnaryHandler(
+		RestoreRequestServiceVetoRequestProcedure,
+		svc.VetoRequest,
+		connect.WithSchema(restoreRequestServiceMethods.ByName("VetoRequest")),
+		connect.WithHandlerOptions(opts...),
+	)
+	restoreRequestServiceReportRestoreProgressHandler := connect.NewUnaryHandler(
+		RestoreRequestServiceReportRestoreProgressProcedure,
+		svc.ReportRestoreProgress,
+		connect.WithSchema(restoreRequestServiceMethods.ByName("ReportRestoreProgress")),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/airgapper.v1.RestoreRequestService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case RestoreRequestServiceListRequestsProcedure:
@@ -229,10 +356,20 @@ func NewRestoreRequestServiceHandler(svc RestoreRequestServiceHandler, opts ...c
 			restoreRequestServiceCreateRequestHandler.ServeHTTP(w, r)
 		case RestoreRequestServiceApproveRequestProcedure:
 			restoreRequestServiceApproveRequestHandler.ServeHTTP(w, r)
+		case RestoreRequestServiceIssueChallengeProcedure:
+			restoreRequestServiceIssueChallengeHandler.ServeHTTP(w, r)
 		case RestoreRequestServiceSignRequestProcedure:
 			restoreRequestServiceSignRequestHandler.ServeHTTP(w, r)
 		case RestoreRequestServiceDenyRequestProcedure:
 			restoreRequestServiceDenyRequestHandler.ServeHTTP(w, r)
+		case RestoreRequestServiceRequestExtensionProcedure:
+			restoreRequestServiceRequestExtensionHandler.ServeHTTP(w, r)
+		case RestoreRequestServiceApproveExtensionProcedure:
+			restoreRequestServiceApproveExtensionHandler.ServeHTTP(w, r)
+		case RestoreRequestServiceVetoRequestProcedure:
+			restoreRequestServiceVetoRequestHandler.ServeHTTP(w, r)
+		case RestoreRequestServiceReportRestoreProgressProcedure:
+			restoreRequestServiceReportRestoreProgressHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -258,6 +395,10 @@ func (UnimplementedRestoreRequestServiceHandler) ApproveRequest(context.Context,
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("airgapper.v1.RestoreRequestService.ApproveRequest is not implemented"))
 }
 
+func (UnimplementedRestoreRequestServiceHandler) IssueChallenge(context.Context, *connect.Request[v1.IssueChallengeRequest]) (*connect.Response[v1.IssueChallengeResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("airgapper.v1.RestoreRequestService.IssueChallenge is not implemented"))
+}
+
 func (UnimplementedRestoreRequestServiceHandler) SignRequest(context.Context, *connect.Request[v1.SignRequestRequest]) (*connect.Response[v1.SignRequestResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("airgapper.v1.RestoreRequestService.SignRequest is not implemented"))
 }
@@ -265,3 +406,19 @@ func (UnimplementedRestoreRequestServiceHandler) SignRequest(context.Context, *c
 func (UnimplementedRestoreRequestServiceHandler) DenyRequest(context.Context, *connect.Request[v1.DenyRequestRequest]) (*connect.Response[v1.DenyRequestResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("airgapper.v1.RestoreRequestService.DenyRequest is not implemented"))
 }
+
+func (UnimplementedRestoreRequestServiceHandler) RequestExtension(context.Context, *connect.Request[v1.RequestExtensionRequest]) (*connect.Response[v1.RequestExtensionResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("airgapper.v1.RestoreRequestService.RequestExtension is not implemented"))
+}
+
+func (UnimplementedRestoreRequestServiceHandler) ApproveExtension(context.Context, *connect.Request[v1.ApproveExtensionRequest]) (*connect.Response[v1.ApproveExtensionResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("airgapper.v1.RestoreRequestService.ApproveExtension is not implemented"))
+}
+
+func (UnimplementedRestoreRequestServiceHandler) VetoRequest(context.Context, *connect.Request[v1.VetoRequestRequest]) (*connect.Response[v1.VetoRequestResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("airgapper.v1.RestoreRequestService.VetoRequest is not implemented"))
+}
+
+func (UnimplementedRestoreRequestServiceHandler) ReportRestoreProgress(context.Context, *connect.Request[v1.ReportRestoreProgressRequest]) (*connect.Response[v1.ReportRestoreProgressResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("airgapper.v1.RestoreRequestService.ReportRestoreProgress is not implemented"))
+}
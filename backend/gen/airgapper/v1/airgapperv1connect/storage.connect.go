@@ -42,6 +42,9 @@ const (
 	// StorageServiceStopStorageProcedure is the fully-qualified name of the StorageService's
 	// StopStorage RPC.
 	StorageServiceStopStorageProcedure = "/airgapper.v1.StorageService/StopStorage"
+	// StorageServiceSetStorageMaintenanceProcedure is the fully-qualified name of the StorageService's
+	// SetStorageMaintenance RPC.
+	StorageServiceSetStorageMaintenanceProcedure = "/airgapper.v1.StorageService/SetStorageMaintenance"
 )
 
 // StorageServiceClient is a client for the airgapper.v1.StorageService service.
@@ -52,6 +55,9 @@ type StorageServiceClient interface {
 	StartStorage(context.Context, *connect.Request[v1.StartStorageRequest]) (*connect.Response[v1.StartStorageResponse], error)
 	// StopStorage stops the storage server
 	StopStorage(context.Context, *connect.Request[v1.StopStorageRequest]) (*connect.Response[v1.StopStorageResponse], error)
+	// SetStorageMaintenance puts the storage server in or out of read-only
+	// maintenance mode
+	SetStorageMaintenance(context.Context, *connect.Request[v1.SetStorageMaintenanceRequest]) (*connect.Response[v1.SetStorageMaintenanceResponse], error)
 }
 
 // NewStorageServiceClient constructs a client for the airgapper.v1.StorageService service. By
@@ -83,14 +89,21 @@ func NewStorageServiceClient(httpClient connect.HTTPClient, baseURL string, opts
 			connect.WithSchema(storageServiceMethods.ByName("StopStorage")),
 			connect.WithClientOptions(opts...),
 		),
+		setStorageMaintenance: connect.NewClient[v1.SetStorageMaintenanceRequest, v1.SetStorageMaintenanceResponse](
+			httpClient,
+			baseURL+StorageServiceSetStorageMaintenanceProcedure,
+			connect.WithSchema(storageServiceMethods.ByName("SetStorageMaintenance")),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
 // storageServiceClient implements StorageServiceClient.
 type storageServiceClient struct {
-	getStorageStatus *connect.Client[v1.GetStorageStatusRequest, v1.GetStorageStatusResponse]
-	startStorage     *connect.Client[v1.StartStorageRequest, v1.StartStorageResponse]
-	stopStorage      *connect.Client[v1.StopStorageRequest, v1.StopStorageResponse]
+	getStorageStatus      *connect.Client[v1.GetStorageStatusRequest, v1.GetStorageStatusResponse]
+	startStorage          *connect.Client[v1.StartStorageRequest, v1.StartStorageResponse]
+	stopStorage           *connect.Client[v1.StopStorageRequest, v1.StopStorageResponse]
+	setStorageMaintenance *connect.Client[v1.SetStorageMaintenanceRequest, v1.SetStorageMaintenanceResponse]
 }
 
 // GetStorageStatus calls airgapper.v1.StorageService.GetStorageStatus.
@@ -108,6 +121,11 @@ func (c *storageServiceClient) StopStorage(ctx context.Context, req *connect.Req
 	return c.stopStorage.CallUnary(ctx, req)
 }
 
+// SetStorageMaintenance calls airgapper.v1.StorageService.SetStorageMaintenance.
+func (c *storageServiceClient) SetStorageMaintenance(ctx context.Context, req *connect.Request[v1.SetStorageMaintenanceRequest]) (*connect.Response[v1.SetStorageMaintenanceResponse], error) {
+	return c.setStorageMaintenance.CallUnary(ctx, req)
+}
+
 // StorageServiceHandler is an implementation of the airgapper.v1.StorageService service.
 type StorageServiceHandler interface {
 	// GetStorageStatus gets the storage server status
@@ -116,6 +134,9 @@ type StorageServiceHandler interface {
 	StartStorage(context.Context, *connect.Request[v1.StartStorageRequest]) (*connect.Response[v1.StartStorageResponse], error)
 	// StopStorage stops the storage server
 	StopStorage(context.Context, *connect.Request[v1.StopStorageRequest]) (*connect.Response[v1.StopStorageResponse], error)
+	// SetStorageMaintenance puts the storage server in or out of read-only
+	// maintenance mode
+	SetStorageMaintenance(context.Context, *connect.Request[v1.SetStorageMaintenanceRequest]) (*connect.Response[v1.SetStorageMaintenanceResponse], error)
 }
 
 // NewStorageServiceHandler builds an HTTP handler from the service implementation. It returns the
@@ -143,6 +164,12 @@ func NewStorageServiceHandler(svc StorageServiceHandler, opts ...connect.Handler
 		connect.WithSchema(storageServiceMethods.ByName("StopStorage")),
 		connect.WithHandlerOptions(opts...),
 	)
+	storageServiceSetStorageMaintenanceHandler := connect.NewUnaryHandler(
+		StorageServiceSetStorageMaintenanceProcedure,
+		svc.SetStorageMaintenance,
+		connect.WithSchema(storageServiceMethods.ByName("SetStorageMaintenance")),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/airgapper.v1.StorageService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case StorageServiceGetStorageStatusProcedure:
@@ -151,6 +178,8 @@ func NewStorageServiceHandler(svc StorageServiceHandler, opts ...connect.Handler
 			storageServiceStartStorageHandler.ServeHTTP(w, r)
 		case StorageServiceStopStorageProcedure:
 			storageServiceStopStorageHandler.ServeHTTP(w, r)
+		case StorageServiceSetStorageMaintenanceProcedure:
+			storageServiceSetStorageMaintenanceHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -171,3 +200,7 @@ func (UnimplementedStorageServiceHandler) StartStorage(context.Context, *connect
 func (UnimplementedStorageServiceHandler) StopStorage(context.Context, *connect.Request[v1.StopStorageRequest]) (*connect.Response[v1.StopStorageResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("airgapper.v1.StorageService.StopStorage is not implemented"))
 }
+
+func (UnimplementedStorageServiceHandler) SetStorageMaintenance(context.Context, *connect.Request[v1.SetStorageMaintenanceRequest]) (*connect.Response[v1.SetStorageMaintenanceResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("airgapper.v1.StorageService.SetStorageMaintenance is not implemented"))
+}
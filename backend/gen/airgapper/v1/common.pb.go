@@ -81,6 +81,7 @@ const (
 	RequestStatus_REQUEST_STATUS_APPROVED    RequestStatus = 2
 	RequestStatus_REQUEST_STATUS_DENIED      RequestStatus = 3
 	RequestStatus_REQUEST_STATUS_EXPIRED     RequestStatus = 4
+	RequestStatus_REQUEST_STATUS_VETOED      RequestStatus = 5
 )
 
 // Enum value maps for RequestStatus.
@@ -91,6 +92,7 @@ var (
 		2: "REQUEST_STATUS_APPROVED",
 		3: "REQUEST_STATUS_DENIED",
 		4: "REQUEST_STATUS_EXPIRED",
+		5: "REQUEST_STATUS_VETOED",
 	}
 	RequestStatus_value = map[string]int32{
 		"REQUEST_STATUS_UNSPECIFIED": 0,
@@ -98,6 +100,7 @@ var (
 		"REQUEST_STATUS_APPROVED":    2,
 		"REQUEST_STATUS_DENIED":      3,
 		"REQUEST_STATUS_EXPIRED":     4,
+		"REQUEST_STATUS_VETOED":      5,
 	}
 )
 
@@ -464,6 +467,10 @@ type Approval struct {
 	KeyHolderName string                 `protobuf:"bytes,2,opt,name=key_holder_name,json=keyHolderName,proto3" json:"key_holder_name,omitempty"`
 	Signature     string                 `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"`
 	ApprovedAt    *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=approved_at,json=approvedAt,proto3" json:"approved_at,omitempty"`
+	OnBehalfOf    string                 `protobuf:"bytes,5,opt,name=on_behalf_of,json=onBehalfOf,proto3" json:"on_behalf_of,omitempty"` // Key holder whose delegated authority was used
+	DelegationId  string                 `protobuf:"bytes,6,opt,name=delegation_id,json=delegationId,proto3" json:"delegation_id,omitempty"`
+	Nonce         string                 `protobuf:"bytes,7,opt,name=nonce,proto3" json:"nonce,omitempty"`                       // Challenge the approval signed
+	SignedAt      *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=signed_at,json=signedAt,proto3" json:"signed_at,omitempty"` // When the key holder signed, by their clock
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -526,6 +533,34 @@ func (x *Approval) GetApprovedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *Approval) GetOnBehalfOf() string {
+	if x != nil {
+		return x.OnBehalfOf
+	}
+	return ""
+}
+
+func (x *Approval) GetDelegationId() string {
+	if x != nil {
+		return x.DelegationId
+	}
+	return ""
+}
+
+func (x *Approval) GetNonce() string {
+	if x != nil {
+		return x.Nonce
+	}
+	return ""
+}
+
+func (x *Approval) GetSignedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SignedAt
+	}
+	return nil
+}
+
 // ApprovalProgress shows the current state of multi-signature approval
 type ApprovalProgress struct {
 	state             protoimpl.MessageState `protogen:"open.v1"`
@@ -751,11 +786,12 @@ func (x *ConsensusInfo) GetRequireApproval() bool {
 
 // Peer represents a connected peer in the system
 type Peer struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Address       string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Name            string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Address         string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	IdentityChanged bool                   `protobuf:"varint,3,opt,name=identity_changed,json=identityChanged,proto3" json:"identity_changed,omitempty"` // Presented a different key or TLS certificate than pinned; calls to it are blocked until repinned
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *Peer) Reset() {
@@ -802,6 +838,13 @@ func (x *Peer) GetAddress() string {
 	return ""
 }
 
+func (x *Peer) GetIdentityChanged() bool {
+	if x != nil {
+		return x.IdentityChanged
+	}
+	return false
+}
+
 var File_airgapper_v1_common_proto protoreflect.FileDescriptor
 
 const file_airgapper_v1_common_proto_rawDesc = "" +
@@ -813,13 +856,18 @@ const file_airgapper_v1_common_proto_rawDesc = "" +
 	"\vErrorDetail\x12\x12\n" +
 	"\x04code\x18\x01 \x01(\tR\x04code\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\x12\x14\n" +
-	"\x05field\x18\x03 \x01(\tR\x05field\"\xb1\x01\n" +
+	"\x05field\x18\x03 \x01(\tR\x05field\"\xc7\x02\n" +
 	"\bApproval\x12\"\n" +
 	"\rkey_holder_id\x18\x01 \x01(\tR\vkeyHolderId\x12&\n" +
 	"\x0fkey_holder_name\x18\x02 \x01(\tR\rkeyHolderName\x12\x1c\n" +
 	"\tsignature\x18\x03 \x01(\tR\tsignature\x12;\n" +
 	"\vapproved_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
-	"approvedAt\"\xa7\x01\n" +
+	"approvedAt\x12 \n" +
+	"\fon_behalf_of\x18\x05 \x01(\tR\n" +
+	"onBehalfOf\x12#\n" +
+	"\rdelegation_id\x18\x06 \x01(\tR\fdelegationId\x12\x14\n" +
+	"\x05nonce\x18\a \x01(\tR\x05nonce\x127\n" +
+	"\tsigned_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\bsignedAt\"\xa7\x01\n" +
 	"\x10ApprovalProgress\x12\x16\n" +
 	"\x06status\x18\x01 \x01(\tR\x06status\x12+\n" +
 	"\x11current_approvals\x18\x02 \x01(\x05R\x10currentApprovals\x12-\n" +
@@ -840,21 +888,23 @@ const file_airgapper_v1_common_proto_rawDesc = "" +
 	"total_keys\x18\x02 \x01(\x05R\ttotalKeys\x128\n" +
 	"\vkey_holders\x18\x03 \x03(\v2\x17.airgapper.v1.KeyHolderR\n" +
 	"keyHolders\x12)\n" +
-	"\x10require_approval\x18\x04 \x01(\bR\x0frequireApproval\"4\n" +
+	"\x10require_approval\x18\x04 \x01(\bR\x0frequireApproval\"_\n" +
 	"\x04Peer\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
-	"\aaddress\x18\x02 \x01(\tR\aaddress*;\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\x12)\n" +
+	"\x10identity_changed\x18\x03 \x01(\bR\x0fidentityChanged*;\n" +
 	"\x04Role\x12\x14\n" +
 	"\x10ROLE_UNSPECIFIED\x10\x00\x12\x0e\n" +
 	"\n" +
 	"ROLE_OWNER\x10\x01\x12\r\n" +
-	"\tROLE_HOST\x10\x02*\x9f\x01\n" +
+	"\tROLE_HOST\x10\x02*\xba\x01\n" +
 	"\rRequestStatus\x12\x1e\n" +
 	"\x1aREQUEST_STATUS_UNSPECIFIED\x10\x00\x12\x1a\n" +
 	"\x16REQUEST_STATUS_PENDING\x10\x01\x12\x1b\n" +
 	"\x17REQUEST_STATUS_APPROVED\x10\x02\x12\x19\n" +
 	"\x15REQUEST_STATUS_DENIED\x10\x03\x12\x1a\n" +
-	"\x16REQUEST_STATUS_EXPIRED\x10\x04*\x91\x01\n" +
+	"\x16REQUEST_STATUS_EXPIRED\x10\x04\x12\x19\n" +
+	"\x15REQUEST_STATUS_VETOED\x10\x05*\x91\x01\n" +
 	"\fDeletionType\x12\x1d\n" +
 	"\x19DELETION_TYPE_UNSPECIFIED\x10\x00\x12\x1a\n" +
 	"\x16DELETION_TYPE_SNAPSHOT\x10\x01\x12\x16\n" +
@@ -910,13 +960,14 @@ var file_airgapper_v1_common_proto_goTypes = []any{
 }
 var file_airgapper_v1_common_proto_depIdxs = []int32{
 	13, // 0: airgapper.v1.Approval.approved_at:type_name -> google.protobuf.Timestamp
-	13, // 1: airgapper.v1.KeyHolder.joined_at:type_name -> google.protobuf.Timestamp
-	10, // 2: airgapper.v1.ConsensusInfo.key_holders:type_name -> airgapper.v1.KeyHolder
-	3,  // [3:3] is the sub-list for method output_type
-	3,  // [3:3] is the sub-list for method input_type
-	3,  // [3:3] is the sub-list for extension type_name
-	3,  // [3:3] is the sub-list for extension extendee
-	0,  // [0:3] is the sub-list for field type_name
+	13, // 1: airgapper.v1.Approval.signed_at:type_name -> google.protobuf.Timestamp
+	13, // 2: airgapper.v1.KeyHolder.joined_at:type_name -> google.protobuf.Timestamp
+	10, // 3: airgapper.v1.ConsensusInfo.key_holders:type_name -> airgapper.v1.KeyHolder
+	4,  // [4:4] is the sub-list for method output_type
+	4,  // [4:4] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_airgapper_v1_common_proto_init() }
@@ -39,8 +39,11 @@ type DeletionRequest struct {
 	RequiredApprovals int32                  `protobuf:"varint,12,opt,name=required_approvals,json=requiredApprovals,proto3" json:"required_approvals,omitempty"`
 	CurrentApprovals  int32                  `protobuf:"varint,13,opt,name=current_approvals,json=currentApprovals,proto3" json:"current_approvals,omitempty"`
 	Approvals         []*Approval            `protobuf:"bytes,14,rep,name=approvals,proto3" json:"approvals,omitempty"`
-	unknownFields     protoimpl.UnknownFields
-	sizeCache         protoimpl.SizeCache
+	// What the retention policy kept and removed when the request was
+	// created, for a request applying it
+	Retention     *RetentionPlan `protobuf:"bytes,15,opt,name=retention,proto3" json:"retention,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *DeletionRequest) Reset() {
@@ -171,6 +174,143 @@ func (x *DeletionRequest) GetApprovals() []*Approval {
 	return nil
 }
 
+func (x *DeletionRequest) GetRetention() *RetentionPlan {
+	if x != nil {
+		return x.Retention
+	}
+	return nil
+}
+
+// RetentionPlan is what a retention policy keeps and removes
+type RetentionPlan struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Policy        string                 `protobuf:"bytes,1,opt,name=policy,proto3" json:"policy,omitempty"` // e.g. "7 daily, 4 weekly"
+	Keep          []*PlannedSnapshot     `protobuf:"bytes,2,rep,name=keep,proto3" json:"keep,omitempty"`
+	Remove        []*PlannedSnapshot     `protobuf:"bytes,3,rep,name=remove,proto3" json:"remove,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetentionPlan) Reset() {
+	*x = RetentionPlan{}
+	mi := &file_airgapper_v1_deletions_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetentionPlan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetentionPlan) ProtoMessage() {}
+
+func (x *RetentionPlan) ProtoReflect() protoreflect.Message {
+	mi := &file_airgapper_v1_deletions_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetentionPlan.ProtoReflect.Descriptor instead.
+func (*RetentionPlan) Descriptor() ([]byte, []int) {
+	return file_airgapper_v1_deletions_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RetentionPlan) GetPolicy() string {
+	if x != nil {
+		return x.Policy
+	}
+	return ""
+}
+
+func (x *RetentionPlan) GetKeep() []*PlannedSnapshot {
+	if x != nil {
+		return x.Keep
+	}
+	return nil
+}
+
+func (x *RetentionPlan) GetRemove() []*PlannedSnapshot {
+	if x != nil {
+		return x.Remove
+	}
+	return nil
+}
+
+// PlannedSnapshot is a snapshot in a RetentionPlan
+type PlannedSnapshot struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	Paths         []string               `protobuf:"bytes,3,rep,name=paths,proto3" json:"paths,omitempty"`
+	Pinned        bool                   `protobuf:"varint,4,opt,name=pinned,proto3" json:"pinned,omitempty"` // Kept only because it is pinned
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlannedSnapshot) Reset() {
+	*x = PlannedSnapshot{}
+	mi := &file_airgapper_v1_deletions_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlannedSnapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlannedSnapshot) ProtoMessage() {}
+
+func (x *PlannedSnapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_airgapper_v1_deletions_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlannedSnapshot.ProtoReflect.Descriptor instead.
+func (*PlannedSnapshot) Descriptor() ([]byte, []int) {
+	return file_airgapper_v1_deletions_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PlannedSnapshot) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *PlannedSnapshot) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *PlannedSnapshot) GetPaths() []string {
+	if x != nil {
+		return x.Paths
+	}
+	return nil
+}
+
+func (x *PlannedSnapshot) GetPinned() bool {
+	if x != nil {
+		return x.Pinned
+	}
+	return false
+}
+
 type ListDeletionsRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Optional filter by status
@@ -181,7 +321,7 @@ type ListDeletionsRequest struct {
 
 func (x *ListDeletionsRequest) Reset() {
 	*x = ListDeletionsRequest{}
-	mi := &file_airgapper_v1_deletions_proto_msgTypes[1]
+	mi := &file_airgapper_v1_deletions_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -193,7 +333,7 @@ func (x *ListDeletionsRequest) String() string {
 func (*ListDeletionsRequest) ProtoMessage() {}
 
 func (x *ListDeletionsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_deletions_proto_msgTypes[1]
+	mi := &file_airgapper_v1_deletions_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -206,7 +346,7 @@ func (x *ListDeletionsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListDeletionsRequest.ProtoReflect.Descriptor instead.
 func (*ListDeletionsRequest) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_deletions_proto_rawDescGZIP(), []int{1}
+	return file_airgapper_v1_deletions_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *ListDeletionsRequest) GetStatusFilter() RequestStatus {
@@ -225,7 +365,7 @@ type ListDeletionsResponse struct {
 
 func (x *ListDeletionsResponse) Reset() {
 	*x = ListDeletionsResponse{}
-	mi := &file_airgapper_v1_deletions_proto_msgTypes[2]
+	mi := &file_airgapper_v1_deletions_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -237,7 +377,7 @@ func (x *ListDeletionsResponse) String() string {
 func (*ListDeletionsResponse) ProtoMessage() {}
 
 func (x *ListDeletionsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_deletions_proto_msgTypes[2]
+	mi := &file_airgapper_v1_deletions_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -250,7 +390,7 @@ func (x *ListDeletionsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListDeletionsResponse.ProtoReflect.Descriptor instead.
 func (*ListDeletionsResponse) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_deletions_proto_rawDescGZIP(), []int{2}
+	return file_airgapper_v1_deletions_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *ListDeletionsResponse) GetDeletions() []*DeletionRequest {
@@ -269,7 +409,7 @@ type GetDeletionRequest struct {
 
 func (x *GetDeletionRequest) Reset() {
 	*x = GetDeletionRequest{}
-	mi := &file_airgapper_v1_deletions_proto_msgTypes[3]
+	mi := &file_airgapper_v1_deletions_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -281,7 +421,7 @@ func (x *GetDeletionRequest) String() string {
 func (*GetDeletionRequest) ProtoMessage() {}
 
 func (x *GetDeletionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_deletions_proto_msgTypes[3]
+	mi := &file_airgapper_v1_deletions_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -294,7 +434,7 @@ func (x *GetDeletionRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetDeletionRequest.ProtoReflect.Descriptor instead.
 func (*GetDeletionRequest) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_deletions_proto_rawDescGZIP(), []int{3}
+	return file_airgapper_v1_deletions_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *GetDeletionRequest) GetId() string {
@@ -313,7 +453,7 @@ type GetDeletionResponse struct {
 
 func (x *GetDeletionResponse) Reset() {
 	*x = GetDeletionResponse{}
-	mi := &file_airgapper_v1_deletions_proto_msgTypes[4]
+	mi := &file_airgapper_v1_deletions_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -325,7 +465,7 @@ func (x *GetDeletionResponse) String() string {
 func (*GetDeletionResponse) ProtoMessage() {}
 
 func (x *GetDeletionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_deletions_proto_msgTypes[4]
+	mi := &file_airgapper_v1_deletions_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -338,7 +478,7 @@ func (x *GetDeletionResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetDeletionResponse.ProtoReflect.Descriptor instead.
 func (*GetDeletionResponse) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_deletions_proto_rawDescGZIP(), []int{4}
+	return file_airgapper_v1_deletions_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *GetDeletionResponse) GetDeletion() *DeletionRequest {
@@ -355,13 +495,16 @@ type CreateDeletionRequest struct {
 	Paths             []string               `protobuf:"bytes,3,rep,name=paths,proto3" json:"paths,omitempty"`
 	Reason            string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
 	RequiredApprovals int32                  `protobuf:"varint,5,opt,name=required_approvals,json=requiredApprovals,proto3" json:"required_approvals,omitempty"`
-	unknownFields     protoimpl.UnknownFields
-	sizeCache         protoimpl.SizeCache
+	// Forget what the configured retention policy removes, previewed now and
+	// attached for approvers to see (prune deletions only)
+	ApplyRetention bool `protobuf:"varint,6,opt,name=apply_retention,json=applyRetention,proto3" json:"apply_retention,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *CreateDeletionRequest) Reset() {
 	*x = CreateDeletionRequest{}
-	mi := &file_airgapper_v1_deletions_proto_msgTypes[5]
+	mi := &file_airgapper_v1_deletions_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -373,7 +516,7 @@ func (x *CreateDeletionRequest) String() string {
 func (*CreateDeletionRequest) ProtoMessage() {}
 
 func (x *CreateDeletionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_deletions_proto_msgTypes[5]
+	mi := &file_airgapper_v1_deletions_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -386,7 +529,7 @@ func (x *CreateDeletionRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateDeletionRequest.ProtoReflect.Descriptor instead.
 func (*CreateDeletionRequest) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_deletions_proto_rawDescGZIP(), []int{5}
+	return file_airgapper_v1_deletions_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *CreateDeletionRequest) GetDeletionType() DeletionType {
@@ -424,6 +567,13 @@ func (x *CreateDeletionRequest) GetRequiredApprovals() int32 {
 	return 0
 }
 
+func (x *CreateDeletionRequest) GetApplyRetention() bool {
+	if x != nil {
+		return x.ApplyRetention
+	}
+	return false
+}
+
 type CreateDeletionResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -435,7 +585,7 @@ type CreateDeletionResponse struct {
 
 func (x *CreateDeletionResponse) Reset() {
 	*x = CreateDeletionResponse{}
-	mi := &file_airgapper_v1_deletions_proto_msgTypes[6]
+	mi := &file_airgapper_v1_deletions_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -447,7 +597,7 @@ func (x *CreateDeletionResponse) String() string {
 func (*CreateDeletionResponse) ProtoMessage() {}
 
 func (x *CreateDeletionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_deletions_proto_msgTypes[6]
+	mi := &file_airgapper_v1_deletions_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -460,7 +610,7 @@ func (x *CreateDeletionResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateDeletionResponse.ProtoReflect.Descriptor instead.
 func (*CreateDeletionResponse) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_deletions_proto_rawDescGZIP(), []int{6}
+	return file_airgapper_v1_deletions_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *CreateDeletionResponse) GetId() string {
@@ -495,7 +645,7 @@ type ApproveDeletionRequest struct {
 
 func (x *ApproveDeletionRequest) Reset() {
 	*x = ApproveDeletionRequest{}
-	mi := &file_airgapper_v1_deletions_proto_msgTypes[7]
+	mi := &file_airgapper_v1_deletions_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -507,7 +657,7 @@ func (x *ApproveDeletionRequest) String() string {
 func (*ApproveDeletionRequest) ProtoMessage() {}
 
 func (x *ApproveDeletionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_deletions_proto_msgTypes[7]
+	mi := &file_airgapper_v1_deletions_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -520,7 +670,7 @@ func (x *ApproveDeletionRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ApproveDeletionRequest.ProtoReflect.Descriptor instead.
 func (*ApproveDeletionRequest) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_deletions_proto_rawDescGZIP(), []int{7}
+	return file_airgapper_v1_deletions_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *ApproveDeletionRequest) GetId() string {
@@ -556,7 +706,7 @@ type ApproveDeletionResponse struct {
 
 func (x *ApproveDeletionResponse) Reset() {
 	*x = ApproveDeletionResponse{}
-	mi := &file_airgapper_v1_deletions_proto_msgTypes[8]
+	mi := &file_airgapper_v1_deletions_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -568,7 +718,7 @@ func (x *ApproveDeletionResponse) String() string {
 func (*ApproveDeletionResponse) ProtoMessage() {}
 
 func (x *ApproveDeletionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_deletions_proto_msgTypes[8]
+	mi := &file_airgapper_v1_deletions_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -581,7 +731,7 @@ func (x *ApproveDeletionResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ApproveDeletionResponse.ProtoReflect.Descriptor instead.
 func (*ApproveDeletionResponse) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_deletions_proto_rawDescGZIP(), []int{8}
+	return file_airgapper_v1_deletions_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *ApproveDeletionResponse) GetStatus() string {
@@ -621,7 +771,7 @@ type DenyDeletionRequest struct {
 
 func (x *DenyDeletionRequest) Reset() {
 	*x = DenyDeletionRequest{}
-	mi := &file_airgapper_v1_deletions_proto_msgTypes[9]
+	mi := &file_airgapper_v1_deletions_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -633,7 +783,7 @@ func (x *DenyDeletionRequest) String() string {
 func (*DenyDeletionRequest) ProtoMessage() {}
 
 func (x *DenyDeletionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_deletions_proto_msgTypes[9]
+	mi := &file_airgapper_v1_deletions_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -646,7 +796,7 @@ func (x *DenyDeletionRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DenyDeletionRequest.ProtoReflect.Descriptor instead.
 func (*DenyDeletionRequest) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_deletions_proto_rawDescGZIP(), []int{9}
+	return file_airgapper_v1_deletions_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *DenyDeletionRequest) GetId() string {
@@ -665,7 +815,7 @@ type DenyDeletionResponse struct {
 
 func (x *DenyDeletionResponse) Reset() {
 	*x = DenyDeletionResponse{}
-	mi := &file_airgapper_v1_deletions_proto_msgTypes[10]
+	mi := &file_airgapper_v1_deletions_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -677,7 +827,7 @@ func (x *DenyDeletionResponse) String() string {
 func (*DenyDeletionResponse) ProtoMessage() {}
 
 func (x *DenyDeletionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_deletions_proto_msgTypes[10]
+	mi := &file_airgapper_v1_deletions_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -690,7 +840,7 @@ func (x *DenyDeletionResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DenyDeletionResponse.ProtoReflect.Descriptor instead.
 func (*DenyDeletionResponse) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_deletions_proto_rawDescGZIP(), []int{10}
+	return file_airgapper_v1_deletions_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *DenyDeletionResponse) GetStatus() string {
@@ -704,7 +854,7 @@ var File_airgapper_v1_deletions_proto protoreflect.FileDescriptor
 
 const file_airgapper_v1_deletions_proto_rawDesc = "" +
 	"\n" +
-	"\x1cairgapper/v1/deletions.proto\x12\fairgapper.v1\x1a\x19airgapper/v1/common.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x88\x05\n" +
+	"\x1cairgapper/v1/deletions.proto\x12\fairgapper.v1\x1a\x19airgapper/v1/common.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xc3\x05\n" +
 	"\x0fDeletionRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1c\n" +
 	"\trequester\x18\x02 \x01(\tR\trequester\x12?\n" +
@@ -724,7 +874,17 @@ const file_airgapper_v1_deletions_proto_rawDesc = "" +
 	"executedAt\x12-\n" +
 	"\x12required_approvals\x18\f \x01(\x05R\x11requiredApprovals\x12+\n" +
 	"\x11current_approvals\x18\r \x01(\x05R\x10currentApprovals\x124\n" +
-	"\tapprovals\x18\x0e \x03(\v2\x16.airgapper.v1.ApprovalR\tapprovals\"X\n" +
+	"\tapprovals\x18\x0e \x03(\v2\x16.airgapper.v1.ApprovalR\tapprovals\x129\n" +
+	"\tretention\x18\x0f \x01(\v2\x1b.airgapper.v1.RetentionPlanR\tretention\"\x91\x01\n" +
+	"\rRetentionPlan\x12\x16\n" +
+	"\x06policy\x18\x01 \x01(\tR\x06policy\x121\n" +
+	"\x04keep\x18\x02 \x03(\v2\x1d.airgapper.v1.PlannedSnapshotR\x04keep\x125\n" +
+	"\x06remove\x18\x03 \x03(\v2\x1d.airgapper.v1.PlannedSnapshotR\x06remove\"\x7f\n" +
+	"\x0fPlannedSnapshot\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12.\n" +
+	"\x04time\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x14\n" +
+	"\x05paths\x18\x03 \x03(\tR\x05paths\x12\x16\n" +
+	"\x06pinned\x18\x04 \x01(\bR\x06pinned\"X\n" +
 	"\x14ListDeletionsRequest\x12@\n" +
 	"\rstatus_filter\x18\x01 \x01(\x0e2\x1b.airgapper.v1.RequestStatusR\fstatusFilter\"T\n" +
 	"\x15ListDeletionsResponse\x12;\n" +
@@ -732,13 +892,14 @@ const file_airgapper_v1_deletions_proto_rawDesc = "" +
 	"\x12GetDeletionRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\"P\n" +
 	"\x13GetDeletionResponse\x129\n" +
-	"\bdeletion\x18\x01 \x01(\v2\x1d.airgapper.v1.DeletionRequestR\bdeletion\"\xd8\x01\n" +
+	"\bdeletion\x18\x01 \x01(\v2\x1d.airgapper.v1.DeletionRequestR\bdeletion\"\x81\x02\n" +
 	"\x15CreateDeletionRequest\x12?\n" +
 	"\rdeletion_type\x18\x01 \x01(\x0e2\x1a.airgapper.v1.DeletionTypeR\fdeletionType\x12!\n" +
 	"\fsnapshot_ids\x18\x02 \x03(\tR\vsnapshotIds\x12\x14\n" +
 	"\x05paths\x18\x03 \x03(\tR\x05paths\x12\x16\n" +
 	"\x06reason\x18\x04 \x01(\tR\x06reason\x12-\n" +
-	"\x12required_approvals\x18\x05 \x01(\x05R\x11requiredApprovals\"{\n" +
+	"\x12required_approvals\x18\x05 \x01(\x05R\x11requiredApprovals\x12'\n" +
+	"\x0fapply_retention\x18\x06 \x01(\bR\x0eapplyRetention\"{\n" +
 	"\x16CreateDeletionResponse\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
 	"\x06status\x18\x02 \x01(\tR\x06status\x129\n" +
@@ -778,52 +939,58 @@ func file_airgapper_v1_deletions_proto_rawDescGZIP() []byte {
 	return file_airgapper_v1_deletions_proto_rawDescData
 }
 
-var file_airgapper_v1_deletions_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_airgapper_v1_deletions_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
 var file_airgapper_v1_deletions_proto_goTypes = []any{
 	(*DeletionRequest)(nil),         // 0: airgapper.v1.DeletionRequest
-	(*ListDeletionsRequest)(nil),    // 1: airgapper.v1.ListDeletionsRequest
-	(*ListDeletionsResponse)(nil),   // 2: airgapper.v1.ListDeletionsResponse
-	(*GetDeletionRequest)(nil),      // 3: airgapper.v1.GetDeletionRequest
-	(*GetDeletionResponse)(nil),     // 4: airgapper.v1.GetDeletionResponse
-	(*CreateDeletionRequest)(nil),   // 5: airgapper.v1.CreateDeletionRequest
-	(*CreateDeletionResponse)(nil),  // 6: airgapper.v1.CreateDeletionResponse
-	(*ApproveDeletionRequest)(nil),  // 7: airgapper.v1.ApproveDeletionRequest
-	(*ApproveDeletionResponse)(nil), // 8: airgapper.v1.ApproveDeletionResponse
-	(*DenyDeletionRequest)(nil),     // 9: airgapper.v1.DenyDeletionRequest
-	(*DenyDeletionResponse)(nil),    // 10: airgapper.v1.DenyDeletionResponse
-	(DeletionType)(0),               // 11: airgapper.v1.DeletionType
-	(RequestStatus)(0),              // 12: airgapper.v1.RequestStatus
-	(*timestamppb.Timestamp)(nil),   // 13: google.protobuf.Timestamp
-	(*Approval)(nil),                // 14: airgapper.v1.Approval
+	(*RetentionPlan)(nil),           // 1: airgapper.v1.RetentionPlan
+	(*PlannedSnapshot)(nil),         // 2: airgapper.v1.PlannedSnapshot
+	(*ListDeletionsRequest)(nil),    // 3: airgapper.v1.ListDeletionsRequest
+	(*ListDeletionsResponse)(nil),   // 4: airgapper.v1.ListDeletionsResponse
+	(*GetDeletionRequest)(nil),      // 5: airgapper.v1.GetDeletionRequest
+	(*GetDeletionResponse)(nil),     // 6: airgapper.v1.GetDeletionResponse
+	(*CreateDeletionRequest)(nil),   // 7: airgapper.v1.CreateDeletionRequest
+	(*CreateDeletionResponse)(nil),  // 8: airgapper.v1.CreateDeletionResponse
+	(*ApproveDeletionRequest)(nil),  // 9: airgapper.v1.ApproveDeletionRequest
+	(*ApproveDeletionResponse)(nil), // 10: airgapper.v1.ApproveDeletionResponse
+	(*DenyDeletionRequest)(nil),     // 11: airgapper.v1.DenyDeletionRequest
+	(*DenyDeletionResponse)(nil),    // 12: airgapper.v1.DenyDeletionResponse
+	(DeletionType)(0),               // 13: airgapper.v1.DeletionType
+	(RequestStatus)(0),              // 14: airgapper.v1.RequestStatus
+	(*timestamppb.Timestamp)(nil),   // 15: google.protobuf.Timestamp
+	(*Approval)(nil),                // 16: airgapper.v1.Approval
 }
 var file_airgapper_v1_deletions_proto_depIdxs = []int32{
-	11, // 0: airgapper.v1.DeletionRequest.deletion_type:type_name -> airgapper.v1.DeletionType
-	12, // 1: airgapper.v1.DeletionRequest.status:type_name -> airgapper.v1.RequestStatus
-	13, // 2: airgapper.v1.DeletionRequest.created_at:type_name -> google.protobuf.Timestamp
-	13, // 3: airgapper.v1.DeletionRequest.expires_at:type_name -> google.protobuf.Timestamp
-	13, // 4: airgapper.v1.DeletionRequest.approved_at:type_name -> google.protobuf.Timestamp
-	13, // 5: airgapper.v1.DeletionRequest.executed_at:type_name -> google.protobuf.Timestamp
-	14, // 6: airgapper.v1.DeletionRequest.approvals:type_name -> airgapper.v1.Approval
-	12, // 7: airgapper.v1.ListDeletionsRequest.status_filter:type_name -> airgapper.v1.RequestStatus
-	0,  // 8: airgapper.v1.ListDeletionsResponse.deletions:type_name -> airgapper.v1.DeletionRequest
-	0,  // 9: airgapper.v1.GetDeletionResponse.deletion:type_name -> airgapper.v1.DeletionRequest
-	11, // 10: airgapper.v1.CreateDeletionRequest.deletion_type:type_name -> airgapper.v1.DeletionType
-	13, // 11: airgapper.v1.CreateDeletionResponse.expires_at:type_name -> google.protobuf.Timestamp
-	1,  // 12: airgapper.v1.DeletionService.ListDeletions:input_type -> airgapper.v1.ListDeletionsRequest
-	3,  // 13: airgapper.v1.DeletionService.GetDeletion:input_type -> airgapper.v1.GetDeletionRequest
-	5,  // 14: airgapper.v1.DeletionService.CreateDeletion:input_type -> airgapper.v1.CreateDeletionRequest
-	7,  // 15: airgapper.v1.DeletionService.ApproveDeletion:input_type -> airgapper.v1.ApproveDeletionRequest
-	9,  // 16: airgapper.v1.DeletionService.DenyDeletion:input_type -> airgapper.v1.DenyDeletionRequest
-	2,  // 17: airgapper.v1.DeletionService.ListDeletions:output_type -> airgapper.v1.ListDeletionsResponse
-	4,  // 18: airgapper.v1.DeletionService.GetDeletion:output_type -> airgapper.v1.GetDeletionResponse
-	6,  // 19: airgapper.v1.DeletionService.CreateDeletion:output_type -> airgapper.v1.CreateDeletionResponse
-	8,  // 20: airgapper.v1.DeletionService.ApproveDeletion:output_type -> airgapper.v1.ApproveDeletionResponse
-	10, // 21: airgapper.v1.DeletionService.DenyDeletion:output_type -> airgapper.v1.DenyDeletionResponse
-	17, // [17:22] is the sub-list for method output_type
-	12, // [12:17] is the sub-list for method input_type
-	12, // [12:12] is the sub-list for extension type_name
-	12, // [12:12] is the sub-list for extension extendee
-	0,  // [0:12] is the sub-list for field type_name
+	13, // 0: airgapper.v1.DeletionRequest.deletion_type:type_name -> airgapper.v1.DeletionType
+	14, // 1: airgapper.v1.DeletionRequest.status:type_name -> airgapper.v1.RequestStatus
+	15, // 2: airgapper.v1.DeletionRequest.created_at:type_name -> google.protobuf.Timestamp
+	15, // 3: airgapper.v1.DeletionRequest.expires_at:type_name -> google.protobuf.Timestamp
+	15, // 4: airgapper.v1.DeletionRequest.approved_at:type_name -> google.protobuf.Timestamp
+	15, // 5: airgapper.v1.DeletionRequest.executed_at:type_name -> google.protobuf.Timestamp
+	16, // 6: airgapper.v1.DeletionRequest.approvals:type_name -> airgapper.v1.Approval
+	1,  // 7: airgapper.v1.DeletionRequest.retention:type_name -> airgapper.v1.RetentionPlan
+	2,  // 8: airgapper.v1.RetentionPlan.keep:type_name -> airgapper.v1.PlannedSnapshot
+	2,  // 9: airgapper.v1.RetentionPlan.remove:type_name -> airgapper.v1.PlannedSnapshot
+	15, // 10: airgapper.v1.PlannedSnapshot.time:type_name -> google.protobuf.Timestamp
+	14, // 11: airgapper.v1.ListDeletionsRequest.status_filter:type_name -> airgapper.v1.RequestStatus
+	0,  // 12: airgapper.v1.ListDeletionsResponse.deletions:type_name -> airgapper.v1.DeletionRequest
+	0,  // 13: airgapper.v1.GetDeletionResponse.deletion:type_name -> airgapper.v1.DeletionRequest
+	13, // 14: airgapper.v1.CreateDeletionRequest.deletion_type:type_name -> airgapper.v1.DeletionType
+	15, // 15: airgapper.v1.CreateDeletionResponse.expires_at:type_name -> google.protobuf.Timestamp
+	3,  // 16: airgapper.v1.DeletionService.ListDeletions:input_type -> airgapper.v1.ListDeletionsRequest
+	5,  // 17: airgapper.v1.DeletionService.GetDeletion:input_type -> airgapper.v1.GetDeletionRequest
+	7,  // 18: airgapper.v1.DeletionService.CreateDeletion:input_type -> airgapper.v1.CreateDeletionRequest
+	9,  // 19: airgapper.v1.DeletionService.ApproveDeletion:input_type -> airgapper.v1.ApproveDeletionRequest
+	11, // 20: airgapper.v1.DeletionService.DenyDeletion:input_type -> airgapper.v1.DenyDeletionRequest
+	4,  // 21: airgapper.v1.DeletionService.ListDeletions:output_type -> airgapper.v1.ListDeletionsResponse
+	6,  // 22: airgapper.v1.DeletionService.GetDeletion:output_type -> airgapper.v1.GetDeletionResponse
+	8,  // 23: airgapper.v1.DeletionService.CreateDeletion:output_type -> airgapper.v1.CreateDeletionResponse
+	10, // 24: airgapper.v1.DeletionService.ApproveDeletion:output_type -> airgapper.v1.ApproveDeletionResponse
+	12, // 25: airgapper.v1.DeletionService.DenyDeletion:output_type -> airgapper.v1.DenyDeletionResponse
+	21, // [21:26] is the sub-list for method output_type
+	16, // [16:21] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
 }
 
 func init() { file_airgapper_v1_deletions_proto_init() }
@@ -838,7 +1005,7 @@ func file_airgapper_v1_deletions_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_airgapper_v1_deletions_proto_rawDesc), len(file_airgapper_v1_deletions_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   11,
+			NumMessages:   13,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
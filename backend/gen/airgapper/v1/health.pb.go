@@ -140,15 +140,20 @@ func (*GetStatusRequest) Descriptor() ([]byte, []int) {
 
 // SchedulerInfo contains backup scheduler status
 type SchedulerInfo struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Enabled       bool                   `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
-	Schedule      string                 `protobuf:"bytes,2,opt,name=schedule,proto3" json:"schedule,omitempty"`
-	Paths         []string               `protobuf:"bytes,3,rep,name=paths,proto3" json:"paths,omitempty"`
-	LastRun       *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=last_run,json=lastRun,proto3" json:"last_run,omitempty"`
-	NextRun       *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=next_run,json=nextRun,proto3" json:"next_run,omitempty"`
-	LastError     string                 `protobuf:"bytes,6,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Enabled   bool                   `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	Schedule  string                 `protobuf:"bytes,2,opt,name=schedule,proto3" json:"schedule,omitempty"`
+	Paths     []string               `protobuf:"bytes,3,rep,name=paths,proto3" json:"paths,omitempty"`
+	LastRun   *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=last_run,json=lastRun,proto3" json:"last_run,omitempty"`
+	NextRun   *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=next_run,json=nextRun,proto3" json:"next_run,omitempty"`
+	LastError string                 `protobuf:"bytes,6,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	// False once consecutive failed runs reach failure_threshold
+	Healthy             bool   `protobuf:"varint,7,opt,name=healthy,proto3" json:"healthy,omitempty"`
+	ConsecutiveFailures int32  `protobuf:"varint,8,opt,name=consecutive_failures,json=consecutiveFailures,proto3" json:"consecutive_failures,omitempty"`
+	FailureThreshold    int32  `protobuf:"varint,9,opt,name=failure_threshold,json=failureThreshold,proto3" json:"failure_threshold,omitempty"`
+	Timezone            string `protobuf:"bytes,10,opt,name=timezone,proto3" json:"timezone,omitempty"` // IANA timezone the schedule's times are in, "Local" for the system's
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
 }
 
 func (x *SchedulerInfo) Reset() {
@@ -223,26 +228,182 @@ func (x *SchedulerInfo) GetLastError() string {
 	return ""
 }
 
+func (x *SchedulerInfo) GetHealthy() bool {
+	if x != nil {
+		return x.Healthy
+	}
+	return false
+}
+
+func (x *SchedulerInfo) GetConsecutiveFailures() int32 {
+	if x != nil {
+		return x.ConsecutiveFailures
+	}
+	return 0
+}
+
+func (x *SchedulerInfo) GetFailureThreshold() int32 {
+	if x != nil {
+		return x.FailureThreshold
+	}
+	return 0
+}
+
+func (x *SchedulerInfo) GetTimezone() string {
+	if x != nil {
+		return x.Timezone
+	}
+	return ""
+}
+
+// ReplicationTargetInfo contains the status of a secondary repository that
+// snapshots are copied to
+type ReplicationTargetInfo struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Name             string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	RepoUrl          string                 `protobuf:"bytes,2,opt,name=repo_url,json=repoUrl,proto3" json:"repo_url,omitempty"`
+	Enabled          bool                   `protobuf:"varint,3,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	LastRun          *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=last_run,json=lastRun,proto3" json:"last_run,omitempty"`
+	LastSuccess      *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=last_success,json=lastSuccess,proto3" json:"last_success,omitempty"`
+	LastError        string                 `protobuf:"bytes,6,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	SourceSnapshots  int32                  `protobuf:"varint,7,opt,name=source_snapshots,json=sourceSnapshots,proto3" json:"source_snapshots,omitempty"`
+	TargetSnapshots  int32                  `protobuf:"varint,8,opt,name=target_snapshots,json=targetSnapshots,proto3" json:"target_snapshots,omitempty"`
+	MissingSnapshots int32                  `protobuf:"varint,9,opt,name=missing_snapshots,json=missingSnapshots,proto3" json:"missing_snapshots,omitempty"`
+	InSync           bool                   `protobuf:"varint,10,opt,name=in_sync,json=inSync,proto3" json:"in_sync,omitempty"` // false when the copy diverged from the primary
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ReplicationTargetInfo) Reset() {
+	*x = ReplicationTargetInfo{}
+	mi := &file_airgapper_v1_health_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicationTargetInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicationTargetInfo) ProtoMessage() {}
+
+func (x *ReplicationTargetInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_airgapper_v1_health_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicationTargetInfo.ProtoReflect.Descriptor instead.
+func (*ReplicationTargetInfo) Descriptor() ([]byte, []int) {
+	return file_airgapper_v1_health_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ReplicationTargetInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ReplicationTargetInfo) GetRepoUrl() string {
+	if x != nil {
+		return x.RepoUrl
+	}
+	return ""
+}
+
+func (x *ReplicationTargetInfo) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *ReplicationTargetInfo) GetLastRun() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastRun
+	}
+	return nil
+}
+
+func (x *ReplicationTargetInfo) GetLastSuccess() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastSuccess
+	}
+	return nil
+}
+
+func (x *ReplicationTargetInfo) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *ReplicationTargetInfo) GetSourceSnapshots() int32 {
+	if x != nil {
+		return x.SourceSnapshots
+	}
+	return 0
+}
+
+func (x *ReplicationTargetInfo) GetTargetSnapshots() int32 {
+	if x != nil {
+		return x.TargetSnapshots
+	}
+	return 0
+}
+
+func (x *ReplicationTargetInfo) GetMissingSnapshots() int32 {
+	if x != nil {
+		return x.MissingSnapshots
+	}
+	return 0
+}
+
+func (x *ReplicationTargetInfo) GetInSync() bool {
+	if x != nil {
+		return x.InSync
+	}
+	return false
+}
+
 type GetStatusResponse struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	Name            string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Role            Role                   `protobuf:"varint,2,opt,name=role,proto3,enum=airgapper.v1.Role" json:"role,omitempty"`
-	RepoUrl         string                 `protobuf:"bytes,3,opt,name=repo_url,json=repoUrl,proto3" json:"repo_url,omitempty"`
-	HasShare        bool                   `protobuf:"varint,4,opt,name=has_share,json=hasShare,proto3" json:"has_share,omitempty"`
-	ShareIndex      int32                  `protobuf:"varint,5,opt,name=share_index,json=shareIndex,proto3" json:"share_index,omitempty"`
-	PendingRequests int32                  `protobuf:"varint,6,opt,name=pending_requests,json=pendingRequests,proto3" json:"pending_requests,omitempty"`
-	BackupPaths     []string               `protobuf:"bytes,7,rep,name=backup_paths,json=backupPaths,proto3" json:"backup_paths,omitempty"`
-	Mode            OperationMode          `protobuf:"varint,8,opt,name=mode,proto3,enum=airgapper.v1.OperationMode" json:"mode,omitempty"`
-	Peer            *Peer                  `protobuf:"bytes,9,opt,name=peer,proto3" json:"peer,omitempty"`
-	Consensus       *ConsensusInfo         `protobuf:"bytes,10,opt,name=consensus,proto3" json:"consensus,omitempty"`
-	Scheduler       *SchedulerInfo         `protobuf:"bytes,11,opt,name=scheduler,proto3" json:"scheduler,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+	state           protoimpl.MessageState   `protogen:"open.v1"`
+	Name            string                   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Role            Role                     `protobuf:"varint,2,opt,name=role,proto3,enum=airgapper.v1.Role" json:"role,omitempty"`
+	RepoUrl         string                   `protobuf:"bytes,3,opt,name=repo_url,json=repoUrl,proto3" json:"repo_url,omitempty"`
+	HasShare        bool                     `protobuf:"varint,4,opt,name=has_share,json=hasShare,proto3" json:"has_share,omitempty"`
+	ShareIndex      int32                    `protobuf:"varint,5,opt,name=share_index,json=shareIndex,proto3" json:"share_index,omitempty"`
+	PendingRequests int32                    `protobuf:"varint,6,opt,name=pending_requests,json=pendingRequests,proto3" json:"pending_requests,omitempty"`
+	BackupPaths     []string                 `protobuf:"bytes,7,rep,name=backup_paths,json=backupPaths,proto3" json:"backup_paths,omitempty"`
+	Mode            OperationMode            `protobuf:"varint,8,opt,name=mode,proto3,enum=airgapper.v1.OperationMode" json:"mode,omitempty"`
+	Peer            *Peer                    `protobuf:"bytes,9,opt,name=peer,proto3" json:"peer,omitempty"`
+	Consensus       *ConsensusInfo           `protobuf:"bytes,10,opt,name=consensus,proto3" json:"consensus,omitempty"`
+	Scheduler       *SchedulerInfo           `protobuf:"bytes,11,opt,name=scheduler,proto3" json:"scheduler,omitempty"`
+	Replication     []*ReplicationTargetInfo `protobuf:"bytes,12,rep,name=replication,proto3" json:"replication,omitempty"`
+	// The server's clock, so peers can detect clock skew
+	ServerTime *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=server_time,json=serverTime,proto3" json:"server_time,omitempty"`
+	// Whether this node's storage server is in read-only maintenance mode
+	StorageReadOnly          bool   `protobuf:"varint,14,opt,name=storage_read_only,json=storageReadOnly,proto3" json:"storage_read_only,omitempty"`
+	StorageMaintenanceReason string `protobuf:"bytes,15,opt,name=storage_maintenance_reason,json=storageMaintenanceReason,proto3" json:"storage_maintenance_reason,omitempty"`
+	// The API version this node serves and every version it accepts, as
+	// GET /api/v1/version reports them
+	ApiVersion           string   `protobuf:"bytes,16,opt,name=api_version,json=apiVersion,proto3" json:"api_version,omitempty"`
+	SupportedApiVersions []string `protobuf:"bytes,17,rep,name=supported_api_versions,json=supportedApiVersions,proto3" json:"supported_api_versions,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
 }
 
 func (x *GetStatusResponse) Reset() {
 	*x = GetStatusResponse{}
-	mi := &file_airgapper_v1_health_proto_msgTypes[4]
+	mi := &file_airgapper_v1_health_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -254,7 +415,7 @@ func (x *GetStatusResponse) String() string {
 func (*GetStatusResponse) ProtoMessage() {}
 
 func (x *GetStatusResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_health_proto_msgTypes[4]
+	mi := &file_airgapper_v1_health_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -267,7 +428,7 @@ func (x *GetStatusResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetStatusResponse.ProtoReflect.Descriptor instead.
 func (*GetStatusResponse) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_health_proto_rawDescGZIP(), []int{4}
+	return file_airgapper_v1_health_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *GetStatusResponse) GetName() string {
@@ -347,6 +508,48 @@ func (x *GetStatusResponse) GetScheduler() *SchedulerInfo {
 	return nil
 }
 
+func (x *GetStatusResponse) GetReplication() []*ReplicationTargetInfo {
+	if x != nil {
+		return x.Replication
+	}
+	return nil
+}
+
+func (x *GetStatusResponse) GetServerTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ServerTime
+	}
+	return nil
+}
+
+func (x *GetStatusResponse) GetStorageReadOnly() bool {
+	if x != nil {
+		return x.StorageReadOnly
+	}
+	return false
+}
+
+func (x *GetStatusResponse) GetStorageMaintenanceReason() string {
+	if x != nil {
+		return x.StorageMaintenanceReason
+	}
+	return ""
+}
+
+func (x *GetStatusResponse) GetApiVersion() string {
+	if x != nil {
+		return x.ApiVersion
+	}
+	return ""
+}
+
+func (x *GetStatusResponse) GetSupportedApiVersions() []string {
+	if x != nil {
+		return x.SupportedApiVersions
+	}
+	return nil
+}
+
 var File_airgapper_v1_health_proto protoreflect.FileDescriptor
 
 const file_airgapper_v1_health_proto_rawDesc = "" +
@@ -355,7 +558,7 @@ const file_airgapper_v1_health_proto_rawDesc = "" +
 	"\fCheckRequest\"'\n" +
 	"\rCheckResponse\x12\x16\n" +
 	"\x06status\x18\x01 \x01(\tR\x06status\"\x12\n" +
-	"\x10GetStatusRequest\"\xe8\x01\n" +
+	"\x10GetStatusRequest\"\xfe\x02\n" +
 	"\rSchedulerInfo\x12\x18\n" +
 	"\aenabled\x18\x01 \x01(\bR\aenabled\x12\x1a\n" +
 	"\bschedule\x18\x02 \x01(\tR\bschedule\x12\x14\n" +
@@ -363,7 +566,25 @@ const file_airgapper_v1_health_proto_rawDesc = "" +
 	"\blast_run\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\alastRun\x125\n" +
 	"\bnext_run\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\anextRun\x12\x1d\n" +
 	"\n" +
-	"last_error\x18\x06 \x01(\tR\tlastError\"\xc5\x03\n" +
+	"last_error\x18\x06 \x01(\tR\tlastError\x12\x18\n" +
+	"\ahealthy\x18\a \x01(\bR\ahealthy\x121\n" +
+	"\x14consecutive_failures\x18\b \x01(\x05R\x13consecutiveFailures\x12+\n" +
+	"\x11failure_threshold\x18\t \x01(\x05R\x10failureThreshold\x12\x1a\n" +
+	"\btimezone\x18\n" +
+	" \x01(\tR\btimezone\"\x91\x03\n" +
+	"\x15ReplicationTargetInfo\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x19\n" +
+	"\brepo_url\x18\x02 \x01(\tR\arepoUrl\x12\x18\n" +
+	"\aenabled\x18\x03 \x01(\bR\aenabled\x125\n" +
+	"\blast_run\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\alastRun\x12=\n" +
+	"\flast_success\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\vlastSuccess\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\x06 \x01(\tR\tlastError\x12)\n" +
+	"\x10source_snapshots\x18\a \x01(\x05R\x0fsourceSnapshots\x12)\n" +
+	"\x10target_snapshots\x18\b \x01(\x05R\x0ftargetSnapshots\x12+\n" +
+	"\x11missing_snapshots\x18\t \x01(\x05R\x10missingSnapshots\x12\x17\n" +
+	"\ain_sync\x18\n" +
+	" \x01(\bR\x06inSync\"\x8a\x06\n" +
 	"\x11GetStatusResponse\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12&\n" +
 	"\x04role\x18\x02 \x01(\x0e2\x12.airgapper.v1.RoleR\x04role\x12\x19\n" +
@@ -377,7 +598,15 @@ const file_airgapper_v1_health_proto_rawDesc = "" +
 	"\x04peer\x18\t \x01(\v2\x12.airgapper.v1.PeerR\x04peer\x129\n" +
 	"\tconsensus\x18\n" +
 	" \x01(\v2\x1b.airgapper.v1.ConsensusInfoR\tconsensus\x129\n" +
-	"\tscheduler\x18\v \x01(\v2\x1b.airgapper.v1.SchedulerInfoR\tscheduler2\x9f\x01\n" +
+	"\tscheduler\x18\v \x01(\v2\x1b.airgapper.v1.SchedulerInfoR\tscheduler\x12E\n" +
+	"\vreplication\x18\f \x03(\v2#.airgapper.v1.ReplicationTargetInfoR\vreplication\x12;\n" +
+	"\vserver_time\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"serverTime\x12*\n" +
+	"\x11storage_read_only\x18\x0e \x01(\bR\x0fstorageReadOnly\x12<\n" +
+	"\x1astorage_maintenance_reason\x18\x0f \x01(\tR\x18storageMaintenanceReason\x12\x1f\n" +
+	"\vapi_version\x18\x10 \x01(\tR\n" +
+	"apiVersion\x124\n" +
+	"\x16supported_api_versions\x18\x11 \x03(\tR\x14supportedApiVersions2\x9f\x01\n" +
 	"\rHealthService\x12@\n" +
 	"\x05Check\x12\x1a.airgapper.v1.CheckRequest\x1a\x1b.airgapper.v1.CheckResponse\x12L\n" +
 	"\tGetStatus\x12\x1e.airgapper.v1.GetStatusRequest\x1a\x1f.airgapper.v1.GetStatusResponseB\xb7\x01\n" +
@@ -395,36 +624,41 @@ func file_airgapper_v1_health_proto_rawDescGZIP() []byte {
 	return file_airgapper_v1_health_proto_rawDescData
 }
 
-var file_airgapper_v1_health_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_airgapper_v1_health_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_airgapper_v1_health_proto_goTypes = []any{
 	(*CheckRequest)(nil),          // 0: airgapper.v1.CheckRequest
 	(*CheckResponse)(nil),         // 1: airgapper.v1.CheckResponse
 	(*GetStatusRequest)(nil),      // 2: airgapper.v1.GetStatusRequest
 	(*SchedulerInfo)(nil),         // 3: airgapper.v1.SchedulerInfo
-	(*GetStatusResponse)(nil),     // 4: airgapper.v1.GetStatusResponse
-	(*timestamppb.Timestamp)(nil), // 5: google.protobuf.Timestamp
-	(Role)(0),                     // 6: airgapper.v1.Role
-	(OperationMode)(0),            // 7: airgapper.v1.OperationMode
-	(*Peer)(nil),                  // 8: airgapper.v1.Peer
-	(*ConsensusInfo)(nil),         // 9: airgapper.v1.ConsensusInfo
+	(*ReplicationTargetInfo)(nil), // 4: airgapper.v1.ReplicationTargetInfo
+	(*GetStatusResponse)(nil),     // 5: airgapper.v1.GetStatusResponse
+	(*timestamppb.Timestamp)(nil), // 6: google.protobuf.Timestamp
+	(Role)(0),                     // 7: airgapper.v1.Role
+	(OperationMode)(0),            // 8: airgapper.v1.OperationMode
+	(*Peer)(nil),                  // 9: airgapper.v1.Peer
+	(*ConsensusInfo)(nil),         // 10: airgapper.v1.ConsensusInfo
 }
 var file_airgapper_v1_health_proto_depIdxs = []int32{
-	5, // 0: airgapper.v1.SchedulerInfo.last_run:type_name -> google.protobuf.Timestamp
-	5, // 1: airgapper.v1.SchedulerInfo.next_run:type_name -> google.protobuf.Timestamp
-	6, // 2: airgapper.v1.GetStatusResponse.role:type_name -> airgapper.v1.Role
-	7, // 3: airgapper.v1.GetStatusResponse.mode:type_name -> airgapper.v1.OperationMode
-	8, // 4: airgapper.v1.GetStatusResponse.peer:type_name -> airgapper.v1.Peer
-	9, // 5: airgapper.v1.GetStatusResponse.consensus:type_name -> airgapper.v1.ConsensusInfo
-	3, // 6: airgapper.v1.GetStatusResponse.scheduler:type_name -> airgapper.v1.SchedulerInfo
-	0, // 7: airgapper.v1.HealthService.Check:input_type -> airgapper.v1.CheckRequest
-	2, // 8: airgapper.v1.HealthService.GetStatus:input_type -> airgapper.v1.GetStatusRequest
-	1, // 9: airgapper.v1.HealthService.Check:output_type -> airgapper.v1.CheckResponse
-	4, // 10: airgapper.v1.HealthService.GetStatus:output_type -> airgapper.v1.GetStatusResponse
-	9, // [9:11] is the sub-list for method output_type
-	7, // [7:9] is the sub-list for method input_type
-	7, // [7:7] is the sub-list for extension type_name
-	7, // [7:7] is the sub-list for extension extendee
-	0, // [0:7] is the sub-list for field type_name
+	6,  // 0: airgapper.v1.SchedulerInfo.last_run:type_name -> google.protobuf.Timestamp
+	6,  // 1: airgapper.v1.SchedulerInfo.next_run:type_name -> google.protobuf.Timestamp
+	6,  // 2: airgapper.v1.ReplicationTargetInfo.last_run:type_name -> google.protobuf.Timestamp
+	6,  // 3: airgapper.v1.ReplicationTargetInfo.last_success:type_name -> google.protobuf.Timestamp
+	7,  // 4: airgapper.v1.GetStatusResponse.role:type_name -> airgapper.v1.Role
+	8,  // 5: airgapper.v1.GetStatusResponse.mode:type_name -> airgapper.v1.OperationMode
+	9,  // 6: airgapper.v1.GetStatusResponse.peer:type_name -> airgapper.v1.Peer
+	10, // 7: airgapper.v1.GetStatusResponse.consensus:type_name -> airgapper.v1.ConsensusInfo
+	3,  // 8: airgapper.v1.GetStatusResponse.scheduler:type_name -> airgapper.v1.SchedulerInfo
+	4,  // 9: airgapper.v1.GetStatusResponse.replication:type_name -> airgapper.v1.ReplicationTargetInfo
+	6,  // 10: airgapper.v1.GetStatusResponse.server_time:type_name -> google.protobuf.Timestamp
+	0,  // 11: airgapper.v1.HealthService.Check:input_type -> airgapper.v1.CheckRequest
+	2,  // 12: airgapper.v1.HealthService.GetStatus:input_type -> airgapper.v1.GetStatusRequest
+	1,  // 13: airgapper.v1.HealthService.Check:output_type -> airgapper.v1.CheckResponse
+	5,  // 14: airgapper.v1.HealthService.GetStatus:output_type -> airgapper.v1.GetStatusResponse
+	13, // [13:15] is the sub-list for method output_type
+	11, // [11:13] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
 }
 
 func init() { file_airgapper_v1_health_proto_init() }
@@ -439,7 +673,7 @@ func file_airgapper_v1_health_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_airgapper_v1_health_proto_rawDesc), len(file_airgapper_v1_health_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   5,
+			NumMessages:   6,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
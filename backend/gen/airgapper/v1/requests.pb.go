@@ -37,8 +37,31 @@ type RestoreRequest struct {
 	ApprovedBy        string                 `protobuf:"bytes,10,opt,name=approved_by,json=approvedBy,proto3" json:"approved_by,omitempty"`
 	RequiredApprovals int32                  `protobuf:"varint,11,opt,name=required_approvals,json=requiredApprovals,proto3" json:"required_approvals,omitempty"`
 	Approvals         []*Approval            `protobuf:"bytes,12,rep,name=approvals,proto3" json:"approvals,omitempty"`
-	unknownFields     protoimpl.UnknownFields
-	sizeCache         protoimpl.SizeCache
+	Extensions        []*ExpiryExtension     `protobuf:"bytes,13,rep,name=extensions,proto3" json:"extensions,omitempty"`
+	// Set while an approved request is cooling off: when it can be executed
+	// and how many seconds are left until then
+	ExecutableAt               *timestamppb.Timestamp `protobuf:"bytes,14,opt,name=executable_at,json=executableAt,proto3" json:"executable_at,omitempty"`
+	CoolingOffRemainingSeconds int64                  `protobuf:"varint,15,opt,name=cooling_off_remaining_seconds,json=coolingOffRemainingSeconds,proto3" json:"cooling_off_remaining_seconds,omitempty"`
+	// Set once a key holder vetoed the request
+	VetoedBy   string                 `protobuf:"bytes,16,opt,name=vetoed_by,json=vetoedBy,proto3" json:"vetoed_by,omitempty"`
+	VetoedAt   *timestamppb.Timestamp `protobuf:"bytes,17,opt,name=vetoed_at,json=vetoedAt,proto3" json:"vetoed_at,omitempty"`
+	VetoReason string                 `protobuf:"bytes,18,opt,name=veto_reason,json=vetoReason,proto3" json:"veto_reason,omitempty"`
+	// The device the request was made from, for approvers to check
+	RequesterContext *RequesterContext `protobuf:"bytes,19,opt,name=requester_context,json=requesterContext,proto3" json:"requester_context,omitempty"`
+	// Size of the restore as the requester declared it; 0 if not given
+	SizeBytes int64 `protobuf:"varint,20,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
+	// Terms approvers attached to their approvals, all of which the restore
+	// honors
+	Terms []*RestoreTerms `protobuf:"bytes,21,rep,name=terms,proto3" json:"terms,omitempty"`
+	// Progress of the restore, once it is scheduled or running
+	Progress *RestoreProgress `protobuf:"bytes,22,opt,name=progress,proto3" json:"progress,omitempty"`
+	// What approving the request releases: empty for a restore, "export-keys"
+	// for the raw repository password or "browse" for a snapshot's listing
+	Purpose string `protobuf:"bytes,23,opt,name=purpose,proto3" json:"purpose,omitempty"`
+	// The template the request was created from, if any
+	Template      string `protobuf:"bytes,24,opt,name=template,proto3" json:"template,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *RestoreRequest) Reset() {
@@ -155,6 +178,170 @@ func (x *RestoreRequest) GetApprovals() []*Approval {
 	return nil
 }
 
+func (x *RestoreRequest) GetExtensions() []*ExpiryExtension {
+	if x != nil {
+		return x.Extensions
+	}
+	return nil
+}
+
+func (x *RestoreRequest) GetExecutableAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExecutableAt
+	}
+	return nil
+}
+
+func (x *RestoreRequest) GetCoolingOffRemainingSeconds() int64 {
+	if x != nil {
+		return x.CoolingOffRemainingSeconds
+	}
+	return 0
+}
+
+func (x *RestoreRequest) GetVetoedBy() string {
+	if x != nil {
+		return x.VetoedBy
+	}
+	return ""
+}
+
+func (x *RestoreRequest) GetVetoedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.VetoedAt
+	}
+	return nil
+}
+
+func (x *RestoreRequest) GetVetoReason() string {
+	if x != nil {
+		return x.VetoReason
+	}
+	return ""
+}
+
+func (x *RestoreRequest) GetRequesterContext() *RequesterContext {
+	if x != nil {
+		return x.RequesterContext
+	}
+	return nil
+}
+
+func (x *RestoreRequest) GetSizeBytes() int64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+func (x *RestoreRequest) GetTerms() []*RestoreTerms {
+	if x != nil {
+		return x.Terms
+	}
+	return nil
+}
+
+func (x *RestoreRequest) GetProgress() *RestoreProgress {
+	if x != nil {
+		return x.Progress
+	}
+	return nil
+}
+
+func (x *RestoreRequest) GetPurpose() string {
+	if x != nil {
+		return x.Purpose
+	}
+	return ""
+}
+
+func (x *RestoreRequest) GetTemplate() string {
+	if x != nil {
+		return x.Template
+	}
+	return ""
+}
+
+// RequesterContext describes the device a restore request was made from.
+// The requester's signature covers all but source_ip, which approvals
+// cover too.
+type RequesterContext struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Hostname       string                 `protobuf:"bytes,1,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	Os             string                 `protobuf:"bytes,2,opt,name=os,proto3" json:"os,omitempty"`
+	Version        string                 `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`                                     // Airgapper version
+	KeyFingerprint string                 `protobuf:"bytes,4,opt,name=key_fingerprint,json=keyFingerprint,proto3" json:"key_fingerprint,omitempty"` // Key ID of the requester's signing key
+	// The requester's address as seen by the node the request was made on
+	SourceIp      string `protobuf:"bytes,5,opt,name=source_ip,json=sourceIp,proto3" json:"source_ip,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequesterContext) Reset() {
+	*x = RequesterContext{}
+	mi := &file_airgapper_v1_requests_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequesterContext) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequesterContext) ProtoMessage() {}
+
+func (x *RequesterContext) ProtoReflect() protoreflect.Message {
+	mi := &file_airgapper_v1_requests_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequesterContext.ProtoReflect.Descriptor instead.
+func (*RequesterContext) Descriptor() ([]byte, []int) {
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RequesterContext) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *RequesterContext) GetOs() string {
+	if x != nil {
+		return x.Os
+	}
+	return ""
+}
+
+func (x *RequesterContext) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *RequesterContext) GetKeyFingerprint() string {
+	if x != nil {
+		return x.KeyFingerprint
+	}
+	return ""
+}
+
+func (x *RequesterContext) GetSourceIp() string {
+	if x != nil {
+		return x.SourceIp
+	}
+	return ""
+}
+
 type ListRequestsRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Optional filter by status
@@ -165,7 +352,7 @@ type ListRequestsRequest struct {
 
 func (x *ListRequestsRequest) Reset() {
 	*x = ListRequestsRequest{}
-	mi := &file_airgapper_v1_requests_proto_msgTypes[1]
+	mi := &file_airgapper_v1_requests_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -177,7 +364,7 @@ func (x *ListRequestsRequest) String() string {
 func (*ListRequestsRequest) ProtoMessage() {}
 
 func (x *ListRequestsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_requests_proto_msgTypes[1]
+	mi := &file_airgapper_v1_requests_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -190,7 +377,7 @@ func (x *ListRequestsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListRequestsRequest.ProtoReflect.Descriptor instead.
 func (*ListRequestsRequest) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{1}
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *ListRequestsRequest) GetStatusFilter() RequestStatus {
@@ -209,7 +396,7 @@ type ListRequestsResponse struct {
 
 func (x *ListRequestsResponse) Reset() {
 	*x = ListRequestsResponse{}
-	mi := &file_airgapper_v1_requests_proto_msgTypes[2]
+	mi := &file_airgapper_v1_requests_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -221,7 +408,7 @@ func (x *ListRequestsResponse) String() string {
 func (*ListRequestsResponse) ProtoMessage() {}
 
 func (x *ListRequestsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_requests_proto_msgTypes[2]
+	mi := &file_airgapper_v1_requests_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -234,7 +421,7 @@ func (x *ListRequestsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListRequestsResponse.ProtoReflect.Descriptor instead.
 func (*ListRequestsResponse) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{2}
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *ListRequestsResponse) GetRequests() []*RestoreRequest {
@@ -253,7 +440,7 @@ type GetRequestRequest struct {
 
 func (x *GetRequestRequest) Reset() {
 	*x = GetRequestRequest{}
-	mi := &file_airgapper_v1_requests_proto_msgTypes[3]
+	mi := &file_airgapper_v1_requests_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -265,7 +452,7 @@ func (x *GetRequestRequest) String() string {
 func (*GetRequestRequest) ProtoMessage() {}
 
 func (x *GetRequestRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_requests_proto_msgTypes[3]
+	mi := &file_airgapper_v1_requests_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -278,7 +465,7 @@ func (x *GetRequestRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetRequestRequest.ProtoReflect.Descriptor instead.
 func (*GetRequestRequest) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{3}
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *GetRequestRequest) GetId() string {
@@ -297,7 +484,7 @@ type GetRequestResponse struct {
 
 func (x *GetRequestResponse) Reset() {
 	*x = GetRequestResponse{}
-	mi := &file_airgapper_v1_requests_proto_msgTypes[4]
+	mi := &file_airgapper_v1_requests_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -309,7 +496,7 @@ func (x *GetRequestResponse) String() string {
 func (*GetRequestResponse) ProtoMessage() {}
 
 func (x *GetRequestResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_requests_proto_msgTypes[4]
+	mi := &file_airgapper_v1_requests_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -322,7 +509,7 @@ func (x *GetRequestResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetRequestResponse.ProtoReflect.Descriptor instead.
 func (*GetRequestResponse) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{4}
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *GetRequestResponse) GetRequest() *RestoreRequest {
@@ -333,17 +520,37 @@ func (x *GetRequestResponse) GetRequest() *RestoreRequest {
 }
 
 type CreateRequestRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	SnapshotId    string                 `protobuf:"bytes,1,opt,name=snapshot_id,json=snapshotId,proto3" json:"snapshot_id,omitempty"`
-	Paths         []string               `protobuf:"bytes,2,rep,name=paths,proto3" json:"paths,omitempty"`
-	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	SnapshotId string                 `protobuf:"bytes,1,opt,name=snapshot_id,json=snapshotId,proto3" json:"snapshot_id,omitempty"`
+	Paths      []string               `protobuf:"bytes,2,rep,name=paths,proto3" json:"paths,omitempty"`
+	Reason     string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	// The requester's signature over the request (required in consensus
+	// mode). The requester chooses the ID and creation time it signs.
+	Id          string                 `protobuf:"bytes,4,opt,name=id,proto3" json:"id,omitempty"`
+	CreatedAt   *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	KeyHolderId string                 `protobuf:"bytes,6,opt,name=key_holder_id,json=keyHolderId,proto3" json:"key_holder_id,omitempty"`
+	Signature   string                 `protobuf:"bytes,7,opt,name=signature,proto3" json:"signature,omitempty"` // Hex encoded
+	// How long the request stays pending, as a duration such as "48h", up to
+	// the node's limit. Empty for the default of 24 hours.
+	Ttl string `protobuf:"bytes,8,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	// The device the request is made from. source_ip is ignored: the node
+	// records the address the call came from.
+	RequesterContext *RequesterContext `protobuf:"bytes,9,opt,name=requester_context,json=requesterContext,proto3" json:"requester_context,omitempty"`
+	// Size of the restore in bytes, signed along with the request. The
+	// node's approval rules may approve small single-path restores by it.
+	SizeBytes int64 `protobuf:"varint,10,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
+	// What the request is for, signed along with it: empty for a restore,
+	// "export-keys" or "browse". Nodes refuse purposes they don't know.
+	Purpose string `protobuf:"bytes,11,opt,name=purpose,proto3" json:"purpose,omitempty"`
+	// The template the request was created from, if any, signed along with it
+	Template      string `protobuf:"bytes,12,opt,name=template,proto3" json:"template,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *CreateRequestRequest) Reset() {
 	*x = CreateRequestRequest{}
-	mi := &file_airgapper_v1_requests_proto_msgTypes[5]
+	mi := &file_airgapper_v1_requests_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -355,7 +562,7 @@ func (x *CreateRequestRequest) String() string {
 func (*CreateRequestRequest) ProtoMessage() {}
 
 func (x *CreateRequestRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_requests_proto_msgTypes[5]
+	mi := &file_airgapper_v1_requests_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -368,7 +575,7 @@ func (x *CreateRequestRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateRequestRequest.ProtoReflect.Descriptor instead.
 func (*CreateRequestRequest) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{5}
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *CreateRequestRequest) GetSnapshotId() string {
@@ -392,6 +599,69 @@ func (x *CreateRequestRequest) GetReason() string {
 	return ""
 }
 
+func (x *CreateRequestRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *CreateRequestRequest) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *CreateRequestRequest) GetKeyHolderId() string {
+	if x != nil {
+		return x.KeyHolderId
+	}
+	return ""
+}
+
+func (x *CreateRequestRequest) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *CreateRequestRequest) GetTtl() string {
+	if x != nil {
+		return x.Ttl
+	}
+	return ""
+}
+
+func (x *CreateRequestRequest) GetRequesterContext() *RequesterContext {
+	if x != nil {
+		return x.RequesterContext
+	}
+	return nil
+}
+
+func (x *CreateRequestRequest) GetSizeBytes() int64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+func (x *CreateRequestRequest) GetPurpose() string {
+	if x != nil {
+		return x.Purpose
+	}
+	return ""
+}
+
+func (x *CreateRequestRequest) GetTemplate() string {
+	if x != nil {
+		return x.Template
+	}
+	return ""
+}
+
 type CreateRequestResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -403,7 +673,7 @@ type CreateRequestResponse struct {
 
 func (x *CreateRequestResponse) Reset() {
 	*x = CreateRequestResponse{}
-	mi := &file_airgapper_v1_requests_proto_msgTypes[6]
+	mi := &file_airgapper_v1_requests_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -415,7 +685,7 @@ func (x *CreateRequestResponse) String() string {
 func (*CreateRequestResponse) ProtoMessage() {}
 
 func (x *CreateRequestResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_requests_proto_msgTypes[6]
+	mi := &file_airgapper_v1_requests_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -428,7 +698,7 @@ func (x *CreateRequestResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CreateRequestResponse.ProtoReflect.Descriptor instead.
 func (*CreateRequestResponse) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{6}
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *CreateRequestResponse) GetId() string {
@@ -456,15 +726,17 @@ type ApproveRequestRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
 	// Share is optional - if not provided, server uses its local share
-	Share         []byte `protobuf:"bytes,2,opt,name=share,proto3" json:"share,omitempty"`
-	ShareIndex    int32  `protobuf:"varint,3,opt,name=share_index,json=shareIndex,proto3" json:"share_index,omitempty"`
+	Share      []byte `protobuf:"bytes,2,opt,name=share,proto3" json:"share,omitempty"`
+	ShareIndex int32  `protobuf:"varint,3,opt,name=share_index,json=shareIndex,proto3" json:"share_index,omitempty"`
+	// Conditions the restore must keep to, if any
+	Terms         *RestoreTerms `protobuf:"bytes,4,opt,name=terms,proto3" json:"terms,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ApproveRequestRequest) Reset() {
 	*x = ApproveRequestRequest{}
-	mi := &file_airgapper_v1_requests_proto_msgTypes[7]
+	mi := &file_airgapper_v1_requests_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -476,7 +748,7 @@ func (x *ApproveRequestRequest) String() string {
 func (*ApproveRequestRequest) ProtoMessage() {}
 
 func (x *ApproveRequestRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_requests_proto_msgTypes[7]
+	mi := &file_airgapper_v1_requests_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -489,7 +761,7 @@ func (x *ApproveRequestRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ApproveRequestRequest.ProtoReflect.Descriptor instead.
 func (*ApproveRequestRequest) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{7}
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *ApproveRequestRequest) GetId() string {
@@ -513,6 +785,13 @@ func (x *ApproveRequestRequest) GetShareIndex() int32 {
 	return 0
 }
 
+func (x *ApproveRequestRequest) GetTerms() *RestoreTerms {
+	if x != nil {
+		return x.Terms
+	}
+	return nil
+}
+
 type ApproveRequestResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
@@ -523,7 +802,7 @@ type ApproveRequestResponse struct {
 
 func (x *ApproveRequestResponse) Reset() {
 	*x = ApproveRequestResponse{}
-	mi := &file_airgapper_v1_requests_proto_msgTypes[8]
+	mi := &file_airgapper_v1_requests_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -535,7 +814,7 @@ func (x *ApproveRequestResponse) String() string {
 func (*ApproveRequestResponse) ProtoMessage() {}
 
 func (x *ApproveRequestResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_requests_proto_msgTypes[8]
+	mi := &file_airgapper_v1_requests_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -548,7 +827,7 @@ func (x *ApproveRequestResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ApproveRequestResponse.ProtoReflect.Descriptor instead.
 func (*ApproveRequestResponse) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{8}
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *ApproveRequestResponse) GetStatus() string {
@@ -565,30 +844,29 @@ func (x *ApproveRequestResponse) GetMessage() string {
 	return ""
 }
 
-type SignRequestRequest struct {
+type IssueChallengeRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
 	KeyHolderId   string                 `protobuf:"bytes,2,opt,name=key_holder_id,json=keyHolderId,proto3" json:"key_holder_id,omitempty"`
-	Signature     string                 `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"` // Hex encoded
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SignRequestRequest) Reset() {
-	*x = SignRequestRequest{}
-	mi := &file_airgapper_v1_requests_proto_msgTypes[9]
+func (x *IssueChallengeRequest) Reset() {
+	*x = IssueChallengeRequest{}
+	mi := &file_airgapper_v1_requests_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SignRequestRequest) String() string {
+func (x *IssueChallengeRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SignRequestRequest) ProtoMessage() {}
+func (*IssueChallengeRequest) ProtoMessage() {}
 
-func (x *SignRequestRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_requests_proto_msgTypes[9]
+func (x *IssueChallengeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_airgapper_v1_requests_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -599,57 +877,608 @@ func (x *SignRequestRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SignRequestRequest.ProtoReflect.Descriptor instead.
-func (*SignRequestRequest) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use IssueChallengeRequest.ProtoReflect.Descriptor instead.
+func (*IssueChallengeRequest) Descriptor() ([]byte, []int) {
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *SignRequestRequest) GetId() string {
+func (x *IssueChallengeRequest) GetId() string {
 	if x != nil {
 		return x.Id
 	}
 	return ""
 }
 
-func (x *SignRequestRequest) GetKeyHolderId() string {
+func (x *IssueChallengeRequest) GetKeyHolderId() string {
 	if x != nil {
 		return x.KeyHolderId
 	}
 	return ""
 }
 
-func (x *SignRequestRequest) GetSignature() string {
-	if x != nil {
-		return x.Signature
-	}
-	return ""
+type IssueChallengeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nonce         string                 `protobuf:"bytes,1,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-type SignRequestResponse struct {
-	state             protoimpl.MessageState `protogen:"open.v1"`
-	Status            string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
-	CurrentApprovals  int32                  `protobuf:"varint,2,opt,name=current_approvals,json=currentApprovals,proto3" json:"current_approvals,omitempty"`
-	RequiredApprovals int32                  `protobuf:"varint,3,opt,name=required_approvals,json=requiredApprovals,proto3" json:"required_approvals,omitempty"`
-	IsApproved        bool                   `protobuf:"varint,4,opt,name=is_approved,json=isApproved,proto3" json:"is_approved,omitempty"`
-	unknownFields     protoimpl.UnknownFields
-	sizeCache         protoimpl.SizeCache
+func (x *IssueChallengeResponse) Reset() {
+	*x = IssueChallengeResponse{}
+	mi := &file_airgapper_v1_requests_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IssueChallengeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IssueChallengeResponse) ProtoMessage() {}
+
+func (x *IssueChallengeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_airgapper_v1_requests_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IssueChallengeResponse.ProtoReflect.Descriptor instead.
+func (*IssueChallengeResponse) Descriptor() ([]byte, []int) {
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *IssueChallengeResponse) GetNonce() string {
+	if x != nil {
+		return x.Nonce
+	}
+	return ""
+}
+
+func (x *IssueChallengeResponse) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+type SignRequestRequest struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Id           string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	KeyHolderId  string                 `protobuf:"bytes,2,opt,name=key_holder_id,json=keyHolderId,proto3" json:"key_holder_id,omitempty"`
+	Signature    string                 `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"`                           // Hex encoded
+	DelegationId string                 `protobuf:"bytes,4,opt,name=delegation_id,json=delegationId,proto3" json:"delegation_id,omitempty"` // Approve with the authority delegated to key_holder_id
+	Nonce        string                 `protobuf:"bytes,5,opt,name=nonce,proto3" json:"nonce,omitempty"`                                   // Challenge from IssueChallenge covered by the signature
+	SignedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=signed_at,json=signedAt,proto3" json:"signed_at,omitempty"`             // When the key holder signed, covered by the signature
+	// Conditions the restore must keep to, if any
+	Terms         *RestoreTerms `protobuf:"bytes,7,opt,name=terms,proto3" json:"terms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SignRequestRequest) Reset() {
+	*x = SignRequestRequest{}
+	mi := &file_airgapper_v1_requests_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SignRequestRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignRequestRequest) ProtoMessage() {}
+
+func (x *SignRequestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_airgapper_v1_requests_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignRequestRequest.ProtoReflect.Descriptor instead.
+func (*SignRequestRequest) Descriptor() ([]byte, []int) {
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SignRequestRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SignRequestRequest) GetKeyHolderId() string {
+	if x != nil {
+		return x.KeyHolderId
+	}
+	return ""
+}
+
+func (x *SignRequestRequest) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *SignRequestRequest) GetDelegationId() string {
+	if x != nil {
+		return x.DelegationId
+	}
+	return ""
+}
+
+func (x *SignRequestRequest) GetNonce() string {
+	if x != nil {
+		return x.Nonce
+	}
+	return ""
+}
+
+func (x *SignRequestRequest) GetSignedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SignedAt
+	}
+	return nil
+}
+
+func (x *SignRequestRequest) GetTerms() *RestoreTerms {
+	if x != nil {
+		return x.Terms
+	}
+	return nil
+}
+
+type SignRequestResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Status            string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	CurrentApprovals  int32                  `protobuf:"varint,2,opt,name=current_approvals,json=currentApprovals,proto3" json:"current_approvals,omitempty"`
+	RequiredApprovals int32                  `protobuf:"varint,3,opt,name=required_approvals,json=requiredApprovals,proto3" json:"required_approvals,omitempty"`
+	IsApproved        bool                   `protobuf:"varint,4,opt,name=is_approved,json=isApproved,proto3" json:"is_approved,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
 func (x *SignRequestResponse) Reset() {
 	*x = SignRequestResponse{}
-	mi := &file_airgapper_v1_requests_proto_msgTypes[10]
+	mi := &file_airgapper_v1_requests_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SignRequestResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignRequestResponse) ProtoMessage() {}
+
+func (x *SignRequestResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_airgapper_v1_requests_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignRequestResponse.ProtoReflect.Descriptor instead.
+func (*SignRequestResponse) Descriptor() ([]byte, []int) {
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *SignRequestResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *SignRequestResponse) GetCurrentApprovals() int32 {
+	if x != nil {
+		return x.CurrentApprovals
+	}
+	return 0
+}
+
+func (x *SignRequestResponse) GetRequiredApprovals() int32 {
+	if x != nil {
+		return x.RequiredApprovals
+	}
+	return 0
+}
+
+func (x *SignRequestResponse) GetIsApproved() bool {
+	if x != nil {
+		return x.IsApproved
+	}
+	return false
+}
+
+type DenyRequestRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DenyRequestRequest) Reset() {
+	*x = DenyRequestRequest{}
+	mi := &file_airgapper_v1_requests_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DenyRequestRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DenyRequestRequest) ProtoMessage() {}
+
+func (x *DenyRequestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_airgapper_v1_requests_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DenyRequestRequest.ProtoReflect.Descriptor instead.
+func (*DenyRequestRequest) Descriptor() ([]byte, []int) {
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *DenyRequestRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DenyRequestResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DenyRequestResponse) Reset() {
+	*x = DenyRequestResponse{}
+	mi := &file_airgapper_v1_requests_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DenyRequestResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DenyRequestResponse) ProtoMessage() {}
+
+func (x *DenyRequestResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_airgapper_v1_requests_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DenyRequestResponse.ProtoReflect.Descriptor instead.
+func (*DenyRequestResponse) Descriptor() ([]byte, []int) {
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *DenyRequestResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+// ExpiryExtension is a requester's ask for more time to collect approvals
+type ExpiryExtension struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ExtendBy      string                 `protobuf:"bytes,1,opt,name=extend_by,json=extendBy,proto3" json:"extend_by,omitempty"` // Duration such as "12h"
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	RequestedAt   *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=requested_at,json=requestedAt,proto3" json:"requested_at,omitempty"`
+	ApprovedBy    string                 `protobuf:"bytes,4,opt,name=approved_by,json=approvedBy,proto3" json:"approved_by,omitempty"` // Empty while awaiting approval
+	ApprovedAt    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=approved_at,json=approvedAt,proto3" json:"approved_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExpiryExtension) Reset() {
+	*x = ExpiryExtension{}
+	mi := &file_airgapper_v1_requests_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExpiryExtension) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExpiryExtension) ProtoMessage() {}
+
+func (x *ExpiryExtension) ProtoReflect() protoreflect.Message {
+	mi := &file_airgapper_v1_requests_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExpiryExtension.ProtoReflect.Descriptor instead.
+func (*ExpiryExtension) Descriptor() ([]byte, []int) {
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ExpiryExtension) GetExtendBy() string {
+	if x != nil {
+		return x.ExtendBy
+	}
+	return ""
+}
+
+func (x *ExpiryExtension) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *ExpiryExtension) GetRequestedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RequestedAt
+	}
+	return nil
+}
+
+func (x *ExpiryExtension) GetApprovedBy() string {
+	if x != nil {
+		return x.ApprovedBy
+	}
+	return ""
+}
+
+func (x *ExpiryExtension) GetApprovedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ApprovedAt
+	}
+	return nil
+}
+
+type RequestExtensionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ExtendBy      string                 `protobuf:"bytes,2,opt,name=extend_by,json=extendBy,proto3" json:"extend_by,omitempty"` // Duration such as "12h", up to the node's limit
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestExtensionRequest) Reset() {
+	*x = RequestExtensionRequest{}
+	mi := &file_airgapper_v1_requests_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestExtensionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestExtensionRequest) ProtoMessage() {}
+
+func (x *RequestExtensionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_airgapper_v1_requests_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestExtensionRequest.ProtoReflect.Descriptor instead.
+func (*RequestExtensionRequest) Descriptor() ([]byte, []int) {
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *RequestExtensionRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *RequestExtensionRequest) GetExtendBy() string {
+	if x != nil {
+		return x.ExtendBy
+	}
+	return ""
+}
+
+func (x *RequestExtensionRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type RequestExtensionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Extension     *ExpiryExtension       `protobuf:"bytes,1,opt,name=extension,proto3" json:"extension,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestExtensionResponse) Reset() {
+	*x = RequestExtensionResponse{}
+	mi := &file_airgapper_v1_requests_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestExtensionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestExtensionResponse) ProtoMessage() {}
+
+func (x *RequestExtensionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_airgapper_v1_requests_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestExtensionResponse.ProtoReflect.Descriptor instead.
+func (*RequestExtensionResponse) Descriptor() ([]byte, []int) {
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *RequestExtensionResponse) GetExtension() *ExpiryExtension {
+	if x != nil {
+		return x.Extension
+	}
+	return nil
+}
+
+type ApproveExtensionRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	KeyHolderId string                 `protobuf:"bytes,2,opt,name=key_holder_id,json=keyHolderId,proto3" json:"key_holder_id,omitempty"`
+	// The key holder's signature over the request ID and the expiry the
+	// extension moves it to, with a nonce from IssueChallenge, as for
+	// SignRequest
+	Signature     string                 `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"` // Hex encoded
+	Nonce         string                 `protobuf:"bytes,4,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	SignedAt      *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=signed_at,json=signedAt,proto3" json:"signed_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApproveExtensionRequest) Reset() {
+	*x = ApproveExtensionRequest{}
+	mi := &file_airgapper_v1_requests_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApproveExtensionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApproveExtensionRequest) ProtoMessage() {}
+
+func (x *ApproveExtensionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_airgapper_v1_requests_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApproveExtensionRequest.ProtoReflect.Descriptor instead.
+func (*ApproveExtensionRequest) Descriptor() ([]byte, []int) {
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ApproveExtensionRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ApproveExtensionRequest) GetKeyHolderId() string {
+	if x != nil {
+		return x.KeyHolderId
+	}
+	return ""
+}
+
+func (x *ApproveExtensionRequest) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *ApproveExtensionRequest) GetNonce() string {
+	if x != nil {
+		return x.Nonce
+	}
+	return ""
+}
+
+func (x *ApproveExtensionRequest) GetSignedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SignedAt
+	}
+	return nil
+}
+
+type ApproveExtensionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApproveExtensionResponse) Reset() {
+	*x = ApproveExtensionResponse{}
+	mi := &file_airgapper_v1_requests_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SignRequestResponse) String() string {
+func (x *ApproveExtensionResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SignRequestResponse) ProtoMessage() {}
+func (*ApproveExtensionResponse) ProtoMessage() {}
 
-func (x *SignRequestResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_requests_proto_msgTypes[10]
+func (x *ApproveExtensionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_airgapper_v1_requests_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -660,61 +1489,344 @@ func (x *SignRequestResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SignRequestResponse.ProtoReflect.Descriptor instead.
-func (*SignRequestResponse) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use ApproveExtensionResponse.ProtoReflect.Descriptor instead.
+func (*ApproveExtensionResponse) Descriptor() ([]byte, []int) {
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{20}
 }
 
-func (x *SignRequestResponse) GetStatus() string {
+func (x *ApproveExtensionResponse) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+type VetoRequestRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	KeyHolderId string                 `protobuf:"bytes,2,opt,name=key_holder_id,json=keyHolderId,proto3" json:"key_holder_id,omitempty"`
+	Reason      string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	// The key holder's signature over the request ID and the reason, with a
+	// nonce from IssueChallenge, as for SignRequest
+	Signature     string                 `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"` // Hex encoded
+	Nonce         string                 `protobuf:"bytes,5,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	SignedAt      *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=signed_at,json=signedAt,proto3" json:"signed_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VetoRequestRequest) Reset() {
+	*x = VetoRequestRequest{}
+	mi := &file_airgapper_v1_requests_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VetoRequestRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VetoRequestRequest) ProtoMessage() {}
+
+func (x *VetoRequestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_airgapper_v1_requests_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VetoRequestRequest.ProtoReflect.Descriptor instead.
+func (*VetoRequestRequest) Descriptor() ([]byte, []int) {
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *VetoRequestRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *VetoRequestRequest) GetKeyHolderId() string {
+	if x != nil {
+		return x.KeyHolderId
+	}
+	return ""
+}
+
+func (x *VetoRequestRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *VetoRequestRequest) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *VetoRequestRequest) GetNonce() string {
+	if x != nil {
+		return x.Nonce
+	}
+	return ""
+}
+
+func (x *VetoRequestRequest) GetSignedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SignedAt
+	}
+	return nil
+}
+
+type VetoRequestResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VetoRequestResponse) Reset() {
+	*x = VetoRequestResponse{}
+	mi := &file_airgapper_v1_requests_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VetoRequestResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VetoRequestResponse) ProtoMessage() {}
+
+func (x *VetoRequestResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_airgapper_v1_requests_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VetoRequestResponse.ProtoReflect.Descriptor instead.
+func (*VetoRequestResponse) Descriptor() ([]byte, []int) {
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *VetoRequestResponse) GetStatus() string {
 	if x != nil {
 		return x.Status
 	}
 	return ""
 }
 
-func (x *SignRequestResponse) GetCurrentApprovals() int32 {
+// RestoreTerms are conditions an approver attaches to their approval, so a
+// large restore doesn't saturate their uplink
+type RestoreTerms struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Daily window of the approver's local time the restore may only start
+	// in, such as "22:00-06:00"; empty for any time
+	Window        string `protobuf:"bytes,1,opt,name=window,proto3" json:"window,omitempty"`
+	UtcOffset     int32  `protobuf:"varint,2,opt,name=utc_offset,json=utcOffset,proto3" json:"utc_offset,omitempty"`    // The approver's offset from UTC in seconds
+	LimitKibps    int32  `protobuf:"varint,3,opt,name=limit_kibps,json=limitKibps,proto3" json:"limit_kibps,omitempty"` // Download cap in KiB/s; 0 for none
+	SetBy         string `protobuf:"bytes,4,opt,name=set_by,json=setBy,proto3" json:"set_by,omitempty"`                 // Set by the node: the approver
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreTerms) Reset() {
+	*x = RestoreTerms{}
+	mi := &file_airgapper_v1_requests_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreTerms) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreTerms) ProtoMessage() {}
+
+func (x *RestoreTerms) ProtoReflect() protoreflect.Message {
+	mi := &file_airgapper_v1_requests_proto_msgTypes[23]
 	if x != nil {
-		return x.CurrentApprovals
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreTerms.ProtoReflect.Descriptor instead.
+func (*RestoreTerms) Descriptor() ([]byte, []int) {
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *RestoreTerms) GetWindow() string {
+	if x != nil {
+		return x.Window
+	}
+	return ""
+}
+
+func (x *RestoreTerms) GetUtcOffset() int32 {
+	if x != nil {
+		return x.UtcOffset
 	}
 	return 0
 }
 
-func (x *SignRequestResponse) GetRequiredApprovals() int32 {
+func (x *RestoreTerms) GetLimitKibps() int32 {
 	if x != nil {
-		return x.RequiredApprovals
+		return x.LimitKibps
 	}
 	return 0
 }
 
-func (x *SignRequestResponse) GetIsApproved() bool {
+func (x *RestoreTerms) GetSetBy() string {
 	if x != nil {
-		return x.IsApproved
+		return x.SetBy
 	}
-	return false
+	return ""
 }
 
-type DenyRequestRequest struct {
+// RestoreProgress is how far an approved request's restore has got
+type RestoreProgress struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`                     // "scheduled", "running", "completed" or "failed"
+	StartsAt      *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=starts_at,json=startsAt,proto3" json:"starts_at,omitempty"` // When a scheduled restore will start
+	StartedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	FinishedAt    *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	Files         int32                  `protobuf:"varint,5,opt,name=files,proto3" json:"files,omitempty"`
+	Restored      int32                  `protobuf:"varint,6,opt,name=restored,proto3" json:"restored,omitempty"`
+	Error         string                 `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreProgress) Reset() {
+	*x = RestoreProgress{}
+	mi := &file_airgapper_v1_requests_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreProgress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreProgress) ProtoMessage() {}
+
+func (x *RestoreProgress) ProtoReflect() protoreflect.Message {
+	mi := &file_airgapper_v1_requests_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreProgress.ProtoReflect.Descriptor instead.
+func (*RestoreProgress) Descriptor() ([]byte, []int) {
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *RestoreProgress) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *RestoreProgress) GetStartsAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartsAt
+	}
+	return nil
+}
+
+func (x *RestoreProgress) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *RestoreProgress) GetFinishedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return nil
+}
+
+func (x *RestoreProgress) GetFiles() int32 {
+	if x != nil {
+		return x.Files
+	}
+	return 0
+}
+
+func (x *RestoreProgress) GetRestored() int32 {
+	if x != nil {
+		return x.Restored
+	}
+	return 0
+}
+
+func (x *RestoreProgress) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *RestoreProgress) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type ReportRestoreProgressRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Progress      *RestoreProgress       `protobuf:"bytes,2,opt,name=progress,proto3" json:"progress,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DenyRequestRequest) Reset() {
-	*x = DenyRequestRequest{}
-	mi := &file_airgapper_v1_requests_proto_msgTypes[11]
+func (x *ReportRestoreProgressRequest) Reset() {
+	*x = ReportRestoreProgressRequest{}
+	mi := &file_airgapper_v1_requests_proto_msgTypes[25]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DenyRequestRequest) String() string {
+func (x *ReportRestoreProgressRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DenyRequestRequest) ProtoMessage() {}
+func (*ReportRestoreProgressRequest) ProtoMessage() {}
 
-func (x *DenyRequestRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_requests_proto_msgTypes[11]
+func (x *ReportRestoreProgressRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_airgapper_v1_requests_proto_msgTypes[25]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -725,40 +1837,47 @@ func (x *DenyRequestRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DenyRequestRequest.ProtoReflect.Descriptor instead.
-func (*DenyRequestRequest) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use ReportRestoreProgressRequest.ProtoReflect.Descriptor instead.
+func (*ReportRestoreProgressRequest) Descriptor() ([]byte, []int) {
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{25}
 }
 
-func (x *DenyRequestRequest) GetId() string {
+func (x *ReportRestoreProgressRequest) GetId() string {
 	if x != nil {
 		return x.Id
 	}
 	return ""
 }
 
-type DenyRequestResponse struct {
+func (x *ReportRestoreProgressRequest) GetProgress() *RestoreProgress {
+	if x != nil {
+		return x.Progress
+	}
+	return nil
+}
+
+type ReportRestoreProgressResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DenyRequestResponse) Reset() {
-	*x = DenyRequestResponse{}
-	mi := &file_airgapper_v1_requests_proto_msgTypes[12]
+func (x *ReportRestoreProgressResponse) Reset() {
+	*x = ReportRestoreProgressResponse{}
+	mi := &file_airgapper_v1_requests_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DenyRequestResponse) String() string {
+func (x *ReportRestoreProgressResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DenyRequestResponse) ProtoMessage() {}
+func (*ReportRestoreProgressResponse) ProtoMessage() {}
 
-func (x *DenyRequestResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_requests_proto_msgTypes[12]
+func (x *ReportRestoreProgressResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_airgapper_v1_requests_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -769,12 +1888,12 @@ func (x *DenyRequestResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DenyRequestResponse.ProtoReflect.Descriptor instead.
-func (*DenyRequestResponse) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{12}
+// Deprecated: Use ReportRestoreProgressResponse.ProtoReflect.Descriptor instead.
+func (*ReportRestoreProgressResponse) Descriptor() ([]byte, []int) {
+	return file_airgapper_v1_requests_proto_rawDescGZIP(), []int{26}
 }
 
-func (x *DenyRequestResponse) GetStatus() string {
+func (x *ReportRestoreProgressResponse) GetStatus() string {
 	if x != nil {
 		return x.Status
 	}
@@ -785,7 +1904,7 @@ var File_airgapper_v1_requests_proto protoreflect.FileDescriptor
 
 const file_airgapper_v1_requests_proto_rawDesc = "" +
 	"\n" +
-	"\x1bairgapper/v1/requests.proto\x12\fairgapper.v1\x1a\x19airgapper/v1/common.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xfb\x03\n" +
+	"\x1bairgapper/v1/requests.proto\x12\fairgapper.v1\x1a\x19airgapper/v1/common.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xc4\b\n" +
 	"\x0eRestoreRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1c\n" +
 	"\trequester\x18\x02 \x01(\tR\trequester\x12\x1f\n" +
@@ -804,7 +1923,29 @@ const file_airgapper_v1_requests_proto_rawDesc = "" +
 	" \x01(\tR\n" +
 	"approvedBy\x12-\n" +
 	"\x12required_approvals\x18\v \x01(\x05R\x11requiredApprovals\x124\n" +
-	"\tapprovals\x18\f \x03(\v2\x16.airgapper.v1.ApprovalR\tapprovals\"W\n" +
+	"\tapprovals\x18\f \x03(\v2\x16.airgapper.v1.ApprovalR\tapprovals\x12=\n" +
+	"\n" +
+	"extensions\x18\r \x03(\v2\x1d.airgapper.v1.ExpiryExtensionR\n" +
+	"extensions\x12?\n" +
+	"\rexecutable_at\x18\x0e \x01(\v2\x1a.google.protobuf.TimestampR\fexecutableAt\x12A\n" +
+	"\x1dcooling_off_remaining_seconds\x18\x0f \x01(\x03R\x1acoolingOffRemainingSeconds\x12\x1b\n" +
+	"\tvetoed_by\x18\x10 \x01(\tR\bvetoedBy\x127\n" +
+	"\tvetoed_at\x18\x11 \x01(\v2\x1a.google.protobuf.TimestampR\bvetoedAt\x12\x1f\n" +
+	"\vveto_reason\x18\x12 \x01(\tR\n" +
+	"vetoReason\x12K\n" +
+	"\x11requester_context\x18\x13 \x01(\v2\x1e.airgapper.v1.RequesterContextR\x10requesterContext\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x14 \x01(\x03R\tsizeBytes\x120\n" +
+	"\x05terms\x18\x15 \x03(\v2\x1a.airgapper.v1.RestoreTermsR\x05terms\x129\n" +
+	"\bprogress\x18\x16 \x01(\v2\x1d.airgapper.v1.RestoreProgressR\bprogress\x12\x18\n" +
+	"\apurpose\x18\x17 \x01(\tR\apurpose\x12\x1a\n" +
+	"\btemplate\x18\x18 \x01(\tR\btemplate\"\x9e\x01\n" +
+	"\x10RequesterContext\x12\x1a\n" +
+	"\bhostname\x18\x01 \x01(\tR\bhostname\x12\x0e\n" +
+	"\x02os\x18\x02 \x01(\tR\x02os\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\tR\aversion\x12'\n" +
+	"\x0fkey_fingerprint\x18\x04 \x01(\tR\x0ekeyFingerprint\x12\x1b\n" +
+	"\tsource_ip\x18\x05 \x01(\tR\bsourceIp\"W\n" +
 	"\x13ListRequestsRequest\x12@\n" +
 	"\rstatus_filter\x18\x01 \x01(\x0e2\x1b.airgapper.v1.RequestStatusR\fstatusFilter\"P\n" +
 	"\x14ListRequestsResponse\x128\n" +
@@ -812,29 +1953,53 @@ const file_airgapper_v1_requests_proto_rawDesc = "" +
 	"\x11GetRequestRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\"L\n" +
 	"\x12GetRequestResponse\x126\n" +
-	"\arequest\x18\x01 \x01(\v2\x1c.airgapper.v1.RestoreRequestR\arequest\"e\n" +
+	"\arequest\x18\x01 \x01(\v2\x1c.airgapper.v1.RestoreRequestR\arequest\"\xa6\x03\n" +
 	"\x14CreateRequestRequest\x12\x1f\n" +
 	"\vsnapshot_id\x18\x01 \x01(\tR\n" +
 	"snapshotId\x12\x14\n" +
 	"\x05paths\x18\x02 \x03(\tR\x05paths\x12\x16\n" +
-	"\x06reason\x18\x03 \x01(\tR\x06reason\"z\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x12\x0e\n" +
+	"\x02id\x18\x04 \x01(\tR\x02id\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\"\n" +
+	"\rkey_holder_id\x18\x06 \x01(\tR\vkeyHolderId\x12\x1c\n" +
+	"\tsignature\x18\a \x01(\tR\tsignature\x12\x10\n" +
+	"\x03ttl\x18\b \x01(\tR\x03ttl\x12K\n" +
+	"\x11requester_context\x18\t \x01(\v2\x1e.airgapper.v1.RequesterContextR\x10requesterContext\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\n" +
+	" \x01(\x03R\tsizeBytes\x12\x18\n" +
+	"\apurpose\x18\v \x01(\tR\apurpose\x12\x1a\n" +
+	"\btemplate\x18\f \x01(\tR\btemplate\"z\n" +
 	"\x15CreateRequestResponse\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
 	"\x06status\x18\x02 \x01(\tR\x06status\x129\n" +
 	"\n" +
-	"expires_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"^\n" +
+	"expires_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"\x90\x01\n" +
 	"\x15ApproveRequestRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
 	"\x05share\x18\x02 \x01(\fR\x05share\x12\x1f\n" +
 	"\vshare_index\x18\x03 \x01(\x05R\n" +
-	"shareIndex\"J\n" +
+	"shareIndex\x120\n" +
+	"\x05terms\x18\x04 \x01(\v2\x1a.airgapper.v1.RestoreTermsR\x05terms\"J\n" +
 	"\x16ApproveRequestResponse\x12\x16\n" +
 	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\"f\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"K\n" +
+	"\x15IssueChallengeRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\"\n" +
+	"\rkey_holder_id\x18\x02 \x01(\tR\vkeyHolderId\"i\n" +
+	"\x16IssueChallengeResponse\x12\x14\n" +
+	"\x05nonce\x18\x01 \x01(\tR\x05nonce\x129\n" +
+	"\n" +
+	"expires_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"\x8c\x02\n" +
 	"\x12SignRequestRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\"\n" +
 	"\rkey_holder_id\x18\x02 \x01(\tR\vkeyHolderId\x12\x1c\n" +
-	"\tsignature\x18\x03 \x01(\tR\tsignature\"\xaa\x01\n" +
+	"\tsignature\x18\x03 \x01(\tR\tsignature\x12#\n" +
+	"\rdelegation_id\x18\x04 \x01(\tR\fdelegationId\x12\x14\n" +
+	"\x05nonce\x18\x05 \x01(\tR\x05nonce\x127\n" +
+	"\tsigned_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\bsignedAt\x120\n" +
+	"\x05terms\x18\a \x01(\v2\x1a.airgapper.v1.RestoreTermsR\x05terms\"\xaa\x01\n" +
 	"\x13SignRequestResponse\x12\x16\n" +
 	"\x06status\x18\x01 \x01(\tR\x06status\x12+\n" +
 	"\x11current_approvals\x18\x02 \x01(\x05R\x10currentApprovals\x12-\n" +
@@ -844,15 +2009,76 @@ const file_airgapper_v1_requests_proto_rawDesc = "" +
 	"\x12DenyRequestRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\"-\n" +
 	"\x13DenyRequestResponse\x12\x16\n" +
-	"\x06status\x18\x01 \x01(\tR\x06status2\x9e\x04\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\"\xe3\x01\n" +
+	"\x0fExpiryExtension\x12\x1b\n" +
+	"\textend_by\x18\x01 \x01(\tR\bextendBy\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\x12=\n" +
+	"\frequested_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\vrequestedAt\x12\x1f\n" +
+	"\vapproved_by\x18\x04 \x01(\tR\n" +
+	"approvedBy\x12;\n" +
+	"\vapproved_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"approvedAt\"^\n" +
+	"\x17RequestExtensionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\textend_by\x18\x02 \x01(\tR\bextendBy\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"W\n" +
+	"\x18RequestExtensionResponse\x12;\n" +
+	"\textension\x18\x01 \x01(\v2\x1d.airgapper.v1.ExpiryExtensionR\textension\"\xba\x01\n" +
+	"\x17ApproveExtensionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\"\n" +
+	"\rkey_holder_id\x18\x02 \x01(\tR\vkeyHolderId\x12\x1c\n" +
+	"\tsignature\x18\x03 \x01(\tR\tsignature\x12\x14\n" +
+	"\x05nonce\x18\x04 \x01(\tR\x05nonce\x127\n" +
+	"\tsigned_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\bsignedAt\"U\n" +
+	"\x18ApproveExtensionResponse\x129\n" +
+	"\n" +
+	"expires_at\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"\xcd\x01\n" +
+	"\x12VetoRequestRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\"\n" +
+	"\rkey_holder_id\x18\x02 \x01(\tR\vkeyHolderId\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x12\x1c\n" +
+	"\tsignature\x18\x04 \x01(\tR\tsignature\x12\x14\n" +
+	"\x05nonce\x18\x05 \x01(\tR\x05nonce\x127\n" +
+	"\tsigned_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\bsignedAt\"-\n" +
+	"\x13VetoRequestResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\"}\n" +
+	"\fRestoreTerms\x12\x16\n" +
+	"\x06window\x18\x01 \x01(\tR\x06window\x12\x1d\n" +
+	"\n" +
+	"utc_offset\x18\x02 \x01(\x05R\tutcOffset\x12\x1f\n" +
+	"\vlimit_kibps\x18\x03 \x01(\x05R\n" +
+	"limitKibps\x12\x15\n" +
+	"\x06set_by\x18\x04 \x01(\tR\x05setBy\"\xdd\x02\n" +
+	"\x0fRestoreProgress\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x127\n" +
+	"\tstarts_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\bstartsAt\x129\n" +
+	"\n" +
+	"started_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12;\n" +
+	"\vfinished_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"finishedAt\x12\x14\n" +
+	"\x05files\x18\x05 \x01(\x05R\x05files\x12\x1a\n" +
+	"\brestored\x18\x06 \x01(\x05R\brestored\x12\x14\n" +
+	"\x05error\x18\a \x01(\tR\x05error\x129\n" +
+	"\n" +
+	"updated_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"i\n" +
+	"\x1cReportRestoreProgressRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x129\n" +
+	"\bprogress\x18\x02 \x01(\v2\x1d.airgapper.v1.RestoreProgressR\bprogress\"7\n" +
+	"\x1dReportRestoreProgressResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status2\x87\b\n" +
 	"\x15RestoreRequestService\x12U\n" +
 	"\fListRequests\x12!.airgapper.v1.ListRequestsRequest\x1a\".airgapper.v1.ListRequestsResponse\x12O\n" +
 	"\n" +
 	"GetRequest\x12\x1f.airgapper.v1.GetRequestRequest\x1a .airgapper.v1.GetRequestResponse\x12X\n" +
 	"\rCreateRequest\x12\".airgapper.v1.CreateRequestRequest\x1a#.airgapper.v1.CreateRequestResponse\x12[\n" +
-	"\x0eApproveRequest\x12#.airgapper.v1.ApproveRequestRequest\x1a$.airgapper.v1.ApproveRequestResponse\x12R\n" +
+	"\x0eApproveRequest\x12#.airgapper.v1.ApproveRequestRequest\x1a$.airgapper.v1.ApproveRequestResponse\x12[\n" +
+	"\x0eIssueChallenge\x12#.airgapper.v1.IssueChallengeRequest\x1a$.airgapper.v1.IssueChallengeResponse\x12R\n" +
 	"\vSignRequest\x12 .airgapper.v1.SignRequestRequest\x1a!.airgapper.v1.SignRequestResponse\x12R\n" +
-	"\vDenyRequest\x12 .airgapper.v1.DenyRequestRequest\x1a!.airgapper.v1.DenyRequestResponseB\xb9\x01\n" +
+	"\vDenyRequest\x12 .airgapper.v1.DenyRequestRequest\x1a!.airgapper.v1.DenyRequestResponse\x12a\n" +
+	"\x10RequestExtension\x12%.airgapper.v1.RequestExtensionRequest\x1a&.airgapper.v1.RequestExtensionResponse\x12a\n" +
+	"\x10ApproveExtension\x12%.airgapper.v1.ApproveExtensionRequest\x1a&.airgapper.v1.ApproveExtensionResponse\x12R\n" +
+	"\vVetoRequest\x12 .airgapper.v1.VetoRequestRequest\x1a!.airgapper.v1.VetoRequestResponse\x12p\n" +
+	"\x15ReportRestoreProgress\x12*.airgapper.v1.ReportRestoreProgressRequest\x1a+.airgapper.v1.ReportRestoreProgressResponseB\xb9\x01\n" +
 	"\x10com.airgapper.v1B\rRequestsProtoP\x01ZEgithub.com/lcrostarosa/airgapper/backend/gen/airgapper/v1;airgapperv1\xa2\x02\x03AXX\xaa\x02\fAirgapper.V1\xca\x02\fAirgapper\\V1\xe2\x02\x18Airgapper\\V1\\GPBMetadata\xea\x02\rAirgapper::V1b\x06proto3"
 
 var (
@@ -867,52 +2093,99 @@ func file_airgapper_v1_requests_proto_rawDescGZIP() []byte {
 	return file_airgapper_v1_requests_proto_rawDescData
 }
 
-var file_airgapper_v1_requests_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_airgapper_v1_requests_proto_msgTypes = make([]protoimpl.MessageInfo, 27)
 var file_airgapper_v1_requests_proto_goTypes = []any{
-	(*RestoreRequest)(nil),         // 0: airgapper.v1.RestoreRequest
-	(*ListRequestsRequest)(nil),    // 1: airgapper.v1.ListRequestsRequest
-	(*ListRequestsResponse)(nil),   // 2: airgapper.v1.ListRequestsResponse
-	(*GetRequestRequest)(nil),      // 3: airgapper.v1.GetRequestRequest
-	(*GetRequestResponse)(nil),     // 4: airgapper.v1.GetRequestResponse
-	(*CreateRequestRequest)(nil),   // 5: airgapper.v1.CreateRequestRequest
-	(*CreateRequestResponse)(nil),  // 6: airgapper.v1.CreateRequestResponse
-	(*ApproveRequestRequest)(nil),  // 7: airgapper.v1.ApproveRequestRequest
-	(*ApproveRequestResponse)(nil), // 8: airgapper.v1.ApproveRequestResponse
-	(*SignRequestRequest)(nil),     // 9: airgapper.v1.SignRequestRequest
-	(*SignRequestResponse)(nil),    // 10: airgapper.v1.SignRequestResponse
-	(*DenyRequestRequest)(nil),     // 11: airgapper.v1.DenyRequestRequest
-	(*DenyRequestResponse)(nil),    // 12: airgapper.v1.DenyRequestResponse
-	(RequestStatus)(0),             // 13: airgapper.v1.RequestStatus
-	(*timestamppb.Timestamp)(nil),  // 14: google.protobuf.Timestamp
-	(*Approval)(nil),               // 15: airgapper.v1.Approval
+	(*RestoreRequest)(nil),                // 0: airgapper.v1.RestoreRequest
+	(*RequesterContext)(nil),              // 1: airgapper.v1.RequesterContext
+	(*ListRequestsRequest)(nil),           // 2: airgapper.v1.ListRequestsRequest
+	(*ListRequestsResponse)(nil),          // 3: airgapper.v1.ListRequestsResponse
+	(*GetRequestRequest)(nil),             // 4: airgapper.v1.GetRequestRequest
+	(*GetRequestResponse)(nil),            // 5: airgapper.v1.GetRequestResponse
+	(*CreateRequestRequest)(nil),          // 6: airgapper.v1.CreateRequestRequest
+	(*CreateRequestResponse)(nil),         // 7: airgapper.v1.CreateRequestResponse
+	(*ApproveRequestRequest)(nil),         // 8: airgapper.v1.ApproveRequestRequest
+	(*ApproveRequestResponse)(nil),        // 9: airgapper.v1.ApproveRequestResponse
+	(*IssueChallengeRequest)(nil),         // 10: airgapper.v1.IssueChallengeRequest
+	(*IssueChallengeResponse)(nil),        // 11: airgapper.v1.IssueChallengeResponse
+	(*SignRequestRequest)(nil),            // 12: airgapper.v1.SignRequestRequest
+	(*SignRequestResponse)(nil),           // 13: airgapper.v1.SignRequestResponse
+	(*DenyRequestRequest)(nil),            // 14: airgapper.v1.DenyRequestRequest
+	(*DenyRequestResponse)(nil),           // 15: airgapper.v1.DenyRequestResponse
+	(*ExpiryExtension)(nil),               // 16: airgapper.v1.ExpiryExtension
+	(*RequestExtensionRequest)(nil),       // 17: airgapper.v1.RequestExtensionRequest
+	(*RequestExtensionResponse)(nil),      // 18: airgapper.v1.RequestExtensionResponse
+	(*ApproveExtensionRequest)(nil),       // 19: airgapper.v1.ApproveExtensionRequest
+	(*ApproveExtensionResponse)(nil),      // 20: airgapper.v1.ApproveExtensionResponse
+	(*VetoRequestRequest)(nil),            // 21: airgapper.v1.VetoRequestRequest
+	(*VetoRequestResponse)(nil),           // 22: airgapper.v1.VetoRequestResponse
+	(*RestoreTerms)(nil),                  // 23: airgapper.v1.RestoreTerms
+	(*RestoreProgress)(nil),               // 24: airgapper.v1.RestoreProgress
+	(*ReportRestoreProgressRequest)(nil),  // 25: airgapper.v1.ReportRestoreProgressRequest
+	(*ReportRestoreProgressResponse)(nil), // 26: airgapper.v1.ReportRestoreProgressResponse
+	(RequestStatus)(0),                    // 27: airgapper.v1.RequestStatus
+	(*timestamppb.Timestamp)(nil),         // 28: google.protobuf.Timestamp
+	(*Approval)(nil),                      // 29: airgapper.v1.Approval
 }
 var file_airgapper_v1_requests_proto_depIdxs = []int32{
-	13, // 0: airgapper.v1.RestoreRequest.status:type_name -> airgapper.v1.RequestStatus
-	14, // 1: airgapper.v1.RestoreRequest.created_at:type_name -> google.protobuf.Timestamp
-	14, // 2: airgapper.v1.RestoreRequest.expires_at:type_name -> google.protobuf.Timestamp
-	14, // 3: airgapper.v1.RestoreRequest.approved_at:type_name -> google.protobuf.Timestamp
-	15, // 4: airgapper.v1.RestoreRequest.approvals:type_name -> airgapper.v1.Approval
-	13, // 5: airgapper.v1.ListRequestsRequest.status_filter:type_name -> airgapper.v1.RequestStatus
-	0,  // 6: airgapper.v1.ListRequestsResponse.requests:type_name -> airgapper.v1.RestoreRequest
-	0,  // 7: airgapper.v1.GetRequestResponse.request:type_name -> airgapper.v1.RestoreRequest
-	14, // 8: airgapper.v1.CreateRequestResponse.expires_at:type_name -> google.protobuf.Timestamp
-	1,  // 9: airgapper.v1.RestoreRequestService.ListRequests:input_type -> airgapper.v1.ListRequestsRequest
-	3,  // 10: airgapper.v1.RestoreRequestService.GetRequest:input_type -> airgapper.v1.GetRequestRequest
-	5,  // 11: airgapper.v1.RestoreRequestService.CreateRequest:input_type -> airgapper.v1.CreateRequestRequest
-	7,  // 12: airgapper.v1.RestoreRequestService.ApproveRequest:input_type -> airgapper.v1.ApproveRequestRequest
-	9,  // 13: airgapper.v1.RestoreRequestService.SignRequest:input_type -> airgapper.v1.SignRequestRequest
-	11, // 14: airgapper.v1.RestoreRequestService.DenyRequest:input_type -> airgapper.v1.DenyRequestRequest
-	2,  // 15: airgapper.v1.RestoreRequestService.ListRequests:output_type -> airgapper.v1.ListRequestsResponse
-	4,  // 16: airgapper.v1.RestoreRequestService.GetRequest:output_type -> airgapper.v1.GetRequestResponse
-	6,  // 17: airgapper.v1.RestoreRequestService.CreateRequest:output_type -> airgapper.v1.CreateRequestResponse
-	8,  // 18: airgapper.v1.RestoreRequestService.ApproveRequest:output_type -> airgapper.v1.ApproveRequestResponse
-	10, // 19: airgapper.v1.RestoreRequestService.SignRequest:output_type -> airgapper.v1.SignRequestResponse
-	12, // 20: airgapper.v1.RestoreRequestService.DenyRequest:output_type -> airgapper.v1.DenyRequestResponse
-	15, // [15:21] is the sub-list for method output_type
-	9,  // [9:15] is the sub-list for method input_type
-	9,  // [9:9] is the sub-list for extension type_name
-	9,  // [9:9] is the sub-list for extension extendee
-	0,  // [0:9] is the sub-list for field type_name
+	27, // 0: airgapper.v1.RestoreRequest.status:type_name -> airgapper.v1.RequestStatus
+	28, // 1: airgapper.v1.RestoreRequest.created_at:type_name -> google.protobuf.Timestamp
+	28, // 2: airgapper.v1.RestoreRequest.expires_at:type_name -> google.protobuf.Timestamp
+	28, // 3: airgapper.v1.RestoreRequest.approved_at:type_name -> google.protobuf.Timestamp
+	29, // 4: airgapper.v1.RestoreRequest.approvals:type_name -> airgapper.v1.Approval
+	16, // 5: airgapper.v1.RestoreRequest.extensions:type_name -> airgapper.v1.ExpiryExtension
+	28, // 6: airgapper.v1.RestoreRequest.executable_at:type_name -> google.protobuf.Timestamp
+	28, // 7: airgapper.v1.RestoreRequest.vetoed_at:type_name -> google.protobuf.Timestamp
+	1,  // 8: airgapper.v1.RestoreRequest.requester_context:type_name -> airgapper.v1.RequesterContext
+	23, // 9: airgapper.v1.RestoreRequest.terms:type_name -> airgapper.v1.RestoreTerms
+	24, // 10: airgapper.v1.RestoreRequest.progress:type_name -> airgapper.v1.RestoreProgress
+	27, // 11: airgapper.v1.ListRequestsRequest.status_filter:type_name -> airgapper.v1.RequestStatus
+	0,  // 12: airgapper.v1.ListRequestsResponse.requests:type_name -> airgapper.v1.RestoreRequest
+	0,  // 13: airgapper.v1.GetRequestResponse.request:type_name -> airgapper.v1.RestoreRequest
+	28, // 14: airgapper.v1.CreateRequestRequest.created_at:type_name -> google.protobuf.Timestamp
+	1,  // 15: airgapper.v1.CreateRequestRequest.requester_context:type_name -> airgapper.v1.RequesterContext
+	28, // 16: airgapper.v1.CreateRequestResponse.expires_at:type_name -> google.protobuf.Timestamp
+	23, // 17: airgapper.v1.ApproveRequestRequest.terms:type_name -> airgapper.v1.RestoreTerms
+	28, // 18: airgapper.v1.IssueChallengeResponse.expires_at:type_name -> google.protobuf.Timestamp
+	28, // 19: airgapper.v1.SignRequestRequest.signed_at:type_name -> google.protobuf.Timestamp
+	23, // 20: airgapper.v1.SignRequestRequest.terms:type_name -> airgapper.v1.RestoreTerms
+	28, // 21: airgapper.v1.ExpiryExtension.requested_at:type_name -> google.protobuf.Timestamp
+	28, // 22: airgapper.v1.ExpiryExtension.approved_at:type_name -> google.protobuf.Timestamp
+	16, // 23: airgapper.v1.RequestExtensionResponse.extension:type_name -> airgapper.v1.ExpiryExtension
+	28, // 24: airgapper.v1.ApproveExtensionRequest.signed_at:type_name -> google.protobuf.Timestamp
+	28, // 25: airgapper.v1.ApproveExtensionResponse.expires_at:type_name -> google.protobuf.Timestamp
+	28, // 26: airgapper.v1.VetoRequestRequest.signed_at:type_name -> google.protobuf.Timestamp
+	28, // 27: airgapper.v1.RestoreProgress.starts_at:type_name -> google.protobuf.Timestamp
+	28, // 28: airgapper.v1.RestoreProgress.started_at:type_name -> google.protobuf.Timestamp
+	28, // 29: airgapper.v1.RestoreProgress.finished_at:type_name -> google.protobuf.Timestamp
+	28, // 30: airgapper.v1.RestoreProgress.updated_at:type_name -> google.protobuf.Timestamp
+	24, // 31: airgapper.v1.ReportRestoreProgressRequest.progress:type_name -> airgapper.v1.RestoreProgress
+	2,  // 32: airgapper.v1.RestoreRequestService.ListRequests:input_type -> airgapper.v1.ListRequestsRequest
+	4,  // 33: airgapper.v1.RestoreRequestService.GetRequest:input_type -> airgapper.v1.GetRequestRequest
+	6,  // 34: airgapper.v1.RestoreRequestService.CreateRequest:input_type -> airgapper.v1.CreateRequestRequest
+	8,  // 35: airgapper.v1.RestoreRequestService.ApproveRequest:input_type -> airgapper.v1.ApproveRequestRequest
+	10, // 36: airgapper.v1.RestoreRequestService.IssueChallenge:input_type -> airgapper.v1.IssueChallengeRequest
+	12, // 37: airgapper.v1.RestoreRequestService.SignRequest:input_type -> airgapper.v1.SignRequestRequest
+	14, // 38: airgapper.v1.RestoreRequestService.DenyRequest:input_type -> airgapper.v1.DenyRequestRequest
+	17, // 39: airgapper.v1.RestoreRequestService.RequestExtension:input_type -> airgapper.v1.RequestExtensionRequest
+	19, // 40: airgapper.v1.RestoreRequestService.ApproveExtension:input_type -> airgapper.v1.ApproveExtensionRequest
+	21, // 41: airgapper.v1.RestoreRequestService.VetoRequest:input_type -> airgapper.v1.VetoRequestRequest
+	25, // 42: airgapper.v1.RestoreRequestService.ReportRestoreProgress:input_type -> airgapper.v1.ReportRestoreProgressRequest
+	3,  // 43: airgapper.v1.RestoreRequestService.ListRequests:output_type -> airgapper.v1.ListRequestsResponse
+	5,  // 44: airgapper.v1.RestoreRequestService.GetRequest:output_type -> airgapper.v1.GetRequestResponse
+	7,  // 45: airgapper.v1.RestoreRequestService.CreateRequest:output_type -> airgapper.v1.CreateRequestResponse
+	9,  // 46: airgapper.v1.RestoreRequestService.ApproveRequest:output_type -> airgapper.v1.ApproveRequestResponse
+	11, // 47: airgapper.v1.RestoreRequestService.IssueChallenge:output_type -> airgapper.v1.IssueChallengeResponse
+	13, // 48: airgapper.v1.RestoreRequestService.SignRequest:output_type -> airgapper.v1.SignRequestResponse
+	15, // 49: airgapper.v1.RestoreRequestService.DenyRequest:output_type -> airgapper.v1.DenyRequestResponse
+	18, // 50: airgapper.v1.RestoreRequestService.RequestExtension:output_type -> airgapper.v1.RequestExtensionResponse
+	20, // 51: airgapper.v1.RestoreRequestService.ApproveExtension:output_type -> airgapper.v1.ApproveExtensionResponse
+	22, // 52: airgapper.v1.RestoreRequestService.VetoRequest:output_type -> airgapper.v1.VetoRequestResponse
+	26, // 53: airgapper.v1.RestoreRequestService.ReportRestoreProgress:output_type -> airgapper.v1.ReportRestoreProgressResponse
+	43, // [43:54] is the sub-list for method output_type
+	32, // [32:43] is the sub-list for method input_type
+	32, // [32:32] is the sub-list for extension type_name
+	32, // [32:32] is the sub-list for extension extendee
+	0,  // [0:32] is the sub-list for field type_name
 }
 
 func init() { file_airgapper_v1_requests_proto_init() }
@@ -927,7 +2200,7 @@ func file_airgapper_v1_requests_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_airgapper_v1_requests_proto_rawDesc), len(file_airgapper_v1_requests_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   13,
+			NumMessages:   27,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
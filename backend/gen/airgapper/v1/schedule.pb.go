@@ -66,6 +66,8 @@ type GetScheduleResponse struct {
 	LastRun       *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=last_run,json=lastRun,proto3" json:"last_run,omitempty"`
 	NextRun       *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=next_run,json=nextRun,proto3" json:"next_run,omitempty"`
 	LastError     string                 `protobuf:"bytes,6,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	Timing        *ScheduleTiming        `protobuf:"bytes,7,opt,name=timing,proto3" json:"timing,omitempty"`
+	Timezone      string                 `protobuf:"bytes,8,opt,name=timezone,proto3" json:"timezone,omitempty"` // IANA timezone the schedule's times are in, "Local" for the system's
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -142,17 +144,95 @@ func (x *GetScheduleResponse) GetLastError() string {
 	return ""
 }
 
+func (x *GetScheduleResponse) GetTiming() *ScheduleTiming {
+	if x != nil {
+		return x.Timing
+	}
+	return nil
+}
+
+func (x *GetScheduleResponse) GetTimezone() string {
+	if x != nil {
+		return x.Timezone
+	}
+	return ""
+}
+
+// ScheduleTiming controls missed-run catch-up, jitter and clock jump
+// handling. Durations are Go duration strings (e.g. "12h", "15m").
+type ScheduleTiming struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	CatchUpWindow      string                 `protobuf:"bytes,1,opt,name=catch_up_window,json=catchUpWindow,proto3" json:"catch_up_window,omitempty"`                // Run a missed backup on wake if it's at most this late ("0" = skip)
+	Jitter             string                 `protobuf:"bytes,2,opt,name=jitter,proto3" json:"jitter,omitempty"`                                                     // Random delay up to this added to each run
+	ClockJumpThreshold string                 `protobuf:"bytes,3,opt,name=clock_jump_threshold,json=clockJumpThreshold,proto3" json:"clock_jump_threshold,omitempty"` // Wall-clock drift treated as a sleep or clock change
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *ScheduleTiming) Reset() {
+	*x = ScheduleTiming{}
+	mi := &file_airgapper_v1_schedule_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScheduleTiming) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScheduleTiming) ProtoMessage() {}
+
+func (x *ScheduleTiming) ProtoReflect() protoreflect.Message {
+	mi := &file_airgapper_v1_schedule_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScheduleTiming.ProtoReflect.Descriptor instead.
+func (*ScheduleTiming) Descriptor() ([]byte, []int) {
+	return file_airgapper_v1_schedule_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ScheduleTiming) GetCatchUpWindow() string {
+	if x != nil {
+		return x.CatchUpWindow
+	}
+	return ""
+}
+
+func (x *ScheduleTiming) GetJitter() string {
+	if x != nil {
+		return x.Jitter
+	}
+	return ""
+}
+
+func (x *ScheduleTiming) GetClockJumpThreshold() string {
+	if x != nil {
+		return x.ClockJumpThreshold
+	}
+	return ""
+}
+
 type UpdateScheduleRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Schedule      string                 `protobuf:"bytes,1,opt,name=schedule,proto3" json:"schedule,omitempty"`
 	Paths         []string               `protobuf:"bytes,2,rep,name=paths,proto3" json:"paths,omitempty"`
+	Timing        *ScheduleTiming        `protobuf:"bytes,3,opt,name=timing,proto3" json:"timing,omitempty"`           // Unset leaves timing unchanged
+	Timezone      *string                `protobuf:"bytes,4,opt,name=timezone,proto3,oneof" json:"timezone,omitempty"` // IANA timezone; unset leaves it unchanged, "" for the system's
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *UpdateScheduleRequest) Reset() {
 	*x = UpdateScheduleRequest{}
-	mi := &file_airgapper_v1_schedule_proto_msgTypes[2]
+	mi := &file_airgapper_v1_schedule_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -164,7 +244,7 @@ func (x *UpdateScheduleRequest) String() string {
 func (*UpdateScheduleRequest) ProtoMessage() {}
 
 func (x *UpdateScheduleRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_schedule_proto_msgTypes[2]
+	mi := &file_airgapper_v1_schedule_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -177,7 +257,7 @@ func (x *UpdateScheduleRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateScheduleRequest.ProtoReflect.Descriptor instead.
 func (*UpdateScheduleRequest) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_schedule_proto_rawDescGZIP(), []int{2}
+	return file_airgapper_v1_schedule_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *UpdateScheduleRequest) GetSchedule() string {
@@ -194,6 +274,20 @@ func (x *UpdateScheduleRequest) GetPaths() []string {
 	return nil
 }
 
+func (x *UpdateScheduleRequest) GetTiming() *ScheduleTiming {
+	if x != nil {
+		return x.Timing
+	}
+	return nil
+}
+
+func (x *UpdateScheduleRequest) GetTimezone() string {
+	if x != nil && x.Timezone != nil {
+		return *x.Timezone
+	}
+	return ""
+}
+
 type UpdateScheduleResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
@@ -205,7 +299,7 @@ type UpdateScheduleResponse struct {
 
 func (x *UpdateScheduleResponse) Reset() {
 	*x = UpdateScheduleResponse{}
-	mi := &file_airgapper_v1_schedule_proto_msgTypes[3]
+	mi := &file_airgapper_v1_schedule_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -217,7 +311,7 @@ func (x *UpdateScheduleResponse) String() string {
 func (*UpdateScheduleResponse) ProtoMessage() {}
 
 func (x *UpdateScheduleResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_schedule_proto_msgTypes[3]
+	mi := &file_airgapper_v1_schedule_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -230,7 +324,7 @@ func (x *UpdateScheduleResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateScheduleResponse.ProtoReflect.Descriptor instead.
 func (*UpdateScheduleResponse) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_schedule_proto_rawDescGZIP(), []int{3}
+	return file_airgapper_v1_schedule_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *UpdateScheduleResponse) GetStatus() string {
@@ -263,7 +357,7 @@ type GetBackupHistoryRequest struct {
 
 func (x *GetBackupHistoryRequest) Reset() {
 	*x = GetBackupHistoryRequest{}
-	mi := &file_airgapper_v1_schedule_proto_msgTypes[4]
+	mi := &file_airgapper_v1_schedule_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -275,7 +369,7 @@ func (x *GetBackupHistoryRequest) String() string {
 func (*GetBackupHistoryRequest) ProtoMessage() {}
 
 func (x *GetBackupHistoryRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_schedule_proto_msgTypes[4]
+	mi := &file_airgapper_v1_schedule_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -288,7 +382,7 @@ func (x *GetBackupHistoryRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetBackupHistoryRequest.ProtoReflect.Descriptor instead.
 func (*GetBackupHistoryRequest) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_schedule_proto_rawDescGZIP(), []int{4}
+	return file_airgapper_v1_schedule_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *GetBackupHistoryRequest) GetLimit() int32 {
@@ -309,13 +403,14 @@ type BackupResult struct {
 	Attempt       int32                  `protobuf:"varint,6,opt,name=attempt,proto3" json:"attempt,omitempty"`
 	IsRetry       bool                   `protobuf:"varint,7,opt,name=is_retry,json=isRetry,proto3" json:"is_retry,omitempty"`
 	Error         string                 `protobuf:"bytes,8,opt,name=error,proto3" json:"error,omitempty"`
+	CatchUp       bool                   `protobuf:"varint,9,opt,name=catch_up,json=catchUp,proto3" json:"catch_up,omitempty"` // Made up for a slot missed while asleep
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *BackupResult) Reset() {
 	*x = BackupResult{}
-	mi := &file_airgapper_v1_schedule_proto_msgTypes[5]
+	mi := &file_airgapper_v1_schedule_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -327,7 +422,7 @@ func (x *BackupResult) String() string {
 func (*BackupResult) ProtoMessage() {}
 
 func (x *BackupResult) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_schedule_proto_msgTypes[5]
+	mi := &file_airgapper_v1_schedule_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -340,7 +435,7 @@ func (x *BackupResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BackupResult.ProtoReflect.Descriptor instead.
 func (*BackupResult) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_schedule_proto_rawDescGZIP(), []int{5}
+	return file_airgapper_v1_schedule_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *BackupResult) GetScheduledTime() *timestamppb.Timestamp {
@@ -399,6 +494,13 @@ func (x *BackupResult) GetError() string {
 	return ""
 }
 
+func (x *BackupResult) GetCatchUp() bool {
+	if x != nil {
+		return x.CatchUp
+	}
+	return false
+}
+
 type GetBackupHistoryResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	History       []*BackupResult        `protobuf:"bytes,1,rep,name=history,proto3" json:"history,omitempty"`
@@ -409,7 +511,7 @@ type GetBackupHistoryResponse struct {
 
 func (x *GetBackupHistoryResponse) Reset() {
 	*x = GetBackupHistoryResponse{}
-	mi := &file_airgapper_v1_schedule_proto_msgTypes[6]
+	mi := &file_airgapper_v1_schedule_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -421,7 +523,7 @@ func (x *GetBackupHistoryResponse) String() string {
 func (*GetBackupHistoryResponse) ProtoMessage() {}
 
 func (x *GetBackupHistoryResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_airgapper_v1_schedule_proto_msgTypes[6]
+	mi := &file_airgapper_v1_schedule_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -434,7 +536,7 @@ func (x *GetBackupHistoryResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetBackupHistoryResponse.ProtoReflect.Descriptor instead.
 func (*GetBackupHistoryResponse) Descriptor() ([]byte, []int) {
-	return file_airgapper_v1_schedule_proto_rawDescGZIP(), []int{6}
+	return file_airgapper_v1_schedule_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *GetBackupHistoryResponse) GetHistory() []*BackupResult {
@@ -456,7 +558,7 @@ var File_airgapper_v1_schedule_proto protoreflect.FileDescriptor
 const file_airgapper_v1_schedule_proto_rawDesc = "" +
 	"\n" +
 	"\x1bairgapper/v1/schedule.proto\x12\fairgapper.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x14\n" +
-	"\x12GetScheduleRequest\"\xee\x01\n" +
+	"\x12GetScheduleRequest\"\xc0\x02\n" +
 	"\x13GetScheduleResponse\x12\x1a\n" +
 	"\bschedule\x18\x01 \x01(\tR\bschedule\x12\x14\n" +
 	"\x05paths\x18\x02 \x03(\tR\x05paths\x12\x18\n" +
@@ -464,16 +566,25 @@ const file_airgapper_v1_schedule_proto_rawDesc = "" +
 	"\blast_run\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\alastRun\x125\n" +
 	"\bnext_run\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\anextRun\x12\x1d\n" +
 	"\n" +
-	"last_error\x18\x06 \x01(\tR\tlastError\"I\n" +
+	"last_error\x18\x06 \x01(\tR\tlastError\x124\n" +
+	"\x06timing\x18\a \x01(\v2\x1c.airgapper.v1.ScheduleTimingR\x06timing\x12\x1a\n" +
+	"\btimezone\x18\b \x01(\tR\btimezone\"\x82\x01\n" +
+	"\x0eScheduleTiming\x12&\n" +
+	"\x0fcatch_up_window\x18\x01 \x01(\tR\rcatchUpWindow\x12\x16\n" +
+	"\x06jitter\x18\x02 \x01(\tR\x06jitter\x120\n" +
+	"\x14clock_jump_threshold\x18\x03 \x01(\tR\x12clockJumpThreshold\"\xad\x01\n" +
 	"\x15UpdateScheduleRequest\x12\x1a\n" +
 	"\bschedule\x18\x01 \x01(\tR\bschedule\x12\x14\n" +
-	"\x05paths\x18\x02 \x03(\tR\x05paths\"m\n" +
+	"\x05paths\x18\x02 \x03(\tR\x05paths\x124\n" +
+	"\x06timing\x18\x03 \x01(\v2\x1c.airgapper.v1.ScheduleTimingR\x06timing\x12\x1f\n" +
+	"\btimezone\x18\x04 \x01(\tH\x00R\btimezone\x88\x01\x01B\v\n" +
+	"\t_timezone\"m\n" +
 	"\x16UpdateScheduleResponse\x12\x16\n" +
 	"\x06status\x18\x01 \x01(\tR\x06status\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\x12!\n" +
 	"\fhot_reloaded\x18\x03 \x01(\bR\vhotReloaded\"/\n" +
 	"\x17GetBackupHistoryRequest\x12\x14\n" +
-	"\x05limit\x18\x01 \x01(\x05R\x05limit\"\xc9\x02\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\"\xe4\x02\n" +
 	"\fBackupResult\x12A\n" +
 	"\x0escheduled_time\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\rscheduledTime\x129\n" +
 	"\n" +
@@ -484,7 +595,8 @@ const file_airgapper_v1_schedule_proto_rawDesc = "" +
 	"\asuccess\x18\x05 \x01(\bR\asuccess\x12\x18\n" +
 	"\aattempt\x18\x06 \x01(\x05R\aattempt\x12\x19\n" +
 	"\bis_retry\x18\a \x01(\bR\aisRetry\x12\x14\n" +
-	"\x05error\x18\b \x01(\tR\x05error\"f\n" +
+	"\x05error\x18\b \x01(\tR\x05error\x12\x19\n" +
+	"\bcatch_up\x18\t \x01(\bR\acatchUp\"f\n" +
 	"\x18GetBackupHistoryResponse\x124\n" +
 	"\ahistory\x18\x01 \x03(\v2\x1a.airgapper.v1.BackupResultR\ahistory\x12\x14\n" +
 	"\x05count\x18\x02 \x01(\x05R\x05count2\xa5\x02\n" +
@@ -506,35 +618,38 @@ func file_airgapper_v1_schedule_proto_rawDescGZIP() []byte {
 	return file_airgapper_v1_schedule_proto_rawDescData
 }
 
-var file_airgapper_v1_schedule_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_airgapper_v1_schedule_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
 var file_airgapper_v1_schedule_proto_goTypes = []any{
 	(*GetScheduleRequest)(nil),       // 0: airgapper.v1.GetScheduleRequest
 	(*GetScheduleResponse)(nil),      // 1: airgapper.v1.GetScheduleResponse
-	(*UpdateScheduleRequest)(nil),    // 2: airgapper.v1.UpdateScheduleRequest
-	(*UpdateScheduleResponse)(nil),   // 3: airgapper.v1.UpdateScheduleResponse
-	(*GetBackupHistoryRequest)(nil),  // 4: airgapper.v1.GetBackupHistoryRequest
-	(*BackupResult)(nil),             // 5: airgapper.v1.BackupResult
-	(*GetBackupHistoryResponse)(nil), // 6: airgapper.v1.GetBackupHistoryResponse
-	(*timestamppb.Timestamp)(nil),    // 7: google.protobuf.Timestamp
+	(*ScheduleTiming)(nil),           // 2: airgapper.v1.ScheduleTiming
+	(*UpdateScheduleRequest)(nil),    // 3: airgapper.v1.UpdateScheduleRequest
+	(*UpdateScheduleResponse)(nil),   // 4: airgapper.v1.UpdateScheduleResponse
+	(*GetBackupHistoryRequest)(nil),  // 5: airgapper.v1.GetBackupHistoryRequest
+	(*BackupResult)(nil),             // 6: airgapper.v1.BackupResult
+	(*GetBackupHistoryResponse)(nil), // 7: airgapper.v1.GetBackupHistoryResponse
+	(*timestamppb.Timestamp)(nil),    // 8: google.protobuf.Timestamp
 }
 var file_airgapper_v1_schedule_proto_depIdxs = []int32{
-	7, // 0: airgapper.v1.GetScheduleResponse.last_run:type_name -> google.protobuf.Timestamp
-	7, // 1: airgapper.v1.GetScheduleResponse.next_run:type_name -> google.protobuf.Timestamp
-	7, // 2: airgapper.v1.BackupResult.scheduled_time:type_name -> google.protobuf.Timestamp
-	7, // 3: airgapper.v1.BackupResult.start_time:type_name -> google.protobuf.Timestamp
-	7, // 4: airgapper.v1.BackupResult.end_time:type_name -> google.protobuf.Timestamp
-	5, // 5: airgapper.v1.GetBackupHistoryResponse.history:type_name -> airgapper.v1.BackupResult
-	0, // 6: airgapper.v1.ScheduleService.GetSchedule:input_type -> airgapper.v1.GetScheduleRequest
-	2, // 7: airgapper.v1.ScheduleService.UpdateSchedule:input_type -> airgapper.v1.UpdateScheduleRequest
-	4, // 8: airgapper.v1.ScheduleService.GetBackupHistory:input_type -> airgapper.v1.GetBackupHistoryRequest
-	1, // 9: airgapper.v1.ScheduleService.GetSchedule:output_type -> airgapper.v1.GetScheduleResponse
-	3, // 10: airgapper.v1.ScheduleService.UpdateSchedule:output_type -> airgapper.v1.UpdateScheduleResponse
-	6, // 11: airgapper.v1.ScheduleService.GetBackupHistory:output_type -> airgapper.v1.GetBackupHistoryResponse
-	9, // [9:12] is the sub-list for method output_type
-	6, // [6:9] is the sub-list for method input_type
-	6, // [6:6] is the sub-list for extension type_name
-	6, // [6:6] is the sub-list for extension extendee
-	0, // [0:6] is the sub-list for field type_name
+	8,  // 0: airgapper.v1.GetScheduleResponse.last_run:type_name -> google.protobuf.Timestamp
+	8,  // 1: airgapper.v1.GetScheduleResponse.next_run:type_name -> google.protobuf.Timestamp
+	2,  // 2: airgapper.v1.GetScheduleResponse.timing:type_name -> airgapper.v1.ScheduleTiming
+	2,  // 3: airgapper.v1.UpdateScheduleRequest.timing:type_name -> airgapper.v1.ScheduleTiming
+	8,  // 4: airgapper.v1.BackupResult.scheduled_time:type_name -> google.protobuf.Timestamp
+	8,  // 5: airgapper.v1.BackupResult.start_time:type_name -> google.protobuf.Timestamp
+	8,  // 6: airgapper.v1.BackupResult.end_time:type_name -> google.protobuf.Timestamp
+	6,  // 7: airgapper.v1.GetBackupHistoryResponse.history:type_name -> airgapper.v1.BackupResult
+	0,  // 8: airgapper.v1.ScheduleService.GetSchedule:input_type -> airgapper.v1.GetScheduleRequest
+	3,  // 9: airgapper.v1.ScheduleService.UpdateSchedule:input_type -> airgapper.v1.UpdateScheduleRequest
+	5,  // 10: airgapper.v1.ScheduleService.GetBackupHistory:input_type -> airgapper.v1.GetBackupHistoryRequest
+	1,  // 11: airgapper.v1.ScheduleService.GetSchedule:output_type -> airgapper.v1.GetScheduleResponse
+	4,  // 12: airgapper.v1.ScheduleService.UpdateSchedule:output_type -> airgapper.v1.UpdateScheduleResponse
+	7,  // 13: airgapper.v1.ScheduleService.GetBackupHistory:output_type -> airgapper.v1.GetBackupHistoryResponse
+	11, // [11:14] is the sub-list for method output_type
+	8,  // [8:11] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
 }
 
 func init() { file_airgapper_v1_schedule_proto_init() }
@@ -542,13 +657,14 @@ func file_airgapper_v1_schedule_proto_init() {
 	if File_airgapper_v1_schedule_proto != nil {
 		return
 	}
+	file_airgapper_v1_schedule_proto_msgTypes[3].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_airgapper_v1_schedule_proto_rawDesc), len(file_airgapper_v1_schedule_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   7,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -74,8 +74,19 @@ type GetStorageStatusResponse struct {
 	DiskUsagePct    int32                  `protobuf:"varint,12,opt,name=disk_usage_pct,json=diskUsagePct,proto3" json:"disk_usage_pct,omitempty"`
 	DiskFreeBytes   int64                  `protobuf:"varint,13,opt,name=disk_free_bytes,json=diskFreeBytes,proto3" json:"disk_free_bytes,omitempty"`
 	DiskTotalBytes  int64                  `protobuf:"varint,14,opt,name=disk_total_bytes,json=diskTotalBytes,proto3" json:"disk_total_bytes,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+	// Storage limit from the signed policy (0 = none)
+	PolicyMaxStorageBytes int64 `protobuf:"varint,15,opt,name=policy_max_storage_bytes,json=policyMaxStorageBytes,proto3" json:"policy_max_storage_bytes,omitempty"`
+	// Stricter of quota_bytes and policy_max_storage_bytes (0 = unlimited)
+	EffectiveQuotaBytes int64 `protobuf:"varint,16,opt,name=effective_quota_bytes,json=effectiveQuotaBytes,proto3" json:"effective_quota_bytes,omitempty"`
+	QuotaUsagePct       int32 `protobuf:"varint,17,opt,name=quota_usage_pct,json=quotaUsagePct,proto3" json:"quota_usage_pct,omitempty"`
+	// Read-only maintenance mode: reads are served, writes get 503
+	ReadOnly          bool                   `protobuf:"varint,18,opt,name=read_only,json=readOnly,proto3" json:"read_only,omitempty"`
+	MaintenanceReason string                 `protobuf:"bytes,19,opt,name=maintenance_reason,json=maintenanceReason,proto3" json:"maintenance_reason,omitempty"`
+	MaintenanceSince  *timestamppb.Timestamp `protobuf:"bytes,20,opt,name=maintenance_since,json=maintenanceSince,proto3" json:"maintenance_since,omitempty"`
+	// Expected end of maintenance, if announced
+	MaintenanceUntil *timestamppb.Timestamp `protobuf:"bytes,21,opt,name=maintenance_until,json=maintenanceUntil,proto3" json:"maintenance_until,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
 func (x *GetStorageStatusResponse) Reset() {
@@ -206,6 +217,55 @@ func (x *GetStorageStatusResponse) GetDiskTotalBytes() int64 {
 	return 0
 }
 
+func (x *GetStorageStatusResponse) GetPolicyMaxStorageBytes() int64 {
+	if x != nil {
+		return x.PolicyMaxStorageBytes
+	}
+	return 0
+}
+
+func (x *GetStorageStatusResponse) GetEffectiveQuotaBytes() int64 {
+	if x != nil {
+		return x.EffectiveQuotaBytes
+	}
+	return 0
+}
+
+func (x *GetStorageStatusResponse) GetQuotaUsagePct() int32 {
+	if x != nil {
+		return x.QuotaUsagePct
+	}
+	return 0
+}
+
+func (x *GetStorageStatusResponse) GetReadOnly() bool {
+	if x != nil {
+		return x.ReadOnly
+	}
+	return false
+}
+
+func (x *GetStorageStatusResponse) GetMaintenanceReason() string {
+	if x != nil {
+		return x.MaintenanceReason
+	}
+	return ""
+}
+
+func (x *GetStorageStatusResponse) GetMaintenanceSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.MaintenanceSince
+	}
+	return nil
+}
+
+func (x *GetStorageStatusResponse) GetMaintenanceUntil() *timestamppb.Timestamp {
+	if x != nil {
+		return x.MaintenanceUntil
+	}
+	return nil
+}
+
 type StartStorageRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
@@ -366,12 +426,117 @@ func (x *StopStorageResponse) GetStatus() string {
 	return ""
 }
 
+type SetStorageMaintenanceRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Enabled bool                   `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	Reason  string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	// Expected end of maintenance, sent to clients as Retry-After
+	Until         *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=until,proto3" json:"until,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetStorageMaintenanceRequest) Reset() {
+	*x = SetStorageMaintenanceRequest{}
+	mi := &file_airgapper_v1_storage_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetStorageMaintenanceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetStorageMaintenanceRequest) ProtoMessage() {}
+
+func (x *SetStorageMaintenanceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_airgapper_v1_storage_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetStorageMaintenanceRequest.ProtoReflect.Descriptor instead.
+func (*SetStorageMaintenanceRequest) Descriptor() ([]byte, []int) {
+	return file_airgapper_v1_storage_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SetStorageMaintenanceRequest) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *SetStorageMaintenanceRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *SetStorageMaintenanceRequest) GetUntil() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Until
+	}
+	return nil
+}
+
+type SetStorageMaintenanceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetStorageMaintenanceResponse) Reset() {
+	*x = SetStorageMaintenanceResponse{}
+	mi := &file_airgapper_v1_storage_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetStorageMaintenanceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetStorageMaintenanceResponse) ProtoMessage() {}
+
+func (x *SetStorageMaintenanceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_airgapper_v1_storage_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetStorageMaintenanceResponse.ProtoReflect.Descriptor instead.
+func (*SetStorageMaintenanceResponse) Descriptor() ([]byte, []int) {
+	return file_airgapper_v1_storage_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SetStorageMaintenanceResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
 var File_airgapper_v1_storage_proto protoreflect.FileDescriptor
 
 const file_airgapper_v1_storage_proto_rawDesc = "" +
 	"\n" +
 	"\x1aairgapper/v1/storage.proto\x12\fairgapper.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x19\n" +
-	"\x17GetStorageStatusRequest\"\x93\x04\n" +
+	"\x17GetStorageStatusRequest\"\x86\a\n" +
 	"\x18GetStorageStatusResponse\x12\x1e\n" +
 	"\n" +
 	"configured\x18\x01 \x01(\bR\n" +
@@ -394,17 +559,31 @@ const file_airgapper_v1_storage_proto_rawDesc = "" +
 	"\x12max_disk_usage_pct\x18\v \x01(\x05R\x0fmaxDiskUsagePct\x12$\n" +
 	"\x0edisk_usage_pct\x18\f \x01(\x05R\fdiskUsagePct\x12&\n" +
 	"\x0fdisk_free_bytes\x18\r \x01(\x03R\rdiskFreeBytes\x12(\n" +
-	"\x10disk_total_bytes\x18\x0e \x01(\x03R\x0ediskTotalBytes\"\x15\n" +
+	"\x10disk_total_bytes\x18\x0e \x01(\x03R\x0ediskTotalBytes\x127\n" +
+	"\x18policy_max_storage_bytes\x18\x0f \x01(\x03R\x15policyMaxStorageBytes\x122\n" +
+	"\x15effective_quota_bytes\x18\x10 \x01(\x03R\x13effectiveQuotaBytes\x12&\n" +
+	"\x0fquota_usage_pct\x18\x11 \x01(\x05R\rquotaUsagePct\x12\x1b\n" +
+	"\tread_only\x18\x12 \x01(\bR\breadOnly\x12-\n" +
+	"\x12maintenance_reason\x18\x13 \x01(\tR\x11maintenanceReason\x12G\n" +
+	"\x11maintenance_since\x18\x14 \x01(\v2\x1a.google.protobuf.TimestampR\x10maintenanceSince\x12G\n" +
+	"\x11maintenance_until\x18\x15 \x01(\v2\x1a.google.protobuf.TimestampR\x10maintenanceUntil\"\x15\n" +
 	"\x13StartStorageRequest\".\n" +
 	"\x14StartStorageResponse\x12\x16\n" +
 	"\x06status\x18\x01 \x01(\tR\x06status\"\x14\n" +
 	"\x12StopStorageRequest\"-\n" +
 	"\x13StopStorageResponse\x12\x16\n" +
-	"\x06status\x18\x01 \x01(\tR\x06status2\x9e\x02\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\"\x82\x01\n" +
+	"\x1cSetStorageMaintenanceRequest\x12\x18\n" +
+	"\aenabled\x18\x01 \x01(\bR\aenabled\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\x120\n" +
+	"\x05until\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x05until\"7\n" +
+	"\x1dSetStorageMaintenanceResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status2\x90\x03\n" +
 	"\x0eStorageService\x12a\n" +
 	"\x10GetStorageStatus\x12%.airgapper.v1.GetStorageStatusRequest\x1a&.airgapper.v1.GetStorageStatusResponse\x12U\n" +
 	"\fStartStorage\x12!.airgapper.v1.StartStorageRequest\x1a\".airgapper.v1.StartStorageResponse\x12R\n" +
-	"\vStopStorage\x12 .airgapper.v1.StopStorageRequest\x1a!.airgapper.v1.StopStorageResponseB\xb8\x01\n" +
+	"\vStopStorage\x12 .airgapper.v1.StopStorageRequest\x1a!.airgapper.v1.StopStorageResponse\x12p\n" +
+	"\x15SetStorageMaintenance\x12*.airgapper.v1.SetStorageMaintenanceRequest\x1a+.airgapper.v1.SetStorageMaintenanceResponseB\xb8\x01\n" +
 	"\x10com.airgapper.v1B\fStorageProtoP\x01ZEgithub.com/lcrostarosa/airgapper/backend/gen/airgapper/v1;airgapperv1\xa2\x02\x03AXX\xaa\x02\fAirgapper.V1\xca\x02\fAirgapper\\V1\xe2\x02\x18Airgapper\\V1\\GPBMetadata\xea\x02\rAirgapper::V1b\x06proto3"
 
 var (
@@ -419,29 +598,36 @@ func file_airgapper_v1_storage_proto_rawDescGZIP() []byte {
 	return file_airgapper_v1_storage_proto_rawDescData
 }
 
-var file_airgapper_v1_storage_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_airgapper_v1_storage_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
 var file_airgapper_v1_storage_proto_goTypes = []any{
-	(*GetStorageStatusRequest)(nil),  // 0: airgapper.v1.GetStorageStatusRequest
-	(*GetStorageStatusResponse)(nil), // 1: airgapper.v1.GetStorageStatusResponse
-	(*StartStorageRequest)(nil),      // 2: airgapper.v1.StartStorageRequest
-	(*StartStorageResponse)(nil),     // 3: airgapper.v1.StartStorageResponse
-	(*StopStorageRequest)(nil),       // 4: airgapper.v1.StopStorageRequest
-	(*StopStorageResponse)(nil),      // 5: airgapper.v1.StopStorageResponse
-	(*timestamppb.Timestamp)(nil),    // 6: google.protobuf.Timestamp
+	(*GetStorageStatusRequest)(nil),       // 0: airgapper.v1.GetStorageStatusRequest
+	(*GetStorageStatusResponse)(nil),      // 1: airgapper.v1.GetStorageStatusResponse
+	(*StartStorageRequest)(nil),           // 2: airgapper.v1.StartStorageRequest
+	(*StartStorageResponse)(nil),          // 3: airgapper.v1.StartStorageResponse
+	(*StopStorageRequest)(nil),            // 4: airgapper.v1.StopStorageRequest
+	(*StopStorageResponse)(nil),           // 5: airgapper.v1.StopStorageResponse
+	(*SetStorageMaintenanceRequest)(nil),  // 6: airgapper.v1.SetStorageMaintenanceRequest
+	(*SetStorageMaintenanceResponse)(nil), // 7: airgapper.v1.SetStorageMaintenanceResponse
+	(*timestamppb.Timestamp)(nil),         // 8: google.protobuf.Timestamp
 }
 var file_airgapper_v1_storage_proto_depIdxs = []int32{
-	6, // 0: airgapper.v1.GetStorageStatusResponse.start_time:type_name -> google.protobuf.Timestamp
-	0, // 1: airgapper.v1.StorageService.GetStorageStatus:input_type -> airgapper.v1.GetStorageStatusRequest
-	2, // 2: airgapper.v1.StorageService.StartStorage:input_type -> airgapper.v1.StartStorageRequest
-	4, // 3: airgapper.v1.StorageService.StopStorage:input_type -> airgapper.v1.StopStorageRequest
-	1, // 4: airgapper.v1.StorageService.GetStorageStatus:output_type -> airgapper.v1.GetStorageStatusResponse
-	3, // 5: airgapper.v1.StorageService.StartStorage:output_type -> airgapper.v1.StartStorageResponse
-	5, // 6: airgapper.v1.StorageService.StopStorage:output_type -> airgapper.v1.StopStorageResponse
-	4, // [4:7] is the sub-list for method output_type
-	1, // [1:4] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	8, // 0: airgapper.v1.GetStorageStatusResponse.start_time:type_name -> google.protobuf.Timestamp
+	8, // 1: airgapper.v1.GetStorageStatusResponse.maintenance_since:type_name -> google.protobuf.Timestamp
+	8, // 2: airgapper.v1.GetStorageStatusResponse.maintenance_until:type_name -> google.protobuf.Timestamp
+	8, // 3: airgapper.v1.SetStorageMaintenanceRequest.until:type_name -> google.protobuf.Timestamp
+	0, // 4: airgapper.v1.StorageService.GetStorageStatus:input_type -> airgapper.v1.GetStorageStatusRequest
+	2, // 5: airgapper.v1.StorageService.StartStorage:input_type -> airgapper.v1.StartStorageRequest
+	4, // 6: airgapper.v1.StorageService.StopStorage:input_type -> airgapper.v1.StopStorageRequest
+	6, // 7: airgapper.v1.StorageService.SetStorageMaintenance:input_type -> airgapper.v1.SetStorageMaintenanceRequest
+	1, // 8: airgapper.v1.StorageService.GetStorageStatus:output_type -> airgapper.v1.GetStorageStatusResponse
+	3, // 9: airgapper.v1.StorageService.StartStorage:output_type -> airgapper.v1.StartStorageResponse
+	5, // 10: airgapper.v1.StorageService.StopStorage:output_type -> airgapper.v1.StopStorageResponse
+	7, // 11: airgapper.v1.StorageService.SetStorageMaintenance:output_type -> airgapper.v1.SetStorageMaintenanceResponse
+	8, // [8:12] is the sub-list for method output_type
+	4, // [4:8] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_airgapper_v1_storage_proto_init() }
@@ -455,7 +641,7 @@ func file_airgapper_v1_storage_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_airgapper_v1_storage_proto_rawDesc), len(file_airgapper_v1_storage_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
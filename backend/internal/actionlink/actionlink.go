@@ -0,0 +1,208 @@
+// Package actionlink issues the links notifications carry to approve or
+// deny a restore request from a confirmation page on this node. A link's
+// token names its action and request, is signed with a key only this node
+// has, expires after TTL and can be used once. It authorizes nothing
+// itself: on confirmation the node approves with its own key, as the
+// approval page does.
+package actionlink
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+// FileName is the links' file in the config directory
+const FileName = "action-links.json"
+
+// TTL is how long a link stays usable
+const TTL = time.Hour
+
+// Actions a link can take
+const (
+	ActionApprove = "approve"
+	ActionDeny    = "deny"
+)
+
+// ErrInvalid means a token is forged, malformed, used or expired
+var ErrInvalid = apperrors.New(apperrors.CodeUnauthenticated, "this link is invalid, used or has expired; approve with 'airgapper approve' or the approval page instead")
+
+// encoding is how tokens encode their parts
+var encoding = base64.RawURLEncoding
+
+// Claims are what a token allows
+type Claims struct {
+	ID        string    `json:"id"` // Random; recorded when the link is used
+	Action    string    `json:"action"`
+	RequestID string    `json:"request"`
+	ExpiresAt time.Time `json:"exp"`
+}
+
+// usedLink is a used link, kept until it would have expired
+type usedLink struct {
+	ID        string    `json:"id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type storeFile struct {
+	Key  []byte     `json:"key"` // HMAC-SHA256 key tokens are signed with
+	Used []usedLink `json:"used,omitempty"`
+}
+
+// Store is the links file of a config directory. It is safe for concurrent
+// use within a process.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore returns the links of config directory dir
+func NewStore(dir string) *Store {
+	return &Store{path: filepath.Join(dir, FileName)}
+}
+
+// Issue returns a token to take action on request requestID until TTL from now
+func (s *Store) Issue(action, requestID string, now time.Time) (string, error) {
+	if action != ActionApprove && action != ActionDeny {
+		return "", fmt.Errorf("unknown action %q", action)
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(Claims{
+		ID:        encoding.EncodeToString(id),
+		Action:    action,
+		RequestID: requestID,
+		ExpiresAt: now.Add(TTL).UTC().Truncate(time.Second),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var key []byte
+	err = s.update(func(f *storeFile) error {
+		if len(f.Key) == 0 {
+			f.Key = make([]byte, 32)
+			if _, err := rand.Read(f.Key); err != nil {
+				return err
+			}
+		}
+		key = f.Key
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	payload := encoding.EncodeToString(claims)
+	return payload + "." + encoding.EncodeToString(sign(key, payload)), nil
+}
+
+// Check returns token's claims if it is valid and unused, without using it
+func (s *Store) Check(token string, now time.Time) (*Claims, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return verify(&f, token, now)
+}
+
+// Use returns token's claims and records it as used, so it can't be used
+// again
+func (s *Store) Use(token string, now time.Time) (*Claims, error) {
+	var claims *Claims
+	err := s.update(func(f *storeFile) error {
+		c, err := verify(f, token, now)
+		if err != nil {
+			return err
+		}
+		claims = c
+		f.Used = append(f.Used, usedLink{ID: c.ID, ExpiresAt: c.ExpiresAt})
+		return nil
+	})
+	return claims, err
+}
+
+// verify checks token's signature, expiry and that it wasn't used
+func verify(f *storeFile, token string, now time.Time) (*Claims, error) {
+	payload, sig, ok := strings.Cut(token, ".")
+	got, err := encoding.DecodeString(sig)
+	if !ok || err != nil || len(f.Key) == 0 || !hmac.Equal(got, sign(f.Key, payload)) {
+		return nil, ErrInvalid
+	}
+	data, err := encoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	var c Claims
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" || !now.Before(c.ExpiresAt) {
+		return nil, ErrInvalid
+	}
+	if slices.ContainsFunc(f.Used, func(u usedLink) bool { return u.ID == c.ID }) {
+		return nil, ErrInvalid
+	}
+	return &c, nil
+}
+
+func sign(key []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// update loads the file, applies fn and saves the result. Links that have
+// expired are forgotten on every update.
+func (s *Store) update(fn func(*storeFile) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := s.load()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	f.Used = slices.DeleteFunc(f.Used, func(u usedLink) bool { return !now.Before(u.ExpiresAt) })
+	if err := fn(&f); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save action links: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) load() (storeFile, error) {
+	var f storeFile
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return f, err
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return f, fmt.Errorf("failed to parse action links: %w", err)
+	}
+	return f, nil
+}
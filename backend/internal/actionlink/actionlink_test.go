@@ -0,0 +1,66 @@
+package actionlink
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir)
+	now := time.Now()
+
+	token, err := store.Issue(ActionApprove, "req1", now)
+	require.NoError(t, err)
+	info, err := os.Stat(filepath.Join(dir, FileName))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	claims, err := store.Check(token, now)
+	require.NoError(t, err)
+	assert.Equal(t, ActionApprove, claims.Action)
+	assert.Equal(t, "req1", claims.RequestID)
+	_, err = store.Check(token, now.Add(TTL))
+	assert.ErrorIs(t, err, ErrInvalid, "links expire")
+
+	_, err = store.Use(token, now)
+	require.NoError(t, err)
+	_, err = store.Use(token, now)
+	assert.ErrorIs(t, err, ErrInvalid, "a link works once")
+	_, err = NewStore(dir).Check(token, now)
+	assert.ErrorIs(t, err, ErrInvalid, "and stays used across restarts")
+
+	_, err = store.Issue("delete", "req1", now)
+	assert.Error(t, err)
+}
+
+func TestForgedTokens(t *testing.T) {
+	store := NewStore(t.TempDir())
+	now := time.Now()
+	_, err := store.Check("anything", now)
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	approve, err := store.Issue(ActionApprove, "req1", now)
+	require.NoError(t, err)
+	deny, err := store.Issue(ActionDeny, "req2", now)
+	require.NoError(t, err)
+	assert.NotEqual(t, approve, deny)
+
+	// Another request's claims under this one's signature
+	payload, _, _ := strings.Cut(deny, ".")
+	_, sig, _ := strings.Cut(approve, ".")
+	_, err = store.Check(payload+"."+sig, now)
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	// Another node's link
+	other, err := NewStore(t.TempDir()).Issue(ActionApprove, "req1", now)
+	require.NoError(t, err)
+	_, err = store.Check(other, now)
+	assert.ErrorIs(t, err, ErrInvalid)
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/actionlink"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	"github.com/lcrostarosa/airgapper/backend/internal/emergency"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+// The endpoints behind notifications' approve and deny links (relative to
+// APIBasePath). The link's token is all they need: it names the action and
+// request, and is used up by confirming it.
+const (
+	actionsPath        = "/actions"
+	actionsConfirmPath = "/actions/confirm"
+)
+
+// actionPagePath is the confirmation page links open, with the token in
+// the fragment so it stays out of logs and Referer headers
+const actionPagePath = "/action.html"
+
+// actionBody is the body of POST /api/v1/actions and /actions/confirm
+type actionBody struct {
+	Token string `json:"token"`
+}
+
+// actionLinksHandler serves the confirmation page's API:
+//
+//	POST /api/v1/actions          what a link's token would do, without doing it
+//	POST /api/v1/actions/confirm  do it, approving as this node's key holder
+func actionLinksHandler(store *actionlink.Store, approver nodeApprover) http.Handler {
+	now := time.Now
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != actionsPath && r.URL.Path != actionsConfirmPath {
+			writeError(w, apperrors.New(apperrors.CodeNotFound, "unknown action route"))
+			return
+		}
+		if r.Method != http.MethodPost {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+		if store == nil {
+			writeError(w, apperrors.New(apperrors.CodeFailedPrecondition, "this node has no config directory to keep action links in"))
+			return
+		}
+		var body actionBody
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&body); err != nil || body.Token == "" {
+			writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "token is required"))
+			return
+		}
+
+		claims, err := store.Check(body.Token, now())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		req, err := pendingRequest(approver, claims.RequestID)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if r.URL.Path == actionsPath {
+			writeJSON(w, http.StatusOK, map[string]any{
+				"action":     claims.Action,
+				"request":    toPendingApproval(req),
+				"expires_at": claims.ExpiresAt,
+			})
+			return
+		}
+
+		// Use the link up before acting on it, so it can't act twice
+		if _, err := store.Use(body.Token, now()); err != nil {
+			writeError(w, err)
+			return
+		}
+		switch claims.Action {
+		case actionlink.ActionApprove:
+			progress, err := approver.ApproveAsNode(req.ID)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			logging.Info("Request approved from a notification link",
+				logging.String("request", req.ID),
+				logging.String("addr", clientAddr(r)))
+			writeJSON(w, http.StatusOK, map[string]any{
+				"status":    "approved",
+				"approvals": progress.Current,
+				"required":  progress.Required,
+				"complete":  progress.IsApproved,
+			})
+		case actionlink.ActionDeny:
+			if err := approver.DenyRequest(req.ID); err != nil {
+				writeError(w, err)
+				return
+			}
+			logging.Info("Request denied from a notification link",
+				logging.String("request", req.ID),
+				logging.String("addr", clientAddr(r)))
+			writeJSON(w, http.StatusOK, map[string]string{"status": "denied"})
+		default:
+			writeError(w, actionlink.ErrInvalid)
+		}
+	})
+}
+
+// pendingRequest returns the pending request id
+func pendingRequest(approver nodeApprover, id string) (*consent.RestoreRequest, error) {
+	pending, err := approver.ListPendingRequests()
+	if err != nil {
+		return nil, apperrors.Coded(apperrors.CodeInternal, err)
+	}
+	for _, req := range pending {
+		if req.ID == id {
+			return req, nil
+		}
+	}
+	return nil, apperrors.Newf(apperrors.CodeRequestNotPending, "request %s is no longer pending", id)
+}
+
+// requestActions returns the approve and deny links for a notification of
+// request id, or nil if the node has no action_link_url
+func requestActions(cfg *config.Config, id string) []emergency.Action {
+	if cfg.ActionLinkURL == "" || cfg.ConfigDir == "" || id == "" {
+		return nil
+	}
+	store := actionlink.NewStore(cfg.ConfigDir)
+	base := strings.TrimSuffix(cfg.ActionLinkURL, "/") + actionPagePath + "#token="
+	var actions []emergency.Action
+	for _, a := range []struct{ action, label string }{
+		{actionlink.ActionApprove, "Approve"},
+		{actionlink.ActionDeny, "Deny"},
+	} {
+		token, err := store.Issue(a.action, id, time.Now())
+		if err != nil {
+			logging.Warn("Failed to create a notification link", logging.Err(err))
+			return nil
+		}
+		actions = append(actions, emergency.Action{Label: a.label, URL: base + token})
+	}
+	return actions
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/actionlink"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+func TestActionLinksHandler(t *testing.T) {
+	store := actionlink.NewStore(t.TempDir())
+	approver := &fakeApprover{}
+	h := actionLinksHandler(store, approver)
+
+	post := func(path, token string) (*httptest.ResponseRecorder, map[string]any) {
+		data, _ := json.Marshal(actionBody{Token: token})
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(string(data))))
+		var body map[string]any
+		_ = json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec, body
+	}
+
+	approve, err := store.Issue(actionlink.ActionApprove, "req1", time.Now())
+	require.NoError(t, err)
+
+	// Looking doesn't act or use the link up
+	rec, body := post(actionsPath, approve)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "approve", body["action"])
+	assert.Equal(t, "alice", body["request"].(map[string]any)["requester"])
+	rec, _ = post(actionsPath, approve)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, approver.approved)
+
+	rec, body = post(actionsConfirmPath, approve)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "approved", body["status"])
+	assert.Equal(t, []string{"req1"}, approver.approved)
+
+	rec, _ = post(actionsConfirmPath, approve)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "a link works once")
+	assert.Equal(t, []string{"req1"}, approver.approved)
+
+	deny, err := store.Issue(actionlink.ActionDeny, "req1", time.Now())
+	require.NoError(t, err)
+	rec, body = post(actionsConfirmPath, deny)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "denied", body["status"])
+	assert.Equal(t, []string{"req1"}, approver.denied)
+
+	// A request that is no longer pending
+	gone, err := store.Issue(actionlink.ActionApprove, "req-decided", time.Now())
+	require.NoError(t, err)
+	rec, body = post(actionsConfirmPath, gone)
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	assert.Equal(t, string(apperrors.CodeRequestNotPending), body["code"])
+
+	rec, _ = post(actionsConfirmPath, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, actionsPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestRequestActions(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, requestActions(&config.Config{ConfigDir: dir}, "req1"), "no links without action_link_url")
+
+	actions := requestActions(&config.Config{ConfigDir: dir, ActionLinkURL: "https://bob-nas.local:8081/"}, "req1")
+	require.Len(t, actions, 2)
+	assert.Equal(t, "Approve", actions[0].Label)
+	assert.Equal(t, "Deny", actions[1].Label)
+
+	prefix := "https://bob-nas.local:8081" + actionPagePath + "#token="
+	require.True(t, strings.HasPrefix(actions[1].URL, prefix), actions[1].URL)
+	claims, err := actionlink.NewStore(dir).Check(strings.TrimPrefix(actions[1].URL, prefix), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, actionlink.ActionDeny, claims.Action)
+	assert.Equal(t, "req1", claims.RequestID)
+}
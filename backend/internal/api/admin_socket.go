@@ -0,0 +1,101 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+// With an admin socket, the network listener serves only what peers and key
+// holders need: the storage, consent, and the calls a peer makes to this
+// node. Everything else (init, keys, settings, storage control) is served
+// on the socket alone, which only local users with access to its file can
+// reach, and without CORS.
+
+// peerServices are the Connect-RPC services served on the network listener
+var peerServices = []string{"HealthService", "RestoreRequestService", "DeletionService"}
+
+// peerPaths are the routes, relative to APIBasePath, served on the network
+// listener besides the open ones
+var peerPaths = []string{
+	approvalsPath, DelegationsPath, RecoveriesPath, OperationsPath,
+	SessionPath, SessionsPath,
+	hostPruneBeginPath, hostPruneEndPath, hostVaultPath, policyHistoryPath,
+	BackupWindowPath,
+}
+
+var errAdminOnly = apperrors.New(apperrors.CodeNotFound, "not served on this address; use the admin socket")
+
+// peerRoute reports whether path is served on the network listener while an
+// admin socket is in use
+func peerRoute(path string) bool {
+	if underPath(path, "/storage") {
+		return true
+	}
+	rel, ok := strings.CutPrefix(path, APIBasePath)
+	switch {
+	case ok:
+	case isLegacyRPCPath(path):
+		rel = path
+	case underPath(path, "/api"):
+		return false
+	default:
+		return true // The web UI's pages, including the approval page
+	}
+
+	if rpc, ok := strings.CutPrefix(rel, "/"+protoPackage+"."); ok {
+		service, _, _ := strings.Cut(rpc, "/")
+		return slices.Contains(peerServices, service)
+	}
+	if slices.Contains(openPaths, rel) || slices.Contains(openPostPaths, rel) {
+		return true
+	}
+	return slices.ContainsFunc(peerPaths, func(p string) bool { return underPath(rel, p) })
+}
+
+// peerOnly serves next's peer-facing routes, refusing the rest
+func peerOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !peerRoute(r.URL.Path) {
+			writeError(w, errAdminOnly)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ListenAdminSocket listens on the Unix socket at path, readable and
+// writable by this user only. A socket left behind by a server that is no
+// longer running is replaced.
+func ListenAdminSocket(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("admin socket %s: file exists and is not a socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("admin socket %s is in use by another server", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale admin socket: %w", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("admin socket %s: %w", path, err)
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on admin socket: %w", err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		_ = l.Close()
+		return nil, fmt.Errorf("failed to restrict admin socket: %w", err)
+	}
+	return l, nil
+}
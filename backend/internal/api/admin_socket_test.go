@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerRoute(t *testing.T) {
+	for _, path := range []string{
+		"/storage/alice/config",
+		APIBasePath + VersionPath,
+		APIBasePath + PanicPath,
+		APIBasePath + SessionLoginPath,
+		APIBasePath + approvalsPath + "/abc",
+		APIBasePath + hostPruneBeginPath,
+		APIBasePath + "/" + protoPackage + ".RestoreRequestService/ApproveRequest",
+		APIBasePath + "/" + protoPackage + ".HealthService/GetStatus",
+		"/" + protoPackage + ".RestoreRequestService/SignRequest",
+		"/",
+		"/assets/index.js",
+	} {
+		assert.True(t, peerRoute(path), path)
+	}
+
+	for _, path := range []string{
+		APIBasePath + UsersPath,
+		APIBasePath + RulesPath,
+		APIBasePath + browsePath,
+		APIBasePath + storageReposPath,
+		APIBasePath + "/" + protoPackage + ".ConfigService/UpdateSettings",
+		APIBasePath + "/" + protoPackage + ".KeyService/GenerateKeys",
+		"/" + protoPackage + ".VaultService/Init",
+		"/api/status",
+	} {
+		assert.False(t, peerRoute(path), path)
+	}
+}
+
+func TestPeerOnly(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	h := peerOnly(next)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, APIBasePath+UsersPath, nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), "admin socket")
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/storage/alice/config", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestListenAdminSocket(t *testing.T) {
+	// Unix socket paths are limited to around 100 bytes
+	dir, err := os.MkdirTemp("", "ag")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	path := filepath.Join(dir, "admin.sock")
+
+	l, err := ListenAdminSocket(path)
+	require.NoError(t, err)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("admin"))
+	})}
+	go func() { _ = srv.Serve(l) }()
+
+	_, err = ListenAdminSocket(path)
+	assert.ErrorContains(t, err, "in use")
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://airgapper/")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_ = srv.Close()
+
+	// A socket left behind by a crashed server is replaced
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	require.NoError(t, err)
+	stale.SetUnlinkOnClose(false)
+	_ = stale.Close()
+	l, err = ListenAdminSocket(path)
+	require.NoError(t, err)
+	_ = l.Close()
+
+	require.NoError(t, os.WriteFile(path, []byte("x"), 0600))
+	_, err = ListenAdminSocket(path)
+	assert.ErrorContains(t, err, "not a socket")
+}
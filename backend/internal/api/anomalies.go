@@ -0,0 +1,104 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/emergency"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+)
+
+// hostAnomalyInboxPath receives storage anomalies from the host
+// (relative to APIBasePath)
+const hostAnomalyInboxPath = "/host/anomalies/incoming"
+
+// hostAnomalyInboxHandler receives anomalies the peer's storage server
+// found in our backups, passing them on to our notification providers
+func hostAnomalyInboxHandler(cfg *config.Config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+
+		var anomalies []storage.Anomaly
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&anomalies); err != nil || len(anomalies) == 0 {
+			writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "invalid anomaly report"))
+			return
+		}
+
+		for _, a := range anomalies {
+			logging.Warn("Peer reported a storage anomaly",
+				logging.String("kind", a.Kind),
+				logging.String("repo", a.Repo),
+				logging.String("detail", a.Detail),
+				tracing.Field(r.Context()))
+		}
+		sendAnomalyNotification(r.Context(), cfg, "Backups changed unexpectedly on the host", anomalies)
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// peerAnomalyNotifier returns a callback that reports storage anomalies to
+// this host's notification providers and forwards them to the owner, who
+// may not be watching the host's logs
+func peerAnomalyNotifier(cfg *config.Config) func(context.Context, []storage.Anomaly) {
+	return func(ctx context.Context, anomalies []storage.Anomaly) {
+		sendAnomalyNotification(ctx, cfg, "Stored backups changed unexpectedly on "+cfg.Name, anomalies)
+
+		if cfg.Peer == nil || cfg.Peer.Address == "" {
+			return
+		}
+
+		body, err := json.Marshal(anomalies)
+		if err != nil {
+			return
+		}
+		go func() {
+			client := PeerClient(cfg, 10*time.Second)
+			url := strings.TrimSuffix(cfg.Peer.Address, "/") + APIBasePath + hostAnomalyInboxPath
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, bytes.NewReader(body))
+			if err != nil {
+				return
+			}
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := client.Do(req)
+			if err != nil {
+				logging.Warn("Could not report storage anomalies to peer", logging.Err(err))
+				return
+			}
+			_ = resp.Body.Close()
+		}()
+	}
+}
+
+// sendAnomalyNotification sends anomalies to the configured providers if
+// the storage_anomaly event is enabled
+func sendAnomalyNotification(ctx context.Context, cfg *config.Config, title string, anomalies []storage.Anomaly) {
+	notify := cfg.Emergency.GetNotify()
+	if !notify.IsEnabled() || !notify.Events.StorageAnomaly {
+		return
+	}
+
+	lines := make([]string, 0, len(anomalies))
+	for _, a := range anomalies {
+		lines = append(lines, a.String())
+	}
+	failed := notify.Send(ctx, emergency.Message{
+		Event:    "storage_anomaly",
+		Title:    title,
+		Body:     strings.Join(lines, "\n"),
+		Priority: "high",
+	})
+	for id, err := range failed {
+		logging.Warn("Failed to send notification", logging.String("provider", id), logging.Err(err))
+	}
+}
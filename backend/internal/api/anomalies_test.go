@@ -0,0 +1,28 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+)
+
+func TestHostAnomalyInboxHandler(t *testing.T) {
+	handler := hostAnomalyInboxHandler(&config.Config{Name: "alice"})
+
+	do := func(method, body string) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, hostAnomalyInboxPath, strings.NewReader(body)))
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusMethodNotAllowed, do(http.MethodGet, ""))
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "not json"))
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "[]"))
+	assert.Equal(t, http.StatusAccepted, do(http.MethodPost,
+		`[{"kind":"snapshots_missing","repo":"alice","detail":"1 snapshot files disappeared: 1111"}]`))
+}
@@ -0,0 +1,524 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/passkey"
+	"github.com/lcrostarosa/airgapper/backend/internal/service"
+)
+
+// The mobile approval page's endpoints (relative to APIBasePath). They
+// authenticate the key holder by passkey rather than by user account.
+const (
+	approvalsPath             = "/approvals"
+	approvalsEnrollPath       = "/approvals/enroll"
+	approvalsEnrollOptionPath = "/approvals/enroll/options"
+	approvalsLoginPath        = "/approvals/login"
+	approvalsLoginOptionPath  = "/approvals/login/options"
+	approvalsLogoutPath       = "/approvals/logout"
+	approvalsRequestsPath     = "/approvals/requests"
+)
+
+const (
+	// ApprovalCookie holds the session token of a passkey sign-in
+	ApprovalCookie = "airgapper_approval"
+	// approvalSessionTTL is how long a passkey sign-in lasts
+	approvalSessionTTL = 15 * time.Minute
+	// passkeyChallengeTTL is how long a passkey ceremony may take
+	passkeyChallengeTTL = 5 * time.Minute
+	// maxPasskeyChallenges caps the challenges outstanding at once, as
+	// anyone may ask for one
+	maxPasskeyChallenges = 256
+)
+
+// nodeApprover decides restore requests as this node's key holder
+type nodeApprover interface {
+	ListPendingRequests() ([]*consent.RestoreRequest, error)
+	ApproveAsNode(id string) (*service.ApprovalProgress, error)
+	DenyRequest(id string) error
+}
+
+// enrollBody is the body of POST /api/v1/approvals/enroll and its options
+type enrollBody struct {
+	Token      string                `json:"token"`
+	Credential *passkey.Registration `json:"credential,omitempty"`
+}
+
+// approvalLoginBody is the body of POST /api/v1/approvals/login
+type approvalLoginBody struct {
+	Credential passkey.Assertion `json:"credential"`
+}
+
+// pendingApproval is a pending request as the approval page shows it
+type pendingApproval struct {
+	ID               string                    `json:"id"`
+	Requester        string                    `json:"requester"`
+	SnapshotID       string                    `json:"snapshot_id"`
+	Paths            []string                  `json:"paths,omitempty"`
+	Reason           string                    `json:"reason"`
+	Purpose          string                    `json:"purpose,omitempty"`
+	Template         string                    `json:"template,omitempty"`
+	SizeBytes        int64                     `json:"size_bytes,omitempty"`
+	RequesterContext *consent.RequesterContext `json:"requester_context,omitempty"`
+	Approvals        int                       `json:"approvals"`
+	Required         int                       `json:"required_approvals,omitempty"`
+	CreatedAt        time.Time                 `json:"created_at"`
+	ExpiresAt        time.Time                 `json:"expires_at"`
+}
+
+// approvalSession is a passkey sign-in. Sessions live in memory, so a
+// restart signs everyone out.
+type approvalSession struct {
+	passkeyID string
+	name      string
+	csrfToken string
+	expiresAt time.Time
+}
+
+// approvalPage holds the passkey ceremonies in progress and the sign-ins
+// they led to
+type approvalPage struct {
+	cfg      *config.Config
+	store    *passkey.Store
+	approver nodeApprover
+	now      func() time.Time
+
+	mu         sync.Mutex
+	challenges map[string]time.Time // challenge -> expiry
+	sessions   map[string]*approvalSession
+}
+
+// approvalsHandler serves the mobile approval page's API:
+//
+//	GET  /api/v1/approvals                  the passkey sign-in, if any
+//	POST /api/v1/approvals/enroll/options   start registering a passkey with an enrollment token
+//	POST /api/v1/approvals/enroll           finish registering it
+//	POST /api/v1/approvals/login/options    start signing in with a passkey
+//	POST /api/v1/approvals/login            finish signing in
+//	POST /api/v1/approvals/logout           sign out
+//	GET  /api/v1/approvals/requests         pending restore requests
+//	POST /api/v1/approvals/requests/{id}/approve  approve as this node's key holder
+//	POST /api/v1/approvals/requests/{id}/deny     deny
+func approvalsHandler(cfg *config.Config, store *passkey.Store, approver nodeApprover) http.Handler {
+	p := &approvalPage{
+		cfg:        cfg,
+		store:      store,
+		approver:   approver,
+		now:        time.Now,
+		challenges: map[string]time.Time{},
+		sessions:   map[string]*approvalSession{},
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p.store == nil {
+			writeError(w, apperrors.New(apperrors.CodeFailedPrecondition, "this node has no config directory to keep passkeys in"))
+			return
+		}
+
+		path := r.URL.Path
+		switch {
+		case path == approvalsPath && (r.Method == http.MethodGet || r.Method == http.MethodHead):
+			s, err := p.session(r, false)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{
+				"passkey":    s.name,
+				"expires_at": s.expiresAt,
+			})
+		case path == approvalsEnrollOptionPath && r.Method == http.MethodPost:
+			p.enrollOptions(w, r)
+		case path == approvalsEnrollPath && r.Method == http.MethodPost:
+			p.enroll(w, r)
+		case path == approvalsLoginOptionPath && r.Method == http.MethodPost:
+			p.loginOptions(w, r)
+		case path == approvalsLoginPath && r.Method == http.MethodPost:
+			p.login(w, r)
+		case path == approvalsLogoutPath && r.Method == http.MethodPost:
+			if cookie, err := r.Cookie(ApprovalCookie); err == nil {
+				p.mu.Lock()
+				delete(p.sessions, cookie.Value)
+				p.mu.Unlock()
+			}
+			p.setCookie(w, r, "", -1)
+			w.WriteHeader(http.StatusNoContent)
+		case path == approvalsRequestsPath && (r.Method == http.MethodGet || r.Method == http.MethodHead):
+			if _, err := p.session(r, false); err != nil {
+				writeError(w, err)
+				return
+			}
+			p.listPending(w)
+		case strings.HasPrefix(path, approvalsRequestsPath+"/") && r.Method == http.MethodPost:
+			s, err := p.session(r, true)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			p.decide(w, r, s)
+		case path == approvalsPath, path == approvalsEnrollOptionPath, path == approvalsEnrollPath, path == approvalsLoginOptionPath,
+			path == approvalsLoginPath, path == approvalsLogoutPath, underPath(path, approvalsRequestsPath):
+			writeError(w, errMethodNotAllowed)
+		default:
+			writeError(w, apperrors.New(apperrors.CodeNotFound, "unknown approval route"))
+		}
+	})
+}
+
+// relyingParty returns the relying party of the page r came from, which
+// browsers name in the Origin header of every POST
+func relyingParty(r *http.Request) (passkey.RelyingParty, error) {
+	rp, err := passkey.NewRelyingParty(r.Header.Get("Origin"))
+	if err != nil {
+		return rp, apperrors.New(apperrors.CodeInvalidArgument, "passkeys must be used from the approval page (missing or invalid Origin header)")
+	}
+	return rp, nil
+}
+
+// issueChallenge returns a new one-time challenge for a ceremony
+func (p *approvalPage) issueChallenge() (string, error) {
+	challenge, err := passkey.NewChallenge()
+	if err != nil {
+		return "", err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	for c, expires := range p.challenges {
+		if !now.Before(expires) {
+			delete(p.challenges, c)
+		}
+	}
+	if len(p.challenges) >= maxPasskeyChallenges {
+		return "", apperrors.New(apperrors.CodeRateLimited, "too many passkey sign-ins in progress; try again shortly")
+	}
+	p.challenges[challenge] = now.Add(passkeyChallengeTTL)
+	return challenge, nil
+}
+
+// takeChallenge uses up the challenge a client's data answers, so it can't
+// be replayed
+func (p *approvalPage) takeChallenge(clientDataJSON string) (string, error) {
+	raw, err := passkey.Encoding.DecodeString(clientDataJSON)
+	if err != nil {
+		return "", apperrors.New(apperrors.CodeInvalidArgument, "invalid client data encoding")
+	}
+	var cd struct {
+		Challenge string `json:"challenge"`
+	}
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return "", apperrors.New(apperrors.CodeInvalidArgument, "invalid client data")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	expires, ok := p.challenges[cd.Challenge]
+	delete(p.challenges, cd.Challenge)
+	if !ok || !p.now().Before(expires) {
+		return "", apperrors.Coded(apperrors.CodeUnauthenticated, passkey.ErrChallenge)
+	}
+	return cd.Challenge, nil
+}
+
+func (p *approvalPage) enrollOptions(w http.ResponseWriter, r *http.Request) {
+	var body enrollBody
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&body); err != nil || body.Token == "" {
+		writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "token is required"))
+		return
+	}
+	rp, err := relyingParty(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := p.store.CheckEnrollment(body.Token, p.now()); err != nil {
+		writeError(w, err)
+		return
+	}
+	challenge, err := p.issueChallenge()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	params := make([]map[string]any, 0, len(passkey.Algorithms))
+	for _, alg := range passkey.Algorithms {
+		params = append(params, map[string]any{"type": "public-key", "alg": alg})
+	}
+	existing, err := p.store.List()
+	if err != nil {
+		writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+		return
+	}
+	exclude := make([]map[string]string, 0, len(existing))
+	for _, c := range existing {
+		exclude = append(exclude, map[string]string{"type": "public-key", "id": c.ID})
+	}
+	// The user handle only tells this node's passkeys apart from others'
+	// at the same site
+	writeJSON(w, http.StatusOK, map[string]any{
+		"challenge": challenge,
+		"rp":        map[string]string{"id": rp.ID, "name": "Airgapper (" + p.cfg.Name + ")"},
+		"user": map[string]string{
+			"id":          passkey.Encoding.EncodeToString([]byte("airgapper:" + p.cfg.Name)),
+			"name":        p.cfg.Name,
+			"displayName": p.cfg.Name + " key holder",
+		},
+		"pubKeyCredParams":       params,
+		"excludeCredentials":     exclude,
+		"authenticatorSelection": map[string]string{"residentKey": "preferred", "userVerification": "required"},
+		"attestation":            "none",
+		"timeout":                passkeyChallengeTTL.Milliseconds(),
+	})
+}
+
+func (p *approvalPage) enroll(w http.ResponseWriter, r *http.Request) {
+	var body enrollBody
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&body); err != nil || body.Token == "" || body.Credential == nil {
+		writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "token and credential are required"))
+		return
+	}
+	rp, err := relyingParty(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	challenge, err := p.takeChallenge(body.Credential.ClientDataJSON)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	cred, err := rp.Register(*body.Credential, challenge)
+	if err != nil {
+		writeError(w, apperrors.Coded(apperrors.CodeUnauthenticated, err))
+		return
+	}
+	if p.cfg.PublicKey != nil {
+		cred.KeyHolderID = crypto.KeyID(p.cfg.PublicKey)
+	}
+	if err := p.store.Add(body.Token, cred, p.now()); err != nil {
+		writeError(w, err)
+		return
+	}
+	logging.Info("Passkey registered for approvals",
+		logging.String("passkey", cred.Name),
+		logging.String("origin", cred.Origin))
+	writeJSON(w, http.StatusCreated, map[string]string{"id": cred.ID, "name": cred.Name})
+}
+
+func (p *approvalPage) loginOptions(w http.ResponseWriter, r *http.Request) {
+	rp, err := relyingParty(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	creds, err := p.store.List()
+	if err != nil {
+		writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+		return
+	}
+	allow := make([]map[string]string, 0, len(creds))
+	for _, c := range creds {
+		if c.Origin == rp.Origin {
+			allow = append(allow, map[string]string{"type": "public-key", "id": c.ID})
+		}
+	}
+	if len(allow) == 0 {
+		writeError(w, apperrors.Newf(apperrors.CodeFailedPrecondition, "no passkey is registered for %s; create an enrollment link with 'airgapper passkey enroll'", rp.Origin))
+		return
+	}
+	challenge, err := p.issueChallenge()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"challenge":        challenge,
+		"rpId":             rp.ID,
+		"allowCredentials": allow,
+		"userVerification": "required",
+		"timeout":          passkeyChallengeTTL.Milliseconds(),
+	})
+}
+
+func (p *approvalPage) login(w http.ResponseWriter, r *http.Request) {
+	var body approvalLoginBody
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&body); err != nil || body.Credential.ID == "" {
+		writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "credential is required"))
+		return
+	}
+	challenge, err := p.takeChallenge(body.Credential.ClientDataJSON)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	cred, err := p.store.Get(body.Credential.ID)
+	if err != nil {
+		writeError(w, apperrors.New(apperrors.CodeUnauthenticated, "unknown passkey"))
+		return
+	}
+	signCount, err := cred.Verify(body.Credential, challenge)
+	if err != nil {
+		logging.Warn("Passkey sign-in failed",
+			logging.String("passkey", cred.Name),
+			logging.String("addr", clientAddr(r)),
+			logging.Err(err))
+		writeError(w, apperrors.Coded(apperrors.CodeUnauthenticated, err))
+		return
+	}
+	now := p.now()
+	if err := p.store.Used(cred.ID, signCount, now); err != nil {
+		writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+		return
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+		return
+	}
+	csrf, err := randomToken()
+	if err != nil {
+		writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+		return
+	}
+	session := &approvalSession{passkeyID: cred.ID, name: cred.Name, csrfToken: csrf, expiresAt: now.Add(approvalSessionTTL)}
+	p.mu.Lock()
+	for t, s := range p.sessions {
+		if !now.Before(s.expiresAt) {
+			delete(p.sessions, t)
+		}
+	}
+	p.sessions[token] = session
+	p.mu.Unlock()
+
+	p.setCookie(w, r, token, int(approvalSessionTTL.Seconds()))
+	writeJSON(w, http.StatusOK, map[string]any{
+		"passkey":    cred.Name,
+		"csrf_token": csrf,
+		"expires_at": session.expiresAt,
+	})
+}
+
+// session returns the passkey sign-in r's cookie belongs to. Changes must
+// also carry the session's CSRF token.
+func (p *approvalPage) session(r *http.Request, change bool) (*approvalSession, error) {
+	cookie, err := r.Cookie(ApprovalCookie)
+	if err != nil {
+		return nil, apperrors.New(apperrors.CodeUnauthenticated, "sign in with your passkey first")
+	}
+	p.mu.Lock()
+	s, ok := p.sessions[cookie.Value]
+	if ok && !p.now().Before(s.expiresAt) {
+		delete(p.sessions, cookie.Value)
+		ok = false
+	}
+	p.mu.Unlock()
+	if !ok {
+		return nil, apperrors.New(apperrors.CodeUnauthenticated, "passkey sign-in has expired; sign in again")
+	}
+	// A removed passkey's sign-ins end with it
+	if _, err := p.store.Get(s.passkeyID); err != nil {
+		return nil, apperrors.New(apperrors.CodeUnauthenticated, "passkey is no longer registered")
+	}
+	if change && subtle.ConstantTimeCompare([]byte(r.Header.Get(CSRFHeader)), []byte(s.csrfToken)) != 1 {
+		return nil, apperrors.New(apperrors.CodePermissionDenied, "missing or wrong "+CSRFHeader+" header")
+	}
+	return s, nil
+}
+
+func (p *approvalPage) setCookie(w http.ResponseWriter, r *http.Request, token string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     ApprovalCookie,
+		Value:    token,
+		Path:     APIBasePath + approvalsPath,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+func (p *approvalPage) listPending(w http.ResponseWriter) {
+	pending, err := p.approver.ListPendingRequests()
+	if err != nil {
+		writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+		return
+	}
+	list := make([]pendingApproval, 0, len(pending))
+	for _, req := range pending {
+		list = append(list, toPendingApproval(req))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"requests": list})
+}
+
+// toPendingApproval returns req as the approval pages show it
+func toPendingApproval(req *consent.RestoreRequest) pendingApproval {
+	return pendingApproval{
+		ID:               req.ID,
+		Requester:        req.Requester,
+		SnapshotID:       req.SnapshotID,
+		Paths:            req.Paths,
+		Reason:           req.Reason,
+		Purpose:          req.Purpose,
+		Template:         req.Template,
+		SizeBytes:        req.SizeBytes,
+		RequesterContext: req.RequesterContext,
+		Approvals:        len(req.Approvals),
+		Required:         req.RequiredApprovals,
+		CreatedAt:        req.CreatedAt,
+		ExpiresAt:        req.ExpiresAt,
+	}
+}
+
+func (p *approvalPage) decide(w http.ResponseWriter, r *http.Request, s *approvalSession) {
+	rest := strings.TrimPrefix(r.URL.Path, approvalsRequestsPath+"/")
+	id, action, _ := strings.Cut(rest, "/")
+	switch action {
+	case "approve":
+		progress, err := p.approver.ApproveAsNode(id)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		logging.Info("Request approved with a passkey",
+			logging.String("request", id),
+			logging.String("passkey", s.name))
+		writeJSON(w, http.StatusOK, map[string]any{
+			"status":    "approved",
+			"approvals": progress.Current,
+			"required":  progress.Required,
+			"complete":  progress.IsApproved,
+		})
+	case "deny":
+		if err := p.approver.DenyRequest(id); err != nil {
+			writeError(w, err)
+			return
+		}
+		logging.Info("Request denied with a passkey",
+			logging.String("request", id),
+			logging.String("passkey", s.name))
+		writeJSON(w, http.StatusOK, map[string]string{"status": "denied"})
+	default:
+		writeError(w, apperrors.New(apperrors.CodeNotFound, "unknown approval route"))
+	}
+}
+
+// randomToken returns a random base64url token
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return passkey.Encoding.EncodeToString(b), nil
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	"github.com/lcrostarosa/airgapper/backend/internal/passkey"
+	"github.com/lcrostarosa/airgapper/backend/internal/service"
+)
+
+// fakeApprover records the decisions made through the approval page
+type fakeApprover struct {
+	approved, denied []string
+}
+
+func (f *fakeApprover) ListPendingRequests() ([]*consent.RestoreRequest, error) {
+	return []*consent.RestoreRequest{{ID: "req1", Requester: "alice", SnapshotID: "latest", Reason: "laptop died", RequiredApprovals: 2}}, nil
+}
+
+func (f *fakeApprover) ApproveAsNode(id string) (*service.ApprovalProgress, error) {
+	f.approved = append(f.approved, id)
+	return &service.ApprovalProgress{Current: 1, Required: 2}, nil
+}
+
+func (f *fakeApprover) DenyRequest(id string) error {
+	f.denied = append(f.denied, id)
+	return nil
+}
+
+// phonePasskey is a software Ed25519 passkey
+type phonePasskey struct {
+	id      []byte
+	pub     ed25519.PublicKey
+	priv    ed25519.PrivateKey
+	counter uint32
+}
+
+func newPhonePasskey(t *testing.T) *phonePasskey {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return &phonePasskey{id: []byte("phone-credential"), pub: pub, priv: priv}
+}
+
+func (p *phonePasskey) clientData(t *testing.T, typ, challenge, origin string) []byte {
+	data, err := json.Marshal(map[string]string{"type": typ, "challenge": challenge, "origin": origin})
+	require.NoError(t, err)
+	return data
+}
+
+// create answers a registration challenge, CBOR-encoding by hand
+func (p *phonePasskey) create(t *testing.T, rpID, origin, challenge string) passkey.Registration {
+	cose := append([]byte{0xa4, 0x01, 0x01, 0x03, 0x27, 0x20, 0x06, 0x21, 0x58, 0x20}, p.pub...)
+	rpHash := sha256.Sum256([]byte(rpID))
+	authData := append(rpHash[:], 0x45) // user present, verified, attested
+	authData = binary.BigEndian.AppendUint32(authData, p.counter)
+	authData = append(authData, make([]byte, 16)...)
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(p.id)))
+	authData = append(append(authData, p.id...), cose...)
+
+	att := []byte{0xa3, 0x63, 'f', 'm', 't', 0x64, 'n', 'o', 'n', 'e', 0x67, 'a', 't', 't', 'S', 't', 'm', 't', 0xa0, 0x68, 'a', 'u', 't', 'h', 'D', 'a', 't', 'a', 0x59}
+	att = binary.BigEndian.AppendUint16(att, uint16(len(authData)))
+	att = append(att, authData...)
+	return passkey.Registration{
+		ID:                passkey.Encoding.EncodeToString(p.id),
+		ClientDataJSON:    passkey.Encoding.EncodeToString(p.clientData(t, "webauthn.create", challenge, origin)),
+		AttestationObject: passkey.Encoding.EncodeToString(att),
+	}
+}
+
+func (p *phonePasskey) get(t *testing.T, rpID, origin, challenge string) passkey.Assertion {
+	p.counter++
+	rpHash := sha256.Sum256([]byte(rpID))
+	authData := binary.BigEndian.AppendUint32(append(rpHash[:], 0x05), p.counter)
+	cd := p.clientData(t, "webauthn.get", challenge, origin)
+	cdHash := sha256.Sum256(cd)
+	return passkey.Assertion{
+		ID:                passkey.Encoding.EncodeToString(p.id),
+		ClientDataJSON:    passkey.Encoding.EncodeToString(cd),
+		AuthenticatorData: passkey.Encoding.EncodeToString(authData),
+		Signature:         passkey.Encoding.EncodeToString(ed25519.Sign(p.priv, append(authData, cdHash[:]...))),
+	}
+}
+
+func TestApprovalsHandler(t *testing.T) {
+	const origin, rpID = "https://bob-nas.local:8082", "bob-nas.local"
+	dir := t.TempDir()
+	store := passkey.NewStore(dir)
+	approver := &fakeApprover{}
+	h := approvalsHandler(&config.Config{Name: "bob", ConfigDir: dir}, store, approver)
+
+	var cookie *http.Cookie
+	csrf := ""
+	do := func(method, path string, body any) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		req := httptest.NewRequest(method, path, strings.NewReader(string(data)))
+		req.Header.Set("Origin", origin)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		if csrf != "" {
+			req.Header.Set(CSRFHeader, csrf)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	challenge := func(rec *httptest.ResponseRecorder) string {
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var opts struct {
+			Challenge string `json:"challenge"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &opts))
+		return opts.Challenge
+	}
+
+	token, err := store.Enroll("bob's phone", time.Now())
+	require.NoError(t, err)
+	phone := newPhonePasskey(t)
+
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, approvalsEnrollOptionPath, enrollBody{Token: "guess"}).Code)
+	reg := phone.create(t, rpID, origin, challenge(do(http.MethodPost, approvalsEnrollOptionPath, enrollBody{Token: token})))
+	rec := do(http.MethodPost, approvalsEnrollPath, enrollBody{Token: token, Credential: &reg})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	cred, err := store.Get(passkey.Encoding.EncodeToString(phone.id))
+	require.NoError(t, err)
+	assert.Equal(t, "bob's phone", cred.Name)
+
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, approvalsRequestsPath, nil).Code, "signing in comes first")
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, approvalsPath, nil).Code)
+
+	assertion := phone.get(t, rpID, origin, challenge(do(http.MethodPost, approvalsLoginOptionPath, nil)))
+	rec = do(http.MethodPost, approvalsLoginPath, approvalLoginBody{Credential: assertion})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var login struct {
+		CSRFToken string `json:"csrf_token"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &login))
+	require.Len(t, rec.Result().Cookies(), 1)
+	cookie = rec.Result().Cookies()[0]
+	assert.Equal(t, APIBasePath+approvalsPath, cookie.Path)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, approvalsLoginPath, approvalLoginBody{Credential: assertion}).Code, "challenges are used once")
+
+	rec = do(http.MethodGet, approvalsPath, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"passkey":"bob's phone"`)
+
+	rec = do(http.MethodGet, approvalsRequestsPath, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var list struct {
+		Requests []pendingApproval `json:"requests"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Requests, 1)
+	assert.Equal(t, "laptop died", list.Requests[0].Reason)
+
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, approvalsRequestsPath+"/req1/approve", nil).Code, "decisions need the CSRF token")
+	csrf = login.CSRFToken
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, approvalsRequestsPath+"/req1/approve", nil).Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, approvalsRequestsPath+"/req2/deny", nil).Code)
+	assert.Equal(t, []string{"req1"}, approver.approved)
+	assert.Equal(t, []string{"req2"}, approver.denied)
+
+	// Removing the passkey ends its sign-ins
+	require.NoError(t, store.Remove(cred.ID))
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, approvalsRequestsPath, nil).Code)
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/auditlog"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/events"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+)
+
+// auditExportPath exports audit records (relative to APIBasePath)
+const auditExportPath = "/audit/export"
+
+// auditExportHandler serves:
+//
+//	GET /api/v1/audit/export?format=&log=&from=&to=   audit records as JSONL or CSV
+//
+// log is "storage", the storage server's audit log (the default on hosts),
+// or "events", the activity feed (the default otherwise). from and to are
+// RFC 3339 times or dates; a date as to includes that whole day.
+func auditExportHandler(srv *storage.Server, feed *events.Log) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+
+		q := r.URL.Query()
+		format := q.Get("format")
+		if format == "" {
+			format = auditlog.FormatJSONL
+		}
+		if !slices.Contains(auditlog.Formats, format) {
+			writeError(w, apperrors.Newf(apperrors.CodeInvalidArgument, "format must be one of %s", strings.Join(auditlog.Formats, ", ")))
+			return
+		}
+		from, err := parseExportTime(q.Get("from"), false)
+		if err != nil {
+			writeError(w, apperrors.Coded(apperrors.CodeInvalidArgument, err))
+			return
+		}
+		to, err := parseExportTime(q.Get("to"), true)
+		if err != nil {
+			writeError(w, apperrors.Coded(apperrors.CodeInvalidArgument, err))
+			return
+		}
+
+		log := q.Get("log")
+		if log == "" {
+			log = auditlog.LogEvents
+			if srv != nil {
+				log = auditlog.LogStorage
+			}
+		}
+		var records []auditlog.Record
+		switch log {
+		case auditlog.LogStorage:
+			if srv == nil {
+				writeError(w, apperrors.New(apperrors.CodeStorageNotConfigured, "storage server not configured"))
+				return
+			}
+			records = auditlog.StorageRecords(srv)
+		case auditlog.LogEvents:
+			if feed == nil {
+				writeError(w, apperrors.New(apperrors.CodeFailedPrecondition, "this node has no config directory to keep events in"))
+				return
+			}
+			if records, err = auditlog.EventRecords(feed); err != nil {
+				writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+				return
+			}
+		default:
+			writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "log must be storage or events"))
+			return
+		}
+		records = auditlog.Between(records, from, to)
+
+		w.Header().Set("Content-Type", auditlog.ContentType(format))
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="airgapper-%s-audit.%s"`, log, format))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodHead {
+			return
+		}
+		if err := auditlog.Write(w, format, records); err != nil {
+			logging.Warn("Audit export interrupted", logging.Err(err))
+		}
+	})
+}
+
+// parseExportTime parses an export bound. A date as the upper bound means
+// the end of that day.
+func parseExportTime(s string, upper bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: want RFC 3339 or YYYY-MM-DD", s)
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/events"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+)
+
+func TestAuditExportHandler(t *testing.T) {
+	feed := events.Open(t.TempDir())
+	yesterday := time.Now().UTC().AddDate(0, 0, -1)
+	feed.Publish(events.Event{Time: yesterday, Type: events.DeletionCreated, Source: events.SourceConsent, Subject: "del-1", Message: "old"})
+	feed.Publish(events.Event{Type: events.DeletionApproved, Source: events.SourceConsent, Subject: "del-1", Message: "new"})
+
+	get := func(h http.Handler, query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, auditExportPath+query, nil))
+		return rec
+	}
+
+	t.Run("owner exports the activity feed", func(t *testing.T) {
+		h := auditExportHandler(nil, feed)
+		rec := get(h, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+		assert.Contains(t, rec.Header().Get("Content-Disposition"), "airgapper-events-audit.jsonl")
+		assert.Len(t, strings.Split(strings.TrimSpace(rec.Body.String()), "\n"), 2)
+
+		rec = get(h, "?format=csv&from="+time.Now().UTC().Format(time.DateOnly))
+		require.Equal(t, http.StatusOK, rec.Code)
+		rows, err := csv.NewReader(rec.Body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, rows, 2, "header and today's event")
+		assert.Equal(t, "new", rows[1][5])
+
+		rec = get(h, "?to="+yesterday.Format(time.DateOnly))
+		assert.Len(t, strings.Split(strings.TrimSpace(rec.Body.String()), "\n"), 1, "to includes the whole day")
+
+		assert.Equal(t, http.StatusPreconditionFailed, get(h, "?log=storage").Code)
+	})
+
+	t.Run("host exports the storage audit log", func(t *testing.T) {
+		srv, err := storage.NewServer(storage.Config{BasePath: t.TempDir()})
+		require.NoError(t, err)
+		srv.Start()
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/alice/", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		rec = httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/alice/keys/k1", strings.NewReader("key")))
+		require.Equal(t, http.StatusOK, rec.Code)
+		rec = httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/alice/keys/k1", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		rec = get(auditExportHandler(srv, feed), "?format=csv")
+		require.Equal(t, http.StatusOK, rec.Code)
+		rows, err := csv.NewReader(rec.Body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+		assert.Equal(t, "storage", rows[1][1])
+		assert.Equal(t, "DELETE", rows[1][3])
+	})
+
+	t.Run("bad requests", func(t *testing.T) {
+		h := auditExportHandler(nil, feed)
+		assert.Equal(t, http.StatusBadRequest, get(h, "?format=xml").Code)
+		assert.Equal(t, http.StatusBadRequest, get(h, "?log=kernel").Code)
+		assert.Equal(t, http.StatusBadRequest, get(h, "?from=last-week").Code)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, auditExportPath, nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
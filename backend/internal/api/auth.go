@@ -0,0 +1,210 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/users"
+)
+
+// Once a node has user accounts, every API call must carry a user's API
+// token ("Authorization: Bearer <token>"), name and password (Basic) or
+// login session cookie, and the user's role must allow the call: viewers can read, approvers can also
+// decide requests, and admins can do anything. A few endpoints stay open
+// because they describe the API, authenticate their callers by key holder
+// signature or passkey, or only pass on a peer's notices.
+
+// openPaths are served to anyone whatever the method
+var openPaths = []string{VersionPath, CapabilitiesPath, openAPIPath, apiDocsPath}
+
+// openPostPaths accept POSTs from anyone: login, and the signed or
+// peer-facing endpoints
+var openPostPaths = []string{
+	SessionLoginPath,
+	PanicPath, PanicLiftPath, GenesisCountersignPath,
+	policyAmendmentInboxPath, hostAnomalyInboxPath, repairInboxPath,
+	ProofChallengePath, ShareCheckPath,
+	actionsPath, actionsConfirmPath,
+}
+
+// openRPCs are the Connect-RPC methods served to anyone: the liveness check,
+// and approval by a key holder's signature over a fresh challenge
+var openRPCs = []string{
+	"HealthService/Check",
+	"RestoreRequestService/IssueChallenge",
+	"RestoreRequestService/SignRequest",
+}
+
+// withAuth requires a user's credentials, and a role that allows the call,
+// for every call not open to anyone. While store has no accounts the API is
+// open, as it was before accounts existed.
+func withAuth(next http.Handler, store *users.Store) http.Handler {
+	if store == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		required, protected := requiredRole(r)
+		if !protected {
+			next.ServeHTTP(w, r)
+			return
+		}
+		enabled, err := store.Enabled()
+		if err != nil {
+			writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+			return
+		}
+		if !enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		c, err := authenticate(r, store)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="airgapper"`)
+			writeError(w, err)
+			return
+		}
+		// Browsers send the session cookie with any request to the node,
+		// so changes made with it must also carry the session's CSRF token
+		if c.session != nil && !safeMethod(r.Method) && !c.session.CheckCSRF(r.Header.Get(CSRFHeader)) {
+			writeError(w, apperrors.New(apperrors.CodePermissionDenied, "missing or wrong "+CSRFHeader+" header"))
+			return
+		}
+		if !c.user.Role.Allows(required) {
+			writeError(w, apperrors.Newf(apperrors.CodePermissionDenied, "user %s is a %s; this needs the %s role", c.user.Name, c.user.Role, required))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), callerKey{}, c)))
+	})
+}
+
+// caller is the authenticated user of a call, and their login session if
+// they used one
+type caller struct {
+	user    *users.User
+	session *users.Session
+}
+
+type callerKey struct{}
+
+// callerFrom returns the authenticated caller of a call, or nil if the
+// node has no user accounts
+func callerFrom(ctx context.Context) *caller {
+	c, _ := ctx.Value(callerKey{}).(*caller)
+	return c
+}
+
+// authenticate returns the caller whose token, password or session cookie
+// r carries
+func authenticate(r *http.Request, store *users.Store) (*caller, error) {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		u, err := store.AuthenticateToken(strings.TrimSpace(token))
+		if err != nil {
+			return nil, err
+		}
+		return &caller{user: u}, nil
+	}
+	if name, password, ok := r.BasicAuth(); ok {
+		u, err := store.AuthenticatePassword(name, password)
+		if err != nil {
+			return nil, err
+		}
+		return &caller{user: u}, nil
+	}
+	if cookie, err := r.Cookie(SessionCookie); err == nil {
+		session, u, err := store.AuthenticateSession(cookie.Value)
+		if err != nil {
+			return nil, err
+		}
+		return &caller{user: u, session: session}, nil
+	}
+	return nil, apperrors.ErrUnauthenticated
+}
+
+// safeMethod reports whether method only reads
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// requiredRole returns the role a call needs, or false if it is open to
+// anyone. Reads need a viewer, deciding restore, deletion and other
+// consent-gated requests, delegating approvals or using a social recovery
+// needs an approver, and everything else an admin.
+func requiredRole(r *http.Request) (users.Role, bool) {
+	if r.Method == http.MethodOptions {
+		return "", false
+	}
+
+	if rpc, ok := strings.CutPrefix(r.URL.Path, "/"+protoPackage+"."); ok {
+		if slices.Contains(openRPCs, rpc) {
+			return "", false
+		}
+		service, method, _ := strings.Cut(rpc, "/")
+		switch {
+		case strings.HasPrefix(method, "Get"), strings.HasPrefix(method, "List"),
+			method == "VerifyAuditChain", method == "ExportAuditChain":
+			return users.RoleViewer, true
+		case service == "RestoreRequestService", service == "DeletionService":
+			return users.RoleApprover, true
+		}
+		return users.RoleAdmin, true
+	}
+
+	path := r.URL.Path
+	switch {
+	case slices.Contains(openPaths, path):
+		return "", false
+	case underPath(path, approvalsPath):
+		// The approval page signs its key holder in with a passkey
+		return "", false
+	case underPath(path, RecoveriesPath):
+		return recoveryRole(r)
+	case underPath(path, UsersPath), underPath(path, notificationTargetsPath):
+		// Accounts, and targets' credentials, are for admins only
+		return users.RoleAdmin, true
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		return users.RoleViewer, true
+	case slices.Contains(openPostPaths, path):
+		return "", false
+	case underPath(path, SessionPath), underPath(path, SessionsPath):
+		// Anyone may end their own sessions; the handler checks others'
+		return users.RoleViewer, true
+	case underPath(path, DelegationsPath):
+		return users.RoleApprover, true
+	case underPath(path, OperationsPath) && (strings.HasSuffix(path, "/approve") || strings.HasSuffix(path, "/deny")):
+		return users.RoleApprover, true
+	}
+	return users.RoleAdmin, true
+}
+
+// recoveryRole returns the role a social recovery call needs. Contacts and
+// key holders sign what they do to a recovery, so need no account; listing
+// recoveries needs a viewer and using one an approver.
+func recoveryRole(r *http.Request) (users.Role, bool) {
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/use"):
+		return users.RoleApprover, true
+	case r.URL.Path == RecoveriesPath && safeMethod(r.Method):
+		return users.RoleViewer, true
+	}
+	return "", false
+}
+
+// underPath reports whether path is prefix or below it
+func underPath(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// userStore returns cfg's user accounts, or nil if it has no config
+// directory to keep them in
+func userStore(cfg *config.Config) *users.Store {
+	if cfg.ConfigDir == "" {
+		return nil
+	}
+	return users.NewStore(cfg.ConfigDir)
+}
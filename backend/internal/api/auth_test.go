@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/users"
+)
+
+func TestWithAuth(t *testing.T) {
+	store := users.NewStore(t.TempDir())
+	h := withAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), store)
+
+	do := func(method, path string, auth func(*http.Request)) int {
+		req := httptest.NewRequest(method, path, nil)
+		if auth != nil {
+			auth(req)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	bearer := func(token string) func(*http.Request) {
+		return func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) }
+	}
+	approveRPC := "/" + protoPackage + ".RestoreRequestService/ApproveRequest"
+
+	// Without accounts the API stays open
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, approveRPC, nil))
+
+	adminToken, err := store.Add("alice", users.RoleAdmin, "correct horse")
+	require.NoError(t, err)
+	viewerToken, err := store.Add("kid", users.RoleViewer, "")
+	require.NoError(t, err)
+	approverToken, err := store.Add("bob", users.RoleApprover, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, approveRPC, nil))
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, approveRPC, bearer("agt_wrong")))
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, approveRPC, bearer(viewerToken)))
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, approveRPC, bearer(approverToken)))
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, approveRPC, func(r *http.Request) { r.SetBasicAuth("alice", "correct horse") }))
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, approveRPC, func(r *http.Request) { r.SetBasicAuth("alice", "wrong horse") }))
+
+	// Viewers can read, only admins can change settings or manage users
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/"+protoPackage+".HealthService/GetStatus", bearer(viewerToken)))
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, templatesPath, bearer(viewerToken)))
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, eventsStreamPath, bearer(viewerToken)))
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, eventsPath, nil))
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, templatesPath, bearer(approverToken)))
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/"+protoPackage+".ScheduleService/UpdateSchedule", bearer(approverToken)))
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, UsersPath, bearer(approverToken)))
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, UsersPath, bearer(adminToken)))
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, notificationTargetsPath+"/ntfy-1", bearer(viewerToken)))
+
+	// Approvers decide operations; requesting one is for admins
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, OperationsPath+"/0123456789abcdef/approve", bearer(approverToken)))
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, OperationsPath+"/0123456789abcdef/deny", bearer(viewerToken)))
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, OperationsPath, bearer(approverToken)))
+
+	// Open endpoints need no credentials
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, VersionPath, nil))
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/"+protoPackage+".RestoreRequestService/SignRequest", nil))
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, PanicPath, nil))
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, approvalsRequestsPath, nil), "the approval page checks passkeys itself")
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, PanicPath, nil), "reading the lockdown needs a viewer")
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, RecoveriesPath+"/rcv-1/sign", nil), "recovery contacts sign instead")
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, RecoveriesPath, nil))
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, RecoveriesPath+"/rcv-1/use", bearer(viewerToken)))
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/backupreport"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+// BackupWindowPath is the owner's backup window endpoint (relative to
+// APIBasePath), which its host reads to anticipate its uplink being busy
+const BackupWindowPath = "/backup-window"
+
+// backupWindowStarts is how many upcoming backup starts are listed
+const backupWindowStarts = 5
+
+// backupWindowHandler serves GET /api/v1/backup-window: the owner's backup
+// schedule, its next start times, and how long and how much recent
+// scheduled backups took. An owner with private_backup_window set serves
+// only that it is private.
+func backupWindowHandler(cfg *config.Config, reports *backupreport.Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+		if !cfg.IsOwner() {
+			writeError(w, apperrors.New(apperrors.CodeNotFound, "only an owner has a backup window"))
+			return
+		}
+		window, err := backupWindow(cfg, reports, time.Now())
+		if err != nil {
+			writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+			return
+		}
+		writeJSON(w, http.StatusOK, window)
+	})
+}
+
+// backupWindow returns cfg's backup window as of now
+func backupWindow(cfg *config.Config, reports *backupreport.Store, now time.Time) (*backupreport.Window, error) {
+	if cfg.PrivateBackupWindow {
+		return &backupreport.Window{Private: true}, nil
+	}
+	list, err := reports.List(0)
+	if err != nil {
+		return nil, err
+	}
+	window := &backupreport.Window{}
+	window.Average(list)
+	if cfg.BackupSchedule == "" {
+		return window, nil
+	}
+
+	window.Schedule, window.Timezone = cfg.BackupSchedule, cfg.ScheduleTimezone()
+	sched, err := cfg.Schedule()
+	if err != nil {
+		return nil, err
+	}
+	next := sched.NextDue(now, backupreport.LastScheduled(list))
+	for range backupWindowStarts {
+		window.NextStarts = append(window.NextStarts, next)
+		next = sched.NextRun(next)
+	}
+	return window, nil
+}
+
+// PeerBackupWindow asks the owner at addr for its backup window, using do
+// to send the request
+func PeerBackupWindow(ctx context.Context, addr string, do func(*http.Request) (*http.Response, error)) (*backupreport.Window, error) {
+	var window backupreport.Window
+	found, err := getPeerJSON(ctx, strings.TrimSuffix(addr, "/")+APIBasePath+BackupWindowPath, do, &window)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, apperrors.New(apperrors.CodeNotFound, "the peer didn't serve its backup window")
+	}
+	return &window, nil
+}
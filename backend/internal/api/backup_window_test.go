@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/backupreport"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+)
+
+func TestBackupWindowHandler(t *testing.T) {
+	cfg := &config.Config{Role: config.RoleOwner, ConfigDir: t.TempDir(), BackupSchedule: "0 2 * * *", BackupTimezone: "UTC"}
+	reports := backupreport.NewStore(cfg.BackupReportsPath())
+	started := time.Now().Add(-time.Hour)
+	require.NoError(t, reports.Add(&backupreport.Report{Scheduled: true, StartedAt: started, FinishedAt: started.Add(time.Minute), BytesAdded: 2048}))
+	h := backupWindowHandler(cfg, reports)
+
+	get := func() (*httptest.ResponseRecorder, backupreport.Window) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, BackupWindowPath, nil))
+		var w backupreport.Window
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &w))
+		}
+		return rec, w
+	}
+
+	rec, w := get()
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "0 2 * * *", w.Schedule)
+	assert.Equal(t, "UTC", w.Timezone)
+	require.Len(t, w.NextStarts, backupWindowStarts)
+	assert.Equal(t, 2, w.NextStarts[0].UTC().Hour())
+	assert.Equal(t, 24*time.Hour, w.NextStarts[1].Sub(w.NextStarts[0]))
+	assert.Equal(t, 1, w.Runs)
+	assert.Equal(t, int64(60), w.AvgDurationSeconds)
+	assert.Equal(t, int64(2048), w.AvgUploadBytes)
+
+	// Opting out hides everything but that
+	cfg.PrivateBackupWindow = true
+	rec, w = get()
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, backupreport.Window{Private: true}, w)
+	assert.NotContains(t, rec.Body.String(), "schedule")
+
+	cfg.Role = config.RoleHost
+	rec, _ = get()
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestPeerBackupWindow(t *testing.T) {
+	cfg := &config.Config{Role: config.RoleOwner, ConfigDir: t.TempDir()}
+	owner := httptest.NewServer(http.StripPrefix(APIBasePath, backupWindowHandler(cfg, backupreport.NewStore(cfg.BackupReportsPath()))))
+	defer owner.Close()
+
+	w, err := PeerBackupWindow(t.Context(), owner.URL, http.DefaultClient.Do)
+	require.NoError(t, err)
+	assert.Empty(t, w.Schedule, "no schedule configured")
+
+	cfg.Role = config.RoleHost
+	_, err = PeerBackupWindow(t.Context(), owner.URL, http.DefaultClient.Do)
+	assert.Error(t, err)
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/backupreport"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+// backupsPath is the prefix of backup report endpoints (relative to APIBasePath)
+const backupsPath = "/backups"
+
+// backupReportsHandler serves:
+//
+//	GET /api/v1/backups?limit=N         reports of recent backup runs, newest first
+//	GET /api/v1/backups/{id}/report     the report of the backup that made snapshot id
+func backupReportsHandler(store *backupreport.Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+
+		rest := strings.Trim(strings.TrimPrefix(r.URL.Path, backupsPath), "/")
+		if rest == "" {
+			limit := 0
+			if v := r.URL.Query().Get("limit"); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n < 0 {
+					writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "limit must be a non-negative integer"))
+					return
+				}
+				limit = n
+			}
+			reports, err := store.List(limit)
+			if err != nil {
+				writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{"reports": reports})
+			return
+		}
+
+		id, action, _ := strings.Cut(rest, "/")
+		if action != "report" {
+			writeError(w, apperrors.New(apperrors.CodeNotFound, "unknown backup endpoint"))
+			return
+		}
+		id = strings.ToLower(id)
+		if !snapshotIDPattern.MatchString(id) {
+			writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "snapshot IDs must be 8-64 hex characters"))
+			return
+		}
+
+		report, err := store.Get(id)
+		if err != nil {
+			writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+			return
+		}
+		writeJSON(w, http.StatusOK, report)
+	})
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/backupreport"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+func TestBackupReportsHandler(t *testing.T) {
+	store := backupreport.NewStore(filepath.Join(t.TempDir(), "backup-reports.json"))
+	require.NoError(t, store.Add(&backupreport.Report{SnapshotID: "aaaa1111aaaa1111", FilesNew: 3}))
+	require.NoError(t, store.Add(&backupreport.Report{SnapshotID: "bbbb2222bbbb2222", FilesNew: 1}))
+	h := backupReportsHandler(store)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	t.Run("list", func(t *testing.T) {
+		rec := get("/backups?limit=1")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var body struct {
+			Reports []backupreport.Report `json:"reports"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		require.Len(t, body.Reports, 1)
+		assert.Equal(t, "bbbb2222bbbb2222", body.Reports[0].SnapshotID)
+	})
+
+	t.Run("report by short ID", func(t *testing.T) {
+		rec := get("/backups/AAAA1111/report")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var report backupreport.Report
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+		assert.Equal(t, 3, report.FilesNew)
+	})
+
+	t.Run("unknown snapshot", func(t *testing.T) {
+		rec := get("/backups/cccc3333/report")
+		require.Equal(t, http.StatusNotFound, rec.Code)
+		var body errorBody
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, apperrors.CodeBackupReportNotFound, body.Code)
+	})
+
+	for name, tc := range map[string]struct {
+		path string
+		want int
+	}{
+		"bad limit":        {"/backups?limit=x", http.StatusBadRequest},
+		"bad ID":           {"/backups/--all/report", http.StatusBadRequest},
+		"unknown endpoint": {"/backups/aaaa1111/files", http.StatusNotFound},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, get(tc.path).Code)
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/backups", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+// IsLoopbackAddr reports whether a listen address only accepts local
+// connections. An empty host (":8081") listens on all interfaces.
+func IsLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	host = strings.Trim(host, "[]")
+
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// CheckBindSafety refuses to expose the API on a non-loopback address unless
+// TLS is configured or the caller explicitly opted in with insecure.
+// Without user accounts the API is unauthenticated, and with them plaintext
+// would expose their passwords and tokens, so either way exposure would let
+// anyone on the network approve restores and read vault metadata.
+func CheckBindSafety(addr string, tlsEnabled, insecure bool) error {
+	if IsLoopbackAddr(addr) || tlsEnabled || insecure {
+		return nil
+	}
+	return fmt.Errorf("%w: %s (configure --tls-cert/--tls-key, bind to 127.0.0.1, or pass --insecure)",
+		apperrors.ErrInsecureBind, addr)
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+func TestIsLoopbackAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"127.0.0.1:8081", true},
+		{"localhost:8081", true},
+		{"[::1]:8081", true},
+		{"127.0.0.2:8081", true},
+		{":8081", false},
+		{"0.0.0.0:8081", false},
+		{"192.168.1.10:8081", false},
+		{"[::]:8081", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsLoopbackAddr(tt.addr))
+		})
+	}
+}
+
+func TestCheckBindSafety(t *testing.T) {
+	assert.NoError(t, CheckBindSafety("127.0.0.1:8081", false, false))
+	assert.NoError(t, CheckBindSafety("0.0.0.0:8081", true, false))
+	assert.NoError(t, CheckBindSafety("0.0.0.0:8081", false, true))
+	assert.ErrorIs(t, CheckBindSafety(":8081", false, false), apperrors.ErrInsecureBind)
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+// CapabilitiesPath lists what this node supports (relative to APIBasePath)
+const CapabilitiesPath = "/capabilities"
+
+// ProtocolVersion is the version of the protocol peers speak to each other.
+// It changes when a call's meaning does; new calls are announced as
+// features instead.
+const ProtocolVersion = 1
+
+// Request types a node can take from its peer
+const (
+	RequestTypeRestore   = "restore"
+	RequestTypeDeletion  = "deletion"
+	RequestTypeAmendment = "policy_amendment"
+)
+
+// Crypto suites a node can use with its peer
+const (
+	CryptoEd25519   = "ed25519"      // Signatures and key IDs
+	CryptoShamir    = "shamir-gf256" // Splitting the repository password
+	CryptoAES256GCM = "aes-256-gcm"  // Sealed files and sync bundles
+)
+
+// Optional features, each a set of calls a peer can make
+const (
+	FeatureConsensus     = "consensus"       // Key holder approvals instead of shares
+	FeatureDeletion      = "deletion"        // Deletion requests and prune windows
+	FeaturePolicies      = "policies"        // Policy amendments and their history
+	FeatureExtensions    = "extensions"      // Extending a request's expiry
+	FeatureVeto          = "veto"            // Vetoing a request
+	FeatureDelegation    = "delegation"      // Delegating approval authority
+	FeatureRecovery      = "social_recovery" // Recovery contacts
+	FeatureShareCheck    = "share_check"     // Checking a key share without releasing it
+	FeatureStorageProofs = "storage_proofs"  // Proof-of-storage challenges
+	FeaturePanic         = "panic"           // Spreading a lockdown
+	FeatureGenesis       = "genesis"         // Countersigning the genesis record
+	FeatureAnomalies     = "anomaly_reports"
+	FeatureRepairs       = "repair_reports"
+	FeatureBackupWindow  = "backup_window" // The owner's backup schedule and upload sizes
+)
+
+// featureNames describe features in errors
+var featureNames = map[string]string{
+	FeatureConsensus:     "consensus mode",
+	FeatureDeletion:      "deletion requests",
+	FeaturePolicies:      "policy amendments",
+	FeatureExtensions:    "request extensions",
+	FeatureVeto:          "vetoes",
+	FeatureDelegation:    "delegations",
+	FeatureRecovery:      "social recovery",
+	FeatureShareCheck:    "share checks",
+	FeatureStorageProofs: "storage proofs",
+	FeaturePanic:         "the panic button",
+	FeatureGenesis:       "genesis countersigning",
+	FeatureAnomalies:     "anomaly reports",
+	FeatureRepairs:       "repair reports",
+	FeatureBackupWindow:  "backup window reporting",
+}
+
+// Capabilities is returned by GET /api/v1/capabilities, so a peer can check
+// a call is supported before making it
+type Capabilities struct {
+	ProtocolVersion int      `json:"protocolVersion"`
+	ServerVersion   string   `json:"serverVersion"`
+	APIVersions     []string `json:"apiVersions"`
+	RequestTypes    []string `json:"requestTypes"`
+	CryptoSuites    []string `json:"cryptoSuites"`
+	Features        []string `json:"features"`
+}
+
+// currentCapabilities lists what this server supports
+func currentCapabilities(serverVersion string) Capabilities {
+	if serverVersion == "" {
+		serverVersion = "dev"
+	}
+	return Capabilities{
+		ProtocolVersion: ProtocolVersion,
+		ServerVersion:   serverVersion,
+		APIVersions:     SupportedAPIVersions,
+		RequestTypes:    []string{RequestTypeRestore, RequestTypeDeletion, RequestTypeAmendment},
+		CryptoSuites:    []string{CryptoEd25519, CryptoShamir, CryptoAES256GCM},
+		Features: []string{
+			FeatureConsensus, FeatureDeletion, FeaturePolicies, FeatureExtensions, FeatureVeto,
+			FeatureDelegation, FeatureRecovery, FeatureShareCheck, FeatureStorageProofs,
+			FeaturePanic, FeatureGenesis, FeatureAnomalies, FeatureRepairs, FeatureBackupWindow,
+		},
+	}
+}
+
+// capabilitiesHandler serves the capabilities document
+func capabilitiesHandler(serverVersion string) http.Handler {
+	caps := currentCapabilities(serverVersion)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, caps)
+	})
+}
+
+// Announced reports whether the node announced its capabilities. Nodes from
+// before GET /capabilities existed didn't, so what they support is unknown.
+func (c *Capabilities) Announced() bool {
+	return c.ProtocolVersion > 0
+}
+
+// Supports reports whether the node has feature
+func (c *Capabilities) Supports(feature string) bool {
+	return slices.Contains(c.Features, feature)
+}
+
+// Require returns an error naming the peer's version if it announced its
+// capabilities without feature
+func (c *Capabilities) Require(feature string) error {
+	if !c.Announced() || c.Supports(feature) {
+		return nil
+	}
+	return Unsupported(c.ServerVersion, feature)
+}
+
+// Unsupported is the error for a peer on version that lacks feature
+func Unsupported(version, feature string) error {
+	what := featureNames[feature]
+	if what == "" {
+		what = feature
+	}
+	if version == "" {
+		return apperrors.Newf(apperrors.CodePeerUnsupported, "peer is on an older version and doesn't support %s; upgrade it", what)
+	}
+	return apperrors.Newf(apperrors.CodePeerUnsupported, "peer is on %s and doesn't support %s; upgrade it", version, what)
+}
+
+// PeerCapabilities asks the peer at addr what it supports, using do to send
+// the requests. A peer from before capabilities were announced yields
+// unannounced capabilities carrying, if it reports one, its version.
+func PeerCapabilities(ctx context.Context, addr string, do func(*http.Request) (*http.Response, error)) (*Capabilities, error) {
+	base := strings.TrimSuffix(addr, "/") + APIBasePath
+	var caps Capabilities
+	found, err := getPeerJSON(ctx, base+CapabilitiesPath, do, &caps)
+	if err != nil {
+		return nil, err
+	}
+	if found && caps.Announced() {
+		return &caps, nil
+	}
+
+	var info VersionInfo
+	if _, err := getPeerJSON(ctx, base+VersionPath, do, &info); err != nil {
+		return nil, err
+	}
+	return &Capabilities{ServerVersion: info.ServerVersion}, nil
+}
+
+// getPeerJSON decodes the JSON at url into v, reporting false if the peer
+// didn't serve it
+func getPeerJSON(ctx context.Context, url string, do func(*http.Request) (*http.Response, error), v any) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := do(req)
+	if err != nil {
+		return false, fmt.Errorf("could not reach peer: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, nil
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v) == nil, nil
+}
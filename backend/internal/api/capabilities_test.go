@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+func TestCapabilitiesHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	capabilitiesHandler("1.4.0").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, CapabilitiesPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var caps Capabilities
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &caps))
+	assert.Equal(t, ProtocolVersion, caps.ProtocolVersion)
+	assert.Equal(t, "1.4.0", caps.ServerVersion)
+	assert.Contains(t, caps.RequestTypes, RequestTypeDeletion)
+	assert.Contains(t, caps.CryptoSuites, CryptoEd25519)
+	for feature := range featureNames {
+		assert.True(t, caps.Supports(feature), feature)
+	}
+
+	rec = httptest.NewRecorder()
+	capabilitiesHandler("").ServeHTTP(rec, httptest.NewRequest(http.MethodPost, CapabilitiesPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestPeerCapabilities(t *testing.T) {
+	ctx := context.Background()
+
+	current := http.NewServeMux()
+	current.Handle(APIBasePath+CapabilitiesPath, capabilitiesHandler("1.4.0"))
+	peer := httptest.NewServer(current)
+	defer peer.Close()
+
+	caps, err := PeerCapabilities(ctx, peer.URL+"/", http.DefaultClient.Do)
+	require.NoError(t, err)
+	assert.True(t, caps.Announced())
+	assert.NoError(t, caps.Require(FeatureDeletion))
+
+	// A peer from before capabilities were announced
+	older := http.NewServeMux()
+	older.Handle(APIBasePath+VersionPath, versionHandler("0.2.3"))
+	oldPeer := httptest.NewServer(older)
+	defer oldPeer.Close()
+
+	caps, err = PeerCapabilities(ctx, oldPeer.URL, http.DefaultClient.Do)
+	require.NoError(t, err)
+	assert.False(t, caps.Announced())
+	assert.Equal(t, "0.2.3", caps.ServerVersion)
+	assert.NoError(t, caps.Require(FeatureDeletion), "unknown, so left to the call")
+
+	oldPeer.Close()
+	_, err = PeerCapabilities(ctx, oldPeer.URL, http.DefaultClient.Do)
+	assert.ErrorContains(t, err, "could not reach peer")
+}
+
+func TestCapabilitiesRequire(t *testing.T) {
+	caps := &Capabilities{ProtocolVersion: ProtocolVersion, ServerVersion: "0.9.1", Features: []string{FeatureConsensus}}
+	assert.NoError(t, caps.Require(FeatureConsensus))
+
+	err := caps.Require(FeatureDeletion)
+	assert.Equal(t, apperrors.CodePeerUnsupported, apperrors.CodeOf(err))
+	assert.ErrorContains(t, err, "peer is on 0.9.1 and doesn't support deletion requests")
+
+	assert.ErrorContains(t, Unsupported("", FeatureVeto), "peer is on an older version and doesn't support vetoes")
+}
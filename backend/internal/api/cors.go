@@ -0,0 +1,84 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/grpc"
+	"github.com/lcrostarosa/airgapper/backend/internal/peerpin"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+)
+
+// CORS settings. Connect clients send Connect-* headers and read the Connect
+// trailers/headers from the response, so those must be allowed and exposed.
+var (
+	corsAllowMethods = strings.Join([]string{http.MethodGet, http.MethodPost, http.MethodOptions}, ", ")
+	corsAllowHeaders = strings.Join([]string{
+		"Content-Type", "Authorization",
+		"Connect-Protocol-Version", "Connect-Timeout-Ms",
+		APIVersionHeader, tracing.Header, CSRFHeader,
+	}, ", ")
+	corsExposeHeaders = strings.Join([]string{
+		APIVersionHeader, tracing.Header, grpc.ErrorCodeHeader, peerpin.KeyHeader, "Deprecation", "Sunset", "Link",
+	}, ", ")
+)
+
+// corsMaxAge is how long (seconds) browsers may cache a preflight response
+const corsMaxAge = "600"
+
+// withCORS adds CORS headers for requests from allowed origins. With no
+// allowed origins configured, cross-origin requests get no CORS headers and
+// browsers enforce same-origin. "*" allows any origin, but only origins
+// listed by name may send the login session cookie.
+func withCORS(next http.Handler, allowedOrigins []string) http.Handler {
+	if len(allowedOrigins) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		allowed := originAllowed(origin, allowedOrigins)
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		if !allowed {
+			if preflight {
+				http.Error(w, "Origin not allowed", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		if !slices.Contains(allowedOrigins, "*") {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+		w.Header().Set("Access-Control-Expose-Headers", corsExposeHeaders)
+
+		if preflight {
+			w.Header().Set("Access-Control-Allow-Methods", corsAllowMethods)
+			w.Header().Set("Access-Control-Allow-Headers", corsAllowHeaders)
+			w.Header().Set("Access-Control-Max-Age", corsMaxAge)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// originAllowed reports whether origin matches an allowed origin exactly
+// (scheme, host and port) or the allowlist contains "*"
+func originAllowed(origin string, allowedOrigins []string) bool {
+	origin = strings.TrimSuffix(origin, "/")
+	return slices.ContainsFunc(allowedOrigins, func(allowed string) bool {
+		return allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin)
+	})
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithCORS(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name        string
+		allowed     []string
+		method      string
+		origin      string
+		preflight   bool
+		wantStatus  int
+		wantAllowed string
+	}{
+		{"no origins configured", nil, http.MethodPost, "http://evil.example", false, http.StatusOK, ""},
+		{"same-origin request", []string{"http://localhost:5173"}, http.MethodPost, "", false, http.StatusOK, ""},
+		{"allowed origin", []string{"http://localhost:5173"}, http.MethodPost, "http://localhost:5173", false, http.StatusOK, "http://localhost:5173"},
+		{"allowed with trailing slash", []string{"http://localhost:5173/"}, http.MethodPost, "http://localhost:5173", false, http.StatusOK, "http://localhost:5173"},
+		{"disallowed origin", []string{"http://localhost:5173"}, http.MethodPost, "http://evil.example", false, http.StatusOK, ""},
+		{"wildcard", []string{"*"}, http.MethodPost, "http://any.example", false, http.StatusOK, "http://any.example"},
+		{"allowed preflight", []string{"http://localhost:5173"}, http.MethodOptions, "http://localhost:5173", true, http.StatusNoContent, "http://localhost:5173"},
+		{"disallowed preflight", []string{"http://localhost:5173"}, http.MethodOptions, "http://evil.example", true, http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/v1/version", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+			rec := httptest.NewRecorder()
+
+			withCORS(next, tt.allowed).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantAllowed, rec.Header().Get("Access-Control-Allow-Origin"))
+			if tt.preflight && tt.wantStatus == http.StatusNoContent {
+				assert.Contains(t, rec.Header().Get("Access-Control-Allow-Headers"), "Connect-Protocol-Version")
+			}
+		})
+	}
+}
@@ -0,0 +1,401 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/backupreport"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/integrity"
+	"github.com/lcrostarosa/airgapper/backend/internal/lockdown"
+	"github.com/lcrostarosa/airgapper/backend/internal/scheduler"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+)
+
+// dashboardPath serves the dashboard's aggregated data (relative to APIBasePath)
+const dashboardPath = "/dashboard"
+
+const (
+	// dashboardTrendPoints is how many backups the size trend covers
+	dashboardTrendPoints = 30
+	// peerProbeTimeout bounds each peer's reachability check, so an
+	// unreachable peer doesn't stall the dashboard
+	peerProbeTimeout = 3 * time.Second
+	// storageQuotaWarnPct is the quota usage storage is reported unhealthy at
+	storageQuotaWarnPct = 90
+)
+
+// Backup results
+const (
+	backupSucceeded = "success"
+	backupPartial   = "partial"
+	backupFailed    = "failed"
+)
+
+// dashboardResponse is the body of GET /api/v1/dashboard
+type dashboardResponse struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	Name        string    `json:"name"`
+	Role        string    `json:"role"`
+	LockedDown  bool      `json:"locked_down"`
+
+	LastBackup *dashboardBackup `json:"last_backup,omitempty"` // nil before the first backup
+	NextBackup *time.Time       `json:"next_backup,omitempty"` // nil without a running schedule
+	Schedule   string           `json:"schedule,omitempty"`
+	// Timezone is the schedule's IANA timezone, "Local" for the system's
+	Timezone string `json:"timezone,omitempty"`
+	// SizeTrend is the size of recent backups and what each added to the
+	// repository, oldest first
+	SizeTrend []dashboardSizePoint `json:"size_trend"`
+	// Dedup is how much deduplication and compression saved and when the
+	// quota fills; nil before the first backup
+	Dedup *dashboardDedup `json:"dedup,omitempty"`
+
+	PendingApprovals int `json:"pending_approvals"`
+
+	Storage   *dashboardStorage  `json:"storage,omitempty"` // nil on nodes without storage
+	Peers     []dashboardPeer    `json:"peers"`
+	Integrity dashboardIntegrity `json:"integrity"`
+}
+
+// dashboardBackup is the outcome of the last backup run
+type dashboardBackup struct {
+	At         time.Time `json:"at"`
+	Result     string    `json:"result"` // success, partial or failed
+	SnapshotID string    `json:"snapshot_id,omitempty"`
+	Scheduled  bool      `json:"scheduled"`
+	Error      string    `json:"error,omitempty"`
+	// ConsecutiveFailures counts scheduled runs failed in a row
+	ConsecutiveFailures int `json:"consecutive_failures,omitempty"`
+}
+
+// dashboardSizePoint is one backup's size
+type dashboardSizePoint struct {
+	At         time.Time `json:"at"`
+	SnapshotID string    `json:"snapshot_id"`
+	TotalBytes int64     `json:"total_bytes"`
+	BytesAdded int64     `json:"bytes_added"`
+}
+
+// dashboardDedup is the dedup stats without their points
+type dashboardDedup struct {
+	DedupRatio       float64                  `json:"dedup_ratio"`
+	CompressionRatio float64                  `json:"compression_ratio"`
+	SavingsPct       float64                  `json:"savings_pct"`
+	Projection       *backupreport.Projection `json:"projection,omitempty"`
+}
+
+// dashboardStorage is the health of the storage this node hosts
+type dashboardStorage struct {
+	Healthy       bool     `json:"healthy"`
+	Problems      []string `json:"problems,omitempty"`
+	Running       bool     `json:"running"`
+	ReadOnly      bool     `json:"read_only"`
+	UsedBytes     int64    `json:"used_bytes"`
+	QuotaUsagePct int      `json:"quota_usage_pct,omitempty"`
+	DiskUsagePct  int      `json:"disk_usage_pct"`
+	DiskFreeBytes int64    `json:"disk_free_bytes"`
+}
+
+// dashboardPeer is whether a peer's API answered
+type dashboardPeer struct {
+	Name       string `json:"name"`
+	Address    string `json:"address"`
+	Reachable  bool   `json:"reachable"`
+	LatencyMs  int64  `json:"latency_ms,omitempty"`
+	APIVersion string `json:"api_version,omitempty"`
+	Error      string `json:"error,omitempty"`
+	// IdentityChanged is set while the configured peer presents a different
+	// identity than the one pinned
+	IdentityChanged bool `json:"identity_changed,omitempty"`
+}
+
+// dashboardIntegrity is the outcome of the last integrity check and
+// restore test
+type dashboardIntegrity struct {
+	Healthy         bool                  `json:"healthy"`
+	LastCheck       *dashboardCheck       `json:"last_check,omitempty"`
+	LastRestoreTest *dashboardRestoreTest `json:"last_restore_test,omitempty"`
+}
+
+// dashboardCheck is the last integrity check of hosted storage
+type dashboardCheck struct {
+	At           time.Time `json:"at"`
+	Passed       bool      `json:"passed"`
+	CorruptFiles int       `json:"corrupt_files"`
+	MissingFiles int       `json:"missing_files"`
+}
+
+// dashboardRestoreTest is the last restore test
+type dashboardRestoreTest struct {
+	At            time.Time `json:"at"`
+	Passed        bool      `json:"passed"`
+	SnapshotID    string    `json:"snapshot_id"`
+	FilesVerified int       `json:"files_verified"`
+}
+
+// dashboard gathers what the dashboard shows from the parts of the node
+// that know it. Any part may be missing; its section is then left out.
+type dashboard struct {
+	cfg       *config.Config
+	reports   *backupreport.Store
+	dedup     *backupreport.DedupStore
+	storage   *storage.Server
+	integrity *integrity.Checker
+	pending   func() (int, error)
+	client    *http.Client
+	now       func() time.Time
+
+	// The scheduler is attached after the server is built
+	scheduler atomic.Pointer[scheduler.Scheduler]
+}
+
+// dashboardHandler serves:
+//
+//	GET /api/v1/dashboard  everything a dashboard shows, in one call
+func dashboardHandler(d *dashboard) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+		resp, err := d.build(r.Context())
+		if err != nil {
+			writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	})
+}
+
+func (d *dashboard) build(ctx context.Context) (*dashboardResponse, error) {
+	// Peers are probed while the rest is gathered
+	peers := make(chan []dashboardPeer, 1)
+	go func() { peers <- d.probePeers(ctx) }()
+
+	resp := &dashboardResponse{
+		GeneratedAt: d.now().UTC(),
+		Name:        d.cfg.Name,
+		Role:        string(d.cfg.Role),
+		LockedDown:  lockdown.Load(d.cfg.ConfigDir) != nil,
+		Schedule:    d.cfg.BackupSchedule,
+		SizeTrend:   []dashboardSizePoint{},
+	}
+	if resp.Schedule != "" {
+		resp.Timezone = d.cfg.ScheduleTimezone()
+	}
+
+	reports, err := d.reports.List(dashboardTrendPoints)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(reports) - 1; i >= 0; i-- {
+		r := reports[i]
+		resp.SizeTrend = append(resp.SizeTrend, dashboardSizePoint{
+			At:         r.FinishedAt,
+			SnapshotID: r.SnapshotID,
+			TotalBytes: r.TotalBytes,
+			BytesAdded: r.BytesAdded,
+		})
+	}
+	var last *backupreport.Report
+	if len(reports) > 0 {
+		last = reports[0]
+	}
+	resp.LastBackup, resp.NextBackup = d.backupStatus(last)
+
+	dedup, err := dedupStats(d.cfg, d.dedup, d.storage, d.now())
+	if err != nil {
+		return nil, err
+	}
+	if dedup.Runs > 0 {
+		resp.Dedup = &dashboardDedup{
+			DedupRatio:       dedup.DedupRatio,
+			CompressionRatio: dedup.CompressionRatio,
+			SavingsPct:       dedup.SavingsPct,
+			Projection:       dedup.Projection,
+		}
+	}
+
+	if d.pending != nil {
+		n, err := d.pending()
+		if err != nil {
+			return nil, err
+		}
+		resp.PendingApprovals = n
+	}
+	if d.storage != nil {
+		resp.Storage = storageHealth(d.storage.Status())
+	}
+	resp.Integrity = d.integrityStatus()
+	resp.Peers = <-peers
+	return resp, nil
+}
+
+// backupStatus combines the last report, which records successful runs,
+// with the scheduler's last run, which also knows about failures
+func (d *dashboard) backupStatus(last *backupreport.Report) (*dashboardBackup, *time.Time) {
+	var status *dashboardBackup
+	if last != nil {
+		status = &dashboardBackup{At: last.FinishedAt, Result: backupSucceeded, SnapshotID: last.SnapshotID, Scheduled: last.Scheduled}
+		if last.Partial {
+			status.Result = backupPartial
+		}
+	}
+
+	sched := d.scheduler.Load()
+	if sched == nil {
+		return status, nil
+	}
+	lastRun, nextRun, lastErr := sched.Status()
+	if lastErr != nil && (status == nil || lastRun.After(status.At)) {
+		status = &dashboardBackup{At: lastRun, Result: backupFailed, Scheduled: true, Error: lastErr.Error()}
+	}
+	if status != nil {
+		status.ConsecutiveFailures = sched.Health().ConsecutiveFailures
+	}
+	if nextRun.IsZero() {
+		return status, nil
+	}
+	return status, &nextRun
+}
+
+// storageHealth reports the hosted storage's state and what is wrong with it
+func storageHealth(s storage.Status) *dashboardStorage {
+	h := &dashboardStorage{
+		Running:       s.Running,
+		ReadOnly:      s.Maintenance != nil,
+		UsedBytes:     s.UsedBytes,
+		QuotaUsagePct: s.QuotaUsagePct,
+		DiskUsagePct:  s.DiskUsagePct,
+		DiskFreeBytes: s.DiskFreeBytes,
+	}
+	if !s.Running {
+		h.Problems = append(h.Problems, "storage server is stopped")
+	}
+	if s.Maintenance != nil {
+		h.Problems = append(h.Problems, "read-only for maintenance: "+s.Maintenance.Reason)
+	}
+	if s.MaxDiskUsagePct > 0 && s.DiskUsagePct >= s.MaxDiskUsagePct {
+		h.Problems = append(h.Problems, fmt.Sprintf("disk is %d%% full (limit %d%%)", s.DiskUsagePct, s.MaxDiskUsagePct))
+	}
+	if s.QuotaUsagePct >= storageQuotaWarnPct {
+		h.Problems = append(h.Problems, fmt.Sprintf("%d%% of the storage quota is used", s.QuotaUsagePct))
+	}
+	h.Healthy = len(h.Problems) == 0
+	return h
+}
+
+// integrityStatus reports the last integrity check of hosted storage and
+// the last restore test. Either passing, or not having run, is healthy.
+func (d *dashboard) integrityStatus() dashboardIntegrity {
+	status := dashboardIntegrity{Healthy: true}
+	if d.integrity != nil {
+		if last := d.integrity.GetHistory(1); len(last) > 0 {
+			c := last[0]
+			status.LastCheck = &dashboardCheck{At: c.Timestamp, Passed: c.Passed, CorruptFiles: c.CorruptFiles, MissingFiles: c.MissingFiles}
+			status.Healthy = c.Passed
+		}
+	}
+	if d.cfg.ConfigDir != "" {
+		if last := integrity.NewRestoreTester(nil, d.cfg.ConfigDir, nil).History(1); len(last) > 0 {
+			t := last[0]
+			status.LastRestoreTest = &dashboardRestoreTest{At: t.Timestamp, Passed: t.Passed, SnapshotID: t.SnapshotID, FilesVerified: t.FilesVerified}
+			status.Healthy = status.Healthy && t.Passed
+		}
+	}
+	return status
+}
+
+// probePeers checks, in parallel, that the peer and other key holders'
+// nodes answer on their API
+func (d *dashboard) probePeers(ctx context.Context) []dashboardPeer {
+	var peers []dashboardPeer
+	seen := map[string]bool{}
+	add := func(name, addr string) {
+		if addr == "" || seen[addr] {
+			return
+		}
+		seen[addr] = true
+		peers = append(peers, dashboardPeer{Name: name, Address: addr})
+	}
+	if d.cfg.Peer != nil {
+		add(d.cfg.Peer.Name, d.cfg.Peer.Address)
+	}
+	if d.cfg.Consensus != nil {
+		self := ""
+		if d.cfg.PublicKey != nil {
+			self = crypto.KeyID(d.cfg.PublicKey)
+		}
+		for _, kh := range d.cfg.Consensus.KeyHolders {
+			if kh.ID != self {
+				add(kh.Name, kh.Address)
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := range peers {
+		wg.Add(1)
+		go func(p *dashboardPeer) {
+			defer wg.Done()
+			d.probe(ctx, p)
+		}(&peers[i])
+	}
+	wg.Wait()
+	if peers == nil {
+		return []dashboardPeer{}
+	}
+	return peers
+}
+
+// probe asks a peer for its API version
+func (d *dashboard) probe(ctx context.Context, p *dashboardPeer) {
+	ctx, cancel := context.WithTimeout(ctx, peerProbeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(p.Address, "/")+APIBasePath+VersionPath, nil)
+	if err != nil {
+		p.Error = "invalid address"
+		return
+	}
+	start := d.now()
+	resp, err := d.client.Do(req)
+	if err != nil {
+		p.Error = err.Error()
+		p.IdentityChanged = apperrors.CodeOf(err) == apperrors.CodePeerIdentityChanged
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		p.Error = "answered " + resp.Status
+		return
+	}
+	var info VersionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		p.Error = "not an Airgapper server"
+		return
+	}
+	p.Reachable, p.APIVersion, p.LatencyMs = true, info.APIVersion, d.now().Sub(start).Milliseconds()
+}
+
+// newDashboard returns the dashboard of the server's node
+func (s *Server) newDashboard(pending func() (int, error)) *dashboard {
+	return &dashboard{
+		cfg:       s.cfg,
+		reports:   backupreport.NewStore(s.cfg.BackupReportsPath()),
+		dedup:     backupreport.NewDedupStore(s.cfg.DedupStatsPath()),
+		storage:   s.storageServer,
+		integrity: s.integrityChecker,
+		pending:   pending,
+		client:    PeerClient(s.cfg, peerProbeTimeout),
+		now:       time.Now,
+	}
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/backupreport"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/integrity"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+)
+
+func TestDashboardHandler(t *testing.T) {
+	dir := t.TempDir()
+	peer := httptest.NewServer(versionHandler("1.2.3"))
+	defer peer.Close()
+	cfg := &config.Config{
+		Name: "alice", Role: config.RoleOwner, ConfigDir: dir, BackupSchedule: "daily",
+		Peer: &config.PeerInfo{Name: "bob", Address: peer.URL},
+		Consensus: &config.ConsensusConfig{KeyHolders: []config.KeyHolder{
+			{ID: "carol", Name: "carol", Address: "http://127.0.0.1:1"},
+			{ID: "bob", Name: "bob", Address: peer.URL}, // Probed once
+		}},
+	}
+
+	reports := backupreport.NewStore(filepath.Join(dir, "backup-reports.json"))
+	start := time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC)
+	for i := range dashboardTrendPoints + 5 {
+		at := start.Add(time.Duration(i) * 24 * time.Hour)
+		require.NoError(t, reports.Add(&backupreport.Report{
+			SnapshotID: fmt.Sprintf("%08x", i), StartedAt: at, FinishedAt: at.Add(time.Minute),
+			TotalBytes: int64(1000 + i), BytesAdded: 10, Scheduled: true, Partial: i == dashboardTrendPoints+4,
+		}))
+	}
+	tests, err := json.Marshal([]integrity.RestoreTestRecord{{SnapshotID: "abcd1234", Timestamp: start, FilesVerified: 5, Passed: false}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "restore-tests.json"), tests, 0600))
+
+	d := &dashboard{
+		cfg:     cfg,
+		reports: reports,
+		dedup:   backupreport.NewDedupStore(filepath.Join(dir, "dedup-stats.json")),
+		pending: func() (int, error) { return 2, nil },
+		client:  &http.Client{Timeout: time.Second},
+		now:     time.Now,
+	}
+	rec := httptest.NewRecorder()
+	dashboardHandler(d).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, dashboardPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp dashboardResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	assert.Equal(t, "alice", resp.Name)
+	assert.Equal(t, 2, resp.PendingApprovals)
+	require.NotNil(t, resp.LastBackup)
+	assert.Equal(t, backupPartial, resp.LastBackup.Result)
+	assert.Equal(t, fmt.Sprintf("%08x", dashboardTrendPoints+4), resp.LastBackup.SnapshotID)
+	assert.Nil(t, resp.NextBackup, "no scheduler is running")
+
+	require.Len(t, resp.SizeTrend, dashboardTrendPoints)
+	assert.Equal(t, int64(1005), resp.SizeTrend[0].TotalBytes, "oldest kept point first")
+	assert.Equal(t, int64(1000+dashboardTrendPoints+4), resp.SizeTrend[dashboardTrendPoints-1].TotalBytes)
+
+	assert.Nil(t, resp.Dedup, "no run recorded")
+
+	assert.Nil(t, resp.Storage, "owners host no storage")
+	assert.False(t, resp.Integrity.Healthy, "the last restore test failed")
+	require.NotNil(t, resp.Integrity.LastRestoreTest)
+	assert.Equal(t, "abcd1234", resp.Integrity.LastRestoreTest.SnapshotID)
+
+	require.Len(t, resp.Peers, 2)
+	assert.Equal(t, "bob", resp.Peers[0].Name)
+	assert.True(t, resp.Peers[0].Reachable)
+	assert.Equal(t, APIVersion, resp.Peers[0].APIVersion)
+	assert.Equal(t, "carol", resp.Peers[1].Name)
+	assert.False(t, resp.Peers[1].Reachable)
+	assert.NotEmpty(t, resp.Peers[1].Error)
+
+	rec = httptest.NewRecorder()
+	dashboardHandler(d).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, dashboardPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestStorageHealth(t *testing.T) {
+	h := storageHealth(storage.Status{Running: true, DiskUsagePct: 40, MaxDiskUsagePct: 90})
+	assert.True(t, h.Healthy)
+	assert.Empty(t, h.Problems)
+
+	h = storageHealth(storage.Status{
+		Running: true, DiskUsagePct: 95, MaxDiskUsagePct: 90, QuotaUsagePct: 92,
+		Maintenance: &storage.Maintenance{Reason: "disk swap"},
+	})
+	assert.False(t, h.Healthy)
+	assert.True(t, h.ReadOnly)
+	assert.Equal(t, []string{
+		"read-only for maintenance: disk swap",
+		"disk is 95% full (limit 90%)",
+		"92% of the storage quota is used",
+	}, h.Problems)
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/backupreport"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+)
+
+// dedupStatsPath serves deduplication stats (relative to APIBasePath)
+const dedupStatsPath = "/stats/dedup"
+
+// dedupStatsHandler serves:
+//
+//	GET /api/v1/stats/dedup?limit=N  raw vs stored size of backup runs, the
+//	                                 last N runs' points, and when the quota fills
+func dedupStatsHandler(cfg *config.Config, store *backupreport.DedupStore, server *storage.Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+		limit := 0
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "limit must be a non-negative integer"))
+				return
+			}
+			limit = n
+		}
+
+		stats, err := dedupStats(cfg, store, server, time.Now())
+		if err != nil {
+			writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+			return
+		}
+		if limit > 0 && limit < len(stats.Points) {
+			stats.Points = stats.Points[len(stats.Points)-limit:]
+		}
+		writeJSON(w, http.StatusOK, stats)
+	})
+}
+
+// dedupStats totals every recorded backup run and projects when the quota
+// fills: the hosted storage's quota and usage on a node hosting storage,
+// otherwise host_quota_bytes and the sum of what backups stored
+func dedupStats(cfg *config.Config, store *backupreport.DedupStore, server *storage.Server, now time.Time) (*backupreport.DedupStats, error) {
+	points, storedTotal, err := store.Points()
+	if err != nil {
+		return nil, err
+	}
+	stats := backupreport.Summarize(points)
+
+	if server != nil {
+		status := server.Status()
+		stats.Projection = backupreport.Project(points, status.EffectiveQuotaBytes, status.UsedBytes, now)
+	} else if p := backupreport.Project(points, cfg.HostQuotaBytes, storedTotal, now); p != nil {
+		p.UsedEstimated = true
+		stats.Projection = p
+	}
+	return &stats, nil
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/backupreport"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+)
+
+func TestDedupStatsHandler(t *testing.T) {
+	store := backupreport.NewDedupStore(filepath.Join(t.TempDir(), "dedup-stats.json"))
+	now := time.Now()
+	for i := range 3 {
+		require.NoError(t, store.Add(backupreport.DedupPoint{
+			At: now.Add(time.Duration(i-3) * 24 * time.Hour), RawBytes: 1000, NewBytes: 100, StoredBytes: 50,
+		}))
+	}
+	cfg := &config.Config{HostQuotaBytes: 1000}
+
+	get := func(h http.Handler, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := get(dedupStatsHandler(cfg, store, nil), dedupStatsPath+"?limit=2")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var stats backupreport.DedupStats
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+	assert.Equal(t, 3, stats.Runs, "totals cover every run")
+	assert.Len(t, stats.Points, 2)
+	assert.InDelta(t, 10, stats.DedupRatio, 0.001)
+	assert.InDelta(t, 2, stats.CompressionRatio, 0.001)
+
+	// Without hosted storage, usage is what the backups stored
+	require.NotNil(t, stats.Projection)
+	assert.True(t, stats.Projection.UsedEstimated)
+	assert.Equal(t, int64(150), stats.Projection.UsedBytes)
+	assert.Equal(t, int64(1000), stats.Projection.QuotaBytes)
+	require.NotNil(t, stats.Projection.FullAt)
+
+	rec = get(dedupStatsHandler(&config.Config{}, store, nil), dedupStatsPath)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "projection", "no quota known")
+
+	rec = get(dedupStatsHandler(cfg, store, nil), dedupStatsPath+"?limit=x")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/service"
+)
+
+// DelegationsPath is the prefix of approval delegation endpoints (relative to APIBasePath)
+const DelegationsPath = "/delegations"
+
+// RevokeDelegationRequest is the body of POST /api/v1/delegations/{id}/revoke
+type RevokeDelegationRequest struct {
+	Signature string `json:"signature"` // hex-encoded, by the delegating key holder
+}
+
+// delegationsHandler serves:
+//
+//	GET  /api/v1/delegations              list delegations and their audit trail
+//	POST /api/v1/delegations              register a signed delegation
+//	POST /api/v1/delegations/{id}/revoke  revoke a delegation early
+func delegationsHandler(svc *service.ConsentService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest := strings.Trim(strings.TrimPrefix(r.URL.Path, DelegationsPath), "/")
+		if rest == "" {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead:
+				delegations, events, err := svc.ListDelegations()
+				if err != nil {
+					writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+					return
+				}
+				writeJSON(w, http.StatusOK, map[string]any{"delegations": delegations, "events": events})
+			case http.MethodPost:
+				var d consent.Delegation
+				if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&d); err != nil {
+					writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "invalid request body"))
+					return
+				}
+				if err := svc.RegisterDelegation(&d); err != nil {
+					writeError(w, apperrors.Coded(apperrors.CodeInvalidArgument, err))
+					return
+				}
+				writeJSON(w, http.StatusCreated, d)
+			default:
+				writeError(w, errMethodNotAllowed)
+			}
+			return
+		}
+
+		id, action, ok := strings.Cut(rest, "/")
+		if !ok || action != "revoke" {
+			writeError(w, apperrors.New(apperrors.CodeNotFound, "unknown delegation route"))
+			return
+		}
+		if r.Method != http.MethodPost {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+
+		var req RevokeDelegationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "invalid request body"))
+			return
+		}
+		signature, err := hex.DecodeString(req.Signature)
+		if err != nil {
+			writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "signature must be hex-encoded"))
+			return
+		}
+
+		d, err := svc.RevokeDelegation(id, signature)
+		if err != nil {
+			writeError(w, apperrors.Coded(apperrors.CodeInvalidArgument, err))
+			return
+		}
+		writeJSON(w, http.StatusOK, d)
+	})
+}
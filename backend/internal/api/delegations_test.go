@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/service"
+)
+
+func TestDelegationsHandler(t *testing.T) {
+	alicePub, alicePriv, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+	bobPub, _, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+	alice, bob := crypto.KeyID(alicePub), crypto.KeyID(bobPub)
+
+	dir := t.TempDir()
+	cfg := &config.Config{Name: "host", ConfigDir: dir, Consensus: &config.ConsensusConfig{
+		Threshold: 2,
+		TotalKeys: 2,
+		KeyHolders: []config.KeyHolder{
+			{ID: alice, Name: "Alice", PublicKey: alicePub},
+			{ID: bob, Name: "Bob", PublicKey: bobPub},
+		},
+	}}
+
+	mux := http.NewServeMux()
+	h := delegationsHandler(service.NewConsentService(cfg, consent.NewManager(dir)))
+	mux.Handle(DelegationsPath, h)
+	mux.Handle(DelegationsPath+"/", h)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+	post := func(path string, v any) *httptest.ResponseRecorder {
+		body, err := json.Marshal(v)
+		require.NoError(t, err)
+		return do(http.MethodPost, path, string(body))
+	}
+
+	d, err := consent.NewDelegation(alice, bob, time.Now(), 14*24*time.Hour, "vacation")
+	require.NoError(t, err)
+
+	// Unsigned, then signed by the wrong key
+	assert.Equal(t, http.StatusBadRequest, post("/delegations", d).Code)
+	_, otherPriv, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+	d.Signature, err = d.SignData().Sign(otherPriv)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, post("/delegations", d).Code)
+
+	d.Signature, err = d.SignData().Sign(alicePriv)
+	require.NoError(t, err)
+	rec := post("/delegations", d)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = do(http.MethodGet, "/delegations", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var list struct {
+		Delegations []consent.Delegation      `json:"delegations"`
+		Events      []consent.DelegationEvent `json:"events"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Delegations, 1)
+	assert.Equal(t, bob, list.Delegations[0].ToKeyHolderID)
+	require.Len(t, list.Events, 1)
+	assert.Equal(t, consent.DelegationCreated, list.Events[0].Event)
+
+	// Revocation must be signed by the delegator
+	assert.Equal(t, http.StatusBadRequest, post("/delegations/"+d.ID+"/revoke", RevokeDelegationRequest{Signature: hex.EncodeToString(d.Signature)}).Code)
+	assert.Equal(t, http.StatusNotFound, post("/delegations/dlg-missing/revoke", RevokeDelegationRequest{Signature: "00"}).Code)
+
+	revokeSig, err := d.RevokeSignData().Sign(alicePriv)
+	require.NoError(t, err)
+	rec = post("/delegations/"+d.ID+"/revoke", RevokeDelegationRequest{Signature: hex.EncodeToString(revokeSig)})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var revoked consent.Delegation
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &revoked))
+	assert.NotNil(t, revoked.RevokedAt)
+
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/delegations/"+d.ID+"/other", "").Code)
+}
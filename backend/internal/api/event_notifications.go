@@ -0,0 +1,136 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/emergency"
+	"github.com/lcrostarosa/airgapper/backend/internal/events"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+// Events published by CLI commands, which other processes' subscribers
+// don't see, are picked up from the log this often
+var eventNotifyPollInterval = 30 * time.Second
+
+// notifiedEvents are the activity feed categories notifications follow.
+// Backups, anomalies and lockdowns send their own notifications.
+var notifiedEvents = events.Filter{Types: []string{"request", "deletion", "recovery", "peer"}}
+
+// StartEventNotifications sends restore and deletion request events from
+// the activity feed to the configured notification providers, for the
+// events enabled in the notify config, and social recovery and peer
+// identity events always.
+// It returns a function stopping it.
+func StartEventNotifications(cfg *config.Config) func() {
+	feed := events.Open(cfg.ConfigDir)
+	if feed == nil || !cfg.Emergency.GetNotify().IsEnabled() {
+		return func() {}
+	}
+	// Only what happens from now on is notified
+	cursor, err := feed.Latest()
+	if err != nil {
+		logging.Warn("Event notifications disabled", logging.Err(err))
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	published, unsubscribe := feed.Subscribe(16)
+	go func() {
+		defer unsubscribe()
+		ticker := time.NewTicker(eventNotifyPollInterval)
+		defer ticker.Stop()
+		for {
+			cursor = notifyEvents(ctx, cfg, feed, cursor)
+			select {
+			case <-ctx.Done():
+				return
+			case <-published:
+			case <-ticker.C:
+			}
+		}
+	}()
+	return cancel
+}
+
+// notifyEvents sends the notifications for the events after cursor and
+// returns the new cursor
+func notifyEvents(ctx context.Context, cfg *config.Config, feed *events.Log, cursor uint64) uint64 {
+	list, _, err := feed.Since(cursor, 0, notifiedEvents)
+	if err != nil {
+		logging.Warn("Failed to read events for notifications", logging.Err(err))
+		return cursor
+	}
+	for i := range list {
+		cursor = list[i].ID
+		notify := cfg.Emergency.GetNotify()
+		if !notify.IsEnabled() {
+			continue
+		}
+		msg, ok := eventNotification(&list[i], notify.Events)
+		if !ok {
+			continue
+		}
+		if list[i].Type == events.RequestCreated {
+			msg = withActions(msg, requestActions(cfg, list[i].Subject))
+		}
+		for id, err := range notify.Send(ctx, msg) {
+			logging.Warn("Failed to send notification", logging.String("provider", id), logging.Err(err))
+		}
+	}
+	return cursor
+}
+
+// withActions adds actions to msg, listing them in its body for
+// providers without buttons
+func withActions(msg emergency.Message, actions []emergency.Action) emergency.Message {
+	for _, a := range actions {
+		msg.Body += "\n" + a.Label + ": " + a.URL
+	}
+	msg.Actions = actions
+	return msg
+}
+
+// eventNotification returns the notification for e, if its event is enabled
+func eventNotification(e *events.Event, enabled emergency.EventConfig) (emergency.Message, bool) {
+	var event, title, priority string
+	on, subject := false, "Request"
+	switch e.Type {
+	case events.RequestCreated:
+		event, title, on = "restore_requested", "Restore requested", enabled.RestoreRequested
+	case events.RequestApproved:
+		event, title, on = "restore_approved", "Restore request approved", enabled.RestoreApproved
+	case events.RequestDenied:
+		event, title, on = "restore_denied", "Restore request denied", enabled.RestoreDenied
+	case events.RequestVetoed:
+		event, title, on = "restore_denied", "Restore request vetoed", enabled.RestoreDenied
+		priority = "high"
+	case events.DeletionCreated:
+		event, title, on = "deletion_requested", "Deletion requested", enabled.DeletionRequested
+		priority = "high"
+	case events.DeletionApproved:
+		event, title, on = "deletion_approved", "Deletion request approved", enabled.DeletionApproved
+		priority = "high"
+	case events.RecoveryOpened, events.RecoverySigned, events.RecoveryReminder, events.RecoveryCancelled, events.RecoveryUsed:
+		// Never muted: they are how a key holder learns someone is
+		// claiming their authority
+		event, title, on = "social_recovery", "Social recovery of a key holder", true
+		priority, subject = "high", "Recovery"
+	case events.PeerIdentityChanged:
+		// Never muted: the peer may be being impersonated
+		event, title, on = "peer_identity_changed", "PEER IDENTITY CHANGED", true
+		priority, subject = "urgent", "Peer"
+	case events.PeerRepinned:
+		event, title, on = "peer_identity_changed", "Peer identity repinned", true
+		priority, subject = "high", "Peer"
+	}
+	if !on {
+		return emergency.Message{}, false
+	}
+	body := e.Message
+	if e.Subject != "" {
+		body += "\n" + subject + ": " + e.Subject
+	}
+	return emergency.Message{Event: event, Title: "Airgapper: " + title, Body: body, Priority: priority}, true
+}
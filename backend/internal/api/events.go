@@ -0,0 +1,186 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/events"
+)
+
+// Activity feed endpoints (relative to APIBasePath)
+const (
+	eventsPath       = "/events"
+	eventsStreamPath = "/events/stream"
+)
+
+const (
+	defaultEventsLimit = 100
+	maxEventsLimit     = events.DefaultCapacity
+)
+
+// The stream checks the log for events from other processes, such as CLI
+// commands, this often, and sends a comment this often to keep proxies from
+// closing an idle connection
+var (
+	eventsPollInterval      = 2 * time.Second
+	eventsKeepaliveInterval = 30 * time.Second
+)
+
+// eventsPage is the body of GET /api/v1/events
+type eventsPage struct {
+	Events []events.Event `json:"events"`
+	// NextCursor is passed as after to get the following events; it is the
+	// cursor given when there are none yet
+	NextCursor uint64 `json:"next_cursor"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// eventsHandler serves the activity feed:
+//
+//	GET /api/v1/events?after=&limit=&type=&source=   events after a cursor, oldest first
+//	GET /api/v1/events/stream?after=&type=&source=   the same as server-sent events, live
+func eventsHandler(log *events.Log) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+		if log == nil {
+			writeError(w, apperrors.New(apperrors.CodeFailedPrecondition, "this node has no config directory to keep events in"))
+			return
+		}
+
+		q := r.URL.Query()
+		after, err := parseCursor(q.Get("after"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		filter := events.Filter{Source: q.Get("source")}
+		if t := q.Get("type"); t != "" {
+			filter.Types = strings.Split(t, ",")
+		}
+
+		switch strings.TrimSuffix(r.URL.Path, "/") {
+		case eventsPath:
+			limit := defaultEventsLimit
+			if v := q.Get("limit"); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n <= 0 || n > maxEventsLimit {
+					writeError(w, apperrors.Newf(apperrors.CodeInvalidArgument, "limit must be between 1 and %d", maxEventsLimit))
+					return
+				}
+				limit = n
+			}
+			list, more, err := log.Since(after, limit, filter)
+			if err != nil {
+				writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+				return
+			}
+			page := eventsPage{Events: list, NextCursor: after, HasMore: more}
+			if page.Events == nil {
+				page.Events = []events.Event{}
+			}
+			if n := len(list); n > 0 {
+				page.NextCursor = list[n-1].ID
+			}
+			writeJSON(w, http.StatusOK, page)
+		case eventsStreamPath:
+			// Reconnecting browsers resume from the last event they saw
+			if id := r.Header.Get("Last-Event-ID"); id != "" {
+				if after, err = parseCursor(id); err != nil {
+					writeError(w, err)
+					return
+				}
+			} else if q.Get("after") == "" {
+				// Without a cursor the stream starts with the next event
+				if after, err = log.Latest(); err != nil {
+					writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+					return
+				}
+			}
+			streamEvents(w, r, log, after, filter)
+		default:
+			writeError(w, apperrors.New(apperrors.CodeNotFound, "unknown events endpoint"))
+		}
+	})
+}
+
+// streamEvents sends the events after cursor that pass filter, then each new
+// one as it is published, until the client goes away
+func streamEvents(w http.ResponseWriter, r *http.Request, log *events.Log, cursor uint64, filter events.Filter) {
+	rc := http.NewResponseController(w)
+	// The server's write timeout is for ordinary calls; a stream stays open
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	published, cancel := log.Subscribe(16)
+	defer cancel()
+	poll := time.NewTicker(eventsPollInterval)
+	defer poll.Stop()
+	keepalive := time.NewTicker(eventsKeepaliveInterval)
+	defer keepalive.Stop()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Stop nginx buffering the stream
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	for {
+		list, _, err := log.Since(cursor, 0, filter)
+		if err != nil {
+			return
+		}
+		for _, e := range list {
+			data, err := json.Marshal(e)
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data); err != nil {
+				return
+			}
+			cursor = e.ID
+		}
+		if len(list) > 0 {
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-published:
+		case <-poll.C:
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// parseCursor parses an event ID used as a cursor; empty means the start
+func parseCursor(s string) (uint64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, apperrors.New(apperrors.CodeInvalidArgument, "after must be an event ID")
+	}
+	return n, nil
+}
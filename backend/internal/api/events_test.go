@@ -0,0 +1,219 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/emergency"
+	"github.com/lcrostarosa/airgapper/backend/internal/events"
+)
+
+func TestEventsHandler(t *testing.T) {
+	feed := events.Open(t.TempDir())
+	feed.Publish(events.Event{Type: events.RequestCreated, Source: events.SourceConsent, Subject: "r1"})
+	feed.Publish(events.Event{Type: events.BackupFailed, Source: events.SourceBackup})
+	feed.Publish(events.Event{Type: events.RequestApproved, Source: events.SourceConsent, Subject: "r1"})
+	h := eventsHandler(feed)
+
+	get := func(path string) (*httptest.ResponseRecorder, eventsPage) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var page eventsPage
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+		}
+		return rec, page
+	}
+
+	t.Run("pages", func(t *testing.T) {
+		rec, page := get("/events?limit=2")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Len(t, page.Events, 2)
+		assert.True(t, page.HasMore)
+		assert.Equal(t, uint64(2), page.NextCursor)
+
+		_, page = get("/events?limit=2&after=2")
+		require.Len(t, page.Events, 1)
+		assert.False(t, page.HasMore)
+		assert.Equal(t, events.RequestApproved, page.Events[0].Type)
+
+		// Past the end the cursor stays put, for polling
+		_, page = get("/events?after=3")
+		assert.Empty(t, page.Events)
+		assert.Equal(t, uint64(3), page.NextCursor)
+		assert.Contains(t, httptestBody(t, h, "/events?after=3"), `"events":[]`)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		_, page := get("/events?type=request")
+		assert.Len(t, page.Events, 2)
+		_, page = get("/events?type=backup.failed,request.approved")
+		assert.Len(t, page.Events, 2)
+		_, page = get("/events?source=backup")
+		require.Len(t, page.Events, 1)
+		assert.Equal(t, events.BackupFailed, page.Events[0].Type)
+	})
+
+	for name, tc := range map[string]struct {
+		method string
+		path   string
+		want   int
+	}{
+		"bad cursor":    {http.MethodGet, "/events?after=x", http.StatusBadRequest},
+		"bad limit":     {http.MethodGet, "/events?limit=0", http.StatusBadRequest},
+		"huge limit":    {http.MethodGet, "/events?limit=100000", http.StatusBadRequest},
+		"unknown route": {http.MethodGet, "/events/nope", http.StatusNotFound},
+		"post":          {http.MethodPost, "/events", http.StatusMethodNotAllowed},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+			assert.Equal(t, tc.want, rec.Code)
+		})
+	}
+
+	t.Run("no config directory", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		eventsHandler(nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	})
+}
+
+func httptestBody(t *testing.T, h http.Handler, path string) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec.Body.String()
+}
+
+func TestEventsStream(t *testing.T) {
+	feed := events.Open(t.TempDir())
+	feed.Publish(events.Event{Type: events.RequestCreated, Subject: "old"})
+	srv := httptest.NewServer(eventsHandler(feed))
+	defer srv.Close()
+
+	// readEvents reads n events from a stream, returning their IDs and subjects
+	readEvents := func(t *testing.T, resp *http.Response, n int) []string {
+		t.Helper()
+		var got []string
+		scanner := bufio.NewScanner(resp.Body)
+		for len(got) < n && scanner.Scan() {
+			line := scanner.Text()
+			if data, ok := strings.CutPrefix(line, "data: "); ok {
+				var e events.Event
+				require.NoError(t, json.Unmarshal([]byte(data), &e))
+				got = append(got, e.Subject)
+			}
+		}
+		return got
+	}
+	open := func(t *testing.T, path string, header http.Header) *http.Response {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		t.Cleanup(cancel)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+path, nil)
+		require.NoError(t, err)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+		return resp
+	}
+
+	t.Run("live events only by default", func(t *testing.T) {
+		resp := open(t, "/events/stream?type=request", nil)
+		feed.Publish(events.Event{Type: events.BackupFinished, Subject: "filtered"})
+		feed.Publish(events.Event{Type: events.RequestApproved, Subject: "new"})
+		assert.Equal(t, []string{"new"}, readEvents(t, resp, 1))
+	})
+
+	t.Run("resumes after a cursor", func(t *testing.T) {
+		resp := open(t, "/events/stream?after=0", nil)
+		assert.Equal(t, []string{"old", "filtered", "new"}, readEvents(t, resp, 3))
+
+		resp = open(t, "/events/stream", http.Header{"Last-Event-Id": {"2"}})
+		assert.Equal(t, []string{"new"}, readEvents(t, resp, 1))
+	})
+}
+
+func TestEventNotifications(t *testing.T) {
+	received := make(chan emergency.Message, 4)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg emergency.Message
+		_ = json.NewDecoder(r.Body).Decode(&msg)
+		received <- msg
+	}))
+	defer hook.Close()
+
+	dir := t.TempDir()
+	feed := events.Open(dir)
+	feed.Publish(events.Event{Type: events.RequestCreated, Subject: "before-start"})
+
+	cfg := &config.Config{ConfigDir: dir, Emergency: &emergency.Config{Notify: &emergency.NotifyConfig{
+		Enabled: true,
+		Providers: map[string]emergency.Provider{
+			"hook": {Type: "webhook", Enabled: true, Settings: map[string]string{"url": hook.URL}},
+		},
+		Events: emergency.EventConfig{RestoreRequested: true, DeletionRequested: true},
+	}}}
+	stop := StartEventNotifications(cfg)
+	defer stop()
+
+	feed.Publish(events.Event{Type: events.BackupFinished, Subject: "not-notified"})
+	feed.Publish(events.Event{Type: events.RequestDenied, Subject: "disabled"})
+	feed.Publish(events.Event{Type: events.RequestCreated, Message: "alice requested a restore", Subject: "r1"})
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "restore_requested", msg.Event)
+		assert.Equal(t, "Airgapper: Restore requested", msg.Title)
+		assert.Equal(t, "alice requested a restore\nRequest: r1", msg.Body)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification sent")
+	}
+	select {
+	case msg := <-received:
+		t.Fatalf("unexpected notification %+v", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestEventNotification(t *testing.T) {
+	all := emergency.EventConfig{RestoreApproved: true, RestoreDenied: true, DeletionApproved: true}
+	msg, ok := eventNotification(&events.Event{Type: events.RequestVetoed, Message: "bob vetoed"}, all)
+	require.True(t, ok)
+	assert.Equal(t, "restore_denied", msg.Event)
+	assert.Equal(t, "high", msg.Priority)
+
+	_, ok = eventNotification(&events.Event{Type: events.RequestCreated}, all)
+	assert.False(t, ok)
+	_, ok = eventNotification(&events.Event{Type: events.IntegrityFailed}, all)
+	assert.False(t, ok)
+
+	// Social recovery can't be muted
+	msg, ok = eventNotification(&events.Event{Type: events.RecoveryReminder, Subject: "rcv-1", Message: "open"}, emergency.EventConfig{})
+	require.True(t, ok)
+	assert.Equal(t, "social_recovery", msg.Event)
+	assert.Equal(t, "open\nRecovery: rcv-1", msg.Body)
+
+	// Nor can a changed peer identity
+	msg, ok = eventNotification(&events.Event{Type: events.PeerIdentityChanged, Subject: "https://bob:8081", Message: "changed"}, emergency.EventConfig{})
+	require.True(t, ok)
+	assert.Equal(t, "peer_identity_changed", msg.Event)
+	assert.Equal(t, "urgent", msg.Priority)
+	assert.Equal(t, "changed\nPeer: https://bob:8081", msg.Body)
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"strconv"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/filesystem"
+)
+
+// browsePath lists directories for the UI path picker (relative to APIBasePath)
+const browsePath = "/filesystem/browse"
+
+// browseHandler serves GET /api/v1/filesystem/browse?path=&offset=&limit=&hidden=.
+// Listings are confined to cfg.AllowedBrowseRoots (the home directory when unset).
+func browseHandler(cfg *config.Config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+
+		q := r.URL.Query()
+		opts := filesystem.BrowseOptions{Path: q.Get("path")}
+		var err error
+		if opts.Offset, err = intParam(q.Get("offset")); err != nil {
+			writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "invalid offset"))
+			return
+		}
+		if opts.Limit, err = intParam(q.Get("limit")); err != nil {
+			writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "invalid limit"))
+			return
+		}
+		if v := q.Get("hidden"); v != "" {
+			if opts.ShowHidden, err = strconv.ParseBool(v); err != nil {
+				writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "invalid hidden flag"))
+				return
+			}
+		}
+
+		browser, err := filesystem.NewBrowser(cfg.AllowedBrowseRoots)
+		if err != nil {
+			writeError(w, apperrors.Coded(apperrors.CodeUnavailable, err))
+			return
+		}
+
+		listing, err := browser.Browse(opts)
+		switch {
+		case err == nil:
+			writeJSON(w, http.StatusOK, listing)
+		case errors.Is(err, apperrors.ErrPathNotAllowed), errors.Is(err, fs.ErrPermission):
+			writeError(w, apperrors.New(apperrors.CodePathNotAllowed, "path is not browsable"))
+		case errors.Is(err, fs.ErrNotExist):
+			writeError(w, apperrors.New(apperrors.CodeNotFound, "path does not exist"))
+		case errors.Is(err, apperrors.ErrNotADirectory):
+			writeError(w, apperrors.New(apperrors.CodeNotADirectory, "path is not a directory"))
+		default:
+			writeError(w, apperrors.New(apperrors.CodeInternal, "failed to list directory"))
+		}
+	})
+}
+
+func intParam(v string) (int, error) {
+	if v == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(v)
+}
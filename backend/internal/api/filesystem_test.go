@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/filesystem"
+)
+
+func TestBrowseHandler(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, os.Mkdir(filepath.Join(root, "photos"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "notes.txt"), []byte("x"), 0644))
+
+	h := browseHandler(&config.Config{AllowedBrowseRoots: []string{root}})
+
+	tests := []struct {
+		name       string
+		query      url.Values
+		wantStatus int
+	}{
+		{"roots", url.Values{}, http.StatusOK},
+		{"directory", url.Values{"path": {root}}, http.StatusOK},
+		{"outside roots", url.Values{"path": {"/"}}, http.StatusForbidden},
+		{"traversal", url.Values{"path": {root + "/../.."}}, http.StatusForbidden},
+		{"missing", url.Values{"path": {filepath.Join(root, "nope")}}, http.StatusNotFound},
+		{"file", url.Values{"path": {filepath.Join(root, "notes.txt")}}, http.StatusBadRequest},
+		{"bad limit", url.Values{"path": {root}, "limit": {"lots"}}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, browsePath+"?"+tt.query.Encode(), nil))
+			assert.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+		})
+	}
+
+	t.Run("listing body", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, browsePath+"?path="+url.QueryEscape(root), nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var listing filesystem.Listing
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listing))
+		assert.Equal(t, 2, listing.Total)
+		assert.Equal(t, "photos", listing.Entries[0].Name)
+		assert.True(t, listing.Entries[0].IsDir)
+		assert.Equal(t, int64(1), listing.Entries[1].Size)
+	})
+}
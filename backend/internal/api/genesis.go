@@ -0,0 +1,79 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/genesis"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+)
+
+// Genesis record paths (relative to APIBasePath)
+const (
+	GenesisPath            = "/genesis"
+	GenesisCountersignPath = "/genesis/countersign"
+)
+
+// errNoGenesis is returned when the vault predates genesis records
+var errNoGenesis = apperrors.New(apperrors.CodeNotFound, "this vault has no genesis record")
+
+// genesisHandler serves the vault's genesis record: GET /api/v1/genesis
+// returns it, and POST /api/v1/genesis/countersign adds a registered key
+// holder's countersignature and returns the updated record
+func genesisHandler(cfg *config.Config) http.Handler {
+	var mu sync.Mutex
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		doc, err := genesis.Load(cfg)
+		if err != nil {
+			writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+			return
+		}
+		if doc == nil {
+			writeError(w, errNoGenesis)
+			return
+		}
+
+		switch {
+		case r.URL.Path == GenesisPath && r.Method == http.MethodGet:
+			writeJSON(w, http.StatusOK, doc)
+		case r.URL.Path == GenesisCountersignPath && r.Method == http.MethodPost:
+			var cs genesis.Countersignature
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&cs); err != nil {
+				writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "invalid countersignature body"))
+				return
+			}
+			holder := cfg.GetKeyHolder(cs.KeyHolderID)
+			if holder == nil || !bytes.Equal(holder.PublicKey, cs.PublicKey) {
+				writeError(w, apperrors.New(apperrors.CodeKeyHolderNotFound, "only registered key holders can countersign"))
+				return
+			}
+			if err := doc.Check(cfg); err != nil {
+				writeError(w, apperrors.Coded(apperrors.CodeGenesisMismatch, err))
+				return
+			}
+			if err := doc.AddCountersignature(&cs); err != nil {
+				writeError(w, apperrors.Coded(apperrors.CodeInvalidSignature, err))
+				return
+			}
+			if err := doc.Save(cfg); err != nil {
+				writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+				return
+			}
+			logging.Info("Genesis record countersigned",
+				logging.String("keyHolder", cs.Name),
+				logging.String("keyID", cs.KeyHolderID),
+				tracing.Field(r.Context()))
+			writeJSON(w, http.StatusOK, doc)
+		default:
+			writeError(w, errMethodNotAllowed)
+		}
+	})
+}
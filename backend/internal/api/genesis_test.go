@@ -0,0 +1,82 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/genesis"
+)
+
+func TestGenesisHandler(t *testing.T) {
+	ownerPub, ownerPriv, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+	bobPub, bobPriv, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+
+	cfg := &config.Config{
+		Name:       "alice",
+		Role:       config.RoleOwner,
+		RepoURL:    "rest:http://bob:8000/alice",
+		Password:   "secret",
+		PublicKey:  ownerPub,
+		PrivateKey: ownerPriv,
+		ConfigDir:  t.TempDir(),
+		Consensus: &config.ConsensusConfig{
+			Threshold: 2,
+			TotalKeys: 2,
+			KeyHolders: []config.KeyHolder{
+				{ID: crypto.KeyID(ownerPub), Name: "alice", PublicKey: ownerPub, JoinedAt: time.Now(), IsOwner: true},
+			},
+		},
+	}
+	h := genesisHandler(cfg)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, GenesisPath, "").Code)
+
+	doc, err := genesis.New(cfg, 0)
+	require.NoError(t, err)
+	require.NoError(t, doc.Save(cfg))
+
+	rec := do(http.MethodGet, GenesisPath, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var fetched genesis.Document
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &fetched))
+	require.NoError(t, fetched.Verify())
+
+	cs, err := fetched.Countersign("bob", bobPriv, bobPub)
+	require.NoError(t, err)
+	body, err := json.Marshal(cs)
+	require.NoError(t, err)
+
+	// Only registered key holders can countersign
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, GenesisCountersignPath, string(body)).Code)
+
+	require.NoError(t, cfg.AddKeyHolder(config.KeyHolder{ID: cs.KeyHolderID, Name: "bob", PublicKey: bobPub, JoinedAt: time.Now()}))
+	rec = do(http.MethodPost, GenesisCountersignPath, string(body))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	stored, err := genesis.Load(cfg)
+	require.NoError(t, err)
+	assert.Len(t, stored.Countersignatures, 1)
+	assert.Empty(t, stored.Uncountersigned(cfg))
+
+	// A tampered config refuses further countersignatures
+	cfg.Consensus.Threshold = 1
+	assert.Equal(t, http.StatusPreconditionFailed, do(http.MethodPost, GenesisCountersignPath, string(body)).Code)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, do(http.MethodPost, GenesisPath, "").Code)
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/emergency"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+)
+
+// Repository lock endpoints (relative to APIBasePath)
+const (
+	locksPath  = "/locks"
+	unlockPath = locksPath + "/unlock"
+)
+
+// errForceUnlockNotAllowed is returned when removing active locks hasn't
+// been allowed
+var errForceUnlockNotAllowed = apperrors.New(apperrors.CodePermissionDenied,
+	"removing active locks needs the force-unlock override (airgapper override allow force-unlock)")
+
+// lockInfo is a repository lock and whether it was left behind
+type lockInfo struct {
+	restic.Lock
+	Stale bool `json:"stale"`
+}
+
+// locksResponse is the body of GET /api/v1/locks and POST /api/v1/locks/unlock
+type locksResponse struct {
+	Locks []lockInfo `json:"locks"`
+	Stale int        `json:"stale"`
+	// Removed is how many locks an unlock removed
+	Removed int `json:"removed,omitempty"`
+}
+
+// unlockRequest is the body of POST /api/v1/locks/unlock
+type unlockRequest struct {
+	// All removes active locks too, which can corrupt a running backup
+	All bool `json:"all,omitempty"`
+}
+
+func newLocksResponse(locks []restic.Lock, now time.Time) locksResponse {
+	resp := locksResponse{Locks: make([]lockInfo, 0, len(locks))}
+	for _, l := range locks {
+		stale := l.Stale(now)
+		if stale {
+			resp.Stale++
+		}
+		resp.Locks = append(resp.Locks, lockInfo{Lock: l, Stale: stale})
+	}
+	return resp
+}
+
+// locksHandler serves:
+//
+//	GET  /api/v1/locks         the repository's locks, flagging stale ones
+//	POST /api/v1/locks/unlock  remove stale locks ("all" removes active ones
+//	                           too, if the force-unlock override is allowed)
+func locksHandler(cfg *config.Config, open restic.RunnerFactory) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !cfg.IsOwner() || cfg.Password == "" {
+			writeError(w, errNoRepoPassword)
+			return
+		}
+		repo := open(cfg.RepoURL, cfg.Password)
+
+		switch {
+		case r.URL.Path == locksPath && (r.Method == http.MethodGet || r.Method == http.MethodHead):
+			locks, err := repo.Locks(r.Context())
+			if err != nil {
+				writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+				return
+			}
+			writeJSON(w, http.StatusOK, newLocksResponse(locks, time.Now()))
+
+		case r.URL.Path == unlockPath && r.Method == http.MethodPost:
+			var req unlockRequest
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+				writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "invalid request body"))
+				return
+			}
+			if req.All && !cfg.Emergency.GetOverride().IsTypeAllowed(emergency.OverrideForceUnlock) {
+				writeError(w, errForceUnlockNotAllowed)
+				return
+			}
+
+			before, err := repo.Locks(r.Context())
+			if err == nil {
+				err = repo.Unlock(r.Context(), req.All)
+			}
+			var after []restic.Lock
+			if err == nil {
+				after, err = repo.Locks(r.Context())
+			}
+			if err != nil {
+				writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+				return
+			}
+
+			resp := newLocksResponse(after, time.Now())
+			resp.Removed = max(len(before)-len(after), 0)
+			logging.Info("Repository unlocked",
+				logging.Int("removed", resp.Removed),
+				logging.Bool("all", req.All),
+				tracing.Field(r.Context()))
+			writeJSON(w, http.StatusOK, resp)
+
+		case r.URL.Path == locksPath || r.URL.Path == unlockPath:
+			writeError(w, errMethodNotAllowed)
+
+		default:
+			writeError(w, apperrors.New(apperrors.CodeNotFound, "unknown lock endpoint"))
+		}
+	})
+}
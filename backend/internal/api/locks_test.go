@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/emergency"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/testutil"
+)
+
+func TestLocksHandler(t *testing.T) {
+	fake := testutil.NewFakeRestic()
+	now := time.Now()
+	fake.HeldLocks = []restic.Lock{
+		{ID: "aaaa", Time: now.Add(-2 * time.Hour), Exclusive: true, Hostname: "laptop"},
+		{ID: "bbbb", Time: now.Add(-time.Minute), Hostname: "laptop"},
+	}
+	cfg := &config.Config{Role: config.RoleOwner, RepoURL: "rest:http://host/alice", Password: "secret"}
+	h := locksHandler(cfg, fake.Factory())
+
+	do := func(method, path, body string) (*httptest.ResponseRecorder, locksResponse) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		var resp locksResponse
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		}
+		return rec, resp
+	}
+
+	rec, resp := do(http.MethodGet, locksPath, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Len(t, resp.Locks, 2)
+	assert.Equal(t, 1, resp.Stale)
+	assert.True(t, resp.Locks[0].Stale)
+	assert.False(t, resp.Locks[1].Stale)
+
+	rec, _ = do(http.MethodPost, unlockPath, `{"all":true}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code, "active locks need the force-unlock override")
+
+	// Without a body only stale locks go
+	rec, resp = do(http.MethodPost, unlockPath, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, 1, resp.Removed)
+	require.Len(t, resp.Locks, 1)
+	assert.Equal(t, "bbbb", resp.Locks[0].ID)
+
+	cfg.EnsureEmergency().WithOverrides().Override.AllowType(emergency.OverrideForceUnlock)
+	rec, resp = do(http.MethodPost, unlockPath, `{"all":true}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, 1, resp.Removed)
+	assert.Empty(t, resp.Locks)
+	assert.Contains(t, fake.Calls, "unlock true")
+
+	rec, _ = do(http.MethodDelete, locksPath, "")
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	t.Run("host has no password", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		locksHandler(&config.Config{Role: config.RoleHost}, fake.Factory()).
+			ServeHTTP(rec, httptest.NewRequest(http.MethodGet, locksPath, nil))
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	})
+}
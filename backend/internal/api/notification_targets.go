@@ -0,0 +1,251 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/emergency"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+// notificationTargetsPath is the prefix of notification target endpoints
+// (relative to APIBasePath)
+const notificationTargetsPath = "/notifications/targets"
+
+// targetIDPattern restricts target IDs to what fits in a URL path segment
+var targetIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// notifyMu serializes changes to the notify config, which is replaced as a
+// whole so notifications being sent never see a half-made change
+var notifyMu sync.Mutex
+
+// notificationTarget is a notification provider as the API shows it, with
+// secret settings redacted
+type notificationTarget struct {
+	ID          string            `json:"id"`
+	Type        string            `json:"type"`
+	Enabled     bool              `json:"enabled"`
+	Settings    map[string]string `json:"settings"`
+	Priority    string            `json:"priority,omitempty"`
+	Events      []string          `json:"events,omitempty"`
+	MinPriority string            `json:"min_priority,omitempty"`
+}
+
+// notificationTargetBody is the body of POST /api/v1/notifications/targets
+// and /targets/{id}. On update, omitted fields keep their value and a
+// setting sent back redacted keeps its stored secret.
+type notificationTargetBody struct {
+	ID          string            `json:"id,omitempty"` // Generated from the type if empty
+	Type        string            `json:"type,omitempty"`
+	Enabled     *bool             `json:"enabled,omitempty"` // Default true
+	Settings    map[string]string `json:"settings,omitempty"`
+	Priority    *string           `json:"priority,omitempty"`
+	Events      []string          `json:"events,omitempty"`
+	MinPriority *string           `json:"min_priority,omitempty"`
+}
+
+func toNotificationTarget(id string, p emergency.Provider) notificationTarget {
+	p = p.Redact()
+	return notificationTarget{
+		ID:          id,
+		Type:        p.Type,
+		Enabled:     p.Enabled,
+		Settings:    p.Settings,
+		Priority:    p.Priority,
+		Events:      p.Events,
+		MinPriority: p.MinPriority,
+	}
+}
+
+// notificationTargetsHandler serves:
+//
+//	GET    /api/v1/notifications/targets            list targets
+//	POST   /api/v1/notifications/targets            add a target
+//	GET    /api/v1/notifications/targets/{id}       get a target
+//	POST   /api/v1/notifications/targets/{id}       change a target
+//	DELETE /api/v1/notifications/targets/{id}       remove a target
+//	POST   /api/v1/notifications/targets/{id}/test  send a target a test notification
+func notificationTargetsHandler(cfg *config.Config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest := strings.Trim(strings.TrimPrefix(r.URL.Path, notificationTargetsPath), "/")
+		if rest == "" {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead:
+				notify := cfg.Emergency.GetNotify()
+				targets := []notificationTarget{}
+				if notify.HasProviders() {
+					for _, id := range slices.Sorted(maps.Keys(notify.Providers)) {
+						targets = append(targets, toNotificationTarget(id, notify.Providers[id]))
+					}
+				}
+				writeJSON(w, http.StatusOK, map[string]any{"targets": targets})
+			case http.MethodPost:
+				createNotificationTarget(w, r, cfg)
+			default:
+				writeError(w, errMethodNotAllowed)
+			}
+			return
+		}
+
+		id, action, _ := strings.Cut(rest, "/")
+		p, ok := cfg.Emergency.GetNotify().Providers[id]
+		if !ok && (action == "" || action == "test") {
+			writeError(w, apperrors.Newf(apperrors.CodeNotFound, "notification target %s not found", id))
+			return
+		}
+		switch {
+		case action == "" && (r.Method == http.MethodGet || r.Method == http.MethodHead):
+			writeJSON(w, http.StatusOK, toNotificationTarget(id, p))
+		case action == "" && r.Method == http.MethodPost:
+			updateNotificationTarget(w, r, cfg, id)
+		case action == "" && r.Method == http.MethodDelete:
+			err := changeNotify(cfg, func(n *emergency.NotifyConfig) error {
+				n.RemoveProvider(id)
+				return nil
+			})
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case action == "test" && r.Method == http.MethodPost:
+			err := p.Send(r.Context(), emergency.Message{
+				Event: emergency.TestEvent,
+				Title: "Airgapper test notification",
+				Body:  fmt.Sprintf("Notifications from %s to %s are working.", cfg.Name, id),
+			})
+			if err != nil {
+				writeError(w, apperrors.Coded(apperrors.CodeUnavailable, err))
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{"sent": true})
+		case action == "" || action == "test":
+			writeError(w, errMethodNotAllowed)
+		default:
+			writeError(w, apperrors.New(apperrors.CodeNotFound, "unknown notification target route"))
+		}
+	})
+}
+
+func createNotificationTarget(w http.ResponseWriter, r *http.Request, cfg *config.Config) {
+	var body notificationTargetBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "invalid request body"))
+		return
+	}
+	p := emergency.Provider{Type: body.Type, Enabled: true, Settings: body.Settings, Priority: "normal"}
+	applyTargetBody(&p, &body)
+
+	id := body.ID
+	err := changeNotify(cfg, func(n *emergency.NotifyConfig) error {
+		if id == "" {
+			id = n.NextProviderID(p.Type)
+		}
+		if !targetIDPattern.MatchString(id) {
+			return apperrors.New(apperrors.CodeInvalidArgument, "target ID must be letters, digits, dots, dashes and underscores")
+		}
+		if _, taken := n.Providers[id]; taken {
+			return apperrors.Newf(apperrors.CodeAlreadyExists, "notification target %s already exists", id)
+		}
+		if err := p.Validate(); err != nil {
+			return apperrors.Coded(apperrors.CodeInvalidArgument, err)
+		}
+		n.AddProvider(id, p)
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toNotificationTarget(id, p))
+}
+
+func updateNotificationTarget(w http.ResponseWriter, r *http.Request, cfg *config.Config, id string) {
+	var body notificationTargetBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "invalid request body"))
+		return
+	}
+	var p emergency.Provider
+	err := changeNotify(cfg, func(n *emergency.NotifyConfig) error {
+		old, ok := n.Providers[id]
+		if !ok {
+			return apperrors.Newf(apperrors.CodeNotFound, "notification target %s not found", id)
+		}
+		p = old
+		if body.Type != "" {
+			p.Type = body.Type
+		}
+		if body.Settings != nil {
+			p.Settings = make(map[string]string, len(body.Settings))
+			for k, v := range body.Settings {
+				if v == emergency.Redacted {
+					v = old.Settings[k]
+				}
+				p.Settings[k] = v
+			}
+		}
+		applyTargetBody(&p, &body)
+		if err := p.Validate(); err != nil {
+			return apperrors.Coded(apperrors.CodeInvalidArgument, err)
+		}
+		n.Providers[id] = p
+		return nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toNotificationTarget(id, p))
+}
+
+// applyTargetBody sets the fields a create or update body gives
+func applyTargetBody(p *emergency.Provider, body *notificationTargetBody) {
+	if body.Enabled != nil {
+		p.Enabled = *body.Enabled
+	}
+	if body.Priority != nil {
+		p.Priority = *body.Priority
+	}
+	if body.Events != nil {
+		p.Events = body.Events
+	}
+	if body.MinPriority != nil {
+		p.MinPriority = *body.MinPriority
+	}
+}
+
+// changeNotify applies fn to a copy of the notify config, then puts the
+// copy in place and saves the config
+func changeNotify(cfg *config.Config, fn func(n *emergency.NotifyConfig) error) error {
+	notifyMu.Lock()
+	defer notifyMu.Unlock()
+
+	e := cfg.EnsureEmergency()
+	old := e.Notify
+	n := &emergency.NotifyConfig{Enabled: true, Providers: map[string]emergency.Provider{}}
+	if old != nil {
+		n.Enabled = old.Enabled
+		n.Events = old.Events
+		n.Providers = maps.Clone(old.Providers)
+		if n.Providers == nil {
+			n.Providers = map[string]emergency.Provider{}
+		}
+	}
+	if err := fn(n); err != nil {
+		return err
+	}
+	e.Notify = n
+	if err := cfg.Save(); err != nil {
+		e.Notify = old
+		return apperrors.Coded(apperrors.CodeInternal, err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/emergency"
+)
+
+func TestNotificationTargetsHandler(t *testing.T) {
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer hook.Close()
+
+	cfg := &config.Config{Name: "alice", ConfigDir: t.TempDir()}
+	h := notificationTargetsHandler(cfg)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+	decode := func(rec *httptest.ResponseRecorder) notificationTarget {
+		var target notificationTarget
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &target))
+		return target
+	}
+
+	rec := do(http.MethodPost, "/notifications/targets", `{"type":"telegram","settings":{"bot_token":"123:abc","chat_id":"42"},"events":["backup_failed"],"min_priority":"high"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	created := decode(rec)
+	assert.Equal(t, "telegram-1", created.ID)
+	assert.True(t, created.Enabled)
+	assert.Equal(t, emergency.Redacted, created.Settings["bot_token"])
+	assert.Equal(t, "42", created.Settings["chat_id"])
+
+	stored := cfg.Emergency.GetNotify().Providers["telegram-1"]
+	assert.Equal(t, "123:abc", stored.Settings["bot_token"])
+	assert.Equal(t, []string{"backup_failed"}, stored.Events)
+	saved, err := config.Load(cfg.ConfigDir)
+	require.NoError(t, err)
+	assert.Equal(t, stored, saved.Emergency.GetNotify().Providers["telegram-1"], "changes are saved")
+
+	t.Run("update keeps redacted secrets", func(t *testing.T) {
+		rec := do(http.MethodPost, "/notifications/targets/telegram-1", `{"settings":{"bot_token":"`+emergency.Redacted+`","chat_id":"43"},"enabled":false}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.False(t, decode(rec).Enabled)
+		p := cfg.Emergency.GetNotify().Providers["telegram-1"]
+		assert.Equal(t, "123:abc", p.Settings["bot_token"])
+		assert.Equal(t, "43", p.Settings["chat_id"])
+		assert.Equal(t, "high", p.MinPriority, "omitted fields are kept")
+	})
+
+	t.Run("list and get", func(t *testing.T) {
+		require.Equal(t, http.StatusCreated, do(http.MethodPost, "/notifications/targets", `{"id":"ops","type":"webhook","settings":{"url":"`+hook.URL+`/ok"}}`).Code)
+		rec := do(http.MethodGet, "/notifications/targets", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var list struct {
+			Targets []notificationTarget `json:"targets"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+		require.Len(t, list.Targets, 2)
+		assert.Equal(t, "ops", list.Targets[0].ID)
+		assert.NotContains(t, rec.Body.String(), "123:abc")
+
+		assert.Equal(t, "webhook", decode(do(http.MethodGet, "/notifications/targets/ops", "")).Type)
+	})
+
+	t.Run("test", func(t *testing.T) {
+		rec := do(http.MethodPost, "/notifications/targets/ops/test", "")
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		require.Equal(t, http.StatusOK, do(http.MethodPost, "/notifications/targets/ops", `{"settings":{"url":"`+hook.URL+`/broken"}}`).Code)
+		assert.Equal(t, http.StatusServiceUnavailable, do(http.MethodPost, "/notifications/targets/ops/test", "").Code)
+	})
+
+	for name, tc := range map[string]struct {
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		"unknown type":     {http.MethodPost, "/notifications/targets", `{"type":"fax"}`, http.StatusBadRequest},
+		"missing settings": {http.MethodPost, "/notifications/targets", `{"type":"matrix","settings":{"room_id":"!r:x"}}`, http.StatusBadRequest},
+		"unknown event":    {http.MethodPost, "/notifications/targets", `{"type":"ntfy","settings":{"topic":"x"},"events":["nope"]}`, http.StatusBadRequest},
+		"bad id":           {http.MethodPost, "/notifications/targets", `{"id":"a/b","type":"ntfy","settings":{"topic":"x"}}`, http.StatusBadRequest},
+		"duplicate id":     {http.MethodPost, "/notifications/targets", `{"id":"ops","type":"ntfy","settings":{"topic":"x"}}`, http.StatusConflict},
+		"bad body":         {http.MethodPost, "/notifications/targets", `{`, http.StatusBadRequest},
+		"missing target":   {http.MethodGet, "/notifications/targets/nope", "", http.StatusNotFound},
+		"unknown route":    {http.MethodGet, "/notifications/targets/ops/nope", "", http.StatusNotFound},
+		"wrong method":     {http.MethodGet, "/notifications/targets/ops/test", "", http.StatusMethodNotAllowed},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, do(tc.method, tc.path, tc.body).Code)
+		})
+	}
+
+	t.Run("delete", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/notifications/targets/ops", "").Code)
+		assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/notifications/targets/telegram-1", "").Code)
+		assert.False(t, cfg.Emergency.GetNotify().HasProviders())
+		assert.False(t, cfg.Emergency.GetNotify().IsEnabled(), "removing the last target turns notifications off")
+	})
+}
@@ -0,0 +1,2192 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	// Registers the airgapper.v1 file descriptors used to build the spec
+	_ "github.com/lcrostarosa/airgapper/backend/gen/airgapper/v1"
+	"github.com/lcrostarosa/airgapper/backend/internal/actionlink"
+	"github.com/lcrostarosa/airgapper/backend/internal/auditlog"
+	"github.com/lcrostarosa/airgapper/backend/internal/emergency"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/events"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+	"github.com/lcrostarosa/airgapper/backend/internal/webui"
+)
+
+// protoPackage is the protobuf package exposed over Connect-RPC
+const protoPackage = "airgapper.v1"
+
+// OpenAPIDocument is the subset of the OpenAPI 3.0 document model we emit
+type OpenAPIDocument struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       OpenAPIInfo          `json:"info"`
+	Tags       []OpenAPITag         `json:"tags,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components OpenAPIComponents    `json:"components"`
+}
+
+// OpenAPIInfo describes the API
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// OpenAPITag groups operations (one tag per RPC service)
+type OpenAPITag struct {
+	Name string `json:"name"`
+}
+
+// OpenAPIComponents holds reusable schemas
+type OpenAPIComponents struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// PathItem holds the operations available on a single path
+type PathItem struct {
+	Get    *Operation `json:"get,omitempty"`
+	Post   *Operation `json:"post,omitempty"`
+	Delete *Operation `json:"delete,omitempty"`
+}
+
+// Operation describes a single API operation
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// RequestBody describes an operation's request payload
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response describes an operation's response payload
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType wraps the schema for a content type
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is a JSON Schema object as used by OpenAPI 3.0
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+}
+
+// connectErrorSchema is the Connect protocol error body
+const connectErrorSchema = "ConnectError"
+
+// apiErrorSchema is the error body of the plain HTTP endpoints (errorBody)
+const apiErrorSchema = "APIError"
+
+// BuildOpenAPI generates an OpenAPI 3.0 document from the registered protobuf
+// service descriptors. Every RPC is exposed by Connect as
+// POST /api/v1/<package>.<Service>/<Method> with a JSON body using protojson
+// field names, so the generated document always matches the wire format.
+func BuildOpenAPI(version string) *OpenAPIDocument {
+	if version == "" {
+		version = "dev"
+	}
+
+	doc := &OpenAPIDocument{
+		OpenAPI: "3.0.3",
+		Info: OpenAPIInfo{
+			Title: "Airgapper API",
+			Description: "Connect-RPC API for Airgapper. Each RPC is called with POST and a JSON body " +
+				"(Content-Type: application/json). The /storage/ prefix serves the restic REST protocol " +
+				"and is not described here. Every response, including errors, carries the request's " +
+				"correlation ID in the X-Airgapper-Request-ID header; send that header to reuse an ID.",
+			Version: version,
+		},
+		Paths: make(map[string]*PathItem),
+		Components: OpenAPIComponents{
+			Schemas: map[string]*Schema{
+				connectErrorSchema: {
+					Type: "object",
+					Properties: map[string]*Schema{
+						"code":    {Type: "string", Description: "Connect error code (e.g. not_found, invalid_argument)"},
+						"message": {Type: "string"},
+						"details": {Type: "array", Description: "Includes a google.protobuf.Struct with the Airgapper error code and reason; the code is also sent in the X-Airgapper-Error-Code header", Items: &Schema{Type: "object"}},
+					},
+				},
+				apiErrorSchema: {
+					Type: "object",
+					Properties: map[string]*Schema{
+						"code":      {Type: "string", Description: "Airgapper error code (e.g. AG-1004)"},
+						"message":   {Type: "string"},
+						"details":   {Type: "object", Description: "Always includes reason, the code's name (e.g. REQUEST_EXPIRED)", AdditionalProperties: &Schema{Type: "string"}},
+						"requestId": {Type: "string", Description: "Correlation ID of the failed request"},
+					},
+				},
+			},
+		},
+	}
+
+	b := &schemaBuilder{schemas: doc.Components.Schemas}
+
+	var services []protoreflect.ServiceDescriptor
+	protoregistry.GlobalFiles.RangeFilesByPackage(protoPackage, func(fd protoreflect.FileDescriptor) bool {
+		for i := 0; i < fd.Services().Len(); i++ {
+			services = append(services, fd.Services().Get(i))
+		}
+		return true
+	})
+	sort.Slice(services, func(i, j int) bool { return services[i].FullName() < services[j].FullName() })
+
+	for _, svc := range services {
+		tag := string(svc.Name())
+		doc.Tags = append(doc.Tags, OpenAPITag{Name: tag})
+
+		for i := 0; i < svc.Methods().Len(); i++ {
+			m := svc.Methods().Get(i)
+			path := APIBasePath + "/" + string(svc.FullName()) + "/" + string(m.Name())
+			doc.Paths[path] = &PathItem{Post: &Operation{
+				OperationID: tag + "_" + string(m.Name()),
+				Summary:     splitCamel(string(m.Name())),
+				Tags:        []string{tag},
+				RequestBody: &RequestBody{
+					Required: true,
+					Content:  jsonContent(b.ref(m.Input())),
+				},
+				Responses: map[string]*Response{
+					"200":     {Description: "Success", Content: jsonContent(b.ref(m.Output()))},
+					"default": {Description: "Connect error", Content: jsonContent(componentRef(connectErrorSchema))},
+				},
+			}}
+		}
+	}
+
+	doc.Paths[APIBasePath+openAPIPath] = &PathItem{Get: &Operation{
+		OperationID: "GetOpenAPI",
+		Summary:     "OpenAPI document for this API",
+		Responses: map[string]*Response{
+			"200": {Description: "OpenAPI 3.0 document", Content: jsonContent(&Schema{Type: "object"})},
+		},
+	}}
+
+	doc.Components.Schemas["VersionInfo"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"apiVersion":        {Type: "string"},
+			"supportedVersions": {Type: "array", Items: &Schema{Type: "string"}},
+			"serverVersion":     {Type: "string"},
+			"protoPackage":      {Type: "string"},
+			"deprecations": {Type: "array", Items: &Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"path":      {Type: "string"},
+					"successor": {Type: "string"},
+					"sunset":    {Type: "string", Format: "date-time"},
+				},
+			}},
+		},
+	}
+	doc.Paths[APIBasePath+VersionPath] = &PathItem{Get: &Operation{
+		OperationID: "GetVersion",
+		Summary:     "API version and deprecation information",
+		Responses: map[string]*Response{
+			"200": {Description: "Version information", Content: jsonContent(componentRef("VersionInfo"))},
+		},
+	}}
+
+	doc.Components.Schemas["Capabilities"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"protocolVersion": {Type: "integer"},
+			"serverVersion":   {Type: "string"},
+			"apiVersions":     {Type: "array", Items: &Schema{Type: "string"}},
+			"requestTypes":    {Type: "array", Items: &Schema{Type: "string"}},
+			"cryptoSuites":    {Type: "array", Items: &Schema{Type: "string"}},
+			"features":        {Type: "array", Items: &Schema{Type: "string"}},
+		},
+	}
+	doc.Paths[APIBasePath+CapabilitiesPath] = &PathItem{Get: &Operation{
+		OperationID: "GetCapabilities",
+		Summary:     "Protocol version, request types, crypto suites and optional features this node supports",
+		Responses: map[string]*Response{
+			"200": {Description: "Capabilities", Content: jsonContent(componentRef("Capabilities"))},
+		},
+	}}
+
+	addBrowseOperation(doc)
+	addSnapshotDiffOperation(doc)
+	addRetentionPreviewOperation(doc)
+	addBackupWindowOperation(doc)
+	addBackupReportOperations(doc)
+	addDashboardOperation(doc)
+	addRestoreJobOperations(doc)
+	addRepairOperations(doc)
+	addPolicyAmendmentOperations(doc)
+	addHostVaultOperation(doc)
+	addHostPruneOperations(doc)
+	addStorageReposOperations(doc)
+	addProofChallengeOperation(doc)
+	addTemplateOperations(doc)
+	addDelegationOperations(doc)
+	addRecoveryOperations(doc)
+	addConsentOperationOperations(doc)
+	addRulesOperation(doc)
+	addUserOperations(doc)
+	addSessionOperations(doc)
+	addApprovalPageOperations(doc)
+	addActionLinkOperations(doc)
+	addEventOperations(doc)
+	addAuditExportOperation(doc)
+	addNotificationTargetOperations(doc)
+	addPanicOperations(doc)
+	addGenesisOperations(doc)
+	addLockOperations(doc)
+	addShareCheckOperation(doc)
+	addHostAnomalyInboxOperation(doc)
+
+	return doc
+}
+
+// addBrowseOperation documents the JSON filesystem browse endpoint
+func addBrowseOperation(doc *OpenAPIDocument) {
+	doc.Components.Schemas["FilesystemEntry"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"name":      {Type: "string"},
+			"path":      {Type: "string"},
+			"isDir":     {Type: "boolean"},
+			"isSymlink": {Type: "boolean"},
+			"hidden":    {Type: "boolean"},
+			"size":      {Type: "integer", Format: "int64"},
+			"modTime":   {Type: "string", Format: "date-time"},
+		},
+	}
+	doc.Components.Schemas["FilesystemListing"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"path":    {Type: "string", Description: "Resolved directory; empty when listing the allowed roots"},
+			"parent":  {Type: "string", Description: "Parent directory, if it is within the allowed roots"},
+			"entries": {Type: "array", Items: componentRef("FilesystemEntry")},
+			"total":   {Type: "integer"},
+			"offset":  {Type: "integer"},
+			"limit":   {Type: "integer"},
+		},
+	}
+	doc.Paths[APIBasePath+browsePath] = &PathItem{Get: &Operation{
+		OperationID: "BrowseFilesystem",
+		Summary: "List a directory within the allowed browse roots " +
+			"(query: path, offset, limit, hidden)",
+		Responses: map[string]*Response{
+			"200":     {Description: "Directory listing", Content: jsonContent(componentRef("FilesystemListing"))},
+			"default": {Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))},
+		},
+	}}
+}
+
+// addHostVaultOperation documents the host's vault metadata endpoint
+func addHostVaultOperation(doc *OpenAPIDocument) {
+	doc.Components.Schemas["UsageSample"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"date":      {Type: "string", Format: "date"},
+			"usedBytes": {Type: "integer", Format: "int64"},
+			"snapshots": {Type: "integer"},
+		},
+	}
+	doc.Components.Schemas["VaultMetadata"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"usedBytes":        {Type: "integer", Format: "int64"},
+			"snapshots":        {Type: "integer", Description: "Snapshot files stored (encrypted, not readable by the host)"},
+			"lastSnapshot":     {Type: "string", Format: "date-time", Description: "Last snapshot write seen by the storage server"},
+			"growth":           {Type: "array", Items: componentRef("UsageSample"), Description: "Daily size samples, oldest first"},
+			"lastOwnerContact": {Type: "string", Format: "date-time"},
+			"daysSinceContact": {Type: "integer", Description: "-1 if the owner has never connected"},
+		},
+	}
+	doc.Paths[APIBasePath+hostVaultPath] = &PathItem{Get: &Operation{
+		OperationID: "GetHostVault",
+		Summary:     "Metadata about the stored vault that a host can see without the key",
+		Responses: map[string]*Response{
+			"200":     {Description: "Vault metadata", Content: jsonContent(componentRef("VaultMetadata"))},
+			"default": {Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))},
+		},
+	}}
+}
+
+// addStorageReposOperations documents the host's repository provisioning
+func addStorageReposOperations(doc *OpenAPIDocument) {
+	doc.Components.Schemas["ProvisionedRepo"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"name":             {Type: "string"},
+			"owner_key_id":     {Type: "string"},
+			"owner_public_key": {Type: "string", Description: "Hex-encoded Ed25519 key the repository's writes must be signed by"},
+			"provisioned_at":   {Type: "string", Format: "date-time"},
+			"adopted":          {Type: "boolean", Description: "On disk before provisioning was required"},
+			"initialized":      {Type: "boolean", Description: "The owner has uploaded the repository's config"},
+		},
+	}
+	errorResponse := &Response{Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))}
+
+	doc.Paths[APIBasePath+storageReposPath] = &PathItem{
+		Get: &Operation{
+			OperationID: "ListStorageRepos",
+			Summary:     "List the repositories the host provisioned",
+			Responses: map[string]*Response{
+				"200": {Description: "Provisioned repositories", Content: jsonContent(&Schema{
+					Type:       "object",
+					Properties: map[string]*Schema{"repos": {Type: "array", Items: componentRef("ProvisionedRepo")}},
+				})},
+				"default": errorResponse,
+			},
+		},
+		Post: &Operation{
+			OperationID: "ProvisionStorageRepo",
+			Summary:     "Provision a repository for the owner to create",
+			RequestBody: &RequestBody{Required: true, Content: jsonContent(&Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"name":             {Type: "string"},
+					"owner_public_key": {Type: "string", Description: "Hex-encoded Ed25519 key allowed to write (default: the peer's key)"},
+				},
+			})},
+			Responses: map[string]*Response{
+				"201":     {Description: "The provisioned repository", Content: jsonContent(componentRef("ProvisionedRepo"))},
+				"default": errorResponse,
+			},
+		},
+	}
+}
+
+// addHostPruneOperations documents the host's prune window endpoints
+func addHostPruneOperations(doc *OpenAPIDocument) {
+	request := &RequestBody{Required: true, Content: jsonContent(&Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"deletion_id": {Type: "string", Description: "The approved deletion request the prune carries out"},
+			"repo":        {Type: "string"},
+		},
+	})}
+	errorResponse := &Response{Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))}
+
+	doc.Paths[APIBasePath+hostPruneBeginPath] = &PathItem{Post: &Operation{
+		OperationID: "BeginHostPrune",
+		Summary:     "Open a prune window: storage is read-only except for the repository being pruned",
+		RequestBody: request,
+		Responses: map[string]*Response{
+			"200": {Description: "The maintenance state, with the prune recorded", Content: jsonContent(&Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"reason":     {Type: "string"},
+					"since":      {Type: "string", Format: "date-time"},
+					"prune":      {Type: "string", Description: "Repository being pruned"},
+					"deletion":   {Type: "string"},
+					"pruneBytes": {Type: "integer", Format: "int64", Description: "The repository's size when the window opened"},
+				},
+			})},
+			"default": errorResponse,
+		},
+	}}
+	doc.Paths[APIBasePath+hostPruneEndPath] = &PathItem{Post: &Operation{
+		OperationID: "EndHostPrune",
+		Summary:     "Close a prune window, collecting partial uploads and re-baselining the repository",
+		RequestBody: request,
+		Responses: map[string]*Response{
+			"200": {Description: "What closing the window found", Content: jsonContent(&Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"repo":             {Type: "string"},
+					"deletion":         {Type: "string"},
+					"bytes":            {Type: "integer", Format: "int64"},
+					"freedBytes":       {Type: "integer", Format: "int64"},
+					"tempFilesRemoved": {Type: "integer"},
+					"anomalies":        {Type: "integer", Description: "Losses the prune doesn't explain"},
+					"integrity":        {Type: "object", Description: "The integrity check re-baselining the repository"},
+				},
+			})},
+			"default": errorResponse,
+		},
+	}}
+}
+
+// addProofChallengeOperation documents the proof-of-storage challenge endpoint
+func addProofChallengeOperation(doc *OpenAPIDocument) {
+	proofRange := map[string]*Schema{
+		"type":   {Type: "string", Description: "data or index"},
+		"id":     {Type: "string", Description: "Repository file ID (64 hex chars)"},
+		"offset": {Type: "integer", Format: "int64"},
+		"length": {Type: "integer", Format: "int64"},
+	}
+	doc.Components.Schemas["ProofRange"] = &Schema{Type: "object", Properties: proofRange}
+	doc.Components.Schemas["ProofChallenge"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"repo":   {Type: "string"},
+			"nonce":  {Type: "string", Description: "Hex-encoded random bytes (at least 16)"},
+			"ranges": {Type: "array", Items: componentRef("ProofRange")},
+		},
+	}
+	result := map[string]*Schema{
+		"hash":  {Type: "string", Description: "Hex SHA-256 of nonce followed by the range bytes"},
+		"error": {Type: "string"},
+	}
+	for k, v := range proofRange {
+		result[k] = v
+	}
+	doc.Components.Schemas["StorageProof"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"repo":        {Type: "string"},
+			"nonce":       {Type: "string"},
+			"results":     {Type: "array", Items: &Schema{Type: "object", Properties: result}},
+			"host_key_id": {Type: "string"},
+			"created_at":  {Type: "string", Format: "date-time"},
+			"signature":   {Type: "string", Description: "Host's Ed25519 signature, hex-encoded"},
+		},
+	}
+	doc.Paths[APIBasePath+ProofChallengePath] = &PathItem{Post: &Operation{
+		OperationID: "ProveStorage",
+		Summary:     "Hash challenged ranges of stored files with the owner's nonce and sign the answer (host)",
+		RequestBody: &RequestBody{Required: true, Content: jsonContent(componentRef("ProofChallenge"))},
+		Responses: map[string]*Response{
+			"200":     {Description: "Signed storage proof", Content: jsonContent(componentRef("StorageProof"))},
+			"default": {Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))},
+		},
+	}}
+}
+
+// addTemplateOperations documents the request template endpoints
+func addTemplateOperations(doc *OpenAPIDocument) {
+	doc.Components.Schemas["RequestTemplate"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"name":        {Type: "string"},
+			"snapshot_id": {Type: "string", Description: "Snapshot selector, e.g. latest"},
+			"paths":       {Type: "array", Items: &Schema{Type: "string"}},
+			"reason":      {Type: "string"},
+			"purpose":     {Type: "string", Description: "Empty for restores, or browse"},
+			"created_at":  {Type: "string", Format: "date-time"},
+		},
+	}
+	errorResponse := &Response{Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))}
+	template := &Response{Description: "Request template", Content: jsonContent(componentRef("RequestTemplate"))}
+
+	doc.Paths[APIBasePath+templatesPath] = &PathItem{
+		Get: &Operation{
+			OperationID: "ListRequestTemplates",
+			Summary:     "List named templates for recurring restore requests",
+			Responses: map[string]*Response{
+				"200": {Description: "Templates", Content: jsonContent(&Schema{
+					Type:       "object",
+					Properties: map[string]*Schema{"templates": {Type: "array", Items: componentRef("RequestTemplate")}},
+				})},
+				"default": errorResponse,
+			},
+		},
+		Post: &Operation{
+			OperationID: "CreateRequestTemplate",
+			Summary:     "Create a request template",
+			RequestBody: &RequestBody{Required: true, Content: jsonContent(componentRef("RequestTemplate"))},
+			Responses:   map[string]*Response{"201": template, "default": errorResponse},
+		},
+	}
+	doc.Paths[APIBasePath+templatesPath+"/{name}"] = &PathItem{
+		Get: &Operation{
+			OperationID: "GetRequestTemplate",
+			Summary:     "Get a request template",
+			Responses:   map[string]*Response{"200": template, "default": errorResponse},
+		},
+		Delete: &Operation{
+			OperationID: "DeleteRequestTemplate",
+			Summary:     "Delete a request template",
+			Responses:   map[string]*Response{"204": {Description: "Deleted"}, "default": errorResponse},
+		},
+	}
+	doc.Paths[APIBasePath+templatesPath+"/{name}/requests"] = &PathItem{Post: &Operation{
+		OperationID: "CreateRequestFromTemplate",
+		Summary:     "Create a restore request pre-filled from a template (body: optional reason to append)",
+		Responses: map[string]*Response{
+			"201":     {Description: "Restore request created"},
+			"default": errorResponse,
+		},
+	}}
+}
+
+// addDelegationOperations documents the approval delegation endpoints
+func addDelegationOperations(doc *OpenAPIDocument) {
+	doc.Components.Schemas["Delegation"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"id":                 {Type: "string"},
+			"from_key_holder_id": {Type: "string", Description: "Key holder whose authority is delegated"},
+			"to_key_holder_id":   {Type: "string", Description: "Key holder who may approve on their behalf"},
+			"starts_at":          {Type: "string", Format: "date-time"},
+			"expires_at":         {Type: "string", Format: "date-time"},
+			"reason":             {Type: "string"},
+			"signature":          {Type: "string", Format: "byte", Description: "Delegator's signature"},
+			"created_at":         {Type: "string", Format: "date-time"},
+			"revoked_at":         {Type: "string", Format: "date-time"},
+			"revoke_signature":   {Type: "string", Format: "byte"},
+		},
+	}
+	doc.Components.Schemas["DelegationEvent"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"at":            {Type: "string", Format: "date-time"},
+			"event":         {Type: "string", Description: "created, used, revoked or expired"},
+			"delegation_id": {Type: "string"},
+			"key_holder_id": {Type: "string"},
+			"request_id":    {Type: "string"},
+		},
+	}
+	errorResponse := &Response{Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))}
+	delegation := &Response{Description: "Delegation", Content: jsonContent(componentRef("Delegation"))}
+
+	doc.Paths[APIBasePath+DelegationsPath] = &PathItem{
+		Get: &Operation{
+			OperationID: "ListDelegations",
+			Summary:     "List approval delegations and their audit trail",
+			Responses: map[string]*Response{
+				"200": {Description: "Delegations", Content: jsonContent(&Schema{
+					Type: "object",
+					Properties: map[string]*Schema{
+						"delegations": {Type: "array", Items: componentRef("Delegation")},
+						"events":      {Type: "array", Items: componentRef("DelegationEvent")},
+					},
+				})},
+				"default": errorResponse,
+			},
+		},
+		Post: &Operation{
+			OperationID: "CreateDelegation",
+			Summary:     "Register a delegation signed by the delegating key holder",
+			RequestBody: &RequestBody{Required: true, Content: jsonContent(componentRef("Delegation"))},
+			Responses:   map[string]*Response{"201": delegation, "default": errorResponse},
+		},
+	}
+	doc.Paths[APIBasePath+DelegationsPath+"/{id}/revoke"] = &PathItem{Post: &Operation{
+		OperationID: "RevokeDelegation",
+		Summary:     "End a delegation early",
+		RequestBody: &RequestBody{Required: true, Content: jsonContent(&Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{"signature": {Type: "string", Description: "Hex signature by the delegating key holder"}},
+		})},
+		Responses: map[string]*Response{"200": delegation, "default": errorResponse},
+	}}
+}
+
+// addRecoveryOperations documents the social recovery endpoints
+func addRecoveryOperations(doc *OpenAPIDocument) {
+	doc.Components.Schemas["Recovery"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"id":                      {Type: "string"},
+			"missing_key_holder_id":   {Type: "string", Description: "Key holder said to have lost their key"},
+			"missing_key_holder_name": {Type: "string"},
+			"reason":                  {Type: "string"},
+			"opened_at":               {Type: "string", Format: "date-time"},
+			"usable_at":               {Type: "string", Format: "date-time", Description: "End of the waiting period"},
+			"signatures": {Type: "array", Items: &Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"contact_id":   {Type: "string"},
+					"contact_name": {Type: "string"},
+					"signature":    {Type: "string", Format: "byte"},
+					"signed_at":    {Type: "string", Format: "date-time"},
+				},
+			}},
+			"cancelled_at": {Type: "string", Format: "date-time"},
+			"cancelled_by": {Type: "string", Description: "Key holder who cancelled"},
+			"used_at":      {Type: "string", Format: "date-time"},
+			"request_id":   {Type: "string", Description: "Restore request the recovery approved"},
+		},
+	}
+	doc.Components.Schemas["RecoveryEvent"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"at":          {Type: "string", Format: "date-time"},
+			"event":       {Type: "string", Description: "opened, signed, reminded, cancelled or used"},
+			"recovery_id": {Type: "string"},
+			"actor_id":    {Type: "string"},
+			"request_id":  {Type: "string"},
+		},
+	}
+	errorResponse := &Response{Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))}
+	recovery := &Response{Description: "Recovery", Content: jsonContent(componentRef("Recovery"))}
+	signed := func(signer, description string) *RequestBody {
+		return &RequestBody{Required: true, Content: jsonContent(&Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				signer:      {Type: "string"},
+				"signature": {Type: "string", Description: description},
+			},
+		})}
+	}
+
+	doc.Paths[APIBasePath+RecoveriesPath] = &PathItem{
+		Get: &Operation{
+			OperationID: "ListRecoveries",
+			Summary:     "List the social recovery policy, recoveries and their audit trail",
+			Responses: map[string]*Response{
+				"200": {Description: "Recoveries", Content: jsonContent(&Schema{
+					Type: "object",
+					Properties: map[string]*Schema{
+						"policy":     {Type: "object", Description: "Recovery contacts, threshold and waiting period"},
+						"recoveries": {Type: "array", Items: componentRef("Recovery")},
+						"events":     {Type: "array", Items: componentRef("RecoveryEvent")},
+					},
+				})},
+				"default": errorResponse,
+			},
+		},
+		Post: &Operation{
+			OperationID: "OpenRecovery",
+			Summary:     "Open a recovery signed by a recovery contact; everyone is notified",
+			RequestBody: &RequestBody{Required: true, Content: jsonContent(componentRef("Recovery"))},
+			Responses:   map[string]*Response{"201": recovery, "default": errorResponse},
+		},
+	}
+	doc.Paths[APIBasePath+RecoveriesPath+"/{id}"] = &PathItem{Get: &Operation{
+		OperationID: "GetRecovery",
+		Summary:     "Get a recovery, for contacts to sign",
+		Responses:   map[string]*Response{"200": recovery, "default": errorResponse},
+	}}
+	doc.Paths[APIBasePath+RecoveriesPath+"/{id}/sign"] = &PathItem{Post: &Operation{
+		OperationID: "SignRecovery",
+		Summary:     "Add a recovery contact's signature",
+		RequestBody: signed("contact_id", "Hex signature by the contact"),
+		Responses:   map[string]*Response{"200": recovery, "default": errorResponse},
+	}}
+	doc.Paths[APIBasePath+RecoveriesPath+"/{id}/cancel"] = &PathItem{Post: &Operation{
+		OperationID: "CancelRecovery",
+		Summary:     "Cancel a recovery, signed by any key holder",
+		RequestBody: signed("key_holder_id", "Hex signature by the key holder"),
+		Responses:   map[string]*Response{"200": recovery, "default": errorResponse},
+	}}
+	doc.Paths[APIBasePath+RecoveriesPath+"/{id}/use"] = &PathItem{Post: &Operation{
+		OperationID: "UseRecovery",
+		Summary:     "Approve a restore request in place of the missing key holder",
+		RequestBody: &RequestBody{Required: true, Content: jsonContent(&Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{"request_id": {Type: "string"}},
+		})},
+		Responses: map[string]*Response{"200": {Description: "Approved, with the request's approval progress"}, "default": errorResponse},
+	}}
+}
+
+// addConsentOperationOperations documents the generic consent-gated
+// operation endpoints
+func addConsentOperationOperations(doc *OpenAPIDocument) {
+	timestamp := &Schema{Type: "string", Format: "date-time"}
+	doc.Components.Schemas["ConsentOperation"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"id":                 {Type: "string"},
+			"type":               {Type: "string", Description: "Registered request type: restore, deletion or an operation type"},
+			"requester":          {Type: "string"},
+			"reason":             {Type: "string"},
+			"payload":            {Type: "object", Description: "The operation's details, as its type defines them"},
+			"status":             {Type: "string", Description: "pending, approved, denied or expired"},
+			"created_at":         timestamp,
+			"expires_at":         timestamp,
+			"approved_at":        timestamp,
+			"approved_by":        {Type: "string"},
+			"executed_at":        timestamp,
+			"execution_error":    {Type: "string", Description: "Why the approved operation last failed to execute"},
+			"correlation_id":     {Type: "string"},
+			"required_approvals": {Type: "integer"},
+			"approvals":          {Type: "array", Items: &Schema{Type: "object"}},
+		},
+	}
+	doc.Components.Schemas["OperationSignData"] = &Schema{
+		Type:        "object",
+		Description: "What a key holder signs (Ed25519, over the SHA-256 of its JSON) to approve an operation",
+		Properties: map[string]*Schema{
+			"scope":         {Type: "string", Description: "The operation type's signature scope"},
+			"request_id":    {Type: "string"},
+			"type":          {Type: "string"},
+			"requester":     {Type: "string"},
+			"reason":        {Type: "string"},
+			"payload":       {Type: "object"},
+			"created_at":    {Type: "integer", Format: "int64"},
+			"key_holder_id": {Type: "string"},
+		},
+	}
+	errorResponse := &Response{Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))}
+	operation := &Response{Description: "Operation", Content: jsonContent(componentRef("ConsentOperation"))}
+
+	doc.Paths[APIBasePath+OperationsPath] = &PathItem{
+		Get: &Operation{
+			OperationID: "ListConsentOperations",
+			Summary:     "List the registered request types and pending requests, restores and deletions included",
+			Responses: map[string]*Response{
+				"200": {Description: "Operation types and pending operations", Content: jsonContent(&Schema{
+					Type: "object",
+					Properties: map[string]*Schema{
+						"types": {Type: "array", Items: &Schema{
+							Type: "object",
+							Properties: map[string]*Schema{
+								"name":        {Type: "string"},
+								"description": {Type: "string"},
+								"ttl_seconds": {Type: "integer", Format: "int64"},
+								"scope":       {Type: "string"},
+							},
+						}},
+						"operations": {Type: "array", Items: componentRef("ConsentOperation")},
+					},
+				})},
+				"default": errorResponse,
+			},
+		},
+		Post: &Operation{
+			OperationID: "CreateConsentOperation",
+			Summary:     "Request an operation of a registered type; it runs once enough key holders approve",
+			RequestBody: &RequestBody{Required: true, Content: jsonContent(&Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"type":    {Type: "string"},
+					"reason":  {Type: "string"},
+					"payload": {Type: "object"},
+				},
+			})},
+			Responses: map[string]*Response{"201": operation, "default": errorResponse},
+		},
+	}
+	doc.Paths[APIBasePath+OperationsPath+"/{id}"] = &PathItem{Get: &Operation{
+		OperationID: "GetConsentOperation",
+		Summary:     "Get an operation, with the sign data for ?key_holder_id=",
+		Responses: map[string]*Response{
+			"200": {Description: "Operation", Content: jsonContent(&Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"operation": componentRef("ConsentOperation"),
+					"sign_data": componentRef("OperationSignData"),
+				},
+			})},
+			"default": errorResponse,
+		},
+	}}
+	doc.Paths[APIBasePath+OperationsPath+"/{id}/approve"] = &PathItem{Post: &Operation{
+		OperationID: "ApproveConsentOperation",
+		Summary:     "Add a key holder's signed approval; the operation is executed once enough approve",
+		RequestBody: &RequestBody{Required: true, Content: jsonContent(&Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"key_holder_id": {Type: "string"},
+				"signature":     {Type: "string", Description: "Hex signature over the operation's sign data"},
+			},
+		})},
+		Responses: map[string]*Response{"200": operation, "default": errorResponse},
+	}}
+	doc.Paths[APIBasePath+OperationsPath+"/{id}/deny"] = &PathItem{Post: &Operation{
+		OperationID: "DenyConsentOperation",
+		Summary:     "Deny a pending operation",
+		Responses:   map[string]*Response{"200": {Description: "Denied"}, "default": errorResponse},
+	}}
+	doc.Paths[APIBasePath+OperationsPath+"/{id}/execute"] = &PathItem{Post: &Operation{
+		OperationID: "ExecuteConsentOperation",
+		Summary:     "Retry an approved operation whose execution failed",
+		Responses:   map[string]*Response{"200": operation, "default": errorResponse},
+	}}
+}
+
+// addRulesOperation documents the approval rules endpoint
+func addRulesOperation(doc *OpenAPIDocument) {
+	doc.Components.Schemas["RuleDecision"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"at":         {Type: "string", Format: "date-time"},
+			"request_id": {Type: "string"},
+			"decision":   {Type: "string", Description: "deny, approve or manual"},
+			"rule":       {Type: "string", Description: "The rule that decided, e.g. deny_paths"},
+			"reason":     {Type: "string"},
+		},
+	}
+	doc.Paths[APIBasePath+RulesPath] = &PathItem{Get: &Operation{
+		OperationID: "GetApprovalRules",
+		Summary:     "Approval rules for incoming restore requests and their decisions",
+		Responses: map[string]*Response{
+			"200": {Description: "Rules and decisions", Content: jsonContent(&Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"rules": {Type: "object", Properties: map[string]*Schema{
+						"deny_paths":             {Type: "array", Items: &Schema{Type: "string"}},
+						"deny_unknown_keys":      {Type: "boolean"},
+						"auto_approve_max_bytes": {Type: "integer"},
+						"auto_approve_hours":     {Type: "string", Description: "Local hours such as 09:00-18:00"},
+					}},
+					"decisions": {Type: "array", Items: componentRef("RuleDecision")},
+				},
+			})},
+			"default": {Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))},
+		},
+	}}
+}
+
+// addSnapshotDiffOperation documents the JSON snapshot diff endpoint
+func addSnapshotDiffOperation(doc *OpenAPIDocument) {
+	stats := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"files":      {Type: "integer"},
+			"dirs":       {Type: "integer"},
+			"others":     {Type: "integer"},
+			"data_blobs": {Type: "integer"},
+			"tree_blobs": {Type: "integer"},
+			"bytes":      {Type: "integer", Format: "int64"},
+		},
+	}
+	paths := &Schema{Type: "array", Items: &Schema{Type: "string"}}
+	doc.Components.Schemas["SnapshotDiff"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"source_snapshot": {Type: "string"},
+			"target_snapshot": {Type: "string"},
+			"added":           paths,
+			"removed":         paths,
+			"modified":        paths,
+			"changed_files":   {Type: "integer"},
+			"added_stats":     stats,
+			"removed_stats":   stats,
+			"size_delta":      {Type: "integer", Format: "int64", Description: "Net change in stored bytes from a to b"},
+		},
+	}
+	doc.Paths[APIBasePath+snapshotsPath+"{a}/diff/{b}"] = &PathItem{Get: &Operation{
+		OperationID: "DiffSnapshots",
+		Summary:     "List files added, removed and modified between snapshots a and b (owner only)",
+		Responses: map[string]*Response{
+			"200":     {Description: "Snapshot diff", Content: jsonContent(componentRef("SnapshotDiff"))},
+			"default": {Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))},
+		},
+	}}
+}
+
+// addRetentionPreviewOperation documents the JSON retention preview endpoint
+func addRetentionPreviewOperation(doc *OpenAPIDocument) {
+	snapshots := &Schema{Type: "array", Items: &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"id":     {Type: "string"},
+			"time":   {Type: "string", Format: "date-time"},
+			"paths":  {Type: "array", Items: &Schema{Type: "string"}},
+			"pinned": {Type: "boolean", Description: "Kept only because it is pinned"},
+		},
+	}}
+	doc.Components.Schemas["RetentionPlan"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"policy": {Type: "string", Description: "The retention policy, e.g. \"7 daily, 4 weekly\""},
+			"keep":   snapshots,
+			"remove": snapshots,
+		},
+	}
+	doc.Paths[APIBasePath+retentionPreviewPath] = &PathItem{Get: &Operation{
+		OperationID: "PreviewRetention",
+		Summary:     "Snapshots the configured retention policy would keep and remove, without removing any (owner only)",
+		Responses: map[string]*Response{
+			"200":     {Description: "Retention plan", Content: jsonContent(componentRef("RetentionPlan"))},
+			"default": {Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))},
+		},
+	}}
+}
+
+// addBackupWindowOperation documents the owner's backup window endpoint
+func addBackupWindowOperation(doc *OpenAPIDocument) {
+	doc.Components.Schemas["BackupWindow"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"private":              {Type: "boolean", Description: "The owner keeps its schedule private; nothing else is set"},
+			"schedule":             {Type: "string", Description: "Backup schedule, e.g. \"0 2 * * *\""},
+			"timezone":             {Type: "string", Description: "IANA timezone the schedule's times are in"},
+			"next_starts":          {Type: "array", Items: &Schema{Type: "string", Format: "date-time"}},
+			"runs":                 {Type: "integer", Description: "Recent scheduled backups the averages are over"},
+			"avg_duration_seconds": {Type: "integer", Format: "int64"},
+			"avg_upload_bytes":     {Type: "integer", Format: "int64", Description: "Bytes stored per backup after deduplication and compression"},
+		},
+	}
+	doc.Paths[APIBasePath+BackupWindowPath] = &PathItem{Get: &Operation{
+		OperationID: "GetBackupWindow",
+		Summary:     "When the owner's scheduled backups run and how long and how much they upload, for its host (owner only)",
+		Responses: map[string]*Response{
+			"200":     {Description: "Backup window", Content: jsonContent(componentRef("BackupWindow"))},
+			"default": {Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))},
+		},
+	}}
+}
+
+// addBackupReportOperations documents the JSON backup report endpoints
+func addBackupReportOperations(doc *OpenAPIDocument) {
+	count := &Schema{Type: "integer"}
+	bytes := &Schema{Type: "integer", Format: "int64"}
+	doc.Components.Schemas["BackupReport"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"snapshot_id":      {Type: "string"},
+			"paths":            {Type: "array", Items: &Schema{Type: "string"}},
+			"scheduled":        {Type: "boolean"},
+			"started_at":       {Type: "string", Format: "date-time"},
+			"finished_at":      {Type: "string", Format: "date-time"},
+			"files_new":        count,
+			"files_changed":    count,
+			"files_unmodified": count,
+			"bytes_added":      bytes,
+			"bytes_stored":     {Type: "integer", Format: "int64", Description: "What bytes_added took in the repository after compression"},
+			"total_files":      count,
+			"total_bytes":      bytes,
+			"skipped":          {Type: "integer", Description: "Files restic couldn't read and left out"},
+			"largest_new": {Type: "array", Description: "The 10 largest new files, largest first", Items: &Schema{
+				Type:       "object",
+				Properties: map[string]*Schema{"path": {Type: "string"}, "size": bytes},
+			}},
+			"diff": {Type: "object", Description: "Changes since the previous snapshot of the same paths, if enabled", Properties: map[string]*Schema{
+				"parent_id":  {Type: "string"},
+				"added":      count,
+				"removed":    count,
+				"modified":   count,
+				"size_delta": bytes,
+			}},
+		},
+	}
+	errorResponse := &Response{Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))}
+
+	doc.Paths[APIBasePath+backupsPath] = &PathItem{Get: &Operation{
+		OperationID: "ListBackupReports",
+		Summary:     "Reports of recent backup runs, newest first (query: limit)",
+		Responses: map[string]*Response{
+			"200": {Description: "Backup reports", Content: jsonContent(&Schema{
+				Type:       "object",
+				Properties: map[string]*Schema{"reports": {Type: "array", Items: componentRef("BackupReport")}},
+			})},
+			"default": errorResponse,
+		},
+	}}
+	doc.Paths[APIBasePath+backupsPath+"/{id}/report"] = &PathItem{Get: &Operation{
+		OperationID: "GetBackupReport",
+		Summary:     "Report of the backup run that created snapshot id (full or 8+ character ID)",
+		Responses: map[string]*Response{
+			"200":     {Description: "Backup report", Content: jsonContent(componentRef("BackupReport"))},
+			"default": errorResponse,
+		},
+	}}
+
+	doc.Components.Schemas["DedupProjection"] = &Schema{
+		Type:        "object",
+		Description: "When the storage quota fills at the rate the repository grew over the last 30 days",
+		Properties: map[string]*Schema{
+			"quota_bytes":    bytes,
+			"used_bytes":     bytes,
+			"used_estimated": {Type: "boolean", Description: "used_bytes is the sum of what backups stored, not the host's measurement"},
+			"bytes_per_day":  bytes,
+			"days_to_full":   {Type: "number"},
+			"full_at":        {Type: "string", Format: "date-time", Description: "Absent when the repository isn't growing"},
+		},
+	}
+	doc.Paths[APIBasePath+dedupStatsPath] = &PathItem{Get: &Operation{
+		OperationID: "GetDedupStats",
+		Summary:     "Raw vs stored size of backup runs, and when the quota fills (query: limit on points)",
+		Responses: map[string]*Response{
+			"200": {Description: "Deduplication stats", Content: jsonContent(&Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"runs":              count,
+					"raw_bytes":         bytes,
+					"new_bytes":         bytes,
+					"stored_bytes":      bytes,
+					"dedup_ratio":       {Type: "number", Description: "raw_bytes / new_bytes"},
+					"compression_ratio": {Type: "number", Description: "new_bytes / stored_bytes"},
+					"savings_pct":       {Type: "number", Description: "Share of raw_bytes never stored"},
+					"points": {Type: "array", Description: "Backup runs, oldest first", Items: &Schema{
+						Type: "object",
+						Properties: map[string]*Schema{
+							"at":           {Type: "string", Format: "date-time"},
+							"snapshot_id":  {Type: "string"},
+							"raw_bytes":    bytes,
+							"new_bytes":    bytes,
+							"stored_bytes": bytes,
+						},
+					}},
+					"projection": componentRef("DedupProjection"),
+				},
+			})},
+			"default": errorResponse,
+		},
+	}}
+}
+
+// addRestoreJobOperations documents the restore job endpoints
+func addRestoreJobOperations(doc *OpenAPIDocument) {
+	count := &Schema{Type: "integer"}
+	bytes := &Schema{Type: "integer", Format: "int64"}
+	timestamp := &Schema{Type: "string", Format: "date-time"}
+	doc.Components.Schemas["RestoreJob"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"id":           {Type: "string"},
+			"request_id":   {Type: "string"},
+			"target":       {Type: "string"},
+			"conflict":     {Type: "string", Description: "skip, overwrite or keep-both"},
+			"suffix":       {Type: "string"},
+			"status":       {Type: "string", Description: "queued, scheduled, running, completed, failed or cancelled"},
+			"error":        {Type: "string"},
+			"interrupted":  {Type: "boolean", Description: "A shutdown stopped the job; it resumes when next picked up"},
+			"snapshot_id":  {Type: "string", Description: "The snapshot the request's selector resolved to"},
+			"preflight":    componentRef("RestorePreflight"),
+			"files":        count,
+			"restored":     count,
+			"percent_done": {Type: "number"},
+			"bytes_done":   bytes,
+			"bytes_total":  bytes,
+			"conflicts": {Type: "array", Description: "Files that existed before the restore, and what it did with each", Items: &Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"path":        {Type: "string"},
+					"action":      {Type: "string"},
+					"restored_as": {Type: "string"},
+					"error":       {Type: "string"},
+				},
+			}},
+			"attempts":    count,
+			"runner":      {Type: "string"},
+			"heartbeat":   timestamp,
+			"created_at":  timestamp,
+			"starts_at":   {Type: "string", Format: "date-time", Description: "When a scheduled job's restore window opens"},
+			"started_at":  timestamp,
+			"finished_at": timestamp,
+		},
+	}
+	doc.Components.Schemas["RestoreJobRequest"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"request_id": {Type: "string"},
+			"target":     {Type: "string", Description: "Absolute directory to restore into; / for the original locations"},
+			"conflict":   {Type: "string", Description: "skip (default), overwrite or keep-both"},
+			"suffix":     {Type: "string", Description: "Suffix for restored copies with keep-both"},
+		},
+	}
+	doc.Components.Schemas["RestorePreflight"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"target":        {Type: "string"},
+			"snapshot_id":   {Type: "string"},
+			"conflict":      {Type: "string"},
+			"files":         count,
+			"conflicts":     count,
+			"restore_bytes": {Type: "integer", Format: "int64", Description: "The snapshot's restore size"},
+			"needed_bytes":  {Type: "integer", Format: "int64", Description: "New space the restore takes, after files skipped or overwritten"},
+			"free_bytes":    bytes,
+			"checks": {Type: "array", Items: &Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"name":    {Type: "string", Description: "target, free_space, backup_loop or existing_files"},
+					"status":  {Type: "string", Description: "ok, warn or fail"},
+					"message": {Type: "string"},
+				},
+			}},
+		},
+	}
+	errorResponse := &Response{Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))}
+	job := &Response{Description: "Restore job", Content: jsonContent(componentRef("RestoreJob"))}
+
+	doc.Paths[APIBasePath+restoresPath] = &PathItem{
+		Get: &Operation{
+			OperationID: "ListRestoreJobs",
+			Summary:     "List restore jobs, oldest first",
+			Responses: map[string]*Response{
+				"200": {Description: "Restore jobs", Content: jsonContent(&Schema{
+					Type:       "object",
+					Properties: map[string]*Schema{"jobs": {Type: "array", Items: componentRef("RestoreJob")}},
+				})},
+				"default": errorResponse,
+			},
+		},
+		Post: &Operation{
+			OperationID: "CreateRestoreJob",
+			Summary:     "Restore an approved request in the background",
+			RequestBody: &RequestBody{Required: true, Content: jsonContent(componentRef("RestoreJobRequest"))},
+			Responses:   map[string]*Response{"202": job, "default": errorResponse},
+		},
+	}
+	doc.Paths[APIBasePath+restoresPath+"/"+restorePreflightID] = &PathItem{
+		Post: &Operation{
+			OperationID: "PreflightRestore",
+			Summary:     "Check a restore's target without submitting it",
+			RequestBody: &RequestBody{Required: true, Content: jsonContent(componentRef("RestoreJobRequest"))},
+			Responses: map[string]*Response{
+				"200":     {Description: "Preflight report, whether or not its checks pass", Content: jsonContent(componentRef("RestorePreflight"))},
+				"default": errorResponse,
+			},
+		},
+	}
+	doc.Paths[APIBasePath+restoresPath+"/{id}"] = &PathItem{
+		Get: &Operation{
+			OperationID: "GetRestoreJob",
+			Summary:     "Get a restore job and its progress",
+			Responses:   map[string]*Response{"200": job, "default": errorResponse},
+		},
+		Delete: &Operation{
+			OperationID: "CancelRestoreJob",
+			Summary:     "Cancel a restore job",
+			Responses:   map[string]*Response{"200": job, "default": errorResponse},
+		},
+	}
+}
+
+// addRepairOperations documents the JSON repair endpoints
+func addRepairOperations(doc *OpenAPIDocument) {
+	doc.Components.Schemas["RepairRequest"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"repo":       {Type: "string"},
+			"file":       {Type: "string", Description: "Relative to the repository, e.g. data/ab/ab12..."},
+			"source":     {Type: "string", Enum: []string{"read", "check"}},
+			"detectedAt": {Type: "string", Format: "date-time"},
+			"receivedAt": {Type: "string", Format: "date-time"},
+			"salvaged":   {Type: "boolean", Description: "The damaged file was removed from the repository; the next backup uploads what it held"},
+			"repairedAt": {Type: "string", Format: "date-time"},
+			"lastError":  {Type: "string"},
+		},
+	}
+	errorResponse := &Response{Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))}
+
+	doc.Paths[APIBasePath+repairsPath] = &PathItem{Get: &Operation{
+		OperationID: "ListRepairs",
+		Summary:     "Files the host found corrupt, and whether a backup has repaired them",
+		Responses: map[string]*Response{
+			"200": {Description: "Repair requests, oldest first", Content: jsonContent(&Schema{
+				Type:       "object",
+				Properties: map[string]*Schema{"repairs": {Type: "array", Items: componentRef("RepairRequest")}},
+			})},
+			"default": errorResponse,
+		},
+	}}
+	doc.Paths[APIBasePath+repairInboxPath] = &PathItem{Post: &Operation{
+		OperationID: "ReceiveRepairRequests",
+		Summary:     "Queue files the host found corrupt for repair by the next backup (sent by the host)",
+		RequestBody: &RequestBody{Required: true, Content: jsonContent(&Schema{Type: "array", Items: componentRef("RepairRequest")})},
+		Responses: map[string]*Response{
+			"202":     {Description: "Queued"},
+			"default": errorResponse,
+		},
+	}}
+}
+
+// addPolicyAmendmentOperations documents the JSON policy renegotiation endpoints
+func addPolicyAmendmentOperations(doc *OpenAPIDocument) {
+	terms := map[string]*Schema{
+		"retention_days":     {Type: "integer"},
+		"deletion_mode":      {Type: "string", Enum: []string{"both-required", "owner-only", "time-lock-only", "never"}},
+		"append_only_locked": {Type: "boolean"},
+		"max_storage_bytes":  {Type: "integer", Format: "int64"},
+	}
+	proposal := &Schema{Type: "object", Properties: map[string]*Schema{
+		"proposed_by": {Type: "string", Enum: []string{"owner", "host"}},
+		"reason":      {Type: "string"},
+	}}
+	for name, schema := range terms {
+		proposal.Properties[name] = schema
+	}
+	doc.Components.Schemas["PolicyAmendmentProposal"] = proposal
+	doc.Components.Schemas["PolicyAmendmentSignature"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"role":      {Type: "string", Enum: []string{"owner", "host"}},
+			"signature": {Type: "string", Description: "Hex-encoded Ed25519 signature over proposed_hash (sign only)"},
+		},
+	}
+	doc.Components.Schemas["PolicyAmendment"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"id":            {Type: "string"},
+			"policy_id":     {Type: "string"},
+			"base_hash":     {Type: "string", Description: "Hash of the policy revision being amended"},
+			"proposed_by":   {Type: "string"},
+			"reason":        {Type: "string"},
+			"terms":         {Type: "object", Properties: terms},
+			"proposed":      {Type: "object", Description: "Proposed policy revision"},
+			"proposed_hash": {Type: "string", Description: "Hash both parties sign"},
+			"status":        {Type: "string", Enum: []string{"pending", "applied", "rejected", "superseded"}},
+			"awaiting":      {Type: "array", Items: &Schema{Type: "string"}},
+			"created_at":    {Type: "string", Format: "date-time"},
+			"resolved_at":   {Type: "string", Format: "date-time"},
+			"resolved_by":   {Type: "string"},
+		},
+	}
+
+	errResponse := &Response{Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))}
+	amendmentResponse := func(desc string) *Response {
+		return &Response{Description: desc, Content: jsonContent(componentRef("PolicyAmendment"))}
+	}
+	signBody := &RequestBody{Required: true, Content: jsonContent(componentRef("PolicyAmendmentSignature"))}
+
+	doc.Paths[APIBasePath+policyAmendmentsPath] = &PathItem{
+		Get: &Operation{
+			OperationID: "ListPolicyAmendments",
+			Summary:     "List proposed policy amendments",
+			Responses: map[string]*Response{
+				"200": {Description: "Amendments", Content: jsonContent(&Schema{
+					Type:       "object",
+					Properties: map[string]*Schema{"amendments": {Type: "array", Items: componentRef("PolicyAmendment")}},
+				})},
+				"default": errResponse,
+			},
+		},
+		Post: &Operation{
+			OperationID: "ProposePolicyAmendment",
+			Summary:     "Propose new policy terms for both parties to sign",
+			RequestBody: &RequestBody{Required: true, Content: jsonContent(componentRef("PolicyAmendmentProposal"))},
+			Responses:   map[string]*Response{"201": amendmentResponse("Proposed amendment"), "default": errResponse},
+		},
+	}
+	doc.Paths[APIBasePath+policyAmendmentsPath+"/{id}/sign"] = &PathItem{Post: &Operation{
+		OperationID: "SignPolicyAmendment",
+		Summary:     "Add a party's signature; the amendment is applied once both have signed",
+		RequestBody: signBody,
+		Responses:   map[string]*Response{"200": amendmentResponse("Updated amendment"), "default": errResponse},
+	}}
+	doc.Paths[APIBasePath+policyAmendmentsPath+"/{id}/reject"] = &PathItem{Post: &Operation{
+		OperationID: "RejectPolicyAmendment",
+		Summary:     "Decline a pending amendment",
+		RequestBody: signBody,
+		Responses:   map[string]*Response{"200": amendmentResponse("Rejected amendment"), "default": errResponse},
+	}}
+	doc.Paths[APIBasePath+policyAmendmentInboxPath] = &PathItem{Post: &Operation{
+		OperationID: "ReceivePolicyAmendmentNotice",
+		Summary:     "Notice that the counterparty has an amendment awaiting this node's signature (sent by the peer)",
+		RequestBody: &RequestBody{Required: true, Content: jsonContent(componentRef("PolicyAmendment"))},
+		Responses:   map[string]*Response{"202": {Description: "Received"}, "default": errResponse},
+	}}
+	doc.Paths[APIBasePath+policyHistoryPath] = &PathItem{Get: &Operation{
+		OperationID: "GetPolicyHistory",
+		Summary:     "Signed policy history, oldest first, and whether the chain verifies",
+		Responses: map[string]*Response{
+			"200": {Description: "Policy history", Content: jsonContent(&Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"policies": {Type: "array", Items: &Schema{Type: "object"}},
+					"verified": {Type: "boolean"},
+					"error":    {Type: "string"},
+				},
+			})},
+			"default": errResponse,
+		},
+	}}
+}
+
+// schemaBuilder converts message descriptors into component schemas
+type schemaBuilder struct {
+	schemas map[string]*Schema
+}
+
+// ref registers the message schema (and its dependencies) and returns a reference to it
+func (b *schemaBuilder) ref(md protoreflect.MessageDescriptor) *Schema {
+	if wkt := wellKnownSchema(md); wkt != nil {
+		return wkt
+	}
+
+	name := string(md.Name())
+	if md.Parent() != nil && md.Parent() != md.ParentFile() {
+		// Nested messages (e.g. map entries) are qualified by their parent
+		name = strings.TrimPrefix(string(md.FullName()), protoPackage+".")
+		name = strings.ReplaceAll(name, ".", "_")
+	}
+
+	if _, ok := b.schemas[name]; !ok {
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		// Register before recursing so self-referencing messages terminate
+		b.schemas[name] = schema
+		fields := md.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			f := fields.Get(i)
+			schema.Properties[f.JSONName()] = b.field(f)
+		}
+	}
+
+	return componentRef(name)
+}
+
+func (b *schemaBuilder) field(f protoreflect.FieldDescriptor) *Schema {
+	if f.IsMap() {
+		return &Schema{Type: "object", AdditionalProperties: b.singular(f.MapValue())}
+	}
+	if f.IsList() {
+		return &Schema{Type: "array", Items: b.singular(f)}
+	}
+	return b.singular(f)
+}
+
+func (b *schemaBuilder) singular(f protoreflect.FieldDescriptor) *Schema {
+	switch f.Kind() {
+	case protoreflect.BoolKind:
+		return &Schema{Type: "boolean"}
+	case protoreflect.StringKind:
+		return &Schema{Type: "string"}
+	case protoreflect.BytesKind:
+		return &Schema{Type: "string", Format: "byte"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &Schema{Type: "integer", Format: "int32"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// protojson encodes 64-bit integers as strings
+		return &Schema{Type: "string", Format: "int64"}
+	case protoreflect.FloatKind:
+		return &Schema{Type: "number", Format: "float"}
+	case protoreflect.DoubleKind:
+		return &Schema{Type: "number", Format: "double"}
+	case protoreflect.EnumKind:
+		values := f.Enum().Values()
+		enum := make([]string, values.Len())
+		for i := 0; i < values.Len(); i++ {
+			enum[i] = string(values.Get(i).Name())
+		}
+		return &Schema{Type: "string", Enum: enum}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return b.ref(f.Message())
+	default:
+		return &Schema{}
+	}
+}
+
+// wellKnownSchema maps google.protobuf types to their protojson representation
+func wellKnownSchema(md protoreflect.MessageDescriptor) *Schema {
+	switch md.FullName() {
+	case "google.protobuf.Timestamp":
+		return &Schema{Type: "string", Format: "date-time"}
+	case "google.protobuf.Duration":
+		return &Schema{Type: "string", Description: "Duration in seconds with an 's' suffix (e.g. \"3.5s\")"}
+	case "google.protobuf.Empty":
+		return &Schema{Type: "object"}
+	}
+	return nil
+}
+
+func componentRef(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func jsonContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
+
+// splitCamel turns "GetStorageStatus" into "Get storage status"
+func splitCamel(s string) string {
+	var out strings.Builder
+	for i, r := range s {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			out.WriteByte(' ')
+			r += 'a' - 'A'
+		}
+		out.WriteRune(r)
+	}
+	return out.String()
+}
+
+// --- HTTP handlers ---
+
+// Routes relative to APIBasePath
+const (
+	openAPIPath = "/openapi.json"
+	apiDocsPath = "/docs"
+)
+
+// openAPIHandler serves the generated OpenAPI document. The document is built
+// once on first request since the registered descriptors never change.
+func openAPIHandler(version string) http.Handler {
+	var (
+		once sync.Once
+		data []byte
+		err  error
+	)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+
+		once.Do(func() {
+			data, err = json.MarshalIndent(BuildOpenAPI(version), "", "  ")
+		})
+		if err != nil {
+			writeError(w, apperrors.Newf(apperrors.CodeInternal, "failed to build OpenAPI document: %w", err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodHead {
+			return
+		}
+		_, _ = w.Write(data)
+	})
+}
+
+// apiDocsHandler serves the bundled API explorer page
+func apiDocsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r2 := r.Clone(r.Context())
+		r2.URL.Path = "/docs.html"
+		webui.Handler().ServeHTTP(w, r2)
+	})
+}
+
+// addNotificationTargetOperations documents the notification target endpoints
+func addNotificationTargetOperations(doc *OpenAPIDocument) {
+	doc.Components.Schemas["NotificationTarget"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"id":      {Type: "string", Description: "Generated from the type, e.g. telegram-1, if empty on create"},
+			"type":    {Type: "string", Enum: emergency.ProviderTypes},
+			"enabled": {Type: "boolean"},
+			"settings": {
+				Type:                 "object",
+				Description:          "Type-specific settings; secrets are shown as " + emergency.Redacted + ", and sending that back keeps them",
+				AdditionalProperties: &Schema{Type: "string"},
+			},
+			"priority":     {Type: "string", Enum: emergency.Priorities, Description: "Priority of messages that have none"},
+			"events":       {Type: "array", Items: &Schema{Type: "string", Enum: emergency.EventNames}, Description: "Events sent to this target; empty for every enabled event"},
+			"min_priority": {Type: "string", Enum: emergency.Priorities, Description: "Drop messages less urgent than this"},
+		},
+	}
+	errorResponse := &Response{Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))}
+	target := &Response{Description: "Notification target", Content: jsonContent(componentRef("NotificationTarget"))}
+	body := &RequestBody{Required: true, Content: jsonContent(componentRef("NotificationTarget"))}
+
+	doc.Paths[APIBasePath+notificationTargetsPath] = &PathItem{
+		Get: &Operation{
+			OperationID: "ListNotificationTargets",
+			Summary:     "List notification targets",
+			Responses: map[string]*Response{
+				"200": {Description: "Targets", Content: jsonContent(&Schema{
+					Type:       "object",
+					Properties: map[string]*Schema{"targets": {Type: "array", Items: componentRef("NotificationTarget")}},
+				})},
+				"default": errorResponse,
+			},
+		},
+		Post: &Operation{
+			OperationID: "CreateNotificationTarget",
+			Summary:     "Add a notification target",
+			RequestBody: body,
+			Responses:   map[string]*Response{"201": target, "default": errorResponse},
+		},
+	}
+	doc.Paths[APIBasePath+notificationTargetsPath+"/{id}"] = &PathItem{
+		Get: &Operation{
+			OperationID: "GetNotificationTarget",
+			Summary:     "Get a notification target",
+			Responses:   map[string]*Response{"200": target, "default": errorResponse},
+		},
+		Post: &Operation{
+			OperationID: "UpdateNotificationTarget",
+			Summary:     "Change a notification target; omitted fields keep their value",
+			RequestBody: body,
+			Responses:   map[string]*Response{"200": target, "default": errorResponse},
+		},
+		Delete: &Operation{
+			OperationID: "DeleteNotificationTarget",
+			Summary:     "Remove a notification target",
+			Responses:   map[string]*Response{"204": {Description: "Deleted"}, "default": errorResponse},
+		},
+	}
+	doc.Paths[APIBasePath+notificationTargetsPath+"/{id}/test"] = &PathItem{Post: &Operation{
+		OperationID: "TestNotificationTarget",
+		Summary:     "Send a test notification to a target, whatever its filters",
+		Responses: map[string]*Response{
+			"200":     {Description: "Sent"},
+			"default": errorResponse,
+		},
+	}}
+}
+
+// addUserOperations documents the local user account endpoints
+func addUserOperations(doc *OpenAPIDocument) {
+	doc.Components.Schemas["User"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"name":         {Type: "string"},
+			"role":         {Type: "string", Description: "viewer, approver or admin"},
+			"has_password": {Type: "boolean"},
+			"has_token":    {Type: "boolean"},
+			"created_at":   {Type: "string", Format: "date-time"},
+			"updated_at":   {Type: "string", Format: "date-time"},
+		},
+	}
+	doc.Components.Schemas["UserToken"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"user":  componentRef("User"),
+			"token": {Type: "string", Description: "API token, shown only once; send it as Authorization: Bearer <token>"},
+		},
+	}
+	errorResponse := &Response{Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))}
+	user := &Response{Description: "User", Content: jsonContent(componentRef("User"))}
+	token := &Response{Description: "User and their new API token", Content: jsonContent(componentRef("UserToken"))}
+
+	doc.Paths[APIBasePath+UsersPath] = &PathItem{
+		Get: &Operation{
+			OperationID: "ListUsers",
+			Summary:     "List local user accounts",
+			Responses: map[string]*Response{
+				"200": {Description: "Users", Content: jsonContent(&Schema{
+					Type:       "object",
+					Properties: map[string]*Schema{"users": {Type: "array", Items: componentRef("User")}},
+				})},
+				"default": errorResponse,
+			},
+		},
+		Post: &Operation{
+			OperationID: "CreateUser",
+			Summary:     "Create a user account; the first must be an admin, and turns authentication on",
+			RequestBody: &RequestBody{Required: true, Content: jsonContent(&Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"name":     {Type: "string"},
+					"role":     {Type: "string", Description: "viewer, approver or admin"},
+					"password": {Type: "string", Description: "Omit for a token-only account"},
+				},
+			})},
+			Responses: map[string]*Response{"201": token, "default": errorResponse},
+		},
+	}
+	doc.Paths[APIBasePath+UsersPath+"/{name}"] = &PathItem{
+		Get: &Operation{
+			OperationID: "GetUser",
+			Summary:     "Get a user account",
+			Responses:   map[string]*Response{"200": user, "default": errorResponse},
+		},
+		Delete: &Operation{
+			OperationID: "DeleteUser",
+			Summary:     "Delete a user account; the last admin can't be deleted while others remain",
+			Responses:   map[string]*Response{"204": {Description: "Deleted"}, "default": errorResponse},
+		},
+	}
+	doc.Paths[APIBasePath+UsersPath+"/{name}/role"] = &PathItem{Post: &Operation{
+		OperationID: "SetUserRole",
+		Summary:     "Change a user's role; the last admin can't be demoted",
+		RequestBody: &RequestBody{Required: true, Content: jsonContent(&Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{"role": {Type: "string"}},
+		})},
+		Responses: map[string]*Response{"200": user, "default": errorResponse},
+	}}
+	doc.Paths[APIBasePath+UsersPath+"/{name}/password"] = &PathItem{Post: &Operation{
+		OperationID: "SetUserPassword",
+		Summary:     "Change a user's password",
+		RequestBody: &RequestBody{Required: true, Content: jsonContent(&Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{"password": {Type: "string"}},
+		})},
+		Responses: map[string]*Response{"200": user, "default": errorResponse},
+	}}
+	doc.Paths[APIBasePath+UsersPath+"/{name}/token"] = &PathItem{Post: &Operation{
+		OperationID: "IssueUserToken",
+		Summary:     "Replace a user's API token, revoking the old one",
+		Responses:   map[string]*Response{"200": token, "default": errorResponse},
+	}}
+}
+
+// addSessionOperations documents the browser login session endpoints
+func addSessionOperations(doc *OpenAPIDocument) {
+	doc.Components.Schemas["Session"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"id":           {Type: "string"},
+			"user":         {Type: "string"},
+			"user_agent":   {Type: "string"},
+			"remote_addr":  {Type: "string"},
+			"created_at":   {Type: "string", Format: "date-time"},
+			"last_seen_at": {Type: "string", Format: "date-time"},
+			"expires_at":   {Type: "string", Format: "date-time"},
+			"current":      {Type: "boolean", Description: "The session making the call"},
+		},
+	}
+	doc.Components.Schemas["SessionStatus"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"auth_required": {Type: "boolean", Description: "False while the node has no user accounts"},
+			"user":          componentRef("User"),
+			"session":       componentRef("Session"),
+			"csrf_token":    {Type: "string", Description: "Send in the " + CSRFHeader + " header on every change made with the session cookie"},
+		},
+	}
+	errorResponse := &Response{Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))}
+	status := &Response{Description: "Login status", Content: jsonContent(componentRef("SessionStatus"))}
+
+	doc.Paths[APIBasePath+SessionPath] = &PathItem{Get: &Operation{
+		OperationID: "GetSession",
+		Summary:     "Who is logged in, and the session's CSRF token",
+		Responses:   map[string]*Response{"200": status, "default": errorResponse},
+	}}
+	doc.Paths[APIBasePath+SessionLoginPath] = &PathItem{Post: &Operation{
+		OperationID: "Login",
+		Summary:     "Log in with a user name and password, setting the " + SessionCookie + " cookie; failed logins are rate limited",
+		RequestBody: &RequestBody{Required: true, Content: jsonContent(&Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"name":     {Type: "string"},
+				"password": {Type: "string"},
+			},
+		})},
+		Responses: map[string]*Response{"200": status, "default": errorResponse},
+	}}
+	doc.Paths[APIBasePath+SessionLogoutPath] = &PathItem{Post: &Operation{
+		OperationID: "Logout",
+		Summary:     "End the current session",
+		Responses:   map[string]*Response{"204": {Description: "Logged out"}, "default": errorResponse},
+	}}
+	doc.Paths[APIBasePath+SessionsPath] = &PathItem{Get: &Operation{
+		OperationID: "ListSessions",
+		Summary:     "List your login sessions, or everyone's for admins",
+		Responses: map[string]*Response{
+			"200": {Description: "Sessions", Content: jsonContent(&Schema{
+				Type:       "object",
+				Properties: map[string]*Schema{"sessions": {Type: "array", Items: componentRef("Session")}},
+			})},
+			"default": errorResponse,
+		},
+	}}
+	doc.Paths[APIBasePath+SessionsPath+"/{id}/revoke"] = &PathItem{Post: &Operation{
+		OperationID: "RevokeSession",
+		Summary:     "End one of your sessions, or anyone's for admins",
+		Responses:   map[string]*Response{"204": {Description: "Revoked"}, "default": errorResponse},
+	}}
+}
+
+// addApprovalPageOperations documents the mobile approval page's API
+func addApprovalPageOperations(doc *OpenAPIDocument) {
+	doc.Components.Schemas["PendingApproval"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"id":                 {Type: "string"},
+			"requester":          {Type: "string"},
+			"snapshot_id":        {Type: "string"},
+			"paths":              {Type: "array", Items: &Schema{Type: "string"}},
+			"reason":             {Type: "string"},
+			"purpose":            {Type: "string", Description: "Empty for restores, export-keys or browse"},
+			"template":           {Type: "string"},
+			"size_bytes":         {Type: "integer", Format: "int64"},
+			"requester_context":  {Type: "object", Description: "The device the request was made from"},
+			"approvals":          {Type: "integer"},
+			"required_approvals": {Type: "integer"},
+			"created_at":         {Type: "string", Format: "date-time"},
+			"expires_at":         {Type: "string", Format: "date-time"},
+		},
+	}
+	errorResponse := &Response{Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))}
+	options := &Response{Description: "Options to pass to the browser's WebAuthn call, binary fields base64url-encoded", Content: jsonContent(&Schema{Type: "object"})}
+	credential := &Schema{Type: "object", Description: "The browser's WebAuthn answer, binary fields base64url-encoded"}
+	tokenBody := func(withCredential bool) *RequestBody {
+		props := map[string]*Schema{"token": {Type: "string", Description: "From 'airgapper passkey enroll'"}}
+		if withCredential {
+			props["credential"] = credential
+		}
+		return &RequestBody{Required: true, Content: jsonContent(&Schema{Type: "object", Properties: props})}
+	}
+
+	doc.Paths[APIBasePath+approvalsPath] = &PathItem{Get: &Operation{
+		OperationID: "GetPasskeySession",
+		Summary:     "The passkey sign-in the " + ApprovalCookie + " cookie belongs to",
+		Responses: map[string]*Response{
+			"200": {Description: "Signed in", Content: jsonContent(&Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"passkey":    {Type: "string"},
+					"expires_at": {Type: "string", Format: "date-time"},
+				},
+			})},
+			"default": errorResponse,
+		},
+	}}
+	doc.Paths[APIBasePath+approvalsEnrollOptionPath] = &PathItem{Post: &Operation{
+		OperationID: "GetPasskeyEnrollOptions",
+		Summary:     "Start registering a passkey with an enrollment token",
+		RequestBody: tokenBody(false),
+		Responses:   map[string]*Response{"200": options, "default": errorResponse},
+	}}
+	doc.Paths[APIBasePath+approvalsEnrollPath] = &PathItem{Post: &Operation{
+		OperationID: "EnrollPasskey",
+		Summary:     "Register a passkey, using up its enrollment token",
+		RequestBody: tokenBody(true),
+		Responses:   map[string]*Response{"201": {Description: "Registered"}, "default": errorResponse},
+	}}
+	doc.Paths[APIBasePath+approvalsLoginOptionPath] = &PathItem{Post: &Operation{
+		OperationID: "GetPasskeyLoginOptions",
+		Summary:     "Start signing in with a passkey",
+		Responses:   map[string]*Response{"200": options, "default": errorResponse},
+	}}
+	doc.Paths[APIBasePath+approvalsLoginPath] = &PathItem{Post: &Operation{
+		OperationID: "PasskeyLogin",
+		Summary:     "Sign in with a passkey, setting the " + ApprovalCookie + " cookie",
+		RequestBody: &RequestBody{Required: true, Content: jsonContent(&Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{"credential": credential},
+		})},
+		Responses: map[string]*Response{
+			"200": {Description: "Signed in", Content: jsonContent(&Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"passkey":    {Type: "string"},
+					"csrf_token": {Type: "string", Description: "Send in the " + CSRFHeader + " header when deciding requests"},
+					"expires_at": {Type: "string", Format: "date-time"},
+				},
+			})},
+			"default": errorResponse,
+		},
+	}}
+	doc.Paths[APIBasePath+approvalsLogoutPath] = &PathItem{Post: &Operation{
+		OperationID: "PasskeyLogout",
+		Summary:     "End the passkey sign-in",
+		Responses:   map[string]*Response{"204": {Description: "Signed out"}, "default": errorResponse},
+	}}
+	doc.Paths[APIBasePath+approvalsRequestsPath] = &PathItem{Get: &Operation{
+		OperationID: "ListPendingApprovals",
+		Summary:     "Pending restore requests, for a key holder signed in with a passkey",
+		Responses: map[string]*Response{
+			"200": {Description: "Pending requests", Content: jsonContent(&Schema{
+				Type:       "object",
+				Properties: map[string]*Schema{"requests": {Type: "array", Items: componentRef("PendingApproval")}},
+			})},
+			"default": errorResponse,
+		},
+	}}
+	doc.Paths[APIBasePath+approvalsRequestsPath+"/{id}/approve"] = &PathItem{Post: &Operation{
+		OperationID: "ApproveWithPasskey",
+		Summary:     "Approve a request as this node's key holder; the node signs the approval with its own key",
+		Responses:   map[string]*Response{"200": {Description: "Approved, with the request's approval progress"}, "default": errorResponse},
+	}}
+	doc.Paths[APIBasePath+approvalsRequestsPath+"/{id}/deny"] = &PathItem{Post: &Operation{
+		OperationID: "DenyWithPasskey",
+		Summary:     "Deny a request",
+		Responses:   map[string]*Response{"200": {Description: "Denied"}, "default": errorResponse},
+	}}
+}
+
+func addActionLinkOperations(doc *OpenAPIDocument) {
+	errorResponse := &Response{Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))}
+	tokenBody := &RequestBody{Required: true, Content: jsonContent(&Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"token": {Type: "string", Description: "From a notification's approve or deny link"}},
+	})}
+
+	doc.Paths[APIBasePath+actionsPath] = &PathItem{Post: &Operation{
+		OperationID: "InspectActionLink",
+		Summary:     "What a notification link would do, without doing it or using it up",
+		RequestBody: tokenBody,
+		Responses: map[string]*Response{
+			"200": {Description: "The link's action and request", Content: jsonContent(&Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"action":     {Type: "string", Enum: []string{actionlink.ActionApprove, actionlink.ActionDeny}},
+					"request":    componentRef("PendingApproval"),
+					"expires_at": {Type: "string", Format: "date-time"},
+				},
+			})},
+			"default": errorResponse,
+		},
+	}}
+	doc.Paths[APIBasePath+actionsConfirmPath] = &PathItem{Post: &Operation{
+		OperationID: "ConfirmActionLink",
+		Summary:     "Approve or deny as a notification link says, using it up; the node signs approvals with its own key",
+		RequestBody: tokenBody,
+		Responses:   map[string]*Response{"200": {Description: "Approved, with the request's approval progress, or denied"}, "default": errorResponse},
+	}}
+}
+
+// addEventOperations documents the activity feed
+func addEventOperations(doc *OpenAPIDocument) {
+	doc.Components.Schemas["Event"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"id":      {Type: "integer", Format: "int64", Description: "Sequence number, used as the cursor"},
+			"time":    {Type: "string", Format: "date-time"},
+			"type":    {Type: "string", Description: "e.g. request.created, backup.failed, integrity.failed, storage.write_denied"},
+			"source":  {Type: "string", Enum: []string{events.SourceConsent, events.SourceBackup, events.SourceStorage, events.SourceIntegrity}},
+			"subject": {Type: "string", Description: "What it happened to, e.g. a request ID or file path"},
+			"message": {Type: "string"},
+			"data":    {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+		},
+	}
+	errorResponse := &Response{Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))}
+
+	doc.Paths[APIBasePath+eventsPath] = &PathItem{Get: &Operation{
+		OperationID: "ListEvents",
+		Summary:     "Events after a cursor, oldest first (query: after, limit, type as types or categories, comma-separated, source)",
+		Responses: map[string]*Response{
+			"200": {Description: "A page of events", Content: jsonContent(&Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"events":      {Type: "array", Items: componentRef("Event")},
+					"next_cursor": {Type: "integer", Format: "int64", Description: "Pass as after to get the following events"},
+					"has_more":    {Type: "boolean"},
+				},
+			})},
+			"default": errorResponse,
+		},
+	}}
+	doc.Paths[APIBasePath+eventsStreamPath] = &PathItem{Get: &Operation{
+		OperationID: "StreamEvents",
+		Summary:     "Events as they happen, as server-sent events (query: after, type, source; resumes from Last-Event-ID)",
+		Responses: map[string]*Response{
+			"200": {Description: "An event stream", Content: map[string]*MediaType{
+				"text/event-stream": {Schema: componentRef("Event")},
+			}},
+			"default": errorResponse,
+		},
+	}}
+}
+
+// addDashboardOperation documents the dashboard's aggregated data
+func addDashboardOperation(doc *OpenAPIDocument) {
+	timestamp := &Schema{Type: "string", Format: "date-time"}
+	bytes := &Schema{Type: "integer", Format: "int64"}
+	doc.Components.Schemas["Dashboard"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"generated_at": timestamp,
+			"name":         {Type: "string"},
+			"role":         {Type: "string"},
+			"locked_down":  {Type: "boolean"},
+			"last_backup": {Type: "object", Description: "Absent before the first backup", Properties: map[string]*Schema{
+				"at":                   timestamp,
+				"result":               {Type: "string", Enum: []string{backupSucceeded, backupPartial, backupFailed}},
+				"snapshot_id":          {Type: "string"},
+				"scheduled":            {Type: "boolean"},
+				"error":                {Type: "string"},
+				"consecutive_failures": {Type: "integer"},
+			}},
+			"next_backup": {Type: "string", Format: "date-time", Description: "Absent without a running schedule"},
+			"schedule":    {Type: "string"},
+			"timezone":    {Type: "string", Description: "The schedule's IANA timezone, Local for the system's"},
+			"size_trend": {Type: "array", Description: "The last 30 backups' sizes, oldest first", Items: &Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"at":          timestamp,
+					"snapshot_id": {Type: "string"},
+					"total_bytes": bytes,
+					"bytes_added": bytes,
+				},
+			}},
+			"dedup": {Type: "object", Description: "Absent before the first backup", Properties: map[string]*Schema{
+				"dedup_ratio":       {Type: "number"},
+				"compression_ratio": {Type: "number"},
+				"savings_pct":       {Type: "number"},
+				"projection":        componentRef("DedupProjection"),
+			}},
+			"pending_approvals": {Type: "integer"},
+			"storage": {Type: "object", Description: "Absent on nodes without storage", Properties: map[string]*Schema{
+				"healthy":         {Type: "boolean"},
+				"problems":        {Type: "array", Items: &Schema{Type: "string"}},
+				"running":         {Type: "boolean"},
+				"read_only":       {Type: "boolean"},
+				"used_bytes":      bytes,
+				"quota_usage_pct": {Type: "integer"},
+				"disk_usage_pct":  {Type: "integer"},
+				"disk_free_bytes": bytes,
+			}},
+			"peers": {Type: "array", Items: &Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"name":        {Type: "string"},
+					"address":     {Type: "string"},
+					"reachable":   {Type: "boolean"},
+					"latency_ms":  {Type: "integer", Format: "int64"},
+					"api_version": {Type: "string"},
+					"error":       {Type: "string"},
+				},
+			}},
+			"integrity": {Type: "object", Properties: map[string]*Schema{
+				"healthy": {Type: "boolean", Description: "False if the last integrity check or restore test failed"},
+				"last_check": {Type: "object", Properties: map[string]*Schema{
+					"at":            timestamp,
+					"passed":        {Type: "boolean"},
+					"corrupt_files": {Type: "integer"},
+					"missing_files": {Type: "integer"},
+				}},
+				"last_restore_test": {Type: "object", Properties: map[string]*Schema{
+					"at":             timestamp,
+					"passed":         {Type: "boolean"},
+					"snapshot_id":    {Type: "string"},
+					"files_verified": {Type: "integer"},
+				}},
+			}},
+		},
+	}
+	doc.Paths[APIBasePath+dashboardPath] = &PathItem{Get: &Operation{
+		OperationID: "GetDashboard",
+		Summary:     "Everything a dashboard shows in one call: backups, schedule, approvals, storage, peers and integrity",
+		Responses: map[string]*Response{
+			"200":     {Description: "Dashboard data", Content: jsonContent(componentRef("Dashboard"))},
+			"default": {Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))},
+		},
+	}}
+}
+
+// addAuditExportOperation documents the audit export
+func addAuditExportOperation(doc *OpenAPIDocument) {
+	doc.Components.Schemas["AuditRecord"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"time":           {Type: "string", Format: "date-time"},
+			"log":            {Type: "string", Enum: []string{auditlog.LogStorage, auditlog.LogEvents}},
+			"sequence":       {Type: "integer", Format: "int64", Description: "Audit chain sequence or event ID"},
+			"operation":      {Type: "string", Description: "Audited operation, e.g. DELETE, or event type"},
+			"subject":        {Type: "string", Description: "Path, or what the event happened to"},
+			"details":        {Type: "string"},
+			"success":        {Type: "boolean", Description: "Audited operations only"},
+			"error":          {Type: "string"},
+			"correlation_id": {Type: "string"},
+			"source":         {Type: "string", Description: "Subsystem that published the event"},
+			"data":           {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+			"hash":           {Type: "string", Description: "Audit chain entries: content hash"},
+			"previous_hash":  {Type: "string"},
+			"signature":      {Type: "string", Description: "Audit chain entries: the host's signature"},
+			"key_id":         {Type: "string"},
+		},
+	}
+	doc.Paths[APIBasePath+auditExportPath] = &PathItem{Get: &Operation{
+		OperationID: "ExportAudit",
+		Summary:     "Audit records as a download (query: format jsonl or csv, log storage or events, from and to as RFC 3339 times or dates)",
+		Responses: map[string]*Response{
+			"200": {Description: "One record per line", Content: map[string]*MediaType{
+				"application/x-ndjson": {Schema: componentRef("AuditRecord")},
+				"text/csv":             {Schema: &Schema{Type: "string"}},
+			}},
+			"default": {Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))},
+		},
+	}}
+}
+
+// addPanicOperations documents the panic button and lifting a lockdown
+func addPanicOperations(doc *OpenAPIDocument) {
+	doc.Components.Schemas["Lockdown"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"id":           {Type: "string", Description: "Shared by every node the lockdown was propagated to"},
+			"reason":       {Type: "string"},
+			"triggered_by": {Type: "string"},
+			"origin":       {Type: "string", Description: "Node the panic button was pressed on"},
+			"since":        {Type: "string", Format: "date-time"},
+		},
+	}
+	doc.Components.Schemas["PanicStatus"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"locked_down": {Type: "boolean"},
+			"lockdown":    componentRef("Lockdown"),
+			"threshold":   {Type: "integer", Description: "Signatures needed to lift the lockdown"},
+			"signers":     {Type: "array", Items: &Schema{Type: "string"}, Description: "Key IDs that can sign the lift"},
+		},
+	}
+	status := &Response{Description: "Lockdown status", Content: jsonContent(componentRef("PanicStatus"))}
+	errResponse := &Response{Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))}
+
+	doc.Paths[APIBasePath+PanicPath] = &PathItem{
+		Get: &Operation{
+			OperationID: "GetPanicStatus",
+			Summary:     "Whether the node is locked down, and who can lift it",
+			Responses:   map[string]*Response{"200": status, "default": errResponse},
+		},
+		Post: &Operation{
+			OperationID: "Panic",
+			Summary:     "Lock the node down: refuse approvals and new requests, freeze storage and tell the peer and key holders (lockdown is set when another node propagates its own)",
+			RequestBody: &RequestBody{Required: true, Content: jsonContent(&Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"reason":       {Type: "string"},
+					"triggered_by": {Type: "string"},
+					"lockdown":     componentRef("Lockdown"),
+				},
+			})},
+			Responses: map[string]*Response{"200": status, "default": errResponse},
+		},
+	}
+	doc.Paths[APIBasePath+PanicLiftPath] = &PathItem{Post: &Operation{
+		OperationID: "LiftLockdown",
+		Summary:     "Lift a lockdown with enough key holders' signatures over its ID",
+		RequestBody: &RequestBody{Required: true, Content: jsonContent(&Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"id": {Type: "string"},
+				"signatures": {Type: "array", Items: &Schema{
+					Type: "object",
+					Properties: map[string]*Schema{
+						"key_id":    {Type: "string"},
+						"signature": {Type: "string", Format: "byte", Description: "Ed25519 signature over \"airgapper lockdown lift\\x00\" + id"},
+					},
+				}},
+				"propagated": {Type: "boolean", Description: "Set when another node passes the lift on"},
+			},
+		})},
+		Responses: map[string]*Response{"200": status, "default": errResponse},
+	}}
+}
+
+// addGenesisOperations documents the vault's genesis record
+func addGenesisOperations(doc *OpenAPIDocument) {
+	keyHolder := map[string]*Schema{
+		"id":         {Type: "string"},
+		"name":       {Type: "string"},
+		"public_key": {Type: "string", Format: "byte"},
+	}
+	doc.Components.Schemas["GenesisCountersignature"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"key_holder_id": {Type: "string"},
+			"name":          {Type: "string"},
+			"public_key":    {Type: "string", Format: "byte"},
+			"signed_at":     {Type: "string", Format: "date-time"},
+			"signature":     {Type: "string", Description: "Hex-encoded Ed25519 signature over the record's hash, key holder ID and signed_at"},
+		},
+	}
+	doc.Components.Schemas["GenesisRecord"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"vault_id":           {Type: "string"},
+			"created_at":         {Type: "string", Format: "date-time"},
+			"repo_url":           {Type: "string"},
+			"secret_fingerprint": {Type: "string", Description: "Identifies the repository password without revealing it"},
+			"mode":               {Type: "string", Enum: []string{"sss", "consensus"}},
+			"threshold":          {Type: "integer"},
+			"total_shares":       {Type: "integer"},
+			"share_indexes":      {Type: "array", Items: &Schema{Type: "integer"}, Description: "SSS share indexes dealt, the owner's first"},
+			"key_holders":        {Type: "array", Items: &Schema{Type: "object", Properties: keyHolder}},
+			"policy_hash":        {Type: "string", Description: "Hash of the settings that decide who can approve a restore"},
+			"owner_key_id":       {Type: "string"},
+			"owner_signature":    {Type: "string"},
+			"countersignatures":  {Type: "array", Items: componentRef("GenesisCountersignature")},
+		},
+	}
+	record := &Response{Description: "Genesis record", Content: jsonContent(componentRef("GenesisRecord"))}
+	errResponse := &Response{Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))}
+
+	doc.Paths[APIBasePath+GenesisPath] = &PathItem{Get: &Operation{
+		OperationID: "GetGenesis",
+		Summary:     "The vault's signed genesis record (not found if the vault predates genesis records)",
+		Responses:   map[string]*Response{"200": record, "default": errResponse},
+	}}
+	doc.Paths[APIBasePath+GenesisCountersignPath] = &PathItem{Post: &Operation{
+		OperationID: "CountersignGenesis",
+		Summary:     "Add a registered key holder's countersignature, replacing an earlier one by the same key",
+		RequestBody: &RequestBody{Required: true, Content: jsonContent(componentRef("GenesisCountersignature"))},
+		Responses:   map[string]*Response{"200": record, "default": errResponse},
+	}}
+}
+
+// addLockOperations documents listing and removing repository locks
+func addLockOperations(doc *OpenAPIDocument) {
+	doc.Components.Schemas["RepositoryLock"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"id":        {Type: "string"},
+			"time":      {Type: "string", Format: "date-time"},
+			"exclusive": {Type: "boolean"},
+			"hostname":  {Type: "string"},
+			"username":  {Type: "string"},
+			"pid":       {Type: "integer"},
+			"stale":     {Type: "boolean", Description: "Old enough to have been left behind by an interrupted command"},
+		},
+	}
+	doc.Components.Schemas["RepositoryLocks"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"locks":   {Type: "array", Items: componentRef("RepositoryLock")},
+			"stale":   {Type: "integer"},
+			"removed": {Type: "integer", Description: "How many locks an unlock removed"},
+		},
+	}
+	locks := &Response{Description: "Repository locks", Content: jsonContent(componentRef("RepositoryLocks"))}
+	errResponse := &Response{Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))}
+
+	doc.Paths[APIBasePath+locksPath] = &PathItem{Get: &Operation{
+		OperationID: "ListLocks",
+		Summary:     "The repository's locks, flagging stale ones (owner only)",
+		Responses:   map[string]*Response{"200": locks, "default": errResponse},
+	}}
+	doc.Paths[APIBasePath+unlockPath] = &PathItem{Post: &Operation{
+		OperationID: "Unlock",
+		Summary:     "Remove stale locks; returns the locks that remain",
+		RequestBody: &RequestBody{Content: jsonContent(&Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"all": {Type: "boolean", Description: "Remove active locks too, which can corrupt a running backup; needs the force-unlock override"},
+			},
+		})},
+		Responses: map[string]*Response{"200": locks, "default": errResponse},
+	}}
+}
+
+// addShareCheckOperation documents the host's key share check
+func addShareCheckOperation(doc *OpenAPIDocument) {
+	doc.Components.Schemas["ShareChallenge"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"owner_index":       {Type: "integer"},
+			"nonce":             {Type: "string", Description: "Hex-encoded, 32 random bytes"},
+			"time":              {Type: "string", Format: "date-time"},
+			"owner_proof":       {Type: "string", Description: "Proof the owner holds its share, keyed on the nonce and time"},
+			"owner_fingerprint": {Type: "string", Description: "Enrolls the owner's share with a host that hasn't recorded it; first check only"},
+		},
+	}
+	doc.Components.Schemas["ShareAnswer"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"host_index":     {Type: "integer"},
+			"host_proof":     {Type: "string", Description: "Proof the host holds its share"},
+			"owner_enrolled": {Type: "boolean", Description: "False when the host has no fingerprint for the owner's share; retry with one"},
+			"owner_verified": {Type: "boolean"},
+		},
+	}
+	doc.Paths[APIBasePath+ShareCheckPath] = &PathItem{Post: &Operation{
+		OperationID: "CheckShares",
+		Summary:     "Check the owner's key share against the fingerprint the host recorded, and prove the host's in turn (SSS mode, sent by the owner)",
+		RequestBody: &RequestBody{Required: true, Content: jsonContent(componentRef("ShareChallenge"))},
+		Responses: map[string]*Response{
+			"200":     {Description: "The host's answer", Content: jsonContent(componentRef("ShareAnswer"))},
+			"default": {Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))},
+		},
+	}}
+}
+
+// addHostAnomalyInboxOperation documents where the host reports storage
+// anomalies to the owner
+func addHostAnomalyInboxOperation(doc *OpenAPIDocument) {
+	doc.Components.Schemas["StorageAnomaly"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"kind": {Type: "string", Enum: []string{
+				storage.AnomalySnapshotsMissing, storage.AnomalyDataMissing, storage.AnomalyConfigChanged,
+				storage.AnomalyRepoShrunk, storage.AnomalyAuditChainReset, storage.AnomalyCorruptRead,
+			}},
+			"repo":       {Type: "string"},
+			"detail":     {Type: "string"},
+			"detectedAt": {Type: "string", Format: "date-time"},
+		},
+	}
+	doc.Paths[APIBasePath+hostAnomalyInboxPath] = &PathItem{Post: &Operation{
+		OperationID: "ReceiveHostAnomalies",
+		Summary:     "Unexplained changes the host's storage server found in the owner's backups (sent by the host)",
+		RequestBody: &RequestBody{Required: true, Content: jsonContent(&Schema{Type: "array", Items: componentRef("StorageAnomaly")})},
+		Responses: map[string]*Response{
+			"202":     {Description: "Received"},
+			"default": {Description: "Error", Content: jsonContent(componentRef(apiErrorSchema))},
+		},
+	}}
+}
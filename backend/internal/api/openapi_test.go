@@ -0,0 +1,116 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildOpenAPI(t *testing.T) {
+	doc := BuildOpenAPI("v1.2.3")
+
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	assert.Equal(t, "v1.2.3", doc.Info.Version)
+
+	t.Run("every RPC has a POST operation", func(t *testing.T) {
+		for _, path := range []string{
+			"/airgapper.v1.HealthService/GetStatus",
+			"/airgapper.v1.RestoreRequestService/ApproveRequest",
+			"/airgapper.v1.ScheduleService/UpdateSchedule",
+			"/airgapper.v1.StorageService/GetStorageStatus",
+		} {
+			item, ok := doc.Paths[APIBasePath+path]
+			require.True(t, ok, "missing path %s", path)
+			require.NotNil(t, item.Post, "missing POST for %s", path)
+			assert.NotNil(t, item.Post.RequestBody)
+			assert.Contains(t, item.Post.Responses, "200")
+		}
+	})
+
+	t.Run("plain HTTP routes are documented", func(t *testing.T) {
+		for path, methods := range map[string][]string{
+			PanicPath:     {http.MethodGet, http.MethodPost},
+			PanicLiftPath: {http.MethodPost},
+
+			GenesisPath:            {http.MethodGet},
+			GenesisCountersignPath: {http.MethodPost},
+			locksPath:              {http.MethodGet},
+			unlockPath:             {http.MethodPost},
+			ShareCheckPath:         {http.MethodPost},
+
+			hostAnomalyInboxPath:     {http.MethodPost},
+			policyAmendmentInboxPath: {http.MethodPost},
+			approvalsPath:            {http.MethodGet},
+		} {
+			item, ok := doc.Paths[APIBasePath+path]
+			require.True(t, ok, "missing path %s", path)
+			for _, method := range methods {
+				op := map[string]*Operation{http.MethodGet: item.Get, http.MethodPost: item.Post, http.MethodDelete: item.Delete}[method]
+				assert.NotNil(t, op, "missing %s for %s", method, path)
+			}
+		}
+	})
+
+	t.Run("schemas use protojson field names and types", func(t *testing.T) {
+		status := doc.Components.Schemas["GetStatusResponse"]
+		require.NotNil(t, status)
+		assert.Equal(t, "string", status.Properties["repoUrl"].Type)
+		assert.Equal(t, "integer", status.Properties["pendingRequests"].Type)
+		assert.Equal(t, "array", status.Properties["backupPaths"].Type)
+		assert.Contains(t, status.Properties["role"].Enum, "ROLE_OWNER")
+		assert.Equal(t, "#/components/schemas/Peer", status.Properties["peer"].Ref)
+
+		storage := doc.Components.Schemas["GetStorageStatusResponse"]
+		require.NotNil(t, storage)
+		assert.Equal(t, "int64", storage.Properties["usedBytes"].Format)
+		assert.Equal(t, "date-time", storage.Properties["startTime"].Format)
+	})
+
+	t.Run("all references resolve", func(t *testing.T) {
+		data, err := json.Marshal(doc)
+		require.NoError(t, err)
+
+		var raw any
+		require.NoError(t, json.Unmarshal(data, &raw))
+
+		var walk func(v any)
+		walk = func(v any) {
+			switch val := v.(type) {
+			case map[string]any:
+				if ref, ok := val["$ref"].(string); ok {
+					name := ref[len("#/components/schemas/"):]
+					assert.Contains(t, doc.Components.Schemas, name, "dangling ref %s", ref)
+				}
+				for _, child := range val {
+					walk(child)
+				}
+			case []any:
+				for _, child := range val {
+					walk(child)
+				}
+			}
+		}
+		walk(raw)
+	})
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	h := openAPIHandler("dev")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, APIBasePath+openAPIPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var doc OpenAPIDocument
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.NotEmpty(t, doc.Paths)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, APIBasePath+openAPIPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/service"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+)
+
+// OperationsPath is the prefix of the generic consent-gated operation
+// endpoints (relative to APIBasePath)
+const OperationsPath = "/operations"
+
+// CreateOperationRequest is the body of POST /api/v1/operations
+type CreateOperationRequest struct {
+	Type    string          `json:"type"`
+	Reason  string          `json:"reason"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// ApproveOperationRequest is the body of POST /api/v1/operations/{id}/approve
+type ApproveOperationRequest struct {
+	KeyHolderID string `json:"key_holder_id"`
+	Signature   string `json:"signature"` // hex-encoded, over the operation's sign data
+}
+
+// OperationsStatus is the body of GET /api/v1/operations
+type OperationsStatus struct {
+	Types      []consent.OperationTypeInfo `json:"types"`
+	Operations []consent.AnyOperation      `json:"operations"`
+}
+
+// OperationDetail is the body of GET /api/v1/operations/{id}: the
+// operation, and the sign data key holders approve it by signing
+type OperationDetail struct {
+	Operation consent.AnyOperation `json:"operation"`
+	// SignData is for the key holder named by ?key_holder_id=
+	SignData *crypto.OperationSignData `json:"sign_data,omitempty"`
+}
+
+// operationsHandler serves:
+//
+//	GET  /api/v1/operations               the registered request types and pending requests, restores and deletions included
+//	POST /api/v1/operations               request an operation
+//	GET  /api/v1/operations/{id}          one operation, with its sign data for ?key_holder_id=
+//	POST /api/v1/operations/{id}/approve  add a key holder's signed approval
+//	POST /api/v1/operations/{id}/deny     deny an operation
+//	POST /api/v1/operations/{id}/execute  retry an approved operation whose execution failed
+func operationsHandler(svc *service.ConsentService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest := strings.Trim(strings.TrimPrefix(r.URL.Path, OperationsPath), "/")
+		if rest == "" {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead:
+				ops, err := svc.ListPendingOperations()
+				if err != nil {
+					writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+					return
+				}
+				if ops == nil {
+					ops = []consent.AnyOperation{}
+				}
+				for i, op := range ops {
+					ops[i] = typedOperation{op}
+				}
+				writeJSON(w, http.StatusOK, OperationsStatus{Types: consent.OperationTypes(), Operations: ops})
+			case http.MethodPost:
+				var req CreateOperationRequest
+				if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil || req.Type == "" {
+					writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "type is required"))
+					return
+				}
+				op, err := svc.CreateOperation(service.CreateOperationParams{
+					Type:          req.Type,
+					Reason:        req.Reason,
+					Payload:       req.Payload,
+					CorrelationID: tracing.ID(r.Context()),
+				})
+				if err != nil {
+					writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+					return
+				}
+				writeJSON(w, http.StatusCreated, op)
+			default:
+				writeError(w, errMethodNotAllowed)
+			}
+			return
+		}
+
+		id, action, _ := strings.Cut(rest, "/")
+		if action == "" {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				writeError(w, errMethodNotAllowed)
+				return
+			}
+			op, err := svc.GetOperation(id)
+			if err != nil {
+				writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+				return
+			}
+			detail := OperationDetail{Operation: typedOperation{op}}
+			if keyHolderID := r.URL.Query().Get("key_holder_id"); keyHolderID != "" {
+				data, err := svc.OperationSignData(id, keyHolderID)
+				if err != nil {
+					writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+					return
+				}
+				detail.SignData = data
+			}
+			writeJSON(w, http.StatusOK, detail)
+			return
+		}
+		if r.Method != http.MethodPost {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+
+		switch action {
+		case "approve":
+			var req ApproveOperationRequest
+			signature, ok := decodeSigned(w, r, &req, func() string { return req.Signature })
+			if !ok {
+				return
+			}
+			op, err := svc.ApproveOperation(r.Context(), id, req.KeyHolderID, signature)
+			if err != nil {
+				writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+				return
+			}
+			writeJSON(w, http.StatusOK, op)
+		case "deny":
+			if err := svc.DenyOperation(id); err != nil {
+				writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]string{"status": "denied"})
+		case "execute":
+			op, err := svc.ExecuteOperation(r.Context(), id)
+			if err != nil {
+				writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+				return
+			}
+			writeJSON(w, http.StatusOK, op)
+		default:
+			writeError(w, apperrors.New(apperrors.CodeNotFound, "unknown operation route"))
+		}
+	})
+}
+
+// typedOperation is an operation as the API lists and shows it. Restore
+// and deletion requests are tagged with their type, which their own JSON
+// leaves out.
+type typedOperation struct{ consent.AnyOperation }
+
+// MarshalJSON adds the operation's type to its JSON
+func (o typedOperation) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(o.AnyOperation)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if fields["type"], err = json.Marshal(o.OperationType()); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
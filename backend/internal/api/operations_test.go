@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/service"
+)
+
+type testNote struct {
+	Note string `json:"note"`
+}
+
+// executedNotes records the notes testNoteOp executed
+var executedNotes []string
+
+var testNoteOp = consent.RegisterOperation(consent.OperationType[testNote]{
+	Name: "test_api_note",
+	Execute: func(ctx context.Context, op *consent.Operation[testNote]) error {
+		executedNotes = append(executedNotes, op.Payload.Note)
+		return nil
+	},
+})
+
+func TestOperationsHandler(t *testing.T) {
+	alicePub, alicePriv, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+	_, mallory, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+	alice := crypto.KeyID(alicePub)
+
+	dir := t.TempDir()
+	cfg := &config.Config{Name: "owner", ConfigDir: dir,
+		Consensus: &config.ConsensusConfig{
+			Threshold:  1,
+			TotalKeys:  1,
+			KeyHolders: []config.KeyHolder{{ID: alice, Name: "Alice", PublicKey: alicePub}},
+		},
+	}
+	executedNotes = nil
+
+	mux := http.NewServeMux()
+	h := operationsHandler(service.NewConsentService(cfg, consent.NewManager(dir)))
+	mux.Handle(OperationsPath, h)
+	mux.Handle(OperationsPath+"/", h)
+	do := func(method, path string, v any) *httptest.ResponseRecorder {
+		var body string
+		if v != nil {
+			b, err := json.Marshal(v)
+			require.NoError(t, err)
+			body = string(b)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	// Only registered types can be requested
+	rec := do(http.MethodPost, OperationsPath, CreateOperationRequest{Type: "no_such_type"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = do(http.MethodPost, OperationsPath, CreateOperationRequest{
+		Type: testNoteOp.Name, Reason: "testing", Payload: json.RawMessage(`{"note":"hello"}`),
+	})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created consent.Operation[testNote]
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, consent.StatusPending, created.Status)
+	assert.Equal(t, "owner", created.Requester)
+	assert.Equal(t, 1, created.RequiredApprovals)
+
+	rec = do(http.MethodGet, OperationsPath, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var status struct {
+		Types      []consent.OperationTypeInfo   `json:"types"`
+		Operations []consent.Operation[testNote] `json:"operations"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	assert.Contains(t, status.Types, consent.OperationTypeInfo{
+		Name: "test_api_note", TTLSeconds: int64(consent.DefaultRequestTTL.Seconds()), Scope: "airgapper.operation.test_api_note",
+	})
+	require.Len(t, status.Operations, 1)
+	assert.Equal(t, created.ID, status.Operations[0].ID)
+
+	// The key holder fetches what to sign
+	rec = do(http.MethodGet, OperationsPath+"/"+created.ID+"?key_holder_id="+alice, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var detail struct {
+		SignData crypto.OperationSignData `json:"sign_data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &detail))
+	assert.Equal(t, "airgapper.operation.test_api_note", detail.SignData.Scope)
+	assert.Equal(t, alice, detail.SignData.KeyHolderID)
+
+	// A signature by another key is refused
+	forged, err := detail.SignData.Sign(mallory)
+	require.NoError(t, err)
+	rec = do(http.MethodPost, OperationsPath+"/"+created.ID+"/approve",
+		ApproveOperationRequest{KeyHolderID: alice, Signature: hex.EncodeToString(forged)})
+	assert.NotEqual(t, http.StatusOK, rec.Code)
+	assert.Empty(t, executedNotes)
+
+	sig, err := detail.SignData.Sign(alicePriv)
+	require.NoError(t, err)
+	rec = do(http.MethodPost, OperationsPath+"/"+created.ID+"/approve",
+		ApproveOperationRequest{KeyHolderID: alice, Signature: hex.EncodeToString(sig)})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var approved consent.Operation[testNote]
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &approved))
+	assert.Equal(t, consent.StatusApproved, approved.Status)
+	assert.NotNil(t, approved.ExecutedAt)
+	assert.Equal(t, []string{"hello"}, executedNotes)
+
+	// Decided operations can't be denied
+	assert.Equal(t, http.StatusPreconditionFailed, do(http.MethodPost, OperationsPath+"/"+created.ID+"/deny", nil).Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, OperationsPath+"/0123456789abcdef", nil).Code)
+}
+
+func TestOperationsHandlerShowsRestoresAndDeletions(t *testing.T) {
+	dir := t.TempDir()
+	mgr := consent.NewManager(dir)
+	h := operationsHandler(service.NewConsentService(&config.Config{Name: "owner", ConfigDir: dir}, mgr))
+	do := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	restore, err := mgr.CreateRequest("alice", "latest", "lost laptop", nil)
+	require.NoError(t, err)
+	deletion, err := mgr.CreateDeletionRequest("alice", consent.DeletionTypeSnapshot, []string{"snap1"}, nil, "tidy", 1)
+	require.NoError(t, err)
+
+	rec := do(http.MethodGet, OperationsPath)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var status struct {
+		Types      []consent.OperationTypeInfo `json:"types"`
+		Operations []struct {
+			ID   string `json:"id"`
+			Type string `json:"type"`
+		} `json:"operations"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	var types []string
+	for _, info := range status.Types {
+		types = append(types, info.Name)
+	}
+	assert.Subset(t, types, []string{"restore", "deletion"})
+	require.Len(t, status.Operations, 2)
+	assert.ElementsMatch(t, []string{restore.ID + " restore", deletion.ID + " deletion"}, []string{
+		status.Operations[0].ID + " " + status.Operations[0].Type,
+		status.Operations[1].ID + " " + status.Operations[1].Type,
+	})
+
+	// They are approved with their own RPCs, but can be denied here
+	assert.Equal(t, http.StatusPreconditionFailed, do(http.MethodGet, OperationsPath+"/"+restore.ID+"?key_holder_id=kh-1").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, OperationsPath+"/"+deletion.ID+"/deny").Code)
+	denied, err := mgr.GetDeletionRequest(deletion.ID)
+	require.NoError(t, err)
+	assert.Equal(t, consent.StatusDenied, denied.Status)
+}
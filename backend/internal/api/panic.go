@@ -0,0 +1,231 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/emergency"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/lockdown"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+)
+
+// Panic button paths (relative to APIBasePath)
+const (
+	PanicPath     = "/panic"
+	PanicLiftPath = "/panic/lift"
+)
+
+// PanicRequest is the body of POST /api/v1/panic. Lockdown is set when
+// another node propagates its lockdown, which isn't propagated further.
+type PanicRequest struct {
+	Reason      string             `json:"reason,omitempty"`
+	TriggeredBy string             `json:"triggered_by,omitempty"`
+	Lockdown    *lockdown.Lockdown `json:"lockdown,omitempty"`
+}
+
+// LiftRequest is the body of POST /api/v1/panic/lift. Propagated is set
+// when another node passes on the lift, which isn't passed on further.
+type LiftRequest struct {
+	ID         string               `json:"id"`
+	Signatures []lockdown.Signature `json:"signatures"`
+	Propagated bool                 `json:"propagated,omitempty"`
+}
+
+// PanicStatus is the body of GET /api/v1/panic
+type PanicStatus struct {
+	LockedDown bool               `json:"locked_down"`
+	Lockdown   *lockdown.Lockdown `json:"lockdown,omitempty"`
+	// Signatures needed to lift the lockdown, and the keys that can give them
+	Threshold int      `json:"threshold"`
+	Signers   []string `json:"signers,omitempty"`
+}
+
+// panicHandler serves the panic button: GET /api/v1/panic reports the
+// lockdown, POST /api/v1/panic locks the node down and POST
+// /api/v1/panic/lift lifts it with enough key holders' signatures
+func panicHandler(cfg *config.Config, srv *storage.Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == PanicPath && r.Method == http.MethodGet:
+			writeJSON(w, http.StatusOK, panicStatus(cfg))
+		case r.URL.Path == PanicPath && r.Method == http.MethodPost:
+			var body PanicRequest
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&body); err != nil {
+				writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "invalid panic body"))
+				return
+			}
+			l := body.Lockdown
+			if l == nil || l.ID == "" {
+				var err error
+				if l, err = lockdown.New(body.Reason, body.TriggeredBy, cfg.Name); err != nil {
+					writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+					return
+				}
+			}
+			logging.Warn("Panic button pressed",
+				logging.String("triggeredBy", l.TriggeredBy),
+				logging.String("origin", l.Origin),
+				logging.String("reason", l.Reason),
+				tracing.Field(r.Context()))
+			if _, err := Panic(r.Context(), cfg, srv, l, body.Lockdown == nil); err != nil {
+				writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+				return
+			}
+			writeJSON(w, http.StatusOK, panicStatus(cfg))
+		case r.URL.Path == PanicLiftPath && r.Method == http.MethodPost:
+			var body LiftRequest
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&body); err != nil {
+				writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "invalid lift body"))
+				return
+			}
+			if err := cfg.LockdownSigners().Verify(body.ID, body.Signatures); err != nil {
+				writeError(w, err)
+				return
+			}
+			if err := LiftLockdown(r.Context(), cfg, srv, body.ID, body.Signatures, !body.Propagated); err != nil {
+				writeError(w, err)
+				return
+			}
+			logging.Info("Lockdown lifted", logging.String("id", body.ID), tracing.Field(r.Context()))
+			writeJSON(w, http.StatusOK, panicStatus(cfg))
+		default:
+			writeError(w, errMethodNotAllowed)
+		}
+	})
+}
+
+// panicStatus reports the lockdown in force and who can lift it
+func panicStatus(cfg *config.Config) PanicStatus {
+	l := lockdown.Load(cfg.ConfigDir)
+	signers := cfg.LockdownSigners()
+	return PanicStatus{
+		LockedDown: l != nil,
+		Lockdown:   l,
+		Threshold:  signers.Threshold,
+		Signers:    slices.Sorted(maps.Keys(signers.Keys)),
+	}
+}
+
+// Panic locks the node down with l: approvals and new requests are
+// refused, storage srv (if any) is frozen and the notification providers
+// are told. When propagate is set and the lockdown is new, it is passed on
+// to the peer and every key holder. It returns the lockdown in force,
+// which is an earlier one if the node was already locked down.
+func Panic(ctx context.Context, cfg *config.Config, srv *storage.Server, l *lockdown.Lockdown, propagate bool) (*lockdown.Lockdown, error) {
+	current, applied, err := lockdown.Apply(cfg.ConfigDir, l)
+	if err != nil {
+		return nil, err
+	}
+	if srv != nil {
+		if err := srv.Freeze(current.ID, current.Reason); err != nil {
+			return nil, err
+		}
+	}
+	if !applied {
+		return current, nil
+	}
+
+	body := "Approvals and new restore and deletion requests are suspended"
+	if srv != nil {
+		body += " and storage is read-only"
+	}
+	body += " until enough key holders sign to lift the lockdown."
+	if current.Reason != "" {
+		body = "Reason: " + current.Reason + "\n" + body
+	}
+	sendLockdownNotification(ctx, cfg, "Airgapper node "+cfg.Name+" locked down", body)
+
+	if propagate {
+		for addr, err := range propagateToPeers(ctx, cfg, PanicPath, PanicRequest{Lockdown: current}) {
+			logging.Warn("Could not pass the lockdown on", logging.String("peer", addr), logging.Err(err))
+		}
+	}
+	return current, nil
+}
+
+// LiftLockdown lifts lockdown id, whose signatures must already have been
+// verified, and unfreezes storage srv (if any). When propagate is set the
+// signatures are passed on to the peer and every key holder, which check
+// them against their own keys.
+func LiftLockdown(ctx context.Context, cfg *config.Config, srv *storage.Server, id string, sigs []lockdown.Signature, propagate bool) error {
+	wasLocked := lockdown.Load(cfg.ConfigDir) != nil
+	if err := lockdown.Lift(cfg.ConfigDir, id); err != nil {
+		return err
+	}
+	if srv != nil {
+		if err := srv.Unfreeze(id); err != nil {
+			return err
+		}
+	}
+	if wasLocked {
+		sendLockdownNotification(ctx, cfg, "Lockdown lifted on "+cfg.Name,
+			"Lockdown "+id+" was lifted. Approvals, new requests and storage writes are allowed again.")
+	}
+
+	if propagate {
+		for addr, err := range propagateToPeers(ctx, cfg, PanicLiftPath, LiftRequest{ID: id, Signatures: sigs, Propagated: true}) {
+			logging.Warn("Could not pass the lift on", logging.String("peer", addr), logging.Err(err))
+		}
+	}
+	return nil
+}
+
+// propagateToPeers POSTs body to path on the peer and every key holder,
+// returning the errors by address
+func propagateToPeers(ctx context.Context, cfg *config.Config, path string, body any) map[string]error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return map[string]error{"": err}
+	}
+	failed := make(map[string]error)
+	client := PeerClient(cfg, 10*time.Second)
+	for _, addr := range cfg.LockdownPeers() {
+		url := strings.TrimSuffix(addr, "/") + APIBasePath + path
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+		if err != nil {
+			failed[addr] = err
+			continue
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			failed[addr] = err
+			continue
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			failed[addr] = fmt.Errorf("peer answered %s", resp.Status)
+		}
+	}
+	return failed
+}
+
+// sendLockdownNotification tells the configured providers about a
+// lockdown, if the lockdown event is enabled
+func sendLockdownNotification(ctx context.Context, cfg *config.Config, title, body string) {
+	notify := cfg.Emergency.GetNotify()
+	if !notify.IsEnabled() || !notify.Events.Lockdown {
+		return
+	}
+
+	failed := notify.Send(ctx, emergency.Message{
+		Event:    "lockdown",
+		Title:    title,
+		Body:     body,
+		Priority: "high",
+	})
+	for id, err := range failed {
+		logging.Warn("Failed to send notification", logging.String("provider", id), logging.Err(err))
+	}
+}
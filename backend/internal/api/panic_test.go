@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/lockdown"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+)
+
+func TestPanicButton(t *testing.T) {
+	alicePub, alicePriv, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+	bobPub, bobPriv, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+
+	bob := &config.Config{Name: "bob", Role: config.RoleHost, ConfigDir: t.TempDir(), PublicKey: bobPub,
+		Peer: &config.PeerInfo{Name: "alice", PublicKey: alicePub}}
+	store, err := storage.NewServer(storage.Config{BasePath: t.TempDir()})
+	require.NoError(t, err)
+	bobServer := http.NewServeMux()
+	bobServer.Handle(APIBasePath+"/", http.StripPrefix(APIBasePath, panicHandler(bob, store)))
+	bobSrv := httptest.NewServer(bobServer)
+	t.Cleanup(bobSrv.Close)
+
+	alice := &config.Config{Name: "alice", Role: config.RoleOwner, ConfigDir: t.TempDir(), PublicKey: alicePub,
+		Peer: &config.PeerInfo{Name: "bob", PublicKey: bobPub, Address: bobSrv.URL}}
+	h := panicHandler(alice, nil)
+
+	post := func(path string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(string(data))))
+		return rec
+	}
+
+	rec := post(PanicPath, PanicRequest{Reason: "laptop stolen", TriggeredBy: "alice"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var status PanicStatus
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	require.True(t, status.LockedDown)
+	assert.Equal(t, 2, status.Threshold)
+	assert.Len(t, status.Signers, 2)
+	id := status.Lockdown.ID
+
+	// The lockdown reached the host, which froze its storage
+	l := lockdown.Load(bob.ConfigDir)
+	require.NotNil(t, l)
+	assert.Equal(t, id, l.ID)
+	require.NotNil(t, store.Maintenance())
+	assert.Equal(t, id, store.Maintenance().Lockdown)
+
+	// One key holder alone can't lift it
+	aliceSig, err := lockdown.Sign(id, alicePriv, alicePub)
+	require.NoError(t, err)
+	rec = post(PanicLiftPath, LiftRequest{ID: id, Signatures: []lockdown.Signature{aliceSig}})
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code, rec.Body.String())
+	assert.NotNil(t, lockdown.Load(alice.ConfigDir))
+
+	bobSig, err := lockdown.Sign(id, bobPriv, bobPub)
+	require.NoError(t, err)
+	rec = post(PanicLiftPath, LiftRequest{ID: id, Signatures: []lockdown.Signature{aliceSig, bobSig}})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Nil(t, lockdown.Load(alice.ConfigDir))
+	assert.Nil(t, lockdown.Load(bob.ConfigDir), "the lift is passed on to the host")
+	assert.Nil(t, store.Maintenance())
+}
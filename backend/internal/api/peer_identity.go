@@ -0,0 +1,34 @@
+package api
+
+import (
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/peerpin"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+)
+
+// withPublicKey announces this node's public key on every response, so
+// peers can check it against the key they pinned
+func withPublicKey(publicKey []byte, next http.Handler) http.Handler {
+	if len(publicKey) == 0 {
+		return next
+	}
+	key := hex.EncodeToString(publicKey)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(peerpin.KeyHeader, key)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// PeerClient returns a client for calls to peers. Calls to the configured
+// peer are checked against its pinned identity.
+func PeerClient(cfg *config.Config, timeout time.Duration) *http.Client {
+	if cfg == nil || cfg.Peer == nil || cfg.Peer.Address == "" || cfg.ConfigDir == "" {
+		return tracing.NewClient(timeout)
+	}
+	guard := &peerpin.Guard{Store: peerpin.NewStore(cfg.ConfigDir), Address: cfg.Peer.Address, Key: cfg.Peer.PublicKey}
+	return guard.Client(timeout)
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/peerpin"
+)
+
+func TestPeerClientChecksPin(t *testing.T) {
+	bob := httptest.NewServer(withPublicKey([]byte{0xbb}, http.NotFoundHandler()))
+	defer bob.Close()
+
+	resp, err := http.Get(bob.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, "bb", resp.Header.Get(peerpin.KeyHeader))
+
+	// The key bob joined with is not the one it announces
+	cfg := &config.Config{ConfigDir: t.TempDir(), Peer: &config.PeerInfo{Name: "bob", Address: bob.URL, PublicKey: []byte{0xaa}}}
+	_, err = PeerClient(cfg, 5*time.Second).Get(bob.URL + "/api/v1/version")
+	assert.Equal(t, apperrors.CodePeerIdentityChanged, apperrors.CodeOf(err))
+
+	cfg.Peer.PublicKey = []byte{0xbb}
+	cfg.ConfigDir = t.TempDir()
+	resp, err = PeerClient(cfg, 5*time.Second).Get(bob.URL + "/api/v1/version")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+}
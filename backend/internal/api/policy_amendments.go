@@ -0,0 +1,242 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/policy"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+)
+
+// Policy renegotiation endpoints (relative to APIBasePath)
+const (
+	policyAmendmentsPath = "/policy/amendments"
+	policyHistoryPath    = "/policy/history"
+
+	// policyAmendmentInboxPath receives amendment notices from the counterparty
+	policyAmendmentInboxPath = policyAmendmentsPath + "/incoming"
+)
+
+// proposeAmendmentRequest is the body of POST /api/v1/policy/amendments
+type proposeAmendmentRequest struct {
+	ProposedBy string `json:"proposed_by"` // "owner" or "host"
+	Reason     string `json:"reason,omitempty"`
+	policy.Terms
+}
+
+// signAmendmentRequest is the body of POST /api/v1/policy/amendments/{id}/sign and /reject
+type signAmendmentRequest struct {
+	Role      string `json:"role"`
+	Signature string `json:"signature,omitempty"` // hex-encoded, over proposed_hash
+}
+
+// amendmentResponse wraps an amendment with what the parties need to act on it
+type amendmentResponse struct {
+	*policy.Amendment
+	ProposedHash string   `json:"proposed_hash"` // What each party signs
+	Awaiting     []string `json:"awaiting,omitempty"`
+}
+
+// policyHistoryResponse is the body of GET /api/v1/policy/history
+type policyHistoryResponse struct {
+	Policies []*policy.Policy `json:"policies"`
+	Verified bool             `json:"verified"`
+	Error    string           `json:"error,omitempty"`
+}
+
+func newAmendmentResponse(a *policy.Amendment) amendmentResponse {
+	hash, _ := a.Proposed.HashHex()
+	resp := amendmentResponse{Amendment: a, ProposedHash: hash}
+	if a.Status == policy.AmendmentPending {
+		resp.Awaiting = a.AwaitingSignatures()
+	}
+	return resp
+}
+
+// policyAmendmentsHandler serves:
+//
+//	GET  /api/v1/policy/amendments              list proposals
+//	POST /api/v1/policy/amendments              propose new terms
+//	POST /api/v1/policy/amendments/{id}/sign    add a party's signature
+//	POST /api/v1/policy/amendments/{id}/reject  decline a proposal
+func policyAmendmentsHandler(srv *storage.Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if srv == nil {
+			writeError(w, apperrors.New(apperrors.CodeStorageNotConfigured, "storage server not configured"))
+			return
+		}
+
+		rest := strings.Trim(strings.TrimPrefix(r.URL.Path, policyAmendmentsPath), "/")
+		if rest == "" {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead:
+				listAmendments(w, srv)
+			case http.MethodPost:
+				proposeAmendment(w, r, srv)
+			default:
+				writeError(w, errMethodNotAllowed)
+			}
+			return
+		}
+
+		id, action, ok := strings.Cut(rest, "/")
+		if !ok || (action != "sign" && action != "reject") {
+			writeError(w, apperrors.New(apperrors.CodeNotFound, "unknown amendment route"))
+			return
+		}
+		if r.Method != http.MethodPost {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+
+		var req signAmendmentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "invalid request body"))
+			return
+		}
+
+		var a *policy.Amendment
+		var err error
+		if action == "sign" {
+			a, err = srv.SignPolicyAmendment(r.Context(), id, req.Role, req.Signature)
+		} else {
+			a, err = srv.RejectPolicyAmendment(r.Context(), id, req.Role)
+		}
+		if err != nil {
+			writeError(w, apperrors.Coded(apperrors.CodeInvalidArgument, err))
+			return
+		}
+		writeJSON(w, http.StatusOK, newAmendmentResponse(a))
+	})
+}
+
+func listAmendments(w http.ResponseWriter, srv *storage.Server) {
+	amendments, err := srv.PolicyAmendments()
+	if err != nil {
+		writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+		return
+	}
+	resp := make([]amendmentResponse, 0, len(amendments))
+	for _, a := range amendments {
+		resp = append(resp, newAmendmentResponse(a))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"amendments": resp})
+}
+
+func proposeAmendment(w http.ResponseWriter, r *http.Request, srv *storage.Server) {
+	var req proposeAmendmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "invalid request body"))
+		return
+	}
+
+	a, err := srv.ProposePolicyAmendment(r.Context(), req.ProposedBy, req.Reason, req.Terms)
+	if err != nil {
+		writeError(w, apperrors.Coded(apperrors.CodeInvalidArgument, err))
+		return
+	}
+	writeJSON(w, http.StatusCreated, newAmendmentResponse(a))
+}
+
+// policyHistoryHandler serves GET /api/v1/policy/history: every enforced
+// policy, oldest first, and whether the signed chain verifies
+func policyHistoryHandler(srv *storage.Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+		if srv == nil {
+			writeError(w, apperrors.New(apperrors.CodeStorageNotConfigured, "storage server not configured"))
+			return
+		}
+
+		history, err := srv.PolicyHistory()
+		if err != nil {
+			writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+			return
+		}
+
+		resp := policyHistoryResponse{Policies: history, Verified: true}
+		if resp.Policies == nil {
+			resp.Policies = []*policy.Policy{}
+		}
+		if err := policy.VerifyHistory(history); err != nil {
+			resp.Verified = false
+			resp.Error = err.Error()
+		}
+		writeJSON(w, http.StatusOK, resp)
+	})
+}
+
+// policyAmendmentInboxHandler receives a notice that the counterparty's host
+// has an amendment awaiting our signature
+func policyAmendmentInboxHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+
+		var a policy.Amendment
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&a); err != nil || a.ID == "" {
+			writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "invalid amendment notice"))
+			return
+		}
+
+		logging.Warn("Peer proposed a policy amendment awaiting your signature",
+			logging.String("amendment", a.ID),
+			logging.String("policy", a.PolicyID),
+			logging.String("proposedBy", a.ProposedBy),
+			logging.String("reason", a.Reason),
+			tracing.Field(r.Context()))
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// peerAmendmentNotifier returns a callback that forwards pending amendments
+// to the configured peer, so the counterparty learns it has something to
+// sign. The notice carries the correlation ID of the request that proposed
+// or signed the amendment.
+func peerAmendmentNotifier(cfg *config.Config) func(context.Context, *policy.Amendment) {
+	return func(ctx context.Context, a *policy.Amendment) {
+		logging.Info("Policy amendment awaiting signature",
+			logging.String("amendment", a.ID),
+			logging.String("awaiting", strings.Join(a.AwaitingSignatures(), ",")),
+			tracing.Field(ctx))
+
+		if cfg.Peer == nil || cfg.Peer.Address == "" {
+			return
+		}
+
+		body, err := json.Marshal(a)
+		if err != nil {
+			return
+		}
+		// The notice outlives the request, so keep only its correlation ID
+		notifyCtx := tracing.WithID(context.Background(), tracing.ID(ctx))
+		go func() {
+			client := PeerClient(cfg, 10*time.Second)
+			url := strings.TrimSuffix(cfg.Peer.Address, "/") + APIBasePath + policyAmendmentInboxPath
+			req, err := http.NewRequestWithContext(notifyCtx, http.MethodPost, url, bytes.NewReader(body))
+			if err != nil {
+				return
+			}
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := client.Do(req)
+			if err != nil {
+				logging.Warn("Could not notify peer of policy amendment", logging.Err(err), tracing.Field(notifyCtx))
+				return
+			}
+			_ = resp.Body.Close()
+		}()
+	}
+}
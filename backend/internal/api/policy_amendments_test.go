@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/policy"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+)
+
+func TestPolicyAmendmentHandlers(t *testing.T) {
+	ownerPub, ownerPriv, _ := crypto.GenerateKeyPair()
+	hostPub, hostPriv, _ := crypto.GenerateKeyPair()
+	p := policy.NewPolicy(
+		"Alice", crypto.KeyID(ownerPub), crypto.EncodePublicKey(ownerPub),
+		"Bob", crypto.KeyID(hostPub), crypto.EncodePublicKey(hostPub),
+	)
+	require.NoError(t, p.SignAsOwner(ownerPriv))
+	require.NoError(t, p.SignAsHost(hostPriv))
+
+	srv, err := storage.NewServer(storage.Config{BasePath: t.TempDir(), Policy: p})
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	amendments := policyAmendmentsHandler(srv)
+	mux.Handle(policyAmendmentsPath, amendments)
+	mux.Handle(policyAmendmentsPath+"/", amendments)
+	mux.Handle(policyHistoryPath, policyHistoryHandler(srv))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+	sign := func(hash string, priv []byte) string {
+		raw, err := hex.DecodeString(hash)
+		require.NoError(t, err)
+		sig, err := crypto.Sign(priv, raw)
+		require.NoError(t, err)
+		return hex.EncodeToString(sig)
+	}
+
+	rec := do(http.MethodPost, policyAmendmentsPath, `{"proposed_by":"owner"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "no terms changed")
+
+	rec = do(http.MethodPost, policyAmendmentsPath, `{"proposed_by":"owner","reason":"keep longer","retention_days":60}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var proposed amendmentResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &proposed))
+	assert.Equal(t, []string{"owner", "host"}, proposed.Awaiting)
+	require.NotEmpty(t, proposed.ProposedHash)
+
+	signPath := policyAmendmentsPath + "/" + proposed.ID + "/sign"
+	rec = do(http.MethodPost, signPath, `{"role":"host","signature":"`+sign(proposed.ProposedHash, ownerPriv)+`"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "owner key can't sign for the host")
+
+	rec = do(http.MethodPost, signPath, `{"role":"owner","signature":"`+sign(proposed.ProposedHash, ownerPriv)+`"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec = do(http.MethodPost, signPath, `{"role":"host","signature":"`+sign(proposed.ProposedHash, hostPriv)+`"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var applied amendmentResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &applied))
+	assert.Equal(t, policy.AmendmentApplied, applied.Status)
+	assert.Equal(t, 60, srv.GetPolicy().RetentionDays)
+
+	rec = do(http.MethodGet, policyHistoryPath, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var history policyHistoryResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &history))
+	assert.Len(t, history.Policies, 2)
+	assert.True(t, history.Verified, history.Error)
+
+	rec = do(http.MethodPost, policyAmendmentsPath+"/missing/sign", `{"role":"owner"}`)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
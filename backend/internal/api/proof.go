@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+	"github.com/lcrostarosa/airgapper/backend/internal/verification"
+)
+
+// ProofChallengePath receives proof-of-storage challenges (relative to APIBasePath)
+const ProofChallengePath = "/proof/challenge"
+
+// proofChallengeHandler serves POST /api/v1/proof/challenge: the host
+// hashes the challenged ranges of its stored files with the owner's nonce
+// and returns the hashes signed with its key
+func proofChallengeHandler(srv *storage.Server, cfg *config.Config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+		if srv == nil {
+			writeError(w, apperrors.New(apperrors.CodeStorageNotConfigured, "storage server not configured"))
+			return
+		}
+		if len(cfg.PrivateKey) == 0 || len(cfg.PublicKey) == 0 {
+			writeError(w, apperrors.New(apperrors.CodeNoSigningKey, "host has no signing key"))
+			return
+		}
+
+		var c verification.ProofChallenge
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&c); err != nil {
+			writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "invalid challenge body"))
+			return
+		}
+
+		proof, err := srv.Prove(r.Context(), &c, cfg.PrivateKey, cfg.PublicKey)
+		if err != nil {
+			writeError(w, apperrors.Coded(apperrors.CodeInvalidArgument, err))
+			return
+		}
+		writeJSON(w, http.StatusOK, proof)
+	})
+}
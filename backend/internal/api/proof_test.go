@@ -0,0 +1,50 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+	"github.com/lcrostarosa/airgapper/backend/internal/verification"
+)
+
+func TestProofChallengeHandler(t *testing.T) {
+	pub, priv, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+	srv, err := storage.NewServer(storage.Config{BasePath: t.TempDir()})
+	require.NoError(t, err)
+	h := proofChallengeHandler(srv, &config.Config{PublicKey: pub, PrivateKey: priv})
+
+	c, err := verification.NewProofChallenge("repo", []verification.ProofRange{
+		{Type: "data", ID: strings.Repeat("ab", 32), Length: 16},
+	})
+	require.NoError(t, err)
+	body, err := json.Marshal(c)
+	require.NoError(t, err)
+
+	post := func(h http.Handler, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, ProofChallengePath, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := post(h, string(body))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var proof verification.StorageProof
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &proof))
+	assert.Equal(t, crypto.KeyID(pub), proof.HostKeyID)
+	require.Len(t, proof.Results, 1)
+	assert.Equal(t, "file not found", proof.Results[0].Error)
+
+	assert.Equal(t, http.StatusBadRequest, post(h, `{"repo":"repo","nonce":"00"}`).Code)
+	assert.Equal(t, http.StatusPreconditionFailed, post(proofChallengeHandler(srv, &config.Config{}), string(body)).Code)
+	assert.Equal(t, http.StatusPreconditionFailed, post(proofChallengeHandler(nil, &config.Config{}), string(body)).Code)
+}
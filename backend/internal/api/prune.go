@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/integrity"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+)
+
+// Paths of the host's prune window endpoints (relative to APIBasePath)
+const (
+	hostPruneBeginPath = "/host/prune/begin"
+	hostPruneEndPath   = "/host/prune/end"
+)
+
+// hostPruneRequest names the prune window an owner opens or closes
+type hostPruneRequest struct {
+	DeletionID string `json:"deletion_id"`
+	Repo       string `json:"repo"`
+}
+
+// hostPruneEndResponse is what closing a prune window found, including the
+// integrity check re-baselining the repository
+type hostPruneEndResponse struct {
+	*storage.PruneResult
+	Integrity *integrity.CheckResult `json:"integrity,omitempty"`
+}
+
+// hostPruneBeginHandler serves POST /api/v1/host/prune/begin: the owner is
+// about to prune after an approved deletion, so storage goes read-only for
+// every other repository until the owner closes the window
+func hostPruneBeginHandler(srv *storage.Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, ok := decodeHostPrune(w, r, srv)
+		if !ok {
+			return
+		}
+		m, err := srv.BeginPrune(r.Context(), req.Repo, req.DeletionID)
+		if err != nil {
+			writeError(w, apperrors.Coded(apperrors.CodeFailedPrecondition, err))
+			return
+		}
+		logging.Info("Owner opened a prune window",
+			logging.String("repo", req.Repo),
+			logging.String("deletion", req.DeletionID),
+			tracing.Field(r.Context()))
+		writeJSON(w, http.StatusOK, m)
+	})
+}
+
+// hostPruneEndHandler serves POST /api/v1/host/prune/end: the owner's
+// prune is over, so the window closes and the repository is re-baselined.
+// A failed integrity check doesn't keep the window open; it is logged and
+// the next scheduled check catches up.
+func hostPruneEndHandler(srv *storage.Server, checker *integrity.Checker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, ok := decodeHostPrune(w, r, srv)
+		if !ok {
+			return
+		}
+		result, err := srv.EndPrune(r.Context(), req.Repo, req.DeletionID)
+		if err != nil {
+			writeError(w, apperrors.Coded(apperrors.CodeFailedPrecondition, err))
+			return
+		}
+
+		resp := hostPruneEndResponse{PruneResult: result}
+		if checker != nil {
+			// The owner is waiting on this, but shouldn't be able to cut it short
+			check, err := checker.Rebaseline(context.WithoutCancel(r.Context()), req.Repo)
+			if err != nil {
+				logging.Warn("Could not re-baseline integrity after a prune",
+					logging.String("repo", req.Repo), logging.Err(err))
+			}
+			resp.Integrity = check
+		}
+		logging.Info("Owner closed a prune window",
+			logging.String("repo", req.Repo),
+			logging.String("deletion", req.DeletionID),
+			logging.Int64("freedBytes", result.FreedBytes),
+			tracing.Field(r.Context()))
+		writeJSON(w, http.StatusOK, resp)
+	})
+}
+
+// decodeHostPrune reads a prune window request, writing the error if
+// there is none to act on
+func decodeHostPrune(w http.ResponseWriter, r *http.Request, srv *storage.Server) (hostPruneRequest, bool) {
+	var req hostPruneRequest
+	if r.Method != http.MethodPost {
+		writeError(w, errMethodNotAllowed)
+		return req, false
+	}
+	if srv == nil {
+		writeError(w, apperrors.New(apperrors.CodeStorageNotConfigured, "storage server not configured"))
+		return req, false
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil || req.DeletionID == "" || req.Repo == "" {
+		writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "deletion_id and repo are required"))
+		return req, false
+	}
+	return req, true
+}
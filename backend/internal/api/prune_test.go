@@ -0,0 +1,59 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/integrity"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+)
+
+func TestHostPruneHandlers(t *testing.T) {
+	t.Run("no storage server", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		hostPruneBeginHandler(nil).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, hostPruneBeginPath, strings.NewReader(`{}`)))
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	})
+
+	base := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(base, "alice", "data"), 0700))
+	srv, err := storage.NewServer(storage.Config{BasePath: base})
+	require.NoError(t, err)
+	checker, err := integrity.NewChecker(base)
+	require.NoError(t, err)
+	begin := hostPruneBeginHandler(srv)
+	end := hostPruneEndHandler(srv, checker)
+	do := func(h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+	window := `{"deletion_id":"del-1","repo":"alice"}`
+
+	assert.Equal(t, http.StatusMethodNotAllowed, do(begin, http.MethodGet, hostPruneBeginPath, "").Code)
+	assert.Equal(t, http.StatusBadRequest, do(begin, http.MethodPost, hostPruneBeginPath, `{"repo":"alice"}`).Code)
+	assert.Equal(t, http.StatusPreconditionFailed, do(end, http.MethodPost, hostPruneEndPath, window).Code, "no window is open")
+
+	require.Equal(t, http.StatusOK, do(begin, http.MethodPost, hostPruneBeginPath, window).Code)
+	require.NotNil(t, srv.Maintenance())
+	assert.Equal(t, "alice", srv.Maintenance().Prune)
+
+	rec := do(end, http.MethodPost, hostPruneEndPath, window)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		storage.PruneResult
+		Integrity *integrity.CheckResult `json:"integrity"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "del-1", body.Deletion)
+	assert.NotNil(t, body.Integrity, "the repository is re-baselined")
+	assert.Nil(t, srv.Maintenance())
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/service"
+)
+
+// RecoveriesPath is the prefix of social recovery endpoints (relative to APIBasePath)
+const RecoveriesPath = "/recoveries"
+
+// SignRecoveryRequest is the body of POST /api/v1/recoveries/{id}/sign
+type SignRecoveryRequest struct {
+	ContactID string `json:"contact_id"`
+	Signature string `json:"signature"` // hex-encoded, over the recovery's sign data
+}
+
+// CancelRecoveryRequest is the body of POST /api/v1/recoveries/{id}/cancel
+type CancelRecoveryRequest struct {
+	KeyHolderID string `json:"key_holder_id"`
+	Signature   string `json:"signature"` // hex-encoded, over the recovery's cancel data
+}
+
+// UseRecoveryRequest is the body of POST /api/v1/recoveries/{id}/use
+type UseRecoveryRequest struct {
+	RequestID string `json:"request_id"`
+}
+
+// RecoveriesStatus is the body of GET /api/v1/recoveries
+type RecoveriesStatus struct {
+	Policy     *consent.RecoveryPolicy `json:"policy,omitempty"`
+	Recoveries []*consent.Recovery     `json:"recoveries"`
+	Events     []consent.RecoveryEvent `json:"events"`
+}
+
+// recoveriesHandler serves:
+//
+//	GET  /api/v1/recoveries              the social recovery policy, recoveries and their audit trail
+//	POST /api/v1/recoveries              open a recovery signed by a recovery contact
+//	GET  /api/v1/recoveries/{id}         one recovery, for contacts to sign
+//	POST /api/v1/recoveries/{id}/sign    add a recovery contact's signature
+//	POST /api/v1/recoveries/{id}/cancel  cancel a recovery, signed by a key holder
+//	POST /api/v1/recoveries/{id}/use     approve a restore request with a usable recovery
+func recoveriesHandler(cfg *config.Config, svc *service.ConsentService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest := strings.Trim(strings.TrimPrefix(r.URL.Path, RecoveriesPath), "/")
+		if rest == "" {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead:
+				recoveries, events, err := svc.ListRecoveries()
+				if err != nil {
+					writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+					return
+				}
+				writeJSON(w, http.StatusOK, RecoveriesStatus{Policy: cfg.SocialRecovery, Recoveries: recoveries, Events: events})
+			case http.MethodPost:
+				var rec consent.Recovery
+				if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&rec); err != nil {
+					writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "invalid request body"))
+					return
+				}
+				if err := svc.OpenRecovery(&rec); err != nil {
+					writeError(w, apperrors.Coded(apperrors.CodeInvalidArgument, err))
+					return
+				}
+				writeJSON(w, http.StatusCreated, rec)
+			default:
+				writeError(w, errMethodNotAllowed)
+			}
+			return
+		}
+
+		id, action, _ := strings.Cut(rest, "/")
+		if action == "" {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				writeError(w, errMethodNotAllowed)
+				return
+			}
+			rec, err := svc.GetRecovery(id)
+			if err != nil {
+				writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+				return
+			}
+			writeJSON(w, http.StatusOK, rec)
+			return
+		}
+		if r.Method != http.MethodPost {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+
+		switch action {
+		case "sign":
+			var req SignRecoveryRequest
+			signature, ok := decodeSigned(w, r, &req, func() string { return req.Signature })
+			if !ok {
+				return
+			}
+			rec, err := svc.SignRecovery(id, req.ContactID, signature)
+			if err != nil {
+				writeError(w, apperrors.Coded(apperrors.CodeInvalidArgument, err))
+				return
+			}
+			writeJSON(w, http.StatusOK, rec)
+		case "cancel":
+			var req CancelRecoveryRequest
+			signature, ok := decodeSigned(w, r, &req, func() string { return req.Signature })
+			if !ok {
+				return
+			}
+			rec, err := svc.CancelRecovery(id, req.KeyHolderID, signature)
+			if err != nil {
+				writeError(w, apperrors.Coded(apperrors.CodeInvalidArgument, err))
+				return
+			}
+			writeJSON(w, http.StatusOK, rec)
+		case "use":
+			var req UseRecoveryRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RequestID == "" {
+				writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "request_id is required"))
+				return
+			}
+			progress, err := svc.UseRecovery(id, req.RequestID)
+			if err != nil {
+				writeError(w, apperrors.Coded(apperrors.CodeInvalidArgument, err))
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{
+				"approvals": progress.Current,
+				"required":  progress.Required,
+				"complete":  progress.IsApproved,
+			})
+		default:
+			writeError(w, apperrors.New(apperrors.CodeNotFound, "unknown recovery route"))
+		}
+	})
+}
+
+// decodeSigned decodes a JSON body carrying a hex-encoded signature into
+// body, writing the error if it fails
+func decodeSigned(w http.ResponseWriter, r *http.Request, body any, signature func() string) ([]byte, bool) {
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "invalid request body"))
+		return nil, false
+	}
+	decoded, err := hex.DecodeString(signature())
+	if err != nil || len(decoded) == 0 {
+		writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "signature must be hex-encoded"))
+		return nil, false
+	}
+	return decoded, true
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/service"
+)
+
+func TestRecoveriesHandler(t *testing.T) {
+	alicePub, alicePriv, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+	danaPub, danaPriv, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+	erinPub, erinPriv, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+	alice, dana, erin := crypto.KeyID(alicePub), crypto.KeyID(danaPub), crypto.KeyID(erinPub)
+
+	dir := t.TempDir()
+	cfg := &config.Config{Name: "owner", ConfigDir: dir,
+		Consensus: &config.ConsensusConfig{
+			Threshold:  1,
+			TotalKeys:  1,
+			KeyHolders: []config.KeyHolder{{ID: alice, Name: "Alice", PublicKey: alicePub}},
+		},
+		SocialRecovery: &consent.RecoveryPolicy{
+			Contacts: []consent.RecoveryContact{
+				{ID: dana, Name: "Dana", PublicKey: danaPub},
+				{ID: erin, Name: "Erin", PublicKey: erinPub},
+			},
+			Threshold: 2,
+		},
+	}
+
+	mux := http.NewServeMux()
+	h := recoveriesHandler(cfg, service.NewConsentService(cfg, consent.NewManager(dir)))
+	mux.Handle(RecoveriesPath, h)
+	mux.Handle(RecoveriesPath+"/", h)
+	post := func(path string, v any) *httptest.ResponseRecorder {
+		body, err := json.Marshal(v)
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(string(body))))
+		return rec
+	}
+
+	r, err := consent.NewRecovery(alice, "lost their keys in a fire")
+	require.NoError(t, err)
+	sign := func(priv []byte) []byte {
+		sig, err := r.SignData().Sign(priv)
+		require.NoError(t, err)
+		return sig
+	}
+
+	// Only a recovery contact can open one
+	r.Signatures = []consent.RecoverySignature{{ContactID: alice, Signature: sign(alicePriv)}}
+	assert.Equal(t, http.StatusBadRequest, post(RecoveriesPath, r).Code)
+	r.Signatures = []consent.RecoverySignature{{ContactID: dana, Signature: sign(danaPriv)}}
+	rec := post(RecoveriesPath, r)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var opened consent.Recovery
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &opened))
+	assert.Equal(t, "Alice", opened.MissingKeyHolderName)
+	assert.Equal(t, "Dana", opened.Signatures[0].ContactName)
+	assert.True(t, r.OpenedAt.Add(consent.DefaultRecoveryWait).Equal(opened.UsableAt))
+
+	// Contacts fetch it to sign
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, RecoveriesPath+"/"+r.ID, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	assert.Equal(t, http.StatusBadRequest, post(RecoveriesPath+"/"+r.ID+"/sign", SignRecoveryRequest{ContactID: erin, Signature: hex.EncodeToString(sign(danaPriv))}).Code)
+	rec = post(RecoveriesPath+"/"+r.ID+"/sign", SignRecoveryRequest{ContactID: erin, Signature: hex.EncodeToString(sign(erinPriv))})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// Still in its waiting period
+	rec = post(RecoveriesPath+"/"+r.ID+"/use", UseRecoveryRequest{RequestID: "req1"})
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code, rec.Body.String())
+
+	// The key holder shows they still have their key
+	assert.Equal(t, http.StatusBadRequest, post(RecoveriesPath+"/"+r.ID+"/cancel", CancelRecoveryRequest{KeyHolderID: alice, Signature: hex.EncodeToString(sign(alicePriv))}).Code,
+		"the cancel data differs from the sign data")
+	cancelSig, err := r.CancelSignData().Sign(alicePriv)
+	require.NoError(t, err)
+	rec = post(RecoveriesPath+"/"+r.ID+"/cancel", CancelRecoveryRequest{KeyHolderID: alice, Signature: hex.EncodeToString(cancelSig)})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, RecoveriesPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var status RecoveriesStatus
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	require.Len(t, status.Recoveries, 1)
+	assert.NotNil(t, status.Recoveries[0].CancelledAt)
+	assert.Len(t, status.Events, 3)
+	assert.Equal(t, 2, status.Policy.Threshold)
+}
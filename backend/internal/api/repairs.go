@@ -0,0 +1,113 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/integrity"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/repair"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+)
+
+// Paths of the repair endpoints (relative to APIBasePath)
+const (
+	repairsPath     = "/repairs"
+	repairInboxPath = "/repairs/incoming"
+)
+
+// repairsHandler serves:
+//
+//	GET /api/v1/repairs    corrupt files the host reported, and whether they are repaired
+func repairsHandler(store *repair.Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+		requests, err := store.List()
+		if err != nil {
+			writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"repairs": requests})
+	})
+}
+
+// repairInboxHandler receives files the peer's storage server found corrupt
+// in our repository, queuing them to be repaired by the next backup
+func repairInboxHandler(store *repair.Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+
+		var requests []repair.Request
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&requests); err != nil || len(requests) == 0 {
+			writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "invalid repair request"))
+			return
+		}
+		for i := range requests {
+			if err := requests[i].Validate(); err != nil {
+				writeError(w, apperrors.Coded(apperrors.CodeInvalidArgument, err))
+				return
+			}
+			requests[i].ReceivedAt = time.Time{}
+		}
+
+		added, err := store.Add(requests)
+		if err != nil {
+			writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+			return
+		}
+		if added > 0 {
+			logging.Warn("Peer found corrupt files in our repository; the next backup will repair them",
+				logging.Int("files", added),
+				tracing.Field(r.Context()))
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+// peerRepairNotifier returns a callback that asks the owner to repair each
+// file added to the bad-file list
+func peerRepairNotifier(cfg *config.Config) func(integrity.BadFile) {
+	return func(f integrity.BadFile) {
+		if cfg.Peer == nil || cfg.Peer.Address == "" {
+			return
+		}
+
+		body, err := json.Marshal([]repair.Request{{
+			Repo:       f.Repo,
+			File:       f.Path,
+			Source:     f.Source,
+			DetectedAt: f.DetectedAt,
+		}})
+		if err != nil {
+			return
+		}
+		go func() {
+			client := PeerClient(cfg, 10*time.Second)
+			url := strings.TrimSuffix(cfg.Peer.Address, "/") + APIBasePath + repairInboxPath
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, bytes.NewReader(body))
+			if err != nil {
+				return
+			}
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := client.Do(req)
+			if err != nil {
+				logging.Warn("Could not ask peer to repair a corrupt file",
+					logging.String("file", f.Path), logging.Err(err))
+				return
+			}
+			_ = resp.Body.Close()
+		}()
+	}
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/repair"
+)
+
+func TestRepairInboxHandler(t *testing.T) {
+	store := repair.NewStore(filepath.Join(t.TempDir(), "repairs.json"))
+	inbox := repairInboxHandler(store)
+	do := func(method, body string) int {
+		rec := httptest.NewRecorder()
+		inbox.ServeHTTP(rec, httptest.NewRequest(method, repairInboxPath, strings.NewReader(body)))
+		return rec.Code
+	}
+	pack := strings.Repeat("ab", 32)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, do(http.MethodGet, ""))
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "not json"))
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "[]"))
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, `[{"repo":"alice","file":"config"}]`))
+	assert.Equal(t, http.StatusAccepted, do(http.MethodPost,
+		`[{"repo":"alice","file":"data/ab/`+pack+`","source":"read","detectedAt":"2026-10-01T12:00:00Z"}]`))
+
+	rec := httptest.NewRecorder()
+	repairsHandler(store).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, repairsPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		Repairs []repair.Request `json:"repairs"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Len(t, body.Repairs, 1)
+	assert.Equal(t, "data/ab/"+pack, body.Repairs[0].File)
+	assert.True(t, body.Repairs[0].Pending())
+}
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/gen/airgapper/v1/airgapperv1connect"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/restore"
+	"github.com/lcrostarosa/airgapper/backend/internal/restorejob"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+)
+
+// restoresPath is the prefix of restore job endpoints (relative to APIBasePath)
+const restoresPath = "/restores"
+
+// restorePreflightID is the path under restoresPath that checks a restore's
+// target without submitting it
+const restorePreflightID = "preflight"
+
+// restoreJobRequest is the body of POST /api/v1/restores
+type restoreJobRequest struct {
+	RequestID string `json:"request_id"`
+	// Target is the absolute directory to restore into; "/" restores to
+	// the original locations
+	Target   string `json:"target"`
+	Conflict string `json:"conflict,omitempty"` // skip (default), overwrite or keep-both
+	Suffix   string `json:"suffix,omitempty"`
+}
+
+// restoresHandler serves the owner's restore jobs:
+//
+//	POST   /api/v1/restores       run a restore of an approved request
+//	POST   /api/v1/restores/preflight  check a restore's target, writing nothing
+//	GET    /api/v1/restores       every job, oldest first
+//	GET    /api/v1/restores/{id}  a job and its progress
+//	DELETE /api/v1/restores/{id}  cancel a job
+func restoresHandler(jobs *restorejob.Manager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if jobs == nil {
+			writeError(w, apperrors.New(apperrors.CodeFailedPrecondition, "restore jobs run on the owner's daemon"))
+			return
+		}
+
+		id := strings.Trim(strings.TrimPrefix(r.URL.Path, restoresPath), "/")
+		if id == "" {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead:
+				list, err := jobs.Store.List()
+				if err != nil {
+					writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+					return
+				}
+				writeJSON(w, http.StatusOK, map[string]any{"jobs": list})
+			case http.MethodPost:
+				submitRestoreJob(w, r, jobs)
+			default:
+				writeError(w, errMethodNotAllowed)
+			}
+			return
+		}
+
+		if id == restorePreflightID {
+			if r.Method != http.MethodPost {
+				writeError(w, errMethodNotAllowed)
+				return
+			}
+			preflightRestore(w, r, jobs)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			job, err := jobs.Store.Get(id)
+			if err != nil {
+				writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+				return
+			}
+			writeJSON(w, http.StatusOK, job)
+		case http.MethodDelete:
+			job, err := jobs.Cancel(id)
+			if err != nil {
+				writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+				return
+			}
+			writeJSON(w, http.StatusOK, job)
+		default:
+			writeError(w, errMethodNotAllowed)
+		}
+	})
+}
+
+// decodeRestoreSpec reads a restore job request's body
+func decodeRestoreSpec(w http.ResponseWriter, r *http.Request) (restorejob.Spec, bool) {
+	var body restoreJobRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&body); err != nil || body.RequestID == "" || body.Target == "" {
+		writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "request_id and target are required"))
+		return restorejob.Spec{}, false
+	}
+	return restorejob.Spec{
+		RequestID: body.RequestID,
+		Target:    body.Target,
+		Conflict:  restore.Strategy(body.Conflict),
+		Suffix:    body.Suffix,
+	}, true
+}
+
+func submitRestoreJob(w http.ResponseWriter, r *http.Request, jobs *restorejob.Manager) {
+	spec, ok := decodeRestoreSpec(w, r)
+	if !ok {
+		return
+	}
+	job, err := jobs.Submit(spec)
+	if err != nil {
+		writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+		return
+	}
+	logging.Info("Restore job submitted",
+		logging.String("job", job.ID),
+		logging.String("request", job.RequestID),
+		tracing.Field(r.Context()))
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// preflightRestore checks a restore's target as its job's first run will,
+// returning the report whether or not the checks pass
+func preflightRestore(w http.ResponseWriter, r *http.Request, jobs *restorejob.Manager) {
+	spec, ok := decodeRestoreSpec(w, r)
+	if !ok {
+		return
+	}
+	report, err := jobs.Preflight(r.Context(), spec)
+	if err != nil {
+		writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// restoreProgressBody is RestoreProgress as the peer's API takes it
+type restoreProgressBody struct {
+	Status     string     `json:"status"`
+	StartsAt   *time.Time `json:"startsAt,omitempty"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	Files      int        `json:"files,omitempty"`
+	Restored   int        `json:"restored,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// PeerRestoreReporter returns a callback that reports a restore's progress
+// to the peer's copy of its request. Failures only warn: progress is
+// informational.
+func PeerRestoreReporter(cfg *config.Config) func(ctx context.Context, req *consent.RestoreRequest, p consent.RestoreProgress) {
+	return func(ctx context.Context, req *consent.RestoreRequest, p consent.RestoreProgress) {
+		if cfg.Peer == nil || cfg.Peer.Address == "" {
+			return
+		}
+
+		body, err := json.Marshal(map[string]any{"id": req.ID, "progress": restoreProgressBody{
+			Status:     p.Status,
+			StartsAt:   p.StartsAt,
+			StartedAt:  p.StartedAt,
+			FinishedAt: p.FinishedAt,
+			Files:      p.Files,
+			Restored:   p.Restored,
+			Error:      p.Error,
+		}})
+		if err != nil {
+			return
+		}
+		url := strings.TrimSuffix(cfg.Peer.Address, "/") + APIBasePath + "/" +
+			airgapperv1connect.RestoreRequestServiceName + "/ReportRestoreProgress"
+		httpReq, err := http.NewRequestWithContext(tracing.WithID(ctx, req.CorrelationID), http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		if cfg.Peer.Token != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+cfg.Peer.Token)
+		}
+		resp, err := PeerClient(cfg, 30*time.Second).Do(httpReq)
+		if err != nil {
+			logging.Warn("Could not report restore progress to peer", logging.Err(err))
+			return
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			logging.Warn("Peer did not record restore progress", logging.Int("status", resp.StatusCode))
+		}
+	}
+}
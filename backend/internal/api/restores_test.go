@@ -0,0 +1,153 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/restore"
+	"github.com/lcrostarosa/airgapper/backend/internal/restorejob"
+)
+
+// approvedRequests approves every request it is asked for
+type approvedRequests struct{}
+
+func (approvedRequests) GetRequest(id string) (*consent.RestoreRequest, error) {
+	if id == "missing" {
+		return nil, apperrors.ErrRequestNotFound
+	}
+	return &consent.RestoreRequest{ID: id, Status: consent.StatusApproved, SnapshotID: "latest"}, nil
+}
+
+func (approvedRequests) CheckLockdown() error { return nil }
+
+func (approvedRequests) RecordProgress(id string, p consent.RestoreProgress) (*consent.RestoreRequest, error) {
+	return nil, nil
+}
+
+// oneFileRepo is a repository whose snapshot holds one small file
+type oneFileRepo struct{}
+
+func (oneFileRepo) RestoreWith(context.Context, string, restic.RestoreOptions) error { return nil }
+
+func (oneFileRepo) ResolveSnapshot(context.Context, string) (string, error) { return "abcd1234", nil }
+
+func (oneFileRepo) ListFiles(context.Context, string) ([]restic.Node, error) {
+	return []restic.Node{{Type: "file", Path: "/docs/a.txt", Size: 10}}, nil
+}
+
+func (oneFileRepo) RestoreSize(context.Context, string) (int64, error) { return 10, nil }
+
+func TestRestorePreflight(t *testing.T) {
+	home := t.TempDir()
+	jobs := &restorejob.Manager{
+		Store:       restorejob.NewStore(filepath.Join(t.TempDir(), "restore-jobs.json")),
+		Requests:    approvedRequests{},
+		Password:    func(context.Context, *consent.RestoreRequest) ([]byte, error) { return []byte("secret"), nil },
+		Open:        func([]byte) restorejob.Repo { return oneFileRepo{} },
+		BackupPaths: []string{home},
+	}
+	h := restoresHandler(jobs)
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/restores/preflight", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := post(`{"request_id":"req1","target":"` + filepath.Join(home, "restore") + `"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var report restore.Preflight
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, "abcd1234", report.SnapshotID)
+	assert.Equal(t, int64(10), report.RestoreBytes)
+	require.Len(t, report.Failed(), 1, "the report is returned even when a check fails")
+	assert.Equal(t, restore.CheckBackupLoop, report.Failed()[0].Name)
+
+	assert.Equal(t, http.StatusBadRequest, post(`{"request_id":"req1"}`).Code)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/restores/preflight", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	jobList, err := jobs.Store.List()
+	require.NoError(t, err)
+	assert.Empty(t, jobList, "a preflight submits nothing")
+}
+
+func TestRestoresHandler(t *testing.T) {
+	jobs := &restorejob.Manager{
+		Store:    restorejob.NewStore(filepath.Join(t.TempDir(), "restore-jobs.json")),
+		Requests: approvedRequests{},
+	}
+	h := restoresHandler(jobs)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/restores", `{"request_id":"req1","target":"/restore","conflict":"keep-both"}`)
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	var job restorejob.Job
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &job))
+	assert.Equal(t, restorejob.StatusQueued, job.Status)
+	assert.Equal(t, "req1", job.RequestID)
+
+	t.Run("one job per request", func(t *testing.T) {
+		rec := do(http.MethodPost, "/restores", `{"request_id":"req1","target":"/elsewhere"}`)
+		require.Equal(t, http.StatusConflict, rec.Code)
+		var body errorBody
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, apperrors.CodeRestoreJobActive, body.Code)
+	})
+
+	t.Run("list and get", func(t *testing.T) {
+		rec := do(http.MethodGet, "/restores", "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var list struct {
+			Jobs []restorejob.Job `json:"jobs"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+		require.Len(t, list.Jobs, 1)
+
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/restores/"+job.ID, "").Code)
+		assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/restores/nope", "").Code)
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		rec := do(http.MethodDelete, "/restores/"+job.ID, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &job))
+		assert.Equal(t, restorejob.StatusCancelled, job.Status)
+		assert.Equal(t, http.StatusPreconditionFailed, do(http.MethodDelete, "/restores/"+job.ID, "").Code)
+	})
+
+	for name, tc := range map[string]struct {
+		body string
+		want int
+	}{
+		"no target":       {`{"request_id":"req2"}`, http.StatusBadRequest},
+		"relative target": {`{"request_id":"req2","target":"restore"}`, http.StatusBadRequest},
+		"bad conflict":    {`{"request_id":"req2","target":"/r","conflict":"merge"}`, http.StatusBadRequest},
+		"unknown request": {`{"request_id":"missing","target":"/r"}`, http.StatusNotFound},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, do(http.MethodPost, "/restores", tc.body).Code)
+		})
+	}
+
+	assert.Equal(t, http.StatusMethodNotAllowed, do(http.MethodPut, "/restores", "").Code)
+
+	rec = httptest.NewRecorder()
+	restoresHandler(nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/restores", nil))
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code, "only the owner's daemon runs jobs")
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/policy"
+	"github.com/lcrostarosa/airgapper/backend/internal/prune"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+// retentionPreviewPath is the retention preview endpoint (relative to
+// APIBasePath)
+const retentionPreviewPath = "/retention/preview"
+
+// retentionPreviewHandler serves GET /api/v1/retention/preview: the
+// snapshots the configured retention policy would keep and remove, without
+// removing any
+func retentionPreviewHandler(cfg *config.Config, open restic.RunnerFactory) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+		if !cfg.IsOwner() || cfg.Password == "" {
+			writeError(w, errNoRepoPassword)
+			return
+		}
+		pins, err := policy.LoadPinSet(cfg.PinsPath())
+		if err != nil {
+			writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+			return
+		}
+		plan, err := prune.Preview(r.Context(), open(cfg.RepoURL, cfg.Password), cfg.BackupRetention, pins)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, plan)
+	})
+}
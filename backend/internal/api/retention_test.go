@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/scheduler"
+	"github.com/lcrostarosa/airgapper/backend/internal/testutil"
+)
+
+func TestRetentionPreviewHandler(t *testing.T) {
+	fake := testutil.NewFakeRestic()
+	now := time.Now()
+	fake.Snapshots = []restic.Snapshot{
+		{ID: "aaaa", Time: now.Add(-2 * time.Hour), Tags: []string{restic.TagAirgapper}},
+		{ID: "bbbb", Time: now.Add(-time.Hour), Tags: []string{restic.TagAirgapper}},
+	}
+	cfg := &config.Config{Role: config.RoleOwner, RepoURL: "rest:http://host/alice", Password: "secret", ConfigDir: t.TempDir()}
+	h := retentionPreviewHandler(cfg, fake.Factory())
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, retentionPreviewPath, nil))
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code, "no retention policy is configured")
+
+	cfg.BackupRetention = &scheduler.RetentionConfig{KeepLast: 1}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, retentionPreviewPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var plan consent.RetentionPlan
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &plan))
+	assert.Equal(t, []string{"aaaa"}, plan.RemoveIDs())
+	require.Len(t, plan.Keep, 1)
+	assert.Equal(t, "bbbb", plan.Keep[0].ID)
+	assert.Len(t, fake.Snapshots, 2, "a preview removes nothing")
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, retentionPreviewPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	cfg.Password = ""
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, retentionPreviewPath, nil))
+	assert.NotEqual(t, http.StatusOK, rec.Code)
+}
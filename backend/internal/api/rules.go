@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+// RulesPath is the approval rules endpoint (relative to APIBasePath)
+const RulesPath = "/rules"
+
+// RulesStatus is the body of GET /api/v1/rules
+type RulesStatus struct {
+	Rules     *consent.ApprovalRules `json:"rules,omitempty"`
+	Decisions []consent.RuleDecision `json:"decisions"`
+}
+
+// rulesHandler serves GET /api/v1/rules: the approval rules in force and
+// every automatic decision they made
+func rulesHandler(cfg *config.Config, consentMgr *consent.Manager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+		decisions, err := consentMgr.ListRuleDecisions()
+		if err != nil {
+			writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+			return
+		}
+		if decisions == nil {
+			decisions = []consent.RuleDecision{}
+		}
+		writeJSON(w, http.StatusOK, RulesStatus{Rules: cfg.ApprovalRules, Decisions: decisions})
+	})
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+)
+
+func TestRulesHandler(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{Name: "bob", ConfigDir: dir, ApprovalRules: &consent.ApprovalRules{DenyUnknownKeys: true}}
+	mgr := consent.NewManager(dir)
+	h := rulesHandler(cfg, mgr)
+
+	get := func() RulesStatus {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, RulesPath, nil))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var status RulesStatus
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+		return status
+	}
+
+	status := get()
+	require.NotNil(t, status.Rules)
+	assert.True(t, status.Rules.DenyUnknownKeys)
+	assert.Empty(t, status.Decisions)
+
+	require.NoError(t, mgr.RecordRuleDecision(consent.RuleDecision{RequestID: "r1", Decision: consent.RuleDeny, Rule: "deny_unknown_keys"}))
+	status = get()
+	require.Len(t, status.Decisions, 1)
+	assert.Equal(t, "r1", status.Decisions[0].RequestID)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, RulesPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -4,14 +4,27 @@ package api
 import (
 	"context"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/lcrostarosa/airgapper/backend/internal/actionlink"
+	"github.com/lcrostarosa/airgapper/backend/internal/auditlog"
+	"github.com/lcrostarosa/airgapper/backend/internal/backupreport"
 	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/events"
 	"github.com/lcrostarosa/airgapper/backend/internal/grpc"
 	"github.com/lcrostarosa/airgapper/backend/internal/integrity"
+	"github.com/lcrostarosa/airgapper/backend/internal/lockdown"
 	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/passkey"
+	"github.com/lcrostarosa/airgapper/backend/internal/repair"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/restorejob"
 	"github.com/lcrostarosa/airgapper/backend/internal/scheduler"
+	"github.com/lcrostarosa/airgapper/backend/internal/service"
 	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+	"github.com/lcrostarosa/airgapper/backend/internal/webui"
 )
 
 // Server is the HTTP API server
@@ -22,6 +35,17 @@ type Server struct {
 	integrityChecker        *integrity.Checker
 	managedScheduledChecker *integrity.ManagedScheduledChecker
 	addr                    string
+	version                 string
+	restic                  restic.RunnerFactory
+	restoreJobs             *restorejob.Manager
+	dashboard               *dashboard
+
+	// Serves every route, without CORS, when an admin socket is in use
+	adminHandler http.Handler
+
+	// Forwards audit records to a syslog collector (optional)
+	auditForwarder *auditlog.Forwarder
+	stopAuditFeed  func()
 
 	// cfg is for internal server initialization only (storage, integrity).
 	cfg *config.Config
@@ -44,6 +68,18 @@ func NewServerWithOptions(cfg *config.Config, addr string, opts *ServerOptions)
 		s.storageServer = opts.StorageServer
 		s.integrityChecker = opts.IntegrityChecker
 		s.managedScheduledChecker = opts.ScheduledChecker
+		s.version = opts.Version
+		s.restic = opts.Restic
+		s.restoreJobs = opts.RestoreJobs
+	}
+	adminSocket := opts != nil && opts.AdminSocket != ""
+	if s.restic == nil {
+		// Backups the daemon runs use the configured compression and restic
+		// options, and are signed with this node's key for a host requiring
+		// its identity
+		s.restic = func(repoURL, password string) restic.Runner {
+			return restic.NewClient(repoURL, password).WithIdentity(cfg.PrivateKey).WithOptions(cfg.Restic).WithCompression(cfg.BackupCompression)
+		}
 	}
 
 	// Initialize storage components if not provided via options
@@ -65,24 +101,216 @@ func NewServerWithOptions(cfg *config.Config, addr string, opts *ServerOptions)
 		StorageServer:    s.storageServer,
 		IntegrityChecker: s.integrityChecker,
 		ScheduledChecker: s.managedScheduledChecker,
+		Restic:           s.restic,
+
+		APIVersion:           APIVersion,
+		SupportedAPIVersions: SupportedAPIVersions,
 	}
 	s.grpcServer = grpc.NewServer(cfg, grpcOpts)
 
-	mux := http.NewServeMux()
+	// Versioned API routes, mounted under APIBasePath
+	apiMux := http.NewServeMux()
 
 	// Register Connect-RPC handlers (gRPC-compatible API)
-	// These are mounted at /airgapper.v1.<ServiceName>/<Method>
-	s.grpcServer.RegisterHandlers(mux)
+	// These are mounted at /api/v1/airgapper.v1.<ServiceName>/<Method>
+	s.grpcServer.RegisterHandlers(apiMux)
 	logging.Info("Connect-RPC handlers registered (gRPC-compatible API)")
 
+	// API description, explorer, and version negotiation
+	apiMux.Handle(openAPIPath, openAPIHandler(s.version))
+	apiMux.Handle(apiDocsPath, apiDocsHandler())
+	apiMux.Handle(VersionPath, versionHandler(s.version))
+	apiMux.Handle(CapabilitiesPath, capabilitiesHandler(s.version))
+
+	// Path picker for choosing backup paths, confined to AllowedBrowseRoots
+	apiMux.Handle(browsePath, browseHandler(cfg))
+
+	// What changed between two snapshots (owner only)
+	apiMux.Handle(snapshotsPath, snapshotDiffHandler(resticDiffer(cfg, s.restic)))
+
+	// Repository locks left by interrupted commands (owner only)
+	locks := locksHandler(cfg, s.restic)
+	apiMux.Handle(locksPath, locks)
+	apiMux.Handle(unlockPath, locks)
+
+	// What the retention policy would delete (owner only)
+	apiMux.Handle(retentionPreviewPath, retentionPreviewHandler(cfg, s.restic))
+
+	// When scheduled backups run and how much they upload, for the host (owner only)
+	apiMux.Handle(BackupWindowPath, backupWindowHandler(cfg, backupreport.NewStore(cfg.BackupReportsPath())))
+
+	// What each backup run stored (owner only)
+	backups := backupReportsHandler(backupreport.NewStore(cfg.BackupReportsPath()))
+	apiMux.Handle(backupsPath, backups)
+	apiMux.Handle(backupsPath+"/", backups)
+	apiMux.Handle(dedupStatsPath, dedupStatsHandler(cfg, backupreport.NewDedupStore(cfg.DedupStatsPath()), s.storageServer))
+
+	// Restores of approved requests, run in the background (owner only)
+	restores := restoresHandler(s.restoreJobs)
+	apiMux.Handle(restoresPath, restores)
+	apiMux.Handle(restoresPath+"/", restores)
+
+	// Corrupt files the host found, repaired by the next backup
+	repairs := repair.NewStore(cfg.RepairsPath())
+	apiMux.Handle(repairsPath, repairsHandler(repairs))
+	apiMux.Handle(repairInboxPath, repairInboxHandler(repairs))
+
+	// Policy renegotiation and signed history
+	amendments := policyAmendmentsHandler(s.storageServer)
+	apiMux.Handle(policyAmendmentsPath, amendments)
+	apiMux.Handle(policyAmendmentsPath+"/", amendments)
+	apiMux.Handle(policyAmendmentInboxPath, policyAmendmentInboxHandler())
+	apiMux.Handle(policyHistoryPath, policyHistoryHandler(s.storageServer))
+	apiMux.Handle(hostVaultPath, hostVaultHandler(s.storageServer))
+	apiMux.Handle(storageReposPath, storageReposHandler(cfg, s.storageServer))
+	apiMux.Handle(hostPruneBeginPath, hostPruneBeginHandler(s.storageServer))
+	apiMux.Handle(hostPruneEndPath, hostPruneEndHandler(s.storageServer, s.integrityChecker))
+	apiMux.Handle(hostAnomalyInboxPath, hostAnomalyInboxHandler(cfg))
+	apiMux.Handle(ProofChallengePath, proofChallengeHandler(s.storageServer, cfg))
+	apiMux.Handle(ShareCheckPath, shareCheckHandler(cfg))
+	if s.storageServer != nil {
+		s.storageServer.SetAmendmentNotifier(peerAmendmentNotifier(cfg))
+		s.storageServer.SetAnomalyNotifier(peerAnomalyNotifier(cfg))
+	}
+
+	// The activity feed consent, backups, storage and integrity checks
+	// publish to
+	feed := events.Open(cfg.ConfigDir)
+	if s.storageServer != nil {
+		s.storageServer.SetEventLog(feed)
+	}
+	if s.managedScheduledChecker != nil {
+		s.managedScheduledChecker.SetEventLog(feed)
+	}
+	activity := eventsHandler(feed)
+	apiMux.Handle(eventsPath, activity)
+	apiMux.Handle(eventsPath+"/", activity)
+
+	// Audit records, exported on request and forwarded as they happen
+	apiMux.Handle(auditExportPath, auditExportHandler(s.storageServer, feed))
+	if cfg.AuditSyslog != "" {
+		s.startAuditForwarding(cfg.AuditSyslog, feed)
+	}
+
+	// The panic button; a lockdown outlives restarts, so storage started
+	// while one is in force starts frozen
+	panicButton := panicHandler(cfg, s.storageServer)
+	apiMux.Handle(PanicPath, panicButton)
+	apiMux.Handle(PanicLiftPath, panicButton)
+	if l := lockdown.Load(cfg.ConfigDir); l != nil && s.storageServer != nil {
+		if err := s.storageServer.Freeze(l.ID, l.Reason); err != nil {
+			logging.Warn("Failed to freeze storage for the lockdown in force", logging.Err(err))
+		}
+	}
+
+	// The vault's signed genesis record and key holder countersignatures
+	genesisRecord := genesisHandler(cfg)
+	apiMux.Handle(GenesisPath, genesisRecord)
+	apiMux.Handle(GenesisCountersignPath, genesisRecord)
+
+	// Named templates for recurring restore requests
+	consentMgr := cfg.ConsentManager()
+	templates := templatesHandler(cfg, consentMgr)
+	apiMux.Handle(templatesPath, templates)
+	apiMux.Handle(templatesPath+"/", templates)
+
+	// Time-bounded delegation of a key holder's approval authority
+	delegations := delegationsHandler(service.NewConsentService(cfg, consentMgr))
+	apiMux.Handle(DelegationsPath, delegations)
+	apiMux.Handle(DelegationsPath+"/", delegations)
+
+	// Recovery contacts standing in for a key holder who lost their key
+	recoveries := recoveriesHandler(cfg, service.NewConsentService(cfg, consentMgr))
+	apiMux.Handle(RecoveriesPath, recoveries)
+	apiMux.Handle(RecoveriesPath+"/", recoveries)
+
+	// Consent-gated operations of the registered types
+	operations := operationsHandler(service.NewConsentService(cfg, consentMgr))
+	apiMux.Handle(OperationsPath, operations)
+	apiMux.Handle(OperationsPath+"/", operations)
+
+	// The mobile approval page, for key holders signed in with a passkey
+	var passkeys *passkey.Store
+	if cfg.ConfigDir != "" {
+		passkeys = passkey.NewStore(cfg.ConfigDir)
+	}
+	approvals := approvalsHandler(cfg, passkeys, service.NewConsentService(cfg, consentMgr))
+	apiMux.Handle(approvalsPath, approvals)
+	apiMux.Handle(approvalsPath+"/", approvals)
+
+	// Approving or denying from a notification's link
+	var actionLinks *actionlink.Store
+	if cfg.ConfigDir != "" {
+		actionLinks = actionlink.NewStore(cfg.ConfigDir)
+	}
+	actions := actionLinksHandler(actionLinks, service.NewConsentService(cfg, consentMgr))
+	apiMux.Handle(actionsPath, actions)
+	apiMux.Handle(actionsPath+"/", actions)
+
+	// Everything a dashboard shows, in one call
+	s.dashboard = s.newDashboard(func() (int, error) {
+		pending, err := consentMgr.ListPending()
+		return len(pending), err
+	})
+	apiMux.Handle(dashboardPath, dashboardHandler(s.dashboard))
+
+	// Rules deciding incoming restore requests, and their decisions
+	apiMux.Handle(RulesPath, rulesHandler(cfg, consentMgr))
+
+	// Where notifications go, and which events and priorities each target
+	// receives
+	targets := notificationTargetsHandler(cfg)
+	apiMux.Handle(notificationTargetsPath, targets)
+	apiMux.Handle(notificationTargetsPath+"/", targets)
+
+	// Local user accounts, which gate every other route once one exists
+	accounts := userStore(cfg)
+	apiMux.Handle(UsersPath, usersHandler(accounts))
+	apiMux.Handle(UsersPath+"/", usersHandler(accounts))
+	session := sessionHandler(accounts)
+	apiMux.Handle(SessionPath, session)
+	apiMux.Handle(SessionPath+"/", session)
+	apiMux.Handle(SessionsPath, sessionsHandler(accounts))
+	apiMux.Handle(SessionsPath+"/", sessionsHandler(accounts))
+
+	versioned := withAPIVersion(withAuth(apiMux, accounts))
+	mux := http.NewServeMux()
+	mux.Handle(APIBasePath+"/", http.StripPrefix(APIBasePath, versioned))
+
+	// Legacy unversioned aliases (deprecated)
+	toVersioned := func(path string) string { return APIBasePath + strings.TrimPrefix(path, "/api") }
+	legacyAPI := deprecated(versioned, toVersioned)
+	mux.Handle("/api"+openAPIPath, http.StripPrefix("/api", legacyAPI))
+	mux.Handle("/api"+apiDocsPath, http.StripPrefix("/api", legacyAPI))
+
 	// Mount storage server if configured
 	if s.storageServer != nil {
 		mux.Handle("/storage/", http.StripPrefix("/storage", storage.WithLogging(s.storageServer.Handler())))
 	}
 
+	// Serve the embedded web dashboard for everything else, except legacy
+	// Connect-RPC paths (/airgapper.v1.<ServiceName>/<Method>)
+	ui := webui.Handler()
+	mux.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isLegacyRPCPath(r.URL.Path) {
+			legacyAPI.ServeHTTP(w, r)
+			return
+		}
+		ui.ServeHTTP(w, r)
+	}))
+
+	// With an admin socket, the network listener serves only the
+	// peer-facing routes
+	handler := tracing.Middleware(withPublicKey(cfg.PublicKey, mux))
+	network := handler
+	if adminSocket {
+		s.adminHandler = handler
+		network = peerOnly(handler)
+	}
+
 	s.httpServer = &http.Server{
 		Addr:              addr,
-		Handler:           mux,
+		Handler:           withCORS(network, cfg.CORSAllowedOrigins),
 		ReadTimeout:       15 * time.Second,
 		ReadHeaderTimeout: 10 * time.Second,
 		WriteTimeout:      30 * time.Second,
@@ -98,17 +326,55 @@ func (s *Server) SetScheduler(sched *scheduler.Scheduler) {
 	if s.grpcServer != nil {
 		s.grpcServer.SetScheduler(sched)
 	}
+	if s.dashboard != nil {
+		s.dashboard.scheduler.Store(sched)
+	}
+
+	// Defer scheduled integrity checks while a backup runs
+	if s.managedScheduledChecker != nil && sched != nil {
+		s.managedScheduledChecker.AddActivitySource(func() (bool, string) {
+			return sched.BackupRunning(), "scheduled backup in progress"
+		})
+	}
 }
 
 // Start starts the HTTP server
 func (s *Server) Start() error {
 	logging.Infof("Starting Airgapper API server on %s", s.addr)
+	if s.cfg.TLSEnabled() {
+		return s.httpServer.ListenAndServeTLS(s.cfg.TLSCertFile, s.cfg.TLSKeyFile)
+	}
 	return s.httpServer.ListenAndServe()
 }
 
+// startAuditForwarding forwards the storage server's audit records and
+// the activity feed to a syslog collector
+func (s *Server) startAuditForwarding(collector string, feed *events.Log) {
+	f, err := auditlog.NewForwarder(collector)
+	if err != nil {
+		logging.Warn("Audit records won't be forwarded", logging.Err(err))
+		return
+	}
+	s.auditForwarder = f
+	if s.storageServer != nil {
+		s.storageServer.SetAuditHandler(func(e storage.AuditEntry) { f.Forward(auditlog.FromStorage(e)) })
+	}
+	if feed != nil {
+		s.stopAuditFeed = f.FollowEvents(feed, eventsPollInterval)
+	}
+	logging.Info("Forwarding audit records to syslog", logging.String("collector", collector))
+}
+
 // Shutdown gracefully shuts down the server
 func (s *Server) Shutdown(ctx context.Context) error {
-	return s.httpServer.Shutdown(ctx)
+	err := s.httpServer.Shutdown(ctx)
+	if s.auditForwarder != nil {
+		if s.stopAuditFeed != nil {
+			s.stopAuditFeed()
+		}
+		s.auditForwarder.Close(5 * time.Second)
+	}
+	return err
 }
 
 // Addr returns the server's listen address
@@ -121,6 +387,17 @@ func (s *Server) Handler() http.Handler {
 	return s.httpServer.Handler
 }
 
+// AdminHandler returns the handler for the admin socket, or nil if the
+// server wasn't created with one
+func (s *Server) AdminHandler() http.Handler {
+	return s.adminHandler
+}
+
+// Restic returns the factory the server opens the owner's repository with
+func (s *Server) Restic() restic.RunnerFactory {
+	return s.restic
+}
+
 // StorageServer returns the storage server instance (may be nil)
 func (s *Server) StorageServer() *storage.Server {
 	return s.storageServer
@@ -0,0 +1,333 @@
+package api
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/users"
+)
+
+// Login session endpoints (relative to APIBasePath)
+const (
+	SessionPath       = "/session"
+	SessionLoginPath  = "/session/login"
+	SessionLogoutPath = "/session/logout"
+	SessionsPath      = "/sessions"
+)
+
+const (
+	// SessionCookie holds the session token of a browser login
+	SessionCookie = "airgapper_session"
+	// CSRFHeader must carry the session's CSRF token on every change made
+	// with a session cookie
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// Failed logins are limited per client address and per user name, so
+// passwords can't be guessed quickly from one place or spread across many
+const (
+	loginFailureLimit  = 5
+	loginFailureWindow = 15 * time.Minute
+)
+
+// loginRequest is the body of POST /api/v1/session/login
+type loginRequest struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+// sessionInfo describes a login session without its token
+type sessionInfo struct {
+	ID         string    `json:"id"`
+	User       string    `json:"user"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	RemoteAddr string    `json:"remote_addr,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current,omitempty"` // The session making the call
+}
+
+// sessionResponse is the body of GET /api/v1/session and of a login
+type sessionResponse struct {
+	// AuthRequired is false while the node has no user accounts, when the
+	// UI needs no login
+	AuthRequired bool         `json:"auth_required"`
+	User         *userInfo    `json:"user,omitempty"`
+	Session      *sessionInfo `json:"session,omitempty"`
+	CSRFToken    string       `json:"csrf_token,omitempty"`
+}
+
+func toSessionInfo(s *users.Session, current *users.Session) sessionInfo {
+	return sessionInfo{
+		ID:         s.ID,
+		User:       s.User,
+		UserAgent:  s.UserAgent,
+		RemoteAddr: s.RemoteAddr,
+		CreatedAt:  s.CreatedAt,
+		LastSeenAt: s.LastSeenAt,
+		ExpiresAt:  s.ExpiresAt(),
+		Current:    current != nil && s.ID == current.ID,
+	}
+}
+
+// sessionHandler serves the browser login:
+//
+//	GET  /api/v1/session         who is logged in, and the CSRF token
+//	POST /api/v1/session/login   log in with a name and password
+//	POST /api/v1/session/logout  end the current session
+func sessionHandler(store *users.Store) http.Handler {
+	limiter := newLoginLimiter()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == SessionPath && (r.Method == http.MethodGet || r.Method == http.MethodHead):
+			c := callerFrom(r.Context())
+			if c == nil {
+				writeJSON(w, http.StatusOK, sessionResponse{})
+				return
+			}
+			writeJSON(w, http.StatusOK, newSessionResponse(c.user, c.session))
+		case r.URL.Path == SessionLoginPath && r.Method == http.MethodPost:
+			login(w, r, store, limiter)
+		case r.URL.Path == SessionLogoutPath && r.Method == http.MethodPost:
+			c := callerFrom(r.Context())
+			if c == nil || c.session == nil {
+				writeError(w, apperrors.New(apperrors.CodeFailedPrecondition, "not logged in with a session"))
+				return
+			}
+			if err := store.RevokeSession(c.session.ID); err != nil {
+				writeError(w, err)
+				return
+			}
+			clearSessionCookie(w, r)
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Path == SessionPath || r.URL.Path == SessionLoginPath || r.URL.Path == SessionLogoutPath:
+			writeError(w, errMethodNotAllowed)
+		default:
+			writeError(w, apperrors.New(apperrors.CodeNotFound, "unknown session route"))
+		}
+	})
+}
+
+func login(w http.ResponseWriter, r *http.Request, store *users.Store, limiter *loginLimiter) {
+	enabled := false
+	if store != nil {
+		var err error
+		if enabled, err = store.Enabled(); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	if !enabled {
+		writeError(w, apperrors.New(apperrors.CodeFailedPrecondition, "this node has no user accounts, so no login is needed"))
+		return
+	}
+
+	var body loginRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "invalid request body"))
+		return
+	}
+	addr := clientAddr(r)
+	keys := []string{"addr:" + addr, "user:" + body.Name}
+	if wait := limiter.retryAfter(keys...); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		writeError(w, apperrors.Newf(apperrors.CodeRateLimited, "too many failed logins; try again in %s", wait.Round(time.Second)))
+		return
+	}
+
+	session, token, err := store.Login(body.Name, body.Password, r.UserAgent(), addr)
+	if err != nil {
+		if apperrors.CodeOf(err) == apperrors.CodeUnauthenticated {
+			limiter.fail(keys...)
+			err = apperrors.New(apperrors.CodeUnauthenticated, "wrong user name or password")
+		}
+		writeError(w, err)
+		return
+	}
+	limiter.reset(keys[1])
+
+	user, err := store.Get(session.User)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  session.CreatedAt.Add(users.SessionMaxAge),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	writeJSON(w, http.StatusOK, newSessionResponse(user, session))
+}
+
+func newSessionResponse(u *users.User, s *users.Session) sessionResponse {
+	info := toUserInfo(u)
+	resp := sessionResponse{AuthRequired: true, User: &info}
+	if s != nil {
+		si := toSessionInfo(s, s)
+		resp.Session = &si
+		resp.CSRFToken = s.CSRFToken
+	}
+	return resp
+}
+
+func clearSessionCookie(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// sessionsHandler serves:
+//
+//	GET  /api/v1/sessions              list your sessions, or everyone's for admins
+//	POST /api/v1/sessions/{id}/revoke  end one of your sessions, or anyone's for admins
+func sessionsHandler(store *users.Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if store == nil {
+			writeError(w, apperrors.New(apperrors.CodeFailedPrecondition, "this node has no config directory to keep user accounts in"))
+			return
+		}
+		// Without accounts the API is open, and there are no sessions to see
+		c := callerFrom(r.Context())
+		admin := c == nil || c.user.Role == users.RoleAdmin
+		var current *users.Session
+		owner := ""
+		if c != nil {
+			current = c.session
+			if !admin {
+				owner = c.user.Name
+			}
+		}
+
+		rest := strings.Trim(strings.TrimPrefix(r.URL.Path, SessionsPath), "/")
+		id, action, _ := strings.Cut(rest, "/")
+		switch {
+		case rest == "" && (r.Method == http.MethodGet || r.Method == http.MethodHead):
+			list, err := store.Sessions(owner)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			infos := make([]sessionInfo, 0, len(list))
+			for i := range list {
+				infos = append(infos, toSessionInfo(&list[i], current))
+			}
+			writeJSON(w, http.StatusOK, map[string]any{"sessions": infos})
+		case action == "revoke" && r.Method == http.MethodPost:
+			list, err := store.Sessions(owner)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			found := false
+			for _, s := range list {
+				found = found || s.ID == id
+			}
+			// Others' sessions are reported as missing, not forbidden, so
+			// their IDs can't be probed
+			if !found {
+				writeError(w, apperrors.ErrSessionNotFound)
+				return
+			}
+			if err := store.RevokeSession(id); err != nil {
+				writeError(w, err)
+				return
+			}
+			if current != nil && current.ID == id {
+				clearSessionCookie(w, r)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case rest == "" || action == "revoke":
+			writeError(w, errMethodNotAllowed)
+		default:
+			writeError(w, apperrors.New(apperrors.CodeNotFound, "unknown session route"))
+		}
+	})
+}
+
+// clientAddr is the address a request came from, without its port
+func clientAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// loginLimiter counts recent failed logins by key
+type loginLimiter struct {
+	mu       sync.Mutex
+	failures map[string][]time.Time
+	now      func() time.Time
+}
+
+func newLoginLimiter() *loginLimiter {
+	return &loginLimiter{failures: make(map[string][]time.Time), now: time.Now}
+}
+
+// retryAfter returns how long until any of keys may try again, or zero if
+// all may
+func (l *loginLimiter) retryAfter(keys ...string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var wait time.Duration
+	for _, key := range keys {
+		recent := l.recent(key)
+		if len(recent) >= loginFailureLimit {
+			if d := recent[0].Add(loginFailureWindow).Sub(l.now()); d > wait {
+				wait = d
+			}
+		}
+	}
+	return wait
+}
+
+// fail records a failed login for each of keys
+func (l *loginLimiter) fail(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		l.failures[key] = append(l.recent(key), l.now())
+	}
+}
+
+// reset forgets key's failures after a successful login
+func (l *loginLimiter) reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.failures, key)
+}
+
+// recent returns key's failures within the window, dropping older ones.
+// l.mu must be held.
+func (l *loginLimiter) recent(key string) []time.Time {
+	cutoff := l.now().Add(-loginFailureWindow)
+	list := l.failures[key]
+	i := 0
+	for i < len(list) && !list[i].After(cutoff) {
+		i++
+	}
+	if i == len(list) {
+		delete(l.failures, key)
+		return nil
+	}
+	l.failures[key] = list[i:]
+	return list[i:]
+}
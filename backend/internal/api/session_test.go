@@ -0,0 +1,183 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/users"
+)
+
+func TestSessionLogin(t *testing.T) {
+	store := users.NewStore(t.TempDir())
+	mux := http.NewServeMux()
+	session := sessionHandler(store)
+	mux.Handle(SessionPath, session)
+	mux.Handle(SessionPath+"/", session)
+	mux.Handle(SessionsPath, sessionsHandler(store))
+	mux.Handle(SessionsPath+"/", sessionsHandler(store))
+	mux.Handle(templatesPath, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	h := withAuth(mux, store)
+
+	do := func(method, path, body string, cookie *http.Cookie, csrf string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		if csrf != "" {
+			req.Header.Set(CSRFHeader, csrf)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Without accounts the UI needs no login
+	rec := do(http.MethodGet, SessionPath, "", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"auth_required":false}`, rec.Body.String())
+	assert.Equal(t, http.StatusPreconditionFailed, do(http.MethodPost, SessionLoginPath, `{"name":"alice","password":"correct horse"}`, nil, "").Code)
+
+	_, err := store.Add("alice", users.RoleAdmin, "correct horse")
+	require.NoError(t, err)
+	_, err = store.Add("bob", users.RoleViewer, "battery staple")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, SessionPath, "", nil, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, SessionLoginPath, `{"name":"alice","password":"wrong horse"}`, nil, "").Code)
+
+	login := func(name, password string) (*http.Cookie, sessionResponse) {
+		rec := do(http.MethodPost, SessionLoginPath, `{"name":"`+name+`","password":"`+password+`"}`, nil, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var resp sessionResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		cookies := rec.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.True(t, cookies[0].HttpOnly)
+		assert.Equal(t, http.SameSiteStrictMode, cookies[0].SameSite)
+		return cookies[0], resp
+	}
+	cookie, resp := login("alice", "correct horse")
+	assert.True(t, resp.AuthRequired)
+	assert.Equal(t, "alice", resp.User.Name)
+	require.NotEmpty(t, resp.CSRFToken)
+
+	// The cookie works for reads; changes also need the CSRF token
+	rec = do(http.MethodGet, SessionPath, "", cookie, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), resp.CSRFToken)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, templatesPath, "", cookie, "").Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, templatesPath, "", cookie, "wrong").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, templatesPath, "", cookie, resp.CSRFToken).Code)
+
+	// Users see their own sessions; admins see and revoke everyone's
+	bobCookie, bobResp := login("bob", "battery staple")
+	rec = do(http.MethodGet, SessionsPath, "", bobCookie, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var list struct {
+		Sessions []sessionInfo `json:"sessions"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Sessions, 1)
+	assert.True(t, list.Sessions[0].Current)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, SessionsPath+"/"+resp.Session.ID+"/revoke", "", bobCookie, bobResp.CSRFToken).Code)
+
+	rec = do(http.MethodGet, SessionsPath, "", cookie, "")
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	assert.Len(t, list.Sessions, 2)
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPost, SessionsPath+"/"+bobResp.Session.ID+"/revoke", "", cookie, resp.CSRFToken).Code)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, SessionPath, "", bobCookie, "").Code)
+
+	// Logging out ends the session and clears the cookie
+	rec = do(http.MethodPost, SessionLogoutPath, "", cookie, resp.CSRFToken)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	require.Len(t, rec.Result().Cookies(), 1)
+	assert.Negative(t, rec.Result().Cookies()[0].MaxAge)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, SessionPath, "", cookie, "").Code)
+}
+
+func TestDashboardLogin(t *testing.T) {
+	cfg := &config.Config{Name: "alice", Role: config.RoleOwner, ConfigDir: t.TempDir()}
+	_, err := users.NewStore(cfg.ConfigDir).Add("alice", users.RoleAdmin, "correct horse")
+	require.NoError(t, err)
+	h := NewServer(cfg, ":0").Handler()
+
+	do := func(method, path, body string, cookie *http.Cookie, csrf string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		if csrf != "" {
+			req.Header.Set(CSRFHeader, csrf)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	getStatus := APIBasePath + "/" + protoPackage + ".HealthService/GetStatus"
+
+	// The dashboard is served to anyone, and offers a login
+	rec := do(http.MethodGet, "/", "", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `id="login-form"`)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, APIBasePath+SessionPath, "", nil, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, getStatus, "{}", nil, "").Code)
+
+	// Once logged in, its RPCs go through with the session's CSRF token,
+	// as the dashboard sends them
+	rec = do(http.MethodPost, APIBasePath+SessionLoginPath, `{"name":"alice","password":"correct horse"}`, nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var session sessionResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &session))
+	require.NotEmpty(t, session.CSRFToken)
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, getStatus, "{}", cookies[0], "").Code)
+	rec = do(http.MethodPost, getStatus, "{}", cookies[0], session.CSRFToken)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"name":"alice"`)
+}
+
+func TestLoginRateLimit(t *testing.T) {
+	store := users.NewStore(t.TempDir())
+	_, err := store.Add("alice", users.RoleAdmin, "correct horse")
+	require.NoError(t, err)
+	h := sessionHandler(store)
+
+	try := func(password string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, SessionLoginPath, strings.NewReader(`{"name":"alice","password":"`+password+`"}`)))
+		return rec
+	}
+	for range loginFailureLimit {
+		assert.Equal(t, http.StatusUnauthorized, try("guess").Code)
+	}
+	rec := try("correct horse")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code, "even the right password waits")
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+}
+
+func TestLoginLimiterWindow(t *testing.T) {
+	now := time.Date(2025, 3, 4, 12, 0, 0, 0, time.UTC)
+	l := newLoginLimiter()
+	l.now = func() time.Time { return now }
+
+	for range loginFailureLimit {
+		l.fail("addr:192.0.2.1")
+	}
+	assert.Equal(t, loginFailureWindow, l.retryAfter("addr:192.0.2.1", "user:alice"))
+	assert.Zero(t, l.retryAfter("addr:192.0.2.2"))
+
+	now = now.Add(loginFailureWindow)
+	assert.Zero(t, l.retryAfter("addr:192.0.2.1"), "failures age out")
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/emergency"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+	"github.com/lcrostarosa/airgapper/backend/internal/verification"
+)
+
+// ShareCheckPath receives share checks from the owner (relative to APIBasePath)
+const ShareCheckPath = "/shares/verify"
+
+// shareCheckHandler serves POST /api/v1/shares/verify: the host checks the
+// owner's proof against the fingerprint recorded when the owner enrolled,
+// and proves in turn that it still holds its own share. Neither share
+// leaves its node.
+func shareCheckHandler(cfg *config.Config) http.Handler {
+	var mu sync.Mutex
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+		if !cfg.UsesSSSMode() {
+			writeError(w, apperrors.ErrNoLocalShare)
+			return
+		}
+
+		var c verification.ShareChallenge
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&c); err != nil {
+			writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "invalid share check body"))
+			return
+		}
+		now := time.Now()
+		if err := c.Validate(now); err != nil {
+			writeError(w, apperrors.Coded(apperrors.CodeInvalidArgument, err))
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		state := cfg.ShareCheck
+		if state == nil {
+			state = &verification.ShareCheckState{}
+		}
+		if state.PeerFingerprint == "" && c.OwnerFingerprint != "" {
+			// First check since joining: trust the owner's fingerprint from now on
+			state.PeerFingerprint = c.OwnerFingerprint
+			logging.Info("Recorded the owner's share fingerprint", tracing.Field(r.Context()))
+		}
+		if state.PeerFingerprint == "" {
+			writeJSON(w, http.StatusOK, c.Answer(cfg.ShareIndex, cfg.LocalShare, false, false))
+			return
+		}
+
+		var err error
+		if c.OwnerFingerprint != "" && c.OwnerFingerprint != state.PeerFingerprint {
+			err = fmt.Errorf("%w: owner enrolled a different share than before", verification.ErrShareMismatch)
+		} else {
+			fingerprint, _ := hex.DecodeString(state.PeerFingerprint)
+			err = c.VerifyOwner(fingerprint)
+		}
+		state.Record(now, err)
+		cfg.ShareCheck = state
+		if saveErr := cfg.Save(); saveErr != nil {
+			logging.Warn("Failed to save share check result", logging.Err(saveErr))
+		}
+
+		if err != nil {
+			logging.Warn("Owner's key share does not match", logging.Err(err), tracing.Field(r.Context()))
+			NotifyShareMismatch(r.Context(), cfg, err)
+		}
+		writeJSON(w, http.StatusOK, c.Answer(cfg.ShareIndex, cfg.LocalShare, true, err == nil))
+	})
+}
+
+// NotifyShareMismatch tells the configured providers that a share check
+// failed, if the share_mismatch event is enabled
+func NotifyShareMismatch(ctx context.Context, cfg *config.Config, err error) {
+	notify := cfg.Emergency.GetNotify()
+	if !notify.IsEnabled() || !notify.Events.ShareMismatch {
+		return
+	}
+
+	failed := notify.Send(ctx, emergency.Message{
+		Event:    "share_mismatch",
+		Title:    "Key shares no longer match on " + cfg.Name,
+		Body:     err.Error() + "\nRestores will fail until the shares are re-issued.",
+		Priority: "high",
+	})
+	for id, err := range failed {
+		logging.Warn("Failed to send notification", logging.String("provider", id), logging.Err(err))
+	}
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/sss"
+	"github.com/lcrostarosa/airgapper/backend/internal/verification"
+)
+
+func TestShareCheckHandler(t *testing.T) {
+	password := []byte("0123456789abcdef0123456789abcdef")
+	shares, err := sss.Split(password, 2, 2)
+	require.NoError(t, err)
+	owner, host := shares[0], shares[1]
+
+	cfg := &config.Config{
+		Name:       "bob",
+		Role:       config.RoleHost,
+		ConfigDir:  t.TempDir(),
+		LocalShare: host.Data,
+		ShareIndex: host.Index,
+	}
+	h := shareCheckHandler(cfg)
+
+	check := func(share sss.Share, enroll bool) (*verification.ShareChallenge, *verification.ShareAnswer) {
+		t.Helper()
+		c, err := verification.NewShareChallenge(share.Index, share.Data, enroll)
+		require.NoError(t, err)
+		body, err := json.Marshal(c)
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, ShareCheckPath, strings.NewReader(string(body))))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var a verification.ShareAnswer
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &a))
+		return c, &a
+	}
+
+	// Before enrollment the host proves itself but can't check the owner
+	_, a := check(owner, false)
+	assert.False(t, a.OwnerEnrolled)
+	assert.False(t, a.OwnerVerified)
+
+	// The owner derives the host's share from the password to check it
+	c, a := check(owner, true)
+	hostShare, err := sss.Derive(password, owner, a.HostIndex)
+	require.NoError(t, err)
+	require.NoError(t, c.VerifyHost(a, verification.ShareFingerprint(a.HostIndex, hostShare)))
+	require.NotNil(t, cfg.ShareCheck)
+	assert.NotEmpty(t, cfg.ShareCheck.PeerFingerprint)
+	assert.False(t, cfg.ShareCheck.LastVerified.IsZero())
+
+	// Later checks don't need the fingerprint again
+	_, a = check(owner, false)
+	assert.True(t, a.OwnerVerified)
+
+	// An owner with a different share is caught, even if it tries to re-enroll
+	impostor := sss.Share{Index: owner.Index, Data: []byte("ffffffffffffffffffffffffffffffff")}
+	_, a = check(impostor, false)
+	assert.False(t, a.OwnerVerified)
+	assert.True(t, cfg.ShareCheck.Mismatched())
+	_, a = check(impostor, true)
+	assert.False(t, a.OwnerVerified)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, ShareCheckPath, strings.NewReader(`{"owner_index":1,"nonce":"00"}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	shareCheckHandler(&config.Config{}).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, ShareCheckPath, strings.NewReader(`{}`)))
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+// snapshotsPath is the prefix of snapshot endpoints (relative to APIBasePath)
+const snapshotsPath = "/snapshots/"
+
+// snapshotIDPattern matches full or abbreviated (8+ hex chars) snapshot IDs
+var snapshotIDPattern = regexp.MustCompile(`^[0-9a-f]{8,64}$`)
+
+// snapshotDiffer compares two snapshots
+type snapshotDiffer func(ctx context.Context, fromID, toID string) (*restic.Diff, error)
+
+// errNoRepoPassword is returned when this node can't open the repository
+var errNoRepoPassword = apperrors.New(apperrors.CodeNoRepoPassword, "repository password not available on this node")
+
+// resticDiffer diffs snapshots in the owner's repository
+func resticDiffer(cfg *config.Config, open restic.RunnerFactory) snapshotDiffer {
+	return func(ctx context.Context, fromID, toID string) (*restic.Diff, error) {
+		if !cfg.IsOwner() || cfg.Password == "" {
+			return nil, errNoRepoPassword
+		}
+		return open(cfg.RepoURL, cfg.Password).Diff(ctx, fromID, toID)
+	}
+}
+
+// snapshotDiffResponse is the body of GET /api/v1/snapshots/{a}/diff/{b}
+type snapshotDiffResponse struct {
+	*restic.Diff
+	SizeDelta int64 `json:"size_delta"`
+}
+
+// snapshotDiffHandler serves GET /api/v1/snapshots/{a}/diff/{b}: the files
+// added, removed and modified from snapshot a to snapshot b
+func snapshotDiffHandler(diff snapshotDiffer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, snapshotsPath), "/"), "/")
+		if len(parts) != 3 || parts[1] != "diff" {
+			writeError(w, apperrors.New(apperrors.CodeNotFound, "unknown snapshot endpoint"))
+			return
+		}
+		fromID, toID := strings.ToLower(parts[0]), strings.ToLower(parts[2])
+		if !snapshotIDPattern.MatchString(fromID) || !snapshotIDPattern.MatchString(toID) {
+			writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "snapshot IDs must be 8-64 hex characters"))
+			return
+		}
+
+		result, err := diff(r.Context(), fromID, toID)
+		if err != nil {
+			writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+			return
+		}
+		writeJSON(w, http.StatusOK, snapshotDiffResponse{Diff: result, SizeDelta: result.SizeDelta()})
+	})
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/testutil"
+)
+
+func TestSnapshotDiffHandler(t *testing.T) {
+	var gotFrom, gotTo string
+	h := snapshotDiffHandler(func(_ context.Context, fromID, toID string) (*restic.Diff, error) {
+		gotFrom, gotTo = fromID, toID
+		if fromID == "deadbeefdeadbeef" {
+			return nil, errors.New("no matching ID found")
+		}
+		return &restic.Diff{
+			Added:        []string{"/docs/new.txt"},
+			Removed:      []string{},
+			Modified:     []string{"/docs/report.pdf"},
+			AddedStats:   restic.DiffStats{Bytes: 4096},
+			RemovedStats: restic.DiffStats{Bytes: 1024},
+		}, nil
+	})
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{"diff", "/snapshots/aaaa1111/diff/BBBB2222", http.StatusOK},
+		{"short id", "/snapshots/aaaa/diff/bbbb2222", http.StatusBadRequest},
+		{"not hex", "/snapshots/--option/diff/bbbb2222", http.StatusBadRequest},
+		{"unknown endpoint", "/snapshots/aaaa1111/files", http.StatusNotFound},
+		{"restic error", "/snapshots/deadbeefdeadbeef/diff/bbbb2222", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+		})
+	}
+
+	t.Run("diff body", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/snapshots/aaaa1111/diff/bbbb2222", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "aaaa1111", gotFrom)
+		assert.Equal(t, "bbbb2222", gotTo)
+
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, []any{"/docs/new.txt"}, body["added"])
+		assert.Equal(t, []any{"/docs/report.pdf"}, body["modified"])
+		assert.Equal(t, float64(3072), body["size_delta"])
+	})
+
+	t.Run("host has no password", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		snapshotDiffHandler(resticDiffer(&config.Config{Role: config.RoleHost}, restic.NewRunner)).
+			ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/snapshots/aaaa1111/diff/bbbb2222", nil))
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	})
+}
+
+func TestSnapshotDiffWithInjectedRestic(t *testing.T) {
+	ctx := context.Background()
+	fake := testutil.NewFakeRestic()
+	require.NoError(t, fake.Init(ctx))
+	for range 2 {
+		_, err := fake.Backup(ctx, []string{"/docs"}, nil)
+		require.NoError(t, err)
+	}
+	fake.DiffResult = &restic.Diff{Added: []string{"/docs/new.txt"}}
+
+	cfg := &config.Config{Role: config.RoleOwner, RepoURL: "rest:http://host/alice", Password: "secret", ConfigDir: t.TempDir()}
+	srv := NewServerWithOptions(cfg, ":0", &ServerOptions{Restic: fake.Factory()})
+
+	from, to := fake.Snapshots[0].ShortID, fake.Snapshots[1].ShortID
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, APIBasePath+"/snapshots/"+from+"/diff/"+to, nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "/docs/new.txt")
+	assert.Contains(t, fake.Calls, "diff "+from+" "+to)
+}
@@ -1,12 +1,20 @@
 package api
 
 import (
+	"time"
+
 	"github.com/lcrostarosa/airgapper/backend/internal/config"
 	"github.com/lcrostarosa/airgapper/backend/internal/integrity"
 	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/restorejob"
 	"github.com/lcrostarosa/airgapper/backend/internal/storage"
 )
 
+// storageIdleWindow is how long after the last storage request a backup or
+// restore is assumed to still be running
+const storageIdleWindow = 5 * time.Minute
+
 // ServerOptions contains optional components that can be injected into the server
 type ServerOptions struct {
 	// StorageServer is an optional pre-initialized storage server
@@ -17,6 +25,19 @@ type ServerOptions struct {
 
 	// ScheduledChecker is an optional pre-initialized scheduled integrity checker
 	ScheduledChecker *integrity.ManagedScheduledChecker
+
+	// Version is the build version reported in the OpenAPI document
+	Version string
+
+	// Restic opens the owner's repository (default: the restic binary)
+	Restic restic.RunnerFactory
+
+	// RestoreJobs runs the owner's restores (nil if this node isn't one)
+	RestoreJobs *restorejob.Manager
+
+	// AdminSocket, if set, is where admin routes are served (see
+	// AdminHandler); the network listener then serves only peer-facing ones
+	AdminSocket string
 }
 
 // InitStorageComponents initializes storage-related components from config.
@@ -30,11 +51,26 @@ func InitStorageComponents(cfg *config.Config) (*ServerOptions, error) {
 	}
 
 	// Initialize storage server
-	storageServer, err := storage.NewServer(storage.Config{
-		BasePath:   cfg.StoragePath,
-		AppendOnly: cfg.StorageAppendOnly,
-		QuotaBytes: cfg.StorageQuotaBytes,
-	})
+	storageCfg := storage.Config{
+		BasePath:        cfg.StoragePath,
+		AppendOnly:      cfg.StorageAppendOnly,
+		QuotaBytes:      cfg.StorageQuotaBytes,
+		Compress:        cfg.StorageCompression,
+		VerifyReads:     cfg.StorageVerifyReads,
+		Immutable:       cfg.StorageImmutable,
+		RequireIdentity: cfg.StorageRequireIdentity,
+		FSSnapshots:     cfg.StorageFSSnapshots,
+
+		RequireProvisioning: cfg.StorageRequireProvisioning,
+	}
+	if cfg.IsHost() && !cfg.StorageRequireProvisioning {
+		logging.Warn("Storage serves any repository name; set storage_require_provisioning to serve only provisioned ones (see GETTING-STARTED.md)")
+	}
+	// A host's peer is the owner whose writes it accepts
+	if cfg.IsHost() && cfg.Peer != nil {
+		storageCfg.OwnerPublicKey = cfg.Peer.PublicKey
+	}
+	storageServer, err := storage.NewServer(storageCfg)
 	if err != nil {
 		logging.Warnf("failed to initialize storage server: %v", err)
 		return opts, nil
@@ -47,7 +83,17 @@ func InitStorageComponents(cfg *config.Config) (*ServerOptions, error) {
 		logging.Warnf("failed to initialize integrity checker: %v", err)
 	} else {
 		opts.IntegrityChecker = integrityChecker
+		enableResticCheck(cfg, integrityChecker)
 		logging.Info("Integrity checker initialized")
+
+		// A blob that fails its hash check when served waits for the next
+		// scheduled check on the bad-file list
+		integrityChecker.SetBadFileHandler(peerRepairNotifier(cfg))
+		storageServer.SetCorruptReadHandler(func(repo, file string) {
+			if err := integrityChecker.ReportBadFile(repo, file, integrity.BadFileSourceRead, ""); err != nil {
+				logging.Warn("Failed to add a corrupt file to the bad-file list", logging.Err(err))
+			}
+		})
 	}
 
 	// Initialize managed scheduled checker for scheduled verification
@@ -56,16 +102,37 @@ func InitStorageComponents(cfg *config.Config) (*ServerOptions, error) {
 		logging.Warnf("failed to initialize scheduled checker: %v", err)
 	} else {
 		opts.ScheduledChecker = managedChecker
+		enableResticCheck(cfg, managedChecker.GetChecker())
+		managedChecker.GetChecker().SetBadFileHandler(peerRepairNotifier(cfg))
+
+		// Don't scrub while an owner is uploading or restoring
+		managedChecker.AddActivitySource(func() (bool, string) {
+			return storageServer.ActiveWithin(storageIdleWindow), "storage transfers in progress"
+		})
+		// nor while the owner prunes, which re-baselines when it's done
+		managedChecker.AddActivitySource(func() (bool, string) {
+			m := storageServer.Maintenance()
+			return m != nil && m.Prune != "", "prune in progress"
+		})
 	}
 
 	return opts, nil
 }
 
+// enableResticCheck allows "restic" integrity checks when this node also
+// holds the repository password (an owner storing its own backups)
+func enableResticCheck(cfg *config.Config, checker *integrity.Checker) {
+	if cfg.Password != "" {
+		checker.SetRepoChecker(integrity.NewResticRepoChecker(cfg.Password, ""))
+	}
+}
+
 // StartStorageComponents starts storage-related components.
 // Call this after InitStorageComponents to begin serving storage requests.
 func StartStorageComponents(opts *ServerOptions) {
 	if opts.StorageServer != nil {
 		opts.StorageServer.Start()
+		opts.StorageServer.StartBackgroundChecks(storage.DefaultCheckInterval)
 		logging.Info("Storage server started")
 	}
 
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+)
+
+// storageReposPath is the host's provisioned repositories (relative to APIBasePath)
+const storageReposPath = "/storage/repos"
+
+// provisionRepoRequest names a repository to provision and the key allowed
+// to write to it
+type provisionRepoRequest struct {
+	Name           string `json:"name"`
+	OwnerPublicKey string `json:"owner_public_key,omitempty"` // hex-encoded Ed25519 (default: the peer's key)
+}
+
+// storageReposHandler serves:
+//
+//	GET  /api/v1/storage/repos    the repositories the host provisioned
+//	POST /api/v1/storage/repos    provision a repository for the owner to create
+func storageReposHandler(cfg *config.Config, srv *storage.Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if srv == nil {
+			writeError(w, apperrors.New(apperrors.CodeStorageNotConfigured, "storage server not configured"))
+			return
+		}
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			writeJSON(w, http.StatusOK, map[string]any{"repos": srv.ProvisionedRepos()})
+		case http.MethodPost:
+			provisionRepo(w, r, cfg, srv)
+		default:
+			writeError(w, errMethodNotAllowed)
+		}
+	})
+}
+
+func provisionRepo(w http.ResponseWriter, r *http.Request, cfg *config.Config, srv *storage.Server) {
+	var req provisionRepoRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil || req.Name == "" {
+		writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "name is required"))
+		return
+	}
+	var ownerKey []byte
+	switch {
+	case req.OwnerPublicKey != "":
+		key, err := hex.DecodeString(req.OwnerPublicKey)
+		if err != nil {
+			writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "owner_public_key must be hex-encoded"))
+			return
+		}
+		ownerKey = key
+	case cfg.IsHost() && cfg.Peer != nil:
+		// A host's peer is the owner
+		ownerKey = cfg.Peer.PublicKey
+	}
+
+	info, err := srv.ProvisionRepo(req.Name, ownerKey)
+	switch {
+	case errors.Is(err, storage.ErrRepoProvisioned):
+		writeError(w, apperrors.Coded(apperrors.CodeAlreadyExists, err))
+		return
+	case err != nil:
+		writeError(w, apperrors.Coded(apperrors.CodeInvalidArgument, err))
+		return
+	}
+	logging.Info("Repository provisioned",
+		logging.String("repo", info.Name),
+		logging.String("ownerKeyID", info.OwnerKeyID),
+		tracing.Field(r.Context()))
+	writeJSON(w, http.StatusCreated, info)
+}
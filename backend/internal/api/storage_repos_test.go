@@ -0,0 +1,59 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+)
+
+func TestStorageReposHandler(t *testing.T) {
+	ownerPub, _, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+	cfg := &config.Config{Role: config.RoleHost, Peer: &config.PeerInfo{Name: "alice", PublicKey: ownerPub}}
+	srv, err := storage.NewServer(storage.Config{BasePath: t.TempDir(), RequireProvisioning: true})
+	require.NoError(t, err)
+	h := storageReposHandler(cfg, srv)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, storageReposPath, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := post(`{"name":"alice"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var info storage.RepoInfo
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &info))
+	assert.Equal(t, crypto.KeyID(ownerPub), info.OwnerKeyID, "the peer's key by default")
+
+	otherPub, _, _ := crypto.GenerateKeyPair()
+	rec = post(`{"name":"carol","owner_public_key":"` + hex.EncodeToString(otherPub) + `"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	assert.Equal(t, http.StatusConflict, post(`{"name":"alice"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"name":"../x"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"name":"x","owner_public_key":"zz"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{}`).Code)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, storageReposPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var list struct{ Repos []storage.RepoInfo }
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Repos, 2)
+	assert.Equal(t, crypto.KeyID(otherPub), list.Repos[1].OwnerKeyID)
+
+	rec = httptest.NewRecorder()
+	storageReposHandler(cfg, nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, storageReposPath, nil))
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+// templatesPath is the prefix of request template endpoints (relative to APIBasePath)
+const templatesPath = "/templates"
+
+// templateRequestBody is the body of POST /api/v1/templates/{name}/requests
+type templateRequestBody struct {
+	Reason string `json:"reason,omitempty"` // Appended to the template's reason
+}
+
+// templatesHandler serves:
+//
+//	GET    /api/v1/templates                  list request templates
+//	POST   /api/v1/templates                  create a template
+//	GET    /api/v1/templates/{name}           get a template
+//	DELETE /api/v1/templates/{name}           delete a template
+//	POST   /api/v1/templates/{name}/requests  create a request from a template
+func templatesHandler(cfg *config.Config, mgr *consent.Manager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest := strings.Trim(strings.TrimPrefix(r.URL.Path, templatesPath), "/")
+		if rest == "" {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead:
+				templates, err := mgr.ListTemplates()
+				if err != nil {
+					writeError(w, apperrors.Coded(apperrors.CodeInternal, err))
+					return
+				}
+				writeJSON(w, http.StatusOK, map[string]any{"templates": templates})
+			case http.MethodPost:
+				createTemplate(w, r, mgr)
+			default:
+				writeError(w, errMethodNotAllowed)
+			}
+			return
+		}
+
+		name, action, _ := strings.Cut(rest, "/")
+		switch {
+		case action == "" && (r.Method == http.MethodGet || r.Method == http.MethodHead):
+			t, err := mgr.GetTemplate(name)
+			if err != nil {
+				writeTemplateError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, t)
+		case action == "" && r.Method == http.MethodDelete:
+			if err := mgr.DeleteTemplate(name); err != nil {
+				writeTemplateError(w, err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case action == "requests" && r.Method == http.MethodPost:
+			if !cfg.IsOwner() {
+				writeError(w, apperrors.New(apperrors.CodePermissionDenied, "only the owner can request restores"))
+				return
+			}
+			var body templateRequestBody
+			if r.ContentLength != 0 {
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "invalid request body"))
+					return
+				}
+			}
+			req, err := mgr.CreateRequestFromTemplate(cfg.Name, name, body.Reason)
+			if err != nil {
+				writeTemplateError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusCreated, req)
+		case action == "" || action == "requests":
+			writeError(w, errMethodNotAllowed)
+		default:
+			writeError(w, apperrors.New(apperrors.CodeNotFound, "unknown template route"))
+		}
+	})
+}
+
+func createTemplate(w http.ResponseWriter, r *http.Request, mgr *consent.Manager) {
+	var t consent.Template
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "invalid request body"))
+		return
+	}
+	if err := mgr.CreateTemplate(&t); err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, t)
+}
+
+func writeTemplateError(w http.ResponseWriter, err error) {
+	writeError(w, apperrors.Coded(apperrors.CodeInvalidArgument, err))
+}
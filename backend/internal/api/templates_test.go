@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+)
+
+func TestTemplatesHandler(t *testing.T) {
+	dir := t.TempDir()
+	mgr := consent.NewManager(dir)
+	cfg := &config.Config{Name: "alice", Role: config.RoleOwner, ConfigDir: dir}
+
+	mux := http.NewServeMux()
+	h := templatesHandler(cfg, mgr)
+	mux.Handle(templatesPath, h)
+	mux.Handle(templatesPath+"/", h)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/templates", `{"name":"monthly-verify","paths":["/Documents"],"reason":"Monthly verify restore"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/templates", `{"name":"monthly-verify","reason":"x"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/templates", `{"name":"no-reason"}`).Code)
+
+	rec = do(http.MethodGet, "/templates", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var list struct {
+		Templates []consent.Template `json:"templates"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Templates, 1)
+	assert.Equal(t, "latest", list.Templates[0].SnapshotID)
+
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/templates/monthly-verify", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/templates/missing", "").Code)
+
+	rec = do(http.MethodPost, "/templates/monthly-verify/requests", `{"reason":"March"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var req consent.RestoreRequest
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &req))
+	assert.Equal(t, "alice", req.Requester)
+	assert.Equal(t, "Monthly verify restore - March", req.Reason)
+	assert.Equal(t, "monthly-verify", req.Template)
+	assert.Equal(t, []string{"/Documents"}, req.Paths)
+
+	hostCfg := &config.Config{Name: "bob", Role: config.RoleHost}
+	rec = httptest.NewRecorder()
+	templatesHandler(hostCfg, mgr).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/templates/monthly-verify/requests", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/templates/monthly-verify", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/templates/monthly-verify", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/templates/x/other", "").Code)
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/users"
+)
+
+// UsersPath is the prefix of user account endpoints (relative to APIBasePath)
+const UsersPath = "/users"
+
+// userInfo describes an account without its password and token hashes
+type userInfo struct {
+	Name        string     `json:"name"`
+	Role        users.Role `json:"role"`
+	HasPassword bool       `json:"has_password"`
+	HasToken    bool       `json:"has_token"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// createUserRequest is the body of POST /api/v1/users
+type createUserRequest struct {
+	Name     string     `json:"name"`
+	Role     users.Role `json:"role"`
+	Password string     `json:"password,omitempty"` // Omit for a token-only account
+}
+
+// updateUserRequest is the body of POST /api/v1/users/{name}/role and
+// /password
+type updateUserRequest struct {
+	Role     users.Role `json:"role,omitempty"`
+	Password string     `json:"password,omitempty"`
+}
+
+// userTokenResponse carries a new API token, which is only ever shown once
+type userTokenResponse struct {
+	User  userInfo `json:"user"`
+	Token string   `json:"token"`
+}
+
+func toUserInfo(u *users.User) userInfo {
+	return userInfo{
+		Name:        u.Name,
+		Role:        u.Role,
+		HasPassword: u.HasPassword(),
+		HasToken:    u.HasToken(),
+		CreatedAt:   u.CreatedAt,
+		UpdatedAt:   u.UpdatedAt,
+	}
+}
+
+// usersHandler serves:
+//
+//	GET    /api/v1/users                  list accounts
+//	POST   /api/v1/users                  create an account, returning its token
+//	GET    /api/v1/users/{name}           get an account
+//	DELETE /api/v1/users/{name}           delete an account
+//	POST   /api/v1/users/{name}/role      change an account's role
+//	POST   /api/v1/users/{name}/password  change an account's password
+//	POST   /api/v1/users/{name}/token     replace an account's token
+func usersHandler(store *users.Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if store == nil {
+			writeError(w, apperrors.New(apperrors.CodeFailedPrecondition, "this node has no config directory to keep user accounts in"))
+			return
+		}
+
+		rest := strings.Trim(strings.TrimPrefix(r.URL.Path, UsersPath), "/")
+		if rest == "" {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead:
+				list, err := store.List()
+				if err != nil {
+					writeError(w, err)
+					return
+				}
+				infos := make([]userInfo, 0, len(list))
+				for i := range list {
+					infos = append(infos, toUserInfo(&list[i]))
+				}
+				writeJSON(w, http.StatusOK, map[string]any{"users": infos})
+			case http.MethodPost:
+				createUser(w, r, store)
+			default:
+				writeError(w, errMethodNotAllowed)
+			}
+			return
+		}
+
+		name, action, _ := strings.Cut(rest, "/")
+		switch {
+		case action == "" && (r.Method == http.MethodGet || r.Method == http.MethodHead):
+			writeUser(w, store, name)
+		case action == "" && r.Method == http.MethodDelete:
+			if err := store.Remove(name); err != nil {
+				writeError(w, err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case (action == "role" || action == "password") && r.Method == http.MethodPost:
+			var body updateUserRequest
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "invalid request body"))
+				return
+			}
+			var err error
+			if action == "role" {
+				err = store.SetRole(name, body.Role)
+			} else {
+				err = store.SetPassword(name, body.Password)
+			}
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			writeUser(w, store, name)
+		case action == "token" && r.Method == http.MethodPost:
+			token, err := store.IssueToken(name)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			u, err := store.Get(name)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, userTokenResponse{User: toUserInfo(u), Token: token})
+		case action == "" || action == "role" || action == "password" || action == "token":
+			writeError(w, errMethodNotAllowed)
+		default:
+			writeError(w, apperrors.New(apperrors.CodeNotFound, "unknown user route"))
+		}
+	})
+}
+
+func createUser(w http.ResponseWriter, r *http.Request, store *users.Store) {
+	var body createUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, apperrors.New(apperrors.CodeInvalidArgument, "invalid request body"))
+		return
+	}
+	token, err := store.Add(body.Name, body.Role, body.Password)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	u, err := store.Get(body.Name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, userTokenResponse{User: toUserInfo(u), Token: token})
+}
+
+func writeUser(w http.ResponseWriter, store *users.Store, name string) {
+	u, err := store.Get(name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toUserInfo(u))
+}
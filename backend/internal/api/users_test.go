@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/users"
+)
+
+func TestUsersHandler(t *testing.T) {
+	store := users.NewStore(t.TempDir())
+	mux := http.NewServeMux()
+	h := usersHandler(store)
+	mux.Handle(UsersPath, h)
+	mux.Handle(UsersPath+"/", h)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/users", `{"name":"kid","role":"viewer"}`).Code, "the first user must be an admin")
+
+	rec := do(http.MethodPost, "/users", `{"name":"alice","role":"admin","password":"correct horse"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created userTokenResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.True(t, strings.HasPrefix(created.Token, "agt_"))
+	assert.True(t, created.User.HasPassword)
+	assert.NotContains(t, rec.Body.String(), "hash", "hashes are never returned")
+
+	assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/users", `{"name":"alice","role":"viewer"}`).Code)
+	require.Equal(t, http.StatusCreated, do(http.MethodPost, "/users", `{"name":"bob","role":"viewer"}`).Code)
+
+	rec = do(http.MethodGet, "/users", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var list struct {
+		Users []userInfo `json:"users"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Users, 2)
+	assert.False(t, list.Users[1].HasPassword)
+
+	rec = do(http.MethodPost, "/users/bob/role", `{"role":"approver"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"role":"approver"`)
+	assert.Equal(t, http.StatusPreconditionFailed, do(http.MethodPost, "/users/alice/role", `{"role":"viewer"}`).Code, "last admin")
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/users/bob/password", `{"password":"short"}`).Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/users/bob/password", `{"password":"long enough"}`).Code)
+
+	rec = do(http.MethodPost, "/users/bob/token", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var rotated userTokenResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &rotated))
+	u, err := store.AuthenticateToken(rotated.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", u.Name)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/users/bob", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/users/bob", "").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, do(http.MethodPut, "/users/alice", "").Code)
+}
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"net/http"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+)
+
+// hostVaultPath is the host's metadata view of the stored vault (relative to APIBasePath)
+const hostVaultPath = "/host/vault"
+
+// hostVaultHandler serves GET /api/v1/host/vault: repository size over
+// time, snapshot count, the last snapshot write and days since the owner
+// last connected. Nothing here needs the repository key.
+func hostVaultHandler(srv *storage.Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+		if srv == nil {
+			writeError(w, apperrors.New(apperrors.CodeStorageNotConfigured, "storage server not configured"))
+			return
+		}
+		writeJSON(w, http.StatusOK, srv.VaultMetadata())
+	})
+}
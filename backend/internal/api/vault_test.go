@@ -0,0 +1,42 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+)
+
+func TestHostVaultHandler(t *testing.T) {
+	t.Run("no storage server", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		hostVaultHandler(nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, hostVaultPath, nil))
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	})
+
+	srv, err := storage.NewServer(storage.Config{BasePath: t.TempDir()})
+	require.NoError(t, err)
+	h := hostVaultHandler(srv)
+
+	t.Run("metadata", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, hostVaultPath, nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var body storage.VaultMetadata
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, -1, body.DaysSinceContact)
+		assert.Len(t, body.Growth, 1)
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, hostVaultPath, nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
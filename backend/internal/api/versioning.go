@@ -0,0 +1,198 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+)
+
+// API versioning.
+//
+// All API routes are served under APIBasePath (e.g. /api/v1/airgapper.v1.HealthService/GetStatus).
+// The original unversioned paths are kept as aliases that behave identically but carry
+// Deprecation/Sunset headers pointing at their versioned successor, so older frontends and
+// peers keep working while they migrate.
+const (
+	// APIVersion is the current API version
+	APIVersion = "v1"
+
+	// APIBasePath is the prefix for all versioned API routes
+	APIBasePath = "/api/" + APIVersion
+
+	// APIVersionHeader is sent on every API response and may be sent by clients
+	// to require a specific version
+	APIVersionHeader = "X-Airgapper-API-Version"
+
+	// VersionPath reports the API version and capabilities (relative to APIBasePath)
+	VersionPath = "/version"
+)
+
+// SupportedAPIVersions lists the API versions this server can serve
+var SupportedAPIVersions = []string{APIVersion}
+
+// Legacy (unversioned) route lifecycle
+var (
+	// legacyDeprecatedAt is when the unversioned routes were deprecated
+	legacyDeprecatedAt = time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)
+
+	// legacySunsetAt is when the unversioned routes will be removed
+	legacySunsetAt = time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+)
+
+// VersionInfo is returned by GET /api/v1/version so peers and clients can
+// detect capability mismatches before calling other endpoints
+type VersionInfo struct {
+	APIVersion        string             `json:"apiVersion"`
+	SupportedVersions []string           `json:"supportedVersions"`
+	ServerVersion     string             `json:"serverVersion"`
+	ProtoPackage      string             `json:"protoPackage"`
+	Deprecations      []DeprecationEntry `json:"deprecations,omitempty"`
+}
+
+// DeprecationEntry describes a deprecated route family and its replacement
+type DeprecationEntry struct {
+	Path      string    `json:"path"`
+	Successor string    `json:"successor"`
+	Sunset    time.Time `json:"sunset"`
+}
+
+// currentVersionInfo builds the version document for this server
+func currentVersionInfo(serverVersion string) VersionInfo {
+	if serverVersion == "" {
+		serverVersion = "dev"
+	}
+	return VersionInfo{
+		APIVersion:        APIVersion,
+		SupportedVersions: SupportedAPIVersions,
+		ServerVersion:     serverVersion,
+		ProtoPackage:      protoPackage,
+		Deprecations: []DeprecationEntry{
+			{Path: "/" + protoPackage + ".*", Successor: APIBasePath + "/" + protoPackage + ".*", Sunset: legacySunsetAt},
+			{Path: "/api" + openAPIPath, Successor: APIBasePath + openAPIPath, Sunset: legacySunsetAt},
+			{Path: "/api" + apiDocsPath, Successor: APIBasePath + apiDocsPath, Sunset: legacySunsetAt},
+		},
+	}
+}
+
+// versionHandler serves the API version document
+func versionHandler(serverVersion string) http.Handler {
+	info := currentVersionInfo(serverVersion)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			writeError(w, errMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, info)
+	})
+}
+
+// withAPIVersion advertises the served API version on every response and
+// rejects requests that explicitly ask for a version this server doesn't support
+func withAPIVersion(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(APIVersionHeader, APIVersion)
+
+		if requested := r.Header.Get(APIVersionHeader); requested != "" && !slices.Contains(SupportedAPIVersions, requested) {
+			writeError(w, apperrors.New(apperrors.CodeUnsupportedAPIVersion, "unsupported API version: "+requested).
+				WithDetail("supportedVersions", strings.Join(SupportedAPIVersions, ",")))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// deprecated wraps a legacy route, adding Deprecation (RFC 9745), Sunset (RFC 8594)
+// and a successor-version Link header. successor maps the legacy request path
+// to its versioned replacement.
+func deprecated(next http.Handler, successor func(path string) string) http.Handler {
+	deprecation := "@" + strconv.FormatInt(legacyDeprecatedAt.Unix(), 10)
+	sunset := legacySunsetAt.Format(http.TimeFormat)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", deprecation)
+		w.Header().Set("Sunset", sunset)
+		w.Header().Add("Link", "<"+successor(r.URL.Path)+`>; rel="successor-version"`)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isLegacyRPCPath reports whether path is an unversioned Connect-RPC route
+func isLegacyRPCPath(path string) bool {
+	return strings.HasPrefix(path, "/"+protoPackage+".")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// errMethodNotAllowed is returned for requests using the wrong HTTP method
+var errMethodNotAllowed = apperrors.New(apperrors.CodeMethodNotAllowed, "method not allowed")
+
+// errorBody is the JSON body of every API error. Code is a stable
+// apperrors code (e.g. AG-1004); details always include its name as
+// "reason", plus the request's correlation ID so failures can be found in
+// the logs.
+type errorBody struct {
+	Code      apperrors.Code    `json:"code"`
+	Message   string            `json:"message"`
+	Details   map[string]string `json:"details"`
+	RequestID string            `json:"requestId,omitempty"`
+}
+
+// writeError writes err as an errorBody with the HTTP status for its code.
+// Errors without a code are reported as internal.
+func writeError(w http.ResponseWriter, err error) {
+	e := apperrors.Coded(apperrors.CodeInternal, err)
+	details := map[string]string{"reason": e.Code.Name()}
+	for k, v := range e.Details {
+		details[k] = v
+	}
+	writeJSON(w, httpStatus(e.Code), errorBody{
+		Code:      e.Code,
+		Message:   e.Message,
+		Details:   details,
+		RequestID: w.Header().Get(tracing.Header),
+	})
+}
+
+// httpStatus maps an error code to its HTTP status
+func httpStatus(code apperrors.Code) int {
+	switch code {
+	case apperrors.CodeMethodNotAllowed:
+		return http.StatusMethodNotAllowed
+	case apperrors.CodeUnsupportedAPIVersion:
+		return http.StatusNotAcceptable
+	}
+	switch code.Kind() {
+	case apperrors.KindInvalidArgument:
+		return http.StatusBadRequest
+	case apperrors.KindNotFound:
+		return http.StatusNotFound
+	case apperrors.KindAlreadyExists:
+		return http.StatusConflict
+	case apperrors.KindFailedPrecondition:
+		return http.StatusPreconditionFailed
+	case apperrors.KindUnauthenticated:
+		return http.StatusUnauthorized
+	case apperrors.KindResourceExhausted:
+		return http.StatusTooManyRequests
+	case apperrors.KindPermissionDenied:
+		return http.StatusForbidden
+	case apperrors.KindUnavailable:
+		return http.StatusServiceUnavailable
+	case apperrors.KindUnimplemented:
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+)
+
+func newTestServer(t *testing.T) http.Handler {
+	t.Helper()
+	cfg := &config.Config{ConfigDir: t.TempDir(), Name: "alice", Role: config.RoleOwner}
+	return NewServerWithOptions(cfg, ":0", &ServerOptions{Version: "v9.9.9"}).Handler()
+}
+
+func rpcRequest(path string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestVersionedRoutes(t *testing.T) {
+	h := newTestServer(t)
+
+	t.Run("versioned RPC path", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, rpcRequest(APIBasePath+"/airgapper.v1.HealthService/Check"))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, APIVersion, rec.Header().Get(APIVersionHeader))
+		assert.Empty(t, rec.Header().Get("Deprecation"))
+		assert.Contains(t, rec.Body.String(), `"status":"ok"`)
+	})
+
+	t.Run("legacy RPC path is deprecated alias", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, rpcRequest("/airgapper.v1.HealthService/Check"))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.True(t, strings.HasPrefix(rec.Header().Get("Deprecation"), "@"))
+		assert.NotEmpty(t, rec.Header().Get("Sunset"))
+		assert.Contains(t, rec.Header().Get("Link"), "<"+APIBasePath+"/airgapper.v1.HealthService/Check>")
+		assert.Contains(t, rec.Body.String(), `"status":"ok"`)
+	})
+
+	t.Run("legacy openapi path", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get("Link"), "<"+APIBasePath+"/openapi.json>")
+	})
+
+	t.Run("version endpoint", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, APIBasePath+"/version", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var info VersionInfo
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &info))
+		assert.Equal(t, APIVersion, info.APIVersion)
+		assert.Equal(t, "v9.9.9", info.ServerVersion)
+		assert.Contains(t, info.SupportedVersions, APIVersion)
+		assert.NotEmpty(t, info.Deprecations)
+	})
+
+	t.Run("unsupported requested version", func(t *testing.T) {
+		req := rpcRequest(APIBasePath + "/airgapper.v1.HealthService/Check")
+		req.Header.Set(APIVersionHeader, "v0")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+
+		var body errorBody
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, apperrors.CodeUnsupportedAPIVersion, body.Code)
+		assert.Equal(t, "UNSUPPORTED_API_VERSION", body.Details["reason"])
+		assert.Equal(t, APIVersion, body.Details["supportedVersions"])
+	})
+
+	t.Run("correlation ID returned with errors", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, APIBasePath+ProofChallengePath, nil)
+		req.Header.Set(tracing.Header, "owner-op-1")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, "owner-op-1", rec.Header().Get(tracing.Header))
+
+		req = httptest.NewRequest(http.MethodPost, APIBasePath+ProofChallengePath, strings.NewReader("{}"))
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+		var body errorBody
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, apperrors.CodeStorageNotConfigured, body.Code)
+		assert.Equal(t, "STORAGE_NOT_CONFIGURED", body.Details["reason"])
+		assert.NotEmpty(t, body.RequestID)
+		assert.Equal(t, rec.Header().Get(tracing.Header), body.RequestID)
+	})
+
+	t.Run("web UI still served at root", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+	})
+}
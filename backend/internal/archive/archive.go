@@ -0,0 +1,325 @@
+// Package archive writes and reads cold-storage archives: a snapshot's tar
+// stream encrypted with a passphrase of its own, independent of the
+// repository password and key split, so a copy on external media can be
+// read back with nothing but the passphrase ('airgapper archive decrypt').
+//
+// An archive is a header line followed by the tar stream in chunks. The
+// header is JSON naming the snapshot and the key derivation parameters;
+// the key is PBKDF2-SHA256 of the passphrase, and each chunk is sealed with
+// AES-256-GCM under a nonce of its index and a final-chunk flag, with the
+// header as additional data. Reordering, truncating or extending chunks,
+// or editing the header, makes the archive fail to open.
+package archive
+
+import (
+	"archive/tar"
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	// Magic starts every archive
+	Magic = "airgapper-archive/v1\n"
+	// ChunkSize is how much of the tar stream each chunk seals
+	ChunkSize = 64 << 10
+	// MinPassphraseLength is the shortest passphrase accepted
+	MinPassphraseLength = 12
+	// Iterations is the PBKDF2 iteration count for new archives
+	Iterations = 600_000
+	// maxHeaderSize bounds the header line read before anything is checked
+	maxHeaderSize = 4 << 10
+)
+
+// ErrWrongPassphrase is returned when an archive doesn't open with the
+// passphrase given
+var ErrWrongPassphrase = errors.New("wrong passphrase, or the archive was modified")
+
+// Header describes an archive; it is stored in the clear
+type Header struct {
+	Vault      string    `json:"vault"`
+	SnapshotID string    `json:"snapshot_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	Salt       []byte    `json:"salt"`
+	Iterations int       `json:"iterations"`
+	ChunkSize  int       `json:"chunk_size"`
+}
+
+// aead derives the archive's cipher from passphrase
+func (h *Header) aead(passphrase string) (cipher.AEAD, error) {
+	if h.Iterations <= 0 || len(h.Salt) == 0 || h.ChunkSize <= 0 || h.ChunkSize > 16<<20 {
+		return nil, errors.New("archive header is invalid")
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, h.Salt, h.Iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// nonce is chunk index's nonce; the last byte marks the final chunk
+func nonce(size int, index uint64, final bool) []byte {
+	n := make([]byte, size)
+	binary.BigEndian.PutUint64(n[size-9:], index)
+	if final {
+		n[size-1] = 1
+	}
+	return n
+}
+
+// Writer encrypts a tar stream into an archive. Close must be called to
+// seal the final chunk; without it the archive doesn't open.
+type Writer struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	header []byte
+	buf    []byte
+	index  uint64
+	closed bool
+}
+
+// NewWriter writes h's header to w and returns a Writer sealing what is
+// written to it with passphrase. h's key derivation fields are filled in.
+func NewWriter(w io.Writer, passphrase string, h Header) (*Writer, error) {
+	if len(passphrase) < MinPassphraseLength {
+		return nil, fmt.Errorf("archive passphrase must be at least %d characters", MinPassphraseLength)
+	}
+	h.Salt = make([]byte, 16)
+	if _, err := rand.Read(h.Salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	h.Iterations = Iterations
+	h.ChunkSize = ChunkSize
+	if h.CreatedAt.IsZero() {
+		h.CreatedAt = time.Now().UTC()
+	}
+	aead, err := h.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	header, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	header = append(header, '\n')
+	if _, err := io.WriteString(w, Magic); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &Writer{w: w, aead: aead, header: header, buf: make([]byte, 0, ChunkSize)}, nil
+}
+
+// Write buffers p, sealing each full chunk once more data follows it
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("archive writer is closed")
+	}
+	written := 0
+	for len(p) > 0 {
+		if len(w.buf) == ChunkSize {
+			if err := w.seal(false); err != nil {
+				return written, err
+			}
+		}
+		n := copy(w.buf[len(w.buf):ChunkSize], p)
+		w.buf = w.buf[:len(w.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+// Close seals the final chunk. It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.seal(true)
+}
+
+func (w *Writer) seal(final bool) error {
+	sealed := w.aead.Seal(nil, nonce(w.aead.NonceSize(), w.index, final), w.buf, w.header)
+	w.index++
+	w.buf = w.buf[:0]
+	_, err := w.w.Write(sealed)
+	return err
+}
+
+// Reader decrypts an archive back into its tar stream. Read returns an
+// error, rather than io.EOF, if the archive ends before its final chunk.
+type Reader struct {
+	r      *bufio.Reader
+	aead   cipher.AEAD
+	header []byte
+	h      Header
+	buf    []byte
+	chunk  []byte
+	index  uint64
+	done   bool
+}
+
+// ReadHeader reads an archive's header without needing the passphrase
+func ReadHeader(r io.Reader) (*Header, error) {
+	h, _, err := readHeader(bufio.NewReader(r))
+	return h, err
+}
+
+func readHeader(br *bufio.Reader) (*Header, []byte, error) {
+	magic := make([]byte, len(Magic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != Magic {
+		return nil, nil, errors.New("not an airgapper archive")
+	}
+	var line []byte
+	for {
+		part, isPrefix, err := br.ReadLine()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read archive header: %w", err)
+		}
+		line = append(line, part...)
+		if len(line) > maxHeaderSize {
+			return nil, nil, errors.New("archive header is too long")
+		}
+		if !isPrefix {
+			break
+		}
+	}
+	var h Header
+	if err := json.Unmarshal(line, &h); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse archive header: %w", err)
+	}
+	return &h, append(line, '\n'), nil
+}
+
+// NewReader reads an archive's header from r and returns a Reader
+// decrypting the rest with passphrase
+func NewReader(r io.Reader, passphrase string) (*Reader, error) {
+	br := bufio.NewReaderSize(r, ChunkSize)
+	h, header, err := readHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := h.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{
+		r:      br,
+		aead:   aead,
+		header: header,
+		h:      *h,
+		buf:    make([]byte, h.ChunkSize+aead.Overhead()),
+	}, nil
+}
+
+// Header returns the archive's header
+func (r *Reader) Header() Header {
+	return r.h
+}
+
+// Read decrypts the next chunk as it is needed
+func (r *Reader) Read(p []byte) (int, error) {
+	for len(r.chunk) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.chunk)
+	r.chunk = r.chunk[n:]
+	return n, nil
+}
+
+// open reads and decrypts the next chunk. A chunk shorter than a full one,
+// or a full one nothing follows, is the final chunk.
+func (r *Reader) open() error {
+	n, err := io.ReadFull(r.r, r.buf)
+	final := false
+	switch {
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		final = true
+	case errors.Is(err, io.EOF):
+		return errors.New("archive is truncated")
+	case err != nil:
+		return err
+	default:
+		if _, err := r.r.Peek(1); errors.Is(err, io.EOF) {
+			final = true
+		}
+	}
+	if n < r.aead.Overhead() {
+		return errors.New("archive is truncated")
+	}
+	chunk, err := r.aead.Open(r.buf[:0], nonce(r.aead.NonceSize(), r.index, final), r.buf[:n], r.header)
+	if err != nil {
+		if r.index == 0 {
+			return ErrWrongPassphrase
+		}
+		return fmt.Errorf("archive chunk %d is corrupt or the archive is truncated", r.index)
+	}
+	r.index++
+	r.chunk = chunk
+	r.done = final
+	return nil
+}
+
+// Summary is what an archive holds
+type Summary struct {
+	Header Header
+	Files  int
+	Dirs   int
+	Bytes  int64
+}
+
+// Verify decrypts an archive and reads its tar stream through to the end,
+// checking every chunk and tar entry, and counts what it holds
+func Verify(r io.Reader, passphrase string) (*Summary, error) {
+	ar, err := NewReader(r, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	summary := &Summary{Header: ar.Header()}
+	tr := tar.NewReader(ar)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("archive's tar stream is invalid: %w", err)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			summary.Dirs++
+		case tar.TypeReg:
+			summary.Files++
+			n, err := io.Copy(io.Discard, tr)
+			if err != nil {
+				return nil, err
+			}
+			summary.Bytes += n
+		}
+	}
+	// The tar stream's padding after its end marker must decrypt too
+	if _, err := io.Copy(io.Discard, ar); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
@@ -0,0 +1,100 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/rand"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const passphrase = "correct horse battery"
+
+// seal archives data and returns the archive
+func seal(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	w, err := NewWriter(&out, passphrase, Header{Vault: "alice", SnapshotID: "abcd1234"})
+	require.NoError(t, err)
+	_, err = w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return out.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, size := range []int{0, 100, ChunkSize, 2*ChunkSize + 7} {
+		data := make([]byte, size)
+		_, _ = rand.Read(data)
+		sealed := seal(t, data)
+
+		r, err := NewReader(bytes.NewReader(sealed), passphrase)
+		require.NoError(t, err)
+		assert.Equal(t, "abcd1234", r.Header().SnapshotID)
+		got, err := io.ReadAll(r)
+		require.NoError(t, err, "size %d", size)
+		assert.Equal(t, data, got, "size %d", size)
+
+		h, err := ReadHeader(bytes.NewReader(sealed))
+		require.NoError(t, err)
+		assert.Equal(t, "alice", h.Vault)
+	}
+
+	_, err := NewWriter(io.Discard, "short", Header{})
+	assert.Error(t, err)
+	_, err = NewReader(strings.NewReader("not an archive"), passphrase)
+	assert.ErrorContains(t, err, "not an airgapper archive")
+}
+
+func TestTampering(t *testing.T) {
+	data := make([]byte, 3*ChunkSize)
+	sealed := seal(t, data)
+	read := func(archive []byte, passphrase string) error {
+		r, err := NewReader(bytes.NewReader(archive), passphrase)
+		if err != nil {
+			return err
+		}
+		_, err = io.ReadAll(r)
+		return err
+	}
+
+	assert.ErrorIs(t, read(sealed, "wrong horse battery"), ErrWrongPassphrase)
+
+	// Dropping the final chunk leaves a full chunk that wasn't sealed as final
+	chunk := ChunkSize + 16
+	assert.ErrorContains(t, read(sealed[:len(sealed)-16], passphrase), "truncated")
+	assert.Error(t, read(sealed[:len(sealed)-16-chunk], passphrase))
+
+	flipped := bytes.Clone(sealed)
+	flipped[len(flipped)-chunk] ^= 1
+	assert.ErrorContains(t, read(flipped, passphrase), "corrupt")
+
+	edited := bytes.Replace(sealed, []byte(`"vault":"alice"`), []byte(`"vault":"mally"`), 1)
+	assert.ErrorIs(t, read(edited, passphrase), ErrWrongPassphrase, "the header is authenticated")
+}
+
+func TestVerify(t *testing.T) {
+	var tarball bytes.Buffer
+	tw := tar.NewWriter(&tarball)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "home/", Typeflag: tar.TypeDir, Mode: 0700}))
+	for _, f := range []struct{ name, body string }{{"home/a.txt", "hello"}, {"home/b.txt", "world!"}} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: f.name, Typeflag: tar.TypeReg, Mode: 0600, Size: int64(len(f.body))}))
+		_, err := tw.Write([]byte(f.body))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	summary, err := Verify(bytes.NewReader(seal(t, tarball.Bytes())), passphrase)
+	require.NoError(t, err)
+	assert.Equal(t, 2, summary.Files)
+	assert.Equal(t, 1, summary.Dirs)
+	assert.Equal(t, int64(11), summary.Bytes)
+	assert.Equal(t, "abcd1234", summary.Header.SnapshotID)
+
+	_, err = Verify(bytes.NewReader(seal(t, []byte("not a tarball, but long enough to be read as a header"))), passphrase)
+	assert.Error(t, err)
+}
@@ -0,0 +1,137 @@
+// Package auditlog exports a node's audit records in standard formats and
+// forwards them to a syslog collector as they happen.
+//
+// A host keeps an audit log of every operation on the repositories it
+// stores: the signed audit chain if verification is enabled, the plain log
+// otherwise. Hosts who must show custody of someone else's data export it.
+// An owner's record of what happened is its activity feed, which exports
+// the same way, so both can be shipped into a SIEM.
+package auditlog
+
+import (
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/events"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+	"github.com/lcrostarosa/airgapper/backend/internal/verification"
+)
+
+// Logs a record can come from
+const (
+	LogStorage = "storage" // The storage server's audit log or chain
+	LogEvents  = "events"  // The activity feed
+)
+
+// Record is an audit log entry or feed event in one shape for export
+type Record struct {
+	Time          time.Time         `json:"time"`
+	Log           string            `json:"log"`
+	Sequence      uint64            `json:"sequence,omitempty"` // Chain sequence or event ID
+	Operation     string            `json:"operation"`          // Audited operation or event type
+	Subject       string            `json:"subject,omitempty"`  // Path or what the event happened to
+	Details       string            `json:"details,omitempty"`
+	Success       *bool             `json:"success,omitempty"` // Audited operations only
+	Error         string            `json:"error,omitempty"`
+	CorrelationID string            `json:"correlation_id,omitempty"`
+	Source        string            `json:"source,omitempty"` // Subsystem that published the event
+	Data          map[string]string `json:"data,omitempty"`
+
+	// Set for audit chain entries, so an export can be checked against the chain
+	Hash         string `json:"hash,omitempty"`
+	PreviousHash string `json:"previous_hash,omitempty"`
+	Signature    string `json:"signature,omitempty"`
+	KeyID        string `json:"key_id,omitempty"`
+}
+
+// FromStorage converts a storage audit log entry
+func FromStorage(e storage.AuditEntry) Record {
+	return Record{
+		Time:          e.Timestamp.UTC(),
+		Log:           LogStorage,
+		Operation:     e.Operation,
+		Subject:       e.Path,
+		Details:       e.Details,
+		Success:       &e.Success,
+		Error:         e.Error,
+		CorrelationID: e.CorrelationID,
+	}
+}
+
+// FromChain converts an audit chain entry
+func FromChain(e verification.ChainedAuditEntry) Record {
+	return Record{
+		Time:          e.Timestamp.UTC(),
+		Log:           LogStorage,
+		Sequence:      e.Sequence,
+		Operation:     e.Operation,
+		Subject:       e.Path,
+		Details:       e.Details,
+		Success:       &e.Success,
+		Error:         e.Error,
+		CorrelationID: e.CorrelationID,
+		Hash:          e.ContentHash,
+		PreviousHash:  e.PreviousHash,
+		Signature:     e.HostSignature,
+		KeyID:         e.HostKeyID,
+	}
+}
+
+// FromEvent converts an activity feed event
+func FromEvent(e events.Event) Record {
+	return Record{
+		Time:      e.Time.UTC(),
+		Log:       LogEvents,
+		Sequence:  e.ID,
+		Operation: e.Type,
+		Subject:   e.Subject,
+		Details:   e.Message,
+		Source:    e.Source,
+		Data:      e.Data,
+	}
+}
+
+// Failed reports whether the record is of an operation that failed
+func (r *Record) Failed() bool {
+	return r.Success != nil && !*r.Success
+}
+
+// StorageRecords returns srv's audit records, oldest first: the audit chain
+// if it has one, the plain log otherwise
+func StorageRecords(srv *storage.Server) []Record {
+	var records []Record
+	if chain := srv.AuditChain(); chain != nil {
+		for _, e := range chain.GetEntrySince(0) {
+			records = append(records, FromChain(e))
+		}
+		return records
+	}
+	for _, e := range srv.GetAuditLog(0) {
+		records = append(records, FromStorage(e))
+	}
+	return records
+}
+
+// EventRecords returns the events kept in l, oldest first
+func EventRecords(l *events.Log) ([]Record, error) {
+	evs, _, err := l.Since(0, 0, events.Filter{})
+	if err != nil {
+		return nil, err
+	}
+	records := make([]Record, 0, len(evs))
+	for _, e := range evs {
+		records = append(records, FromEvent(e))
+	}
+	return records, nil
+}
+
+// Between returns the records from from (inclusive) until to (exclusive).
+// A zero bound is open.
+func Between(records []Record, from, to time.Time) []Record {
+	var kept []Record
+	for _, r := range records {
+		if (from.IsZero() || !r.Time.Before(from)) && (to.IsZero() || r.Time.Before(to)) {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
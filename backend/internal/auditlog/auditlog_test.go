@@ -0,0 +1,182 @@
+package auditlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/events"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+	"github.com/lcrostarosa/airgapper/backend/internal/verification"
+)
+
+var day = time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+func sampleRecords() []Record {
+	return []Record{
+		FromStorage(storage.AuditEntry{Timestamp: day.Add(time.Hour), Operation: "CREATE", Path: "/srv/alice/data/ab", Success: true, CorrelationID: "req-1"}),
+		FromChain(verification.ChainedAuditEntry{Sequence: 7, Timestamp: day.Add(25 * time.Hour), Operation: "DELETE_DENIED", Path: "/srv/alice/snapshots/x", Details: "append-only, \"really\"", ContentHash: "h7", PreviousHash: "h6", HostSignature: "sig"}),
+		FromEvent(events.Event{ID: 3, Time: day.Add(49 * time.Hour), Type: events.BackupFinished, Source: events.SourceBackup, Message: "Backup finished", Data: map[string]string{"snapshot": "abc", "files": "12"}}),
+	}
+}
+
+func TestBetween(t *testing.T) {
+	records := sampleRecords()
+	assert.Len(t, Between(records, time.Time{}, time.Time{}), 3)
+	assert.Len(t, Between(records, day.Add(24*time.Hour), time.Time{}), 2)
+	kept := Between(records, day.Add(time.Hour), day.Add(25*time.Hour))
+	require.Len(t, kept, 1, "from is inclusive, to exclusive")
+	assert.Equal(t, "CREATE", kept[0].Operation)
+}
+
+func TestWriteJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, FormatJSONL, sampleRecords()))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+
+	var chained Record
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &chained))
+	assert.Equal(t, uint64(7), chained.Sequence)
+	assert.Equal(t, "h7", chained.Hash)
+	assert.True(t, chained.Failed())
+
+	var event map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[2]), &event))
+	assert.NotContains(t, event, "success", "events have no outcome")
+	assert.Equal(t, "events", event["log"])
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, FormatCSV, sampleRecords()))
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+	assert.Equal(t, csvHeader, rows[0])
+	assert.Equal(t, "true", rows[1][6])
+	assert.Equal(t, `append-only, "really"`, rows[2][5])
+	assert.Equal(t, "", rows[3][6])
+	assert.Equal(t, "files=12 snapshot=abc", rows[3][10])
+
+	assert.Error(t, Write(&buf, "xml", nil))
+}
+
+func TestFormatSyslog(t *testing.T) {
+	records := sampleRecords()
+	msg := string(FormatSyslog(records[0], "host one"))
+	assert.True(t, strings.HasPrefix(msg, "<110>1 2026-10-01T01:00:00Z host_one airgapper "), msg)
+	assert.Contains(t, msg, " CREATE - {")
+	assert.Contains(t, string(FormatSyslog(records[1], "h")), "<108>1 ", "denials are warnings")
+}
+
+func TestParseSyslogURL(t *testing.T) {
+	for raw, want := range map[string]string{
+		"udp://siem.example":      "siem.example:514",
+		"tcp://siem.example:1514": "siem.example:1514",
+		"tls://siem.example":      "siem.example:6514",
+	} {
+		_, addr, err := ParseSyslogURL(raw)
+		require.NoError(t, err, raw)
+		assert.Equal(t, want, addr)
+	}
+	for _, raw := range []string{"", "siem.example:514", "http://siem.example", "udp://"} {
+		_, _, err := ParseSyslogURL(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestForwarderUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = pc.Close() }()
+
+	f, err := NewForwarder("udp://" + pc.LocalAddr().String())
+	require.NoError(t, err)
+	f.Forward(sampleRecords()[0])
+
+	buf := make([]byte, 4096)
+	require.NoError(t, pc.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := pc.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(buf[:n]), "<110>1 "))
+	f.Close(time.Second)
+	f.Forward(sampleRecords()[0]) // Ignored once closed
+}
+
+func TestForwarderTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = ln.Close() }()
+	received := make(chan string, 3)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		r := bufio.NewReader(conn)
+		for {
+			// Octet counting: "<length> <message>"
+			length, err := r.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(length))
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(r, msg); err != nil {
+				return
+			}
+			received <- string(msg)
+		}
+	}()
+
+	f, err := NewForwarder("tcp://" + ln.Addr().String())
+	require.NoError(t, err)
+	for _, r := range sampleRecords() {
+		f.Forward(r)
+	}
+	f.Close(5 * time.Second)
+	for range 3 {
+		select {
+		case msg := <-received:
+			assert.True(t, strings.HasPrefix(msg, "<1"), msg)
+			assert.True(t, strings.HasSuffix(msg, "}"), msg)
+		case <-time.After(5 * time.Second):
+			t.Fatal("message not received")
+		}
+	}
+}
+
+func TestFollowEvents(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = pc.Close() }()
+	feed := events.Open(t.TempDir())
+	feed.Publish(events.Event{Type: events.BackupFinished, Source: events.SourceBackup, Message: "before"})
+
+	f, err := NewForwarder("udp://" + pc.LocalAddr().String())
+	require.NoError(t, err)
+	defer f.Close(time.Second)
+	stop := f.FollowEvents(feed, 10*time.Millisecond)
+	defer stop()
+	feed.Publish(events.Event{Type: events.BackupFailed, Source: events.SourceBackup, Message: "after"})
+
+	buf := make([]byte, 4096)
+	require.NoError(t, pc.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := pc.ReadFrom(buf)
+	require.NoError(t, err)
+	msg := string(buf[:n])
+	assert.True(t, strings.HasPrefix(msg, "<108>1 "), "failures are warnings: %s", msg)
+	assert.Contains(t, msg, `"details":"after"`, "events from before following aren't sent")
+}
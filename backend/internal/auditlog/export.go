@@ -0,0 +1,91 @@
+package auditlog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export formats
+const (
+	FormatJSONL = "jsonl" // One JSON record per line
+	FormatCSV   = "csv"   // RFC 4180, with a header row
+)
+
+// Formats lists the export formats
+var Formats = []string{FormatJSONL, FormatCSV}
+
+// ContentType returns the MIME type of an export format
+func ContentType(format string) string {
+	if format == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// Write writes records in format
+func Write(w io.Writer, format string, records []Record) error {
+	switch format {
+	case FormatJSONL:
+		return writeJSONL(w, records)
+	case FormatCSV:
+		return writeCSV(w, records)
+	default:
+		return fmt.Errorf("unknown export format %q (want %s)", format, strings.Join(Formats, " or "))
+	}
+}
+
+func writeJSONL(w io.Writer, records []Record) error {
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// csvHeader are the CSV export's columns
+var csvHeader = []string{
+	"time", "log", "sequence", "operation", "subject", "details", "success", "error",
+	"correlation_id", "source", "data", "hash", "previous_hash", "signature", "key_id",
+}
+
+func writeCSV(w io.Writer, records []Record) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, r := range records {
+		var sequence, success string
+		if r.Sequence > 0 {
+			sequence = strconv.FormatUint(r.Sequence, 10)
+		}
+		if r.Success != nil {
+			success = strconv.FormatBool(*r.Success)
+		}
+		if err := cw.Write([]string{
+			r.Time.Format(time.RFC3339Nano), r.Log, sequence, r.Operation, r.Subject, r.Details, success, r.Error,
+			r.CorrelationID, r.Source, formatData(r.Data), r.Hash, r.PreviousHash, r.Signature, r.KeyID,
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatData flattens event data to key=value pairs, sorted by key
+func formatData(data map[string]string) string {
+	pairs := make([]string, 0, len(data))
+	for _, k := range slices.Sorted(maps.Keys(data)) {
+		pairs = append(pairs, k+"="+data[k])
+	}
+	return strings.Join(pairs, " ")
+}
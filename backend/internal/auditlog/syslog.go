@@ -0,0 +1,269 @@
+package auditlog
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/events"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+// Records are sent as RFC 5424 syslog messages whose text is the record as
+// JSON, which SIEMs parse without a custom format. UDP sends one message
+// per datagram; TCP and TLS frame messages by octet counting (RFC 6587).
+
+const (
+	// facilityAudit is syslog's "log audit" facility
+	facilityAudit = 13
+	severityWarn  = 4
+	severityInfo  = 6
+
+	// queueSize is how many records wait to be sent before new ones are
+	// dropped, so a slow collector never holds up what is being audited
+	queueSize = 1024
+	// redialDelay is how long the forwarder waits after failing to reach
+	// the collector before trying again
+	redialDelay = 30 * time.Second
+	dialTimeout = 5 * time.Second
+)
+
+// Default ports by scheme
+var syslogPorts = map[string]string{"udp": "514", "tcp": "514", "tls": "6514"}
+
+// Forwarder sends records to a syslog collector in the background
+type Forwarder struct {
+	url      string
+	network  string
+	addr     string
+	tls      *tls.Config
+	hostname string
+
+	queue chan Record
+	wg    sync.WaitGroup
+
+	mu      sync.Mutex
+	closed  bool
+	dropped int
+}
+
+// ParseSyslogURL checks a collector URL: udp://, tcp:// or tls:// and a
+// host, with an optional port
+func ParseSyslogURL(rawURL string) (network, addr string, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid syslog URL: %w", err)
+	}
+	port, ok := syslogPorts[u.Scheme]
+	if !ok || u.Hostname() == "" {
+		return "", "", fmt.Errorf("invalid syslog URL %q: want udp://, tcp:// or tls:// and a host", rawURL)
+	}
+	if u.Port() != "" {
+		port = u.Port()
+	}
+	return u.Scheme, net.JoinHostPort(u.Hostname(), port), nil
+}
+
+// NewForwarder starts forwarding records to the collector at rawURL
+func NewForwarder(rawURL string) (*Forwarder, error) {
+	network, addr, err := ParseSyslogURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	f := &Forwarder{
+		url:      rawURL,
+		network:  network,
+		addr:     addr,
+		hostname: hostname,
+		queue:    make(chan Record, queueSize),
+	}
+	if network == "tls" {
+		host, _, _ := net.SplitHostPort(addr)
+		f.tls = &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+	}
+	f.wg.Add(1)
+	go f.run()
+	return f, nil
+}
+
+// Forward queues r to be sent. It never blocks: if the queue is full the
+// record is dropped, and the drop is logged once the collector catches up.
+func (f *Forwarder) Forward(r Record) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return
+	}
+	select {
+	case f.queue <- r:
+	default:
+		f.dropped++
+	}
+}
+
+// Close stops forwarding once the queued records are sent, or the timeout
+// passes
+func (f *Forwarder) Close(timeout time.Duration) {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return
+	}
+	f.closed = true
+	close(f.queue)
+	f.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
+
+// FollowEvents forwards events published to l from now on, including those
+// other processes such as CLI commands publish, checking for them every
+// interval. It returns a function that stops following.
+func (f *Forwarder) FollowEvents(l *events.Log, interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	var once sync.Once
+	cursor, err := l.Latest()
+	if err != nil {
+		logging.Warn("Cannot read the activity feed to forward it", logging.Err(err))
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			evs, _, err := l.Since(cursor, 0, events.Filter{})
+			if err != nil {
+				continue
+			}
+			for _, e := range evs {
+				f.Forward(FromEvent(e))
+				cursor = e.ID
+			}
+		}
+	}()
+	return func() { once.Do(func() { close(done) }) }
+}
+
+func (f *Forwarder) run() {
+	defer f.wg.Done()
+	var conn net.Conn
+	var retryAt time.Time
+	failing := false
+	defer func() {
+		if conn != nil {
+			_ = conn.Close()
+		}
+	}()
+
+	for r := range f.queue {
+		msg := f.format(r)
+		sent := false
+		// A broken connection is only noticed on write, so redial once
+		for attempt := 0; attempt < 2 && !sent; attempt++ {
+			if conn == nil {
+				if time.Now().Before(retryAt) {
+					break
+				}
+				c, err := f.dial()
+				if err != nil {
+					retryAt = time.Now().Add(redialDelay)
+					if !failing {
+						logging.Warn("Cannot reach the syslog collector; audit records are being dropped",
+							logging.String("collector", f.url), logging.Err(err))
+						failing = true
+					}
+					break
+				}
+				conn = c
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(dialTimeout))
+			if _, err := conn.Write(msg); err != nil {
+				_ = conn.Close()
+				conn = nil
+				continue
+			}
+			sent = true
+		}
+
+		f.mu.Lock()
+		if !sent {
+			f.dropped++
+		} else if failing || f.dropped > 0 {
+			logging.Info("Syslog collector reachable again",
+				logging.String("collector", f.url), logging.Int("dropped", f.dropped))
+			failing, f.dropped = false, 0
+		}
+		f.mu.Unlock()
+	}
+}
+
+func (f *Forwarder) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	if f.tls != nil {
+		return tls.DialWithDialer(dialer, "tcp", f.addr, f.tls)
+	}
+	return dialer.Dial(f.network, f.addr)
+}
+
+// format renders r as a syslog message, framed for the transport
+func (f *Forwarder) format(r Record) []byte {
+	msg := FormatSyslog(r, f.hostname)
+	if f.network == "udp" {
+		return msg
+	}
+	return append([]byte(fmt.Sprintf("%d ", len(msg))), msg...)
+}
+
+// FormatSyslog renders r as an RFC 5424 message from host
+func FormatSyslog(r Record, host string) []byte {
+	severity := severityInfo
+	if r.Failed() || strings.HasSuffix(r.Operation, "_DENIED") || strings.HasSuffix(r.Operation, ".failed") {
+		severity = severityWarn
+	}
+	body, _ := json.Marshal(r)
+	// PRI VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	return []byte(fmt.Sprintf("<%d>1 %s %s airgapper %d %s - %s",
+		facilityAudit*8+severity,
+		r.Time.UTC().Format(time.RFC3339Nano),
+		syslogField(host, 255),
+		os.Getpid(),
+		syslogField(r.Operation, 32),
+		body))
+}
+
+// syslogField makes s a valid header field: printable ASCII without
+// spaces, at most n long, and "-" if empty
+func syslogField(s string, n int) string {
+	s = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, s)
+	if len(s) > n {
+		s = s[:n]
+	}
+	if s == "" {
+		return "-"
+	}
+	return s
+}
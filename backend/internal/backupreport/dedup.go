@@ -0,0 +1,222 @@
+package backupreport
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxDedupPoints is how many backup runs a DedupStore keeps; at one
+	// backup an hour that is about six weeks
+	MaxDedupPoints = 1000
+	// ProjectionWindow is how far back the growth rate the projection
+	// extrapolates is measured
+	ProjectionWindow = 30 * 24 * time.Hour
+)
+
+// DedupPoint is how much one backup run read and how much of it reached
+// the repository
+type DedupPoint struct {
+	At         time.Time `json:"at"`
+	SnapshotID string    `json:"snapshot_id"`
+	// RawBytes is the size of the files the backup read
+	RawBytes int64 `json:"raw_bytes"`
+	// NewBytes is the part of RawBytes the repository didn't already hold
+	NewBytes int64 `json:"new_bytes"`
+	// StoredBytes is what NewBytes took in the repository after compression
+	StoredBytes int64 `json:"stored_bytes"`
+}
+
+// PointFromReport returns a report's dedup point. Reports from restic
+// versions that don't tell the compressed size count NewBytes as stored.
+func PointFromReport(r *Report) DedupPoint {
+	stored := r.BytesStored
+	if stored == 0 {
+		stored = r.BytesAdded
+	}
+	return DedupPoint{
+		At:          r.FinishedAt,
+		SnapshotID:  r.SnapshotID,
+		RawBytes:    r.TotalBytes,
+		NewBytes:    r.BytesAdded,
+		StoredBytes: stored,
+	}
+}
+
+// DedupStats is how well deduplication and compression shrink backups
+type DedupStats struct {
+	Runs        int   `json:"runs"`
+	RawBytes    int64 `json:"raw_bytes"`
+	NewBytes    int64 `json:"new_bytes"`
+	StoredBytes int64 `json:"stored_bytes"`
+	// DedupRatio is RawBytes over NewBytes: how many times over each byte
+	// stored would have been uploaded without deduplication
+	DedupRatio float64 `json:"dedup_ratio"`
+	// CompressionRatio is NewBytes over StoredBytes
+	CompressionRatio float64 `json:"compression_ratio"`
+	// SavingsPct is the share of RawBytes that was never stored
+	SavingsPct float64 `json:"savings_pct"`
+	// Points are the runs, oldest first
+	Points []DedupPoint `json:"points"`
+	// Projection is nil until a quota is known
+	Projection *Projection `json:"projection,omitempty"`
+}
+
+// Projection estimates when the storage quota fills up at the rate the
+// repository grew over the last ProjectionWindow
+type Projection struct {
+	QuotaBytes int64 `json:"quota_bytes"`
+	UsedBytes  int64 `json:"used_bytes"`
+	// UsedEstimated is set when UsedBytes is the sum of what backups stored
+	// rather than the host's measurement; it ignores space prune freed
+	UsedEstimated bool    `json:"used_estimated,omitempty"`
+	BytesPerDay   int64   `json:"bytes_per_day"`
+	DaysToFull    float64 `json:"days_to_full,omitempty"`
+	// FullAt is nil when the repository isn't growing
+	FullAt *time.Time `json:"full_at,omitempty"`
+}
+
+// Summarize totals points, oldest first
+func Summarize(points []DedupPoint) DedupStats {
+	stats := DedupStats{Runs: len(points), Points: points}
+	if stats.Points == nil {
+		stats.Points = []DedupPoint{}
+	}
+	for _, p := range points {
+		stats.RawBytes += p.RawBytes
+		stats.NewBytes += p.NewBytes
+		stats.StoredBytes += p.StoredBytes
+	}
+	if stats.NewBytes > 0 {
+		stats.DedupRatio = float64(stats.RawBytes) / float64(stats.NewBytes)
+	}
+	if stats.StoredBytes > 0 {
+		stats.CompressionRatio = float64(stats.NewBytes) / float64(stats.StoredBytes)
+	}
+	if stats.RawBytes > 0 {
+		stats.SavingsPct = 100 * (1 - float64(stats.StoredBytes)/float64(stats.RawBytes))
+	}
+	return stats
+}
+
+// Project extrapolates the growth of the repository over the points in
+// the ProjectionWindow before now to when used reaches quota. The first
+// point in the window only marks its start; what it stored predates it.
+func Project(points []DedupPoint, quota, used int64, now time.Time) *Projection {
+	if quota <= 0 {
+		return nil
+	}
+	p := &Projection{QuotaBytes: quota, UsedBytes: used}
+
+	var first *DedupPoint
+	var grown int64
+	for i := range points {
+		if points[i].At.Before(now.Add(-ProjectionWindow)) {
+			continue
+		}
+		if first == nil {
+			first = &points[i]
+			continue
+		}
+		grown += points[i].StoredBytes
+	}
+	if first != nil {
+		if days := now.Sub(first.At).Hours() / 24; days > 0 {
+			p.BytesPerDay = int64(float64(grown) / days)
+		}
+	}
+
+	switch {
+	case used >= quota:
+		p.FullAt = &now
+	case p.BytesPerDay > 0:
+		p.DaysToFull = float64(quota-used) / float64(p.BytesPerDay)
+		at := now.Add(time.Duration(p.DaysToFull * 24 * float64(time.Hour)))
+		p.FullAt = &at
+	}
+	return p
+}
+
+// DedupStore persists a DedupPoint per backup run, oldest first, and the
+// total stored since the store was created
+type DedupStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// dedupFile is the store's file
+type dedupFile struct {
+	Points []DedupPoint `json:"points"`
+	// StoredTotal sums StoredBytes over every run, including those dropped
+	// beyond MaxDedupPoints
+	StoredTotal int64 `json:"stored_total"`
+}
+
+// NewDedupStore returns a store backed by the file at path
+func NewDedupStore(path string) *DedupStore {
+	return &DedupStore{path: path}
+}
+
+// Add records a point, dropping the oldest beyond MaxDedupPoints
+func (s *DedupStore) Add(p DedupPoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := s.load()
+	if err != nil {
+		return err
+	}
+	f.Points = append(f.Points, p)
+	if len(f.Points) > MaxDedupPoints {
+		f.Points = f.Points[len(f.Points)-MaxDedupPoints:]
+	}
+	f.StoredTotal += p.StoredBytes
+	return s.save(f)
+}
+
+// Points returns the recorded points, oldest first, and the total stored
+// by every run recorded
+func (s *DedupStore) Points() ([]DedupPoint, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := s.load()
+	if err != nil {
+		return nil, 0, err
+	}
+	return f.Points, f.StoredTotal, nil
+}
+
+func (s *DedupStore) load() (*dedupFile, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return &dedupFile{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dedup stats: %w", err)
+	}
+
+	var f dedupFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse dedup stats: %w", err)
+	}
+	return &f, nil
+}
+
+func (s *DedupStore) save(f *dedupFile) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize dedup stats: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save dedup stats: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package backupreport
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarize(t *testing.T) {
+	stats := Summarize(nil)
+	assert.Equal(t, 0, stats.Runs)
+	assert.NotNil(t, stats.Points)
+
+	// An old restic doesn't report the compressed size
+	point := PointFromReport(&Report{SnapshotID: "a", TotalBytes: 1000, BytesAdded: 100})
+	assert.Equal(t, int64(100), point.StoredBytes)
+
+	stats = Summarize([]DedupPoint{
+		point,
+		PointFromReport(&Report{SnapshotID: "b", TotalBytes: 1000, BytesAdded: 100, BytesStored: 50}),
+	})
+	assert.Equal(t, 2, stats.Runs)
+	assert.InDelta(t, 10, stats.DedupRatio, 0.001)
+	assert.InDelta(t, 200.0/150, stats.CompressionRatio, 0.001)
+	assert.InDelta(t, 92.5, stats.SavingsPct, 0.001)
+}
+
+func TestProject(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	points := []DedupPoint{
+		{At: now.Add(-60 * day), StoredBytes: 1 << 30}, // Outside the window
+		{At: now.Add(-10 * day), StoredBytes: 500},     // Marks the window's start
+		{At: now.Add(-5 * day), StoredBytes: 400},
+		{At: now.Add(-1 * day), StoredBytes: 600},
+	}
+
+	assert.Nil(t, Project(points, 0, 0, now), "no quota")
+
+	p := Project(points, 10_000, 4_000, now)
+	require.NotNil(t, p)
+	assert.Equal(t, int64(100), p.BytesPerDay)
+	assert.InDelta(t, 60, p.DaysToFull, 0.001)
+	require.NotNil(t, p.FullAt)
+	assert.Equal(t, now.Add(60*day), *p.FullAt)
+
+	p = Project(points[:2], 10_000, 4_000, now)
+	assert.Zero(t, p.BytesPerDay)
+	assert.Nil(t, p.FullAt, "not growing")
+
+	p = Project(points, 10_000, 12_000, now)
+	assert.Equal(t, now, *p.FullAt, "already full")
+}
+
+func TestDedupStore(t *testing.T) {
+	store := NewDedupStore(filepath.Join(t.TempDir(), "stats", "dedup-stats.json"))
+
+	points, total, err := store.Points()
+	require.NoError(t, err)
+	assert.Empty(t, points)
+	assert.Zero(t, total)
+
+	for range MaxDedupPoints + 5 {
+		require.NoError(t, store.Add(DedupPoint{StoredBytes: 10}))
+	}
+	points, total, err = store.Points()
+	require.NoError(t, err)
+	assert.Len(t, points, MaxDedupPoints)
+	assert.Equal(t, int64(10*(MaxDedupPoints+5)), total, "total keeps trimmed runs")
+}
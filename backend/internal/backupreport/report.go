@@ -0,0 +1,206 @@
+// Package backupreport describes what each backup run stored: how many
+// files were new, changed or unchanged, the bytes added, the largest new
+// files and, optionally, what changed since the previous snapshot. Reports
+// are kept with the backup history and sent in backup notifications.
+package backupreport
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+// Report is the outcome of one successful, or partly successful, backup run
+type Report struct {
+	SnapshotID string    `json:"snapshot_id"`
+	Paths      []string  `json:"paths"`
+	Scheduled  bool      `json:"scheduled"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+
+	FilesNew        int   `json:"files_new"`
+	FilesChanged    int   `json:"files_changed"`
+	FilesUnmodified int   `json:"files_unmodified"`
+	BytesAdded      int64 `json:"bytes_added"`
+	// BytesStored is what BytesAdded took in the repository after compression
+	BytesStored int64 `json:"bytes_stored,omitempty"`
+	TotalFiles  int   `json:"total_files"`
+	TotalBytes  int64 `json:"total_bytes"`
+
+	// Skipped counts files restic couldn't read and left out of the snapshot
+	Skipped int `json:"skipped"`
+
+	// FailedPaths are backup paths left out because they were missing or
+	// unreadable, with backup_continue_on_error set
+	FailedPaths []restic.PathError `json:"failed_paths,omitempty"`
+	// Partial is set when the snapshot left out failed paths or skipped files
+	Partial bool `json:"partial,omitempty"`
+
+	// LargestNew are the largest new files, largest first
+	LargestNew []restic.FileSize `json:"largest_new"`
+
+	// Diff compares the snapshot with the previous one of the same paths.
+	// It is nil when diffing is disabled or there is no previous snapshot.
+	Diff *DiffSummary `json:"diff,omitempty"`
+}
+
+// DiffSummary counts what changed since the parent snapshot
+type DiffSummary struct {
+	ParentID  string `json:"parent_id"`
+	Added     int    `json:"added"`
+	Removed   int    `json:"removed"`
+	Modified  int    `json:"modified"`
+	SizeDelta int64  `json:"size_delta"`
+}
+
+// New builds a report from restic's summary of a backup run
+func New(summary *restic.BackupSummary, paths []string, started, finished time.Time, scheduled bool) *Report {
+	largest := slices.Clone(summary.LargestNew)
+	if largest == nil {
+		largest = []restic.FileSize{}
+	}
+	return &Report{
+		SnapshotID:      summary.SnapshotID,
+		Paths:           slices.Clone(paths),
+		Scheduled:       scheduled,
+		StartedAt:       started,
+		FinishedAt:      finished,
+		FilesNew:        summary.FilesNew,
+		FilesChanged:    summary.FilesChanged,
+		FilesUnmodified: summary.FilesUnmodified,
+		BytesAdded:      summary.DataAdded,
+		BytesStored:     summary.DataAddedPacked,
+		TotalFiles:      summary.TotalFilesProcessed,
+		TotalBytes:      summary.TotalBytesProcessed,
+		Skipped:         summary.Skipped,
+		Partial:         summary.Skipped > 0,
+		LargestNew:      largest,
+	}
+}
+
+// AddFailedPaths records the backup paths left out of the snapshot
+func (r *Report) AddFailedPaths(failed []restic.PathError) {
+	r.FailedPaths = append(r.FailedPaths, failed...)
+	r.Partial = r.Partial || len(failed) > 0
+}
+
+// AddDiff diffs the report's snapshot against the most recent earlier
+// snapshot of the same paths. It does nothing for a first backup.
+func (r *Report) AddDiff(ctx context.Context, repo restic.Runner) error {
+	snapshots, err := repo.SnapshotList(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	parent := parentOf(snapshots, r.SnapshotID)
+	if parent == "" {
+		return nil
+	}
+
+	diff, err := repo.Diff(ctx, parent, r.SnapshotID)
+	if err != nil {
+		return fmt.Errorf("failed to diff against %s: %w", shortID(parent), err)
+	}
+	r.Diff = &DiffSummary{
+		ParentID:  parent,
+		Added:     countFiles(diff.Added),
+		Removed:   countFiles(diff.Removed),
+		Modified:  countFiles(diff.Modified),
+		SizeDelta: diff.SizeDelta(),
+	}
+	return nil
+}
+
+// parentOf returns the newest snapshot older than id with the same paths
+func parentOf(snapshots []restic.Snapshot, id string) string {
+	i := slices.IndexFunc(snapshots, func(s restic.Snapshot) bool { return s.ID == id })
+	if i < 0 {
+		return ""
+	}
+	target := snapshots[i]
+	paths := slices.Sorted(slices.Values(target.Paths))
+
+	var parent *restic.Snapshot
+	for j := range snapshots {
+		s := &snapshots[j]
+		if s.ID == id || !s.Time.Before(target.Time) || !slices.Equal(slices.Sorted(slices.Values(s.Paths)), paths) {
+			continue
+		}
+		if parent == nil || s.Time.After(parent.Time) {
+			parent = s
+		}
+	}
+	if parent == nil {
+		return ""
+	}
+	return parent.ID
+}
+
+// countFiles counts the entries of a diff list that aren't directories
+func countFiles(paths []string) int {
+	n := 0
+	for _, p := range paths {
+		if !strings.HasSuffix(p, "/") {
+			n++
+		}
+	}
+	return n
+}
+
+// Text renders the report for notifications and the CLI
+func (r *Report) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Snapshot %s: %d new, %d changed, %d unchanged files; %s added\n",
+		shortID(r.SnapshotID), r.FilesNew, r.FilesChanged, r.FilesUnmodified, FormatBytes(r.BytesAdded))
+	if r.Diff != nil {
+		fmt.Fprintf(&b, "Since %s: %d added, %d removed, %d modified (%s)\n",
+			shortID(r.Diff.ParentID), r.Diff.Added, r.Diff.Removed, r.Diff.Modified, formatDelta(r.Diff.SizeDelta))
+	}
+	if len(r.FailedPaths) > 0 {
+		fmt.Fprintf(&b, "%d path(s) not backed up:\n", len(r.FailedPaths))
+		for _, f := range r.FailedPaths {
+			fmt.Fprintf(&b, "  %s: %s\n", f.Path, f.Error)
+		}
+	}
+	if r.Skipped > 0 {
+		fmt.Fprintf(&b, "%d unreadable file(s) skipped\n", r.Skipped)
+	}
+	if len(r.LargestNew) > 0 {
+		b.WriteString("Largest new files:\n")
+		for _, f := range r.LargestNew {
+			fmt.Fprintf(&b, "  %10s  %s\n", FormatBytes(f.Size), f.Path)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// FormatBytes formats a size with binary units, e.g. "1.5 MiB"
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func formatDelta(n int64) string {
+	if n < 0 {
+		return "-" + FormatBytes(-n)
+	}
+	return "+" + FormatBytes(n)
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
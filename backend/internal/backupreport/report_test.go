@@ -0,0 +1,113 @@
+package backupreport
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/testutil"
+)
+
+func TestNewAndText(t *testing.T) {
+	started := time.Now()
+	r := New(&restic.BackupSummary{
+		SnapshotID:      "abcdef0123456789",
+		FilesNew:        2,
+		FilesChanged:    1,
+		FilesUnmodified: 40,
+		DataAdded:       3 << 20,
+		Skipped:         1,
+		LargestNew:      []restic.FileSize{{Path: "/docs/big.iso", Size: 2 << 20}, {Path: "/docs/a.txt", Size: 512}},
+	}, []string{"/docs"}, started, started.Add(time.Minute), true)
+
+	assert.Equal(t, "abcdef0123456789", r.SnapshotID)
+	assert.True(t, r.Scheduled)
+	assert.Equal(t, int64(3<<20), r.BytesAdded)
+
+	text := r.Text()
+	assert.Contains(t, text, "Snapshot abcdef01: 2 new, 1 changed, 40 unchanged files; 3.0 MiB added")
+	assert.Contains(t, text, "1 unreadable file(s) skipped")
+	assert.Contains(t, text, "2.0 MiB  /docs/big.iso")
+	assert.NotContains(t, text, "Since")
+	assert.True(t, r.Partial, "skipped files make the backup partial")
+
+	r.AddFailedPaths([]restic.PathError{{Path: "/pictures", Error: "no such file or directory"}})
+	assert.Contains(t, r.Text(), "1 path(s) not backed up:\n  /pictures: no such file or directory")
+
+	r.Diff = &DiffSummary{ParentID: "0123456789abcdef", Added: 2, Removed: 3, Modified: 1, SizeDelta: -2048}
+	assert.Contains(t, r.Text(), "Since 01234567: 2 added, 3 removed, 1 modified (-2.0 KiB)")
+}
+
+func TestAddDiff(t *testing.T) {
+	ctx := context.Background()
+	fake := testutil.NewFakeRestic()
+	require.NoError(t, fake.Init(ctx))
+
+	first, err := fake.Backup(ctx, []string{"/docs"}, nil)
+	require.NoError(t, err)
+	_, err = fake.Backup(ctx, []string{"/photos"}, nil)
+	require.NoError(t, err)
+
+	t.Run("first backup of the paths has no parent", func(t *testing.T) {
+		r := New(first, []string{"/docs"}, time.Now(), time.Now(), false)
+		require.NoError(t, r.AddDiff(ctx, fake))
+		assert.Nil(t, r.Diff)
+	})
+
+	t.Run("diffs against the previous snapshot of the same paths", func(t *testing.T) {
+		second, err := fake.Backup(ctx, []string{"/docs"}, nil)
+		require.NoError(t, err)
+		fake.DiffResult = &restic.Diff{
+			Added:      []string{"/docs/new/", "/docs/new/a.txt", "/docs/b.txt"},
+			Removed:    []string{"/docs/old.txt"},
+			Modified:   []string{"/docs/notes.md"},
+			AddedStats: restic.DiffStats{Bytes: 4096},
+		}
+
+		r := New(second, []string{"/docs"}, time.Now(), time.Now(), false)
+		require.NoError(t, r.AddDiff(ctx, fake))
+		require.NotNil(t, r.Diff)
+		assert.Equal(t, first.SnapshotID, r.Diff.ParentID)
+		assert.Equal(t, 2, r.Diff.Added)
+		assert.Equal(t, 1, r.Diff.Removed)
+		assert.Equal(t, 1, r.Diff.Modified)
+		assert.Equal(t, int64(4096), r.Diff.SizeDelta)
+		assert.Contains(t, fake.Calls, fmt.Sprintf("diff %s %s", first.SnapshotID, second.SnapshotID))
+	})
+}
+
+func TestStore(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "reports", "backup-reports.json"))
+
+	reports, err := store.List(0)
+	require.NoError(t, err)
+	assert.Empty(t, reports)
+
+	for i := range MaxReports + 5 {
+		require.NoError(t, store.Add(&Report{SnapshotID: fmt.Sprintf("%08x%08x", i, i)}))
+	}
+
+	reports, err = store.List(0)
+	require.NoError(t, err)
+	require.Len(t, reports, MaxReports)
+	assert.Equal(t, fmt.Sprintf("%08x%08x", MaxReports+4, MaxReports+4), reports[0].SnapshotID, "newest first")
+
+	reports, err = store.List(2)
+	require.NoError(t, err)
+	assert.Len(t, reports, 2)
+
+	got, err := store.Get("0000000a")
+	require.NoError(t, err)
+	assert.Equal(t, "0000000a0000000a", got.SnapshotID)
+
+	_, err = store.Get("00000001")
+	assert.ErrorIs(t, err, ErrReportNotFound, "trimmed")
+	_, err = store.Get("")
+	assert.ErrorIs(t, err, ErrReportNotFound)
+}
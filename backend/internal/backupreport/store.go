@@ -0,0 +1,115 @@
+package backupreport
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+// MaxReports is how many reports a Store keeps
+const MaxReports = 100
+
+// ErrReportNotFound is returned for unknown snapshot IDs
+var ErrReportNotFound = apperrors.New(apperrors.CodeBackupReportNotFound, "backup report not found")
+
+// Store persists the reports of recent backup runs, oldest first
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore returns a store backed by the file at path
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Add records a report, dropping the oldest beyond MaxReports
+func (s *Store) Add(r *Report) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reports, err := s.load()
+	if err != nil {
+		return err
+	}
+	reports = append(reports, r)
+	if len(reports) > MaxReports {
+		reports = reports[len(reports)-MaxReports:]
+	}
+	return s.save(reports)
+}
+
+// List returns up to limit of the most recent reports, newest first
+// (limit <= 0 returns all)
+func (s *Store) List(limit int) ([]*Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reports, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > len(reports) {
+		limit = len(reports)
+	}
+	result := make([]*Report, 0, limit)
+	for i := len(reports) - 1; i >= len(reports)-limit; i-- {
+		result = append(result, reports[i])
+	}
+	return result, nil
+}
+
+// Get returns the report for a snapshot, by full or abbreviated ID
+func (s *Store) Get(snapshotID string) (*Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reports, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	if snapshotID == "" {
+		return nil, ErrReportNotFound
+	}
+	for i := len(reports) - 1; i >= 0; i-- {
+		if strings.HasPrefix(reports[i].SnapshotID, snapshotID) {
+			return reports[i], nil
+		}
+	}
+	return nil, ErrReportNotFound
+}
+
+func (s *Store) load() ([]*Report, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup reports: %w", err)
+	}
+
+	var reports []*Report
+	if err := json.Unmarshal(data, &reports); err != nil {
+		return nil, fmt.Errorf("failed to parse backup reports: %w", err)
+	}
+	return reports, nil
+}
+
+func (s *Store) save(reports []*Report) error {
+	data, err := json.MarshalIndent(reports, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize backup reports: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save backup reports: %w", err)
+	}
+	return nil
+}
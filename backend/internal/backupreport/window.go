@@ -0,0 +1,60 @@
+package backupreport
+
+import "time"
+
+// WindowRuns is how many recent scheduled backups a Window averages
+const WindowRuns = 10
+
+// Window is when an owner's scheduled backups run and how long and how much
+// they upload, so the host can tell when its uplink will be busy
+type Window struct {
+	// Private is set when the owner keeps its schedule to itself; nothing
+	// else is filled in then
+	Private  bool   `json:"private,omitempty"`
+	Schedule string `json:"schedule,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+	// NextStarts are the next times a scheduled backup starts
+	NextStarts []time.Time `json:"next_starts,omitempty"`
+	// Runs is how many recent scheduled backups the averages are over
+	Runs               int   `json:"runs"`
+	AvgDurationSeconds int64 `json:"avg_duration_seconds"`
+	// AvgUploadBytes is what a backup stored in the repository, after
+	// deduplication and compression
+	AvgUploadBytes int64 `json:"avg_upload_bytes"`
+}
+
+// Average sets the window's averages from up to WindowRuns of reports,
+// newest first, counting only scheduled backups
+func (w *Window) Average(reports []*Report) {
+	var duration time.Duration
+	var bytes int64
+	w.Runs = 0
+	for _, r := range reports {
+		if w.Runs == WindowRuns {
+			break
+		}
+		if !r.Scheduled {
+			continue
+		}
+		duration += r.FinishedAt.Sub(r.StartedAt)
+		bytes += PointFromReport(r).StoredBytes
+		w.Runs++
+	}
+	if w.Runs == 0 {
+		w.AvgDurationSeconds, w.AvgUploadBytes = 0, 0
+		return
+	}
+	w.AvgDurationSeconds = int64((duration / time.Duration(w.Runs)).Seconds())
+	w.AvgUploadBytes = bytes / int64(w.Runs)
+}
+
+// LastScheduled returns when the newest scheduled backup in reports, newest
+// first, finished, or the zero time if there is none
+func LastScheduled(reports []*Report) time.Time {
+	for _, r := range reports {
+		if r.Scheduled {
+			return r.FinishedAt
+		}
+	}
+	return time.Time{}
+}
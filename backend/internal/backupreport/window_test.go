@@ -0,0 +1,28 @@
+package backupreport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindowAverage(t *testing.T) {
+	start := time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC)
+	reports := []*Report{
+		{Scheduled: true, StartedAt: start, FinishedAt: start.Add(30 * time.Minute), BytesAdded: 300, BytesStored: 100},
+		{Scheduled: false, StartedAt: start, FinishedAt: start.Add(5 * time.Hour), BytesAdded: 1 << 30},
+		{Scheduled: true, StartedAt: start, FinishedAt: start.Add(10 * time.Minute), BytesAdded: 300},
+	}
+
+	var w Window
+	w.Average(reports)
+	assert.Equal(t, 2, w.Runs, "manual backups are left out")
+	assert.Equal(t, int64(20*60), w.AvgDurationSeconds)
+	assert.Equal(t, int64(200), w.AvgUploadBytes, "stored bytes, or added bytes when restic didn't report them")
+	assert.Equal(t, start.Add(30*time.Minute), LastScheduled(reports))
+
+	w.Average(nil)
+	assert.Equal(t, Window{}, w)
+	assert.True(t, LastScheduled(reports[1:2]).IsZero())
+}
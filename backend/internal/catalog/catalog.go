@@ -0,0 +1,229 @@
+// Package catalog is the owner's local record of its snapshots: ID, time,
+// host, paths, tags and what each backup stored. It is kept encrypted with
+// the repository password, so snapshots can be listed and picked for
+// restore requests offline, without contacting the repository.
+package catalog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/backupreport"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+// keyContext separates the catalog key from other uses of the password
+const keyContext = "airgapper snapshot catalog v1\x00"
+
+// ErrNotFound is returned when no cataloged snapshot has an ID
+var ErrNotFound = errors.New("snapshot not in the local catalog")
+
+// Entry is a cataloged snapshot
+type Entry struct {
+	SnapshotID string    `json:"snapshot_id"`
+	Time       time.Time `json:"time"`
+	Hostname   string    `json:"hostname,omitempty"`
+	Paths      []string  `json:"paths"`
+	Tags       []string  `json:"tags,omitempty"`
+	// Stats is what the backup stored. It is nil for snapshots learned
+	// from the repository's snapshot list rather than backed up here.
+	Stats *Stats `json:"stats,omitempty"`
+}
+
+// Stats is what a backup run stored
+type Stats struct {
+	FilesNew        int   `json:"files_new"`
+	FilesChanged    int   `json:"files_changed"`
+	FilesUnmodified int   `json:"files_unmodified"`
+	BytesAdded      int64 `json:"bytes_added"`
+	TotalFiles      int   `json:"total_files"`
+	TotalBytes      int64 `json:"total_bytes"`
+}
+
+// FromReport catalogs the snapshot of a backup run, tagged with tags
+func FromReport(r *backupreport.Report, hostname string, tags []string) Entry {
+	return Entry{
+		SnapshotID: r.SnapshotID,
+		Time:       r.FinishedAt,
+		Hostname:   hostname,
+		Paths:      slices.Clone(r.Paths),
+		Tags:       slices.Clone(tags),
+		Stats: &Stats{
+			FilesNew:        r.FilesNew,
+			FilesChanged:    r.FilesChanged,
+			FilesUnmodified: r.FilesUnmodified,
+			BytesAdded:      r.BytesAdded,
+			TotalFiles:      r.TotalFiles,
+			TotalBytes:      r.TotalBytes,
+		},
+	}
+}
+
+// Snapshot returns the entry as restic lists it, e.g. to resolve a
+// snapshot selector against the catalog
+func (e Entry) Snapshot() restic.Snapshot {
+	return restic.Snapshot{
+		ID:       e.SnapshotID,
+		Time:     e.Time,
+		Hostname: e.Hostname,
+		Paths:    e.Paths,
+		Tags:     e.Tags,
+	}
+}
+
+// Catalog is the encrypted file of cataloged snapshots, oldest first
+type Catalog struct {
+	path   string
+	secret []byte
+	mu     sync.Mutex
+}
+
+// New returns the catalog in the file at path, encrypted with password
+func New(path, password string) *Catalog {
+	return &Catalog{path: path, secret: []byte(password)}
+}
+
+// Add catalogs a snapshot, replacing any entry with its ID
+func (c *Catalog) Add(e Entry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := c.load()
+	if err != nil {
+		return err
+	}
+	entries = slices.DeleteFunc(entries, func(old Entry) bool { return old.SnapshotID == e.SnapshotID })
+	return c.save(append(entries, e))
+}
+
+// List returns every cataloged snapshot, oldest first
+func (c *Catalog) List() ([]Entry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.load()
+}
+
+// Find returns the snapshot with a full or abbreviated ID. An abbreviation
+// matching more than one snapshot is an error.
+func (c *Catalog) Find(snapshotID string) (*Entry, error) {
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+	var found *Entry
+	for i := range entries {
+		if snapshotID == "" || !strings.HasPrefix(entries[i].SnapshotID, snapshotID) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("snapshot ID %q is ambiguous in the local catalog", snapshotID)
+		}
+		found = &entries[i]
+	}
+	if found == nil {
+		return nil, ErrNotFound
+	}
+	return found, nil
+}
+
+// Resolve returns the cataloged snapshot a selector picks
+func (c *Catalog) Resolve(sel restic.SnapshotSelector) (*Entry, error) {
+	if sel.ID != "latest" {
+		return c.Find(sel.ID)
+	}
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make([]restic.Snapshot, len(entries))
+	for i, e := range entries {
+		snapshots[i] = e.Snapshot()
+	}
+	snap, err := sel.Resolve(snapshots)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(entries, func(e Entry) bool { return e.SnapshotID == snap.ID })
+	return &entries[i], nil
+}
+
+// Sync brings the catalog in line with the repository's snapshot list:
+// snapshots it didn't know are added without stats, and those no longer
+// in the repository (forgotten or pruned) are dropped. It returns how many
+// were added and removed.
+func (c *Catalog) Sync(snapshots []restic.Snapshot) (added, removed int, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := c.load()
+	if err != nil {
+		return 0, 0, err
+	}
+	known := make(map[string]Entry, len(entries))
+	for _, e := range entries {
+		known[e.SnapshotID] = e
+	}
+
+	synced := make([]Entry, 0, len(snapshots))
+	for _, s := range snapshots {
+		e, ok := known[s.ID]
+		if !ok {
+			added++
+			e = Entry{SnapshotID: s.ID, Time: s.Time, Hostname: s.Hostname, Paths: s.Paths, Tags: s.Tags}
+		}
+		delete(known, s.ID)
+		synced = append(synced, e)
+	}
+	removed = len(known)
+	if added == 0 && removed == 0 {
+		return 0, 0, nil
+	}
+	return added, removed, c.save(synced)
+}
+
+func (c *Catalog) load() ([]Entry, error) {
+	sealed, err := os.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot catalog: %w", err)
+	}
+	data, err := crypto.Open(c.secret, keyContext, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt snapshot catalog: %w", err)
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot catalog: %w", err)
+	}
+	return entries, nil
+}
+
+func (c *Catalog) save(entries []Entry) error {
+	slices.SortStableFunc(entries, func(a, b Entry) int { return a.Time.Compare(b.Time) })
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to serialize snapshot catalog: %w", err)
+	}
+	sealed, err := crypto.Seal(c.secret, keyContext, data)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt snapshot catalog: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(c.path, sealed, 0600); err != nil {
+		return fmt.Errorf("failed to save snapshot catalog: %w", err)
+	}
+	return nil
+}
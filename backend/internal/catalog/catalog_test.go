@@ -0,0 +1,99 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/backupreport"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+func testCatalog(t *testing.T) (*Catalog, string) {
+	path := filepath.Join(t.TempDir(), "catalog.enc")
+	return New(path, "repository password"), path
+}
+
+func TestAddList(t *testing.T) {
+	c, path := testCatalog(t)
+	entries, err := c.List()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	now := time.Now()
+	report := &backupreport.Report{SnapshotID: "bbbb2222", Paths: []string{"/home/alice/photos"}, FinishedAt: now, FilesNew: 3, BytesAdded: 1024}
+	require.NoError(t, c.Add(FromReport(report, "laptop", []string{"airgapper", "photos"})))
+	require.NoError(t, c.Add(Entry{SnapshotID: "aaaa1111", Time: now.Add(-time.Hour), Paths: []string{"/home/alice"}}))
+	// Cataloging a snapshot again replaces it
+	require.NoError(t, c.Add(FromReport(report, "laptop", []string{"airgapper", "photos"})))
+
+	entries, err = c.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "aaaa1111", entries[0].SnapshotID, "oldest first")
+	assert.Equal(t, "laptop", entries[1].Hostname)
+	assert.Equal(t, int64(1024), entries[1].Stats.BytesAdded)
+	assert.Nil(t, entries[0].Stats)
+
+	// The file is encrypted with the password
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "alice")
+	_, err = New(path, "wrong password").List()
+	assert.ErrorContains(t, err, "decrypt")
+}
+
+func TestFindResolve(t *testing.T) {
+	c, _ := testCatalog(t)
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 12, 0, 0, 0, time.UTC) }
+	require.NoError(t, c.Add(Entry{SnapshotID: "aaaa1111", Time: day(1), Tags: []string{"photos"}}))
+	require.NoError(t, c.Add(Entry{SnapshotID: "aaaa2222", Time: day(2)}))
+	require.NoError(t, c.Add(Entry{SnapshotID: "bbbb3333", Time: day(3)}))
+
+	e, err := c.Find("bbbb")
+	require.NoError(t, err)
+	assert.Equal(t, "bbbb3333", e.SnapshotID)
+	_, err = c.Find("aaaa")
+	assert.ErrorContains(t, err, "ambiguous")
+	_, err = c.Find("cccc")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	resolve := func(in string) string {
+		t.Helper()
+		sel, err := restic.ParseSnapshotSelector(in)
+		require.NoError(t, err)
+		e, err := c.Resolve(sel)
+		require.NoError(t, err)
+		return e.SnapshotID
+	}
+	assert.Equal(t, "bbbb3333", resolve("latest"))
+	assert.Equal(t, "aaaa1111", resolve("latest:tag=photos"))
+	assert.Equal(t, "aaaa2222", resolve("before:2024-05-03"))
+	assert.Equal(t, "aaaa2222", resolve("aaaa2"))
+}
+
+func TestSync(t *testing.T) {
+	c, _ := testCatalog(t)
+	now := time.Now()
+	require.NoError(t, c.Add(Entry{SnapshotID: "aaaa", Time: now.Add(-2 * time.Hour), Stats: &Stats{FilesNew: 1}}))
+	require.NoError(t, c.Add(Entry{SnapshotID: "bbbb", Time: now.Add(-time.Hour)}))
+
+	added, removed, err := c.Sync([]restic.Snapshot{
+		{ID: "aaaa", Time: now.Add(-2 * time.Hour)},
+		{ID: "cccc", Time: now, Hostname: "laptop", Paths: []string{"/srv"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+	assert.Equal(t, 1, removed)
+
+	entries, err := c.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, 1, entries[0].Stats.FilesNew, "stats recorded at backup time are kept")
+	assert.Equal(t, "cccc", entries[1].SnapshotID)
+	assert.Equal(t, []string{"/srv"}, entries[1].Paths)
+}
@@ -0,0 +1,281 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/archive"
+	"github.com/lcrostarosa/airgapper/backend/internal/backupreport"
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Export a snapshot to an encrypted archive on external media (requires approval)",
+	Long: `Write a snapshot's files to a single encrypted tarball, for a cold copy kept
+offline, such as on a USB drive in a safe deposit box.
+
+The archive is encrypted with a passphrase of its own (AES-256-GCM, with the
+key derived by PBKDF2), not the repository password, so it can be read back
+with nothing but the passphrase and 'airgapper archive decrypt', without the
+repository, your peer or this vault. Keep the passphrase apart from the
+media. Like a restore, exporting needs an approved restore request, and the
+snapshot archived is the request's; --snapshot checks it is the one meant.
+
+The archive is written beside --out and renamed into place once complete,
+then read back and checked, so a bad write to the media shows up at once.
+Check it again later with 'airgapper archive verify'.`,
+	Example: `  export ARCHIVE_PASSPHRASE='a long passphrase kept apart from the drive'
+  airgapper archive --request abc123 --out /media/usb/archive.tar.enc --archive-passphrase-env ARCHIVE_PASSPHRASE
+  airgapper archive --request abc123 --snapshot 3f9a1c2e --out /media/usb/archive.tar.enc --archive-passphrase-env ARCHIVE_PASSPHRASE
+  airgapper archive verify /media/usb/archive.tar.enc --archive-passphrase-env ARCHIVE_PASSPHRASE
+  airgapper archive decrypt /media/usb/archive.tar.enc --archive-passphrase-env ARCHIVE_PASSPHRASE | tar -x -C /restore`,
+	Args: cobra.NoArgs,
+	RunE: runners.Owner().Wrap(runArchive),
+}
+
+var archiveVerifyCmd = &cobra.Command{
+	Use:   "verify <archive>",
+	Short: "Check an archive decrypts and holds a complete tarball",
+	Long: `Decrypt an archive and read its tarball through to the end, checking every
+chunk and file, without writing anything. Needs no vault, so it runs on any
+machine with the archive and its passphrase.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runners.Uninitialized().Wrap(runArchiveVerify),
+}
+
+var archiveDecryptCmd = &cobra.Command{
+	Use:   "decrypt <archive>",
+	Short: "Decrypt an archive back into its tarball",
+	Long: `Decrypt an archive and write its tarball to --out, or to stdout to pipe it
+into tar. Needs no vault, so it runs on any machine with the archive and its
+passphrase. Decryption fails, rather than producing a partial tarball
+silently, if the archive was modified or cut short; check an archive with
+'airgapper archive verify' before relying on it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runners.Uninitialized().Wrap(runArchiveDecrypt),
+}
+
+func init() {
+	f := archiveCmd.Flags()
+	f.String("request", "", "Approved restore request ID (required)")
+	f.String("snapshot", "", "Snapshot expected, checked against the request's")
+	f.StringP("out", "o", "", "Archive file to write (required)")
+	f.String("archive-passphrase-env", "", "Environment variable holding the archive's passphrase (required)")
+	f.Bool("force", false, "Overwrite an existing archive")
+	addUnlockFlags(archiveCmd)
+	_ = archiveCmd.MarkFlagRequired("request")
+	_ = archiveCmd.MarkFlagRequired("out")
+	_ = archiveCmd.MarkFlagRequired("archive-passphrase-env")
+
+	for _, cmd := range []*cobra.Command{archiveVerifyCmd, archiveDecryptCmd} {
+		cmd.Flags().String("archive-passphrase-env", "", "Environment variable holding the archive's passphrase (required)")
+		_ = cmd.MarkFlagRequired("archive-passphrase-env")
+		archiveCmd.AddCommand(cmd)
+	}
+	archiveDecryptCmd.Flags().StringP("out", "o", "", "Tarball to write (default: stdout)")
+	rootCmd.AddCommand(archiveCmd)
+}
+
+func runArchive(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	requestID := flags.String("request")
+	snapshot := flags.String("snapshot")
+	out := flags.String("out")
+	passphraseEnv := flags.String("archive-passphrase-env")
+	force := flags.Bool("force")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	unlock, err := unlockFlags(cmd)
+	if err != nil {
+		return err
+	}
+	passphrase, err := passwordFromEnv(passphraseEnv)
+	if err != nil {
+		return err
+	}
+	if len(passphrase) < archive.MinPassphraseLength {
+		return fmt.Errorf("archive passphrase must be at least %d characters", archive.MinPassphraseLength)
+	}
+	if _, err := os.Stat(out); err == nil && !force {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", out)
+	}
+
+	req, err := ctx.Consent().GetRequest(requestID)
+	if err != nil {
+		return err
+	}
+	if req.IsKeyExport() || req.IsBrowse() {
+		return fmt.Errorf("request %s is not a restore request", requestID)
+	}
+	if req.Status != consent.StatusApproved {
+		return fmt.Errorf("request is not approved (status: %s)", req.Status)
+	}
+	if err := req.CheckExecutable(time.Now()); err != nil {
+		return err
+	}
+	if err := ctx.Consent().CheckLockdown(); err != nil {
+		return err
+	}
+	if !restic.IsInstalled() {
+		return fmt.Errorf("restic is not installed")
+	}
+
+	secret, err := recoverPassword(cmd.Context(), ctx.Config, req, unlock)
+	if err != nil {
+		return err
+	}
+	client := restic.NewClient(ctx.Config.RepoURL, string(secret)).WithIdentity(ctx.Config.PrivateKey).WithOptions(ctx.Config.Restic)
+	snapshotID, err := resolveSnapshot(cmd.Context(), client, req.SnapshotID)
+	if err != nil {
+		return err
+	}
+	if snapshot != "" && !strings.HasPrefix(snapshotID, snapshot) {
+		return fmt.Errorf("request %s was approved for snapshot %s, not %s", requestID, snapshotID, snapshot)
+	}
+
+	logging.Info("Writing archive", logging.String("snapshot", snapshotID), logging.String("out", out))
+	started := time.Now()
+	if err := writeArchive(cmd, client, snapshotID, ctx.Config.Name, out, passphrase); err != nil {
+		return err
+	}
+
+	f, err := os.Open(out)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	summary, err := archive.Verify(f, passphrase)
+	if err != nil {
+		return fmt.Errorf("archive written but failed to read back: %w", err)
+	}
+	logArchiveSummary(summary)
+	logging.Info("Archive complete", logging.String("out", out), logging.Duration("took", time.Since(started)))
+	logging.Warn("Anyone with the archive and its passphrase can read the snapshot without your peer's consent - keep them apart.")
+	return nil
+}
+
+// writeArchive streams snapshotID through restic dump into an archive at
+// out, written beside it and renamed into place once synced
+func writeArchive(cmd *cobra.Command, client *restic.Client, snapshotID, vault, out, passphrase string) error {
+	if err := os.MkdirAll(filepath.Dir(out), 0700); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	partial := out + ".partial"
+	f, err := os.OpenFile(partial, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(partial)
+	}()
+
+	w, err := archive.NewWriter(f, passphrase, archive.Header{Vault: vault, SnapshotID: snapshotID})
+	if err != nil {
+		return err
+	}
+	if err := client.Dump(cmd.Context(), snapshotID, w); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return os.Rename(partial, out)
+}
+
+func runArchiveVerify(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	passphrase, err := archivePassphrase(cmd)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	summary, err := archive.Verify(f, passphrase)
+	if err != nil {
+		return fmt.Errorf("archive failed verification: %w", err)
+	}
+	logArchiveSummary(summary)
+	logging.Info("Archive verified", logging.String("archive", args[0]))
+	return nil
+}
+
+func runArchiveDecrypt(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	out := flags.String("out")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	passphrase, err := archivePassphrase(cmd)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	r, err := archive.NewReader(f, passphrase)
+	if err != nil {
+		return err
+	}
+
+	var dst io.Writer = os.Stdout
+	if out != "" {
+		tarball, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tarball.Close() }()
+		dst = tarball
+	}
+	if _, err := io.Copy(dst, r); err != nil {
+		return fmt.Errorf("failed to decrypt archive: %w", err)
+	}
+	if out != "" {
+		logging.Info("Archive decrypted", logging.String("snapshot", r.Header().SnapshotID), logging.String("tarball", out))
+	}
+	return nil
+}
+
+// archivePassphrase reads the passphrase of the archive verify and decrypt
+// commands
+func archivePassphrase(cmd *cobra.Command) (string, error) {
+	flags := runner.Flags(cmd)
+	env := flags.String("archive-passphrase-env")
+	if err := flags.Err(); err != nil {
+		return "", err
+	}
+	return passwordFromEnv(env)
+}
+
+// logArchiveSummary shows what an archive holds
+func logArchiveSummary(s *archive.Summary) {
+	logging.Info("Archive",
+		logging.String("vault", s.Header.Vault),
+		logging.String("snapshot", s.Header.SnapshotID),
+		logging.String("created", s.Header.CreatedAt.Local().Format("2006-01-02 15:04")),
+		logging.Int("files", s.Files),
+		logging.Int("dirs", s.Dirs),
+		logging.String("size", backupreport.FormatBytes(s.Bytes)))
+}
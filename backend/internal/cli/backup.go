@@ -1,78 +1,338 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/lcrostarosa/airgapper/backend/internal/backupreport"
+	"github.com/lcrostarosa/airgapper/backend/internal/catalog"
 	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
 	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/repair"
 	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/scheduler"
+	"github.com/lcrostarosa/airgapper/backend/internal/sources"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
 )
 
 var backupCmd = &cobra.Command{
 	Use:   "backup [paths...]",
 	Short: "Create a backup (owner only)",
-	Long:  `Create a new backup of the specified paths to the restic repository.`,
+	Long: `Create a new backup of the specified paths to the restic repository.
+
+With --retry-last, re-run the most recent backup (scheduled or manual) if it
+failed, using the schedule's retry and backoff settings.
+
+Each path is checked before restic runs: a missing or unreadable path fails
+the backup, unless --continue-on-error (or the config's
+backup_continue_on_error) is set, in which case the readable paths are
+backed up and the backup is recorded as partial, naming the paths left out.
+
+Database sources (see 'airgapper source') are dumped first and backed up
+with the paths; --no-sources skips them.
+
+Snapshots are tagged "airgapper", host:<hostname> and os:<os>, plus the
+config's backup_tags and any --tag, so they can be picked out later with
+'airgapper snapshots --tag' or 'airgapper request --tag'.
+
+With 'airgapper schedule --backup-state', an encrypted export of this node's
+state is backed up too, and the snapshot tagged "airgapper-state" (see
+'airgapper self-restore').`,
 	Example: `  airgapper backup ~/Documents ~/Photos
-  airgapper backup /home/alice/important`,
-	Args: cobra.MinimumNArgs(1),
+  airgapper backup /home/alice/important
+  airgapper backup --tag documents ~/Documents
+  airgapper backup --continue-on-error ~/Documents ~/Pictures
+  airgapper backup --retry-last`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if retryLast, _ := cmd.Flags().GetBool("retry-last"); retryLast {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
 	RunE: runners.OwnerWithActivity().Use(runner.RequirePassword()).Wrap(runBackup),
 }
 
 func init() {
+	backupCmd.Flags().Bool("retry-last", false, "Retry the last backup if it failed")
+	backupCmd.Flags().Bool("no-sources", false, "Don't dump and back up database sources")
+	backupCmd.Flags().StringSlice("tag", nil, "Tag the snapshot (can specify multiple)")
+	backupCmd.Flags().Bool("continue-on-error", false, "Back up the readable paths when others are missing or unreadable")
 	rootCmd.AddCommand(backupCmd)
 }
 
 func runBackup(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	retryLast := flags.Bool("retry-last")
+	noSources := flags.Bool("no-sources")
+	tagFlags := flags.StringSlice("tag")
+	continueOnError := flags.Bool("continue-on-error")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	if !flags.Changed("continue-on-error") {
+		continueOnError = ctx.Config.BackupContinueOnError
+	}
+	if err := validateTags(tagFlags); err != nil {
+		return err
+	}
+	tags := restic.BackupTags(append(slices.Clone(ctx.Config.BackupTags), tagFlags...)...)
+
+	statePath := ctx.Config.BackupStatePath()
+	state, err := scheduler.LoadJobState(statePath)
+	if err != nil {
+		return err
+	}
+
+	paths := args
+	retry := scheduler.NoRetry()
+	if retryLast {
+		if state.LastAttempt.IsZero() {
+			return fmt.Errorf("no previous backup to retry")
+		}
+		if !state.Failed() {
+			logging.Info("Last backup succeeded, nothing to retry",
+				logging.String("at", state.LastAttempt.Format("2006-01-02 15:04:05")))
+			return nil
+		}
+		if len(state.Paths) == 0 {
+			return fmt.Errorf("the last backup recorded no paths")
+		}
+		paths = state.Paths
+		if retry, _, err = ctx.Config.ScheduleRetry(); err != nil {
+			return fmt.Errorf("invalid backup retry settings: %w", err)
+		}
+		logging.Info("Retrying last backup",
+			logging.String("failedAt", state.LastAttempt.Format("2006-01-02 15:04:05")),
+			logging.String("error", state.LastError))
+	}
+
 	logging.Info("Creating backup",
 		logging.String("repository", ctx.Config.RepoURL),
-		logging.String("paths", strings.Join(args, ", ")))
+		logging.String("paths", strings.Join(paths, ", ")))
 
 	if !restic.IsInstalled() {
 		return fmt.Errorf("restic is not installed")
 	}
 
-	client := restic.NewClient(ctx.Config.RepoURL, ctx.Config.Password)
-	if err := client.Backup(cmd.Context(), args, []string{"airgapper"}); err != nil {
+	started := time.Now()
+	ping := backupPinger(ctx.Config)
+	pingBackupStart(cmd.Context(), ping)
+	failBefore := func(err error) error {
+		state.Record(paths, 0, err, time.Now())
+		state.Manual = true
+		if saveErr := state.Save(statePath); saveErr != nil {
+			logging.Warn("Failed to save backup state", logging.Err(saveErr))
+		}
+		publishBackupFailed(ctx.Config, err, false)
+		pingBackupFailure(cmd.Context(), ping, started, err)
+		return fmt.Errorf("backup failed: %w", err)
+	}
+	backupPaths, unreadable, err := checkBackupPaths(paths, continueOnError)
+	if err != nil {
+		return failBefore(err)
+	}
+	if !noSources {
+		var dumps *sources.Dumps
+		if backupPaths, dumps, err = dumpSources(cmd.Context(), ctx.Config, backupPaths); err != nil {
+			return failBefore(err)
+		}
+		defer dumps.Cleanup()
+	}
+	backupPaths, tags = withStateExport(ctx.Config, backupPaths, tags)
+
+	client := restic.NewClient(ctx.Config.RepoURL, ctx.Config.Password).WithIdentity(ctx.Config.PrivateKey).WithOptions(ctx.Config.Restic).WithCompression(ctx.Config.BackupCompression)
+	repairs := repair.NewStore(ctx.Config.RepairsPath())
+	attempts := 0
+	var summary *restic.BackupSummary
+	err = retry.Do(cmd.Context(), func(attempt int) error {
+		attempts = attempt
+		s, err := repair.Backup(cmd.Context(), repairs, client, backupPaths, tags, ctx.Config.BackupExclude...)
+		summary = s
+		if errors.Is(err, restic.ErrIncomplete) && continueOnError {
+			logging.Warn("Some files couldn't be read and were left out of the snapshot", logging.Int("skipped", s.Skipped))
+			err = nil
+		}
+		return err
+	}, func(attempt int, err error, willRetry bool) {
+		if willRetry {
+			logging.Warn("Backup attempt failed, retrying",
+				logging.Int("attempt", attempt),
+				logging.Duration("in", retry.NextDelay(attempt)),
+				logging.Err(err))
+		}
+	})
+
+	state.Record(paths, attempts, err, time.Now())
+	state.Manual = true
+	if saveErr := state.Save(statePath); saveErr != nil {
+		logging.Warn("Failed to save backup state", logging.Err(saveErr))
+	}
+
+	if err != nil {
+		publishBackupFailed(ctx.Config, err, false)
+		pingBackupFailure(cmd.Context(), ping, started, err)
 		return fmt.Errorf("backup failed: %w", err)
 	}
 
-	logging.Info("Backup complete")
+	report := recordBackupReport(cmd.Context(), ctx.Config, client, summary, backupPaths, tags, unreadable, started, false)
+	pingBackupSuccess(cmd.Context(), ping, started, report.Text())
+	logging.Infof("Backup complete\n%s", report.Text())
 	return nil
 }
 
 var snapshotsCmd = &cobra.Command{
 	Use:   "snapshots",
 	Short: "List snapshots (requires password)",
-	Long:  `List all backup snapshots in the repository.`,
-	RunE:  runners.Config().Wrap(runSnapshots),
+	Long: `List all backup snapshots in the repository. With --tag, list only the
+snapshots carrying every given tag.
+
+Each backup is also recorded in a local encrypted catalog, which listing the
+repository brings up to date. --cached lists the catalog instead, without
+contacting the repository.
+
+On a backup host, which can't decrypt the repository, show what the host can
+see instead: stored size over time, the number of snapshots, when the last
+one was written and how long since the owner last connected.`,
+	Example: `  airgapper snapshots
+  airgapper snapshots --tag documents --tag host:laptop
+  airgapper snapshots --cached`,
+	RunE: runners.Config().Wrap(runSnapshots),
 }
 
 func init() {
+	snapshotsCmd.Flags().StringSlice("tag", nil, "Only list snapshots with this tag (can specify multiple)")
+	snapshotsCmd.Flags().Bool("cached", false, "List the local snapshot catalog without contacting the repository")
 	rootCmd.AddCommand(snapshotsCmd)
 }
 
+// validateTags checks tags given on the command line can be passed to restic
+func validateTags(tags []string) error {
+	for _, tag := range tags {
+		if err := restic.ValidateTag(tag); err != nil {
+			return fmt.Errorf("invalid --tag: %w", err)
+		}
+	}
+	return nil
+}
+
 func runSnapshots(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
 	if !ctx.Config.IsOwner() {
-		logging.Info("Snapshots", logging.String("repository", ctx.Config.RepoURL))
-		logging.Warn("As a backup host, you cannot list snapshots - the data is encrypted and you don't have the key")
-		return nil
+		return showHostVault(ctx)
 	}
 
+	flags := runner.Flags(cmd)
+	tags := flags.StringSlice("tag")
+	cached := flags.Bool("cached")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	if err := validateTags(tags); err != nil {
+		return err
+	}
 	if ctx.Config.Password == "" {
 		return fmt.Errorf("no password found")
 	}
+	cat := catalog.New(ctx.Config.CatalogPath(), ctx.Config.Password)
+	if cached {
+		return showCatalog(cat, tags)
+	}
 
 	logging.Info("Listing snapshots", logging.String("repository", ctx.Config.RepoURL))
 
-	client := restic.NewClient(ctx.Config.RepoURL, ctx.Config.Password)
-	output, err := client.Snapshots(cmd.Context())
+	client := restic.NewClient(ctx.Config.RepoURL, ctx.Config.Password).WithIdentity(ctx.Config.PrivateKey).WithOptions(ctx.Config.Restic)
+	output, err := client.Snapshots(cmd.Context(), tags...)
 	if err != nil {
 		return fmt.Errorf("failed to list snapshots: %w", err)
 	}
 
 	logging.Infof("Snapshots:\n%s", output)
+
+	snapshots, err := client.SnapshotList(cmd.Context())
+	if err == nil {
+		var added, removed int
+		if added, removed, err = cat.Sync(snapshots); err == nil && added+removed > 0 {
+			logging.Info("Updated the local snapshot catalog", logging.Int("added", added), logging.Int("removed", removed))
+		}
+	}
+	if err != nil {
+		logging.Warn("Failed to update the local snapshot catalog", logging.Err(err))
+	}
+	return nil
+}
+
+// showCatalog lists the cataloged snapshots carrying every one of tags
+func showCatalog(cat *catalog.Catalog, tags []string) error {
+	entries, err := cat.List()
+	if err != nil {
+		return err
+	}
+	entries = slices.DeleteFunc(entries, func(e catalog.Entry) bool { return !e.Snapshot().HasTags(tags) })
+	if len(entries) == 0 {
+		logging.Info("No snapshots in the local catalog")
+		return nil
+	}
+
+	logging.Info("Cataloged snapshots (may be out of date; run 'airgapper snapshots' to refresh)", logging.Int("count", len(entries)))
+	for _, e := range entries {
+		id := e.SnapshotID
+		if len(id) > 8 {
+			id = id[:8]
+		}
+		line := fmt.Sprintf("%s  %s  %s  %s", id, e.Time.Local().Format("2006-01-02 15:04:05"), e.Hostname, strings.Join(e.Paths, ", "))
+		if len(e.Tags) > 0 {
+			line += "  [" + strings.Join(e.Tags, ", ") + "]"
+		}
+		if e.Stats != nil {
+			line += fmt.Sprintf("  %d files, %s added", e.Stats.TotalFiles, backupreport.FormatBytes(e.Stats.BytesAdded))
+		}
+		logging.Infof("  %s", line)
+	}
+	return nil
+}
+
+// hostGrowthDays is how many daily size samples showHostVault lists
+const hostGrowthDays = 14
+
+// showHostVault shows a host the vault metadata it can see without the key
+func showHostVault(ctx *runner.CommandContext) error {
+	if ctx.Config.StoragePath == "" {
+		logging.Warn("As a backup host, you cannot list snapshots - the data is encrypted and you don't have the key")
+		return nil
+	}
+
+	srv, err := storage.NewServer(storage.Config{BasePath: ctx.Config.StoragePath})
+	if err != nil {
+		return err
+	}
+	meta := srv.VaultMetadata()
+
+	logging.Info("Vault (encrypted - snapshot contents are not visible to hosts)",
+		logging.String("path", ctx.Config.StoragePath),
+		logging.Int("snapshots", meta.Snapshots),
+		logging.Int64("usedBytes", meta.UsedBytes))
+	if meta.LastSnapshot.IsZero() {
+		logging.Info("No snapshots written yet")
+	} else {
+		logging.Infof("Last snapshot: %s", meta.LastSnapshot.Local().Format("2006-01-02 15:04"))
+	}
+	if meta.DaysSinceContact < 0 {
+		logging.Info("The owner has not connected yet")
+	} else {
+		logging.Infof("Last owner contact: %s (%d days ago)", meta.LastOwnerContact.Local().Format("2006-01-02 15:04"), meta.DaysSinceContact)
+	}
+
+	growth := meta.Growth
+	if len(growth) > hostGrowthDays {
+		growth = growth[len(growth)-hostGrowthDays:]
+	}
+	logging.Info("Size history:")
+	for _, sample := range growth {
+		logging.Infof("  %s  %d bytes  %d snapshots", sample.Date, sample.UsedBytes, sample.Snapshots)
+	}
 	return nil
 }
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"context"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/heartbeat"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+// backupPinger returns a pinger for the configured backup ping URL, or nil
+// if there is none
+func backupPinger(cfg *config.Config) *heartbeat.Pinger {
+	if cfg.BackupPingURL == "" {
+		return nil
+	}
+	p, err := heartbeat.Parse(cfg.BackupPingURL)
+	if err != nil {
+		logging.Warn("Backup ping disabled", logging.Err(err))
+		return nil
+	}
+	return p
+}
+
+// Pings are best effort: a monitor that can't be reached must not fail the
+// backup, and will alert on the missing ping anyway
+
+func pingBackupStart(ctx context.Context, p *heartbeat.Pinger) {
+	if p == nil {
+		return
+	}
+	if err := p.Start(ctx); err != nil {
+		logging.Warn("Failed to ping backup monitor", logging.Err(err))
+	}
+}
+
+func pingBackupSuccess(ctx context.Context, p *heartbeat.Pinger, started time.Time, summary string) {
+	if p == nil {
+		return
+	}
+	if err := p.Success(ctx, time.Since(started), summary); err != nil {
+		logging.Warn("Failed to ping backup monitor", logging.Err(err))
+	}
+}
+
+func pingBackupFailure(ctx context.Context, p *heartbeat.Pinger, started time.Time, cause error) {
+	if p == nil {
+		return
+	}
+	if err := p.Failure(ctx, time.Since(started), cause); err != nil {
+		logging.Warn("Failed to ping backup monitor", logging.Err(err))
+	}
+}
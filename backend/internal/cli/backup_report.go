@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/backupreport"
+	"github.com/lcrostarosa/airgapper/backend/internal/catalog"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/emergency"
+	"github.com/lcrostarosa/airgapper/backend/internal/events"
+	"github.com/lcrostarosa/airgapper/backend/internal/integrity"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+// recordBackupReport builds the report of a successful backup, notes the
+// paths left out of it, adds the diff against the previous snapshot if
+// configured, saves it with the backup history and dedup stats, catalogs the
+// snapshot with its tags, records the canary files' hashes, publishes it to
+// the activity feed and sends it to the backup_completed notification
+func recordBackupReport(ctx context.Context, cfg *config.Config, repo restic.Runner, summary *restic.BackupSummary, paths, tags []string, unreadable []restic.PathError, started time.Time, scheduled bool) *backupreport.Report {
+	report := backupreport.New(summary, paths, started, time.Now(), scheduled)
+	report.AddFailedPaths(unreadable)
+	if cfg.BackupReportDiff {
+		if err := report.AddDiff(ctx, repo); err != nil {
+			logging.Warn("Failed to diff new snapshot", logging.Err(err))
+		}
+	}
+
+	if err := backupreport.NewStore(cfg.BackupReportsPath()).Add(report); err != nil {
+		logging.Warn("Failed to save backup report", logging.Err(err))
+	}
+	if err := backupreport.NewDedupStore(cfg.DedupStatsPath()).Add(backupreport.PointFromReport(report)); err != nil {
+		logging.Warn("Failed to record dedup stats", logging.Err(err))
+	}
+	catalogSnapshot(cfg, report, tags)
+	recordCanaries(cfg, report.SnapshotID)
+	message := fmt.Sprintf("Backup finished: %d new and %d changed files, %s added", report.FilesNew, report.FilesChanged, backupreport.FormatBytes(report.BytesAdded))
+	if len(report.FailedPaths) > 0 {
+		message = fmt.Sprintf("Backup partly finished: %d path(s) not backed up; %d new and %d changed files, %s added",
+			len(report.FailedPaths), report.FilesNew, report.FilesChanged, backupreport.FormatBytes(report.BytesAdded))
+	}
+	events.Open(cfg.ConfigDir).Publish(events.Event{
+		Type:    events.BackupFinished,
+		Source:  events.SourceBackup,
+		Subject: report.SnapshotID,
+		Message: message,
+		Data: map[string]string{
+			"scheduled": strconv.FormatBool(scheduled),
+			"paths":     strings.Join(paths, ","),
+			"partial":   strconv.FormatBool(report.Partial),
+		},
+	})
+
+	notify := cfg.Emergency.GetNotify()
+	if notify.IsEnabled() && notify.Events.BackupCompleted {
+		failed := notify.Send(ctx, emergency.Message{
+			Event: "backup_completed",
+			Title: "Airgapper backup complete on " + cfg.Name,
+			Body:  report.Text(),
+		})
+		for id, err := range failed {
+			logging.Warn("Failed to send notification", logging.String("provider", id), logging.Err(err))
+		}
+	}
+	return report
+}
+
+// checkBackupPaths checks each backup path can be read before restic runs.
+// A missing or unreadable path fails the backup unless continueOnError is
+// set, in which case the readable paths are backed up without it; the backup
+// fails either way when no path is readable.
+func checkBackupPaths(paths []string, continueOnError bool) ([]string, []restic.PathError, error) {
+	readable, failed := restic.CheckPaths(paths)
+	for _, f := range failed {
+		logging.Warn("Backup path not readable", logging.String("path", f.Path), logging.String("error", f.Error))
+	}
+	switch {
+	case len(failed) == 0:
+		return readable, nil, nil
+	case len(readable) == 0:
+		return nil, failed, fmt.Errorf("none of the backup paths can be read")
+	case !continueOnError:
+		return nil, failed, fmt.Errorf("%d backup path(s) can't be read (%s: %s); use --continue-on-error to back up the rest",
+			len(failed), failed[0].Path, failed[0].Error)
+	}
+	return readable, failed, nil
+}
+
+// publishBackupFailed records a failed backup run in the activity feed
+func publishBackupFailed(cfg *config.Config, err error, scheduled bool) {
+	events.Open(cfg.ConfigDir).Publish(events.Event{
+		Type:    events.BackupFailed,
+		Source:  events.SourceBackup,
+		Message: "Backup failed: " + err.Error(),
+		Data:    map[string]string{"scheduled": strconv.FormatBool(scheduled)},
+	})
+}
+
+// publishBackupInterrupted records a scheduled backup a shutdown interrupted
+// in the activity feed
+func publishBackupInterrupted(cfg *config.Config) {
+	events.Open(cfg.ConfigDir).Publish(events.Event{
+		Type:    events.BackupInterrupted,
+		Source:  events.SourceBackup,
+		Message: "Scheduled backup interrupted by shutdown; it runs again on the next start",
+		Data:    map[string]string{"scheduled": "true"},
+	})
+}
+
+// recordCanaries records the configured canary files' hashes against a new
+// snapshot, for restore tests to check
+func recordCanaries(cfg *config.Config, snapshotID string) {
+	if cfg.RestoreTest == nil || len(cfg.RestoreTest.CanaryFiles) == 0 {
+		return
+	}
+	failed, err := integrity.NewCanaryStore(cfg.ConfigDir).Record(snapshotID, cfg.RestoreTest.CanaryFiles, time.Now())
+	if err != nil {
+		logging.Warn("Failed to record canary files", logging.Err(err))
+	}
+	for path, err := range failed {
+		logging.Warn("Failed to hash canary file", logging.String("path", path), logging.Err(err))
+	}
+}
+
+// catalogSnapshot records a backed up snapshot in the local catalog
+func catalogSnapshot(cfg *config.Config, report *backupreport.Report, tags []string) {
+	hostname, _ := os.Hostname()
+	entry := catalog.FromReport(report, hostname, tags)
+	if err := catalog.New(cfg.CatalogPath(), cfg.Password).Add(entry); err != nil {
+		logging.Warn("Failed to catalog snapshot", logging.Err(err))
+	}
+}
@@ -0,0 +1,20 @@
+package cli
+
+import (
+	"context"
+	"slices"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/sources"
+)
+
+// dumpSources dumps the configured database sources and returns the paths
+// to back up: the job's paths followed by the dumps. The caller must call
+// Cleanup on the returned dumps once the backup has finished.
+func dumpSources(ctx context.Context, cfg *config.Config, paths []string) ([]string, *sources.Dumps, error) {
+	dumps, err := sources.DumpAll(ctx, cfg.BackupSources, cfg.SourceDumpDir())
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(slices.Clone(paths), dumps.Paths...), dumps, nil
+}
@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"slices"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/statebackup"
+)
+
+// withStateExport writes the encrypted state export when backup_state is
+// on, and returns the paths and tags to back up with it. A failed export
+// is only logged: it shouldn't cost the backup of the data itself.
+func withStateExport(cfg *config.Config, paths, tags []string) ([]string, []string) {
+	if !cfg.BackupState {
+		return paths, tags
+	}
+	path, err := statebackup.Write(cfg)
+	if err != nil {
+		logging.Warn("Failed to export Airgapper state; backing up without it", logging.Err(err))
+		return paths, tags
+	}
+	return append(slices.Clone(paths), path), append(slices.Clone(tags), restic.TagState)
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/backupreport"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/volumes"
+)
+
+// backupVolumes backs up each selected Docker volume as its own snapshot
+// and records a report for each. It returns an error if any volume failed.
+func backupVolumes(ctx context.Context, cfg *config.Config, repo restic.Runner, scheduled bool) error {
+	vc := cfg.DockerVolumes
+	engine := volumes.NewClient(vc.GetSocket())
+	vols, err := volumes.Select(ctx, engine, vc)
+	if err != nil {
+		return fmt.Errorf("failed to list volumes: %w", err)
+	}
+	if len(vols) == 0 {
+		logging.Warn("No Docker volumes selected for backup")
+		return nil
+	}
+
+	tags := []string{"volume"}
+	if scheduled {
+		tags = append(tags, restic.TagScheduled)
+	}
+	tags = restic.BackupTags(append(tags, vc.Tags...)...)
+
+	store := backupreport.NewStore(cfg.BackupReportsPath())
+	started := time.Now()
+	var errs []error
+	for _, r := range volumes.Backup(ctx, repo, engine, vc, vols, tags) {
+		if r.Err != nil {
+			logging.Error("Volume backup failed", logging.String("volume", r.Volume.Name), logging.Err(r.Err))
+			errs = append(errs, r.Err)
+			continue
+		}
+		report := backupreport.New(r.Summary, r.Paths, started, time.Now(), scheduled)
+		if err := store.Add(report); err != nil {
+			logging.Warn("Failed to save backup report", logging.Err(err))
+		}
+		catalogSnapshot(cfg, report, append(slices.Clone(tags), volumes.TagPrefix+r.Volume.Name))
+		logging.Info("Backed up volume",
+			logging.String("volume", r.Volume.Name),
+			logging.String("snapshot", r.Summary.SnapshotID),
+			logging.String("added", backupreport.FormatBytes(r.Summary.DataAdded)))
+	}
+	return errors.Join(errs...)
+}
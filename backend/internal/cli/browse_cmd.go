@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+var browseCmd = &cobra.Command{
+	Use:   "browse",
+	Short: "List a snapshot's contents (requires browse approval)",
+	Long: `List the files in a snapshot after your peer approved a browse request.
+
+A browse request ('airgapper request --browse') is lighter-weight than a
+restore: it only allows listing file metadata (names, sizes, times), expires
+sooner while pending, and once approved can only be used for a short window.
+Use it to confirm the data you need exists before asking for a full restore.`,
+	Example: `  airgapper browse --request abc123
+  airgapper browse --request abc123 --path /home/alice/taxes`,
+	RunE: runners.Owner().Wrap(runBrowse),
+}
+
+func init() {
+	f := browseCmd.Flags()
+	f.String("request", "", "Browse request ID (required)")
+	f.String("path", "", "Only list entries under this path")
+	addUnlockFlags(browseCmd)
+	_ = browseCmd.MarkFlagRequired("request")
+	rootCmd.AddCommand(browseCmd)
+}
+
+func runBrowse(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	requestID := flags.String("request")
+	prefix := flags.String("path")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	unlock, err := unlockFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	req, err := ctx.Consent().BrowseAccess(requestID)
+	if err != nil {
+		return fmt.Errorf("request %s can't be used to browse: %w", requestID, err)
+	}
+
+	secret, err := recoverPassword(cmd.Context(), ctx.Config, req, unlock)
+	if err != nil {
+		return err
+	}
+	password := string(secret)
+
+	client := restic.NewClient(ctx.Config.RepoURL, password).WithIdentity(ctx.Config.PrivateKey).WithOptions(ctx.Config.Restic)
+	snapshotID, err := resolveSnapshot(cmd.Context(), client, req.SnapshotID)
+	if err != nil {
+		return err
+	}
+	nodes, err := client.ListFiles(cmd.Context(), snapshotID)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	for _, node := range nodes {
+		if prefix != "" && !strings.HasPrefix(node.Path, prefix) {
+			continue
+		}
+		count++
+		logging.Info(node.Path,
+			logging.String("type", node.Type),
+			logging.Int64("size", node.Size),
+			logging.String("modified", node.ModTime.Format("2006-01-02 15:04")))
+	}
+
+	logging.Info("Browse complete",
+		logging.String("snapshot", snapshotID),
+		logging.Int("entries", count),
+		logging.String("windowCloses", req.ExpiresAt.Format("2006-01-02 15:04")))
+	logging.Infof("To restore, request approval with: airgapper request --snapshot %s --reason ...", snapshotID)
+	return nil
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion bash|zsh|fish",
+	Short: "Generate shell completion scripts",
+	Long: `Print a completion script for your shell. Besides commands and flags,
+approve and deny complete the IDs of pending requests.`,
+	Example: `  # Bash (current shell, or install for all sessions)
+  source <(airgapper completion bash)
+  airgapper completion bash > /etc/bash_completion.d/airgapper
+
+  # Zsh
+  airgapper completion zsh > "${fpath[1]}/_airgapper"
+
+  # Fish
+  airgapper completion fish > ~/.config/fish/completions/airgapper.fish`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	// Completion scripts are written to stdout, not through logging
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		switch args[0] {
+		case "bash":
+			return rootCmd.GenBashCompletionV2(out, true)
+		case "zsh":
+			return rootCmd.GenZshCompletion(out)
+		case "fish":
+			return rootCmd.GenFishCompletion(out, true)
+		default:
+			return fmt.Errorf("unsupported shell %q (use bash, zsh or fish)", args[0])
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+
+	approveCmd.ValidArgsFunction = completePendingRequests
+	denyCmd.ValidArgsFunction = completePendingRequests
+}
+
+// completePendingRequests completes the IDs of pending restore requests,
+// described by their requester and reason
+func completePendingRequests(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	mgr := runner.NewContext(cfg, cfgErr).Consent()
+	if len(args) > 0 || mgr == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	pending, err := mgr.ListPending()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var ids []string
+	for _, req := range pending {
+		if strings.HasPrefix(req.ID, toComplete) {
+			ids = append(ids, req.ID+"\t"+req.Requester+": "+req.Reason)
+		}
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
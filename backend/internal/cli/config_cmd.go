@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration",
+	Long: `Inspect the configuration.
+
+Deployment settings can be overridden without editing config.json, which is
+handy in containers. Each layer overrides the one before it:
+
+  1. config.json
+  2. AIRGAPPER_<KEY> environment variables, e.g. AIRGAPPER_LISTEN_ADDR
+  3. --set key=value flags, e.g. --set storage_path=/data
+
+Overrides apply to one run and are never saved to config.json. Lists such as
+backup_paths are given comma-separated.`,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the overridable settings",
+	Long: `Show the overridable settings as config.json has them, or with
+--resolved, the values in force and where each comes from.`,
+	Example: `  airgapper config show
+  AIRGAPPER_STORAGE_PATH=/data airgapper config show --resolved
+  airgapper --set listen_addr=0.0.0.0:8081 config show --resolved`,
+	RunE: runners.Config().Wrap(runConfigShow),
+}
+
+func init() {
+	configShowCmd.Flags().Bool("resolved", false, "Show effective values, with overrides applied, and their sources")
+
+	configCmd.AddCommand(configShowCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+func runConfigShow(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	resolved := flags.Bool("resolved")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	logging.Info("Configuration",
+		logging.String("file", filepath.Join(ctx.Config.ConfigDir, "config.json")),
+		logging.Int("version", ctx.Config.Version),
+		logging.String("name", ctx.Config.Name),
+		logging.String("role", string(ctx.Config.Role)))
+
+	for _, s := range ctx.Config.Resolved() {
+		if !resolved {
+			if s.File != "" {
+				logging.Info("Setting", logging.String("key", s.Key), logging.String("value", s.File))
+			}
+			continue
+		}
+		switch s.Source {
+		case config.SourceEnv, config.SourceFlag:
+			// What the override replaced
+			logging.Info("Setting",
+				logging.String("key", s.Key),
+				logging.String("value", s.Value),
+				logging.String("source", string(s.Source)),
+				logging.String("file", s.File))
+		case config.SourceDefault:
+			// How to set it
+			logging.Info("Setting",
+				logging.String("key", s.Key),
+				logging.String("source", string(s.Source)),
+				logging.String("env", s.Env))
+		default:
+			logging.Info("Setting",
+				logging.String("key", s.Key),
+				logging.String("value", s.Value),
+				logging.String("source", string(s.Source)))
+		}
+	}
+	return nil
+}
@@ -1,36 +1,116 @@
 package cli
 
 import (
-	"bytes"
+	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/lcrostarosa/airgapper/backend/gen/airgapper/v1/airgapperv1connect"
+	"github.com/lcrostarosa/airgapper/backend/internal/api"
+	"github.com/lcrostarosa/airgapper/backend/internal/catalog"
 	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
 	"github.com/lcrostarosa/airgapper/backend/internal/consent"
 	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/genesis"
 	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/peerqueue"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/service"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
 )
 
 // --- Request Command ---
 
+// describeSnapshot returns a request's snapshot with what a selector picks
+// spelled out, so approvers know what they're approving
+func describeSnapshot(snapshotID string) string {
+	sel, err := restic.ParseSnapshotSelector(snapshotID)
+	if err != nil || !sel.Filtered() {
+		return snapshotID
+	}
+	return fmt.Sprintf("%s (%s)", snapshotID, sel.Describe())
+}
+
+// resolveCached resolves a snapshot selector to a concrete ID from the
+// local snapshot catalog, without contacting the repository
+func resolveCached(ctx *runner.CommandContext, snapshotID string) (string, error) {
+	sel, err := restic.ParseSnapshotSelector(snapshotID)
+	if err != nil {
+		return "", err
+	}
+	entry, err := catalog.New(ctx.Config.CatalogPath(), ctx.Config.Password).Resolve(sel)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %q from the local catalog: %w", snapshotID, err)
+	}
+	logging.Info("Resolved snapshot from the local catalog",
+		logging.String("selector", describeSnapshot(snapshotID)),
+		logging.String("snapshot", entry.SnapshotID),
+		logging.String("taken", entry.Time.Local().Format("2006-01-02 15:04:05")))
+	return entry.SnapshotID, nil
+}
+
+// requestSnapshot returns the selector a request is made for: --snapshot,
+// narrowed to snapshots carrying every --tag
+func requestSnapshot(snapshotID string, tags []string) (string, error) {
+	sel, err := restic.ParseSnapshotSelector(snapshotID)
+	if err != nil {
+		return "", err
+	}
+	if len(tags) == 0 {
+		return snapshotID, nil
+	}
+	if err := validateTags(tags); err != nil {
+		return "", err
+	}
+	if sel.ID != "latest" {
+		return "", fmt.Errorf("--tag can't narrow snapshot %s", sel.ID)
+	}
+	sel.Tags = append(sel.Tags, tags...)
+	return sel.String(), nil
+}
+
 var requestCmd = &cobra.Command{
 	Use:   "request",
 	Short: "Request restore approval from peer(s)",
 	Long:  `Create a new restore request that must be approved by your peer(s).`,
 	Example: `  airgapper request --snapshot latest --reason "Need to recover deleted files"
-  airgapper request --snapshot abc123 --reason "Testing restore" --peer http://bob:8081`,
+  airgapper request --snapshot abc123 --reason "Testing restore" --peer http://bob:8081
+  airgapper request --snapshot abc123 --browse --reason "Check the tax folder is there"
+  airgapper request --snapshot latest --ttl 72h --reason "Bob is travelling this week"
+  airgapper request --tag documents --reason "Restore the latest documents backup"
+  airgapper request --snapshot "host=laptop before:2024-06-01" --reason "Before the upgrade"
+  airgapper request --snapshot latest --cached --reason "Pin today's latest snapshot"
+  airgapper request --path /home/alice/taxes --private-paths --reason "Restore the tax folder"
+  airgapper request --template monthly-verify --reason "March"`,
 	RunE: runners.Owner().Wrap(runRequest),
 }
 
 func init() {
 	f := requestCmd.Flags()
-	f.String("snapshot", "latest", "Snapshot ID to restore")
-	f.String("reason", "", "Reason for restore (required)")
+	f.String("snapshot", "latest", "Snapshot ID or selector to restore, e.g. latest:tag=photos or \"host=laptop before:2024-06-01\"")
+	f.StringSlice("tag", nil, "Restore the latest snapshot with this tag (can specify multiple)")
+	f.StringSlice("path", nil, "Path to restore (can specify multiple; default: everything)")
+	f.Bool("cached", false, "Resolve the snapshot to a concrete ID from the local snapshot catalog")
+	f.String("reason", "", "Reason for restore (required unless --template is used)")
 	f.String("peer", "", "Peer address to notify")
-	_ = requestCmd.MarkFlagRequired("reason")
+	f.Bool("export-keys", false, "Request approval to export the repository password (see export-keys)")
+	f.Bool("browse", false, "Only request approval to list the snapshot's contents (see browse)")
+	f.String("template", "", "Create the request from a saved template (see template); --reason is appended")
+	f.Bool("private-paths", false, "Send the peer sealed paths it can't read (default: private_request_paths in the config)")
+	f.String("ttl", "", "How long the request stays pending, e.g. 72h (default: 24h, at most max_request_ttl)")
+	requestCmd.MarkFlagsMutuallyExclusive("export-keys", "browse", "template")
+	requestCmd.MarkFlagsMutuallyExclusive("browse", "ttl")
+	requestCmd.MarkFlagsMutuallyExclusive("snapshot", "template")
+	requestCmd.MarkFlagsMutuallyExclusive("tag", "template", "export-keys")
+	requestCmd.MarkFlagsMutuallyExclusive("cached", "template", "export-keys")
+	requestCmd.MarkFlagsMutuallyExclusive("path", "template", "export-keys", "browse")
 	rootCmd.AddCommand(requestCmd)
 }
 
@@ -39,18 +119,63 @@ func runRequest(ctx *runner.CommandContext, cmd *cobra.Command, args []string) e
 	snapshotID := flags.String("snapshot")
 	reason := flags.String("reason")
 	peerAddr := flags.String("peer")
+	exportKeys := flags.Bool("export-keys")
+	browse := flags.Bool("browse")
+	template := flags.String("template")
+	privatePaths := flags.Bool("private-paths") || ctx.Config.PrivateRequestPaths
+	ttlStr := flags.Duration("ttl")
+	tags := flags.StringSlice("tag")
+	paths := flags.StringSlice("path")
+	cached := flags.Bool("cached")
 	if err := flags.Err(); err != nil {
 		return err
 	}
+	if reason == "" && template == "" {
+		return fmt.Errorf("--reason is required")
+	}
+	snapshotID, err := requestSnapshot(snapshotID, tags)
+	if err != nil {
+		return err
+	}
+	if cached {
+		if snapshotID, err = resolveCached(ctx, snapshotID); err != nil {
+			return err
+		}
+	}
+
+	// Sign the request as its requester so peers can tell it wasn't forged,
+	// with this machine's details for approvers to check
+	mgr := ctx.Consent().WithRequesterContext(consent.LocalRequesterContext(Version, ctx.Config.PublicKey))
+	if ctx.Config.PrivateKey != nil {
+		mgr = mgr.WithSigningKey(crypto.KeyID(ctx.Config.PublicKey), ctx.Config.PrivateKey)
+	}
+	var ttl time.Duration
+	if ttlStr != "" {
+		if ttl, err = parseRequestTTL(ctx, ttlStr); err != nil {
+			return err
+		}
+		mgr = mgr.WithTTL(ttl)
+	}
 
-	req, err := ctx.Consent().CreateRequest(ctx.Config.Name, snapshotID, reason, nil)
+	var req *consent.RestoreRequest
+	switch {
+	case template != "":
+		req, err = mgr.CreateRequestFromTemplate(ctx.Config.Name, template, reason)
+	case exportKeys:
+		req, err = mgr.CreateKeyExportRequest(ctx.Config.Name, reason)
+	case browse:
+		req, err = mgr.CreateBrowseRequest(ctx.Config.Name, snapshotID, reason)
+	default:
+		req, err = mgr.CreateRequest(ctx.Config.Name, snapshotID, reason, paths)
+	}
 	if err != nil {
 		return err
 	}
 
 	logging.Info("Restore request created",
 		logging.String("requestID", req.ID),
-		logging.String("snapshot", req.SnapshotID),
+		logging.String(tracing.LogKey, req.CorrelationID),
+		logging.String("snapshot", describeSnapshot(req.SnapshotID)),
 		logging.String("reason", req.Reason),
 		logging.String("expires", req.ExpiresAt.Format("2006-01-02 15:04:05")))
 
@@ -60,39 +185,82 @@ func runRequest(ctx *runner.CommandContext, cmd *cobra.Command, args []string) e
 	}
 
 	if peerAddr != "" {
-		notifyPeer(peerAddr, req)
+		peerReq := req
+		if privatePaths && len(req.Paths) > 0 {
+			if peerReq, err = req.SealPaths(ctx.Config.Password, ctx.Config.PrivateKey); err != nil {
+				return err
+			}
+		}
+		notifyPeer(cmd.Context(), ctx.Config, peerAddr, peerReq, ttl)
 	}
 
 	logging.Info("Waiting for peer approval...")
 	logging.Infof("Share request ID with your peer: %s", req.ID)
-	logging.Infof("Once approved, run: airgapper restore --request %s --target /restore/path", req.ID)
+	switch {
+	case req.IsKeyExport():
+		logging.Infof("Once approved, run: airgapper export-keys --request %s", req.ID)
+	case req.IsBrowse():
+		logging.Infof("Once approved, run: airgapper browse --request %s (within %s)", req.ID, consent.BrowseWindow)
+	default:
+		logging.Infof("Once approved, run: airgapper restore --request %s --target /restore/path", req.ID)
+	}
 
 	return nil
 }
 
-func notifyPeer(peerAddr string, req *consent.RestoreRequest) {
-	logging.Info("Notifying peer", logging.String("address", peerAddr))
+// parseRequestTTL parses a TTL or extension such as "72h", which may be
+// at most the configured max_request_ttl
+func parseRequestTTL(ctx *runner.CommandContext, value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q, use e.g. 72h", value)
+	}
+	limit, err := ctx.Config.RequestTTLLimit()
+	if err != nil {
+		return 0, err
+	}
+	if d > limit {
+		return 0, fmt.Errorf("%s exceeds the limit of %s (max_request_ttl in the config)", d, limit)
+	}
+	return d, nil
+}
+
+func notifyPeer(ctx context.Context, cfg *config.Config, peerAddr string, req *consent.RestoreRequest, ttl time.Duration) {
+	logging.Info("Notifying peer", logging.String("address", peerAddr), logging.String(tracing.LogKey, req.CorrelationID))
 
 	reqBody := map[string]interface{}{
 		"id":          req.ID,
 		"requester":   req.Requester,
 		"snapshot_id": req.SnapshotID,
 		"reason":      req.Reason,
+		"purpose":     req.Purpose,
+		"paths":       req.Paths,
+		"template":    req.Template,
+		"created_at":  req.CreatedAt.Format(time.RFC3339),
 	}
-	jsonBody, _ := json.Marshal(reqBody)
-
-	resp, err := http.Post(peerAddr+"/api/requests", "application/json", bytes.NewReader(jsonBody))
-	if err != nil {
-		logging.Warn("Could not notify peer - share the request ID manually", logging.Err(err))
-		return
+	if ttl != 0 {
+		reqBody["ttl"] = ttl.String()
 	}
-	_ = resp.Body.Close()
-
-	if resp.StatusCode == http.StatusCreated || resp.StatusCode == http.StatusOK {
-		logging.Info("Peer notified successfully")
-	} else {
-		logging.Warn("Peer returned unexpected status", logging.Int("status", resp.StatusCode))
+	if req.RequesterSignature != nil {
+		reqBody["key_holder_id"] = req.RequesterKeyID
+		reqBody["signature"] = hex.EncodeToString(req.RequesterSignature)
+	}
+	if req.RequesterContext != nil {
+		reqBody["requester_context"] = req.RequesterContext
 	}
+	if req.SizeBytes != 0 {
+		reqBody["size_bytes"] = req.SizeBytes
+	}
+	jsonBody, _ := json.Marshal(reqBody)
+
+	sendToPeer(ctx, cfg, &peerqueue.Message{
+		Kind:          peerqueue.KindRequest,
+		Peer:          peerAddr,
+		Path:          requestServicePath("CreateRequest"),
+		Body:          jsonBody,
+		RequestID:     req.ID,
+		CorrelationID: req.CorrelationID,
+	})
 }
 
 // --- Pending Command ---
@@ -124,28 +292,93 @@ func runPending(ctx *runner.CommandContext, cmd *cobra.Command, args []string) e
 		logging.Info("Request",
 			logging.String("id", req.ID),
 			logging.String("from", req.Requester),
-			logging.String("snapshot", req.SnapshotID),
+			logging.String("snapshot", describeSnapshot(req.SnapshotID)),
+			logging.String("purpose", requestPurpose(req)),
 			logging.String("reason", req.Reason),
 			logging.String("expires", req.ExpiresAt.Format("2006-01-02 15:04")))
+		if req.Template != "" {
+			logging.Info("Created from template", logging.String("id", req.ID), logging.String("template", req.Template))
+		}
+		logRequesterContext(req)
+		logRestoreTerms(req)
+		if left := req.CoolingOffLeft(time.Now()); left > 0 {
+			logging.Info("Approved, cooling off",
+				logging.String("id", req.ID),
+				logging.String("executable", req.ExecutableAt.Format("2006-01-02 15:04")),
+				logging.String("left", left.Round(time.Minute).String()))
+		}
+		if ext := req.PendingExtension(); ext != nil {
+			logging.Info("Extension awaiting approval",
+				logging.String("id", req.ID),
+				logging.String("by", ext.By.String()),
+				logging.String("reason", ext.Reason))
+		}
 	}
 
 	logging.Info("To approve: airgapper approve <request-id>")
 	logging.Info("To deny:    airgapper deny <request-id>")
+	logging.Info("To extend:  airgapper extend <request-id> --approve")
+	logging.Info("To veto:    airgapper veto <request-id>")
 
 	return nil
 }
 
+// logRequesterContext shows the device a request was made from, so an
+// approver can spot one from an unexpected machine or address
+func logRequesterContext(req *consent.RestoreRequest) {
+	rc := req.RequesterContext
+	if rc == nil {
+		logging.Warn("Requested from an unknown device (sent without requester context)", logging.String("id", req.ID))
+		return
+	}
+	source := rc.SourceIP
+	if source == "" {
+		source = "this node"
+	}
+	logging.Info("Requested from",
+		logging.String("id", req.ID),
+		logging.String("host", rc.Hostname),
+		logging.String("os", rc.OS),
+		logging.String("version", rc.Version),
+		logging.String("key", rc.KeyFingerprint),
+		logging.String("sourceIP", source))
+}
+
+// requestPurpose describes what approving a request releases
+func requestPurpose(req *consent.RestoreRequest) string {
+	if req.IsKeyExport() {
+		return "EXPORT KEYS (releases the raw repository password)"
+	}
+	if req.IsBrowse() {
+		return "browse (list snapshot contents, metadata only)"
+	}
+	return "restore"
+}
+
 // --- Approve Command ---
 
 var approveCmd = &cobra.Command{
 	Use:   "approve <request-id>",
 	Short: "Approve a restore request (sign or release share)",
-	Long:  `Approve a pending restore request by signing it or releasing your key share.`,
-	Args:  cobra.ExactArgs(1),
-	RunE:  runners.Config().Wrap(runApprove),
+	Long: `Approve a pending restore request by signing it or releasing your key share.
+
+With --delegation, the approval is given on behalf of the key holder who
+delegated their authority to you (see 'airgapper delegate').
+
+Hosts can attach terms so a large restore doesn't saturate their uplink:
+--start-window defers its start to a daily window of your local time, and
+--limit caps its download rate. The requester's restore honors both.`,
+	Example: `  airgapper approve abc123
+  airgapper approve abc123 --start-window 22:00-06:00 --limit 10MB`,
+	Args: cobra.ExactArgs(1),
+	RunE: runners.Config().Wrap(runApprove),
 }
 
 func init() {
+	f := approveCmd.Flags()
+	f.String("delegation", "", "Approve on behalf of another key holder under this delegation")
+	f.String("start-window", "", "Only let the restore start within these local hours, e.g. 22:00-06:00")
+	f.String("limit", "", "Cap the restore's download rate per second, e.g. 10MB")
 	rootCmd.AddCommand(approveCmd)
 }
 
@@ -153,21 +386,54 @@ func runApprove(ctx *runner.CommandContext, cmd *cobra.Command, args []string) e
 	requestID := args[0]
 	mgr := ctx.Consent()
 
+	flags := runner.Flags(cmd)
+	delegationID := flags.String("delegation")
+	window := flags.String("start-window")
+	limit := flags.String("limit")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	// Don't release a share or signature from a tampered-with vault
+	if err := genesis.CheckConfig(ctx.Config); err != nil {
+		return err
+	}
+
+	terms, err := parseRestoreTerms(window, limit)
+	if err != nil {
+		return err
+	}
+	if terms != nil {
+		mgr = mgr.WithRestoreTerms(terms)
+		logging.Info("Attaching restore terms", logging.String("terms", terms.String()))
+	}
+
+	if delegationID != "" {
+		return approveDelegated(ctx, mgr, requestID, delegationID, terms)
+	}
 	if ctx.Config.UsesConsensusMode() || ctx.Config.PrivateKey != nil {
 		return approveConsensus(ctx, mgr, requestID)
 	}
 
-	return approveSSS(ctx, mgr, requestID)
+	return approveSSS(cmd.Context(), ctx, mgr, requestID, terms)
 }
 
-func approveSSS(ctx *runner.CommandContext, mgr *consent.Manager, requestID string) error {
+// approveSSS releases our key share and, on a host, sends it to the owner,
+// whose restore needs it. An owner's share never leaves the owner: with the
+// host's it would make the repository password.
+func approveSSS(cmdCtx context.Context, ctx *runner.CommandContext, mgr *consent.Manager, requestID string, terms *consent.RestoreTerms) error {
 	share, shareIndex, err := ctx.Config.LoadShare()
 	if err != nil {
 		return fmt.Errorf("failed to load share: %w", err)
 	}
 
+	req, err := mgr.GetRequest(requestID)
+	if err != nil {
+		return err
+	}
 	logging.Info("Approving request",
 		logging.String("requestID", requestID),
+		logging.String("purpose", requestPurpose(req)),
 		logging.Int("shareIndex", int(shareIndex)))
 
 	if err := mgr.Approve(requestID, ctx.Config.Name, share); err != nil {
@@ -175,7 +441,21 @@ func approveSSS(ctx *runner.CommandContext, mgr *consent.Manager, requestID stri
 	}
 
 	logging.Info("Request approved - key share released")
-	logging.Info("The requester can now restore their data")
+	if ctx.Config.IsHost() && ctx.Config.Peer != nil && ctx.Config.Peer.Address != "" {
+		req, err := mgr.GetRequest(requestID)
+		if err != nil {
+			return err
+		}
+		forwardToPeer(cmdCtx, ctx.Config, ctx.Config.Peer.Address, "ApproveRequest", req, peerqueue.KindApproval, map[string]any{
+			"id":          requestID,
+			"share":       share,
+			"share_index": shareIndex,
+			"terms":       terms,
+		})
+	}
+	if !logCoolingOff(mgr, requestID) {
+		logging.Info("The requester can now restore their data")
+	}
 
 	return nil
 }
@@ -193,25 +473,100 @@ func approveConsensus(ctx *runner.CommandContext, mgr *consent.Manager, requestI
 	keyID := crypto.KeyID(ctx.Config.PublicKey)
 	logging.Info("Signing request",
 		logging.String("requestID", requestID),
-		logging.String("keyID", keyID))
+		logging.String("keyID", keyID),
+		logging.String("snapshot", describeSnapshot(req.SnapshotID)),
+		logging.String(tracing.LogKey, req.CorrelationID))
 
-	signature, err := (&crypto.RestoreRequestSignData{
-		RequestID:   req.ID,
-		Requester:   req.Requester,
-		SnapshotID:  req.SnapshotID,
-		Reason:      req.Reason,
-		KeyHolderID: keyID,
-		Paths:       req.Paths,
-		CreatedAt:   req.CreatedAt.Unix(),
-	}).Sign(ctx.Config.PrivateKey)
+	approval, err := signRestoreRequest(ctx, mgr, req, keyID)
+	if err != nil {
+		return err
+	}
+
+	if err := mgr.AddSignature(requestID, keyID, ctx.Config.Name, approval.Nonce, approval.SignedAt, approval.Signature); err != nil {
+		return err
+	}
+
+	logApprovalProgress(mgr, requestID)
+	return nil
+}
+
+// approveDelegated signs a request with our own key, counting as the
+// approval of the key holder who delegated their authority to us
+func approveDelegated(ctx *runner.CommandContext, mgr *consent.Manager, requestID, delegationID string, terms *consent.RestoreTerms) error {
+	if ctx.Config.PrivateKey == nil {
+		return fmt.Errorf("no private key found - cannot sign")
+	}
+
+	req, err := mgr.GetRequest(requestID)
+	if err != nil {
+		return err
+	}
+
+	keyID := crypto.KeyID(ctx.Config.PublicKey)
+	approval, err := signRestoreRequest(ctx, mgr, req, keyID)
 	if err != nil {
-		return fmt.Errorf("failed to sign request: %w", err)
+		return err
 	}
+	approval.DelegationID = delegationID
+	approval.Terms = terms
 
-	if err := mgr.AddSignature(requestID, keyID, ctx.Config.Name, signature); err != nil {
+	svc := service.NewConsentService(ctx.Config, mgr)
+	_, err = svc.SignRequest(*approval)
+	if err != nil {
 		return err
 	}
 
+	logging.Info("Request signed under delegation",
+		logging.String("requestID", requestID),
+		logging.String("delegationID", delegationID),
+		logging.String(tracing.LogKey, req.CorrelationID))
+	logApprovalProgress(mgr, requestID)
+	return nil
+}
+
+// parseRestoreTerms builds the terms given to approve, or nil if none. The
+// window is in this node's local time.
+func parseRestoreTerms(window, limit string) (*consent.RestoreTerms, error) {
+	if window == "" && limit == "" {
+		return nil, nil
+	}
+	_, offset := time.Now().Zone()
+	terms := &consent.RestoreTerms{Window: window, UTCOffset: offset}
+	if limit != "" {
+		n, err := parseQuota(limit)
+		if err != nil || n < 1024 {
+			return nil, fmt.Errorf("invalid --limit %q, use e.g. 10MB (per second, at least 1KB)", limit)
+		}
+		terms.LimitKiBps = int(n / 1024)
+	}
+	if err := terms.Validate(); err != nil {
+		return nil, err
+	}
+	return terms, nil
+}
+
+// signRestoreRequest issues a challenge for the request on this node and
+// signs the request's approval with it and the current time
+func signRestoreRequest(ctx *runner.CommandContext, mgr *consent.Manager, req *consent.RestoreRequest, keyID string) (*service.SignRequestParams, error) {
+	challenge, err := mgr.IssueChallenge(req.ID, keyID)
+	if err != nil {
+		return nil, err
+	}
+	signedAt := time.Now().Truncate(time.Second)
+	signature, err := req.ApprovalSignData(keyID, challenge.Nonce, signedAt).Sign(ctx.Config.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign request: %w", err)
+	}
+	return &service.SignRequestParams{
+		RequestID:   req.ID,
+		KeyHolderID: keyID,
+		Signature:   signature,
+		Nonce:       challenge.Nonce,
+		SignedAt:    signedAt,
+	}, nil
+}
+
+func logApprovalProgress(mgr *consent.Manager, requestID string) {
 	current, required, _ := mgr.GetApprovalProgress(requestID)
 
 	logging.Info("Request signed",
@@ -219,12 +574,33 @@ func approveConsensus(ctx *runner.CommandContext, mgr *consent.Manager, requestI
 		logging.Int("required", required))
 
 	if current >= required {
-		logging.Info("Request is now fully approved - the requester can now restore their data")
-	} else {
-		logging.Infof("Waiting for %d more approval(s)...", required-current)
+		if !logCoolingOff(mgr, requestID) {
+			logging.Info("Request is now fully approved - the requester can now restore their data")
+		}
+		return
+	}
+	if p, err := mgr.GetQuorumProgress(requestID); err == nil && len(p.Missing) > 0 {
+		logging.Infof("Waiting for approval from: %s", strings.Join(p.Missing, ", "))
+		return
 	}
+	logging.Infof("Waiting for %d more approval(s)...", required-current)
+}
 
-	return nil
+// logCoolingOff logs when an approved request can be executed, if it is
+// cooling off, and reports whether it is
+func logCoolingOff(mgr *consent.Manager, requestID string) bool {
+	req, err := mgr.GetRequest(requestID)
+	if err != nil {
+		return false
+	}
+	left := req.CoolingOffLeft(time.Now())
+	if left <= 0 {
+		return false
+	}
+	logging.Info("Request is approved and cooling off; any key holder can veto it until then",
+		logging.String("executable", req.ExecutableAt.Format("2006-01-02 15:04")),
+		logging.String("left", left.Round(time.Minute).String()))
+	return true
 }
 
 // --- Deny Command ---
@@ -244,10 +620,292 @@ func init() {
 func runDeny(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
 	requestID := args[0]
 
-	if err := ctx.Consent().Deny(requestID, ctx.Config.Name); err != nil {
+	if err := consent.Restores.Deny(ctx.Consent(), requestID, ctx.Config.Name); err != nil {
 		return err
 	}
 
 	logging.Info("Request denied", logging.String("requestID", requestID))
 	return nil
 }
+
+// --- Veto Command ---
+
+var vetoCmd = &cobra.Command{
+	Use:   "veto <request-id>",
+	Short: "Stop a restore request, even after approval, while it cools off",
+	Long: `Veto a restore request. Pending requests can be vetoed, and so can approved
+ones until their cooling-off period ends (restore_cooling_off in the config),
+e.g. if you suspect an approval was coerced or a key was stolen. Any
+released key share is discarded.
+
+The veto is signed with your key, like an approval, and forwarded to the
+peer's copy of the request.`,
+	Example: `  airgapper veto abc123 --reason "I did not ask for this restore"`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runners.Config().Wrap(runVeto),
+}
+
+func init() {
+	f := vetoCmd.Flags()
+	f.String("reason", "", "Why the request is vetoed")
+	f.String("peer", "", "Peer address to forward the veto to (default: the configured peer)")
+	rootCmd.AddCommand(vetoCmd)
+}
+
+func runVeto(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	requestID := args[0]
+	flags := runner.Flags(cmd)
+	reason := flags.String("reason")
+	peerAddr := flags.String("peer")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	if peerAddr == "" && ctx.Config.Peer != nil {
+		peerAddr = ctx.Config.Peer.Address
+	}
+
+	if ctx.Config.PrivateKey == nil {
+		return fmt.Errorf("no private key found - cannot sign")
+	}
+	keyID := crypto.KeyID(ctx.Config.PublicKey)
+	mgr := ctx.Consent()
+
+	challenge, err := mgr.IssueChallenge(requestID, keyID)
+	if err != nil {
+		return err
+	}
+	req, err := mgr.GetRequest(requestID)
+	if err != nil {
+		return err
+	}
+	signedAt := time.Now().Truncate(time.Second)
+	signature, err := req.VetoSignData(keyID, challenge.Nonce, reason, signedAt).Sign(ctx.Config.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("failed to sign veto: %w", err)
+	}
+	if req, err = mgr.Veto(requestID, keyID, ctx.Config.Name, challenge.Nonce, reason, signedAt, signature); err != nil {
+		return err
+	}
+	logging.Info("Request vetoed",
+		logging.String("requestID", requestID),
+		logging.String(tracing.LogKey, req.CorrelationID))
+
+	if peerAddr != "" {
+		if err := forwardVeto(cmd.Context(), ctx.Config, peerAddr, req, keyID); err != nil {
+			logging.Warn("Failed to send the veto to the peer - run the same command there", logging.Err(err))
+		}
+	}
+	return nil
+}
+
+// forwardVeto signs the veto recorded on req with a challenge from the
+// peer and sends it there
+func forwardVeto(ctx context.Context, cfg *config.Config, peerAddr string, req *consent.RestoreRequest, keyID string) error {
+	ctx = tracing.WithID(withPeerToken(ctx, cfg), req.CorrelationID)
+	nonce, err := peerChallenge(ctx, peerAddr, req.ID, keyID)
+	if err != nil {
+		return err
+	}
+
+	signedAt := time.Now().Truncate(time.Second)
+	signature, err := req.VetoSignData(keyID, nonce, req.VetoReason, signedAt).Sign(cfg.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("failed to sign veto: %w", err)
+	}
+	forwardToPeer(ctx, cfg, peerAddr, "VetoRequest", req, peerqueue.KindVeto, map[string]string{
+		"id":          req.ID,
+		"keyHolderId": keyID,
+		"reason":      req.VetoReason,
+		"signature":   hex.EncodeToString(signature),
+		"nonce":       nonce,
+		"signedAt":    signedAt.UTC().Format(time.RFC3339),
+	})
+	return nil
+}
+
+// --- Extend Command ---
+
+var extendCmd = &cobra.Command{
+	Use:   "extend <request-id>",
+	Short: "Ask for, or approve, more time on a pending restore request",
+	Long: `Push back the expiry of a pending restore request, e.g. while a key holder
+in another timezone is asleep.
+
+The requester asks for an extension with --by. Any key holder then approves
+it with --approve, and the approval is recorded on the request. Only one
+extension can await approval at a time, and each may be at most
+max_request_ttl (default 7 days).`,
+	Example: `  airgapper extend abc123 --by 12h --reason "Bob is back tomorrow"
+  airgapper extend abc123 --approve`,
+	Args: cobra.ExactArgs(1),
+	RunE: runners.Config().Wrap(runExtend),
+}
+
+func init() {
+	f := extendCmd.Flags()
+	f.String("by", "", "How much longer the request should stay pending, e.g. 12h")
+	f.String("reason", "", "Why more time is needed")
+	f.Bool("approve", false, "Approve the request's pending extension as a key holder")
+	f.String("peer", "", "Peer address to forward the extension to (default: the configured peer)")
+	extendCmd.MarkFlagsMutuallyExclusive("by", "approve")
+	extendCmd.MarkFlagsOneRequired("by", "approve")
+	extendCmd.MarkFlagsMutuallyExclusive("reason", "approve")
+	rootCmd.AddCommand(extendCmd)
+}
+
+func runExtend(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	requestID := args[0]
+	flags := runner.Flags(cmd)
+	byStr := flags.Duration("by")
+	reason := flags.String("reason")
+	approve := flags.Bool("approve")
+	peerAddr := flags.String("peer")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	if peerAddr == "" && ctx.Config.Peer != nil {
+		peerAddr = ctx.Config.Peer.Address
+	}
+	mgr := ctx.Consent()
+
+	if approve {
+		return approveExtension(cmd.Context(), ctx, mgr, requestID, peerAddr)
+	}
+
+	by, err := parseRequestTTL(ctx, byStr)
+	if err != nil {
+		return err
+	}
+	if _, err := mgr.RequestExtension(requestID, by, reason); err != nil {
+		return err
+	}
+	req, err := mgr.GetRequest(requestID)
+	if err != nil {
+		return err
+	}
+	logging.Info("Extension requested",
+		logging.String("requestID", requestID),
+		logging.String(tracing.LogKey, req.CorrelationID),
+		logging.String("by", by.String()))
+	if peerAddr != "" {
+		forwardToPeer(cmd.Context(), ctx.Config, peerAddr, "RequestExtension", req, peerqueue.KindExtension, map[string]string{
+			"id":       requestID,
+			"extendBy": by.String(),
+			"reason":   reason,
+		})
+	}
+	logging.Infof("Ask a key holder to run: airgapper extend %s --approve", requestID)
+	return nil
+}
+
+// forwardToPeer sends a call about a request, e.g. an extension, to the
+// peer's copy of it, queued until the peer can be reached
+// approveExtension signs request id's pending extension with our key and
+// approves it, then sends the peer an approval signed with a challenge the
+// peer issues
+func approveExtension(cmdCtx context.Context, ctx *runner.CommandContext, mgr *consent.Manager, requestID, peerAddr string) error {
+	if ctx.Config.PrivateKey == nil {
+		return fmt.Errorf("no private key found - cannot sign")
+	}
+	keyID := crypto.KeyID(ctx.Config.PublicKey)
+
+	challenge, err := mgr.IssueChallenge(requestID, keyID)
+	if err != nil {
+		return err
+	}
+	req, err := mgr.GetRequest(requestID)
+	if err != nil {
+		return err
+	}
+	signedAt := time.Now().Truncate(time.Second)
+	data, err := req.ExtensionSignData(keyID, challenge.Nonce, signedAt)
+	if err != nil {
+		return err
+	}
+	signature, err := data.Sign(ctx.Config.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("failed to sign extension: %w", err)
+	}
+	if req, err = mgr.ApproveExtension(requestID, keyID, ctx.Config.Name, challenge.Nonce, signedAt, signature); err != nil {
+		return err
+	}
+	logging.Info("Extension approved",
+		logging.String("requestID", requestID),
+		logging.String(tracing.LogKey, req.CorrelationID),
+		logging.String("expires", req.ExpiresAt.Format("2006-01-02 15:04")))
+
+	if peerAddr != "" {
+		if err := forwardExtensionApproval(cmdCtx, ctx.Config, peerAddr, req, keyID); err != nil {
+			logging.Warn("Failed to send the extension approval to the peer - run the same command there", logging.Err(err))
+		}
+	}
+	return nil
+}
+
+// forwardExtensionApproval signs the extension approved on req, whose
+// expiry it has moved, with a challenge from the peer and sends it there
+func forwardExtensionApproval(ctx context.Context, cfg *config.Config, peerAddr string, req *consent.RestoreRequest, keyID string) error {
+	ctx = tracing.WithID(withPeerToken(ctx, cfg), req.CorrelationID)
+	nonce, err := peerChallenge(ctx, peerAddr, req.ID, keyID)
+	if err != nil {
+		return err
+	}
+
+	signedAt := time.Now().Truncate(time.Second)
+	signature, err := (&crypto.ExtensionSignData{
+		RequestID:   req.ID,
+		KeyHolderID: keyID,
+		ExpiresAt:   req.ExpiresAt.Unix(),
+		Nonce:       nonce,
+		SignedAt:    signedAt.Unix(),
+	}).Sign(cfg.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("failed to sign extension: %w", err)
+	}
+	forwardToPeer(ctx, cfg, peerAddr, "ApproveExtension", req, peerqueue.KindExtension, map[string]string{
+		"id":          req.ID,
+		"keyHolderId": keyID,
+		"signature":   hex.EncodeToString(signature),
+		"nonce":       nonce,
+		"signedAt":    signedAt.UTC().Format(time.RFC3339),
+	})
+	return nil
+}
+
+// peerChallenge asks the peer to issue keyID a challenge for its copy of
+// request id, returning the nonce
+func peerChallenge(ctx context.Context, peerAddr, id, keyID string) (string, error) {
+	body, _ := json.Marshal(map[string]string{"id": id, "keyHolderId": keyID})
+	resp, err := postToPeer(ctx, 30*time.Second, strings.TrimSuffix(peerAddr, "/")+requestServicePath("IssueChallenge"), body)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach peer: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", peerError("peer refused a challenge", resp)
+	}
+	var challenge struct {
+		Nonce string `json:"nonce"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&challenge); err != nil || challenge.Nonce == "" {
+		return "", fmt.Errorf("invalid challenge from peer")
+	}
+	return challenge.Nonce, nil
+}
+
+func forwardToPeer(ctx context.Context, cfg *config.Config, peerAddr, method string, req *consent.RestoreRequest, kind string, body any) {
+	data, _ := json.Marshal(body)
+	sendToPeer(ctx, cfg, &peerqueue.Message{
+		Kind:          kind,
+		Peer:          peerAddr,
+		Path:          requestServicePath(method),
+		Body:          data,
+		RequestID:     req.ID,
+		CorrelationID: req.CorrelationID,
+	})
+}
+
+// requestServicePath is the path of a RestoreRequestService method
+func requestServicePath(method string) string {
+	return api.APIBasePath + "/" + airgapperv1connect.RestoreRequestServiceName + "/" + method
+}
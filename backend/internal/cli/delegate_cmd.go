@@ -0,0 +1,219 @@
+package cli
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/api"
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/service"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+)
+
+var delegateCmd = &cobra.Command{
+	Use:   "delegate",
+	Short: "Delegate your approval authority while you are away",
+	Long: `Hand your restore approval authority to another registered key holder
+for a bounded time, e.g. while on vacation. The delegation is signed with
+your key, expires on its own, and every use is recorded.`,
+}
+
+var delegateCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Delegate approvals to another key holder",
+	Example: `  airgapper delegate create --to 3f9a1c2b7d4e5f60 --days 14 --reason "Vacation"
+  airgapper delegate create --to 3f9a1c2b7d4e5f60 --days 7 --starts 2026-12-20 \
+    --reason "Holidays" --peer http://host:8081`,
+	RunE: runners.Config().Wrap(runDelegateCreate),
+}
+
+var delegateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List delegations and their audit trail",
+	RunE:  runners.Config().Wrap(runDelegateList),
+}
+
+var delegateRevokeCmd = &cobra.Command{
+	Use:   "revoke <delegation-id>",
+	Short: "End a delegation early",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runners.Config().Wrap(runDelegateRevoke),
+}
+
+func init() {
+	f := delegateCreateCmd.Flags()
+	f.String("to", "", "Key ID of the key holder to delegate to (required)")
+	f.Int("days", 14, fmt.Sprintf("How long the delegation lasts (at most %d)", int(consent.MaxDelegationPeriod.Hours()/24)))
+	f.String("starts", "", "Start date (YYYY-MM-DD, default: now)")
+	f.String("reason", "", "Why approvals are delegated (required)")
+	f.String("peer", "", "Also register the delegation with this peer")
+	_ = delegateCreateCmd.MarkFlagRequired("to")
+	_ = delegateCreateCmd.MarkFlagRequired("reason")
+
+	delegateRevokeCmd.Flags().String("peer", "", "Also revoke the delegation on this peer")
+
+	delegateCmd.AddCommand(delegateCreateCmd)
+	delegateCmd.AddCommand(delegateListCmd)
+	delegateCmd.AddCommand(delegateRevokeCmd)
+	rootCmd.AddCommand(delegateCmd)
+}
+
+func runDelegateCreate(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	if ctx.Config.PrivateKey == nil {
+		return fmt.Errorf("no private key found - cannot sign")
+	}
+
+	flags := runner.Flags(cmd)
+	to := flags.String("to")
+	days := flags.Int("days")
+	starts := flags.String("starts")
+	reason := flags.String("reason")
+	peer := flags.String("peer")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	if starts != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", starts, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid --starts date %q: use YYYY-MM-DD", starts)
+		}
+		start = parsed
+	}
+
+	from := crypto.KeyID(ctx.Config.PublicKey)
+	d, err := consent.NewDelegation(from, to, start, time.Duration(days)*24*time.Hour, reason)
+	if err != nil {
+		return err
+	}
+	if d.Signature, err = d.SignData().Sign(ctx.Config.PrivateKey); err != nil {
+		return fmt.Errorf("failed to sign delegation: %w", err)
+	}
+
+	svc := service.NewConsentService(ctx.Config, ctx.Consent())
+	if err := svc.RegisterDelegation(d); err != nil {
+		return err
+	}
+
+	logging.Info("Approval authority delegated",
+		logging.String("id", d.ID),
+		logging.String("to", d.ToKeyHolderID),
+		logging.String("from", d.StartsAt.Format(time.RFC3339)),
+		logging.String("until", d.ExpiresAt.Format(time.RFC3339)))
+
+	if peer != "" {
+		if err := postDelegation(withPeerToken(cmd.Context(), ctx.Config), peer, "", d); err != nil {
+			return err
+		}
+		logging.Info("Delegation registered with peer", logging.String("address", peer))
+	}
+	logging.Infof("The delegate approves with: airgapper approve <request-id> --delegation %s", d.ID)
+	return nil
+}
+
+func runDelegateList(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	delegations, events, err := ctx.Consent().ListDelegations()
+	if err != nil {
+		return err
+	}
+	if len(delegations) == 0 {
+		logging.Info("No delegations")
+		return nil
+	}
+
+	now := time.Now()
+	for _, d := range delegations {
+		status := "scheduled"
+		switch {
+		case d.RevokedAt != nil:
+			status = "revoked"
+		case !now.Before(d.ExpiresAt):
+			status = "expired"
+		case d.ActiveAt(now):
+			status = "active"
+		}
+		logging.Info(d.ID,
+			logging.String("status", status),
+			logging.String("from", d.FromKeyHolderID),
+			logging.String("to", d.ToKeyHolderID),
+			logging.String("starts", d.StartsAt.Format(time.RFC3339)),
+			logging.String("expires", d.ExpiresAt.Format(time.RFC3339)),
+			logging.String("reason", d.Reason))
+	}
+
+	logging.Info("Audit trail:")
+	for _, e := range events {
+		logging.Infof("  %s  %-8s %s %s %s", e.At.Format(time.RFC3339), e.Event, e.DelegationID, e.KeyHolderID, e.RequestID)
+	}
+	return nil
+}
+
+func runDelegateRevoke(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	if ctx.Config.PrivateKey == nil {
+		return fmt.Errorf("no private key found - cannot sign")
+	}
+
+	flags := runner.Flags(cmd)
+	peer := flags.String("peer")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	svc := service.NewConsentService(ctx.Config, ctx.Consent())
+	d, err := ctx.Consent().GetDelegation(args[0])
+	if err != nil {
+		return err
+	}
+	signature, err := d.RevokeSignData().Sign(ctx.Config.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("failed to sign revocation: %w", err)
+	}
+	if _, err := svc.RevokeDelegation(d.ID, signature); err != nil {
+		return err
+	}
+	logging.Info("Delegation revoked", logging.String("id", d.ID))
+
+	if peer != "" {
+		body := api.RevokeDelegationRequest{Signature: hex.EncodeToString(signature)}
+		if err := postDelegation(withPeerToken(cmd.Context(), ctx.Config), peer, "/"+d.ID+"/revoke", body); err != nil {
+			return err
+		}
+		logging.Info("Delegation revoked on peer", logging.String("address", peer))
+	}
+	return nil
+}
+
+// postDelegation posts body to the peer's delegations endpoint plus suffix
+func postDelegation(ctx context.Context, peerAddr, suffix string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	ctx, correlationID := tracing.Ensure(ctx)
+	if err := checkPeerFeature(ctx, peerAddr, api.FeatureDelegation); err != nil {
+		return err
+	}
+	endpoint := strings.TrimSuffix(peerAddr, "/") + api.APIBasePath + api.DelegationsPath + suffix
+	resp, err := postToPeer(ctx, 30*time.Second, endpoint, data)
+	if err != nil {
+		return fmt.Errorf("failed to reach peer (request ID %s): %w", correlationID, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return featureError(ctx, peerAddr, api.FeatureDelegation, "peer rejected delegation", resp)
+	}
+	return nil
+}
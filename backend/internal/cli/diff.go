@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <snapshot-a> <snapshot-b>",
+	Short: "Show what changed between two snapshots (owner only)",
+	Long: `List the files added, removed and modified from snapshot a to snapshot b,
+with the size change, to check what a deletion would drop or which snapshot
+to restore.`,
+	Example: `  airgapper diff 4f1a2b3c 9d8e7f6a
+  airgapper diff 4f1a2b3c 9d8e7f6a --limit 0`,
+	Args: cobra.ExactArgs(2),
+	RunE: runners.Owner().Use(runner.RequirePassword()).Wrap(runDiff),
+}
+
+func init() {
+	diffCmd.Flags().Int("limit", 100, "Maximum changed paths to list per category (0 = all)")
+	rootCmd.AddCommand(diffCmd)
+}
+
+func runDiff(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	limit := flags.Int("limit")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	if !restic.IsInstalled() {
+		return fmt.Errorf("restic is not installed")
+	}
+
+	client := restic.NewClient(ctx.Config.RepoURL, ctx.Config.Password).WithIdentity(ctx.Config.PrivateKey).WithOptions(ctx.Config.Restic)
+	diff, err := client.Diff(cmd.Context(), args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	listChanges("+", diff.Added, limit)
+	listChanges("-", diff.Removed, limit)
+	listChanges("M", diff.Modified, limit)
+
+	logging.Info("Snapshot diff",
+		logging.String("from", diff.SourceSnapshot),
+		logging.String("to", diff.TargetSnapshot),
+		logging.Int("added", len(diff.Added)),
+		logging.Int("removed", len(diff.Removed)),
+		logging.Int("modified", len(diff.Modified)),
+		logging.Int64("addedBytes", diff.AddedStats.Bytes),
+		logging.Int64("removedBytes", diff.RemovedStats.Bytes),
+		logging.Int64("sizeDelta", diff.SizeDelta()))
+	return nil
+}
+
+// listChanges logs up to limit paths prefixed with the change marker
+func listChanges(marker string, paths []string, limit int) {
+	for i, path := range paths {
+		if limit > 0 && i == limit {
+			logging.Infof("%s ... and %d more (use --limit 0 to list all)", marker, len(paths)-limit)
+			return
+		}
+		logging.Infof("%s %s", marker, path)
+	}
+}
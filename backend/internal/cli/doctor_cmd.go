@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/doctor"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check this node's setup and suggest fixes",
+	Long: `Run preflight checks: config, restic version, repository and peer
+reachability, key shares, clock skew against peers, disk space, backup
+schedule and the API port. Each problem is reported with a suggested fix.
+
+Exits with an error if any check fails.`,
+	Example: `  # Run every check
+  airgapper doctor
+
+  # Skip the repository and peer checks, e.g. when offline
+  airgapper doctor --offline
+
+  # Check a different API port than the default
+  airgapper doctor --addr 127.0.0.1:9090`,
+	RunE: runners.Uninitialized().Wrap(runDoctor),
+}
+
+func init() {
+	f := doctorCmd.Flags()
+	f.String("addr", "", "API address 'airgapper serve' will listen on (default listen_addr, $AIRGAPPER_LISTEN_ADDR or 127.0.0.1:8081)")
+	f.Bool("offline", false, "Skip checks that contact the repository or peers")
+	rootCmd.AddCommand(doctorCmd)
+}
+
+func runDoctor(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	offline := flags.Bool("offline")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	d := &doctor.Doctor{
+		Config:     ctx.Config,
+		ConfigErr:  ctx.ConfigErr,
+		ListenAddr: resolveAddr(cmd, ctx.Config),
+		Offline:    offline,
+	}
+	results := d.Run(cmd.Context())
+
+	warnings := 0
+	for _, r := range results {
+		line := fmt.Sprintf("[%-4s] %-20s %s", r.Status, r.Name, r.Message)
+		switch r.Status {
+		case doctor.StatusOK, doctor.StatusSkip:
+			logging.Info(line)
+			continue
+		case doctor.StatusWarn:
+			warnings++
+		}
+		logging.Warn(line)
+		if r.Fix != "" {
+			logging.Infof("       fix: %s", r.Fix)
+		}
+	}
+
+	if failed := doctor.Failed(results); failed > 0 {
+		return fmt.Errorf("%d checks failed, %d warnings", failed, warnings)
+	}
+	logging.Infof("All checks passed (%d warnings)", warnings)
+	return nil
+}
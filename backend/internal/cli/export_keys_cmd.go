@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+// --- Export Keys Command ---
+
+var exportKeysCmd = &cobra.Command{
+	Use:   "export-keys",
+	Short: "Export the raw restic password to leave Airgapper (requires approval)",
+	Long: `Reconstruct the raw restic repository password and write a recovery bundle
+you can store in a safe.
+
+The bundle contains the repository URL, the password and a cheat-sheet of
+plain restic commands, so your backups stay usable without Airgapper. Because
+it bypasses the key split, exporting needs a consent-approved request created
+with 'airgapper request --export-keys'.`,
+	Example: `  airgapper request --export-keys --reason "migrating off Airgapper"
+  airgapper export-keys --request abc123 --out ~/safe`,
+	RunE: runners.Owner().Wrap(runExportKeys),
+}
+
+func init() {
+	f := exportKeysCmd.Flags()
+	f.String("request", "", "Approved export request ID (required)")
+	f.StringP("out", "o", ".", "Directory to write the recovery bundle to")
+	f.Bool("force", false, "Overwrite an existing recovery bundle")
+	addUnlockFlags(exportKeysCmd)
+	_ = exportKeysCmd.MarkFlagRequired("request")
+	rootCmd.AddCommand(exportKeysCmd)
+}
+
+func runExportKeys(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	requestID := flags.String("request")
+	outDir := flags.String("out")
+	force := flags.Bool("force")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	unlock, err := unlockFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	req, err := ctx.Consent().GetRequest(requestID)
+	if err != nil {
+		return err
+	}
+	if !req.IsKeyExport() {
+		return fmt.Errorf("request %s is not a key export request (create one with 'airgapper request --export-keys')", requestID)
+	}
+	if req.Status != consent.StatusApproved {
+		return fmt.Errorf("request is not approved (status: %s)", req.Status)
+	}
+	if err := req.CheckExecutable(time.Now()); err != nil {
+		return err
+	}
+	if err := ctx.Consent().CheckLockdown(); err != nil {
+		return err
+	}
+
+	secret, err := recoverPassword(cmd.Context(), ctx.Config, req, unlock)
+	if err != nil {
+		return err
+	}
+	password := string(secret)
+	if password == "" {
+		return fmt.Errorf("no repository password available to export")
+	}
+
+	repoURL := restic.NewClient(ctx.Config.RepoURL, "").RepoURL
+	bundlePath := filepath.Join(outDir, fmt.Sprintf("airgapper-recovery-%s.txt", ctx.Config.Name))
+	if _, err := os.Stat(bundlePath); err == nil && !force {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", bundlePath)
+	}
+	if err := os.MkdirAll(outDir, 0700); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := os.WriteFile(bundlePath, []byte(recoveryBundle(ctx.Config.Name, repoURL, password)), 0600); err != nil {
+		return fmt.Errorf("failed to write recovery bundle: %w", err)
+	}
+
+	// The password only goes to the bundle, never to the log
+	logging.Info("Keys exported",
+		logging.String("repo", repoURL),
+		logging.String("bundle", bundlePath))
+	logging.Info("Use the repository directly with restic:")
+	logging.Infof("  export RESTIC_REPOSITORY=%s", repoURL)
+	logging.Info("  export RESTIC_PASSWORD='<the password in the bundle>'")
+	logging.Info("  restic snapshots")
+	logging.Info("  restic restore latest --target /restore/path")
+	logging.Warn("Anyone with this password can decrypt your backups without your peer's consent - store the bundle offline, then delete any other copies.")
+	return nil
+}
+
+// recoveryBundle renders the standalone recovery document written by export-keys
+func recoveryBundle(name, repoURL, password string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "AIRGAPPER RECOVERY BUNDLE\n")
+	fmt.Fprintf(&b, "=========================\n\n")
+	fmt.Fprintf(&b, "Owner:      %s\n", name)
+	fmt.Fprintf(&b, "Exported:   %s\n", time.Now().UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "Repository: %s\n", repoURL)
+	fmt.Fprintf(&b, "Password:   %s\n\n", password)
+	b.WriteString(`This file is everything needed to read your backups with plain restic
+(https://restic.net), without Airgapper or your peer. Keep it somewhere safe
+and offline: anyone holding it can decrypt every snapshot.
+
+SETUP
+  export RESTIC_REPOSITORY='` + repoURL + `'
+  export RESTIC_PASSWORD='` + strings.ReplaceAll(password, "'", `'\''`) + `'
+
+BROWSE
+  restic snapshots                          # list snapshots
+  restic ls latest                          # list files in the newest snapshot
+  restic find '*.pdf'                       # search all snapshots
+  restic mount /mnt/restic                  # browse snapshots as a filesystem
+
+RESTORE
+  restic restore latest --target /restore/path
+  restic restore <snapshot-id> --target /restore/path --include /home/me/docs
+
+VERIFY
+  restic check                              # repository structure
+  restic check --read-data-subset 10%       # sample actual pack data
+
+MIGRATE AWAY
+  restic -r /new/repo init --from-repo "$RESTIC_REPOSITORY" --copy-chunker-params
+  restic -r /new/repo copy --from-repo "$RESTIC_REPOSITORY"
+
+CONTINUE BACKING UP WITHOUT AIRGAPPER
+  restic backup /path/to/data
+  restic forget --keep-daily 7 --keep-weekly 4 --prune
+  (The host may serve the repository append-only; forget/prune then has to
+  run on the host itself or against a migrated copy.)
+`)
+	return b.String()
+}
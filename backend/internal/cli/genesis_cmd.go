@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/api"
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/genesis"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+var genesisCmd = &cobra.Command{
+	Use:   "genesis",
+	Short: "Show, verify and countersign the vault's genesis record",
+	Long: `At init the owner records the vault's genesis: its ID, repository,
+a fingerprint of the password, the share split or key holders, and a hash of
+the approval policy, signed with the owner's key. Each key holder that joins
+countersigns it and keeps a copy.
+
+Approvals and key holder registrations are refused when this node's
+configuration no longer matches the record.`,
+}
+
+var genesisShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show this node's genesis record",
+	RunE:  runners.Config().Wrap(runGenesisShow),
+}
+
+var genesisVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check the genesis record's signatures and compare it with this node's config",
+	RunE:  runners.Config().Wrap(runGenesisVerify),
+}
+
+var genesisCountersignCmd = &cobra.Command{
+	Use:   "countersign",
+	Short: "Fetch the owner's genesis record, countersign it and keep a copy",
+	Long: `Fetch the genesis record from the owner, check the owner's signature,
+countersign it with this node's key and store the countersigned copy here.
+Run it once the owner has registered you as a key holder.
+
+SSS hosts, which aren't registered key holders, store the record without
+countersigning it.`,
+	Example: `  airgapper genesis countersign --owner https://alice-laptop:8081`,
+	RunE:    runners.Config().Wrap(runGenesisCountersign),
+}
+
+func init() {
+	genesisCountersignCmd.Flags().String("owner", "", "Owner API address (default: the peer's address)")
+
+	genesisCmd.AddCommand(genesisShowCmd)
+	genesisCmd.AddCommand(genesisVerifyCmd)
+	genesisCmd.AddCommand(genesisCountersignCmd)
+	rootCmd.AddCommand(genesisCmd)
+}
+
+// loadGenesis loads this node's genesis record, which must exist
+func loadGenesis(ctx *runner.CommandContext) (*genesis.Document, error) {
+	doc, err := genesis.Load(ctx.Config)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("no genesis record on this node; vaults created before genesis records have none")
+	}
+	return doc, nil
+}
+
+func runGenesisShow(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	doc, err := loadGenesis(ctx)
+	if err != nil {
+		return err
+	}
+
+	logging.Info("Genesis record",
+		logging.String("vaultID", doc.VaultID),
+		logging.String("created", doc.CreatedAt.Format("2006-01-02 15:04:05")),
+		logging.String("repo", doc.RepoURL),
+		logging.String("mode", string(doc.Mode)),
+		logging.String("rule", fmt.Sprintf("%d of %d", doc.Threshold, doc.TotalShares)),
+		logging.String("secretFingerprint", doc.SecretFingerprint[:16]),
+		logging.String("policyHash", doc.PolicyHash[:16]))
+	if doc.OwnerSignature != "" {
+		logging.Info("Signed by owner", logging.String("keyID", doc.OwnerKeyID))
+	} else {
+		logging.Info("Unsigned (SSS vaults have no signing keys)")
+	}
+	for _, kh := range doc.KeyHolders {
+		logging.Info("Key holder at init", logging.String("name", kh.Name), logging.String("keyID", kh.ID))
+	}
+	for _, cs := range doc.Countersignatures {
+		logging.Info("Countersigned",
+			logging.String("name", cs.Name),
+			logging.String("keyID", cs.KeyHolderID),
+			logging.String("at", cs.SignedAt.Format("2006-01-02 15:04:05")))
+	}
+	return nil
+}
+
+func runGenesisVerify(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	doc, err := loadGenesis(ctx)
+	if err != nil {
+		return err
+	}
+	if err := doc.Check(ctx.Config); err != nil {
+		return err
+	}
+	if names := doc.Uncountersigned(ctx.Config); len(names) > 0 {
+		logging.Warn("Key holders have not countersigned the genesis record",
+			logging.String("keyHolders", strings.Join(names, ", ")))
+	}
+	logging.Info("Configuration matches the genesis record", logging.String("vaultID", doc.VaultID))
+	return nil
+}
+
+func runGenesisCountersign(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	ownerAddr := flags.String("owner")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	if ownerAddr == "" && ctx.Config.Peer != nil {
+		ownerAddr = ctx.Config.Peer.Address
+	}
+	if ownerAddr == "" {
+		return fmt.Errorf("no owner address configured; use --owner")
+	}
+	base := strings.TrimSuffix(ownerAddr, "/") + api.APIBasePath
+
+	doc, err := fetchGenesis(withPeerToken(cmd.Context(), ctx.Config), base)
+	if err != nil {
+		return err
+	}
+	if err := doc.Verify(); err != nil {
+		return err
+	}
+	if doc.RepoURL != ctx.Config.RepoURL {
+		return fmt.Errorf("owner's genesis record is for %s, but this node uses %s", doc.RepoURL, ctx.Config.RepoURL)
+	}
+	if local, err := genesis.Load(ctx.Config); err == nil && local != nil && local.VaultID != doc.VaultID {
+		return fmt.Errorf("owner's genesis record is for vault %s, but this node belongs to %s", doc.VaultID, local.VaultID)
+	}
+
+	if ctx.Config.PrivateKey != nil && doc.Mode == genesis.ModeConsensus {
+		cs, err := doc.Countersign(ctx.Config.Name, ctx.Config.PrivateKey, ctx.Config.PublicKey)
+		if err != nil {
+			return err
+		}
+		if doc, err = postCountersignature(cmd.Context(), base, cs); err != nil {
+			return err
+		}
+		if err := doc.Verify(); err != nil {
+			return err
+		}
+		if doc.Signer(cs.KeyHolderID) == nil {
+			return fmt.Errorf("owner did not record our countersignature")
+		}
+		logging.Info("Genesis record countersigned", logging.String("keyID", crypto.KeyID(ctx.Config.PublicKey)))
+	} else {
+		logging.Info("Only consensus key holders countersign; storing the genesis record as is")
+	}
+
+	if err := doc.Save(ctx.Config); err != nil {
+		return fmt.Errorf("failed to save genesis record: %w", err)
+	}
+	logging.Info("Genesis record saved", logging.String("vaultID", doc.VaultID))
+	return nil
+}
+
+// fetchGenesis gets the owner's genesis record
+func fetchGenesis(ctx context.Context, base string) (*genesis.Document, error) {
+	resp, err := getFromPeer(ctx, 30*time.Second, base+api.GenesisPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach owner: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, peerError("owner has no genesis record", resp)
+	}
+	var doc genesis.Document
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid genesis record from owner: %w", err)
+	}
+	return &doc, nil
+}
+
+// postCountersignature sends our countersignature to the owner and
+// returns the record it stored
+func postCountersignature(ctx context.Context, base string, cs *genesis.Countersignature) (*genesis.Document, error) {
+	body, err := json.Marshal(cs)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := postToPeer(ctx, 30*time.Second, base+api.GenesisCountersignPath, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach owner: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, peerError("owner rejected countersignature", resp)
+	}
+	var doc genesis.Document
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid genesis record from owner: %w", err)
+	}
+	return &doc, nil
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+// hints suggest what to do next after an error with a given code
+var hints = map[apperrors.Code]string{
+	apperrors.CodeNotInitialized:         "run 'airgapper init' (owner) or 'airgapper join' (host) first",
+	apperrors.CodeAlreadyInitialized:     "this node is already set up; check 'airgapper status'",
+	apperrors.CodeNoLocalShare:           "this node holds no key share; approvals must come from a key holder",
+	apperrors.CodeConsensusNotConfigured: "initialize with consensus mode to manage key holders",
+	apperrors.CodeKeyHolderNotFound:      "check the key holder ID with 'airgapper status'",
+	apperrors.CodeInvalidRole:            "this command must be run on the other party's node",
+	apperrors.CodeGenesisMismatch:        "the vault's configuration changed since it was created; compare it with 'airgapper genesis show'",
+	apperrors.CodeRequestNotFound:        "list open requests with 'airgapper pending'",
+	apperrors.CodeRequestNotPending:      "the request was already decided; list open requests with 'airgapper pending'",
+	apperrors.CodeRequestExpired:         "create a new request with 'airgapper request'",
+	apperrors.CodeRequestNotApproved:     "wait for approval; check progress with 'airgapper pending'",
+	apperrors.CodeAlreadyApproved:        "your approval is already recorded; wait for the remaining key holders",
+	apperrors.CodeInsufficientApprovals:  "more key holders must approve; check progress with 'airgapper pending'",
+	apperrors.CodeWrongRequestPurpose:    "create a request for this operation with 'airgapper request'",
+	apperrors.CodeInvalidSignature:       "make sure the request was signed with this key holder's current key",
+	apperrors.CodeSnapshotPinned:         "release the pin first with 'airgapper storage pin release'",
+	apperrors.CodeRequestUnsigned:        "create the request with 'airgapper request', which signs it with your key",
+	apperrors.CodeRequestExists:          "sign the request again with a new ID",
+	apperrors.CodeChallengeInvalid:       "fetch a new challenge from the node and sign the request again",
+	apperrors.CodeNonceReused:            "fetch a new challenge from the node and sign the request again",
+	apperrors.CodeExtensionPending:       "wait for a key holder to approve it with 'airgapper extend --approve'",
+	apperrors.CodeNoPendingExtension:     "the requester can ask for one with 'airgapper extend'",
+	apperrors.CodeCoolingOff:             "wait for the cooling-off period to end; check it with 'airgapper pending'",
+	apperrors.CodeLockedDown:             "the node is locked down by a panic; see 'airgapper panic status' and lift it with 'airgapper panic lift'",
+	apperrors.CodeTemplateNotFound:       "list templates with 'airgapper template list'",
+	apperrors.CodeTemplateExists:         "pick another name or remove it with 'airgapper template remove'",
+	apperrors.CodeDelegationNotFound:     "list delegations with 'airgapper delegate list'",
+	apperrors.CodeDelegationInactive:     "the delegation was revoked or has expired; create a new one with 'airgapper delegate create'",
+	apperrors.CodeStorageNotConfigured:   "start the storage server with 'airgapper serve'",
+	apperrors.CodeNoRepoPassword:         "run this on the owner's node, which holds the repository password",
+	apperrors.CodeInsecureBind:           "pass --tls-cert and --tls-key, bind to 127.0.0.1, or use --insecure",
+	apperrors.CodePathNotAllowed:         "only paths under the configured browse roots can be listed",
+	apperrors.CodeUnsupportedAPIVersion:  "upgrade airgapper on both nodes to compatible versions",
+	apperrors.CodeUnauthenticated:        "the node has user accounts; ask its admin for a token and save it with 'airgapper user peer-token'",
+	apperrors.CodeUserNotFound:           "list accounts with 'airgapper user list'",
+	apperrors.CodeUserExists:             "pick another name, or issue the user a new token with 'airgapper user token'",
+	apperrors.CodeLastAdmin:              "make another user an admin first with 'airgapper user role'",
+	apperrors.CodeSessionNotFound:        "list sessions with 'airgapper user sessions'",
+	apperrors.CodeRateLimited:            "wait a few minutes before trying again",
+}
+
+// printHint writes the code of err and a hint for it, if err has a known code
+func printHint(w io.Writer, err error) {
+	var coded *apperrors.Error
+	if !errors.As(err, &coded) || !coded.Code.Known() {
+		return
+	}
+	if hint, ok := hints[coded.Code]; ok {
+		_, _ = fmt.Fprintf(w, "Hint (%s): %s\n", coded.Code, hint)
+	}
+}
@@ -0,0 +1,219 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/api"
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/service"
+)
+
+var hostCmd = &cobra.Command{
+	Use:   "host",
+	Short: "Run as a backup host in one command (for containers)",
+	Long: `Set up this node as a backup host if it isn't yet, then serve the
+storage backend and the API until stopped.
+
+All state lives under the --storage directory, so a single volume holds it:
+
+  <storage>/config     config.json, keys and consent state
+  <storage>/repos      the restic repositories, served at /storage/
+  <storage>/config/join.json
+                       what an owner needs to back up here
+
+The join info is also logged on every start. The config in ~/.airgapper is
+not used.`,
+	Example: `  # In a container, with all state in the "backups" volume
+  docker run -v backups:/data -p 8080:8080 airgapper host --addr :8080 --insecure
+
+  # On a NAS, reachable by owners at https://nas.example.com:8080
+  airgapper host --storage /srv/airgapper --addr :8080 \
+    --tls-cert cert.pem --tls-key key.pem --public-url https://nas.example.com:8080`,
+	RunE: runners.Uninitialized().Wrap(runHost),
+}
+
+func init() {
+	addHostFlags(hostCmd)
+	rootCmd.AddCommand(hostCmd)
+}
+
+// addHostFlags defines the host command's flags on cmd
+func addHostFlags(cmd *cobra.Command) {
+	f := cmd.Flags()
+	f.String("storage", "/data", "Directory holding all of the host's state")
+	f.StringP("addr", "a", "", "Listen address (default: listen_addr, AIRGAPPER_LISTEN_ADDR or 127.0.0.1:8081)")
+	f.String("name", "", "Host name, used on first start (default: the machine's hostname)")
+	f.String("quota", "", "Storage quota on first start (e.g., 100GB, 1TB)")
+	f.Bool("append-only", true, "Enable append-only mode on first start (prevents deletions)")
+	f.String("public-url", "", "URL owners reach this host at (default: the local URL)")
+	f.StringSlice("cors-origin", nil, "Allowed CORS origin (repeatable, \"*\" for any; default: same-origin only)")
+	f.String("tls-cert", "", "TLS certificate file (enables HTTPS)")
+	f.String("tls-key", "", "TLS private key file")
+	f.Bool("insecure", false, "Allow binding to a non-loopback address without TLS")
+}
+
+// joinInfo is what an owner needs to use this host, saved as join.json
+type joinInfo struct {
+	Name       string `json:"name"`
+	KeyID      string `json:"key_id"`
+	PublicKey  string `json:"public_key"`
+	APIURL     string `json:"api_url"`
+	StorageURL string `json:"storage_url"`
+}
+
+func runHost(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	dataDir := flags.String("storage")
+	name := flags.String("name")
+	quotaStr := flags.String("quota")
+	appendOnly := flags.Bool("append-only")
+	publicURL := flags.String("public-url")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	if dataDir == "" {
+		return fmt.Errorf("--storage is required")
+	}
+
+	hostCfg, err := loadOrInitHost(dataDir, name, quotaStr, appendOnly)
+	if err != nil {
+		return err
+	}
+	if err := hostCfg.ApplyOverrides(os.LookupEnv, configOverrides); err != nil {
+		return err
+	}
+
+	addr := resolveAddr(cmd, hostCfg)
+	hostCfg.ListenAddr = addr
+	insecure, err := applyServeSecurityFlags(cmd, hostCfg)
+	if err != nil {
+		return err
+	}
+	if err := api.CheckBindSafety(addr, hostCfg.TLSEnabled(), insecure); err != nil {
+		return err
+	}
+	if insecure && !api.IsLoopbackAddr(addr) && !hostCfg.TLSEnabled() {
+		logging.Warn("Serving the API over plaintext HTTP on a non-loopback address",
+			logging.String("addr", addr))
+	}
+
+	if publicURL == "" {
+		publicURL = localURL(hostCfg, addr)
+	}
+	info := hostJoinInfo(hostCfg, publicURL)
+	infoPath, err := saveJoinInfo(hostCfg, info)
+	if err != nil {
+		return err
+	}
+	printJoinInfo(info, infoPath)
+
+	printServerInfo(hostCfg, addr)
+	apiServer := api.NewServerWithOptions(hostCfg, addr, &api.ServerOptions{Version: Version})
+	if apiServer.StorageServer() == nil {
+		return fmt.Errorf("failed to start the storage server at %s", hostCfg.StoragePath)
+	}
+	stopShareChecks := startShareChecks(hostCfg)
+	stopEventNotifications := api.StartEventNotifications(hostCfg)
+
+	return runServer(apiServer, hostCfg, nil, func() {
+		stopShareChecks()
+		stopEventNotifications()
+	}, nil)
+}
+
+// loadOrInitHost loads the host's config from under dataDir, initializing
+// the host on first start
+func loadOrInitHost(dataDir, name, quotaStr string, appendOnly bool) (*config.Config, error) {
+	configDir := filepath.Join(dataDir, "config")
+	if config.Exists(configDir) {
+		hostCfg, err := config.Load(configDir)
+		if err != nil {
+			return nil, err
+		}
+		if !hostCfg.IsHost() {
+			return nil, fmt.Errorf("%s belongs to a %s, not a host", configDir, hostCfg.Role)
+		}
+		logging.Info("Host already initialized", logging.String("name", hostCfg.Name), logging.String("config", configDir))
+		return hostCfg, nil
+	}
+
+	if name == "" {
+		hostname, err := os.Hostname()
+		if err != nil || hostname == "" {
+			return nil, fmt.Errorf("--name is required: the hostname is unknown")
+		}
+		name = hostname
+	}
+	var quotaBytes int64
+	if quotaStr != "" {
+		parsed, err := parseQuota(quotaStr)
+		if err != nil {
+			return nil, err
+		}
+		quotaBytes = parsed
+	}
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", configDir, err)
+	}
+
+	hostCfg := &config.Config{ConfigDir: configDir}
+	result, err := service.NewHostService(hostCfg).Init(service.HostInitParams{
+		Name:         name,
+		StoragePath:  filepath.Join(dataDir, "repos"),
+		StorageQuota: quotaBytes,
+		AppendOnly:   appendOnly,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize host: %w", err)
+	}
+	logging.Info("Host initialized",
+		logging.String("name", result.Name),
+		logging.String("keyId", result.KeyID),
+		logging.String("storage", result.StoragePath))
+	return hostCfg, nil
+}
+
+func hostJoinInfo(hostCfg *config.Config, publicURL string) joinInfo {
+	publicURL = strings.TrimSuffix(publicURL, "/")
+	return joinInfo{
+		Name:       hostCfg.Name,
+		KeyID:      crypto.KeyID(hostCfg.PublicKey),
+		PublicKey:  crypto.EncodePublicKey(hostCfg.PublicKey),
+		APIURL:     publicURL,
+		StorageURL: "rest:" + publicURL + "/storage/",
+	}
+}
+
+// saveJoinInfo writes the join info next to the config, rewriting it on each
+// start as the public URL may have changed
+func saveJoinInfo(hostCfg *config.Config, info joinInfo) (string, error) {
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(hostCfg.ConfigDir, "join.json")
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return "", fmt.Errorf("failed to save join info: %w", err)
+	}
+	return path, nil
+}
+
+func printJoinInfo(info joinInfo, path string) {
+	logging.Info("Join info",
+		logging.String("name", info.Name),
+		logging.String("keyId", info.KeyID),
+		logging.String("publicKey", info.PublicKey),
+		logging.String("api", info.APIURL),
+		logging.String("storage", info.StorageURL),
+		logging.String("file", path))
+	logging.Infof("Owners should run: airgapper init --name <their-name> --repo '%s<repo-name>'", info.StorageURL)
+}
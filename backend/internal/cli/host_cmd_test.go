@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+func TestLoadOrInitHost(t *testing.T) {
+	dataDir := t.TempDir()
+
+	// The first start initializes the host under dataDir
+	first, err := loadOrInitHost(dataDir, "bob-nas", "10GB", true)
+	require.NoError(t, err)
+	assert.Equal(t, "bob-nas", first.Name)
+	assert.True(t, first.IsHost())
+	assert.Equal(t, filepath.Join(dataDir, "config"), first.ConfigDir)
+	assert.Equal(t, filepath.Join(dataDir, "repos"), first.StoragePath)
+	assert.Equal(t, int64(10*1024*1024*1024), first.StorageQuotaBytes)
+	assert.True(t, first.StorageAppendOnly)
+	require.NotEmpty(t, first.PrivateKey)
+
+	// Later starts load it again, ignoring the first-start settings
+	again, err := loadOrInitHost(dataDir, "other-name", "1GB", false)
+	require.NoError(t, err)
+	assert.Equal(t, "bob-nas", again.Name)
+	assert.Equal(t, first.PublicKey, again.PublicKey)
+	assert.Equal(t, first.StorageQuotaBytes, again.StorageQuotaBytes)
+	assert.True(t, again.StorageAppendOnly)
+
+	// The name defaults to the hostname
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+	unnamed, err := loadOrInitHost(t.TempDir(), "", "", true)
+	require.NoError(t, err)
+	assert.Equal(t, hostname, unnamed.Name)
+}
+
+func TestLoadOrInitHostRefusesOwnerConfig(t *testing.T) {
+	dataDir := t.TempDir()
+	configDir := filepath.Join(dataDir, "config")
+	require.NoError(t, os.MkdirAll(configDir, 0700))
+	owner := &config.Config{ConfigDir: configDir, Name: "alice", Role: config.RoleOwner}
+	require.NoError(t, owner.Save())
+
+	_, err := loadOrInitHost(dataDir, "", "", true)
+	assert.ErrorContains(t, err, "not a host")
+}
+
+func TestSaveJoinInfo(t *testing.T) {
+	hostCfg, err := loadOrInitHost(t.TempDir(), "bob-nas", "", true)
+	require.NoError(t, err)
+
+	info := hostJoinInfo(hostCfg, "https://nas.example.com:8080/")
+	assert.Equal(t, "bob-nas", info.Name)
+	assert.Equal(t, crypto.KeyID(hostCfg.PublicKey), info.KeyID)
+	assert.Equal(t, crypto.EncodePublicKey(hostCfg.PublicKey), info.PublicKey)
+	assert.Equal(t, "https://nas.example.com:8080", info.APIURL)
+	assert.Equal(t, "rest:https://nas.example.com:8080/storage/", info.StorageURL)
+
+	path, err := saveJoinInfo(hostCfg, info)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(hostCfg.ConfigDir, "join.json"), path)
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	readJoinInfo := func() joinInfo {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		var saved joinInfo
+		require.NoError(t, json.Unmarshal(data, &saved))
+		return saved
+	}
+	assert.Equal(t, info, readJoinInfo())
+
+	// Each start rewrites it, as the public URL may have changed
+	_, err = saveJoinInfo(hostCfg, hostJoinInfo(hostCfg, "http://10.0.0.5:8080"))
+	require.NoError(t, err)
+	assert.Equal(t, "rest:http://10.0.0.5:8080/storage/", readJoinInfo().StorageURL)
+}
+
+func TestRunHost(t *testing.T) {
+	newCmd := func(flags map[string]string) *cobra.Command {
+		cmd := &cobra.Command{Use: "host"}
+		addHostFlags(cmd)
+		for name, value := range flags {
+			require.NoError(t, cmd.Flags().Set(name, value))
+		}
+		return cmd
+	}
+
+	assert.ErrorContains(t, runHost(nil, newCmd(map[string]string{"storage": ""}), nil), "--storage is required")
+
+	// Serving plain HTTP on the network is refused, after the host is
+	// initialized, on the first start and on later ones alike
+	dataDir := t.TempDir()
+	flags := map[string]string{"storage": dataDir, "name": "bob-nas", "addr": "0.0.0.0:0"}
+	err := runHost(nil, newCmd(flags), nil)
+	assert.ErrorIs(t, err, apperrors.ErrInsecureBind)
+	first, err := config.Load(filepath.Join(dataDir, "config"))
+	require.NoError(t, err)
+	assert.Equal(t, "bob-nas", first.Name)
+	assert.NoFileExists(t, filepath.Join(dataDir, "config", "join.json"))
+
+	flags["name"] = "renamed"
+	err = runHost(nil, newCmd(flags), nil)
+	assert.ErrorIs(t, err, apperrors.ErrInsecureBind)
+	again, err := config.Load(filepath.Join(dataDir, "config"))
+	require.NoError(t, err)
+	assert.Equal(t, "bob-nas", again.Name)
+	assert.Equal(t, first.PublicKey, again.PublicKey)
+}
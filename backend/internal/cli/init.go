@@ -1,9 +1,11 @@
 package cli
 
 import (
+	"crypto/ed25519"
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -11,11 +13,15 @@ import (
 
 	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
 	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
 	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
 	"github.com/lcrostarosa/airgapper/backend/internal/emergency"
+	"github.com/lcrostarosa/airgapper/backend/internal/genesis"
+	"github.com/lcrostarosa/airgapper/backend/internal/keyprotect"
 	"github.com/lcrostarosa/airgapper/backend/internal/logging"
 	"github.com/lcrostarosa/airgapper/backend/internal/restic"
 	"github.com/lcrostarosa/airgapper/backend/internal/sss"
+	"github.com/lcrostarosa/airgapper/backend/internal/verification"
 )
 
 var initCmd = &cobra.Command{
@@ -25,10 +31,19 @@ var initCmd = &cobra.Command{
 
 This creates a restic repository and splits the encryption password
 using Shamir's Secret Sharing. You keep one share, and give the
-other to your backup host.`,
+other to your backup host.
+
+--protect adds further ways to recover the password for a restore: kms
+escrows a copy with a cloud KMS key (through the aws or gcloud CLI and its
+credentials), and passphrase seals your share with a passphrase you
+memorize, so a restore needs the passphrase as well as the host's share.
+Any one of the vault's protections can recover the password.`,
 	Example: `  # Standard 2-of-2 initialization
   airgapper init --name alice --repo rest:http://bob-nas:8000/backup
 
+  # Compress hard, for a fast CPU and a slow link to the host
+  airgapper init --name alice --repo rest:http://bob-nas:8000/backup --compression max
+
   # With recovery shares (2-of-4 scheme)
   airgapper init --name alice --repo rest:http://bob-nas:8000/backup \
     --recovery-shares 4 --recovery-threshold 2 \
@@ -36,7 +51,19 @@ other to your backup host.`,
 
   # With consensus mode (m-of-n key holders)
   airgapper init --name alice --repo rest:http://bob-nas:8000/backup \
-    --threshold 2 --holders 3`,
+    --threshold 2 --holders 3
+
+  # Escrow the password with a cloud KMS key, and seal your share with a
+  # memorized passphrase instead of keeping it in plain form
+  VAULT_PASSPHRASE='...' airgapper init --name alice --repo rest:http://bob-nas:8000/backup \
+    --protect kms --kms-provider aws --kms-key alias/airgapper \
+    --protect passphrase --passphrase-env VAULT_PASSPHRASE
+
+  # With recovery contacts who can stand in for a key holder who lost their key
+  airgapper init --name alice --repo rest:http://bob-nas:8000/backup \
+    --threshold 2 --holders 3 \
+    --social-recovery-contact dana=<public-key> --social-recovery-contact erin=<public-key> \
+    --social-recovery-threshold 2 --social-recovery-days 30`,
 	RunE: runners.Uninitialized().Wrap(runInit),
 }
 
@@ -46,6 +73,7 @@ func init() {
 	// Required
 	f.StringP("name", "n", "", "Your name/identifier")
 	f.StringP("repo", "r", "", "Restic repository URL")
+	f.String("compression", "", "Backup compression: auto, max or off (default: restic's, auto on new repositories)")
 	_ = initCmd.MarkFlagRequired("name")
 	_ = initCmd.MarkFlagRequired("repo")
 
@@ -58,6 +86,17 @@ func init() {
 	f.Int("threshold", 0, "Approval threshold (enables consensus mode)")
 	f.Int("holders", 0, "Total key holders")
 
+	// Social recovery options (consensus mode)
+	f.StringSlice("social-recovery-contact", nil, "Recovery contact as name=public-key-hex, from 'airgapper social-recovery keygen' (can specify multiple)")
+	f.Int("social-recovery-threshold", 0, "Recovery contacts needed to stand in for a key holder (default: all of them)")
+	f.Int("social-recovery-days", int(consent.DefaultRecoveryWait.Hours()/24), fmt.Sprintf("Days a recovery waits before it can be used (at least %d)", int(consent.MinRecoveryWait.Hours()/24)))
+
+	// Key protection options
+	f.StringSlice("protect", nil, "Also protect the repository password with: kms, passphrase (can specify multiple)")
+	f.String("kms-provider", "", "KMS to escrow the password with: aws or gcp (--protect kms)")
+	f.String("kms-key", "", "KMS key ARN or alias (aws), or key resource name (gcp) (--protect kms)")
+	f.String("passphrase-env", "", "Environment variable holding the passphrase that seals your share (--protect passphrase, SSS mode)")
+
 	// Emergency options
 	f.String("dead-man-switch", "", "Days of inactivity before trigger (e.g., 180d)")
 	f.StringSlice("escalation-contact", nil, "Escalation contact (can specify multiple)")
@@ -74,24 +113,116 @@ func runInit(ctx *runner.CommandContext, cmd *cobra.Command, args []string) erro
 	flags := runner.Flags(cmd)
 	name := flags.String("name")
 	repoURL := flags.String("repo")
+	compression := flags.String("compression")
 	threshold := flags.Int("threshold")
 	holders := flags.Int("holders")
 	if err := flags.Err(); err != nil {
 		return err
 	}
+	if err := restic.ValidateCompression(compression); err != nil {
+		return err
+	}
 
 	if config.Exists("") {
 		return fmt.Errorf("already initialized. Remove ~/.airgapper to reinitialize")
 	}
 
+	socialRecovery, err := socialRecoveryPolicy(cmd)
+	if err != nil {
+		return err
+	}
+
 	if threshold > 0 || holders > 0 {
-		return initConsensus(cmd, name, repoURL, threshold, holders)
+		return initConsensus(cmd, name, repoURL, compression, threshold, holders, socialRecovery)
+	}
+	if socialRecovery != nil {
+		return fmt.Errorf("social recovery needs consensus mode (--threshold and --holders)")
 	}
 
-	return initSSS(cmd, name, repoURL)
+	return initSSS(cmd, name, repoURL, compression)
+}
+
+// socialRecoveryPolicy builds the social recovery from the init flags, or
+// returns nil if no recovery contacts are given
+func socialRecoveryPolicy(cmd *cobra.Command) (*consent.RecoveryPolicy, error) {
+	flags := runner.Flags(cmd)
+	contacts := flags.StringSlice("social-recovery-contact")
+	threshold := flags.Int("social-recovery-threshold")
+	days := flags.Int("social-recovery-days")
+	if err := flags.Err(); err != nil {
+		return nil, err
+	}
+	if len(contacts) == 0 {
+		return nil, nil
+	}
+
+	policy := &consent.RecoveryPolicy{Threshold: threshold, WaitingPeriod: fmt.Sprintf("%dh", days*24)}
+	for _, c := range contacts {
+		name, keyHex, ok := strings.Cut(c, "=")
+		pub, err := hex.DecodeString(keyHex)
+		if !ok || err != nil || len(pub) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid --social-recovery-contact %q: use name=public-key-hex", c)
+		}
+		policy.Contacts = append(policy.Contacts, consent.RecoveryContact{ID: crypto.KeyID(pub), Name: name, PublicKey: pub})
+	}
+	if policy.Threshold == 0 {
+		policy.Threshold = len(policy.Contacts)
+	}
+	return policy, policy.Validate()
+}
+
+// keyProtections sets up the key protections asked for with --protect.
+// share is the owner's share in SSS mode, or nil in consensus mode, where
+// there's no share to seal with a passphrase.
+func keyProtections(cmd *cobra.Command, password string, share *sss.Share) ([]keyprotect.Protection, error) {
+	flags := runner.Flags(cmd)
+	protect := flags.StringSlice("protect")
+	kmsProvider := flags.String("kms-provider")
+	kmsKey := flags.String("kms-key")
+	passphraseEnv := flags.String("passphrase-env")
+	if err := flags.Err(); err != nil {
+		return nil, err
+	}
+
+	var protections []keyprotect.Protection
+	for _, name := range protect {
+		t, err := keyprotect.ParseType(name)
+		if err != nil {
+			return nil, err
+		}
+		var p keyprotect.Protection
+		switch t {
+		case keyprotect.TypeKMS:
+			if p, err = keyprotect.NewKMS(cmd.Context(), keyprotect.KMSKey{Provider: kmsProvider, Key: kmsKey}, []byte(password)); err != nil {
+				return nil, fmt.Errorf("--protect kms: %w", err)
+			}
+			logging.Info("Escrowed the repository password with the KMS key",
+				logging.String("provider", kmsProvider),
+				logging.String("key", kmsKey))
+		case keyprotect.TypePassphrase:
+			if share == nil {
+				return nil, fmt.Errorf("--protect passphrase needs SSS mode: consensus mode has no share to seal")
+			}
+			if passphraseEnv == "" {
+				return nil, fmt.Errorf("--protect passphrase needs --passphrase-env")
+			}
+			passphrase, err := passwordFromEnv(passphraseEnv)
+			if err != nil {
+				return nil, err
+			}
+			if p, err = keyprotect.NewPassphrase(passphrase, *share); err != nil {
+				return nil, fmt.Errorf("--protect passphrase: %w", err)
+			}
+			logging.Info("Sealed your key share with the passphrase; restores will need it and the host's share")
+		default:
+			return nil, fmt.Errorf("%s protection comes with the vault's mode; --protect takes kms or passphrase", t)
+		}
+		protections = append(protections, p)
+	}
+	return protections, nil
 }
 
-func initSSS(cmd *cobra.Command, name, repoURL string) error {
+func initSSS(cmd *cobra.Command, name, repoURL, compression string) error {
 	flags := runner.Flags(cmd)
 	recoveryShares := flags.Int("recovery-shares")
 	recoveryThreshold := flags.Int("recovery-threshold")
@@ -131,9 +262,15 @@ func initSSS(cmd *cobra.Command, name, repoURL string) error {
 	}
 	logging.Infof("Split password into %d shares (%d-of-%d required)", recoveryShares, recoveryThreshold, recoveryShares)
 
+	protections, err := keyProtections(cmd, password, &shares[0])
+	if err != nil {
+		return err
+	}
+	sealedShare := slices.ContainsFunc(protections, func(p keyprotect.Protection) bool { return p.Type == keyprotect.TypePassphrase })
+
 	// Initialize restic repo
 	logging.Info("Initializing restic repository...")
-	client := restic.NewClient(repoURL, password)
+	client := restic.NewClient(repoURL, password).WithCompression(compression)
 	if err := client.Init(cmd.Context()); err != nil {
 		return fmt.Errorf("failed to init repo: %w", err)
 	}
@@ -141,12 +278,24 @@ func initSSS(cmd *cobra.Command, name, repoURL string) error {
 
 	// Build config
 	newCfg := &config.Config{
-		Name:       name,
-		Role:       config.RoleOwner,
-		RepoURL:    repoURL,
-		Password:   password,
-		LocalShare: shares[0].Data,
-		ShareIndex: shares[0].Index,
+		Name:              name,
+		Role:              config.RoleOwner,
+		RepoURL:           repoURL,
+		Password:          password,
+		BackupCompression: compression,
+		LocalShare:        shares[0].Data,
+		ShareIndex:        shares[0].Index,
+		// Remember the host share's fingerprint so 'airgapper verify-shares'
+		// can check it later, even when the threshold is above 2
+		ShareCheck: &verification.ShareCheckState{
+			PeerFingerprint: hex.EncodeToString(verification.ShareFingerprint(shares[1].Index, shares[1].Data)),
+		},
+	}
+
+	newCfg.KeyProtection = protections
+	if sealedShare {
+		// Only the passphrase-sealed copy of our share is kept
+		newCfg.LocalShare = nil
 	}
 
 	// Configure emergency features
@@ -170,6 +319,9 @@ func initSSS(cmd *cobra.Command, name, repoURL string) error {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 	logging.Info("Configuration saved to ~/.airgapper/")
+	if err := writeGenesis(newCfg, recoveryShares); err != nil {
+		return err
+	}
 
 	// Output shares
 	printShareInfo(shares, repoURL, recoveryThreshold, recoveryShares, custodians)
@@ -184,7 +336,7 @@ func initSSS(cmd *cobra.Command, name, repoURL string) error {
 	return nil
 }
 
-func initConsensus(cmd *cobra.Command, name, repoURL string, threshold, holders int) error {
+func initConsensus(cmd *cobra.Command, name, repoURL, compression string, threshold, holders int, socialRecovery *consent.RecoveryPolicy) error {
 	if threshold < 1 {
 		threshold = 1
 	}
@@ -214,9 +366,14 @@ func initConsensus(cmd *cobra.Command, name, repoURL string, threshold, holders
 	keyID := crypto.KeyID(pubKey)
 	logging.Info("Generated Ed25519 key pair", logging.String("keyID", keyID))
 
+	protections, err := keyProtections(cmd, password, nil)
+	if err != nil {
+		return err
+	}
+
 	// Initialize restic repo
 	logging.Info("Initializing restic repository...")
-	client := restic.NewClient(repoURL, password)
+	client := restic.NewClient(repoURL, password).WithIdentity(privKey).WithCompression(compression)
 	if err := client.Init(cmd.Context()); err != nil {
 		return fmt.Errorf("failed to init repo: %w", err)
 	}
@@ -232,24 +389,36 @@ func initConsensus(cmd *cobra.Command, name, repoURL string, threshold, holders
 	}
 
 	newCfg := &config.Config{
-		Name:       name,
-		Role:       config.RoleOwner,
-		RepoURL:    repoURL,
-		Password:   password,
-		PublicKey:  pubKey,
-		PrivateKey: privKey,
+		Name:              name,
+		Role:              config.RoleOwner,
+		RepoURL:           repoURL,
+		Password:          password,
+		BackupCompression: compression,
+		PublicKey:         pubKey,
+		PrivateKey:        privKey,
 		Consensus: &config.ConsensusConfig{
 			Threshold:       threshold,
 			TotalKeys:       holders,
 			KeyHolders:      []config.KeyHolder{ownerHolder},
 			RequireApproval: threshold > 1 || holders > 1,
 		},
+		SocialRecovery: socialRecovery,
+		KeyProtection:  protections,
 	}
 
 	if err := newCfg.Save(); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 	logging.Info("Configuration saved to ~/.airgapper/")
+	if err := writeGenesis(newCfg, 0); err != nil {
+		return err
+	}
+
+	if socialRecovery != nil {
+		wait, _ := socialRecovery.Wait()
+		logging.Infof("Social recovery: %d of %d recovery contacts can stand in for a key holder who lost their key, %d days after opening a recovery",
+			socialRecovery.Threshold, len(socialRecovery.Contacts), int(wait.Hours()/24))
+	}
 
 	if holders > 1 {
 		logging.Warn("IMPORTANT: Invite other key holders to join",
@@ -257,12 +426,26 @@ func initConsensus(cmd *cobra.Command, name, repoURL string, threshold, holders
 			logging.Int("threshold", threshold),
 			logging.Int("total", holders))
 		logging.Infof("They should run: airgapper join --name <their-name> --repo '%s' --consensus", repoURL)
+		logging.Info("Once registered, they countersign the vault's genesis record: airgapper genesis countersign --owner <your-address>")
 	}
 
 	logging.Info("Initialization complete")
 	return nil
 }
 
+// writeGenesis records the new vault's genesis document next to its config
+func writeGenesis(cfg *config.Config, totalShares int) error {
+	doc, err := genesis.New(cfg, totalShares)
+	if err != nil {
+		return err
+	}
+	if err := doc.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save genesis record: %w", err)
+	}
+	logging.Info("Genesis record saved", logging.String("vaultID", doc.VaultID))
+	return nil
+}
+
 func printShareInfo(shares []sss.Share, repoURL string, k, n int, custodians []string) {
 	logging.Warn("IMPORTANT: Share this with your backup host")
 	peerShare := hex.EncodeToString(shares[1].Data)
@@ -308,6 +491,7 @@ func printInitNextSteps(hasRecovery bool) {
 	}
 	logging.Info("  3. Configure backup schedule: airgapper schedule --set daily ~/Documents")
 	logging.Info("  4. Run: airgapper backup <paths>  (or start server for scheduled backups)")
+	logging.Info("  5. Once the host is serving, check your shares match: airgapper verify-shares --host <address>")
 }
 
 func parseDays(s string) int {
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/restorejob"
+)
+
+var mountCmd = &cobra.Command{
+	Use:   "mount",
+	Short: "Mount the repository with FUSE after restore approval",
+	Long: `Mount the approved snapshot read-only at a mountpoint once a restore
+request is approved, so you can browse and copy just the files you need.
+Requests approving only some paths can't be mounted; restore them instead.
+
+restic can't mount a single snapshot, so only the snapshots sharing the
+approved one's host, paths and tags are mounted. The approved snapshot is at
+<mountpoint>/ids/<id>.
+
+The mount is served by 'restic mount' (FUSE must be installed) and is removed
+automatically when the request expires, after --timeout, or on Ctrl+C.`,
+	Example: `  airgapper mount --request abc123 --mountpoint /mnt/backup
+  airgapper mount --request abc123 --mountpoint /mnt/backup --timeout 30m`,
+	RunE: runners.Owner().Wrap(runMount),
+}
+
+func init() {
+	f := mountCmd.Flags()
+	f.String("request", "", "Approved restore request ID (required)")
+	f.String("mountpoint", "", "Directory to mount the repository at (required)")
+	f.String("timeout", "", "Unmount after this long, e.g. 30m (default: when the request expires)")
+	addUnlockFlags(mountCmd)
+	_ = mountCmd.MarkFlagRequired("request")
+	_ = mountCmd.MarkFlagRequired("mountpoint")
+	rootCmd.AddCommand(mountCmd)
+}
+
+func runMount(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	requestID := flags.String("request")
+	mountpoint := flags.String("mountpoint")
+	timeoutStr := flags.Duration("timeout")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	unlock, err := unlockFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	var timeout time.Duration
+	if timeoutStr != "" {
+		if timeout, err = time.ParseDuration(timeoutStr); err != nil || timeout <= 0 {
+			return fmt.Errorf("invalid timeout %q", timeoutStr)
+		}
+	}
+
+	req, err := ctx.Consent().GetRequest(requestID)
+	if err != nil {
+		return err
+	}
+	if err := restorejob.CheckMount(req, time.Now()); err != nil {
+		return err
+	}
+	if err := ctx.Consent().CheckLockdown(); err != nil {
+		return err
+	}
+
+	deadline := req.ExpiresAt
+	if timeout > 0 && time.Now().Add(timeout).Before(deadline) {
+		deadline = time.Now().Add(timeout)
+	}
+
+	if !restic.IsInstalled() {
+		return fmt.Errorf("restic is not installed")
+	}
+
+	secret, err := recoverPassword(cmd.Context(), ctx.Config, req, unlock)
+	if err != nil {
+		return err
+	}
+	password := string(secret)
+
+	client := restic.NewClient(ctx.Config.RepoURL, password).WithIdentity(ctx.Config.PrivateKey).WithOptions(ctx.Config.Restic)
+
+	if err := os.MkdirAll(mountpoint, 0700); err != nil {
+		return fmt.Errorf("failed to create mountpoint: %w", err)
+	}
+
+	mountCtx, cancel := context.WithDeadline(cmd.Context(), deadline)
+	defer cancel()
+	mountCtx, stop := signal.NotifyContext(mountCtx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logging.Info("Mounting repository",
+		logging.String("mountpoint", mountpoint),
+		logging.String("unmountAt", deadline.Format("2006-01-02 15:04:05")))
+	logging.Info("Press Ctrl+C to unmount")
+
+	if err := restorejob.Mount(mountCtx, client, req, mountpoint, time.Now()); err != nil {
+		return err
+	}
+
+	logging.Info("Repository unmounted", logging.String("mountpoint", mountpoint))
+	return nil
+}
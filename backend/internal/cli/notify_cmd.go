@@ -23,12 +23,23 @@ var notifyAddCmd = &cobra.Command{
 	Long: `Add a notification provider for alerts.
 
 Supported providers:
-  pushover   - Pushover push notifications
-  ntfy       - ntfy.sh notifications
-  webhook    - Generic HTTP webhooks
-  email      - SMTP email notifications
-  slack      - Slack webhooks
-  discord    - Discord webhooks`,
+  pushover   - Pushover push notifications (--api-token, --user-key)
+  ntfy       - ntfy.sh notifications (--topic, optional --server, --auth-token)
+  webhook    - Generic HTTP webhooks (--url)
+  email      - SMTP email notifications (--smtp-host, --from, --to)
+  slack      - Slack webhooks (--webhook-url)
+  discord    - Discord webhooks (--webhook-url)
+  telegram   - Telegram bot messages (--bot-token, --chat-id)
+  matrix     - Matrix room messages (--homeserver, --access-token, --room-id)
+
+Each provider gets every enabled event (see 'notify events') unless
+--events narrows it, and --min-priority drops less urgent messages.
+
+Examples:
+  airgapper notify add telegram --bot-token 123:abc --chat-id -1001234
+  airgapper notify add email --smtp-host smtp.example.com --from backups@example.com \
+    --to me@example.com --username backups --password secret --min-priority high
+  airgapper notify add ntfy --topic mybackups --events backup_failed,lockdown`,
 	Args: cobra.ExactArgs(1),
 	RunE: runners.Config().Wrap(runNotifyAdd),
 }
@@ -47,9 +58,12 @@ var notifyListCmd = &cobra.Command{
 }
 
 var notifyTestCmd = &cobra.Command{
-	Use:   "test",
+	Use:   "test [id]",
 	Short: "Send a test notification",
-	RunE:  runners.Config().Wrap(runNotifyTest),
+	Long: `Send a test notification to every enabled provider, or to one provider
+by ID, whatever its event and priority filters.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runners.Config().Wrap(runNotifyTest),
 }
 
 func init() {
@@ -67,10 +81,18 @@ func init() {
 	f.String("smtp-host", "", "SMTP host (for email)")
 	f.String("smtp-port", "587", "SMTP port (for email)")
 	f.String("from", "", "From address (for email)")
-	f.String("to", "", "To address (for email)")
+	f.String("to", "", "To addresses, comma-separated (for email)")
 	f.String("username", "", "Username (for email)")
 	f.String("password", "", "Password (for email)")
+	f.String("bot-token", "", "Bot token (for telegram)")
+	f.String("chat-id", "", "Chat ID (for telegram)")
+	f.String("homeserver", "", "Homeserver URL (for matrix)")
+	f.String("access-token", "", "Access token (for matrix)")
+	f.String("room-id", "", "Room ID (for matrix)")
+	f.String("api-url", "", "API server URL, for self-hosted or proxied APIs (for telegram/pushover)")
 	f.String("priority", "normal", "Notification priority (low, normal, high, urgent)")
+	f.StringSlice("events", nil, "Only send these events, e.g. backup_failed,lockdown (default: all enabled events)")
+	f.String("min-priority", "", "Only send messages at least this urgent (low, normal, high, urgent)")
 	f.Bool("dry-run", false, "Preview changes without applying")
 
 	notifyCmd.AddCommand(notifyAddCmd)
@@ -92,6 +114,7 @@ func runNotifyAdd(ctx *runner.CommandContext, cmd *cobra.Command, args []string)
 		"api-token", "user-key", "server", "topic", "auth-token",
 		"url", "method", "webhook-url", "channel", "smtp-host",
 		"smtp-port", "from", "to", "username", "password",
+		"bot-token", "chat-id", "homeserver", "access-token", "room-id", "api-url",
 	}
 
 	for _, key := range settingKeys {
@@ -102,10 +125,24 @@ func runNotifyAdd(ctx *runner.CommandContext, cmd *cobra.Command, args []string)
 		}
 	}
 
+	events := flags.StringSlice("events")
+	minPriority := flags.String("min-priority")
 	if err := flags.Err(); err != nil {
 		return err
 	}
 
+	provider := emergency.Provider{
+		Type:        providerType,
+		Enabled:     true,
+		Settings:    settings,
+		Priority:    priority,
+		Events:      events,
+		MinPriority: minPriority,
+	}
+	if err := provider.Validate(); err != nil {
+		return err
+	}
+
 	e := ctx.Config.EnsureEmergency()
 	if e.Notify == nil {
 		e.Notify = &emergency.NotifyConfig{
@@ -114,13 +151,7 @@ func runNotifyAdd(ctx *runner.CommandContext, cmd *cobra.Command, args []string)
 		}
 	}
 
-	providerID := fmt.Sprintf("%s-%d", providerType, len(e.Notify.Providers)+1)
-	provider := emergency.Provider{
-		Type:     providerType,
-		Enabled:  true,
-		Settings: settings,
-		Priority: priority,
-	}
+	providerID := e.Notify.NextProviderID(providerType)
 
 	if dryRun {
 		logging.Info("Dry-run: Would add notification provider",
@@ -176,11 +207,17 @@ func runNotifyList(ctx *runner.CommandContext, cmd *cobra.Command, args []string
 		if !provider.Enabled {
 			status = "disabled"
 		}
+		events := "all enabled"
+		if len(provider.Events) > 0 {
+			events = strings.Join(provider.Events, ",")
+		}
 		logging.Info("Provider",
 			logging.String("id", id),
 			logging.String("type", provider.Type),
 			logging.String("status", status),
-			logging.String("priority", provider.Priority))
+			logging.String("priority", provider.Priority),
+			logging.String("events", events),
+			logging.String("minPriority", provider.MinPriority))
 	}
 	return nil
 }
@@ -191,9 +228,44 @@ func runNotifyTest(ctx *runner.CommandContext, cmd *cobra.Command, args []string
 		return fmt.Errorf("no notification providers configured")
 	}
 
+	msg := emergency.Message{
+		Event: emergency.TestEvent,
+		Title: "Airgapper test notification",
+		Body:  fmt.Sprintf("Notifications from %s are working.", ctx.Config.Name),
+	}
+
+	// One provider is tested even if it's disabled, to check it before
+	// turning it on
+	if len(args) == 1 {
+		provider, ok := notify.Providers[args[0]]
+		if !ok {
+			return fmt.Errorf("no notification provider %s", args[0])
+		}
+		logging.Info("Sending test notification",
+			logging.String("id", args[0]),
+			logging.String("type", provider.Type))
+		if err := provider.Send(cmd.Context(), msg); err != nil {
+			return fmt.Errorf("provider %s failed: %w", args[0], err)
+		}
+		logging.Info("Test notification sent")
+		return nil
+	}
+
+	if !notify.IsEnabled() {
+		return fmt.Errorf("notifications are disabled")
+	}
+
 	logging.Info("Sending test notification",
 		logging.Int("providers", notify.ProviderCount()))
-	logging.Warn("Note: Full notification delivery requires the notification service")
-	logging.Info("Test messages will be sent when 'airgapper serve' is running")
+
+	failed := notify.Send(cmd.Context(), msg)
+	for id, err := range failed {
+		logging.Warn("Provider failed", logging.String("id", id), logging.Err(err))
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d providers failed", len(failed), notify.ProviderCount())
+	}
+
+	logging.Info("Test notification sent")
 	return nil
 }
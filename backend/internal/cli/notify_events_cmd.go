@@ -27,6 +27,10 @@ func init() {
 	ef.Bool("restore-approved", false, "Notify on restore approval")
 	ef.Bool("restore-denied", false, "Notify on restore denial")
 	ef.Bool("emergency-triggered", false, "Notify on emergency trigger")
+	ef.Bool("storage-anomaly", false, "Notify when stored backups change unexpectedly")
+	ef.Bool("share-mismatch", false, "Notify when a share check finds mismatched key shares")
+	ef.Bool("lockdown", false, "Notify when a node is locked down by a panic or the lockdown is lifted")
+	ef.Bool("restore-test-failed", false, "Notify when a scheduled restore test or canary file check fails")
 
 	notifyCmd.AddCommand(notifyEventsCmd)
 }
@@ -45,7 +49,7 @@ func runNotifyEvents(ctx *runner.CommandContext, cmd *cobra.Command, args []stri
 	none := flags.Bool("none")
 
 	// If no flags, show current config
-	if !all && !none && !flags.Changed("backup-started") && !flags.Changed("backup-completed") {
+	if !all && !none && !flags.Changed("backup-started") && !flags.Changed("backup-completed") && !flags.Changed("storage-anomaly") && !flags.Changed("share-mismatch") && !flags.Changed("lockdown") && !flags.Changed("restore-test-failed") {
 		events := e.Notify.Events
 		logging.Info("Notification events",
 			logging.Bool("backupStarted", events.BackupStarted),
@@ -59,7 +63,11 @@ func runNotifyEvents(ctx *runner.CommandContext, cmd *cobra.Command, args []stri
 			logging.Bool("consensusReceived", events.ConsensusReceived),
 			logging.Bool("emergencyTriggered", events.EmergencyTriggered),
 			logging.Bool("deadManWarning", events.DeadManWarning),
-			logging.Bool("heartbeatMissed", events.HeartbeatMissed))
+			logging.Bool("heartbeatMissed", events.HeartbeatMissed),
+			logging.Bool("storageAnomaly", events.StorageAnomaly),
+			logging.Bool("shareMismatch", events.ShareMismatch),
+			logging.Bool("lockdown", events.Lockdown),
+			logging.Bool("restoreTestFailed", events.RestoreTestFailed))
 		return nil
 	}
 
@@ -90,6 +98,18 @@ func runNotifyEvents(ctx *runner.CommandContext, cmd *cobra.Command, args []stri
 		if flags.Bool("emergency-triggered") {
 			e.Notify.Events.EmergencyTriggered = true
 		}
+		if flags.Bool("storage-anomaly") {
+			e.Notify.Events.StorageAnomaly = true
+		}
+		if flags.Bool("share-mismatch") {
+			e.Notify.Events.ShareMismatch = true
+		}
+		if flags.Bool("lockdown") {
+			e.Notify.Events.Lockdown = true
+		}
+		if flags.Bool("restore-test-failed") {
+			e.Notify.Events.RestoreTestFailed = true
+		}
 	}
 
 	if err := ctx.SaveConfig(); err != nil {
@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/api"
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/lockdown"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+)
+
+var panicCmd = &cobra.Command{
+	Use:   "panic",
+	Short: "Lock this node down: freeze all approvals and storage writes",
+	Long: `Press the panic button when this node, or a key, is suspected compromised.
+
+Until the lockdown is lifted:
+  - new restore and deletion requests are refused
+  - approvals, and the execution of approved requests, are suspended
+  - the storage server, if this node runs one, is read-only
+
+The lockdown is passed on to the peer and every key holder with an address,
+and the notification providers are told (enable the 'lockdown' event with
+'airgapper notify events --lockdown'). Anyone can press the button; lifting
+the lockdown takes the signatures of enough key holders (the consensus
+threshold, or both owner and host in SSS mode), so one stolen key can't
+undo it.`,
+	Example: `  airgapper panic --reason "laptop stolen"
+  airgapper panic status`,
+	RunE: runners.Config().Wrap(runPanic),
+}
+
+var panicStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the lockdown in force, and who can lift it",
+	RunE:  runners.Config().Wrap(runPanicStatus),
+}
+
+var panicSignCmd = &cobra.Command{
+	Use:   "sign [lockdown-id]",
+	Short: "Sign the lifting of a lockdown with this node's key",
+	Long: `Sign the lifting of a lockdown (by default the one in force here) and print
+the signature as keyID:hex, to hand to whoever runs 'airgapper panic lift'.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runners.Config().Wrap(runPanicSign),
+}
+
+var panicLiftCmd = &cobra.Command{
+	Use:   "lift",
+	Short: "Lift the lockdown with enough key holders' signatures",
+	Long: `Lift the lockdown in force, here and on the peer and every key holder, given
+the signatures of enough key holders from 'airgapper panic sign'. Each node
+checks the signatures against its own keys.
+
+A node with no signing keys at all (an SSS node that never set one up) can
+only be lifted here, without signatures.`,
+	Example: `  airgapper panic lift --sig 1a2b3c4d5e6f7a8b:9f8e... --sig 8b7a6f5e4d3c2b1a:0a1b...`,
+	RunE:    runners.Config().Wrap(runPanicLift),
+}
+
+func init() {
+	panicCmd.Flags().String("reason", "", "Why the node is locked down, shown to the other key holders")
+	panicLiftCmd.Flags().StringSlice("sig", nil, "Lift signature as keyID:hex (repeat for each key holder)")
+
+	panicCmd.AddCommand(panicStatusCmd)
+	panicCmd.AddCommand(panicSignCmd)
+	panicCmd.AddCommand(panicLiftCmd)
+	rootCmd.AddCommand(panicCmd)
+}
+
+func runPanic(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	reason := flags.String("reason")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	srv, err := lockdownStorage(ctx.Config)
+	if err != nil {
+		return err
+	}
+	l, err := lockdown.New(reason, ctx.Config.Name, ctx.Config.Name)
+	if err != nil {
+		return err
+	}
+	current, err := api.Panic(cmd.Context(), ctx.Config, srv, l, true)
+	if err != nil {
+		return err
+	}
+	if current.ID != l.ID {
+		logging.Info("Node was already locked down", logging.String("id", current.ID), logging.String("reason", current.Reason))
+		return nil
+	}
+
+	logging.Warn("Node locked down: approvals and new requests are suspended",
+		logging.String("id", current.ID),
+		logging.Bool("storageFrozen", srv != nil))
+	signers := ctx.Config.LockdownSigners()
+	if len(signers.Keys) > 0 {
+		logging.Infof("To lift it, %d key holders run 'airgapper panic sign %s' and one runs 'airgapper panic lift --sig ...'",
+			signers.Threshold, current.ID)
+	} else {
+		logging.Info("No signing keys are configured: lift it on this node with 'airgapper panic lift'")
+	}
+	return nil
+}
+
+func runPanicStatus(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	l := lockdown.Load(ctx.Config.ConfigDir)
+	if l == nil {
+		logging.Info("Not locked down")
+		return nil
+	}
+	signers := ctx.Config.LockdownSigners()
+	logging.Warn("Locked down",
+		logging.String("id", l.ID),
+		logging.String("reason", l.Reason),
+		logging.String("triggeredBy", l.TriggeredBy),
+		logging.String("origin", l.Origin),
+		logging.String("since", l.Since.Local().Format("2006-01-02 15:04:05")))
+	logging.Info("Signatures needed to lift it",
+		logging.Int("threshold", signers.Threshold),
+		logging.Int("keys", len(signers.Keys)))
+	return nil
+}
+
+func runPanicSign(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	if ctx.Config.PrivateKey == nil {
+		return fmt.Errorf("this node has no signing key")
+	}
+	var id string
+	if len(args) == 1 {
+		id = args[0]
+	} else if l := lockdown.Load(ctx.Config.ConfigDir); l != nil {
+		id = l.ID
+	} else {
+		return fmt.Errorf("not locked down here: give the lockdown ID")
+	}
+
+	sig, err := lockdown.Sign(id, ctx.Config.PrivateKey, ctx.Config.PublicKey)
+	if err != nil {
+		return err
+	}
+	logging.Info("Lift signed", logging.String("id", id))
+	logging.Info("Give this to whoever lifts the lockdown: --sig " + sig.String())
+	return nil
+}
+
+func runPanicLift(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	sigFlags := flags.StringSlice("sig")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	l := lockdown.Load(ctx.Config.ConfigDir)
+	if l == nil {
+		logging.Info("Not locked down")
+		return nil
+	}
+	var sigs []lockdown.Signature
+	for _, f := range sigFlags {
+		sig, err := lockdown.ParseSignature(f)
+		if err != nil {
+			return err
+		}
+		sigs = append(sigs, sig)
+	}
+
+	signers := ctx.Config.LockdownSigners()
+	propagate := len(signers.Keys) > 0
+	if propagate {
+		if err := signers.Verify(l.ID, sigs); err != nil {
+			return err
+		}
+	} else {
+		logging.Warn("No signing keys are configured; lifting the lockdown on this node only")
+	}
+
+	srv, err := lockdownStorage(ctx.Config)
+	if err != nil {
+		return err
+	}
+	if err := api.LiftLockdown(cmd.Context(), ctx.Config, srv, l.ID, sigs, propagate); err != nil {
+		return err
+	}
+	logging.Info("Lockdown lifted", logging.String("id", l.ID))
+	return nil
+}
+
+// lockdownStorage opens this node's storage, if it has any, to freeze or
+// unfreeze it
+func lockdownStorage(cfg *config.Config) (*storage.Server, error) {
+	if cfg.StoragePath == "" {
+		return nil, nil
+	}
+	return storage.NewServer(storage.Config{BasePath: cfg.StoragePath, AppendOnly: true})
+}
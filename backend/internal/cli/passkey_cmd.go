@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/passkey"
+)
+
+// approvalPagePath is where the web UI serves the mobile approval page
+const approvalPagePath = "/approve.html"
+
+var passkeyCmd = &cobra.Command{
+	Use:   "passkey",
+	Short: "Manage the passkeys that approve requests from a phone",
+	Long: `Approve and deny restore requests from your phone instead of the CLI.
+
+The node serves a small approval page at /approve.html. You sign in to it
+with a passkey (Face ID, a fingerprint or the phone's PIN) registered here,
+and the node then approves as this node's key holder: it signs the approval
+with its own key, which never leaves the node.
+
+'passkey enroll' prints a one-time link, valid for 15 minutes; open it on
+the phone to register its passkey. Browsers only allow passkeys on https
+pages (or localhost), so serve with TLS and use the name the phone reaches
+the node by.`,
+}
+
+var passkeyEnrollCmd = &cobra.Command{
+	Use:   "enroll",
+	Short: "Print a one-time link that registers a phone's passkey",
+	Example: `  airgapper passkey enroll --name "bob's phone" --url https://bob-nas.local:8081
+  airgapper passkey enroll --url https://nas.example.com`,
+	Args: cobra.NoArgs,
+	RunE: runners.Config().Wrap(runPasskeyEnroll),
+}
+
+var passkeyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List registered passkeys",
+	RunE:  runners.Config().Wrap(runPasskeyList),
+}
+
+var passkeyRemoveCmd = &cobra.Command{
+	Use:   "remove <id>",
+	Short: "Remove a passkey, e.g. a lost phone's; its sign-ins end at once",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runners.Config().Wrap(runPasskeyRemove),
+}
+
+func init() {
+	passkeyEnrollCmd.Flags().String("name", "phone", "Name to remember the passkey by")
+	passkeyEnrollCmd.Flags().String("url", "", "Base URL the phone reaches this node's API at (default: the local listen address)")
+
+	passkeyCmd.AddCommand(passkeyEnrollCmd)
+	passkeyCmd.AddCommand(passkeyListCmd)
+	passkeyCmd.AddCommand(passkeyRemoveCmd)
+	rootCmd.AddCommand(passkeyCmd)
+}
+
+func runPasskeyEnroll(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	name := flags.String("name")
+	base := flags.String("url")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	if base == "" {
+		base = localURL(ctx.Config, resolveAddr(cmd, ctx.Config))
+	}
+	u, err := url.Parse(base)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid --url %q", base)
+	}
+	if u.Scheme != "https" && u.Hostname() != "localhost" {
+		logging.Warn("Browsers only allow passkeys on https pages; serve with TLS and pass its https URL")
+	}
+
+	token, err := passkey.NewStore(ctx.Config.ConfigDir).Enroll(name, time.Now())
+	if err != nil {
+		return err
+	}
+	link := strings.TrimSuffix(base, "/") + approvalPagePath + "#enroll=" + token
+	logging.Info("Open this link on the phone to register its passkey (valid once, for 15 minutes)",
+		logging.String("name", name))
+	fmt.Println(link)
+	return nil
+}
+
+func runPasskeyList(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	list, err := passkey.NewStore(ctx.Config.ConfigDir).List()
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		logging.Info("No passkeys registered; add one with 'airgapper passkey enroll'")
+		return nil
+	}
+	for _, c := range list {
+		lastUsed := "never"
+		if c.LastUsedAt != nil {
+			lastUsed = c.LastUsedAt.Local().Format("2006-01-02 15:04")
+		}
+		logging.Info(c.ID,
+			logging.String("name", c.Name),
+			logging.String("origin", c.Origin),
+			logging.String("created", c.CreatedAt.Local().Format("2006-01-02 15:04")),
+			logging.String("lastUsed", lastUsed))
+	}
+	return nil
+}
+
+func runPasskeyRemove(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	if err := passkey.NewStore(ctx.Config.ConfigDir).Remove(args[0]); err != nil {
+		return err
+	}
+	logging.Info("Passkey removed", logging.String("id", args[0]))
+	return nil
+}
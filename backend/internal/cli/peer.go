@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/api"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/grpc"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/peerqueue"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+)
+
+// peerTokenKey carries the configured peer's API token in a context
+type peerTokenKey struct{}
+
+// peerToken is the API token a peer with user accounts issued us, and the
+// address it is for
+type peerToken struct {
+	address string
+	token   string
+}
+
+// withPeerToken returns ctx carrying the API token the configured peer
+// issued us, if any. postToPeer and getFromPeer send it only to that peer,
+// so a --peer pointing elsewhere never sees it.
+func withPeerToken(ctx context.Context, cfg *config.Config) context.Context {
+	if cfg.Peer == nil || cfg.Peer.Token == "" || cfg.Peer.Address == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, peerTokenKey{}, peerToken{address: strings.TrimSuffix(cfg.Peer.Address, "/"), token: cfg.Peer.Token})
+}
+
+// setPeerToken adds ctx's peer token to req if req is for that peer
+func setPeerToken(ctx context.Context, req *http.Request) {
+	t, ok := ctx.Value(peerTokenKey{}).(peerToken)
+	if ok && strings.HasPrefix(req.URL.String(), t.address+"/") {
+		req.Header.Set("Authorization", "Bearer "+t.token)
+	}
+}
+
+// postToPeer POSTs a JSON body to a peer, sending the correlation ID in ctx
+// so the peer's logs and audit entries for the call can be matched with ours.
+// Calls to the configured peer are checked against its pinned identity.
+func postToPeer(ctx context.Context, timeout time.Duration, url string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	setPeerToken(ctx, req)
+	return api.PeerClient(cfg, timeout).Do(req)
+}
+
+// getFromPeer GETs a peer endpoint, sending the correlation ID in ctx
+func getFromPeer(ctx context.Context, timeout time.Duration, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	setPeerToken(ctx, req)
+	return api.PeerClient(cfg, timeout).Do(req)
+}
+
+// peerError describes a peer's error response, including the correlation
+// ID to search for in the peer's logs. If the peer sent a coded error body,
+// the returned error carries its code.
+func peerError(what string, resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	msg := strings.TrimSpace(string(data))
+
+	var body struct {
+		Code    apperrors.Code `json:"code"`
+		Message string         `json:"message"`
+	}
+	if json.Unmarshal(data, &body) == nil && body.Code != "" {
+		return apperrors.Newf(body.Code, "%s (%s, %s, request ID %s): %s", what, resp.Status, body.Code, resp.Header.Get(tracing.Header), body.Message)
+	}
+	return fmt.Errorf("%s (%s, request ID %s): %s", what, resp.Status, resp.Header.Get(tracing.Header), msg)
+}
+
+// peerCapabilities asks the peer at addr what it supports
+func peerCapabilities(ctx context.Context, addr string) (*api.Capabilities, error) {
+	return api.PeerCapabilities(ctx, addr, func(req *http.Request) (*http.Response, error) {
+		setPeerToken(ctx, req)
+		return api.PeerClient(cfg, 10*time.Second).Do(req)
+	})
+}
+
+// checkPeerFeature returns an error if the peer at addr announces it lacks
+// feature. A peer that can't be asked is left for the call itself to fail.
+func checkPeerFeature(ctx context.Context, addr, feature string) error {
+	caps, err := peerCapabilities(ctx, addr)
+	if err != nil {
+		return nil
+	}
+	return caps.Require(feature)
+}
+
+// featureError is peerError for a call needing feature: a peer without
+// the call's route, which answers with an uncoded 404, is reported as
+// lacking the feature, naming its version
+func featureError(ctx context.Context, addr, feature, what string, resp *http.Response) error {
+	err := peerError(what, resp)
+	var coded *apperrors.Error
+	if resp.StatusCode != http.StatusNotFound || errors.As(err, &coded) {
+		return err
+	}
+	var version string
+	if caps, capsErr := peerCapabilities(ctx, addr); capsErr == nil {
+		version = caps.ServerVersion
+	}
+	return api.Unsupported(version, feature)
+}
+
+// sendToPeer queues a call for the peer and delivers it, after any calls
+// still queued before it, if the peer can be reached. Otherwise it stays
+// queued: 'airgapper serve' keeps retrying, as does 'airgapper peer-queue
+// flush'.
+func sendToPeer(ctx context.Context, cfg *config.Config, m *peerqueue.Message) {
+	q := peerqueue.New(cfg.PeerQueuePath())
+	if err := q.Add(m); err != nil {
+		logging.Warn("Failed to queue the "+m.Kind+" for the peer - run the same command there", logging.Err(err))
+		return
+	}
+	if _, err := q.Deliver(ctx, time.Now(), peerSender(cfg), true); err != nil {
+		logging.Warn("Failed to deliver queued calls to the peer", logging.Err(err))
+	}
+
+	queued, _ := q.List()
+	for _, left := range queued {
+		if left.ID != m.ID {
+			continue
+		}
+		switch {
+		case left.Failed():
+			logging.Warn("Peer did not accept the "+m.Kind+" - run the same command there",
+				logging.String("error", left.LastError), logging.String(tracing.LogKey, m.CorrelationID))
+		case left.Attempts == 0:
+			logging.Warn("Queued the "+m.Kind+" behind calls the peer hasn't received yet; it is sent when the peer is back",
+				logging.String("peer", m.Peer))
+		default:
+			logging.Warn("Could not reach peer - queued the "+m.Kind+" to send when it is back",
+				logging.String("peer", m.Peer), logging.String("error", left.LastError))
+		}
+		return
+	}
+	logging.Infof("Sent the %s to peer %s", m.Kind, m.Peer)
+}
+
+// kindFeatures are the features queued calls need beyond restore requests
+// and their approval
+var kindFeatures = map[string]string{
+	peerqueue.KindVeto:      api.FeatureVeto,
+	peerqueue.KindExtension: api.FeatureExtensions,
+}
+
+// peerSender delivers queued calls, sending the API token the configured
+// peer issued us to that peer only. A call the peer announces it doesn't
+// support is rejected without sending it.
+func peerSender(cfg *config.Config) peerqueue.Sender {
+	return func(ctx context.Context, m *peerqueue.Message) error {
+		ctx = tracing.WithID(withPeerToken(ctx, cfg), m.CorrelationID)
+		feature := kindFeatures[m.Kind]
+		if feature != "" {
+			if err := checkPeerFeature(ctx, m.Peer, feature); err != nil {
+				return fmt.Errorf("%w: %w", peerqueue.ErrRejected, err)
+			}
+		}
+		resp, err := postToPeer(ctx, 30*time.Second, strings.TrimSuffix(m.Peer, "/")+m.Path, m.Body)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		code := apperrors.Code(resp.Header.Get(grpc.ErrorCodeHeader))
+		switch {
+		case resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated:
+			return nil
+		case alreadyDelivered(m.Kind, code):
+			// An earlier attempt got through but its response was lost
+			return nil
+		case resp.StatusCode >= 500, resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests:
+			return peerError("peer failed to take the "+m.Kind, resp)
+		case feature != "":
+			return fmt.Errorf("%w: %w", peerqueue.ErrRejected, featureError(ctx, m.Peer, feature, "peer rejected the "+m.Kind, resp))
+		default:
+			return fmt.Errorf("%w: %w", peerqueue.ErrRejected, peerError("peer rejected the "+m.Kind, resp))
+		}
+	}
+}
+
+// alreadyDelivered reports whether the peer's error code for a call means
+// it already has what the call carries: the request, or an approval of it,
+// after which the request is no longer pending
+func alreadyDelivered(kind string, code apperrors.Code) bool {
+	switch kind {
+	case peerqueue.KindRequest:
+		return code == apperrors.CodeRequestExists
+	case peerqueue.KindApproval:
+		return code == apperrors.CodeAlreadyApproved || code == apperrors.CodeRequestNotPending
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"context"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/peerqueue"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+)
+
+var peerQueueCmd = &cobra.Command{
+	Use:   "peer-queue",
+	Short: "Show and deliver calls waiting for the peer",
+	Long: `Calls for the peer - a new restore request, a released key share, a veto
+or an extension - are queued before they are sent, and stay queued until the
+peer takes them. While 'airgapper serve' runs it retries every minute, backing
+off to hourly while the peer stays unreachable, so the two nodes needn't be
+online at the same time.
+
+Calls are delivered in the order they were made. One the peer rejects, or
+that isn't delivered within 7 days, is kept as failed until it is retried
+or dropped.`,
+	Example: `  airgapper peer-queue list
+  airgapper peer-queue flush
+  airgapper peer-queue retry 3f9a1c2e4b5d6a7f`,
+}
+
+var peerQueueListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the calls waiting for the peer",
+	RunE:  runners.Config().Wrap(runPeerQueueList),
+}
+
+var peerQueueFlushCmd = &cobra.Command{
+	Use:   "flush",
+	Short: "Deliver the queued calls now, without waiting out their backoff",
+	RunE:  runners.Config().Wrap(runPeerQueueFlush),
+}
+
+var peerQueueRetryCmd = &cobra.Command{
+	Use:   "retry <id>",
+	Short: "Deliver a failed call again",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runners.Config().Wrap(runPeerQueueRetry),
+}
+
+var peerQueueDropCmd = &cobra.Command{
+	Use:   "drop <id>",
+	Short: "Remove a call without delivering it",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runners.Config().Wrap(runPeerQueueDrop),
+}
+
+func init() {
+	peerQueueCmd.AddCommand(peerQueueListCmd)
+	peerQueueCmd.AddCommand(peerQueueFlushCmd)
+	peerQueueCmd.AddCommand(peerQueueRetryCmd)
+	peerQueueCmd.AddCommand(peerQueueDropCmd)
+	rootCmd.AddCommand(peerQueueCmd)
+}
+
+func runPeerQueueList(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	messages, err := peerqueue.New(ctx.Config.PeerQueuePath()).List()
+	if err != nil {
+		return err
+	}
+	if len(messages) == 0 {
+		logging.Info("Nothing is waiting for the peer")
+		return nil
+	}
+	for _, m := range messages {
+		status := "waiting"
+		switch {
+		case m.Failed():
+			status = "failed"
+		case !m.NextAttempt.IsZero():
+			status = "retrying at " + m.NextAttempt.Local().Format("2006-01-02 15:04:05")
+		}
+		logging.Info("Queued call",
+			logging.String("id", m.ID),
+			logging.String("kind", m.Kind),
+			logging.String("requestID", m.RequestID),
+			logging.String("peer", m.Peer),
+			logging.String("queued", m.QueuedAt.Local().Format("2006-01-02 15:04:05")),
+			logging.Int("attempts", m.Attempts),
+			logging.String("status", status),
+			logging.String("error", m.LastError))
+	}
+	return nil
+}
+
+func runPeerQueueFlush(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	return deliverPeerQueue(cmd.Context(), ctx.Config, true)
+}
+
+func runPeerQueueRetry(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	if err := peerqueue.New(ctx.Config.PeerQueuePath()).Retry(args[0]); err != nil {
+		return err
+	}
+	return deliverPeerQueue(cmd.Context(), ctx.Config, true)
+}
+
+func runPeerQueueDrop(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	if err := peerqueue.New(ctx.Config.PeerQueuePath()).Drop(args[0]); err != nil {
+		return err
+	}
+	logging.Info("Dropped the queued call", logging.String("id", args[0]))
+	return nil
+}
+
+// deliverPeerQueue delivers the queued calls and reports what is left
+func deliverPeerQueue(ctx context.Context, cfg *config.Config, force bool) error {
+	q := peerqueue.New(cfg.PeerQueuePath())
+	delivered, err := q.Deliver(ctx, time.Now(), peerSender(cfg), force)
+	if err != nil {
+		return err
+	}
+	left, err := q.List()
+	if err != nil {
+		return err
+	}
+	logging.Info("Delivered queued calls to the peer", logging.Int("delivered", delivered), logging.Int("waiting", len(left)))
+	for _, m := range left {
+		if m.LastError != "" {
+			logging.Warn("Not delivered", logging.String("id", m.ID), logging.String("kind", m.Kind),
+				logging.String(tracing.LogKey, m.CorrelationID), logging.String("error", m.LastError))
+		}
+	}
+	return nil
+}
+
+// startPeerQueue delivers calls queued for the peer in the background, so
+// they reach it once it is back
+func startPeerQueue(serveCfg *config.Config) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	q := peerqueue.New(serveCfg.PeerQueuePath())
+	deliver := func() {
+		delivered, err := q.Deliver(ctx, time.Now(), peerSender(serveCfg), false)
+		if err != nil {
+			logging.Warn("Failed to deliver queued calls to the peer", logging.Err(err))
+		}
+		if delivered > 0 {
+			logging.Info("Delivered queued calls to the peer", logging.Int("delivered", delivered))
+		}
+	}
+	go func() {
+		deliver()
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				deliver()
+			}
+		}
+	}()
+	return cancel
+}
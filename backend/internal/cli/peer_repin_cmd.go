@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/api"
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/peerpin"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+)
+
+var peerCmd = &cobra.Command{
+	Use:   "peer",
+	Short: "Manage the configured peer's pinned identity",
+	Long: `The peer's public key and, over HTTPS, its API's TLS certificate are pinned
+the first time it is called, and checked on every later call. If either
+changes, every call to the peer fails, and every command, 'airgapper status'
+and the notification providers raise the alarm, until the new identity is
+accepted with 'airgapper peer repin'.`,
+}
+
+var peerRepinCmd = &cobra.Command{
+	Use:   "repin",
+	Short: "Accept the peer's new identity after comparing it with its operator",
+	Long: `Show the identity the peer pinned on first use and the one it presents now.
+Nothing is changed without --confirm.
+
+Accept the new identity only after its operator has confirmed it out of
+band - in person or over a call, not over a channel that goes through the
+peer's address. They see their node's identity code in 'airgapper status'.
+If the codes differ, someone may be impersonating the peer: do not repin.
+
+Passing the code shown for the presented identity with --confirm pins it
+and unblocks calls to the peer.`,
+	Example: `  airgapper peer repin
+  airgapper peer repin --confirm 3f1a-9c2e-77d0-b415`,
+	RunE: runners.Config().Wrap(runPeerRepin),
+}
+
+func init() {
+	peerRepinCmd.Flags().String("confirm", "", "Identity code of the presented identity, as shown without --confirm and confirmed by the peer's operator")
+	peerCmd.AddCommand(peerRepinCmd)
+	rootCmd.AddCommand(peerCmd)
+}
+
+func runPeerRepin(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	confirm := flags.String("confirm")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	if ctx.Config.Peer == nil || ctx.Config.Peer.Address == "" {
+		return fmt.Errorf("no peer address is configured")
+	}
+	address := ctx.Config.Peer.Address
+	store := peerpin.NewStore(ctx.Config.ConfigDir)
+	pin, err := store.Get()
+	if err != nil {
+		return err
+	}
+
+	// Asked without the pin's checks, which would refuse a changed peer
+	req, err := http.NewRequestWithContext(cmd.Context(), http.MethodGet, strings.TrimSuffix(address, "/")+api.APIBasePath+api.CapabilitiesPath, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := tracing.NewClient(10 * time.Second).Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach the peer: %w", err)
+	}
+	_ = resp.Body.Close()
+	seen := peerpin.Observe(resp)
+	if seen.IsZero() {
+		return fmt.Errorf("the peer at %s announces no public key and serves plain HTTP; there is nothing to pin", address)
+	}
+
+	if pin != nil {
+		logPeerIdentity("Pinned", pin.Identity)
+		if pin.Change != nil {
+			logging.Error("Changed", logging.String("fields", strings.Join(pin.Change.Fields, ", ")),
+				logging.String("seen", pin.Change.SeenAt.Local().Format(time.DateTime)))
+		}
+	}
+	logPeerIdentity("Presented", seen)
+
+	if pin != nil && pin.Change == nil && pin.Identity == seen {
+		logging.Info("The peer presents its pinned identity; nothing to repin")
+		return nil
+	}
+	if confirm == "" {
+		logging.Warn("Compare the presented identity code with the one the peer's operator sees in 'airgapper status', " +
+			"then run 'airgapper peer repin --confirm " + seen.Code() + "'")
+		return nil
+	}
+	if confirm != seen.Code() {
+		return fmt.Errorf("--confirm %s does not match the presented identity %s; the peer's identity may have changed again", confirm, seen.Code())
+	}
+	if err := store.Repin(address, seen, time.Now()); err != nil {
+		return err
+	}
+	logging.Info("Peer repinned", logging.String("code", seen.Code()))
+	return nil
+}
+
+// logPeerIdentity logs id with its code
+func logPeerIdentity(label string, id peerpin.Identity) {
+	key := "(none)"
+	if b, err := hex.DecodeString(id.PublicKey); err == nil && len(b) > 0 {
+		key = crypto.KeyID(b)
+	}
+	tls := id.TLSFingerprint
+	if tls == "" {
+		tls = "(plain HTTP)"
+	}
+	logging.Info(label,
+		logging.String("code", id.Code()),
+		logging.String("key_id", key),
+		logging.String("tls_sha256", tls))
+}
+
+// warnPeerIdentityChanged raises the alarm on every command while the
+// peer's identity change hasn't been accepted
+func warnPeerIdentityChanged(cfg *config.Config) {
+	if cfg == nil || cfg.ConfigDir == "" {
+		return
+	}
+	pin, err := peerpin.NewStore(cfg.ConfigDir).Get()
+	if err != nil {
+		logging.Warn("Failed to read the peer pin", logging.Err(err))
+		return
+	}
+	if err := pin.Err(); err != nil {
+		logging.Error(err.Error())
+	}
+}
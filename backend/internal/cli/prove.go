@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/api"
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+	"github.com/lcrostarosa/airgapper/backend/internal/verification"
+)
+
+var proveCmd = &cobra.Command{
+	Use:   "prove",
+	Short: "Challenge the host to prove it still stores your data (owner only)",
+	Long: `Send the host random byte ranges of repository files together with a fresh
+nonce. The host hashes each range with the nonce and signs the answer; the
+hashes are checked against the copies in your local restic cache (index files
+and tree packs), so the host can only pass by actually holding the data.`,
+	Example: `  airgapper prove
+  airgapper prove --count 32 --range-size 4096`,
+	RunE: runners.Owner().Use(runner.RequirePassword()).Wrap(runProve),
+}
+
+func init() {
+	f := proveCmd.Flags()
+	f.Int("count", 8, "Number of ranges to challenge")
+	f.Int("range-size", 64*1024, "Maximum bytes per range")
+	f.String("host", "", "Host API address (default: the peer's address)")
+	rootCmd.AddCommand(proveCmd)
+}
+
+func runProve(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	count := flags.Int("count")
+	rangeSize := flags.Int("range-size")
+	hostAddr := flags.String("host")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	if count < 1 || count > verification.MaxProofRanges {
+		return fmt.Errorf("--count must be between 1 and %d", verification.MaxProofRanges)
+	}
+	if rangeSize < 1 || rangeSize > verification.MaxProofRangeLength {
+		return fmt.Errorf("--range-size must be between 1 and %d", verification.MaxProofRangeLength)
+	}
+
+	if ctx.Config.Peer == nil || len(ctx.Config.Peer.PublicKey) == 0 {
+		return fmt.Errorf("no host public key configured; proofs can't be verified")
+	}
+	if hostAddr == "" {
+		hostAddr = ctx.Config.Peer.Address
+	}
+	if hostAddr == "" {
+		return fmt.Errorf("no host address configured; use --host")
+	}
+
+	repo, err := repoName(ctx.Config.RepoURL)
+	if err != nil {
+		return err
+	}
+
+	if !restic.IsInstalled() {
+		return fmt.Errorf("restic is not installed")
+	}
+	client := restic.NewClient(ctx.Config.RepoURL, ctx.Config.Password).WithIdentity(ctx.Config.PrivateKey).WithOptions(ctx.Config.Restic)
+	repoID, err := client.RepoID(cmd.Context())
+	if err != nil {
+		return err
+	}
+	cacheDir, err := client.CacheDir(repoID)
+	if err != nil {
+		return err
+	}
+	files, err := restic.CachedFiles(cacheDir)
+	if err != nil {
+		return fmt.Errorf("failed to read restic cache: %w", err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("restic cache %s is empty; run 'airgapper snapshots' to populate it", cacheDir)
+	}
+
+	paths := make(map[string]string)
+	var ranges []verification.ProofRange
+	for i := 0; i < count; i++ {
+		f := files[rand.IntN(len(files))]
+		length := min(int64(rangeSize), f.Size)
+		ranges = append(ranges, verification.ProofRange{
+			Type:   f.Type,
+			ID:     f.ID,
+			Offset: rand.Int64N(f.Size - length + 1),
+			Length: length,
+		})
+		paths[f.ID] = f.Path
+	}
+
+	challenge, err := verification.NewProofChallenge(repo, ranges)
+	if err != nil {
+		return err
+	}
+
+	challengeCtx, correlationID := tracing.Ensure(cmd.Context())
+	logging.Info("Sending storage challenge",
+		logging.String("host", hostAddr),
+		logging.String("repo", repo),
+		logging.Int("ranges", len(ranges)),
+		logging.String(tracing.LogKey, correlationID))
+
+	proof, err := sendProofChallenge(challengeCtx, hostAddr, challenge)
+	if err != nil {
+		return err
+	}
+
+	read := func(r verification.ProofRange) ([]byte, error) {
+		f, err := os.Open(paths[r.ID])
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = f.Close() }()
+		data := make([]byte, r.Length)
+		if _, err := f.ReadAt(data, r.Offset); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+	if err := challenge.Verify(proof, ctx.Config.Peer.PublicKey, read); err != nil {
+		return fmt.Errorf("storage proof failed: %w", err)
+	}
+
+	logging.Info("Storage proof verified",
+		logging.String("hostKey", crypto.KeyID(ctx.Config.Peer.PublicKey)),
+		logging.Int("ranges", len(proof.Results)),
+		logging.String("signedAt", proof.CreatedAt.Format("2006-01-02 15:04:05")))
+	return nil
+}
+
+// repoName is the repository's name on the host: the last path element
+// of the repository URL
+func repoName(repoURL string) (string, error) {
+	u, err := url.Parse(strings.TrimPrefix(repoURL, "rest:"))
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("repository %q is not served by an airgapper host", repoURL)
+	}
+	name := path.Base(strings.TrimSuffix(u.Path, "/"))
+	if name == "/" || name == "." {
+		return "", fmt.Errorf("repository URL %q has no repository name", repoURL)
+	}
+	return name, nil
+}
+
+// sendProofChallenge posts a challenge to the host and decodes its proof
+func sendProofChallenge(ctx context.Context, hostAddr string, c *verification.ProofChallenge) (*verification.StorageProof, error) {
+	body, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkPeerFeature(ctx, hostAddr, api.FeatureStorageProofs); err != nil {
+		return nil, err
+	}
+	endpoint := strings.TrimSuffix(hostAddr, "/") + api.APIBasePath + api.ProofChallengePath
+	resp, err := postToPeer(ctx, 60*time.Second, endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach host: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, featureError(ctx, hostAddr, api.FeatureStorageProofs, "host rejected challenge", resp)
+	}
+
+	var proof verification.StorageProof
+	if err := json.NewDecoder(resp.Body).Decode(&proof); err != nil {
+		return nil, fmt.Errorf("invalid proof from host: %w", err)
+	}
+	return &proof, nil
+}
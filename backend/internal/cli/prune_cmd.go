@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/api"
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/prune"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Carry out an approved deletion and free its space (owner only)",
+	Long: `Carry out an approved deletion request: forget the snapshots it names
+(for a snapshot deletion), then prune the repository so the space they held
+is freed.
+
+restic prune needs the repository to itself and rewrites much of it, so the
+host is asked to open a prune window first: for its duration the host's
+storage is read-only for everyone but this repository. Stale locks left by
+interrupted commands are removed; a lock held by a running command stops
+the prune until that command finishes. When the prune is over, or if it
+fails, the host closes the window, removes partial uploads and re-baselines
+its usage tracking and integrity checks for the repository.
+
+Progress is recorded on the deletion request as the prune goes, and shown by
+'airgapper prune --request <id> --status'. Running the command again for a
+deletion that failed resumes it.`,
+	Example: `  airgapper prune --request abc123
+  airgapper prune --request abc123 --status`,
+	RunE: runners.Owner().Use(runner.RequirePassword()).Wrap(runPrune),
+}
+
+func init() {
+	f := pruneCmd.Flags()
+	f.String("request", "", "Approved deletion request ID (required)")
+	f.Bool("status", false, "Show the deletion's prune progress instead of running it")
+	_ = pruneCmd.MarkFlagRequired("request")
+	rootCmd.AddCommand(pruneCmd)
+}
+
+func runPrune(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	requestID := flags.String("request")
+	status := flags.Bool("status")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	mgr := ctx.Consent()
+	req, err := mgr.GetDeletionRequest(requestID)
+	if err != nil {
+		return err
+	}
+	if status {
+		logPruneStatus(req)
+		return nil
+	}
+	if err := prune.Check(req); err != nil {
+		return fmt.Errorf("deletion %s can't be carried out: %w", requestID, err)
+	}
+	if !restic.IsInstalled() {
+		return fmt.Errorf("restic is not installed")
+	}
+
+	e := &prune.Executor{
+		Repo: restic.NewClient(ctx.Config.RepoURL, ctx.Config.Password).WithIdentity(ctx.Config.PrivateKey).WithOptions(ctx.Config.Restic),
+		Record: func(p consent.PruneProgress) {
+			if _, err := mgr.RecordPruneProgress(req.ID, p); err != nil {
+				logging.Warn("Failed to record prune progress", logging.Err(err))
+			}
+			if p.Status == consent.ProgressRunning && p.Message == "" {
+				logging.Info("Prune stage", logging.String("stage", p.Stage))
+			}
+		},
+		Output: func(line string) { logging.Info(line) },
+	}
+	// A repository on an airgapper host prunes inside a window the host opens
+	if ctx.Config.Peer != nil && ctx.Config.Peer.Address != "" {
+		if repo, err := repoName(ctx.Config.RepoURL); err == nil {
+			e.Host = &peerPruneHost{cfg: ctx.Config, repo: repo, correlationID: req.CorrelationID}
+		}
+	}
+
+	if err := e.Run(cmd.Context(), req); err != nil {
+		return fmt.Errorf("prune failed: %w", err)
+	}
+	if err := mgr.MarkDeletionExecuted(req.ID); err != nil {
+		return err
+	}
+
+	done, err := mgr.GetDeletionRequest(req.ID)
+	if err != nil {
+		return err
+	}
+	logPruneStatus(done)
+	return nil
+}
+
+// logPruneStatus shows how far a deletion's prune got
+func logPruneStatus(req *consent.DeletionRequest) {
+	p := req.Prune
+	if p == nil {
+		logging.Info("Deletion not carried out yet",
+			logging.String("request", req.ID),
+			logging.String("status", string(req.Status)))
+		return
+	}
+	var started, took string
+	if p.StartedAt != nil {
+		started = p.StartedAt.Local().Format(time.DateTime)
+		if p.FinishedAt != nil {
+			took = p.FinishedAt.Sub(*p.StartedAt).Round(time.Second).String()
+		}
+	}
+	if p.Error != "" {
+		logging.Warn("Prune failed",
+			logging.String("request", req.ID),
+			logging.String("stage", p.Stage),
+			logging.String("started", started),
+			logging.String("error", p.Error))
+		return
+	}
+	logging.Info("Prune",
+		logging.String("request", req.ID),
+		logging.String("status", p.Status),
+		logging.String("stage", p.Stage),
+		logging.String("started", started),
+		logging.String("took", took),
+		logging.Int64("freedBytes", p.FreedBytes),
+		logging.String("message", p.Message))
+}
+
+// peerPruneHost opens and closes prune windows on the peer storing the
+// repository
+type peerPruneHost struct {
+	cfg           *config.Config
+	repo          string
+	correlationID string
+}
+
+func (h *peerPruneHost) BeginPrune(ctx context.Context, deletionID string) error {
+	resp, err := h.post(ctx, "/host/prune/begin", deletionID)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+func (h *peerPruneHost) EndPrune(ctx context.Context, deletionID string) (*storage.PruneResult, error) {
+	resp, err := h.post(ctx, "/host/prune/end", deletionID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var result storage.PruneResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid prune result from peer: %w", err)
+	}
+	return &result, nil
+}
+
+// post sends a prune window request, returning the response if the peer
+// accepted it. Closing a window re-baselines the repository's integrity,
+// which can take a while.
+func (h *peerPruneHost) post(ctx context.Context, path, deletionID string) (*http.Response, error) {
+	ctx = tracing.WithID(withPeerToken(ctx, h.cfg), h.correlationID)
+	if err := checkPeerFeature(ctx, h.cfg.Peer.Address, api.FeatureDeletion); err != nil {
+		return nil, err
+	}
+	data, _ := json.Marshal(map[string]string{"deletion_id": deletionID, "repo": h.repo})
+	endpoint := strings.TrimSuffix(h.cfg.Peer.Address, "/") + api.APIBasePath + path
+	resp, err := postToPeer(ctx, 10*time.Minute, endpoint, data)
+	if err != nil {
+		return nil, fmt.Errorf("could not reach peer: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer func() { _ = resp.Body.Close() }()
+		return nil, featureError(ctx, h.cfg.Peer.Address, api.FeatureDeletion, "peer rejected the prune window", resp)
+	}
+	return resp, nil
+}
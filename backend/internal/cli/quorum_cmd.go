@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+var quorumCmd = &cobra.Command{
+	Use:   "quorum",
+	Short: "Show or change the approval quorum rule",
+	Long: `By default a restore needs a flat number of approvals (the threshold).
+A quorum rule can instead make some approvals mandatory and give keys
+different weights, e.g. "owner + any one host".`,
+	RunE: runners.Owner().Wrap(runQuorumShow),
+}
+
+var quorumSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Set the approval quorum rule",
+	Example: `  # Owner plus any one other key holder
+  airgapper quorum set --require-owner --min-weight 2
+
+  # Any two approvals, but the auditor's key counts double
+  airgapper quorum set --weight 3f9a1c2b7d4e5f60=2 --min-weight 2`,
+	RunE: runners.Owner().Wrap(runQuorumSet),
+}
+
+var quorumClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Go back to a flat approval threshold",
+	RunE:  runners.Owner().Wrap(runQuorumClear),
+}
+
+func init() {
+	f := quorumSetCmd.Flags()
+	f.Bool("require-owner", false, "The owner's approval is mandatory")
+	f.StringSlice("require", nil, "Key ID whose approval is mandatory (can specify multiple)")
+	f.StringSlice("weight", nil, "Key weight as <key-id>=<weight> (can specify multiple; default 1)")
+	f.Int("min-weight", 0, "Total weight of approvals needed (default: threshold)")
+
+	quorumCmd.AddCommand(quorumSetCmd)
+	quorumCmd.AddCommand(quorumClearCmd)
+	rootCmd.AddCommand(quorumCmd)
+}
+
+func runQuorumShow(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	if ctx.Config.Consensus == nil {
+		return fmt.Errorf("consensus mode not configured")
+	}
+
+	q, err := ctx.Config.Quorum()
+	if err != nil {
+		return fmt.Errorf("invalid quorum rule: %w", err)
+	}
+	if q == nil {
+		logging.Info("Flat approval threshold",
+			logging.Int("threshold", ctx.Config.Consensus.Threshold),
+			logging.Int("keyHolders", ctx.Config.Consensus.TotalKeys))
+		return nil
+	}
+
+	logging.Info("Quorum rule", logging.String("rule", q.String()))
+	for _, kh := range ctx.Config.Consensus.KeyHolders {
+		logging.Info(kh.Name,
+			logging.String("keyID", kh.ID),
+			logging.Int("weight", q.Weight(kh.ID)))
+	}
+	return nil
+}
+
+func runQuorumSet(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	if ctx.Config.Consensus == nil {
+		return fmt.Errorf("consensus mode not configured")
+	}
+
+	flags := runner.Flags(cmd)
+	qc := &config.QuorumConfig{
+		RequireOwner: flags.Bool("require-owner"),
+		Required:     flags.StringSlice("require"),
+		MinWeight:    flags.Int("min-weight"),
+	}
+	weights := flags.StringSlice("weight")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	if len(weights) > 0 {
+		qc.Weights = make(map[string]int, len(weights))
+		for _, w := range weights {
+			id, value, ok := strings.Cut(w, "=")
+			n, err := strconv.Atoi(value)
+			if !ok || err != nil {
+				return fmt.Errorf("invalid --weight %q: use <key-id>=<weight>", w)
+			}
+			qc.Weights[id] = n
+		}
+	}
+
+	previous := ctx.Config.Consensus.Quorum
+	ctx.Config.Consensus.Quorum = qc
+	q, err := ctx.Config.Quorum()
+	if err != nil {
+		ctx.Config.Consensus.Quorum = previous
+		return err
+	}
+	if err := ctx.Config.Save(); err != nil {
+		return err
+	}
+
+	logging.Info("Quorum rule set", logging.String("rule", q.String()))
+	logging.Info("New restore requests use this rule; pending requests keep theirs")
+	return nil
+}
+
+func runQuorumClear(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	if ctx.Config.Consensus == nil {
+		return fmt.Errorf("consensus mode not configured")
+	}
+
+	ctx.Config.Consensus.Quorum = nil
+	if err := ctx.Config.Save(); err != nil {
+		return err
+	}
+	logging.Info("Quorum rule cleared", logging.Int("threshold", ctx.Config.Consensus.Threshold))
+	return nil
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/recoverykit"
+)
+
+var recoveryKitCmd = &cobra.Command{
+	Use:   "recovery-kit",
+	Short: "Write a disaster recovery kit: printable instructions and an encrypted archive (owner only)",
+	Long: `Write a disaster recovery kit to --out:
+
+  RECOVERY.txt      printable instructions: the repository, who holds which
+                    key share, and the exact commands to recover with
+                    'airgapper self-restore' or with plain restic if
+                    Airgapper is gone
+  recovery-kit.enc  this node's state, encrypted with the repository
+                    password, for 'airgapper self-restore --kit'
+  SHA256SUMS        checksums to verify both
+
+The kit holds no password and no key share, so it can be printed and kept
+offline. Once written, it is regenerated in the same place whenever the
+key material or approval policy changes.`,
+	Example: `  airgapper recovery-kit --out /media/usb/airgapper-recovery`,
+	RunE:    runners.OwnerWithPassword().Wrap(runRecoveryKit),
+}
+
+func init() {
+	recoveryKitCmd.Flags().StringP("out", "o", "airgapper-recovery-kit", "Directory to write the kit to")
+	rootCmd.AddCommand(recoveryKitCmd)
+}
+
+func runRecoveryKit(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	outDir := flags.String("out")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	m, err := recoverykit.Write(ctx.Config, outDir)
+	if err != nil {
+		return err
+	}
+	logging.Info("Recovery kit written",
+		logging.String("dir", outDir),
+		logging.String("mode", m.Mode),
+		logging.Int("holders", len(m.Holders)))
+	logging.Infof("Print %s and keep it with the kit, offline. Verify the files with: sha256sum -c %s",
+		recoverykit.InstructionsFile, recoverykit.ChecksumsFile)
+	return nil
+}
+
+// refreshRecoveryKit rewrites the recovery kit where it was last written
+// if the key material or approval policy has changed since
+func refreshRecoveryKit(cfg *config.Config) {
+	if cfg == nil || !cfg.IsOwner() || cfg.Password == "" {
+		return
+	}
+	stale, st, err := recoverykit.Stale(cfg)
+	if err != nil {
+		logging.Warn("Failed to check the recovery kit", logging.Err(err))
+		return
+	}
+	if !stale {
+		return
+	}
+	if _, err := recoverykit.Write(cfg, st.Dir); err != nil {
+		logging.Warn("Recovery kit is out of date and could not be regenerated; run 'airgapper recovery-kit'",
+			logging.String("dir", st.Dir), logging.Err(err))
+		return
+	}
+	logging.Info("Key material or policy changed; recovery kit regenerated", logging.String("dir", st.Dir))
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/repair"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+var repairCmd = &cobra.Command{
+	Use:   "repair",
+	Short: "Repair files the host found corrupt (owner only)",
+	Long: `List the files the host found corrupt in this owner's repository, and
+repair them.
+
+When the host's integrity checks or read verification find a corrupt file,
+the host asks the owner to repair it. The next backup, scheduled or run by
+hand, salvages the damaged pack with 'restic repair packs' (or rebuilds a
+damaged index) and re-reads every file, uploading again what was lost. The
+host lets a corrupt pack or index be deleted even in append-only mode, and
+records the repair in its audit chain.
+
+--run repairs now instead of waiting, backing up the configured backup
+paths, or the paths given.
+
+Snapshot and key files can't be rebuilt from the source data. They stay on
+the list for a person to deal with.`,
+	Example: `  airgapper repair
+  airgapper repair --run
+  airgapper repair --run ~/Documents`,
+	RunE: runners.Owner().Use(runner.RequirePassword()).Wrap(runRepair),
+}
+
+func init() {
+	repairCmd.Flags().Bool("run", false, "Repair now by backing up the configured paths, or those given")
+	rootCmd.AddCommand(repairCmd)
+}
+
+func runRepair(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	run := flags.Bool("run")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	if len(args) > 0 && !run {
+		return fmt.Errorf("paths are only used with --run")
+	}
+
+	store := repair.NewStore(ctx.Config.RepairsPath())
+	pending, err := store.Pending()
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		logging.Info("No files awaiting repair")
+		return nil
+	}
+	for _, r := range pending {
+		if r.LastError != "" {
+			logging.Warn("Awaiting repair",
+				logging.String("file", r.File),
+				logging.String("foundOn", r.Source),
+				logging.String("detected", r.DetectedAt.Local().Format(time.DateTime)),
+				logging.String("lastError", r.LastError))
+		} else {
+			logging.Info("Awaiting repair",
+				logging.String("file", r.File),
+				logging.String("foundOn", r.Source),
+				logging.String("detected", r.DetectedAt.Local().Format(time.DateTime)))
+		}
+	}
+	if !run {
+		logging.Info("The next backup repairs these; 'airgapper repair --run' repairs them now")
+		return nil
+	}
+
+	paths := args
+	if len(paths) == 0 {
+		paths = ctx.Config.BackupPaths
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no backup paths configured; give the paths to back up")
+	}
+	if !restic.IsInstalled() {
+		return fmt.Errorf("restic is not installed")
+	}
+
+	client := restic.NewClient(ctx.Config.RepoURL, ctx.Config.Password).WithIdentity(ctx.Config.PrivateKey).WithOptions(ctx.Config.Restic).WithCompression(ctx.Config.BackupCompression)
+	tags := restic.BackupTags(slices.Clone(ctx.Config.BackupTags)...)
+	summary, err := repair.Backup(cmd.Context(), store, client, paths, tags, ctx.Config.BackupExclude...)
+	if err != nil {
+		return fmt.Errorf("repair failed: %w", err)
+	}
+
+	left, err := store.Pending()
+	if err != nil {
+		return err
+	}
+	logging.Info("Repair backup complete",
+		logging.String("snapshot", summary.SnapshotID),
+		logging.Int("stillPending", len(left)))
+	return nil
+}
@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/replication"
+)
+
+var replicateCmd = &cobra.Command{
+	Use:   "replicate",
+	Short: "Copy snapshots to secondary repositories",
+	Long: `Configure secondary repositories (a second host or cloud storage) that
+receive a copy of every snapshot. While 'airgapper serve' is running,
+snapshots are copied with 'restic copy' after each successful scheduled
+backup, and targets that fall out of sync are reported.`,
+}
+
+var replicateAddCmd = &cobra.Command{
+	Use:   "add <name> <repo-url>",
+	Short: "Add a replication target",
+	Example: `  # Replicate to a second Airgapper host
+  airgapper replicate add offsite https://host2:8000/backups
+
+  # Replicate to S3 with its own password
+  airgapper replicate add cloud s3:s3.amazonaws.com/bucket/airgapper --password secret`,
+	Args: cobra.ExactArgs(2),
+	RunE: runners.Owner().Wrap(runReplicateAdd),
+}
+
+var replicateRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a replication target",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runners.Owner().Wrap(runReplicateRemove),
+}
+
+var replicateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List replication targets and their status",
+	RunE:  runners.Owner().Wrap(runReplicateList),
+}
+
+var replicateRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Copy snapshots to all enabled targets now",
+	RunE:  runners.OwnerWithPassword().Wrap(runReplicateRun),
+}
+
+func init() {
+	replicateAddCmd.Flags().String("password", "", "Target repository password (default: primary repository password)")
+	replicateAddCmd.Flags().Bool("disabled", false, "Add the target without enabling it")
+
+	replicateCmd.AddCommand(replicateAddCmd)
+	replicateCmd.AddCommand(replicateRemoveCmd)
+	replicateCmd.AddCommand(replicateListCmd)
+	replicateCmd.AddCommand(replicateRunCmd)
+	rootCmd.AddCommand(replicateCmd)
+}
+
+func runReplicateAdd(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	password := flags.String("password")
+	disabled := flags.Bool("disabled")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	target := replication.Target{
+		Name:     args[0],
+		RepoURL:  args[1],
+		Password: password,
+		Enabled:  !disabled,
+	}
+	if err := target.Validate(); err != nil {
+		return err
+	}
+	if ctx.Config.ReplicationTarget(target.Name) != nil {
+		return fmt.Errorf("replication target %q already exists", target.Name)
+	}
+	if target.RepoURL == ctx.Config.RepoURL {
+		return fmt.Errorf("replication target must differ from the primary repository")
+	}
+
+	ctx.Config.Replication = append(ctx.Config.Replication, target)
+	if err := ctx.SaveConfig(); err != nil {
+		return err
+	}
+
+	logging.Info("Added replication target",
+		logging.String("name", target.Name),
+		logging.String("repo", target.RepoURL),
+		logging.Bool("enabled", target.Enabled))
+	return nil
+}
+
+func runReplicateRemove(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	name := args[0]
+	targets := ctx.Config.Replication[:0]
+	found := false
+	for _, t := range ctx.Config.Replication {
+		if t.Name == name {
+			found = true
+			continue
+		}
+		targets = append(targets, t)
+	}
+	if !found {
+		return fmt.Errorf("replication target %q not found", name)
+	}
+
+	ctx.Config.Replication = targets
+	if err := ctx.SaveConfig(); err != nil {
+		return err
+	}
+
+	logging.Info("Removed replication target", logging.String("name", name))
+	return nil
+}
+
+func runReplicateList(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	if len(ctx.Config.Replication) == 0 {
+		logging.Info("No replication targets configured")
+		logging.Info("Add one with: airgapper replicate add offsite https://host2:8000/backups")
+		return nil
+	}
+
+	statuses := replication.LoadStatus(ctx.Config.ConfigDir)
+	for _, t := range ctx.Config.Replication {
+		status, ok := statuses[t.Name]
+		if !ok {
+			logging.Info("Replication target",
+				logging.String("name", t.Name),
+				logging.String("repo", t.RepoURL),
+				logging.Bool("enabled", t.Enabled),
+				logging.String("lastRun", "never"))
+			continue
+		}
+		logging.Info("Replication target",
+			logging.String("name", t.Name),
+			logging.String("repo", t.RepoURL),
+			logging.Bool("enabled", t.Enabled),
+			logging.String("lastRun", status.LastRun.Format(time.DateTime)),
+			logging.Bool("inSync", status.InSync),
+			logging.Int("missing", status.MissingCount))
+	}
+	return nil
+}
+
+func runReplicateRun(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	if !ctx.Config.HasReplication() {
+		return fmt.Errorf("no enabled replication targets - add one with: airgapper replicate add")
+	}
+
+	results := newReplicator(ctx.Config).Run(cmd.Context())
+	failed := 0
+	for _, status := range results {
+		logReplicationStatus(status)
+		if !status.InSync {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d replication target(s) out of sync", failed)
+	}
+	return nil
+}
+
+func logReplicationStatus(status replication.TargetStatus) {
+	if status.InSync {
+		logging.Info("Replication target in sync",
+			logging.String("name", status.Name),
+			logging.Int("snapshots", status.TargetSnapshots))
+		return
+	}
+	logging.Error("Replication target out of sync",
+		logging.String("name", status.Name),
+		logging.Int("sourceSnapshots", status.SourceSnapshots),
+		logging.Int("targetSnapshots", status.TargetSnapshots),
+		logging.Int("missing", status.MissingCount),
+		logging.String("error", status.LastError))
+}
+
+// newReplicator creates a replicator from the primary repository to the configured targets
+func newReplicator(cfg *config.Config) *replication.Replicator {
+	source := replication.Repo{URL: cfg.RepoURL, Password: cfg.Password}
+	return replication.NewReplicator(source, cfg.Replication, cfg.ConfigDir)
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+var repoCmd = &cobra.Command{
+	Use:   "repo",
+	Short: "Inspect how restic reaches the repository (owner only)",
+}
+
+var repoEnvCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Show the environment and global flags restic runs with",
+	Long: `Show the environment variables and global flags every restic invocation
+runs with, and where each variable comes from:
+
+  process    Airgapper's own environment (only variables restic or its
+             backends read, such as RESTIC_*, AWS_* and HTTPS_PROXY)
+  config     the "restic" section of config.json, which overrides the process
+  airgapper  set by Airgapper for the repository, overriding both
+
+The "restic" section takes extra variables and global flags:
+
+  "restic": {
+    "env": {"HTTPS_PROXY": "http://proxy:3128", "RESTIC_CACHE_DIR": "/var/cache/restic"},
+    "cacert": ["/etc/ssl/private-ca.pem"],
+    "insecure_tls": false,
+    "pack_size": 64,
+    "flags": ["--limit-upload=1024"]
+  }
+
+Secret values are masked unless --show-secrets is given. --check also opens
+the repository with this environment.`,
+	Example: `  airgapper repo env
+  airgapper repo env --check`,
+	RunE: runners.Owner().Wrap(runRepoEnv),
+}
+
+func init() {
+	repoEnvCmd.Flags().Bool("show-secrets", false, "Show secret values instead of masking them")
+	repoEnvCmd.Flags().Bool("check", false, "Also open the repository with this environment")
+
+	repoCmd.AddCommand(repoEnvCmd)
+	rootCmd.AddCommand(repoCmd)
+}
+
+func runRepoEnv(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	showSecrets := flags.Bool("show-secrets")
+	check := flags.Bool("check")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	if err := ctx.Config.Restic.Validate(); err != nil {
+		logging.Warn("Invalid restic options in config.json", logging.Err(err))
+	}
+	client := restic.NewClient(ctx.Config.RepoURL, ctx.Config.Password).WithIdentity(ctx.Config.PrivateKey).WithOptions(ctx.Config.Restic)
+
+	logging.Info("Repository", logging.String("url", client.RepoURL))
+	for _, v := range client.Environment(os.Environ()) {
+		value := v.Value
+		if !showSecrets && secretEnv(v.Name) {
+			value = maskSecret(value)
+		}
+		logging.Info("  "+v.Name, logging.String("value", value), logging.String("source", v.Source))
+	}
+	if global := ctx.Config.Restic.Args(); len(global) > 0 {
+		logging.Info("Global flags", logging.String("flags", strings.Join(global, " ")))
+	} else {
+		logging.Info("Global flags: None")
+	}
+
+	if !check {
+		return nil
+	}
+	if !restic.IsInstalled() {
+		return fmt.Errorf("restic is not installed")
+	}
+	checkCtx, cancel := context.WithTimeout(cmd.Context(), time.Minute)
+	defer cancel()
+	repo, err := client.RepoConfig(checkCtx)
+	if err != nil {
+		return fmt.Errorf("restic can't open the repository with this environment: %w", err)
+	}
+	logging.Info("Repository opened", logging.String("id", repo.ID), logging.Int("version", repo.Version))
+	return nil
+}
+
+// secretEnv reports whether a variable's value is likely a secret
+func secretEnv(name string) bool {
+	upper := strings.ToUpper(name)
+	if strings.HasSuffix(upper, "_KEY_ID") {
+		return false
+	}
+	for _, s := range []string{"PASSWORD", "SECRET", "TOKEN", "KEY", "CREDENTIAL"} {
+		if strings.Contains(upper, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskSecret hides a secret value, showing only whether it is set
+func maskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return fmt.Sprintf("(set, %d characters)", len(value))
+}
@@ -1,88 +1,526 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/lcrostarosa/airgapper/backend/internal/api"
 	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
 	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/keyprotect"
 	"github.com/lcrostarosa/airgapper/backend/internal/logging"
 	"github.com/lcrostarosa/airgapper/backend/internal/restic"
-	"github.com/lcrostarosa/airgapper/backend/internal/sss"
+	"github.com/lcrostarosa/airgapper/backend/internal/restore"
+	"github.com/lcrostarosa/airgapper/backend/internal/restorejob"
 )
 
 var restoreCmd = &cobra.Command{
 	Use:   "restore",
 	Short: "Restore from a snapshot (requires approval)",
-	Long:  `Restore data from a backup snapshot after approval has been granted.`,
+	Long: `Restore data from a backup snapshot after approval has been granted.
+
+Restore into a --target directory, or --in-place to the original locations.
+Files that already exist are handled by --conflict:
+  skip       keep the existing file (default)
+  overwrite  replace it with the snapshot's version
+  keep-both  keep it and restore the snapshot's version beside it with --suffix
+
+Use --dry-run to list what would be restored, skipped or overwritten, and
+to run the preflight checks of the target without restoring. The same checks
+run before the job restores anything, and it fails if the target's disk is
+too small for the snapshot (restic stats), the target isn't a directory, or
+it lies inside a backed-up path, where the next backup would store the
+restored copy again. Existing files left alone by the default skip are
+warned about.
+
+The restore runs as a job. If 'airgapper serve' is running, the daemon runs
+it in the background and this command only follows it: Ctrl-C, or a dropped
+SSH session, stops following, not the restore. Use --detach to return at
+once. Without a daemon the job runs in this command; if the command stops,
+the job is left to resume, from where it stopped, when the command is run
+again or the daemon starts.
+
+The repository password is recovered with the first of the vault's key
+protections that works, or the one named by --unlock. A passphrase-protected
+share needs --passphrase-env; such a restore runs in this command, since
+the daemon can't be given the passphrase.
+
+If approvers attached restore terms, the restore waits for their start
+windows to open and caps its download rate at their tightest limit. Its
+progress is recorded on the request and reported to the peer; see it with
+'airgapper restore-status'.`,
 	Example: `  airgapper restore --request abc123 --target /restore/path
-  airgapper restore --request abc123 --target ~/recovered`,
+  airgapper restore --request abc123 --target ~/recovered
+  airgapper restore --request abc123 --in-place --dry-run
+  airgapper restore --request abc123 --in-place --conflict keep-both
+  airgapper restore --request abc123 --target /restore/path --detach
+  airgapper restore --request abc123 --target ~/recovered --unlock passphrase --passphrase-env VAULT_PASSPHRASE`,
 	RunE: runners.Owner().Wrap(runRestore),
 }
 
 func init() {
 	f := restoreCmd.Flags()
 	f.String("request", "", "Request ID (required)")
-	f.String("target", "", "Restore target directory (required)")
+	f.String("target", "", "Restore target directory")
+	f.Bool("in-place", false, "Restore to the original locations")
+	f.String("conflict", string(restore.StrategySkip), "What to do with existing files: skip, overwrite or keep-both")
+	f.String("suffix", restore.DefaultSuffix, "Suffix for restored copies with --conflict keep-both")
+	f.Bool("dry-run", false, "Preview the restore and its conflicts without writing files")
+	f.Bool("detach", false, "Submit the restore and return without following it")
+	f.String("addr", "", "API address of the daemon to hand the restore to (default listen_addr, $AIRGAPPER_LISTEN_ADDR or 127.0.0.1:8081)")
+	addUnlockFlags(restoreCmd)
 	_ = restoreCmd.MarkFlagRequired("request")
-	_ = restoreCmd.MarkFlagRequired("target")
+	restoreCmd.MarkFlagsOneRequired("target", "in-place")
+	restoreCmd.MarkFlagsMutuallyExclusive("target", "in-place")
 	rootCmd.AddCommand(restoreCmd)
 }
 
+var restoreStatusCmd = &cobra.Command{
+	Use:   "restore-status <request-id>",
+	Short: "Show a restore's terms and progress",
+	Long: `Show the terms approvers attached to a restore request, and how far its
+restore has got. The node running the restore reports its progress to the
+peer, so hosts can follow it too.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runners.Config().Wrap(runRestoreStatus),
+}
+
+func init() {
+	rootCmd.AddCommand(restoreStatusCmd)
+}
+
+func runRestoreStatus(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	req, err := ctx.Consent().GetRequest(args[0])
+	if err != nil {
+		return err
+	}
+
+	logging.Info("Request",
+		logging.String("id", req.ID),
+		logging.String("status", string(req.Status)),
+		logging.String("snapshot", describeSnapshot(req.SnapshotID)))
+	logRestoreTerms(req)
+	if ctx.Config.IsOwner() {
+		logLatestRestoreJob(ctx.Config, req.ID)
+	}
+
+	p := req.Progress
+	if p == nil {
+		logging.Info("The restore hasn't been started")
+		return nil
+	}
+	logging.Info("Restore progress",
+		logging.String("status", p.Status),
+		logging.String("updated", p.UpdatedAt.Local().Format("2006-01-02 15:04:05")))
+	switch {
+	case p.Status == consent.ProgressScheduled && p.StartsAt != nil:
+		logging.Info("Waiting for the approvers' restore window",
+			logging.String("starts", p.StartsAt.Local().Format("2006-01-02 15:04")))
+	case p.Status == consent.ProgressRunning && p.StartedAt != nil:
+		logging.Info("Restoring",
+			logging.String("started", p.StartedAt.Local().Format("2006-01-02 15:04:05")),
+			logging.Int("files", p.Files))
+	case p.Status == consent.ProgressFailed:
+		logging.Warn("Restore failed", logging.String("error", p.Error))
+	}
+	if p.FinishedAt != nil {
+		logging.Info("Finished",
+			logging.String("at", p.FinishedAt.Local().Format("2006-01-02 15:04:05")),
+			logging.Int("files", p.Files),
+			logging.Int("restored", p.Restored))
+	}
+	return nil
+}
+
+// logLatestRestoreJob shows the newest restore job for a request, if any
+func logLatestRestoreJob(cfg *config.Config, requestID string) {
+	jobs, err := restorejob.NewStore(cfg.RestoreJobsPath()).List()
+	if err != nil {
+		logging.Warn("Failed to read restore jobs", logging.Err(err))
+		return
+	}
+	for i := len(jobs) - 1; i >= 0; i-- {
+		if job := jobs[i]; job.RequestID == requestID {
+			logging.Info("Restore job",
+				logging.String("job", job.ID),
+				logging.String("status", string(job.Status)),
+				logging.String("target", job.Target),
+				logging.String("runner", job.Runner),
+				logging.Int("attempts", job.Attempts),
+				logging.Bool("interrupted", job.Interrupted))
+			if job.Status == restorejob.StatusScheduled || job.Status == restorejob.StatusRunning {
+				logRestoreJob(&job)
+			}
+			return
+		}
+	}
+}
+
+// logRestoreTerms shows the terms approvers attached to a request
+func logRestoreTerms(req *consent.RestoreRequest) {
+	for _, t := range req.Terms {
+		logging.Info("Restore terms",
+			logging.String("id", req.ID),
+			logging.String("by", t.SetBy),
+			logging.String("terms", t.String()))
+	}
+}
+
 func runRestore(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
 	flags := runner.Flags(cmd)
 	requestID := flags.String("request")
 	target := flags.String("target")
+	inPlace := flags.Bool("in-place")
+	conflict := flags.String("conflict")
+	suffix := flags.String("suffix")
+	dryRun := flags.Bool("dry-run")
+	detach := flags.Bool("detach")
 	if err := flags.Err(); err != nil {
 		return err
 	}
+	unlock, err := unlockFlags(cmd)
+	if err != nil {
+		return err
+	}
+	if detach && unlock.passphrase != "" {
+		return fmt.Errorf("--detach can't be used with --passphrase-env: the daemon can't be given the passphrase")
+	}
 
-	req, err := ctx.Consent().GetRequest(requestID)
+	strategy, err := restore.ParseStrategy(conflict)
+	if err != nil {
+		return err
+	}
+	if inPlace {
+		// Snapshot paths are absolute, so the filesystem root restores
+		// each file to where it was backed up from
+		target = "/"
+	} else if target, err = filepath.Abs(target); err != nil {
+		return err
+	}
+	if dryRun {
+		return previewRestore(cmd.Context(), ctx, requestID, target, strategy, suffix, unlock)
+	}
+
+	jobs := restoreJobs(ctx.Config, ctx.Consent(), "cli", unlock)
+	job, err := jobs.Store.Active(requestID)
 	if err != nil {
 		return err
 	}
+	if job != nil {
+		logging.Info("Following the restore job already submitted for this request",
+			logging.String("job", job.ID),
+			logging.String("status", string(job.Status)),
+			logging.String("target", job.Target))
+	} else if job, err = jobs.Submit(restorejob.Spec{RequestID: requestID, Target: target, Conflict: strategy, Suffix: suffix}); err != nil {
+		return err
+	}
 
-	if req.Status != consent.StatusApproved {
-		return fmt.Errorf("request is not approved (status: %s)", req.Status)
+	// The daemon can't be given a passphrase, so a restore that needs one
+	// runs here
+	if unlock.passphrase == "" && daemonRunning(cmd.Context(), ctx.Config, resolveAddr(cmd, ctx.Config)) {
+		if detach {
+			logging.Info("The daemon is running the restore; follow it with 'airgapper restore-status "+requestID+"'",
+				logging.String("job", job.ID))
+			return nil
+		}
+		logging.Info("The daemon is running the restore; Ctrl-C stops following it, not the restore",
+			logging.String("job", job.ID))
+		return followRestoreJob(cmd.Context(), jobs.Store, job.ID, nil)
 	}
+	if detach {
+		logging.Info("The restore runs once 'airgapper serve' starts",
+			logging.String("job", job.ID))
+		return nil
+	}
+
+	// No daemon to hand the restore to, so it runs here. If this command
+	// is stopped, even by a dropped SSH session, the job is left to resume.
+	logging.Info("Restoring in the foreground; if this command stops, run it again or start 'airgapper serve' to resume",
+		logging.String("job", job.ID))
+	runCtx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer stop()
+	done := make(chan error, 1)
+	go func() {
+		_, err := jobs.Run(runCtx, job.ID)
+		done <- err
+	}()
+	return followRestoreJob(cmd.Context(), jobs.Store, job.ID, done)
+}
 
-	if req.ShareData == nil {
-		return fmt.Errorf("approved request missing share data")
+// restoreJobs returns the owner's restore jobs, which the daemon and CLI
+// commands share. name identifies the process in the jobs it runs, and
+// unlock how it recovers the repository password.
+func restoreJobs(cfg *config.Config, requests restorejob.Requests, name string, unlock unlockOptions) *restorejob.Manager {
+	return &restorejob.Manager{
+		Store:    restorejob.NewStore(cfg.RestoreJobsPath()),
+		Requests: requests,
+		Password: func(ctx context.Context, req *consent.RestoreRequest) ([]byte, error) {
+			return recoverPassword(ctx, cfg, req, unlock)
+		},
+		Open: func(password []byte) restorejob.Repo {
+			return restic.NewClient(cfg.RepoURL, string(password)).WithIdentity(cfg.PrivateKey).WithOptions(cfg.Restic)
+		},
+		Report:      api.PeerRestoreReporter(cfg),
+		Name:        name,
+		BackupPaths: cfg.BackupPaths,
 	}
+}
 
-	// Reconstruct password
-	localShare, localIndex, err := ctx.Config.LoadShare()
+// daemonRunning reports whether 'airgapper serve' answers at addr
+func daemonRunning(ctx context.Context, cfg *config.Config, addr string) bool {
+	probeCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(probeCtx, http.MethodGet, localURL(cfg, addr)+api.APIBasePath+api.VersionPath, nil)
 	if err != nil {
-		return err
+		return false
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false
 	}
+	_ = resp.Body.Close()
+	return resp.Header.Get(api.APIVersionHeader) != ""
+}
 
-	peerIndex := byte(1)
-	if localIndex == 1 {
-		peerIndex = 2
+// followRestoreJob logs a job's progress until it finishes. If the job runs
+// in this process, done receives the run's error once it ends.
+func followRestoreJob(ctx context.Context, store *restorejob.Store, id string, done <-chan error) error {
+	ticker := time.NewTicker(followInterval)
+	defer ticker.Stop()
+	var last restorejob.Job
+	for {
+		job, err := store.Get(id)
+		if err != nil {
+			return err
+		}
+		if job.Status != last.Status || job.PercentDone != last.PercentDone {
+			logRestoreJob(job)
+			last = *job
+		}
+		if job.Status.Finished() {
+			return restoreJobResult(job)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-done:
+			if apperrors.CodeOf(err) == apperrors.CodeRestoreJobActive {
+				// Another runner picked the job up first
+				logging.Info("Following the restore job, which another runner has", logging.String("job", id))
+				done = nil
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if job, err = store.Get(id); err != nil {
+				return err
+			}
+			if job.Status == restorejob.StatusQueued {
+				return fmt.Errorf("restore interrupted; run the command again, or start 'airgapper serve', to resume it")
+			}
+			return restoreJobResult(job)
+		case <-ticker.C:
+		}
 	}
+}
 
-	shares := []sss.Share{
-		{Index: localIndex, Data: localShare},
-		{Index: peerIndex, Data: req.ShareData},
+// followInterval is how often a followed job's progress is checked
+const followInterval = 5 * time.Second
+
+// logRestoreJob logs where a job is
+func logRestoreJob(job *restorejob.Job) {
+	switch job.Status {
+	case restorejob.StatusScheduled:
+		if job.StartsAt != nil {
+			logging.Info("Waiting for the approvers' restore window",
+				logging.String("job", job.ID),
+				logging.String("starts", job.StartsAt.Local().Format("2006-01-02 15:04")))
+		}
+	case restorejob.StatusRunning:
+		logging.Info("Restoring",
+			logging.String("job", job.ID),
+			logging.String("done", fmt.Sprintf("%.0f%%", job.PercentDone*100)),
+			logging.Int64("bytes", job.BytesDone),
+			logging.Int64("totalBytes", job.BytesTotal),
+			logging.Int("attempt", job.Attempts))
+	default:
+		logging.Info("Restore job",
+			logging.String("job", job.ID),
+			logging.String("status", string(job.Status)))
 	}
+}
 
-	logging.Info("Reconstructing password from key shares")
-	password, err := sss.Combine(shares)
+// restoreJobResult logs a finished job's conflicts and outcome, and returns
+// its error
+func restoreJobResult(job *restorejob.Job) error {
+	plan := &restore.Plan{Files: job.Conflicts}
+	logRestoreResults(plan)
+	if job.Preflight != nil {
+		logPreflight(job.Preflight, false)
+	}
+	switch job.Status {
+	case restorejob.StatusCompleted:
+		logging.Info("Restore complete",
+			logging.String("target", job.Target),
+			logging.Int("files", job.Files),
+			logging.Int("restored", job.Restored))
+		return nil
+	case restorejob.StatusCancelled:
+		return fmt.Errorf("restore job %s was cancelled", job.ID)
+	}
+	return fmt.Errorf("restore failed: %s", job.Error)
+}
+
+// previewRestore logs what a restore would do, writing no files
+func previewRestore(cmdCtx context.Context, ctx *runner.CommandContext, requestID, target string, strategy restore.Strategy, suffix string, unlock unlockOptions) error {
+	req, err := ctx.Consent().GetRequest(requestID)
 	if err != nil {
-		return fmt.Errorf("failed to reconstruct password: %w", err)
+		return err
+	}
+	if err := restorejob.Check(req, time.Now()); err != nil {
+		return err
+	}
+	if err := ctx.Consent().CheckLockdown(); err != nil {
+		return err
 	}
 
-	logging.Info("Password reconstructed successfully")
-	logging.Info("Starting restore",
-		logging.String("snapshot", req.SnapshotID),
-		logging.String("target", target))
+	password, err := recoverPassword(cmdCtx, ctx.Config, req, unlock)
+	if err != nil {
+		return err
+	}
 
-	client := restic.NewClient(ctx.Config.RepoURL, string(password))
-	if err := client.Restore(cmd.Context(), req.SnapshotID, target); err != nil {
-		return fmt.Errorf("restore failed: %w", err)
+	client := restic.NewClient(ctx.Config.RepoURL, string(password)).WithIdentity(ctx.Config.PrivateKey).WithOptions(ctx.Config.Restic)
+	snapshotID, err := resolveSnapshot(cmdCtx, client, req.SnapshotID)
+	if err != nil {
+		return err
+	}
+	nodes, err := client.ListFiles(cmdCtx, snapshotID)
+	if err != nil {
+		return err
 	}
+	size, err := client.RestoreSize(cmdCtx, snapshotID)
+	if err != nil {
+		return err
+	}
+	plan := restore.NewPlan(target, nodes, strategy, suffix)
+	logRestoreResults(plan)
+	preflight := restore.NewPreflight(plan, size, ctx.Config.BackupPaths)
+	logPreflight(preflight, true)
+	logging.Info("Dry-run: no files were written",
+		logging.String("snapshot", snapshotID),
+		logging.String("target", target),
+		logging.Int("conflicts", plan.Conflicts))
+	return preflight.Err()
+}
 
-	logging.Info("Restore complete", logging.String("target", target))
-	return nil
+// logPreflight logs what checking a restore's target found; all includes
+// the checks that passed
+func logPreflight(p *restore.Preflight, all bool) {
+	for _, c := range p.Checks {
+		check := logging.String("check", c.Name)
+		switch {
+		case c.Status != restore.CheckOK:
+			logging.Warn("Preflight "+string(c.Status)+": "+c.Message, check)
+		case all:
+			logging.Info("Preflight ok: "+c.Message, check)
+		}
+	}
+}
+
+// logRestoreResults logs what happened (or would happen) to each file
+func logRestoreResults(plan *restore.Plan) {
+	for _, f := range plan.Files {
+		action := logging.String("action", string(f.Action))
+		switch {
+		case f.Error != "":
+			logging.Warn(f.Path, action, logging.String("restoredAs", f.RestoredAs), logging.String("error", f.Error))
+		case f.RestoredAs != "":
+			logging.Info(f.Path, action, logging.String("restoredAs", f.RestoredAs))
+		default:
+			logging.Info(f.Path, action)
+		}
+	}
+
+	counts := plan.Counts()
+	logging.Info("Restore summary",
+		logging.Int("restored", counts[restore.ActionRestore]),
+		logging.Int("skipped", counts[restore.ActionSkip]),
+		logging.Int("overwritten", counts[restore.ActionOverwrite]),
+		logging.Int("keptBoth", counts[restore.ActionKeepBoth]))
+}
+
+// unlockOptions choose how a command recovers the repository password
+type unlockOptions struct {
+	// only limits recovery to one key protection (default: any)
+	only       keyprotect.Type
+	passphrase string
+}
+
+// addUnlockFlags adds the flags that set a command's unlockOptions
+func addUnlockFlags(cmd *cobra.Command) {
+	f := cmd.Flags()
+	f.String("unlock", "", "Key protection to recover the password with: shamir, consensus, kms or passphrase (default: the first that works)")
+	f.String("passphrase-env", "", "Environment variable holding the passphrase (passphrase key protection)")
+}
+
+// unlockFlags reads the flags added by addUnlockFlags
+func unlockFlags(cmd *cobra.Command) (unlockOptions, error) {
+	flags := runner.Flags(cmd)
+	only := flags.String("unlock")
+	passphraseEnv := flags.String("passphrase-env")
+	if err := flags.Err(); err != nil {
+		return unlockOptions{}, err
+	}
+
+	var opts unlockOptions
+	if only != "" {
+		t, err := keyprotect.ParseType(only)
+		if err != nil {
+			return unlockOptions{}, err
+		}
+		opts.only = t
+	}
+	passphrase, err := passwordFromEnv(passphraseEnv)
+	if err != nil {
+		return unlockOptions{}, err
+	}
+	opts.passphrase = passphrase
+	return opts, nil
+}
+
+// recoverPassword reconstructs the repository password for an approved
+// request with the vault's key protections
+func recoverPassword(ctx context.Context, cfg *config.Config, req *consent.RestoreRequest, opts unlockOptions) ([]byte, error) {
+	password, used, err := cfg.RecoverPassword(ctx, opts.only, keyprotect.Unlock{PeerShare: req.ShareData, Passphrase: opts.passphrase})
+	if err != nil {
+		return nil, err
+	}
+	logging.Info("Recovered the repository password", logging.String("protection", string(used)))
+	return password, nil
+}
+
+// resolveSnapshot resolves a request's snapshot selector once, so every step
+// of a restore works on the same snapshot even if a backup lands meanwhile
+func resolveSnapshot(ctx context.Context, client *restic.Client, snapshotID string) (string, error) {
+	id, err := client.ResolveSnapshot(ctx, snapshotID)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve snapshot: %w", err)
+	}
+	if id != snapshotID && id != "latest" {
+		logging.Info("Resolved snapshot",
+			logging.String("selector", describeSnapshot(snapshotID)),
+			logging.String("snapshot", id))
+	}
+	return id, nil
 }
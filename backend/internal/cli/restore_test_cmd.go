@@ -0,0 +1,270 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/emergency"
+	"github.com/lcrostarosa/airgapper/backend/internal/integrity"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+var restoreTestCmd = &cobra.Command{
+	Use:   "restore-test",
+	Short: "Verify backups by restoring a random sample of files",
+	Long: `Restore a random sample of small files from a snapshot into a temporary
+directory and compare them with the source files. Results are signed with
+your key and kept in the restore test history.
+
+With --enable, restore tests also run on a schedule while 'airgapper serve'
+is running, and a failed one is sent to the restore_test_failed notification.
+
+Canary files (--canary) are a few files you pick inside the backed up paths.
+Their hashes are recorded after each backup, and every restore test restores
+just those files from that backup and compares the hashes: a cheap check
+that the backups still restore byte for byte.`,
+	Example: `  # Run a restore test now
+  airgapper restore-test
+
+  # Test 50 files from a specific snapshot
+  airgapper restore-test --sample 50 --snapshot abc123
+
+  # Run weekly while serving
+  airgapper restore-test --enable --interval 168h
+
+  # Check two canary files in every restore test
+  airgapper restore-test --canary ~/Documents/taxes-2025.pdf --canary ~/Pictures/wedding/001.jpg
+
+  # Show recent results
+  airgapper restore-test --history`,
+	RunE: runners.Owner().Wrap(runRestoreTest),
+}
+
+func init() {
+	f := restoreTestCmd.Flags()
+	f.Int("sample", 0, "Number of files to restore (default: configured or 20)")
+	f.String("snapshot", "", "Snapshot to test (default: latest)")
+	f.Bool("enable", false, "Enable scheduled restore tests")
+	f.Bool("disable", false, "Disable scheduled restore tests")
+	f.String("interval", "", "Interval between scheduled restore tests (e.g. 24h, 168h)")
+	f.Bool("history", false, "Show recent restore test results")
+	f.StringSlice("canary", nil, "Canary file checked by every restore test (can specify multiple; replaces the configured ones)")
+	f.Bool("clear-canaries", false, "Stop checking canary files")
+	rootCmd.AddCommand(restoreTestCmd)
+}
+
+func runRestoreTest(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	sample := flags.Int("sample")
+	snapshot := flags.String("snapshot")
+	enable := flags.Bool("enable")
+	disable := flags.Bool("disable")
+	interval := flags.String("interval")
+	history := flags.Bool("history")
+	canaries := flags.StringSlice("canary")
+	clearCanaries := flags.Bool("clear-canaries")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	if clearCanaries || len(canaries) > 0 {
+		if err := configureCanaries(ctx, canaries, clearCanaries); err != nil {
+			return err
+		}
+		if !enable && !disable && interval == "" {
+			return nil
+		}
+	}
+	if enable || disable || interval != "" {
+		return configureRestoreTest(ctx, enable, disable, interval, sample)
+	}
+
+	cfg := *restoreTestConfig(ctx.Config)
+	if sample > 0 {
+		cfg.SampleSize = sample
+	}
+	if snapshot != "" {
+		cfg.SnapshotID = snapshot
+	}
+
+	tester, err := newRestoreTester(ctx.Config, &cfg)
+	if err != nil {
+		return err
+	}
+
+	if history {
+		return showRestoreTestHistory(tester)
+	}
+
+	logging.Info("Running restore test", logging.Int("sample", cfg.SampleSize))
+	record, err := tester.Run(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	logRestoreTestRecord(record)
+	if !record.Passed {
+		return fmt.Errorf("restore test failed")
+	}
+	return nil
+}
+
+func configureRestoreTest(ctx *runner.CommandContext, enable, disable bool, interval string, sample int) error {
+	if enable && disable {
+		return fmt.Errorf("--enable and --disable are mutually exclusive")
+	}
+
+	cfg := *restoreTestConfig(ctx.Config)
+	if enable {
+		cfg.Enabled = true
+	}
+	if disable {
+		cfg.Enabled = false
+	}
+	if interval != "" {
+		cfg.Interval = interval
+	}
+	if sample > 0 {
+		cfg.SampleSize = sample
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	ctx.Config.RestoreTest = &cfg
+	if err := ctx.SaveConfig(); err != nil {
+		return err
+	}
+
+	logging.Info("Restore test schedule updated",
+		logging.Bool("enabled", cfg.Enabled),
+		logging.String("interval", cfg.Interval),
+		logging.Int("sample", cfg.SampleSize))
+	return nil
+}
+
+// configureCanaries replaces the configured canary files. Their hashes are
+// first recorded after the next backup.
+func configureCanaries(ctx *runner.CommandContext, paths []string, clear bool) error {
+	if clear && len(paths) > 0 {
+		return fmt.Errorf("--canary and --clear-canaries are mutually exclusive")
+	}
+	var canaries []string
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return err
+		}
+		canaries = append(canaries, abs)
+	}
+
+	cfg := *restoreTestConfig(ctx.Config)
+	cfg.CanaryFiles = canaries
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	ctx.Config.RestoreTest = &cfg
+	if err := ctx.SaveConfig(); err != nil {
+		return err
+	}
+
+	if len(canaries) == 0 {
+		logging.Info("Canary files cleared")
+		return nil
+	}
+	logging.Info("Canary files configured; their hashes are recorded after the next backup",
+		logging.String("files", strings.Join(canaries, ", ")))
+	return nil
+}
+
+func showRestoreTestHistory(tester *integrity.RestoreTester) error {
+	records := tester.History(10)
+	if len(records) == 0 {
+		logging.Info("No restore tests have been run yet - run: airgapper restore-test")
+		return nil
+	}
+	for i := range records {
+		logRestoreTestRecord(&records[i])
+	}
+	return nil
+}
+
+func logRestoreTestRecord(record *integrity.RestoreTestRecord) {
+	log, status := logging.Info, "passed"
+	if !record.Passed {
+		log, status = logging.Error, "FAILED"
+	}
+	log("Restore test "+status,
+		logging.String("id", record.ID),
+		logging.String("snapshot", record.SnapshotID),
+		logging.String("time", record.Timestamp.Format(time.DateTime)),
+		logging.Int("sampled", record.FilesSampled),
+		logging.Int("verified", record.FilesVerified),
+		logging.Int("hashCompared", record.HashCompared),
+		logging.String("duration", record.Duration))
+	if record.CanariesChecked > 0 {
+		logging.Infof("  canaries: %d of %d verified", record.CanariesVerified, record.CanariesChecked)
+	}
+
+	for _, m := range record.Mismatches {
+		logging.Error("  mismatch: " + m)
+	}
+	for _, e := range record.Errors {
+		logging.Error("  error: " + e)
+	}
+}
+
+// notifyRestoreTestFailed logs a failed scheduled restore test and sends it
+// to the restore_test_failed notification
+func notifyRestoreTestFailed(cfg *config.Config, record *integrity.RestoreTestRecord) {
+	logRestoreTestRecord(record)
+
+	notify := cfg.Emergency.GetNotify()
+	if !notify.IsEnabled() || !notify.Events.RestoreTestFailed {
+		return
+	}
+	body := fmt.Sprintf("A restore test of snapshot %s on %s failed.", record.SnapshotID, cfg.Name)
+	for _, m := range append(slices.Clone(record.Mismatches), record.Errors...) {
+		body += "\n- " + m
+	}
+	body += "\nRun 'airgapper restore-test' to check again."
+	failed := notify.Send(context.Background(), emergency.Message{
+		Event:    "restore_test_failed",
+		Title:    "Airgapper restore test failed",
+		Body:     body,
+		Priority: "high",
+	})
+	for id, err := range failed {
+		logging.Warn("Failed to send notification", logging.String("provider", id), logging.Err(err))
+	}
+}
+
+// restoreTestConfig returns the configured restore test settings or defaults
+func restoreTestConfig(cfg *config.Config) *integrity.RestoreTestConfig {
+	if cfg.RestoreTest != nil {
+		return cfg.RestoreTest
+	}
+	return integrity.DefaultRestoreTestConfig()
+}
+
+// newRestoreTester creates a restore tester signing with the owner's key
+func newRestoreTester(cfg *config.Config, testCfg *integrity.RestoreTestConfig) (*integrity.RestoreTester, error) {
+	if cfg.RepoURL == "" || cfg.Password == "" {
+		return nil, fmt.Errorf("repository URL and password are required for restore tests")
+	}
+	tester := integrity.NewRestoreTester(restic.NewClient(cfg.RepoURL, cfg.Password).WithIdentity(cfg.PrivateKey), cfg.ConfigDir, testCfg)
+	if cfg.PrivateKey != nil {
+		tester.SetSigningKey(cfg.PrivateKey, crypto.KeyID(cfg.PublicKey))
+	}
+	return tester, nil
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/policy"
+	"github.com/lcrostarosa/airgapper/backend/internal/prune"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+var retentionCmd = &cobra.Command{
+	Use:   "retention",
+	Short: "Inspect the backup retention policy",
+}
+
+var retentionPreviewCmd = &cobra.Command{
+	Use:   "preview",
+	Short: "Show which snapshots the retention policy would delete (owner only)",
+	Long: `Run restic forget --dry-run with the configured retention policy and list
+the snapshots it would keep and remove. Nothing is deleted.
+
+Only the scheduled backups' snapshots are considered, as when the policy is
+applied after a backup. Pinned snapshots are kept whatever the policy says.
+
+A prune deletion request created with apply_retention forgets exactly the
+snapshots this lists for removal, and shows them to approvers.`,
+	Example: `  airgapper retention preview`,
+	RunE:    runners.Owner().Use(runner.RequirePassword()).Wrap(runRetentionPreview),
+}
+
+func init() {
+	retentionCmd.AddCommand(retentionPreviewCmd)
+	rootCmd.AddCommand(retentionCmd)
+}
+
+func runRetentionPreview(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	if !restic.IsInstalled() {
+		return fmt.Errorf("restic is not installed")
+	}
+	pins, err := policy.LoadPinSet(ctx.Config.PinsPath())
+	if err != nil {
+		return err
+	}
+
+	client := restic.NewClient(ctx.Config.RepoURL, ctx.Config.Password).WithIdentity(ctx.Config.PrivateKey).WithOptions(ctx.Config.Restic)
+	plan, err := prune.Preview(cmd.Context(), client, ctx.Config.BackupRetention, pins)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range plan.Keep {
+		logPlannedSnapshot("Keep", s)
+	}
+	for _, s := range plan.Remove {
+		logPlannedSnapshot("Remove", s)
+	}
+	logging.Info("Retention preview",
+		logging.String("policy", plan.Policy),
+		logging.Int("keep", len(plan.Keep)),
+		logging.Int("remove", len(plan.Remove)))
+	return nil
+}
+
+func logPlannedSnapshot(action string, s consent.PlannedSnapshot) {
+	id := s.ID
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	logging.Info(action,
+		logging.String("snapshot", id),
+		logging.String("time", s.Time.Local().Format(time.DateTime)),
+		logging.String("paths", strings.Join(s.Paths, ", ")),
+		logging.Bool("pinned", s.Pinned))
+}
@@ -23,6 +23,10 @@ var (
 	cfg    *config.Config
 	cfgErr error
 
+	// configOverrides are the --set key=value flags, which override the
+	// config file and AIRGAPPER_* environment variables for one command
+	configOverrides []string
+
 	// runners is the builder for creating command runners with interceptors.
 	// It is initialized with the configProvider function.
 	runners = runner.NewBuilder(configProvider)
@@ -59,6 +63,7 @@ the backup host can decrypt/restore backups alone - both must agree.`,
 // Execute runs the CLI
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
+		printHint(os.Stderr, err)
 		os.Exit(1)
 	}
 }
@@ -67,6 +72,17 @@ func init() {
 	rootCmd.Version = Version
 	cobra.OnInitialize(initLogging, initConfig)
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.PersistentFlags().StringArrayVar(&configOverrides, "set", nil,
+		"Override a config setting for this command, as key=value (see 'airgapper config show --resolved')")
+	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		// status and peer repin show the change themselves
+		if cmd != statusCmd && cmd != peerRepinCmd {
+			warnPeerIdentityChanged(cfg)
+		}
+	}
+	rootCmd.PersistentPostRun = func(cmd *cobra.Command, args []string) {
+		refreshRecoveryKit(cfg)
+	}
 }
 
 func initLogging() {
@@ -76,6 +92,9 @@ func initLogging() {
 
 func initConfig() {
 	cfg, cfgErr = config.Load("")
+	if cfgErr == nil {
+		cfgErr = cfg.ApplyOverrides(os.LookupEnv, configOverrides)
+	}
 }
 
 // Config returns the loaded config (may be nil)
@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/backupreport"
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Show the approval rules and their recent decisions",
+	Long: `Approval rules decide some restore requests this node receives before they
+wait for manual approval: denying requests for sensitive paths or from
+unknown keys, and approving small single-path restores, optionally only
+within working hours. Every automatic decision is recorded.`,
+	RunE: runners.Config().Wrap(runRulesShow),
+}
+
+var rulesSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Set the approval rules (replacing the current ones)",
+	Example: `  # Never release SSH or key material, and refuse requests from unknown keys
+  airgapper rules set --deny-path "/home/*/.ssh" --deny-path "*.key" --deny-unknown-keys
+
+  # Approve restores of a single path up to 10MB during working hours
+  airgapper rules set --auto-approve-max 10MB --auto-approve-hours 09:00-18:00`,
+	RunE: runners.Config().Wrap(runRulesSet),
+}
+
+var rulesClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove the approval rules: every request waits for manual approval",
+	RunE:  runners.Config().Wrap(runRulesClear),
+}
+
+func init() {
+	f := rulesSetCmd.Flags()
+	f.StringSlice("deny-path", nil, "Deny restores of paths matching this pattern (can specify multiple)")
+	f.Bool("deny-unknown-keys", false, "Deny requests not signed by, or fingerprinted with, a known key")
+	f.String("auto-approve-max", "", "Approve restores of a single path declared at most this size, e.g. 10MB")
+	f.String("auto-approve-hours", "", "Only approve automatically within these local hours, e.g. 09:00-18:00")
+	rulesCmd.Flags().Int("last", 20, "Number of recent decisions to show")
+
+	rulesCmd.AddCommand(rulesSetCmd)
+	rulesCmd.AddCommand(rulesClearCmd)
+	rootCmd.AddCommand(rulesCmd)
+}
+
+func runRulesShow(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	last := flags.Int("last")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	logRules(ctx.Config.ApprovalRules)
+	decisions, err := ctx.Consent().ListRuleDecisions()
+	if err != nil {
+		return err
+	}
+	if len(decisions) == 0 {
+		logging.Info("No automatic decisions yet")
+		return nil
+	}
+	if last > 0 && len(decisions) > last {
+		decisions = decisions[len(decisions)-last:]
+	}
+	for _, d := range decisions {
+		logging.Info("Decision",
+			logging.String("at", d.At.Local().Format("2006-01-02 15:04:05")),
+			logging.String("request", d.RequestID),
+			logging.String("decision", d.Decision),
+			logging.String("rule", d.Rule),
+			logging.String("reason", d.Reason))
+	}
+	return nil
+}
+
+func runRulesSet(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	rules := &consent.ApprovalRules{
+		DenyPaths:        flags.StringSlice("deny-path"),
+		DenyUnknownKeys:  flags.Bool("deny-unknown-keys"),
+		AutoApproveHours: flags.String("auto-approve-hours"),
+	}
+	maxSize := flags.String("auto-approve-max")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	if maxSize != "" {
+		n, err := parseQuota(maxSize)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid --auto-approve-max %q, use e.g. 10MB", maxSize)
+		}
+		rules.AutoApproveMaxBytes = n
+	}
+	if len(rules.DenyPaths) == 0 && !rules.DenyUnknownKeys && rules.AutoApproveMaxBytes == 0 {
+		return fmt.Errorf("give at least one rule, or remove them with 'airgapper rules clear'")
+	}
+	if rules.AutoApproveHours != "" && rules.AutoApproveMaxBytes == 0 {
+		return fmt.Errorf("--auto-approve-hours only limits --auto-approve-max")
+	}
+	if err := rules.Validate(); err != nil {
+		return err
+	}
+
+	ctx.Config.ApprovalRules = rules
+	if err := ctx.Config.Save(); err != nil {
+		return err
+	}
+	logging.Info("Approval rules set")
+	logRules(rules)
+	return nil
+}
+
+func runRulesClear(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	ctx.Config.ApprovalRules = nil
+	if err := ctx.Config.Save(); err != nil {
+		return err
+	}
+	logging.Info("Approval rules cleared: every request waits for manual approval")
+	return nil
+}
+
+// logRules shows the approval rules in force
+func logRules(rules *consent.ApprovalRules) {
+	if rules == nil {
+		logging.Info("No approval rules: every request waits for manual approval")
+		return
+	}
+	if len(rules.DenyPaths) > 0 {
+		logging.Info("Deny paths", logging.String("patterns", strings.Join(rules.DenyPaths, ", ")))
+	}
+	if rules.DenyUnknownKeys {
+		logging.Info("Deny requests from unknown keys")
+	}
+	if rules.AutoApproveMaxBytes > 0 {
+		hours := rules.AutoApproveHours
+		if hours == "" {
+			hours = "any time"
+		}
+		logging.Info("Auto-approve single-path restores",
+			logging.String("upTo", backupreport.FormatBytes(rules.AutoApproveMaxBytes)),
+			logging.String("hours", hours))
+	}
+}
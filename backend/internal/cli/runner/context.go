@@ -34,7 +34,7 @@ func NewContext(cfg *config.Config, cfgErr error) *CommandContext {
 func (c *CommandContext) Consent() *consent.Manager {
 	c.consentOnce.Do(func() {
 		if c.Config != nil && c.Config.ConfigDir != "" {
-			c.consentMgr = consent.NewManager(c.Config.ConfigDir)
+			c.consentMgr = c.Config.ConsentManager()
 		}
 	})
 	return c.consentMgr
@@ -2,12 +2,17 @@ package cli
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/heartbeat"
 	"github.com/lcrostarosa/airgapper/backend/internal/logging"
 	"github.com/lcrostarosa/airgapper/backend/internal/scheduler"
 )
@@ -15,7 +20,36 @@ import (
 var scheduleCmd = &cobra.Command{
 	Use:   "schedule",
 	Short: "Configure backup schedule",
-	Long:  `View or configure the automatic backup schedule.`,
+	Long: `View or configure the automatic backup schedule.
+
+--preset sets the schedule, exclude patterns and retention in one go:
+
+  laptop  hourly while online, Time Machine style: 24 hourly, 30 daily and
+          52 weekly snapshots
+  server  nightly at 2 AM: 7 daily, 4 weekly and 12 monthly snapshots
+  photos  daily: 14 daily, 12 monthly and 10 yearly snapshots
+
+Every preset leaves out caches, trash, node_modules and temporary files;
+--exclude-git also leaves out .git directories. Retention is applied after
+each scheduled backup, so it only takes effect if the host allows deletions.
+
+--timezone pins the schedule's times to a timezone, so a laptop that
+travels keeps backing up at the same hour at home. Without it they are
+the system's local time. Across DST changes a skipped time runs when the
+clock jumps, and a repeated one runs once; schedules that run every hour
+carry on by elapsed time.
+
+--import-crontab converts a restic backup job from an existing crontab
+(its schedule and CRON_TZ, paths, --exclude and --tag options, and the keep
+policy of a restic forget) into the Airgapper schedule. Remove the cron job afterwards
+so backups don't run twice.
+
+--ping-url reports every backup, manual or scheduled, to an uptime monitor
+that alerts when backups stop arriving. A healthchecks.io check URL is
+pinged at URL/start when a run begins, at URL when it succeeds and at
+URL/fail when it fails, with a summary and the run's duration. An Uptime
+Kuma push URL (.../api/push/<token>) gets status=up or down, a message and
+the duration as ping. A failed ping is logged and never fails the backup.`,
 	Example: `  # View current schedule
   airgapper schedule
 
@@ -28,6 +62,40 @@ var scheduleCmd = &cobra.Command{
   # Set custom cron schedule (2am daily)
   airgapper schedule --set "0 2 * * *" ~/Documents
 
+  # Back up at 2am Berlin time wherever the machine is
+  airgapper schedule --set "0 2 * * *" --timezone Europe/Berlin
+
+  # Back up whenever online, making sure a backup succeeds at least every 3 days
+  airgapper schedule --set "at-least 72h" ~/Documents
+
+  # Catch up missed backups within 6h of waking, spread runs over 20 minutes
+  airgapper schedule --catch-up 6h --jitter 20m
+
+  # Try each run up to 4 times, backing off from 5m to at most 1h, and
+  # report the job unhealthy after 3 failed runs in a row
+  airgapper schedule --retry-attempts 4 --backoff-base 5m --backoff-cap 1h --failure-threshold 3
+
+  # Include what changed since the previous snapshot in backup reports
+  airgapper schedule --report-diff
+
+  # Back up the readable paths when others are missing or unreadable
+  airgapper schedule --continue-on-error
+
+  # Back up an encrypted export of this node's state with every backup
+  airgapper schedule --backup-state
+
+  # Alert through healthchecks.io when backups stop succeeding
+  airgapper schedule --ping-url https://hc-ping.com/your-check-uuid
+
+  # Stop pinging
+  airgapper schedule --ping-url ""
+
+  # Back up a laptop's home directory Time Machine style
+  airgapper schedule --preset laptop ~
+
+  # Take over the restic job from your crontab
+  crontab -l | airgapper schedule --import-crontab -
+
   # Clear schedule
   airgapper schedule --clear`,
 	RunE: runners.Owner().Wrap(runSchedule),
@@ -35,8 +103,24 @@ var scheduleCmd = &cobra.Command{
 
 func init() {
 	f := scheduleCmd.Flags()
-	f.String("set", "", "Set schedule (daily, hourly, weekly, or cron expression)")
+	f.String("set", "", "Set schedule (daily, hourly, weekly, \"at-least 72h\", or cron expression)")
+	f.String("timezone", "", "IANA timezone the schedule's times are in (e.g. Europe/Berlin; \"\" for the system's)")
 	f.Bool("clear", false, "Clear the current schedule")
+	f.String("catch-up", "", "Run a missed backup on wake if it's at most this late (e.g. 12h, 0 = skip)")
+	f.String("jitter", "", "Delay each run by a random amount up to this (e.g. 15m)")
+	f.String("clock-jump", "", "Wall-clock drift treated as a sleep or clock change (default 2m)")
+	f.Int("retry-attempts", 0, "Attempts per scheduled run, including the first (1 = no retries)")
+	f.String("backoff-base", "", "Delay before the first retry, doubled after each attempt (e.g. 5m)")
+	f.String("backoff-cap", "", "Maximum delay between retries (e.g. 1h)")
+	f.Int("failure-threshold", 0, "Failed runs in a row before the job is unhealthy and a notification is sent")
+	f.Bool("report-diff", false, "Diff each new snapshot against the previous one in backup reports")
+	f.Bool("continue-on-error", false, "Back up the readable paths when others are missing or unreadable, recording a partial backup")
+	f.Bool("backup-state", false, "Back up an encrypted export of this node's state with each backup, for 'airgapper self-restore'")
+	f.String("ping-url", "", "Uptime monitor URL pinged after each backup (healthchecks.io or Uptime Kuma push; \"\" to remove)")
+	f.String("preset", "", "Apply a preset schedule, excludes and retention (laptop, server, photos)")
+	f.Bool("exclude-git", false, "With --preset, also exclude .git directories")
+	f.String("import-crontab", "", "Import a restic backup job from a crontab file (- for stdin)")
+	f.Int("job", 0, "With --import-crontab, which restic backup job to import when there are several (from 1)")
 	rootCmd.AddCommand(scheduleCmd)
 }
 
@@ -44,6 +128,22 @@ func runSchedule(ctx *runner.CommandContext, cmd *cobra.Command, args []string)
 	flags := runner.Flags(cmd)
 	clear := flags.Bool("clear")
 	setSchedule := flags.String("set")
+	timezone := flags.String("timezone")
+	catchUp := flags.String("catch-up")
+	jitter := flags.String("jitter")
+	clockJump := flags.String("clock-jump")
+	retryAttempts := flags.Int("retry-attempts")
+	backoffBase := flags.String("backoff-base")
+	backoffCap := flags.String("backoff-cap")
+	failureThreshold := flags.Int("failure-threshold")
+	reportDiff := flags.Bool("report-diff")
+	continueOnError := flags.Bool("continue-on-error")
+	backupState := flags.Bool("backup-state")
+	pingURL := flags.String("ping-url")
+	preset := flags.String("preset")
+	excludeGit := flags.Bool("exclude-git")
+	crontab := flags.String("import-crontab")
+	job := flags.Int("job")
 	if err := flags.Err(); err != nil {
 		return err
 	}
@@ -51,6 +151,110 @@ func runSchedule(ctx *runner.CommandContext, cmd *cobra.Command, args []string)
 	if clear {
 		return clearSchedule(ctx)
 	}
+	if preset != "" {
+		return applyPreset(ctx, preset, excludeGit, args)
+	}
+	if crontab != "" {
+		return importCrontab(ctx, cmd, crontab, job, args)
+	}
+
+	if flags.Changed("catch-up") || flags.Changed("jitter") || flags.Changed("clock-jump") {
+		timing := scheduler.FormatTiming(scheduler.DefaultTiming())
+		if ctx.Config.BackupTiming != nil {
+			timing = *ctx.Config.BackupTiming
+		}
+		if flags.Changed("catch-up") {
+			timing.CatchUpWindow = catchUp
+		}
+		if flags.Changed("jitter") {
+			timing.Jitter = jitter
+		}
+		if flags.Changed("clock-jump") {
+			timing.ClockJumpThreshold = clockJump
+		}
+		if err := setScheduleTiming(ctx, timing); err != nil {
+			return err
+		}
+		if setSchedule == "" {
+			return nil
+		}
+	}
+
+	if flags.Changed("retry-attempts") || flags.Changed("backoff-base") || flags.Changed("backoff-cap") || flags.Changed("failure-threshold") {
+		var retry scheduler.RetryConfig
+		if ctx.Config.BackupRetry != nil {
+			retry = *ctx.Config.BackupRetry
+		}
+		if flags.Changed("retry-attempts") {
+			retry.MaxAttempts = retryAttempts
+		}
+		if flags.Changed("backoff-base") {
+			retry.BackoffBase = backoffBase
+		}
+		if flags.Changed("backoff-cap") {
+			retry.BackoffCap = backoffCap
+		}
+		if flags.Changed("failure-threshold") {
+			retry.FailureThreshold = failureThreshold
+		}
+		if err := setScheduleRetry(ctx, retry); err != nil {
+			return err
+		}
+		if setSchedule == "" {
+			return nil
+		}
+	}
+
+	if flags.Changed("report-diff") {
+		ctx.Config.BackupReportDiff = reportDiff
+		if err := ctx.SaveConfig(); err != nil {
+			return err
+		}
+		logging.Info("Backup report diffs configured", logging.Bool("enabled", reportDiff))
+		if setSchedule == "" {
+			return nil
+		}
+	}
+
+	if flags.Changed("continue-on-error") {
+		ctx.Config.BackupContinueOnError = continueOnError
+		if err := ctx.SaveConfig(); err != nil {
+			return err
+		}
+		logging.Info("Backup continue on error configured", logging.Bool("enabled", continueOnError))
+		if setSchedule == "" {
+			return nil
+		}
+	}
+
+	if flags.Changed("backup-state") {
+		ctx.Config.BackupState = backupState
+		if err := ctx.SaveConfig(); err != nil {
+			return err
+		}
+		logging.Info("State backups configured", logging.Bool("enabled", backupState))
+		if setSchedule == "" {
+			return nil
+		}
+	}
+
+	if flags.Changed("timezone") {
+		if err := setScheduleTimezone(ctx, timezone); err != nil {
+			return err
+		}
+		if setSchedule == "" {
+			return nil
+		}
+	}
+
+	if flags.Changed("ping-url") {
+		if err := setBackupPingURL(ctx, pingURL); err != nil {
+			return err
+		}
+		if setSchedule == "" {
+			return nil
+		}
+	}
 
 	if setSchedule != "" {
 		return setBackupSchedule(ctx, setSchedule, args)
@@ -59,9 +263,63 @@ func runSchedule(ctx *runner.CommandContext, cmd *cobra.Command, args []string)
 	return showSchedule(ctx)
 }
 
+// setBackupPingURL sets the uptime monitor pinged after each backup, or
+// removes it if rawURL is empty
+func setBackupPingURL(ctx *runner.CommandContext, rawURL string) error {
+	if rawURL == "" {
+		ctx.Config.BackupPingURL = ""
+		if err := ctx.SaveConfig(); err != nil {
+			return err
+		}
+		logging.Info("Backup ping removed")
+		return nil
+	}
+
+	ping, err := heartbeat.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	ctx.Config.BackupPingURL = rawURL
+	if err := ctx.SaveConfig(); err != nil {
+		return err
+	}
+	monitor := "healthchecks.io"
+	if ping.IsUptimeKuma() {
+		monitor = "Uptime Kuma"
+	}
+	logging.Info("Backup ping configured", logging.String("url", rawURL), logging.String("style", monitor))
+	return nil
+}
+
+// setScheduleTimezone sets the timezone the schedule's times are in, or
+// the system's if tz is empty
+func setScheduleTimezone(ctx *runner.CommandContext, tz string) error {
+	if _, err := scheduler.LoadTimezone(tz); err != nil {
+		return err
+	}
+	ctx.Config.BackupTimezone = tz
+	if err := ctx.SaveConfig(); err != nil {
+		return err
+	}
+	logging.Info("Schedule timezone configured", logging.String("timezone", scheduleTimezone(ctx.Config)))
+	if ctx.Config.BackupSchedule != "" {
+		logging.Info("Restart 'airgapper serve' to apply")
+	}
+	return nil
+}
+
+// scheduleTimezone names the timezone cfg's schedule is in
+func scheduleTimezone(cfg *config.Config) string {
+	if cfg.BackupTimezone == "" {
+		return "Local (" + time.Now().Format("MST") + ")"
+	}
+	return cfg.BackupTimezone
+}
+
 func clearSchedule(ctx *runner.CommandContext) error {
 	ctx.Config.BackupSchedule = ""
 	ctx.Config.BackupPaths = nil
+	ctx.Config.BackupRetention = nil
 	if err := ctx.SaveConfig(); err != nil {
 		return err
 	}
@@ -70,7 +328,7 @@ func clearSchedule(ctx *runner.CommandContext) error {
 }
 
 func setBackupSchedule(ctx *runner.CommandContext, scheduleExpr string, paths []string) error {
-	sched, err := scheduler.ParseSchedule(scheduleExpr)
+	sched, err := scheduler.ParseScheduleIn(scheduleExpr, ctx.Config.BackupTimezone)
 	if err != nil {
 		return fmt.Errorf("invalid schedule: %w", err)
 	}
@@ -84,17 +342,151 @@ func setBackupSchedule(ctx *runner.CommandContext, scheduleExpr string, paths []
 		return err
 	}
 
-	nextRun := sched.NextRun(time.Now())
+	nextRun := nextScheduledRun(ctx, sched)
 	logging.Info("Schedule configured",
 		logging.String("schedule", ctx.Config.BackupSchedule),
+		logging.String("timezone", scheduleTimezone(ctx.Config)),
 		logging.String("paths", strings.Join(ctx.Config.BackupPaths, ", ")),
-		logging.String("nextRun", nextRun.Format("2006-01-02 15:04:05")),
+		logging.String("nextRun", nextRun.Format("2006-01-02 15:04:05 MST")),
 		logging.String("in", scheduler.FormatDuration(time.Until(nextRun))))
 
 	logging.Info("To start scheduled backups, run: airgapper serve")
 	return nil
 }
 
+// applyPreset sets the schedule, excludes and retention of a preset,
+// backing up paths (or the configured paths)
+func applyPreset(ctx *runner.CommandContext, name string, excludeGit bool, paths []string) error {
+	preset, err := scheduler.FindPreset(name)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 && len(ctx.Config.BackupPaths) == 0 {
+		return fmt.Errorf("no backup paths: pass them after --preset %s", preset.Name)
+	}
+
+	exclude := slices.Clone(preset.Exclude)
+	if excludeGit {
+		exclude = append(exclude, scheduler.GitExcludes...)
+	}
+	retention := preset.Retention
+	ctx.Config.BackupExclude = exclude
+	ctx.Config.BackupRetention = &retention
+
+	logging.Info("Applying preset", logging.String("preset", preset.Name), logging.String("description", preset.Description))
+	return setBackupSchedule(ctx, preset.Schedule, paths)
+}
+
+// importCrontab converts a restic backup job from a crontab into the
+// schedule. paths, if given, replace the job's paths.
+func importCrontab(ctx *runner.CommandContext, cmd *cobra.Command, file string, job int, paths []string) error {
+	var r io.Reader = cmd.InOrStdin()
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return fmt.Errorf("failed to open crontab: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		r = f
+	}
+
+	imported, err := scheduler.ParseCrontab(r)
+	if err != nil {
+		return err
+	}
+	for _, w := range imported.Warnings {
+		logging.Warn("Crontab: " + w)
+	}
+
+	switch {
+	case len(imported.Jobs) == 0:
+		return fmt.Errorf("no restic backup job found in the crontab")
+	case job == 0 && len(imported.Jobs) > 1:
+		for i, j := range imported.Jobs {
+			logging.Info("Restic backup job",
+				logging.Int("job", i+1),
+				logging.Int("line", j.Line),
+				logging.String("schedule", j.Schedule),
+				logging.String("paths", strings.Join(j.Paths, ", ")))
+		}
+		return fmt.Errorf("the crontab has %d restic backup jobs: choose one with --job", len(imported.Jobs))
+	case job < 0 || job > len(imported.Jobs):
+		return fmt.Errorf("--job must be between 1 and %d", len(imported.Jobs))
+	}
+	j := imported.Jobs[max(job, 1)-1]
+
+	if len(paths) == 0 {
+		paths = j.Paths
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("the job on line %d backs up no paths: pass them after --import-crontab", j.Line)
+	}
+	if err := validateTags(j.Tags); err != nil {
+		return err
+	}
+	for _, tag := range j.Tags {
+		if !slices.Contains(ctx.Config.BackupTags, tag) {
+			ctx.Config.BackupTags = append(ctx.Config.BackupTags, tag)
+		}
+	}
+	ctx.Config.BackupExclude = j.Exclude
+	ctx.Config.BackupTimezone = j.Timezone
+	if imported.Retention != nil {
+		ctx.Config.BackupRetention = imported.Retention
+	}
+
+	logging.Info("Importing restic job from crontab",
+		logging.Int("line", j.Line),
+		logging.String("exclude", strings.Join(j.Exclude, ", ")),
+		logging.String("tags", strings.Join(j.Tags, ", ")),
+		logging.String("retention", ctx.Config.BackupRetention.String()))
+	if err := setBackupSchedule(ctx, j.Schedule, paths); err != nil {
+		return err
+	}
+	logging.Warn("Remove the restic job from your crontab so backups don't run twice")
+	return nil
+}
+
+func setScheduleTiming(ctx *runner.CommandContext, timing scheduler.TimingConfig) error {
+	parsed, err := timing.Parse()
+	if err != nil {
+		return err
+	}
+
+	ctx.Config.BackupTiming = &timing
+	if err := ctx.SaveConfig(); err != nil {
+		return err
+	}
+
+	formatted := scheduler.FormatTiming(parsed)
+	logging.Info("Schedule timing configured",
+		logging.String("catchUpWindow", formatted.CatchUpWindow),
+		logging.String("jitter", formatted.Jitter),
+		logging.String("clockJumpThreshold", formatted.ClockJumpThreshold))
+	return nil
+}
+
+func setScheduleRetry(ctx *runner.CommandContext, retry scheduler.RetryConfig) error {
+	parsed, err := retry.Parse()
+	if err != nil {
+		return err
+	}
+
+	ctx.Config.BackupRetry = &retry
+	if err := ctx.SaveConfig(); err != nil {
+		return err
+	}
+
+	formatted := scheduler.FormatRetry(parsed, retry.Threshold())
+	logging.Info("Schedule retry configured",
+		logging.Int("maxAttempts", formatted.MaxAttempts),
+		logging.String("backoffBase", formatted.BackoffBase),
+		logging.String("backoffCap", formatted.BackoffCap),
+		logging.Int("failureThreshold", formatted.FailureThreshold))
+	logging.Info("Restart 'airgapper serve' to apply")
+	return nil
+}
+
 func showSchedule(ctx *runner.CommandContext) error {
 	logging.Info("Backup schedule")
 
@@ -106,13 +498,55 @@ func showSchedule(ctx *runner.CommandContext) error {
 
 	logging.Info("Current schedule",
 		logging.String("schedule", ctx.Config.BackupSchedule),
-		logging.String("paths", strings.Join(ctx.Config.BackupPaths, ", ")))
+		logging.String("timezone", scheduleTimezone(ctx.Config)),
+		logging.String("paths", strings.Join(ctx.Config.BackupPaths, ", ")),
+		logging.String("exclude", strings.Join(ctx.Config.BackupExclude, ", ")),
+		logging.String("retention", ctx.Config.BackupRetention.String()))
+
+	if timing, err := ctx.Config.ScheduleTiming(); err == nil {
+		formatted := scheduler.FormatTiming(timing)
+		logging.Info("Timing",
+			logging.String("catchUpWindow", formatted.CatchUpWindow),
+			logging.String("jitter", formatted.Jitter),
+			logging.String("clockJumpThreshold", formatted.ClockJumpThreshold))
+	}
+
+	if retry, threshold, err := ctx.Config.ScheduleRetry(); err == nil {
+		formatted := scheduler.FormatRetry(retry, threshold)
+		logging.Info("Retry",
+			logging.Int("maxAttempts", formatted.MaxAttempts),
+			logging.String("backoffBase", formatted.BackoffBase),
+			logging.String("backoffCap", formatted.BackoffCap),
+			logging.Int("failureThreshold", formatted.FailureThreshold))
+	}
 
-	sched, err := scheduler.ParseSchedule(ctx.Config.BackupSchedule)
+	if ctx.Config.BackupPingURL != "" {
+		logging.Info("Backup ping", logging.String("url", ctx.Config.BackupPingURL))
+	}
+
+	if st, err := scheduler.LoadJobState(ctx.Config.BackupStatePath()); err == nil && st.Failed() {
+		logging.Warn("Last backup failed",
+			logging.String("at", st.LastAttempt.Format("2006-01-02 15:04:05")),
+			logging.Int("consecutiveFailures", st.ConsecutiveFailures),
+			logging.String("error", st.LastError))
+		logging.Info("Retry it with: airgapper backup --retry-last")
+	}
+
+	sched, err := ctx.Config.Schedule()
 	if err == nil {
-		nextRun := sched.NextRun(time.Now())
-		logging.Infof("Next run: %s (in %s)", nextRun.Format("2006-01-02 15:04:05"), scheduler.FormatDuration(time.Until(nextRun)))
+		nextRun := nextScheduledRun(ctx, sched).In(sched.Location())
+		logging.Infof("Next run: %s (in %s)", nextRun.Format("2006-01-02 15:04:05 MST"), scheduler.FormatDuration(time.Until(nextRun)))
 	}
 
 	return nil
 }
+
+// nextScheduledRun returns when the schedule is next due, using the last
+// successful backup for "at-least" schedules
+func nextScheduledRun(ctx *runner.CommandContext, sched *scheduler.Schedule) time.Time {
+	var lastSuccess time.Time
+	if st, err := scheduler.LoadJobState(ctx.Config.BackupStatePath()); err == nil {
+		lastSuccess = st.LastSuccess
+	}
+	return sched.NextDue(time.Now(), lastSuccess)
+}
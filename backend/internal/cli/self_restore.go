@@ -0,0 +1,246 @@
+package cli
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/genesis"
+	"github.com/lcrostarosa/airgapper/backend/internal/keyprotect"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/recoverykit"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/sss"
+	"github.com/lcrostarosa/airgapper/backend/internal/statebackup"
+)
+
+var selfRestoreCmd = &cobra.Command{
+	Use:   "self-restore",
+	Short: "Rebuild a lost owner node's configuration from the repository",
+	Long: `Rebuild ~/.airgapper on a new machine from the state export backed up
+with each snapshot (enable it with 'airgapper schedule --backup-state').
+
+The export is encrypted with the repository password, so it takes the same
+consent as any restore: bring together enough key shares to reconstruct the
+password, e.g. the host's share and a recovery custodian's, or give a file
+holding the password itself.
+
+If the repository is unreachable, --kit rebuilds the node from the
+encrypted archive of a recovery kit (see 'airgapper recovery-kit') instead.
+
+The config, requests, audit logs and genesis record are restored. The
+export never holds the password, your key share or your signing key:
+the password is put back from the shares, and in SSS mode your key share
+is re-issued from them. A consensus signing key has to be restored from
+your own key backup.`,
+	Example: `  airgapper self-restore --repo rest:http://bob-nas:8000/alice --share 2:8f3a... --share 3:c41d...
+  airgapper self-restore --repo rest:http://bob-nas:8000/alice --password-file ~/safe/restic-password
+  airgapper self-restore --kit /media/usb/recovery-kit.enc --share 2:8f3a... --share 3:c41d...`,
+	RunE: runners.Uninitialized().Wrap(runSelfRestore),
+}
+
+func init() {
+	f := selfRestoreCmd.Flags()
+	f.String("repo", "", "Repository URL")
+	f.String("kit", "", "Restore from a recovery kit's encrypted archive instead of the repository")
+	f.StringSlice("share", nil, "Key share as index:hex (repeat for each share)")
+	f.String("password-file", "", "File holding the repository password, instead of --share")
+	f.String("snapshot", restic.LatestWithTags([]string{restic.TagState}), "Snapshot to restore the state from")
+	selfRestoreCmd.MarkFlagsOneRequired("repo", "kit")
+	selfRestoreCmd.MarkFlagsMutuallyExclusive("repo", "kit")
+	selfRestoreCmd.MarkFlagsOneRequired("share", "password-file")
+	rootCmd.AddCommand(selfRestoreCmd)
+}
+
+func runSelfRestore(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	repoURL := flags.String("repo")
+	kitPath := flags.String("kit")
+	shareFlags := flags.StringSlice("share")
+	passwordFile := flags.String("password-file")
+	snapshot := flags.String("snapshot")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	if config.Exists("") {
+		return fmt.Errorf("this node is already initialized; move ~/.airgapper aside to restore over it")
+	}
+	shares, err := parseShares(shareFlags)
+	if err != nil {
+		return err
+	}
+	password, err := selfRestorePassword(passwordFile, shares)
+	if err != nil {
+		return err
+	}
+
+	var archive *statebackup.Archive
+	if kitPath != "" {
+		var manifest *recoverykit.Manifest
+		if archive, manifest, err = openRecoveryKit(kitPath, string(password)); err != nil {
+			return err
+		}
+		repoURL = manifest.RepoURL
+	} else if archive, err = fetchStateExport(cmd, repoURL, string(password), snapshot); err != nil {
+		return err
+	}
+
+	logging.Info("Restoring Airgapper state",
+		logging.String("name", archive.Name),
+		logging.String("exported", archive.CreatedAt.Local().Format("2006-01-02 15:04:05")),
+		logging.Int("files", len(archive.Files)))
+
+	cfg, err := archive.Restore(config.DefaultConfigDir(), string(password))
+	if err != nil {
+		return err
+	}
+	if cfg.RepoURL != repoURL {
+		logging.Warn("The restored config uses another repository URL; check it before backing up",
+			logging.String("config", cfg.RepoURL),
+			logging.String("restoredFrom", repoURL))
+	}
+
+	// A passphrase-sealed share comes back with the config
+	if cfg.ShareIndex != 0 && !cfg.UsesConsensusMode() && !cfg.HasProtection(keyprotect.TypePassphrase) {
+		share, err := reissueLocalShare(cfg, password, shares)
+		if err != nil {
+			logging.Warn("Could not re-issue your key share; restores need the host's share and a custodian's until it is", logging.Err(err))
+		} else if err := cfg.SaveShare(share, cfg.ShareIndex); err != nil {
+			return err
+		}
+	}
+	if cfg.PublicKey != nil {
+		logging.Warn("Your signing key is never backed up; copy private_key into ~/.airgapper/config.json from your own key backup to sign approvals again")
+	}
+
+	if err := genesis.CheckConfig(cfg); err != nil {
+		return err
+	}
+	logging.Info("Owner node restored", logging.String("configDir", cfg.ConfigDir))
+	logging.Info("Check it with: airgapper doctor")
+	return nil
+}
+
+// parseShares parses --share flags of the form index:hex
+func parseShares(flags []string) ([]sss.Share, error) {
+	var shares []sss.Share
+	for _, f := range flags {
+		indexStr, shareHex, ok := strings.Cut(f, ":")
+		index, err := strconv.ParseUint(indexStr, 10, 8)
+		if !ok || err != nil || index == 0 {
+			return nil, fmt.Errorf("invalid --share %q: want index:hex, e.g. 2:8f3a...", f)
+		}
+		data, err := hex.DecodeString(shareHex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --share %q (must be hex): %w", f, err)
+		}
+		shares = append(shares, sss.Share{Index: byte(index), Data: data})
+	}
+	return shares, nil
+}
+
+// selfRestorePassword reads the password from passwordFile, or else
+// reconstructs it from the shares
+func selfRestorePassword(passwordFile string, shares []sss.Share) ([]byte, error) {
+	if passwordFile != "" {
+		data, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read password file: %w", err)
+		}
+		return []byte(strings.TrimSpace(string(data))), nil
+	}
+	if len(shares) < 2 {
+		return nil, fmt.Errorf("need at least 2 key shares to reconstruct the password")
+	}
+	password, err := sss.Combine(shares)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconstruct password: %w", err)
+	}
+	logging.Info("Password reconstructed from key shares", logging.Int("shares", len(shares)))
+	return password, nil
+}
+
+// fetchStateExport restores the state export from a snapshot and opens it
+func fetchStateExport(cmd *cobra.Command, repoURL, password, snapshot string) (*statebackup.Archive, error) {
+	if !restic.IsInstalled() {
+		return nil, fmt.Errorf("restic is not installed")
+	}
+	client := restic.NewClient(repoURL, password)
+	snapshotID, err := resolveSnapshot(cmd.Context(), client, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := restoreStateExport(cmd, client, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	logging.Info("Found Airgapper state export", logging.String("snapshot", snapshotID))
+	return statebackup.Open(sealed, password)
+}
+
+// openRecoveryKit opens a recovery kit's archive
+func openRecoveryKit(path, password string) (*statebackup.Archive, *recoverykit.Manifest, error) {
+	sealed, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read recovery kit: %w", err)
+	}
+	manifest, archive, err := recoverykit.Open(sealed, password)
+	if err != nil {
+		return nil, nil, err
+	}
+	logging.Info("Opened recovery kit",
+		logging.String("repo", manifest.RepoURL),
+		logging.String("generated", manifest.CreatedAt.Local().Format("2006-01-02 15:04:05")))
+	return archive, manifest, nil
+}
+
+// restoreStateExport restores the state export from a snapshot into a
+// temporary directory and returns its contents
+func restoreStateExport(cmd *cobra.Command, client *restic.Client, snapshotID string) ([]byte, error) {
+	nodes, err := client.ListFiles(cmd.Context(), snapshotID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshot (wrong shares or password?): %w", err)
+	}
+	var exportPath string
+	for _, n := range nodes {
+		if n.Type == "file" && n.Name == statebackup.FileName {
+			exportPath = n.Path
+			break
+		}
+	}
+	if exportPath == "" {
+		return nil, fmt.Errorf("snapshot %s holds no Airgapper state export", snapshotID)
+	}
+
+	tmp, err := os.MkdirTemp("", "airgapper-self-restore-")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+	if err := client.RestoreFiles(cmd.Context(), snapshotID, tmp, []string{exportPath}); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(filepath.Join(tmp, exportPath))
+}
+
+// reissueLocalShare works out the owner's key share from the shares given,
+// or from the password and one share when 2 shares restore the password
+func reissueLocalShare(cfg *config.Config, password []byte, shares []sss.Share) ([]byte, error) {
+	threshold := cfg.Emergency.GetRecovery().GetThreshold()
+	switch {
+	case len(shares) >= threshold:
+		return sss.ShareAt(shares, cfg.ShareIndex)
+	case len(shares) == 1 && threshold <= 2:
+		return sss.Derive(password, shares[0], cfg.ShareIndex)
+	default:
+		return nil, fmt.Errorf("need %d key shares to re-issue yours, have %d", threshold, len(shares))
+	}
+}
@@ -2,9 +2,13 @@ package cli
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -12,8 +16,13 @@ import (
 	"github.com/lcrostarosa/airgapper/backend/internal/api"
 	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
 	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/emergency"
+	"github.com/lcrostarosa/airgapper/backend/internal/integrity"
 	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/prune"
+	"github.com/lcrostarosa/airgapper/backend/internal/repair"
 	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/restorejob"
 	"github.com/lcrostarosa/airgapper/backend/internal/scheduler"
 	"github.com/lcrostarosa/airgapper/backend/internal/server"
 )
@@ -29,7 +38,17 @@ scheduled backups will run automatically while the server is running.`,
   airgapper serve
 
   # Start server on custom port
-  airgapper serve --addr :8080
+  airgapper serve --addr 127.0.0.1:8080
+
+  # Expose on the network over HTTPS
+  airgapper serve --addr 0.0.0.0:8081 --tls-cert cert.pem --tls-key key.pem
+
+  # Allow a separately hosted frontend to call the API
+  airgapper serve --cors-origin http://localhost:5173
+
+  # Serve admin routes on a local socket, and only peer-facing ones on the network
+  airgapper serve --addr 0.0.0.0:8081 --tls-cert cert.pem --tls-key key.pem \
+    --admin-socket /run/airgapper.sock
 
   # Override schedule for this session
   airgapper serve --schedule daily --paths ~/Documents,~/Photos`,
@@ -38,7 +57,12 @@ scheduled backups will run automatically while the server is running.`,
 
 func init() {
 	f := serveCmd.Flags()
-	f.StringP("addr", "a", "", "Listen address (default: :8081 or AIRGAPPER_PORT)")
+	f.StringP("addr", "a", "", "Listen address (default: listen_addr, AIRGAPPER_LISTEN_ADDR or 127.0.0.1:8081)")
+	f.StringSlice("cors-origin", nil, "Allowed CORS origin (repeatable, \"*\" for any; default: same-origin only)")
+	f.String("tls-cert", "", "TLS certificate file (enables HTTPS)")
+	f.String("tls-key", "", "TLS private key file")
+	f.Bool("insecure", false, "Allow binding to a non-loopback address without TLS")
+	f.String("admin-socket", "", "Serve admin routes on this Unix socket only; the address then serves storage and consent")
 	f.String("schedule", "", "Override backup schedule for this session")
 	f.String("paths", "", "Override backup paths for this session (comma-separated)")
 	rootCmd.AddCommand(serveCmd)
@@ -50,20 +74,120 @@ func runServe(ctx *runner.CommandContext, cmd *cobra.Command, args []string) err
 		serveCfg = &config.Config{
 			ConfigDir: config.DefaultConfigDir(),
 		}
+		if err := serveCfg.ApplyOverrides(os.LookupEnv, configOverrides); err != nil {
+			return err
+		}
 	}
 
-	addr := resolveAddr(cmd)
+	addr := resolveAddr(cmd, serveCfg)
 	serveCfg.ListenAddr = addr
 
+	insecure, err := applyServeSecurityFlags(cmd, serveCfg)
+	if err != nil {
+		return err
+	}
+	if err := api.CheckBindSafety(addr, serveCfg.TLSEnabled(), insecure); err != nil {
+		return err
+	}
+	if insecure && !api.IsLoopbackAddr(addr) && !serveCfg.TLSEnabled() {
+		logging.Warn("Serving the API over plaintext HTTP on a non-loopback address",
+			logging.String("addr", addr))
+	}
+
+	flags := runner.Flags(cmd)
+	adminSocket := flags.String("admin-socket")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	var admin net.Listener
+	if adminSocket != "" {
+		if admin, err = api.ListenAdminSocket(adminSocket); err != nil {
+			return err
+		}
+	}
+
 	printServerInfo(serveCfg, addr)
+	if admin != nil {
+		logging.Info("Admin routes served on the admin socket only", logging.String("socket", adminSocket))
+	}
 
-	apiServer := api.NewServer(serveCfg, addr)
+	restores := setupRestoreJobs(serveCfg)
+	apiServer := api.NewServerWithOptions(serveCfg, addr, &api.ServerOptions{
+		Version:     Version,
+		RestoreJobs: restores,
+		AdminSocket: adminSocket,
+	})
 	sched := setupScheduler(cmd, serveCfg, apiServer)
+	restoreTests := setupRestoreTests(serveCfg)
+	stopShareChecks := startShareChecks(serveCfg)
+	stopEventNotifications := api.StartEventNotifications(serveCfg)
+	stopRecoveryReminders := startRecoveryReminders(serveCfg)
+	stopPeerQueue := startPeerQueue(serveCfg)
+
+	return runServer(apiServer, serveCfg, admin, func() {
+		stopShareChecks()
+		stopEventNotifications()
+		stopRecoveryReminders()
+		stopPeerQueue()
+		if restoreTests != nil {
+			restoreTests.Stop()
+		}
+	}, func(ctx context.Context) {
+		var wg sync.WaitGroup
+		if sched != nil {
+			wg.Go(func() { sched.Drain(ctx) })
+		}
+		if restores != nil {
+			wg.Go(func() { restores.Drain(ctx) })
+		}
+		wg.Wait()
+	})
+}
+
+// setupRestoreJobs starts running the owner's restore jobs in the
+// background, resuming any a shutdown interrupted
+func setupRestoreJobs(serveCfg *config.Config) *restorejob.Manager {
+	if !serveCfg.IsOwner() {
+		return nil
+	}
+	jobs := restoreJobs(serveCfg, serveCfg.ConsentManager(), "serve", unlockOptions{})
+	jobs.Start()
+	return jobs
+}
+
+// applyServeSecurityFlags applies CORS/TLS flag overrides to the config for
+// this session and returns whether --insecure was passed
+func applyServeSecurityFlags(cmd *cobra.Command, serveCfg *config.Config) (bool, error) {
+	flags := runner.Flags(cmd)
+	origins := flags.StringSlice("cors-origin")
+	certFile := flags.String("tls-cert")
+	keyFile := flags.String("tls-key")
+	insecure := flags.Bool("insecure")
+	if err := flags.Err(); err != nil {
+		return false, err
+	}
+
+	if flags.Changed("cors-origin") {
+		serveCfg.CORSAllowedOrigins = origins
+	}
+	if certFile != "" {
+		serveCfg.TLSCertFile = certFile
+	}
+	if keyFile != "" {
+		serveCfg.TLSKeyFile = keyFile
+	}
+	if (serveCfg.TLSCertFile == "") != (serveCfg.TLSKeyFile == "") {
+		return false, fmt.Errorf("both --tls-cert and --tls-key are required to enable TLS")
+	}
 
-	return runServer(apiServer, sched)
+	return insecure, nil
 }
 
-func resolveAddr(cmd *cobra.Command) string {
+// resolveAddr returns the API's listen address: --addr, else listen_addr
+// (which AIRGAPPER_LISTEN_ADDR or AIRGAPPER_PORT override), else
+// 127.0.0.1:8081. A bare port listens on all interfaces, as it always has;
+// CheckBindSafety refuses that without TLS or --insecure.
+func resolveAddr(cmd *cobra.Command, c *config.Config) string {
 	flags := runner.Flags(cmd)
 	addr := flags.String("addr")
 
@@ -71,11 +195,13 @@ func resolveAddr(cmd *cobra.Command) string {
 		return addr
 	}
 
-	addr = os.Getenv("AIRGAPPER_PORT")
+	if c != nil {
+		addr = c.ListenAddr
+	}
 	if addr == "" {
-		addr = ":8081"
+		return "127.0.0.1:8081"
 	}
-	if addr[0] != ':' {
+	if !strings.Contains(addr, ":") {
 		addr = ":" + addr
 	}
 
@@ -83,11 +209,17 @@ func resolveAddr(cmd *cobra.Command) string {
 }
 
 func printServerInfo(serveCfg *config.Config, addr string) {
+	baseURL := localURL(serveCfg, addr)
 	logging.Info("Airgapper server starting",
 		logging.String("name", serveCfg.Name),
 		logging.String("role", string(serveCfg.Role)),
-		logging.String("api", "http://localhost"+addr))
+		logging.String("api", baseURL))
 
+	logging.Info("Web UI available", logging.String("url", baseURL+"/"))
+	logging.Info("API docs available", logging.String("url", baseURL+api.APIBasePath+"/docs"))
+	if len(serveCfg.CORSAllowedOrigins) > 0 {
+		logging.Info("CORS enabled", logging.String("origins", strings.Join(serveCfg.CORSAllowedOrigins, ", ")))
+	}
 	logging.Info("Endpoints available:")
 	logging.Info("  GET  /health               - Health check")
 	logging.Info("  GET  /api/status           - System status")
@@ -97,6 +229,19 @@ func printServerInfo(serveCfg *config.Config, addr string) {
 	logging.Info("  POST /api/requests/{id}/deny    - Deny request")
 }
 
+// localURL returns the URL to reach the server from this machine
+func localURL(serveCfg *config.Config, addr string) string {
+	scheme := "http"
+	if serveCfg.TLSEnabled() {
+		scheme = "https"
+	}
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return scheme + "://" + addr
+	}
+	return scheme + "://localhost:" + port
+}
+
 func setupScheduler(cmd *cobra.Command, serveCfg *config.Config, apiServer *api.Server) *scheduler.Scheduler {
 	if !serveCfg.IsOwner() {
 		return nil
@@ -121,49 +266,233 @@ func setupScheduler(cmd *cobra.Command, serveCfg *config.Config, apiServer *api.
 		return nil
 	}
 
-	parsedSched, err := scheduler.ParseSchedule(scheduleExpr)
+	parsedSched, err := scheduler.ParseScheduleIn(scheduleExpr, serveCfg.BackupTimezone)
 	if err != nil {
 		logging.Warn("Invalid schedule", logging.Err(err))
 		return nil
 	}
 
-	backupFunc := func() error {
-		client := restic.NewClient(serveCfg.RepoURL, serveCfg.Password)
-		// Use background context for scheduled backups since they run asynchronously
-		err := client.Backup(context.Background(), backupPaths, []string{"airgapper", "scheduled"})
+	openRepo := apiServer.Restic()
+	repairs := repair.NewStore(serveCfg.RepairsPath())
+	// ctx is cancelled when a shutdown interrupts the backup
+	backupFunc := func(ctx context.Context) error {
+		repo := openRepo(serveCfg.RepoURL, serveCfg.Password)
+		started := time.Now()
+		paths, unreadable, err := checkBackupPaths(backupPaths, serveCfg.BackupContinueOnError)
+		if err != nil {
+			return err
+		}
+		paths, dumps, err := dumpSources(ctx, serveCfg, paths)
+		if err != nil {
+			return err
+		}
+		defer dumps.Cleanup()
+		tags := restic.BackupTags(append([]string{restic.TagScheduled}, serveCfg.BackupTags...)...)
+		paths, tags = withStateExport(serveCfg, paths, tags)
+		// Files the host reported corrupt are repaired along the way
+		summary, err := repair.Backup(ctx, repairs, repo, paths, tags, serveCfg.BackupExclude...)
+		if errors.Is(err, restic.ErrIncomplete) && serveCfg.BackupContinueOnError {
+			err = nil
+		}
+		if err == nil {
+			recordBackupReport(ctx, serveCfg, repo, summary, paths, tags, unreadable, started, true)
+		}
+		if err == nil && serveCfg.VolumesEnabled() {
+			err = backupVolumes(ctx, serveCfg, repo, true)
+		}
 		if err == nil && serveCfg.Emergency != nil {
 			serveCfg.Emergency.GetDeadManSwitch().RecordActivity()
 			if saveErr := serveCfg.Save(); saveErr != nil {
 				logging.Warn("Failed to save config after backup", logging.Err(saveErr))
 			}
 		}
+		if err == nil && !serveCfg.BackupRetention.IsZero() {
+			applyRetention(ctx, repo, serveCfg.BackupRetention)
+		}
+		if err == nil && serveCfg.HasReplication() {
+			newReplicator(serveCfg).Run(ctx)
+		}
+		if err == nil {
+			// Key holders may have joined through the API since the last run
+			refreshRecoveryKit(serveCfg)
+		}
 		return err
 	}
 
-	sched := scheduler.NewScheduler(parsedSched, backupFunc)
+	timing, err := serveCfg.ScheduleTiming()
+	if err != nil {
+		logging.Warn("Invalid backup timing, using defaults", logging.Err(err))
+		timing = scheduler.DefaultTiming()
+	}
+
+	retry, threshold, err := serveCfg.ScheduleRetry()
+	if err != nil {
+		logging.Warn("Invalid backup retry settings, retries disabled", logging.Err(err))
+		retry, threshold = scheduler.NoRetry(), scheduler.DefaultFailureThreshold
+	}
+
+	sched := scheduler.NewSchedulerWithConfig(scheduler.SchedulerConfig{
+		Schedule:         parsedSched,
+		BackupFunc:       backupFunc,
+		Retry:            retry,
+		Callbacks:        backupCallbacks(serveCfg),
+		Timing:           timing,
+		FailureThreshold: threshold,
+		StatePath:        serveCfg.BackupStatePath(),
+		Paths:            backupPaths,
+	})
 	apiServer.SetScheduler(sched)
 
-	nextRun := parsedSched.NextRun(time.Now())
+	nextRun := parsedSched.NextDue(time.Now(), sched.LastSuccess())
 	logging.Info("Scheduled backups enabled",
 		logging.String("schedule", scheduleExpr),
+		logging.String("timezone", parsedSched.Timezone()),
 		logging.String("paths", strings.Join(backupPaths, ", ")),
-		logging.String("nextRun", nextRun.Format("2006-01-02 15:04:05")))
+		logging.Duration("catchUpWindow", timing.CatchUpWindow),
+		logging.Duration("jitter", timing.Jitter),
+		logging.Int("retryAttempts", scheduler.FormatRetry(retry, threshold).MaxAttempts),
+		logging.Int("failureThreshold", threshold),
+		logging.String("nextRun", nextRun.Format("2006-01-02 15:04:05 MST")))
 
 	sched.Start()
 	return sched
 }
 
-func runServer(apiServer *api.Server, sched *scheduler.Scheduler) error {
+// applyRetention forgets the scheduled snapshots the retention config no
+// longer keeps. Append-only hosts refuse the deletion, which is only logged.
+func applyRetention(ctx context.Context, repo restic.Runner, keep *scheduler.RetentionConfig) {
+	if err := repo.Forget(ctx, prune.ForgetOptions(keep)); err != nil {
+		logging.Warn("Retention not applied (append-only hosts refuse deletions)", logging.Err(err))
+		return
+	}
+	logging.Info("Retention applied", logging.String("keep", keep.String()))
+}
+
+// backupCallbacks publishes failed scheduled backup runs to the activity
+// feed, pings the backup monitor about each run and sends a notification
+// when runs keep failing
+func backupCallbacks(serveCfg *config.Config) *scheduler.SchedulerCallbacks {
+	// Attempts of a run are sequential, so the run's start needs no lock
+	var runStarted time.Time
+	return &scheduler.SchedulerCallbacks{
+		OnBackupStart: func(result *scheduler.BackupResult) {
+			if result.Attempt == 1 {
+				runStarted = result.StartTime
+				pingBackupStart(context.Background(), backupPinger(serveCfg))
+			}
+		},
+		OnBackupSuccess: func(result *scheduler.BackupResult) {
+			summary := "Scheduled backup succeeded"
+			if result.IsRetry() {
+				summary += fmt.Sprintf(" on attempt %d", result.Attempt)
+			}
+			pingBackupSuccess(context.Background(), backupPinger(serveCfg), runStarted, summary)
+		},
+		OnBackupFailure: func(result *scheduler.BackupResult) {
+			// A run fails once its last attempt does
+			if !result.WillRetry && result.Error != nil {
+				publishBackupFailed(serveCfg, result.Error, true)
+				pingBackupFailure(context.Background(), backupPinger(serveCfg), runStarted, result.Error)
+			}
+		},
+		OnBackupInterrupted: func(*scheduler.BackupResult) {
+			publishBackupInterrupted(serveCfg)
+		},
+		OnFailureThreshold: func(health scheduler.Health, results []*scheduler.BackupResult) {
+			notify := serveCfg.Emergency.GetNotify()
+			if !notify.IsEnabled() || !notify.Events.BackupFailed {
+				return
+			}
+			body := fmt.Sprintf("Scheduled backups on %s have failed %d times in a row.", serveCfg.Name, health.ConsecutiveFailures)
+			if last := results[len(results)-1]; last.Error != nil {
+				body += "\nLast error: " + last.Error.Error()
+			}
+			body += "\nRun 'airgapper backup --retry-last' once the problem is fixed."
+
+			failed := notify.Send(context.Background(), emergency.Message{
+				Event:    "backup_failed",
+				Title:    "Airgapper backups are failing",
+				Body:     body,
+				Priority: "high",
+			})
+			for id, err := range failed {
+				logging.Warn("Failed to send notification", logging.String("provider", id), logging.Err(err))
+			}
+		},
+	}
+}
+
+// setupRestoreTests starts scheduled restore tests if enabled (owner only)
+func setupRestoreTests(serveCfg *config.Config) *integrity.ScheduledRestoreTester {
+	if !serveCfg.IsOwner() || serveCfg.RestoreTest == nil || !serveCfg.RestoreTest.Enabled {
+		return nil
+	}
+
+	interval, err := serveCfg.RestoreTest.ParseInterval()
+	if err != nil {
+		logging.Warn("Invalid restore test interval", logging.Err(err))
+		return nil
+	}
+
+	tester, err := newRestoreTester(serveCfg, serveCfg.RestoreTest)
+	if err != nil {
+		logging.Warn("Restore tests disabled", logging.Err(err))
+		return nil
+	}
+
+	scheduled := integrity.NewScheduledRestoreTester(tester, interval)
+	scheduled.SetFailureCallback(func(record *integrity.RestoreTestRecord) {
+		notifyRestoreTestFailed(serveCfg, record)
+	})
+	scheduled.Start()
+
+	logging.Info("Scheduled restore tests enabled",
+		logging.String("interval", interval.String()),
+		logging.Int("sample", serveCfg.RestoreTest.SampleSize),
+		logging.Int("canaries", len(serveCfg.RestoreTest.CanaryFiles)))
+	return scheduled
+}
+
+// runServer serves the API, and its admin routes on admin if set, until
+// SIGINT or SIGTERM. On shutdown, drain (if set) and in-flight requests such
+// as storage uploads get the configured drain timeout to finish.
+func runServer(apiServer *api.Server, serveCfg *config.Config, admin net.Listener, beforeStop func(), drain func(ctx context.Context)) error {
 	logging.Info("Press Ctrl+C to stop")
 
 	httpServer := &http.Server{
 		Addr:    apiServer.Addr(),
 		Handler: apiServer.Handler(),
 	}
+	var extra []server.Listener
+	if admin != nil {
+		extra = append(extra, server.Listener{
+			Server:   &http.Server{Handler: apiServer.AdminHandler(), ReadHeaderTimeout: 10 * time.Second},
+			Listener: admin,
+		})
+	}
 
-	return server.RunWithGracefulShutdown(httpServer, func() {
-		if sched != nil {
-			sched.Stop()
-		}
+	gs := server.NewGracefulServer(httpServer, &server.GracefulServerOptions{
+		BeforeStop:   beforeStop,
+		Drain:        drain,
+		DrainTimeout: drainTimeout(serveCfg),
+		ShutdownHook: func() {
+			// Flushes the audit forwarder; the API's own server never started
+			_ = apiServer.Shutdown(context.Background())
+		},
+		TLSCertFile: serveCfg.TLSCertFile,
+		TLSKeyFile:  serveCfg.TLSKeyFile,
+		Extra:       extra,
 	})
+	return gs.ListenAndServe()
+}
+
+// drainTimeout returns the configured drain timeout, falling back to the
+// default when it is invalid
+func drainTimeout(cfg *config.Config) time.Duration {
+	d, err := cfg.ShutdownDrain()
+	if err != nil {
+		logging.Warn("Invalid drain timeout, using the default", logging.Err(err))
+		return server.ShutdownTimeout
+	}
+	return d
 }
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/api"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+func TestResolveAddr(t *testing.T) {
+	cmd := &cobra.Command{Use: "serve"}
+	cmd.Flags().String("addr", "", "")
+
+	assert.Equal(t, "127.0.0.1:8081", resolveAddr(cmd, nil))
+	assert.Equal(t, "127.0.0.1:8081", resolveAddr(cmd, &config.Config{}))
+	assert.Equal(t, "10.0.0.5:9000", resolveAddr(cmd, &config.Config{ListenAddr: "10.0.0.5:9000"}))
+
+	// A bare port, as AIRGAPPER_PORT sets, still listens on all
+	// interfaces, so it needs TLS or --insecure
+	addr := resolveAddr(cmd, &config.Config{ListenAddr: "8081"})
+	assert.Equal(t, ":8081", addr)
+	assert.ErrorIs(t, api.CheckBindSafety(addr, false, false), apperrors.ErrInsecureBind)
+	assert.NoError(t, api.CheckBindSafety(addr, false, true))
+
+	require.NoError(t, cmd.Flags().Set("addr", "127.0.0.1:7000"))
+	assert.Equal(t, "127.0.0.1:7000", resolveAddr(cmd, &config.Config{ListenAddr: "8081"}))
+}
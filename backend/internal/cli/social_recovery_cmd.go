@@ -0,0 +1,413 @@
+package cli
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/api"
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/service"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+)
+
+var socialRecoveryCmd = &cobra.Command{
+	Use:   "social-recovery",
+	Short: "Let recovery contacts stand in for a key holder who lost their key",
+	Long: `Recover when a key holder loses their key for good.
+
+At init, the owner names recovery contacts (--social-recovery-contact) and
+how many of them must agree. If a key holder loses their key, a contact
+opens a recovery and the others sign it. Once the waiting period (30 days
+by default) has passed, the recovery approves one restore request in that
+key holder's place.
+
+Opening, signing and every day of the waiting period notify everyone, with
+notifications that can't be turned off, so a recovery opened behind a key
+holder's back is noticed. Any key holder can cancel it meanwhile; the key
+holder it is for does so just by signing with the key they supposedly lost.
+
+Contacts don't need an Airgapper vault of their own, only a key pair from
+'airgapper social-recovery keygen'.`,
+}
+
+var socialRecoveryKeygenCmd = &cobra.Command{
+	Use:     "keygen",
+	Short:   "Create a recovery contact's key pair",
+	Example: `  airgapper social-recovery keygen --out ~/airgapper-contact.key`,
+	Args:    cobra.NoArgs,
+	RunE:    runners.Uninitialized().Wrap(runSocialRecoveryKeygen),
+}
+
+var socialRecoveryOpenCmd = &cobra.Command{
+	Use:   "open",
+	Short: "Open a recovery for a key holder who lost their key (contacts)",
+	Example: `  airgapper social-recovery open --key ~/airgapper-contact.key --node https://alice-nas:8081 \
+    --missing 3f9a1c2b7d4e5f60 --reason "Bob's laptop and paper backup were lost in a fire"`,
+	Args: cobra.NoArgs,
+	RunE: runners.Uninitialized().Wrap(runSocialRecoveryOpen),
+}
+
+var socialRecoverySignCmd = &cobra.Command{
+	Use:     "sign <recovery-id>",
+	Short:   "Vouch for an open recovery (contacts)",
+	Example: `  airgapper social-recovery sign rcv-1a2b3c4d5e6f7a8b --key ~/airgapper-contact.key --node https://alice-nas:8081`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runners.Uninitialized().Wrap(runSocialRecoverySign),
+}
+
+var socialRecoveryStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the recovery contacts, recoveries and their audit trail",
+	Args:  cobra.NoArgs,
+	RunE:  runners.Config().Wrap(runSocialRecoveryStatus),
+}
+
+var socialRecoveryCancelCmd = &cobra.Command{
+	Use:   "cancel <recovery-id>",
+	Short: "Cancel a recovery with your key holder key",
+	Example: `  airgapper social-recovery cancel rcv-1a2b3c4d5e6f7a8b
+  airgapper social-recovery cancel rcv-1a2b3c4d5e6f7a8b --node https://alice-nas:8081`,
+	Args: cobra.ExactArgs(1),
+	RunE: runners.Config().Wrap(runSocialRecoveryCancel),
+}
+
+var socialRecoveryUseCmd = &cobra.Command{
+	Use:   "use <recovery-id>",
+	Short: "Approve a restore request in place of the missing key holder",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runners.Owner().Wrap(runSocialRecoveryUse),
+}
+
+func init() {
+	socialRecoveryKeygenCmd.Flags().String("out", "", "File to write the private key to (required)")
+	_ = socialRecoveryKeygenCmd.MarkFlagRequired("out")
+
+	f := socialRecoveryOpenCmd.Flags()
+	f.String("key", "", "Your recovery contact key file (required)")
+	f.String("node", "", "Address of the vault owner's node (required)")
+	f.String("missing", "", "Key ID of the key holder who lost their key (required)")
+	f.String("reason", "", "What happened (required)")
+	for _, name := range []string{"key", "node", "missing", "reason"} {
+		_ = socialRecoveryOpenCmd.MarkFlagRequired(name)
+	}
+
+	socialRecoverySignCmd.Flags().String("key", "", "Your recovery contact key file (required)")
+	socialRecoverySignCmd.Flags().String("node", "", "Address of the vault owner's node (required)")
+	_ = socialRecoverySignCmd.MarkFlagRequired("key")
+	_ = socialRecoverySignCmd.MarkFlagRequired("node")
+
+	socialRecoveryCancelCmd.Flags().String("node", "", "Cancel on the vault owner's node at this address (default: this node)")
+
+	socialRecoveryUseCmd.Flags().String("request", "", "Restore request to approve (required)")
+	_ = socialRecoveryUseCmd.MarkFlagRequired("request")
+
+	socialRecoveryCmd.AddCommand(socialRecoveryKeygenCmd)
+	socialRecoveryCmd.AddCommand(socialRecoveryOpenCmd)
+	socialRecoveryCmd.AddCommand(socialRecoverySignCmd)
+	socialRecoveryCmd.AddCommand(socialRecoveryStatusCmd)
+	socialRecoveryCmd.AddCommand(socialRecoveryCancelCmd)
+	socialRecoveryCmd.AddCommand(socialRecoveryUseCmd)
+	rootCmd.AddCommand(socialRecoveryCmd)
+}
+
+func runSocialRecoveryKeygen(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	out := flags.String("out")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	if _, err := os.Stat(out); err == nil {
+		return fmt.Errorf("%s already exists", out)
+	}
+
+	pub, priv, err := crypto.GenerateKeyPair()
+	if err != nil {
+		return fmt.Errorf("failed to generate key pair: %w", err)
+	}
+	if err := os.WriteFile(out, []byte(hex.EncodeToString(priv)+"\n"), 0600); err != nil {
+		return err
+	}
+	logging.Info("Recovery contact key written; keep it safe, it can help replace a key holder",
+		logging.String("file", out),
+		logging.String("keyID", crypto.KeyID(pub)))
+	logging.Info("Give the vault owner your name and this public key for 'airgapper init --social-recovery-contact':")
+	fmt.Println(hex.EncodeToString(pub))
+	return nil
+}
+
+// loadContactKey reads a recovery contact's private key file, returning
+// the key and its key ID
+func loadContactKey(path string) ([]byte, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	priv, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(priv) != ed25519.PrivateKeySize {
+		return nil, "", fmt.Errorf("%s is not a recovery contact key", path)
+	}
+	return priv, crypto.KeyID(ed25519.PrivateKey(priv).Public().(ed25519.PublicKey)), nil
+}
+
+func runSocialRecoveryOpen(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	keyPath := flags.String("key")
+	node := flags.String("node")
+	missing := flags.String("missing")
+	reason := flags.String("reason")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	priv, contactID, err := loadContactKey(keyPath)
+	if err != nil {
+		return err
+	}
+
+	r, err := consent.NewRecovery(missing, reason)
+	if err != nil {
+		return err
+	}
+	signature, err := r.SignData().Sign(priv)
+	if err != nil {
+		return fmt.Errorf("failed to sign recovery: %w", err)
+	}
+	r.Signatures = []consent.RecoverySignature{{ContactID: contactID, Signature: signature, SignedAt: time.Now()}}
+
+	var opened consent.Recovery
+	if err := postRecovery(cmd.Context(), node, "", r, &opened); err != nil {
+		return err
+	}
+	logging.Info("Recovery opened; everyone is being notified",
+		logging.String("id", opened.ID),
+		logging.String("keyHolder", opened.MissingKeyHolderName),
+		logging.String("usableAt", opened.UsableAt.Local().Format(time.RFC3339)))
+	logging.Infof("Other contacts vouch with: airgapper social-recovery sign %s --key <their key file> --node %s", opened.ID, node)
+	return nil
+}
+
+func runSocialRecoverySign(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	keyPath := flags.String("key")
+	node := flags.String("node")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	priv, contactID, err := loadContactKey(keyPath)
+	if err != nil {
+		return err
+	}
+
+	r, err := getRecovery(cmd.Context(), node, args[0])
+	if err != nil {
+		return err
+	}
+	logging.Info("Signing recovery",
+		logging.String("keyHolder", r.MissingKeyHolderName),
+		logging.String("reason", r.Reason),
+		logging.String("opened", r.OpenedAt.Local().Format(time.RFC3339)))
+	signature, err := r.SignData().Sign(priv)
+	if err != nil {
+		return fmt.Errorf("failed to sign recovery: %w", err)
+	}
+
+	var signed consent.Recovery
+	body := api.SignRecoveryRequest{ContactID: contactID, Signature: hex.EncodeToString(signature)}
+	if err := postRecovery(cmd.Context(), node, "/"+r.ID+"/sign", body, &signed); err != nil {
+		return err
+	}
+	logging.Info("Recovery signed",
+		logging.Int("signatures", len(signed.Signatures)),
+		logging.String("usableAt", signed.UsableAt.Local().Format(time.RFC3339)))
+	return nil
+}
+
+func runSocialRecoveryStatus(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	policy := ctx.Config.SocialRecovery
+	if policy == nil {
+		logging.Info("Social recovery is not configured; it is set up at init with --social-recovery-contact")
+		return nil
+	}
+	wait, err := policy.Wait()
+	if err != nil {
+		return err
+	}
+	logging.Info("Social recovery",
+		logging.Int("threshold", policy.Threshold),
+		logging.Int("contacts", len(policy.Contacts)),
+		logging.String("waitingPeriod", fmt.Sprintf("%d days", int(wait.Hours()/24))))
+	for _, c := range policy.Contacts {
+		logging.Infof("  %s  %s", c.ID, c.Name)
+	}
+
+	recoveries, events, err := ctx.Consent().ListRecoveries()
+	if err != nil {
+		return err
+	}
+	if len(recoveries) == 0 {
+		logging.Info("No recoveries")
+		return nil
+	}
+	now := time.Now()
+	for _, r := range recoveries {
+		logging.Info(r.ID,
+			logging.String("status", r.Status(now)),
+			logging.String("keyHolder", r.MissingKeyHolderName),
+			logging.Int("signatures", len(r.Signatures)),
+			logging.String("usableAt", r.UsableAt.Local().Format(time.RFC3339)),
+			logging.String("reason", r.Reason))
+	}
+
+	logging.Info("Audit trail:")
+	for _, e := range events {
+		logging.Infof("  %s  %-9s %s %s %s", e.At.Format(time.RFC3339), e.Event, e.RecoveryID, e.ActorID, e.RequestID)
+	}
+	return nil
+}
+
+func runSocialRecoveryCancel(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	if ctx.Config.PrivateKey == nil {
+		return fmt.Errorf("no private key found - cannot sign")
+	}
+	flags := runner.Flags(cmd)
+	node := flags.String("node")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	keyID := crypto.KeyID(ctx.Config.PublicKey)
+
+	if node == "" {
+		svc := service.NewConsentService(ctx.Config, ctx.Consent())
+		r, err := svc.GetRecovery(args[0])
+		if err != nil {
+			return err
+		}
+		signature, err := r.CancelSignData().Sign(ctx.Config.PrivateKey)
+		if err != nil {
+			return fmt.Errorf("failed to sign cancellation: %w", err)
+		}
+		if _, err := svc.CancelRecovery(r.ID, keyID, signature); err != nil {
+			return err
+		}
+		logging.Info("Recovery cancelled", logging.String("id", r.ID))
+		return nil
+	}
+
+	r, err := getRecovery(cmd.Context(), node, args[0])
+	if err != nil {
+		return err
+	}
+	signature, err := r.CancelSignData().Sign(ctx.Config.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("failed to sign cancellation: %w", err)
+	}
+	body := api.CancelRecoveryRequest{KeyHolderID: keyID, Signature: hex.EncodeToString(signature)}
+	if err := postRecovery(cmd.Context(), node, "/"+r.ID+"/cancel", body, nil); err != nil {
+		return err
+	}
+	logging.Info("Recovery cancelled on the owner's node", logging.String("id", r.ID), logging.String("address", node))
+	return nil
+}
+
+func runSocialRecoveryUse(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	requestID := flags.String("request")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	svc := service.NewConsentService(ctx.Config, ctx.Consent())
+	if _, err := svc.UseRecovery(args[0], requestID); err != nil {
+		return err
+	}
+	logApprovalProgress(ctx.Consent(), requestID)
+	return nil
+}
+
+// getRecovery fetches a recovery from the owner's node
+func getRecovery(ctx context.Context, node, id string) (*consent.Recovery, error) {
+	ctx, correlationID := tracing.Ensure(ctx)
+	resp, err := getFromPeer(ctx, 30*time.Second, strings.TrimSuffix(node, "/")+api.APIBasePath+api.RecoveriesPath+"/"+id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach node (request ID %s): %w", correlationID, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, peerError("node did not return the recovery", resp)
+	}
+	var r consent.Recovery
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("invalid recovery from node: %w", err)
+	}
+	return &r, nil
+}
+
+// postRecovery posts body to the owner node's recoveries endpoint plus
+// suffix, decoding the response into out if set
+func postRecovery(ctx context.Context, node, suffix string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	ctx, correlationID := tracing.Ensure(ctx)
+	if err := checkPeerFeature(ctx, node, api.FeatureRecovery); err != nil {
+		return err
+	}
+	endpoint := strings.TrimSuffix(node, "/") + api.APIBasePath + api.RecoveriesPath + suffix
+	resp, err := postToPeer(ctx, 30*time.Second, endpoint, data)
+	if err != nil {
+		return fmt.Errorf("failed to reach node (request ID %s): %w", correlationID, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return featureError(ctx, node, api.FeatureRecovery, "node rejected the recovery", resp)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// startRecoveryReminders reminds everyone daily, through the activity feed
+// and its notifications, of recoveries that are open. It returns a
+// function stopping it.
+func startRecoveryReminders(serveCfg *config.Config) func() {
+	if serveCfg.SocialRecovery == nil {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	remind := func() {
+		if _, err := serveCfg.ConsentManager().RemindRecoveries(time.Now()); err != nil {
+			logging.Warn("Failed to send social recovery reminders", logging.Err(err))
+		}
+	}
+	go func() {
+		remind()
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				remind()
+			}
+		}
+	}()
+	return cancel
+}
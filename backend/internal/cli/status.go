@@ -1,12 +1,20 @@
 package cli
 
 import (
+	"context"
+	"net/http"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/lcrostarosa/airgapper/backend/internal/api"
+	"github.com/lcrostarosa/airgapper/backend/internal/backupreport"
 	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/integrity"
 	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/peerpin"
 	"github.com/lcrostarosa/airgapper/backend/internal/restic"
 )
 
@@ -25,7 +33,7 @@ func runStatus(ctx *runner.CommandContext, cmd *cobra.Command, args []string) er
 	if ctx.Config == nil {
 		return showUninitialized()
 	}
-	return showStatus(ctx)
+	return showStatus(cmd.Context(), ctx)
 }
 
 func showUninitialized() error {
@@ -36,7 +44,7 @@ func showUninitialized() error {
 	return nil
 }
 
-func showStatus(ctx *runner.CommandContext) error {
+func showStatus(cmdCtx context.Context, ctx *runner.CommandContext) error {
 	logging.Info("Airgapper status",
 		logging.String("name", ctx.Config.Name),
 		logging.String("role", string(ctx.Config.Role)),
@@ -55,6 +63,13 @@ func showStatus(ctx *runner.CommandContext) error {
 		} else {
 			logging.Warn("Password: Missing")
 		}
+		var protections []string
+		for _, p := range ctx.Config.Protections() {
+			protections = append(protections, p.String())
+		}
+		if len(protections) > 0 {
+			logging.Info("Key protection: " + strings.Join(protections, ", "))
+		}
 	}
 
 	// Peer info
@@ -64,27 +79,53 @@ func showStatus(ctx *runner.CommandContext) error {
 			peerInfo += " (" + ctx.Config.Peer.Address + ")"
 		}
 		logging.Info("Peer", logging.String("peer", peerInfo))
+		if pin, err := peerpin.NewStore(ctx.Config.ConfigDir).Get(); err != nil {
+			logging.Warn("Peer pin: unreadable", logging.Err(err))
+		} else if err := pin.Err(); err != nil {
+			logging.Error(err.Error())
+		} else if pin != nil {
+			logging.Info("Peer pinned", logging.String("code", pin.Code()),
+				logging.String("since", pin.PinnedAt.Local().Format(time.DateOnly)))
+		}
 	} else {
 		logging.Info("Peer: Not configured")
 	}
+	// For a peer's operator to compare with before repinning this node
+	if self, err := peerpin.Self(ctx.Config.PublicKey, ctx.Config.TLSCertFile); err == nil && len(ctx.Config.PublicKey) > 0 {
+		logging.Info("Identity code", logging.String("code", self.Code()))
+	}
 
 	// Schedule
 	if ctx.Config.BackupSchedule != "" {
 		logging.Info("Schedule",
 			logging.String("schedule", ctx.Config.BackupSchedule),
+			logging.String("timezone", scheduleTimezone(ctx.Config)),
 			logging.String("paths", strings.Join(ctx.Config.BackupPaths, ", ")))
 	} else {
 		logging.Info("Schedule: Not configured")
 	}
+	if ctx.Config.IsOwner() && ctx.Config.PrivateBackupWindow {
+		logging.Info("Backup window: Private (not shown to the host)")
+	}
+	if ctx.Config.IsHost() && ctx.Config.Peer != nil && ctx.Config.Peer.Address != "" {
+		showOwnerBackupWindow(cmdCtx, ctx.Config)
+	}
 
 	// Restic
 	if restic.IsInstalled() {
 		ver, _ := restic.Version()
 		logging.Info("Restic", logging.String("version", ver))
+		if ctx.Config.IsOwner() && ctx.Config.Password != "" {
+			showRepoFormat(cmdCtx, ctx.Config)
+		}
 	} else {
 		logging.Warn("Restic: Not installed")
 	}
 
+	if ctx.Config.IsOwner() {
+		showRestoreTestStatus(ctx.Config)
+	}
+
 	// Pending requests
 	pending, _ := ctx.Consent().ListPending()
 	logging.Info("Pending restore requests", logging.Int("count", len(pending)))
@@ -119,3 +160,103 @@ func showStatus(ctx *runner.CommandContext) error {
 
 	return nil
 }
+
+// showOwnerBackupWindow shows when the owner's scheduled backups will keep
+// this host's uplink busy
+func showOwnerBackupWindow(ctx context.Context, cfg *config.Config) {
+	ctx, cancel := context.WithTimeout(withPeerToken(ctx, cfg), 10*time.Second)
+	defer cancel()
+	if err := checkPeerFeature(ctx, cfg.Peer.Address, api.FeatureBackupWindow); err != nil {
+		logging.Info("Owner backup window: Unknown", logging.Err(err))
+		return
+	}
+	window, err := api.PeerBackupWindow(ctx, cfg.Peer.Address, func(req *http.Request) (*http.Response, error) {
+		setPeerToken(ctx, req)
+		return api.PeerClient(cfg, 10*time.Second).Do(req)
+	})
+	switch {
+	case err != nil:
+		logging.Info("Owner backup window: Unknown", logging.Err(err))
+		return
+	case window.Private:
+		logging.Info("Owner backup window: Private")
+		return
+	case window.Schedule == "":
+		logging.Info("Owner backup window: No scheduled backups")
+		return
+	}
+
+	if window.Runs > 0 {
+		logging.Info("Owner backup window",
+			logging.String("schedule", window.Schedule),
+			logging.String("timezone", window.Timezone),
+			logging.String("avgDuration", (time.Duration(window.AvgDurationSeconds)*time.Second).String()),
+			logging.String("avgUpload", backupreport.FormatBytes(window.AvgUploadBytes)))
+	} else {
+		logging.Info("Owner backup window",
+			logging.String("schedule", window.Schedule),
+			logging.String("timezone", window.Timezone))
+	}
+	for _, start := range window.NextStarts {
+		logging.Info("  Next backup", logging.String("start", start.Local().Format(time.DateTime)))
+	}
+}
+
+// showRestoreTestStatus shows the last restore test and the canary files
+func showRestoreTestStatus(cfg *config.Config) {
+	testCfg := restoreTestConfig(cfg)
+	if testCfg.Enabled {
+		logging.Info("Restore tests: Scheduled", logging.String("interval", testCfg.Interval))
+	} else {
+		logging.Info("Restore tests: Not scheduled")
+	}
+
+	if last := integrity.NewRestoreTester(nil, cfg.ConfigDir, testCfg).History(1); len(last) > 0 {
+		r := last[0]
+		log, status := logging.Info, "passed"
+		if !r.Passed {
+			log, status = logging.Warn, "FAILED"
+		}
+		log("  Last restore test "+status,
+			logging.String("time", r.Timestamp.Format(time.DateTime)),
+			logging.Int("verified", r.FilesVerified),
+			logging.Int("canariesVerified", r.CanariesVerified),
+			logging.Int("canariesChecked", r.CanariesChecked))
+	}
+
+	if len(testCfg.CanaryFiles) == 0 {
+		return
+	}
+	records, err := integrity.NewCanaryStore(cfg.ConfigDir).List()
+	if err != nil {
+		logging.Warn("  Canary files: can't read records", logging.Err(err))
+		return
+	}
+	logging.Infof("  Canary files: %d configured, %d recorded", len(testCfg.CanaryFiles), len(records))
+}
+
+// showRepoFormat shows the repository's format version and the compression
+// backups use. Version 1 repositories can't compress; 'restic migrate
+// upgrade_repo_v2' upgrades them.
+func showRepoFormat(ctx context.Context, cfg *config.Config) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	repo, err := restic.NewClient(cfg.RepoURL, cfg.Password).WithIdentity(cfg.PrivateKey).WithOptions(cfg.Restic).RepoConfig(ctx)
+	if err != nil {
+		logging.Warn("Repository: unreachable", logging.Err(err))
+		return
+	}
+	compression := cfg.BackupCompression
+	if compression == "" {
+		compression = "default"
+	}
+	if repo.Version < 2 {
+		compression = "unsupported"
+	}
+	logging.Info("Repository",
+		logging.Int("version", repo.Version),
+		logging.String("compression", compression))
+	if repo.Version < 2 && cfg.BackupCompression != "" && cfg.BackupCompression != restic.CompressionOff {
+		logging.Warn("Backups can't be compressed in a version 1 repository; upgrade it with 'restic migrate upgrade_repo_v2'")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/lcrostarosa/airgapper/backend/internal/api"
 	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
 	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/fssnap"
 	"github.com/lcrostarosa/airgapper/backend/internal/logging"
 	"github.com/lcrostarosa/airgapper/backend/internal/server"
 	"github.com/lcrostarosa/airgapper/backend/internal/storage"
@@ -38,7 +39,22 @@ separate from the main Airgapper API server.`,
   airgapper storage serve --path /data/backups --append-only --quota 100GB
 
   # Start on custom address
-  airgapper storage serve --path /data/backups --addr :8000`,
+  airgapper storage serve --path /data/backups --addr :8000
+
+  # Compress metadata transfers for owners on a slow uplink
+  airgapper storage serve --path /data/backups --compress
+
+  # Catch corrupt blobs as restores read them
+  airgapper storage serve --path /data/backups --verify-reads
+
+  # Accept writes only from the owner named in the policy
+  airgapper storage serve --path /data/backups --require-identity
+
+  # Serve only repositories provisioned with 'airgapper storage repos add'
+  airgapper storage serve --path /data/backups --require-provisioning
+
+  # Snapshot the ZFS dataset after each backup window and before deletions
+  airgapper storage serve --path /tank/backups --fs-snapshots zfs --fs-snapshot-dataset tank/backups`,
 	RunE: runners.Uninitialized().Wrap(runStorageServe),
 }
 
@@ -56,8 +72,19 @@ func init() {
 	sf.StringP("addr", "a", ":8000", "Listen address for storage server")
 	sf.Bool("append-only", true, "Enable append-only mode (prevents deletions)")
 	sf.String("quota", "", "Storage quota (e.g., 100GB, 1TB)")
+	sf.Bool("compress", false, "Gzip index, snapshot, lock and listing responses for clients that accept it")
+	sf.Bool("verify-reads", false, "Hash blobs as they are served, reporting corruption as soon as a restore reads it")
+	sf.Bool("immutable", false, "Make complete data and snapshot files immutable (chattr +i), lifted only by an approved deletion's prune")
+	sf.Bool("require-identity", false, "Refuse writes not signed by the owner's key in the policy (owners need restic 0.17+)")
+	sf.Bool("require-provisioning", false, "Refuse requests for repositories not provisioned with 'storage repos add'")
+	sf.String("fs-snapshots", "", "Snapshot the storage's filesystem (zfs or btrfs) after each backup window and before approved deletions")
+	sf.String("fs-snapshot-dataset", "", "ZFS dataset, or Btrfs subvolume path, holding the storage")
+	sf.Int("fs-snapshot-keep", fssnap.DefaultKeep, "Filesystem snapshots kept")
 	sf.Bool("integrity", true, "Enable integrity checking")
 	sf.String("integrity-interval", "24h", "Integrity check interval")
+	sf.String("mirror", "", "Mirror storage URL to push new files to (requires an owner-signed amendment)")
+	sf.String("mirror-interval", "6h", "Interval between mirror syncs")
+	sf.String("drain-timeout", "", "How long in-flight uploads get to finish on shutdown, e.g. 10m (default 5s)")
 
 	_ = storageServeCmd.MarkFlagRequired("path")
 
@@ -75,11 +102,39 @@ func runStorageServe(ctx *runner.CommandContext, cmd *cobra.Command, args []stri
 	addr := flags.String("addr")
 	appendOnly := flags.Bool("append-only")
 	quotaStr := flags.String("quota")
+	compress := flags.Bool("compress")
+	verifyReads := flags.Bool("verify-reads")
+	immutable := flags.Bool("immutable")
+	requireIdentity := flags.Bool("require-identity")
+	requireProvisioning := flags.Bool("require-provisioning")
+	fsSnapshots := flags.String("fs-snapshots")
+	fsSnapshotDataset := flags.String("fs-snapshot-dataset")
+	fsSnapshotKeep := flags.Int("fs-snapshot-keep")
 	enableIntegrity := flags.Bool("integrity")
+	mirrorURL := flags.String("mirror")
+	mirrorInterval := flags.Duration("mirror-interval")
+	drainFlag := flags.Duration("drain-timeout")
 	if err := flags.Err(); err != nil {
 		return err
 	}
 
+	var mirrorEvery time.Duration
+	if mirrorURL != "" {
+		parsed, err := time.ParseDuration(mirrorInterval)
+		if err != nil || parsed <= 0 {
+			return fmt.Errorf("invalid --mirror-interval %q", mirrorInterval)
+		}
+		mirrorEvery = parsed
+	}
+
+	var fsSnap *fssnap.Config
+	if fsSnapshots != "" {
+		fsSnap = &fssnap.Config{Enabled: true, Type: fsSnapshots, Dataset: fsSnapshotDataset, Keep: fsSnapshotKeep}
+		if err := fsSnap.Validate(); err != nil {
+			return err
+		}
+	}
+
 	// Parse quota
 	var quotaBytes int64
 	if quotaStr != "" {
@@ -93,13 +148,29 @@ func runStorageServe(ctx *runner.CommandContext, cmd *cobra.Command, args []stri
 	logging.Info("Starting standalone storage server",
 		logging.String("path", path),
 		logging.String("addr", addr),
-		logging.Bool("appendOnly", appendOnly))
+		logging.Bool("appendOnly", appendOnly),
+		logging.Bool("compress", compress),
+		logging.Bool("verifyReads", verifyReads),
+		logging.Bool("immutable", immutable),
+		logging.Bool("requireIdentity", requireIdentity),
+		logging.Bool("requireProvisioning", requireProvisioning))
 
 	// Create temporary config for storage initialization
 	storageCfg := &config.Config{
-		StoragePath:       path,
-		StorageAppendOnly: appendOnly,
-		StorageQuotaBytes: quotaBytes,
+		StoragePath:                path,
+		StorageAppendOnly:          appendOnly,
+		StorageQuotaBytes:          quotaBytes,
+		StorageCompression:         compress,
+		StorageVerifyReads:         verifyReads,
+		StorageImmutable:           immutable,
+		StorageFSSnapshots:         fsSnap,
+		StorageRequireIdentity:     requireIdentity,
+		StorageRequireProvisioning: requireProvisioning,
+		DrainTimeout:               drainFlag,
+	}
+	drain, err := storageCfg.ShutdownDrain()
+	if err != nil {
+		return err
 	}
 
 	// Initialize storage components
@@ -116,6 +187,15 @@ func runStorageServe(ctx *runner.CommandContext, cmd *cobra.Command, args []stri
 	// Start components
 	api.StartStorageComponents(opts)
 
+	var mirror *storage.MirrorScheduler
+	if mirrorURL != "" {
+		mirror = storage.NewMirrorScheduler(storage.NewSyncer(opts.StorageServer, mirrorURL), mirrorEvery)
+		mirror.Start()
+		logging.Info("Mirror sync enabled",
+			logging.String("mirror", mirrorURL),
+			logging.String("interval", mirrorEvery.String()))
+	}
+
 	// Create a simple HTTP server for the storage endpoint
 	mux := http.NewServeMux()
 
@@ -134,6 +214,7 @@ func runStorageServe(ctx *runner.CommandContext, cmd *cobra.Command, args []stri
 		w.Header().Set("Content-Type", "application/json")
 		_, _ = w.Write([]byte(`{"running":` + boolStr(status.Running) +
 			`,"appendOnly":` + boolStr(status.AppendOnly) +
+			`,"readOnly":` + boolStr(status.Maintenance != nil) +
 			`,"usedBytes":` + int64Str(status.UsedBytes) +
 			`,"diskUsagePct":` + intStr(status.DiskUsagePct) + `}`))
 	})
@@ -149,9 +230,10 @@ func runStorageServe(ctx *runner.CommandContext, cmd *cobra.Command, args []stri
 			if repoName == "" {
 				repoName = "default"
 			}
-			result, err := opts.IntegrityChecker.CheckDataIntegrity(repoName)
+			checkType := r.URL.Query().Get("type") // full (default), packs or restic
+			result, err := opts.IntegrityChecker.RunCheck(r.Context(), checkType, repoName, r.URL.Query().Get("snapshot"))
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
 			}
 			w.Header().Set("Content-Type", "application/json")
@@ -172,7 +254,10 @@ func runStorageServe(ctx *runner.CommandContext, cmd *cobra.Command, args []stri
 		logging.String("addr", addr),
 		logging.String("path", path))
 
-	return server.RunWithGracefulShutdown(httpServer, func() {
+	return server.RunWithGracefulShutdown(httpServer, drain, func() {
+		if mirror != nil {
+			mirror.Stop()
+		}
 		api.StopStorageComponents(opts)
 	})
 }
@@ -205,7 +290,10 @@ func runStorageStatus(ctx *runner.CommandContext, cmd *cobra.Command, args []str
 	logging.Info("Storage Server Status",
 		logging.String("path", status.BasePath),
 		logging.Bool("running", status.Running),
-		logging.Bool("appendOnly", status.AppendOnly))
+		logging.Bool("appendOnly", status.AppendOnly),
+		logging.Bool("immutable", ctx.Config.StorageImmutable),
+		logging.Bool("requireIdentity", ctx.Config.StorageRequireIdentity),
+		logging.Bool("requireProvisioning", ctx.Config.StorageRequireProvisioning))
 
 	logging.Info("Disk Usage",
 		logging.Int64("usedBytes", status.UsedBytes),
@@ -213,9 +301,21 @@ func runStorageStatus(ctx *runner.CommandContext, cmd *cobra.Command, args []str
 		logging.Int("diskUsagePct", status.DiskUsagePct),
 		logging.Int64("diskFreeBytes", status.DiskFreeBytes))
 
+	if m := status.Maintenance; m != nil {
+		logging.Warn("Read-only for maintenance",
+			logging.String("reason", m.Reason),
+			logging.String("since", m.Since.Local().Format(time.RFC1123)))
+	}
+
 	if status.HasPolicy {
 		logging.Info("Policy",
-			logging.String("policyId", status.PolicyID))
+			logging.String("policyId", status.PolicyID),
+			logging.Int64("maxStorageBytes", status.PolicyMaxStorageBytes))
+	}
+	if status.EffectiveQuotaBytes > 0 {
+		logging.Info("Effective quota",
+			logging.Int64("limitBytes", status.EffectiveQuotaBytes),
+			logging.Int("usedPct", status.QuotaUsagePct))
 	}
 
 	return nil
@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/fssnap"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+)
+
+var storageFSSnapshotsCmd = &cobra.Command{
+	Use:   "fs-snapshots",
+	Short: "Snapshot the storage's ZFS dataset or Btrfs subvolume",
+	Long: `Take ZFS or Btrfs snapshots of the filesystem holding the storage, as a last
+local undo for catastrophic mistakes such as a deletion approved in error.
+
+While the storage server runs, it snapshots the dataset once a backup window
+is over (no backup has finished for the quiet period, 10 minutes by default)
+and before it opens the prune window of an approved deletion; the prune is
+refused if the snapshot fails. Each snapshot is recorded in the storage
+audit log, and only the newest --keep snapshots named airgapper-* are kept.
+
+Snapshots need the zfs or btrfs tool and the rights to use it, usually
+root or a 'zfs allow' delegation of snapshot and destroy.`,
+}
+
+var storageFSSnapshotsEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Enable filesystem snapshots",
+	Example: `  airgapper storage fs-snapshots enable --type zfs --dataset tank/airgapper --keep 30
+  airgapper storage fs-snapshots enable --type btrfs --dataset /srv/airgapper --dir /srv/.snapshots`,
+	RunE: runners.Host().Wrap(runStorageFSSnapshotsEnable),
+}
+
+var storageFSSnapshotsDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Disable filesystem snapshots (existing snapshots are kept)",
+	RunE:  runners.Host().Wrap(runStorageFSSnapshotsDisable),
+}
+
+var storageFSSnapshotsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the filesystem snapshots taken of the storage",
+	RunE:  runners.Host().Wrap(runStorageFSSnapshotsList),
+}
+
+var storageFSSnapshotsTakeCmd = &cobra.Command{
+	Use:   "take",
+	Short: "Snapshot the storage now",
+	RunE:  runners.Host().Wrap(runStorageFSSnapshotsTake),
+}
+
+func init() {
+	f := storageFSSnapshotsEnableCmd.Flags()
+	f.String("type", "", "Filesystem: zfs or btrfs (required)")
+	f.String("dataset", "", "ZFS dataset, or Btrfs subvolume path, holding the storage (required)")
+	f.String("dir", "", "Directory Btrfs snapshots are created in (default: .airgapper-snapshots beside the subvolume)")
+	f.Int("keep", fssnap.DefaultKeep, "Snapshots kept")
+	f.String("quiet", "", "How long after the last finished backup the window is over, e.g. 30m (default 10m)")
+	_ = storageFSSnapshotsEnableCmd.MarkFlagRequired("type")
+	_ = storageFSSnapshotsEnableCmd.MarkFlagRequired("dataset")
+
+	storageFSSnapshotsCmd.AddCommand(storageFSSnapshotsEnableCmd)
+	storageFSSnapshotsCmd.AddCommand(storageFSSnapshotsDisableCmd)
+	storageFSSnapshotsCmd.AddCommand(storageFSSnapshotsListCmd)
+	storageFSSnapshotsCmd.AddCommand(storageFSSnapshotsTakeCmd)
+	storageCmd.AddCommand(storageFSSnapshotsCmd)
+}
+
+func runStorageFSSnapshotsEnable(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	fc := &fssnap.Config{
+		Enabled: true,
+		Type:    flags.String("type"),
+		Dataset: flags.String("dataset"),
+		Dir:     flags.String("dir"),
+		Keep:    flags.Int("keep"),
+		Quiet:   flags.String("quiet"),
+	}
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	if err := fc.Validate(); err != nil {
+		return err
+	}
+
+	ctx.Config.StorageFSSnapshots = fc
+	if err := ctx.SaveConfig(); err != nil {
+		return err
+	}
+	logging.Info("Filesystem snapshots enabled",
+		logging.String("type", fc.Type),
+		logging.String("dataset", fc.Dataset),
+		logging.Int("keep", fc.GetKeep()),
+		logging.Duration("quiet", fc.GetQuiet()))
+	logging.Info("Restart 'airgapper serve' for the storage server to take them")
+	return nil
+}
+
+func runStorageFSSnapshotsDisable(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	fc := ctx.Config.StorageFSSnapshots
+	if fc == nil || !fc.Enabled {
+		logging.Info("Filesystem snapshots are not enabled")
+		return nil
+	}
+	fc.Enabled = false
+	if err := ctx.SaveConfig(); err != nil {
+		return err
+	}
+	logging.Info("Filesystem snapshots disabled; existing snapshots are kept")
+	return nil
+}
+
+func runStorageFSSnapshotsList(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	fc := ctx.Config.StorageFSSnapshots
+	if fc == nil || !fc.Enabled {
+		return fmt.Errorf("filesystem snapshots are not enabled - enable with: airgapper storage fs-snapshots enable")
+	}
+	snaps, err := fssnap.List(cmd.Context(), fc)
+	if err != nil {
+		return err
+	}
+	if len(snaps) == 0 {
+		logging.Info("No filesystem snapshots yet", logging.String("dataset", fc.Dataset))
+		return nil
+	}
+	for _, s := range snaps {
+		logging.Info("Snapshot",
+			logging.String("name", s.Name),
+			logging.String("reason", s.Reason),
+			logging.String("created", s.CreatedAt.Local().Format("2006-01-02 15:04:05")))
+	}
+	return nil
+}
+
+func runStorageFSSnapshotsTake(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	fc := ctx.Config.StorageFSSnapshots
+	if fc == nil || !fc.Enabled {
+		return fmt.Errorf("filesystem snapshots are not enabled - enable with: airgapper storage fs-snapshots enable")
+	}
+	if ctx.Config.StoragePath == "" {
+		return fmt.Errorf("no storage path is configured")
+	}
+	srv, err := storage.NewServer(storage.Config{BasePath: ctx.Config.StoragePath, AppendOnly: true, FSSnapshots: fc})
+	if err != nil {
+		return err
+	}
+	snap, err := srv.TakeFSSnapshot(cmd.Context(), fssnap.ReasonManual)
+	if err != nil {
+		return err
+	}
+	logging.Info("Snapshot taken", logging.String("name", snap.Name), logging.String("dataset", fc.Dataset))
+	return nil
+}
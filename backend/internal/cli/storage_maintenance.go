@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+)
+
+var storageMaintenanceCmd = &cobra.Command{
+	Use:   "maintenance",
+	Short: "Make the storage server read-only for maintenance",
+	Long: `Put the storage server in read-only maintenance mode, e.g. while migrating
+its disk, scrubbing it or before decommissioning the host.
+
+Reads keep working, so the owner can still list and restore snapshots.
+Writes are refused with 503 Service Unavailable and a Retry-After header
+(the announced end of maintenance, or 10 minutes), so restic backs off
+instead of failing. The owner's 'airgapper doctor' reports the mode.
+
+The mode takes effect immediately on a running server and survives restarts
+until it is turned off.`,
+}
+
+var storageMaintenanceOnCmd = &cobra.Command{
+	Use:   "on",
+	Short: "Make the storage server read-only",
+	Example: `  airgapper storage maintenance on --path /data/backups \
+    --reason "moving to a new disk" --until 4h`,
+	RunE: runners.Uninitialized().Wrap(runStorageMaintenanceOn),
+}
+
+var storageMaintenanceOffCmd = &cobra.Command{
+	Use:     "off",
+	Short:   "Let the storage server accept writes again",
+	Example: `  airgapper storage maintenance off --path /data/backups`,
+	RunE:    runners.Uninitialized().Wrap(runStorageMaintenanceOff),
+}
+
+func init() {
+	of := storageMaintenanceOnCmd.Flags()
+	of.StringP("path", "p", "", "Storage base path (default: the configured storage path)")
+	of.String("reason", "", "Why the storage is read-only, shown to clients")
+	of.String("until", "", "Expected end of maintenance, as a duration (4h) or RFC 3339 time")
+
+	storageMaintenanceOffCmd.Flags().StringP("path", "p", "", "Storage base path (default: the configured storage path)")
+
+	storageMaintenanceCmd.AddCommand(storageMaintenanceOnCmd)
+	storageMaintenanceCmd.AddCommand(storageMaintenanceOffCmd)
+	storageCmd.AddCommand(storageMaintenanceCmd)
+}
+
+func runStorageMaintenanceOn(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	path := flags.String("path")
+	reason := flags.String("reason")
+	untilStr := flags.String("until")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	var until time.Time
+	if untilStr != "" {
+		if d, err := time.ParseDuration(untilStr); err == nil && d > 0 {
+			until = time.Now().Add(d)
+		} else if t, err := time.Parse(time.RFC3339, untilStr); err == nil {
+			until = t
+		} else {
+			return fmt.Errorf("invalid --until %q (want a duration like 4h or an RFC 3339 time)", untilStr)
+		}
+	}
+
+	srv, err := maintenanceServer(ctx, path)
+	if err != nil {
+		return err
+	}
+	m, err := srv.SetMaintenance(reason, until)
+	if err != nil {
+		return err
+	}
+
+	logging.Info("Storage is read-only for maintenance", logging.String("reason", m.Reason))
+	if !m.Until.IsZero() {
+		logging.Info("Clients are told to retry at " + m.Until.Local().Format(time.RFC1123))
+	}
+	logging.Info("Turn it off with: airgapper storage maintenance off")
+	return nil
+}
+
+func runStorageMaintenanceOff(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	path := flags.String("path")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	srv, err := maintenanceServer(ctx, path)
+	if err != nil {
+		return err
+	}
+	if srv.Maintenance() == nil {
+		logging.Info("Storage is not in maintenance mode")
+		return nil
+	}
+	if err := srv.EndMaintenance(); err != nil {
+		return err
+	}
+	logging.Info("Storage accepts writes again")
+	return nil
+}
+
+// maintenanceServer opens the storage at path, or the configured storage
+func maintenanceServer(ctx *runner.CommandContext, path string) (*storage.Server, error) {
+	if path == "" && ctx.Config != nil {
+		path = ctx.Config.StoragePath
+	}
+	if path == "" {
+		return nil, fmt.Errorf("no storage path: pass --path")
+	}
+	return storage.NewServer(storage.Config{BasePath: path, AppendOnly: true})
+}
@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/policy"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+)
+
+var storageMirrorCmd = &cobra.Command{
+	Use:   "mirror",
+	Short: "Mirror stored repositories to another host",
+	Long: `Mirror the repositories this host stores to another host you trust, so a
+single site failure doesn't destroy the only backup.
+
+Mirroring is append-only and consent-gated: the owner signs a policy
+amendment naming the mirror ('mirror authorize'), the host registers it
+('mirror add'), and only then will 'mirror sync' or 'storage serve --mirror'
+push new files to that mirror.`,
+}
+
+var storageMirrorAuthorizeCmd = &cobra.Command{
+	Use:   "authorize <mirror-name> <mirror-url>",
+	Short: "Sign a policy amendment allowing the host to mirror your data (owner)",
+	Example: `  airgapper storage mirror authorize carol https://carol.example:8000 \
+    --policy policy.json --out mirror-carol.json`,
+	Args: cobra.ExactArgs(2),
+	RunE: runners.Owner().Wrap(runStorageMirrorAuthorize),
+}
+
+var storageMirrorAddCmd = &cobra.Command{
+	Use:     "add <amendment-file>",
+	Short:   "Register an owner-signed mirror amendment (host)",
+	Example: `  airgapper storage mirror add mirror-carol.json --path /data/backups`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runners.Uninitialized().Wrap(runStorageMirrorAdd),
+}
+
+var storageMirrorSyncCmd = &cobra.Command{
+	Use:     "sync",
+	Short:   "Push new files to an authorized mirror now (host)",
+	Example: `  airgapper storage mirror sync --path /data/backups --to https://carol.example:8000`,
+	RunE:    runners.Uninitialized().Wrap(runStorageMirrorSync),
+}
+
+func init() {
+	af := storageMirrorAuthorizeCmd.Flags()
+	af.String("policy", "", "Signed policy JSON file to amend (required)")
+	af.StringSlice("repos", nil, "Repositories that may be mirrored (default: all)")
+	af.Int("expires-days", 0, "Days until the authorization expires (0 = never)")
+	af.StringP("out", "o", "mirror-amendment.json", "Output file for the signed amendment")
+	_ = storageMirrorAuthorizeCmd.MarkFlagRequired("policy")
+
+	storageMirrorAddCmd.Flags().StringP("path", "p", "", "Storage base path (required)")
+	_ = storageMirrorAddCmd.MarkFlagRequired("path")
+
+	sf := storageMirrorSyncCmd.Flags()
+	sf.StringP("path", "p", "", "Storage base path (required)")
+	sf.String("to", "", "Mirror storage URL (required)")
+	_ = storageMirrorSyncCmd.MarkFlagRequired("path")
+	_ = storageMirrorSyncCmd.MarkFlagRequired("to")
+
+	storageMirrorCmd.AddCommand(storageMirrorAuthorizeCmd)
+	storageMirrorCmd.AddCommand(storageMirrorAddCmd)
+	storageMirrorCmd.AddCommand(storageMirrorSyncCmd)
+	storageCmd.AddCommand(storageMirrorCmd)
+}
+
+func runStorageMirrorAuthorize(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	policyFile := flags.String("policy")
+	repos := flags.StringSlice("repos")
+	expiresDays := flags.Int("expires-days")
+	out := flags.String("out")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	if ctx.Config.PrivateKey == nil {
+		return fmt.Errorf("no signing key configured")
+	}
+
+	data, err := os.ReadFile(policyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read policy: %w", err)
+	}
+	pol, err := policy.FromJSON(data)
+	if err != nil {
+		return err
+	}
+	if err := pol.Verify(); err != nil {
+		return fmt.Errorf("policy is not fully signed: %w", err)
+	}
+	if pol.OwnerKeyID != crypto.KeyID(ctx.Config.PublicKey) {
+		return fmt.Errorf("you are not the owner of policy %s", pol.ID)
+	}
+
+	amendment := policy.NewMirrorAmendment(pol, args[0], args[1])
+	amendment.Repos = repos
+	if expiresDays > 0 {
+		amendment.ExpiresAt = amendment.CreatedAt.Add(time.Duration(expiresDays) * 24 * time.Hour)
+	}
+	if err := amendment.SignAsOwner(ctx.Config.PrivateKey); err != nil {
+		return err
+	}
+
+	amendmentJSON, err := amendment.ToJSON()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(out, amendmentJSON, 0600); err != nil {
+		return fmt.Errorf("failed to write amendment: %w", err)
+	}
+
+	logging.Info("Mirror authorized",
+		logging.String("amendment", amendment.ID),
+		logging.String("mirror", amendment.MirrorURL),
+		logging.String("file", out))
+	logging.Info("Send the amendment to your host to register with: airgapper storage mirror add " + out)
+	return nil
+}
+
+func runStorageMirrorAdd(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	path := flags.String("path")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read amendment: %w", err)
+	}
+	amendment, err := policy.MirrorAmendmentFromJSON(data)
+	if err != nil {
+		return err
+	}
+
+	srv, err := storage.NewServer(storage.Config{BasePath: path})
+	if err != nil {
+		return err
+	}
+	if err := srv.AddMirrorAmendment(amendment); err != nil {
+		return err
+	}
+
+	logging.Info("Mirror amendment registered",
+		logging.String("mirror", amendment.MirrorName),
+		logging.String("url", amendment.MirrorURL))
+	return nil
+}
+
+func runStorageMirrorSync(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	path := flags.String("path")
+	to := flags.String("to")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	srv, err := storage.NewServer(storage.Config{BasePath: path})
+	if err != nil {
+		return err
+	}
+
+	result, err := storage.NewSyncer(srv, to).Sync(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	logging.Info("Mirror sync complete",
+		logging.String("mirror", result.MirrorURL),
+		logging.Int("repos", result.Repos),
+		logging.Int("pushed", result.PushedFiles),
+		logging.Int64("bytes", result.PushedBytes),
+		logging.Int("alreadyPresent", result.PresentFiles),
+		logging.String("duration", result.Duration))
+	for _, e := range result.Errors {
+		logging.Error("  " + e)
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("mirror sync finished with %d error(s)", len(result.Errors))
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/policy"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+)
+
+var storageModeCmd = &cobra.Command{
+	Use:   "mode",
+	Short: "Change the storage mode a policy locks (requires owner and host)",
+	Long: `Disable (or re-enable) append-only mode on a storage server whose policy
+locks it.
+
+Editing the host's config is not enough: while the policy locks append-only,
+the storage server escalates back to append-only at startup. Unlocking needs a
+mode change signed by both the owner and the host: one party creates it
+('mode request'), the other countersigns ('mode sign'), and the host registers
+it ('mode apply'). The change is bound to the current policy revision and is
+recorded in the audit log.`,
+}
+
+var storageModeRequestCmd = &cobra.Command{
+	Use:   "request",
+	Short: "Create and sign a mode change",
+	Example: `  airgapper storage mode request --policy policy.json \
+    --reason "migrating repository, prune needed" --out mode-change.json`,
+	RunE: runners.Config().Wrap(runStorageModeRequest),
+}
+
+var storageModeSignCmd = &cobra.Command{
+	Use:     "sign <mode-change-file>",
+	Short:   "Countersign a mode change created by the other party",
+	Example: `  airgapper storage mode sign mode-change.json --policy policy.json`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runners.Config().Wrap(runStorageModeSign),
+}
+
+var storageModeApplyCmd = &cobra.Command{
+	Use:     "apply <mode-change-file>",
+	Short:   "Register a fully signed mode change with the storage server (host)",
+	Example: `  airgapper storage mode apply mode-change.json --path /data/backups`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runners.Uninitialized().Wrap(runStorageModeApply),
+}
+
+func init() {
+	rf := storageModeRequestCmd.Flags()
+	rf.String("policy", "", "Signed policy JSON file (required)")
+	rf.Bool("append-only", false, "Requested mode (false unlocks append-only)")
+	rf.String("reason", "", "Why the mode change is needed (required)")
+	rf.Int("expires-days", 7, "Days until the mode change expires (0 = never)")
+	rf.StringP("out", "o", "mode-change.json", "Output file for the signed mode change")
+	_ = storageModeRequestCmd.MarkFlagRequired("policy")
+	_ = storageModeRequestCmd.MarkFlagRequired("reason")
+
+	storageModeSignCmd.Flags().String("policy", "", "Signed policy JSON file (required)")
+	_ = storageModeSignCmd.MarkFlagRequired("policy")
+
+	storageModeApplyCmd.Flags().StringP("path", "p", "", "Storage base path (required)")
+	_ = storageModeApplyCmd.MarkFlagRequired("path")
+
+	storageModeCmd.AddCommand(storageModeRequestCmd)
+	storageModeCmd.AddCommand(storageModeSignCmd)
+	storageModeCmd.AddCommand(storageModeApplyCmd)
+	storageCmd.AddCommand(storageModeCmd)
+}
+
+func runStorageModeRequest(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	policyFile := flags.String("policy")
+	appendOnly := flags.Bool("append-only")
+	reason := flags.String("reason")
+	expiresDays := flags.Int("expires-days")
+	out := flags.String("out")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	pol, role, err := loadPolicyForSigning(ctx, policyFile)
+	if err != nil {
+		return err
+	}
+
+	mc, err := policy.NewModeChange(pol, appendOnly, reason)
+	if err != nil {
+		return err
+	}
+	if expiresDays > 0 {
+		mc.ExpiresAt = mc.CreatedAt.Add(time.Duration(expiresDays) * 24 * time.Hour)
+	}
+	if err := mc.Sign(role, ctx.Config.PrivateKey); err != nil {
+		return err
+	}
+
+	if err := writeModeChange(out, mc); err != nil {
+		return err
+	}
+
+	logging.Info("Mode change created",
+		logging.String("id", mc.ID),
+		logging.Bool("appendOnly", mc.AppendOnly),
+		logging.String("signedAs", role),
+		logging.String("file", out))
+	logging.Info("Send it to the other party to countersign with: airgapper storage mode sign " + out)
+	return nil
+}
+
+func runStorageModeSign(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	policyFile := flags.String("policy")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	pol, role, err := loadPolicyForSigning(ctx, policyFile)
+	if err != nil {
+		return err
+	}
+
+	mc, err := readModeChange(args[0])
+	if err != nil {
+		return err
+	}
+	if mc.PolicyID != pol.ID {
+		return fmt.Errorf("mode change is for policy %s, not %s", mc.PolicyID, pol.ID)
+	}
+
+	logging.Info("Signing mode change",
+		logging.String("id", mc.ID),
+		logging.Bool("appendOnly", mc.AppendOnly),
+		logging.String("reason", mc.Reason),
+		logging.String("signedAs", role))
+	if err := mc.Sign(role, ctx.Config.PrivateKey); err != nil {
+		return err
+	}
+
+	if err := writeModeChange(args[0], mc); err != nil {
+		return err
+	}
+
+	if err := mc.Verify(pol); err != nil {
+		logging.Warn("Mode change still needs the other party's signature", logging.Err(err))
+		return nil
+	}
+	logging.Info("Mode change fully signed. The host registers it with: airgapper storage mode apply " + args[0])
+	return nil
+}
+
+func runStorageModeApply(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	path := flags.String("path")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	mc, err := readModeChange(args[0])
+	if err != nil {
+		return err
+	}
+
+	srv, err := storage.NewServer(storage.Config{BasePath: path, AppendOnly: true})
+	if err != nil {
+		return err
+	}
+	if err := srv.ApplyModeChange(mc); err != nil {
+		return err
+	}
+
+	if mc.AppendOnly {
+		logging.Info("Append-only re-enabled")
+		return nil
+	}
+	logging.Info("Append-only unlocked",
+		logging.String("id", mc.ID),
+		logging.String("reason", mc.Reason))
+	logging.Info("Restart the storage server without --append-only for the change to take effect")
+	return nil
+}
+
+// loadPolicyForSigning reads a signed policy and returns the role the local
+// key holds in it
+func loadPolicyForSigning(ctx *runner.CommandContext, policyFile string) (*policy.Policy, string, error) {
+	if ctx.Config.PrivateKey == nil {
+		return nil, "", fmt.Errorf("no signing key configured")
+	}
+
+	data, err := os.ReadFile(policyFile)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read policy: %w", err)
+	}
+	pol, err := policy.FromJSON(data)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := pol.Verify(); err != nil {
+		return nil, "", fmt.Errorf("policy is not fully signed: %w", err)
+	}
+
+	switch crypto.KeyID(ctx.Config.PublicKey) {
+	case pol.OwnerKeyID:
+		return pol, policy.RoleOwner, nil
+	case pol.HostKeyID:
+		return pol, policy.RoleHost, nil
+	default:
+		return nil, "", fmt.Errorf("your key is not a party to policy %s", pol.ID)
+	}
+}
+
+func readModeChange(path string) (*policy.ModeChange, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mode change: %w", err)
+	}
+	return policy.ModeChangeFromJSON(data)
+}
+
+func writeModeChange(path string, mc *policy.ModeChange) error {
+	data, err := mc.ToJSON()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write mode change: %w", err)
+	}
+	return nil
+}
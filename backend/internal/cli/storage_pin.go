@@ -0,0 +1,425 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/policy"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+)
+
+var storagePinCmd = &cobra.Command{
+	Use:   "pin",
+	Short: "Pin snapshots so they can't be deleted (legal hold)",
+	Long: `Pin specific snapshots as non-deletable, e.g. "tax year 2023".
+
+Either party can pin a snapshot. A pin is signed with your policy key and
+holds the snapshot regardless of retention rules: the storage server refuses
+to delete it and deletion requests covering it are rejected.
+
+You can release your own pins. Releasing a pin the other party set is a
+consent request: create it with 'pin release', the other party approves it
+with 'pin consent', then it is applied.
+
+Pin and unpin files are registered with the storage server by the host
+('pin apply --path') and recorded locally by the owner ('pin apply --policy').`,
+}
+
+var storagePinAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Create and sign a pin",
+	Example: `  airgapper storage pin add --policy policy.json --snapshot 4f2a9c1b \
+    --label "tax year 2023" --out pin.json`,
+	RunE: runners.Config().Wrap(runStoragePinAdd),
+}
+
+var storagePinReleaseCmd = &cobra.Command{
+	Use:   "release",
+	Short: "Create and sign a request to release a pin",
+	Example: `  airgapper storage pin release --policy policy.json --pin pin.json \
+    --reason "retention no longer required" --out unpin.json`,
+	RunE: runners.Config().Wrap(runStoragePinRelease),
+}
+
+var storagePinConsentCmd = &cobra.Command{
+	Use:     "consent <unpin-file>",
+	Short:   "Consent to the other party releasing your pin",
+	Example: `  airgapper storage pin consent unpin.json --policy policy.json --pin pin.json`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runners.Config().Wrap(runStoragePinConsent),
+}
+
+var storagePinApplyCmd = &cobra.Command{
+	Use:   "apply <pin-or-unpin-file>",
+	Short: "Register a pin or unpin with the storage server or locally",
+	Example: `  airgapper storage pin apply pin.json --path /data/backups
+  airgapper storage pin apply unpin.json --policy policy.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runners.Uninitialized().Wrap(runStoragePinApply),
+}
+
+var storagePinListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List pinned snapshots",
+	RunE:  runners.Uninitialized().Wrap(runStoragePinList),
+}
+
+func init() {
+	af := storagePinAddCmd.Flags()
+	af.String("policy", "", "Signed policy JSON file (required)")
+	af.String("snapshot", "", "Snapshot ID or short ID to pin (required)")
+	af.String("label", "", "Why the snapshot is held (required)")
+	af.StringP("out", "o", "pin.json", "Output file for the signed pin")
+	_ = storagePinAddCmd.MarkFlagRequired("policy")
+	_ = storagePinAddCmd.MarkFlagRequired("snapshot")
+	_ = storagePinAddCmd.MarkFlagRequired("label")
+
+	rf := storagePinReleaseCmd.Flags()
+	rf.String("policy", "", "Signed policy JSON file (required)")
+	rf.String("pin", "", "Pin file to release (required)")
+	rf.String("reason", "", "Why the pin is released")
+	rf.StringP("out", "o", "unpin.json", "Output file for the signed unpin")
+	_ = storagePinReleaseCmd.MarkFlagRequired("policy")
+	_ = storagePinReleaseCmd.MarkFlagRequired("pin")
+
+	cf := storagePinConsentCmd.Flags()
+	cf.String("policy", "", "Signed policy JSON file (required)")
+	cf.String("pin", "", "Pin file being released (required)")
+	_ = storagePinConsentCmd.MarkFlagRequired("policy")
+	_ = storagePinConsentCmd.MarkFlagRequired("pin")
+
+	pf := storagePinApplyCmd.Flags()
+	pf.StringP("path", "p", "", "Storage base path (host)")
+	pf.String("policy", "", "Signed policy JSON file (when recording locally)")
+
+	storagePinListCmd.Flags().StringP("path", "p", "", "Storage base path (default: pins recorded locally)")
+
+	storagePinCmd.AddCommand(storagePinAddCmd)
+	storagePinCmd.AddCommand(storagePinReleaseCmd)
+	storagePinCmd.AddCommand(storagePinConsentCmd)
+	storagePinCmd.AddCommand(storagePinApplyCmd)
+	storagePinCmd.AddCommand(storagePinListCmd)
+	storageCmd.AddCommand(storagePinCmd)
+}
+
+func runStoragePinAdd(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	policyFile := flags.String("policy")
+	snapshotID := flags.String("snapshot")
+	label := flags.String("label")
+	out := flags.String("out")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	pol, role, err := loadPolicyForSigning(ctx, policyFile)
+	if err != nil {
+		return err
+	}
+
+	pin, err := policy.NewPin(pol, snapshotID, label, role)
+	if err != nil {
+		return err
+	}
+	if err := pin.Sign(ctx.Config.PrivateKey); err != nil {
+		return err
+	}
+
+	if err := writePinRecord(out, pin); err != nil {
+		return err
+	}
+	if err := recordPinLocally(ctx, func(set *policy.PinSet) error {
+		return set.Add(pol, pin)
+	}); err != nil {
+		return err
+	}
+
+	logging.Info("Snapshot pin created",
+		logging.String("id", pin.ID),
+		logging.String("snapshot", pin.SnapshotID),
+		logging.String("label", pin.Label),
+		logging.String("setBy", role),
+		logging.String("file", out))
+	logging.Info("The host registers it with: airgapper storage pin apply " + out + " --path <storage-path>")
+	return nil
+}
+
+func runStoragePinRelease(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	policyFile := flags.String("policy")
+	pinFile := flags.String("pin")
+	reason := flags.String("reason")
+	out := flags.String("out")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	pol, role, err := loadPolicyForSigning(ctx, policyFile)
+	if err != nil {
+		return err
+	}
+	pin, err := readPin(pinFile)
+	if err != nil {
+		return err
+	}
+
+	u, err := policy.NewUnpin(pin, role, reason)
+	if err != nil {
+		return err
+	}
+	if err := u.Sign(pin, role, ctx.Config.PrivateKey); err != nil {
+		return err
+	}
+	if err := writePinRecord(out, u); err != nil {
+		return err
+	}
+
+	if u.RequiresConsent(pin) {
+		logging.Info("Unpin request created; the pin was set by the "+pin.SetBy+", whose consent is required",
+			logging.String("id", u.ID),
+			logging.String("snapshot", u.SnapshotID),
+			logging.String("file", out))
+		logging.Info("Send it to the other party to approve with: airgapper storage pin consent " + out)
+		return nil
+	}
+
+	if err := recordPinLocally(ctx, func(set *policy.PinSet) error {
+		_, err := set.Remove(pol, u)
+		return err
+	}); err != nil {
+		return err
+	}
+	logging.Info("Unpin created",
+		logging.String("id", u.ID),
+		logging.String("snapshot", u.SnapshotID),
+		logging.String("file", out))
+	logging.Info("The host registers it with: airgapper storage pin apply " + out + " --path <storage-path>")
+	return nil
+}
+
+func runStoragePinConsent(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	policyFile := flags.String("policy")
+	pinFile := flags.String("pin")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	pol, role, err := loadPolicyForSigning(ctx, policyFile)
+	if err != nil {
+		return err
+	}
+	pin, err := readPin(pinFile)
+	if err != nil {
+		return err
+	}
+	u, err := readUnpin(args[0])
+	if err != nil {
+		return err
+	}
+	if role != pin.SetBy || !u.RequiresConsent(pin) {
+		return fmt.Errorf("only the party that set the pin can consent to another party releasing it")
+	}
+
+	logging.Info("Consenting to unpin",
+		logging.String("snapshot", pin.SnapshotID),
+		logging.String("label", pin.Label),
+		logging.String("requestedBy", u.RequestedBy),
+		logging.String("reason", u.Reason))
+	if err := u.Sign(pin, role, ctx.Config.PrivateKey); err != nil {
+		return err
+	}
+	if err := u.Verify(pol, pin); err != nil {
+		return err
+	}
+	if err := writePinRecord(args[0], u); err != nil {
+		return err
+	}
+
+	if err := recordPinLocally(ctx, func(set *policy.PinSet) error {
+		_, err := set.Remove(pol, u)
+		return err
+	}); err != nil {
+		return err
+	}
+	logging.Info("Unpin approved. The host registers it with: airgapper storage pin apply " + args[0] + " --path <storage-path>")
+	return nil
+}
+
+func runStoragePinApply(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	path := flags.String("path")
+	policyFile := flags.String("policy")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read pin record: %w", err)
+	}
+	var probe struct {
+		PinID string `json:"pin_id"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return fmt.Errorf("failed to parse pin record: %w", err)
+	}
+
+	if path != "" {
+		srv, err := storage.NewServer(storage.Config{BasePath: path, AppendOnly: true})
+		if err != nil {
+			return err
+		}
+		if probe.PinID != "" {
+			u, err := policy.UnpinFromJSON(data)
+			if err != nil {
+				return err
+			}
+			pin, err := srv.UnpinSnapshot(u)
+			if err != nil {
+				return err
+			}
+			logging.Info("Snapshot unpinned", logging.String("snapshot", pin.SnapshotID), logging.String("label", pin.Label))
+			return nil
+		}
+		pin, err := policy.PinFromJSON(data)
+		if err != nil {
+			return err
+		}
+		if err := srv.PinSnapshot(pin); err != nil {
+			return err
+		}
+		logging.Info("Snapshot pinned", logging.String("snapshot", pin.SnapshotID), logging.String("label", pin.Label))
+		return nil
+	}
+
+	if policyFile == "" {
+		return fmt.Errorf("--path (host) or --policy (record locally) is required")
+	}
+	if !ctx.HasConfig() {
+		return fmt.Errorf("airgapper not initialized; use --path to register with a storage server")
+	}
+	polData, err := os.ReadFile(policyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read policy: %w", err)
+	}
+	pol, err := policy.FromJSON(polData)
+	if err != nil {
+		return err
+	}
+	if err := pol.Verify(); err != nil {
+		return fmt.Errorf("policy is not fully signed: %w", err)
+	}
+
+	return recordPinLocally(ctx, func(set *policy.PinSet) error {
+		if probe.PinID != "" {
+			u, err := policy.UnpinFromJSON(data)
+			if err != nil {
+				return err
+			}
+			pin, err := set.Remove(pol, u)
+			if err != nil {
+				return err
+			}
+			logging.Info("Snapshot unpinned locally", logging.String("snapshot", pin.SnapshotID))
+			return nil
+		}
+		pin, err := policy.PinFromJSON(data)
+		if err != nil {
+			return err
+		}
+		if err := set.Add(pol, pin); err != nil {
+			return err
+		}
+		logging.Info("Snapshot pin recorded locally", logging.String("snapshot", pin.SnapshotID), logging.String("label", pin.Label))
+		return nil
+	})
+}
+
+func runStoragePinList(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	path := flags.String("path")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	var pins []*policy.Pin
+	if path != "" {
+		srv, err := storage.NewServer(storage.Config{BasePath: path, AppendOnly: true})
+		if err != nil {
+			return err
+		}
+		if pins, err = srv.Pins(); err != nil {
+			return err
+		}
+	} else {
+		if !ctx.HasConfig() {
+			return fmt.Errorf("airgapper not initialized; use --path to list a storage server's pins")
+		}
+		set, err := policy.LoadPinSet(ctx.Config.PinsPath())
+		if err != nil {
+			return err
+		}
+		pins = set.Pins
+	}
+
+	if len(pins) == 0 {
+		logging.Info("No pinned snapshots")
+		return nil
+	}
+	for _, pin := range pins {
+		logging.Info("Pinned snapshot",
+			logging.String("snapshot", pin.SnapshotID),
+			logging.String("label", pin.Label),
+			logging.String("setBy", pin.SetBy),
+			logging.String("id", pin.ID),
+			logging.String("created", pin.CreatedAt.Format("2006-01-02")))
+	}
+	return nil
+}
+
+// recordPinLocally updates the pins this node knows about, which deletion
+// requests are checked against
+func recordPinLocally(ctx *runner.CommandContext, update func(*policy.PinSet) error) error {
+	set, err := policy.LoadPinSet(ctx.Config.PinsPath())
+	if err != nil {
+		return err
+	}
+	if err := update(set); err != nil {
+		return err
+	}
+	return set.Save(ctx.Config.PinsPath())
+}
+
+func readPin(path string) (*policy.Pin, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pin: %w", err)
+	}
+	return policy.PinFromJSON(data)
+}
+
+func readUnpin(path string) (*policy.Unpin, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read unpin: %w", err)
+	}
+	return policy.UnpinFromJSON(data)
+}
+
+// writePinRecord writes a signed pin or unpin
+func writePinRecord(path string, record interface{ ToJSON() ([]byte, error) }) error {
+	data, err := record.ToJSON()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
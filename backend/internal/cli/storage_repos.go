@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+var storageReposCmd = &cobra.Command{
+	Use:   "repos",
+	Short: "Provision the repositories the storage server serves",
+	Long: `Provision a repository before the owner creates it. With
+storage_require_provisioning set (or 'storage serve --require-provisioning'),
+the storage server refuses any request for a repository it hasn't
+provisioned, so whoever finds its URL can't create repositories or race the
+owner to a new one's config. Repositories already stored are adopted when
+the setting is turned on.
+
+A repository provisioned for a key accepts only writes signed by that key,
+whatever the other settings; on a host, the peer's key is used unless
+another is given. A running server picks up new repositories at once.`,
+	Example: `  airgapper storage repos add alice --path /data/backups
+  airgapper storage repos add carol --owner-key 9f2c...e41a
+  airgapper storage repos list`,
+}
+
+var storageReposAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Provision a repository for the owner to create",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runners.Uninitialized().Wrap(runStorageReposAdd),
+}
+
+var storageReposListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the provisioned repositories",
+	RunE:  runners.Uninitialized().Wrap(runStorageReposList),
+}
+
+func init() {
+	af := storageReposAddCmd.Flags()
+	af.StringP("path", "p", "", "Storage base path (default: the configured storage path)")
+	af.String("owner-key", "", "Hex-encoded Ed25519 public key allowed to write (default: the peer's key)")
+	af.Bool("any-writer", false, "Don't tie the repository to a key")
+
+	storageReposListCmd.Flags().StringP("path", "p", "", "Storage base path (default: the configured storage path)")
+
+	storageReposCmd.AddCommand(storageReposAddCmd)
+	storageReposCmd.AddCommand(storageReposListCmd)
+	storageCmd.AddCommand(storageReposCmd)
+}
+
+func runStorageReposAdd(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	path := flags.String("path")
+	ownerKeyHex := flags.String("owner-key")
+	anyWriter := flags.Bool("any-writer")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	var ownerKey []byte
+	switch {
+	case anyWriter && ownerKeyHex != "":
+		return fmt.Errorf("--owner-key and --any-writer can't be used together")
+	case ownerKeyHex != "":
+		key, err := hex.DecodeString(ownerKeyHex)
+		if err != nil {
+			return fmt.Errorf("invalid --owner-key: %w", err)
+		}
+		ownerKey = key
+	case !anyWriter && ctx.Config != nil && ctx.Config.IsHost() && ctx.Config.Peer != nil:
+		ownerKey = ctx.Config.Peer.PublicKey
+	}
+
+	srv, err := maintenanceServer(ctx, path)
+	if err != nil {
+		return err
+	}
+	info, err := srv.ProvisionRepo(args[0], ownerKey)
+	if err != nil {
+		return err
+	}
+
+	logging.Info("Repository provisioned", logging.String("repo", info.Name), logging.String("ownerKeyID", info.OwnerKeyID))
+	if info.OwnerKeyID == "" && !anyWriter {
+		logging.Warn("Anyone who can reach the storage may write to this repository; pass --owner-key to tie it to the owner")
+	}
+	return nil
+}
+
+func runStorageReposList(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	path := flags.String("path")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	srv, err := maintenanceServer(ctx, path)
+	if err != nil {
+		return err
+	}
+	repos := srv.ProvisionedRepos()
+	if len(repos) == 0 {
+		logging.Info("No repositories are provisioned")
+		return nil
+	}
+	for _, r := range repos {
+		logging.Info("Repository",
+			logging.String("name", r.Name),
+			logging.String("ownerKeyID", r.OwnerKeyID),
+			logging.Bool("initialized", r.Initialized),
+			logging.Bool("adopted", r.Adopted),
+			logging.String("provisioned", r.ProvisionedAt.Local().Format("2006-01-02 15:04:05")))
+	}
+	return nil
+}
@@ -0,0 +1,293 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/api"
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/grpc"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/peerqueue"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+	"github.com/lcrostarosa/airgapper/backend/internal/syncbundle"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+	"github.com/lcrostarosa/airgapper/backend/internal/verification"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Carry calls for the peer on a file when there is no network path",
+	Long: `Move the calls queued for the peer (see 'airgapper peer-queue') between two
+nodes that can't reach each other, on a file carried by hand.
+
+'sync export' writes a bundle of the queued calls - restore requests,
+released key shares, vetoes and extensions - with the IDs of the peer's
+calls this node has applied and the head of this node's audit chain. The
+bundle is signed with this node's key and encrypted with a passphrase both
+of you know. 'sync import' on the peer applies the calls it hasn't applied
+before, and drops from its own queue the calls the bundle says were applied.
+Importing the same bundle twice applies nothing twice.
+
+A round trip takes two bundles: carry one over, import it, and carry one
+back so the first node learns what was applied.
+
+The first import checks the bundle's key against the peer's. If the peer's
+key isn't known yet, compare the key ID 'sync export' prints, e.g. over the
+phone, and pass it with --trust-key.`,
+	Example: `  export SYNC_PASSPHRASE='a passphrase both of you know'
+  airgapper sync export /media/usb/alice-to-bob.agsync --passphrase-env SYNC_PASSPHRASE
+  airgapper sync import /media/usb/alice-to-bob.agsync --passphrase-env SYNC_PASSPHRASE`,
+}
+
+var syncExportCmd = &cobra.Command{
+	Use:   "export <file>",
+	Short: "Write the calls queued for the peer to a signed, encrypted bundle",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runners.Config().Wrap(runSyncExport),
+}
+
+var syncImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Apply the calls in a bundle from the peer",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runners.Config().Wrap(runSyncImport),
+}
+
+func init() {
+	for _, c := range []*cobra.Command{syncExportCmd, syncImportCmd} {
+		c.Flags().String("passphrase-env", "", "Environment variable holding the bundle's passphrase (required)")
+		_ = c.MarkFlagRequired("passphrase-env")
+	}
+	syncExportCmd.Flags().Bool("force", false, "Overwrite the file if it exists")
+	syncImportCmd.Flags().String("trust-key", "", "Key ID to accept the bundle from if the peer's key isn't known yet")
+
+	syncCmd.AddCommand(syncExportCmd)
+	syncCmd.AddCommand(syncImportCmd)
+	rootCmd.AddCommand(syncCmd)
+}
+
+// syncMethods are the RestoreRequestService calls a bundle can carry
+var syncMethods = []string{"CreateRequest", "ApproveRequest", "VetoRequest", "RequestExtension", "ApproveExtension"}
+
+func syncPassphrase(cmd *cobra.Command) (string, error) {
+	passphrase, err := passwordFromEnv(runner.Flags(cmd).String("passphrase-env"))
+	if err != nil {
+		return "", err
+	}
+	if len(passphrase) < syncbundle.MinPassphraseLength {
+		return "", fmt.Errorf("bundle passphrase must be at least %d characters", syncbundle.MinPassphraseLength)
+	}
+	return passphrase, nil
+}
+
+func runSyncExport(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	out := args[0]
+	force := runner.Flags(cmd).Bool("force")
+	passphrase, err := syncPassphrase(cmd)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(out); err == nil && !force {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", out)
+	}
+
+	queued, err := peerqueue.New(ctx.Config.PeerQueuePath()).List()
+	if err != nil {
+		return err
+	}
+	state, err := syncbundle.LoadState(ctx.Config.SyncStatePath())
+	if err != nil {
+		return err
+	}
+	c := &syncbundle.Contents{
+		From:      ctx.Config.Name,
+		Messages:  slices.DeleteFunc(queued, func(m peerqueue.Message) bool { return m.Failed() }),
+		Acks:      state.Applied,
+		AuditHead: localAuditHead(ctx.Config),
+	}
+
+	f, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err := syncbundle.Write(f, passphrase, c, ctx.Config.PrivateKey); err != nil {
+		_ = f.Close()
+		_ = os.Remove(out)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	logging.Info("Sync bundle written",
+		logging.String("file", out),
+		logging.Int("calls", len(c.Messages)),
+		logging.Int("acknowledged", len(c.Acks)),
+		logging.String("keyID", c.KeyID()))
+	logging.Infof("Import it on the peer with: airgapper sync import %s --passphrase-env <VAR>", filepath.Base(out))
+	return nil
+}
+
+// localAuditHead is the head of this host's storage audit chain, or nil
+// if it keeps none
+func localAuditHead(cfg *config.Config) *syncbundle.AuditHead {
+	if cfg.StoragePath == "" {
+		return nil
+	}
+	dir := storage.AuditChainDir(cfg.StoragePath)
+	if _, err := os.Stat(dir); err != nil {
+		return nil
+	}
+	chain, err := verification.NewAuditChain(dir, "", nil, nil, false)
+	if err != nil || chain.GetSequence() == 0 {
+		return nil
+	}
+	return &syncbundle.AuditHead{Sequence: chain.GetSequence(), Hash: chain.GetLatestHash()}
+}
+
+func runSyncImport(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	trustKey := runner.Flags(cmd).String("trust-key")
+	passphrase, err := syncPassphrase(cmd)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	c, err := syncbundle.Read(f, passphrase)
+	_ = f.Close()
+	if err != nil {
+		return err
+	}
+	if err := trustBundleKey(ctx, c, trustKey); err != nil {
+		return err
+	}
+
+	acked, err := peerqueue.New(ctx.Config.PeerQueuePath()).Acknowledge(c.Acks)
+	if err != nil {
+		return err
+	}
+	state, err := syncbundle.LoadState(ctx.Config.SyncStatePath())
+	if err != nil {
+		return err
+	}
+
+	handler := syncHandler(ctx.Config)
+	applied, skipped, failed := 0, 0, 0
+	for _, m := range c.Messages {
+		if state.WasApplied(m.ID) {
+			skipped++
+			continue
+		}
+		if err := applyPeerCall(cmd.Context(), handler, &m); err != nil {
+			logging.Warn("Could not apply the peer's "+m.Kind,
+				logging.String("id", m.ID),
+				logging.String("requestID", m.RequestID),
+				logging.String(tracing.LogKey, m.CorrelationID),
+				logging.Err(err))
+			failed++
+			continue
+		}
+		state.MarkApplied(m.ID)
+		applied++
+	}
+	if err := state.CheckAuditHead(c.AuditHead); err != nil {
+		logging.Error("The peer's audit chain was rolled back or rewritten", logging.Err(err))
+	}
+	state.LastImport = time.Now().UTC()
+	if err := state.Save(ctx.Config.SyncStatePath()); err != nil {
+		return err
+	}
+
+	logging.Info("Sync bundle imported",
+		logging.String("from", c.From),
+		logging.String("exported", c.CreatedAt.Local().Format("2006-01-02 15:04:05")),
+		logging.Int("applied", applied),
+		logging.Int("alreadyApplied", skipped),
+		logging.Int("failed", failed),
+		logging.Int("acknowledged", acked))
+	if applied > 0 {
+		logging.Info("Export a bundle back so the peer learns what was applied: airgapper sync export <file>")
+	}
+	return nil
+}
+
+// trustBundleKey checks a bundle is signed by the peer. A key given with
+// --trust-key is recorded as the peer's if it has none yet.
+func trustBundleKey(ctx *runner.CommandContext, c *syncbundle.Contents, trustKey string) error {
+	cfg := ctx.Config
+	keyID := c.KeyID()
+	switch {
+	case cfg.PublicKey != nil && crypto.KeyID(cfg.PublicKey) == keyID:
+		return fmt.Errorf("this bundle was exported by this node; import it on the peer")
+	case cfg.KnownKey(keyID):
+		return nil
+	case trustKey != keyID:
+		return fmt.Errorf("bundle from %s is signed by key %s, which isn't the peer's; check the key ID with them and pass --trust-key %s", c.From, keyID, keyID)
+	case cfg.Peer != nil && cfg.Peer.PublicKey != nil:
+		return fmt.Errorf("bundle is signed by key %s, but the peer's key is %s", keyID, crypto.KeyID(cfg.Peer.PublicKey))
+	}
+	if cfg.Peer == nil {
+		cfg.Peer = &config.PeerInfo{Name: c.From}
+	}
+	cfg.Peer.PublicKey = c.PublicKey
+	if err := ctx.SaveConfig(); err != nil {
+		return err
+	}
+	logging.Info("Recorded the peer's key", logging.String("peer", c.From), logging.String("keyID", keyID))
+	return nil
+}
+
+// syncHandler serves the calls in a bundle as this node's API would, but
+// without its authentication: the bundle is signed by the peer, and
+// whoever imports it is on this node
+func syncHandler(cfg *config.Config) http.Handler {
+	mux := http.NewServeMux()
+	grpc.NewServer(cfg, nil).RegisterHandlers(mux)
+	return http.StripPrefix(api.APIBasePath, mux)
+}
+
+// applyPeerCall makes a call from the peer's bundle against this node
+func applyPeerCall(ctx context.Context, handler http.Handler, m *peerqueue.Message) error {
+	method, ok := strings.CutPrefix(m.Path, requestServicePath(""))
+	if !ok || !slices.Contains(syncMethods, method) {
+		return fmt.Errorf("%s is not a call a bundle can carry", m.Path)
+	}
+	req, err := http.NewRequestWithContext(tracing.WithID(ctx, m.CorrelationID), http.MethodPost, m.Path, bytes.NewReader(m.Body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp := &bufferedResponse{header: http.Header{}, status: http.StatusOK}
+	handler.ServeHTTP(resp, req)
+
+	if resp.status == http.StatusOK || alreadyDelivered(m.Kind, apperrors.Code(resp.header.Get(grpc.ErrorCodeHeader))) {
+		return nil
+	}
+	return fmt.Errorf("%s: %s", http.StatusText(resp.status), strings.TrimSpace(resp.body.String()))
+}
+
+// bufferedResponse keeps a response in memory
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *bufferedResponse) Header() http.Header         { return r.header }
+func (r *bufferedResponse) Write(p []byte) (int, error) { return r.body.Write(p) }
+func (r *bufferedResponse) WriteHeader(status int)      { r.status = status }
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Manage templates for recurring restore requests",
+	Long: `Save the snapshot, paths and reason of a recurring request, such as a
+monthly test restore or an audit, and create requests from it with
+'airgapper request --template <name>'.`,
+}
+
+var templateAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Save a request template",
+	Example: `  airgapper template add monthly-verify --path /Documents \
+    --reason "Monthly verify restore of /Documents"
+  airgapper template add audit --browse --reason "Quarterly audit listing"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runners.Owner().Wrap(runTemplateAdd),
+}
+
+var templateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List request templates",
+	RunE:  runners.Owner().Wrap(runTemplateList),
+}
+
+var templateRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a request template",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runners.Owner().Wrap(runTemplateRemove),
+}
+
+func init() {
+	f := templateAddCmd.Flags()
+	f.String("snapshot", "latest", "Snapshot to request, or latest:tag=<tags> for the latest with those tags")
+	f.StringSlice("path", nil, "Path to restore (can specify multiple; default: everything)")
+	f.String("reason", "", "Reason copied into each request (required)")
+	f.Bool("browse", false, "Request approval to list the snapshot rather than restore it")
+	_ = templateAddCmd.MarkFlagRequired("reason")
+	templateAddCmd.MarkFlagsMutuallyExclusive("path", "browse")
+
+	templateCmd.AddCommand(templateAddCmd)
+	templateCmd.AddCommand(templateListCmd)
+	templateCmd.AddCommand(templateRemoveCmd)
+	rootCmd.AddCommand(templateCmd)
+}
+
+func runTemplateAdd(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	t := &consent.Template{
+		Name:       args[0],
+		SnapshotID: flags.String("snapshot"),
+		Paths:      flags.StringSlice("path"),
+		Reason:     flags.String("reason"),
+	}
+	if flags.Bool("browse") {
+		t.Purpose = consent.PurposeBrowse
+	}
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	if _, err := restic.ParseSnapshotSelector(t.SnapshotID); err != nil {
+		return err
+	}
+
+	if err := ctx.Consent().CreateTemplate(t); err != nil {
+		return err
+	}
+
+	logging.Info("Request template saved", logging.String("name", t.Name))
+	logging.Infof("Create a request from it with: airgapper request --template %s", t.Name)
+	return nil
+}
+
+func runTemplateList(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	templates, err := ctx.Consent().ListTemplates()
+	if err != nil {
+		return err
+	}
+	if len(templates) == 0 {
+		logging.Info("No request templates")
+		return nil
+	}
+
+	for _, t := range templates {
+		kind := "restore"
+		if t.Purpose == consent.PurposeBrowse {
+			kind = "browse"
+		}
+		paths := "all paths"
+		if len(t.Paths) > 0 {
+			paths = strings.Join(t.Paths, ", ")
+		}
+		logging.Info(t.Name,
+			logging.String("type", kind),
+			logging.String("snapshot", t.SnapshotID),
+			logging.String("paths", paths),
+			logging.String("reason", t.Reason))
+	}
+	return nil
+}
+
+func runTemplateRemove(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	if err := ctx.Consent().DeleteTemplate(args[0]); err != nil {
+		return err
+	}
+	logging.Info("Request template removed", logging.String("name", args[0]))
+	return nil
+}
@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/scheduler"
+	"github.com/lcrostarosa/airgapper/backend/internal/service"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+	"github.com/lcrostarosa/airgapper/backend/internal/tui"
+)
+
+// tuiSnapshotTimeout bounds listing snapshots for the backup history
+const tuiSnapshotTimeout = 30 * time.Second
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Interactive view of pending approvals, backups and storage",
+	Long: `Show pending restore and deletion requests, backup history and storage
+status in the terminal, and approve or deny requests from the keyboard.`,
+	RunE: runners.Config().Wrap(runTUI),
+}
+
+func init() {
+	rootCmd.AddCommand(tuiCmd)
+}
+
+func runTUI(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	return tui.Run(&tuiSource{ctx: ctx, mgr: ctx.Consent()})
+}
+
+// tuiSource reads the local consent state and signs approvals with this
+// node's key or share, like approve and deny do
+type tuiSource struct {
+	ctx *runner.CommandContext
+	mgr *consent.Manager
+}
+
+func (s *tuiSource) Load() (*tui.Data, error) {
+	restores, err := s.mgr.ListPending()
+	if err != nil {
+		return nil, err
+	}
+	deletions, err := s.mgr.ListPendingDeletions()
+	if err != nil {
+		return nil, err
+	}
+
+	data := &tui.Data{Restores: restores, Deletions: deletions}
+	s.loadBackups(data)
+	s.loadStatus(data)
+	return data, nil
+}
+
+func (s *tuiSource) loadBackups(data *tui.Data) {
+	cfg := s.ctx.Config
+	switch {
+	case cfg.IsHost():
+		data.BackupsNote = "Hosts can't list snapshots - the data is encrypted (see Status for the vault)"
+		return
+	case cfg.Password == "":
+		data.BackupsNote = "No repository password - cannot list snapshots"
+		return
+	case !restic.IsInstalled():
+		data.BackupsNote = "restic is not installed"
+		return
+	}
+
+	c, cancel := context.WithTimeout(context.Background(), tuiSnapshotTimeout)
+	defer cancel()
+	snapshots, err := restic.NewClient(cfg.RepoURL, cfg.Password).WithIdentity(cfg.PrivateKey).WithOptions(cfg.Restic).SnapshotList(c)
+	if err != nil {
+		data.BackupsNote = "Failed to list snapshots: " + err.Error()
+		return
+	}
+
+	slices.SortFunc(snapshots, func(a, b restic.Snapshot) int { return b.Time.Compare(a.Time) })
+	for _, snap := range snapshots {
+		data.Backups = append(data.Backups, tui.Backup{
+			Time:   snap.Time,
+			ID:     snap.ShortID,
+			Detail: snap.Hostname + "  " + strings.Join(snap.Paths, ", "),
+		})
+	}
+}
+
+func (s *tuiSource) loadStatus(data *tui.Data) {
+	cfg := s.ctx.Config
+	add := func(label, value string) {
+		data.Status = append(data.Status, tui.Field{Label: label, Value: value})
+	}
+
+	add("Name", cfg.Name)
+	add("Role", string(cfg.Role))
+	add("Repository", cfg.RepoURL)
+	if cfg.Consensus != nil {
+		rule := fmt.Sprintf("%d of %d key holders", cfg.Consensus.Threshold, cfg.Consensus.TotalKeys)
+		if q, err := cfg.Quorum(); err == nil && q != nil {
+			rule = "quorum " + q.String()
+		}
+		add("Approvals", rule)
+	}
+	if cfg.Peer != nil {
+		add("Peer", strings.TrimSpace(cfg.Peer.Name+" "+cfg.Peer.Address))
+	}
+
+	if cfg.IsOwner() {
+		if cfg.BackupSchedule == "" {
+			add("Schedule", "not configured")
+		} else {
+			add("Schedule", cfg.BackupSchedule+" "+scheduleTimezone(cfg)+" ("+strings.Join(cfg.BackupPaths, ", ")+")")
+		}
+		if st, err := scheduler.LoadJobState(cfg.BackupStatePath()); err == nil && !st.LastAttempt.IsZero() {
+			last := "ok"
+			if st.Failed() {
+				last = fmt.Sprintf("FAILED (%d in a row): %s", st.ConsecutiveFailures, st.LastError)
+			}
+			add("Last backup", st.LastAttempt.Local().Format("2006-01-02 15:04")+"  "+last)
+			if !st.LastSuccess.IsZero() {
+				add("Last success", st.LastSuccess.Local().Format("2006-01-02 15:04"))
+			}
+		}
+	}
+
+	if cfg.StoragePath != "" {
+		add("Storage", cfg.StoragePath)
+		if cfg.StorageQuotaBytes > 0 {
+			add("Quota", fmt.Sprintf("%d bytes", cfg.StorageQuotaBytes))
+		}
+		if srv, err := storage.NewServer(storage.Config{BasePath: cfg.StoragePath}); err == nil {
+			meta := srv.VaultMetadata()
+			add("Used", fmt.Sprintf("%d bytes in %d snapshots", meta.UsedBytes, meta.Snapshots))
+			if !meta.LastSnapshot.IsZero() {
+				add("Last snapshot", meta.LastSnapshot.Local().Format("2006-01-02 15:04"))
+			}
+			if meta.DaysSinceContact < 0 {
+				add("Owner contact", "never")
+			} else {
+				add("Owner contact", fmt.Sprintf("%s (%d days ago)", meta.LastOwnerContact.Local().Format("2006-01-02 15:04"), meta.DaysSinceContact))
+			}
+		}
+	}
+}
+
+func (s *tuiSource) ApproveRestore(id string) (string, error) {
+	cfg := s.ctx.Config
+	if !cfg.UsesConsensusMode() && cfg.PrivateKey == nil {
+		share, _, err := cfg.LoadShare()
+		if err != nil {
+			return "", fmt.Errorf("failed to load share: %w", err)
+		}
+		if err := s.mgr.Approve(id, cfg.Name, share); err != nil {
+			return "", err
+		}
+		return "Approved " + id + " - key share released", nil
+	}
+
+	if cfg.PrivateKey == nil {
+		return "", fmt.Errorf("no private key found - cannot sign")
+	}
+	req, err := s.mgr.GetRequest(id)
+	if err != nil {
+		return "", err
+	}
+	keyID := crypto.KeyID(cfg.PublicKey)
+	approval, err := signRestoreRequest(s.ctx, s.mgr, req, keyID)
+	if err != nil {
+		return "", err
+	}
+	if err := s.mgr.AddSignature(id, keyID, cfg.Name, approval.Nonce, approval.SignedAt, approval.Signature); err != nil {
+		return "", err
+	}
+
+	current, required, _ := s.mgr.GetApprovalProgress(id)
+	return fmt.Sprintf("Signed %s (%d/%d approvals)", id, current, required), nil
+}
+
+func (s *tuiSource) DenyRestore(id string) error {
+	return consent.Restores.Deny(s.mgr, id, s.ctx.Config.Name)
+}
+
+func (s *tuiSource) ApproveDeletion(id string) (string, error) {
+	cfg := s.ctx.Config
+	if cfg.PrivateKey == nil {
+		return "", fmt.Errorf("no private key found - cannot sign")
+	}
+	req, err := s.mgr.GetDeletionRequest(id)
+	if err != nil {
+		return "", err
+	}
+
+	keyID := crypto.KeyID(cfg.PublicKey)
+	signature, err := (&crypto.DeletionRequestSignData{
+		RequestID:    req.ID,
+		Requester:    req.Requester,
+		DeletionType: string(req.DeletionType),
+		SnapshotIDs:  req.SnapshotIDs,
+		Paths:        req.Paths,
+		Reason:       req.Reason,
+		CreatedAt:    req.CreatedAt.Unix(),
+		KeyHolderID:  keyID,
+	}).Sign(cfg.PrivateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign deletion: %w", err)
+	}
+
+	progress, err := service.NewConsentService(cfg, s.mgr).ApproveDeletion(id, keyID, signature)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Signed deletion %s (%d/%d approvals)", id, progress.Current, progress.Required), nil
+}
+
+func (s *tuiSource) DenyDeletion(id string) error {
+	return consent.Deletions.Deny(s.mgr, id, s.ctx.Config.Name)
+}
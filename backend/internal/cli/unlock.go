@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/emergency"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+var unlockCmd = &cobra.Command{
+	Use:   "unlock",
+	Short: "Remove repository locks left by interrupted backups (owner only)",
+	Long: `List the repository's locks and remove stale ones.
+
+An interrupted backup, prune or check leaves its lock behind, and the next
+run fails with "repository is already locked". Running commands refresh
+their locks every few minutes, so a lock older than 30 minutes is stale and
+safe to remove.
+
+--all also removes active locks, which can corrupt a backup that is still
+running. It needs the force-unlock override to be allowed first:
+
+  airgapper override allow force-unlock`,
+	Example: `  airgapper unlock
+  airgapper unlock --list
+  airgapper unlock --all`,
+	RunE: runners.Owner().Use(runner.RequirePassword()).Wrap(runUnlock),
+}
+
+func init() {
+	unlockCmd.Flags().Bool("list", false, "Only list locks")
+	unlockCmd.Flags().Bool("all", false, "Also remove active locks (needs the force-unlock override)")
+	rootCmd.AddCommand(unlockCmd)
+}
+
+func runUnlock(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	listOnly := flags.Bool("list")
+	all := flags.Bool("all")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+
+	if all && !ctx.Config.Emergency.GetOverride().IsTypeAllowed(emergency.OverrideForceUnlock) {
+		return fmt.Errorf("removing active locks needs the force-unlock override: run 'airgapper override allow force-unlock' first")
+	}
+	if !restic.IsInstalled() {
+		return fmt.Errorf("restic is not installed")
+	}
+
+	client := restic.NewClient(ctx.Config.RepoURL, ctx.Config.Password).WithIdentity(ctx.Config.PrivateKey).WithOptions(ctx.Config.Restic)
+	locks, err := client.Locks(cmd.Context())
+	if err != nil {
+		return err
+	}
+	if len(locks) == 0 {
+		logging.Info("Repository is not locked")
+		return nil
+	}
+
+	now := time.Now()
+	stale := 0
+	for _, l := range locks {
+		id := l.ID
+		if len(id) > 8 {
+			id = id[:8]
+		}
+		state := "active"
+		if l.Stale(now) {
+			state = "stale"
+			stale++
+		}
+		logging.Info("Lock",
+			logging.String("id", id),
+			logging.String("state", state),
+			logging.Bool("exclusive", l.Exclusive),
+			logging.String("host", l.Hostname),
+			logging.Int("pid", l.PID),
+			logging.String("age", now.Sub(l.Time).Round(time.Second).String()))
+	}
+	if listOnly {
+		return nil
+	}
+	if stale == 0 && !all {
+		logging.Info("No stale locks; active locks belong to running commands (--all removes them anyway)")
+		return nil
+	}
+
+	if err := client.Unlock(cmd.Context(), all); err != nil {
+		return err
+	}
+	removed := stale
+	if all {
+		removed = len(locks)
+	}
+	logging.Info("Repository unlocked", logging.Int("removed", removed))
+	return nil
+}
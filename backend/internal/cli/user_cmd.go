@@ -0,0 +1,291 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/users"
+)
+
+var userCmd = &cobra.Command{
+	Use:   "user",
+	Short: "Manage the API's local user accounts",
+	Long: `Let several people share this node's API with different rights, e.g. on a
+family NAS running the host role where one person approves restores and the
+others only check on them.
+
+Roles:
+  viewer    read status, requests and history
+  approver  also approve, deny, veto and extend requests
+  admin     anything, including managing users
+
+While there are no accounts the API needs no credentials. Adding the first
+account, which must be an admin, turns authentication on: every call then
+needs a user's API token (Authorization: Bearer <token>) or name and
+password. Tokens are shown once, when issued. The web UI logs in with a
+name and password instead, and its sessions can be listed and ended here.
+
+Passwords aren't passed on the command line: --password-env names an
+environment variable holding it.`,
+}
+
+var userAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Add a user account and print its API token",
+	Example: `  airgapper user add alice --role admin --password-env ALICE_PASSWORD
+  airgapper user add kid --role viewer`,
+	Args: cobra.ExactArgs(1),
+	RunE: runners.Config().Wrap(runUserAdd),
+}
+
+var userListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List user accounts",
+	RunE:  runners.Config().Wrap(runUserList),
+}
+
+var userRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a user account",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runners.Config().Wrap(runUserRemove),
+}
+
+var userRoleCmd = &cobra.Command{
+	Use:     "role <name> <viewer|approver|admin>",
+	Short:   "Change a user's role",
+	Example: `  airgapper user role bob approver`,
+	Args:    cobra.ExactArgs(2),
+	RunE:    runners.Config().Wrap(runUserRole),
+}
+
+var userPasswordCmd = &cobra.Command{
+	Use:     "password <name>",
+	Short:   "Change a user's password",
+	Example: `  airgapper user password bob --password-env BOB_PASSWORD`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runners.Config().Wrap(runUserPassword),
+}
+
+var userTokenCmd = &cobra.Command{
+	Use:   "token <name>",
+	Short: "Issue a user a new API token, revoking their old one",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runners.Config().Wrap(runUserToken),
+}
+
+var userSessionsCmd = &cobra.Command{
+	Use:   "sessions [name]",
+	Short: "List web UI login sessions, of one user or everyone",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runners.Config().Wrap(runUserSessions),
+}
+
+var userRevokeSessionCmd = &cobra.Command{
+	Use:   "revoke-session <session-id>",
+	Short: "End a web UI login session, e.g. one left open on a shared computer",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runners.Config().Wrap(runUserRevokeSession),
+}
+
+var userPeerTokenCmd = &cobra.Command{
+	Use:   "peer-token <token>",
+	Short: "Save the API token the peer issued this node",
+	Long: `If the peer has user accounts, its admin issues this node an account
+(an approver, so vetoes, extensions and restore progress can be passed on)
+and gives you its token. Save it here and this node sends it on every call
+to the configured peer. Pass an empty token to forget it.`,
+	Example: `  airgapper user peer-token agt_0123...`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runners.Config().Wrap(runUserPeerToken),
+}
+
+func init() {
+	userAddCmd.Flags().String("role", "", "viewer, approver or admin (required)")
+	userAddCmd.Flags().String("password-env", "", "Environment variable holding the user's password (default: token only)")
+	_ = userAddCmd.MarkFlagRequired("role")
+	userPasswordCmd.Flags().String("password-env", "", "Environment variable holding the new password (required)")
+	_ = userPasswordCmd.MarkFlagRequired("password-env")
+
+	userCmd.AddCommand(userAddCmd)
+	userCmd.AddCommand(userListCmd)
+	userCmd.AddCommand(userRemoveCmd)
+	userCmd.AddCommand(userRoleCmd)
+	userCmd.AddCommand(userPasswordCmd)
+	userCmd.AddCommand(userTokenCmd)
+	userCmd.AddCommand(userSessionsCmd)
+	userCmd.AddCommand(userRevokeSessionCmd)
+	userCmd.AddCommand(userPeerTokenCmd)
+	rootCmd.AddCommand(userCmd)
+}
+
+// passwordFromEnv reads the password in environment variable name, if set
+func passwordFromEnv(name string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+	password, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return password, nil
+}
+
+func runUserAdd(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	roleName := flags.String("role")
+	passwordEnv := flags.String("password-env")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	role, err := users.ParseRole(roleName)
+	if err != nil {
+		return err
+	}
+	password, err := passwordFromEnv(passwordEnv)
+	if err != nil {
+		return err
+	}
+
+	store := users.NewStore(ctx.Config.ConfigDir)
+	wasEnabled, err := store.Enabled()
+	if err != nil {
+		return err
+	}
+	token, err := store.Add(args[0], role, password)
+	if err != nil {
+		return err
+	}
+
+	logging.Info("User added", logging.String("name", args[0]), logging.String("role", string(role)))
+	logging.Info("API token (shown only once)", logging.String("token", token))
+	if !wasEnabled {
+		logging.Warn("The API now requires credentials; give peers and dashboards a token")
+	}
+	return nil
+}
+
+func runUserList(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	list, err := users.NewStore(ctx.Config.ConfigDir).List()
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		logging.Info("No user accounts; the API needs no credentials")
+		return nil
+	}
+	for _, u := range list {
+		login := "token"
+		if u.HasPassword() {
+			login = "token or password"
+		}
+		logging.Info(u.Name,
+			logging.String("role", string(u.Role)),
+			logging.String("login", login),
+			logging.String("created", u.CreatedAt.Local().Format("2006-01-02 15:04")))
+	}
+	return nil
+}
+
+func runUserRemove(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	store := users.NewStore(ctx.Config.ConfigDir)
+	if err := store.Remove(args[0]); err != nil {
+		return err
+	}
+	logging.Info("User removed", logging.String("name", args[0]))
+	if enabled, err := store.Enabled(); err == nil && !enabled {
+		logging.Warn("No user accounts remain; the API no longer requires credentials")
+	}
+	return nil
+}
+
+func runUserRole(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	role, err := users.ParseRole(args[1])
+	if err != nil {
+		return err
+	}
+	if err := users.NewStore(ctx.Config.ConfigDir).SetRole(args[0], role); err != nil {
+		return err
+	}
+	logging.Info("User role changed", logging.String("name", args[0]), logging.String("role", string(role)))
+	return nil
+}
+
+func runUserPassword(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	passwordEnv := flags.String("password-env")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	password, err := passwordFromEnv(passwordEnv)
+	if err != nil {
+		return err
+	}
+	if err := users.NewStore(ctx.Config.ConfigDir).SetPassword(args[0], password); err != nil {
+		return err
+	}
+	logging.Info("User password changed", logging.String("name", args[0]))
+	return nil
+}
+
+func runUserToken(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	token, err := users.NewStore(ctx.Config.ConfigDir).IssueToken(args[0])
+	if err != nil {
+		return err
+	}
+	logging.Info("New API token issued; the old one no longer works", logging.String("name", args[0]))
+	logging.Info("API token (shown only once)", logging.String("token", token))
+	return nil
+}
+
+func runUserSessions(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	name := ""
+	if len(args) > 0 {
+		name = args[0]
+	}
+	sessions, err := users.NewStore(ctx.Config.ConfigDir).Sessions(name)
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		logging.Info("No login sessions")
+		return nil
+	}
+	for _, s := range sessions {
+		logging.Info(s.ID,
+			logging.String("user", s.User),
+			logging.String("from", s.RemoteAddr),
+			logging.String("browser", s.UserAgent),
+			logging.String("lastSeen", s.LastSeenAt.Local().Format("2006-01-02 15:04")),
+			logging.String("expires", s.ExpiresAt().Local().Format("2006-01-02 15:04")))
+	}
+	return nil
+}
+
+func runUserRevokeSession(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	if err := users.NewStore(ctx.Config.ConfigDir).RevokeSession(args[0]); err != nil {
+		return err
+	}
+	logging.Info("Session ended", logging.String("session", args[0]))
+	return nil
+}
+
+func runUserPeerToken(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	if ctx.Config.Peer == nil {
+		return fmt.Errorf("no peer configured")
+	}
+	ctx.Config.Peer.Token = args[0]
+	if err := ctx.Config.Save(); err != nil {
+		return err
+	}
+	if args[0] == "" {
+		logging.Info("Peer API token removed")
+	} else {
+		logging.Info("Peer API token saved", logging.String("peer", ctx.Config.Peer.Name))
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package cli
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/api"
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/sss"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+	"github.com/lcrostarosa/airgapper/backend/internal/verification"
+)
+
+var verifySharesCmd = &cobra.Command{
+	Use:   "verify-shares",
+	Short: "Check your key share and the host's still combine to the password (owner only)",
+	Long: `Prove to the host that you still hold your key share, and have the host
+prove it still holds its own, without either share leaving its machine.
+
+Each side answers a fresh nonce with an HMAC keyed by its share's
+fingerprint. The host checks yours against the fingerprint it recorded on
+the first check; you check the host's against the fingerprint recorded at
+init (or, for 2-of-n splits, the share derived from the password).
+
+'airgapper serve' repeats the check every 30 days, so a lost or corrupted
+share is found long before a restore needs it.`,
+	Example: `  airgapper verify-shares
+  airgapper verify-shares --host https://bob-nas:8081`,
+	RunE: runners.Owner().Use(runner.RequirePassword()).Wrap(runVerifyShares),
+}
+
+func init() {
+	verifySharesCmd.Flags().String("host", "", "Host API address (default: the peer's address)")
+	rootCmd.AddCommand(verifySharesCmd)
+}
+
+func runVerifyShares(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	hostAddr := flags.String("host")
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	if hostAddr == "" && ctx.Config.Peer != nil {
+		hostAddr = ctx.Config.Peer.Address
+	}
+	if hostAddr == "" {
+		return fmt.Errorf("no host address configured; use --host")
+	}
+
+	checkCtx, correlationID := tracing.Ensure(cmd.Context())
+	logging.Info("Checking key shares with host",
+		logging.String("host", hostAddr),
+		logging.String(tracing.LogKey, correlationID))
+
+	err := verifyShares(checkCtx, ctx.Config, hostAddr)
+	if saveErr := ctx.SaveConfig(); saveErr != nil {
+		return saveErr
+	}
+	if err != nil {
+		return err
+	}
+	logging.Info("Key shares verified: yours and the host's combine to the repository password")
+	return nil
+}
+
+// verifyShares runs a share check against the host and records the result
+// in cfg.ShareCheck; the caller saves the config. Only a definite answer
+// from the host is recorded, so an unreachable host is not a mismatch.
+func verifyShares(ctx context.Context, cfg *config.Config, hostAddr string) error {
+	if !cfg.UsesSSSMode() {
+		return fmt.Errorf("share checks need SSS mode; this vault uses consensus mode")
+	}
+	if cfg.ShareCheck == nil {
+		cfg.ShareCheck = &verification.ShareCheckState{}
+	}
+	state := cfg.ShareCheck
+
+	// Enroll on the first check, and again if the host has forgotten us
+	// (e.g. after re-joining)
+	enroll := state.LastVerified.IsZero()
+	answer, challenge, err := sendShareCheck(ctx, cfg, hostAddr, enroll)
+	if err == nil && !answer.OwnerEnrolled && !enroll {
+		answer, challenge, err = sendShareCheck(ctx, cfg, hostAddr, true)
+	}
+	if err != nil {
+		return err
+	}
+
+	fingerprint, err := hostShareFingerprint(cfg, answer.HostIndex)
+	if err != nil {
+		return err
+	}
+	err = challenge.VerifyHost(answer, fingerprint)
+	state.Record(time.Now(), err)
+	return err
+}
+
+// sendShareCheck posts a fresh challenge to the host and decodes its answer
+func sendShareCheck(ctx context.Context, cfg *config.Config, hostAddr string, enroll bool) (*verification.ShareAnswer, *verification.ShareChallenge, error) {
+	challenge, err := verification.NewShareChallenge(cfg.ShareIndex, cfg.LocalShare, enroll)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := json.Marshal(challenge)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := checkPeerFeature(ctx, hostAddr, api.FeatureShareCheck); err != nil {
+		return nil, nil, err
+	}
+	endpoint := strings.TrimSuffix(hostAddr, "/") + api.APIBasePath + api.ShareCheckPath
+	resp, err := postToPeer(ctx, 30*time.Second, endpoint, body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to reach host: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, featureError(ctx, hostAddr, api.FeatureShareCheck, "host rejected share check", resp)
+	}
+
+	var answer verification.ShareAnswer
+	if err := json.NewDecoder(resp.Body).Decode(&answer); err != nil {
+		return nil, nil, fmt.Errorf("invalid share check answer from host: %w", err)
+	}
+	return &answer, challenge, nil
+}
+
+// hostShareFingerprint is the fingerprint the host's share should have:
+// the one recorded at init, or else derived from the password and our
+// share, which works whenever 2 shares restore the password
+func hostShareFingerprint(cfg *config.Config, hostIndex byte) ([]byte, error) {
+	if cfg.ShareCheck != nil && cfg.ShareCheck.PeerFingerprint != "" {
+		return hex.DecodeString(cfg.ShareCheck.PeerFingerprint)
+	}
+	if threshold := cfg.Emergency.GetRecovery().GetThreshold(); threshold > 2 {
+		return nil, fmt.Errorf("no fingerprint recorded for the host's share, and with a %d-share threshold it can't be derived", threshold)
+	}
+	share, err := sss.Derive([]byte(cfg.Password), sss.Share{Index: cfg.ShareIndex, Data: cfg.LocalShare}, hostIndex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive the host's share: %w", err)
+	}
+	return verification.ShareFingerprint(hostIndex, share), nil
+}
+
+// startShareChecks re-checks shares with the host whenever a check is due,
+// notifying on a mismatch. It returns a function that stops the checks.
+func startShareChecks(serveCfg *config.Config) func() {
+	if !serveCfg.IsOwner() || !serveCfg.UsesSSSMode() || serveCfg.Password == "" ||
+		serveCfg.Peer == nil || serveCfg.Peer.Address == "" {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	check := func() {
+		if !serveCfg.ShareCheck.Due(time.Now(), verification.ShareCheckInterval) {
+			return
+		}
+		err := verifyShares(ctx, serveCfg, serveCfg.Peer.Address)
+		switch {
+		case errors.Is(err, verification.ErrShareMismatch):
+			logging.Error("Key shares no longer match; restores will fail", logging.Err(err))
+			api.NotifyShareMismatch(ctx, serveCfg, err)
+		case err != nil:
+			logging.Warn("Share check failed", logging.Err(err))
+			return
+		default:
+			logging.Info("Key shares verified with host")
+		}
+		if err := serveCfg.Save(); err != nil {
+			logging.Warn("Failed to save share check result", logging.Err(err))
+		}
+	}
+
+	go func() {
+		check()
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				check()
+			}
+		}
+	}()
+	return cancel
+}
@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/cli/runner"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/volumes"
+)
+
+var volumesCmd = &cobra.Command{
+	Use:   "volumes",
+	Short: "Back up Docker and Podman volumes",
+	Long: `Back up named volumes of a Docker or Podman engine. Volumes are listed
+through the engine's API socket and each selected volume is stored as its own
+snapshot tagged volume:<name>, so it can be found and restored on its own.
+
+Select volumes with --include and --exclude name globs; a volume labelled
+airgapper.exclude=true is always skipped. While 'airgapper serve' is running, selected volumes are backed up after each
+scheduled backup.
+
+Volumes are read in one of two modes:
+  path     Back up the volume's directory on the host (needs root for Docker)
+  archive  Export the volume through a temporary helper container, for
+           rootless Podman, Docker Desktop or when the data directory isn't
+           readable. Snapshots hold volumes/<name>.tar.`,
+}
+
+var volumesEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Enable volume backups",
+	Example: `  # Back up every volume except caches
+  airgapper volumes enable --exclude '*cache*'
+
+  # Back up some volumes of rootless Podman
+  airgapper volumes enable --mode archive --include 'nextcloud_*' --include vaultwarden
+
+  # Back up up to three volumes at a time
+  airgapper volumes enable --concurrency 3`,
+	RunE: runners.Owner().Wrap(runVolumesEnable),
+}
+
+var volumesDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Disable volume backups",
+	RunE:  runners.Owner().Wrap(runVolumesDisable),
+}
+
+var volumesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List volumes and whether they are backed up",
+	RunE:  runners.Owner().Wrap(runVolumesList),
+}
+
+var volumesBackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Back up the selected volumes now",
+	RunE:  runners.OwnerWithPassword().Wrap(runVolumesBackup),
+}
+
+func init() {
+	f := volumesEnableCmd.Flags()
+	f.String("socket", "", "Engine API socket (default: DOCKER_HOST, Docker's or Podman's socket)")
+	f.String("mode", string(volumes.ModePath), "How volumes are read: path or archive")
+	f.String("helper-image", "", "Image for archive mode's helper container (default: "+volumes.DefaultHelperImage+")")
+	f.StringSlice("include", nil, "Only back up volumes matching this glob (can specify multiple)")
+	f.StringSlice("exclude", nil, "Don't back up volumes matching this glob (can specify multiple)")
+	f.Int("concurrency", 1, "How many volumes are backed up at once")
+
+	volumesCmd.AddCommand(volumesEnableCmd)
+	volumesCmd.AddCommand(volumesDisableCmd)
+	volumesCmd.AddCommand(volumesListCmd)
+	volumesCmd.AddCommand(volumesBackupCmd)
+	rootCmd.AddCommand(volumesCmd)
+}
+
+func runVolumesEnable(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	flags := runner.Flags(cmd)
+	vc := &volumes.Config{
+		Enabled:     true,
+		Socket:      flags.String("socket"),
+		Mode:        volumes.Mode(flags.String("mode")),
+		HelperImage: flags.String("helper-image"),
+		Include:     flags.StringSlice("include"),
+		Exclude:     flags.StringSlice("exclude"),
+		Concurrency: flags.Int("concurrency"),
+	}
+	if err := flags.Err(); err != nil {
+		return err
+	}
+	if err := vc.Validate(); err != nil {
+		return err
+	}
+
+	ctx.Config.DockerVolumes = vc
+	if err := ctx.SaveConfig(); err != nil {
+		return err
+	}
+
+	logging.Info("Volume backups enabled",
+		logging.String("socket", vc.GetSocket()),
+		logging.String("mode", string(vc.GetMode())))
+	return nil
+}
+
+func runVolumesDisable(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	if !ctx.Config.VolumesEnabled() {
+		logging.Info("Volume backups are not enabled")
+		return nil
+	}
+	ctx.Config.DockerVolumes.Enabled = false
+	if err := ctx.SaveConfig(); err != nil {
+		return err
+	}
+	logging.Info("Volume backups disabled")
+	return nil
+}
+
+func runVolumesList(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	vc := ctx.Config.DockerVolumes
+	if vc == nil {
+		vc = &volumes.Config{}
+	}
+
+	vols, err := volumes.NewClient(vc.GetSocket()).Volumes(cmd.Context())
+	if err != nil {
+		return err
+	}
+	if len(vols) == 0 {
+		logging.Info("No volumes found", logging.String("socket", vc.GetSocket()))
+		return nil
+	}
+	for _, v := range vols {
+		logging.Info("Volume",
+			logging.String("name", v.Name),
+			logging.String("driver", v.Driver),
+			logging.Bool("selected", vc.Selected(v)))
+	}
+	if !vc.Enabled {
+		logging.Info("Volume backups are disabled - enable with: airgapper volumes enable")
+	}
+	return nil
+}
+
+func runVolumesBackup(ctx *runner.CommandContext, cmd *cobra.Command, args []string) error {
+	if !ctx.Config.VolumesEnabled() {
+		return fmt.Errorf("volume backups are not enabled - enable with: airgapper volumes enable")
+	}
+	if !restic.IsInstalled() {
+		return fmt.Errorf("restic is not installed")
+	}
+
+	repo := restic.NewClient(ctx.Config.RepoURL, ctx.Config.Password).WithIdentity(ctx.Config.PrivateKey).WithOptions(ctx.Config.Restic).WithCompression(ctx.Config.BackupCompression)
+	if err := backupVolumes(cmd.Context(), ctx.Config, repo, false); err != nil {
+		return fmt.Errorf("volume backup failed: %w", err)
+	}
+	logging.Info("Volume backup complete")
+	return nil
+}
@@ -0,0 +1,93 @@
+// Package clientid ties writes to a storage repository to the owner's key,
+// so whoever finds the storage URL can't upload to the repository or race
+// the owner to create its config.
+//
+// restic can't add headers of its own to REST requests, but it sends HTTP
+// basic auth on every one of them (RESTIC_REST_USERNAME and
+// RESTIC_REST_PASSWORD, restic 0.17 or later). The owner puts its key ID in
+// the username, and in the password the time it signed and an Ed25519
+// signature over the repository, key ID and that time. The host checks the
+// signature against the owner's key in the policy.
+//
+// A credential holds for MaxAge, long enough for a large backup, since
+// restic sends the same one for the whole run; over TLS it can't be read
+// off the wire.
+package clientid
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+)
+
+const (
+	// MaxAge is how long after signing a credential is accepted
+	MaxAge = 24 * time.Hour
+	// MaxSkew is how far ahead of the host's clock a credential may be signed
+	MaxSkew = 5 * time.Minute
+)
+
+var (
+	// ErrMissing is returned for a request without a credential
+	ErrMissing = errors.New("no owner identity")
+	// ErrUnknownKey is returned for a credential from a key that isn't the owner's
+	ErrUnknownKey = errors.New("not signed by the owner's key")
+)
+
+// message is what the owner signs
+func message(repo, keyID string, signedAt int64) []byte {
+	return fmt.Appendf(nil, "airgapper-storage-write/v1\n%s\n%s\n%d", repo, keyID, signedAt)
+}
+
+// Sign returns the basic auth username and password binding writes to repo
+// to privateKey, signed at now
+func Sign(privateKey []byte, repo string, now time.Time) (username, password string, err error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return "", "", errors.New("invalid private key size")
+	}
+	keyID := crypto.KeyID(ed25519.PrivateKey(privateKey).Public().(ed25519.PublicKey))
+	signedAt := now.Unix()
+	sig, err := crypto.Sign(privateKey, message(repo, keyID, signedAt))
+	if err != nil {
+		return "", "", err
+	}
+	return keyID, strconv.FormatInt(signedAt, 10) + "." + hex.EncodeToString(sig), nil
+}
+
+// Verify checks that r carries a credential for repo signed by ownerKey,
+// current at now, and returns its key ID
+func Verify(r *http.Request, repo string, ownerKey []byte, now time.Time) (string, error) {
+	keyID, password, ok := r.BasicAuth()
+	if !ok {
+		return "", ErrMissing
+	}
+	if keyID != crypto.KeyID(ownerKey) {
+		return keyID, fmt.Errorf("key %s is %w", keyID, ErrUnknownKey)
+	}
+	ts, sigHex, ok := strings.Cut(password, ".")
+	if !ok {
+		return keyID, errors.New("malformed owner identity")
+	}
+	signedAt, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return keyID, errors.New("malformed owner identity")
+	}
+	sig, err := hex.DecodeString(sigHex)
+	if err != nil || !crypto.Verify(ownerKey, message(repo, keyID, signedAt), sig) {
+		return keyID, fmt.Errorf("owner identity signature is invalid for repository %s", repo)
+	}
+	switch at := time.Unix(signedAt, 0); {
+	case at.After(now.Add(MaxSkew)):
+		return keyID, fmt.Errorf("owner identity is signed %s in the future; check the clocks", at.Sub(now).Round(time.Second))
+	case now.Sub(at) > MaxAge:
+		return keyID, fmt.Errorf("owner identity expired %s ago", (now.Sub(at) - MaxAge).Round(time.Second))
+	}
+	return keyID, nil
+}
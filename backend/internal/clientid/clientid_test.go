@@ -0,0 +1,59 @@
+package clientid
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+)
+
+func TestVerify(t *testing.T) {
+	pub, priv, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+	otherPub, otherPriv, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	verify := func(priv []byte, signedFor string, signedAt time.Time, repo string) (string, error) {
+		r := httptest.NewRequest("PUT", "/"+repo+"/data/ab", nil)
+		if priv != nil {
+			user, pass, err := Sign(priv, signedFor, signedAt)
+			require.NoError(t, err)
+			r.SetBasicAuth(user, pass)
+		}
+		return Verify(r, repo, pub, now)
+	}
+
+	keyID, err := verify(priv, "alice", now.Add(-time.Hour), "alice")
+	require.NoError(t, err)
+	assert.Equal(t, crypto.KeyID(pub), keyID)
+
+	_, err = verify(nil, "", now, "alice")
+	assert.ErrorIs(t, err, ErrMissing)
+	keyID, err = verify(otherPriv, "alice", now, "alice")
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	assert.Equal(t, crypto.KeyID(otherPub), keyID)
+	_, err = verify(priv, "alice", now, "bob")
+	assert.ErrorContains(t, err, "invalid for repository bob", "bound to the repository")
+	_, err = verify(priv, "alice", now.Add(-MaxAge-time.Minute), "alice")
+	assert.ErrorContains(t, err, "expired")
+	_, err = verify(priv, "alice", now.Add(MaxSkew+time.Minute), "alice")
+	assert.ErrorContains(t, err, "future")
+	_, err = verify(priv, "alice", now.Add(MaxSkew-time.Minute), "alice")
+	assert.NoError(t, err, "within the allowed skew")
+
+	// A signature can't be moved to another time
+	r := httptest.NewRequest("PUT", "/alice/config", nil)
+	user, pass, err := Sign(priv, "alice", now.Add(-MaxAge-time.Hour))
+	require.NoError(t, err)
+	_, sig, _ := strings.Cut(pass, ".")
+	r.SetBasicAuth(user, strconv.FormatInt(now.Unix(), 10)+"."+sig)
+	_, err = Verify(r, "alice", pub, now)
+	assert.ErrorContains(t, err, "signature is invalid")
+}
@@ -2,14 +2,33 @@
 package config
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
 	"github.com/lcrostarosa/airgapper/backend/internal/emergency"
 	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/fssnap"
+	"github.com/lcrostarosa/airgapper/backend/internal/integrity"
+	"github.com/lcrostarosa/airgapper/backend/internal/keyprotect"
+	"github.com/lcrostarosa/airgapper/backend/internal/lockdown"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/replication"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/scheduler"
+	"github.com/lcrostarosa/airgapper/backend/internal/server"
+	"github.com/lcrostarosa/airgapper/backend/internal/sources"
+	"github.com/lcrostarosa/airgapper/backend/internal/sss"
 	"github.com/lcrostarosa/airgapper/backend/internal/verification"
+	"github.com/lcrostarosa/airgapper/backend/internal/volumes"
 )
 
 // Role defines the role of this node
@@ -36,6 +55,18 @@ type ConsensusConfig struct {
 	TotalKeys       int         `json:"total_keys"`
 	KeyHolders      []KeyHolder `json:"key_holders"`
 	RequireApproval bool        `json:"require_approval,omitempty"`
+
+	// Optional quorum rule used instead of the flat Threshold
+	Quorum *QuorumConfig `json:"quorum,omitempty"`
+}
+
+// QuorumConfig is a richer approval rule than Threshold, e.g. "owner + any
+// one host" is RequireOwner with MinWeight 2
+type QuorumConfig struct {
+	RequireOwner bool           `json:"require_owner,omitempty"` // Owner's approval is mandatory
+	Required     []string       `json:"required,omitempty"`      // Other key holder IDs whose approval is mandatory
+	Weights      map[string]int `json:"weights,omitempty"`       // Key holder ID -> weight; unlisted keys weigh 1
+	MinWeight    int            `json:"min_weight,omitempty"`    // Total weight needed (default: Threshold)
 }
 
 // PeerInfo represents information about the other party
@@ -43,10 +74,14 @@ type PeerInfo struct {
 	Name      string `json:"name"`
 	PublicKey []byte `json:"public_key,omitempty"`
 	Address   string `json:"address,omitempty"`
+	Token     string `json:"token,omitempty"` // API token the peer issued us, if it has user accounts
 }
 
 // Config represents the Airgapper configuration
 type Config struct {
+	// Schema version; see CurrentVersion
+	Version int `json:"version,omitempty"`
+
 	// Identity
 	Name       string `json:"name"`
 	Role       Role   `json:"role"`
@@ -65,26 +100,167 @@ type Config struct {
 	// Consensus configuration (new m-of-n mode)
 	Consensus *ConsensusConfig `json:"consensus,omitempty"`
 
+	// Further ways to recover the repository password for a restore,
+	// besides the mode's shares or consensus: a KMS-escrowed copy, or the
+	// owner's share sealed with a passphrase instead of kept in LocalShare
+	KeyProtection []keyprotect.Protection `json:"key_protection,omitempty"`
+
 	// Peer info (legacy - for 2-of-2 SSS mode)
 	Peer *PeerInfo `json:"peer,omitempty"`
 
+	// Result of the last check that our share and the peer's still combine
+	// to the repository password (SSS mode)
+	ShareCheck *verification.ShareCheckState `json:"share_check,omitempty"`
+
 	// API settings
-	ListenAddr string `json:"listen_addr,omitempty"`
+	ListenAddr         string   `json:"listen_addr,omitempty"`
+	CORSAllowedOrigins []string `json:"cors_allowed_origins,omitempty"`
+	TLSCertFile        string   `json:"tls_cert_file,omitempty"`
+	TLSKeyFile         string   `json:"tls_key_file,omitempty"`
+
+	// How long shutdown waits for a scheduled backup and in-flight requests
+	// such as storage uploads to finish, as a duration such as "10m"
+	// (default: server.ShutdownTimeout)
+	DrainTimeout string `json:"drain_timeout,omitempty"`
+
+	// Base URL key holders reach this node's web UI at, e.g.
+	// https://bob-nas.local:8081. When set, restore request notifications
+	// carry links to approve or deny the request there.
+	ActionLinkURL string `json:"action_link_url,omitempty"`
 
 	// Backup settings (owner only)
 	BackupPaths    []string `json:"backup_paths,omitempty"`
 	BackupSchedule string   `json:"backup_schedule,omitempty"`
 	BackupExclude  []string `json:"backup_exclude,omitempty"`
 
+	// IANA timezone the schedule's times are in, e.g. Europe/Berlin, so
+	// backups keep their hour when the machine moves; empty for the
+	// system's timezone (owner only)
+	BackupTimezone string `json:"backup_timezone,omitempty"`
+
+	// Compression mode backups use: auto, max or off, as restic's
+	// --compression; empty leaves restic's default (owner only)
+	BackupCompression string `json:"backup_compression,omitempty"`
+
+	// Extra environment variables (a proxy, a cache directory, S3
+	// credentials) and global flags (--cacert, --insecure-tls, --pack-size)
+	// every restic invocation runs with (owner only)
+	Restic *restic.Options `json:"restic,omitempty"`
+
+	// Storage the host gave this vault, in bytes, for the dedup stats to
+	// project when it fills up; nodes hosting the storage use its quota
+	// instead (owner only)
+	HostQuotaBytes int64 `json:"host_quota_bytes,omitempty"`
+
+	// Tags added to every backup snapshot, alongside "airgapper" and the
+	// host and OS tags (owner only)
+	BackupTags []string `json:"backup_tags,omitempty"`
+
+	// Diff each new snapshot against the previous one in backup reports (owner only)
+	BackupReportDiff bool `json:"backup_report_diff,omitempty"`
+
+	// Back up the readable paths when others are missing or unreadable,
+	// recording the backup as partial instead of failing it (owner only)
+	BackupContinueOnError bool `json:"backup_continue_on_error,omitempty"`
+
+	// Back up an encrypted export of this node's state (config, requests,
+	// audit logs) with each backup, for 'airgapper self-restore' (owner only)
+	BackupState bool `json:"backup_state,omitempty"`
+
+	// Databases dumped before each backup and backed up with its paths (owner only)
+	BackupSources []sources.Source `json:"backup_sources,omitempty"`
+
+	// Seal the paths of restore requests sent to peers, so only this node
+	// can read them (owner only)
+	PrivateRequestPaths bool `json:"private_request_paths,omitempty"`
+
+	// Docker/Podman volumes backed up with each scheduled backup (owner only)
+	DockerVolumes *volumes.Config `json:"docker_volumes,omitempty"`
+
+	// Catch-up, jitter and clock jump handling for scheduled backups (owner only)
+	BackupTiming *scheduler.TimingConfig `json:"backup_timing,omitempty"`
+
+	// Retry, backoff and failure threshold for scheduled backups (owner only)
+	BackupRetry *scheduler.RetryConfig `json:"backup_retry,omitempty"`
+
+	// Snapshots kept after each scheduled backup (owner only)
+	BackupRetention *scheduler.RetentionConfig `json:"backup_retention,omitempty"`
+
+	// Keep the backup schedule, and how long and how much backups upload,
+	// from the host, which otherwise sees them to plan its bandwidth (owner only)
+	PrivateBackupWindow bool `json:"private_backup_window,omitempty"`
+
+	// Uptime monitor pinged after each backup run, e.g. a healthchecks.io
+	// check or an Uptime Kuma push monitor (owner only)
+	BackupPingURL string `json:"backup_ping_url,omitempty"`
+
+	// Scheduled restore tests (owner only)
+	RestoreTest *integrity.RestoreTestConfig `json:"restore_test,omitempty"`
+
+	// Secondary repositories snapshots are copied to after each backup (owner only)
+	Replication []replication.Target `json:"replication,omitempty"`
+
 	// Filesystem browsing security
 	AllowedBrowseRoots []string `json:"allowed_browse_roots,omitempty"`
 
+	// How far peers' clocks may differ when checking request expiry and
+	// signed timestamps, as a duration such as "2m" (default:
+	// consent.DefaultClockSkewTolerance)
+	ClockSkewTolerance string `json:"clock_skew_tolerance,omitempty"`
+
+	// The longest TTL, and extension, a restore request may ask for, as a
+	// duration such as "72h" (default: consent.DefaultMaxRequestTTL)
+	MaxRequestTTL string `json:"max_request_ttl,omitempty"`
+
+	// How long an approved restore waits before it can be carried out, as
+	// a duration such as "6h". Any key holder can veto it meanwhile.
+	// Empty for none.
+	RestoreCoolingOff string `json:"restore_cooling_off,omitempty"`
+
+	// Rules that deny or approve incoming restore requests before they wait
+	// for manual approval
+	ApprovalRules *consent.ApprovalRules `json:"approval_rules,omitempty"`
+
+	// Recovery contacts who, after a long waiting period, can stand in for
+	// a key holder who lost their key (consensus mode; set at init)
+	SocialRecovery *consent.RecoveryPolicy `json:"social_recovery,omitempty"`
+
 	// Storage server settings (host only)
 	StoragePath       string `json:"storage_path,omitempty"`
 	StorageQuotaBytes int64  `json:"storage_quota_bytes,omitempty"`
 	StorageAppendOnly bool   `json:"storage_append_only,omitempty"`
 	StoragePort       int    `json:"storage_port,omitempty"`
 
+	// Gzip index, snapshot, lock and listing responses for clients that
+	// accept it, to speed up slow uplinks (host only)
+	StorageCompression bool `json:"storage_compression,omitempty"`
+
+	// Hash each blob as it is served, reporting corruption as soon as a
+	// restore reads it rather than at the next scrub (host only)
+	StorageVerifyReads bool `json:"storage_verify_reads,omitempty"`
+
+	// Set the filesystem's immutable attribute on complete data and
+	// snapshot files, lifted only by an approved deletion's prune (host
+	// only; needs root or CAP_LINUX_IMMUTABLE on Linux)
+	StorageImmutable bool `json:"storage_immutable,omitempty"`
+
+	// Refuse writes to the storage not signed by the owner's key, checked
+	// against the policy's owner key or, until there is a policy, the
+	// peer's (host only; the owner needs restic 0.17 or later)
+	StorageRequireIdentity bool `json:"storage_require_identity,omitempty"`
+
+	// Serve only the repositories provisioned with 'airgapper storage repos
+	// add' or POST /api/v1/storage/repos (host only)
+	StorageRequireProvisioning bool `json:"storage_require_provisioning,omitempty"`
+
+	// ZFS or Btrfs snapshots of the storage dataset, taken after each
+	// backup window and before an approved deletion's prune (host only)
+	StorageFSSnapshots *fssnap.Config `json:"storage_fs_snapshots,omitempty"`
+
+	// Syslog collector each audit record and activity feed event is
+	// forwarded to, e.g. tls://siem.example.com:6514
+	AuditSyslog string `json:"audit_syslog,omitempty"`
+
 	// Emergency recovery settings (uses emergency package types)
 	Emergency *emergency.Config `json:"emergency,omitempty"`
 
@@ -93,6 +269,10 @@ type Config struct {
 
 	// Paths (not serialized)
 	ConfigDir string `json:"-"`
+
+	// Settings overridden from the environment or flags, which Save leaves
+	// out of the file
+	overrides map[string]override
 }
 
 // DefaultConfigDir returns the default config directory
@@ -101,7 +281,9 @@ func DefaultConfigDir() string {
 	return filepath.Join(home, ".airgapper")
 }
 
-// Load loads configuration from the config directory
+// Load loads configuration from the config directory. A config from an
+// older version is migrated and saved, keeping the original alongside as
+// config.json.v<version>.bak. Unknown fields are an error.
 func Load(configDir string) (*Config, error) {
 	if configDir == "" {
 		configDir = DefaultConfigDir()
@@ -116,12 +298,39 @@ func Load(configDir string) (*Config, error) {
 		return nil, err
 	}
 
+	var head struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return nil, describeDecodeError(data, err)
+	}
+	version := max(head.Version, 1)
+	if version > CurrentVersion {
+		return nil, fmt.Errorf("config.json is version %d, but this airgapper only understands up to version %d; upgrade airgapper", version, CurrentVersion)
+	}
+	original := data
+	if version < CurrentVersion {
+		if data, err = migrate(data, version); err != nil {
+			return nil, err
+		}
+	}
+
 	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
+	if err := decodeStrict(data, original, &cfg); err != nil {
 		return nil, err
 	}
-
 	cfg.ConfigDir = configDir
+
+	if version < CurrentVersion {
+		backup, err := backupBeforeMigration(configDir, version, original)
+		if err != nil {
+			return nil, fmt.Errorf("backing up config.json before migrating it: %w", err)
+		}
+		if err := cfg.Save(); err != nil {
+			return nil, fmt.Errorf("saving migrated config.json: %w", err)
+		}
+		logging.Info("Config upgraded", logging.Int("version", CurrentVersion), logging.String("backup", backup))
+	}
 	return &cfg, nil
 }
 
@@ -145,7 +354,8 @@ func (c *Config) Save() error {
 		return err
 	}
 
-	data, err := json.MarshalIndent(c, "", "  ")
+	c.Version = CurrentVersion
+	data, err := json.MarshalIndent(c.withoutOverrides(), "", "  ")
 	if err != nil {
 		return err
 	}
@@ -159,6 +369,11 @@ func (c *Config) Save() error {
 func (c *Config) IsOwner() bool { return c.Role == RoleOwner }
 func (c *Config) IsHost() bool  { return c.Role == RoleHost }
 
+// --- API methods ---
+
+// TLSEnabled returns true if both a TLS certificate and key are configured
+func (c *Config) TLSEnabled() bool { return c.TLSCertFile != "" && c.TLSKeyFile != "" }
+
 // --- Share methods ---
 
 func (c *Config) SharePath() string {
@@ -178,6 +393,110 @@ func (c *Config) LoadShare() ([]byte, byte, error) {
 	return c.LocalShare, c.ShareIndex, nil
 }
 
+// Protections lists the ways this node can recover the repository
+// password, the mode's own first
+func (c *Config) Protections() []keyprotect.Protection {
+	var protections []keyprotect.Protection
+	switch {
+	case c.UsesConsensusMode():
+		protections = append(protections, keyprotect.Protection{Type: keyprotect.TypeConsensus})
+	case c.UsesSSSMode():
+		protections = append(protections, keyprotect.Protection{Type: keyprotect.TypeShamir, ShareIndex: c.ShareIndex})
+	}
+	return append(protections, c.KeyProtection...)
+}
+
+// HasProtection reports whether the password can be recovered with t
+func (c *Config) HasProtection(t keyprotect.Type) bool {
+	return slices.ContainsFunc(c.Protections(), func(p keyprotect.Protection) bool { return p.Type == t })
+}
+
+// ValidateKeyProtection checks the configured key protections. Shamir and
+// consensus come from the mode, and a passphrase-sealed share is pointless
+// while the plain share is kept beside it.
+func (c *Config) ValidateKeyProtection() error {
+	for _, p := range c.KeyProtection {
+		switch {
+		case p.Type == keyprotect.TypeShamir || p.Type == keyprotect.TypeConsensus:
+			return fmt.Errorf("key protection %s comes from the vault's mode and can't be added", p.Type)
+		case p.Type == keyprotect.TypePassphrase && (c.UsesConsensusMode() || c.LocalShare != nil):
+			return fmt.Errorf("passphrase key protection needs SSS mode without a plain local share")
+		}
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("key protection %s: %w", p.Type, err)
+		}
+	}
+	return nil
+}
+
+// RecoverPassword reconstructs the repository password for an approved
+// request with the first protection that can (or only those of type only),
+// given what in brings from the request and the user. It returns the type
+// of protection used.
+func (c *Config) RecoverPassword(ctx context.Context, only keyprotect.Type, in keyprotect.Unlock) ([]byte, keyprotect.Type, error) {
+	if c.LocalShare != nil {
+		in.LocalShare = &sss.Share{Index: c.ShareIndex, Data: c.LocalShare}
+	}
+	if c.Password != "" {
+		in.Password = []byte(c.Password)
+	}
+	return keyprotect.Recover(ctx, c.Protections(), only, in)
+}
+
+// PinsPath is where snapshot pins known to this node are recorded
+func (c *Config) PinsPath() string {
+	return filepath.Join(c.ConfigDir, "pins.json")
+}
+
+// BackupStatePath is where the outcome of the last backup run is recorded
+func (c *Config) BackupStatePath() string {
+	return filepath.Join(c.ConfigDir, "backup-state.json")
+}
+
+// BackupReportsPath is where the reports of recent backup runs are kept
+func (c *Config) BackupReportsPath() string {
+	return filepath.Join(c.ConfigDir, "backup-reports.json")
+}
+
+// DedupStatsPath is where the raw and stored size of each backup run is
+// kept for the deduplication stats
+func (c *Config) DedupStatsPath() string {
+	return filepath.Join(c.ConfigDir, "dedup-stats.json")
+}
+
+// RepairsPath is where corrupt files the host reported are queued for repair
+func (c *Config) RepairsPath() string {
+	return filepath.Join(c.ConfigDir, "repairs.json")
+}
+
+// PeerQueuePath is where calls for the peer wait until it can be reached
+func (c *Config) PeerQueuePath() string {
+	return filepath.Join(c.ConfigDir, "peer-queue.json")
+}
+
+// SyncStatePath is where what was imported from the peer's sync bundles is
+// recorded
+func (c *Config) SyncStatePath() string {
+	return filepath.Join(c.ConfigDir, "sync-state.json")
+}
+
+// RestoreJobsPath is where restore jobs are queued for the daemon and their
+// progress recorded
+func (c *Config) RestoreJobsPath() string {
+	return filepath.Join(c.ConfigDir, "restore-jobs.json")
+}
+
+// CatalogPath is where the encrypted catalog of this owner's snapshots is kept
+func (c *Config) CatalogPath() string {
+	return filepath.Join(c.ConfigDir, "snapshot-catalog.enc")
+}
+
+// SourceDumpDir is where database sources are dumped before a backup.
+// The path is stable so each source's dump keeps the same path in restic.
+func (c *Config) SourceDumpDir() string {
+	return filepath.Join(c.ConfigDir, "dumps")
+}
+
 // --- Schedule methods ---
 
 func (c *Config) SetSchedule(schedule string, paths []string) error {
@@ -188,6 +507,213 @@ func (c *Config) SetSchedule(schedule string, paths []string) error {
 	return c.Save()
 }
 
+// Schedule returns the backup schedule, in its timezone
+func (c *Config) Schedule() (*scheduler.Schedule, error) {
+	return scheduler.ParseScheduleIn(c.BackupSchedule, c.BackupTimezone)
+}
+
+// ScheduleTimezone names the timezone the schedule's times are in, "Local"
+// for the system's
+func (c *Config) ScheduleTimezone() string {
+	if c.BackupTimezone == "" {
+		return time.Local.String()
+	}
+	return c.BackupTimezone
+}
+
+// ScheduleTiming returns the configured scheduler timing, or the default
+// (catch up missed backups, no jitter) when none is configured
+func (c *Config) ScheduleTiming() (scheduler.Timing, error) {
+	if c.BackupTiming == nil {
+		return scheduler.DefaultTiming(), nil
+	}
+	return c.BackupTiming.Parse()
+}
+
+// ScheduleRetry returns the configured retry strategy and failure
+// threshold (no retries, DefaultFailureThreshold when none is configured)
+func (c *Config) ScheduleRetry() (*scheduler.RetryStrategy, int, error) {
+	retry, err := c.BackupRetry.Parse()
+	if err != nil {
+		return nil, 0, err
+	}
+	return retry, c.BackupRetry.Threshold(), nil
+}
+
+// SkewTolerance returns the configured clock skew tolerance, or
+// consent.DefaultClockSkewTolerance when none is configured
+func (c *Config) SkewTolerance() (time.Duration, error) {
+	if c.ClockSkewTolerance == "" {
+		return consent.DefaultClockSkewTolerance, nil
+	}
+	d, err := time.ParseDuration(c.ClockSkewTolerance)
+	if err != nil {
+		return 0, fmt.Errorf("invalid clock_skew_tolerance: %w", err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("clock_skew_tolerance cannot be negative")
+	}
+	return d, nil
+}
+
+// ShutdownDrain returns how long shutdown waits for work in progress, or
+// server.ShutdownTimeout when no drain timeout is configured
+func (c *Config) ShutdownDrain() (time.Duration, error) {
+	if c.DrainTimeout == "" {
+		return server.ShutdownTimeout, nil
+	}
+	d, err := time.ParseDuration(c.DrainTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid drain_timeout: %w", err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("drain_timeout must be positive")
+	}
+	return d, nil
+}
+
+// RequestTTLLimit returns the configured cap on restore request TTLs and
+// extensions, or consent.DefaultMaxRequestTTL when none is configured
+func (c *Config) RequestTTLLimit() (time.Duration, error) {
+	if c.MaxRequestTTL == "" {
+		return consent.DefaultMaxRequestTTL, nil
+	}
+	d, err := time.ParseDuration(c.MaxRequestTTL)
+	if err != nil {
+		return 0, fmt.Errorf("invalid max_request_ttl: %w", err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("max_request_ttl must be positive")
+	}
+	return d, nil
+}
+
+// invalidCoolingOff is the cooling-off period used when the configured one
+// is invalid, so a typo doesn't turn the safeguard off
+const invalidCoolingOff = 24 * time.Hour
+
+// CoolingOff returns the configured cooling-off period for approved
+// restores, or zero when none is configured
+func (c *Config) CoolingOff() (time.Duration, error) {
+	if c.RestoreCoolingOff == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(c.RestoreCoolingOff)
+	if err != nil {
+		return 0, fmt.Errorf("invalid restore_cooling_off: %w", err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("restore_cooling_off cannot be negative")
+	}
+	return d, nil
+}
+
+// ConsentManager returns the manager for this node's requests, allowing for
+// the configured clock skew and holding approved restores for the
+// configured cooling-off period. An invalid tolerance falls back to the
+// default, and an invalid cooling-off period to a day; doctor reports both.
+func (c *Config) ConsentManager() *consent.Manager {
+	tolerance, err := c.SkewTolerance()
+	if err != nil {
+		tolerance = consent.DefaultClockSkewTolerance
+	}
+	coolingOff, err := c.CoolingOff()
+	if err != nil {
+		coolingOff = invalidCoolingOff
+	}
+	return consent.NewManager(c.ConfigDir).WithClockSkewTolerance(tolerance).WithCoolingOff(coolingOff)
+}
+
+// --- Lockdown methods ---
+
+// LockdownSigners are the keys that can lift a lockdown: in consensus mode
+// the key holders, a threshold of whom must sign; otherwise this node's
+// key and the peer's, both of which must sign
+func (c *Config) LockdownSigners() lockdown.Signers {
+	signers := lockdown.Signers{Keys: make(map[string][]byte)}
+	if c.Consensus != nil {
+		for _, kh := range c.Consensus.KeyHolders {
+			if kh.PublicKey != nil {
+				signers.Keys[kh.ID] = kh.PublicKey
+			}
+		}
+		signers.Threshold = min(c.Consensus.Threshold, len(signers.Keys))
+		return signers
+	}
+	keys := [][]byte{c.PublicKey}
+	if c.Peer != nil {
+		keys = append(keys, c.Peer.PublicKey)
+	}
+	for _, pub := range keys {
+		if pub != nil {
+			signers.Keys[crypto.KeyID(pub)] = pub
+		}
+	}
+	signers.Threshold = len(signers.Keys)
+	return signers
+}
+
+// LockdownPeers are the addresses of the other nodes a lockdown is
+// propagated to: the peer and every key holder with an address
+func (c *Config) LockdownPeers() []string {
+	var addrs []string
+	add := func(addr string) {
+		if addr != "" && !slices.Contains(addrs, addr) {
+			addrs = append(addrs, addr)
+		}
+	}
+	if c.Peer != nil {
+		add(c.Peer.Address)
+	}
+	if c.Consensus != nil {
+		for _, kh := range c.Consensus.KeyHolders {
+			if c.PublicKey == nil || !bytes.Equal(kh.PublicKey, c.PublicKey) {
+				add(kh.Address)
+			}
+		}
+	}
+	return addrs
+}
+
+// --- Replication methods ---
+
+// ReplicationTarget returns the replication target with the given name
+func (c *Config) ReplicationTarget(name string) *replication.Target {
+	for i := range c.Replication {
+		if c.Replication[i].Name == name {
+			return &c.Replication[i]
+		}
+	}
+	return nil
+}
+
+// HasReplication returns true if any replication target is enabled
+func (c *Config) HasReplication() bool {
+	for _, t := range c.Replication {
+		if t.Enabled {
+			return true
+		}
+	}
+	return false
+}
+
+// --- Backup source methods ---
+
+// BackupSource returns the backup source with the given name
+func (c *Config) BackupSource(name string) *sources.Source {
+	for i := range c.BackupSources {
+		if c.BackupSources[i].Name == name {
+			return &c.BackupSources[i]
+		}
+	}
+	return nil
+}
+
+// VolumesEnabled returns true if Docker volume backups are enabled
+func (c *Config) VolumesEnabled() bool {
+	return c.DockerVolumes != nil && c.DockerVolumes.Enabled
+}
+
 // --- Mode detection ---
 
 func (c *Config) UsesSSSMode() bool       { return c.Consensus == nil && c.LocalShare != nil }
@@ -210,6 +736,18 @@ func (c *Config) AddKeyHolder(holder KeyHolder) error {
 	return c.Save()
 }
 
+// KnownKey reports whether keyID is this node's key, the peer's or a
+// registered key holder's
+func (c *Config) KnownKey(keyID string) bool {
+	if c.GetKeyHolder(keyID) != nil {
+		return true
+	}
+	if c.PublicKey != nil && crypto.KeyID(c.PublicKey) == keyID {
+		return true
+	}
+	return c.Peer != nil && c.Peer.PublicKey != nil && crypto.KeyID(c.Peer.PublicKey) == keyID
+}
+
 func (c *Config) GetKeyHolder(id string) *KeyHolder {
 	if c.Consensus == nil {
 		return nil
@@ -235,9 +773,54 @@ func (c *Config) RequiredApprovals() int {
 	if c.Consensus == nil {
 		return 2 // Legacy SSS mode
 	}
+	if q, err := c.Quorum(); err == nil && q != nil {
+		return q.MinWeight
+	}
 	return c.Consensus.Threshold
 }
 
+// Quorum resolves the configured quorum rule against the registered key
+// holders. It returns nil when approvals are a flat Threshold count.
+func (c *Config) Quorum() (*consent.Quorum, error) {
+	if c.Consensus == nil || c.Consensus.Quorum == nil {
+		return nil, nil
+	}
+	qc := c.Consensus.Quorum
+
+	q := &consent.Quorum{
+		Required:  append([]string(nil), qc.Required...),
+		Weights:   qc.Weights,
+		MinWeight: qc.MinWeight,
+	}
+	if q.MinWeight == 0 {
+		q.MinWeight = c.Consensus.Threshold
+	}
+
+	ids := make([]string, len(c.Consensus.KeyHolders))
+	for i, kh := range c.Consensus.KeyHolders {
+		ids[i] = kh.ID
+	}
+	if qc.RequireOwner {
+		owner := ""
+		for _, kh := range c.Consensus.KeyHolders {
+			if kh.IsOwner {
+				owner = kh.ID
+			}
+		}
+		if owner == "" {
+			return nil, errors.New("quorum requires the owner but no owner key is registered")
+		}
+		if !slices.Contains(q.Required, owner) {
+			q.Required = append([]string{owner}, q.Required...)
+		}
+	}
+
+	if err := q.Validate(ids); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
 // HasEmergencyConfig returns true if any emergency features are configured
 func (c *Config) HasEmergencyConfig() bool {
 	return c.Emergency != nil
@@ -2,14 +2,21 @@
 package config
 
 import (
+	"context"
 	"encoding/json"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
 	"github.com/lcrostarosa/airgapper/backend/internal/emergency"
 	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/keyprotect"
+	"github.com/lcrostarosa/airgapper/backend/internal/server"
+	"github.com/lcrostarosa/airgapper/backend/internal/sss"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -367,6 +374,51 @@ func TestLoadShare(t *testing.T) {
 	})
 }
 
+func TestRecoverPassword(t *testing.T) {
+	shares, err := sss.Split([]byte("repo-password"), 2, 2)
+	require.NoError(t, err)
+	sealed, err := keyprotect.NewPassphrase("correct horse battery", shares[0])
+	require.NoError(t, err)
+
+	t.Run("combines the local share with the peer's", func(t *testing.T) {
+		cfg := &Config{LocalShare: shares[0].Data, ShareIndex: shares[0].Index}
+		password, used, err := cfg.RecoverPassword(context.Background(), "", keyprotect.Unlock{PeerShare: shares[1].Data})
+		require.NoError(t, err)
+		assert.Equal(t, "repo-password", string(password))
+		assert.Equal(t, keyprotect.TypeShamir, used)
+	})
+
+	t.Run("uses the held password in consensus mode", func(t *testing.T) {
+		cfg := &Config{Password: "held", Consensus: &ConsensusConfig{Threshold: 1, TotalKeys: 1}}
+		password, used, err := cfg.RecoverPassword(context.Background(), "", keyprotect.Unlock{})
+		require.NoError(t, err)
+		assert.Equal(t, "held", string(password))
+		assert.Equal(t, keyprotect.TypeConsensus, used)
+	})
+
+	t.Run("opens a passphrase-sealed share", func(t *testing.T) {
+		cfg := &Config{ShareIndex: shares[0].Index, KeyProtection: []keyprotect.Protection{sealed}}
+		require.NoError(t, cfg.ValidateKeyProtection())
+		assert.False(t, cfg.UsesSSSMode())
+		assert.True(t, cfg.HasProtection(keyprotect.TypePassphrase))
+
+		_, _, err := cfg.RecoverPassword(context.Background(), "", keyprotect.Unlock{PeerShare: shares[1].Data})
+		assert.ErrorContains(t, err, "passphrase is needed")
+		password, used, err := cfg.RecoverPassword(context.Background(), "",
+			keyprotect.Unlock{PeerShare: shares[1].Data, Passphrase: "correct horse battery"})
+		require.NoError(t, err)
+		assert.Equal(t, "repo-password", string(password))
+		assert.Equal(t, keyprotect.TypePassphrase, used)
+	})
+
+	t.Run("rejects protections that clash with the mode", func(t *testing.T) {
+		cfg := &Config{LocalShare: shares[0].Data, ShareIndex: shares[0].Index, KeyProtection: []keyprotect.Protection{sealed}}
+		assert.Error(t, cfg.ValidateKeyProtection(), "plain share beside the sealed one")
+		cfg = &Config{KeyProtection: []keyprotect.Protection{{Type: keyprotect.TypeShamir}}}
+		assert.Error(t, cfg.ValidateKeyProtection())
+	})
+}
+
 // --- Schedule method tests ---
 
 func TestSetSchedule(t *testing.T) {
@@ -610,6 +662,149 @@ func TestRequiredApprovals(t *testing.T) {
 	})
 }
 
+func TestSkewTolerance(t *testing.T) {
+	cfg := &Config{ConfigDir: t.TempDir()}
+	d, err := cfg.SkewTolerance()
+	require.NoError(t, err)
+	assert.Equal(t, consent.DefaultClockSkewTolerance, d)
+
+	cfg.ClockSkewTolerance = "90s"
+	d, err = cfg.SkewTolerance()
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Second, d)
+	assert.Equal(t, 90*time.Second, cfg.ConsentManager().ClockSkewTolerance())
+
+	for _, bad := range []string{"soon", "-1m"} {
+		cfg.ClockSkewTolerance = bad
+		_, err = cfg.SkewTolerance()
+		assert.Error(t, err, bad)
+		assert.Equal(t, consent.DefaultClockSkewTolerance, cfg.ConsentManager().ClockSkewTolerance())
+	}
+}
+
+func TestShutdownDrain(t *testing.T) {
+	cfg := &Config{}
+	d, err := cfg.ShutdownDrain()
+	require.NoError(t, err)
+	assert.Equal(t, server.ShutdownTimeout, d)
+
+	cfg.DrainTimeout = "10m"
+	d, err = cfg.ShutdownDrain()
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, d)
+
+	for _, bad := range []string{"later", "0s", "-1m"} {
+		cfg.DrainTimeout = bad
+		_, err = cfg.ShutdownDrain()
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestRequestTTLLimit(t *testing.T) {
+	cfg := &Config{}
+	d, err := cfg.RequestTTLLimit()
+	require.NoError(t, err)
+	assert.Equal(t, consent.DefaultMaxRequestTTL, d)
+
+	cfg.MaxRequestTTL = "72h"
+	d, err = cfg.RequestTTLLimit()
+	require.NoError(t, err)
+	assert.Equal(t, 72*time.Hour, d)
+
+	for _, bad := range []string{"a week", "0s", "-1h"} {
+		cfg.MaxRequestTTL = bad
+		_, err = cfg.RequestTTLLimit()
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestCoolingOff(t *testing.T) {
+	cfg := &Config{ConfigDir: t.TempDir()}
+	d, err := cfg.CoolingOff()
+	require.NoError(t, err)
+	assert.Zero(t, d)
+	assert.Zero(t, cfg.ConsentManager().CoolingOff())
+
+	cfg.RestoreCoolingOff = "6h"
+	d, err = cfg.CoolingOff()
+	require.NoError(t, err)
+	assert.Equal(t, 6*time.Hour, d)
+	assert.Equal(t, 6*time.Hour, cfg.ConsentManager().CoolingOff())
+
+	// An invalid period doesn't turn the safeguard off
+	for _, bad := range []string{"six hours", "-1h"} {
+		cfg.RestoreCoolingOff = bad
+		_, err = cfg.CoolingOff()
+		assert.Error(t, err, bad)
+		assert.Equal(t, invalidCoolingOff, cfg.ConsentManager().CoolingOff())
+	}
+}
+
+func TestLockdownSigners(t *testing.T) {
+	alice, bob := []byte("alice-public-key-32-bytes-long!!"), []byte("bob-public-key-32-bytes-long!!!!")
+
+	cfg := &Config{Name: "alice", PublicKey: alice, Peer: &PeerInfo{Name: "bob", PublicKey: bob, Address: "http://bob:8081"}}
+	signers := cfg.LockdownSigners()
+	assert.Len(t, signers.Keys, 2)
+	assert.Equal(t, 2, signers.Threshold, "both nodes must sign in SSS mode")
+	assert.Equal(t, []string{"http://bob:8081"}, cfg.LockdownPeers())
+
+	assert.Empty(t, (&Config{Name: "alice"}).LockdownSigners().Keys)
+
+	cfg.Consensus = &ConsensusConfig{Threshold: 2, KeyHolders: []KeyHolder{
+		{ID: "aaaa", PublicKey: alice, Address: "http://alice:8081"},
+		{ID: "bbbb", PublicKey: bob, Address: "http://bob:8081"},
+		{ID: "cccc", PublicKey: []byte("carol"), Address: "http://carol:8081"},
+	}}
+	signers = cfg.LockdownSigners()
+	assert.Equal(t, []string{"aaaa", "bbbb", "cccc"}, slices.Sorted(maps.Keys(signers.Keys)))
+	assert.Equal(t, 2, signers.Threshold)
+	assert.Equal(t, []string{"http://bob:8081", "http://carol:8081"}, cfg.LockdownPeers())
+}
+
+func TestQuorum(t *testing.T) {
+	consensus := func(q *QuorumConfig) *Config {
+		return &Config{Consensus: &ConsensusConfig{
+			Threshold: 2,
+			TotalKeys: 3,
+			KeyHolders: []KeyHolder{
+				{ID: "owner", IsOwner: true},
+				{ID: "host1"},
+				{ID: "host2"},
+			},
+			Quorum: q,
+		}}
+	}
+
+	t.Run("nil without a quorum rule", func(t *testing.T) {
+		q, err := consensus(nil).Quorum()
+		require.NoError(t, err)
+		assert.Nil(t, q)
+	})
+
+	t.Run("owner plus any one host", func(t *testing.T) {
+		cfg := consensus(&QuorumConfig{RequireOwner: true})
+		q, err := cfg.Quorum()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"owner"}, q.Required)
+		assert.Equal(t, 2, q.MinWeight, "defaults to the threshold")
+		assert.Equal(t, 2, cfg.RequiredApprovals())
+	})
+
+	t.Run("rejects rules that can't be met", func(t *testing.T) {
+		_, err := consensus(&QuorumConfig{MinWeight: 4}).Quorum()
+		assert.Error(t, err)
+
+		_, err = consensus(&QuorumConfig{Required: []string{"stranger"}}).Quorum()
+		assert.Error(t, err)
+
+		cfg := consensus(&QuorumConfig{RequireOwner: true})
+		cfg.Consensus.KeyHolders[0].IsOwner = false
+		_, err = cfg.Quorum()
+		assert.Error(t, err)
+	})
+}
+
 // --- Emergency config tests ---
 
 func TestHasEmergencyConfig(t *testing.T) {
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+// CurrentVersion is the config schema version this build writes. Configs
+// from before versioning have no version field and count as version 1.
+const CurrentVersion = 2
+
+// migration upgrades a config from one schema version to the next. It works
+// on the raw JSON object, so it can read fields the Config struct no longer
+// has.
+type migration struct {
+	from        int
+	description string
+	apply       func(fields map[string]json.RawMessage) error
+}
+
+// migrations upgrade each older version in turn; migrations[i] takes
+// version i+1 to i+2
+var migrations = []migration{
+	{
+		from:        1,
+		description: "add the schema version",
+		apply:       func(map[string]json.RawMessage) error { return nil },
+	},
+}
+
+// migrate upgrades config data of the given version to CurrentVersion,
+// returning the upgraded data
+func migrate(data []byte, version int) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		return nil, errors.New("config.json must hold a JSON object")
+	}
+	for _, m := range migrations[version-1:] {
+		if err := m.apply(fields); err != nil {
+			return nil, fmt.Errorf("migrating config from version %d (%s): %w", m.from, m.description, err)
+		}
+		logging.Info("Migrated config",
+			logging.Int("from", m.from),
+			logging.Int("to", m.from+1),
+			logging.String("change", m.description))
+	}
+	current, err := json.Marshal(CurrentVersion)
+	if err != nil {
+		return nil, err
+	}
+	fields["version"] = current
+	return json.Marshal(fields)
+}
+
+// backupPath is where the config is copied before a migration from version
+func backupPath(configDir string, version int) string {
+	return filepath.Join(configDir, fmt.Sprintf("config.json.v%d.bak", version))
+}
+
+// backupBeforeMigration keeps a copy of the config as it was before being
+// migrated from version. An earlier backup of the same version is kept, as
+// it is the file the user last wrote by hand.
+func backupBeforeMigration(configDir string, version int, data []byte) (string, error) {
+	path := backupPath(configDir, version)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, os.ErrExist) {
+		return path, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeRawConfig(t *testing.T, dir, data string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.json"), []byte(data), 0600))
+}
+
+func TestMigrations(t *testing.T) {
+	require.Len(t, migrations, CurrentVersion-1, "every older version needs a migration")
+	for i, m := range migrations {
+		assert.Equal(t, i+1, m.from)
+		assert.NotEmpty(t, m.description)
+	}
+}
+
+func TestLoadMigratesOldConfig(t *testing.T) {
+	dir := createTempConfigDir(t)
+	original := `{"name": "old-node", "role": "owner", "repo_url": "rest:http://host:8000/"}`
+	writeRawConfig(t, dir, original)
+
+	cfg, err := Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "old-node", cfg.Name)
+	assert.Equal(t, CurrentVersion, cfg.Version)
+
+	backup, err := os.ReadFile(backupPath(dir, 1))
+	require.NoError(t, err)
+	assert.Equal(t, original, string(backup), "the original is kept")
+
+	var saved map[string]any
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &saved))
+	assert.EqualValues(t, CurrentVersion, saved["version"], "the migrated config is saved")
+
+	t.Run("an earlier backup is kept", func(t *testing.T) {
+		writeRawConfig(t, dir, `{"name": "edited-by-hand"}`)
+		_, err := Load(dir)
+		require.NoError(t, err)
+		backup, err := os.ReadFile(backupPath(dir, 1))
+		require.NoError(t, err)
+		assert.Equal(t, original, string(backup))
+	})
+}
+
+func TestLoadCurrentConfigIsNotRewritten(t *testing.T) {
+	dir := createTempConfigDir(t)
+	writeConfigFile(t, dir, &Config{Version: CurrentVersion, Name: "current"})
+	before, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	require.NoError(t, err)
+
+	_, err = Load(dir)
+	require.NoError(t, err)
+	after, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+	assert.NoFileExists(t, backupPath(dir, CurrentVersion))
+}
+
+func TestLoadErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		data string
+		want string
+	}{
+		"newer version": {
+			data: `{"version": 99, "name": "x"}`,
+			want: "config.json is version 99, but this airgapper only understands up to version 2; upgrade airgapper",
+		},
+		"misspelled field": {
+			data: "{\n  \"version\": 2,\n  \"name\": \"x\",\n  \"backup_pahts\": [\"/home\"]\n}",
+			want: `config.json line 4, column 3: unknown field "backup_pahts" (did you mean "backup_paths"?)`,
+		},
+		"misspelled nested field": {
+			data: `{"version": 2, "backup_retry": {"max_attempt": 3}}`,
+			want: `unknown field "max_attempt" (did you mean "max_attempts"?)`,
+		},
+		"unknown field in an old config": {
+			data: "{\n  \"name\": \"x\",\n  \"colour\": \"blue\"\n}",
+			want: `config.json line 3, column 3: unknown field "colour"`,
+		},
+		"wrong type": {
+			data: "{\n  \"version\": 2,\n  \"storage_port\": \"8000\"\n}",
+			want: "config.json line 3: storage_port must be a number, not string",
+		},
+		"wrong type in an old config": {
+			data: `{"storage_append_only": "yes"}`,
+			want: "config.json: storage_append_only must be true or false, not string",
+		},
+		"syntax error": {
+			data: "{\n  \"name\": \"x\",,\n}",
+			want: "config.json line 2, column 15: invalid character ','",
+		},
+		"not an object": {
+			data: `[]`,
+			want: "config.json",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := createTempConfigDir(t)
+			writeRawConfig(t, dir, tc.data)
+			_, err := Load(dir)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.want)
+			assert.NoFileExists(t, backupPath(dir, 1), "nothing is migrated when loading fails")
+		})
+	}
+}
+
+func TestSaveStampsVersion(t *testing.T) {
+	dir := createTempConfigDir(t)
+	cfg := &Config{Name: "new", ConfigDir: dir}
+	require.NoError(t, cfg.Save())
+	assert.Equal(t, CurrentVersion, cfg.Version)
+
+	loaded, err := Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, CurrentVersion, loaded.Version)
+	assert.NoFileExists(t, backupPath(dir, 1))
+}
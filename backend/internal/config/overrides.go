@@ -0,0 +1,275 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Source is where a setting's effective value comes from. Later sources
+// override earlier ones: file < env < flag.
+type Source string
+
+const (
+	SourceDefault Source = "default" // Not set anywhere
+	SourceFile    Source = "file"    // config.json
+	SourceEnv     Source = "env"     // An AIRGAPPER_* environment variable
+	SourceFlag    Source = "flag"    // --set key=value
+)
+
+// EnvPrefix prefixes the environment variable of each overridable setting,
+// e.g. AIRGAPPER_LISTEN_ADDR for listen_addr
+const EnvPrefix = "AIRGAPPER_"
+
+// setting is a config field that can be overridden without editing
+// config.json, by its JSON name
+type setting struct {
+	key     string
+	aliases []string // Older environment variables, used if the main one isn't set
+	get     func(c *Config) string
+	set     func(c *Config, value string) error
+}
+
+// settings are the overridable fields: deployment details a container sets
+// rather than keys, shares or consent state
+var settings = []setting{
+	stringSetting("listen_addr", func(c *Config) *string { return &c.ListenAddr }, "AIRGAPPER_PORT"),
+	stringSetting("repo_url", func(c *Config) *string { return &c.RepoURL }),
+	listSetting("cors_allowed_origins", func(c *Config) *[]string { return &c.CORSAllowedOrigins }),
+	stringSetting("tls_cert_file", func(c *Config) *string { return &c.TLSCertFile }),
+	stringSetting("tls_key_file", func(c *Config) *string { return &c.TLSKeyFile }),
+	stringSetting("drain_timeout", func(c *Config) *string { return &c.DrainTimeout }),
+	stringSetting("action_link_url", func(c *Config) *string { return &c.ActionLinkURL }),
+	listSetting("backup_paths", func(c *Config) *[]string { return &c.BackupPaths }),
+	stringSetting("backup_schedule", func(c *Config) *string { return &c.BackupSchedule }),
+	stringSetting("backup_timezone", func(c *Config) *string { return &c.BackupTimezone }),
+	listSetting("backup_exclude", func(c *Config) *[]string { return &c.BackupExclude }),
+	listSetting("backup_tags", func(c *Config) *[]string { return &c.BackupTags }),
+	stringSetting("backup_compression", func(c *Config) *string { return &c.BackupCompression }),
+	int64Setting("host_quota_bytes", func(c *Config) *int64 { return &c.HostQuotaBytes }),
+	stringSetting("backup_ping_url", func(c *Config) *string { return &c.BackupPingURL }),
+	boolSetting("private_backup_window", func(c *Config) *bool { return &c.PrivateBackupWindow }),
+	stringSetting("clock_skew_tolerance", func(c *Config) *string { return &c.ClockSkewTolerance }),
+	stringSetting("max_request_ttl", func(c *Config) *string { return &c.MaxRequestTTL }),
+	stringSetting("restore_cooling_off", func(c *Config) *string { return &c.RestoreCoolingOff }),
+	stringSetting("storage_path", func(c *Config) *string { return &c.StoragePath }),
+	intSetting("storage_port", func(c *Config) *int { return &c.StoragePort }),
+	int64Setting("storage_quota_bytes", func(c *Config) *int64 { return &c.StorageQuotaBytes }),
+	boolSetting("storage_append_only", func(c *Config) *bool { return &c.StorageAppendOnly }),
+	boolSetting("storage_compression", func(c *Config) *bool { return &c.StorageCompression }),
+	boolSetting("storage_verify_reads", func(c *Config) *bool { return &c.StorageVerifyReads }),
+	boolSetting("storage_immutable", func(c *Config) *bool { return &c.StorageImmutable }),
+	boolSetting("storage_require_identity", func(c *Config) *bool { return &c.StorageRequireIdentity }),
+	boolSetting("storage_require_provisioning", func(c *Config) *bool { return &c.StorageRequireProvisioning }),
+	stringSetting("audit_syslog", func(c *Config) *string { return &c.AuditSyslog }),
+}
+
+// override records a setting's value from the file and the value that
+// replaced it, so Save writes the file's value back
+type override struct {
+	source Source
+	file   string
+	value  string
+}
+
+// ResolvedSetting is an overridable setting's effective value and where it
+// comes from
+type ResolvedSetting struct {
+	Key    string
+	Env    string // Environment variable overriding it
+	Value  string // Effective value
+	File   string // Value in config.json
+	Source Source
+}
+
+// SettingKeys returns the keys of the overridable settings
+func SettingKeys() []string {
+	keys := make([]string, len(settings))
+	for i, s := range settings {
+		keys[i] = s.key
+	}
+	return keys
+}
+
+// EnvName returns the environment variable overriding a setting
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(key)
+}
+
+// ApplyOverrides sets the overridable settings given in the environment,
+// then those given as key=value flags, which win. lookupEnv is usually
+// os.LookupEnv. Overridden values are used but not saved: Save writes the
+// file's values back unless a command changed the setting itself.
+func (c *Config) ApplyOverrides(lookupEnv func(string) (string, bool), flags []string) error {
+	for _, s := range settings {
+		for _, name := range append([]string{EnvName(s.key)}, s.aliases...) {
+			if value, ok := lookupEnv(name); ok {
+				if err := c.override(s, SourceEnv, value); err != nil {
+					return fmt.Errorf("invalid %s: %w", name, err)
+				}
+				break
+			}
+		}
+	}
+	for _, flag := range flags {
+		key, value, ok := strings.Cut(flag, "=")
+		i := slices.IndexFunc(settings, func(s setting) bool { return s.key == key })
+		if !ok || i < 0 {
+			return fmt.Errorf("invalid --set %q: want key=value with one of: %s", flag, strings.Join(SettingKeys(), ", "))
+		}
+		if err := c.override(settings[i], SourceFlag, value); err != nil {
+			return fmt.Errorf("invalid --set %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func (c *Config) override(s setting, source Source, value string) error {
+	file := s.get(c)
+	if prev, ok := c.overrides[s.key]; ok {
+		file = prev.file
+	}
+	if err := s.set(c, value); err != nil {
+		return err
+	}
+	if c.overrides == nil {
+		c.overrides = make(map[string]override)
+	}
+	c.overrides[s.key] = override{source: source, file: file, value: s.get(c)}
+	return nil
+}
+
+// Resolved returns every overridable setting's effective value and source
+func (c *Config) Resolved() []ResolvedSetting {
+	resolved := make([]ResolvedSetting, len(settings))
+	for i, s := range settings {
+		r := ResolvedSetting{Key: s.key, Env: EnvName(s.key), Value: s.get(c), Source: SourceDefault}
+		r.File = r.Value
+		if o, ok := c.overrides[s.key]; ok && o.value == r.Value {
+			r.Source, r.File = o.source, o.file
+		} else if r.Value != "" {
+			r.Source = SourceFile
+		}
+		resolved[i] = r
+	}
+	return resolved
+}
+
+// withoutOverrides returns the config as it should be saved: c itself, or a
+// copy with overridden settings put back to the file's values. A setting
+// changed since it was overridden keeps its new value.
+func (c *Config) withoutOverrides() *Config {
+	if len(c.overrides) == 0 {
+		return c
+	}
+	saved := *c
+	for _, s := range settings {
+		if o, ok := c.overrides[s.key]; ok && s.get(c) == o.value {
+			_ = s.set(&saved, o.file)
+		}
+	}
+	return &saved
+}
+
+func stringSetting(key string, field func(c *Config) *string, aliases ...string) setting {
+	return setting{
+		key:     key,
+		aliases: aliases,
+		get:     func(c *Config) string { return *field(c) },
+		set: func(c *Config, v string) error {
+			*field(c) = v
+			return nil
+		},
+	}
+}
+
+// listSetting is a list given comma-separated
+func listSetting(key string, field func(c *Config) *[]string) setting {
+	return setting{
+		key: key,
+		get: func(c *Config) string { return strings.Join(*field(c), ",") },
+		set: func(c *Config, v string) error {
+			var list []string
+			for item := range strings.SplitSeq(v, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					list = append(list, item)
+				}
+			}
+			*field(c) = list
+			return nil
+		},
+	}
+}
+
+func intSetting(key string, field func(c *Config) *int) setting {
+	return setting{
+		key: key,
+		get: func(c *Config) string {
+			if *field(c) == 0 {
+				return ""
+			}
+			return strconv.Itoa(*field(c))
+		},
+		set: func(c *Config, v string) error {
+			if v == "" {
+				*field(c) = 0
+				return nil
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("%q is not a number", v)
+			}
+			*field(c) = n
+			return nil
+		},
+	}
+}
+
+func int64Setting(key string, field func(c *Config) *int64) setting {
+	return setting{
+		key: key,
+		get: func(c *Config) string {
+			if *field(c) == 0 {
+				return ""
+			}
+			return strconv.FormatInt(*field(c), 10)
+		},
+		set: func(c *Config, v string) error {
+			if v == "" {
+				*field(c) = 0
+				return nil
+			}
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return fmt.Errorf("%q is not a number", v)
+			}
+			*field(c) = n
+			return nil
+		},
+	}
+}
+
+func boolSetting(key string, field func(c *Config) *bool) setting {
+	return setting{
+		key: key,
+		get: func(c *Config) string {
+			if !*field(c) {
+				return ""
+			}
+			return "true"
+		},
+		set: func(c *Config, v string) error {
+			if v == "" {
+				*field(c) = false
+				return nil
+			}
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("%q is not true or false", v)
+			}
+			*field(c) = b
+			return nil
+		},
+	}
+}
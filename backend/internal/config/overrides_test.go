@@ -0,0 +1,118 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// env returns a lookup function over a fixed environment
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+func resolvedByKey(c *Config) map[string]ResolvedSetting {
+	m := make(map[string]ResolvedSetting)
+	for _, r := range c.Resolved() {
+		m[r.Key] = r
+	}
+	return m
+}
+
+func TestApplyOverrides(t *testing.T) {
+	cfg := &Config{
+		ListenAddr:  "127.0.0.1:8081",
+		RepoURL:     "rest:http://file:8000/",
+		StoragePath: "/file/storage",
+	}
+	err := cfg.ApplyOverrides(env(map[string]string{
+		"AIRGAPPER_LISTEN_ADDR":         "0.0.0.0:9000",
+		"AIRGAPPER_PORT":                "7000",
+		"AIRGAPPER_REPO_URL":            "rest:http://env:8000/",
+		"AIRGAPPER_STORAGE_PORT":        "8001",
+		"AIRGAPPER_STORAGE_APPEND_ONLY": "true",
+		"AIRGAPPER_BACKUP_PATHS":        "/data, /etc ,",
+	}), []string{"repo_url=rest:http://flag:8000/"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "0.0.0.0:9000", cfg.ListenAddr, "the main variable beats its alias")
+	assert.Equal(t, "rest:http://flag:8000/", cfg.RepoURL, "flags beat the environment")
+	assert.Equal(t, 8001, cfg.StoragePort)
+	assert.True(t, cfg.StorageAppendOnly)
+	assert.Equal(t, []string{"/data", "/etc"}, cfg.BackupPaths)
+
+	resolved := resolvedByKey(cfg)
+	assert.Equal(t, ResolvedSetting{
+		Key:    "repo_url",
+		Env:    "AIRGAPPER_REPO_URL",
+		Value:  "rest:http://flag:8000/",
+		File:   "rest:http://file:8000/",
+		Source: SourceFlag,
+	}, resolved["repo_url"])
+	assert.Equal(t, SourceEnv, resolved["listen_addr"].Source)
+	assert.Equal(t, SourceFile, resolved["storage_path"].Source)
+	assert.Equal(t, SourceDefault, resolved["backup_schedule"].Source)
+}
+
+func TestApplyOverridesAlias(t *testing.T) {
+	cfg := &Config{}
+	require.NoError(t, cfg.ApplyOverrides(env(map[string]string{"AIRGAPPER_PORT": "9090"}), nil))
+	assert.Equal(t, "9090", cfg.ListenAddr)
+}
+
+func TestApplyOverridesErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		env   map[string]string
+		flags []string
+		want  string
+	}{
+		"bad number":    {env: map[string]string{"AIRGAPPER_STORAGE_PORT": "http"}, want: "invalid AIRGAPPER_STORAGE_PORT"},
+		"bad bool":      {flags: []string{"storage_compression=maybe"}, want: "invalid --set storage_compression"},
+		"unknown key":   {flags: []string{"password=hunter2"}, want: "one of: listen_addr"},
+		"not key=value": {flags: []string{"repo_url"}, want: `invalid --set "repo_url"`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := (&Config{}).ApplyOverrides(env(tc.env), tc.flags)
+			assert.ErrorContains(t, err, tc.want)
+		})
+	}
+}
+
+func TestSaveLeavesOverridesOut(t *testing.T) {
+	dir := createTempConfigDir(t)
+	cfg := &Config{
+		Name:              "node",
+		ConfigDir:         dir,
+		RepoURL:           "rest:http://file:8000/",
+		StorageAppendOnly: true,
+	}
+	require.NoError(t, cfg.ApplyOverrides(env(map[string]string{
+		"AIRGAPPER_REPO_URL":            "rest:http://env:8000/",
+		"AIRGAPPER_STORAGE_APPEND_ONLY": "false",
+		"AIRGAPPER_BACKUP_SCHEDULE":     "hourly",
+		"AIRGAPPER_STORAGE_PATH":        "/env/storage",
+	}), nil))
+
+	// A command changing an overridden setting means to keep the change
+	cfg.BackupSchedule = "daily"
+	require.NoError(t, cfg.Save())
+
+	assert.Equal(t, "rest:http://env:8000/", cfg.RepoURL, "the overrides stay in force")
+	assert.False(t, cfg.StorageAppendOnly)
+
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	require.NoError(t, err)
+	var saved Config
+	require.NoError(t, json.Unmarshal(data, &saved))
+	assert.Equal(t, "rest:http://file:8000/", saved.RepoURL)
+	assert.True(t, saved.StorageAppendOnly)
+	assert.Empty(t, saved.StoragePath)
+	assert.Equal(t, "daily", saved.BackupSchedule)
+}
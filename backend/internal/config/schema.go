@@ -0,0 +1,160 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// decodeStrict decodes config data, refusing fields the Config doesn't
+// have, so a misspelled setting is reported rather than silently ignored.
+// original is the file as read, which data differs from if it was migrated;
+// errors point into it.
+func decodeStrict(data, original []byte, cfg *Config) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		if !bytes.Equal(data, original) {
+			return describeDecodeError(original, stripOffset(err))
+		}
+		return describeDecodeError(data, err)
+	}
+	if dec.More() {
+		return errors.New("config.json has data after the config object")
+	}
+	return nil
+}
+
+// describeDecodeError turns a JSON decoding error into one saying where in
+// config.json the problem is and, for unknown fields, what was likely meant
+func describeDecodeError(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		// The offset is just past the offending character
+		return fmt.Errorf("config.json %s: %w", position(data, syntaxErr.Offset-1), err)
+	case errors.As(err, &typeErr):
+		where := "config.json"
+		if typeErr.Offset >= 0 {
+			// The offset is past the end of the value, so only its line is certain
+			where += fmt.Sprintf(" line %d", bytes.Count(data[:min(typeErr.Offset, int64(len(data)))], []byte("\n"))+1)
+		}
+		return fmt.Errorf("%s: %s must be %s, not %s", where, typeErr.Field, kindName(typeErr.Type), typeErr.Value)
+	}
+
+	field, ok := strings.CutPrefix(err.Error(), "json: unknown field ")
+	if !ok {
+		return fmt.Errorf("config.json: %w", err)
+	}
+	field = strings.Trim(field, `"`)
+	msg := fmt.Sprintf("config.json: unknown field %q", field)
+	if i := bytes.Index(data, []byte(`"`+field+`"`)); i >= 0 {
+		msg = fmt.Sprintf("config.json %s: unknown field %q", position(data, int64(i)), field)
+	}
+	if guess := closestField(field); guess != "" {
+		msg += fmt.Sprintf(" (did you mean %q?)", guess)
+	}
+	return errors.New(msg)
+}
+
+// stripOffset drops the offset of a type error in migrated data, which
+// doesn't match the file
+func stripOffset(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		stripped := *typeErr
+		stripped.Offset = -1
+		return &stripped
+	}
+	return err
+}
+
+// position returns the line and column of a byte offset
+func position(data []byte, offset int64) string {
+	offset = min(max(offset, 0), int64(len(data)))
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := int(offset) - bytes.LastIndexByte(before, '\n')
+	return fmt.Sprintf("line %d, column %d", line, col)
+}
+
+func kindName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "a base64 string"
+		}
+		return "a list"
+	}
+	return "an object"
+}
+
+// closestField returns the config field name nearest to an unknown one,
+// if any is within two edits
+func closestField(unknown string) string {
+	best, bestDist := "", 3
+	for _, name := range fieldNames(reflect.TypeFor[Config](), map[reflect.Type]bool{}) {
+		if d := editDistance(unknown, name); d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+// fieldNames lists the JSON names of t's fields and its nested structs'
+func fieldNames(t reflect.Type, seen map[reflect.Type]bool) []string {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return nil
+	}
+	seen[t] = true
+	var names []string
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		names = append(names, name)
+		names = append(names, fieldNames(f.Type, seen)...)
+	}
+	return names
+}
+
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,89 @@
+package consent
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+// ChallengeTTL is how long a key holder has to sign with an issued challenge
+const ChallengeTTL = 5 * time.Minute
+
+// Challenge is a nonce this node issues to a key holder about to approve a
+// request. The key holder signs it with the request, so an approval only
+// counts once and only on the node that issued it: an intercepted approval
+// can't be replayed onto another node storing a request with the same ID.
+type Challenge struct {
+	Nonce       string    `json:"nonce"`
+	KeyHolderID string    `json:"key_holder_id"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// ApprovalSignData returns the data a key holder signs at signedAt to
+// approve a request with a challenge's nonce. Unlike the requester, the
+// key holder also signs the source address the request came from.
+func (r *RestoreRequest) ApprovalSignData(keyHolderID, nonce string, signedAt time.Time) *crypto.RestoreRequestSignData {
+	data := r.SignData(keyHolderID)
+	data.Nonce = nonce
+	if r.RequesterContext != nil {
+		data.SourceIP = r.RequesterContext.SourceIP
+	}
+	if !signedAt.IsZero() {
+		data.SignedAt = signedAt.Unix()
+	}
+	return data
+}
+
+// IssueChallenge issues keyHolderID a new nonce to sign request id with.
+// Approved requests still cooling off get challenges too, to veto them.
+// Expired challenges are dropped.
+func (m *Manager) IssueChallenge(id, keyHolderID string) (*Challenge, error) {
+	req, err := pendingRequest(m, m.restores(), id)
+	if errors.Is(err, apperrors.ErrRequestNotPending) {
+		if req, err = m.GetRequest(id); err == nil {
+			err = req.checkVetoable(time.Now())
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	c := Challenge{
+		Nonce:       hex.EncodeToString(nonce),
+		KeyHolderID: keyHolderID,
+		ExpiresAt:   now.Add(ChallengeTTL),
+	}
+
+	req.Challenges = slices.DeleteFunc(req.Challenges, func(c Challenge) bool { return now.After(c.ExpiresAt) })
+	req.Challenges = append(req.Challenges, c)
+	if err := m.saveRequest(req); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// consumeChallenge removes the live challenge issued to keyHolderID with
+// nonce, rejecting nonces already used by an approval
+func (r *RestoreRequest) consumeChallenge(keyHolderID, nonce string) error {
+	if slices.ContainsFunc(r.Approvals, func(a Approval) bool { return a.Nonce == nonce }) {
+		return apperrors.ErrNonceReused
+	}
+	i := slices.IndexFunc(r.Challenges, func(c Challenge) bool {
+		return c.Nonce == nonce && c.KeyHolderID == keyHolderID
+	})
+	if i < 0 || time.Now().After(r.Challenges[i].ExpiresAt) {
+		return apperrors.ErrChallengeInvalid
+	}
+	r.Challenges = slices.Delete(r.Challenges, i, i+1)
+	return nil
+}
@@ -0,0 +1,84 @@
+package consent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+func TestChallenges(t *testing.T) {
+	m := NewManager(t.TempDir())
+	req, err := m.CreateRequestWithConsensus("alice", "latest", "test", nil, 3)
+	require.NoError(t, err)
+
+	alice, err := m.IssueChallenge(req.ID, "alice")
+	require.NoError(t, err)
+	assert.Len(t, alice.Nonce, 64)
+	assert.WithinDuration(t, time.Now().Add(ChallengeTTL), alice.ExpiresAt, time.Second)
+
+	bob, err := m.IssueChallenge(req.ID, "bob")
+	require.NoError(t, err)
+	assert.NotEqual(t, alice.Nonce, bob.Nonce)
+
+	// A challenge only answers for the key holder it was issued to
+	err = m.AddSignature(req.ID, "bob", "Bob", alice.Nonce, time.Time{}, []byte("sig"))
+	assert.ErrorIs(t, err, apperrors.ErrChallengeInvalid)
+	err = m.AddSignature(req.ID, "bob", "Bob", "unknown", time.Time{}, []byte("sig"))
+	assert.ErrorIs(t, err, apperrors.ErrChallengeInvalid)
+
+	signedAt := time.Now().Truncate(time.Second)
+	require.NoError(t, m.AddSignature(req.ID, "alice", "Alice", alice.Nonce, signedAt, []byte("sig")))
+	stored, err := m.GetRequest(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, alice.Nonce, stored.Approvals[0].Nonce)
+	require.NotNil(t, stored.Approvals[0].SignedAt)
+	assert.True(t, signedAt.Equal(*stored.Approvals[0].SignedAt))
+	assert.Len(t, stored.Challenges, 1, "used challenges are removed")
+
+	// A used nonce can't be replayed, even by another key holder
+	err = m.AddSignature(req.ID, "carol", "Carol", alice.Nonce, time.Time{}, []byte("sig"))
+	assert.ErrorIs(t, err, apperrors.ErrNonceReused)
+
+	require.NoError(t, m.AddSignature(req.ID, "bob", "Bob", bob.Nonce, time.Time{}, []byte("sig")))
+}
+
+func TestChallengeExpired(t *testing.T) {
+	m := NewManager(t.TempDir())
+	req, err := m.CreateRequestWithConsensus("alice", "latest", "test", nil, 2)
+	require.NoError(t, err)
+
+	old, err := m.IssueChallenge(req.ID, "alice")
+	require.NoError(t, err)
+	req, err = m.GetRequest(req.ID)
+	require.NoError(t, err)
+	req.Challenges[0].ExpiresAt = time.Now().Add(-time.Second)
+	require.NoError(t, m.saveRequest(req))
+
+	err = m.AddSignature(req.ID, "alice", "Alice", old.Nonce, time.Time{}, []byte("sig"))
+	assert.ErrorIs(t, err, apperrors.ErrChallengeInvalid)
+
+	// Issuing another challenge drops expired ones
+	_, err = m.IssueChallenge(req.ID, "alice")
+	require.NoError(t, err)
+	req, err = m.GetRequest(req.ID)
+	require.NoError(t, err)
+	require.Len(t, req.Challenges, 1)
+	assert.NotEqual(t, old.Nonce, req.Challenges[0].Nonce)
+}
+
+func TestChallengeNotPending(t *testing.T) {
+	m := NewManager(t.TempDir())
+	req, err := m.CreateRequestWithConsensus("alice", "latest", "test", nil, 2)
+	require.NoError(t, err)
+	require.NoError(t, Restores.Deny(m, req.ID, "bob"))
+
+	_, err = m.IssueChallenge(req.ID, "alice")
+	assert.ErrorIs(t, err, apperrors.ErrRequestNotPending)
+
+	_, err = m.IssueChallenge("missing", "alice")
+	assert.ErrorIs(t, err, apperrors.ErrRequestNotFound)
+}
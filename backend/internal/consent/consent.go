@@ -4,13 +4,18 @@ package consent
 import (
 	"crypto/rand"
 	"encoding/hex"
-	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"time"
 
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
 	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/events"
 	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
 )
 
 // RequestStatus represents the status of a restore request
@@ -21,6 +26,51 @@ const (
 	StatusApproved RequestStatus = "approved"
 	StatusDenied   RequestStatus = "denied"
 	StatusExpired  RequestStatus = "expired"
+	StatusVetoed   RequestStatus = "vetoed"
+)
+
+// PurposeExportKeys marks a request to export the raw repository password
+// rather than restore a snapshot
+const PurposeExportKeys = "export-keys"
+
+// PurposeBrowse marks a request to list a snapshot's contents (metadata
+// only) rather than restore it
+const PurposeBrowse = "browse"
+
+// ValidPurpose reports whether purpose is one this node knows how to
+// honor: empty for a restore, PurposeExportKeys or PurposeBrowse
+func ValidPurpose(purpose string) bool {
+	return purpose == "" || purpose == PurposeExportKeys || purpose == PurposeBrowse
+}
+
+// NormalizeSnapshotID returns the snapshot a request with purpose is made
+// and signed for: "latest" when none is given, except for key exports,
+// which name no snapshot. Requesters and the nodes verifying their
+// signatures must agree on it.
+func NormalizeSnapshotID(purpose, snapshotID string) string {
+	if snapshotID == "" && purpose != PurposeExportKeys {
+		return "latest"
+	}
+	return snapshotID
+}
+
+// DefaultClockSkewTolerance is how far peers' clocks may differ before
+// expiry checks and signed timestamps stop allowing for it
+const DefaultClockSkewTolerance = 5 * time.Minute
+
+// Restore requests stay pending for DefaultRequestTTL unless created with
+// another TTL. Nodes cap the TTLs, and extensions, they accept at
+// DefaultMaxRequestTTL unless configured otherwise.
+const (
+	DefaultRequestTTL    = 24 * time.Hour
+	DefaultMaxRequestTTL = 7 * 24 * time.Hour
+)
+
+// Browse requests are lighter-weight than restores: they expire sooner while
+// pending, and once approved the listing is only allowed for a short window
+const (
+	BrowseRequestTTL = 4 * time.Hour
+	BrowseWindow     = time.Hour
 )
 
 // Approval represents a cryptographic approval from a key holder
@@ -29,25 +79,92 @@ type Approval struct {
 	KeyHolderName string    `json:"key_holder_name,omitempty"` // Name of the key holder
 	Signature     []byte    `json:"signature"`                 // Ed25519 signature over request hash
 	ApprovedAt    time.Time `json:"approved_at"`
+
+	// Set when a delegate approved with another key holder's authority
+	OnBehalfOf   string `json:"on_behalf_of,omitempty"`
+	DelegationID string `json:"delegation_id,omitempty"`
+
+	// Set when recovery contacts stood in for a key holder who lost their
+	// key; the approval counts as that key holder's
+	RecoveryID string `json:"recovery_id,omitempty"`
+
+	// Nonce is the challenge the approval signed, kept so it can't be reused
+	Nonce string `json:"nonce,omitempty"`
+	// SignedAt is when the key holder signed, by their clock. It is covered
+	// by the signature, so it can settle disputes about when they approved.
+	SignedAt *time.Time `json:"signed_at,omitempty"`
+}
+
+// Authority is the key holder whose approval this counts as
+func (a Approval) Authority() string {
+	if a.OnBehalfOf != "" {
+		return a.OnBehalfOf
+	}
+	return a.KeyHolderID
 }
 
 // RestoreRequest represents a request to restore data
 type RestoreRequest struct {
 	ID         string        `json:"id"`
-	Requester  string        `json:"requester"`   // Name of requesting party
-	SnapshotID string        `json:"snapshot_id"` // Restic snapshot to restore
-	Paths      []string      `json:"paths"`       // Specific paths (optional)
-	Reason     string        `json:"reason"`      // Why restore is needed
+	Requester  string        `json:"requester"`         // Name of requesting party
+	SnapshotID string        `json:"snapshot_id"`       // Restic snapshot to restore
+	Paths      []string      `json:"paths"`             // Specific paths (optional)
+	Reason     string        `json:"reason"`            // Why restore is needed
+	Purpose    string        `json:"purpose,omitempty"` // Empty for restores, PurposeExportKeys or PurposeBrowse
 	Status     RequestStatus `json:"status"`
 	CreatedAt  time.Time     `json:"created_at"`
-	ExpiresAt  time.Time     `json:"expires_at"`
+	ExpiresAt  time.Time     `json:"expires_at"` // For approved browse requests, the end of the browse window
 	ApprovedAt *time.Time    `json:"approved_at,omitempty"`
 	ApprovedBy string        `json:"approved_by,omitempty"`
+	Template   string        `json:"template,omitempty"`   // Template the request was created from
 	ShareData  []byte        `json:"share_data,omitempty"` // Released share (only after approval) - legacy SSS mode
 
+	// CorrelationID is sent with every peer call about this request, so
+	// both nodes' logs and audit entries for it can be matched up
+	CorrelationID string `json:"correlation_id,omitempty"`
+
+	// The requester's signature over the request, proving it wasn't forged
+	RequesterKeyID     string `json:"requester_key_id,omitempty"`
+	RequesterSignature []byte `json:"requester_signature,omitempty"`
+
+	// The device the request was made from, shown to approvers
+	RequesterContext *RequesterContext `json:"requester_context,omitempty"`
+
+	// SizeBytes is the size of the restore as its requester declared it;
+	// zero if not given. The requester's signature covers it.
+	SizeBytes int64 `json:"size_bytes,omitempty"`
+
 	// Consensus mode fields
 	RequiredApprovals int        `json:"required_approvals,omitempty"` // Number of approvals needed (m in m-of-n)
+	Quorum            *Quorum    `json:"quorum,omitempty"`             // Richer rule replacing RequiredApprovals, if set
 	Approvals         []Approval `json:"approvals,omitempty"`          // Collected cryptographic approvals
+
+	// Challenges issued to key holders and not yet used
+	Challenges []Challenge `json:"challenges,omitempty"`
+
+	// Extensions of the request's expiry asked for by the requester
+	Extensions []Extension `json:"extensions,omitempty"`
+
+	// ExecutableAt is when the cooling-off period started by approval ends;
+	// nil if approval started none
+	ExecutableAt *time.Time `json:"executable_at,omitempty"`
+
+	// Set when a key holder vetoed the request, with their signature over
+	// VetoSignData
+	VetoedBy        string     `json:"vetoed_by,omitempty"`
+	VetoedAt        *time.Time `json:"vetoed_at,omitempty"`
+	VetoReason      string     `json:"veto_reason,omitempty"`
+	VetoKeyHolderID string     `json:"veto_key_holder_id,omitempty"`
+	VetoSignature   []byte     `json:"veto_signature,omitempty"`
+	VetoNonce       string     `json:"veto_nonce,omitempty"`
+	VetoSignedAt    *time.Time `json:"veto_signed_at,omitempty"`
+
+	// Terms approvers attached to their approvals, all of which the
+	// restore honors
+	Terms []RestoreTerms `json:"terms,omitempty"`
+
+	// Progress of the restore, once it is scheduled or running
+	Progress *RestoreProgress `json:"progress,omitempty"`
 }
 
 // DeletionType specifies what is being deleted
@@ -75,15 +192,130 @@ type DeletionRequest struct {
 	ApprovedBy   string        `json:"approved_by,omitempty"`
 	ExecutedAt   *time.Time    `json:"executed_at,omitempty"` // When deletion was performed
 
+	// Progress of the forget and prune carrying out the deletion, once
+	// started
+	Prune *PruneProgress `json:"prune,omitempty"`
+
+	// Retention is what the owner's retention policy removes, for a prune
+	// deletion requested from it; SnapshotIDs are its Remove list, so the
+	// deletion forgets exactly what approvers saw
+	Retention *RetentionPlan `json:"retention,omitempty"`
+
+	// CorrelationID is sent with every peer call about this request
+	CorrelationID string `json:"correlation_id,omitempty"`
+
 	// Consensus mode fields
 	RequiredApprovals int        `json:"required_approvals,omitempty"`
 	Approvals         []Approval `json:"approvals,omitempty"`
+	Quorum            *Quorum    `json:"quorum,omitempty"` // Richer rule replacing RequiredApprovals, if set
+}
+
+// RetentionPlan is what a retention policy keeps and removes, previewed
+// with restic forget --dry-run
+type RetentionPlan struct {
+	Policy string            `json:"policy"` // e.g. "7 daily, 4 weekly"
+	Keep   []PlannedSnapshot `json:"keep"`
+	Remove []PlannedSnapshot `json:"remove"`
+}
+
+// PlannedSnapshot is a snapshot in a RetentionPlan
+type PlannedSnapshot struct {
+	ID    string    `json:"id"`
+	Time  time.Time `json:"time"`
+	Paths []string  `json:"paths,omitempty"`
+	// Pinned is set on snapshots kept only because they are pinned
+	Pinned bool `json:"pinned,omitempty"`
+}
+
+// RemoveIDs returns the IDs of the snapshots the plan removes
+func (p *RetentionPlan) RemoveIDs() []string {
+	ids := make([]string, len(p.Remove))
+	for i, s := range p.Remove {
+		ids[i] = s.ID
+	}
+	return ids
 }
 
 // Manager handles consent operations
 type Manager struct {
 	dataDir         string
 	deletionDataDir string
+	templatesPath   string
+	delegationsPath string
+	recoveriesPath  string
+	rulesLogPath    string
+
+	// correlationID is given to created requests instead of a new one
+	correlationID string
+
+	// purpose and template are recorded on, and signed with, created
+	// requests
+	purpose  string
+	template string
+
+	// signed, if set, gives the next created request the ID, creation time
+	// and signature its requester chose
+	signed *RequesterSignature
+
+	// signingKey, if set, signs created requests as the key holder signingKeyID
+	signingKey   []byte
+	signingKeyID string
+
+	// skewTolerance extends expiry checks to allow for peers' clocks
+	// differing from ours
+	skewTolerance time.Duration
+
+	// ttl is how long created restore requests stay pending
+	ttl time.Duration
+
+	// coolingOff is how long approved requests wait before they can be
+	// executed, during which any key holder can veto them
+	coolingOff time.Duration
+
+	// configDir holds the lockdown, while which approvals and new requests
+	// are refused
+	configDir string
+
+	// requesterContext, if set, is recorded on created requests
+	requesterContext *RequesterContext
+
+	// sizeBytes is the declared size of created requests
+	sizeBytes int64
+
+	// terms, if set, are attached to the requests this manager approves
+	terms *RestoreTerms
+
+	// deletionQuorum, if set, is the rule created deletion requests are
+	// approved under
+	deletionQuorum *Quorum
+}
+
+// RequesterSignature is a requester's signature over a request it asks
+// another node to create. The requester picks the ID and creation time, so
+// they are covered by the signature.
+type RequesterSignature struct {
+	RequestID   string
+	CreatedAt   time.Time
+	KeyHolderID string
+	Signature   []byte
+}
+
+// SignData returns the data a requester signs for a request
+func (r *RestoreRequest) SignData(keyHolderID string) *crypto.RestoreRequestSignData {
+	data := &crypto.RestoreRequestSignData{
+		RequestID:   r.ID,
+		Requester:   r.Requester,
+		SnapshotID:  r.SnapshotID,
+		Paths:       r.Paths,
+		Reason:      r.Reason,
+		CreatedAt:   r.CreatedAt.Unix(),
+		KeyHolderID: keyHolderID,
+		SizeBytes:   r.SizeBytes,
+		Purpose:     r.Purpose,
+		Template:    r.Template,
+	}
+	r.RequesterContext.signContext(data)
+	return data
 }
 
 // NewManager creates a consent manager
@@ -91,244 +323,477 @@ func NewManager(dataDir string) *Manager {
 	return &Manager{
 		dataDir:         filepath.Join(dataDir, "requests"),
 		deletionDataDir: filepath.Join(dataDir, "deletions"),
+		templatesPath:   filepath.Join(dataDir, "request-templates.json"),
+		delegationsPath: filepath.Join(dataDir, "delegations.json"),
+		recoveriesPath:  filepath.Join(dataDir, "recoveries.json"),
+		rulesLogPath:    filepath.Join(dataDir, "rule-decisions.json"),
+		skewTolerance:   DefaultClockSkewTolerance,
+		ttl:             DefaultRequestTTL,
+		configDir:       dataDir,
 	}
 }
 
-// CreateRequest creates a new restore request
-func (m *Manager) CreateRequest(requester, snapshotID, reason string, paths []string) (*RestoreRequest, error) {
-	// Generate unique ID
+// WithDeletionQuorum returns a manager whose created deletion requests are
+// approved once quorum is met, rather than by a flat count
+func (m *Manager) WithDeletionQuorum(quorum *Quorum) *Manager {
+	c := *m
+	c.deletionQuorum = quorum
+	return &c
+}
+
+// WithCorrelationID returns a manager whose created requests take
+// correlationID, e.g. that of the API call creating them, instead of a new one
+func (m *Manager) WithCorrelationID(correlationID string) *Manager {
+	c := *m
+	c.correlationID = correlationID
+	return &c
+}
+
+// WithPurpose returns a manager whose created requests are for purpose,
+// e.g. PurposeExportKeys, rather than a restore
+func (m *Manager) WithPurpose(purpose string) *Manager {
+	c := *m
+	c.purpose = purpose
+	return &c
+}
+
+// WithTemplate returns a manager whose created requests record that they
+// were created from the template name
+func (m *Manager) WithTemplate(name string) *Manager {
+	c := *m
+	c.template = name
+	return &c
+}
+
+// WithTTL returns a manager whose created restore requests stay pending
+// for ttl instead of DefaultRequestTTL. Browse requests keep BrowseRequestTTL.
+func (m *Manager) WithTTL(ttl time.Duration) *Manager {
+	c := *m
+	c.ttl = ttl
+	return &c
+}
+
+// WithSize returns a manager whose created requests declare a restore of
+// sizeBytes bytes
+func (m *Manager) WithSize(sizeBytes int64) *Manager {
+	c := *m
+	c.sizeBytes = sizeBytes
+	return &c
+}
+
+// WithClockSkewTolerance returns a manager that treats pending requests as
+// expired only once tolerance past their expiry, since the expiry may have
+// been set by a peer whose clock differs from ours
+func (m *Manager) WithClockSkewTolerance(tolerance time.Duration) *Manager {
+	c := *m
+	c.skewTolerance = tolerance
+	return &c
+}
+
+// ClockSkewTolerance is the allowance for peers' clocks differing from ours
+func (m *Manager) ClockSkewTolerance() time.Duration {
+	return m.skewTolerance
+}
+
+// expired reports whether a pending request expiring at expiresAt has
+// expired, allowing for clock skew
+func (m *Manager) expired(expiresAt time.Time) bool {
+	return time.Now().After(expiresAt.Add(m.skewTolerance))
+}
+
+// WithRequesterSignature returns a manager whose next created request
+// takes the ID and creation time sig was made over and records sig.
+// Callers verify the signature first.
+func (m *Manager) WithRequesterSignature(sig *RequesterSignature) *Manager {
+	c := *m
+	c.signed = sig
+	return &c
+}
+
+// WithSigningKey returns a manager that signs the requests it creates with
+// privateKey, as the key holder keyHolderID, so peers can tell they
+// weren't forged
+func (m *Manager) WithSigningKey(keyHolderID string, privateKey []byte) *Manager {
+	c := *m
+	c.signingKeyID = keyHolderID
+	c.signingKey = privateKey
+	return &c
+}
+
+// requestIDPattern matches the IDs of restore requests
+var requestIDPattern = regexp.MustCompile(`^[0-9a-f]{16}$`)
+
+// NewRequestID returns a random restore request ID
+func NewRequestID() (string, error) {
 	idBytes := make([]byte, 8)
 	if _, err := rand.Read(idBytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(idBytes), nil
+}
+
+// newRequest builds a pending restore request with a new ID, or the ID and
+// signature given with WithRequesterSignature
+func (m *Manager) newRequest(requester, snapshotID, reason string, paths []string) (*RestoreRequest, error) {
+	if err := m.CheckLockdown(); err != nil {
 		return nil, err
 	}
 
-	req := &RestoreRequest{
-		ID:         hex.EncodeToString(idBytes),
-		Requester:  requester,
-		SnapshotID: snapshotID,
-		Paths:      paths,
-		Reason:     reason,
-		Status:     StatusPending,
-		CreatedAt:  time.Now(),
-		ExpiresAt:  time.Now().Add(24 * time.Hour), // 24 hour expiry
+	if !ValidPurpose(m.purpose) {
+		return nil, apperrors.Newf(apperrors.CodeInvalidArgument, "unknown request purpose %q", m.purpose)
 	}
 
-	if err := m.saveRequest(req); err != nil {
+	req := &RestoreRequest{
+		Requester:     requester,
+		SnapshotID:    NormalizeSnapshotID(m.purpose, snapshotID),
+		Paths:         paths,
+		Reason:        reason,
+		Purpose:       m.purpose,
+		Template:      m.template,
+		Status:        StatusPending,
+		CorrelationID: m.newCorrelationID(),
+		SizeBytes:     m.sizeBytes,
+	}
+	if m.requesterContext != nil {
+		rc := *m.requesterContext
+		req.RequesterContext = &rc
+	}
+
+	if m.signed != nil {
+		if !requestIDPattern.MatchString(m.signed.RequestID) {
+			return nil, apperrors.New(apperrors.CodeInvalidArgument, "request ID must be 16 hex characters")
+		}
+		if _, err := os.Stat(filepath.Join(m.dataDir, m.signed.RequestID+".json")); err == nil {
+			return nil, apperrors.ErrRequestExists
+		}
+		req.ID = m.signed.RequestID
+		req.CreatedAt = m.signed.CreatedAt
+		req.RequesterKeyID = m.signed.KeyHolderID
+		req.RequesterSignature = m.signed.Signature
+	} else {
+		id, err := NewRequestID()
+		if err != nil {
+			return nil, err
+		}
+		req.ID = id
+		req.CreatedAt = time.Now()
+	}
+	req.ExpiresAt = req.CreatedAt.Add(m.ttl)
+	if req.IsBrowse() {
+		req.ExpiresAt = req.CreatedAt.Add(BrowseRequestTTL)
+	}
+	if err := req.checkScope(); err != nil {
 		return nil, err
 	}
 
+	if m.signingKey != nil && req.RequesterSignature == nil {
+		sig, err := req.SignData(m.signingKeyID).Sign(m.signingKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign request: %w", err)
+		}
+		req.RequesterKeyID = m.signingKeyID
+		req.RequesterSignature = sig
+	}
 	return req, nil
 }
 
-// GetRequest retrieves a request by ID
-func (m *Manager) GetRequest(id string) (*RestoreRequest, error) {
-	path := filepath.Join(m.dataDir, id+".json")
-	data, err := os.ReadFile(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, apperrors.ErrRequestNotFound
-		}
-		return nil, err
+// newCorrelationID returns the correlation ID for a new request
+func (m *Manager) newCorrelationID() string {
+	if m.correlationID != "" {
+		return m.correlationID
 	}
+	return tracing.NewID()
+}
 
-	var req RestoreRequest
-	if err := json.Unmarshal(data, &req); err != nil {
+// CreateRequest creates a new restore request
+func (m *Manager) CreateRequest(requester, snapshotID, reason string, paths []string) (*RestoreRequest, error) {
+	req, err := m.newRequest(requester, snapshotID, reason, paths)
+	if err != nil {
 		return nil, err
 	}
 
-	// Check expiry
-	if req.Status == StatusPending && time.Now().After(req.ExpiresAt) {
-		req.Status = StatusExpired
-		if err := m.saveRequest(&req); err != nil {
-			logging.Warn("Failed to save expired request", logging.Err(err))
-		}
+	if err := Restores.add(m, req, requestCreatedMessage(req)); err != nil {
+		return nil, err
 	}
+	return req, nil
+}
 
-	return &req, nil
+// CreateKeyExportRequest creates a request to export the repository
+// password. Approving it hands the owner the raw key, so it is kept distinct
+// from restore requests.
+func (m *Manager) CreateKeyExportRequest(requester, reason string) (*RestoreRequest, error) {
+	return m.WithPurpose(PurposeExportKeys).CreateRequest(requester, "", reason, nil)
 }
 
-// ListPending returns all pending requests
-func (m *Manager) ListPending() ([]*RestoreRequest, error) {
-	if err := os.MkdirAll(m.dataDir, 0700); err != nil {
-		return nil, err
-	}
+// IsKeyExport returns true if the request is for exporting keys
+func (r *RestoreRequest) IsKeyExport() bool { return r.Purpose == PurposeExportKeys }
+
+// HasSealedPaths returns true if the requester sealed the request's paths,
+// so they can't be read here
+func (r *RestoreRequest) HasSealedPaths() bool {
+	return slices.ContainsFunc(r.Paths, crypto.IsSealedPath)
+}
 
-	entries, err := os.ReadDir(m.dataDir)
+// SealPaths returns a copy of the request for peers, with its paths sealed
+// by the repository password; the local copy keeps the plain paths. Peers
+// verify the requester signature over the sealed paths they receive, so a
+// signed request's copy is signed again with privateKey.
+func (r *RestoreRequest) SealPaths(password string, privateKey []byte) (*RestoreRequest, error) {
+	sealed, err := crypto.SealPaths([]byte(password), r.Paths)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to seal request paths: %w", err)
 	}
+	c := *r
+	c.Paths = sealed
+	if r.RequesterSignature == nil {
+		return &c, nil
+	}
+	if privateKey == nil {
+		return nil, fmt.Errorf("no private key to sign the sealed request with")
+	}
+	if c.RequesterSignature, err = c.SignData(c.RequesterKeyID).Sign(privateKey); err != nil {
+		return nil, fmt.Errorf("failed to sign sealed request: %w", err)
+	}
+	return &c, nil
+}
 
-	var requests []*RestoreRequest
-	for _, entry := range entries {
-		if filepath.Ext(entry.Name()) != ".json" {
-			continue
-		}
+// CreateBrowseRequest creates a request to list a snapshot's contents
+// without restoring it, so the owner can check the data exists first
+func (m *Manager) CreateBrowseRequest(requester, snapshotID, reason string) (*RestoreRequest, error) {
+	return m.WithPurpose(PurposeBrowse).CreateRequest(requester, snapshotID, reason, nil)
+}
 
-		id := entry.Name()[:len(entry.Name())-5]
-		req, err := m.GetRequest(id)
-		if err != nil {
-			continue
-		}
+// IsBrowse returns true if the request is for browsing a snapshot
+func (r *RestoreRequest) IsBrowse() bool { return r.Purpose == PurposeBrowse }
 
-		if req.Status == StatusPending {
-			requests = append(requests, req)
+// checkScope checks a new request asks for no more than its purpose
+// allows: a browse lists a whole snapshot, and a key export names none
+func (r *RestoreRequest) checkScope() error {
+	switch r.Purpose {
+	case PurposeBrowse:
+		if len(r.Paths) > 0 {
+			return apperrors.New(apperrors.CodeInvalidArgument, "a browse request lists a whole snapshot and takes no paths")
+		}
+	case PurposeExportKeys:
+		if r.SnapshotID != "" || len(r.Paths) > 0 {
+			return apperrors.New(apperrors.CodeInvalidArgument, "a key export names no snapshot or paths")
 		}
 	}
-
-	return requests, nil
+	return nil
 }
 
-// Approve approves a request and attaches the share data
-func (m *Manager) Approve(id, approver string, shareData []byte) error {
+// BrowseAccess returns an approved browse request whose cooling-off period
+// is over and whose browse window is still open. Once the window closes the request expires and the released
+// share is discarded.
+func (m *Manager) BrowseAccess(id string) (*RestoreRequest, error) {
 	req, err := m.GetRequest(id)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	if req.Status != StatusPending {
-		return apperrors.ErrRequestNotPending
+	if !req.IsBrowse() {
+		return nil, apperrors.ErrWrongRequestPurpose
+	}
+	if err := req.CheckExecutable(time.Now()); err != nil {
+		return nil, err
+	}
+	if err := m.CheckLockdown(); err != nil {
+		return nil, err
 	}
 
 	if time.Now().After(req.ExpiresAt) {
 		req.Status = StatusExpired
+		req.ShareData = nil
 		if err := m.saveRequest(req); err != nil {
 			logging.Warn("Failed to save expired request", logging.Err(err))
 		}
-		return apperrors.ErrRequestExpired
+		return nil, apperrors.ErrRequestExpired
 	}
+	return req, nil
+}
 
+// markApproved approves req and starts its cooling-off period, if any.
+// Browse requests' browse window opens once it ends.
+func (m *Manager) markApproved(req *RestoreRequest, approver string) {
 	now := time.Now()
 	req.Status = StatusApproved
 	req.ApprovedAt = &now
 	req.ApprovedBy = approver
-	req.ShareData = shareData
-
-	return m.saveRequest(req)
-}
-
-// Deny denies a request
-func (m *Manager) Deny(id, denier string) error {
-	req, err := m.GetRequest(id)
-	if err != nil {
-		return err
+	executableAt := now
+	if m.coolingOff > 0 {
+		executableAt = now.Add(m.coolingOff)
+		req.ExecutableAt = &executableAt
 	}
-
-	if req.Status != StatusPending {
-		return apperrors.ErrRequestNotPending
+	if req.IsBrowse() {
+		req.ExpiresAt = executableAt.Add(BrowseWindow)
 	}
+}
 
-	req.Status = StatusDenied
-	now := time.Now()
-	req.ApprovedAt = &now
-	req.ApprovedBy = denier
-
-	return m.saveRequest(req)
+// Restores is the restore request type, covering browse requests and key
+// exports too. Its requests are created and approved with the
+// RestoreRequestService RPCs, approvers signing RestoreRequest.SignData.
+var Restores = registerType(&RequestType[*RestoreRequest]{
+	Name:        TypeRestore,
+	Description: "Restore, browse or export the keys of the repository",
+	TTL:         DefaultRequestTTL,
+	Scope:       "airgapper.restore",
+	dir:         func(m *Manager) string { return m.dataDir },
+	newRecord:   func() *RestoreRequest { return &RestoreRequest{} },
+	expire: func(m *Manager) func(*RequestStore[*RestoreRequest], *RestoreRequest) {
+		return m.expireRestore
+	},
+	prepare: func(m *Manager, req *RestoreRequest, approval Approval) error {
+		if approval.Nonce != "" {
+			if err := req.consumeChallenge(approval.KeyHolderID, approval.Nonce); err != nil {
+				return err
+			}
+		}
+		m.attachTerms(req, approval.name())
+		return nil
+	},
+	markApproved: (*Manager).markApproved,
+	// A released share is only handed to the requester
+	view: func(req *RestoreRequest) *RestoreRequest {
+		c := *req
+		c.ShareData = nil
+		return &c
+	},
+	noun:     "the restore request",
+	created:  events.RequestCreated,
+	approved: events.RequestApproved,
+	denied:   events.RequestDenied,
+	via:      "the RestoreRequestService RPCs",
+})
+
+// restores is the store of restore requests
+func (m *Manager) restores() *RequestStore[*RestoreRequest] {
+	return Restores.store(m)
 }
 
-func (m *Manager) saveRequest(req *RestoreRequest) error {
-	if err := os.MkdirAll(m.dataDir, 0700); err != nil {
-		return err
+// expireRestore expires req like expireWith, and also closes an approved
+// browse request once its browse window has passed, discarding the share
+// it released. Every node holding the request does so, so a browse
+// approval can't outlive its window anywhere.
+func (m *Manager) expireRestore(s *RequestStore[*RestoreRequest], req *RestoreRequest) {
+	if req.IsBrowse() && req.Status == StatusApproved && m.expired(req.ExpiresAt) {
+		req.Status = StatusExpired
+		req.ShareData = nil
+		if err := s.Save(req); err != nil {
+			logging.Warn("Failed to save expired request", logging.Err(err))
+		}
+		return
 	}
+	expireWith[*RestoreRequest](m)(s, req)
+}
+
+// GetRequest retrieves a request by ID
+func (m *Manager) GetRequest(id string) (*RestoreRequest, error) {
+	return m.restores().Get(id)
+}
 
-	data, err := json.MarshalIndent(req, "", "  ")
+// ListPending returns all pending requests, and approved ones still
+// cooling off, which can be vetoed
+func (m *Manager) ListPending() ([]*RestoreRequest, error) {
+	all, err := m.restores().List()
 	if err != nil {
-		return err
+		return nil, err
 	}
+	var requests []*RestoreRequest
+	for _, req := range all {
+		if req.Status == StatusPending || req.CoolingOffLeft(time.Now()) > 0 {
+			requests = append(requests, req)
+		}
+	}
+	return requests, nil
+}
 
-	path := filepath.Join(m.dataDir, req.ID+".json")
-	return os.WriteFile(path, data, 0600)
+// Approve approves a request and attaches the share data
+func (m *Manager) Approve(id, approver string, shareData []byte) error {
+	_, err := Restores.Approve(m, id, approver, func(req *RestoreRequest) {
+		m.attachTerms(req, approver)
+		req.ShareData = shareData
+	})
+	return err
+}
+
+func (m *Manager) saveRequest(req *RestoreRequest) error {
+	return m.restores().Save(req)
 }
 
 // CreateRequestWithConsensus creates a new restore request with consensus requirements
 func (m *Manager) CreateRequestWithConsensus(requester, snapshotID, reason string, paths []string, requiredApprovals int) (*RestoreRequest, error) {
-	// Generate unique ID
-	idBytes := make([]byte, 8)
-	if _, err := rand.Read(idBytes); err != nil {
+	req, err := m.newRequest(requester, snapshotID, reason, paths)
+	if err != nil {
 		return nil, err
 	}
+	req.RequiredApprovals = requiredApprovals
+	req.Approvals = []Approval{}
 
-	req := &RestoreRequest{
-		ID:                hex.EncodeToString(idBytes),
-		Requester:         requester,
-		SnapshotID:        snapshotID,
-		Paths:             paths,
-		Reason:            reason,
-		Status:            StatusPending,
-		CreatedAt:         time.Now(),
-		ExpiresAt:         time.Now().Add(24 * time.Hour),
-		RequiredApprovals: requiredApprovals,
-		Approvals:         []Approval{},
-	}
-
-	if err := m.saveRequest(req); err != nil {
+	if err := Restores.add(m, req, requestCreatedMessage(req)); err != nil {
 		return nil, err
 	}
-
 	return req, nil
 }
 
-// AddSignature adds a cryptographic signature/approval to a request
-func (m *Manager) AddSignature(id, keyHolderID, keyHolderName string, signature []byte) error {
-	req, err := m.GetRequest(id)
+// CreateRequestWithQuorum creates a new restore request approved once the
+// quorum rule is met
+func (m *Manager) CreateRequestWithQuorum(requester, snapshotID, reason string, paths []string, quorum *Quorum) (*RestoreRequest, error) {
+	req, err := m.CreateRequestWithConsensus(requester, snapshotID, reason, paths, quorum.MinWeight)
 	if err != nil {
-		return err
-	}
-
-	if req.Status != StatusPending {
-		return apperrors.ErrRequestNotPending
-	}
-
-	if time.Now().After(req.ExpiresAt) {
-		req.Status = StatusExpired
-		if err := m.saveRequest(req); err != nil {
-			logging.Warn("Failed to save expired request", logging.Err(err))
-		}
-		return apperrors.ErrRequestExpired
+		return nil, err
 	}
 
-	// Check if this key holder already approved
-	for _, approval := range req.Approvals {
-		if approval.KeyHolderID == keyHolderID {
-			return apperrors.ErrAlreadyApproved
-		}
+	req.Quorum = quorum
+	if err := m.saveRequest(req); err != nil {
+		return nil, err
 	}
+	return req, nil
+}
 
-	// Add the approval
-	approval := Approval{
+// AddSignature adds a cryptographic signature/approval to a request. A
+// non-empty nonce must be a live challenge issued to the key holder, and is
+// used up. A non-zero signedAt records when the signature says it was made.
+func (m *Manager) AddSignature(id, keyHolderID, keyHolderName, nonce string, signedAt time.Time, signature []byte) error {
+	return m.addApproval(id, Approval{
 		KeyHolderID:   keyHolderID,
 		KeyHolderName: keyHolderName,
 		Signature:     signature,
 		ApprovedAt:    time.Now(),
-	}
-	req.Approvals = append(req.Approvals, approval)
+		Nonce:         nonce,
+		SignedAt:      signedTime(signedAt),
+	})
+}
 
-	// Check if we have enough approvals
-	if len(req.Approvals) >= req.RequiredApprovals {
-		now := time.Now()
-		req.Status = StatusApproved
-		req.ApprovedAt = &now
-		req.ApprovedBy = "consensus"
+// signedTime returns a pointer to t, or nil if t is zero
+func signedTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
 	}
-
-	return m.saveRequest(req)
+	return &t
 }
 
-// HasEnoughApprovals checks if a request has sufficient approvals
-func (m *Manager) HasEnoughApprovals(id string) (bool, error) {
-	req, err := m.GetRequest(id)
-	if err != nil {
-		return false, err
-	}
-	return len(req.Approvals) >= req.RequiredApprovals, nil
+// addApproval records an approval on a pending request and marks it
+// approved once enough approvals count
+func (m *Manager) addApproval(id string, approval Approval) error {
+	_, err := Restores.AddApproval(m, id, approval)
+	return err
 }
 
-// GetApprovalProgress returns current approvals and required count
+// GetApprovalProgress returns current approvals and required count. Under a
+// quorum these are approval weights, and current stays below required while
+// a mandatory key holder has yet to approve.
 func (m *Manager) GetApprovalProgress(id string) (current int, required int, err error) {
-	req, err := m.GetRequest(id)
+	progress, err := Restores.Progress(m, id)
 	if err != nil {
 		return 0, 0, err
 	}
-	return len(req.Approvals), req.RequiredApprovals, nil
+	current, required = progress.Counts()
+	return current, required, nil
+}
+
+// GetQuorumProgress returns a request's progress toward its quorum,
+// including the mandatory key holders who haven't approved
+func (m *Manager) GetQuorumProgress(id string) (QuorumProgress, error) {
+	return Restores.Progress(m, id)
 }
 
 // ============================================================================
@@ -336,8 +801,28 @@ func (m *Manager) GetApprovalProgress(id string) (current int, required int, err
 // ============================================================================
 
 // CreateDeletionRequest creates a new deletion request
-// Deletion requests have a longer expiry (7 days) than restore requests
+// Deletion requests have a longer expiry (Deletions.TTL) than restore requests
 func (m *Manager) CreateDeletionRequest(requester string, deletionType DeletionType, snapshotIDs, paths []string, reason string, requiredApprovals int) (*DeletionRequest, error) {
+	return m.createDeletion(requester, deletionType, snapshotIDs, paths, reason, requiredApprovals, nil)
+}
+
+// CreateRetentionDeletion creates a prune deletion request forgetting what
+// plan removes, with plan attached for approvers to see
+func (m *Manager) CreateRetentionDeletion(requester string, plan *RetentionPlan, reason string, requiredApprovals int) (*DeletionRequest, error) {
+	if len(plan.Remove) == 0 {
+		return nil, fmt.Errorf("the retention policy (%s) removes no snapshots", plan.Policy)
+	}
+	return m.createDeletion(requester, DeletionTypePrune, plan.RemoveIDs(), nil, reason, requiredApprovals, plan)
+}
+
+func (m *Manager) createDeletion(requester string, deletionType DeletionType, snapshotIDs, paths []string, reason string, requiredApprovals int, plan *RetentionPlan) (*DeletionRequest, error) {
+	if err := m.CheckLockdown(); err != nil {
+		return nil, err
+	}
+	if m.deletionQuorum != nil {
+		requiredApprovals = m.deletionQuorum.MinWeight
+	}
+
 	idBytes := make([]byte, 8)
 	if _, err := rand.Read(idBytes); err != nil {
 		return nil, err
@@ -352,139 +837,50 @@ func (m *Manager) CreateDeletionRequest(requester string, deletionType DeletionT
 		Reason:            reason,
 		Status:            StatusPending,
 		CreatedAt:         time.Now(),
-		ExpiresAt:         time.Now().Add(7 * 24 * time.Hour), // 7 day expiry
+		ExpiresAt:         time.Now().Add(Deletions.TTL),
 		RequiredApprovals: requiredApprovals,
 		Approvals:         []Approval{},
+		CorrelationID:     m.newCorrelationID(),
+		Retention:         plan,
+		Quorum:            m.deletionQuorum,
 	}
 
-	if err := m.saveDeletionRequest(req); err != nil {
+	if err := Deletions.add(m, req, fmt.Sprintf("%s requested a deletion (%s)", requester, deletionType)); err != nil {
 		return nil, err
 	}
-
 	return req, nil
 }
 
+// Deletions is the deletion request type. Its requests are created and
+// approved with the DeletionService RPCs, approvers signing
+// crypto.DeletionRequestSignData.
+var Deletions = registerType(&RequestType[*DeletionRequest]{
+	Name:        TypeDeletion,
+	Description: "Delete snapshots, paths or the whole repository",
+	TTL:         7 * 24 * time.Hour,
+	Scope:       "airgapper.deletion",
+	dir:         func(m *Manager) string { return m.deletionDataDir },
+	newRecord:   func() *DeletionRequest { return &DeletionRequest{} },
+	noun:        "the deletion request",
+	created:     events.DeletionCreated,
+	approved:    events.DeletionApproved,
+	denied:      events.DeletionDenied,
+	via:         "the DeletionService RPCs",
+})
+
+// deletions is the store of deletion requests
+func (m *Manager) deletions() *RequestStore[*DeletionRequest] {
+	return Deletions.store(m)
+}
+
 // GetDeletionRequest retrieves a deletion request by ID
 func (m *Manager) GetDeletionRequest(id string) (*DeletionRequest, error) {
-	path := filepath.Join(m.deletionDataDir, id+".json")
-	data, err := os.ReadFile(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, apperrors.ErrRequestNotFound
-		}
-		return nil, err
-	}
-
-	var req DeletionRequest
-	if err := json.Unmarshal(data, &req); err != nil {
-		return nil, err
-	}
-
-	// Check expiry
-	if req.Status == StatusPending && time.Now().After(req.ExpiresAt) {
-		req.Status = StatusExpired
-		if err := m.saveDeletionRequest(&req); err != nil {
-			logging.Warn("Failed to save expired deletion request", logging.Err(err))
-		}
-	}
-
-	return &req, nil
+	return m.deletions().Get(id)
 }
 
 // ListPendingDeletions returns all pending deletion requests
 func (m *Manager) ListPendingDeletions() ([]*DeletionRequest, error) {
-	if err := os.MkdirAll(m.deletionDataDir, 0700); err != nil {
-		return nil, err
-	}
-
-	entries, err := os.ReadDir(m.deletionDataDir)
-	if err != nil {
-		return nil, err
-	}
-
-	var requests []*DeletionRequest
-	for _, entry := range entries {
-		if filepath.Ext(entry.Name()) != ".json" {
-			continue
-		}
-
-		id := entry.Name()[:len(entry.Name())-5]
-		req, err := m.GetDeletionRequest(id)
-		if err != nil {
-			continue
-		}
-
-		if req.Status == StatusPending {
-			requests = append(requests, req)
-		}
-	}
-
-	return requests, nil
-}
-
-// ApproveDeletion approves a deletion request with a signature
-func (m *Manager) ApproveDeletion(id, keyHolderID, keyHolderName string, signature []byte) error {
-	req, err := m.GetDeletionRequest(id)
-	if err != nil {
-		return err
-	}
-
-	if req.Status != StatusPending {
-		return apperrors.ErrRequestNotPending
-	}
-
-	if time.Now().After(req.ExpiresAt) {
-		req.Status = StatusExpired
-		if err := m.saveDeletionRequest(req); err != nil {
-			logging.Warn("Failed to save expired deletion request", logging.Err(err))
-		}
-		return apperrors.ErrRequestExpired
-	}
-
-	// Check if this key holder already approved
-	for _, approval := range req.Approvals {
-		if approval.KeyHolderID == keyHolderID {
-			return apperrors.ErrAlreadyApproved
-		}
-	}
-
-	// Add the approval
-	approval := Approval{
-		KeyHolderID:   keyHolderID,
-		KeyHolderName: keyHolderName,
-		Signature:     signature,
-		ApprovedAt:    time.Now(),
-	}
-	req.Approvals = append(req.Approvals, approval)
-
-	// Check if we have enough approvals
-	if len(req.Approvals) >= req.RequiredApprovals {
-		now := time.Now()
-		req.Status = StatusApproved
-		req.ApprovedAt = &now
-		req.ApprovedBy = "consensus"
-	}
-
-	return m.saveDeletionRequest(req)
-}
-
-// DenyDeletion denies a deletion request
-func (m *Manager) DenyDeletion(id, denier string) error {
-	req, err := m.GetDeletionRequest(id)
-	if err != nil {
-		return err
-	}
-
-	if req.Status != StatusPending {
-		return apperrors.ErrRequestNotPending
-	}
-
-	req.Status = StatusDenied
-	now := time.Now()
-	req.ApprovedAt = &now
-	req.ApprovedBy = denier
-
-	return m.saveDeletionRequest(req)
+	return m.deletions().ListPending()
 }
 
 // MarkDeletionExecuted marks a deletion request as executed
@@ -501,28 +897,13 @@ func (m *Manager) MarkDeletionExecuted(id string) error {
 	now := time.Now()
 	req.ExecutedAt = &now
 
-	return m.saveDeletionRequest(req)
-}
-
-// GetDeletionApprovalProgress returns current approvals and required count for a deletion
-func (m *Manager) GetDeletionApprovalProgress(id string) (current int, required int, err error) {
-	req, err := m.GetDeletionRequest(id)
-	if err != nil {
-		return 0, 0, err
+	if err := m.saveDeletionRequest(req); err != nil {
+		return err
 	}
-	return len(req.Approvals), req.RequiredApprovals, nil
+	m.publish(events.DeletionExecuted, req, "The deletion was carried out")
+	return nil
 }
 
 func (m *Manager) saveDeletionRequest(req *DeletionRequest) error {
-	if err := os.MkdirAll(m.deletionDataDir, 0700); err != nil {
-		return err
-	}
-
-	data, err := json.MarshalIndent(req, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	path := filepath.Join(m.deletionDataDir, req.ID+".json")
-	return os.WriteFile(path, data, 0600)
+	return m.deletions().Save(req)
 }
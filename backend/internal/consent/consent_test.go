@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
 	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -58,13 +59,88 @@ func TestRestoreRequest(t *testing.T) {
 	assert.Equal(t, "secret share", string(got.ShareData))
 }
 
+func TestRestoreRequestCorrelationID(t *testing.T) {
+	m := NewManager(t.TempDir())
+
+	first, err := m.CreateRequest("alice", "latest", "one", nil)
+	require.NoError(t, err)
+	second, err := m.CreateRequestWithConsensus("alice", "latest", "two", nil, 2)
+	require.NoError(t, err)
+	assert.NotEmpty(t, first.CorrelationID)
+	assert.NotEqual(t, first.CorrelationID, second.CorrelationID, "each operation gets its own ID")
+
+	adopted, err := m.WithCorrelationID("from-api").CreateDeletionRequest("alice", DeletionTypePrune, nil, nil, "tidy", 2)
+	require.NoError(t, err)
+	got, err := m.GetDeletionRequest(adopted.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "from-api", got.CorrelationID)
+}
+
+func TestRestoreRequestRequesterSignature(t *testing.T) {
+	m := NewManager(t.TempDir())
+	pub, priv, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+	keyID := crypto.KeyID(pub)
+
+	t.Run("signed on creation", func(t *testing.T) {
+		req, err := m.WithSigningKey(keyID, priv).CreateRequest("alice", "latest", "signed", []string{"/docs"})
+		require.NoError(t, err)
+		assert.Equal(t, keyID, req.RequesterKeyID)
+
+		stored, err := m.GetRequest(req.ID)
+		require.NoError(t, err)
+		valid, err := stored.SignData(keyID).Verify(pub, stored.RequesterSignature)
+		require.NoError(t, err)
+		assert.True(t, valid)
+	})
+
+	t.Run("requester's ID and signature", func(t *testing.T) {
+		id, err := NewRequestID()
+		require.NoError(t, err)
+		sig := &RequesterSignature{RequestID: id, CreatedAt: time.Now().Add(-time.Minute), KeyHolderID: keyID, Signature: []byte("sig")}
+
+		req, err := m.WithRequesterSignature(sig).CreateRequest("alice", "latest", "remote", nil)
+		require.NoError(t, err)
+		assert.Equal(t, id, req.ID)
+		assert.Equal(t, sig.CreatedAt, req.CreatedAt)
+		assert.Equal(t, sig.Signature, req.RequesterSignature)
+
+		_, err = m.WithRequesterSignature(sig).CreateRequest("alice", "latest", "remote", nil)
+		assert.ErrorIs(t, err, apperrors.ErrRequestExists, "IDs can't be reused")
+
+		sig.RequestID = "../../config"
+		_, err = m.WithRequesterSignature(sig).CreateRequest("alice", "latest", "remote", nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("signed as peers verify it", func(t *testing.T) {
+		// An unset snapshot is signed as the "latest" peers default it to
+		req, err := m.WithSigningKey(keyID, priv).CreateRequest("alice", "", "whatever is newest", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "latest", req.SignData(keyID).SnapshotID)
+
+		// Purpose and template are signed, so a peer can't drop them
+		export, err := m.WithSigningKey(keyID, priv).CreateKeyExportRequest("alice", "leaving")
+		require.NoError(t, err)
+		assert.Empty(t, export.SnapshotID)
+		restore := *export
+		restore.Purpose = ""
+		valid, err := restore.SignData(keyID).Verify(pub, export.RequesterSignature)
+		require.NoError(t, err)
+		assert.False(t, valid)
+
+		_, err = m.WithPurpose("format-disk").CreateRequest("alice", "latest", "?", nil)
+		assert.Error(t, err)
+	})
+}
+
 func TestRestoreRequestDeny(t *testing.T) {
 	tmpDir := t.TempDir()
 	m := NewManager(tmpDir)
 
 	req, _ := m.CreateRequest("alice", "latest", "need files", nil)
 
-	require.NoError(t, m.Deny(req.ID, "bob"), "Deny failed")
+	require.NoError(t, Restores.Deny(m, req.ID, "bob"), "Deny failed")
 
 	got, _ := m.GetRequest(req.ID)
 	assert.Equal(t, StatusDenied, got.Status)
@@ -114,10 +190,10 @@ func TestRestoreRequestDenyNotPending(t *testing.T) {
 	req, _ := m.CreateRequest("alice", "latest", "need files", nil)
 
 	// Deny first
-	require.NoError(t, m.Deny(req.ID, "bob"))
+	require.NoError(t, Restores.Deny(m, req.ID, "bob"))
 
 	// Try to deny again
-	err := m.Deny(req.ID, "charlie")
+	err := Restores.Deny(m, req.ID, "charlie")
 	assert.ErrorIs(t, err, apperrors.ErrRequestNotPending)
 }
 
@@ -133,7 +209,7 @@ func TestRestoreRequestDenyNotFound(t *testing.T) {
 	tmpDir := t.TempDir()
 	m := NewManager(tmpDir)
 
-	err := m.Deny("nonexistent", "bob")
+	err := Restores.Deny(m, "nonexistent", "bob")
 	assert.ErrorIs(t, err, apperrors.ErrRequestNotFound)
 }
 
@@ -180,6 +256,25 @@ func TestRestoreRequestExpirationOnGet(t *testing.T) {
 	assert.Equal(t, StatusExpired, got.Status)
 }
 
+func TestExpiryAllowsForClockSkew(t *testing.T) {
+	m := NewManager(t.TempDir())
+	assert.Equal(t, DefaultClockSkewTolerance, m.ClockSkewTolerance())
+
+	req, err := m.CreateRequest("alice", "latest", "need files", nil)
+	require.NoError(t, err)
+	req.ExpiresAt = time.Now().Add(-time.Minute)
+	require.NoError(t, m.saveRequest(req))
+
+	// Within the tolerance the request is still pending
+	got, err := m.GetRequest(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, got.Status)
+
+	got, err = m.WithClockSkewTolerance(30 * time.Second).GetRequest(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusExpired, got.Status)
+}
+
 func TestListPendingWithEmptyDirectory(t *testing.T) {
 	tmpDir := t.TempDir()
 	m := NewManager(tmpDir)
@@ -201,7 +296,7 @@ func TestListPendingFiltersNonPending(t *testing.T) {
 
 	// Approve one, deny another
 	require.NoError(t, m.Approve(req1.ID, "approver", []byte("share")))
-	require.NoError(t, m.Deny(req2.ID, "denier"))
+	require.NoError(t, Restores.Deny(m, req2.ID, "denier"))
 
 	// Only req3 should be pending
 	pending, err := m.ListPending()
@@ -268,6 +363,25 @@ func TestCreateRequestWithConsensus(t *testing.T) {
 	assert.Empty(t, req.Approvals)
 }
 
+func TestCreateKeyExportRequest(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := NewManager(tmpDir)
+
+	req, err := m.CreateKeyExportRequest("alice", "leaving airgapper")
+	require.NoError(t, err)
+	assert.True(t, req.IsKeyExport())
+	assert.Empty(t, req.SnapshotID)
+
+	// Purpose survives a reload
+	loaded, err := m.GetRequest(req.ID)
+	require.NoError(t, err)
+	assert.True(t, loaded.IsKeyExport())
+
+	restore, err := m.CreateRequest("alice", "latest", "restore", nil)
+	require.NoError(t, err)
+	assert.False(t, restore.IsKeyExport())
+}
+
 func TestAddSignature(t *testing.T) {
 	tmpDir := t.TempDir()
 	m := NewManager(tmpDir)
@@ -275,7 +389,7 @@ func TestAddSignature(t *testing.T) {
 	req, _ := m.CreateRequestWithConsensus("alice", "latest", "reason", nil, 2)
 
 	// Add first signature
-	err := m.AddSignature(req.ID, "key1", "Alice", []byte("sig1"))
+	err := m.AddSignature(req.ID, "key1", "Alice", "", time.Time{}, []byte("sig1"))
 	require.NoError(t, err)
 
 	got, _ := m.GetRequest(req.ID)
@@ -286,7 +400,7 @@ func TestAddSignature(t *testing.T) {
 	assert.Equal(t, StatusPending, got.Status) // Still pending with 1/2
 
 	// Add second signature
-	err = m.AddSignature(req.ID, "key2", "Bob", []byte("sig2"))
+	err = m.AddSignature(req.ID, "key2", "Bob", "", time.Time{}, []byte("sig2"))
 	require.NoError(t, err)
 
 	got, _ = m.GetRequest(req.ID)
@@ -303,10 +417,10 @@ func TestAddSignatureDuplicate(t *testing.T) {
 	req, _ := m.CreateRequestWithConsensus("alice", "latest", "reason", nil, 2)
 
 	// Add first signature
-	require.NoError(t, m.AddSignature(req.ID, "key1", "Alice", []byte("sig1")))
+	require.NoError(t, m.AddSignature(req.ID, "key1", "Alice", "", time.Time{}, []byte("sig1")))
 
 	// Try to add duplicate signature
-	err := m.AddSignature(req.ID, "key1", "Alice", []byte("sig2"))
+	err := m.AddSignature(req.ID, "key1", "Alice", "", time.Time{}, []byte("sig2"))
 	assert.ErrorIs(t, err, apperrors.ErrAlreadyApproved)
 }
 
@@ -314,7 +428,7 @@ func TestAddSignatureNotFound(t *testing.T) {
 	tmpDir := t.TempDir()
 	m := NewManager(tmpDir)
 
-	err := m.AddSignature("nonexistent", "key1", "Alice", []byte("sig"))
+	err := m.AddSignature("nonexistent", "key1", "Alice", "", time.Time{}, []byte("sig"))
 	assert.ErrorIs(t, err, apperrors.ErrRequestNotFound)
 }
 
@@ -325,10 +439,10 @@ func TestAddSignatureNotPending(t *testing.T) {
 	req, _ := m.CreateRequestWithConsensus("alice", "latest", "reason", nil, 1)
 
 	// Approve with first signature
-	require.NoError(t, m.AddSignature(req.ID, "key1", "Alice", []byte("sig1")))
+	require.NoError(t, m.AddSignature(req.ID, "key1", "Alice", "", time.Time{}, []byte("sig1")))
 
 	// Try to add another signature to already approved request
-	err := m.AddSignature(req.ID, "key2", "Bob", []byte("sig2"))
+	err := m.AddSignature(req.ID, "key2", "Bob", "", time.Time{}, []byte("sig2"))
 	assert.ErrorIs(t, err, apperrors.ErrRequestNotPending)
 }
 
@@ -344,43 +458,43 @@ func TestAddSignatureExpired(t *testing.T) {
 
 	// Try to add signature - either ErrRequestExpired or ErrRequestNotPending
 	// depending on timing (GetRequest marks as expired before AddSignature checks)
-	err := m.AddSignature(req.ID, "key1", "Alice", []byte("sig"))
+	err := m.AddSignature(req.ID, "key1", "Alice", "", time.Time{}, []byte("sig"))
 	assert.Error(t, err)
 	assert.True(t, err == apperrors.ErrRequestExpired || err == apperrors.ErrRequestNotPending,
 		"expected ErrRequestExpired or ErrRequestNotPending, got: %v", err)
 }
 
-func TestHasEnoughApprovals(t *testing.T) {
+func TestQuorumProgressSatisfied(t *testing.T) {
 	tmpDir := t.TempDir()
 	m := NewManager(tmpDir)
 
 	req, _ := m.CreateRequestWithConsensus("alice", "latest", "reason", nil, 2)
 
 	// Initially not enough
-	enough, err := m.HasEnoughApprovals(req.ID)
+	progress, err := m.GetQuorumProgress(req.ID)
 	require.NoError(t, err)
-	assert.False(t, enough)
+	assert.False(t, progress.Satisfied())
 
 	// Add one signature
-	require.NoError(t, m.AddSignature(req.ID, "key1", "Alice", []byte("sig1")))
+	require.NoError(t, m.AddSignature(req.ID, "key1", "Alice", "", time.Time{}, []byte("sig1")))
 
-	enough, err = m.HasEnoughApprovals(req.ID)
+	progress, err = m.GetQuorumProgress(req.ID)
 	require.NoError(t, err)
-	assert.False(t, enough)
+	assert.False(t, progress.Satisfied())
 
 	// Add second signature
-	require.NoError(t, m.AddSignature(req.ID, "key2", "Bob", []byte("sig2")))
+	require.NoError(t, m.AddSignature(req.ID, "key2", "Bob", "", time.Time{}, []byte("sig2")))
 
-	enough, err = m.HasEnoughApprovals(req.ID)
+	progress, err = m.GetQuorumProgress(req.ID)
 	require.NoError(t, err)
-	assert.True(t, enough)
+	assert.True(t, progress.Satisfied())
 }
 
-func TestHasEnoughApprovalsNotFound(t *testing.T) {
+func TestQuorumProgressNotFound(t *testing.T) {
 	tmpDir := t.TempDir()
 	m := NewManager(tmpDir)
 
-	_, err := m.HasEnoughApprovals("nonexistent")
+	_, err := m.GetQuorumProgress("nonexistent")
 	assert.ErrorIs(t, err, apperrors.ErrRequestNotFound)
 }
 
@@ -396,7 +510,7 @@ func TestGetApprovalProgress(t *testing.T) {
 	assert.Equal(t, 3, required)
 
 	// Add a signature
-	require.NoError(t, m.AddSignature(req.ID, "key1", "Alice", []byte("sig1")))
+	require.NoError(t, m.AddSignature(req.ID, "key1", "Alice", "", time.Time{}, []byte("sig1")))
 
 	current, required, err = m.GetApprovalProgress(req.ID)
 	require.NoError(t, err)
@@ -416,6 +530,17 @@ func TestGetApprovalProgressNotFound(t *testing.T) {
 // Deletion Request Tests
 // ============================================================================
 
+// approveDeletion records key holder keyHolderID's approval of deletion id
+func approveDeletion(m *Manager, id, keyHolderID, keyHolderName string, signature []byte) error {
+	_, err := Deletions.AddApproval(m, id, Approval{
+		KeyHolderID:   keyHolderID,
+		KeyHolderName: keyHolderName,
+		Signature:     signature,
+		ApprovedAt:    time.Now(),
+	})
+	return err
+}
+
 func TestDeletionRequest(t *testing.T) {
 	tmpDir := t.TempDir()
 	m := NewManager(tmpDir)
@@ -447,9 +572,10 @@ func TestDeletionRequest(t *testing.T) {
 
 	// First approval - not enough yet
 	sig1 := []byte("signature1")
-	require.NoError(t, m.ApproveDeletion(req.ID, "alice-key", "Alice", sig1), "First approval failed")
+	require.NoError(t, approveDeletion(m, req.ID, "alice-key", "Alice", sig1), "First approval failed")
 
-	current, required, _ := m.GetDeletionApprovalProgress(req.ID)
+	progress, _ := Deletions.Progress(m, req.ID)
+	current, required := progress.Counts()
 	assert.Equal(t, 1, current)
 	assert.Equal(t, 2, required)
 
@@ -458,7 +584,7 @@ func TestDeletionRequest(t *testing.T) {
 
 	// Second approval - should approve
 	sig2 := []byte("signature2")
-	require.NoError(t, m.ApproveDeletion(req.ID, "bob-key", "Bob", sig2), "Second approval failed")
+	require.NoError(t, approveDeletion(m, req.ID, "bob-key", "Bob", sig2), "Second approval failed")
 
 	got, _ = m.GetDeletionRequest(req.ID)
 	assert.Equal(t, StatusApproved, got.Status, "Should be approved with 2/2 approvals")
@@ -478,7 +604,7 @@ func TestDeletionRequestDeny(t *testing.T) {
 		1,
 	)
 
-	require.NoError(t, m.DenyDeletion(req.ID, "bob"), "DenyDeletion failed")
+	require.NoError(t, Deletions.Deny(m, req.ID, "bob"), "DenyDeletion failed")
 
 	got, _ := m.GetDeletionRequest(req.ID)
 	assert.Equal(t, StatusDenied, got.Status)
@@ -498,11 +624,11 @@ func TestDeletionDuplicateApproval(t *testing.T) {
 	)
 
 	// First approval
-	err := m.ApproveDeletion(req.ID, "alice-key", "Alice", []byte("sig"))
+	err := approveDeletion(m, req.ID, "alice-key", "Alice", []byte("sig"))
 	require.NoError(t, err, "first approval failed")
 
 	// Duplicate approval should fail
-	err = m.ApproveDeletion(req.ID, "alice-key", "Alice", []byte("sig2"))
+	err = approveDeletion(m, req.ID, "alice-key", "Alice", []byte("sig2"))
 	assert.ErrorIs(t, err, apperrors.ErrAlreadyApproved)
 }
 
@@ -524,7 +650,7 @@ func TestMarkDeletionExecuted(t *testing.T) {
 	assert.ErrorIs(t, err, apperrors.ErrRequestNotApproved)
 
 	// Approve
-	err = m.ApproveDeletion(req.ID, "alice-key", "Alice", []byte("sig"))
+	err = approveDeletion(m, req.ID, "alice-key", "Alice", []byte("sig"))
 	require.NoError(t, err, "approval failed")
 
 	// Now can mark as executed
@@ -555,6 +681,30 @@ func TestDeletionPersistence(t *testing.T) {
 	assert.Equal(t, DeletionTypeAll, got.DeletionType)
 }
 
+func TestRetentionDeletion(t *testing.T) {
+	m := NewManager(t.TempDir())
+	now := time.Now().Truncate(time.Second)
+	plan := &RetentionPlan{
+		Policy: "1 last",
+		Keep:   []PlannedSnapshot{{ID: "snap3", Time: now}},
+		Remove: []PlannedSnapshot{{ID: "snap1", Time: now.Add(-2 * time.Hour)}, {ID: "snap2", Time: now.Add(-time.Hour)}},
+	}
+
+	req, err := m.CreateRetentionDeletion("alice", plan, "apply retention", 1)
+	require.NoError(t, err)
+	assert.Equal(t, DeletionTypePrune, req.DeletionType)
+	assert.Equal(t, []string{"snap1", "snap2"}, req.SnapshotIDs, "exactly what approvers see is forgotten")
+
+	got, err := m.GetDeletionRequest(req.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.Retention)
+	assert.Equal(t, "1 last", got.Retention.Policy)
+	assert.Len(t, got.Retention.Remove, 2)
+
+	_, err = m.CreateRetentionDeletion("alice", &RetentionPlan{Policy: "1 last", Keep: plan.Keep}, "", 1)
+	assert.ErrorContains(t, err, "removes no snapshots")
+}
+
 func TestDeletionRequestNotFound(t *testing.T) {
 	tmpDir := t.TempDir()
 	m := NewManager(tmpDir)
@@ -575,7 +725,7 @@ func TestDeletionRequestExpiration(t *testing.T) {
 
 	// Approve should fail - either ErrRequestExpired or ErrRequestNotPending
 	// depending on timing (GetDeletionRequest marks as expired before ApproveDeletion checks)
-	err := m.ApproveDeletion(req.ID, "key1", "Alice", []byte("sig"))
+	err := approveDeletion(m, req.ID, "key1", "Alice", []byte("sig"))
 	assert.Error(t, err)
 	assert.True(t, err == apperrors.ErrRequestExpired || err == apperrors.ErrRequestNotPending,
 		"expected ErrRequestExpired or ErrRequestNotPending, got: %v", err)
@@ -604,10 +754,10 @@ func TestApproveDeletionNotPending(t *testing.T) {
 	req, _ := m.CreateDeletionRequest("alice", DeletionTypeSnapshot, []string{"snap1"}, nil, "reason", 1)
 
 	// Approve first
-	require.NoError(t, m.ApproveDeletion(req.ID, "key1", "Alice", []byte("sig")))
+	require.NoError(t, approveDeletion(m, req.ID, "key1", "Alice", []byte("sig")))
 
 	// Try to approve again
-	err := m.ApproveDeletion(req.ID, "key2", "Bob", []byte("sig2"))
+	err := approveDeletion(m, req.ID, "key2", "Bob", []byte("sig2"))
 	assert.ErrorIs(t, err, apperrors.ErrRequestNotPending)
 }
 
@@ -615,7 +765,7 @@ func TestApproveDeletionNotFound(t *testing.T) {
 	tmpDir := t.TempDir()
 	m := NewManager(tmpDir)
 
-	err := m.ApproveDeletion("nonexistent", "key1", "Alice", []byte("sig"))
+	err := approveDeletion(m, "nonexistent", "key1", "Alice", []byte("sig"))
 	assert.ErrorIs(t, err, apperrors.ErrRequestNotFound)
 }
 
@@ -626,10 +776,10 @@ func TestDenyDeletionNotPending(t *testing.T) {
 	req, _ := m.CreateDeletionRequest("alice", DeletionTypeSnapshot, []string{"snap1"}, nil, "reason", 1)
 
 	// Deny first
-	require.NoError(t, m.DenyDeletion(req.ID, "bob"))
+	require.NoError(t, Deletions.Deny(m, req.ID, "bob"))
 
 	// Try to deny again
-	err := m.DenyDeletion(req.ID, "charlie")
+	err := Deletions.Deny(m, req.ID, "charlie")
 	assert.ErrorIs(t, err, apperrors.ErrRequestNotPending)
 }
 
@@ -637,7 +787,7 @@ func TestDenyDeletionNotFound(t *testing.T) {
 	tmpDir := t.TempDir()
 	m := NewManager(tmpDir)
 
-	err := m.DenyDeletion("nonexistent", "bob")
+	err := Deletions.Deny(m, "nonexistent", "bob")
 	assert.ErrorIs(t, err, apperrors.ErrRequestNotFound)
 }
 
@@ -649,11 +799,11 @@ func TestMarkDeletionExecutedNotFound(t *testing.T) {
 	assert.ErrorIs(t, err, apperrors.ErrRequestNotFound)
 }
 
-func TestGetDeletionApprovalProgressNotFound(t *testing.T) {
+func TestDeletionProgressNotFound(t *testing.T) {
 	tmpDir := t.TempDir()
 	m := NewManager(tmpDir)
 
-	_, _, err := m.GetDeletionApprovalProgress("nonexistent")
+	_, err := Deletions.Progress(m, "nonexistent")
 	assert.ErrorIs(t, err, apperrors.ErrRequestNotFound)
 }
 
@@ -676,8 +826,8 @@ func TestListPendingDeletionsFiltersNonPending(t *testing.T) {
 	req3, _ := m.CreateDeletionRequest("charlie", DeletionTypePrune, nil, nil, "reason3", 1)
 
 	// Approve one, deny another
-	require.NoError(t, m.ApproveDeletion(req1.ID, "key1", "Key1", []byte("sig")))
-	require.NoError(t, m.DenyDeletion(req2.ID, "denier"))
+	require.NoError(t, approveDeletion(m, req1.ID, "key1", "Key1", []byte("sig")))
+	require.NoError(t, Deletions.Deny(m, req2.ID, "denier"))
 
 	// Only req3 should be pending
 	pending, err := m.ListPendingDeletions()
@@ -894,192 +1044,6 @@ func TestRequestStoreListPending(t *testing.T) {
 	assert.Len(t, pending, 2)
 }
 
-func TestRequestStoreAddApproval(t *testing.T) {
-	tmpDir := t.TempDir()
-	store := NewRequestStore[*RestoreRequest](
-		tmpDir,
-		func() *RestoreRequest { return &RestoreRequest{} },
-		nil,
-	)
-
-	req := &RestoreRequest{
-		ID:                "req1",
-		Status:            StatusPending,
-		ExpiresAt:         time.Now().Add(time.Hour),
-		RequiredApprovals: 2,
-		Approvals:         []Approval{},
-	}
-	require.NoError(t, store.Save(req))
-
-	// Add first approval
-	err := store.AddApproval("req1", "key1", "Alice", []byte("sig1"))
-	require.NoError(t, err)
-
-	got, _ := store.Get("req1")
-	assert.Len(t, got.Approvals, 1)
-	assert.Equal(t, StatusPending, got.Status)
-
-	// Add second approval - should approve
-	err = store.AddApproval("req1", "key2", "Bob", []byte("sig2"))
-	require.NoError(t, err)
-
-	got, _ = store.Get("req1")
-	assert.Len(t, got.Approvals, 2)
-	assert.Equal(t, StatusApproved, got.Status)
-}
-
-func TestRequestStoreAddApprovalNotPending(t *testing.T) {
-	tmpDir := t.TempDir()
-	store := NewRequestStore[*RestoreRequest](
-		tmpDir,
-		func() *RestoreRequest { return &RestoreRequest{} },
-		nil,
-	)
-
-	req := &RestoreRequest{
-		ID:        "req1",
-		Status:    StatusApproved,
-		ExpiresAt: time.Now().Add(time.Hour),
-	}
-	require.NoError(t, store.Save(req))
-
-	err := store.AddApproval("req1", "key1", "Alice", []byte("sig"))
-	assert.ErrorIs(t, err, apperrors.ErrRequestNotPending)
-}
-
-func TestRequestStoreAddApprovalExpired(t *testing.T) {
-	tmpDir := t.TempDir()
-	store := NewRequestStore[*RestoreRequest](
-		tmpDir,
-		func() *RestoreRequest { return &RestoreRequest{} },
-		nil,
-	)
-
-	req := &RestoreRequest{
-		ID:        "req1",
-		Status:    StatusPending,
-		ExpiresAt: time.Now().Add(-time.Hour), // Already expired
-	}
-	require.NoError(t, store.Save(req))
-
-	err := store.AddApproval("req1", "key1", "Alice", []byte("sig"))
-	assert.ErrorIs(t, err, apperrors.ErrRequestExpired)
-}
-
-func TestRequestStoreAddApprovalDuplicate(t *testing.T) {
-	tmpDir := t.TempDir()
-	store := NewRequestStore[*RestoreRequest](
-		tmpDir,
-		func() *RestoreRequest { return &RestoreRequest{} },
-		nil,
-	)
-
-	req := &RestoreRequest{
-		ID:                "req1",
-		Status:            StatusPending,
-		ExpiresAt:         time.Now().Add(time.Hour),
-		RequiredApprovals: 2,
-		Approvals:         []Approval{},
-	}
-	require.NoError(t, store.Save(req))
-
-	require.NoError(t, store.AddApproval("req1", "key1", "Alice", []byte("sig1")))
-
-	err := store.AddApproval("req1", "key1", "Alice", []byte("sig2"))
-	assert.ErrorIs(t, err, apperrors.ErrAlreadyApproved)
-}
-
-func TestRequestStoreDeny(t *testing.T) {
-	tmpDir := t.TempDir()
-	store := NewRequestStore[*RestoreRequest](
-		tmpDir,
-		func() *RestoreRequest { return &RestoreRequest{} },
-		nil,
-	)
-
-	req := &RestoreRequest{
-		ID:        "req1",
-		Status:    StatusPending,
-		ExpiresAt: time.Now().Add(time.Hour),
-	}
-	require.NoError(t, store.Save(req))
-
-	err := store.Deny("req1", "denier")
-	require.NoError(t, err)
-
-	got, _ := store.Get("req1")
-	assert.Equal(t, StatusDenied, got.Status)
-}
-
-func TestRequestStoreDenyNotPending(t *testing.T) {
-	tmpDir := t.TempDir()
-	store := NewRequestStore[*RestoreRequest](
-		tmpDir,
-		func() *RestoreRequest { return &RestoreRequest{} },
-		nil,
-	)
-
-	req := &RestoreRequest{
-		ID:        "req1",
-		Status:    StatusApproved,
-		ExpiresAt: time.Now().Add(time.Hour),
-	}
-	require.NoError(t, store.Save(req))
-
-	err := store.Deny("req1", "denier")
-	assert.ErrorIs(t, err, apperrors.ErrRequestNotPending)
-}
-
-func TestRequestStoreHasEnoughApprovals(t *testing.T) {
-	tmpDir := t.TempDir()
-	store := NewRequestStore[*RestoreRequest](
-		tmpDir,
-		func() *RestoreRequest { return &RestoreRequest{} },
-		nil,
-	)
-
-	req := &RestoreRequest{
-		ID:                "req1",
-		Status:            StatusPending,
-		ExpiresAt:         time.Now().Add(time.Hour),
-		RequiredApprovals: 1,
-		Approvals: []Approval{
-			{KeyHolderID: "key1"},
-		},
-	}
-	require.NoError(t, store.Save(req))
-
-	enough, err := store.HasEnoughApprovals("req1")
-	require.NoError(t, err)
-	assert.True(t, enough)
-}
-
-func TestRequestStoreGetApprovalProgress(t *testing.T) {
-	tmpDir := t.TempDir()
-	store := NewRequestStore[*RestoreRequest](
-		tmpDir,
-		func() *RestoreRequest { return &RestoreRequest{} },
-		nil,
-	)
-
-	req := &RestoreRequest{
-		ID:                "req1",
-		Status:            StatusPending,
-		ExpiresAt:         time.Now().Add(time.Hour),
-		RequiredApprovals: 3,
-		Approvals: []Approval{
-			{KeyHolderID: "key1"},
-			{KeyHolderID: "key2"},
-		},
-	}
-	require.NoError(t, store.Save(req))
-
-	current, required, err := store.GetApprovalProgress("req1")
-	require.NoError(t, err)
-	assert.Equal(t, 2, current)
-	assert.Equal(t, 3, required)
-}
-
 func TestRequestStoreWithExpiryCallback(t *testing.T) {
 	tmpDir := t.TempDir()
 	expiryCalled := false
@@ -1150,7 +1114,7 @@ func TestSequentialApprovals(t *testing.T) {
 	for i := 0; i < 3; i++ {
 		keyID := "key" + string(rune('0'+i))
 		name := "User" + string(rune('0'+i))
-		err := m.AddSignature(req.ID, keyID, name, []byte("sig"+string(rune('0'+i))))
+		err := m.AddSignature(req.ID, keyID, name, "", time.Time{}, []byte("sig"+string(rune('0'+i))))
 		require.NoError(t, err)
 	}
 
@@ -1175,7 +1139,7 @@ func TestZeroRequiredApprovals(t *testing.T) {
 	assert.Equal(t, StatusPending, req.Status)
 
 	// Any signature should immediately approve it
-	err = m.AddSignature(req.ID, "key1", "Alice", []byte("sig"))
+	err = m.AddSignature(req.ID, "key1", "Alice", "", time.Time{}, []byte("sig"))
 	require.NoError(t, err)
 
 	got, _ := m.GetRequest(req.ID)
@@ -1255,7 +1219,7 @@ func TestApprovalStructFields(t *testing.T) {
 	req, _ := m.CreateRequestWithConsensus("alice", "latest", "reason", nil, 1)
 
 	before := time.Now()
-	require.NoError(t, m.AddSignature(req.ID, "key-123", "Alice Keys", []byte("signature-data")))
+	require.NoError(t, m.AddSignature(req.ID, "key-123", "Alice Keys", "", time.Time{}, []byte("signature-data")))
 	after := time.Now()
 
 	got, _ := m.GetRequest(req.ID)
@@ -1268,3 +1232,103 @@ func TestApprovalStructFields(t *testing.T) {
 	assert.True(t, approval.ApprovedAt.After(before) || approval.ApprovedAt.Equal(before))
 	assert.True(t, approval.ApprovedAt.Before(after) || approval.ApprovedAt.Equal(after))
 }
+
+func TestBrowseRequest(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := NewManager(tmpDir)
+
+	req, err := m.CreateBrowseRequest("alice", "latest", "is the tax folder there?")
+	require.NoError(t, err)
+	assert.True(t, req.IsBrowse())
+	assert.False(t, req.IsKeyExport())
+	assert.WithinDuration(t, req.CreatedAt.Add(BrowseRequestTTL), req.ExpiresAt, time.Second)
+
+	_, err = m.BrowseAccess(req.ID)
+	assert.ErrorIs(t, err, apperrors.ErrRequestNotApproved)
+
+	require.NoError(t, m.Approve(req.ID, "bob", []byte("share")))
+	approved, err := m.BrowseAccess(req.ID)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(BrowseWindow), approved.ExpiresAt, time.Minute)
+
+	// Restore requests can't be used to browse
+	restore, err := m.CreateRequest("alice", "latest", "restore", nil)
+	require.NoError(t, err)
+	require.NoError(t, m.Approve(restore.ID, "bob", []byte("share")))
+	_, err = m.BrowseAccess(restore.ID)
+	assert.ErrorIs(t, err, apperrors.ErrWrongRequestPurpose)
+
+	// Once the window closes the released share is discarded
+	approved.ExpiresAt = time.Now().Add(-time.Minute)
+	require.NoError(t, m.saveRequest(approved))
+	_, err = m.BrowseAccess(req.ID)
+	assert.ErrorIs(t, err, apperrors.ErrRequestExpired)
+
+	expired, err := m.GetRequest(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusExpired, expired.Status)
+	assert.Nil(t, expired.ShareData)
+}
+
+func TestBrowseRequestScope(t *testing.T) {
+	m := NewManager(t.TempDir())
+
+	_, err := m.WithPurpose(PurposeBrowse).CreateRequest("alice", "latest", "test", []string{"/home/alice/taxes"})
+	assert.Error(t, err, "a browse lists a whole snapshot")
+	_, err = m.WithPurpose(PurposeExportKeys).CreateRequest("alice", "latest", "test", nil)
+	assert.Error(t, err, "a key export names no snapshot")
+
+	// Any node holding the request closes it when the window passes, not
+	// only the one browsing with it
+	req, err := m.CreateBrowseRequest("alice", "latest", "test")
+	require.NoError(t, err)
+	require.NoError(t, m.Approve(req.ID, "bob", []byte("share")))
+	approved, err := m.GetRequest(req.ID)
+	require.NoError(t, err)
+	approved.ExpiresAt = time.Now().Add(-time.Hour)
+	require.NoError(t, m.saveRequest(approved))
+
+	expired, err := m.GetRequest(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusExpired, expired.Status)
+	assert.Nil(t, expired.ShareData)
+}
+
+func TestRestoreRequestHasSealedPaths(t *testing.T) {
+	sealed, err := crypto.SealPaths([]byte("password"), []string{"/home/user/taxes"})
+	require.NoError(t, err)
+
+	assert.False(t, (&RestoreRequest{Paths: []string{"/home/user/taxes"}}).HasSealedPaths())
+	assert.False(t, (&RestoreRequest{}).HasSealedPaths())
+	assert.True(t, (&RestoreRequest{Paths: sealed}).HasSealedPaths())
+}
+
+func TestRestoreRequestSealPaths(t *testing.T) {
+	pub, priv, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+	keyID := crypto.KeyID(pub)
+	m := NewManager(t.TempDir()).WithSigningKey(keyID, priv)
+
+	req, err := m.CreateRequest("alice", "latest", "taxes", []string{"/home/alice/taxes"})
+	require.NoError(t, err)
+	peerReq, err := req.SealPaths("password", priv)
+	require.NoError(t, err)
+	assert.True(t, peerReq.HasSealedPaths())
+	assert.Equal(t, []string{"/home/alice/taxes"}, req.Paths, "the local copy keeps the plain paths")
+
+	// The copy is signed over the sealed paths peers receive
+	assert.Equal(t, keyID, peerReq.RequesterKeyID)
+	valid, err := peerReq.SignData(keyID).Verify(pub, peerReq.RequesterSignature)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	valid, err = req.SignData(keyID).Verify(pub, req.RequesterSignature)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	opened, err := crypto.OpenPaths([]byte("password"), peerReq.Paths)
+	require.NoError(t, err)
+	assert.Equal(t, req.Paths, opened)
+
+	_, err = req.SealPaths("password", nil)
+	assert.Error(t, err, "a signed request can't be sealed without the key")
+}
@@ -0,0 +1,113 @@
+package consent
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/events"
+)
+
+// A cooling-off period holds an approved request back before it can be
+// executed. Any key holder can veto it meanwhile, so approvals given under
+// coercion, or with stolen keys, can still be stopped.
+
+// WithCoolingOff returns a manager whose approved requests can only be
+// executed once d has passed, and can be vetoed until then. Zero means
+// approved requests can be executed at once.
+func (m *Manager) WithCoolingOff(d time.Duration) *Manager {
+	c := *m
+	c.coolingOff = d
+	return &c
+}
+
+// CoolingOff is how long approved requests wait before they can be executed
+func (m *Manager) CoolingOff() time.Duration {
+	return m.coolingOff
+}
+
+// CoolingOffLeft returns how long approved request r has to wait before it
+// can be executed, or zero if it can be executed now
+func (r *RestoreRequest) CoolingOffLeft(now time.Time) time.Duration {
+	if r.Status != StatusApproved || r.ExecutableAt == nil || !now.Before(*r.ExecutableAt) {
+		return 0
+	}
+	return r.ExecutableAt.Sub(now)
+}
+
+// CheckExecutable returns nil if r is approved and its cooling-off period,
+// if any, is over
+func (r *RestoreRequest) CheckExecutable(now time.Time) error {
+	if r.Status != StatusApproved {
+		return apperrors.ErrRequestNotApproved
+	}
+	if left := r.CoolingOffLeft(now); left > 0 {
+		return apperrors.Newf(apperrors.CodeCoolingOff, "request is cooling off until %s (%s left); any key holder can still veto it",
+			r.ExecutableAt.Local().Format("2006-01-02 15:04"), left.Round(time.Minute))
+	}
+	return nil
+}
+
+// VetoSignData returns the data key holder keyHolderID signs at signedAt
+// to veto the request for reason with a challenge's nonce
+func (r *RestoreRequest) VetoSignData(keyHolderID, nonce, reason string, signedAt time.Time) *crypto.VetoSignData {
+	return &crypto.VetoSignData{
+		RequestID:   r.ID,
+		KeyHolderID: keyHolderID,
+		Reason:      reason,
+		Nonce:       nonce,
+		SignedAt:    signedAt.Unix(),
+	}
+}
+
+// checkVetoable returns nil if r can be vetoed at now: it is pending, or
+// approved and still cooling off
+func (r *RestoreRequest) checkVetoable(now time.Time) error {
+	switch {
+	case r.Status == StatusPending:
+	case r.Status == StatusApproved && r.CoolingOffLeft(now) > 0:
+	case r.Status == StatusApproved:
+		return apperrors.New(apperrors.CodeRequestNotPending, "the request's cooling-off period is over; it can no longer be vetoed")
+	case r.Status == StatusExpired:
+		return apperrors.ErrRequestExpired
+	default:
+		return apperrors.ErrRequestNotPending
+	}
+	return nil
+}
+
+// Veto stops request id as vetoer, the key holder keyHolderID, discarding
+// any released share. Pending requests can be vetoed, and approved ones
+// until their cooling-off period is over. The signature must be over
+// VetoSignData with a challenge issued to keyHolderID, which is consumed;
+// callers verify the signature first.
+func (m *Manager) Veto(id, keyHolderID, vetoer, nonce, reason string, signedAt time.Time, signature []byte) (*RestoreRequest, error) {
+	req, err := m.GetRequest(id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := req.checkVetoable(now); err != nil {
+		return nil, err
+	}
+	if err := req.consumeChallenge(keyHolderID, nonce); err != nil {
+		return nil, err
+	}
+
+	req.Status = StatusVetoed
+	req.ShareData = nil
+	req.VetoedBy = vetoer
+	req.VetoedAt = &now
+	req.VetoReason = reason
+	req.VetoKeyHolderID = keyHolderID
+	req.VetoSignature = signature
+	req.VetoNonce = nonce
+	req.VetoSignedAt = &signedAt
+	if err := m.saveRequest(req); err != nil {
+		return nil, err
+	}
+	m.publish(events.RequestVetoed, req, fmt.Sprintf("%s vetoed the restore request", vetoer))
+	return req, nil
+}
@@ -0,0 +1,127 @@
+package consent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+func TestCoolingOff(t *testing.T) {
+	m := NewManager(t.TempDir()).WithCoolingOff(6 * time.Hour)
+	req, err := m.CreateRequest("alice", "latest", "test", nil)
+	require.NoError(t, err)
+	require.NoError(t, m.Approve(req.ID, "bob", []byte("share")))
+
+	got, err := m.GetRequest(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusApproved, got.Status)
+	require.NotNil(t, got.ExecutableAt)
+	assert.Equal(t, 6*time.Hour, got.ExecutableAt.Sub(*got.ApprovedAt))
+	assert.InDelta(t, float64(6*time.Hour), float64(got.CoolingOffLeft(time.Now())), float64(time.Minute))
+	assert.Equal(t, apperrors.CodeCoolingOff, apperrors.CodeOf(got.CheckExecutable(time.Now())))
+	assert.NoError(t, got.CheckExecutable(got.ExecutableAt.Add(time.Second)))
+	assert.Zero(t, got.CoolingOffLeft(got.ExecutableAt.Add(time.Second)))
+
+	// Still listed while it can be vetoed
+	pending, err := m.ListPending()
+	require.NoError(t, err)
+	assert.Len(t, pending, 1)
+
+	// Without a cooling-off period approval is executable at once
+	m = NewManager(t.TempDir())
+	req, err = m.CreateRequest("alice", "latest", "test", nil)
+	require.NoError(t, err)
+	require.NoError(t, m.Approve(req.ID, "bob", []byte("share")))
+	got, err = m.GetRequest(req.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got.ExecutableAt)
+	assert.NoError(t, got.CheckExecutable(time.Now()))
+}
+
+func TestCoolingOffConsensus(t *testing.T) {
+	m := NewManager(t.TempDir()).WithCoolingOff(time.Hour)
+	req, err := m.CreateRequestWithConsensus("alice", "latest", "test", nil, 2)
+	require.NoError(t, err)
+
+	require.NoError(t, m.AddSignature(req.ID, "aaaa", "alice", "", time.Time{}, []byte("sig")))
+	got, err := m.GetRequest(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, apperrors.ErrRequestNotApproved, got.CheckExecutable(time.Now()))
+
+	require.NoError(t, m.AddSignature(req.ID, "bbbb", "bob", "", time.Time{}, []byte("sig")))
+	got, err = m.GetRequest(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusApproved, got.Status)
+	assert.Positive(t, got.CoolingOffLeft(time.Now()))
+}
+
+func TestCoolingOffBrowse(t *testing.T) {
+	m := NewManager(t.TempDir()).WithCoolingOff(time.Hour)
+	req, err := m.CreateBrowseRequest("alice", "latest", "test")
+	require.NoError(t, err)
+	require.NoError(t, m.Approve(req.ID, "bob", []byte("share")))
+
+	_, err = m.BrowseAccess(req.ID)
+	assert.Equal(t, apperrors.CodeCoolingOff, apperrors.CodeOf(err))
+
+	// The browse window opens when the cooling-off period ends
+	got, err := m.GetRequest(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, BrowseWindow, got.ExpiresAt.Sub(*got.ExecutableAt))
+}
+
+func TestVeto(t *testing.T) {
+	m := NewManager(t.TempDir()).WithCoolingOff(time.Hour)
+	veto := func(id, reason string) (*RestoreRequest, error) {
+		t.Helper()
+		c, err := m.IssueChallenge(id, "cccc")
+		require.NoError(t, err)
+		return m.Veto(id, "cccc", "carol", c.Nonce, reason, time.Now(), []byte("sig"))
+	}
+
+	// A pending request can be vetoed, with a challenge issued to the key holder
+	req, err := m.CreateRequest("alice", "latest", "test", nil)
+	require.NoError(t, err)
+	_, err = m.Veto(req.ID, "cccc", "carol", "bogus", "not me", time.Now(), []byte("sig"))
+	assert.ErrorIs(t, err, apperrors.ErrChallengeInvalid)
+	got, err := veto(req.ID, "not me")
+	require.NoError(t, err)
+	assert.Equal(t, StatusVetoed, got.Status)
+	assert.Equal(t, "carol", got.VetoedBy)
+	assert.Equal(t, "cccc", got.VetoKeyHolderID)
+	assert.Equal(t, "not me", got.VetoReason)
+	assert.Equal(t, []byte("sig"), got.VetoSignature)
+	assert.NotEmpty(t, got.VetoNonce)
+	assert.NotNil(t, got.VetoedAt)
+	assert.NotNil(t, got.VetoSignedAt)
+	assert.ErrorIs(t, m.Approve(req.ID, "bob", []byte("share")), apperrors.ErrRequestNotPending)
+
+	// So can an approved one while it cools off, discarding the share
+	req, err = m.CreateRequest("alice", "latest", "test", nil)
+	require.NoError(t, err)
+	require.NoError(t, m.Approve(req.ID, "bob", []byte("share")))
+	got, err = veto(req.ID, "")
+	require.NoError(t, err)
+	assert.Nil(t, got.ShareData)
+	got, err = m.GetRequest(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusVetoed, got.Status)
+	assert.Nil(t, got.ShareData)
+	assert.Equal(t, apperrors.ErrRequestNotApproved, got.CheckExecutable(time.Now().Add(2*time.Hour)))
+	_, err = m.IssueChallenge(req.ID, "cccc")
+	assert.ErrorIs(t, err, apperrors.ErrRequestNotPending)
+
+	// But not once it can be executed
+	m = m.WithCoolingOff(0)
+	req, err = m.CreateRequest("alice", "latest", "test", nil)
+	require.NoError(t, err)
+	require.NoError(t, m.Approve(req.ID, "bob", []byte("share")))
+	_, err = m.IssueChallenge(req.ID, "cccc")
+	assert.Equal(t, apperrors.CodeRequestNotPending, apperrors.CodeOf(err))
+	_, err = m.Veto(req.ID, "cccc", "carol", "bogus", "", time.Now(), []byte("sig"))
+	assert.Equal(t, apperrors.CodeRequestNotPending, apperrors.CodeOf(err))
+}
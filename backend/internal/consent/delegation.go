@@ -0,0 +1,304 @@
+package consent
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+// MaxDelegationPeriod bounds how long approval authority can be delegated
+const MaxDelegationPeriod = 30 * 24 * time.Hour
+
+// Delegation events recorded in the delegation audit trail
+const (
+	DelegationCreated = "created"
+	DelegationUsed    = "used"
+	DelegationRevoked = "revoked"
+	DelegationExpired = "expired"
+)
+
+// Delegation lets a key holder who is away (e.g. on vacation) hand their
+// restore approval authority to another registered key holder for a
+// bounded window. It is signed by the delegating key holder.
+type Delegation struct {
+	ID              string     `json:"id"`
+	FromKeyHolderID string     `json:"from_key_holder_id"`
+	ToKeyHolderID   string     `json:"to_key_holder_id"`
+	StartsAt        time.Time  `json:"starts_at"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+	Reason          string     `json:"reason"`
+	Signature       []byte     `json:"signature"` // Delegator's signature over SignData()
+	CreatedAt       time.Time  `json:"created_at"`
+	RevokedAt       *time.Time `json:"revoked_at,omitempty"`
+	RevokeSignature []byte     `json:"revoke_signature,omitempty"` // Delegator's signature over RevokeSignData()
+}
+
+// DelegationEvent is one entry in the delegation audit trail
+type DelegationEvent struct {
+	At           time.Time `json:"at"`
+	Event        string    `json:"event"` // DelegationCreated, DelegationUsed, ...
+	DelegationID string    `json:"delegation_id"`
+	KeyHolderID  string    `json:"key_holder_id,omitempty"` // Who acted
+	RequestID    string    `json:"request_id,omitempty"`    // For DelegationUsed
+}
+
+// delegationLog is persisted in delegations.json
+type delegationLog struct {
+	Delegations []*Delegation     `json:"delegations"`
+	Events      []DelegationEvent `json:"events"`
+}
+
+// NewDelegation creates an unsigned delegation from one key holder to
+// another, starting at start and lasting period
+func NewDelegation(fromKeyHolderID, toKeyHolderID string, start time.Time, period time.Duration, reason string) (*Delegation, error) {
+	idBytes := make([]byte, 8)
+	if _, err := rand.Read(idBytes); err != nil {
+		return nil, err
+	}
+
+	d := &Delegation{
+		ID:              "dlg-" + hex.EncodeToString(idBytes),
+		FromKeyHolderID: fromKeyHolderID,
+		ToKeyHolderID:   toKeyHolderID,
+		StartsAt:        start.Truncate(time.Second),
+		ExpiresAt:       start.Add(period).Truncate(time.Second),
+		Reason:          reason,
+		CreatedAt:       time.Now(),
+	}
+	return d, d.Validate()
+}
+
+// Validate checks the delegation is between two key holders and bounded
+func (d *Delegation) Validate() error {
+	if d.ID == "" || d.FromKeyHolderID == "" || d.ToKeyHolderID == "" {
+		return errors.New("delegation needs an ID and both key holders")
+	}
+	if d.FromKeyHolderID == d.ToKeyHolderID {
+		return errors.New("cannot delegate to yourself")
+	}
+	if !d.ExpiresAt.After(d.StartsAt) {
+		return errors.New("delegation must end after it starts")
+	}
+	if d.ExpiresAt.Sub(d.StartsAt) > MaxDelegationPeriod {
+		return fmt.Errorf("delegation cannot last longer than %d days", int(MaxDelegationPeriod.Hours()/24))
+	}
+	return nil
+}
+
+// SignData is what the delegating key holder signs
+func (d *Delegation) SignData() *crypto.DelegationSignData {
+	return &crypto.DelegationSignData{
+		DelegationID:    d.ID,
+		FromKeyHolderID: d.FromKeyHolderID,
+		ToKeyHolderID:   d.ToKeyHolderID,
+		StartsAt:        d.StartsAt.Unix(),
+		ExpiresAt:       d.ExpiresAt.Unix(),
+		Reason:          d.Reason,
+	}
+}
+
+// RevokeSignData is what the delegating key holder signs to revoke
+func (d *Delegation) RevokeSignData() *crypto.DelegationSignData {
+	data := d.SignData()
+	data.Revoked = true
+	return data
+}
+
+// ActiveAt reports whether the delegation grants authority at t
+func (d *Delegation) ActiveAt(t time.Time) bool {
+	if t.Before(d.StartsAt) || !t.Before(d.ExpiresAt) {
+		return false
+	}
+	return d.RevokedAt == nil || t.Before(*d.RevokedAt)
+}
+
+func (m *Manager) loadDelegations() (*delegationLog, error) {
+	log := &delegationLog{}
+	data, err := os.ReadFile(m.delegationsPath)
+	if os.IsNotExist(err) {
+		return log, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, log); err != nil {
+		return nil, fmt.Errorf("failed to parse delegations: %w", err)
+	}
+	return log, nil
+}
+
+func (m *Manager) saveDelegations(log *delegationLog) error {
+	if err := os.MkdirAll(filepath.Dir(m.delegationsPath), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.delegationsPath, data, 0600)
+}
+
+func (l *delegationLog) find(id string) *Delegation {
+	for _, d := range l.Delegations {
+		if d.ID == id {
+			return d
+		}
+	}
+	return nil
+}
+
+// recordExpiries adds an expired event for delegations that have run out
+// since the log was last written. It reports whether any were added.
+func (l *delegationLog) recordExpiries(now time.Time) bool {
+	expired := make(map[string]bool)
+	for _, e := range l.Events {
+		if e.Event == DelegationExpired {
+			expired[e.DelegationID] = true
+		}
+	}
+
+	added := false
+	for _, d := range l.Delegations {
+		if d.RevokedAt == nil && !now.Before(d.ExpiresAt) && !expired[d.ID] {
+			l.Events = append(l.Events, DelegationEvent{At: d.ExpiresAt, Event: DelegationExpired, DelegationID: d.ID})
+			added = true
+		}
+	}
+	return added
+}
+
+// AddDelegation registers a signed delegation. The caller verifies the
+// signature against the delegating key holder's public key.
+func (m *Manager) AddDelegation(d *Delegation) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	if len(d.Signature) == 0 {
+		return errors.New("delegation is not signed")
+	}
+	if !time.Now().Before(d.ExpiresAt) {
+		return errors.New("delegation has already expired")
+	}
+
+	log, err := m.loadDelegations()
+	if err != nil {
+		return err
+	}
+	if log.find(d.ID) != nil {
+		return fmt.Errorf("delegation %s already registered", d.ID)
+	}
+
+	log.Delegations = append(log.Delegations, d)
+	log.Events = append(log.Events, DelegationEvent{
+		At:           time.Now(),
+		Event:        DelegationCreated,
+		DelegationID: d.ID,
+		KeyHolderID:  d.FromKeyHolderID,
+	})
+	return m.saveDelegations(log)
+}
+
+// GetDelegation returns a delegation by ID
+func (m *Manager) GetDelegation(id string) (*Delegation, error) {
+	log, err := m.loadDelegations()
+	if err != nil {
+		return nil, err
+	}
+	d := log.find(id)
+	if d == nil {
+		return nil, apperrors.ErrDelegationNotFound
+	}
+	return d, nil
+}
+
+// ListDelegations returns all delegations and the audit trail of their
+// creation, use, revocation and expiry
+func (m *Manager) ListDelegations() ([]*Delegation, []DelegationEvent, error) {
+	log, err := m.loadDelegations()
+	if err != nil {
+		return nil, nil, err
+	}
+	if log.recordExpiries(time.Now()) {
+		if err := m.saveDelegations(log); err != nil {
+			return nil, nil, err
+		}
+	}
+	return log.Delegations, log.Events, nil
+}
+
+// RevokeDelegation ends a delegation early. The caller verifies the
+// signature against the delegating key holder's public key.
+func (m *Manager) RevokeDelegation(id string, signature []byte) (*Delegation, error) {
+	log, err := m.loadDelegations()
+	if err != nil {
+		return nil, err
+	}
+	d := log.find(id)
+	if d == nil {
+		return nil, apperrors.ErrDelegationNotFound
+	}
+	if d.RevokedAt != nil {
+		return nil, errors.New("delegation already revoked")
+	}
+
+	now := time.Now()
+	d.RevokedAt = &now
+	d.RevokeSignature = signature
+	log.Events = append(log.Events, DelegationEvent{
+		At:           now,
+		Event:        DelegationRevoked,
+		DelegationID: d.ID,
+		KeyHolderID:  d.FromKeyHolderID,
+	})
+	return d, m.saveDelegations(log)
+}
+
+// AddDelegatedSignature adds an approval signed by a delegate, counting as
+// the delegating key holder's approval. The delegation must be active, and a
+// non-empty nonce a live challenge issued to the delegate.
+func (m *Manager) AddDelegatedSignature(requestID, delegationID, keyHolderName, nonce string, signedAt time.Time, signature []byte) error {
+	log, err := m.loadDelegations()
+	if err != nil {
+		return err
+	}
+	d := log.find(delegationID)
+	if d == nil {
+		return apperrors.ErrDelegationNotFound
+	}
+
+	now := time.Now()
+	if !d.ActiveAt(now) {
+		return apperrors.ErrDelegationInactive
+	}
+
+	err = m.addApproval(requestID, Approval{
+		KeyHolderID:   d.ToKeyHolderID,
+		KeyHolderName: keyHolderName,
+		Signature:     signature,
+		ApprovedAt:    now,
+		OnBehalfOf:    d.FromKeyHolderID,
+		DelegationID:  d.ID,
+		Nonce:         nonce,
+		SignedAt:      signedTime(signedAt),
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Events = append(log.Events, DelegationEvent{
+		At:           now,
+		Event:        DelegationUsed,
+		DelegationID: d.ID,
+		KeyHolderID:  d.ToKeyHolderID,
+		RequestID:    requestID,
+	})
+	return m.saveDelegations(log)
+}
@@ -0,0 +1,177 @@
+package consent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+func addTestDelegation(t *testing.T, m *Manager, from, to string, start time.Time, period time.Duration) *Delegation {
+	t.Helper()
+	d, err := NewDelegation(from, to, start, period, "vacation")
+	require.NoError(t, err)
+	d.Signature = []byte("sig")
+	require.NoError(t, m.AddDelegation(d))
+	return d
+}
+
+func TestNewDelegationValidation(t *testing.T) {
+	now := time.Now()
+
+	_, err := NewDelegation("alice", "alice", now, time.Hour, "")
+	assert.Error(t, err)
+
+	_, err = NewDelegation("alice", "bob", now, 0, "")
+	assert.Error(t, err)
+
+	_, err = NewDelegation("alice", "bob", now, MaxDelegationPeriod+time.Hour, "")
+	assert.Error(t, err)
+
+	d, err := NewDelegation("alice", "bob", now, 14*24*time.Hour, "vacation")
+	require.NoError(t, err)
+	assert.True(t, d.ActiveAt(now))
+	assert.False(t, d.ActiveAt(now.Add(-time.Minute)))
+	assert.False(t, d.ActiveAt(d.ExpiresAt))
+}
+
+func TestAddDelegationRequiresSignature(t *testing.T) {
+	m := NewManager(t.TempDir())
+
+	d, err := NewDelegation("alice", "bob", time.Now(), time.Hour, "")
+	require.NoError(t, err)
+	assert.Error(t, m.AddDelegation(d))
+
+	d.Signature = []byte("sig")
+	require.NoError(t, m.AddDelegation(d))
+	assert.Error(t, m.AddDelegation(d), "duplicate delegation")
+}
+
+func TestDelegatedApprovalCountsForDelegator(t *testing.T) {
+	m := NewManager(t.TempDir())
+	d := addTestDelegation(t, m, "alice", "bob", time.Now().Add(-time.Minute), 14*24*time.Hour)
+
+	req, err := m.CreateRequestWithConsensus("carol", "latest", "reason", nil, 2)
+	require.NoError(t, err)
+
+	require.NoError(t, m.AddSignature(req.ID, "bob", "Bob", "", time.Time{}, []byte("bob-sig")))
+	require.NoError(t, m.AddDelegatedSignature(req.ID, d.ID, "Bob", "", time.Time{}, []byte("bob-for-alice")))
+
+	got, err := m.GetRequest(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusApproved, got.Status)
+	require.Len(t, got.Approvals, 2)
+	assert.Equal(t, "alice", got.Approvals[1].OnBehalfOf)
+	assert.Equal(t, "alice", got.Approvals[1].Authority())
+
+	_, events, err := m.ListDelegations()
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, DelegationCreated, events[0].Event)
+	assert.Equal(t, DelegationUsed, events[1].Event)
+	assert.Equal(t, req.ID, events[1].RequestID)
+}
+
+func TestDelegatedApprovalDuplicateAuthority(t *testing.T) {
+	m := NewManager(t.TempDir())
+	d := addTestDelegation(t, m, "alice", "bob", time.Now().Add(-time.Minute), time.Hour)
+
+	req, err := m.CreateRequestWithConsensus("carol", "latest", "reason", nil, 3)
+	require.NoError(t, err)
+
+	require.NoError(t, m.AddSignature(req.ID, "alice", "Alice", "", time.Time{}, []byte("sig")))
+	err = m.AddDelegatedSignature(req.ID, d.ID, "Bob", "", time.Time{}, []byte("sig"))
+	assert.ErrorIs(t, err, apperrors.ErrAlreadyApproved)
+}
+
+func TestDelegatedApprovalOutsideWindow(t *testing.T) {
+	m := NewManager(t.TempDir())
+	future := addTestDelegation(t, m, "alice", "bob", time.Now().Add(time.Hour), time.Hour)
+
+	req, err := m.CreateRequestWithConsensus("carol", "latest", "reason", nil, 2)
+	require.NoError(t, err)
+
+	err = m.AddDelegatedSignature(req.ID, future.ID, "Bob", "", time.Time{}, []byte("sig"))
+	assert.ErrorIs(t, err, apperrors.ErrDelegationInactive)
+
+	err = m.AddDelegatedSignature(req.ID, "dlg-missing", "Bob", "", time.Time{}, []byte("sig"))
+	assert.ErrorIs(t, err, apperrors.ErrDelegationNotFound)
+}
+
+func TestRevokedDelegationStopsCounting(t *testing.T) {
+	m := NewManager(t.TempDir())
+	d := addTestDelegation(t, m, "alice", "bob", time.Now().Add(-time.Minute), time.Hour)
+
+	req, err := m.CreateRequestWithConsensus("carol", "latest", "reason", nil, 2)
+	require.NoError(t, err)
+	require.NoError(t, m.AddDelegatedSignature(req.ID, d.ID, "Bob", "", time.Time{}, []byte("sig")))
+
+	current, _, err := m.GetApprovalProgress(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, current)
+
+	// Approvals given before revocation still count; new ones are refused
+	_, err = m.RevokeDelegation(d.ID, []byte("revoke-sig"))
+	require.NoError(t, err)
+	current, _, err = m.GetApprovalProgress(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, current)
+
+	req2, err := m.CreateRequestWithConsensus("carol", "latest", "reason", nil, 2)
+	require.NoError(t, err)
+	err = m.AddDelegatedSignature(req2.ID, d.ID, "Bob", "", time.Time{}, []byte("sig"))
+	assert.ErrorIs(t, err, apperrors.ErrDelegationInactive)
+
+	_, err = m.RevokeDelegation(d.ID, []byte("revoke-sig"))
+	assert.Error(t, err, "already revoked")
+}
+
+func TestDelegatedApprovalWithoutDelegationDoesNotCount(t *testing.T) {
+	m := NewManager(t.TempDir())
+
+	req, err := m.CreateRequestWithConsensus("carol", "latest", "reason", nil, 1)
+	require.NoError(t, err)
+
+	// An approval claiming a delegation that was never registered
+	err = m.addApproval(req.ID, Approval{
+		KeyHolderID:  "bob",
+		Signature:    []byte("sig"),
+		ApprovedAt:   time.Now(),
+		OnBehalfOf:   "alice",
+		DelegationID: "dlg-forged",
+	})
+	require.NoError(t, err)
+
+	got, err := m.GetRequest(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, got.Status)
+}
+
+func TestListDelegationsRecordsExpiry(t *testing.T) {
+	m := NewManager(t.TempDir())
+	d := addTestDelegation(t, m, "alice", "bob", time.Now().Add(-time.Minute), time.Hour)
+
+	_, events, err := m.ListDelegations()
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	// Let the delegation run out
+	log, err := m.loadDelegations()
+	require.NoError(t, err)
+	log.Delegations[0].ExpiresAt = time.Now().Add(-time.Second)
+	require.NoError(t, m.saveDelegations(log))
+
+	_, events, err = m.ListDelegations()
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, DelegationExpired, events[1].Event)
+	assert.Equal(t, d.ID, events[1].DelegationID)
+
+	// Recorded once
+	_, events, err = m.ListDelegations()
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+}
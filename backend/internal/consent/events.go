@@ -0,0 +1,50 @@
+package consent
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/events"
+)
+
+// publish records a consent request's change in the activity feed
+func (m *Manager) publish(eventType string, req AnyOperation, message string) {
+	data := req.eventData()
+	if id := req.GetCorrelationID(); id != "" {
+		data["correlation_id"] = id
+	}
+	events.Open(m.configDir).Publish(events.Event{
+		Type:    eventType,
+		Source:  events.SourceConsent,
+		Subject: req.GetID(),
+		Message: message,
+		Data:    data,
+	})
+}
+
+// requestCreatedMessage describes a new restore request
+func requestCreatedMessage(req *RestoreRequest) string {
+	switch {
+	case req.IsKeyExport():
+		return fmt.Sprintf("%s requested an export of the repository key", req.Requester)
+	case req.IsBrowse():
+		return fmt.Sprintf("%s requested to browse snapshot %s", req.Requester, req.SnapshotID)
+	case req.SnapshotID == "":
+		return fmt.Sprintf("%s requested a restore", req.Requester)
+	}
+	return fmt.Sprintf("%s requested a restore of snapshot %s", req.Requester, req.SnapshotID)
+}
+
+// publishRecovery records a social recovery's change in the activity feed
+func (m *Manager) publishRecovery(eventType string, r *Recovery, message string) {
+	events.Open(m.configDir).Publish(events.Event{
+		Type:    eventType,
+		Source:  events.SourceConsent,
+		Subject: r.ID,
+		Message: message,
+		Data: map[string]string{
+			"missing_key_holder_id": r.MissingKeyHolderID,
+			"usable_at":             r.UsableAt.UTC().Format(time.RFC3339),
+		},
+	})
+}
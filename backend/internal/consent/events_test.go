@@ -0,0 +1,53 @@
+package consent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/events"
+)
+
+func TestRequestEvents(t *testing.T) {
+	dir := t.TempDir()
+	m := NewManager(dir)
+
+	req, err := m.CreateRequest("alice", "latest", "lost laptop", nil)
+	require.NoError(t, err)
+	require.NoError(t, m.Approve(req.ID, "bob", []byte("share")))
+	denied, err := m.CreateBrowseRequest("alice", "abc123", "check")
+	require.NoError(t, err)
+	require.NoError(t, Restores.Deny(m, denied.ID, "bob"))
+
+	// Partial consensus approvals aren't events; reaching consensus is
+	quorum, err := m.CreateRequestWithConsensus("alice", "latest", "test", nil, 2)
+	require.NoError(t, err)
+	require.NoError(t, m.AddSignature(quorum.ID, "kh1", "bob", "", time.Time{}, []byte("sig1")))
+	require.NoError(t, m.AddSignature(quorum.ID, "kh2", "carol", "", time.Time{}, []byte("sig2")))
+
+	deletion, err := m.CreateDeletionRequest("alice", DeletionTypeSnapshot, []string{"s1"}, nil, "cleanup", 1)
+	require.NoError(t, err)
+	require.NoError(t, Deletions.Deny(m, deletion.ID, "bob"))
+
+	list, _, err := events.Open(dir).Since(0, 0, events.Filter{})
+	require.NoError(t, err)
+	var types []string
+	for _, e := range list {
+		assert.Equal(t, events.SourceConsent, e.Source)
+		types = append(types, e.Type)
+	}
+	assert.Equal(t, []string{
+		events.RequestCreated, events.RequestApproved,
+		events.RequestCreated, events.RequestDenied,
+		events.RequestCreated, events.RequestApproved,
+		events.DeletionCreated, events.DeletionDenied,
+	}, types)
+
+	assert.Equal(t, req.ID, list[0].Subject)
+	assert.Equal(t, "alice requested a restore of snapshot latest", list[0].Message)
+	assert.Equal(t, "alice", list[0].Data["requester"])
+	assert.Equal(t, PurposeBrowse, list[2].Data["purpose"])
+	assert.Equal(t, "s1", list[6].Data["snapshot_ids"])
+}
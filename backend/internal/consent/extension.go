@@ -0,0 +1,119 @@
+package consent
+
+import (
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+// Extension is a requester asking for more time to collect approvals, e.g.
+// while a key holder in another timezone is asleep. Any key holder can
+// approve it by signing the new expiry, which pushes the request's expiry
+// back by By.
+type Extension struct {
+	By          time.Duration `json:"by"`
+	Reason      string        `json:"reason,omitempty"`
+	RequestedAt time.Time     `json:"requested_at"`
+	ApprovedBy  string        `json:"approved_by,omitempty"`
+	ApprovedAt  *time.Time    `json:"approved_at,omitempty"`
+
+	// The approving key holder's signature over the new expiry, with the
+	// challenge nonce and signing time it covers
+	KeyHolderID string     `json:"key_holder_id,omitempty"`
+	Signature   []byte     `json:"signature,omitempty"`
+	Nonce       string     `json:"nonce,omitempty"`
+	SignedAt    *time.Time `json:"signed_at,omitempty"`
+}
+
+// IsPending reports whether the extension is awaiting approval
+func (e *Extension) IsPending() bool {
+	return e.ApprovedAt == nil
+}
+
+// PendingExtension returns the extension awaiting approval, or nil
+func (r *RestoreRequest) PendingExtension() *Extension {
+	for i := range r.Extensions {
+		if r.Extensions[i].IsPending() {
+			return &r.Extensions[i]
+		}
+	}
+	return nil
+}
+
+// ExtensionSignData returns the data keyHolderID signs at signedAt to
+// approve the request's pending extension with a challenge's nonce
+func (r *RestoreRequest) ExtensionSignData(keyHolderID, nonce string, signedAt time.Time) (*crypto.ExtensionSignData, error) {
+	ext := r.PendingExtension()
+	if ext == nil {
+		return nil, apperrors.ErrNoPendingExtension
+	}
+	return &crypto.ExtensionSignData{
+		RequestID:   r.ID,
+		KeyHolderID: keyHolderID,
+		ExpiresAt:   r.ExpiresAt.Add(ext.By).Unix(),
+		Nonce:       nonce,
+		SignedAt:    signedAt.Unix(),
+	}, nil
+}
+
+// RequestExtension asks for pending request id's expiry to be pushed back
+// by by. Only one extension can await approval at a time.
+func (m *Manager) RequestExtension(id string, by time.Duration, reason string) (*Extension, error) {
+	if by <= 0 {
+		return nil, apperrors.New(apperrors.CodeInvalidArgument, "extension must be positive")
+	}
+	req, err := pendingRequest(m, m.restores(), id)
+	if err != nil {
+		return nil, err
+	}
+	if req.PendingExtension() != nil {
+		return nil, apperrors.ErrExtensionPending
+	}
+
+	req.Extensions = append(req.Extensions, Extension{
+		By:          by,
+		Reason:      reason,
+		RequestedAt: time.Now(),
+	})
+	if err := m.saveRequest(req); err != nil {
+		return nil, err
+	}
+	return &req.Extensions[len(req.Extensions)-1], nil
+}
+
+// ApproveExtension approves pending request id's extension as approver,
+// the key holder keyHolderID, and pushes its expiry back. The signature
+// must be over ExtensionSignData with a challenge issued to keyHolderID,
+// which is consumed; callers verify the signature first.
+func (m *Manager) ApproveExtension(id, keyHolderID, approver, nonce string, signedAt time.Time, signature []byte) (*RestoreRequest, error) {
+	if err := m.CheckLockdown(); err != nil {
+		return nil, err
+	}
+
+	req, err := pendingRequest(m, m.restores(), id)
+	if err != nil {
+		return nil, err
+	}
+	ext := req.PendingExtension()
+	if ext == nil {
+		return nil, apperrors.ErrNoPendingExtension
+	}
+
+	if err := req.consumeChallenge(keyHolderID, nonce); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	ext.ApprovedBy = approver
+	ext.ApprovedAt = &now
+	ext.KeyHolderID = keyHolderID
+	ext.Signature = signature
+	ext.Nonce = nonce
+	ext.SignedAt = &signedAt
+	req.ExpiresAt = req.ExpiresAt.Add(ext.By)
+	if err := m.saveRequest(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
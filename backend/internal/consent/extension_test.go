@@ -0,0 +1,91 @@
+package consent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+func TestRequestTTL(t *testing.T) {
+	m := NewManager(t.TempDir())
+	req, err := m.CreateRequest("alice", "latest", "test", nil)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRequestTTL, req.ExpiresAt.Sub(req.CreatedAt))
+
+	req, err = m.WithTTL(72*time.Hour).CreateRequestWithConsensus("alice", "latest", "test", nil, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 72*time.Hour, req.ExpiresAt.Sub(req.CreatedAt))
+
+	// Browse requests keep their shorter TTL
+	req, err = m.WithTTL(72*time.Hour).CreateBrowseRequest("alice", "latest", "test")
+	require.NoError(t, err)
+	assert.Equal(t, BrowseRequestTTL, req.ExpiresAt.Sub(req.CreatedAt))
+}
+
+func TestExtensions(t *testing.T) {
+	m := NewManager(t.TempDir())
+	req, err := m.CreateRequestWithConsensus("alice", "latest", "test", nil, 2)
+	require.NoError(t, err)
+	expiresAt := req.ExpiresAt
+
+	_, err = m.ApproveExtension(req.ID, "bob-key", "bob", "nonce", time.Now(), []byte("sig"))
+	assert.ErrorIs(t, err, apperrors.ErrNoPendingExtension)
+	_, err = m.RequestExtension(req.ID, 0, "")
+	assert.Error(t, err)
+
+	ext, err := m.RequestExtension(req.ID, 12*time.Hour, "bob is asleep")
+	require.NoError(t, err)
+	assert.True(t, ext.IsPending())
+	_, err = m.RequestExtension(req.ID, time.Hour, "")
+	assert.ErrorIs(t, err, apperrors.ErrExtensionPending)
+
+	// The approval must use a challenge issued to the approving key holder
+	challenge, err := m.IssueChallenge(req.ID, "carol-key")
+	require.NoError(t, err)
+	_, err = m.ApproveExtension(req.ID, "bob-key", "bob", challenge.Nonce, time.Now(), []byte("sig"))
+	assert.ErrorIs(t, err, apperrors.ErrChallengeInvalid)
+
+	signedAt := time.Now().Truncate(time.Second)
+	got, err := m.ApproveExtension(req.ID, "carol-key", "carol", challenge.Nonce, signedAt, []byte("sig"))
+	require.NoError(t, err)
+	assert.True(t, expiresAt.Add(12*time.Hour).Equal(got.ExpiresAt))
+	assert.Nil(t, got.PendingExtension())
+
+	stored, err := m.GetRequest(req.ID)
+	require.NoError(t, err)
+	require.Len(t, stored.Extensions, 1)
+	assert.Equal(t, "carol", stored.Extensions[0].ApprovedBy)
+	assert.Equal(t, "carol-key", stored.Extensions[0].KeyHolderID)
+	assert.Equal(t, []byte("sig"), stored.Extensions[0].Signature)
+	assert.True(t, signedAt.Equal(*stored.Extensions[0].SignedAt))
+	assert.Equal(t, "bob is asleep", stored.Extensions[0].Reason)
+	assert.True(t, got.ExpiresAt.Equal(stored.ExpiresAt))
+
+	// Once approved, another extension can be asked for, and the used
+	// challenge can't approve it
+	_, err = m.RequestExtension(req.ID, time.Hour, "")
+	require.NoError(t, err)
+	_, err = m.ApproveExtension(req.ID, "carol-key", "carol", challenge.Nonce, signedAt, []byte("sig"))
+	assert.ErrorIs(t, err, apperrors.ErrChallengeInvalid)
+}
+
+func TestExtensionNotPending(t *testing.T) {
+	m := NewManager(t.TempDir())
+	req, err := m.CreateRequest("alice", "latest", "test", nil)
+	require.NoError(t, err)
+	req.ExpiresAt = time.Now().Add(-time.Hour)
+	require.NoError(t, m.saveRequest(req))
+
+	_, err = m.RequestExtension(req.ID, time.Hour, "")
+	assert.ErrorIs(t, err, apperrors.ErrRequestExpired)
+
+	req, err = m.CreateRequest("alice", "latest", "test", nil)
+	require.NoError(t, err)
+	require.NoError(t, Restores.Deny(m, req.ID, "bob"))
+	_, err = m.RequestExtension(req.ID, time.Hour, "")
+	assert.ErrorIs(t, err, apperrors.ErrRequestNotPending)
+}
@@ -0,0 +1,10 @@
+package consent
+
+import "github.com/lcrostarosa/airgapper/backend/internal/lockdown"
+
+// CheckLockdown returns a CodeLockedDown error while the node is locked
+// down. New requests, approvals and approved requests' execution are all
+// refused until the lockdown is lifted; denials and vetoes still work.
+func (m *Manager) CheckLockdown() error {
+	return lockdown.Check(m.configDir)
+}
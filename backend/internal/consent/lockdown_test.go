@@ -0,0 +1,45 @@
+package consent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/lockdown"
+)
+
+func TestLockdown(t *testing.T) {
+	dir := t.TempDir()
+	m := NewManager(dir)
+	pending, err := m.CreateRequest("alice", "latest", "test", nil)
+	require.NoError(t, err)
+	consensus, err := m.CreateRequestWithConsensus("alice", "latest", "test", nil, 2)
+	require.NoError(t, err)
+	deletion, err := m.CreateDeletionRequest("alice", DeletionTypeSnapshot, []string{"abc"}, nil, "test", 1)
+	require.NoError(t, err)
+
+	l, err := lockdown.New("key stolen", "alice", "alice")
+	require.NoError(t, err)
+	_, _, err = lockdown.Apply(dir, l)
+	require.NoError(t, err)
+
+	locked := func(err error) {
+		t.Helper()
+		assert.Equal(t, apperrors.CodeLockedDown, apperrors.CodeOf(err))
+	}
+	_, err = m.CreateRequest("alice", "latest", "test", nil)
+	locked(err)
+	_, err = m.CreateDeletionRequest("alice", DeletionTypeSnapshot, []string{"abc"}, nil, "test", 1)
+	locked(err)
+	locked(m.Approve(pending.ID, "bob", []byte("share")))
+	locked(m.AddSignature(consensus.ID, "aaaa", "alice", "", consensus.CreatedAt, []byte("sig")))
+	locked(approveDeletion(m, deletion.ID, "aaaa", "alice", []byte("sig")))
+
+	// Stopping a request is still allowed
+	require.NoError(t, Restores.Deny(m, pending.ID, "bob"))
+
+	require.NoError(t, lockdown.Lift(dir, l.ID))
+	require.NoError(t, m.AddSignature(consensus.ID, "aaaa", "alice", "", consensus.CreatedAt, []byte("sig")))
+}
@@ -0,0 +1,527 @@
+package consent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/events"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+// Operations are consent-gated actions beyond restores and deletions. A
+// type of operation registers an OperationType saying what its payload is,
+// how long its requests stay pending, the scope approvers sign and what
+// runs once it is approved. The generic workflow, a RequestType like the
+// restores' and deletions', does the rest: creating, storing, expiring,
+// approving and denying requests, and publishing them to the activity
+// feed.
+
+// OperationType describes a type of consent-gated operation whose details
+// are a P
+type OperationType[P any] struct {
+	// Name identifies the type, e.g. "membership_change"
+	Name        string
+	Description string
+	// TTL is how long requests stay pending (default DefaultRequestTTL)
+	TTL time.Duration
+	// Scope is bound into approvers' signatures, so an approval of one
+	// type can't be replayed for another (default "airgapper.operation."
+	// followed by Name)
+	Scope string
+	// Validate checks a payload before a request is created (optional)
+	Validate func(P) error
+	// Execute carries out an operation once approved (optional). If it
+	// fails, the operation stays approved with the error recorded, and can
+	// be executed again.
+	Execute func(ctx context.Context, op *Operation[P]) error
+
+	// requests is the workflow of the type's requests, set up when it is
+	// registered
+	requests *RequestType[*Operation[P]]
+}
+
+// Operation is a request for an operation of a registered type
+type Operation[P any] struct {
+	ID         string        `json:"id"`
+	Type       string        `json:"type"`
+	Requester  string        `json:"requester"`
+	Reason     string        `json:"reason"`
+	Payload    P             `json:"payload"`
+	Status     RequestStatus `json:"status"`
+	CreatedAt  time.Time     `json:"created_at"`
+	ExpiresAt  time.Time     `json:"expires_at"`
+	ApprovedAt *time.Time    `json:"approved_at,omitempty"`
+	ApprovedBy string        `json:"approved_by,omitempty"`
+
+	// ExecutedAt is when Execute succeeded; ExecutionError is why it last
+	// failed, if it did
+	ExecutedAt     *time.Time `json:"executed_at,omitempty"`
+	ExecutionError string     `json:"execution_error,omitempty"`
+
+	// CorrelationID is sent with every peer call about this request
+	CorrelationID string `json:"correlation_id,omitempty"`
+
+	RequiredApprovals int        `json:"required_approvals"`
+	Approvals         []Approval `json:"approvals"`
+}
+
+func (o *Operation[P]) GetID() string             { return o.ID }
+func (o *Operation[P]) GetStatus() RequestStatus  { return o.Status }
+func (o *Operation[P]) SetStatus(s RequestStatus) { o.Status = s }
+func (o *Operation[P]) GetExpiresAt() time.Time   { return o.ExpiresAt }
+func (o *Operation[P]) GetApprovals() []Approval  { return o.Approvals }
+func (o *Operation[P]) AddApproval(a Approval)    { o.Approvals = append(o.Approvals, a) }
+func (o *Operation[P]) GetRequiredApprovals() int { return o.RequiredApprovals }
+func (o *Operation[P]) GetQuorum() *Quorum        { return nil }
+func (o *Operation[P]) GetCorrelationID() string  { return o.CorrelationID }
+
+// OperationType returns the name of the operation's type
+func (o *Operation[P]) OperationType() string { return o.Type }
+
+func (o *Operation[P]) decide(status RequestStatus, by string, at time.Time) {
+	o.Status, o.ApprovedBy, o.ApprovedAt = status, by, &at
+}
+
+func (o *Operation[P]) eventData() map[string]string {
+	return map[string]string{"type": o.Type}
+}
+
+// SignData returns the data key holder keyHolderID signs to approve the
+// operation
+func (o *Operation[P]) SignData(keyHolderID string) (*crypto.OperationSignData, error) {
+	kind, err := operationKindOf(o.Type)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(o.Payload)
+	if err != nil {
+		return nil, err
+	}
+	return &crypto.OperationSignData{
+		Scope:       kind.info().Scope,
+		RequestID:   o.ID,
+		Type:        o.Type,
+		Requester:   o.Requester,
+		Reason:      o.Reason,
+		Payload:     payload,
+		CreatedAt:   o.CreatedAt.Unix(),
+		KeyHolderID: keyHolderID,
+	}, nil
+}
+
+// AnyOperation is a request of any registered type: a restore, a deletion
+// or an operation
+type AnyOperation interface {
+	Request
+	// OperationType names the request's type
+	OperationType() string
+	// GetCorrelationID returns the ID sent with peer calls about the request
+	GetCorrelationID() string
+	// GetQuorum returns the rule the request is approved under, or nil if
+	// it takes GetRequiredApprovals key holders
+	GetQuorum() *Quorum
+
+	// decide records the request's approval or denial by by
+	decide(status RequestStatus, by string, at time.Time)
+	// eventData describes the request in activity feed events
+	eventData() map[string]string
+}
+
+// OperationTypeInfo describes a registered request type
+type OperationTypeInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	TTLSeconds  int64  `json:"ttl_seconds"`
+	Scope       string `json:"scope"`
+}
+
+// operationKind is a registered RequestType or OperationType, whatever its
+// requests
+type operationKind interface {
+	info() OperationTypeInfo
+	create(m *Manager, requester, reason string, payload json.RawMessage, requiredApprovals int) (AnyOperation, error)
+	get(m *Manager, id string) (AnyOperation, error)
+	signData(m *Manager, id, keyHolderID string) (*crypto.OperationSignData, error)
+	listPending(m *Manager) ([]AnyOperation, error)
+	approve(ctx context.Context, m *Manager, id, keyHolderID, keyHolderName string, signature []byte) (AnyOperation, error)
+	deny(m *Manager, id, denier string) error
+	execute(ctx context.Context, m *Manager, id string) (AnyOperation, error)
+}
+
+var (
+	operationKindsMu sync.RWMutex
+	operationKinds   = map[string]operationKind{}
+)
+
+// operationNamePattern matches operation type names, which name their
+// store's directory
+var operationNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// RegisterOperation registers an operation type, typically from an init
+// function, and returns it for typed use. It panics if the name is invalid
+// or taken.
+func RegisterOperation[P any](t OperationType[P]) *OperationType[P] {
+	if t.TTL <= 0 {
+		t.TTL = DefaultRequestTTL
+	}
+	if t.Scope == "" {
+		t.Scope = "airgapper.operation." + t.Name
+	}
+	registered := &t
+	registered.requests = &RequestType[*Operation[P]]{
+		Name:        t.Name,
+		Description: t.Description,
+		TTL:         t.TTL,
+		Scope:       t.Scope,
+		dir: func(m *Manager) string {
+			return filepath.Join(m.configDir, "operations", t.Name)
+		},
+		newRecord: func() *Operation[P] { return &Operation[P]{} },
+		noun:      registered.describe(),
+		created:   events.OperationCreated,
+		approved:  events.OperationApproved,
+		denied:    events.OperationDenied,
+	}
+	register(registered)
+	return registered
+}
+
+// registerType registers a built-in request type and returns it
+func registerType[R AnyOperation](t *RequestType[R]) *RequestType[R] {
+	register(t)
+	return t
+}
+
+// register adds kind to the registry, panicking if its name is invalid or
+// taken
+func register(kind operationKind) {
+	name := kind.info().Name
+	if !operationNamePattern.MatchString(name) {
+		panic(fmt.Sprintf("consent: invalid operation type name %q", name))
+	}
+	operationKindsMu.Lock()
+	defer operationKindsMu.Unlock()
+	if _, ok := operationKinds[name]; ok {
+		panic(fmt.Sprintf("consent: operation type %q registered twice", name))
+	}
+	operationKinds[name] = kind
+}
+
+// OperationTypes describes the registered request types, by name
+func OperationTypes() []OperationTypeInfo {
+	operationKindsMu.RLock()
+	defer operationKindsMu.RUnlock()
+	infos := make([]OperationTypeInfo, 0, len(operationKinds))
+	for _, kind := range operationKinds {
+		infos = append(infos, kind.info())
+	}
+	slices.SortFunc(infos, func(a, b OperationTypeInfo) int { return strings.Compare(a.Name, b.Name) })
+	return infos
+}
+
+// operationKindOf returns the registered type called name
+func operationKindOf(name string) (operationKind, error) {
+	operationKindsMu.RLock()
+	defer operationKindsMu.RUnlock()
+	kind, ok := operationKinds[name]
+	if !ok {
+		return nil, apperrors.Newf(apperrors.CodeInvalidArgument, "unknown operation type %q", name)
+	}
+	return kind, nil
+}
+
+// sortedKinds returns the registered types, by name
+func sortedKinds() []operationKind {
+	var kinds []operationKind
+	for _, info := range OperationTypes() {
+		if kind, err := operationKindOf(info.Name); err == nil {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
+
+// --- The typed workflow ---
+
+// store is the store of m's operations of this type
+func (t *OperationType[P]) store(m *Manager) *RequestStore[*Operation[P]] {
+	return t.requests.store(m)
+}
+
+// Create creates a pending request for the operation described by payload
+func (t *OperationType[P]) Create(m *Manager, requester, reason string, payload P, requiredApprovals int) (*Operation[P], error) {
+	if err := m.CheckLockdown(); err != nil {
+		return nil, err
+	}
+	if requiredApprovals < 1 {
+		return nil, apperrors.New(apperrors.CodeInvalidArgument, "an operation needs at least one approval")
+	}
+	if t.Validate != nil {
+		if err := t.Validate(payload); err != nil {
+			return nil, apperrors.Coded(apperrors.CodeInvalidArgument, err)
+		}
+	}
+	id, err := NewRequestID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	op := &Operation[P]{
+		ID:                id,
+		Type:              t.Name,
+		Requester:         requester,
+		Reason:            reason,
+		Payload:           payload,
+		Status:            StatusPending,
+		CreatedAt:         now,
+		ExpiresAt:         now.Add(t.TTL),
+		CorrelationID:     m.newCorrelationID(),
+		RequiredApprovals: requiredApprovals,
+		Approvals:         []Approval{},
+	}
+	if err := t.requests.add(m, op, fmt.Sprintf("%s requested %s", requester, t.describe())); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+// Get returns the operation with the given ID
+func (t *OperationType[P]) Get(m *Manager, id string) (*Operation[P], error) {
+	if !requestIDPattern.MatchString(id) {
+		return nil, apperrors.ErrRequestNotFound
+	}
+	return t.requests.Get(m, id)
+}
+
+// ListPending returns the pending operations of this type
+func (t *OperationType[P]) ListPending(m *Manager) ([]*Operation[P], error) {
+	return t.requests.ListPending(m)
+}
+
+// Approve records key holder keyHolderID's approval. Callers verify the
+// signature first, over SignData. Once enough key holders approve, the
+// operation is executed.
+func (t *OperationType[P]) Approve(ctx context.Context, m *Manager, id, keyHolderID, keyHolderName string, signature []byte) (*Operation[P], error) {
+	op, err := t.requests.AddApproval(m, id, Approval{
+		KeyHolderID:   keyHolderID,
+		KeyHolderName: keyHolderName,
+		Signature:     signature,
+		ApprovedAt:    time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if op.Status != StatusApproved {
+		return op, nil
+	}
+	return t.Run(ctx, m, id)
+}
+
+// Deny denies a pending operation
+func (t *OperationType[P]) Deny(m *Manager, id, denier string) error {
+	return t.requests.Deny(m, id, denier)
+}
+
+// Run runs the type's Execute hook on an approved operation not yet
+// executed. Without a hook there is nothing to run, and the operation is
+// returned as it is.
+func (t *OperationType[P]) Run(ctx context.Context, m *Manager, id string) (*Operation[P], error) {
+	s := t.store(m)
+	op, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if op.Status != StatusApproved {
+		return nil, apperrors.ErrRequestNotApproved
+	}
+	if t.Execute == nil || op.ExecutedAt != nil {
+		return op, nil
+	}
+	if err := m.CheckLockdown(); err != nil {
+		return nil, err
+	}
+
+	if err := t.Execute(ctx, op); err != nil {
+		op.ExecutionError = err.Error()
+		logging.Warn("Approved operation failed", logging.String("type", op.Type), logging.String("id", op.ID), logging.Err(err))
+		if saveErr := s.Save(op); saveErr != nil {
+			logging.Warn("Failed to save operation", logging.Err(saveErr))
+		}
+		return op, err
+	}
+	now := time.Now()
+	op.ExecutedAt = &now
+	op.ExecutionError = ""
+	if err := s.Save(op); err != nil {
+		return nil, err
+	}
+	m.publish(events.OperationExecuted, op, "Carried out "+t.describe())
+	return op, nil
+}
+
+// describe names the operation in event messages
+func (t *OperationType[P]) describe() string {
+	if t.Description != "" {
+		return "an operation (" + t.Description + ")"
+	}
+	return "an operation (" + t.Name + ")"
+}
+
+// The untyped workflow, for the API
+
+func (t *OperationType[P]) info() OperationTypeInfo {
+	return t.requests.info()
+}
+
+func (t *OperationType[P]) create(m *Manager, requester, reason string, payload json.RawMessage, requiredApprovals int) (AnyOperation, error) {
+	var p P
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return nil, apperrors.Newf(apperrors.CodeInvalidArgument, "invalid %s payload: %v", t.Name, err)
+		}
+	}
+	return nilSafe(t.Create(m, requester, reason, p, requiredApprovals))
+}
+
+func (t *OperationType[P]) get(m *Manager, id string) (AnyOperation, error) {
+	return nilSafe(t.Get(m, id))
+}
+
+func (t *OperationType[P]) signData(m *Manager, id, keyHolderID string) (*crypto.OperationSignData, error) {
+	op, err := t.Get(m, id)
+	if err != nil {
+		return nil, err
+	}
+	return op.SignData(keyHolderID)
+}
+
+func (t *OperationType[P]) listPending(m *Manager) ([]AnyOperation, error) {
+	ops, err := t.ListPending(m)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]AnyOperation, len(ops))
+	for i, op := range ops {
+		list[i] = op
+	}
+	return list, nil
+}
+
+func (t *OperationType[P]) approve(ctx context.Context, m *Manager, id, keyHolderID, keyHolderName string, signature []byte) (AnyOperation, error) {
+	return nilSafe(t.Approve(ctx, m, id, keyHolderID, keyHolderName, signature))
+}
+
+func (t *OperationType[P]) deny(m *Manager, id, denier string) error {
+	return t.Deny(m, id, denier)
+}
+
+func (t *OperationType[P]) execute(ctx context.Context, m *Manager, id string) (AnyOperation, error) {
+	return nilSafe(t.Run(ctx, m, id))
+}
+
+// nilSafe returns op as an AnyOperation, nil rather than a typed nil
+// pointer when there is none
+func nilSafe[P any](op *Operation[P], err error) (AnyOperation, error) {
+	if op == nil {
+		return nil, err
+	}
+	return op, err
+}
+
+// --- Manager access to operations of any type ---
+
+// CreateOperation creates a pending request for an operation of the
+// registered type typ, its payload given as JSON
+func (m *Manager) CreateOperation(typ, requester, reason string, payload json.RawMessage, requiredApprovals int) (AnyOperation, error) {
+	kind, err := operationKindOf(typ)
+	if err != nil {
+		return nil, err
+	}
+	return kind.create(m, requester, reason, payload, requiredApprovals)
+}
+
+// GetOperation returns the request with the given ID, of whatever type
+func (m *Manager) GetOperation(id string) (AnyOperation, error) {
+	op, _, err := m.operation(id)
+	return op, err
+}
+
+// operation returns the request with the given ID and its type
+func (m *Manager) operation(id string) (AnyOperation, operationKind, error) {
+	if !requestIDPattern.MatchString(id) {
+		return nil, nil, apperrors.ErrRequestNotFound
+	}
+	for _, kind := range sortedKinds() {
+		op, err := kind.get(m, id)
+		if err == nil {
+			return op, kind, nil
+		}
+		if apperrors.CodeOf(err) != apperrors.CodeRequestNotFound {
+			return nil, nil, err
+		}
+	}
+	return nil, nil, apperrors.ErrRequestNotFound
+}
+
+// ListPendingOperations returns the pending requests of every type
+func (m *Manager) ListPendingOperations() ([]AnyOperation, error) {
+	var all []AnyOperation
+	for _, kind := range sortedKinds() {
+		ops, err := kind.listPending(m)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, ops...)
+	}
+	return all, nil
+}
+
+// OperationSignData returns the data key holder keyHolderID signs to
+// approve the operation with the given ID
+func (m *Manager) OperationSignData(id, keyHolderID string) (*crypto.OperationSignData, error) {
+	_, kind, err := m.operation(id)
+	if err != nil {
+		return nil, err
+	}
+	return kind.signData(m, id, keyHolderID)
+}
+
+// ApproveOperation records an approval of the operation with the given
+// ID, executing it once enough key holders approve. Callers verify the
+// signature first, over its OperationSignData.
+func (m *Manager) ApproveOperation(ctx context.Context, id, keyHolderID, keyHolderName string, signature []byte) (AnyOperation, error) {
+	_, kind, err := m.operation(id)
+	if err != nil {
+		return nil, err
+	}
+	return kind.approve(ctx, m, id, keyHolderID, keyHolderName, signature)
+}
+
+// DenyOperation denies the pending request with the given ID, of whatever
+// type
+func (m *Manager) DenyOperation(id, denier string) error {
+	_, kind, err := m.operation(id)
+	if err != nil {
+		return err
+	}
+	return kind.deny(m, id, denier)
+}
+
+// ExecuteOperation retries executing an approved operation whose
+// execution failed
+func (m *Manager) ExecuteOperation(ctx context.Context, id string) (AnyOperation, error) {
+	_, kind, err := m.operation(id)
+	if err != nil {
+		return nil, err
+	}
+	return kind.execute(ctx, m, id)
+}
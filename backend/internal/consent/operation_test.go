@@ -0,0 +1,248 @@
+package consent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/lockdown"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// executedRenames counts testRenameOp's executions; failRename makes them fail
+var (
+	executedRenames int
+	failRename      bool
+)
+
+var testRenameOp = RegisterOperation(OperationType[testRename]{
+	Name:        "test_rename",
+	Description: "rename a key holder",
+	TTL:         time.Hour,
+	Validate: func(p testRename) error {
+		if p.From == "" || p.To == "" {
+			return errors.New("from and to are required")
+		}
+		return nil
+	},
+	Execute: func(ctx context.Context, op *Operation[testRename]) error {
+		if failRename {
+			return errors.New("rename failed")
+		}
+		executedRenames++
+		return nil
+	},
+})
+
+func TestRegisterOperationDefaults(t *testing.T) {
+	assert.Equal(t, "airgapper.operation.test_rename", testRenameOp.Scope)
+	assert.Contains(t, OperationTypes(), OperationTypeInfo{
+		Name: "test_rename", Description: "rename a key holder", TTLSeconds: 3600, Scope: "airgapper.operation.test_rename",
+	})
+
+	assert.Panics(t, func() { RegisterOperation(OperationType[testRename]{Name: "test_rename"}) })
+	assert.Panics(t, func() { RegisterOperation(OperationType[testRename]{Name: "../escape"}) })
+}
+
+func TestBuiltInRequestTypes(t *testing.T) {
+	assert.Contains(t, OperationTypes(), OperationTypeInfo{
+		Name: "restore", Description: Restores.Description, TTLSeconds: int64(DefaultRequestTTL.Seconds()), Scope: "airgapper.restore",
+	})
+	assert.Contains(t, OperationTypes(), OperationTypeInfo{
+		Name: "deletion", Description: Deletions.Description, TTLSeconds: int64((7 * 24 * time.Hour).Seconds()), Scope: "airgapper.deletion",
+	})
+
+	m := NewManager(t.TempDir())
+	restore, err := m.CreateRequest("alice", "latest", "lost laptop", nil)
+	require.NoError(t, err)
+	deletion, err := m.CreateDeletionRequest("alice", DeletionTypeSnapshot, []string{"snap1"}, nil, "tidy", 1)
+	require.NoError(t, err)
+
+	pending, err := m.ListPendingOperations()
+	require.NoError(t, err)
+	var types []string
+	for _, op := range pending {
+		types = append(types, op.OperationType())
+	}
+	assert.ElementsMatch(t, []string{"restore", "deletion"}, types)
+
+	// The generic API shows and denies them, but leaves creating and
+	// approving them to their own RPCs
+	_, err = m.CreateOperation("restore", "alice", "", nil, 1)
+	assert.Equal(t, apperrors.CodeFailedPrecondition, apperrors.CodeOf(err))
+	_, err = m.OperationSignData(deletion.ID, "kh-1")
+	assert.Equal(t, apperrors.CodeFailedPrecondition, apperrors.CodeOf(err))
+	_, err = m.ApproveOperation(context.Background(), deletion.ID, "kh-1", "Alice", nil)
+	assert.Equal(t, apperrors.CodeFailedPrecondition, apperrors.CodeOf(err))
+
+	require.NoError(t, m.Approve(restore.ID, "bob", []byte("share")))
+	shown, err := m.GetOperation(restore.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusApproved, shown.GetStatus())
+	assert.Nil(t, shown.(*RestoreRequest).ShareData, "released shares stay out of the generic API")
+
+	require.NoError(t, m.DenyOperation(deletion.ID, "bob"))
+	denied, err := m.GetDeletionRequest(deletion.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusDenied, denied.Status)
+	assert.Equal(t, "bob", denied.ApprovedBy)
+}
+
+func TestOperationWorkflow(t *testing.T) {
+	m := NewManager(t.TempDir())
+	executedRenames, failRename = 0, false
+
+	op, err := testRenameOp.Create(m, "alice", "typo in the name", testRename{From: "bob", To: "robert"}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, op.Status)
+	assert.Equal(t, "test_rename", op.Type)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), op.ExpiresAt, time.Minute)
+	assert.FileExists(t, filepath.Join(m.configDir, "operations", "test_rename", op.ID+".json"))
+
+	pending, err := testRenameOp.ListPending(m)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, testRename{From: "bob", To: "robert"}, pending[0].Payload)
+
+	op, err = testRenameOp.Approve(context.Background(), m, op.ID, "kh-1", "Alice", []byte("sig1"))
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, op.Status)
+	assert.Zero(t, executedRenames)
+
+	_, err = testRenameOp.Approve(context.Background(), m, op.ID, "kh-1", "Alice", []byte("sig1"))
+	assert.ErrorIs(t, err, apperrors.ErrAlreadyApproved)
+
+	op, err = testRenameOp.Approve(context.Background(), m, op.ID, "kh-2", "Bob", []byte("sig2"))
+	require.NoError(t, err)
+	assert.Equal(t, StatusApproved, op.Status)
+	assert.NotNil(t, op.ExecutedAt)
+	assert.Equal(t, 1, executedRenames)
+
+	// Executing again does nothing
+	_, err = m.ExecuteOperation(context.Background(), op.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, executedRenames)
+}
+
+func TestOperationExecutionFailure(t *testing.T) {
+	m := NewManager(t.TempDir())
+	executedRenames, failRename = 0, true
+
+	op, err := testRenameOp.Create(m, "alice", "", testRename{From: "bob", To: "robert"}, 1)
+	require.NoError(t, err)
+	op, err = testRenameOp.Approve(context.Background(), m, op.ID, "kh-1", "Alice", nil)
+	require.Error(t, err)
+	assert.Equal(t, StatusApproved, op.Status)
+	assert.Equal(t, "rename failed", op.ExecutionError)
+	assert.Nil(t, op.ExecutedAt)
+
+	failRename = false
+	retried, err := m.ExecuteOperation(context.Background(), op.ID)
+	require.NoError(t, err)
+	stored, err := testRenameOp.Get(m, retried.GetID())
+	require.NoError(t, err)
+	assert.NotNil(t, stored.ExecutedAt)
+	assert.Empty(t, stored.ExecutionError)
+	assert.Equal(t, 1, executedRenames)
+}
+
+func TestOperationValidation(t *testing.T) {
+	m := NewManager(t.TempDir())
+
+	_, err := testRenameOp.Create(m, "alice", "", testRename{From: "bob"}, 1)
+	assert.Equal(t, apperrors.CodeInvalidArgument, apperrors.CodeOf(err))
+
+	_, err = testRenameOp.Create(m, "alice", "", testRename{From: "bob", To: "robert"}, 0)
+	assert.Equal(t, apperrors.CodeInvalidArgument, apperrors.CodeOf(err))
+
+	_, err = m.CreateOperation("no_such_type", "alice", "", nil, 1)
+	assert.Equal(t, apperrors.CodeInvalidArgument, apperrors.CodeOf(err))
+
+	_, err = m.CreateOperation("test_rename", "alice", "", json.RawMessage(`{"from":1}`), 1)
+	assert.Equal(t, apperrors.CodeInvalidArgument, apperrors.CodeOf(err))
+}
+
+func TestOperationDenyAndExpiry(t *testing.T) {
+	m := NewManager(t.TempDir())
+
+	op, err := m.CreateOperation("test_rename", "alice", "", json.RawMessage(`{"from":"bob","to":"robert"}`), 1)
+	require.NoError(t, err)
+	require.NoError(t, m.DenyOperation(op.GetID(), "bob"))
+	assert.ErrorIs(t, m.DenyOperation(op.GetID(), "bob"), apperrors.ErrRequestNotPending)
+	_, err = m.ApproveOperation(context.Background(), op.GetID(), "kh-1", "Alice", nil)
+	assert.ErrorIs(t, err, apperrors.ErrRequestNotPending)
+
+	expiring, err := testRenameOp.Create(m, "alice", "", testRename{From: "bob", To: "robert"}, 1)
+	require.NoError(t, err)
+	expiring.ExpiresAt = time.Now().Add(-time.Hour)
+	require.NoError(t, testRenameOp.store(m).Save(expiring))
+	got, err := m.GetOperation(expiring.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusExpired, got.GetStatus())
+
+	pending, err := m.ListPendingOperations()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	_, err = m.GetOperation("0123456789abcdef")
+	assert.ErrorIs(t, err, apperrors.ErrRequestNotFound)
+	_, err = m.GetOperation("../../etc")
+	assert.ErrorIs(t, err, apperrors.ErrRequestNotFound)
+}
+
+func TestOperationSignDataBindsScope(t *testing.T) {
+	m := NewManager(t.TempDir())
+	pub, priv, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+
+	op, err := testRenameOp.Create(m, "alice", "", testRename{From: "bob", To: "robert"}, 1)
+	require.NoError(t, err)
+	data, err := op.SignData("kh-1")
+	require.NoError(t, err)
+	assert.Equal(t, "airgapper.operation.test_rename", data.Scope)
+	sig, err := data.Sign(priv)
+	require.NoError(t, err)
+	valid, err := data.Verify(pub, sig)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	// An approval doesn't carry over to another key holder or payload
+	other, err := op.SignData("kh-2")
+	require.NoError(t, err)
+	valid, _ = other.Verify(pub, sig)
+	assert.False(t, valid)
+	op.Payload.To = "mallory"
+	changed, err := op.SignData("kh-1")
+	require.NoError(t, err)
+	valid, _ = changed.Verify(pub, sig)
+	assert.False(t, valid)
+}
+
+func TestOperationLockdown(t *testing.T) {
+	dir := t.TempDir()
+	m := NewManager(dir)
+	op, err := testRenameOp.Create(m, "alice", "", testRename{From: "bob", To: "robert"}, 1)
+	require.NoError(t, err)
+
+	l, err := lockdown.New("key stolen", "alice", "alice")
+	require.NoError(t, err)
+	_, _, err = lockdown.Apply(dir, l)
+	require.NoError(t, err)
+
+	_, err = testRenameOp.Create(m, "alice", "", testRename{From: "bob", To: "robert"}, 1)
+	assert.Equal(t, apperrors.CodeLockedDown, apperrors.CodeOf(err))
+	_, err = testRenameOp.Approve(context.Background(), m, op.ID, "kh-1", "Alice", nil)
+	assert.Equal(t, apperrors.CodeLockedDown, apperrors.CodeOf(err))
+	require.NoError(t, testRenameOp.Deny(m, op.ID, "bob"))
+}
@@ -0,0 +1,53 @@
+package consent
+
+import (
+	"time"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+// Stages of carrying out a deletion, in order
+const (
+	PruneStageMaintenance = "maintenance" // The host stops other writes
+	PruneStageUnlock      = "unlock"      // Stale repository locks are removed
+	PruneStageForget      = "forget"      // The deleted snapshots are forgotten
+	PruneStagePrune       = "prune"       // Data only they referenced is removed
+	PruneStageCleanup     = "cleanup"     // The host collects garbage and re-baselines its checks
+)
+
+// PruneProgress is how far carrying out an approved deletion has got
+type PruneProgress struct {
+	Status     string     `json:"status"` // ProgressRunning, ProgressCompleted or ProgressFailed
+	Stage      string     `json:"stage"`
+	Message    string     `json:"message,omitempty"` // The latest progress line of the stage
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	FreedBytes int64      `json:"freed_bytes,omitempty"` // What the host reclaimed
+	Error      string     `json:"error,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// RecordPruneProgress records how far deletion id has got. Only approved
+// deletions are carried out, so only their progress is recorded.
+func (m *Manager) RecordPruneProgress(id string, p PruneProgress) (*DeletionRequest, error) {
+	req, err := m.GetDeletionRequest(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Status != StatusApproved {
+		return nil, apperrors.ErrRequestNotApproved
+	}
+	switch p.Status {
+	case ProgressRunning, ProgressCompleted, ProgressFailed:
+	default:
+		return nil, apperrors.Newf(apperrors.CodeInvalidArgument, "unknown prune progress status %q", p.Status)
+	}
+	if p.UpdatedAt.IsZero() {
+		p.UpdatedAt = time.Now()
+	}
+	req.Prune = &p
+	if err := m.saveDeletionRequest(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
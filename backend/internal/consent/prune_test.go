@@ -0,0 +1,33 @@
+package consent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordPruneProgress(t *testing.T) {
+	m := NewManager(t.TempDir())
+	req, err := m.CreateDeletionRequest("alice", DeletionTypePrune, nil, nil, "free space", 1)
+	require.NoError(t, err)
+
+	_, err = m.RecordPruneProgress(req.ID, PruneProgress{Status: ProgressRunning, Stage: PruneStagePrune})
+	assert.Error(t, err, "not approved yet")
+	assert.Error(t, m.MarkDeletionExecuted(req.ID))
+
+	require.NoError(t, approveDeletion(m, req.ID, "alice-key", "Alice", []byte("sig")))
+	_, err = m.RecordPruneProgress(req.ID, PruneProgress{Status: ProgressScheduled})
+	assert.Error(t, err, "deletions aren't scheduled")
+
+	updated, err := m.RecordPruneProgress(req.ID, PruneProgress{Status: ProgressRunning, Stage: PruneStagePrune, Message: "repacking"})
+	require.NoError(t, err)
+	assert.Equal(t, PruneStagePrune, updated.Prune.Stage)
+	assert.False(t, updated.Prune.UpdatedAt.IsZero())
+
+	require.NoError(t, m.MarkDeletionExecuted(req.ID))
+	stored, err := m.GetDeletionRequest(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "repacking", stored.Prune.Message)
+	assert.NotNil(t, stored.ExecutedAt)
+}
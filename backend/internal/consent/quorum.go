@@ -0,0 +1,171 @@
+package consent
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Quorum is an approval rule richer than a flat m-of-n count: some key
+// holders' approvals can be mandatory and keys can carry different weights.
+// "Owner + any one host" is the owner required with a minimum weight of 2.
+type Quorum struct {
+	Required  []string       `json:"required,omitempty"` // Key holder IDs whose approval is mandatory
+	Weights   map[string]int `json:"weights,omitempty"`  // Key holder ID -> weight; unlisted keys weigh 1
+	MinWeight int            `json:"min_weight"`         // Total weight of approvals needed
+}
+
+// QuorumProgress is how far a set of approvals is toward a quorum
+type QuorumProgress struct {
+	Weight    int      `json:"weight"`
+	MinWeight int      `json:"min_weight"`
+	Missing   []string `json:"missing,omitempty"` // Mandatory key holders who haven't approved
+}
+
+// Satisfied reports whether the quorum is met
+func (p QuorumProgress) Satisfied() bool {
+	return p.Weight >= p.MinWeight && len(p.Missing) == 0
+}
+
+// Counts returns the approvals so far and the count required. Under a
+// quorum these are weights, and current stays below required while a
+// mandatory key holder has yet to approve.
+func (p QuorumProgress) Counts() (current, required int) {
+	if !p.Satisfied() && p.Weight >= p.MinWeight {
+		return p.MinWeight - 1, p.MinWeight
+	}
+	return p.Weight, p.MinWeight
+}
+
+// Weight returns the weight of a key holder's approval
+func (q *Quorum) Weight(keyHolderID string) int {
+	if w, ok := q.Weights[keyHolderID]; ok {
+		return w
+	}
+	return 1
+}
+
+// Validate checks the rule against the registered key holders and that it
+// can be met if they all approve
+func (q *Quorum) Validate(keyHolderIDs []string) error {
+	if q.MinWeight < 1 {
+		return errors.New("quorum minimum weight must be at least 1")
+	}
+
+	registered := make(map[string]bool, len(keyHolderIDs))
+	for _, id := range keyHolderIDs {
+		registered[id] = true
+	}
+	for id, w := range q.Weights {
+		if !registered[id] {
+			return fmt.Errorf("quorum weights unknown key holder %s", id)
+		}
+		if w < 0 {
+			return fmt.Errorf("quorum weight for %s cannot be negative", id)
+		}
+	}
+	for _, id := range q.Required {
+		if !registered[id] {
+			return fmt.Errorf("quorum requires unknown key holder %s", id)
+		}
+		if q.Weight(id) == 0 {
+			return fmt.Errorf("required key holder %s cannot have zero weight", id)
+		}
+	}
+
+	total := 0
+	for _, id := range keyHolderIDs {
+		total += q.Weight(id)
+	}
+	if total < q.MinWeight {
+		return fmt.Errorf("quorum needs weight %d but all key holders together only have %d", q.MinWeight, total)
+	}
+	return nil
+}
+
+// Evaluate measures approvals by the given key holders against the quorum
+func (q *Quorum) Evaluate(approvers []string) QuorumProgress {
+	approved := make(map[string]bool, len(approvers))
+	p := QuorumProgress{MinWeight: q.MinWeight}
+	for _, id := range approvers {
+		if !approved[id] {
+			approved[id] = true
+			p.Weight += q.Weight(id)
+		}
+	}
+	for _, id := range q.Required {
+		if !approved[id] {
+			p.Missing = append(p.Missing, id)
+		}
+	}
+	return p
+}
+
+// approvalProgress measures a request's approvals against its quorum, or
+// its required count when it has none
+func (m *Manager) approvalProgress(req AnyOperation) QuorumProgress {
+	return m.quorumProgress(req.GetApprovals(), req.GetQuorum(), req.GetRequiredApprovals())
+}
+
+// quorumProgress measures approvals against quorum, or against required
+// when it is nil. A delegated approval only counts if its delegation is
+// registered and was active when it was given.
+func (m *Manager) quorumProgress(approvals []Approval, quorum *Quorum, required int) QuorumProgress {
+	log, recoveries := &delegationLog{}, &recoveryLog{}
+	if slices.ContainsFunc(approvals, func(a Approval) bool { return a.DelegationID != "" }) {
+		if loaded, err := m.loadDelegations(); err == nil {
+			log = loaded
+		}
+	}
+	if slices.ContainsFunc(approvals, func(a Approval) bool { return a.RecoveryID != "" }) {
+		if loaded, err := m.loadRecoveries(); err == nil {
+			recoveries = loaded
+		}
+	}
+
+	var approvers []string
+	for _, a := range approvals {
+		if a.DelegationID != "" {
+			d := log.find(a.DelegationID)
+			if d == nil || d.FromKeyHolderID != a.OnBehalfOf || d.ToKeyHolderID != a.KeyHolderID || !d.ActiveAt(a.ApprovedAt) {
+				continue
+			}
+		}
+		if a.RecoveryID != "" {
+			r := recoveries.find(a.RecoveryID)
+			if r == nil || r.MissingKeyHolderID != a.KeyHolderID || r.CancelledAt != nil || a.ApprovedAt.Before(r.UsableAt) {
+				continue
+			}
+		}
+		approvers = append(approvers, a.Authority())
+	}
+
+	if quorum != nil {
+		return quorum.Evaluate(approvers)
+	}
+	return QuorumProgress{Weight: len(approvers), MinWeight: required}
+}
+
+// String describes the rule, e.g. "weight 3 incl. ab12cd34 (weights ef56ab78=2)"
+func (q *Quorum) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "weight %d", q.MinWeight)
+	if len(q.Required) > 0 {
+		fmt.Fprintf(&b, " incl. %s", strings.Join(q.Required, ", "))
+	}
+	if len(q.Weights) > 0 {
+		ids := make([]string, 0, len(q.Weights))
+		for id := range q.Weights {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		weights := make([]string, len(ids))
+		for i, id := range ids {
+			weights[i] = fmt.Sprintf("%s=%d", id, q.Weights[id])
+		}
+		fmt.Fprintf(&b, " (weights %s)", strings.Join(weights, ", "))
+	}
+	return b.String()
+}
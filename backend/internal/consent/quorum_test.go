@@ -0,0 +1,107 @@
+package consent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuorumEvaluate(t *testing.T) {
+	q := &Quorum{Required: []string{"owner"}, Weights: map[string]int{"auditor": 2, "observer": 0}, MinWeight: 3}
+
+	p := q.Evaluate([]string{"auditor", "host"})
+	assert.Equal(t, 3, p.Weight)
+	assert.Equal(t, []string{"owner"}, p.Missing)
+	assert.False(t, p.Satisfied(), "owner is mandatory")
+
+	p = q.Evaluate([]string{"owner", "observer", "observer"})
+	assert.Equal(t, 1, p.Weight)
+	assert.False(t, p.Satisfied())
+
+	assert.True(t, q.Evaluate([]string{"owner", "auditor"}).Satisfied())
+}
+
+func TestQuorumValidate(t *testing.T) {
+	holders := []string{"owner", "host1", "host2"}
+
+	assert.NoError(t, (&Quorum{Required: []string{"owner"}, MinWeight: 2}).Validate(holders))
+	assert.Error(t, (&Quorum{MinWeight: 0}).Validate(holders))
+	assert.Error(t, (&Quorum{MinWeight: 4}).Validate(holders))
+	assert.Error(t, (&Quorum{Required: []string{"nobody"}, MinWeight: 1}).Validate(holders))
+	assert.Error(t, (&Quorum{Weights: map[string]int{"host1": -1}, MinWeight: 1}).Validate(holders))
+	assert.Error(t, (&Quorum{Required: []string{"owner"}, Weights: map[string]int{"owner": 0}, MinWeight: 1}).Validate(holders))
+	assert.NoError(t, (&Quorum{Weights: map[string]int{"host1": 3}, MinWeight: 5}).Validate(holders))
+}
+
+func TestRequestWithQuorum(t *testing.T) {
+	m := NewManager(t.TempDir())
+
+	// Owner + any one host
+	req, err := m.CreateRequestWithQuorum("owner", "latest", "reason", nil, &Quorum{Required: []string{"owner"}, MinWeight: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 2, req.RequiredApprovals)
+
+	require.NoError(t, m.AddSignature(req.ID, "host1", "Host 1", "", time.Time{}, []byte("sig")))
+	require.NoError(t, m.AddSignature(req.ID, "host2", "Host 2", "", time.Time{}, []byte("sig")))
+
+	got, err := m.GetRequest(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, got.Status, "two hosts are not enough without the owner")
+
+	current, required, err := m.GetApprovalProgress(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, current)
+	assert.Equal(t, 2, required)
+
+	p, err := m.GetQuorumProgress(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"owner"}, p.Missing)
+
+	require.NoError(t, m.AddSignature(req.ID, "owner", "Owner", "", time.Time{}, []byte("sig")))
+	got, err = m.GetRequest(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusApproved, got.Status)
+}
+
+func TestDeletionWithQuorum(t *testing.T) {
+	m := NewManager(t.TempDir()).WithDeletionQuorum(&Quorum{Required: []string{"owner"}, MinWeight: 2})
+
+	req, err := m.CreateDeletionRequest("owner", DeletionTypeSnapshot, []string{"snap1"}, nil, "reason", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, req.RequiredApprovals, "the quorum sets the required weight")
+
+	require.NoError(t, approveDeletion(m, req.ID, "host1", "Host 1", []byte("sig")))
+	require.NoError(t, approveDeletion(m, req.ID, "host2", "Host 2", []byte("sig")))
+	got, err := m.GetDeletionRequest(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, got.Status, "two hosts are not enough without the owner")
+
+	progress, err := Deletions.Progress(m, req.ID)
+	require.NoError(t, err)
+	current, required := progress.Counts()
+	assert.Equal(t, 1, current)
+	assert.Equal(t, 2, required)
+
+	require.NoError(t, approveDeletion(m, req.ID, "owner", "Owner", []byte("sig")))
+	got, err = m.GetDeletionRequest(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusApproved, got.Status)
+}
+
+func TestQuorumCountsDelegatedAuthority(t *testing.T) {
+	m := NewManager(t.TempDir())
+	d := addTestDelegation(t, m, "owner", "host1", time.Now().Add(-time.Minute), time.Hour)
+
+	req, err := m.CreateRequestWithQuorum("owner", "latest", "reason", nil, &Quorum{Required: []string{"owner"}, MinWeight: 2})
+	require.NoError(t, err)
+
+	// The host approves for the vacationing owner, then another host
+	require.NoError(t, m.AddDelegatedSignature(req.ID, d.ID, "Host 1", "", time.Time{}, []byte("sig")))
+	require.NoError(t, m.AddSignature(req.ID, "host2", "Host 2", "", time.Time{}, []byte("sig")))
+
+	progress, err := m.GetQuorumProgress(req.ID)
+	require.NoError(t, err)
+	assert.True(t, progress.Satisfied())
+}
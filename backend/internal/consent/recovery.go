@@ -0,0 +1,474 @@
+package consent
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/events"
+)
+
+// Social recovery waiting periods
+const (
+	DefaultRecoveryWait = 30 * 24 * time.Hour
+	MinRecoveryWait     = 7 * 24 * time.Hour
+)
+
+// RecoveryReminderInterval is how often everyone is reminded of a recovery
+// that is open, until it is used or cancelled
+const RecoveryReminderInterval = 24 * time.Hour
+
+// Recovery events recorded in the recovery audit trail
+const (
+	RecoveryOpened    = "opened"
+	RecoverySigned    = "signed"
+	RecoveryReminded  = "reminded"
+	RecoveryCancelled = "cancelled"
+	RecoveryUsed      = "used"
+)
+
+// RecoveryContact is someone trusted to vouch that a key holder is lost.
+// Contacts need not be key holders or run a node; they only hold a key.
+type RecoveryContact struct {
+	ID        string `json:"id"` // crypto.KeyID(PublicKey)
+	Name      string `json:"name"`
+	PublicKey []byte `json:"public_key"`
+}
+
+// RecoveryPolicy is the social recovery set up at init: Threshold of the
+// contacts can stand in for a key holder who lost their key, once the
+// waiting period has passed without anyone cancelling
+type RecoveryPolicy struct {
+	Contacts  []RecoveryContact `json:"contacts"`
+	Threshold int               `json:"threshold"`
+	// WaitingPeriod is a duration such as "720h" (default: DefaultRecoveryWait)
+	WaitingPeriod string `json:"waiting_period,omitempty"`
+}
+
+// Wait returns the waiting period before a recovery can be used
+func (p *RecoveryPolicy) Wait() (time.Duration, error) {
+	if p.WaitingPeriod == "" {
+		return DefaultRecoveryWait, nil
+	}
+	d, err := time.ParseDuration(p.WaitingPeriod)
+	if err != nil {
+		return 0, fmt.Errorf("invalid social recovery waiting period: %w", err)
+	}
+	if d < MinRecoveryWait {
+		return 0, fmt.Errorf("social recovery waiting period must be at least %d days", int(MinRecoveryWait.Hours()/24))
+	}
+	return d, nil
+}
+
+// Validate checks the contacts are distinct and the threshold reachable
+func (p *RecoveryPolicy) Validate() error {
+	if len(p.Contacts) == 0 {
+		return errors.New("social recovery needs at least one contact")
+	}
+	if p.Threshold < 1 || p.Threshold > len(p.Contacts) {
+		return fmt.Errorf("social recovery threshold must be between 1 and %d", len(p.Contacts))
+	}
+	seen := make(map[string]bool)
+	for _, c := range p.Contacts {
+		if c.Name == "" || len(c.PublicKey) == 0 {
+			return errors.New("recovery contacts need a name and public key")
+		}
+		if c.ID != crypto.KeyID(c.PublicKey) {
+			return fmt.Errorf("recovery contact %s has the wrong ID for its key", c.Name)
+		}
+		if seen[c.ID] {
+			return fmt.Errorf("recovery contact %s is listed twice", c.Name)
+		}
+		seen[c.ID] = true
+	}
+	_, err := p.Wait()
+	return err
+}
+
+// Contact returns the contact with the given ID, or nil
+func (p *RecoveryPolicy) Contact(id string) *RecoveryContact {
+	for i := range p.Contacts {
+		if p.Contacts[i].ID == id {
+			return &p.Contacts[i]
+		}
+	}
+	return nil
+}
+
+// Recovery is a claim, signed by recovery contacts, that a key holder lost
+// their key. Once UsableAt has passed it can stand in for that key holder's
+// approval of one restore request, unless a key holder cancels it first.
+type Recovery struct {
+	ID                   string              `json:"id"`
+	MissingKeyHolderID   string              `json:"missing_key_holder_id"`
+	MissingKeyHolderName string              `json:"missing_key_holder_name,omitempty"`
+	Reason               string              `json:"reason"`
+	OpenedAt             time.Time           `json:"opened_at"`
+	UsableAt             time.Time           `json:"usable_at"`
+	Signatures           []RecoverySignature `json:"signatures"` // Contacts' signatures over SignData()
+	CancelledAt          *time.Time          `json:"cancelled_at,omitempty"`
+	CancelledBy          string              `json:"cancelled_by,omitempty"` // Key holder ID
+	CancelSignature      []byte              `json:"cancel_signature,omitempty"`
+	UsedAt               *time.Time          `json:"used_at,omitempty"`
+	RequestID            string              `json:"request_id,omitempty"` // The request it approved
+}
+
+// RecoverySignature is one contact's signature on a recovery
+type RecoverySignature struct {
+	ContactID   string    `json:"contact_id"`
+	ContactName string    `json:"contact_name,omitempty"`
+	Signature   []byte    `json:"signature"`
+	SignedAt    time.Time `json:"signed_at"`
+}
+
+// RecoveryEvent is one entry in the recovery audit trail
+type RecoveryEvent struct {
+	At         time.Time `json:"at"`
+	Event      string    `json:"event"` // RecoveryOpened, RecoverySigned, ...
+	RecoveryID string    `json:"recovery_id"`
+	ActorID    string    `json:"actor_id,omitempty"`   // Contact or key holder who acted
+	RequestID  string    `json:"request_id,omitempty"` // For RecoveryUsed
+}
+
+// recoveryLog is persisted in recoveries.json
+type recoveryLog struct {
+	Recoveries []*Recovery     `json:"recoveries"`
+	Events     []RecoveryEvent `json:"events"`
+}
+
+// NewRecovery creates an unsigned recovery for a key holder who lost their key
+func NewRecovery(missingKeyHolderID, reason string) (*Recovery, error) {
+	if missingKeyHolderID == "" || reason == "" {
+		return nil, errors.New("recovery needs the missing key holder and a reason")
+	}
+	idBytes := make([]byte, 8)
+	if _, err := rand.Read(idBytes); err != nil {
+		return nil, err
+	}
+	return &Recovery{
+		ID:                 "rcv-" + hex.EncodeToString(idBytes),
+		MissingKeyHolderID: missingKeyHolderID,
+		Reason:             reason,
+		OpenedAt:           time.Now().Truncate(time.Second),
+	}, nil
+}
+
+// SignData is what recovery contacts sign
+func (r *Recovery) SignData() *crypto.RecoverySignData {
+	return &crypto.RecoverySignData{
+		RecoveryID:         r.ID,
+		MissingKeyHolderID: r.MissingKeyHolderID,
+		OpenedAt:           r.OpenedAt.Unix(),
+		Reason:             r.Reason,
+	}
+}
+
+// CancelSignData is what a key holder signs to cancel the recovery
+func (r *Recovery) CancelSignData() *crypto.RecoverySignData {
+	data := r.SignData()
+	data.Cancelled = true
+	return data
+}
+
+// Open reports whether the recovery is neither cancelled nor used
+func (r *Recovery) Open() bool {
+	return r.CancelledAt == nil && r.UsedAt == nil
+}
+
+// Status describes the recovery at now: "waiting", "usable", "cancelled"
+// or "used"
+func (r *Recovery) Status(now time.Time) string {
+	switch {
+	case r.CancelledAt != nil:
+		return "cancelled"
+	case r.UsedAt != nil:
+		return "used"
+	case now.Before(r.UsableAt):
+		return "waiting"
+	}
+	return "usable"
+}
+
+// signedBy reports whether the contact has signed the recovery
+func (r *Recovery) signedBy(contactID string) bool {
+	for _, s := range r.Signatures {
+		if s.ContactID == contactID {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Manager) loadRecoveries() (*recoveryLog, error) {
+	log := &recoveryLog{}
+	data, err := os.ReadFile(m.recoveriesPath)
+	if os.IsNotExist(err) {
+		return log, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, log); err != nil {
+		return nil, fmt.Errorf("failed to parse recoveries: %w", err)
+	}
+	return log, nil
+}
+
+func (m *Manager) saveRecoveries(log *recoveryLog) error {
+	if err := os.MkdirAll(filepath.Dir(m.recoveriesPath), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.recoveriesPath, data, 0600)
+}
+
+func (l *recoveryLog) find(id string) *Recovery {
+	for _, r := range l.Recoveries {
+		if r.ID == id {
+			return r
+		}
+	}
+	return nil
+}
+
+// OpenRecovery registers a recovery signed by the contact opening it. It
+// becomes usable after wait. The caller verifies the signature against the
+// contact's public key.
+func (m *Manager) OpenRecovery(r *Recovery, wait time.Duration) error {
+	if r.ID == "" || r.MissingKeyHolderID == "" {
+		return errors.New("recovery needs an ID and the missing key holder")
+	}
+	if len(r.Signatures) != 1 {
+		return errors.New("recovery must be signed by the contact opening it")
+	}
+
+	log, err := m.loadRecoveries()
+	if err != nil {
+		return err
+	}
+	if log.find(r.ID) != nil {
+		return fmt.Errorf("recovery %s already registered", r.ID)
+	}
+	for _, existing := range log.Recoveries {
+		if existing.MissingKeyHolderID == r.MissingKeyHolderID && existing.Open() {
+			return fmt.Errorf("recovery %s is already open for this key holder", existing.ID)
+		}
+	}
+
+	r.UsableAt = r.OpenedAt.Add(wait)
+	r.CancelledAt, r.CancelledBy, r.CancelSignature = nil, "", nil
+	r.UsedAt, r.RequestID = nil, ""
+	log.Recoveries = append(log.Recoveries, r)
+	log.Events = append(log.Events, RecoveryEvent{
+		At:         time.Now(),
+		Event:      RecoveryOpened,
+		RecoveryID: r.ID,
+		ActorID:    r.Signatures[0].ContactID,
+	})
+	if err := m.saveRecoveries(log); err != nil {
+		return err
+	}
+	m.publishRecovery(events.RecoveryOpened, r, fmt.Sprintf(
+		"Recovery contact %s says key holder %s lost their key (%s). From %s this recovery can approve a restore in their place, unless a key holder cancels it.",
+		r.Signatures[0].ContactName, r.missingName(), r.Reason, r.UsableAt.UTC().Format(time.RFC3339)))
+	return nil
+}
+
+// GetRecovery returns a recovery by ID
+func (m *Manager) GetRecovery(id string) (*Recovery, error) {
+	log, err := m.loadRecoveries()
+	if err != nil {
+		return nil, err
+	}
+	r := log.find(id)
+	if r == nil {
+		return nil, apperrors.ErrRecoveryNotFound
+	}
+	return r, nil
+}
+
+// ListRecoveries returns all recoveries and their audit trail
+func (m *Manager) ListRecoveries() ([]*Recovery, []RecoveryEvent, error) {
+	log, err := m.loadRecoveries()
+	if err != nil {
+		return nil, nil, err
+	}
+	return log.Recoveries, log.Events, nil
+}
+
+// AddRecoverySignature adds another contact's signature to an open
+// recovery. The caller verifies the signature against the contact's
+// public key.
+func (m *Manager) AddRecoverySignature(id string, sig RecoverySignature) (*Recovery, error) {
+	log, err := m.loadRecoveries()
+	if err != nil {
+		return nil, err
+	}
+	r := log.find(id)
+	if r == nil {
+		return nil, apperrors.ErrRecoveryNotFound
+	}
+	if !r.Open() {
+		return nil, apperrors.Newf(apperrors.CodeRecoveryNotUsable, "recovery is %s", r.Status(time.Now()))
+	}
+	if r.signedBy(sig.ContactID) {
+		return nil, fmt.Errorf("contact %s already signed this recovery", sig.ContactID)
+	}
+
+	r.Signatures = append(r.Signatures, sig)
+	log.Events = append(log.Events, RecoveryEvent{
+		At:         time.Now(),
+		Event:      RecoverySigned,
+		RecoveryID: r.ID,
+		ActorID:    sig.ContactID,
+	})
+	if err := m.saveRecoveries(log); err != nil {
+		return nil, err
+	}
+	m.publishRecovery(events.RecoverySigned, r, fmt.Sprintf(
+		"Recovery contact %s also vouches that key holder %s lost their key (%d signatures)",
+		sig.ContactName, r.missingName(), len(r.Signatures)))
+	return r, nil
+}
+
+// CancelRecovery ends an open recovery, e.g. because the supposedly lost
+// key holder is around after all. The caller verifies the signature
+// against the cancelling key holder's public key.
+func (m *Manager) CancelRecovery(id, keyHolderID string, signature []byte) (*Recovery, error) {
+	log, err := m.loadRecoveries()
+	if err != nil {
+		return nil, err
+	}
+	r := log.find(id)
+	if r == nil {
+		return nil, apperrors.ErrRecoveryNotFound
+	}
+	if !r.Open() {
+		return nil, apperrors.Newf(apperrors.CodeRecoveryNotUsable, "recovery is already %s", r.Status(time.Now()))
+	}
+
+	now := time.Now()
+	r.CancelledAt = &now
+	r.CancelledBy = keyHolderID
+	r.CancelSignature = signature
+	log.Events = append(log.Events, RecoveryEvent{
+		At:         now,
+		Event:      RecoveryCancelled,
+		RecoveryID: r.ID,
+		ActorID:    keyHolderID,
+	})
+	if err := m.saveRecoveries(log); err != nil {
+		return nil, err
+	}
+	m.publishRecovery(events.RecoveryCancelled, r, fmt.Sprintf(
+		"Key holder %s cancelled the recovery for %s", keyHolderID, r.missingName()))
+	return r, nil
+}
+
+// UseRecovery adds the recovery's approval of a restore request, counting
+// as the missing key holder's. The recovery must be past its waiting
+// period and open; it is then used up. The caller checks enough contacts
+// signed it.
+func (m *Manager) UseRecovery(requestID, recoveryID string) error {
+	log, err := m.loadRecoveries()
+	if err != nil {
+		return err
+	}
+	r := log.find(recoveryID)
+	if r == nil {
+		return apperrors.ErrRecoveryNotFound
+	}
+
+	now := time.Now()
+	if status := r.Status(now); status != "usable" {
+		if status == "waiting" {
+			return apperrors.Newf(apperrors.CodeRecoveryNotUsable, "recovery is waiting until %s", r.UsableAt.UTC().Format(time.RFC3339))
+		}
+		return apperrors.Newf(apperrors.CodeRecoveryNotUsable, "recovery is %s", status)
+	}
+
+	err = m.addApproval(requestID, Approval{
+		KeyHolderID:   r.MissingKeyHolderID,
+		KeyHolderName: r.MissingKeyHolderName,
+		ApprovedAt:    now,
+		RecoveryID:    r.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	r.UsedAt = &now
+	r.RequestID = requestID
+	log.Events = append(log.Events, RecoveryEvent{
+		At:         now,
+		Event:      RecoveryUsed,
+		RecoveryID: r.ID,
+		RequestID:  requestID,
+	})
+	if err := m.saveRecoveries(log); err != nil {
+		return err
+	}
+	m.publishRecovery(events.RecoveryUsed, r, fmt.Sprintf(
+		"The recovery for key holder %s approved restore request %s in their place", r.missingName(), requestID))
+	return nil
+}
+
+// RemindRecoveries records a reminder, which notifies everyone, for each
+// open recovery not mentioned in the last RecoveryReminderInterval. It
+// returns how many it reminded of.
+func (m *Manager) RemindRecoveries(now time.Time) (int, error) {
+	log, err := m.loadRecoveries()
+	if err != nil {
+		return 0, err
+	}
+
+	last := make(map[string]time.Time)
+	for _, e := range log.Events {
+		if e.At.After(last[e.RecoveryID]) {
+			last[e.RecoveryID] = e.At
+		}
+	}
+
+	var due []*Recovery
+	for _, r := range log.Recoveries {
+		if r.Open() && now.Sub(last[r.ID]) >= RecoveryReminderInterval {
+			due = append(due, r)
+			log.Events = append(log.Events, RecoveryEvent{At: now, Event: RecoveryReminded, RecoveryID: r.ID})
+		}
+	}
+	if len(due) == 0 {
+		return 0, nil
+	}
+	if err := m.saveRecoveries(log); err != nil {
+		return 0, err
+	}
+
+	for _, r := range due {
+		when := "It can approve a restore in their place now"
+		if now.Before(r.UsableAt) {
+			when = fmt.Sprintf("It can approve a restore in their place in %d days", int(r.UsableAt.Sub(now).Hours()/24)+1)
+		}
+		m.publishRecovery(events.RecoveryReminder, r, fmt.Sprintf(
+			"A recovery for key holder %s is open (%s, %d contact signatures). %s. If they haven't lost their key, cancel it: airgapper social-recovery cancel %s",
+			r.missingName(), r.Reason, len(r.Signatures), when, r.ID))
+	}
+	return len(due), nil
+}
+
+// missingName is the missing key holder's name, or their ID
+func (r *Recovery) missingName() string {
+	if r.MissingKeyHolderName != "" {
+		return r.MissingKeyHolderName
+	}
+	return r.MissingKeyHolderID
+}
@@ -0,0 +1,151 @@
+package consent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/events"
+)
+
+func openTestRecovery(t *testing.T, m *Manager, missing string, wait time.Duration) *Recovery {
+	t.Helper()
+	r, err := NewRecovery(missing, "lost their laptop and paper backup")
+	require.NoError(t, err)
+	r.Signatures = []RecoverySignature{{ContactID: "dana", ContactName: "Dana", Signature: []byte("sig"), SignedAt: time.Now()}}
+	require.NoError(t, m.OpenRecovery(r, wait))
+	return r
+}
+
+func TestRecoveryPolicyValidate(t *testing.T) {
+	pub, _, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+	contact := RecoveryContact{ID: crypto.KeyID(pub), Name: "Dana", PublicKey: pub}
+
+	p := &RecoveryPolicy{Contacts: []RecoveryContact{contact}, Threshold: 1}
+	require.NoError(t, p.Validate())
+	wait, err := p.Wait()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRecoveryWait, wait)
+	assert.Equal(t, &p.Contacts[0], p.Contact(contact.ID))
+
+	p.Threshold = 2
+	assert.Error(t, p.Validate(), "threshold above the contact count")
+	p.Threshold = 1
+	p.WaitingPeriod = "48h"
+	assert.Error(t, p.Validate(), "waiting period too short")
+	p.WaitingPeriod = ""
+	p.Contacts = append(p.Contacts, contact)
+	assert.Error(t, p.Validate(), "duplicate contact")
+	p.Contacts = []RecoveryContact{{ID: "forged", Name: "Dana", PublicKey: pub}}
+	assert.Error(t, p.Validate(), "ID not derived from the key")
+}
+
+func TestRecoveryWaitsBeforeUse(t *testing.T) {
+	m := NewManager(t.TempDir())
+	r := openTestRecovery(t, m, "alice", time.Hour)
+
+	req, err := m.CreateRequestWithConsensus("carol", "latest", "reason", nil, 2)
+	require.NoError(t, err)
+
+	err = m.UseRecovery(req.ID, r.ID)
+	assert.Equal(t, apperrors.CodeRecoveryNotUsable, apperrors.CodeOf(err))
+
+	// Only one open recovery per key holder
+	_, err = NewRecovery("alice", "")
+	assert.Error(t, err)
+	dup, err := NewRecovery("alice", "again")
+	require.NoError(t, err)
+	dup.Signatures = r.Signatures
+	assert.Error(t, m.OpenRecovery(dup, time.Hour))
+}
+
+func TestRecoveryApprovesInPlaceOfMissingKeyHolder(t *testing.T) {
+	m := NewManager(t.TempDir())
+	r := openTestRecovery(t, m, "alice", 0)
+
+	_, err := m.AddRecoverySignature(r.ID, RecoverySignature{ContactID: "dana", Signature: []byte("again")})
+	assert.Error(t, err, "each contact signs once")
+	_, err = m.AddRecoverySignature(r.ID, RecoverySignature{ContactID: "erin", ContactName: "Erin", Signature: []byte("sig")})
+	require.NoError(t, err)
+
+	req, err := m.CreateRequestWithConsensus("carol", "latest", "reason", nil, 2)
+	require.NoError(t, err)
+	require.NoError(t, m.AddSignature(req.ID, "bob", "Bob", "", time.Time{}, []byte("bob-sig")))
+	require.NoError(t, m.UseRecovery(req.ID, r.ID))
+
+	got, err := m.GetRequest(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusApproved, got.Status)
+	require.Len(t, got.Approvals, 2)
+	assert.Equal(t, "alice", got.Approvals[1].Authority())
+	assert.Equal(t, r.ID, got.Approvals[1].RecoveryID)
+
+	// Used up
+	req2, err := m.CreateRequestWithConsensus("carol", "latest", "reason", nil, 2)
+	require.NoError(t, err)
+	assert.Equal(t, apperrors.CodeRecoveryNotUsable, apperrors.CodeOf(m.UseRecovery(req2.ID, r.ID)))
+
+	recoveries, trail, err := m.ListRecoveries()
+	require.NoError(t, err)
+	require.Len(t, recoveries, 1)
+	assert.Equal(t, "used", recoveries[0].Status(time.Now()))
+	assert.Equal(t, req.ID, recoveries[0].RequestID)
+	require.Len(t, trail, 3)
+	assert.Equal(t, []string{RecoveryOpened, RecoverySigned, RecoveryUsed}, []string{trail[0].Event, trail[1].Event, trail[2].Event})
+}
+
+func TestCancelledRecoveryCannotBeUsed(t *testing.T) {
+	dir := t.TempDir()
+	m := NewManager(dir)
+	r := openTestRecovery(t, m, "alice", 0)
+
+	_, err := m.CancelRecovery(r.ID, "alice", []byte("still-here"))
+	require.NoError(t, err)
+	_, err = m.CancelRecovery(r.ID, "alice", []byte("still-here"))
+	assert.Error(t, err)
+	_, err = m.AddRecoverySignature(r.ID, RecoverySignature{ContactID: "erin"})
+	assert.Error(t, err)
+
+	req, err := m.CreateRequestWithConsensus("carol", "latest", "reason", nil, 1)
+	require.NoError(t, err)
+	assert.Equal(t, apperrors.CodeRecoveryNotUsable, apperrors.CodeOf(m.UseRecovery(req.ID, r.ID)))
+	_, err = m.GetRecovery("rcv-missing")
+	assert.Equal(t, apperrors.CodeRecoveryNotFound, apperrors.CodeOf(err))
+
+	list, _, err := events.Open(dir).Since(0, 0, events.Filter{Types: []string{"recovery"}})
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, events.RecoveryOpened, list[0].Type)
+	assert.Equal(t, events.RecoveryCancelled, list[1].Type)
+}
+
+func TestRemindRecoveries(t *testing.T) {
+	m := NewManager(t.TempDir())
+	r := openTestRecovery(t, m, "alice", DefaultRecoveryWait)
+	closed := openTestRecovery(t, m, "bob", 0)
+	_, err := m.CancelRecovery(closed.ID, "bob", []byte("sig"))
+	require.NoError(t, err)
+
+	now := time.Now()
+	n, err := m.RemindRecoveries(now)
+	require.NoError(t, err)
+	assert.Zero(t, n, "just opened")
+
+	n, err = m.RemindRecoveries(now.Add(RecoveryReminderInterval))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	n, err = m.RemindRecoveries(now.Add(RecoveryReminderInterval + time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, n, "reminded an hour ago")
+
+	_, trail, err := m.ListRecoveries()
+	require.NoError(t, err)
+	last := trail[len(trail)-1]
+	assert.Equal(t, RecoveryReminded, last.Event)
+	assert.Equal(t, r.ID, last.RecoveryID)
+}
@@ -1,9 +1,12 @@
 package consent
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
-// Request is an interface for consent requests (restore or deletion).
-// Both RestoreRequest and DeletionRequest implement this interface.
+// Request is an interface for consent requests (restore, deletion or
+// operation). RestoreRequest, DeletionRequest and Operation implement it.
 type Request interface {
 	// GetID returns the unique request ID.
 	GetID() string
@@ -21,6 +24,12 @@ type Request interface {
 	GetRequiredApprovals() int
 }
 
+// The names of the built-in request types
+const (
+	TypeRestore  = "restore"
+	TypeDeletion = "deletion"
+)
+
 // --- RestoreRequest implements Request ---
 
 func (r *RestoreRequest) GetID() string             { return r.ID }
@@ -30,6 +39,24 @@ func (r *RestoreRequest) GetExpiresAt() time.Time   { return r.ExpiresAt }
 func (r *RestoreRequest) GetApprovals() []Approval  { return r.Approvals }
 func (r *RestoreRequest) AddApproval(a Approval)    { r.Approvals = append(r.Approvals, a) }
 func (r *RestoreRequest) GetRequiredApprovals() int { return r.RequiredApprovals }
+func (r *RestoreRequest) GetQuorum() *Quorum        { return r.Quorum }
+func (r *RestoreRequest) GetCorrelationID() string  { return r.CorrelationID }
+func (r *RestoreRequest) OperationType() string     { return TypeRestore }
+
+func (r *RestoreRequest) decide(status RequestStatus, by string, at time.Time) {
+	r.Status, r.ApprovedBy, r.ApprovedAt = status, by, &at
+}
+
+func (r *RestoreRequest) eventData() map[string]string {
+	data := map[string]string{"requester": r.Requester}
+	if r.SnapshotID != "" {
+		data["snapshot_id"] = r.SnapshotID
+	}
+	if r.Purpose != "" {
+		data["purpose"] = r.Purpose
+	}
+	return data
+}
 
 // --- DeletionRequest implements Request ---
 
@@ -40,3 +67,21 @@ func (r *DeletionRequest) GetExpiresAt() time.Time   { return r.ExpiresAt }
 func (r *DeletionRequest) GetApprovals() []Approval  { return r.Approvals }
 func (r *DeletionRequest) AddApproval(a Approval)    { r.Approvals = append(r.Approvals, a) }
 func (r *DeletionRequest) GetRequiredApprovals() int { return r.RequiredApprovals }
+func (r *DeletionRequest) GetQuorum() *Quorum        { return r.Quorum }
+func (r *DeletionRequest) GetCorrelationID() string  { return r.CorrelationID }
+func (r *DeletionRequest) OperationType() string     { return TypeDeletion }
+
+func (r *DeletionRequest) decide(status RequestStatus, by string, at time.Time) {
+	r.Status, r.ApprovedBy, r.ApprovedAt = status, by, &at
+}
+
+func (r *DeletionRequest) eventData() map[string]string {
+	data := map[string]string{
+		"requester":     r.Requester,
+		"deletion_type": string(r.DeletionType),
+	}
+	if len(r.SnapshotIDs) > 0 {
+		data["snapshot_ids"] = strings.Join(r.SnapshotIDs, ",")
+	}
+	return data
+}
@@ -0,0 +1,85 @@
+package consent
+
+import (
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+)
+
+// RequesterContext describes the device a request was made from. Approvers
+// see it next to the reason, so a request from an unknown machine, an old
+// version or an unexpected address stands out. The requester's signature
+// covers everything but SourceIP, which approvals cover too.
+type RequesterContext struct {
+	Hostname string `json:"hostname,omitempty"`
+	OS       string `json:"os,omitempty"`
+	Version  string `json:"version,omitempty"` // Airgapper version
+	// KeyFingerprint is the key ID of the requester's signing key, if any
+	KeyFingerprint string `json:"key_fingerprint,omitempty"`
+	// SourceIP is the requester's address as seen by the node the request
+	// was made on; empty for requests made on the node itself
+	SourceIP string `json:"source_ip,omitempty"`
+}
+
+// LocalRequesterContext describes this machine, running Airgapper version,
+// with signing key publicKey (nil if none)
+func LocalRequesterContext(version string, publicKey []byte) *RequesterContext {
+	c := &RequesterContext{
+		OS:      runtime.GOOS + "/" + runtime.GOARCH,
+		Version: version,
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		c.Hostname = hostname
+	}
+	if publicKey != nil {
+		c.KeyFingerprint = crypto.KeyID(publicKey)
+	}
+	return c
+}
+
+// WithRequesterContext returns a manager whose created requests record
+// the device they were made from
+func (m *Manager) WithRequesterContext(rc *RequesterContext) *Manager {
+	c := *m
+	c.requesterContext = rc
+	return &c
+}
+
+// String describes the device on one line, e.g.
+// "laptop (linux/amd64) v1.4.0 from 192.168.1.20 key 1a2b3c4d5e6f7a8b"
+func (c *RequesterContext) String() string {
+	if c == nil {
+		return "unknown device"
+	}
+	host := c.Hostname
+	if host == "" {
+		host = "unknown host"
+	}
+	parts := []string{host}
+	if c.OS != "" {
+		parts = append(parts, "("+c.OS+")")
+	}
+	if c.Version != "" {
+		parts = append(parts, "v"+strings.TrimPrefix(c.Version, "v"))
+	}
+	if c.SourceIP != "" {
+		parts = append(parts, "from "+c.SourceIP)
+	}
+	if c.KeyFingerprint != "" {
+		parts = append(parts, "key "+c.KeyFingerprint)
+	}
+	return strings.Join(parts, " ")
+}
+
+// signContext adds the requester context to data a key holder signs
+func (c *RequesterContext) signContext(data *crypto.RestoreRequestSignData) {
+	if c == nil {
+		return
+	}
+	data.RequesterHostname = c.Hostname
+	data.RequesterOS = c.OS
+	data.RequesterVersion = c.Version
+	data.RequesterKeyFingerprint = c.KeyFingerprint
+}
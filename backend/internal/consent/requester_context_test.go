@@ -0,0 +1,49 @@
+package consent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+)
+
+func TestRequesterContext(t *testing.T) {
+	dir := t.TempDir()
+	rc := &RequesterContext{Hostname: "alice-laptop", OS: "linux/amd64", Version: "1.4.0", KeyFingerprint: "1a2b3c4d5e6f7a8b", SourceIP: "192.168.1.20"}
+	req, err := NewManager(dir).WithRequesterContext(rc).CreateRequestWithConsensus("alice", "latest", "test", nil, 2)
+	require.NoError(t, err)
+
+	stored, err := NewManager(dir).GetRequest(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, rc, stored.RequesterContext)
+	assert.Equal(t, "alice-laptop (linux/amd64) v1.4.0 from 192.168.1.20 key 1a2b3c4d5e6f7a8b", stored.RequesterContext.String())
+
+	// The requester signs the device but not the address the node saw
+	signed := stored.SignData("alice")
+	assert.Equal(t, "alice-laptop", signed.RequesterHostname)
+	assert.Equal(t, "1a2b3c4d5e6f7a8b", signed.RequesterKeyFingerprint)
+	assert.Empty(t, signed.SourceIP)
+	moved := *stored
+	moved.RequesterContext = &RequesterContext{Hostname: "alice-laptop", OS: "linux/amd64", Version: "1.4.0", KeyFingerprint: "1a2b3c4d5e6f7a8b", SourceIP: "203.0.113.9"}
+	assert.Equal(t, hashSignData(t, signed), hashSignData(t, moved.SignData("alice")))
+
+	// Approvals cover the address too
+	approval := stored.ApprovalSignData("bob", "nonce", stored.CreatedAt)
+	assert.Equal(t, "192.168.1.20", approval.SourceIP)
+	assert.NotEqual(t, hashSignData(t, approval), hashSignData(t, moved.ApprovalSignData("bob", "nonce", stored.CreatedAt)))
+
+	// Requests without a context sign the same data as before
+	plain := *stored
+	plain.RequesterContext = nil
+	assert.Empty(t, plain.SignData("alice").RequesterHostname)
+	assert.Equal(t, "unknown device", plain.RequesterContext.String())
+}
+
+func hashSignData(t *testing.T, data *crypto.RestoreRequestSignData) []byte {
+	t.Helper()
+	h, err := data.Hash()
+	require.NoError(t, err)
+	return h
+}
@@ -0,0 +1,184 @@
+package consent
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+// Restore terms let a host approve a large restore on conditions that keep
+// it from saturating their uplink for days: a daily window it may start in
+// and a bandwidth cap. The owner's restore executor defers the start and
+// passes the cap to restic, and reports its progress to both sides.
+
+// RestoreTerms are the conditions an approver attached to their approval
+type RestoreTerms struct {
+	// Window is a daily window of the approver's local time such as
+	// "22:00-06:00", which may wrap past midnight, that the restore may
+	// only start in. Empty for any time.
+	Window string `json:"window,omitempty"`
+
+	// UTCOffset is the approver's offset from UTC in seconds when they
+	// approved, so the window is kept in their time wherever the restore runs
+	UTCOffset int `json:"utc_offset,omitempty"`
+
+	// LimitKiBps caps the restore's download rate in KiB/s; zero for no cap
+	LimitKiBps int `json:"limit_kibps,omitempty"`
+
+	// SetBy is the approver who attached the terms
+	SetBy string `json:"set_by,omitempty"`
+}
+
+// Restore progress statuses
+const (
+	ProgressScheduled = "scheduled" // Waiting for the restore window to open
+	ProgressRunning   = "running"
+	ProgressCompleted = "completed"
+	ProgressFailed    = "failed"
+)
+
+// RestoreProgress is how far an approved request's restore has got, as
+// reported by the node executing it
+type RestoreProgress struct {
+	Status     string     `json:"status"`
+	StartsAt   *time.Time `json:"starts_at,omitempty"` // When a scheduled restore will start
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Files      int        `json:"files,omitempty"`    // Files the restore covers
+	Restored   int        `json:"restored,omitempty"` // Files restored so far
+	Error      string     `json:"error,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// WithRestoreTerms returns a manager whose approvals attach terms to the
+// request they approve
+func (m *Manager) WithRestoreTerms(terms *RestoreTerms) *Manager {
+	c := *m
+	c.terms = terms
+	return &c
+}
+
+// Validate checks the window is well-formed and the cap isn't negative
+func (t *RestoreTerms) Validate() error {
+	if t.Window != "" {
+		if _, _, err := parseHours(t.Window); err != nil {
+			return err
+		}
+	}
+	if t.LimitKiBps < 0 {
+		return errors.New("restore bandwidth limit cannot be negative")
+	}
+	return nil
+}
+
+// IsZero reports whether the terms set no conditions
+func (t *RestoreTerms) IsZero() bool {
+	return t == nil || (t.Window == "" && t.LimitKiBps == 0)
+}
+
+// zone is the approver's time zone, as a fixed offset
+func (t *RestoreTerms) zone() *time.Location {
+	return time.FixedZone("", t.UTCOffset)
+}
+
+// String describes the terms, e.g. "start 22:00-06:00 UTC+02:00, limit 10240 KiB/s"
+func (t *RestoreTerms) String() string {
+	if t.IsZero() {
+		return "none"
+	}
+	var s string
+	if t.Window != "" {
+		s = "start " + t.Window + " UTC" + time.Unix(0, 0).In(t.zone()).Format("-07:00")
+	}
+	if t.LimitKiBps > 0 {
+		if s != "" {
+			s += ", "
+		}
+		s += fmt.Sprintf("limit %d KiB/s", t.LimitKiBps)
+	}
+	return s
+}
+
+// attachTerms records the manager's restore terms, if any, on req as
+// approver's
+func (m *Manager) attachTerms(req *RestoreRequest, approver string) {
+	if m.terms.IsZero() {
+		return
+	}
+	t := *m.terms
+	t.SetBy = approver
+	req.Terms = append(req.Terms, t)
+}
+
+// LimitKiBps is the tightest bandwidth cap any approver set, in KiB/s, or
+// zero if none did
+func (r *RestoreRequest) LimitKiBps() int {
+	limit := 0
+	for _, t := range r.Terms {
+		if t.LimitKiBps > 0 && (limit == 0 || t.LimitKiBps < limit) {
+			limit = t.LimitKiBps
+		}
+	}
+	return limit
+}
+
+// NextStart returns the first minute from now at which every approver's
+// window is open, or now if they all are
+func (r *RestoreRequest) NextStart(now time.Time) (time.Time, error) {
+	if r.windowsOpen(now) {
+		return now, nil
+	}
+	next := now.Truncate(time.Minute)
+	for i := 0; i < 24*60; i++ {
+		next = next.Add(time.Minute)
+		if r.windowsOpen(next) {
+			return next, nil
+		}
+	}
+	return time.Time{}, apperrors.New(apperrors.CodeInvalidArgument, "the approvers' restore windows never overlap; ask them to agree on one")
+}
+
+// windowsOpen reports whether every approver's window is open at t
+func (r *RestoreRequest) windowsOpen(t time.Time) bool {
+	for _, terms := range r.Terms {
+		if terms.Window == "" {
+			continue
+		}
+		start, end, err := parseHours(terms.Window)
+		if err != nil || !withinHours(start, end, t.In(terms.zone())) {
+			return false
+		}
+	}
+	return true
+}
+
+// RecordProgress records how far request id's restore has got. Only
+// approved requests can be restored, so only their progress is recorded.
+func (m *Manager) RecordProgress(id string, p RestoreProgress) (*RestoreRequest, error) {
+	req, err := m.GetRequest(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.IsBrowse() || req.IsKeyExport() {
+		// A browse or key export approval doesn't cover a restore
+		return nil, apperrors.ErrWrongRequestPurpose
+	}
+	if req.Status != StatusApproved {
+		return nil, apperrors.ErrRequestNotApproved
+	}
+	switch p.Status {
+	case ProgressScheduled, ProgressRunning, ProgressCompleted, ProgressFailed:
+	default:
+		return nil, apperrors.Newf(apperrors.CodeInvalidArgument, "unknown restore progress status %q", p.Status)
+	}
+	if p.UpdatedAt.IsZero() {
+		p.UpdatedAt = time.Now()
+	}
+	req.Progress = &p
+	if err := m.saveRequest(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
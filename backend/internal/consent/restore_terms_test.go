@@ -0,0 +1,101 @@
+package consent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+func TestRestoreTermsAttachedOnApproval(t *testing.T) {
+	dir := t.TempDir()
+	m := NewManager(dir)
+	req, err := m.CreateRequestWithConsensus("alice", "latest", "test", nil, 2)
+	require.NoError(t, err)
+
+	bob := &RestoreTerms{Window: "22:00-06:00", UTCOffset: 3600, LimitKiBps: 10240}
+	require.NoError(t, bob.Validate())
+	require.NoError(t, m.WithRestoreTerms(bob).AddSignature(req.ID, "bbbb", "bob", "", time.Time{}, []byte("sig")))
+	require.NoError(t, m.WithRestoreTerms(&RestoreTerms{LimitKiBps: 2048}).AddSignature(req.ID, "cccc", "carol", "", time.Time{}, []byte("sig")))
+
+	stored, err := m.GetRequest(req.ID)
+	require.NoError(t, err)
+	require.Len(t, stored.Terms, 2)
+	assert.Equal(t, "bob", stored.Terms[0].SetBy)
+	assert.Equal(t, "start 22:00-06:00 UTC+01:00, limit 10240 KiB/s", stored.Terms[0].String())
+	assert.Equal(t, 2048, stored.LimitKiBps(), "the tightest cap wins")
+
+	// Approvals without terms attach none
+	plain, err := m.CreateRequest("alice", "latest", "test", nil)
+	require.NoError(t, err)
+	require.NoError(t, m.WithRestoreTerms(&RestoreTerms{}).Approve(plain.ID, "bob", []byte("share")))
+	stored, err = m.GetRequest(plain.ID)
+	require.NoError(t, err)
+	assert.Empty(t, stored.Terms)
+	assert.Zero(t, stored.LimitKiBps())
+}
+
+func TestRestoreTermsNextStart(t *testing.T) {
+	utc := time.FixedZone("", 0)
+	req := &RestoreRequest{Terms: []RestoreTerms{{Window: "22:00-06:00", UTCOffset: 2 * 3600}}}
+
+	// 21:30 UTC is 23:30 in the approver's zone: open
+	now := time.Date(2025, 3, 4, 21, 30, 0, 0, utc)
+	start, err := req.NextStart(now)
+	require.NoError(t, err)
+	assert.Equal(t, now, start)
+
+	// 12:00 UTC is 14:00 there: wait until 22:00 there, 20:00 UTC
+	start, err = req.NextStart(time.Date(2025, 3, 4, 12, 0, 0, 0, utc))
+	require.NoError(t, err)
+	assert.True(t, start.Equal(time.Date(2025, 3, 4, 20, 0, 0, 0, utc)), start)
+
+	// All windows must be open at once
+	req.Terms = append(req.Terms, RestoreTerms{Window: "23:00-01:00"})
+	start, err = req.NextStart(time.Date(2025, 3, 4, 12, 0, 0, 0, utc))
+	require.NoError(t, err)
+	assert.True(t, start.Equal(time.Date(2025, 3, 4, 23, 0, 0, 0, utc)), start)
+
+	req.Terms = append(req.Terms, RestoreTerms{Window: "09:00-10:00"})
+	_, err = req.NextStart(now)
+	assert.Error(t, err, "windows that never overlap")
+}
+
+func TestRestoreTermsValidate(t *testing.T) {
+	assert.Error(t, (&RestoreTerms{Window: "22"}).Validate())
+	assert.Error(t, (&RestoreTerms{LimitKiBps: -1}).Validate())
+	assert.True(t, (*RestoreTerms)(nil).IsZero())
+	assert.Equal(t, "none", (&RestoreTerms{}).String())
+}
+
+func TestRecordProgress(t *testing.T) {
+	m := NewManager(t.TempDir())
+	req, err := m.CreateRequest("alice", "latest", "test", nil)
+	require.NoError(t, err)
+
+	_, err = m.RecordProgress(req.ID, RestoreProgress{Status: ProgressRunning})
+	assert.Error(t, err, "not approved yet")
+
+	require.NoError(t, m.Approve(req.ID, "bob", []byte("share")))
+	_, err = m.RecordProgress(req.ID, RestoreProgress{Status: "halfway"})
+	assert.Error(t, err)
+
+	updated, err := m.RecordProgress(req.ID, RestoreProgress{Status: ProgressCompleted, Files: 3, Restored: 3})
+	require.NoError(t, err)
+	assert.Equal(t, ProgressCompleted, updated.Progress.Status)
+	assert.False(t, updated.Progress.UpdatedAt.IsZero())
+
+	stored, err := m.GetRequest(req.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stored.Progress.Restored)
+
+	// A browse approval doesn't cover a restore
+	browse, err := m.CreateBrowseRequest("alice", "latest", "test")
+	require.NoError(t, err)
+	require.NoError(t, m.Approve(browse.ID, "bob", []byte("share")))
+	_, err = m.RecordProgress(browse.ID, RestoreProgress{Status: ProgressRunning})
+	assert.ErrorIs(t, err, apperrors.ErrWrongRequestPurpose)
+}
@@ -0,0 +1,245 @@
+package consent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ApprovalRules are a node's own rules for the restore requests it
+// receives, applied before a request waits for manual approval. Every
+// automatic decision is recorded in the rule decision log.
+type ApprovalRules struct {
+	// DenyPaths denies restores of paths matching any of these patterns. A
+	// pattern with a slash matches a path or a directory above it
+	// ("/home/*/.ssh"); one without matches any element of a path
+	// ("*.key"). While any is set, restores of whole snapshots and of
+	// private paths this node can't read are denied too.
+	DenyPaths []string `json:"deny_paths,omitempty"`
+
+	// DenyUnknownKeys denies requests that aren't signed by, or don't carry
+	// the fingerprint of, a key this node knows
+	DenyUnknownKeys bool `json:"deny_unknown_keys,omitempty"`
+
+	// AutoApproveMaxBytes approves restores of a single path its requester
+	// declares at most this many bytes. Zero turns it off.
+	AutoApproveMaxBytes int64 `json:"auto_approve_max_bytes,omitempty"`
+
+	// AutoApproveHours limits automatic approvals to a daily window of
+	// local time such as "09:00-18:00", which may wrap past midnight.
+	// Outside it requests wait for manual approval. Empty for any time.
+	AutoApproveHours string `json:"auto_approve_hours,omitempty"`
+}
+
+// Rule decisions
+const (
+	RuleDeny    = "deny"
+	RuleApprove = "approve"
+	RuleManual  = "manual"
+)
+
+// RulesActor is recorded as the approver or denier of requests the
+// approval rules decided
+const RulesActor = "approval-rules"
+
+// RuleDecision is the approval rules' verdict on a request, as recorded in
+// the rule decision log
+type RuleDecision struct {
+	At        time.Time `json:"at"`
+	RequestID string    `json:"request_id"`
+	Decision  string    `json:"decision"`       // RuleDeny, RuleApprove or RuleManual
+	Rule      string    `json:"rule,omitempty"` // The rule that decided, e.g. "deny_paths"
+	Reason    string    `json:"reason"`
+}
+
+// Decided reports whether a rule decided the request, rather than it
+// waiting for manual approval as usual
+func (d RuleDecision) Decided() bool {
+	return d.Rule != ""
+}
+
+// Validate checks the patterns and hours are well-formed
+func (r *ApprovalRules) Validate() error {
+	for _, p := range r.DenyPaths {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid deny path %q: %w", p, err)
+		}
+	}
+	if r.AutoApproveMaxBytes < 0 {
+		return errors.New("auto_approve_max_bytes cannot be negative")
+	}
+	if r.AutoApproveHours != "" {
+		if _, _, err := parseHours(r.AutoApproveHours); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Evaluate decides req at now. knownKey reports whether a key ID belongs to
+// a key this node knows. Deny rules come first; a request no rule decides
+// waits for manual approval.
+func (r *ApprovalRules) Evaluate(req *RestoreRequest, knownKey func(keyID string) bool, now time.Time) RuleDecision {
+	d := RuleDecision{At: now, RequestID: req.ID, Decision: RuleManual}
+	if r == nil {
+		return d
+	}
+	deny := func(rule, reason string) RuleDecision {
+		d.Decision, d.Rule, d.Reason = RuleDeny, rule, reason
+		return d
+	}
+
+	if r.DenyUnknownKeys {
+		keys := requestKeys(req)
+		if len(keys) == 0 {
+			return deny("deny_unknown_keys", "request carries no key")
+		}
+		for _, k := range keys {
+			if !knownKey(k) {
+				return deny("deny_unknown_keys", "unknown key "+k)
+			}
+		}
+	}
+
+	restore := !req.IsKeyExport() && !req.IsBrowse()
+	if restore && len(r.DenyPaths) > 0 {
+		switch {
+		case len(req.Paths) == 0:
+			return deny("deny_paths", "whole-snapshot restores are denied while paths are")
+		case req.HasSealedPaths():
+			return deny("deny_paths", "private paths can't be checked")
+		}
+		for _, p := range req.Paths {
+			for _, pattern := range r.DenyPaths {
+				if matchesPath(pattern, p) {
+					return deny("deny_paths", fmt.Sprintf("%s matches %s", p, pattern))
+				}
+			}
+		}
+	}
+
+	if restore && r.AutoApproveMaxBytes > 0 && len(req.Paths) == 1 && !req.HasSealedPaths() &&
+		req.SizeBytes > 0 && req.SizeBytes <= r.AutoApproveMaxBytes {
+		if r.AutoApproveHours != "" {
+			start, end, err := parseHours(r.AutoApproveHours)
+			if err != nil || !withinHours(start, end, now.Local()) {
+				d.Rule, d.Reason = "auto_approve_hours", "outside "+r.AutoApproveHours+", needs manual approval"
+				return d
+			}
+		}
+		d.Decision, d.Rule = RuleApprove, "auto_approve_max_bytes"
+		d.Reason = fmt.Sprintf("single path of %d bytes (limit %d)", req.SizeBytes, r.AutoApproveMaxBytes)
+	}
+	return d
+}
+
+// requestKeys returns the key IDs a request claims: the key that signed it
+// and the fingerprint in its requester context
+func requestKeys(req *RestoreRequest) []string {
+	var keys []string
+	if req.RequesterKeyID != "" {
+		keys = append(keys, req.RequesterKeyID)
+	}
+	if rc := req.RequesterContext; rc != nil && rc.KeyFingerprint != "" && rc.KeyFingerprint != req.RequesterKeyID {
+		keys = append(keys, rc.KeyFingerprint)
+	}
+	return keys
+}
+
+// matchesPath reports whether p, or with a pattern that has no slash any
+// element of p, matches pattern. Patterns with a slash also match
+// directories above p.
+func matchesPath(pattern, p string) bool {
+	p = path.Clean(p)
+	if !strings.Contains(pattern, "/") {
+		for _, elem := range strings.Split(p, "/") {
+			if ok, _ := path.Match(pattern, elem); ok {
+				return true
+			}
+		}
+		return false
+	}
+	for {
+		if ok, _ := path.Match(path.Clean(pattern), p); ok {
+			return true
+		}
+		if p == "/" || p == "." {
+			return false
+		}
+		p = path.Dir(p)
+	}
+}
+
+// parseHours parses a window such as "09:00-18:00" into minutes since
+// midnight
+func parseHours(s string) (start, end int, err error) {
+	from, to, ok := strings.Cut(s, "-")
+	if ok {
+		if start, err = parseClock(from); err == nil {
+			end, err = parseClock(to)
+		}
+	}
+	if !ok || err != nil {
+		return 0, 0, fmt.Errorf("invalid hours %q, use e.g. 09:00-18:00", s)
+	}
+	if start == end {
+		return 0, 0, fmt.Errorf("hours %q are an empty window", s)
+	}
+	return start, end, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// withinHours reports whether now, in its own location's time, falls in
+// the window from start to end minutes since midnight
+func withinHours(start, end int, now time.Time) bool {
+	m := now.Hour()*60 + now.Minute()
+	if start < end {
+		return m >= start && m < end
+	}
+	return m >= start || m < end
+}
+
+// RecordRuleDecision adds d to the rule decision log
+func (m *Manager) RecordRuleDecision(d RuleDecision) error {
+	decisions, err := m.ListRuleDecisions()
+	if err != nil {
+		return err
+	}
+	decisions = append(decisions, d)
+	data, err := json.MarshalIndent(decisions, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.rulesLogPath), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(m.rulesLogPath, data, 0600)
+}
+
+// ListRuleDecisions returns the rule decision log, oldest first
+func (m *Manager) ListRuleDecisions() ([]RuleDecision, error) {
+	var decisions []RuleDecision
+	data, err := os.ReadFile(m.rulesLogPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &decisions); err != nil {
+		return nil, fmt.Errorf("failed to parse rule decisions: %w", err)
+	}
+	return decisions, nil
+}
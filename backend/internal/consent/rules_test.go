@@ -0,0 +1,96 @@
+package consent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApprovalRules(t *testing.T) {
+	known := func(keyID string) bool { return keyID == "aaaa" }
+	noon := time.Date(2025, 3, 4, 12, 0, 0, 0, time.Local)
+	night := time.Date(2025, 3, 4, 23, 30, 0, 0, time.Local)
+	rules := &ApprovalRules{
+		DenyPaths:           []string{"/home/*/.ssh", "*.key"},
+		DenyUnknownKeys:     true,
+		AutoApproveMaxBytes: 1 << 20,
+		AutoApproveHours:    "09:00-18:00",
+	}
+	require.NoError(t, rules.Validate())
+
+	restore := func(size int64, paths ...string) *RestoreRequest {
+		return &RestoreRequest{ID: "r1", RequesterKeyID: "aaaa", Paths: paths, SizeBytes: size}
+	}
+
+	tests := []struct {
+		name     string
+		req      *RestoreRequest
+		at       time.Time
+		decision string
+		rule     string
+	}{
+		{"small single file", restore(4096, "/home/alice/notes.txt"), noon, RuleApprove, "auto_approve_max_bytes"},
+		{"outside hours", restore(4096, "/home/alice/notes.txt"), night, RuleManual, "auto_approve_hours"},
+		{"too big", restore(2<<20, "/home/alice/video.mp4"), noon, RuleManual, ""},
+		{"no declared size", restore(0, "/home/alice/notes.txt"), noon, RuleManual, ""},
+		{"several paths", restore(4096, "/home/alice/a", "/home/alice/b"), noon, RuleManual, ""},
+		{"under denied directory", restore(4096, "/home/alice/.ssh/id_ed25519"), noon, RuleDeny, "deny_paths"},
+		{"denied name", restore(4096, "/srv/tls/server.key"), noon, RuleDeny, "deny_paths"},
+		{"whole snapshot", restore(0), noon, RuleDeny, "deny_paths"},
+		{"unknown key", &RestoreRequest{ID: "r1", RequesterKeyID: "bbbb", Paths: []string{"/a"}}, noon, RuleDeny, "deny_unknown_keys"},
+		{"unsigned", &RestoreRequest{ID: "r1", Paths: []string{"/a"}}, noon, RuleDeny, "deny_unknown_keys"},
+		{"unknown fingerprint", &RestoreRequest{ID: "r1", RequesterKeyID: "aaaa", Paths: []string{"/a"},
+			RequesterContext: &RequesterContext{KeyFingerprint: "bbbb"}}, noon, RuleDeny, "deny_unknown_keys"},
+		{"browse isn't path checked", &RestoreRequest{ID: "r1", RequesterKeyID: "aaaa", Purpose: PurposeBrowse}, noon, RuleManual, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := rules.Evaluate(tt.req, known, tt.at)
+			assert.Equal(t, tt.decision, d.Decision, d.Reason)
+			assert.Equal(t, tt.rule, d.Rule)
+			assert.Equal(t, tt.rule != "", d.Decided())
+		})
+	}
+
+	// No rules decide nothing
+	var none *ApprovalRules
+	assert.False(t, none.Evaluate(restore(1, "/a"), known, noon).Decided())
+}
+
+func TestApprovalRulesHoursWrap(t *testing.T) {
+	rules := &ApprovalRules{AutoApproveMaxBytes: 100, AutoApproveHours: "22:00-06:00"}
+	req := &RestoreRequest{Paths: []string{"/a"}, SizeBytes: 10}
+	known := func(string) bool { return true }
+
+	at := func(hour int) string {
+		return rules.Evaluate(req, known, time.Date(2025, 3, 4, hour, 0, 0, 0, time.Local)).Decision
+	}
+	assert.Equal(t, RuleApprove, at(23))
+	assert.Equal(t, RuleApprove, at(5))
+	assert.Equal(t, RuleManual, at(12))
+}
+
+func TestApprovalRulesValidate(t *testing.T) {
+	assert.Error(t, (&ApprovalRules{DenyPaths: []string{"[abc"}}).Validate())
+	assert.Error(t, (&ApprovalRules{AutoApproveHours: "9-5"}).Validate())
+	assert.Error(t, (&ApprovalRules{AutoApproveHours: "09:00-09:00"}).Validate())
+	assert.Error(t, (&ApprovalRules{AutoApproveMaxBytes: -1}).Validate())
+	assert.NoError(t, (&ApprovalRules{AutoApproveHours: "22:00-06:00"}).Validate())
+}
+
+func TestRuleDecisionLog(t *testing.T) {
+	m := NewManager(t.TempDir())
+	decisions, err := m.ListRuleDecisions()
+	require.NoError(t, err)
+	assert.Empty(t, decisions)
+
+	require.NoError(t, m.RecordRuleDecision(RuleDecision{RequestID: "r1", Decision: RuleDeny, Rule: "deny_paths"}))
+	require.NoError(t, m.RecordRuleDecision(RuleDecision{RequestID: "r2", Decision: RuleApprove, Rule: "auto_approve_max_bytes"}))
+	decisions, err = m.ListRuleDecisions()
+	require.NoError(t, err)
+	require.Len(t, decisions, 2)
+	assert.Equal(t, "r1", decisions[0].RequestID)
+	assert.Equal(t, RuleApprove, decisions[1].Decision)
+}
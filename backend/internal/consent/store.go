@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
-	"time"
 
 	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
 	"github.com/lcrostarosa/airgapper/backend/internal/logging"
@@ -114,79 +113,50 @@ func (s *RequestStore[T]) Save(req T) error {
 	return os.WriteFile(path, data, 0600)
 }
 
-// AddApproval adds an approval to a request with validation.
-// Returns apperrors.ErrRequestNotPending, apperrors.ErrRequestExpired, or apperrors.ErrAlreadyApproved on failure.
-func (s *RequestStore[T]) AddApproval(id, keyHolderID, keyHolderName string, signature []byte) error {
-	req, err := s.Get(id)
-	if err != nil {
-		return err
-	}
-
-	if req.GetStatus() != StatusPending {
-		return apperrors.ErrRequestNotPending
-	}
-
-	if time.Now().After(req.GetExpiresAt()) {
-		req.SetStatus(StatusExpired)
-		if err := s.Save(req); err != nil {
-			logging.Warn("Failed to save expired request", logging.Err(err))
-		}
-		return apperrors.ErrRequestExpired
-	}
-
-	// Check if this key holder already approved
-	for _, approval := range req.GetApprovals() {
-		if approval.KeyHolderID == keyHolderID {
-			return apperrors.ErrAlreadyApproved
+// expireWith returns an expiry check marking pending requests expired, and
+// saving them, once m considers them expired
+func expireWith[T Request](m *Manager) func(*RequestStore[T], T) {
+	return func(s *RequestStore[T], req T) {
+		if req.GetStatus() == StatusPending && m.expired(req.GetExpiresAt()) {
+			req.SetStatus(StatusExpired)
+			if err := s.Save(req); err != nil {
+				logging.Warn("Failed to save expired request", logging.Err(err))
+			}
 		}
 	}
-
-	// Add the approval
-	approval := Approval{
-		KeyHolderID:   keyHolderID,
-		KeyHolderName: keyHolderName,
-		Signature:     signature,
-		ApprovedAt:    time.Now(),
-	}
-	req.AddApproval(approval)
-
-	// Check if we have enough approvals
-	if len(req.GetApprovals()) >= req.GetRequiredApprovals() {
-		req.SetStatus(StatusApproved)
-	}
-
-	return s.Save(req)
 }
 
-// Deny denies a request.
-func (s *RequestStore[T]) Deny(id, denier string) error {
+// pendingRequest returns request id from s if it can still be decided:
+// it is pending and, allowing for clock skew, not expired
+func pendingRequest[T Request](m *Manager, s *RequestStore[T], id string) (T, error) {
+	var zero T
 	req, err := s.Get(id)
 	if err != nil {
-		return err
+		return zero, err
+	}
+	if req.GetStatus() == StatusExpired {
+		return zero, apperrors.ErrRequestExpired
 	}
-
 	if req.GetStatus() != StatusPending {
-		return apperrors.ErrRequestNotPending
+		return zero, apperrors.ErrRequestNotPending
 	}
-
-	req.SetStatus(StatusDenied)
-	return s.Save(req)
-}
-
-// HasEnoughApprovals checks if a request has sufficient approvals.
-func (s *RequestStore[T]) HasEnoughApprovals(id string) (bool, error) {
-	req, err := s.Get(id)
-	if err != nil {
-		return false, err
+	if m.expired(req.GetExpiresAt()) {
+		req.SetStatus(StatusExpired)
+		if err := s.Save(req); err != nil {
+			logging.Warn("Failed to save expired request", logging.Err(err))
+		}
+		return zero, apperrors.ErrRequestExpired
 	}
-	return len(req.GetApprovals()) >= req.GetRequiredApprovals(), nil
+	return req, nil
 }
 
-// GetApprovalProgress returns current approvals and required count.
-func (s *RequestStore[T]) GetApprovalProgress(id string) (current int, required int, err error) {
-	req, err := s.Get(id)
-	if err != nil {
-		return 0, 0, err
+// approvedBy reports whether authority has already approved, directly or
+// through a delegate
+func approvedBy(approvals []Approval, authority string) bool {
+	for _, a := range approvals {
+		if a.Authority() == authority {
+			return true
+		}
 	}
-	return len(req.GetApprovals()), req.GetRequiredApprovals(), nil
+	return false
 }
@@ -0,0 +1,153 @@
+package consent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+// templateNamePattern limits template names to something safe in URLs
+var templateNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{0,63}$`)
+
+// Template pre-fills a recurring request, such as a monthly test restore,
+// so the reason doesn't have to be retyped and the host sees the same
+// justification each time
+type Template struct {
+	Name       string    `json:"name"`
+	SnapshotID string    `json:"snapshot_id"`       // Snapshot selector, e.g. "latest"
+	Paths      []string  `json:"paths,omitempty"`   // Specific paths (optional)
+	Reason     string    `json:"reason"`            // Justification copied into each request
+	Purpose    string    `json:"purpose,omitempty"` // Empty for restores, or PurposeBrowse
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Validate checks the template can be used to create requests. Key export
+// is deliberately not templatable.
+func (t *Template) Validate() error {
+	if !templateNamePattern.MatchString(t.Name) {
+		return fmt.Errorf("template name must be 1-64 letters, digits, '.', '_' or '-'")
+	}
+	if t.Reason == "" {
+		return fmt.Errorf("template reason is required")
+	}
+	if t.Purpose != "" && t.Purpose != PurposeBrowse {
+		return fmt.Errorf("templates can only be for restores or browsing")
+	}
+	return nil
+}
+
+func (m *Manager) loadTemplates() (map[string]*Template, error) {
+	templates := make(map[string]*Template)
+	data, err := os.ReadFile(m.templatesPath)
+	if os.IsNotExist(err) {
+		return templates, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return nil, fmt.Errorf("failed to parse request templates: %w", err)
+	}
+	return templates, nil
+}
+
+func (m *Manager) saveTemplates(templates map[string]*Template) error {
+	if err := os.MkdirAll(filepath.Dir(m.templatesPath), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(templates, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.templatesPath, data, 0600)
+}
+
+// CreateTemplate saves a new request template
+func (m *Manager) CreateTemplate(t *Template) error {
+	if t.SnapshotID == "" {
+		t.SnapshotID = "latest"
+	}
+	if err := t.Validate(); err != nil {
+		return err
+	}
+
+	templates, err := m.loadTemplates()
+	if err != nil {
+		return err
+	}
+	if _, ok := templates[t.Name]; ok {
+		return apperrors.ErrTemplateExists
+	}
+
+	t.CreatedAt = time.Now()
+	templates[t.Name] = t
+	return m.saveTemplates(templates)
+}
+
+// GetTemplate returns a request template by name
+func (m *Manager) GetTemplate(name string) (*Template, error) {
+	templates, err := m.loadTemplates()
+	if err != nil {
+		return nil, err
+	}
+	t, ok := templates[name]
+	if !ok {
+		return nil, apperrors.ErrTemplateNotFound
+	}
+	return t, nil
+}
+
+// ListTemplates returns all request templates, sorted by name
+func (m *Manager) ListTemplates() ([]*Template, error) {
+	templates, err := m.loadTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*Template, 0, len(templates))
+	for _, t := range templates {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// DeleteTemplate removes a request template
+func (m *Manager) DeleteTemplate(name string) error {
+	templates, err := m.loadTemplates()
+	if err != nil {
+		return err
+	}
+	if _, ok := templates[name]; !ok {
+		return apperrors.ErrTemplateNotFound
+	}
+	delete(templates, name)
+	return m.saveTemplates(templates)
+}
+
+// CreateRequestFromTemplate creates a request pre-filled from a template.
+// A non-empty reason is appended to the template's reason, e.g. to note
+// which month's test this is.
+func (m *Manager) CreateRequestFromTemplate(requester, name, reason string) (*RestoreRequest, error) {
+	t, err := m.GetTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+
+	fullReason := t.Reason
+	if reason != "" {
+		fullReason += " - " + reason
+	}
+
+	m = m.WithTemplate(t.Name)
+	if t.Purpose == PurposeBrowse {
+		return m.CreateBrowseRequest(requester, t.SnapshotID, fullReason)
+	}
+	return m.CreateRequest(requester, t.SnapshotID, fullReason, t.Paths)
+}
@@ -0,0 +1,68 @@
+package consent
+
+import (
+	"testing"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplates(t *testing.T) {
+	m := NewManager(t.TempDir())
+
+	require.NoError(t, m.CreateTemplate(&Template{
+		Name:   "monthly-verify",
+		Paths:  []string{"/Documents"},
+		Reason: "Monthly verify restore of /Documents",
+	}))
+	require.NoError(t, m.CreateTemplate(&Template{
+		Name:       "audit-browse",
+		SnapshotID: "abc123",
+		Reason:     "Quarterly audit listing",
+		Purpose:    PurposeBrowse,
+	}))
+
+	err := m.CreateTemplate(&Template{Name: "monthly-verify", Reason: "again"})
+	assert.ErrorIs(t, err, apperrors.ErrTemplateExists)
+	assert.Error(t, m.CreateTemplate(&Template{Name: "no reason"}))
+	assert.Error(t, m.CreateTemplate(&Template{Name: "../escape", Reason: "x"}))
+	assert.Error(t, m.CreateTemplate(&Template{Name: "keys", Reason: "x", Purpose: PurposeExportKeys}))
+
+	list, err := m.ListTemplates()
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "audit-browse", list[0].Name)
+	assert.Equal(t, "latest", list[1].SnapshotID, "snapshot defaults to latest")
+
+	t.Run("restore request from template", func(t *testing.T) {
+		req, err := m.CreateRequestFromTemplate("alice", "monthly-verify", "March")
+		require.NoError(t, err)
+		assert.Equal(t, "latest", req.SnapshotID)
+		assert.Equal(t, []string{"/Documents"}, req.Paths)
+		assert.Equal(t, "Monthly verify restore of /Documents - March", req.Reason)
+		assert.Equal(t, "monthly-verify", req.Template)
+		assert.False(t, req.IsBrowse())
+
+		stored, err := m.GetRequest(req.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "monthly-verify", stored.Template)
+	})
+
+	t.Run("browse request from template", func(t *testing.T) {
+		req, err := m.CreateRequestFromTemplate("alice", "audit-browse", "")
+		require.NoError(t, err)
+		assert.True(t, req.IsBrowse())
+		assert.Equal(t, "abc123", req.SnapshotID)
+		assert.Equal(t, "Quarterly audit listing", req.Reason)
+	})
+
+	_, err = m.CreateRequestFromTemplate("alice", "missing", "")
+	assert.ErrorIs(t, err, apperrors.ErrTemplateNotFound)
+
+	require.NoError(t, m.DeleteTemplate("audit-browse"))
+	assert.ErrorIs(t, m.DeleteTemplate("audit-browse"), apperrors.ErrTemplateNotFound)
+	list, err = m.ListTemplates()
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+}
@@ -0,0 +1,245 @@
+package consent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+// RequestType is a type of consent request and the workflow its requests
+// go through: they are stored, expired, approved once their quorum is met
+// or denied, and each change is published to the activity feed. Restores,
+// deletions and every registered OperationType are RequestTypes.
+type RequestType[R AnyOperation] struct {
+	// Name identifies the type, e.g. "restore"
+	Name        string
+	Description string
+	// TTL is how long requests stay pending unless created with another
+	TTL time.Duration
+	// Scope names what approvers sign, so an approval of one type can't
+	// be replayed for another
+	Scope string
+
+	// dir is where m keeps the type's requests
+	dir func(m *Manager) string
+	// newRecord returns an empty request to load one into
+	newRecord func() R
+	// expire, if set, replaces expireWith as the expiry check of loaded
+	// requests
+	expire func(m *Manager) func(*RequestStore[R], R)
+	// prepare, if set, checks and completes a request before an approval
+	// is recorded on it
+	prepare func(m *Manager, req R, approval Approval) error
+	// markApproved, if set, approves a request in place of decide
+	markApproved func(m *Manager, req R, approver string)
+	// view, if set, returns a request as the operations API shows it
+	view func(R) R
+
+	// noun names a request in event messages, e.g. "the restore request"
+	noun string
+	// The activity feed event types of created, approved and denied
+	// requests
+	created, approved, denied string
+
+	// via names the RPCs creating and approving the type's requests, if
+	// the operations API doesn't
+	via string
+}
+
+// store is the store of m's requests of this type
+func (t *RequestType[R]) store(m *Manager) *RequestStore[R] {
+	expire := expireWith[R](m)
+	if t.expire != nil {
+		expire = t.expire(m)
+	}
+	return NewRequestStore(t.dir(m), t.newRecord, expire)
+}
+
+// add stores a new request and publishes it, described by message
+func (t *RequestType[R]) add(m *Manager, req R, message string) error {
+	if err := t.store(m).Save(req); err != nil {
+		return err
+	}
+	m.publish(t.created, req, message)
+	return nil
+}
+
+// Get returns the request with the given ID
+func (t *RequestType[R]) Get(m *Manager, id string) (R, error) {
+	return t.store(m).Get(id)
+}
+
+// ListPending returns the pending requests of this type
+func (t *RequestType[R]) ListPending(m *Manager) ([]R, error) {
+	return t.store(m).ListPending()
+}
+
+// AddApproval records an approval of a pending request, approving it once
+// its quorum, or its required count of key holders, is met. Callers verify
+// the approval's signature first.
+func (t *RequestType[R]) AddApproval(m *Manager, id string, approval Approval) (R, error) {
+	var zero R
+	if err := m.CheckLockdown(); err != nil {
+		return zero, err
+	}
+	s := t.store(m)
+	req, err := pendingRequest(m, s, id)
+	if err != nil {
+		return zero, err
+	}
+	if approvedBy(req.GetApprovals(), approval.Authority()) {
+		return zero, apperrors.ErrAlreadyApproved
+	}
+	if t.prepare != nil {
+		if err := t.prepare(m, req, approval); err != nil {
+			return zero, err
+		}
+	}
+	req.AddApproval(approval)
+
+	approved := m.approvalProgress(req).Satisfied()
+	if approved {
+		t.grant(m, req, "consensus")
+	}
+	if err := s.Save(req); err != nil {
+		return zero, err
+	}
+	if approved {
+		m.publish(t.approved, req, fmt.Sprintf("Enough key holders approved %s, the last being %s", t.noun, approval.name()))
+	}
+	return req, nil
+}
+
+// Approve approves a pending request on approver's word alone, as a
+// restore releasing a share is. update, if set, completes the request
+// before it is saved.
+func (t *RequestType[R]) Approve(m *Manager, id, approver string, update func(R)) (R, error) {
+	var zero R
+	if err := m.CheckLockdown(); err != nil {
+		return zero, err
+	}
+	s := t.store(m)
+	req, err := pendingRequest(m, s, id)
+	if err != nil {
+		return zero, err
+	}
+	t.grant(m, req, approver)
+	if update != nil {
+		update(req)
+	}
+	if err := s.Save(req); err != nil {
+		return zero, err
+	}
+	m.publish(t.approved, req, fmt.Sprintf("%s approved %s", approver, t.noun))
+	return req, nil
+}
+
+// grant marks req approved by approver
+func (t *RequestType[R]) grant(m *Manager, req R, approver string) {
+	if t.markApproved != nil {
+		t.markApproved(m, req, approver)
+		return
+	}
+	req.decide(StatusApproved, approver, time.Now())
+}
+
+// Deny denies a pending request
+func (t *RequestType[R]) Deny(m *Manager, id, denier string) error {
+	s := t.store(m)
+	req, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	if req.GetStatus() != StatusPending {
+		return apperrors.ErrRequestNotPending
+	}
+	req.decide(StatusDenied, denier, time.Now())
+	if err := s.Save(req); err != nil {
+		return err
+	}
+	m.publish(t.denied, req, fmt.Sprintf("%s denied %s", denier, t.noun))
+	return nil
+}
+
+// Progress returns a request's progress toward its quorum, or its required
+// count of key holders
+func (t *RequestType[R]) Progress(m *Manager, id string) (QuorumProgress, error) {
+	req, err := t.Get(m, id)
+	if err != nil {
+		return QuorumProgress{}, err
+	}
+	return m.approvalProgress(req), nil
+}
+
+// name is who gave an approval, as event messages show them
+func (a Approval) name() string {
+	if a.KeyHolderName != "" {
+		return a.KeyHolderName
+	}
+	return a.KeyHolderID
+}
+
+// The untyped workflow, for the operations API. Types with RPCs of their
+// own are only listed, shown and denied through it.
+
+func (t *RequestType[R]) info() OperationTypeInfo {
+	return OperationTypeInfo{Name: t.Name, Description: t.Description, TTLSeconds: int64(t.TTL.Seconds()), Scope: t.Scope}
+}
+
+func (t *RequestType[R]) get(m *Manager, id string) (AnyOperation, error) {
+	req, err := t.Get(m, id)
+	if err != nil {
+		return nil, err
+	}
+	return t.show(req), nil
+}
+
+func (t *RequestType[R]) listPending(m *Manager) ([]AnyOperation, error) {
+	reqs, err := t.ListPending(m)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]AnyOperation, len(reqs))
+	for i, req := range reqs {
+		list[i] = t.show(req)
+	}
+	return list, nil
+}
+
+func (t *RequestType[R]) deny(m *Manager, id, denier string) error {
+	return t.Deny(m, id, denier)
+}
+
+func (t *RequestType[R]) create(*Manager, string, string, json.RawMessage, int) (AnyOperation, error) {
+	return nil, t.elsewhere()
+}
+
+func (t *RequestType[R]) signData(*Manager, string, string) (*crypto.OperationSignData, error) {
+	return nil, t.elsewhere()
+}
+
+func (t *RequestType[R]) approve(context.Context, *Manager, string, string, string, []byte) (AnyOperation, error) {
+	return nil, t.elsewhere()
+}
+
+func (t *RequestType[R]) execute(context.Context, *Manager, string) (AnyOperation, error) {
+	return nil, t.elsewhere()
+}
+
+// show returns req as the operations API shows it
+func (t *RequestType[R]) show(req R) AnyOperation {
+	if t.view != nil {
+		return t.view(req)
+	}
+	return req
+}
+
+// elsewhere is the error for the operations API doing what the type's own
+// RPCs do
+func (t *RequestType[R]) elsewhere() error {
+	return apperrors.Newf(apperrors.CodeFailedPrecondition, "%s requests are created and approved with %s", t.Name, t.via)
+}
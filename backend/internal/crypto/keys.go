@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"sort"
 )
 
@@ -56,6 +57,28 @@ type RestoreRequestSignData struct {
 	Reason      string   `json:"reason"`
 	CreatedAt   int64    `json:"created_at"` // Unix timestamp
 	KeyHolderID string   `json:"key_holder_id"`
+	// Nonce is the challenge the verifying node issued for this approval,
+	// binding the signature to that node and that one use
+	Nonce string `json:"nonce,omitempty"`
+	// SignedAt is when the approver signed, by their clock (Unix timestamp)
+	SignedAt int64 `json:"signed_at,omitempty"`
+
+	// The device the request was made from, as its requester reported it
+	RequesterHostname       string `json:"requester_hostname,omitempty"`
+	RequesterOS             string `json:"requester_os,omitempty"`
+	RequesterVersion        string `json:"requester_version,omitempty"`
+	RequesterKeyFingerprint string `json:"requester_key_fingerprint,omitempty"`
+	// SourceIP is the requester's address as seen by the node the request
+	// was made on. Only approvals cover it: the requester can't know it.
+	SourceIP string `json:"source_ip,omitempty"`
+
+	// SizeBytes is the size of the restore as its requester declared it
+	SizeBytes int64 `json:"size_bytes,omitempty"`
+
+	// Purpose is what approving the request releases, empty for a restore
+	Purpose string `json:"purpose,omitempty"`
+	// Template is the template the request was created from, if any
+	Template string `json:"template,omitempty"`
 }
 
 // Hash creates a canonical hash of the restore request for signing
@@ -74,6 +97,19 @@ func (d *RestoreRequestSignData) Hash() ([]byte, error) {
 		Reason:      d.Reason,
 		CreatedAt:   d.CreatedAt,
 		KeyHolderID: d.KeyHolderID,
+		Nonce:       d.Nonce,
+		SignedAt:    d.SignedAt,
+
+		RequesterHostname:       d.RequesterHostname,
+		RequesterOS:             d.RequesterOS,
+		RequesterVersion:        d.RequesterVersion,
+		RequesterKeyFingerprint: d.RequesterKeyFingerprint,
+		SourceIP:                d.SourceIP,
+
+		SizeBytes: d.SizeBytes,
+
+		Purpose:  d.Purpose,
+		Template: d.Template,
 	}
 
 	// Create canonical JSON
@@ -105,6 +141,251 @@ func (d *RestoreRequestSignData) Verify(publicKey, signature []byte) (bool, erro
 	return Verify(publicKey, hash, signature), nil
 }
 
+// DelegationSignData holds the data a key holder signs to delegate their
+// approval authority, or (with Revoked set) to take it back
+type DelegationSignData struct {
+	DelegationID    string `json:"delegation_id"`
+	FromKeyHolderID string `json:"from_key_holder_id"`
+	ToKeyHolderID   string `json:"to_key_holder_id"`
+	StartsAt        int64  `json:"starts_at"`  // Unix timestamp
+	ExpiresAt       int64  `json:"expires_at"` // Unix timestamp
+	Reason          string `json:"reason"`
+	Revoked         bool   `json:"revoked,omitempty"`
+}
+
+// Hash creates a canonical hash of the delegation for signing
+func (d *DelegationSignData) Hash() ([]byte, error) {
+	jsonBytes, err := json.Marshal(d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal delegation data: %w", err)
+	}
+	hash := sha256.Sum256(jsonBytes)
+	return hash[:], nil
+}
+
+// Sign signs the delegation with an Ed25519 private key
+func (d *DelegationSignData) Sign(privateKey []byte) ([]byte, error) {
+	hash, err := d.Hash()
+	if err != nil {
+		return nil, err
+	}
+	return Sign(privateKey, hash)
+}
+
+// Verify verifies a signature against a public key
+func (d *DelegationSignData) Verify(publicKey, signature []byte) (bool, error) {
+	hash, err := d.Hash()
+	if err != nil {
+		return false, err
+	}
+	return Verify(publicKey, hash, signature), nil
+}
+
+// ExtensionSignData holds the data a key holder signs to approve a pending
+// request's extension: the expiry it moves the request to
+type ExtensionSignData struct {
+	RequestID   string `json:"request_id"`
+	KeyHolderID string `json:"key_holder_id"`
+	ExpiresAt   int64  `json:"expires_at"` // Unix timestamp, once extended
+	// Nonce is the challenge the verifying node issued for this approval
+	Nonce string `json:"nonce"`
+	// SignedAt is when the approver signed, by their clock (Unix timestamp)
+	SignedAt int64 `json:"signed_at"`
+}
+
+// Hash creates a canonical hash of the extension approval for signing
+func (d *ExtensionSignData) Hash() ([]byte, error) {
+	jsonBytes, err := json.Marshal(d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal extension data: %w", err)
+	}
+	hash := sha256.Sum256(jsonBytes)
+	return hash[:], nil
+}
+
+// Sign signs the extension approval with an Ed25519 private key
+func (d *ExtensionSignData) Sign(privateKey []byte) ([]byte, error) {
+	hash, err := d.Hash()
+	if err != nil {
+		return nil, err
+	}
+	return Sign(privateKey, hash)
+}
+
+// Verify verifies a signature against a public key
+func (d *ExtensionSignData) Verify(publicKey, signature []byte) (bool, error) {
+	hash, err := d.Hash()
+	if err != nil {
+		return false, err
+	}
+	return Verify(publicKey, hash, signature), nil
+}
+
+// VetoSignData holds the data a key holder signs to veto a request,
+// including why, so the veto can't be replayed with another reason
+type VetoSignData struct {
+	RequestID   string `json:"request_id"`
+	KeyHolderID string `json:"key_holder_id"`
+	Reason      string `json:"reason"`
+	// Nonce is the challenge the verifying node issued for this veto
+	Nonce string `json:"nonce"`
+	// SignedAt is when the key holder signed, by their clock (Unix timestamp)
+	SignedAt int64 `json:"signed_at"`
+}
+
+// Hash creates a canonical hash of the veto for signing
+func (d *VetoSignData) Hash() ([]byte, error) {
+	jsonBytes, err := json.Marshal(d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal veto data: %w", err)
+	}
+	hash := sha256.Sum256(jsonBytes)
+	return hash[:], nil
+}
+
+// Sign signs the veto with an Ed25519 private key
+func (d *VetoSignData) Sign(privateKey []byte) ([]byte, error) {
+	hash, err := d.Hash()
+	if err != nil {
+		return nil, err
+	}
+	return Sign(privateKey, hash)
+}
+
+// Verify verifies a signature against a public key
+func (d *VetoSignData) Verify(publicKey, signature []byte) (bool, error) {
+	hash, err := d.Hash()
+	if err != nil {
+		return false, err
+	}
+	return Verify(publicKey, hash, signature), nil
+}
+
+// RecoverySignData holds the data a recovery contact signs to vouch that a
+// key holder is lost, or (with Cancelled set) a key holder signs to cancel
+// the recovery
+type RecoverySignData struct {
+	RecoveryID         string `json:"recovery_id"`
+	MissingKeyHolderID string `json:"missing_key_holder_id"`
+	OpenedAt           int64  `json:"opened_at"` // Unix timestamp
+	Reason             string `json:"reason"`
+	Cancelled          bool   `json:"cancelled,omitempty"`
+}
+
+// Hash creates a canonical hash of the recovery for signing
+func (d *RecoverySignData) Hash() ([]byte, error) {
+	jsonBytes, err := json.Marshal(d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal recovery data: %w", err)
+	}
+	hash := sha256.Sum256(jsonBytes)
+	return hash[:], nil
+}
+
+// Sign signs the recovery with an Ed25519 private key
+func (d *RecoverySignData) Sign(privateKey []byte) ([]byte, error) {
+	hash, err := d.Hash()
+	if err != nil {
+		return nil, err
+	}
+	return Sign(privateKey, hash)
+}
+
+// Verify verifies a signature against a public key
+func (d *RecoverySignData) Verify(publicKey, signature []byte) (bool, error) {
+	hash, err := d.Hash()
+	if err != nil {
+		return false, err
+	}
+	return Verify(publicKey, hash, signature), nil
+}
+
+// DeletionRequestSignData holds the data that gets signed for deletion request approval
+type DeletionRequestSignData struct {
+	RequestID    string   `json:"request_id"`
+	Requester    string   `json:"requester"`
+	DeletionType string   `json:"deletion_type"`
+	SnapshotIDs  []string `json:"snapshot_ids,omitempty"`
+	Paths        []string `json:"paths,omitempty"`
+	Reason       string   `json:"reason"`
+	CreatedAt    int64    `json:"created_at"` // Unix timestamp
+	KeyHolderID  string   `json:"key_holder_id"`
+}
+
+// Hash creates a canonical hash of the deletion request for signing
+func (d *DeletionRequestSignData) Hash() ([]byte, error) {
+	data := *d
+	data.SnapshotIDs = slices.Sorted(slices.Values(d.SnapshotIDs))
+	data.Paths = slices.Sorted(slices.Values(d.Paths))
+
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal deletion data: %w", err)
+	}
+	hash := sha256.Sum256(jsonBytes)
+	return hash[:], nil
+}
+
+// Sign signs the deletion request with an Ed25519 private key
+func (d *DeletionRequestSignData) Sign(privateKey []byte) ([]byte, error) {
+	hash, err := d.Hash()
+	if err != nil {
+		return nil, err
+	}
+	return Sign(privateKey, hash)
+}
+
+// Verify verifies a signature against a public key
+func (d *DeletionRequestSignData) Verify(publicKey, signature []byte) (bool, error) {
+	hash, err := d.Hash()
+	if err != nil {
+		return false, err
+	}
+	return Verify(publicKey, hash, signature), nil
+}
+
+// OperationSignData holds the data that gets signed to approve a generic
+// consent-gated operation. Scope binds the signature to the operation's
+// type, so an approval can't be replayed for an operation of another type.
+type OperationSignData struct {
+	Scope       string          `json:"scope"`
+	RequestID   string          `json:"request_id"`
+	Type        string          `json:"type"`
+	Requester   string          `json:"requester"`
+	Reason      string          `json:"reason"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   int64           `json:"created_at"` // Unix timestamp
+	KeyHolderID string          `json:"key_holder_id"`
+}
+
+// Hash creates a canonical hash of the operation for signing
+func (d *OperationSignData) Hash() ([]byte, error) {
+	jsonBytes, err := json.Marshal(d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal operation data: %w", err)
+	}
+	hash := sha256.Sum256(jsonBytes)
+	return hash[:], nil
+}
+
+// Sign signs the operation with an Ed25519 private key
+func (d *OperationSignData) Sign(privateKey []byte) ([]byte, error) {
+	hash, err := d.Hash()
+	if err != nil {
+		return nil, err
+	}
+	return Sign(privateKey, hash)
+}
+
+// Verify verifies a signature against a public key
+func (d *OperationSignData) Verify(publicKey, signature []byte) (bool, error) {
+	hash, err := d.Hash()
+	if err != nil {
+		return false, err
+	}
+	return Verify(publicKey, hash, signature), nil
+}
+
 // EncodePublicKey encodes a public key as hex
 func EncodePublicKey(publicKey []byte) string {
 	return hex.EncodeToString(publicKey)
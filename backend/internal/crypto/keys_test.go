@@ -310,6 +310,70 @@ func TestRestoreRequestSignData_SignAndVerify(t *testing.T) {
 	})
 }
 
+func TestDelegationSignData_SignAndVerify(t *testing.T) {
+	pub, priv, err := GenerateKeyPair()
+	require.NoError(t, err)
+
+	data := DelegationSignData{
+		DelegationID:    "dlg-1",
+		FromKeyHolderID: "alice",
+		ToKeyHolderID:   "bob",
+		StartsAt:        1700000000,
+		ExpiresAt:       1701209600,
+		Reason:          "vacation",
+	}
+	sig, err := data.Sign(priv)
+	require.NoError(t, err)
+
+	valid, err := data.Verify(pub, sig)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	// A delegation signature cannot be replayed as a revocation
+	revoke := data
+	revoke.Revoked = true
+	valid, err = revoke.Verify(pub, sig)
+	require.NoError(t, err)
+	assert.False(t, valid)
+
+	extended := data
+	extended.ExpiresAt += 86400
+	valid, err = extended.Verify(pub, sig)
+	require.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestDeletionRequestSignData_SignAndVerify(t *testing.T) {
+	pub, priv, err := GenerateKeyPair()
+	require.NoError(t, err)
+
+	data := DeletionRequestSignData{
+		RequestID:    "del-1",
+		Requester:    "alice",
+		DeletionType: "snapshot",
+		SnapshotIDs:  []string{"b", "a"},
+		Reason:       "cleanup",
+		CreatedAt:    1234567890,
+		KeyHolderID:  "holder-1",
+	}
+	sig, err := data.Sign(priv)
+	require.NoError(t, err)
+
+	// Snapshot order does not matter
+	reordered := data
+	reordered.SnapshotIDs = []string{"a", "b"}
+	valid, err := reordered.Verify(pub, sig)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, []string{"b", "a"}, data.SnapshotIDs, "signing does not reorder the caller's slice")
+
+	widened := data
+	widened.DeletionType = "all"
+	valid, err = widened.Verify(pub, sig)
+	require.NoError(t, err)
+	assert.False(t, valid)
+}
+
 func TestEncodeDecodePublicKey(t *testing.T) {
 	t.Run("round-trip encoding", func(t *testing.T) {
 		pub, _, err := GenerateKeyPair()
@@ -0,0 +1,81 @@
+package crypto
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SealedPathPrefix marks a path sealed with SealPaths
+const SealedPathPrefix = "sealed:"
+
+// pathKeyContext separates the path key from other uses of the secret
+const pathKeyContext = "airgapper request paths v1\x00"
+
+// pathCipher derives the AEAD that seals paths from a secret only the
+// owner holds (the repository password)
+func pathCipher(secret []byte) (cipher.AEAD, error) {
+	return secretCipher(pathKeyContext, secret)
+}
+
+// SealPaths encrypts each path so only the holder of secret can read it.
+// Sealing the same path twice gives different tokens, so peers can't tell
+// which requests name the same files.
+func SealPaths(secret []byte, paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		return paths, nil
+	}
+	aead, err := pathCipher(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := make([]string, len(paths))
+	for i, p := range paths {
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, fmt.Errorf("failed to generate nonce: %w", err)
+		}
+		ct := aead.Seal(nonce, nonce, []byte(p), nil)
+		sealed[i] = SealedPathPrefix + base64.RawURLEncoding.EncodeToString(ct)
+	}
+	return sealed, nil
+}
+
+// OpenPaths decrypts paths sealed with SealPaths. Paths that aren't sealed
+// are returned as they are.
+func OpenPaths(secret []byte, paths []string) ([]string, error) {
+	var aead cipher.AEAD
+	opened := make([]string, len(paths))
+	for i, p := range paths {
+		token, ok := strings.CutPrefix(p, SealedPathPrefix)
+		if !ok {
+			opened[i] = p
+			continue
+		}
+		if aead == nil {
+			var err error
+			if aead, err = pathCipher(secret); err != nil {
+				return nil, err
+			}
+		}
+		ct, err := base64.RawURLEncoding.DecodeString(token)
+		if err != nil || len(ct) < aead.NonceSize() {
+			return nil, errors.New("malformed sealed path")
+		}
+		plain, err := aead.Open(nil, ct[:aead.NonceSize()], ct[aead.NonceSize():], nil)
+		if err != nil {
+			return nil, errors.New("sealed path was not sealed with this key")
+		}
+		opened[i] = string(plain)
+	}
+	return opened, nil
+}
+
+// IsSealedPath reports whether p was sealed with SealPaths
+func IsSealedPath(p string) bool {
+	return strings.HasPrefix(p, SealedPathPrefix)
+}
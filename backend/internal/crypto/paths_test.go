@@ -0,0 +1,49 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealPaths(t *testing.T) {
+	secret := []byte("repository password")
+	paths := []string{"/home/alice/taxes", "/home/alice/photos"}
+
+	sealed, err := SealPaths(secret, paths)
+	require.NoError(t, err)
+	require.Len(t, sealed, 2)
+	for _, s := range sealed {
+		assert.True(t, IsSealedPath(s))
+		assert.NotContains(t, s, "alice")
+	}
+
+	again, err := SealPaths(secret, paths)
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed[0], again[0], "sealing should not be deterministic")
+
+	opened, err := OpenPaths(secret, append(sealed, "/plain"))
+	require.NoError(t, err)
+	assert.Equal(t, append(paths, "/plain"), opened)
+}
+
+func TestOpenPathsWrongSecret(t *testing.T) {
+	sealed, err := SealPaths([]byte("right"), []string{"/secret"})
+	require.NoError(t, err)
+
+	_, err = OpenPaths([]byte("wrong"), sealed)
+	assert.Error(t, err)
+
+	_, err = OpenPaths([]byte("right"), []string{SealedPathPrefix + "!!"})
+	assert.Error(t, err)
+}
+
+func TestSealPathsNoSecret(t *testing.T) {
+	_, err := SealPaths(nil, []string{"/a"})
+	assert.Error(t, err)
+
+	sealed, err := SealPaths(nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, sealed)
+}
@@ -0,0 +1,55 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+// secretCipher derives an AEAD from a secret only the owner holds (the
+// repository password). keyContext keeps keys for different uses of the
+// same secret apart.
+func secretCipher(keyContext string, secret []byte) (cipher.AEAD, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("no secret to seal with")
+	}
+	key := sha256.Sum256(append([]byte(keyContext), secret...))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Seal encrypts data so only the holder of secret can read it, with a key
+// derived for keyContext
+func Seal(secret []byte, keyContext string, data []byte) ([]byte, error) {
+	aead, err := secretCipher(keyContext, secret)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, data, nil), nil
+}
+
+// Open decrypts data sealed with Seal for the same secret and keyContext
+func Open(secret []byte, keyContext string, sealed []byte) ([]byte, error) {
+	aead, err := secretCipher(keyContext, secret)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("sealed data is truncated")
+	}
+	data, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.New("data was not sealed with this key")
+	}
+	return data, nil
+}
@@ -0,0 +1,28 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealOpen(t *testing.T) {
+	secret := []byte("repository password")
+	sealed, err := Seal(secret, "test v1\x00", []byte("snapshot list"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), "snapshot")
+
+	data, err := Open(secret, "test v1\x00", sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "snapshot list", string(data))
+
+	_, err = Open([]byte("other password"), "test v1\x00", sealed)
+	assert.Error(t, err)
+	_, err = Open(secret, "other v1\x00", sealed)
+	assert.Error(t, err, "keys for other contexts can't open it")
+	_, err = Open(secret, "test v1\x00", sealed[:4])
+	assert.Error(t, err)
+	_, err = Seal(nil, "test v1\x00", []byte("x"))
+	assert.Error(t, err)
+}
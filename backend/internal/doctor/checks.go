@@ -0,0 +1,721 @@
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/gen/airgapper/v1/airgapperv1connect"
+	"github.com/lcrostarosa/airgapper/backend/internal/api"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/genesis"
+	"github.com/lcrostarosa/airgapper/backend/internal/keyprotect"
+	"github.com/lcrostarosa/airgapper/backend/internal/lockdown"
+	"github.com/lcrostarosa/airgapper/backend/internal/peerpin"
+	"github.com/lcrostarosa/airgapper/backend/internal/recoverykit"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/sources"
+	"github.com/lcrostarosa/airgapper/backend/internal/verification"
+)
+
+const (
+	// MinResticVersion is the oldest restic release Airgapper is tested with
+	MinResticVersion = "0.14.0"
+
+	// SkewWarn is the clock skew against a peer that warns. Skew beyond the
+	// configured tolerance fails: request expiry and signed timestamps no
+	// longer allow for it.
+	SkewWarn = time.Minute
+
+	// LowDiskBytes is the free space below which disk checks warn
+	LowDiskBytes = 1 << 30
+
+	networkTimeout = 10 * time.Second
+
+	// localProbeTimeout bounds asking whoever holds the API port who they are
+	localProbeTimeout = 2 * time.Second
+)
+
+func (d *Doctor) setDefaults() {
+	if d.ResticVersion == nil {
+		d.ResticVersion = restic.Version
+	}
+	if d.RepoCheck == nil && d.Config != nil {
+		client := restic.NewClient(d.Config.RepoURL, d.Config.Password).WithIdentity(d.Config.PrivateKey).WithOptions(d.Config.Restic)
+		d.RepoCheck = func(ctx context.Context) error {
+			_, err := client.RepoID(ctx)
+			return err
+		}
+	}
+	if d.RepoLocks == nil && d.Config != nil {
+		d.RepoLocks = restic.NewClient(d.Config.RepoURL, d.Config.Password).WithIdentity(d.Config.PrivateKey).WithOptions(d.Config.Restic).Locks
+	}
+	if d.LookPath == nil {
+		d.LookPath = exec.LookPath
+	}
+	if d.HTTPClient == nil {
+		d.HTTPClient = &http.Client{Timeout: networkTimeout}
+	}
+	if d.Now == nil {
+		d.Now = time.Now
+	}
+}
+
+func (d *Doctor) checkConfig() Result {
+	const name = "config"
+	switch {
+	case errors.Is(d.ConfigErr, apperrors.ErrNotInitialized):
+		return fail(name, "Airgapper is not initialized", "Run 'airgapper init' (owner) or 'airgapper join' (host)")
+	case d.ConfigErr != nil:
+		return fail(name, "Config could not be read: "+d.ConfigErr.Error(), "Fix or restore config.json in the config directory")
+	case d.Config == nil:
+		return fail(name, "No config loaded", "Run 'airgapper init' (owner) or 'airgapper join' (host)")
+	}
+
+	cfg := d.Config
+	var problems []string
+	if cfg.Name == "" {
+		problems = append(problems, "name is empty")
+	}
+	if !cfg.IsOwner() && !cfg.IsHost() {
+		problems = append(problems, fmt.Sprintf("unknown role %q", cfg.Role))
+	}
+	if cfg.RepoURL == "" && cfg.IsOwner() {
+		problems = append(problems, "repo_url is empty")
+	}
+	if cfg.Consensus != nil {
+		c := cfg.Consensus
+		if c.Threshold < 1 || c.Threshold > c.TotalKeys {
+			problems = append(problems, fmt.Sprintf("threshold %d of %d keys is invalid", c.Threshold, c.TotalKeys))
+		}
+		if _, err := cfg.Quorum(); err != nil {
+			problems = append(problems, "quorum: "+err.Error())
+		}
+	}
+	if _, err := cfg.SkewTolerance(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := cfg.RequestTTLLimit(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := cfg.CoolingOff(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if cfg.ApprovalRules != nil {
+		if err := cfg.ApprovalRules.Validate(); err != nil {
+			problems = append(problems, "approval_rules: "+err.Error())
+		}
+	}
+	if cfg.SocialRecovery != nil {
+		if err := cfg.SocialRecovery.Validate(); err != nil {
+			problems = append(problems, "social_recovery: "+err.Error())
+		}
+	}
+	if err := cfg.ValidateKeyProtection(); err != nil {
+		problems = append(problems, "key_protection: "+err.Error())
+	}
+	if err := restic.ValidateCompression(cfg.BackupCompression); err != nil {
+		problems = append(problems, "backup_compression: "+err.Error())
+	}
+	if err := cfg.Restic.Validate(); err != nil {
+		problems = append(problems, "restic: "+err.Error())
+	}
+	for _, tag := range cfg.BackupTags {
+		if err := restic.ValidateTag(tag); err != nil {
+			problems = append(problems, "backup_tags: "+err.Error())
+		}
+	}
+	if cfg.TLSCertFile != "" || cfg.TLSKeyFile != "" {
+		for _, path := range []string{cfg.TLSCertFile, cfg.TLSKeyFile} {
+			if _, err := os.Stat(path); err != nil {
+				problems = append(problems, "TLS file: "+err.Error())
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return fail(name, "Config is invalid: "+strings.Join(problems, "; "), "Edit config.json or re-run the command that set these values")
+	}
+	return ok(name, fmt.Sprintf("%s (%s) in %s", cfg.Name, cfg.Role, cfg.ConfigDir))
+}
+
+func (d *Doctor) checkRestic() Result {
+	const name = "restic"
+	if d.Config.IsHost() {
+		return skip(name, "Hosts only store encrypted data and don't need restic")
+	}
+	out, err := d.ResticVersion()
+	if err != nil {
+		return fail(name, "restic is not installed or failed to run: "+err.Error(), "Install restic "+MinResticVersion+" or newer (https://restic.net)")
+	}
+	version := parseResticVersion(out)
+	if version == "" {
+		return warn(name, "Could not parse restic version from "+strconv.Quote(out), "Check that 'restic version' works")
+	}
+	if compareVersions(version, MinResticVersion) < 0 {
+		return fail(name, "restic "+version+" is too old", "Upgrade restic to "+MinResticVersion+" or newer")
+	}
+	return ok(name, "restic "+version)
+}
+
+// parseResticVersion extracts "0.16.4" from "restic 0.16.4 compiled with ..."
+func parseResticVersion(out string) string {
+	fields := strings.Fields(out)
+	if len(fields) < 2 || fields[0] != "restic" {
+		return ""
+	}
+	return strings.TrimPrefix(fields[1], "v")
+}
+
+// compareVersions compares dotted numeric versions
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(as), len(bs)); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(strings.TrimRightFunc(as[i], func(r rune) bool { return r < '0' || r > '9' }))
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func (d *Doctor) checkRepository(ctx context.Context) Result {
+	const name = "repository"
+	cfg := d.Config
+	if cfg.IsHost() {
+		if cfg.StoragePath == "" {
+			return skip(name, "No storage path configured")
+		}
+		info, err := os.Stat(cfg.StoragePath)
+		if err != nil || !info.IsDir() {
+			return fail(name, "Storage path "+cfg.StoragePath+" is not a directory", "Create it or fix storage_path in config.json")
+		}
+		return ok(name, "Storage at "+cfg.StoragePath)
+	}
+
+	switch {
+	case d.Offline:
+		return skip(name, "Skipped (offline)")
+	case cfg.Password == "":
+		return skip(name, "No repository password on this node - it is released only on restore")
+	}
+	c, cancel := context.WithTimeout(ctx, 2*networkTimeout)
+	defer cancel()
+	if err := d.RepoCheck(c); err != nil {
+		return fail(name, "Repository "+cfg.RepoURL+" is unreachable: "+err.Error(),
+			"Check that the host is running 'airgapper serve' with storage enabled and that repo_url is correct")
+	}
+	return ok(name, "Repository "+cfg.RepoURL+" is reachable")
+}
+
+// checkLocks warns about stale locks, which make the next backup fail
+func (d *Doctor) checkLocks(ctx context.Context) Result {
+	const name = "locks"
+	cfg := d.Config
+	switch {
+	case cfg.IsHost():
+		return skip(name, "Locks are managed by the owner")
+	case d.Offline:
+		return skip(name, "Skipped (offline)")
+	case cfg.Password == "":
+		return skip(name, "No repository password on this node")
+	}
+	c, cancel := context.WithTimeout(ctx, 2*networkTimeout)
+	defer cancel()
+	locks, err := d.RepoLocks(c)
+	if err != nil {
+		return skip(name, "Could not list locks: "+err.Error())
+	}
+
+	now := d.Now()
+	stale := 0
+	for _, l := range locks {
+		if l.Stale(now) {
+			stale++
+		}
+	}
+	switch {
+	case stale > 0:
+		return warn(name, fmt.Sprintf("%d stale lock(s) left by interrupted commands will block backups", stale),
+			"Run 'airgapper unlock'")
+	case len(locks) > 0:
+		return ok(name, fmt.Sprintf("%d active lock(s) held by running commands", len(locks)))
+	}
+	return ok(name, "Repository is not locked")
+}
+
+// checkKeys checks the signing key and the key share or consensus keys
+func (d *Doctor) checkKeys() []Result {
+	cfg := d.Config
+	var results []Result
+
+	switch {
+	case cfg.PrivateKey == nil && cfg.PublicKey == nil:
+		if cfg.UsesConsensusMode() {
+			results = append(results, fail("signing key", "No signing key in consensus mode", "Re-run 'airgapper init' or 'airgapper join' to generate keys"))
+		} else {
+			results = append(results, skip("signing key", "No signing key (SSS mode)"))
+		}
+	case len(cfg.PrivateKey) != ed25519.PrivateKeySize || len(cfg.PublicKey) != ed25519.PublicKeySize:
+		results = append(results, fail("signing key", "Signing key has the wrong length", "Restore config.json from a backup"))
+	case !bytes.Equal(ed25519.PrivateKey(cfg.PrivateKey).Public().(ed25519.PublicKey), cfg.PublicKey):
+		results = append(results, fail("signing key", "Private key does not match the public key", "Restore config.json from a backup"))
+	default:
+		results = append(results, ok("signing key", "Key pair is valid"))
+	}
+
+	const name = "shares"
+	switch {
+	case cfg.UsesConsensusMode():
+		c := cfg.Consensus
+		var missing []string
+		for _, kh := range c.KeyHolders {
+			if len(kh.PublicKey) != ed25519.PublicKeySize {
+				missing = append(missing, kh.Name)
+			}
+		}
+		switch {
+		case len(missing) > 0:
+			results = append(results, fail(name, "Key holders without a valid public key: "+strings.Join(missing, ", "), "Have them run 'airgapper join' against the owner again"))
+		case len(c.KeyHolders) < c.TotalKeys:
+			results = append(results, warn(name, fmt.Sprintf("%d of %d key holders registered", len(c.KeyHolders), c.TotalKeys), "Have the remaining key holders run 'airgapper join'"))
+		default:
+			results = append(results, ok(name, fmt.Sprintf("%d key holders, %d required", len(c.KeyHolders), cfg.RequiredApprovals())))
+		}
+	case cfg.HasProtection(keyprotect.TypePassphrase):
+		results = append(results, ok(name, fmt.Sprintf("Share %d sealed with a passphrase", cfg.ShareIndex)))
+	default:
+		share, index, err := cfg.LoadShare()
+		switch {
+		case errors.Is(err, apperrors.ErrNoLocalShare):
+			results = append(results, fail(name, "No key share on this node", "Re-run 'airgapper init' (owner) or 'airgapper join' with the share from the owner (host)"))
+		case err != nil:
+			results = append(results, fail(name, "Key share could not be read: "+err.Error(), "Restore config.json from a backup"))
+		case index == 0 || len(share) == 0:
+			results = append(results, fail(name, "Key share is malformed", "Ask the owner to re-share and run 'airgapper join' again"))
+		default:
+			results = append(results, ok(name, fmt.Sprintf("Share %d present (%d bytes)", index, len(share))))
+		}
+	}
+	return results
+}
+
+// checkLockdown reports a lockdown in force
+func (d *Doctor) checkLockdown() Result {
+	const name = "lockdown"
+	l := lockdown.Load(d.Config.ConfigDir)
+	if l == nil {
+		return ok(name, "Not locked down")
+	}
+	msg := "Locked down"
+	if !l.Since.IsZero() {
+		msg += " since " + l.Since.Local().Format("2006-01-02 15:04")
+	}
+	if l.Reason != "" {
+		msg += ": " + l.Reason
+	}
+	return fail(name, msg+"; approvals and new requests are suspended",
+		"Once the node is safe, have enough key holders run 'airgapper panic sign' and lift it with 'airgapper panic lift'")
+}
+
+// checkGenesis compares this node's config with the vault's genesis record
+func (d *Doctor) checkGenesis() Result {
+	const name = "genesis"
+	doc, err := genesis.Load(d.Config)
+	switch {
+	case err != nil:
+		return fail(name, "Genesis record could not be read: "+err.Error(), "Restore genesis.json from a backup")
+	case doc == nil:
+		return skip(name, "No genesis record (vault predates them)")
+	}
+	if err := doc.Check(d.Config); err != nil {
+		return fail(name, err.Error(), "Compare config.json with 'airgapper genesis show' and undo the change")
+	}
+	if names := doc.Uncountersigned(d.Config); len(names) > 0 {
+		return warn(name, "Not countersigned by "+strings.Join(names, ", "), "Have them run 'airgapper genesis countersign'")
+	}
+	return ok(name, "Config matches vault "+doc.VaultID)
+}
+
+// checkRecoveryKit reports whether the recovery kit still matches the
+// key material and approval policy
+func (d *Doctor) checkRecoveryKit() Result {
+	const name = "recovery kit"
+	if !d.Config.IsOwner() || d.Config.Password == "" {
+		return skip(name, "Only owners with the password write recovery kits")
+	}
+	stale, st, err := recoverykit.Stale(d.Config)
+	switch {
+	case err != nil:
+		return fail(name, "Recovery kit record could not be read: "+err.Error(), "Run 'airgapper recovery-kit' again")
+	case st == nil:
+		return warn(name, "No recovery kit written", "Run 'airgapper recovery-kit --out <dir>' and keep the kit offline")
+	case stale:
+		return warn(name, "Key material or policy changed since the kit in "+st.Dir+" was written",
+			"Run 'airgapper recovery-kit --out "+st.Dir+"'")
+	}
+	return ok(name, "Kit in "+st.Dir+" is up to date")
+}
+
+// checkShareMatch reports the last check that our key share and the
+// peer's still combine to the repository password (SSS mode)
+func (d *Doctor) checkShareMatch() Result {
+	const name = "share check"
+	cfg := d.Config
+	if !cfg.UsesSSSMode() {
+		return skip(name, "Not in SSS mode")
+	}
+
+	fix := "Run 'airgapper verify-shares'"
+	if !cfg.IsOwner() {
+		fix = "Ask the owner to run 'airgapper verify-shares'"
+	}
+	state := cfg.ShareCheck
+	switch {
+	case state.Mismatched():
+		return fail(name, "Key shares did not match on "+state.LastChecked.Format("2006-01-02")+": "+state.LastError,
+			"Re-issue the shares: run 'airgapper init' again and have the host re-join")
+	case state == nil || state.LastVerified.IsZero():
+		return warn(name, "Key shares have never been checked against the peer's", fix)
+	case d.Now().Sub(state.LastVerified) > 2*verification.ShareCheckInterval:
+		return warn(name, "Key shares last verified "+state.LastVerified.Format("2006-01-02"), fix)
+	default:
+		return ok(name, "Key shares verified "+state.LastVerified.Format("2006-01-02"))
+	}
+}
+
+// checkPeers checks that each peer answers and that its clock agrees with ours
+func (d *Doctor) checkPeers(ctx context.Context) []Result {
+	peers := map[string]string{}
+	var order []string
+	add := func(name, addr string) {
+		if addr == "" {
+			return
+		}
+		if _, seen := peers[addr]; !seen {
+			order = append(order, addr)
+			peers[addr] = name
+		}
+	}
+	if d.Config.Peer != nil {
+		add(d.Config.Peer.Name, d.Config.Peer.Address)
+	}
+	if d.Config.Consensus != nil {
+		for _, kh := range d.Config.Consensus.KeyHolders {
+			add(kh.Name, kh.Address)
+		}
+	}
+
+	if len(order) == 0 {
+		return []Result{skip("peer", "No peer address configured")}
+	}
+	var results []Result
+	if r, ok := d.checkPeerPin(); ok {
+		results = append(results, r)
+	}
+	for _, addr := range order {
+		name := "peer " + peers[addr]
+		if d.Offline {
+			results = append(results, skip(name, "Skipped (offline)"))
+			continue
+		}
+		results = append(results, d.checkPeer(ctx, name, addr)...)
+	}
+	return results
+}
+
+// checkPeerPin fails while the peer's identity differs from its pin
+func (d *Doctor) checkPeerPin() (Result, bool) {
+	if d.Config.Peer == nil || d.Config.ConfigDir == "" {
+		return Result{}, false
+	}
+	pin, err := peerpin.NewStore(d.Config.ConfigDir).Get()
+	switch {
+	case err != nil:
+		return warn("peer identity", "Peer pin is unreadable: "+err.Error(), "Check "+peerpin.FileName+" in the config directory"), true
+	case pin == nil:
+		return Result{}, false
+	case pin.Change != nil:
+		return fail("peer identity", pin.Err().Error(), "Compare the peer's identity code with its operator, then run 'airgapper peer repin'"), true
+	}
+	return ok("peer identity", "Peer pinned as "+pin.Code()), true
+}
+
+func (d *Doctor) checkPeer(ctx context.Context, name, addr string) []Result {
+	endpoint := strings.TrimSuffix(addr, "/") + api.APIBasePath + api.VersionPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return []Result{fail(name, "Invalid peer address "+addr+": "+err.Error(), "Set the address as http(s)://host:port")}
+	}
+
+	sent := d.Now()
+	resp, err := d.HTTPClient.Do(req)
+	if err != nil {
+		return []Result{fail(name, addr+" is unreachable: "+err.Error(), "Check that the peer is running 'airgapper serve' and that the address and firewall allow it")}
+	}
+	defer func() { _ = resp.Body.Close() }()
+	received := d.Now()
+
+	if resp.StatusCode != http.StatusOK {
+		return []Result{fail(name, fmt.Sprintf("%s answered %s", addr, resp.Status), "Check that the address points at an Airgapper server")}
+	}
+	var info api.VersionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return []Result{fail(name, addr+" is not an Airgapper server", "Check that the address points at an Airgapper server")}
+	}
+
+	results := []Result{ok(name, fmt.Sprintf("%s reachable (airgapper %s, API %s)", addr, info.ServerVersion, info.APIVersion))}
+	if info.APIVersion != api.APIVersion {
+		results[0] = warn(name, fmt.Sprintf("%s speaks API %s, this node %s", addr, info.APIVersion, api.APIVersion), "Upgrade the older node")
+	}
+
+	peer := strings.TrimPrefix(name, "peer ")
+	status, skew, measured := d.peerStatus(ctx, addr)
+	if status != nil && status.StorageReadOnly {
+		msg := "Storage is read-only for maintenance"
+		if status.StorageMaintenanceReason != "" {
+			msg += ": " + status.StorageMaintenanceReason
+		}
+		results = append(results, warn("storage "+peer, msg,
+			"Backups to this peer are refused until its host runs 'airgapper storage maintenance off'"))
+	}
+
+	clockName := "clock " + peer
+	tolerance, err := d.Config.SkewTolerance()
+	if err != nil {
+		return append(results, skip(clockName, "Invalid clock skew tolerance"))
+	}
+
+	// Prefer the status endpoint's precise clock; older peers only send a
+	// Date header
+	if !measured {
+		peerTime, err := http.ParseTime(resp.Header.Get("Date"))
+		if err != nil {
+			return append(results, skip(clockName, "Peer reported no time"))
+		}
+		skew = clockSkew(peerTime, sent, received)
+	}
+	return append(results, checkSkew(clockName, skew, tolerance))
+}
+
+// statusPath is the status endpoint (relative to APIBasePath), which
+// reports the server's clock and whether its storage is read-only
+const statusPath = airgapperv1connect.HealthServiceGetStatusProcedure
+
+// peerStatus is what the doctor reads from a peer's status endpoint
+type peerStatus struct {
+	ServerTime               time.Time `json:"serverTime"`
+	StorageReadOnly          bool      `json:"storageReadOnly"`
+	StorageMaintenanceReason string    `json:"storageMaintenanceReason"`
+}
+
+// peerStatus asks the peer's status endpoint for its status. If it reports
+// its clock, skew is how far it is ahead of ours, measured at the midpoint
+// of the request. Older peers have no status endpoint.
+func (d *Doctor) peerStatus(ctx context.Context, addr string) (status *peerStatus, skew time.Duration, measured bool) {
+	endpoint := strings.TrimSuffix(addr, "/") + api.APIBasePath + statusPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader("{}"))
+	if err != nil {
+		return nil, 0, false
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	sent := d.Now()
+	resp, err := d.HTTPClient.Do(req)
+	if err != nil {
+		return nil, 0, false
+	}
+	defer func() { _ = resp.Body.Close() }()
+	received := d.Now()
+
+	status = &peerStatus{}
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(status) != nil {
+		return nil, 0, false
+	}
+	if status.ServerTime.IsZero() {
+		return status, 0, false
+	}
+	return status, status.ServerTime.Sub(sent.Add(received.Sub(sent) / 2)), true
+}
+
+// clockSkew is how far the peer's clock is ahead of ours, measured at the
+// midpoint of the request. The Date header has one second resolution.
+func clockSkew(peer, sent, received time.Time) time.Duration {
+	mid := sent.Add(received.Sub(sent) / 2)
+	skew := peer.Sub(mid.Truncate(time.Second))
+	if skew > -time.Second && skew < time.Second {
+		return 0
+	}
+	return skew
+}
+
+func checkSkew(name string, skew, tolerance time.Duration) Result {
+	abs := skew.Abs()
+	msg := fmt.Sprintf("Clock differs from the peer by %s", abs.Round(time.Second))
+	const fix = "Enable NTP (e.g. 'timedatectl set-ntp true') on both machines"
+	switch {
+	case abs > tolerance:
+		return fail(name, fmt.Sprintf("%s, beyond the %s tolerance", msg, tolerance),
+			fix+", or raise clock_skew_tolerance in config.json")
+	case abs >= SkewWarn:
+		return warn(name, msg, fix)
+	}
+	return ok(name, "Clock in sync with the peer")
+}
+
+func (d *Doctor) checkDiskSpace() []Result {
+	dirs := []string{d.Config.ConfigDir}
+	if d.Config.StoragePath != "" {
+		dirs = append(dirs, d.Config.StoragePath)
+	}
+
+	var results []Result
+	for _, dir := range dirs {
+		name := "disk " + dir
+		var stat syscall.Statfs_t
+		if err := syscall.Statfs(dir, &stat); err != nil {
+			results = append(results, warn(name, "Could not check free space: "+err.Error(), "Check that "+dir+" exists"))
+			continue
+		}
+		free := int64(stat.Bavail) * int64(stat.Bsize)
+		msg := fmt.Sprintf("%d MiB free", free>>20)
+		if free < LowDiskBytes {
+			results = append(results, warn(name, msg, "Free up space on the disk holding "+dir))
+			continue
+		}
+		results = append(results, ok(name, msg))
+	}
+	return results
+}
+
+func (d *Doctor) checkSchedule() []Result {
+	const name = "schedule"
+	cfg := d.Config
+	if !cfg.IsOwner() {
+		return []Result{skip(name, "Only owners run scheduled backups")}
+	}
+	if cfg.BackupSchedule == "" {
+		return []Result{skip(name, "No backup schedule configured")}
+	}
+
+	var problems []string
+	if _, err := cfg.Schedule(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := cfg.ScheduleTiming(); err != nil {
+		problems = append(problems, "timing: "+err.Error())
+	}
+	if _, _, err := cfg.ScheduleRetry(); err != nil {
+		problems = append(problems, "retry: "+err.Error())
+	}
+	if len(problems) > 0 {
+		return []Result{fail(name, "Schedule is invalid: "+strings.Join(problems, "; "), "Set it again with 'airgapper schedule --set'")}
+	}
+
+	if len(cfg.BackupPaths) == 0 {
+		return []Result{fail(name, "Schedule has no backup paths", "Set it with 'airgapper schedule --set <schedule> <paths...>'")}
+	}
+	var missing []string
+	for _, p := range cfg.BackupPaths {
+		if _, err := os.Stat(p); err != nil {
+			missing = append(missing, p)
+		}
+	}
+	if len(missing) > 0 {
+		return []Result{warn(name, "Backup paths missing: "+strings.Join(missing, ", "), "Remove them or restore the directories")}
+	}
+	schedule := cfg.BackupSchedule
+	if cfg.BackupTimezone != "" {
+		schedule += " " + cfg.BackupTimezone
+	}
+	return []Result{ok(name, fmt.Sprintf("%s (%d paths)", schedule, len(cfg.BackupPaths)))}
+}
+
+// checkSources checks that each enabled database source can be dumped
+func (d *Doctor) checkSources() []Result {
+	cfg := d.Config
+	if !cfg.IsOwner() {
+		return nil
+	}
+	var results []Result
+	for _, s := range cfg.BackupSources {
+		name := "source " + s.Name
+		if !s.Enabled {
+			results = append(results, skip(name, "Disabled"))
+			continue
+		}
+		if err := s.Validate(); err != nil {
+			results = append(results, fail(name, err.Error(), "Remove it with 'airgapper source remove "+s.Name+"' and add it again"))
+			continue
+		}
+		if _, err := d.LookPath(s.Tool()); err != nil {
+			results = append(results, fail(name, s.Tool()+" not found in PATH", "Install the "+string(s.Type)+" client tools"))
+			continue
+		}
+		if s.PasswordEnv != "" {
+			if _, set := os.LookupEnv(s.PasswordEnv); !set {
+				results = append(results, warn(name, s.PasswordEnv+" is not set", "Set it in the environment 'airgapper serve' runs in"))
+				continue
+			}
+		}
+		if s.Type == sources.TypeSQLite {
+			if _, err := os.Stat(s.Database); err != nil {
+				results = append(results, fail(name, "Database file missing: "+s.Database, "Fix the path and add the source again"))
+				continue
+			}
+		}
+		results = append(results, ok(name, fmt.Sprintf("%s dump of %s", s.Tool(), s.Database)))
+	}
+	return results
+}
+
+// checkPorts checks that the API port is free, or already serving Airgapper
+func (d *Doctor) checkPorts(ctx context.Context) []Result {
+	if d.ListenAddr == "" {
+		return nil
+	}
+	name := "port " + d.ListenAddr
+
+	ln, err := net.Listen("tcp", d.ListenAddr)
+	if err == nil {
+		_ = ln.Close()
+		return []Result{ok(name, d.ListenAddr+" is free")}
+	}
+
+	// Busy is fine if it's us
+	scheme := "http"
+	if d.Config.TLSEnabled() {
+		scheme = "https"
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, localProbeTimeout)
+	defer cancel()
+	req, reqErr := http.NewRequestWithContext(probeCtx, http.MethodGet, scheme+"://"+d.ListenAddr+api.APIBasePath+api.VersionPath, nil)
+	if reqErr == nil {
+		if resp, err := d.HTTPClient.Do(req); err == nil {
+			_ = resp.Body.Close()
+			if resp.Header.Get(api.APIVersionHeader) != "" {
+				return []Result{ok(name, "airgapper serve is already running on "+d.ListenAddr)}
+			}
+		}
+	}
+	return []Result{fail(name, d.ListenAddr+" is in use: "+err.Error(), "Stop whatever is listening there or pass --addr to 'airgapper serve'")}
+}
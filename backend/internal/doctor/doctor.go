@@ -0,0 +1,108 @@
+// Package doctor runs preflight diagnostics on an Airgapper node and
+// suggests fixes for what it finds
+package doctor
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+// Status is the outcome of a check
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip" // Not applicable to this node
+)
+
+// Result is the outcome of one check
+type Result struct {
+	Name    string `json:"name"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+	Fix     string `json:"fix,omitempty"` // What to do about a warning or failure
+}
+
+func ok(name, message string) Result { return Result{Name: name, Status: StatusOK, Message: message} }
+
+func skip(name, message string) Result {
+	return Result{Name: name, Status: StatusSkip, Message: message}
+}
+
+func warn(name, message, fix string) Result {
+	return Result{Name: name, Status: StatusWarn, Message: message, Fix: fix}
+}
+
+func fail(name, message, fix string) Result {
+	return Result{Name: name, Status: StatusFail, Message: message, Fix: fix}
+}
+
+// Doctor runs the checks. Config and ConfigErr are the result of loading
+// the config; the other fields default to the real environment.
+type Doctor struct {
+	Config    *config.Config
+	ConfigErr error
+
+	// ListenAddr is where `airgapper serve` listens (host:port)
+	ListenAddr string
+
+	// Offline skips the checks that contact the repository and peers
+	Offline bool
+
+	// ResticVersion returns `restic version` output (default: restic.Version)
+	ResticVersion func() (string, error)
+
+	// RepoCheck opens the repository (default: restic cat config)
+	RepoCheck func(ctx context.Context) error
+
+	// RepoLocks lists the repository's locks (default: restic list locks)
+	RepoLocks func(ctx context.Context) ([]restic.Lock, error)
+
+	// LookPath finds the database dump tools (default: exec.LookPath)
+	LookPath func(file string) (string, error)
+
+	HTTPClient *http.Client
+	Now        func() time.Time
+}
+
+// Run runs every check in order
+func (d *Doctor) Run(ctx context.Context) []Result {
+	d.setDefaults()
+
+	results := []Result{d.checkConfig()}
+	if d.Config == nil {
+		return results
+	}
+
+	results = append(results, d.checkRestic())
+	results = append(results, d.checkRepository(ctx))
+	results = append(results, d.checkLocks(ctx))
+	results = append(results, d.checkLockdown())
+	results = append(results, d.checkKeys()...)
+	results = append(results, d.checkShareMatch())
+	results = append(results, d.checkGenesis())
+	results = append(results, d.checkRecoveryKit())
+	results = append(results, d.checkPeers(ctx)...)
+	results = append(results, d.checkDiskSpace()...)
+	results = append(results, d.checkSchedule()...)
+	results = append(results, d.checkSources()...)
+	results = append(results, d.checkPorts(ctx)...)
+	return results
+}
+
+// Failed counts the failed results
+func Failed(results []Result) int {
+	n := 0
+	for _, r := range results {
+		if r.Status == StatusFail {
+			n++
+		}
+	}
+	return n
+}
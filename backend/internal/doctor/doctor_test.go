@@ -0,0 +1,420 @@
+package doctor
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/api"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/genesis"
+	"github.com/lcrostarosa/airgapper/backend/internal/lockdown"
+	"github.com/lcrostarosa/airgapper/backend/internal/peerpin"
+	"github.com/lcrostarosa/airgapper/backend/internal/recoverykit"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/sources"
+	"github.com/lcrostarosa/airgapper/backend/internal/verification"
+)
+
+func testConfig(t *testing.T) *config.Config {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	return &config.Config{
+		Name:       "alice",
+		Role:       config.RoleOwner,
+		RepoURL:    "rest:http://localhost:8000/alice",
+		Password:   "secret",
+		PublicKey:  pub,
+		PrivateKey: priv,
+		LocalShare: []byte{1, 2, 3},
+		ShareIndex: 1,
+		ConfigDir:  dir,
+	}
+}
+
+func testDoctor(cfg *config.Config) *Doctor {
+	return &Doctor{
+		Config:        cfg,
+		ResticVersion: func() (string, error) { return "restic 0.16.4 compiled with go1.21.6 on linux/amd64", nil },
+		RepoCheck:     func(ctx context.Context) error { return nil },
+		RepoLocks:     func(ctx context.Context) ([]restic.Lock, error) { return nil, nil },
+	}
+}
+
+func find(t *testing.T, results []Result, name string) Result {
+	t.Helper()
+	for _, r := range results {
+		if r.Name == name {
+			return r
+		}
+	}
+	t.Fatalf("no result named %q in %+v", name, results)
+	return Result{}
+}
+
+// peerServer answers the version and status endpoints with its clock
+// offset by skew
+func peerServer(t *testing.T, skew time.Duration) *httptest.Server {
+	return newPeerServer(t, skew, true)
+}
+
+// newPeerServer is peerServer, optionally without the status endpoint like
+// peers that only report their clock in the Date header
+func newPeerServer(t *testing.T, skew time.Duration, status bool) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now().Add(skew)
+		w.Header().Set("Date", now.UTC().Format(http.TimeFormat))
+		w.Header().Set(api.APIVersionHeader, api.APIVersion)
+		switch {
+		case r.URL.Path == api.APIBasePath+api.VersionPath:
+			_, _ = w.Write([]byte(`{"apiVersion":"v1","serverVersion":"1.2.3"}`))
+		case status && r.URL.Path == api.APIBasePath+statusPath:
+			_, _ = fmt.Fprintf(w, `{"serverTime":%q}`, now.UTC().Format(time.RFC3339Nano))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestHealthyOwner(t *testing.T) {
+	cfg := testConfig(t)
+	peer := peerServer(t, 0)
+	cfg.Peer = &config.PeerInfo{Name: "bob", Address: peer.URL}
+
+	results := testDoctor(cfg).Run(context.Background())
+	for _, r := range results {
+		assert.NotEqual(t, StatusFail, r.Status, "%s: %s", r.Name, r.Message)
+	}
+	assert.Equal(t, 0, Failed(results))
+	assert.Equal(t, StatusOK, find(t, results, "peer bob").Status)
+	assert.Equal(t, StatusOK, find(t, results, "clock bob").Status)
+	assert.Equal(t, StatusOK, find(t, results, "restic").Status)
+}
+
+func TestNotInitialized(t *testing.T) {
+	results := (&Doctor{ConfigErr: apperrors.ErrNotInitialized}).Run(context.Background())
+	require.Len(t, results, 1)
+	assert.Equal(t, StatusFail, results[0].Status)
+	assert.Contains(t, results[0].Fix, "airgapper init")
+}
+
+func TestInvalidConfig(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.RepoURL = ""
+	cfg.Consensus = &config.ConsensusConfig{Threshold: 3, TotalKeys: 2}
+	cfg.ClockSkewTolerance = "-1m"
+	cfg.BackupTags = []string{"documents", "two words"}
+
+	r := find(t, testDoctor(cfg).Run(context.Background()), "config")
+	assert.Equal(t, StatusFail, r.Status)
+	assert.Contains(t, r.Message, "repo_url is empty")
+	assert.Contains(t, r.Message, "threshold 3 of 2")
+	assert.Contains(t, r.Message, "clock_skew_tolerance cannot be negative")
+	assert.Contains(t, r.Message, `backup_tags: tag "two words"`)
+}
+
+func TestResticVersion(t *testing.T) {
+	d := testDoctor(testConfig(t))
+	d.ResticVersion = func() (string, error) { return "restic 0.12.1 compiled with go1.16", nil }
+	assert.Equal(t, StatusFail, find(t, d.Run(context.Background()), "restic").Status)
+
+	d.ResticVersion = func() (string, error) { return "", errors.New("executable file not found") }
+	r := find(t, d.Run(context.Background()), "restic")
+	assert.Equal(t, StatusFail, r.Status)
+	assert.Contains(t, r.Fix, "Install restic")
+
+	assert.Equal(t, -1, compareVersions("0.9.6", "0.14.0"))
+	assert.Equal(t, 0, compareVersions("0.14.0", "0.14"))
+	assert.Equal(t, 1, compareVersions("0.16.4-dev", "0.14.0"))
+}
+
+func TestRepositoryUnreachable(t *testing.T) {
+	d := testDoctor(testConfig(t))
+	d.RepoCheck = func(ctx context.Context) error { return errors.New("connection refused") }
+	r := find(t, d.Run(context.Background()), "repository")
+	assert.Equal(t, StatusFail, r.Status)
+	assert.Contains(t, r.Message, "connection refused")
+
+	d.Offline = true
+	assert.Equal(t, StatusSkip, find(t, d.Run(context.Background()), "repository").Status)
+}
+
+func TestStaleLocks(t *testing.T) {
+	d := testDoctor(testConfig(t))
+	assert.Equal(t, StatusOK, find(t, d.Run(context.Background()), "locks").Status)
+
+	now := time.Now()
+	d.RepoLocks = func(ctx context.Context) ([]restic.Lock, error) {
+		return []restic.Lock{
+			{ID: "aaaa", Time: now.Add(-time.Minute)},
+			{ID: "bbbb", Time: now.Add(-2 * time.Hour)},
+		}, nil
+	}
+	r := find(t, d.Run(context.Background()), "locks")
+	assert.Equal(t, StatusWarn, r.Status)
+	assert.Contains(t, r.Message, "1 stale lock")
+	assert.Contains(t, r.Fix, "airgapper unlock")
+
+	d.Offline = true
+	assert.Equal(t, StatusSkip, find(t, d.Run(context.Background()), "locks").Status)
+}
+
+func TestKeys(t *testing.T) {
+	cfg := testConfig(t)
+	other, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	cfg.PublicKey = other
+	cfg.LocalShare = nil
+
+	results := testDoctor(cfg).Run(context.Background())
+	assert.Equal(t, StatusFail, find(t, results, "signing key").Status)
+	assert.Equal(t, StatusFail, find(t, results, "shares").Status)
+}
+
+func TestShareCheck(t *testing.T) {
+	cfg := testConfig(t)
+	d := testDoctor(cfg)
+	r := find(t, d.Run(context.Background()), "share check")
+	assert.Equal(t, StatusWarn, r.Status)
+	assert.Contains(t, r.Fix, "verify-shares")
+
+	now := time.Now()
+	cfg.ShareCheck = &verification.ShareCheckState{}
+	cfg.ShareCheck.Record(now, nil)
+	assert.Equal(t, StatusOK, find(t, d.Run(context.Background()), "share check").Status)
+
+	d.Now = func() time.Time { return now.Add(3 * verification.ShareCheckInterval) }
+	assert.Equal(t, StatusWarn, find(t, d.Run(context.Background()), "share check").Status)
+
+	cfg.ShareCheck.Record(now, verification.ErrShareMismatch)
+	r = find(t, d.Run(context.Background()), "share check")
+	assert.Equal(t, StatusFail, r.Status)
+	assert.Contains(t, r.Message, "shares mismatch")
+}
+
+func TestGenesis(t *testing.T) {
+	cfg := testConfig(t)
+	d := testDoctor(cfg)
+	assert.Equal(t, StatusSkip, find(t, d.Run(context.Background()), "genesis").Status)
+
+	doc, err := genesis.New(cfg, 2)
+	require.NoError(t, err)
+	require.NoError(t, doc.Save(cfg))
+	assert.Equal(t, StatusOK, find(t, d.Run(context.Background()), "genesis").Status)
+
+	cfg.RepoURL = "rest:http://localhost:8000/mallory"
+	r := find(t, d.Run(context.Background()), "genesis")
+	assert.Equal(t, StatusFail, r.Status)
+	assert.Contains(t, r.Message, "repository is")
+}
+
+func TestLockdown(t *testing.T) {
+	cfg := testConfig(t)
+	d := testDoctor(cfg)
+	assert.Equal(t, StatusOK, find(t, d.Run(context.Background()), "lockdown").Status)
+
+	l, err := lockdown.New("laptop stolen", "alice", "alice")
+	require.NoError(t, err)
+	_, _, err = lockdown.Apply(cfg.ConfigDir, l)
+	require.NoError(t, err)
+	r := find(t, d.Run(context.Background()), "lockdown")
+	assert.Equal(t, StatusFail, r.Status)
+	assert.Contains(t, r.Message, "laptop stolen")
+}
+
+func TestRecoveryKit(t *testing.T) {
+	cfg := testConfig(t)
+	require.NoError(t, cfg.Save())
+	d := testDoctor(cfg)
+	assert.Equal(t, StatusWarn, find(t, d.Run(context.Background()), "recovery kit").Status)
+
+	_, err := recoverykit.Write(cfg, filepath.Join(t.TempDir(), "kit"))
+	require.NoError(t, err)
+	assert.Equal(t, StatusOK, find(t, d.Run(context.Background()), "recovery kit").Status)
+
+	cfg.Peer = &config.PeerInfo{Name: "carol", Address: "http://carol:8081"}
+	r := find(t, d.Run(context.Background()), "recovery kit")
+	assert.Equal(t, StatusWarn, r.Status)
+	assert.Contains(t, r.Fix, "airgapper recovery-kit --out")
+}
+
+func TestPeerUnreachable(t *testing.T) {
+	cfg := testConfig(t)
+	peer := peerServer(t, 0)
+	addr := peer.URL
+	peer.Close()
+	cfg.Peer = &config.PeerInfo{Name: "bob", Address: addr}
+
+	r := find(t, testDoctor(cfg).Run(context.Background()), "peer bob")
+	assert.Equal(t, StatusFail, r.Status)
+	assert.Contains(t, r.Fix, "airgapper serve")
+}
+
+func TestPeerIdentityChanged(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Peer = &config.PeerInfo{Name: "bob", Address: peerServer(t, 0).URL}
+	d := testDoctor(cfg)
+	d.Offline = true
+	store := peerpin.NewStore(cfg.ConfigDir)
+	require.NoError(t, store.Check(cfg.Peer.Address, peerpin.Identity{PublicKey: "aa"}, time.Now()))
+	assert.Equal(t, StatusOK, find(t, d.Run(context.Background()), "peer identity").Status)
+
+	require.Error(t, store.Check(cfg.Peer.Address, peerpin.Identity{PublicKey: "bb"}, time.Now()))
+	r := find(t, d.Run(context.Background()), "peer identity")
+	assert.Equal(t, StatusFail, r.Status, "even offline")
+	assert.Contains(t, r.Fix, "airgapper peer repin")
+}
+
+func TestClockSkew(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Peer = &config.PeerInfo{Name: "bob", Address: peerServer(t, 3*time.Minute).URL}
+	assert.Equal(t, StatusWarn, find(t, testDoctor(cfg).Run(context.Background()), "clock bob").Status)
+
+	cfg.Peer.Address = peerServer(t, -time.Hour).URL
+	r := find(t, testDoctor(cfg).Run(context.Background()), "clock bob")
+	assert.Equal(t, StatusFail, r.Status)
+	assert.Contains(t, r.Message, "1h0m0s")
+
+	// Skew beyond a tighter configured tolerance fails
+	cfg.ClockSkewTolerance = "2m"
+	cfg.Peer.Address = peerServer(t, 3*time.Minute).URL
+	r = find(t, testDoctor(cfg).Run(context.Background()), "clock bob")
+	assert.Equal(t, StatusFail, r.Status)
+	assert.Contains(t, r.Message, "2m0s tolerance")
+}
+
+func TestClockSkewFromDateHeader(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Peer = &config.PeerInfo{Name: "bob", Address: newPeerServer(t, 0, false).URL}
+	assert.Equal(t, StatusOK, find(t, testDoctor(cfg).Run(context.Background()), "clock bob").Status)
+
+	cfg.Peer.Address = newPeerServer(t, -time.Hour, false).URL
+	assert.Equal(t, StatusFail, find(t, testDoctor(cfg).Run(context.Background()), "clock bob").Status)
+}
+
+func TestSchedule(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.BackupSchedule = "every tuesday-ish"
+	cfg.BackupPaths = []string{t.TempDir()}
+	assert.Equal(t, StatusFail, find(t, testDoctor(cfg).Run(context.Background()), "schedule").Status)
+
+	cfg.BackupSchedule = "daily"
+	cfg.BackupPaths = append(cfg.BackupPaths, filepath.Join(t.TempDir(), "gone"))
+	r := find(t, testDoctor(cfg).Run(context.Background()), "schedule")
+	assert.Equal(t, StatusWarn, r.Status)
+	assert.Contains(t, r.Message, "gone")
+}
+
+func TestPorts(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	d := testDoctor(testConfig(t))
+	d.ListenAddr = addr
+	assert.Equal(t, StatusOK, find(t, d.Run(context.Background()), "port "+addr).Status)
+
+	// Busy with something else
+	ln, err = net.Listen("tcp", addr)
+	require.NoError(t, err)
+	defer func() { _ = ln.Close() }()
+	r := find(t, d.Run(context.Background()), "port "+addr)
+	assert.Equal(t, StatusFail, r.Status)
+	assert.Contains(t, r.Fix, "--addr")
+}
+
+func TestPortServedByAirgapper(t *testing.T) {
+	peer := peerServer(t, 0)
+	d := testDoctor(testConfig(t))
+	d.ListenAddr = peer.Listener.Addr().String()
+
+	r := find(t, d.Run(context.Background()), "port "+d.ListenAddr)
+	assert.Equal(t, StatusOK, r.Status)
+	assert.Contains(t, r.Message, "already running")
+}
+
+func TestHostSkipsOwnerChecks(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Role = config.RoleHost
+	cfg.StoragePath = t.TempDir()
+	require.NoError(t, os.MkdirAll(cfg.StoragePath, 0o700))
+
+	results := testDoctor(cfg).Run(context.Background())
+	assert.Equal(t, StatusSkip, find(t, results, "restic").Status)
+	assert.Equal(t, StatusSkip, find(t, results, "schedule").Status)
+	assert.Equal(t, StatusOK, find(t, results, "repository").Status)
+	assert.Equal(t, StatusOK, find(t, results, "disk "+cfg.StoragePath).Status)
+}
+
+func TestSources(t *testing.T) {
+	cfg := testConfig(t)
+	db := filepath.Join(t.TempDir(), "app.db")
+	require.NoError(t, os.WriteFile(db, nil, 0600))
+	cfg.BackupSources = []sources.Source{
+		{Name: "app", Type: sources.TypeSQLite, Database: db, Enabled: true},
+		{Name: "gone", Type: sources.TypeSQLite, Database: db + ".missing", Enabled: true},
+		{Name: "pg", Type: sources.TypePostgres, Database: "app", Enabled: true},
+		{Name: "off", Type: sources.TypePostgres, Database: "app"},
+	}
+
+	d := testDoctor(cfg)
+	d.LookPath = func(file string) (string, error) {
+		if file == "pg_dump" {
+			return "", errors.New("not found")
+		}
+		return "/usr/bin/" + file, nil
+	}
+	results := d.Run(context.Background())
+	assert.Equal(t, StatusOK, find(t, results, "source app").Status)
+	assert.Equal(t, StatusFail, find(t, results, "source gone").Status)
+	assert.Equal(t, StatusFail, find(t, results, "source pg").Status)
+	assert.Equal(t, StatusSkip, find(t, results, "source off").Status)
+}
+
+func TestPeerStorageMaintenance(t *testing.T) {
+	cfg := testConfig(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case api.APIBasePath + api.VersionPath:
+			_, _ = w.Write([]byte(`{"apiVersion":"v1","serverVersion":"1.2.3"}`))
+		case api.APIBasePath + statusPath:
+			_, _ = fmt.Fprintf(w, `{"serverTime":%q,"storageReadOnly":true,"storageMaintenanceReason":"new disk"}`,
+				time.Now().UTC().Format(time.RFC3339Nano))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	cfg.Peer = &config.PeerInfo{Name: "bob", Address: srv.URL}
+
+	results := testDoctor(cfg).Run(context.Background())
+	r := find(t, results, "storage bob")
+	assert.Equal(t, StatusWarn, r.Status)
+	assert.Contains(t, r.Message, "new disk")
+	assert.Equal(t, StatusOK, find(t, results, "clock bob").Status)
+
+	// A writable peer adds no storage result
+	cfg.Peer.Address = peerServer(t, 0).URL
+	for _, r := range testDoctor(cfg).Run(context.Background()) {
+		assert.NotEqual(t, "storage bob", r.Name)
+	}
+}
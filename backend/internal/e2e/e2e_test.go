@@ -1,4 +1,3 @@
-// Package e2e provides end-to-end tests for Airgapper workflows
 package e2e
 
 import (
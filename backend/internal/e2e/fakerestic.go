@@ -0,0 +1,425 @@
+package e2e
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+// fakeResticEnv makes the test binary act as restic (see RunFakeResticIfRequested)
+const fakeResticEnv = "AIRGAPPER_E2E_FAKE_RESTIC"
+
+// InstallFakeRestic puts a restic on PATH for the rest of the test that is
+// the test binary itself acting as a minimal restic. It speaks the restic
+// REST protocol, so backups really travel to the host's storage server, but
+// stores files unencrypted in its own simple format. The package's TestMain
+// must call RunFakeResticIfRequested.
+func InstallFakeRestic(t testing.TB) {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("failed to find test binary: %v", err)
+	}
+	dir := t.TempDir()
+	if err := os.Symlink(exe, filepath.Join(dir, "restic")); err != nil {
+		t.Fatalf("failed to install fake restic: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv(fakeResticEnv, "1")
+}
+
+// RunFakeResticIfRequested runs the fake restic and exits if this process
+// was started as one by InstallFakeRestic
+func RunFakeResticIfRequested() {
+	if os.Getenv(fakeResticEnv) == "" {
+		return
+	}
+	if err := runFakeRestic(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "Fatal:", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// fakeRepoConfig is the repository config. The password hash stands in for
+// restic's key files so a wrong password fails like it would with restic.
+type fakeRepoConfig struct {
+	ID           string `json:"id"`
+	PasswordHash string `json:"password_hash"`
+}
+
+// fakeSnapshot is a snapshot file: restic's snapshot metadata plus the
+// data blob holding each file
+type fakeSnapshot struct {
+	restic.Snapshot
+	Files []fakeFile `json:"files"`
+}
+
+type fakeFile struct {
+	Path string      `json:"path"`
+	Mode fs.FileMode `json:"mode"`
+	Blob string      `json:"blob"`
+}
+
+// fakeArgs splits restic arguments into positional arguments and flags
+type fakeArgs struct {
+	positional []string
+	flags      map[string][]string
+}
+
+func parseFakeArgs(args []string) fakeArgs {
+	a := fakeArgs{flags: map[string][]string{}}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			a.positional = append(a.positional, arg)
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		if name, value, ok := strings.Cut(name, "="); ok {
+			a.flags[name] = append(a.flags[name], value)
+			continue
+		}
+		if name == "json" {
+			a.flags[name] = append(a.flags[name], "true")
+			continue
+		}
+		if i+1 < len(args) {
+			a.flags[name] = append(a.flags[name], args[i+1])
+			i++
+		}
+	}
+	return a
+}
+
+func (a fakeArgs) flag(names ...string) string {
+	for _, n := range names {
+		if v := a.flags[n]; len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+func runFakeRestic(args []string, out io.Writer) error {
+	a := parseFakeArgs(args)
+	if len(a.positional) == 0 {
+		return errors.New("no command given")
+	}
+	if a.positional[0] == "version" {
+		_, err := fmt.Fprintln(out, "restic 0.16.4 (airgapper e2e fake) compiled with go on linux/amd64")
+		return err
+	}
+
+	repoURL := a.flag("r", "repo")
+	if !strings.HasPrefix(repoURL, "rest:") {
+		return fmt.Errorf("fake restic only supports rest: repositories, got %q", repoURL)
+	}
+	repo := &fakeRepo{url: strings.TrimSuffix(strings.TrimPrefix(repoURL, "rest:"), "/")}
+	password := os.Getenv("RESTIC_PASSWORD")
+
+	cmd, rest := a.positional[0], a.positional[1:]
+	if cmd == "init" {
+		return repo.init(password)
+	}
+	if err := repo.unlock(password); err != nil {
+		return err
+	}
+
+	switch cmd {
+	case "cat":
+		if len(rest) != 1 || rest[0] != "config" {
+			return errors.New("fake restic only supports 'cat config'")
+		}
+		return json.NewEncoder(out).Encode(repo.config)
+	case "backup":
+		return repo.backup(rest, a.flags["tag"], a.flag("json") != "", out)
+	case "snapshots":
+		return repo.listSnapshots(a.flags["tag"], a.flag("json") != "", out)
+	case "restore":
+		if len(rest) != 1 {
+			return errors.New("restore needs a snapshot ID")
+		}
+		return repo.restore(rest[0], a.flag("target"), a.flags["include"])
+	}
+	return fmt.Errorf("fake restic does not support %q", cmd)
+}
+
+type fakeRepo struct {
+	url    string
+	config fakeRepoConfig
+}
+
+func passwordHash(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+func (r *fakeRepo) init(password string) error {
+	if status, _, err := r.do(http.MethodHead, "/config", nil); err != nil {
+		return err
+	} else if status == http.StatusOK {
+		return errors.New("create repository failed: config file already exists, repository already initialized")
+	}
+
+	if _, err := r.expect(http.MethodPost, "/?create=true", nil); err != nil {
+		return err
+	}
+	id := sha256.Sum256([]byte(r.url + time.Now().String()))
+	config, err := json.Marshal(fakeRepoConfig{ID: hex.EncodeToString(id[:]), PasswordHash: passwordHash(password)})
+	if err != nil {
+		return err
+	}
+	_, err = r.expect(http.MethodPost, "/config", config)
+	return err
+}
+
+func (r *fakeRepo) unlock(password string) error {
+	data, err := r.expect(http.MethodGet, "/config", nil)
+	if err != nil {
+		return fmt.Errorf("unable to open repository: %w", err)
+	}
+	if err := json.Unmarshal(data, &r.config); err != nil {
+		return fmt.Errorf("invalid repository config: %w", err)
+	}
+	if r.config.PasswordHash != passwordHash(password) {
+		return errors.New("wrong password or no key found")
+	}
+	return nil
+}
+
+func (r *fakeRepo) backup(paths, tags []string, asJSON bool, out io.Writer) error {
+	if len(paths) == 0 {
+		return errors.New("nothing to backup, please specify source files/dirs")
+	}
+
+	hostname, _ := os.Hostname()
+	snap := fakeSnapshot{Snapshot: restic.Snapshot{Time: time.Now(), Hostname: hostname, Tags: tags}}
+	summary := restic.BackupSummary{}
+	var events []any
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return err
+		}
+		snap.Paths = append(snap.Paths, abs)
+		err = filepath.WalkDir(abs, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			blob, err := r.saveBlob("data", data)
+			if err != nil {
+				return err
+			}
+			snap.Files = append(snap.Files, fakeFile{Path: path, Mode: info.Mode().Perm(), Blob: blob})
+			// Every file counts as new: the fake doesn't look at the parent snapshot
+			summary.FilesNew++
+			summary.DataAdded += info.Size()
+			events = append(events, map[string]any{"message_type": "verbose_status", "action": "new", "item": path, "data_size": info.Size()})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	id, err := r.saveBlob("snapshots", data)
+	if err != nil {
+		return err
+	}
+	if !asJSON {
+		_, err = fmt.Fprintf(out, "snapshot %s saved\n", id[:8])
+		return err
+	}
+
+	summary.SnapshotID = id
+	summary.TotalFilesProcessed = summary.FilesNew
+	summary.TotalBytesProcessed = summary.DataAdded
+	enc := json.NewEncoder(out)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return enc.Encode(struct {
+		MessageType string `json:"message_type"`
+		restic.BackupSummary
+	}{"summary", summary})
+}
+
+// saveBlob stores data under its SHA-256, as restic does
+func (r *fakeRepo) saveBlob(fileType string, data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	id := hex.EncodeToString(sum[:])
+	_, err := r.expect(http.MethodPost, "/"+fileType+"/"+id, data)
+	return id, err
+}
+
+func (r *fakeRepo) snapshots() ([]fakeSnapshot, error) {
+	data, err := r.expect(http.MethodGet, "/snapshots/", nil)
+	if err != nil {
+		return nil, err
+	}
+	var entries []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid snapshot list: %w", err)
+	}
+
+	snapshots := make([]fakeSnapshot, 0, len(entries))
+	for _, e := range entries {
+		data, err := r.expect(http.MethodGet, "/snapshots/"+e.Name, nil)
+		if err != nil {
+			return nil, err
+		}
+		var snap fakeSnapshot
+		if err := json.Unmarshal(data, &snap); err != nil {
+			return nil, fmt.Errorf("invalid snapshot %s: %w", e.Name, err)
+		}
+		snap.ID, snap.ShortID = e.Name, e.Name[:8]
+		snapshots = append(snapshots, snap)
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Time.Before(snapshots[j].Time) })
+	return snapshots, nil
+}
+
+// taggedWith reports whether s matches restic --tag filters: each filter
+// is a comma separated list of tags the snapshot must all carry, and any
+// one filter matching is enough
+func taggedWith(s restic.Snapshot, filters []string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	for _, f := range filters {
+		if s.HasTags(strings.Split(f, ",")) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *fakeRepo) listSnapshots(tagFilters []string, asJSON bool, out io.Writer) error {
+	snapshots, err := r.snapshots()
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		list := make([]restic.Snapshot, 0, len(snapshots))
+		for _, s := range snapshots {
+			if taggedWith(s.Snapshot, tagFilters) {
+				list = append(list, s.Snapshot)
+			}
+		}
+		return json.NewEncoder(out).Encode(list)
+	}
+	for _, s := range snapshots {
+		if !taggedWith(s.Snapshot, tagFilters) {
+			continue
+		}
+		if _, err := fmt.Fprintf(out, "%s  %s  %s  %s\n", s.ShortID, s.Time.Format("2006-01-02 15:04:05"), s.Hostname, strings.Join(s.Paths, ", ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *fakeRepo) restore(snapshotID, target string, includes []string) error {
+	if target == "" {
+		return errors.New("please specify a directory to restore to (--target)")
+	}
+	snapshots, err := r.snapshots()
+	if err != nil {
+		return err
+	}
+
+	var snap *fakeSnapshot
+	for i := range snapshots {
+		if snapshotID == "latest" || strings.HasPrefix(snapshots[i].ID, snapshotID) {
+			snap = &snapshots[i]
+		}
+	}
+	if snap == nil {
+		return fmt.Errorf("no matching ID found for prefix %q", snapshotID)
+	}
+
+	for _, f := range snap.Files {
+		if len(includes) > 0 && !included(f.Path, includes) {
+			continue
+		}
+		data, err := r.expect(http.MethodGet, "/data/"+f.Blob, nil)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(target, f.Path)
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dest, data, f.Mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func included(path string, includes []string) bool {
+	for _, inc := range includes {
+		if path == inc || strings.HasPrefix(path, strings.TrimSuffix(inc, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// expect makes a request and fails unless the server answers 200 OK
+func (r *fakeRepo) expect(method, path string, body []byte) ([]byte, error) {
+	status, data, err := r.do(method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%s %s: %d %s", method, path, status, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+func (r *fakeRepo) do(method, path string, body []byte) (int, []byte, error) {
+	req, err := http.NewRequest(method, r.url+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, data, err
+}
@@ -0,0 +1,331 @@
+// Package e2e provides end-to-end tests for Airgapper workflows, and a
+// harness that runs owner and host API servers in-process so tests can
+// drive them over HTTP like real peers do
+package e2e
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	airgapperv1 "github.com/lcrostarosa/airgapper/backend/gen/airgapper/v1"
+	"github.com/lcrostarosa/airgapper/backend/gen/airgapper/v1/airgapperv1connect"
+	"github.com/lcrostarosa/airgapper/backend/internal/api"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+// Node is an Airgapper API server on an ephemeral port with its own
+// config directory. Config is the server's live config, so changes made
+// over HTTP (e.g. InitVault) are visible through it.
+type Node struct {
+	Config *config.Config
+	Server *api.Server
+	URL    string
+}
+
+// StartNode serves cfg on an ephemeral loopback port until the test ends
+func StartNode(t testing.TB, cfg *config.Config) *Node {
+	t.Helper()
+	if cfg.ConfigDir == "" {
+		cfg.ConfigDir = t.TempDir()
+	}
+
+	srv := api.NewServer(cfg, "127.0.0.1:0")
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(func() {
+		ts.Close()
+		if s := srv.StorageServer(); s != nil {
+			s.Stop()
+		}
+		if c := srv.ManagedScheduledChecker(); c != nil {
+			c.Stop()
+		}
+	})
+
+	return &Node{Config: cfg, Server: srv, URL: ts.URL}
+}
+
+// StartOwner starts an uninitialized node, to be set up with InitVault
+func StartOwner(t testing.TB) *Node {
+	t.Helper()
+	return StartNode(t, &config.Config{})
+}
+
+// StartHost starts an uninitialized node serving repositories from a
+// temporary storage directory, to be set up with InitHost
+func StartHost(t testing.TB) *Node {
+	t.Helper()
+	dir := t.TempDir()
+	return StartNode(t, &config.Config{
+		ConfigDir:   filepath.Join(dir, "config"),
+		StoragePath: filepath.Join(dir, "storage"),
+	})
+}
+
+// StorageURL is the restic repository URL for repo on this node's storage server
+func (n *Node) StorageURL(repo string) string {
+	return "rest:" + n.URL + "/storage/" + repo
+}
+
+// Restic returns a restic client for this node's repository and password
+func (n *Node) Restic() *restic.Client {
+	return restic.NewClient(n.Config.RepoURL, n.Config.Password).WithIdentity(n.Config.PrivateKey).WithOptions(n.Config.Restic)
+}
+
+// KeyID is this node's key holder ID
+func (n *Node) KeyID() string {
+	return crypto.KeyID(n.Config.PublicKey)
+}
+
+func (n *Node) baseURL() string {
+	return n.URL + api.APIBasePath
+}
+
+// Vault returns a VaultService client for this node
+func (n *Node) Vault() airgapperv1connect.VaultServiceClient {
+	return airgapperv1connect.NewVaultServiceClient(http.DefaultClient, n.baseURL())
+}
+
+// Host returns a HostService client for this node
+func (n *Node) Host() airgapperv1connect.HostServiceClient {
+	return airgapperv1connect.NewHostServiceClient(http.DefaultClient, n.baseURL())
+}
+
+// KeyHolders returns a KeyHolderService client for this node
+func (n *Node) KeyHolders() airgapperv1connect.KeyHolderServiceClient {
+	return airgapperv1connect.NewKeyHolderServiceClient(http.DefaultClient, n.baseURL())
+}
+
+// Requests returns a RestoreRequestService client for this node
+func (n *Node) Requests() airgapperv1connect.RestoreRequestServiceClient {
+	return airgapperv1connect.NewRestoreRequestServiceClient(http.DefaultClient, n.baseURL())
+}
+
+// Deletions returns a DeletionService client for this node
+func (n *Node) Deletions() airgapperv1connect.DeletionServiceClient {
+	return airgapperv1connect.NewDeletionServiceClient(http.DefaultClient, n.baseURL())
+}
+
+// Health returns a HealthService client for this node
+func (n *Node) Health() airgapperv1connect.HealthServiceClient {
+	return airgapperv1connect.NewHealthServiceClient(http.DefaultClient, n.baseURL())
+}
+
+// NewRequest returns a restore request for this node's API, signed with
+// its owner's key the way the CLI signs requests it creates
+func (n *Node) NewRequest(snapshotID, reason string, paths ...string) (*connect.Request[airgapperv1.CreateRequestRequest], error) {
+	return n.newRequest("", snapshotID, reason, paths)
+}
+
+// NewKeyExportRequest returns a request to export the repository password
+// for this node's API, signed like NewRequest
+func (n *Node) NewKeyExportRequest(reason string) (*connect.Request[airgapperv1.CreateRequestRequest], error) {
+	return n.newRequest(consent.PurposeExportKeys, "", reason, nil)
+}
+
+// NewBrowseRequest returns a request to list snapshotID's files for this
+// node's API, signed like NewRequest
+func (n *Node) NewBrowseRequest(snapshotID, reason string, paths ...string) (*connect.Request[airgapperv1.CreateRequestRequest], error) {
+	return n.newRequest(consent.PurposeBrowse, snapshotID, reason, paths)
+}
+
+func (n *Node) newRequest(purpose, snapshotID, reason string, paths []string) (*connect.Request[airgapperv1.CreateRequestRequest], error) {
+	id, err := consent.NewRequestID()
+	if err != nil {
+		return nil, err
+	}
+	snapshotID = consent.NormalizeSnapshotID(purpose, snapshotID)
+	createdAt := time.Now()
+
+	keyID := n.KeyID()
+	signature, err := (&crypto.RestoreRequestSignData{
+		RequestID:   id,
+		Requester:   n.Config.Name,
+		SnapshotID:  snapshotID,
+		Reason:      reason,
+		KeyHolderID: keyID,
+		Paths:       paths,
+		CreatedAt:   createdAt.Unix(),
+		Purpose:     purpose,
+	}).Sign(n.Config.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	return connect.NewRequest(&airgapperv1.CreateRequestRequest{
+		SnapshotId:  snapshotID,
+		Paths:       paths,
+		Reason:      reason,
+		Id:          id,
+		CreatedAt:   timestamppb.New(createdAt),
+		KeyHolderId: keyID,
+		Signature:   hex.EncodeToString(signature),
+		Purpose:     purpose,
+	}), nil
+}
+
+// Approval fetches restore request id and a challenge from this node and
+// returns signer's approval of it, without sending it
+func (n *Node) Approval(ctx context.Context, signer *Node, id string) (*airgapperv1.SignRequestRequest, error) {
+	return n.ApprovalAt(ctx, signer, id, time.Now())
+}
+
+// ApprovalAt is Approval with signer's clock reading signedAt
+func (n *Node) ApprovalAt(ctx context.Context, signer *Node, id string, signedAt time.Time) (*airgapperv1.SignRequestRequest, error) {
+	got, err := n.Requests().GetRequest(ctx, connect.NewRequest(&airgapperv1.GetRequestRequest{Id: id}))
+	if err != nil {
+		return nil, err
+	}
+	req := got.Msg.Request
+
+	keyID := signer.KeyID()
+	challenge, err := n.Requests().IssueChallenge(ctx, connect.NewRequest(&airgapperv1.IssueChallengeRequest{
+		Id:          id,
+		KeyHolderId: keyID,
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	signedAt = signedAt.Truncate(time.Second)
+	data := &crypto.RestoreRequestSignData{
+		RequestID:   req.Id,
+		Requester:   req.Requester,
+		SnapshotID:  req.SnapshotId,
+		Reason:      req.Reason,
+		KeyHolderID: keyID,
+		Paths:       req.Paths,
+		CreatedAt:   req.CreatedAt.AsTime().Unix(),
+		Nonce:       challenge.Msg.Nonce,
+		SignedAt:    signedAt.Unix(),
+		SizeBytes:   req.SizeBytes,
+		Purpose:     req.Purpose,
+		Template:    req.Template,
+	}
+	if rc := req.RequesterContext; rc != nil {
+		data.RequesterHostname = rc.Hostname
+		data.RequesterOS = rc.Os
+		data.RequesterVersion = rc.Version
+		data.RequesterKeyFingerprint = rc.KeyFingerprint
+		data.SourceIP = rc.SourceIp
+	}
+	signature, err := data.Sign(signer.Config.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	return &airgapperv1.SignRequestRequest{
+		Id:          id,
+		KeyHolderId: keyID,
+		Signature:   hex.EncodeToString(signature),
+		Nonce:       challenge.Msg.Nonce,
+		SignedAt:    timestamppb.New(signedAt),
+	}, nil
+}
+
+// ExtensionApproval fetches restore request id and a challenge from this
+// node and returns signer's approval of its pending extension, without
+// sending it
+func (n *Node) ExtensionApproval(ctx context.Context, signer *Node, id string) (*airgapperv1.ApproveExtensionRequest, error) {
+	got, err := n.Requests().GetRequest(ctx, connect.NewRequest(&airgapperv1.GetRequestRequest{Id: id}))
+	if err != nil {
+		return nil, err
+	}
+	req := got.Msg.Request
+	var by time.Duration
+	for _, ext := range req.Extensions {
+		if ext.ApprovedAt == nil {
+			if by, err = time.ParseDuration(ext.ExtendBy); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	keyID := signer.KeyID()
+	challenge, err := n.Requests().IssueChallenge(ctx, connect.NewRequest(&airgapperv1.IssueChallengeRequest{
+		Id:          id,
+		KeyHolderId: keyID,
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	signedAt := time.Now().Truncate(time.Second)
+	signature, err := (&crypto.ExtensionSignData{
+		RequestID:   id,
+		KeyHolderID: keyID,
+		ExpiresAt:   req.ExpiresAt.AsTime().Add(by).Unix(),
+		Nonce:       challenge.Msg.Nonce,
+		SignedAt:    signedAt.Unix(),
+	}).Sign(signer.Config.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign extension: %w", err)
+	}
+
+	return &airgapperv1.ApproveExtensionRequest{
+		Id:          id,
+		KeyHolderId: keyID,
+		Signature:   hex.EncodeToString(signature),
+		Nonce:       challenge.Msg.Nonce,
+		SignedAt:    timestamppb.New(signedAt),
+	}, nil
+}
+
+// Veto fetches a challenge from this node for restore request id and
+// returns signer's veto of it for reason, without sending it
+func (n *Node) Veto(ctx context.Context, signer *Node, id, reason string) (*airgapperv1.VetoRequestRequest, error) {
+	keyID := signer.KeyID()
+	challenge, err := n.Requests().IssueChallenge(ctx, connect.NewRequest(&airgapperv1.IssueChallengeRequest{
+		Id:          id,
+		KeyHolderId: keyID,
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	signedAt := time.Now().Truncate(time.Second)
+	signature, err := (&crypto.VetoSignData{
+		RequestID:   id,
+		KeyHolderID: keyID,
+		Reason:      reason,
+		Nonce:       challenge.Msg.Nonce,
+		SignedAt:    signedAt.Unix(),
+	}).Sign(signer.Config.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign veto: %w", err)
+	}
+
+	return &airgapperv1.VetoRequestRequest{
+		Id:          id,
+		KeyHolderId: keyID,
+		Reason:      reason,
+		Signature:   hex.EncodeToString(signature),
+		Nonce:       challenge.Msg.Nonce,
+		SignedAt:    timestamppb.New(signedAt),
+	}, nil
+}
+
+// Sign approves restore request id on this node with signer's key, the way
+// a key holder signs a request fetched from the owner
+func (n *Node) Sign(ctx context.Context, signer *Node, id string) (*airgapperv1.SignRequestResponse, error) {
+	approval, err := n.Approval(ctx, signer, id)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := n.Requests().SignRequest(ctx, connect.NewRequest(approval))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Msg, nil
+}
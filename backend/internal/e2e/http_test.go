@@ -0,0 +1,891 @@
+package e2e
+
+import (
+	"context"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	airgapperv1 "github.com/lcrostarosa/airgapper/backend/gen/airgapper/v1"
+	"github.com/lcrostarosa/airgapper/backend/internal/api"
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/grpc"
+	"github.com/lcrostarosa/airgapper/backend/internal/keyprotect"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+)
+
+func TestMain(m *testing.M) {
+	RunFakeResticIfRequested()
+	os.Exit(m.Run())
+}
+
+// setupPair initializes a host and an owner over HTTP, registers the host
+// as the owner's second key holder (2-of-2) and backs up src to the host
+func setupPair(t *testing.T, src string) (owner, host *Node) {
+	t.Helper()
+	InstallFakeRestic(t)
+	ctx := context.Background()
+
+	host = StartHost(t)
+	hostInit, err := host.Host().InitHost(ctx, connect.NewRequest(&airgapperv1.InitHostRequest{
+		Name:        "bob",
+		StoragePath: host.Config.StoragePath,
+	}))
+	require.NoError(t, err)
+
+	owner = StartOwner(t)
+	_, err = owner.Vault().InitVault(ctx, connect.NewRequest(&airgapperv1.InitVaultRequest{
+		Name:        "alice",
+		RepoUrl:     host.StorageURL("alice"),
+		Threshold:   2,
+		TotalKeys:   2,
+		BackupPaths: []string{src},
+	}))
+	require.NoError(t, err)
+
+	_, err = owner.KeyHolders().RegisterKeyHolder(ctx, connect.NewRequest(&airgapperv1.RegisterKeyHolderRequest{
+		Name:      "bob",
+		PublicKey: hostInit.Msg.PublicKey,
+		Address:   host.URL,
+	}))
+	require.NoError(t, err)
+
+	require.NoError(t, owner.Restic().Init(ctx))
+	summary, err := owner.Restic().Backup(ctx, owner.Config.BackupPaths, nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, summary.SnapshotID)
+	return owner, host
+}
+
+func TestE2E_HTTP_BackupRequestApproveRestore(t *testing.T) {
+	ctx := context.Background()
+	src := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "photos"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "notes.txt"), []byte("remember the milk"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "photos", "cat.jpg"), []byte{0xff, 0xd8, 0xff, 0xe0}, 0o644))
+
+	owner, host := setupPair(t, src)
+
+	// The backup landed on the host's storage server
+	snapshots, err := owner.Restic().SnapshotList(ctx)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	assert.Equal(t, 1, host.Server.StorageServer().VaultMetadata().Snapshots)
+
+	create, err := owner.NewRequest(snapshots[0].ID, "laptop died")
+	require.NoError(t, err)
+	created, err := owner.Requests().CreateRequest(ctx, create)
+	require.NoError(t, err)
+	id := created.Msg.Id
+
+	// The host's approval alone isn't enough for 2-of-2
+	progress, err := owner.Sign(ctx, host, id)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), progress.CurrentApprovals)
+	assert.Equal(t, int32(2), progress.RequiredApprovals)
+	assert.False(t, progress.IsApproved)
+
+	_, err = owner.Sign(ctx, host, id)
+	assert.Error(t, err, "a key holder can only approve once")
+
+	progress, err = owner.Sign(ctx, owner, id)
+	require.NoError(t, err)
+	assert.True(t, progress.IsApproved)
+
+	got, err := owner.Requests().GetRequest(ctx, connect.NewRequest(&airgapperv1.GetRequestRequest{Id: id}))
+	require.NoError(t, err)
+	assert.Equal(t, airgapperv1.RequestStatus_REQUEST_STATUS_APPROVED, got.Msg.Request.Status)
+	assert.Len(t, got.Msg.Request.Approvals, 2)
+
+	target := t.TempDir()
+	require.NoError(t, owner.Restic().Restore(ctx, got.Msg.Request.SnapshotId, target))
+
+	restored, err := os.ReadFile(filepath.Join(target, src, "notes.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "remember the milk", string(restored))
+	restored, err = os.ReadFile(filepath.Join(target, src, "photos", "cat.jpg"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xff, 0xd8, 0xff, 0xe0}, restored)
+}
+
+func TestE2E_HTTP_UnregisteredKeyHolderCannotApprove(t *testing.T) {
+	ctx := context.Background()
+	owner, _ := setupPair(t, t.TempDir())
+
+	stranger := StartHost(t)
+	_, err := stranger.Host().InitHost(ctx, connect.NewRequest(&airgapperv1.InitHostRequest{
+		Name:        "mallory",
+		StoragePath: stranger.Config.StoragePath,
+	}))
+	require.NoError(t, err)
+
+	create, err := owner.NewRequest("", "just looking")
+	require.NoError(t, err)
+	created, err := owner.Requests().CreateRequest(ctx, create)
+	require.NoError(t, err)
+
+	_, err = owner.Sign(ctx, stranger, created.Msg.Id)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown key holder")
+	var connectErr *connect.Error
+	require.ErrorAs(t, err, &connectErr)
+	assert.Equal(t, string(apperrors.CodeKeyHolderNotFound), connectErr.Meta().Get(grpc.ErrorCodeHeader))
+
+	got, err := owner.Requests().GetRequest(ctx, connect.NewRequest(&airgapperv1.GetRequestRequest{Id: created.Msg.Id}))
+	require.NoError(t, err)
+	assert.Equal(t, airgapperv1.RequestStatus_REQUEST_STATUS_PENDING, got.Msg.Request.Status)
+	assert.Empty(t, got.Msg.Request.Approvals)
+}
+
+func TestE2E_HTTP_WrongPasswordCannotRestore(t *testing.T) {
+	ctx := context.Background()
+	src := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(src, "secret.txt"), []byte("hunter2"), 0o600))
+	owner, _ := setupPair(t, src)
+
+	owner.Config.Password = "not-the-password"
+	err := owner.Restic().Restore(ctx, "latest", t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestE2E_HTTP_CorrelationIDTracksRequest(t *testing.T) {
+	ctx := context.Background()
+	owner, _ := setupPair(t, t.TempDir())
+
+	create, err := owner.NewRequest("", "trace me")
+	require.NoError(t, err)
+	create.Header().Set(tracing.Header, "owner-op-42")
+	created, err := owner.Requests().CreateRequest(ctx, create)
+	require.NoError(t, err)
+	assert.Equal(t, "owner-op-42", created.Header().Get(tracing.Header))
+
+	stored, err := consent.NewManager(owner.Config.ConfigDir).GetRequest(created.Msg.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "owner-op-42", stored.CorrelationID)
+
+	// Failed calls return an ID to look up in the server's logs
+	_, err = owner.Requests().GetRequest(ctx, connect.NewRequest(&airgapperv1.GetRequestRequest{Id: "missing"}))
+	var connectErr *connect.Error
+	require.ErrorAs(t, err, &connectErr)
+	assert.True(t, tracing.Valid(connectErr.Meta().Get(tracing.Header)))
+}
+
+func TestE2E_HTTP_ErrorsCarryCodes(t *testing.T) {
+	ctx := context.Background()
+	owner, _ := setupPair(t, t.TempDir())
+
+	_, err := owner.Requests().GetRequest(ctx, connect.NewRequest(&airgapperv1.GetRequestRequest{Id: "missing"}))
+	var connectErr *connect.Error
+	require.ErrorAs(t, err, &connectErr)
+	assert.Equal(t, connect.CodeNotFound, connectErr.Code())
+	assert.Equal(t, string(apperrors.CodeRequestNotFound), connectErr.Meta().Get(grpc.ErrorCodeHeader))
+
+	require.Len(t, connectErr.Details(), 1)
+	detail, err := connectErr.Details()[0].Value()
+	require.NoError(t, err)
+	fields := detail.(*structpb.Struct).AsMap()
+	assert.Equal(t, string(apperrors.CodeRequestNotFound), fields["code"])
+	assert.Equal(t, "REQUEST_NOT_FOUND", fields["reason"])
+}
+
+func TestE2E_HTTP_RequestsMustBeSignedByRequester(t *testing.T) {
+	ctx := context.Background()
+	owner, host := setupPair(t, t.TempDir())
+
+	code := func(err error) string {
+		t.Helper()
+		var connectErr *connect.Error
+		require.ErrorAs(t, err, &connectErr)
+		return connectErr.Meta().Get(grpc.ErrorCodeHeader)
+	}
+
+	// Unsigned
+	_, err := owner.Requests().CreateRequest(ctx, connect.NewRequest(&airgapperv1.CreateRequestRequest{Reason: "trust me"}))
+	assert.Equal(t, string(apperrors.CodeRequestUnsigned), code(err))
+
+	// Changed after signing
+	create, err := owner.NewRequest("", "just the notes", "/home/alice/notes")
+	require.NoError(t, err)
+	create.Msg.Paths = []string{"/home/alice"}
+	_, err = owner.Requests().CreateRequest(ctx, create)
+	assert.Equal(t, string(apperrors.CodeInvalidSignature), code(err))
+
+	// Signed by another key holder
+	_, err = owner.Requests().CreateRequest(ctx, signedBy(t, owner, host, "not mine"))
+	assert.Equal(t, string(apperrors.CodeInvalidSignature), code(err))
+
+	// Replayed
+	create, err = owner.NewRequest("", "once")
+	require.NoError(t, err)
+	_, err = owner.Requests().CreateRequest(ctx, create)
+	require.NoError(t, err)
+	_, err = owner.Requests().CreateRequest(ctx, create)
+	assert.Equal(t, string(apperrors.CodeRequestExists), code(err))
+
+	pending, err := owner.Requests().ListRequests(ctx, connect.NewRequest(&airgapperv1.ListRequestsRequest{}))
+	require.NoError(t, err)
+	require.Len(t, pending.Msg.Requests, 1)
+	assert.Equal(t, create.Msg.Id, pending.Msg.Requests[0].Id)
+}
+
+// signedBy returns a request for owner's API signed by signer's key
+func signedBy(t *testing.T, owner, signer *Node, reason string) *connect.Request[airgapperv1.CreateRequestRequest] {
+	t.Helper()
+	req, err := signer.NewRequest("", reason)
+	require.NoError(t, err)
+
+	// Re-sign under the owner's name with the signer's key
+	sig := &crypto.RestoreRequestSignData{
+		RequestID:   req.Msg.Id,
+		Requester:   owner.Config.Name,
+		SnapshotID:  req.Msg.SnapshotId,
+		Reason:      reason,
+		KeyHolderID: signer.KeyID(),
+		CreatedAt:   req.Msg.CreatedAt.AsTime().Unix(),
+	}
+	signature, err := sig.Sign(signer.Config.PrivateKey)
+	require.NoError(t, err)
+	req.Msg.Signature = hex.EncodeToString(signature)
+	return req
+}
+
+func TestE2E_HTTP_KeyExportApproveAndReconstruct(t *testing.T) {
+	ctx := context.Background()
+	owner, host := setupPair(t, t.TempDir())
+
+	code := func(err error) string {
+		t.Helper()
+		var connectErr *connect.Error
+		require.ErrorAs(t, err, &connectErr)
+		return connectErr.Meta().Get(grpc.ErrorCodeHeader)
+	}
+
+	// A node dropping the purpose can't pass the export off as a restore,
+	// and a purpose the node doesn't know is refused
+	create, err := owner.NewKeyExportRequest("moving to plain restic")
+	require.NoError(t, err)
+	create.Msg.Purpose = ""
+	_, err = owner.Requests().CreateRequest(ctx, create)
+	assert.Equal(t, string(apperrors.CodeInvalidSignature), code(err))
+	create.Msg.Purpose = "format-disk"
+	_, err = owner.Requests().CreateRequest(ctx, create)
+	assert.Equal(t, string(apperrors.CodeInvalidArgument), code(err))
+
+	create, err = owner.NewKeyExportRequest("moving to plain restic")
+	require.NoError(t, err)
+	created, err := owner.Requests().CreateRequest(ctx, create)
+	require.NoError(t, err)
+	id := created.Msg.Id
+
+	got, err := owner.Requests().GetRequest(ctx, connect.NewRequest(&airgapperv1.GetRequestRequest{Id: id}))
+	require.NoError(t, err)
+	assert.Equal(t, consent.PurposeExportKeys, got.Msg.Request.Purpose)
+	assert.Empty(t, got.Msg.Request.SnapshotId)
+
+	// An approval signed without seeing the purpose is refused
+	blind, err := owner.Approval(ctx, host, id)
+	require.NoError(t, err)
+	data := &crypto.RestoreRequestSignData{
+		RequestID:   id,
+		Requester:   got.Msg.Request.Requester,
+		Reason:      got.Msg.Request.Reason,
+		KeyHolderID: host.KeyID(),
+		CreatedAt:   got.Msg.Request.CreatedAt.AsTime().Unix(),
+		Nonce:       blind.Nonce,
+		SignedAt:    blind.SignedAt.AsTime().Unix(),
+	}
+	signature, err := data.Sign(host.Config.PrivateKey)
+	require.NoError(t, err)
+	blind.Signature = hex.EncodeToString(signature)
+	_, err = owner.Requests().SignRequest(ctx, connect.NewRequest(blind))
+	assert.Equal(t, string(apperrors.CodeInvalidSignature), code(err))
+
+	_, err = owner.Sign(ctx, host, id)
+	require.NoError(t, err)
+	progress, err := owner.Sign(ctx, owner, id)
+	require.NoError(t, err)
+	require.True(t, progress.IsApproved)
+
+	// The approved export reconstructs the password that opens the repository
+	req, err := consent.NewManager(owner.Config.ConfigDir).GetRequest(id)
+	require.NoError(t, err)
+	require.True(t, req.IsKeyExport())
+	require.NoError(t, req.CheckExecutable(time.Now()))
+	password, _, err := owner.Config.RecoverPassword(ctx, "", keyprotect.Unlock{PeerShare: req.ShareData})
+	require.NoError(t, err)
+	snapshots, err := restic.NewClient(owner.Config.RepoURL, string(password)).SnapshotList(ctx)
+	require.NoError(t, err)
+	assert.Len(t, snapshots, 1)
+}
+
+func TestE2E_HTTP_BrowseScopeEnforced(t *testing.T) {
+	ctx := context.Background()
+	owner, host := setupPair(t, t.TempDir())
+
+	code := func(err error) string {
+		t.Helper()
+		var connectErr *connect.Error
+		require.ErrorAs(t, err, &connectErr)
+		return connectErr.Meta().Get(grpc.ErrorCodeHeader)
+	}
+
+	// A browse lists a whole snapshot for a short while, so it takes no
+	// paths or longer TTL
+	create, err := owner.NewBrowseRequest("latest", "is the tax folder there?", "/home/alice/taxes")
+	require.NoError(t, err)
+	_, err = owner.Requests().CreateRequest(ctx, create)
+	assert.Equal(t, string(apperrors.CodeInvalidArgument), code(err))
+	create, err = owner.NewBrowseRequest("latest", "is the tax folder there?")
+	require.NoError(t, err)
+	create.Msg.Ttl = "72h"
+	_, err = owner.Requests().CreateRequest(ctx, create)
+	assert.Equal(t, string(apperrors.CodeInvalidArgument), code(err))
+
+	create, err = owner.NewBrowseRequest("latest", "is the tax folder there?")
+	require.NoError(t, err)
+	created, err := owner.Requests().CreateRequest(ctx, create)
+	require.NoError(t, err)
+	id := created.Msg.Id
+	got, err := owner.Requests().GetRequest(ctx, connect.NewRequest(&airgapperv1.GetRequestRequest{Id: id}))
+	require.NoError(t, err)
+	assert.Equal(t, consent.PurposeBrowse, got.Msg.Request.Purpose)
+	assert.Equal(t, consent.BrowseRequestTTL, got.Msg.Request.ExpiresAt.AsTime().Sub(got.Msg.Request.CreatedAt.AsTime()))
+
+	_, err = owner.Sign(ctx, host, id)
+	require.NoError(t, err)
+	progress, err := owner.Sign(ctx, owner, id)
+	require.NoError(t, err)
+	require.True(t, progress.IsApproved)
+
+	// The approval covers listing only: the node won't record a restore on it
+	_, err = owner.Requests().ReportRestoreProgress(ctx, connect.NewRequest(&airgapperv1.ReportRestoreProgressRequest{
+		Id:       id,
+		Progress: &airgapperv1.RestoreProgress{Status: consent.ProgressRunning},
+	}))
+	assert.Equal(t, string(apperrors.CodeWrongRequestPurpose), code(err))
+}
+
+func TestE2E_HTTP_DeletionQuorum(t *testing.T) {
+	ctx := context.Background()
+	owner, host := setupPair(t, t.TempDir())
+
+	// Only the owner's approval carries weight
+	owner.Config.Consensus.Quorum = &config.QuorumConfig{RequireOwner: true, Weights: map[string]int{host.KeyID(): 0}, MinWeight: 1}
+
+	created, err := owner.Deletions().CreateDeletion(ctx, connect.NewRequest(&airgapperv1.CreateDeletionRequest{
+		DeletionType:      airgapperv1.DeletionType_DELETION_TYPE_SNAPSHOT,
+		SnapshotIds:       []string{"abcd1234"},
+		Reason:            "remove the test snapshot",
+		RequiredApprovals: 2,
+	}))
+	require.NoError(t, err)
+	id := created.Msg.Id
+
+	_, err = owner.Deletions().ApproveDeletion(ctx, connect.NewRequest(&airgapperv1.ApproveDeletionRequest{
+		Id:          id,
+		KeyHolderId: host.KeyID(),
+		Signature:   hex.EncodeToString([]byte("sig")),
+	}))
+	assert.ErrorContains(t, err, "carries no weight")
+
+	approved, err := owner.Deletions().ApproveDeletion(ctx, connect.NewRequest(&airgapperv1.ApproveDeletionRequest{
+		Id:          id,
+		KeyHolderId: owner.KeyID(),
+		Signature:   hex.EncodeToString([]byte("sig")),
+	}))
+	require.NoError(t, err)
+	assert.True(t, approved.Msg.IsApproved)
+	assert.Equal(t, int32(1), approved.Msg.RequiredApprovals)
+}
+
+func TestE2E_HTTP_ApprovalsCannotBeReplayed(t *testing.T) {
+	ctx := context.Background()
+	owner, host := setupPair(t, t.TempDir())
+
+	// Another owner's node stores a request with the same ID and fields and
+	// trusts the same host
+	other := StartOwner(t)
+	_, err := other.Vault().InitVault(ctx, connect.NewRequest(&airgapperv1.InitVaultRequest{
+		Name:      "alice",
+		RepoUrl:   host.StorageURL("alice-too"),
+		Threshold: 2,
+		TotalKeys: 2,
+	}))
+	require.NoError(t, err)
+	_, err = other.KeyHolders().RegisterKeyHolder(ctx, connect.NewRequest(&airgapperv1.RegisterKeyHolderRequest{
+		Name:      "bob",
+		PublicKey: hex.EncodeToString(host.Config.PublicKey),
+		Address:   host.URL,
+	}))
+	require.NoError(t, err)
+
+	create, err := owner.NewRequest("", "replay me")
+	require.NoError(t, err)
+	created, err := owner.Requests().CreateRequest(ctx, create)
+	require.NoError(t, err)
+	id := created.Msg.Id
+	stored, err := os.ReadFile(filepath.Join(owner.Config.ConfigDir, "requests", id+".json"))
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(other.Config.ConfigDir, "requests"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(other.Config.ConfigDir, "requests", id+".json"), stored, 0o600))
+
+	approval, err := owner.Approval(ctx, host, id)
+	require.NoError(t, err)
+	_, err = owner.Requests().SignRequest(ctx, connect.NewRequest(approval))
+	require.NoError(t, err)
+
+	code := func(err error) string {
+		t.Helper()
+		var connectErr *connect.Error
+		require.ErrorAs(t, err, &connectErr)
+		return connectErr.Meta().Get(grpc.ErrorCodeHeader)
+	}
+
+	// The nonce was issued by the owner's node, not the other one
+	_, err = other.Requests().SignRequest(ctx, connect.NewRequest(approval))
+	assert.Equal(t, string(apperrors.CodeChallengeInvalid), code(err))
+
+	// Approvals must answer a challenge
+	approval.Nonce = ""
+	_, err = other.Requests().SignRequest(ctx, connect.NewRequest(approval))
+	assert.Equal(t, string(apperrors.CodeChallengeInvalid), code(err))
+
+	got, err := other.Requests().GetRequest(ctx, connect.NewRequest(&airgapperv1.GetRequestRequest{Id: id}))
+	require.NoError(t, err)
+	assert.Empty(t, got.Msg.Request.Approvals)
+}
+
+func TestE2E_HTTP_ApprovalsCarrySignedTimestamps(t *testing.T) {
+	ctx := context.Background()
+	owner, host := setupPair(t, t.TempDir())
+
+	create, err := owner.NewRequest("", "what time is it")
+	require.NoError(t, err)
+	created, err := owner.Requests().CreateRequest(ctx, create)
+	require.NoError(t, err)
+	id := created.Msg.Id
+
+	// An approval signed an hour ago is rejected, even with a fresh nonce
+	approval, err := owner.ApprovalAt(ctx, host, id, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	_, err = owner.Requests().SignRequest(ctx, connect.NewRequest(approval))
+	var connectErr *connect.Error
+	require.ErrorAs(t, err, &connectErr)
+	assert.Equal(t, string(apperrors.CodeInvalidSignature), connectErr.Meta().Get(grpc.ErrorCodeHeader))
+
+	// The signer's clock running a little ahead is tolerated
+	signedAt := time.Now().Add(2 * time.Minute).Truncate(time.Second)
+	approval, err = owner.ApprovalAt(ctx, host, id, signedAt)
+	require.NoError(t, err)
+	_, err = owner.Requests().SignRequest(ctx, connect.NewRequest(approval))
+	require.NoError(t, err)
+
+	got, err := owner.Requests().GetRequest(ctx, connect.NewRequest(&airgapperv1.GetRequestRequest{Id: id}))
+	require.NoError(t, err)
+	require.Len(t, got.Msg.Request.Approvals, 1)
+	assert.True(t, signedAt.Equal(got.Msg.Request.Approvals[0].SignedAt.AsTime()))
+
+	status, err := owner.Health().GetStatus(ctx, connect.NewRequest(&airgapperv1.GetStatusRequest{}))
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), status.Msg.ServerTime.AsTime(), time.Minute)
+}
+
+func TestE2E_HTTP_RequestsRecordRequesterContext(t *testing.T) {
+	ctx := context.Background()
+	owner, host := setupPair(t, t.TempDir())
+
+	rc := &airgapperv1.RequesterContext{Hostname: "alice-laptop", Os: "linux/amd64", Version: "1.4.0", KeyFingerprint: owner.KeyID()}
+	created, err := owner.Requests().CreateRequest(ctx, withRequesterContext(t, owner, rc, "from the laptop"))
+	require.NoError(t, err)
+
+	// The node records the address the request came from
+	got, err := owner.Requests().GetRequest(ctx, connect.NewRequest(&airgapperv1.GetRequestRequest{Id: created.Msg.Id}))
+	require.NoError(t, err)
+	recorded := got.Msg.Request.RequesterContext
+	require.NotNil(t, recorded)
+	assert.Equal(t, "alice-laptop", recorded.Hostname)
+	assert.Equal(t, owner.KeyID(), recorded.KeyFingerprint)
+	assert.Equal(t, "127.0.0.1", recorded.SourceIp)
+
+	_, err = owner.Sign(ctx, host, created.Msg.Id)
+	require.NoError(t, err)
+
+	// A fingerprint that isn't the signing key's is rejected
+	rc.KeyFingerprint = host.KeyID()
+	_, err = owner.Requests().CreateRequest(ctx, withRequesterContext(t, owner, rc, "from somewhere else"))
+	var connectErr *connect.Error
+	require.ErrorAs(t, err, &connectErr)
+	assert.Equal(t, string(apperrors.CodeInvalidSignature), connectErr.Meta().Get(grpc.ErrorCodeHeader))
+}
+
+func TestE2E_HTTP_SealedPathsRequestSigned(t *testing.T) {
+	ctx := context.Background()
+	owner, _ := setupPair(t, t.TempDir())
+	require.True(t, owner.Config.UsesConsensusMode())
+
+	// The requester keeps the plain paths and sends the node a sealed copy,
+	// the way 'airgapper request --private-paths' notifies its peer
+	local, err := consent.NewManager(t.TempDir()).WithSigningKey(owner.KeyID(), owner.Config.PrivateKey).
+		CreateRequest(owner.Config.Name, "latest", "restore the tax folder", []string{"/home/alice/taxes"})
+	require.NoError(t, err)
+	sealed, err := local.SealPaths(owner.Config.Password, owner.Config.PrivateKey)
+	require.NoError(t, err)
+
+	// The signature over the plain paths doesn't cover the sealed copy
+	create := &airgapperv1.CreateRequestRequest{
+		Id:          sealed.ID,
+		SnapshotId:  sealed.SnapshotID,
+		Paths:       sealed.Paths,
+		Reason:      sealed.Reason,
+		CreatedAt:   timestamppb.New(sealed.CreatedAt),
+		KeyHolderId: sealed.RequesterKeyID,
+		Signature:   hex.EncodeToString(local.RequesterSignature),
+	}
+	_, err = owner.Requests().CreateRequest(ctx, connect.NewRequest(create))
+	assert.Error(t, err)
+
+	create.Signature = hex.EncodeToString(sealed.RequesterSignature)
+	_, err = owner.Requests().CreateRequest(ctx, connect.NewRequest(create))
+	require.NoError(t, err)
+
+	got, err := owner.Requests().GetRequest(ctx, connect.NewRequest(&airgapperv1.GetRequestRequest{Id: sealed.ID}))
+	require.NoError(t, err)
+	assert.Equal(t, sealed.Paths, got.Msg.Request.Paths)
+	assert.NotContains(t, got.Msg.Request.Paths, "/home/alice/taxes")
+}
+
+// withRequesterContext returns a request for n's API made from the device
+// rc describes, signed with n's key
+func withRequesterContext(t *testing.T, n *Node, rc *airgapperv1.RequesterContext, reason string) *connect.Request[airgapperv1.CreateRequestRequest] {
+	t.Helper()
+	req, err := n.NewRequest("", reason)
+	require.NoError(t, err)
+	req.Msg.RequesterContext = rc
+	resign(t, n, req)
+	return req
+}
+
+// resign signs req again with n's key, covering the requester context and
+// size it was given after NewRequest
+func resign(t *testing.T, n *Node, req *connect.Request[airgapperv1.CreateRequestRequest]) {
+	t.Helper()
+	data := &crypto.RestoreRequestSignData{
+		RequestID:   req.Msg.Id,
+		Requester:   n.Config.Name,
+		SnapshotID:  req.Msg.SnapshotId,
+		Paths:       req.Msg.Paths,
+		Reason:      req.Msg.Reason,
+		KeyHolderID: req.Msg.KeyHolderId,
+		CreatedAt:   req.Msg.CreatedAt.AsTime().Unix(),
+		SizeBytes:   req.Msg.SizeBytes,
+	}
+	if rc := req.Msg.RequesterContext; rc != nil {
+		data.RequesterHostname = rc.Hostname
+		data.RequesterOS = rc.Os
+		data.RequesterVersion = rc.Version
+		data.RequesterKeyFingerprint = rc.KeyFingerprint
+	}
+	signature, err := data.Sign(n.Config.PrivateKey)
+	require.NoError(t, err)
+	req.Msg.Signature = hex.EncodeToString(signature)
+}
+
+func TestE2E_HTTP_ApprovalRules(t *testing.T) {
+	ctx := context.Background()
+	owner, _ := setupPair(t, t.TempDir())
+	owner.Config.ApprovalRules = &consent.ApprovalRules{DenyPaths: []string{"*.key"}, AutoApproveMaxBytes: 1 << 20}
+
+	create := func(size int64, path string) *airgapperv1.RestoreRequest {
+		t.Helper()
+		req, err := owner.NewRequest("", "lost a file", path)
+		require.NoError(t, err)
+		req.Msg.SizeBytes = size
+		resign(t, owner, req)
+		created, err := owner.Requests().CreateRequest(ctx, req)
+		require.NoError(t, err)
+		got, err := owner.Requests().GetRequest(ctx, connect.NewRequest(&airgapperv1.GetRequestRequest{Id: created.Msg.Id}))
+		require.NoError(t, err)
+		return got.Msg.Request
+	}
+
+	denied := create(100, "/srv/tls/server.key")
+	assert.Equal(t, airgapperv1.RequestStatus_REQUEST_STATUS_DENIED, denied.Status)
+	assert.Equal(t, consent.RulesActor, denied.ApprovedBy)
+
+	// A small single file gets this node's approval; the host's is still needed
+	small := create(4096, "/home/alice/notes.txt")
+	assert.Equal(t, airgapperv1.RequestStatus_REQUEST_STATUS_PENDING, small.Status)
+	require.Len(t, small.Approvals, 1)
+	assert.Equal(t, owner.KeyID(), small.Approvals[0].KeyHolderId)
+	assert.Equal(t, int64(4096), small.SizeBytes)
+
+	// A large one waits for manual approval, and isn't logged
+	large := create(50<<20, "/home/alice/video.mp4")
+	assert.Empty(t, large.Approvals)
+
+	decisions, err := owner.Config.ConsentManager().ListRuleDecisions()
+	require.NoError(t, err)
+	require.Len(t, decisions, 2)
+	assert.Equal(t, denied.Id, decisions[0].RequestID)
+	assert.Equal(t, consent.RuleDeny, decisions[0].Decision)
+	assert.Equal(t, small.Id, decisions[1].RequestID)
+	assert.Equal(t, consent.RuleApprove, decisions[1].Decision)
+}
+
+func TestE2E_HTTP_RestoreTermsAndProgress(t *testing.T) {
+	ctx := context.Background()
+	owner, host := setupPair(t, t.TempDir())
+
+	create, err := owner.NewRequest("", "rebuild the photo library")
+	require.NoError(t, err)
+	created, err := owner.Requests().CreateRequest(ctx, create)
+	require.NoError(t, err)
+	id := created.Msg.Id
+
+	// Progress can't be reported before approval
+	progress := &airgapperv1.RestoreProgress{Status: consent.ProgressRunning, Files: 10}
+	_, err = owner.Requests().ReportRestoreProgress(ctx, connect.NewRequest(&airgapperv1.ReportRestoreProgressRequest{Id: id, Progress: progress}))
+	assert.Error(t, err)
+
+	// Malformed terms are refused
+	approval, err := owner.Approval(ctx, host, id)
+	require.NoError(t, err)
+	approval.Terms = &airgapperv1.RestoreTerms{Window: "after ten"}
+	_, err = owner.Requests().SignRequest(ctx, connect.NewRequest(approval))
+	assert.Error(t, err)
+
+	// The host approves on terms; the node records who set them
+	approval, err = owner.Approval(ctx, host, id)
+	require.NoError(t, err)
+	approval.Terms = &airgapperv1.RestoreTerms{Window: "22:00-06:00", UtcOffset: 3600, LimitKibps: 10240}
+	_, err = owner.Requests().SignRequest(ctx, connect.NewRequest(approval))
+	require.NoError(t, err)
+	_, err = owner.Sign(ctx, owner, id)
+	require.NoError(t, err)
+
+	got, err := owner.Requests().GetRequest(ctx, connect.NewRequest(&airgapperv1.GetRequestRequest{Id: id}))
+	require.NoError(t, err)
+	require.Len(t, got.Msg.Request.Terms, 1)
+	assert.Equal(t, "22:00-06:00", got.Msg.Request.Terms[0].Window)
+	assert.Equal(t, int32(10240), got.Msg.Request.Terms[0].LimitKibps)
+	assert.Equal(t, host.Config.Name, got.Msg.Request.Terms[0].SetBy)
+
+	stored, err := owner.Config.ConsentManager().GetRequest(id)
+	require.NoError(t, err)
+	assert.Equal(t, 10240, stored.LimitKiBps())
+
+	// Progress reported by the executing node shows on the request
+	_, err = owner.Requests().ReportRestoreProgress(ctx, connect.NewRequest(&airgapperv1.ReportRestoreProgressRequest{Id: id, Progress: progress}))
+	require.NoError(t, err)
+	got, err = owner.Requests().GetRequest(ctx, connect.NewRequest(&airgapperv1.GetRequestRequest{Id: id}))
+	require.NoError(t, err)
+	require.NotNil(t, got.Msg.Request.Progress)
+	assert.Equal(t, consent.ProgressRunning, got.Msg.Request.Progress.Status)
+	assert.Equal(t, int32(10), got.Msg.Request.Progress.Files)
+	assert.NotNil(t, got.Msg.Request.Progress.UpdatedAt)
+}
+
+func TestE2E_HTTP_RequestTTLAndExtensions(t *testing.T) {
+	ctx := context.Background()
+	owner, host := setupPair(t, t.TempDir())
+
+	code := func(err error) string {
+		t.Helper()
+		var connectErr *connect.Error
+		require.ErrorAs(t, err, &connectErr)
+		return connectErr.Meta().Get(grpc.ErrorCodeHeader)
+	}
+
+	// TTLs beyond the node's limit are rejected
+	create, err := owner.NewRequest("", "too long")
+	require.NoError(t, err)
+	create.Msg.Ttl = "720h"
+	_, err = owner.Requests().CreateRequest(ctx, create)
+	assert.Equal(t, string(apperrors.CodeInvalidArgument), code(err))
+
+	create, err = owner.NewRequest("", "bob is asleep")
+	require.NoError(t, err)
+	create.Msg.Ttl = "48h"
+	created, err := owner.Requests().CreateRequest(ctx, create)
+	require.NoError(t, err)
+	id := created.Msg.Id
+	expiresAt := created.Msg.ExpiresAt.AsTime()
+	assert.WithinDuration(t, time.Now().Add(48*time.Hour), expiresAt, time.Minute)
+
+	_, err = owner.ExtensionApproval(ctx, host, id)
+	require.NoError(t, err)
+	_, err = owner.Requests().ApproveExtension(ctx, connect.NewRequest(&airgapperv1.ApproveExtensionRequest{
+		Id:          id,
+		KeyHolderId: host.KeyID(),
+		Nonce:       "0000",
+		SignedAt:    timestamppb.Now(),
+	}))
+	assert.Equal(t, string(apperrors.CodeNoPendingExtension), code(err))
+
+	ext, err := owner.Requests().RequestExtension(ctx, connect.NewRequest(&airgapperv1.RequestExtensionRequest{
+		Id:       id,
+		ExtendBy: "12h",
+		Reason:   "still asleep",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "12h0m0s", ext.Msg.Extension.ExtendBy)
+	assert.Empty(t, ext.Msg.Extension.ApprovedBy)
+
+	// Only registered key holders can approve it, with their signature
+	// over the new expiry and a challenge from this node
+	_, err = owner.Requests().ApproveExtension(ctx, connect.NewRequest(&airgapperv1.ApproveExtensionRequest{
+		Id:          id,
+		KeyHolderId: "0000000000000000",
+	}))
+	assert.Equal(t, string(apperrors.CodeKeyHolderNotFound), code(err))
+	_, err = owner.Requests().ApproveExtension(ctx, connect.NewRequest(&airgapperv1.ApproveExtensionRequest{
+		Id:          id,
+		KeyHolderId: host.KeyID(),
+	}))
+	assert.Equal(t, string(apperrors.CodeChallengeInvalid), code(err))
+
+	approval, err := owner.ExtensionApproval(ctx, host, id)
+	require.NoError(t, err)
+	forged := proto.Clone(approval).(*airgapperv1.ApproveExtensionRequest)
+	forged.KeyHolderId = owner.KeyID()
+	_, err = owner.Requests().ApproveExtension(ctx, connect.NewRequest(forged))
+	assert.Equal(t, string(apperrors.CodeInvalidSignature), code(err))
+
+	approved, err := owner.Requests().ApproveExtension(ctx, connect.NewRequest(approval))
+	require.NoError(t, err)
+	assert.True(t, expiresAt.Add(12*time.Hour).Equal(approved.Msg.ExpiresAt.AsTime()))
+
+	got, err := owner.Requests().GetRequest(ctx, connect.NewRequest(&airgapperv1.GetRequestRequest{Id: id}))
+	require.NoError(t, err)
+	require.Len(t, got.Msg.Request.Extensions, 1)
+	assert.Equal(t, "bob", got.Msg.Request.Extensions[0].ApprovedBy)
+	assert.Equal(t, "still asleep", got.Msg.Request.Extensions[0].Reason)
+
+	// The approval can't be replayed for the next extension
+	_, err = owner.Requests().RequestExtension(ctx, connect.NewRequest(&airgapperv1.RequestExtensionRequest{Id: id, ExtendBy: "1h"}))
+	require.NoError(t, err)
+	_, err = owner.Requests().ApproveExtension(ctx, connect.NewRequest(approval))
+	assert.Equal(t, string(apperrors.CodeInvalidSignature), code(err))
+}
+
+func TestE2E_HTTP_VetoIsSigned(t *testing.T) {
+	ctx := context.Background()
+	owner, host := setupPair(t, t.TempDir())
+
+	code := func(err error) string {
+		t.Helper()
+		var connectErr *connect.Error
+		require.ErrorAs(t, err, &connectErr)
+		return connectErr.Meta().Get(grpc.ErrorCodeHeader)
+	}
+
+	create, err := owner.NewRequest("", "monthly check")
+	require.NoError(t, err)
+	created, err := owner.Requests().CreateRequest(ctx, create)
+	require.NoError(t, err)
+	id := created.Msg.Id
+
+	// Nobody can veto unsigned, in this node's name or a key holder's
+	_, err = owner.Requests().VetoRequest(ctx, connect.NewRequest(&airgapperv1.VetoRequestRequest{Id: id, Reason: "not me"}))
+	assert.Equal(t, string(apperrors.CodeKeyHolderNotFound), code(err))
+	_, err = owner.Requests().VetoRequest(ctx, connect.NewRequest(&airgapperv1.VetoRequestRequest{
+		Id:          id,
+		KeyHolderId: host.KeyID(),
+		Reason:      "not me",
+	}))
+	assert.Equal(t, string(apperrors.CodeChallengeInvalid), code(err))
+
+	// Nor with a signature by someone else, or over another reason
+	veto, err := owner.Veto(ctx, host, id, "not me")
+	require.NoError(t, err)
+	forged := proto.Clone(veto).(*airgapperv1.VetoRequestRequest)
+	forged.KeyHolderId = owner.KeyID()
+	_, err = owner.Requests().VetoRequest(ctx, connect.NewRequest(forged))
+	assert.Equal(t, string(apperrors.CodeInvalidSignature), code(err))
+	forged = proto.Clone(veto).(*airgapperv1.VetoRequestRequest)
+	forged.Reason = "changed my mind"
+	_, err = owner.Requests().VetoRequest(ctx, connect.NewRequest(forged))
+	assert.Equal(t, string(apperrors.CodeInvalidSignature), code(err))
+
+	got, err := owner.Requests().GetRequest(ctx, connect.NewRequest(&airgapperv1.GetRequestRequest{Id: id}))
+	require.NoError(t, err)
+	assert.Equal(t, airgapperv1.RequestStatus_REQUEST_STATUS_PENDING, got.Msg.Request.Status)
+
+	// The key holder's own signed veto stops it
+	vetoed, err := owner.Requests().VetoRequest(ctx, connect.NewRequest(veto))
+	require.NoError(t, err)
+	assert.Equal(t, "vetoed", vetoed.Msg.Status)
+	got, err = owner.Requests().GetRequest(ctx, connect.NewRequest(&airgapperv1.GetRequestRequest{Id: id}))
+	require.NoError(t, err)
+	assert.Equal(t, "bob", got.Msg.Request.VetoedBy)
+	assert.Equal(t, "not me", got.Msg.Request.VetoReason)
+}
+
+func TestE2E_HTTP_RestoreLatestWithTag(t *testing.T) {
+	ctx := context.Background()
+	docs := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(docs, "taxes.txt"), []byte("2025 return"), 0o600))
+	owner, host := setupPair(t, t.TempDir())
+
+	// A tagged backup, then an untagged one that becomes plain "latest"
+	_, err := owner.Restic().Backup(ctx, []string{docs}, restic.BackupTags("documents"))
+	require.NoError(t, err)
+	_, err = owner.Restic().Backup(ctx, owner.Config.BackupPaths, restic.BackupTags())
+	require.NoError(t, err)
+
+	snapshots, err := owner.Restic().SnapshotList(ctx)
+	require.NoError(t, err)
+	tagged := restic.FilterByTags(snapshots, []string{"documents"})
+	require.Len(t, tagged, 1)
+	assert.Contains(t, tagged[0].Tags, restic.OSTagPrefix+runtime.GOOS)
+
+	create, err := owner.NewRequest("latest:tag=a b", "bad selector")
+	require.NoError(t, err)
+	_, err = owner.Requests().CreateRequest(ctx, create)
+	var connectErr *connect.Error
+	require.ErrorAs(t, err, &connectErr)
+	assert.Equal(t, string(apperrors.CodeInvalidArgument), connectErr.Meta().Get(grpc.ErrorCodeHeader))
+
+	create, err = owner.NewRequest(restic.LatestWithTags([]string{"documents"}), "restore the documents")
+	require.NoError(t, err)
+	created, err := owner.Requests().CreateRequest(ctx, create)
+	require.NoError(t, err)
+	_, err = owner.Sign(ctx, host, created.Msg.Id)
+	require.NoError(t, err)
+	progress, err := owner.Sign(ctx, owner, created.Msg.Id)
+	require.NoError(t, err)
+	require.True(t, progress.IsApproved)
+
+	got, err := owner.Requests().GetRequest(ctx, connect.NewRequest(&airgapperv1.GetRequestRequest{Id: created.Msg.Id}))
+	require.NoError(t, err)
+	assert.Equal(t, "latest:tag=documents", got.Msg.Request.SnapshotId)
+
+	target := t.TempDir()
+	require.NoError(t, owner.Restic().Restore(ctx, got.Msg.Request.SnapshotId, target))
+	restored, err := os.ReadFile(filepath.Join(target, docs, "taxes.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "2025 return", string(restored))
+}
+
+func TestE2E_HTTP_StatusReportsAPIVersion(t *testing.T) {
+	owner, _ := setupPair(t, t.TempDir())
+
+	status, err := owner.Health().GetStatus(context.Background(), connect.NewRequest(&airgapperv1.GetStatusRequest{}))
+	require.NoError(t, err)
+	assert.Equal(t, api.APIVersion, status.Msg.ApiVersion)
+	assert.Equal(t, api.SupportedAPIVersions, status.Msg.SupportedApiVersions)
+}
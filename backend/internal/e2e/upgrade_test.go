@@ -384,17 +384,16 @@ func TestUpgrade_Ed25519KeysCompatible(t *testing.T) {
 	assert.Equal(t, keyID1, keyID2, "Key ID mismatch")
 }
 
-// TestUpgrade_ConfigSavePreservesUnknownFields tests that saving a config
-// doesn't lose fields we don't know about (forward compatibility)
-func TestUpgrade_ConfigSavePreservesUnknownFields(t *testing.T) {
+// TestUpgrade_UnknownConfigFieldsRejected tests that a config with fields
+// this version doesn't know is refused rather than silently losing them on
+// the next save, and is left as it was
+func TestUpgrade_UnknownConfigFieldsRejected(t *testing.T) {
 	tmpDir := t.TempDir()
 
-	// Create a config with "future" fields
 	futureConfig := map[string]interface{}{
 		"name":              "test",
 		"role":              "owner",
 		"repo_url":          "rest:http://test:8000/",
-		"future_field":      "some value from future version",
 		"another_new_thing": 12345,
 	}
 
@@ -403,36 +402,20 @@ func TestUpgrade_ConfigSavePreservesUnknownFields(t *testing.T) {
 	err := os.WriteFile(configPath, data, 0600)
 	require.NoError(t, err, "failed to write future config")
 
-	// Load with current code
-	cfg, err := config.Load(tmpDir)
-	require.NoError(t, err, "Failed to load config with unknown fields")
-
-	// Modify a known field
-	cfg.Name = "modified-name"
-
-	// Save it back
-	err = cfg.Save()
-	require.NoError(t, err, "Failed to save config")
+	_, err = config.Load(tmpDir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown field "another_new_thing"`)
 
-	// Note: Current implementation will LOSE unknown fields on save
-	// This test documents that behavior - we may want to fix this
-	// by using a json.RawMessage approach in the future
-
-	// Re-load
-	reloaded, _ := config.Load(tmpDir)
-	assert.Equal(t, "modified-name", reloaded.Name, "Modified field should be saved")
-
-	// Check the raw JSON to see if unknown fields were preserved
 	rawData, _ := os.ReadFile(configPath)
-	var rawMap map[string]interface{}
-	_ = json.Unmarshal(rawData, &rawMap)
-
-	// Document current behavior: unknown fields are lost
-	if _, ok := rawMap["future_field"]; ok {
-		t.Log("Note: Unknown fields ARE preserved (good!)")
-	} else {
-		t.Log("Note: Unknown fields are NOT preserved (may want to fix)")
-	}
+	assert.Equal(t, data, rawData, "a config that fails to load is not migrated")
+
+	// A config from a newer version says so
+	futureConfig["version"] = config.CurrentVersion + 1
+	delete(futureConfig, "another_new_thing")
+	data, _ = json.MarshalIndent(futureConfig, "", "  ")
+	require.NoError(t, os.WriteFile(configPath, data, 0600))
+	_, err = config.Load(tmpDir)
+	assert.ErrorContains(t, err, "upgrade airgapper")
 }
 
 // TestUpgrade_DeletionRequestsNewFeature tests that old consent managers
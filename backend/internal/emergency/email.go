@@ -0,0 +1,108 @@
+package emergency
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// sendEmail mails a message through the provider's SMTP server. Port 465
+// uses implicit TLS; other ports upgrade with STARTTLS when the server
+// offers it. Credentials are only sent over TLS or to localhost.
+func (p Provider) sendEmail(ctx context.Context, msg Message) error {
+	host := p.Settings["smtp_host"]
+	port := p.Settings["smtp_port"]
+	if port == "" {
+		port = "587"
+	}
+	from := p.Settings["from"]
+	var to []string
+	for _, addr := range strings.Split(p.Settings["to"], ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			to = append(to, addr)
+		}
+	}
+	if host == "" || from == "" || len(to) == 0 {
+		return fmt.Errorf("email provider needs smtp_host, from and to settings")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	addr := net.JoinHostPort(host, port)
+	tlsConfig := &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if port == "465" {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("email provider: %w", err)
+	}
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("email provider: %w", err)
+	}
+	defer func() { _ = c.Close() }()
+
+	if ok, _ := c.Extension("STARTTLS"); ok && port != "465" {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("email provider: STARTTLS: %w", err)
+		}
+	}
+	if user := p.Settings["username"]; user != "" {
+		// PlainAuth refuses to send credentials in the clear
+		if err := c.Auth(smtp.PlainAuth("", user, p.Settings["password"], host)); err != nil {
+			return fmt.Errorf("email provider: %w", err)
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return fmt.Errorf("email provider: %w", err)
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("email provider: recipient %s: %w", rcpt, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("email provider: %w", err)
+	}
+	if _, err := w.Write(emailMessage(from, to, msg)); err != nil {
+		return fmt.Errorf("email provider: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("email provider: %w", err)
+	}
+	return c.Quit()
+}
+
+// emailMessage formats a message as a plain-text email
+func emailMessage(from string, to []string, msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Title))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	if msg.Priority == "high" || msg.Priority == "urgent" {
+		b.WriteString("Importance: high\r\nX-Priority: 1\r\n")
+	}
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
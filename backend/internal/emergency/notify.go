@@ -13,6 +13,12 @@ type Provider struct {
 	Enabled  bool              `json:"enabled"`
 	Settings map[string]string `json:"settings"`
 	Priority string            `json:"priority"`
+	// Events limits the provider to these events (the EventConfig names);
+	// empty sends it every enabled event
+	Events []string `json:"events,omitempty"`
+	// MinPriority drops messages less urgent than this (low, normal, high,
+	// urgent); empty sends all
+	MinPriority string `json:"min_priority,omitempty"`
 }
 
 // EventConfig defines which events trigger notifications
@@ -29,6 +35,10 @@ type EventConfig struct {
 	EmergencyTriggered bool `json:"emergency_triggered"`
 	DeadManWarning     bool `json:"dead_man_warning"`
 	HeartbeatMissed    bool `json:"heartbeat_missed"`
+	StorageAnomaly     bool `json:"storage_anomaly"`
+	ShareMismatch      bool `json:"share_mismatch"`
+	Lockdown           bool `json:"lockdown"`
+	RestoreTestFailed  bool `json:"restore_test_failed"`
 }
 
 // IsEnabled returns true if notifications are enabled (nil-safe)
@@ -90,6 +100,10 @@ func (n *NotifyConfig) EnableAllEvents() {
 		EmergencyTriggered: true,
 		DeadManWarning:     true,
 		HeartbeatMissed:    true,
+		StorageAnomaly:     true,
+		ShareMismatch:      true,
+		Lockdown:           true,
+		RestoreTestFailed:  true,
 	}
 }
 
@@ -0,0 +1,214 @@
+package emergency
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+)
+
+// Message is a notification sent to every enabled provider
+type Message struct {
+	Event    string   `json:"event"`
+	Title    string   `json:"title"`
+	Body     string   `json:"body"`
+	Priority string   `json:"priority,omitempty"`
+	Actions  []Action `json:"actions,omitempty"` // Also listed in Body
+}
+
+// Action is a link a notification offers, e.g. to approve a request
+type Action struct {
+	Label string `json:"label"`
+	URL   string `json:"url"`
+}
+
+// sendTimeout bounds each provider request
+const sendTimeout = 15 * time.Second
+
+// Send delivers a message to every enabled provider whose event and
+// priority filters accept it. It returns the errors of the providers that
+// failed, keyed by provider ID; other providers are tried regardless
+// (nil-safe).
+func (n *NotifyConfig) Send(ctx context.Context, msg Message) map[string]error {
+	if !n.IsEnabled() {
+		return nil
+	}
+
+	failed := make(map[string]error)
+	client := &http.Client{Timeout: sendTimeout}
+	for id, p := range n.Providers {
+		if !p.Enabled || !p.Accepts(msg) {
+			continue
+		}
+		if err := p.send(ctx, client, msg); err != nil {
+			failed[id] = err
+		}
+	}
+	return failed
+}
+
+// Send delivers a message to this provider alone, whether or not it is
+// enabled or its filters accept the message
+func (p Provider) Send(ctx context.Context, msg Message) error {
+	return p.send(ctx, &http.Client{Timeout: sendTimeout}, msg)
+}
+
+func (p Provider) send(ctx context.Context, client *http.Client, msg Message) error {
+	if p.Type == "email" {
+		return p.sendEmail(ctx, msg)
+	}
+
+	text := msg.Title
+	if msg.Body != "" {
+		text += "\n" + msg.Body
+	}
+	priority := msg.Priority
+	if priority == "" {
+		priority = p.Priority
+	}
+
+	var (
+		method  = http.MethodPost
+		url     string
+		body    []byte
+		headers = map[string]string{"Content-Type": "application/json"}
+		err     error
+	)
+	switch p.Type {
+	case "webhook":
+		url = p.Settings["url"]
+		if m := p.Settings["method"]; m != "" {
+			method = strings.ToUpper(m)
+		}
+		body, err = json.Marshal(msg)
+	case "ntfy":
+		server := strings.TrimSuffix(p.Settings["server"], "/")
+		if server == "" {
+			server = "https://ntfy.sh"
+		}
+		if p.Settings["topic"] == "" {
+			return fmt.Errorf("ntfy provider has no topic")
+		}
+		url = server + "/" + p.Settings["topic"]
+		body = []byte(msg.Body)
+		headers = map[string]string{"Title": msg.Title, "Content-Type": "text/plain"}
+		if priority == "normal" {
+			priority = "default" // ntfy's name for normal priority
+		}
+		if priority != "" {
+			headers["Priority"] = priority
+		}
+		if len(msg.Actions) > 0 {
+			headers["Actions"] = ntfyActions(msg.Actions)
+		}
+		if token := p.Settings["auth_token"]; token != "" {
+			headers["Authorization"] = "Bearer " + token
+		}
+	case "slack":
+		url = p.Settings["webhook_url"]
+		body, err = json.Marshal(map[string]string{"text": text})
+	case "discord":
+		url = p.Settings["webhook_url"]
+		body, err = json.Marshal(map[string]string{"content": text})
+	case "telegram":
+		url = apiURL(p, "https://api.telegram.org") + "/bot" + p.Settings["bot_token"] + "/sendMessage"
+		body, err = json.Marshal(map[string]any{
+			"chat_id":              p.Settings["chat_id"],
+			"text":                 text,
+			"disable_notification": priority == "low",
+		})
+	case "matrix":
+		// Matrix sends are idempotent per transaction ID, so each message
+		// needs its own
+		method = http.MethodPut
+		url = fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/airgapper-%d",
+			strings.TrimSuffix(p.Settings["homeserver"], "/"), neturl.PathEscape(p.Settings["room_id"]), time.Now().UnixNano())
+		body, err = json.Marshal(map[string]string{"msgtype": "m.text", "body": text})
+		headers["Authorization"] = "Bearer " + p.Settings["access_token"]
+	case "pushover":
+		url = apiURL(p, "https://api.pushover.net") + "/1/messages.json"
+		form := neturl.Values{
+			"token":    {p.Settings["api_token"]},
+			"user":     {p.Settings["user_key"]},
+			"title":    {msg.Title},
+			"message":  {msg.Body},
+			"priority": {pushoverPriority(priority)},
+		}
+		if priority == "urgent" {
+			// Emergency priority repeats until acknowledged, for up to an hour
+			form.Set("retry", "60")
+			form.Set("expire", "3600")
+		}
+		body = []byte(form.Encode())
+		headers["Content-Type"] = "application/x-www-form-urlencoded"
+	default:
+		return fmt.Errorf("delivery for %s providers is not supported yet", p.Type)
+	}
+	if err != nil {
+		return err
+	}
+	if url == "" {
+		return fmt.Errorf("%s provider has no URL configured", p.Type)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// The URL may carry a token (Telegram, webhooks), so keep it out of
+		// the error, which is logged
+		var urlErr *neturl.Error
+		if errors.As(err, &urlErr) {
+			return fmt.Errorf("%s provider: %s failed: %w", p.Type, urlErr.Op, urlErr.Err)
+		}
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s provider returned %s", p.Type, resp.Status)
+	}
+	return nil
+}
+
+// apiURL returns the provider's api_url setting, for self-hosted API
+// servers and proxies, or the public API's URL
+func apiURL(p Provider, public string) string {
+	if u := p.Settings["api_url"]; u != "" {
+		return strings.TrimSuffix(u, "/")
+	}
+	return public
+}
+
+// ntfyActions renders actions as ntfy's buttons opening their links
+func ntfyActions(actions []Action) string {
+	buttons := make([]string, 0, len(actions))
+	for _, a := range actions {
+		buttons = append(buttons, "view, "+a.Label+", "+a.URL+", clear=true")
+	}
+	return strings.Join(buttons, "; ")
+}
+
+// pushoverPriority maps a priority to Pushover's -1 (quiet) to 2
+// (emergency) scale
+func pushoverPriority(priority string) string {
+	switch priority {
+	case "low":
+		return "-1"
+	case "high":
+		return "1"
+	case "urgent":
+		return "2"
+	}
+	return "0"
+}
@@ -0,0 +1,175 @@
+package emergency
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type received struct {
+	method string
+	path   string
+	header http.Header
+	body   string
+}
+
+func endpoint(t *testing.T) (*httptest.Server, chan received) {
+	t.Helper()
+	got := make(chan received, 8)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- received{r.Method, r.URL.EscapedPath(), r.Header.Clone(), string(body)}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, got
+}
+
+func TestProviderSend(t *testing.T) {
+	srv, got := endpoint(t)
+	msg := Message{Event: "backup_failed", Title: "Backup failed", Body: "disk full", Priority: "urgent"}
+	ctx := context.Background()
+
+	t.Run("telegram", func(t *testing.T) {
+		p := Provider{Type: "telegram", Settings: map[string]string{"api_url": srv.URL, "bot_token": "123:abc", "chat_id": "-100"}}
+		require.NoError(t, p.Send(ctx, msg))
+		r := <-got
+		assert.Equal(t, "/bot123:abc/sendMessage", r.path)
+		assert.JSONEq(t, `{"chat_id":"-100","text":"Backup failed\ndisk full","disable_notification":false}`, r.body)
+	})
+
+	t.Run("matrix", func(t *testing.T) {
+		p := Provider{Type: "matrix", Settings: map[string]string{"homeserver": srv.URL + "/", "access_token": "syt_x", "room_id": "!room:example.org"}}
+		require.NoError(t, p.Send(ctx, msg))
+		r := <-got
+		assert.Equal(t, http.MethodPut, r.method)
+		assert.True(t, strings.HasPrefix(r.path, "/_matrix/client/v3/rooms/%21room:example.org/send/m.room.message/airgapper-"), r.path)
+		assert.Equal(t, "Bearer syt_x", r.header.Get("Authorization"))
+		assert.JSONEq(t, `{"msgtype":"m.text","body":"Backup failed\ndisk full"}`, r.body)
+	})
+
+	t.Run("pushover", func(t *testing.T) {
+		p := Provider{Type: "pushover", Settings: map[string]string{"api_url": srv.URL, "api_token": "tok", "user_key": "usr"}}
+		require.NoError(t, p.Send(ctx, msg))
+		r := <-got
+		assert.Equal(t, "/1/messages.json", r.path)
+		form, err := url.ParseQuery(r.body)
+		require.NoError(t, err)
+		assert.Equal(t, "2", form.Get("priority"))
+		assert.Equal(t, "3600", form.Get("expire"))
+		assert.Equal(t, "disk full", form.Get("message"))
+	})
+
+	t.Run("discord", func(t *testing.T) {
+		p := Provider{Type: "discord", Settings: map[string]string{"webhook_url": srv.URL + "/api/webhooks/1/x"}}
+		require.NoError(t, p.Send(ctx, msg))
+		r := <-got
+		var body map[string]string
+		require.NoError(t, json.Unmarshal([]byte(r.body), &body))
+		assert.Equal(t, "Backup failed\ndisk full", body["content"])
+	})
+
+	t.Run("errors keep tokens out", func(t *testing.T) {
+		p := Provider{Type: "telegram", Settings: map[string]string{"api_url": "http://127.0.0.1:1", "bot_token": "secret-token", "chat_id": "1"}}
+		err := p.Send(ctx, msg)
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "secret-token")
+	})
+}
+
+func TestSendFilters(t *testing.T) {
+	srv, got := endpoint(t)
+	hook := func(events []string, minPriority string) Provider {
+		return Provider{Type: "webhook", Enabled: true, Settings: map[string]string{"url": srv.URL}, Events: events, MinPriority: minPriority}
+	}
+	n := &NotifyConfig{Enabled: true, Providers: map[string]Provider{
+		"all":      hook(nil, ""),
+		"failures": hook([]string{"backup_failed"}, ""),
+		"urgent":   hook(nil, "urgent"),
+	}}
+
+	assert.Empty(t, n.Send(context.Background(), Message{Event: "backup_completed", Title: "done"}))
+	assert.Len(t, got, 1, "only the unfiltered provider")
+	<-got
+
+	assert.Empty(t, n.Send(context.Background(), Message{Event: "backup_failed", Title: "failed", Priority: "urgent"}))
+	assert.Len(t, got, 3)
+}
+
+// smtpServer is just enough of an SMTP server to take one message without
+// TLS or authentication
+func smtpServer(t *testing.T) (host, port string, mail chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+	mail = make(chan string, 1)
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { _, _ = io.WriteString(conn, s+"\r\n") }
+		reply("220 localhost ESMTP")
+		var transcript strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			transcript.WriteString(line)
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"):
+				reply("250 localhost")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					transcript.WriteString(line)
+				}
+				reply("250 queued")
+			case cmd == "QUIT":
+				reply("221 bye")
+				mail <- transcript.String()
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	host, port, _ = net.SplitHostPort(ln.Addr().String())
+	return host, port, mail
+}
+
+func TestEmailSend(t *testing.T) {
+	host, port, mail := smtpServer(t)
+	p := Provider{Type: "email", Settings: map[string]string{
+		"smtp_host": host, "smtp_port": port,
+		"from": "airgapper@example.com", "to": "alice@example.com, bob@example.com",
+	}}
+	require.NoError(t, p.Send(context.Background(), Message{Title: "Backup failed", Body: "disk full\nretrying", Priority: "high"}))
+
+	transcript := <-mail
+	assert.Contains(t, transcript, "MAIL FROM:<airgapper@example.com>")
+	assert.Contains(t, transcript, "RCPT TO:<alice@example.com>")
+	assert.Contains(t, transcript, "RCPT TO:<bob@example.com>")
+	assert.Contains(t, transcript, "Subject: Backup failed\r\n")
+	assert.Contains(t, transcript, "Importance: high\r\n")
+	assert.Contains(t, transcript, "disk full\r\nretrying\r\n")
+}
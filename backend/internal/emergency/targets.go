@@ -0,0 +1,117 @@
+package emergency
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// TestEvent is the event of test notifications, which every provider
+// receives regardless of its event and priority filters
+const TestEvent = "test"
+
+// Redacted replaces secret settings when a provider is shown
+const Redacted = "********"
+
+// ProviderTypes are the supported provider types
+var ProviderTypes = []string{"webhook", "ntfy", "slack", "discord", "email", "telegram", "matrix", "pushover"}
+
+// Priorities are the message priorities, least urgent first
+var Priorities = []string{"low", "normal", "high", "urgent"}
+
+// EventNames are the names of the events in EventConfig
+var EventNames = []string{
+	"backup_started", "backup_completed", "backup_failed",
+	"restore_requested", "restore_approved", "restore_denied",
+	"deletion_requested", "deletion_approved", "consensus_received",
+	"emergency_triggered", "dead_man_warning", "heartbeat_missed",
+	"storage_anomaly", "share_mismatch", "lockdown", "restore_test_failed",
+}
+
+// requiredSettings are the settings each provider type can't send without
+var requiredSettings = map[string][]string{
+	"webhook":  {"url"},
+	"ntfy":     {"topic"},
+	"slack":    {"webhook_url"},
+	"discord":  {"webhook_url"},
+	"email":    {"smtp_host", "from", "to"},
+	"telegram": {"bot_token", "chat_id"},
+	"matrix":   {"homeserver", "access_token", "room_id"},
+	"pushover": {"api_token", "user_key"},
+}
+
+// secretSettings are settings holding credentials. Webhook URLs carry
+// their token in the URL, so they count too.
+var secretSettings = []string{"url", "webhook_url", "auth_token", "password", "bot_token", "access_token", "api_token", "user_key"}
+
+// Validate checks the provider's type, settings and filters
+func (p Provider) Validate() error {
+	required, ok := requiredSettings[p.Type]
+	if !ok {
+		return fmt.Errorf("unknown provider type %q (supported: %s)", p.Type, strings.Join(ProviderTypes, ", "))
+	}
+	for _, key := range required {
+		if strings.TrimSpace(p.Settings[key]) == "" {
+			return fmt.Errorf("%s provider needs the %s setting", p.Type, key)
+		}
+	}
+	for _, e := range p.Events {
+		if !slices.Contains(EventNames, e) {
+			return fmt.Errorf("unknown event %q (events: %s)", e, strings.Join(EventNames, ", "))
+		}
+	}
+	for _, pr := range []string{p.Priority, p.MinPriority} {
+		if pr != "" && !slices.Contains(Priorities, pr) {
+			return fmt.Errorf("unknown priority %q (priorities: %s)", pr, strings.Join(Priorities, ", "))
+		}
+	}
+	return nil
+}
+
+// Accepts reports whether msg passes the provider's event and priority
+// filters. Messages without a priority count as normal.
+func (p Provider) Accepts(msg Message) bool {
+	if msg.Event == TestEvent {
+		return true
+	}
+	if len(p.Events) > 0 && !slices.Contains(p.Events, msg.Event) {
+		return false
+	}
+	return p.MinPriority == "" || priorityRank(msg.Priority) >= priorityRank(p.MinPriority)
+}
+
+// Redact returns a copy of the provider with its secret settings replaced
+// by Redacted
+func (p Provider) Redact() Provider {
+	settings := make(map[string]string, len(p.Settings))
+	for k, v := range p.Settings {
+		if v != "" && slices.Contains(secretSettings, k) {
+			v = Redacted
+		}
+		settings[k] = v
+	}
+	p.Settings = settings
+	p.Events = slices.Clone(p.Events)
+	return p
+}
+
+// NextProviderID returns an unused provider ID for a type, such as ntfy-2
+// (nil-safe)
+func (n *NotifyConfig) NextProviderID(providerType string) string {
+	for i := 1; ; i++ {
+		id := fmt.Sprintf("%s-%d", providerType, i)
+		if n == nil {
+			return id
+		}
+		if _, taken := n.Providers[id]; !taken {
+			return id
+		}
+	}
+}
+
+func priorityRank(priority string) int {
+	if i := slices.Index(Priorities, priority); i >= 0 {
+		return i
+	}
+	return slices.Index(Priorities, "normal")
+}
@@ -0,0 +1,45 @@
+package emergency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProviderValidate(t *testing.T) {
+	valid := Provider{Type: "matrix", Settings: map[string]string{"homeserver": "https://matrix.org", "access_token": "t", "room_id": "!r:matrix.org"}}
+	assert.NoError(t, valid.Validate())
+
+	for name, p := range map[string]Provider{
+		"unknown type":     {Type: "carrier-pigeon"},
+		"missing setting":  {Type: "telegram", Settings: map[string]string{"bot_token": "t"}},
+		"unknown event":    {Type: "ntfy", Settings: map[string]string{"topic": "x"}, Events: []string{"backup_exploded"}},
+		"unknown priority": {Type: "ntfy", Settings: map[string]string{"topic": "x"}, MinPriority: "critical"},
+	} {
+		assert.Error(t, p.Validate(), name)
+	}
+}
+
+func TestProviderAccepts(t *testing.T) {
+	p := Provider{Events: []string{"backup_failed", "lockdown"}, MinPriority: "high"}
+	assert.True(t, p.Accepts(Message{Event: "lockdown", Priority: "urgent"}))
+	assert.False(t, p.Accepts(Message{Event: "lockdown"}), "no priority counts as normal")
+	assert.False(t, p.Accepts(Message{Event: "backup_completed", Priority: "urgent"}))
+	assert.True(t, p.Accepts(Message{Event: TestEvent}))
+	assert.True(t, Provider{}.Accepts(Message{Event: "anything", Priority: "low"}))
+}
+
+func TestProviderRedact(t *testing.T) {
+	p := Provider{Type: "email", Settings: map[string]string{"smtp_host": "mail", "password": "hunter2", "username": ""}}
+	r := p.Redact()
+	assert.Equal(t, Redacted, r.Settings["password"])
+	assert.Equal(t, "mail", r.Settings["smtp_host"])
+	assert.Equal(t, "", r.Settings["username"])
+	assert.Equal(t, "hunter2", p.Settings["password"], "the original is untouched")
+}
+
+func TestNextProviderID(t *testing.T) {
+	n := &NotifyConfig{Providers: map[string]Provider{"ntfy-1": {}, "ntfy-3": {}}}
+	assert.Equal(t, "ntfy-2", n.NextProviderID("ntfy"))
+	assert.Equal(t, "email-1", (*NotifyConfig)(nil).NextProviderID("email"))
+}
@@ -0,0 +1,339 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Code is a stable, machine-readable error code reported by the API, e.g.
+// AG-1004. Codes are never reused; each has a name (REQUEST_EXPIRED) and
+// a kind that decides the HTTP status or Connect code it is sent with.
+type Code string
+
+// Kind is the broad class of an error. Values are Connect code names.
+type Kind string
+
+// Error kinds
+const (
+	KindInternal           Kind = "internal"
+	KindInvalidArgument    Kind = "invalid_argument"
+	KindNotFound           Kind = "not_found"
+	KindAlreadyExists      Kind = "already_exists"
+	KindFailedPrecondition Kind = "failed_precondition"
+	KindPermissionDenied   Kind = "permission_denied"
+	KindUnavailable        Kind = "unavailable"
+	KindUnimplemented      Kind = "unimplemented"
+	KindUnauthenticated    Kind = "unauthenticated"
+	KindResourceExhausted  Kind = "resource_exhausted"
+)
+
+// General codes (AG-00xx), used when nothing more specific applies
+const (
+	CodeInternal              Code = "AG-0001"
+	CodeInvalidArgument       Code = "AG-0002"
+	CodeNotFound              Code = "AG-0003"
+	CodeAlreadyExists         Code = "AG-0004"
+	CodeFailedPrecondition    Code = "AG-0005"
+	CodePermissionDenied      Code = "AG-0006"
+	CodeUnavailable           Code = "AG-0007"
+	CodeMethodNotAllowed      Code = "AG-0008"
+	CodeUnsupportedAPIVersion Code = "AG-0009"
+	CodeUnauthenticated       Code = "AG-0010"
+	CodeRateLimited           Code = "AG-0011"
+	CodePeerUnsupported       Code = "AG-0012"
+	CodePeerIdentityChanged   Code = "AG-0013"
+)
+
+// Restore and deletion request codes (AG-10xx)
+const (
+	CodeRequestNotFound       Code = "AG-1001"
+	CodeRequestNotPending     Code = "AG-1002"
+	CodeRequestNotApproved    Code = "AG-1003"
+	CodeRequestExpired        Code = "AG-1004"
+	CodeAlreadyApproved       Code = "AG-1005"
+	CodeInsufficientApprovals Code = "AG-1006"
+	CodeWrongRequestPurpose   Code = "AG-1007"
+	CodeInvalidSignature      Code = "AG-1008"
+	CodeSnapshotPinned        Code = "AG-1009"
+	CodeRequestUnsigned       Code = "AG-1010"
+	CodeRequestExists         Code = "AG-1011"
+	CodeChallengeInvalid      Code = "AG-1012"
+	CodeNonceReused           Code = "AG-1013"
+	CodeExtensionPending      Code = "AG-1014"
+	CodeNoPendingExtension    Code = "AG-1015"
+	CodeCoolingOff            Code = "AG-1016"
+	CodeLockedDown            Code = "AG-1017"
+)
+
+// Template, delegation and recovery codes (AG-11xx)
+const (
+	CodeTemplateNotFound   Code = "AG-1101"
+	CodeTemplateExists     Code = "AG-1102"
+	CodeDelegationNotFound Code = "AG-1103"
+	CodeDelegationInactive Code = "AG-1104"
+	CodeRecoveryNotFound   Code = "AG-1105"
+	CodeRecoveryNotUsable  Code = "AG-1106"
+)
+
+// Backup codes (AG-12xx)
+const (
+	CodeBackupReportNotFound Code = "AG-1201"
+)
+
+// Restore job codes (AG-13xx)
+const (
+	CodeRestoreJobNotFound Code = "AG-1301"
+	CodeRestoreJobActive   Code = "AG-1302"
+	CodeRestoreJobFinished Code = "AG-1303"
+	CodePreflightFailed    Code = "AG-1304"
+)
+
+// Setup and key holder codes (AG-20xx)
+const (
+	CodeNotInitialized         Code = "AG-2001"
+	CodeAlreadyInitialized     Code = "AG-2002"
+	CodeNoLocalShare           Code = "AG-2003"
+	CodeConsensusNotConfigured Code = "AG-2004"
+	CodeKeyHolderExists        Code = "AG-2005"
+	CodeKeyHolderNotFound      Code = "AG-2006"
+	CodeInvalidRole            Code = "AG-2007"
+	CodeGenesisMismatch        Code = "AG-2008"
+	CodeUserNotFound           Code = "AG-2009"
+	CodeUserExists             Code = "AG-2010"
+	CodeLastAdmin              Code = "AG-2011"
+	CodeSessionNotFound        Code = "AG-2012"
+	CodePasskeyNotFound        Code = "AG-2013"
+)
+
+// Host and storage codes (AG-30xx)
+const (
+	CodeStorageNotConfigured Code = "AG-3001"
+	CodeAmendmentNotFound    Code = "AG-3002"
+	CodeNoSigningKey         Code = "AG-3003"
+	CodeNoRepoPassword       Code = "AG-3004"
+)
+
+// Filesystem codes (AG-40xx)
+const (
+	CodePathNotAllowed Code = "AG-4001"
+	CodeNotADirectory  Code = "AG-4002"
+)
+
+// Server codes (AG-50xx)
+const (
+	CodeInsecureBind Code = "AG-5001"
+)
+
+type codeInfo struct {
+	name string
+	kind Kind
+}
+
+var codes = map[Code]codeInfo{
+	CodeInternal:              {"INTERNAL", KindInternal},
+	CodeInvalidArgument:       {"INVALID_ARGUMENT", KindInvalidArgument},
+	CodeNotFound:              {"NOT_FOUND", KindNotFound},
+	CodeAlreadyExists:         {"ALREADY_EXISTS", KindAlreadyExists},
+	CodeFailedPrecondition:    {"FAILED_PRECONDITION", KindFailedPrecondition},
+	CodePermissionDenied:      {"PERMISSION_DENIED", KindPermissionDenied},
+	CodeUnavailable:           {"UNAVAILABLE", KindUnavailable},
+	CodeMethodNotAllowed:      {"METHOD_NOT_ALLOWED", KindUnimplemented},
+	CodeUnsupportedAPIVersion: {"UNSUPPORTED_API_VERSION", KindFailedPrecondition},
+	CodeUnauthenticated:       {"UNAUTHENTICATED", KindUnauthenticated},
+	CodeRateLimited:           {"RATE_LIMITED", KindResourceExhausted},
+	CodePeerUnsupported:       {"PEER_UNSUPPORTED", KindFailedPrecondition},
+	CodePeerIdentityChanged:   {"PEER_IDENTITY_CHANGED", KindFailedPrecondition},
+
+	CodeRequestNotFound:       {"REQUEST_NOT_FOUND", KindNotFound},
+	CodeRequestNotPending:     {"REQUEST_NOT_PENDING", KindFailedPrecondition},
+	CodeRequestNotApproved:    {"REQUEST_NOT_APPROVED", KindFailedPrecondition},
+	CodeRequestExpired:        {"REQUEST_EXPIRED", KindFailedPrecondition},
+	CodeAlreadyApproved:       {"ALREADY_APPROVED", KindAlreadyExists},
+	CodeInsufficientApprovals: {"INSUFFICIENT_APPROVALS", KindFailedPrecondition},
+	CodeWrongRequestPurpose:   {"WRONG_REQUEST_PURPOSE", KindFailedPrecondition},
+	CodeInvalidSignature:      {"INVALID_SIGNATURE", KindInvalidArgument},
+	CodeSnapshotPinned:        {"SNAPSHOT_PINNED", KindFailedPrecondition},
+	CodeRequestUnsigned:       {"REQUEST_UNSIGNED", KindPermissionDenied},
+	CodeRequestExists:         {"REQUEST_EXISTS", KindAlreadyExists},
+	CodeChallengeInvalid:      {"CHALLENGE_INVALID", KindPermissionDenied},
+	CodeNonceReused:           {"NONCE_REUSED", KindPermissionDenied},
+	CodeExtensionPending:      {"EXTENSION_PENDING", KindFailedPrecondition},
+	CodeNoPendingExtension:    {"NO_PENDING_EXTENSION", KindFailedPrecondition},
+	CodeCoolingOff:            {"COOLING_OFF", KindFailedPrecondition},
+	CodeLockedDown:            {"LOCKED_DOWN", KindFailedPrecondition},
+
+	CodeTemplateNotFound:   {"TEMPLATE_NOT_FOUND", KindNotFound},
+	CodeTemplateExists:     {"TEMPLATE_EXISTS", KindAlreadyExists},
+	CodeDelegationNotFound: {"DELEGATION_NOT_FOUND", KindNotFound},
+	CodeDelegationInactive: {"DELEGATION_INACTIVE", KindFailedPrecondition},
+	CodeRecoveryNotFound:   {"RECOVERY_NOT_FOUND", KindNotFound},
+	CodeRecoveryNotUsable:  {"RECOVERY_NOT_USABLE", KindFailedPrecondition},
+
+	CodeBackupReportNotFound: {"BACKUP_REPORT_NOT_FOUND", KindNotFound},
+
+	CodeRestoreJobNotFound: {"RESTORE_JOB_NOT_FOUND", KindNotFound},
+	CodeRestoreJobActive:   {"RESTORE_JOB_ACTIVE", KindAlreadyExists},
+	CodeRestoreJobFinished: {"RESTORE_JOB_FINISHED", KindFailedPrecondition},
+	CodePreflightFailed:    {"PREFLIGHT_FAILED", KindFailedPrecondition},
+
+	CodeNotInitialized:         {"NOT_INITIALIZED", KindFailedPrecondition},
+	CodeAlreadyInitialized:     {"ALREADY_INITIALIZED", KindAlreadyExists},
+	CodeNoLocalShare:           {"NO_LOCAL_SHARE", KindFailedPrecondition},
+	CodeConsensusNotConfigured: {"CONSENSUS_NOT_CONFIGURED", KindFailedPrecondition},
+	CodeKeyHolderExists:        {"KEY_HOLDER_EXISTS", KindAlreadyExists},
+	CodeKeyHolderNotFound:      {"KEY_HOLDER_NOT_FOUND", KindNotFound},
+	CodeInvalidRole:            {"INVALID_ROLE", KindPermissionDenied},
+	CodeGenesisMismatch:        {"GENESIS_MISMATCH", KindFailedPrecondition},
+	CodeUserNotFound:           {"USER_NOT_FOUND", KindNotFound},
+	CodeUserExists:             {"USER_EXISTS", KindAlreadyExists},
+	CodeLastAdmin:              {"LAST_ADMIN", KindFailedPrecondition},
+	CodeSessionNotFound:        {"SESSION_NOT_FOUND", KindNotFound},
+	CodePasskeyNotFound:        {"PASSKEY_NOT_FOUND", KindNotFound},
+
+	CodeStorageNotConfigured: {"STORAGE_NOT_CONFIGURED", KindFailedPrecondition},
+	CodeAmendmentNotFound:    {"AMENDMENT_NOT_FOUND", KindNotFound},
+	CodeNoSigningKey:         {"NO_SIGNING_KEY", KindFailedPrecondition},
+	CodeNoRepoPassword:       {"NO_REPO_PASSWORD", KindFailedPrecondition},
+
+	CodePathNotAllowed: {"PATH_NOT_ALLOWED", KindPermissionDenied},
+	CodeNotADirectory:  {"NOT_A_DIRECTORY", KindInvalidArgument},
+
+	CodeInsecureBind: {"INSECURE_BIND", KindFailedPrecondition},
+}
+
+// genericCodes is the general code for each kind
+var genericCodes = map[Kind]Code{
+	KindInternal:           CodeInternal,
+	KindInvalidArgument:    CodeInvalidArgument,
+	KindNotFound:           CodeNotFound,
+	KindAlreadyExists:      CodeAlreadyExists,
+	KindFailedPrecondition: CodeFailedPrecondition,
+	KindPermissionDenied:   CodePermissionDenied,
+	KindUnavailable:        CodeUnavailable,
+	KindUnimplemented:      CodeMethodNotAllowed,
+	KindUnauthenticated:    CodeUnauthenticated,
+	KindResourceExhausted:  CodeRateLimited,
+}
+
+// Name returns the code's name, e.g. REQUEST_EXPIRED, or "" if unknown
+func (c Code) Name() string {
+	return codes[c].name
+}
+
+// Kind returns the code's kind; unknown codes are internal
+func (c Code) Kind() Kind {
+	if info, ok := codes[c]; ok {
+		return info.kind
+	}
+	return KindInternal
+}
+
+// Known reports whether c is a defined code
+func (c Code) Known() bool {
+	_, ok := codes[c]
+	return ok
+}
+
+// String returns e.g. "AG-1004 REQUEST_EXPIRED"
+func (c Code) String() string {
+	if name := c.Name(); name != "" {
+		return string(c) + " " + name
+	}
+	return string(c)
+}
+
+// Codes returns every defined code in order
+func Codes() []Code {
+	return slices.Sorted(maps.Keys(codes))
+}
+
+// GenericCode returns the general code for errors of kind k
+func GenericCode(k Kind) Code {
+	if c, ok := genericCodes[k]; ok {
+		return c
+	}
+	return CodeInternal
+}
+
+// Error is an error with a code. Sentinel errors in this package are
+// *Error values, so errors.Is keeps working on them and errors.As finds
+// their code through any wrapping.
+type Error struct {
+	Code    Code
+	Message string
+	// Details are extra machine-readable facts about the error
+	Details map[string]string
+	// Err is the underlying error, if any
+	Err error
+}
+
+// New returns an error with code and message
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Newf returns an error with code and a formatted message. A %w verb
+// wraps its argument as usual.
+func Newf(code Code, format string, args ...any) *Error {
+	err := fmt.Errorf(format, args...)
+	return &Error{Code: code, Message: err.Error(), Err: errors.Unwrap(err)}
+}
+
+// Coded returns err as an *Error: its own code if it has one, otherwise
+// fallback. The message is err's full message. Returns nil for a nil err.
+func Coded(fallback Code, err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		if e == err {
+			return e
+		}
+		return &Error{Code: e.Code, Message: err.Error(), Details: e.Details, Err: err}
+	}
+	return &Error{Code: fallback, Message: err.Error(), Err: err}
+}
+
+// CodeOf returns the code of err or of the first coded error it wraps,
+// CodeInternal if there is none, or "" for a nil err
+func CodeOf(err error) Code {
+	if err == nil {
+		return ""
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return CodeInternal
+}
+
+// WithDetail returns a copy of e with a detail added
+func (e *Error) WithDetail(key, value string) *Error {
+	c := *e
+	c.Details = maps.Clone(e.Details)
+	if c.Details == nil {
+		c.Details = map[string]string{}
+	}
+	c.Details[key] = value
+	return &c
+}
+
+// Error implements error
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is reports whether target has the same code and message, so copies made
+// by WithDetail still match their sentinel
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code && t.Message == e.Message
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
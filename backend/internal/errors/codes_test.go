@@ -0,0 +1,79 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodesWellFormed(t *testing.T) {
+	pattern := regexp.MustCompile(`^AG-\d{4}$`)
+	names := map[string]Code{}
+	for _, c := range Codes() {
+		assert.Regexp(t, pattern, string(c))
+		require.NotEmpty(t, c.Name(), c)
+		if other, dup := names[c.Name()]; dup {
+			t.Errorf("%s and %s share the name %s", c, other, c.Name())
+		}
+		names[c.Name()] = c
+	}
+
+	for kind, c := range genericCodes {
+		assert.Equal(t, kind, c.Kind(), c)
+	}
+}
+
+func TestCodeString(t *testing.T) {
+	assert.Equal(t, "AG-1004 REQUEST_EXPIRED", CodeRequestExpired.String())
+	assert.Equal(t, "AG-9999", Code("AG-9999").String())
+	assert.False(t, Code("AG-9999").Known())
+	assert.Equal(t, KindInternal, Code("AG-9999").Kind())
+}
+
+func TestCoded(t *testing.T) {
+	assert.Nil(t, Coded(CodeInternal, nil))
+
+	t.Run("sentinel keeps its code", func(t *testing.T) {
+		e := Coded(CodeInternal, ErrRequestExpired)
+		assert.Same(t, ErrRequestExpired, e)
+	})
+
+	t.Run("wrapped sentinel keeps code and full message", func(t *testing.T) {
+		err := fmt.Errorf("approve abc: %w", ErrRequestExpired)
+		e := Coded(CodeInternal, err)
+		assert.Equal(t, CodeRequestExpired, e.Code)
+		assert.Equal(t, "approve abc: request has expired", e.Message)
+		assert.ErrorIs(t, e, ErrRequestExpired)
+	})
+
+	t.Run("uncoded error takes the fallback", func(t *testing.T) {
+		e := Coded(CodeInvalidArgument, errors.New("bad input"))
+		assert.Equal(t, CodeInvalidArgument, e.Code)
+		assert.Equal(t, "bad input", e.Message)
+	})
+}
+
+func TestCodeOf(t *testing.T) {
+	assert.Equal(t, Code(""), CodeOf(nil))
+	assert.Equal(t, CodeInternal, CodeOf(errors.New("boom")))
+	assert.Equal(t, CodeSnapshotPinned, CodeOf(fmt.Errorf("delete: %w", Newf(CodeSnapshotPinned, "snapshot %s is pinned", "abc"))))
+}
+
+func TestNewfWraps(t *testing.T) {
+	base := errors.New("disk full")
+	e := Newf(CodeInternal, "save: %w", base)
+	assert.Equal(t, "save: disk full", e.Error())
+	assert.ErrorIs(t, e, base)
+}
+
+func TestWithDetailCopies(t *testing.T) {
+	e := ErrRequestExpired.WithDetail("requestId", "abc")
+	assert.Equal(t, "abc", e.Details["requestId"])
+	assert.Nil(t, ErrRequestExpired.Details)
+	assert.ErrorIs(t, e, ErrRequestExpired)
+	assert.NotErrorIs(t, e, ErrRequestNotPending)
+}
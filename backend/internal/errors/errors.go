@@ -1,52 +1,133 @@
-// Package errors provides sentinel errors for the airgapper application.
+// Package errors provides coded sentinel errors for the airgapper
+// application. See codes.go for the code table.
 package errors
 
-import "errors"
-
 // Configuration errors
 var (
 	// ErrNotInitialized is returned when airgapper has not been initialized.
-	ErrNotInitialized = errors.New("airgapper not initialized")
+	ErrNotInitialized = New(CodeNotInitialized, "airgapper not initialized")
 
 	// ErrNoLocalShare is returned when no local key share is found.
-	ErrNoLocalShare = errors.New("no local share found")
+	ErrNoLocalShare = New(CodeNoLocalShare, "no local share found")
 
 	// ErrConsensusNotConfigured is returned when consensus is required but not configured.
-	ErrConsensusNotConfigured = errors.New("consensus not configured")
+	ErrConsensusNotConfigured = New(CodeConsensusNotConfigured, "consensus not configured")
 )
 
 // Key holder errors
 var (
 	// ErrKeyHolderExists is returned when trying to add a key holder that already exists.
-	ErrKeyHolderExists = errors.New("key holder already registered")
+	ErrKeyHolderExists = New(CodeKeyHolderExists, "key holder already registered")
 
 	// ErrKeyHolderNotFound is returned when a key holder is not found.
-	ErrKeyHolderNotFound = errors.New("key holder not found")
+	ErrKeyHolderNotFound = New(CodeKeyHolderNotFound, "key holder not found")
 )
 
 // Request errors
 var (
 	// ErrRequestNotFound is returned when a request (restore or deletion) is not found.
-	ErrRequestNotFound = errors.New("request not found")
+	ErrRequestNotFound = New(CodeRequestNotFound, "request not found")
 
 	// ErrRequestNotPending is returned when an operation requires a pending request.
-	ErrRequestNotPending = errors.New("request is not pending")
+	ErrRequestNotPending = New(CodeRequestNotPending, "request is not pending")
 
 	// ErrRequestExpired is returned when a request has expired.
-	ErrRequestExpired = errors.New("request has expired")
+	ErrRequestExpired = New(CodeRequestExpired, "request has expired")
 
 	// ErrRequestNotApproved is returned when an operation requires an approved request.
-	ErrRequestNotApproved = errors.New("request is not approved")
+	ErrRequestNotApproved = New(CodeRequestNotApproved, "request is not approved")
 
 	// ErrAlreadyApproved is returned when a key holder has already approved a request.
-	ErrAlreadyApproved = errors.New("key holder already approved this request")
+	ErrAlreadyApproved = New(CodeAlreadyApproved, "key holder already approved this request")
 
 	// ErrInsufficientApprovals is returned when there aren't enough approvals.
-	ErrInsufficientApprovals = errors.New("insufficient approvals")
+	ErrInsufficientApprovals = New(CodeInsufficientApprovals, "insufficient approvals")
+
+	// ErrWrongRequestPurpose is returned when a request is used for something it didn't ask for.
+	ErrWrongRequestPurpose = New(CodeWrongRequestPurpose, "request was made for a different purpose")
+
+	// ErrRequestUnsigned is returned when a request must be signed by its requester but isn't.
+	ErrRequestUnsigned = New(CodeRequestUnsigned, "request must be signed by the requester")
+
+	// ErrRequestExists is returned when a requester reuses a request ID.
+	ErrRequestExists = New(CodeRequestExists, "request already exists")
+
+	// ErrChallengeInvalid is returned when an approval doesn't sign a live challenge issued to its key holder.
+	ErrChallengeInvalid = New(CodeChallengeInvalid, "approval challenge is missing, unknown or expired")
+
+	// ErrNonceReused is returned when an approval replays a nonce that was already used.
+	ErrNonceReused = New(CodeNonceReused, "approval nonce was already used")
+
+	// ErrExtensionPending is returned when asking to extend a request whose last extension wasn't approved yet.
+	ErrExtensionPending = New(CodeExtensionPending, "an extension of this request is already awaiting approval")
+
+	// ErrNoPendingExtension is returned when approving an extension of a request nobody asked to extend.
+	ErrNoPendingExtension = New(CodeNoPendingExtension, "no extension of this request is awaiting approval")
+
+	// ErrTemplateNotFound is returned when a request template doesn't exist.
+	ErrTemplateNotFound = New(CodeTemplateNotFound, "request template not found")
+
+	// ErrTemplateExists is returned when creating a template whose name is taken.
+	ErrTemplateExists = New(CodeTemplateExists, "request template already exists")
+
+	// ErrDelegationNotFound is returned when an approval delegation doesn't exist.
+	ErrDelegationNotFound = New(CodeDelegationNotFound, "delegation not found")
+
+	// ErrInvalidSignature is returned when a request, approval or delegation signature doesn't verify.
+	ErrInvalidSignature = New(CodeInvalidSignature, "invalid signature")
+
+	// ErrDelegationInactive is returned when a delegation is used outside its window or after revocation.
+	ErrDelegationInactive = New(CodeDelegationInactive, "delegation is not active")
+
+	// ErrRecoveryNotFound is returned when a social recovery doesn't exist.
+	ErrRecoveryNotFound = New(CodeRecoveryNotFound, "recovery not found")
+
+	// ErrRecoveryNotUsable is returned when a recovery is still waiting, lacks signatures, or was cancelled or used.
+	ErrRecoveryNotUsable = New(CodeRecoveryNotUsable, "recovery cannot be used")
 )
 
 // Role errors
 var (
 	// ErrInvalidRole is returned when an operation is attempted with an invalid role.
-	ErrInvalidRole = errors.New("invalid role for this operation")
+	ErrInvalidRole = New(CodeInvalidRole, "invalid role for this operation")
+)
+
+// User account errors
+var (
+	// ErrUnauthenticated is returned when an API call to a node with user
+	// accounts carries no valid credentials.
+	ErrUnauthenticated = New(CodeUnauthenticated, "authentication required")
+
+	// ErrUserNotFound is returned when a user account doesn't exist.
+	ErrUserNotFound = New(CodeUserNotFound, "user not found")
+
+	// ErrUserExists is returned when adding a user whose name is taken.
+	ErrUserExists = New(CodeUserExists, "user already exists")
+
+	// ErrLastAdmin is returned when removing or demoting the only admin.
+	ErrLastAdmin = New(CodeLastAdmin, "the last admin can't be removed or demoted")
+
+	// ErrSessionNotFound is returned when a login session doesn't exist
+	// or has expired.
+	ErrSessionNotFound = New(CodeSessionNotFound, "session not found")
+
+	// ErrPasskeyNotFound is returned when a passkey isn't registered with
+	// this node.
+	ErrPasskeyNotFound = New(CodePasskeyNotFound, "passkey not found")
+)
+
+// Server errors
+var (
+	// ErrInsecureBind is returned when the API would be exposed on a non-loopback
+	// address without TLS.
+	ErrInsecureBind = New(CodeInsecureBind, "refusing to bind to a non-loopback address without TLS")
+)
+
+// Filesystem errors
+var (
+	// ErrPathNotAllowed is returned when a path is outside the allowed browse roots.
+	ErrPathNotAllowed = New(CodePathNotAllowed, "path is outside the allowed browse roots")
+
+	// ErrNotADirectory is returned when a directory listing is requested for a file.
+	ErrNotADirectory = New(CodeNotADirectory, "path is not a directory")
 )
@@ -0,0 +1,365 @@
+// Package events is the node's activity feed: one log that consent,
+// backups, storage and integrity checks all publish to, so dashboards and
+// notifications can follow what happens without polling each subsystem.
+//
+// The log lives in the config directory as one JSON event per line. Only
+// the most recent events are kept; older ones are compacted away. Every
+// event gets the next sequence number as its ID, which readers use as a
+// cursor. CLI commands append to the same file as the server, and the
+// server picks their events up on its next read.
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+// FileName is the event log's file in the config directory
+const FileName = "events.jsonl"
+
+// DefaultCapacity is how many recent events a log keeps
+const DefaultCapacity = 1000
+
+// Event sources
+const (
+	SourceConsent   = "consent"
+	SourceBackup    = "backup"
+	SourceStorage   = "storage"
+	SourceIntegrity = "integrity"
+	SourcePeer      = "peer"
+)
+
+// Event types. The part before the dot is the type's category, which
+// filters match as a whole.
+const (
+	RequestCreated  = "request.created"
+	RequestApproved = "request.approved"
+	RequestDenied   = "request.denied"
+	RequestVetoed   = "request.vetoed"
+
+	DeletionCreated  = "deletion.created"
+	DeletionApproved = "deletion.approved"
+	DeletionDenied   = "deletion.denied"
+	DeletionExecuted = "deletion.executed"
+
+	OperationCreated  = "operation.created"
+	OperationApproved = "operation.approved"
+	OperationDenied   = "operation.denied"
+	OperationExecuted = "operation.executed"
+
+	RecoveryOpened    = "recovery.opened"
+	RecoverySigned    = "recovery.signed"
+	RecoveryReminder  = "recovery.reminder"
+	RecoveryCancelled = "recovery.cancelled"
+	RecoveryUsed      = "recovery.used"
+
+	BackupFinished    = "backup.finished"
+	BackupFailed      = "backup.failed"
+	BackupInterrupted = "backup.interrupted"
+
+	IntegrityFailed = "integrity.failed"
+
+	StorageWriteDenied = "storage.write_denied"
+	StorageAnomaly     = "storage.anomaly"
+
+	PeerIdentityChanged = "peer.identity_changed"
+	PeerRepinned        = "peer.repinned"
+)
+
+// Event is something that happened on the node
+type Event struct {
+	ID      uint64            `json:"id"` // Sequence number, the feed's cursor
+	Time    time.Time         `json:"time"`
+	Type    string            `json:"type"`
+	Source  string            `json:"source"`
+	Subject string            `json:"subject,omitempty"` // What it happened to, e.g. a request ID or file path
+	Message string            `json:"message"`
+	Data    map[string]string `json:"data,omitempty"`
+}
+
+// Category is the part of the event's type before the dot, e.g. "request"
+func (e *Event) Category() string {
+	category, _, _ := strings.Cut(e.Type, ".")
+	return category
+}
+
+// Filter selects events. Empty fields match everything.
+type Filter struct {
+	// Types are event types or whole categories, e.g. "backup.failed" or "request"
+	Types  []string
+	Source string
+}
+
+// Match reports whether e passes the filter
+func (f Filter) Match(e *Event) bool {
+	if f.Source != "" && e.Source != f.Source {
+		return false
+	}
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, t := range f.Types {
+		if t == e.Type || t == e.Category() {
+			return true
+		}
+	}
+	return false
+}
+
+// Log is a config directory's event log. It is safe for concurrent use, and
+// a nil Log discards what is published to it.
+type Log struct {
+	path     string
+	capacity int
+
+	mu     sync.Mutex
+	recent []Event // The kept events, oldest first
+	lines  int     // Events in the file, including those compacted away next
+	size   int64   // File size when last read or written, to notice other writers
+	loaded bool
+
+	subsMu sync.Mutex
+	subs   map[chan Event]struct{}
+}
+
+var (
+	openMu sync.Mutex
+	opened = map[string]*Log{}
+)
+
+// Open returns the event log of config directory dir. Every caller in a
+// process shares one Log per directory, so subscribers see events from
+// all of them. Open returns nil if dir is empty.
+func Open(dir string) *Log {
+	if dir == "" {
+		return nil
+	}
+	path := filepath.Join(filepath.Clean(dir), FileName)
+
+	openMu.Lock()
+	defer openMu.Unlock()
+	if l, ok := opened[path]; ok {
+		return l
+	}
+	l := &Log{path: path, capacity: DefaultCapacity, subs: make(map[chan Event]struct{})}
+	opened[path] = l
+	return l
+}
+
+// Publish records an event, giving it the next ID and the current time
+// unless it has one, and passes it to subscribers. Failures to save are
+// logged rather than returned: the feed must never stop what it reports on.
+func (l *Log) Publish(e Event) {
+	if l == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+
+	l.mu.Lock()
+	err := l.append(&e)
+	l.mu.Unlock()
+	if err != nil {
+		logging.Warn("Failed to record event", logging.String("type", e.Type), logging.Err(err))
+		return
+	}
+
+	l.subsMu.Lock()
+	defer l.subsMu.Unlock()
+	for ch := range l.subs {
+		// A slow subscriber misses events rather than stall the publisher;
+		// it can catch up with Since
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// Since returns up to limit events after cursor that pass filter, oldest
+// first, and whether more follow. A zero cursor starts at the oldest kept
+// event; a limit of zero or less returns them all.
+func (l *Log) Since(cursor uint64, limit int, filter Filter) ([]Event, bool, error) {
+	if l == nil {
+		return nil, false, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.refresh(); err != nil {
+		return nil, false, err
+	}
+
+	var out []Event
+	for i := range l.recent {
+		e := &l.recent[i]
+		if e.ID <= cursor || !filter.Match(e) {
+			continue
+		}
+		if limit > 0 && len(out) == limit {
+			return out, true, nil
+		}
+		out = append(out, *e)
+	}
+	return out, false, nil
+}
+
+// Latest returns the ID of the newest event, or zero if there are none
+func (l *Log) Latest() (uint64, error) {
+	if l == nil {
+		return 0, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.refresh(); err != nil {
+		return 0, err
+	}
+	return l.lastID(), nil
+}
+
+// Subscribe returns a channel receiving events published in this process
+// from now on, and a function ending the subscription. Events published
+// while the channel's buffer of size buf is full are dropped, so callers
+// should track the last ID they saw and fill gaps with Since.
+func (l *Log) Subscribe(buf int) (<-chan Event, func()) {
+	ch := make(chan Event, buf)
+	if l == nil {
+		return ch, func() {}
+	}
+	l.subsMu.Lock()
+	l.subs[ch] = struct{}{}
+	l.subsMu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			l.subsMu.Lock()
+			delete(l.subs, ch)
+			l.subsMu.Unlock()
+		})
+	}
+}
+
+// append saves e as the next event. l.mu must be held.
+func (l *Log) append(e *Event) error {
+	if err := l.refresh(); err != nil {
+		return err
+	}
+	e.ID = l.lastID() + 1
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(line)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save event: %w", err)
+	}
+
+	l.size += int64(len(line))
+	l.lines++
+	l.recent = append(l.recent, *e)
+	if len(l.recent) > l.capacity {
+		l.recent = append([]Event(nil), l.recent[len(l.recent)-l.capacity:]...)
+	}
+	if l.lines > 2*l.capacity {
+		return l.compact()
+	}
+	return nil
+}
+
+// refresh reloads the file if another process changed it since it was last
+// read or written. l.mu must be held.
+func (l *Log) refresh() error {
+	info, err := os.Stat(l.path)
+	if os.IsNotExist(err) {
+		l.recent, l.lines, l.size, l.loaded = nil, 0, 0, true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if l.loaded && info.Size() == l.size {
+		return nil
+	}
+
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		return err
+	}
+	var list []Event
+	lines := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		lines++
+		var e Event
+		// A line torn by a crash mid-write is skipped, not fatal
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		list = append(list, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read events: %w", err)
+	}
+	if len(list) > l.capacity {
+		list = list[len(list)-l.capacity:]
+	}
+	l.recent, l.lines, l.size, l.loaded = list, lines, int64(len(data)), true
+	return nil
+}
+
+// compact rewrites the file with only the kept events. l.mu must be held.
+func (l *Log) compact() error {
+	var buf bytes.Buffer
+	for i := range l.recent {
+		line, err := json.Marshal(&l.recent[i])
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to compact events: %w", err)
+	}
+	if err := os.Rename(tmp, l.path); err != nil {
+		return fmt.Errorf("failed to compact events: %w", err)
+	}
+	l.lines, l.size = len(l.recent), int64(buf.Len())
+	return nil
+}
+
+// lastID is the ID of the newest kept event. l.mu must be held.
+func (l *Log) lastID() uint64 {
+	if len(l.recent) == 0 {
+		return 0
+	}
+	return l.recent[len(l.recent)-1].ID
+}
@@ -0,0 +1,139 @@
+package events
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishAndSince(t *testing.T) {
+	l := Open(t.TempDir())
+	assert.Same(t, l, Open(filepath.Dir(l.path)+"/"))
+
+	l.Publish(Event{Type: RequestCreated, Source: SourceConsent, Subject: "r1", Message: "created"})
+	l.Publish(Event{Type: BackupFailed, Source: SourceBackup, Message: "failed"})
+	l.Publish(Event{Type: RequestApproved, Source: SourceConsent, Subject: "r1", Message: "approved"})
+
+	all, more, err := l.Since(0, 0, Filter{})
+	require.NoError(t, err)
+	assert.False(t, more)
+	require.Len(t, all, 3)
+	assert.Equal(t, []uint64{1, 2, 3}, []uint64{all[0].ID, all[1].ID, all[2].ID})
+	assert.False(t, all[0].Time.IsZero())
+
+	page, more, err := l.Since(0, 2, Filter{})
+	require.NoError(t, err)
+	assert.True(t, more)
+	assert.Len(t, page, 2)
+	page, more, err = l.Since(page[1].ID, 2, Filter{})
+	require.NoError(t, err)
+	assert.False(t, more)
+	require.Len(t, page, 1)
+	assert.Equal(t, RequestApproved, page[0].Type)
+
+	requests, _, err := l.Since(0, 0, Filter{Types: []string{"request"}})
+	require.NoError(t, err)
+	assert.Len(t, requests, 2)
+	failed, _, err := l.Since(0, 0, Filter{Types: []string{BackupFailed}})
+	require.NoError(t, err)
+	assert.Len(t, failed, 1)
+	consent, _, err := l.Since(0, 0, Filter{Source: SourceConsent, Types: []string{RequestApproved}})
+	require.NoError(t, err)
+	assert.Len(t, consent, 1)
+
+	latest, err := l.Latest()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), latest)
+}
+
+func TestCompaction(t *testing.T) {
+	dir := t.TempDir()
+	l := &Log{path: filepath.Join(dir, FileName), capacity: 3, subs: make(map[chan Event]struct{})}
+	for range 7 {
+		l.Publish(Event{Type: BackupFinished})
+	}
+
+	list, _, err := l.Since(0, 0, Filter{})
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	assert.Equal(t, uint64(5), list[0].ID)
+	assert.Equal(t, uint64(7), list[2].ID)
+
+	// The file was compacted to the kept events, and IDs carry on from them
+	reopened := &Log{path: l.path, capacity: 3, subs: make(map[chan Event]struct{})}
+	reopened.Publish(Event{Type: BackupFinished})
+	latest, err := reopened.Latest()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(8), latest)
+	assert.LessOrEqual(t, reopened.lines, 2*reopened.capacity)
+}
+
+func TestOtherWriters(t *testing.T) {
+	dir := t.TempDir()
+	l := Open(dir)
+	l.Publish(Event{Type: RequestCreated})
+
+	// Another process, e.g. a CLI command, appends to the same file
+	other := &Log{path: l.path, capacity: DefaultCapacity, subs: make(map[chan Event]struct{})}
+	other.Publish(Event{Type: RequestDenied, Time: time.Now().UTC()})
+
+	list, _, err := l.Since(1, 0, Filter{})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, uint64(2), list[0].ID)
+	assert.Equal(t, RequestDenied, list[0].Type)
+
+	// A torn last line is skipped
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"id":3,"ty`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	list, _, err = l.Since(0, 0, Filter{})
+	require.NoError(t, err)
+	assert.Len(t, list, 2)
+}
+
+func TestSubscribe(t *testing.T) {
+	l := Open(t.TempDir())
+	ch, cancel := l.Subscribe(1)
+	l.Publish(Event{Type: IntegrityFailed, Message: "corrupt"})
+	l.Publish(Event{Type: IntegrityFailed, Message: "dropped"})
+
+	e := <-ch
+	assert.Equal(t, "corrupt", e.Message)
+	assert.Equal(t, uint64(1), e.ID)
+	select {
+	case e := <-ch:
+		t.Fatalf("unexpected event %v", e)
+	default:
+	}
+
+	cancel()
+	cancel()
+	l.Publish(Event{Type: IntegrityFailed})
+	assert.Empty(t, ch)
+}
+
+func TestNilLog(t *testing.T) {
+	var l *Log
+	assert.Nil(t, Open(""))
+	l.Publish(Event{Type: BackupFinished})
+	list, more, err := l.Since(0, 10, Filter{})
+	assert.NoError(t, err)
+	assert.False(t, more)
+	assert.Empty(t, list)
+	_, cancel := l.Subscribe(1)
+	cancel()
+}
+
+func TestEventJSON(t *testing.T) {
+	data, err := json.Marshal(Event{ID: 1, Type: StorageWriteDenied, Source: SourceStorage, Message: "denied"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":1,"time":"0001-01-01T00:00:00Z","type":"storage.write_denied","source":"storage","message":"denied"}`, string(data))
+}
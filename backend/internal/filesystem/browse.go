@@ -0,0 +1,227 @@
+// Package filesystem provides sandboxed directory browsing for the path picker
+package filesystem
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+// Pagination limits for directory listings
+const (
+	DefaultPageSize = 200
+	MaxPageSize     = 1000
+)
+
+// Entry is a single item in a directory listing
+type Entry struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	IsDir     bool      `json:"isDir"`
+	IsSymlink bool      `json:"isSymlink,omitempty"`
+	Hidden    bool      `json:"hidden,omitempty"`
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"modTime"`
+}
+
+// Listing is a page of a directory listing. When Path is empty the entries
+// are the allowed roots themselves.
+type Listing struct {
+	Path    string  `json:"path"`
+	Parent  string  `json:"parent,omitempty"`
+	Entries []Entry `json:"entries"`
+	Total   int     `json:"total"`
+	Offset  int     `json:"offset"`
+	Limit   int     `json:"limit"`
+}
+
+// BrowseOptions controls a directory listing
+type BrowseOptions struct {
+	Path       string
+	ShowHidden bool
+	Offset     int
+	Limit      int
+}
+
+// Browser lists directories constrained to a set of allowed roots.
+// Every path is resolved through symlinks before the root check, so links
+// cannot be used to escape the allowlist.
+type Browser struct {
+	roots []string
+}
+
+// NewBrowser creates a browser for the given roots. If no roots are given
+// the user's home directory is used. Roots that don't exist are skipped.
+func NewBrowser(roots []string) (*Browser, error) {
+	if len(roots) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("no browse roots configured and home directory unknown: %w", err)
+		}
+		roots = []string{home}
+	}
+
+	b := &Browser{}
+	for _, root := range roots {
+		resolved, err := resolve(root)
+		if err != nil {
+			continue
+		}
+		b.roots = append(b.roots, resolved)
+	}
+	if len(b.roots) == 0 {
+		return nil, fmt.Errorf("none of the configured browse roots exist")
+	}
+	return b, nil
+}
+
+// Roots returns the resolved allowed roots
+func (b *Browser) Roots() []string {
+	return append([]string(nil), b.roots...)
+}
+
+// Resolve cleans path, follows symlinks and verifies the result lies within
+// an allowed root. It returns the resolved absolute path.
+func (b *Browser) Resolve(path string) (string, error) {
+	resolved, err := resolve(path)
+	if err != nil {
+		return "", err
+	}
+	if !b.allowed(resolved) {
+		return "", fmt.Errorf("%w: %s", apperrors.ErrPathNotAllowed, path)
+	}
+	return resolved, nil
+}
+
+// Browse lists a directory. An empty path lists the allowed roots.
+func (b *Browser) Browse(opts BrowseOptions) (*Listing, error) {
+	if opts.Offset < 0 {
+		opts.Offset = 0
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultPageSize
+	}
+	if opts.Limit > MaxPageSize {
+		opts.Limit = MaxPageSize
+	}
+
+	if opts.Path == "" {
+		entries := make([]Entry, 0, len(b.roots))
+		for _, root := range b.roots {
+			if info, err := os.Stat(root); err == nil {
+				entries = append(entries, newEntry(root, root, info, false))
+			}
+		}
+		return paginate(&Listing{}, entries, opts), nil
+	}
+
+	dir, err := b.Resolve(opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%w: %s", apperrors.ErrNotADirectory, opts.Path)
+	}
+
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(dirEntries))
+	for _, de := range dirEntries {
+		if !opts.ShowHidden && isHidden(de.Name()) {
+			continue
+		}
+
+		path := filepath.Join(dir, de.Name())
+		isSymlink := de.Type()&os.ModeSymlink != 0
+		if isSymlink {
+			// Only show links whose target stays within the allowed roots
+			if _, err := b.Resolve(path); err != nil {
+				continue
+			}
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, newEntry(de.Name(), path, info, isSymlink))
+	}
+
+	listing := &Listing{Path: dir}
+	if parent := filepath.Dir(dir); parent != dir && b.allowed(parent) {
+		listing.Parent = parent
+	}
+	return paginate(listing, entries, opts), nil
+}
+
+func (b *Browser) allowed(path string) bool {
+	for _, root := range b.roots {
+		if path == root {
+			return true
+		}
+		rel, err := filepath.Rel(root, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the absolute, symlink-free form of path
+func resolve(path string) (string, error) {
+	abs, err := filepath.Abs(filepath.Clean(path))
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
+
+func newEntry(name, path string, info os.FileInfo, isSymlink bool) Entry {
+	e := Entry{
+		Name:      name,
+		Path:      path,
+		IsDir:     info.IsDir(),
+		IsSymlink: isSymlink,
+		Hidden:    isHidden(filepath.Base(path)),
+		ModTime:   info.ModTime(),
+	}
+	if !e.IsDir {
+		e.Size = info.Size()
+	}
+	return e
+}
+
+// paginate sorts entries (directories first, then by name) and slices out the requested page
+func paginate(listing *Listing, entries []Entry, opts BrowseOptions) *Listing {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].IsDir != entries[j].IsDir {
+			return entries[i].IsDir
+		}
+		return strings.ToLower(entries[i].Name) < strings.ToLower(entries[j].Name)
+	})
+
+	listing.Total = len(entries)
+	listing.Offset = opts.Offset
+	listing.Limit = opts.Limit
+
+	start := min(opts.Offset, len(entries))
+	end := min(start+opts.Limit, len(entries))
+	listing.Entries = entries[start:end]
+	return listing
+}
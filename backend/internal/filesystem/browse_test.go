@@ -0,0 +1,140 @@
+package filesystem
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+// setupTree creates:
+//
+//	root/docs/a.txt (5 bytes)
+//	root/.secret
+//	root/b.txt
+//	root/escape -> outside
+//	root/inner  -> root/docs
+//	outside/private.txt
+func setupTree(t *testing.T) (root, outside string) {
+	t.Helper()
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+
+	root = filepath.Join(base, "root")
+	outside = filepath.Join(base, "outside")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "docs"), 0755))
+	require.NoError(t, os.MkdirAll(outside, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "docs", "a.txt"), []byte("hello"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".secret"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "b.txt"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "private.txt"), nil, 0644))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "escape")))
+	require.NoError(t, os.Symlink(filepath.Join(root, "docs"), filepath.Join(root, "inner")))
+	return root, outside
+}
+
+func names(entries []Entry) []string {
+	out := make([]string, len(entries))
+	for i, e := range entries {
+		out[i] = e.Name
+	}
+	return out
+}
+
+func TestBrowse(t *testing.T) {
+	root, outside := setupTree(t)
+	b, err := NewBrowser([]string{root})
+	require.NoError(t, err)
+
+	t.Run("lists roots when path is empty", func(t *testing.T) {
+		listing, err := b.Browse(BrowseOptions{})
+		require.NoError(t, err)
+		require.Len(t, listing.Entries, 1)
+		assert.Equal(t, root, listing.Entries[0].Path)
+	})
+
+	t.Run("hides dotfiles and escaping symlinks", func(t *testing.T) {
+		listing, err := b.Browse(BrowseOptions{Path: root})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"docs", "inner", "b.txt"}, names(listing.Entries))
+		assert.Empty(t, listing.Parent, "parent of a root is outside the allowlist")
+	})
+
+	t.Run("shows dotfiles on request", func(t *testing.T) {
+		listing, err := b.Browse(BrowseOptions{Path: root, ShowHidden: true})
+		require.NoError(t, err)
+		assert.Contains(t, names(listing.Entries), ".secret")
+	})
+
+	t.Run("reports size and mtime", func(t *testing.T) {
+		listing, err := b.Browse(BrowseOptions{Path: filepath.Join(root, "docs")})
+		require.NoError(t, err)
+		require.Len(t, listing.Entries, 1)
+		assert.Equal(t, int64(5), listing.Entries[0].Size)
+		assert.False(t, listing.Entries[0].ModTime.IsZero())
+		assert.Equal(t, root, listing.Parent)
+	})
+
+	t.Run("rejects paths outside roots", func(t *testing.T) {
+		for _, p := range []string{
+			outside,
+			filepath.Join(root, "..", "outside"),
+			filepath.Join(root, "escape"),
+			"/",
+		} {
+			_, err := b.Browse(BrowseOptions{Path: p})
+			assert.ErrorIs(t, err, apperrors.ErrPathNotAllowed, p)
+		}
+	})
+
+	t.Run("rejects files", func(t *testing.T) {
+		_, err := b.Browse(BrowseOptions{Path: filepath.Join(root, "b.txt")})
+		assert.ErrorIs(t, err, apperrors.ErrNotADirectory)
+	})
+
+	t.Run("rejects sibling with root as prefix", func(t *testing.T) {
+		sibling := root + "2"
+		require.NoError(t, os.Mkdir(sibling, 0755))
+		_, err := b.Browse(BrowseOptions{Path: sibling})
+		assert.ErrorIs(t, err, apperrors.ErrPathNotAllowed)
+	})
+}
+
+func TestBrowsePagination(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	for i := 0; i < 25; i++ {
+		require.NoError(t, os.WriteFile(filepath.Join(root, fmt.Sprintf("f%02d", i)), nil, 0644))
+	}
+
+	b, err := NewBrowser([]string{root})
+	require.NoError(t, err)
+
+	listing, err := b.Browse(BrowseOptions{Path: root, Offset: 20, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 25, listing.Total)
+	assert.Equal(t, []string{"f20", "f21", "f22", "f23", "f24"}, names(listing.Entries))
+
+	listing, err = b.Browse(BrowseOptions{Path: root, Offset: 100})
+	require.NoError(t, err)
+	assert.Empty(t, listing.Entries)
+
+	listing, err = b.Browse(BrowseOptions{Path: root, Limit: MaxPageSize + 1})
+	require.NoError(t, err)
+	assert.Equal(t, MaxPageSize, listing.Limit)
+}
+
+func TestNewBrowserSkipsMissingRoots(t *testing.T) {
+	_, err := NewBrowser([]string{"/does/not/exist"})
+	assert.Error(t, err)
+
+	dir := t.TempDir()
+	b, err := NewBrowser([]string{"/does/not/exist", dir})
+	require.NoError(t, err)
+	assert.Len(t, b.Roots(), 1)
+}
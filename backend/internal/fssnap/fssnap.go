@@ -0,0 +1,267 @@
+// Package fssnap takes ZFS and Btrfs snapshots of the host's storage
+// dataset: after each backup window and before an approved deletion is
+// carried out. A filesystem snapshot sits below the storage server, so it
+// is a last local undo when everything above it went wrong - a bad policy,
+// a deletion approved by mistake, or a bug. Only the newest Keep snapshots
+// this package took are kept; snapshots taken by other tools are left
+// alone.
+package fssnap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Filesystems
+const (
+	TypeZFS   = "zfs"
+	TypeBtrfs = "btrfs"
+)
+
+// Reasons a snapshot is taken
+const (
+	ReasonBackup   = "backup"
+	ReasonDeletion = "deletion"
+	ReasonManual   = "manual"
+)
+
+// Prefix starts the name of every snapshot this package takes
+const Prefix = "airgapper-"
+
+// nameTime is the layout of the time in a snapshot's name, which sorts by
+// name in the order the snapshots were taken
+const nameTime = "20060102T150405Z"
+
+// Defaults
+const (
+	DefaultKeep  = 14
+	DefaultQuiet = 10 * time.Minute
+)
+
+// Config selects the dataset snapshotted and how many snapshots are kept
+type Config struct {
+	Enabled bool   `json:"enabled"`
+	Type    string `json:"type"`
+	// Dataset is the ZFS dataset holding the storage (tank/airgapper), or
+	// the path of the Btrfs subvolume holding it
+	Dataset string `json:"dataset"`
+	// Dir is where Btrfs snapshots are created (default: .airgapper-snapshots
+	// beside the subvolume). ZFS keeps snapshots in the dataset.
+	Dir string `json:"dir,omitempty"`
+	// Keep is how many snapshots are kept (default: DefaultKeep)
+	Keep int `json:"keep,omitempty"`
+	// Quiet is how long after the last snapshot upload a backup window is
+	// taken to be over, as a duration such as "10m" (default: DefaultQuiet)
+	Quiet string `json:"quiet,omitempty"`
+}
+
+// Validate checks that the config is usable
+func (c *Config) Validate() error {
+	if _, ok := plugins[c.Type]; !ok {
+		return fmt.Errorf("unknown filesystem %q for snapshots (supported: %s, %s)", c.Type, TypeZFS, TypeBtrfs)
+	}
+	switch {
+	case c.Dataset == "":
+		return errors.New("a dataset to snapshot is required")
+	case strings.HasPrefix(c.Dataset, "-"):
+		return fmt.Errorf("invalid dataset %q", c.Dataset)
+	case c.Type == TypeZFS && strings.ContainsAny(c.Dataset, "@ "):
+		return fmt.Errorf("invalid ZFS dataset %q", c.Dataset)
+	case c.Type == TypeBtrfs && !filepath.IsAbs(c.Dataset):
+		return fmt.Errorf("btrfs subvolume %q must be an absolute path", c.Dataset)
+	case c.Dir != "" && !filepath.IsAbs(c.Dir):
+		return fmt.Errorf("snapshot directory %q must be an absolute path", c.Dir)
+	case c.Keep < 0:
+		return errors.New("keep can't be negative")
+	}
+	if c.Quiet != "" {
+		if d, err := time.ParseDuration(c.Quiet); err != nil || d <= 0 {
+			return fmt.Errorf("invalid quiet period %q", c.Quiet)
+		}
+	}
+	return nil
+}
+
+// GetKeep returns how many snapshots are kept
+func (c *Config) GetKeep() int {
+	if c.Keep > 0 {
+		return c.Keep
+	}
+	return DefaultKeep
+}
+
+// GetQuiet returns how long after the last snapshot upload a backup
+// window is over
+func (c *Config) GetQuiet() time.Duration {
+	if d, err := time.ParseDuration(c.Quiet); err == nil && d > 0 {
+		return d
+	}
+	return DefaultQuiet
+}
+
+// GetDir returns where Btrfs snapshots are created
+func (c *Config) GetDir() string {
+	if c.Dir != "" {
+		return c.Dir
+	}
+	return filepath.Join(filepath.Dir(filepath.Clean(c.Dataset)), ".airgapper-snapshots")
+}
+
+// Snapshot is a filesystem snapshot this package took
+type Snapshot struct {
+	Name      string    `json:"name"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// parseName parses a snapshot name, reporting whether it is one of ours
+func parseName(name string) (Snapshot, bool) {
+	stamp, reason, ok := strings.Cut(strings.TrimPrefix(name, Prefix), "-")
+	if !ok || !strings.HasPrefix(name, Prefix) {
+		return Snapshot{}, false
+	}
+	at, err := time.Parse(nameTime, stamp)
+	if err != nil {
+		return Snapshot{}, false
+	}
+	return Snapshot{Name: name, Reason: reason, CreatedAt: at}, true
+}
+
+// plugin drives one filesystem's tool
+type plugin struct {
+	tool string
+	// create and destroy return the tool's arguments
+	create, destroy func(c *Config, name string) []string
+	// list returns the names of the dataset's snapshots
+	list func(ctx context.Context, c *Config) ([]string, error)
+}
+
+var plugins = map[string]plugin{
+	TypeZFS: {
+		tool: "zfs",
+		create: func(c *Config, name string) []string {
+			return []string{"snapshot", c.Dataset + "@" + name}
+		},
+		destroy: func(c *Config, name string) []string {
+			return []string{"destroy", c.Dataset + "@" + name}
+		},
+		list: func(ctx context.Context, c *Config) ([]string, error) {
+			out, err := run(exec.CommandContext(ctx, "zfs", "list", "-H", "-t", "snapshot", "-o", "name", "-d", "1", c.Dataset))
+			if err != nil {
+				return nil, fmt.Errorf("zfs list failed: %w", err)
+			}
+			var names []string
+			for _, line := range strings.Split(string(out), "\n") {
+				if _, name, ok := strings.Cut(strings.TrimSpace(line), "@"); ok {
+					names = append(names, name)
+				}
+			}
+			return names, nil
+		},
+	},
+	TypeBtrfs: {
+		tool: "btrfs",
+		create: func(c *Config, name string) []string {
+			return []string{"subvolume", "snapshot", "-r", c.Dataset, filepath.Join(c.GetDir(), name)}
+		},
+		destroy: func(c *Config, name string) []string {
+			return []string{"subvolume", "delete", filepath.Join(c.GetDir(), name)}
+		},
+		list: func(ctx context.Context, c *Config) ([]string, error) {
+			entries, err := os.ReadDir(c.GetDir())
+			if err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+			var names []string
+			for _, e := range entries {
+				if e.IsDir() {
+					names = append(names, e.Name())
+				}
+			}
+			return names, nil
+		},
+	},
+}
+
+// run runs a filesystem command and returns its stdout; tests replace it
+var run = func(cmd *exec.Cmd) ([]byte, error) {
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, errors.New(msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// List returns the snapshots this package took, oldest first
+func List(ctx context.Context, c *Config) ([]Snapshot, error) {
+	p, ok := plugins[c.Type]
+	if !ok {
+		return nil, c.Validate()
+	}
+	names, err := p.list(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	var snaps []Snapshot
+	for _, name := range names {
+		if s, ok := parseName(name); ok {
+			snaps = append(snaps, s)
+		}
+	}
+	slices.SortFunc(snaps, func(a, b Snapshot) int { return strings.Compare(a.Name, b.Name) })
+	return snaps, nil
+}
+
+// Take snapshots the dataset for reason, then destroys the oldest of this
+// package's snapshots beyond Keep. It returns the snapshot taken and the
+// names of those destroyed. A failure to destroy an old snapshot is
+// returned alongside the new snapshot.
+func Take(ctx context.Context, c *Config, reason string, now time.Time) (*Snapshot, []string, error) {
+	if err := c.Validate(); err != nil {
+		return nil, nil, err
+	}
+	p := plugins[c.Type]
+	snap := &Snapshot{
+		Name:      Prefix + now.UTC().Format(nameTime) + "-" + reason,
+		Reason:    reason,
+		CreatedAt: now.UTC().Truncate(time.Second),
+	}
+	if c.Type == TypeBtrfs {
+		if err := os.MkdirAll(c.GetDir(), 0700); err != nil {
+			return nil, nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+		}
+	}
+	if _, err := run(exec.CommandContext(ctx, p.tool, p.create(c, snap.Name)...)); err != nil {
+		return nil, nil, fmt.Errorf("%s snapshot failed: %w", p.tool, err)
+	}
+
+	snaps, err := List(ctx, c)
+	if err != nil {
+		return snap, nil, err
+	}
+	var destroyed []string
+	for len(snaps) > c.GetKeep() {
+		old := snaps[0]
+		snaps = snaps[1:]
+		if old.Name == snap.Name {
+			continue
+		}
+		if _, err := run(exec.CommandContext(ctx, p.tool, p.destroy(c, old.Name)...)); err != nil {
+			return snap, destroyed, fmt.Errorf("failed to destroy old snapshot %s: %w", old.Name, err)
+		}
+		destroyed = append(destroyed, old.Name)
+	}
+	return snap, destroyed, nil
+}
@@ -0,0 +1,108 @@
+package fssnap
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeZFS replaces the zfs tool with one keeping snapshots in memory
+func fakeZFS(t *testing.T, existing ...string) *[]string {
+	t.Helper()
+	snaps := append([]string{}, existing...)
+	orig := run
+	run = func(cmd *exec.Cmd) ([]byte, error) {
+		args := cmd.Args[1:]
+		switch args[0] {
+		case "snapshot":
+			snaps = append(snaps, args[1])
+		case "destroy":
+			for i, s := range snaps {
+				if s == args[1] {
+					snaps = append(snaps[:i], snaps[i+1:]...)
+					break
+				}
+			}
+		case "list":
+			return []byte(strings.Join(snaps, "\n") + "\n"), nil
+		}
+		return nil, nil
+	}
+	t.Cleanup(func() { run = orig })
+	return &snaps
+}
+
+func TestTakeKeepsNewest(t *testing.T) {
+	snaps := fakeZFS(t, "tank/backups@weekly", "tank/backups@airgapper-garbage")
+	c := &Config{Enabled: true, Type: TypeZFS, Dataset: "tank/backups", Keep: 2}
+	ctx := context.Background()
+	start := time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC)
+
+	var destroyed []string
+	for i := range 4 {
+		snap, d, err := Take(ctx, c, ReasonBackup, start.Add(time.Duration(i)*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, ReasonBackup, snap.Reason)
+		destroyed = append(destroyed, d...)
+	}
+	assert.Equal(t, []string{"airgapper-20260101T030000Z-backup", "airgapper-20260101T040000Z-backup"}, destroyed)
+
+	_, _, err := Take(ctx, c, ReasonDeletion, start.Add(5*time.Hour))
+	require.NoError(t, err)
+	list, err := List(ctx, c)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "airgapper-20260101T080000Z-deletion", list[1].Name)
+	assert.Equal(t, start.Add(5*time.Hour), list[1].CreatedAt)
+	assert.Contains(t, *snaps, "tank/backups@weekly", "other snapshots are left alone")
+	assert.Contains(t, *snaps, "tank/backups@airgapper-garbage")
+}
+
+func TestBtrfsCommands(t *testing.T) {
+	dir := t.TempDir()
+	c := &Config{Enabled: true, Type: TypeBtrfs, Dataset: filepath.Join(dir, "backups"), Dir: filepath.Join(dir, "snaps"), Keep: 1}
+	var calls [][]string
+	orig := run
+	run = func(cmd *exec.Cmd) ([]byte, error) {
+		calls = append(calls, cmd.Args)
+		if cmd.Args[2] == "snapshot" {
+			return nil, os.Mkdir(cmd.Args[5], 0700)
+		}
+		return nil, nil
+	}
+	t.Cleanup(func() { run = orig })
+
+	now := time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC)
+	_, _, err := Take(context.Background(), c, ReasonBackup, now)
+	require.NoError(t, err)
+	_, destroyed, err := Take(context.Background(), c, ReasonBackup, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"airgapper-20260101T030000Z-backup"}, destroyed)
+	assert.Equal(t, []string{"btrfs", "subvolume", "snapshot", "-r", c.Dataset, filepath.Join(c.Dir, "airgapper-20260101T030000Z-backup")}, calls[0])
+	assert.Equal(t, []string{"btrfs", "subvolume", "delete", filepath.Join(c.Dir, "airgapper-20260101T030000Z-backup")}, calls[2])
+}
+
+func TestValidate(t *testing.T) {
+	for _, c := range []Config{
+		{Type: "ext4", Dataset: "tank"},
+		{Type: TypeZFS},
+		{Type: TypeZFS, Dataset: "tank@snap"},
+		{Type: TypeZFS, Dataset: "-tank"},
+		{Type: TypeBtrfs, Dataset: "relative/path"},
+		{Type: TypeZFS, Dataset: "tank", Quiet: "soon"},
+	} {
+		assert.Error(t, c.Validate(), "%+v", c)
+	}
+	c := Config{Type: TypeBtrfs, Dataset: "/srv/backups"}
+	require.NoError(t, c.Validate())
+	assert.Equal(t, "/srv/.airgapper-snapshots", c.GetDir())
+	assert.Equal(t, DefaultKeep, c.GetKeep())
+	assert.Equal(t, DefaultQuiet, c.GetQuiet())
+}
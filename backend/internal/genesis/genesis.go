@@ -0,0 +1,398 @@
+// Package genesis records how a vault was set up: a document created at
+// init, signed by the owner and countersigned by each key holder that
+// joins. Every node keeps a copy and checks its configuration against it
+// before approvals and key holder changes, so an edited config.json (a
+// swapped key, a lowered threshold, another repository) is caught.
+package genesis
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+// FileName is the genesis document's file in the config directory
+const FileName = "genesis.json"
+
+// Mode is how the vault's password is protected
+type Mode string
+
+const (
+	ModeSSS       Mode = "sss"
+	ModeConsensus Mode = "consensus"
+)
+
+// KeyHolder is a signing key known when the document was created
+type KeyHolder struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	PublicKey []byte `json:"public_key"`
+}
+
+// Countersignature is a joining key holder's signature over the document
+type Countersignature struct {
+	KeyHolderID string    `json:"key_holder_id"`
+	Name        string    `json:"name"`
+	PublicKey   []byte    `json:"public_key"`
+	SignedAt    time.Time `json:"signed_at"`
+	Signature   string    `json:"signature"`
+}
+
+// Document is a vault's genesis record. SSS vaults usually have no signing
+// keys, so their document is unsigned and only catches changes made on
+// this node after init.
+type Document struct {
+	VaultID   string    `json:"vault_id"`
+	CreatedAt time.Time `json:"created_at"`
+	RepoURL   string    `json:"repo_url"`
+	// SecretFingerprint identifies the repository password without revealing it
+	SecretFingerprint string `json:"secret_fingerprint"`
+	Mode              Mode   `json:"mode"`
+	Threshold         int    `json:"threshold"`
+	TotalShares       int    `json:"total_shares"`
+	// ShareIndexes are the SSS share indexes dealt, the owner's first
+	ShareIndexes []int       `json:"share_indexes,omitempty"`
+	KeyHolders   []KeyHolder `json:"key_holders,omitempty"`
+	// PolicyHash covers the approval settings, see PolicyHash
+	PolicyHash string `json:"policy_hash"`
+
+	OwnerKeyID        string             `json:"owner_key_id,omitempty"`
+	OwnerSignature    string             `json:"owner_signature,omitempty"`
+	Countersignatures []Countersignature `json:"countersignatures,omitempty"`
+}
+
+// SecretFingerprint hashes the repository password for the document
+func SecretFingerprint(password string) string {
+	h := sha256.Sum256([]byte("airgapper-genesis-secret-v1" + password))
+	return hex.EncodeToString(h[:])
+}
+
+// PolicyHash hashes the settings that decide who can approve a restore:
+// the threshold and key count, whether approval is required, the
+// recovery share split, the social recovery contacts and the extra key
+// protections, each a way to the password besides the approvals
+func PolicyHash(cfg *config.Config) string {
+	policy := struct {
+		Threshold       int  `json:"threshold"`
+		TotalKeys       int  `json:"total_keys"`
+		RequireApproval bool `json:"require_approval"`
+		RecoveryK       int  `json:"recovery_threshold"`
+		RecoveryN       int  `json:"recovery_shares"`
+		// Omitted without social recovery, so older vaults' hashes hold
+		SocialContacts  []string `json:"social_recovery_contacts,omitempty"`
+		SocialThreshold int      `json:"social_recovery_threshold,omitempty"`
+		SocialWait      string   `json:"social_recovery_wait,omitempty"`
+		KeyProtection   []string `json:"key_protection,omitempty"`
+	}{}
+	if c := cfg.Consensus; c != nil {
+		policy.Threshold, policy.TotalKeys, policy.RequireApproval = c.Threshold, c.TotalKeys, c.RequireApproval
+	}
+	if r := cfg.Emergency.GetRecovery(); r != nil && r.Enabled {
+		policy.RecoveryK, policy.RecoveryN = r.Threshold, r.TotalShares
+	}
+	if s := cfg.SocialRecovery; s != nil {
+		for _, c := range s.Contacts {
+			policy.SocialContacts = append(policy.SocialContacts, c.ID)
+		}
+		policy.SocialThreshold = s.Threshold
+		if wait, err := s.Wait(); err == nil {
+			policy.SocialWait = wait.String()
+		}
+	}
+	for _, p := range cfg.KeyProtection {
+		policy.KeyProtection = append(policy.KeyProtection, p.String())
+	}
+	data, _ := json.Marshal(policy)
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+// New creates the genesis document for a freshly initialized owner config,
+// signed with the owner's key if it has one. totalShares is the number of
+// SSS shares dealt (ignored in consensus mode).
+func New(cfg *config.Config, totalShares int) (*Document, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate vault ID: %w", err)
+	}
+	d := &Document{
+		VaultID:           hex.EncodeToString(id),
+		CreatedAt:         time.Now().UTC().Truncate(time.Second),
+		RepoURL:           cfg.RepoURL,
+		SecretFingerprint: SecretFingerprint(cfg.Password),
+		PolicyHash:        PolicyHash(cfg),
+	}
+
+	if c := cfg.Consensus; c != nil {
+		d.Mode, d.Threshold, d.TotalShares = ModeConsensus, c.Threshold, c.TotalKeys
+		for _, kh := range c.KeyHolders {
+			d.KeyHolders = append(d.KeyHolders, KeyHolder{ID: kh.ID, Name: kh.Name, PublicKey: kh.PublicKey})
+		}
+	} else {
+		d.Mode, d.Threshold, d.TotalShares = ModeSSS, 2, totalShares
+		if r := cfg.Emergency.GetRecovery(); r != nil && r.Enabled {
+			d.Threshold = r.Threshold
+		}
+		d.ShareIndexes = []int{int(cfg.ShareIndex)}
+		for i := 1; i <= totalShares; i++ {
+			if i != int(cfg.ShareIndex) {
+				d.ShareIndexes = append(d.ShareIndexes, i)
+			}
+		}
+	}
+
+	if len(cfg.PrivateKey) > 0 {
+		if d.Mode == ModeSSS {
+			d.KeyHolders = []KeyHolder{{ID: crypto.KeyID(cfg.PublicKey), Name: cfg.Name, PublicKey: cfg.PublicKey}}
+		}
+		if err := d.sign(cfg.PrivateKey, cfg.PublicKey); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// Hash is the SHA-256 of the document without its signatures, which the
+// owner and every countersigner sign
+func (d *Document) Hash() ([]byte, error) {
+	unsigned := *d
+	unsigned.OwnerKeyID, unsigned.OwnerSignature, unsigned.Countersignatures = "", "", nil
+	unsigned.CreatedAt = d.CreatedAt.UTC()
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.Sum256(data)
+	return h[:], nil
+}
+
+func (d *Document) sign(privateKey, publicKey []byte) error {
+	hash, err := d.Hash()
+	if err != nil {
+		return err
+	}
+	sig, err := crypto.Sign(privateKey, hash)
+	if err != nil {
+		return fmt.Errorf("failed to sign genesis document: %w", err)
+	}
+	d.OwnerKeyID = crypto.KeyID(publicKey)
+	d.OwnerSignature = hex.EncodeToString(sig)
+	return nil
+}
+
+// owner is the key holder whose key signed the document
+func (d *Document) owner() *KeyHolder {
+	for i := range d.KeyHolders {
+		if d.KeyHolders[i].ID == d.OwnerKeyID {
+			return &d.KeyHolders[i]
+		}
+	}
+	return nil
+}
+
+// countersignData is what a countersigner signs: the document hash, their
+// key ID and when they signed
+func countersignData(hash []byte, keyHolderID string, signedAt time.Time) []byte {
+	return []byte(fmt.Sprintf("airgapper-genesis-countersign:%x:%s:%d", hash, keyHolderID, signedAt.Unix()))
+}
+
+// Countersign signs the document as a joining key holder
+func (d *Document) Countersign(name string, privateKey, publicKey []byte) (*Countersignature, error) {
+	hash, err := d.Hash()
+	if err != nil {
+		return nil, err
+	}
+	cs := &Countersignature{
+		KeyHolderID: crypto.KeyID(publicKey),
+		Name:        name,
+		PublicKey:   publicKey,
+		SignedAt:    time.Now().UTC().Truncate(time.Second),
+	}
+	sig, err := crypto.Sign(privateKey, countersignData(hash, cs.KeyHolderID, cs.SignedAt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to countersign genesis document: %w", err)
+	}
+	cs.Signature = hex.EncodeToString(sig)
+	return cs, nil
+}
+
+// verifyCountersignature checks a countersignature is by the key it names
+func verifyCountersignature(hash []byte, cs *Countersignature) error {
+	if cs.KeyHolderID != crypto.KeyID(cs.PublicKey) {
+		return fmt.Errorf("countersignature by %s names another key", cs.Name)
+	}
+	sig, err := hex.DecodeString(cs.Signature)
+	if err != nil || !crypto.Verify(cs.PublicKey, countersignData(hash, cs.KeyHolderID, cs.SignedAt), sig) {
+		return fmt.Errorf("invalid countersignature by %s", cs.Name)
+	}
+	return nil
+}
+
+// AddCountersignature verifies a countersignature and records it,
+// replacing an earlier one by the same key
+func (d *Document) AddCountersignature(cs *Countersignature) error {
+	hash, err := d.Hash()
+	if err != nil {
+		return err
+	}
+	if err := verifyCountersignature(hash, cs); err != nil {
+		return apperrors.Coded(apperrors.CodeInvalidSignature, err)
+	}
+	d.Countersignatures = slices.DeleteFunc(d.Countersignatures, func(c Countersignature) bool {
+		return c.KeyHolderID == cs.KeyHolderID
+	})
+	d.Countersignatures = append(d.Countersignatures, *cs)
+	return nil
+}
+
+// Verify checks the owner's signature and every countersignature
+func (d *Document) Verify() error {
+	hash, err := d.Hash()
+	if err != nil {
+		return err
+	}
+	if d.OwnerSignature != "" {
+		owner := d.owner()
+		if owner == nil {
+			return mismatch("signed by %s, which is not one of its key holders", d.OwnerKeyID)
+		}
+		sig, err := hex.DecodeString(d.OwnerSignature)
+		if err != nil || !crypto.Verify(owner.PublicKey, hash, sig) {
+			return mismatch("owner signature does not match its contents")
+		}
+	} else if d.Mode == ModeConsensus {
+		return mismatch("consensus vault document is not signed")
+	}
+	for i := range d.Countersignatures {
+		if err := verifyCountersignature(hash, &d.Countersignatures[i]); err != nil {
+			return mismatch("%v", err)
+		}
+	}
+	return nil
+}
+
+// Signer returns the public key the document records for a key holder,
+// from the keys known at init or the countersignatures
+func (d *Document) Signer(keyHolderID string) []byte {
+	for _, kh := range d.KeyHolders {
+		if kh.ID == keyHolderID {
+			return kh.PublicKey
+		}
+	}
+	for _, cs := range d.Countersignatures {
+		if cs.KeyHolderID == keyHolderID {
+			return cs.PublicKey
+		}
+	}
+	return nil
+}
+
+// Check verifies the document and compares it with cfg. Only what this
+// node knows is compared: a key holder's config has no consensus settings
+// and a host's has no password.
+func (d *Document) Check(cfg *config.Config) error {
+	if err := d.Verify(); err != nil {
+		return err
+	}
+
+	var diffs []string
+	if cfg.RepoURL != d.RepoURL {
+		diffs = append(diffs, fmt.Sprintf("repository is %s, not %s", cfg.RepoURL, d.RepoURL))
+	}
+	if cfg.Password != "" && SecretFingerprint(cfg.Password) != d.SecretFingerprint {
+		diffs = append(diffs, "repository password changed")
+	}
+	if cfg.IsOwner() && PolicyHash(cfg) != d.PolicyHash {
+		diffs = append(diffs, "approval policy (threshold, key count, recovery shares, contacts or key protection) changed")
+	}
+	if cfg.LocalShare != nil && d.Mode == ModeSSS && !slices.Contains(d.ShareIndexes, int(cfg.ShareIndex)) {
+		diffs = append(diffs, fmt.Sprintf("share index %d was never dealt", cfg.ShareIndex))
+	}
+	if c := cfg.Consensus; c != nil {
+		for _, kh := range c.KeyHolders {
+			if pub := d.Signer(kh.ID); pub != nil && string(pub) != string(kh.PublicKey) {
+				diffs = append(diffs, fmt.Sprintf("key holder %s has a different public key", kh.Name))
+			}
+		}
+		for _, kh := range d.KeyHolders {
+			if cfg.GetKeyHolder(kh.ID) == nil {
+				diffs = append(diffs, fmt.Sprintf("key holder %s was removed", kh.Name))
+			}
+		}
+	}
+	if len(diffs) > 0 {
+		return mismatch("%s", strings.Join(diffs, "; "))
+	}
+	return nil
+}
+
+// Uncountersigned lists the configured key holders the document doesn't
+// know, i.e. who joined but haven't countersigned yet
+func (d *Document) Uncountersigned(cfg *config.Config) []string {
+	if cfg.Consensus == nil {
+		return nil
+	}
+	var names []string
+	for _, kh := range cfg.Consensus.KeyHolders {
+		if d.Signer(kh.ID) == nil {
+			names = append(names, kh.Name)
+		}
+	}
+	return names
+}
+
+func mismatch(format string, args ...any) error {
+	return apperrors.Newf(apperrors.CodeGenesisMismatch, "vault does not match its genesis record: "+format, args...)
+}
+
+// Path is where cfg's genesis document is kept
+func Path(cfg *config.Config) string {
+	return filepath.Join(cfg.ConfigDir, FileName)
+}
+
+// Load reads cfg's genesis document; it returns nil, nil if there is none
+// (vaults created before genesis records)
+func Load(cfg *config.Config) (*Document, error) {
+	data, err := os.ReadFile(Path(cfg))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var d Document
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("invalid genesis document: %w", err)
+	}
+	return &d, nil
+}
+
+// Save writes the document to cfg's config directory
+func (d *Document) Save(cfg *config.Config) error {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(Path(cfg), data, 0600)
+}
+
+// CheckConfig checks cfg against its genesis document, if it has one
+func CheckConfig(cfg *config.Config) error {
+	d, err := Load(cfg)
+	if err != nil || d == nil {
+		return err
+	}
+	return d.Check(cfg)
+}
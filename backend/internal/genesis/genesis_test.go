@@ -0,0 +1,186 @@
+package genesis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/keyprotect"
+)
+
+type keyPair struct{ pub, priv []byte }
+
+func newKeyPair(t *testing.T) keyPair {
+	t.Helper()
+	pub, priv, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+	return keyPair{pub, priv}
+}
+
+func holder(name string, k keyPair, owner bool) config.KeyHolder {
+	return config.KeyHolder{ID: crypto.KeyID(k.pub), Name: name, PublicKey: k.pub, JoinedAt: time.Now(), IsOwner: owner}
+}
+
+func consensusConfig(t *testing.T, owner keyPair) *config.Config {
+	return &config.Config{
+		Name:       "alice",
+		Role:       config.RoleOwner,
+		RepoURL:    "rest:http://bob:8000/alice",
+		Password:   "secret",
+		PublicKey:  owner.pub,
+		PrivateKey: owner.priv,
+		ConfigDir:  t.TempDir(),
+		Consensus: &config.ConsensusConfig{
+			Threshold:  2,
+			TotalKeys:  3,
+			KeyHolders: []config.KeyHolder{holder("alice", owner, true)},
+		},
+	}
+}
+
+func TestConsensusGenesis(t *testing.T) {
+	owner, bob := newKeyPair(t), newKeyPair(t)
+	cfg := consensusConfig(t, owner)
+
+	doc, err := New(cfg, 0)
+	require.NoError(t, err)
+	assert.Equal(t, ModeConsensus, doc.Mode)
+	assert.Equal(t, crypto.KeyID(owner.pub), doc.OwnerKeyID)
+	assert.Len(t, doc.VaultID, 32)
+	assert.NotContains(t, doc.SecretFingerprint, "secret")
+	require.NoError(t, doc.Check(cfg))
+
+	// Bob joins and countersigns
+	require.NoError(t, cfg.AddKeyHolder(holder("bob", bob, false)))
+	assert.Equal(t, []string{"bob"}, doc.Uncountersigned(cfg))
+	cs, err := doc.Countersign("bob", bob.priv, bob.pub)
+	require.NoError(t, err)
+	require.NoError(t, doc.AddCountersignature(cs))
+	require.NoError(t, doc.Check(cfg))
+	assert.Empty(t, doc.Uncountersigned(cfg))
+	assert.Equal(t, bob.pub, doc.Signer(cs.KeyHolderID))
+
+	// Countersigning again replaces the earlier signature
+	cs, err = doc.Countersign("bob", bob.priv, bob.pub)
+	require.NoError(t, err)
+	require.NoError(t, doc.AddCountersignature(cs))
+	assert.Len(t, doc.Countersignatures, 1)
+
+	// A forged countersignature is rejected
+	forged := *cs
+	forged.PublicKey = newKeyPair(t).pub
+	assert.Error(t, doc.AddCountersignature(&forged))
+
+	// Round trip through the config directory
+	require.NoError(t, doc.Save(cfg))
+	loaded, err := Load(cfg)
+	require.NoError(t, err)
+	require.NoError(t, loaded.Check(cfg))
+	require.NoError(t, CheckConfig(cfg))
+}
+
+func TestGenesisDetectsTampering(t *testing.T) {
+	owner, bob := newKeyPair(t), newKeyPair(t)
+
+	tests := []struct {
+		name   string
+		tamper func(cfg *config.Config, doc *Document)
+		want   string
+	}{
+		{"threshold lowered", func(cfg *config.Config, doc *Document) { cfg.Consensus.Threshold = 1 }, "approval policy"},
+		{"recovery contact added", func(cfg *config.Config, doc *Document) {
+			eve := newKeyPair(t)
+			cfg.SocialRecovery = &consent.RecoveryPolicy{
+				Contacts:  []consent.RecoveryContact{{ID: crypto.KeyID(eve.pub), Name: "eve", PublicKey: eve.pub}},
+				Threshold: 1,
+			}
+		}, "approval policy"},
+		{"KMS escrow added", func(cfg *config.Config, doc *Document) {
+			cfg.KeyProtection = []keyprotect.Protection{{Type: keyprotect.TypeKMS, KMS: &keyprotect.KMSKey{Provider: keyprotect.KMSProviderAWS, Key: "alias/eve"}, Sealed: []byte("x")}}
+		}, "approval policy"},
+		{"repository moved", func(cfg *config.Config, doc *Document) { cfg.RepoURL = "rest:http://eve:8000/alice" }, "repository is"},
+		{"password changed", func(cfg *config.Config, doc *Document) { cfg.Password = "other" }, "password changed"},
+		{"key swapped", func(cfg *config.Config, doc *Document) {
+			cfg.Consensus.KeyHolders[1].PublicKey = newKeyPair(t).pub
+		}, "bob has a different public key"},
+		{"owner removed", func(cfg *config.Config, doc *Document) {
+			cfg.Consensus.KeyHolders = cfg.Consensus.KeyHolders[1:]
+		}, "alice was removed"},
+		{"document edited", func(cfg *config.Config, doc *Document) { doc.Threshold = 1 }, "owner signature"},
+		{"signature stripped", func(cfg *config.Config, doc *Document) { doc.OwnerSignature = "" }, "not signed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := consensusConfig(t, owner)
+			doc, err := New(cfg, 0)
+			require.NoError(t, err)
+			cfg.Consensus.KeyHolders = append(cfg.Consensus.KeyHolders, holder("bob", bob, false))
+			cs, err := doc.Countersign("bob", bob.priv, bob.pub)
+			require.NoError(t, err)
+			require.NoError(t, doc.AddCountersignature(cs))
+
+			tt.tamper(cfg, doc)
+			err = doc.Check(cfg)
+			require.Error(t, err)
+			assert.Equal(t, apperrors.CodeGenesisMismatch, apperrors.CodeOf(err))
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestSSSGenesis(t *testing.T) {
+	cfg := &config.Config{
+		Name:       "alice",
+		Role:       config.RoleOwner,
+		RepoURL:    "rest:http://bob:8000/alice",
+		Password:   "secret",
+		LocalShare: []byte("share"),
+		ShareIndex: 1,
+		ConfigDir:  t.TempDir(),
+	}
+	doc, err := New(cfg, 2)
+	require.NoError(t, err)
+	assert.Equal(t, ModeSSS, doc.Mode)
+	assert.Equal(t, 2, doc.Threshold)
+	assert.Equal(t, []int{1, 2}, doc.ShareIndexes)
+	assert.Empty(t, doc.OwnerSignature)
+	require.NoError(t, doc.Check(cfg))
+
+	// The host holds share 2 but not the password
+	host := &config.Config{Role: config.RoleHost, RepoURL: cfg.RepoURL, LocalShare: []byte("share"), ShareIndex: 2}
+	require.NoError(t, doc.Check(host))
+	host.ShareIndex = 3
+	assert.ErrorContains(t, doc.Check(host), "share index 3 was never dealt")
+}
+
+func TestSSSGenesisWithOwnerKey(t *testing.T) {
+	owner := newKeyPair(t)
+	cfg := &config.Config{
+		Name:       "alice",
+		Role:       config.RoleOwner,
+		RepoURL:    "rest:http://bob:8000/alice",
+		Password:   "secret",
+		PublicKey:  owner.pub,
+		PrivateKey: owner.priv,
+		LocalShare: []byte("share"),
+		ShareIndex: 1,
+	}
+	doc, err := New(cfg, 2)
+	require.NoError(t, err)
+	assert.NotEmpty(t, doc.OwnerSignature)
+	require.NoError(t, doc.Check(cfg))
+}
+
+func TestLoadMissing(t *testing.T) {
+	cfg := &config.Config{ConfigDir: t.TempDir()}
+	doc, err := Load(cfg)
+	require.NoError(t, err)
+	assert.Nil(t, doc)
+	assert.NoError(t, CheckConfig(cfg))
+}
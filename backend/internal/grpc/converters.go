@@ -1,6 +1,8 @@
 package grpc
 
 import (
+	"encoding/hex"
+	"net"
 	"time"
 
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -61,6 +63,8 @@ func toProtoRequestStatus(status consent.RequestStatus) airgapperv1.RequestStatu
 		return airgapperv1.RequestStatus_REQUEST_STATUS_DENIED
 	case consent.StatusExpired:
 		return airgapperv1.RequestStatus_REQUEST_STATUS_EXPIRED
+	case consent.StatusVetoed:
+		return airgapperv1.RequestStatus_REQUEST_STATUS_VETOED
 	default:
 		return airgapperv1.RequestStatus_REQUEST_STATUS_UNSPECIFIED
 	}
@@ -137,12 +141,19 @@ func toProtoConsensusInfo(consensus *config.ConsensusConfig) *airgapperv1.Consen
 // ============================================================================
 
 func toProtoApproval(a consent.Approval) *airgapperv1.Approval {
-	return &airgapperv1.Approval{
+	result := &airgapperv1.Approval{
 		KeyHolderId:   a.KeyHolderID,
 		KeyHolderName: a.KeyHolderName,
-		Signature:     string(a.Signature), // Convert []byte to string
+		Signature:     hex.EncodeToString(a.Signature),
 		ApprovedAt:    timestamppb.New(a.ApprovedAt),
+		OnBehalfOf:    a.OnBehalfOf,
+		DelegationId:  a.DelegationID,
+		Nonce:         a.Nonce,
 	}
+	if a.SignedAt != nil {
+		result.SignedAt = timestamppb.New(*a.SignedAt)
+	}
+	return result
 }
 
 func toProtoApprovals(approvals []consent.Approval) []*airgapperv1.Approval {
@@ -170,12 +181,120 @@ func toProtoRestoreRequest(req *consent.RestoreRequest) *airgapperv1.RestoreRequ
 		ApprovedBy:        req.ApprovedBy,
 		RequiredApprovals: int32(req.RequiredApprovals),
 		Approvals:         toProtoApprovals(req.Approvals),
+		Extensions:        mapSlice(req.Extensions, toProtoExtension),
+		VetoedBy:          req.VetoedBy,
+		VetoReason:        req.VetoReason,
+		SizeBytes:         req.SizeBytes,
+		Terms:             mapSlice(req.Terms, toProtoRestoreTerms),
+		Progress:          toProtoRestoreProgress(req.Progress),
+		Purpose:           req.Purpose,
+		Template:          req.Template,
 	}
 
 	if req.ApprovedAt != nil {
 		result.ApprovedAt = timestamppb.New(*req.ApprovedAt)
 	}
+	if left := req.CoolingOffLeft(time.Now()); left > 0 {
+		result.ExecutableAt = timestamppb.New(*req.ExecutableAt)
+		result.CoolingOffRemainingSeconds = int64(left.Round(time.Second) / time.Second)
+	}
+	if req.VetoedAt != nil {
+		result.VetoedAt = timestamppb.New(*req.VetoedAt)
+	}
+	if rc := req.RequesterContext; rc != nil {
+		result.RequesterContext = &airgapperv1.RequesterContext{
+			Hostname:       rc.Hostname,
+			Os:             rc.OS,
+			Version:        rc.Version,
+			KeyFingerprint: rc.KeyFingerprint,
+			SourceIp:       rc.SourceIP,
+		}
+	}
+
+	return result
+}
+
+// fromProtoRequesterContext records the context a requester sent, with the
+// source address taken from the call (host:port) rather than the requester
+func fromProtoRequesterContext(rc *airgapperv1.RequesterContext, peerAddr string) *consent.RequesterContext {
+	result := &consent.RequesterContext{}
+	if rc != nil {
+		result.Hostname = rc.Hostname
+		result.OS = rc.Os
+		result.Version = rc.Version
+		result.KeyFingerprint = rc.KeyFingerprint
+	}
+	if host, _, err := net.SplitHostPort(peerAddr); err == nil {
+		result.SourceIP = host
+	} else {
+		result.SourceIP = peerAddr
+	}
+	return result
+}
+
+func toProtoRestoreTerms(t consent.RestoreTerms) *airgapperv1.RestoreTerms {
+	return &airgapperv1.RestoreTerms{
+		Window:     t.Window,
+		UtcOffset:  int32(t.UTCOffset),
+		LimitKibps: int32(t.LimitKiBps),
+		SetBy:      t.SetBy,
+	}
+}
+
+// fromProtoRestoreTerms returns the terms an approver sent, or nil if none.
+// The node records who set them.
+func fromProtoRestoreTerms(t *airgapperv1.RestoreTerms) *consent.RestoreTerms {
+	if t == nil {
+		return nil
+	}
+	return &consent.RestoreTerms{
+		Window:     t.Window,
+		UTCOffset:  int(t.UtcOffset),
+		LimitKiBps: int(t.LimitKibps),
+	}
+}
+
+func toProtoRestoreProgress(p *consent.RestoreProgress) *airgapperv1.RestoreProgress {
+	if p == nil {
+		return nil
+	}
+	return &airgapperv1.RestoreProgress{
+		Status:     p.Status,
+		StartsAt:   timePtrToTimestamp(p.StartsAt),
+		StartedAt:  timePtrToTimestamp(p.StartedAt),
+		FinishedAt: timePtrToTimestamp(p.FinishedAt),
+		Files:      int32(p.Files),
+		Restored:   int32(p.Restored),
+		Error:      p.Error,
+		UpdatedAt:  timeToTimestamp(p.UpdatedAt),
+	}
+}
 
+func fromProtoRestoreProgress(p *airgapperv1.RestoreProgress) consent.RestoreProgress {
+	if p == nil {
+		return consent.RestoreProgress{}
+	}
+	return consent.RestoreProgress{
+		Status:     p.Status,
+		StartsAt:   timestampToTimePtr(p.StartsAt),
+		StartedAt:  timestampToTimePtr(p.StartedAt),
+		FinishedAt: timestampToTimePtr(p.FinishedAt),
+		Files:      int(p.Files),
+		Restored:   int(p.Restored),
+		Error:      p.Error,
+	}
+}
+
+func toProtoExtension(e consent.Extension) *airgapperv1.ExpiryExtension {
+	result := &airgapperv1.ExpiryExtension{
+		ExtendBy:    e.By.String(),
+		Reason:      e.Reason,
+		RequestedAt: timestamppb.New(e.RequestedAt),
+		ApprovedBy:  e.ApprovedBy,
+	}
+	if e.ApprovedAt != nil {
+		result.ApprovedAt = timestamppb.New(*e.ApprovedAt)
+	}
 	return result
 }
 
@@ -213,10 +332,26 @@ func toProtoDeletionRequest(del *consent.DeletionRequest) *airgapperv1.DeletionR
 	if del.ExecutedAt != nil {
 		result.ExecutedAt = timestamppb.New(*del.ExecutedAt)
 	}
+	if plan := del.Retention; plan != nil {
+		result.Retention = &airgapperv1.RetentionPlan{
+			Policy: plan.Policy,
+			Keep:   mapSlice(plan.Keep, toProtoPlannedSnapshot),
+			Remove: mapSlice(plan.Remove, toProtoPlannedSnapshot),
+		}
+	}
 
 	return result
 }
 
+func toProtoPlannedSnapshot(s consent.PlannedSnapshot) *airgapperv1.PlannedSnapshot {
+	return &airgapperv1.PlannedSnapshot{
+		Id:     s.ID,
+		Time:   timestamppb.New(s.Time),
+		Paths:  s.Paths,
+		Pinned: s.Pinned,
+	}
+}
+
 func toProtoDeletionRequests(dels []*consent.DeletionRequest) []*airgapperv1.DeletionRequest {
 	return mapSlice(dels, toProtoDeletionRequest)
 }
@@ -251,3 +386,18 @@ func timeToTimestamp(t time.Time) *timestamppb.Timestamp {
 	}
 	return timestamppb.New(t)
 }
+
+func timePtrToTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func timestampToTimePtr(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
@@ -8,7 +8,12 @@ import (
 
 	airgapperv1 "github.com/lcrostarosa/airgapper/backend/gen/airgapper/v1"
 	"github.com/lcrostarosa/airgapper/backend/gen/airgapper/v1/airgapperv1connect"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/policy"
+	"github.com/lcrostarosa/airgapper/backend/internal/prune"
 	"github.com/lcrostarosa/airgapper/backend/internal/service"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
 )
 
 // deletionsServer implements the DeletionService
@@ -59,6 +64,14 @@ func (d *deletionsServer) CreateDeletion(
 		Paths:             req.Msg.Paths,
 		Reason:            req.Msg.Reason,
 		RequiredApprovals: int(req.Msg.RequiredApprovals),
+		CorrelationID:     tracing.ID(ctx),
+	}
+	if req.Msg.ApplyRetention {
+		plan, err := d.retentionPlan(ctx, params.DeletionType)
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, err)
+		}
+		params.Retention = plan
 	}
 
 	deletion, err := d.server.consentSvc.CreateDeletionRequest(params)
@@ -73,6 +86,23 @@ func (d *deletionsServer) CreateDeletion(
 	}), nil
 }
 
+// retentionPlan previews what the configured retention policy removes, for
+// a prune request applying it
+func (d *deletionsServer) retentionPlan(ctx context.Context, deletionType consent.DeletionType) (*consent.RetentionPlan, error) {
+	cfg := d.server.cfg
+	switch {
+	case deletionType != consent.DeletionTypePrune:
+		return nil, apperrors.New(apperrors.CodeInvalidArgument, "only prune deletions can apply the retention policy")
+	case !cfg.IsOwner() || cfg.Password == "" || d.server.restic == nil:
+		return nil, apperrors.New(apperrors.CodeNoRepoPassword, "repository password not available on this node")
+	}
+	pins, err := policy.LoadPinSet(cfg.PinsPath())
+	if err != nil {
+		return nil, err
+	}
+	return prune.Preview(ctx, d.server.restic(cfg.RepoURL, cfg.Password), cfg.BackupRetention, pins)
+}
+
 func (d *deletionsServer) ApproveDeletion(
 	ctx context.Context,
 	req *connect.Request[airgapperv1.ApproveDeletionRequest],
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	airgapperv1 "github.com/lcrostarosa/airgapper/backend/gen/airgapper/v1"
 	"github.com/lcrostarosa/airgapper/backend/gen/airgapper/v1/airgapperv1connect"
@@ -51,24 +52,60 @@ func (h *healthServer) GetStatus(
 		BackupPaths:     status.BackupPaths,
 		Mode:            toProtoOperationMode(cfg),
 		Consensus:       toProtoConsensusInfo(cfg.Consensus),
+		ServerTime:      timestamppb.Now(),
+
+		ApiVersion:           h.server.apiVersion,
+		SupportedApiVersions: h.server.supportedAPIVersions,
+	}
+
+	// Let peers know writes to this node's storage will be refused
+	if m := h.server.hostSvc.GetStorageStatus().Maintenance; m != nil {
+		resp.StorageReadOnly = true
+		resp.StorageMaintenanceReason = m.Reason
 	}
 
 	// Add peer info if available
 	if status.Peer != nil {
 		resp.Peer = &airgapperv1.Peer{
-			Name:    status.Peer.Name,
-			Address: status.Peer.Address,
+			Name:            status.Peer.Name,
+			Address:         status.Peer.Address,
+			IdentityChanged: status.Peer.IdentityChanged,
 		}
 	}
 
 	// Add scheduler info
 	if status.Scheduler != nil {
 		resp.Scheduler = &airgapperv1.SchedulerInfo{
-			Enabled:   status.Scheduler.Enabled,
-			Schedule:  status.Scheduler.Schedule,
-			Paths:     status.Scheduler.Paths,
-			LastError: status.Scheduler.LastError,
+			Enabled:             status.Scheduler.Enabled,
+			Schedule:            status.Scheduler.Schedule,
+			Timezone:            status.Scheduler.Timezone,
+			Paths:               status.Scheduler.Paths,
+			LastError:           status.Scheduler.LastError,
+			Healthy:             status.Scheduler.Healthy,
+			ConsecutiveFailures: int32(status.Scheduler.ConsecutiveFailures),
+			FailureThreshold:    int32(status.Scheduler.FailureThreshold),
+		}
+	}
+
+	// Add replication target status
+	for _, r := range status.Replication {
+		info := &airgapperv1.ReplicationTargetInfo{
+			Name:             r.Name,
+			RepoUrl:          r.RepoURL,
+			Enabled:          r.Enabled,
+			LastError:        r.LastError,
+			SourceSnapshots:  int32(r.SourceSnapshots),
+			TargetSnapshots:  int32(r.TargetSnapshots),
+			MissingSnapshots: int32(r.Missing),
+			InSync:           r.InSync,
+		}
+		if !r.LastRun.IsZero() {
+			info.LastRun = timestamppb.New(r.LastRun)
+		}
+		if !r.LastSuccess.IsZero() {
+			info.LastSuccess = timestamppb.New(r.LastSuccess)
 		}
+		resp.Replication = append(resp.Replication, info)
 	}
 
 	return connect.NewResponse(resp), nil
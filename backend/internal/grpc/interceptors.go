@@ -2,10 +2,14 @@ package grpc
 
 import (
 	"context"
+	"errors"
 
 	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/structpb"
 
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
 	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
 )
 
 // loggingInterceptor logs RPC calls
@@ -17,10 +21,10 @@ func newLoggingInterceptor() connect.Interceptor {
 
 func (i *loggingInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
 	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
-		logging.Debugf("gRPC call: %s", req.Spec().Procedure)
+		logging.Debug("gRPC call", logging.String("procedure", req.Spec().Procedure), tracing.Field(ctx))
 		resp, err := next(ctx, req)
 		if err != nil {
-			logging.Debugf("gRPC error: %s: %v", req.Spec().Procedure, err)
+			logging.Warn("gRPC error", logging.String("procedure", req.Spec().Procedure), logging.Err(err), tracing.Field(ctx))
 		}
 		return resp, err
 	}
@@ -33,3 +37,68 @@ func (i *loggingInterceptor) WrapStreamingClient(next connect.StreamingClientFun
 func (i *loggingInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
 	return next // No streaming RPCs in our API
 }
+
+// ErrorCodeHeader carries the apperrors code (e.g. AG-1004) of a failed RPC
+const ErrorCodeHeader = "X-Airgapper-Error-Code"
+
+// errorCodeInterceptor attaches a machine-readable code to every RPC error:
+// as the ErrorCodeHeader and as a google.protobuf.Struct detail holding
+// {code, reason, ...details}. Handlers that report an uncoded error keep
+// their Connect code; a coded error wrapped as internal takes its code's kind.
+type errorCodeInterceptor struct{}
+
+func newErrorCodeInterceptor() connect.Interceptor {
+	return &errorCodeInterceptor{}
+}
+
+func (i *errorCodeInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		resp, err := next(ctx, req)
+		if err != nil {
+			err = codeError(err)
+		}
+		return resp, err
+	}
+}
+
+func (i *errorCodeInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next // No streaming RPCs in our API
+}
+
+func (i *errorCodeInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next // No streaming RPCs in our API
+}
+
+// codeError returns err as a *connect.Error carrying its apperrors code
+func codeError(err error) *connect.Error {
+	var ce *connect.Error
+	if !errors.As(err, &ce) {
+		ce = connect.NewError(connect.CodeUnknown, err)
+	}
+
+	code := apperrors.GenericCode(apperrors.Kind(ce.Code().String()))
+	var coded *apperrors.Error
+	if errors.As(ce.Unwrap(), &coded) {
+		code = coded.Code
+		if ce.Code() == connect.CodeInternal || ce.Code() == connect.CodeUnknown {
+			var kind connect.Code
+			if kind.UnmarshalText([]byte(code.Kind())) == nil {
+				ce = connect.NewError(kind, ce.Unwrap())
+			}
+		}
+	}
+
+	fields := map[string]any{"code": string(code), "reason": code.Name()}
+	if coded != nil {
+		for k, v := range coded.Details {
+			fields[k] = v
+		}
+	}
+	if s, err := structpb.NewStruct(fields); err == nil {
+		if detail, err := connect.NewErrorDetail(s); err == nil {
+			ce.AddDetail(detail)
+		}
+	}
+	ce.Meta().Set(ErrorCodeHeader, string(code))
+	return ce
+}
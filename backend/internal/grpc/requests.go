@@ -3,12 +3,16 @@ package grpc
 import (
 	"context"
 	"encoding/hex"
+	"time"
 
 	"connectrpc.com/connect"
 
 	airgapperv1 "github.com/lcrostarosa/airgapper/backend/gen/airgapper/v1"
 	"github.com/lcrostarosa/airgapper/backend/gen/airgapper/v1/airgapperv1connect"
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
 	"github.com/lcrostarosa/airgapper/backend/internal/service"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
 )
 
 // requestsServer implements the RestoreRequestService
@@ -57,6 +61,34 @@ func (r *requestsServer) CreateRequest(
 		SnapshotID: req.Msg.SnapshotId,
 		Paths:      req.Msg.Paths,
 		Reason:     req.Msg.Reason,
+
+		CorrelationID:    tracing.ID(ctx),
+		RequesterContext: fromProtoRequesterContext(req.Msg.RequesterContext, req.Peer().Addr),
+		SizeBytes:        req.Msg.SizeBytes,
+		Purpose:          req.Msg.Purpose,
+		Template:         req.Msg.Template,
+	}
+	if req.Msg.Ttl != "" {
+		ttl, err := parseDuration("ttl", req.Msg.Ttl)
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		params.TTL = ttl
+	}
+	if req.Msg.Signature != "" {
+		signature, err := hex.DecodeString(req.Msg.Signature)
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+		if req.Msg.CreatedAt == nil {
+			return nil, connect.NewError(connect.CodeInvalidArgument, apperrors.New(apperrors.CodeInvalidArgument, "a signed request needs its creation time"))
+		}
+		params.Signature = &consent.RequesterSignature{
+			RequestID:   req.Msg.Id,
+			CreatedAt:   req.Msg.CreatedAt.AsTime(),
+			KeyHolderID: req.Msg.KeyHolderId,
+			Signature:   signature,
+		}
 	}
 
 	request, err := r.server.consentSvc.CreateRestoreRequest(params)
@@ -75,7 +107,7 @@ func (r *requestsServer) ApproveRequest(
 	ctx context.Context,
 	req *connect.Request[airgapperv1.ApproveRequestRequest],
 ) (*connect.Response[airgapperv1.ApproveRequestResponse], error) {
-	err := r.server.consentSvc.ApproveRequest(req.Msg.Id, req.Msg.Share)
+	err := r.server.consentSvc.ApproveRequest(req.Msg.Id, req.Msg.Share, fromProtoRestoreTerms(req.Msg.Terms))
 	if err != nil {
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
@@ -86,6 +118,21 @@ func (r *requestsServer) ApproveRequest(
 	}), nil
 }
 
+func (r *requestsServer) IssueChallenge(
+	ctx context.Context,
+	req *connect.Request[airgapperv1.IssueChallengeRequest],
+) (*connect.Response[airgapperv1.IssueChallengeResponse], error) {
+	challenge, err := r.server.consentSvc.IssueChallenge(req.Msg.Id, req.Msg.KeyHolderId)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&airgapperv1.IssueChallengeResponse{
+		Nonce:     challenge.Nonce,
+		ExpiresAt: timeToTimestamp(challenge.ExpiresAt),
+	}), nil
+}
+
 func (r *requestsServer) SignRequest(
 	ctx context.Context,
 	req *connect.Request[airgapperv1.SignRequestRequest],
@@ -97,9 +144,15 @@ func (r *requestsServer) SignRequest(
 	}
 
 	params := service.SignRequestParams{
-		RequestID:   req.Msg.Id,
-		KeyHolderID: req.Msg.KeyHolderId,
-		Signature:   signature,
+		RequestID:    req.Msg.Id,
+		KeyHolderID:  req.Msg.KeyHolderId,
+		Signature:    signature,
+		Nonce:        req.Msg.Nonce,
+		DelegationID: req.Msg.DelegationId,
+		Terms:        fromProtoRestoreTerms(req.Msg.Terms),
+	}
+	if req.Msg.SignedAt != nil {
+		params.SignedAt = req.Msg.SignedAt.AsTime()
 	}
 
 	progress, err := r.server.consentSvc.SignRequest(params)
@@ -128,3 +181,104 @@ func (r *requestsServer) DenyRequest(
 		Status: "denied",
 	}), nil
 }
+
+func (r *requestsServer) RequestExtension(
+	ctx context.Context,
+	req *connect.Request[airgapperv1.RequestExtensionRequest],
+) (*connect.Response[airgapperv1.RequestExtensionResponse], error) {
+	by, err := parseDuration("extend_by", req.Msg.ExtendBy)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	extension, err := r.server.consentSvc.RequestExtension(req.Msg.Id, by, req.Msg.Reason)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&airgapperv1.RequestExtensionResponse{
+		Extension: toProtoExtension(*extension),
+	}), nil
+}
+
+func (r *requestsServer) ApproveExtension(
+	ctx context.Context,
+	req *connect.Request[airgapperv1.ApproveExtensionRequest],
+) (*connect.Response[airgapperv1.ApproveExtensionResponse], error) {
+	signature, err := hex.DecodeString(req.Msg.Signature)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	params := service.ApproveExtensionParams{
+		RequestID:   req.Msg.Id,
+		KeyHolderID: req.Msg.KeyHolderId,
+		Signature:   signature,
+		Nonce:       req.Msg.Nonce,
+	}
+	if req.Msg.SignedAt != nil {
+		params.SignedAt = req.Msg.SignedAt.AsTime()
+	}
+
+	request, err := r.server.consentSvc.ApproveExtension(params)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&airgapperv1.ApproveExtensionResponse{
+		ExpiresAt: timeToTimestamp(request.ExpiresAt),
+	}), nil
+}
+
+func (r *requestsServer) VetoRequest(
+	ctx context.Context,
+	req *connect.Request[airgapperv1.VetoRequestRequest],
+) (*connect.Response[airgapperv1.VetoRequestResponse], error) {
+	signature, err := hex.DecodeString(req.Msg.Signature)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	params := service.VetoRequestParams{
+		RequestID:   req.Msg.Id,
+		KeyHolderID: req.Msg.KeyHolderId,
+		Reason:      req.Msg.Reason,
+		Signature:   signature,
+		Nonce:       req.Msg.Nonce,
+	}
+	if req.Msg.SignedAt != nil {
+		params.SignedAt = req.Msg.SignedAt.AsTime()
+	}
+
+	request, err := r.server.consentSvc.VetoRequest(params)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&airgapperv1.VetoRequestResponse{
+		Status: string(request.Status),
+	}), nil
+}
+
+func (r *requestsServer) ReportRestoreProgress(
+	ctx context.Context,
+	req *connect.Request[airgapperv1.ReportRestoreProgressRequest],
+) (*connect.Response[airgapperv1.ReportRestoreProgressResponse], error) {
+	request, err := r.server.consentSvc.ReportRestoreProgress(req.Msg.Id, fromProtoRestoreProgress(req.Msg.Progress))
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	return connect.NewResponse(&airgapperv1.ReportRestoreProgressResponse{
+		Status: request.Progress.Status,
+	}), nil
+}
+
+// parseDuration parses a duration field such as "12h"
+func parseDuration(field, value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, apperrors.Newf(apperrors.CodeInvalidArgument, "invalid %s %q: use a duration such as \"12h\"", field, value)
+	}
+	return d, nil
+}
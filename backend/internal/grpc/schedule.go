@@ -7,6 +7,7 @@ import (
 
 	airgapperv1 "github.com/lcrostarosa/airgapper/backend/gen/airgapper/v1"
 	"github.com/lcrostarosa/airgapper/backend/gen/airgapper/v1/airgapperv1connect"
+	"github.com/lcrostarosa/airgapper/backend/internal/scheduler"
 )
 
 // scheduleServer implements the ScheduleService
@@ -27,9 +28,15 @@ func (s *scheduleServer) GetSchedule(
 
 	return connect.NewResponse(&airgapperv1.GetScheduleResponse{
 		Schedule:  info.Schedule,
+		Timezone:  info.Timezone,
 		Paths:     info.Paths,
 		Enabled:   info.Enabled,
 		LastError: info.LastError,
+		Timing: &airgapperv1.ScheduleTiming{
+			CatchUpWindow:      info.Timing.CatchUpWindow,
+			Jitter:             info.Timing.Jitter,
+			ClockJumpThreshold: info.Timing.ClockJumpThreshold,
+		},
 	}), nil
 }
 
@@ -37,8 +44,26 @@ func (s *scheduleServer) UpdateSchedule(
 	ctx context.Context,
 	req *connect.Request[airgapperv1.UpdateScheduleRequest],
 ) (*connect.Response[airgapperv1.UpdateScheduleResponse], error) {
+	var timing *scheduler.TimingConfig
+	if t := req.Msg.Timing; t != nil {
+		timing = &scheduler.TimingConfig{
+			CatchUpWindow:      t.CatchUpWindow,
+			Jitter:             t.Jitter,
+			ClockJumpThreshold: t.ClockJumpThreshold,
+		}
+		if _, err := timing.Parse(); err != nil {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+	}
+
+	if tz := req.Msg.Timezone; tz != nil {
+		if _, err := scheduler.LoadTimezone(*tz); err != nil {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
+	}
+
 	// Update config
-	if err := s.server.statusSvc.UpdateSchedule(req.Msg.Schedule, req.Msg.Paths); err != nil {
+	if err := s.server.statusSvc.UpdateSchedule(req.Msg.Schedule, req.Msg.Paths, req.Msg.Timezone, timing); err != nil {
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
 
@@ -69,6 +94,7 @@ func (s *scheduleServer) GetBackupHistory(
 			Success:       r.Success,
 			Attempt:       int32(r.Attempt),
 			IsRetry:       r.IsRetry(),
+			CatchUp:       r.CatchUp,
 		}
 		if r.Error != nil {
 			protoResults[i].Error = r.Error.Error()
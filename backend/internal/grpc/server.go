@@ -12,6 +12,7 @@ import (
 	"github.com/lcrostarosa/airgapper/backend/internal/config"
 	"github.com/lcrostarosa/airgapper/backend/internal/consent"
 	"github.com/lcrostarosa/airgapper/backend/internal/integrity"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
 	"github.com/lcrostarosa/airgapper/backend/internal/scheduler"
 	"github.com/lcrostarosa/airgapper/backend/internal/service"
 	"github.com/lcrostarosa/airgapper/backend/internal/storage"
@@ -33,6 +34,11 @@ type Server struct {
 	integrityChecker        *integrity.Checker
 	managedScheduledChecker *integrity.ManagedScheduledChecker
 	scheduler               *scheduler.Scheduler
+	restic                  restic.RunnerFactory
+
+	// API versions reported in status
+	apiVersion           string
+	supportedAPIVersions []string
 
 	// Verification components
 	auditChain      *verification.AuditChain
@@ -46,6 +52,11 @@ type ServerOptions struct {
 	IntegrityChecker *integrity.Checker
 	ScheduledChecker *integrity.ManagedScheduledChecker
 	Scheduler        *scheduler.Scheduler
+	// Restic opens the owner's repository, to preview retention
+	Restic restic.RunnerFactory
+	// APIVersion and SupportedAPIVersions are reported by GetStatus
+	APIVersion           string
+	SupportedAPIVersions []string
 
 	// Verification components
 	AuditChain      *verification.AuditChain
@@ -55,7 +66,7 @@ type ServerOptions struct {
 
 // NewServer creates a new Connect-RPC server with all service handlers
 func NewServer(cfg *config.Config, opts *ServerOptions) *Server {
-	consentMgr := consent.NewManager(cfg.ConfigDir)
+	consentMgr := cfg.ConsentManager()
 
 	s := &Server{
 		cfg:        cfg,
@@ -71,6 +82,9 @@ func NewServer(cfg *config.Config, opts *ServerOptions) *Server {
 		s.integrityChecker = opts.IntegrityChecker
 		s.managedScheduledChecker = opts.ScheduledChecker
 		s.scheduler = opts.Scheduler
+		s.restic = opts.Restic
+		s.apiVersion = opts.APIVersion
+		s.supportedAPIVersions = opts.SupportedAPIVersions
 
 		// Verification components
 		s.auditChain = opts.AuditChain
@@ -94,6 +108,7 @@ func (s *Server) RegisterHandlers(mux *http.ServeMux) {
 	// Create interceptors for logging, error handling, etc.
 	interceptors := connect.WithInterceptors(
 		newLoggingInterceptor(),
+		newErrorCodeInterceptor(),
 	)
 
 	// Health service
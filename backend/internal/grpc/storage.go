@@ -3,8 +3,10 @@ package grpc
 import (
 	"context"
 	"errors"
+	"time"
 
 	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	airgapperv1 "github.com/lcrostarosa/airgapper/backend/gen/airgapper/v1"
 	"github.com/lcrostarosa/airgapper/backend/gen/airgapper/v1/airgapperv1connect"
@@ -26,7 +28,7 @@ func (s *storageServer) GetStorageStatus(
 ) (*connect.Response[airgapperv1.GetStorageStatusResponse], error) {
 	status := s.server.hostSvc.GetStorageStatus()
 
-	return connect.NewResponse(&airgapperv1.GetStorageStatusResponse{
+	resp := &airgapperv1.GetStorageStatusResponse{
 		Configured:     status.Configured,
 		Running:        status.Running,
 		BasePath:       status.BasePath,
@@ -39,7 +41,22 @@ func (s *storageServer) GetStorageStatus(
 		DiskUsagePct:   int32(status.DiskUsagePct),
 		DiskFreeBytes:  status.DiskFreeBytes,
 		DiskTotalBytes: status.DiskTotalBytes,
-	}), nil
+
+		PolicyMaxStorageBytes: status.PolicyMaxStorageBytes,
+		EffectiveQuotaBytes:   status.EffectiveQuotaBytes,
+		QuotaUsagePct:         int32(status.QuotaUsagePct),
+	}
+
+	if m := status.Maintenance; m != nil {
+		resp.ReadOnly = true
+		resp.MaintenanceReason = m.Reason
+		resp.MaintenanceSince = timestamppb.New(m.Since)
+		if !m.Until.IsZero() {
+			resp.MaintenanceUntil = timestamppb.New(m.Until)
+		}
+	}
+
+	return connect.NewResponse(resp), nil
 }
 
 func (s *storageServer) StartStorage(
@@ -67,3 +84,24 @@ func (s *storageServer) StopStorage(
 		Status: "stopped",
 	}), nil
 }
+
+func (s *storageServer) SetStorageMaintenance(
+	ctx context.Context,
+	req *connect.Request[airgapperv1.SetStorageMaintenanceRequest],
+) (*connect.Response[airgapperv1.SetStorageMaintenanceResponse], error) {
+	var until time.Time
+	if req.Msg.Until != nil {
+		until = req.Msg.Until.AsTime()
+	}
+	if err := s.server.hostSvc.SetStorageMaintenance(req.Msg.Enabled, req.Msg.Reason, until); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	status := "writable"
+	if req.Msg.Enabled {
+		status = "read-only"
+	}
+	return connect.NewResponse(&airgapperv1.SetStorageMaintenanceResponse{
+		Status: status,
+	}), nil
+}
@@ -0,0 +1,136 @@
+// Package heartbeat pings an uptime monitor after each backup, so a
+// dead-man monitor such as healthchecks.io or an Uptime Kuma push monitor
+// alerts when backups stop succeeding, even if this node is down and can't
+// send a notification itself.
+//
+// Uptime Kuma push URLs (.../api/push/<token>) get status=up or down, the
+// message and the run's duration in milliseconds as query parameters. Any
+// other URL is pinged the healthchecks.io way: URL/start when a run begins,
+// URL on success and URL/fail on failure, with a summary as the body.
+package heartbeat
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pingTimeout bounds each ping, so a slow monitor never holds up a backup
+const pingTimeout = 10 * time.Second
+
+// maxBody is the most of a summary sent with a ping; healthchecks.io keeps
+// the first 100 kB
+const maxBody = 10_000
+
+// Pinger pings a monitor's URL about backup runs
+type Pinger struct {
+	url    *url.URL
+	kuma   bool
+	client *http.Client
+}
+
+// Parse validates a ping URL and returns a pinger for it
+func Parse(rawURL string) (*Pinger, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid ping URL %q: must be an http or https URL", rawURL)
+	}
+	return &Pinger{
+		url:    u,
+		kuma:   strings.Contains(u.Path, "/api/push/"),
+		client: &http.Client{Timeout: pingTimeout},
+	}, nil
+}
+
+// IsUptimeKuma reports whether the URL is an Uptime Kuma push URL
+func (p *Pinger) IsUptimeKuma() bool { return p.kuma }
+
+// Start reports that a backup run began. Uptime Kuma has no start signal,
+// so nothing is sent to it.
+func (p *Pinger) Start(ctx context.Context) error {
+	if p.kuma {
+		return nil
+	}
+	return p.send(ctx, p.withPath("/start"), "")
+}
+
+// Success reports a backup run that succeeded after d, with a summary
+func (p *Pinger) Success(ctx context.Context, d time.Duration, summary string) error {
+	if p.kuma {
+		return p.send(ctx, p.kumaURL("up", "Backup succeeded", d), "")
+	}
+	return p.send(ctx, p.url, withDuration(summary, d))
+}
+
+// Failure reports a backup run that failed after d
+func (p *Pinger) Failure(ctx context.Context, d time.Duration, cause error) error {
+	msg := "Backup failed"
+	if cause != nil {
+		msg += ": " + cause.Error()
+	}
+	if p.kuma {
+		return p.send(ctx, p.kumaURL("down", msg, d), "")
+	}
+	return p.send(ctx, p.withPath("/fail"), withDuration(msg, d))
+}
+
+// withPath returns the URL with suffix added to its path, keeping its query
+func (p *Pinger) withPath(suffix string) *url.URL {
+	u := *p.url
+	u.Path = strings.TrimSuffix(u.Path, "/") + suffix
+	u.RawPath = ""
+	return &u
+}
+
+// kumaURL returns the push URL carrying a status, message and duration
+func (p *Pinger) kumaURL(status, msg string, d time.Duration) *url.URL {
+	u := *p.url
+	q := u.Query()
+	q.Set("status", status)
+	q.Set("msg", truncate(msg, 250))
+	q.Set("ping", strconv.FormatInt(d.Milliseconds(), 10))
+	u.RawQuery = q.Encode()
+	return &u
+}
+
+func (p *Pinger) send(ctx context.Context, u *url.URL, body string) error {
+	method := http.MethodGet
+	var r io.Reader
+	if body != "" {
+		method = http.MethodPost
+		r = strings.NewReader(truncate(body, maxBody))
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), r)
+	if err != nil {
+		return err
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ping failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("ping failed: %s returned %s", u.Host, resp.Status)
+	}
+	return nil
+}
+
+func withDuration(msg string, d time.Duration) string {
+	return fmt.Sprintf("%s\nDuration: %s", msg, d.Round(time.Second))
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,92 @@
+package heartbeat
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ping struct {
+	method string
+	path   string
+	query  map[string]string
+	body   string
+}
+
+func monitor(t *testing.T, status int) (*httptest.Server, *[]ping) {
+	t.Helper()
+	var pings []ping
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		q := map[string]string{}
+		for k := range r.URL.Query() {
+			q[k] = r.URL.Query().Get(k)
+		}
+		pings = append(pings, ping{r.Method, r.URL.Path, q, string(body)})
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &pings
+}
+
+func TestParse(t *testing.T) {
+	for _, bad := range []string{"", "hc-ping.com/uuid", "ftp://example.com/x", "http://"} {
+		_, err := Parse(bad)
+		assert.Error(t, err, bad)
+	}
+	p, err := Parse("https://hc-ping.com/0123")
+	require.NoError(t, err)
+	assert.False(t, p.IsUptimeKuma())
+	p, err = Parse("https://kuma.example.com/api/push/AbCd?status=up")
+	require.NoError(t, err)
+	assert.True(t, p.IsUptimeKuma())
+}
+
+func TestHealthchecks(t *testing.T) {
+	srv, pings := monitor(t, http.StatusOK)
+	p, err := Parse(srv.URL + "/0123-abcd/?rid=1")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, p.Start(ctx))
+	require.NoError(t, p.Success(ctx, 90*time.Second, "12 new files"))
+	require.NoError(t, p.Failure(ctx, 5*time.Second, errors.New("repository locked")))
+
+	require.Len(t, *pings, 3)
+	assert.Equal(t, ping{http.MethodGet, "/0123-abcd/start", map[string]string{"rid": "1"}, ""}, (*pings)[0])
+	assert.Equal(t, ping{http.MethodPost, "/0123-abcd/", map[string]string{"rid": "1"}, "12 new files\nDuration: 1m30s"}, (*pings)[1])
+	assert.Equal(t, ping{http.MethodPost, "/0123-abcd/fail", map[string]string{"rid": "1"}, "Backup failed: repository locked\nDuration: 5s"}, (*pings)[2])
+}
+
+func TestUptimeKuma(t *testing.T) {
+	srv, pings := monitor(t, http.StatusOK)
+	p, err := Parse(srv.URL + "/api/push/AbCd?status=up&msg=OK&ping=")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, p.Start(ctx))
+	require.NoError(t, p.Success(ctx, 1500*time.Millisecond, "ignored"))
+	require.NoError(t, p.Failure(ctx, time.Second, errors.New("disk full")))
+
+	require.Len(t, *pings, 2, "Uptime Kuma has no start ping")
+	assert.Equal(t, ping{http.MethodGet, "/api/push/AbCd", map[string]string{"status": "up", "msg": "Backup succeeded", "ping": "1500"}, ""}, (*pings)[0])
+	assert.Equal(t, ping{http.MethodGet, "/api/push/AbCd", map[string]string{"status": "down", "msg": "Backup failed: disk full", "ping": "1000"}, ""}, (*pings)[1])
+}
+
+func TestPingErrors(t *testing.T) {
+	srv, _ := monitor(t, http.StatusNotFound)
+	p, err := Parse(srv.URL + "/unknown")
+	require.NoError(t, err)
+	assert.ErrorContains(t, p.Success(context.Background(), time.Second, ""), "404")
+
+	p, err = Parse("http://127.0.0.1:1/x")
+	require.NoError(t, err)
+	assert.Error(t, p.Start(context.Background()))
+}
@@ -0,0 +1,184 @@
+package integrity
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+// Files found corrupt, by a check or by the storage server hashing a blob
+// as it serves it, are put on a bad-file list. Every check re-verifies the
+// files on it, so the next scheduled check fails on them whatever its type,
+// and a file that is intact again, or gone, leaves the list. The bad-file
+// handler hears of each file as it is added, so the owner can repair it.
+
+// Where a bad file was found
+const (
+	BadFileSourceRead  = "read"  // Failed its hash check while being served
+	BadFileSourceCheck = "check" // Failed an integrity check
+)
+
+// BadFile is a file found corrupt, awaiting the next check
+type BadFile struct {
+	Repo       string    `json:"repo"`
+	Path       string    `json:"path"` // Relative to the repository, e.g. data/ab/ab12...
+	Source     string    `json:"source"`
+	Detail     string    `json:"detail,omitempty"`
+	DetectedAt time.Time `json:"detectedAt"`
+}
+
+// badFilesMu serializes changes to the bad-file list, which checkers for
+// the same storage directory share
+var badFilesMu sync.Mutex
+
+func (c *Checker) badFilesPath() string {
+	return filepath.Join(c.basePath, ".airgapper-bad-files.json")
+}
+
+// SetBadFileHandler sets a callback invoked with each file added to the
+// bad-file list
+func (c *Checker) SetBadFileHandler(fn func(BadFile)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onBadFile = fn
+}
+
+// ReportBadFile puts a file on the bad-file list. path is relative to the
+// repository. A file already on the list keeps its first report.
+func (c *Checker) ReportBadFile(repoName, path, source, detail string) error {
+	badFilesMu.Lock()
+	bad := c.loadBadFiles()
+	for _, f := range bad {
+		if f.Repo == repoName && f.Path == path {
+			badFilesMu.Unlock()
+			return nil
+		}
+	}
+	file := BadFile{
+		Repo:       repoName,
+		Path:       path,
+		Source:     source,
+		Detail:     detail,
+		DetectedAt: time.Now().UTC(),
+	}
+	err := c.saveBadFiles(append(bad, file))
+	badFilesMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	handler := c.onBadFile
+	c.mu.RUnlock()
+	if handler != nil {
+		handler(file)
+	}
+	return nil
+}
+
+// BadFiles returns the files on the bad-file list for a repository, or for
+// every repository if repoName is empty
+func (c *Checker) BadFiles(repoName string) []BadFile {
+	badFilesMu.Lock()
+	defer badFilesMu.Unlock()
+
+	var files []BadFile
+	for _, f := range c.loadBadFiles() {
+		if repoName == "" || f.Repo == repoName {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// checkBadFiles re-verifies the repository's files on the bad-file list,
+// adding those still corrupt to the result unless the check already found
+// them, and taking the rest off the list. It reports whether it changed the
+// result.
+func (c *Checker) checkBadFiles(repoName string, result *CheckResult) bool {
+	badFilesMu.Lock()
+	defer badFilesMu.Unlock()
+
+	bad := c.loadBadFiles()
+	kept := bad[:0]
+	changed, added := false, false
+	for _, f := range bad {
+		if f.Repo != repoName {
+			kept = append(kept, f)
+			continue
+		}
+		actual, err := hashFile(filepath.Join(c.basePath, repoName, f.Path))
+		if os.IsNotExist(err) || (err == nil && actual == filepath.Base(f.Path)) {
+			logging.Info("File on the bad-file list is no longer corrupt",
+				logging.String("repo", repoName),
+				logging.String("path", f.Path))
+			changed = true
+			continue
+		}
+		kept = append(kept, f)
+		if !reportsCorrupt(result, f.Path) {
+			result.CorruptFiles++
+			result.Errors = append(result.Errors,
+				fmt.Sprintf("CORRUPT: %s (found on %s at %s)", f.Path, f.Source, f.DetectedAt.Format(time.RFC3339)))
+			added = true
+		}
+	}
+	if added {
+		result.Passed = false
+	}
+
+	if changed {
+		if err := c.saveBadFiles(kept); err != nil {
+			logging.Warn("Failed to update the bad-file list", logging.Err(err))
+		}
+	}
+	return added
+}
+
+// reportsCorrupt reports whether the result already lists the file as
+// corrupt. Checks name files differently, but always by their hash.
+func reportsCorrupt(result *CheckResult, path string) bool {
+	name := filepath.Base(path)
+	for _, e := range result.Errors {
+		if strings.HasPrefix(e, "CORRUPT: ") && strings.Contains(e, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Checker) loadBadFiles() []BadFile {
+	data, err := os.ReadFile(c.badFilesPath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logging.Debug("failed to read bad-file list", logging.Err(err))
+		}
+		return nil
+	}
+	var bad []BadFile
+	if err := json.Unmarshal(data, &bad); err != nil {
+		logging.Debug("failed to parse bad-file list", logging.Err(err))
+	}
+	return bad
+}
+
+func (c *Checker) saveBadFiles(bad []BadFile) error {
+	if len(bad) == 0 {
+		err := os.Remove(c.badFilesPath())
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	data, err := json.MarshalIndent(bad, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.badFilesPath(), data, 0600)
+}
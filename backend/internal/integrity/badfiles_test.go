@@ -0,0 +1,59 @@
+package integrity
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBadFilesFailTheNextCheck(t *testing.T) {
+	tmpDir := t.TempDir()
+	repoPath := setupPackRepo(t, tmpDir, "repo")
+	checker, err := NewChecker(tmpDir)
+	require.NoError(t, err)
+
+	// A full check only reads data packs, so a corrupt index found on read
+	// would otherwise go unnoticed
+	index := writeContentAddressed(t, filepath.Join(repoPath, "index"), []byte("other index"), false)
+	require.NoError(t, os.WriteFile(index, []byte("rotten"), 0644))
+	rel, _ := filepath.Rel(repoPath, index)
+	require.NoError(t, checker.ReportBadFile("repo", rel, BadFileSourceRead, ""))
+	require.NoError(t, checker.ReportBadFile("repo", rel, BadFileSourceRead, "again"))
+	require.Len(t, checker.BadFiles("repo"), 1, "a file is listed once")
+	assert.Empty(t, checker.BadFiles("other"))
+
+	result, err := checker.RunCheck(context.Background(), CheckTypeFull, "repo", "")
+	require.NoError(t, err)
+	assert.False(t, result.Passed)
+	assert.Equal(t, 1, result.CorruptFiles)
+	assert.Contains(t, result.Errors[0], "CORRUPT: "+rel+" (found on read")
+	assert.False(t, checker.GetHistory(1)[0].Passed, "the history has the failure")
+
+	// Once the file is gone, e.g. after 'restic repair', it leaves the list
+	require.NoError(t, os.Remove(index))
+	result, err = checker.RunCheck(context.Background(), CheckTypeFull, "repo", "")
+	require.NoError(t, err)
+	assert.True(t, result.Passed, "errors: %v", result.Errors)
+	assert.Empty(t, checker.BadFiles(""))
+}
+
+func TestBadFilesNotCountedTwice(t *testing.T) {
+	tmpDir := t.TempDir()
+	repoPath := setupPackRepo(t, tmpDir, "repo")
+	checker, err := NewChecker(tmpDir)
+	require.NoError(t, err)
+
+	pack := writeContentAddressed(t, filepath.Join(repoPath, "data"), fakePack([]byte("blob"), 1), true)
+	require.NoError(t, os.WriteFile(pack, []byte("rotten"), 0644))
+	rel, _ := filepath.Rel(repoPath, pack)
+	require.NoError(t, checker.ReportBadFile("repo", rel, BadFileSourceRead, ""))
+
+	result, err := checker.RunCheck(context.Background(), CheckTypeFull, "repo", "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.CorruptFiles)
+	assert.Len(t, checker.BadFiles("repo"), 1, "a file still corrupt stays listed")
+}
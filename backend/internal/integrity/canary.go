@@ -0,0 +1,210 @@
+package integrity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+// Canary files are a handful of files the owner picks whose hashes are
+// recorded after each backup. Restore tests restore just those files from
+// the snapshot they were recorded against and compare the hashes, a cheap
+// end-to-end check that backups still restore byte for byte.
+
+// CanaryRecord is a canary file's hash as of a backup
+type CanaryRecord struct {
+	Path       string    `json:"path"`
+	SnapshotID string    `json:"snapshotId"`
+	SHA256     string    `json:"sha256"`
+	Size       int64     `json:"size"`
+	ModTime    time.Time `json:"modTime"`
+	RecordedAt time.Time `json:"recordedAt"`
+}
+
+// CanaryStore keeps the latest record of each canary file
+type CanaryStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewCanaryStore returns the canary store kept in dataDir
+func NewCanaryStore(dataDir string) *CanaryStore {
+	return &CanaryStore{path: filepath.Join(dataDir, "canaries.json")}
+}
+
+// List returns the records, sorted by path
+func (s *CanaryStore) List() ([]CanaryRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Record hashes the canary files after a backup and records them against
+// its snapshot. A file that can't be read keeps its previous record and is
+// returned in failed.
+func (s *CanaryStore) Record(snapshotID string, paths []string, now time.Time) (failed map[string]error, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	// Canaries no longer configured are dropped
+	byPath := make(map[string]CanaryRecord, len(paths))
+	for _, r := range records {
+		if slices.Contains(paths, r.Path) {
+			byPath[r.Path] = r
+		}
+	}
+	for _, p := range paths {
+		r, err := hashCanary(p)
+		if err != nil {
+			if failed == nil {
+				failed = map[string]error{}
+			}
+			failed[p] = err
+			continue
+		}
+		r.SnapshotID, r.RecordedAt = snapshotID, now
+		byPath[p] = r
+	}
+
+	records = records[:0]
+	for _, r := range byPath {
+		records = append(records, r)
+	}
+	slices.SortFunc(records, func(a, b CanaryRecord) int { return strings.Compare(a.Path, b.Path) })
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return failed, err
+	}
+	return failed, os.WriteFile(s.path, data, 0600)
+}
+
+func (s *CanaryStore) load() ([]CanaryRecord, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var records []CanaryRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("parse canary records: %w", err)
+	}
+	return records, nil
+}
+
+// hashCanary hashes a canary file, checking it didn't change while it was
+// read
+func hashCanary(path string) (CanaryRecord, error) {
+	before, err := os.Stat(path)
+	if err != nil {
+		return CanaryRecord{}, err
+	}
+	if !before.Mode().IsRegular() {
+		return CanaryRecord{}, fmt.Errorf("not a regular file")
+	}
+	hash, err := hashFile(path)
+	if err != nil {
+		return CanaryRecord{}, err
+	}
+	after, err := os.Stat(path)
+	if err != nil {
+		return CanaryRecord{}, err
+	}
+	if after.Size() != before.Size() || !after.ModTime().Equal(before.ModTime()) {
+		return CanaryRecord{}, fmt.Errorf("changed while it was hashed")
+	}
+	return CanaryRecord{Path: path, SHA256: hash, Size: before.Size(), ModTime: before.ModTime()}, nil
+}
+
+// checkCanaries restores the configured canary files from the snapshots
+// their hashes were recorded against and compares them. Canaries with no
+// record yet are left for after the next backup.
+func (t *RestoreTester) checkCanaries(ctx context.Context, record *RestoreTestRecord) {
+	if len(t.config.CanaryFiles) == 0 {
+		return
+	}
+	records, err := t.canaries.List()
+	if err != nil {
+		record.Errors = append(record.Errors, fmt.Sprintf("canaries: %v", err))
+		return
+	}
+	bySnapshot := map[string][]CanaryRecord{}
+	for _, r := range records {
+		if slices.Contains(t.config.CanaryFiles, r.Path) {
+			bySnapshot[r.SnapshotID] = append(bySnapshot[r.SnapshotID], r)
+		}
+	}
+	if len(bySnapshot) == 0 {
+		logging.Debug("No canary files recorded yet; they are recorded after the next backup")
+		return
+	}
+
+	target, err := os.MkdirTemp("", "airgapper-canaries-")
+	if err != nil {
+		record.Errors = append(record.Errors, fmt.Sprintf("canaries: create temp dir: %v", err))
+		return
+	}
+	defer func() { _ = os.RemoveAll(target) }()
+
+	for snapshotID, canaries := range bySnapshot {
+		dir := filepath.Join(target, snapshotID)
+		paths := make([]string, len(canaries))
+		for i, c := range canaries {
+			paths[i] = c.Path
+		}
+		if err := t.restorer.RestoreFiles(ctx, snapshotID, dir, paths); err != nil {
+			record.Errors = append(record.Errors, fmt.Sprintf("canaries: restore from %s: %v", shortSnapshotID(snapshotID), err))
+			continue
+		}
+		for _, c := range canaries {
+			verifyCanary(record, c, filepath.Join(dir, c.Path))
+		}
+	}
+}
+
+// verifyCanary compares a restored canary file with its record. A file
+// restored with a different mtime changed between the backup and its
+// hashing, so its record proves nothing and it is skipped.
+func verifyCanary(record *RestoreTestRecord, c CanaryRecord, restored string) {
+	info, err := os.Stat(restored)
+	if err == nil && !info.ModTime().Equal(c.ModTime) {
+		logging.Warn("Canary file changed during its backup, skipping it until the next one", logging.String("path", c.Path))
+		return
+	}
+	record.CanariesChecked++
+	if err != nil {
+		record.Mismatches = append(record.Mismatches, fmt.Sprintf("canary %s: not restored", c.Path))
+		return
+	}
+	hash, err := hashFile(restored)
+	if err != nil {
+		record.Errors = append(record.Errors, fmt.Sprintf("canary %s: hash failed", c.Path))
+		return
+	}
+	if info.Size() != c.Size || hash != c.SHA256 {
+		record.Mismatches = append(record.Mismatches, fmt.Sprintf("canary %s: content hash differs from backup time", c.Path))
+		return
+	}
+	record.CanariesVerified++
+}
+
+func shortSnapshotID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
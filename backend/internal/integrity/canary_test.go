@@ -0,0 +1,80 @@
+package integrity
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanaryStore_Record(t *testing.T) {
+	dir := t.TempDir()
+	canary := filepath.Join(dir, "canary.txt")
+	require.NoError(t, os.WriteFile(canary, []byte("tweet tweet"), 0644))
+	store := NewCanaryStore(t.TempDir())
+	now := time.Now()
+
+	failed, err := store.Record("snap1", []string{canary, filepath.Join(dir, "missing"), dir}, now)
+	require.NoError(t, err)
+	assert.Len(t, failed, 2, "missing files and directories can't be canaries")
+
+	records, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "snap1", records[0].SnapshotID)
+	assert.Equal(t, int64(11), records[0].Size)
+	assert.NotEmpty(t, records[0].SHA256)
+
+	t.Run("an unreadable canary keeps its record", func(t *testing.T) {
+		require.NoError(t, os.Rename(canary, canary+".moved"))
+		defer func() { require.NoError(t, os.Rename(canary+".moved", canary)) }()
+		failed, err := store.Record("snap2", []string{canary}, now)
+		require.NoError(t, err)
+		assert.Contains(t, failed, canary)
+		records, err := store.List()
+		require.NoError(t, err)
+		assert.Equal(t, "snap1", records[0].SnapshotID)
+	})
+
+	t.Run("canaries no longer configured are dropped", func(t *testing.T) {
+		_, err := store.Record("snap3", nil, now)
+		require.NoError(t, err)
+		records, err := store.List()
+		require.NoError(t, err)
+		assert.Empty(t, records)
+	})
+}
+
+func TestRestoreTester_Canaries(t *testing.T) {
+	restorer := &fakeRestorer{nodes: setupSourceFiles(t, 2)}
+	canary := filepath.Join(t.TempDir(), "canary.txt")
+	require.NoError(t, os.WriteFile(canary, []byte("tweet tweet"), 0644))
+
+	cfg := DefaultRestoreTestConfig()
+	cfg.CanaryFiles = []string{canary}
+	dataDir := t.TempDir()
+	tester := NewRestoreTester(restorer, dataDir, cfg)
+
+	record, err := tester.Run(context.Background())
+	require.NoError(t, err)
+	assert.True(t, record.Passed)
+	assert.Zero(t, record.CanariesChecked, "no canary is recorded before a backup")
+
+	_, err = NewCanaryStore(dataDir).Record("snap1", cfg.CanaryFiles, time.Now())
+	require.NoError(t, err)
+	record, err = tester.Run(context.Background())
+	require.NoError(t, err)
+	assert.True(t, record.Passed)
+	assert.Equal(t, 1, record.CanariesChecked)
+	assert.Equal(t, 1, record.CanariesVerified)
+
+	restorer.corrupt = true
+	record, err = tester.Run(context.Background())
+	require.NoError(t, err)
+	assert.False(t, record.Passed)
+	assert.Contains(t, record.Mismatches, "canary "+canary+": content hash differs from backup time")
+}
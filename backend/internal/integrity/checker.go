@@ -22,6 +22,15 @@ type Checker struct {
 	// Verification records (owner-signed)
 	records     map[string]*VerificationRecord // keyed by snapshot ID
 	recordsPath string
+
+	// Optional restic check for CheckTypeRestic
+	repoChecker RepoChecker
+
+	// Options for CheckTypeIncremental
+	incremental IncrementalOptions
+
+	// Called with each file added to the bad-file list
+	onBadFile func(BadFile)
 }
 
 // NewChecker creates a new integrity checker
@@ -35,6 +44,7 @@ func NewChecker(basePath string) (*Checker, error) {
 		maxHistory:  100,
 		records:     make(map[string]*VerificationRecord),
 		recordsPath: filepath.Join(basePath, ".airgapper-verification-records.json"),
+		incremental: DefaultIncrementalOptions(),
 	}
 
 	// Load existing records
@@ -83,6 +93,8 @@ func (c *Checker) CheckDataIntegrity(repoName string) (*CheckResult, error) {
 			result.CorruptFiles++
 			result.Errors = append(result.Errors,
 				fmt.Sprintf("CORRUPT: %s (expected hash doesn't match content)", info.Name()))
+			rel, _ := filepath.Rel(result.RepoPath, path)
+			result.CorruptPaths = append(result.CorruptPaths, filepath.ToSlash(rel))
 		}
 
 		return nil
@@ -2,12 +2,17 @@
 package integrity
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/lcrostarosa/airgapper/backend/internal/events"
 	"github.com/lcrostarosa/airgapper/backend/internal/logging"
 )
 
@@ -19,9 +24,27 @@ type VerificationConfig struct {
 	// Interval between verification checks (e.g., "1h", "24h", "168h" for weekly)
 	Interval string `json:"interval"`
 
-	// CheckType: "quick" (file count/merkle only) or "full" (content hash verification)
+	// CheckType: "quick" (file count/merkle only), "full" (content hash verification),
+	// "packs" (full plus index/snapshot/key files and pack structure) or
+	// "restic" (packs plus restic check; needs the repository password) or
+	// "incremental" (content hash of new files and files due for a recheck)
 	CheckType string `json:"checkType"`
 
+	// RecheckDays re-verifies files not verified within this many days
+	// (incremental checks only; default 30)
+	RecheckDays int `json:"recheckDays,omitempty"`
+
+	// MaxBytesPerSecond limits read throughput of incremental checks (0 = unlimited)
+	MaxBytesPerSecond int64 `json:"maxBytesPerSecond,omitempty"`
+
+	// BlackoutWindows are daily local time ranges (e.g. the backup window)
+	// during which scheduled checks are deferred
+	BlackoutWindows []BlackoutWindow `json:"blackoutWindows,omitempty"`
+
+	// DeferInterval is how long a deferred check waits before retrying
+	// (e.g., "15m"; default 15m)
+	DeferInterval string `json:"deferInterval,omitempty"`
+
 	// RepoName is the repository to verify
 	RepoName string `json:"repoName,omitempty"`
 
@@ -49,7 +72,7 @@ func DefaultVerificationConfig() *VerificationConfig {
 	return &VerificationConfig{
 		Enabled:           false,
 		Interval:          "6h", // Every 6 hours by default
-		CheckType:         "quick",
+		CheckType:         CheckTypeQuick,
 		AlertOnCorruption: true,
 	}
 }
@@ -64,8 +87,33 @@ func (c *VerificationConfig) ParseInterval() (time.Duration, error) {
 
 // Validate checks that the configuration is valid
 func (c *VerificationConfig) Validate() error {
-	if c.CheckType != "" && c.CheckType != "quick" && c.CheckType != "full" {
-		return fmt.Errorf("invalid checkType: must be 'quick' or 'full'")
+	switch c.CheckType {
+	case "", CheckTypeQuick, CheckTypeFull, CheckTypePacks, CheckTypeRestic, CheckTypeIncremental:
+	default:
+		return fmt.Errorf("invalid checkType: must be 'quick', 'full', 'packs', 'restic' or 'incremental'")
+	}
+
+	if c.RecheckDays < 0 {
+		return fmt.Errorf("recheckDays must not be negative")
+	}
+	if c.MaxBytesPerSecond < 0 {
+		return fmt.Errorf("maxBytesPerSecond must not be negative")
+	}
+
+	for _, w := range c.BlackoutWindows {
+		if err := w.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.DeferInterval != "" {
+		d, err := time.ParseDuration(c.DeferInterval)
+		if err != nil {
+			return fmt.Errorf("invalid deferInterval: %w", err)
+		}
+		if d < time.Minute {
+			return fmt.Errorf("deferInterval must be at least 1 minute")
+		}
 	}
 
 	if c.Interval != "" {
@@ -84,6 +132,24 @@ func (c *VerificationConfig) Validate() error {
 	return nil
 }
 
+// IncrementalOptions returns the incremental check options for this config
+func (c *VerificationConfig) IncrementalOptions() IncrementalOptions {
+	opts := DefaultIncrementalOptions()
+	if c.RecheckDays > 0 {
+		opts.RecheckAfter = time.Duration(c.RecheckDays) * 24 * time.Hour
+	}
+	opts.MaxBytesPerSecond = c.MaxBytesPerSecond
+	return opts
+}
+
+// ParseDeferInterval parses the defer interval, defaulting to DefaultDeferInterval
+func (c *VerificationConfig) ParseDeferInterval() time.Duration {
+	if d, err := time.ParseDuration(c.DeferInterval); err == nil && d > 0 {
+		return d
+	}
+	return DefaultDeferInterval
+}
+
 // ConfigManager handles loading/saving verification configuration
 type ConfigManager struct {
 	basePath   string
@@ -169,6 +235,12 @@ type ManagedScheduledChecker struct {
 	checker       *Checker
 	configManager *ConfigManager
 	scheduler     *ScheduledChecker
+
+	activityMu sync.Mutex
+	activity   []ActivityFunc
+
+	// Activity feed failed checks are published to (optional)
+	eventLog atomic.Pointer[events.Log]
 }
 
 // NewManagedScheduledChecker creates a managed scheduled checker
@@ -233,11 +305,15 @@ func (msc *ManagedScheduledChecker) startScheduler() error {
 		return err
 	}
 
+	msc.checker.SetIncrementalOptions(config.IncrementalOptions())
 	msc.scheduler = NewScheduledChecker(msc.checker, config.RepoName, interval)
+	msc.scheduler.SetCheckType(config.CheckType, config.SnapshotID)
+	msc.scheduler.SetDeferral(msc.shouldDefer, config.ParseDeferInterval())
 
 	// Set up the callback to record results and trigger alerts
 	msc.scheduler.SetCorruptionCallback(func(result *CheckResult) {
 		_ = msc.configManager.RecordCheck(result)
+		msc.publishFailure(result)
 
 		if config.AlertOnCorruption {
 			msc.sendAlert(result)
@@ -257,6 +333,32 @@ func (msc *ManagedScheduledChecker) startScheduler() error {
 	return nil
 }
 
+// AddActivitySource registers a source consulted before each scheduled
+// check; checks are deferred while any source reports busy
+func (msc *ManagedScheduledChecker) AddActivitySource(fn ActivityFunc) {
+	msc.activityMu.Lock()
+	defer msc.activityMu.Unlock()
+	msc.activity = append(msc.activity, fn)
+}
+
+// shouldDefer reports whether a scheduled check due at now should wait
+func (msc *ManagedScheduledChecker) shouldDefer(now time.Time) (bool, string) {
+	if w, ok := inBlackout(msc.configManager.Get().BlackoutWindows, now); ok {
+		return true, "blackout window " + w.String()
+	}
+
+	msc.activityMu.Lock()
+	sources := append([]ActivityFunc(nil), msc.activity...)
+	msc.activityMu.Unlock()
+
+	for _, fn := range sources {
+		if busy, reason := fn(); busy {
+			return true, reason
+		}
+	}
+	return false, ""
+}
+
 func (msc *ManagedScheduledChecker) restartScheduler() {
 	msc.Stop()
 	_ = msc.Start()
@@ -279,28 +381,43 @@ func (msc *ManagedScheduledChecker) sendAlert(result *CheckResult) {
 	}
 }
 
+// SetEventLog sets the activity feed failed checks are published to
+func (msc *ManagedScheduledChecker) SetEventLog(l *events.Log) {
+	msc.eventLog.Store(l)
+}
+
+// publishFailure records a failed check in the activity feed
+func (msc *ManagedScheduledChecker) publishFailure(result *CheckResult) {
+	data := map[string]string{
+		"corrupt_files": strconv.Itoa(result.CorruptFiles),
+		"missing_files": strconv.Itoa(result.MissingFiles),
+	}
+	if result.CheckType != "" {
+		data["check_type"] = result.CheckType
+	}
+	msc.eventLog.Load().Publish(events.Event{
+		Type:    events.IntegrityFailed,
+		Source:  events.SourceIntegrity,
+		Subject: msc.configManager.Get().RepoName,
+		Message: fmt.Sprintf("Integrity check found %d corrupt and %d missing files", result.CorruptFiles, result.MissingFiles),
+		Data:    data,
+	})
+}
+
 // RunManualCheck performs a manual integrity check
 func (msc *ManagedScheduledChecker) RunManualCheck(checkType string) (*CheckResult, error) {
 	config := msc.configManager.Get()
-	repoName := config.RepoName
-
-	var result *CheckResult
-	var err error
-
-	switch checkType {
-	case "full":
-		result, err = msc.checker.CheckDataIntegrity(repoName)
-	case "quick":
-		result, err = msc.checker.QuickCheck(repoName, config.SnapshotID)
-	default:
-		result, err = msc.checker.CheckDataIntegrity(repoName)
-	}
+	msc.checker.SetIncrementalOptions(config.IncrementalOptions())
 
+	result, err := msc.checker.RunCheck(context.Background(), checkType, config.RepoName, config.SnapshotID)
 	if err != nil {
 		return nil, err
 	}
 
 	_ = msc.configManager.RecordCheck(result)
+	if !result.Passed {
+		msc.publishFailure(result)
+	}
 	return result, nil
 }
 
@@ -3,11 +3,14 @@ package integrity
 import (
 	"os"
 	"path/filepath"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/events"
 )
 
 func TestVerificationConfig_Validate(t *testing.T) {
@@ -35,6 +38,15 @@ func TestVerificationConfig_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid packs check",
+			config: &VerificationConfig{
+				Enabled:   true,
+				Interval:  "24h",
+				CheckType: "packs",
+			},
+			wantErr: false,
+		},
 		{
 			name: "invalid check type",
 			config: &VerificationConfig{
@@ -264,6 +276,7 @@ func TestManagedScheduledChecker_ConfigPersistence(t *testing.T) {
 
 	err = msc.UpdateConfig(newCfg)
 	require.NoError(t, err, "failed to update config")
+	defer msc.Stop()
 
 	// Verify config file exists
 	configPath := filepath.Join(tmpDir, ".airgapper-verification-config.json")
@@ -308,6 +321,34 @@ func TestManagedScheduledChecker_RunManualCheck(t *testing.T) {
 	assert.Equal(t, 5, loadedCfg.LastResult.TotalFiles, "expected 5 total files")
 }
 
+func TestManagedScheduledChecker_FailureEvent(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupTestRepo(t, tmpDir, "testrepo")
+	dataPath := filepath.Join(tmpDir, "testrepo", "data")
+	subdirs, err := os.ReadDir(dataPath)
+	require.NoError(t, err)
+	files, err := os.ReadDir(filepath.Join(dataPath, subdirs[0].Name()))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dataPath, subdirs[0].Name(), files[0].Name()), []byte("CORRUPTED DATA"), 0644))
+
+	msc, err := NewManagedScheduledChecker(tmpDir)
+	require.NoError(t, err)
+	require.NoError(t, msc.UpdateConfig(&VerificationConfig{Interval: "1h", CheckType: "full", RepoName: "testrepo"}))
+	log := events.Open(t.TempDir())
+	msc.SetEventLog(log)
+
+	result, err := msc.RunManualCheck("full")
+	require.NoError(t, err)
+	require.False(t, result.Passed)
+
+	list, _, err := log.Since(0, 0, events.Filter{})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, events.IntegrityFailed, list[0].Type)
+	assert.Equal(t, "testrepo", list[0].Subject)
+	assert.Equal(t, strconv.Itoa(result.CorruptFiles), list[0].Data["corrupt_files"])
+}
+
 func TestManagedScheduledChecker_StartStop(t *testing.T) {
 	tmpDir := t.TempDir()
 	setupTestRepo(t, tmpDir, "testrepo")
@@ -0,0 +1,82 @@
+package integrity
+
+import (
+	"fmt"
+	"time"
+)
+
+// Scheduled checks read the whole repository and compete with backups and
+// restores for disk IO. Before each scheduled check the checker consults
+// blackout windows and activity sources and defers the check while either
+// says the disk is busy.
+
+// DefaultDeferInterval is how long a deferred check waits before trying again
+const DefaultDeferInterval = 15 * time.Minute
+
+// BlackoutWindow is a daily local time range during which scheduled checks
+// don't start. End before Start wraps past midnight (e.g. 22:00-06:00).
+type BlackoutWindow struct {
+	Start string `json:"start"` // "HH:MM"
+	End   string `json:"end"`   // "HH:MM"
+}
+
+// Validate checks that the window's times are valid
+func (w BlackoutWindow) Validate() error {
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return fmt.Errorf("invalid blackout start %q: %w", w.Start, err)
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return fmt.Errorf("invalid blackout end %q: %w", w.End, err)
+	}
+	if start == end {
+		return fmt.Errorf("blackout window %s-%s is empty", w.Start, w.End)
+	}
+	return nil
+}
+
+// Contains reports whether t falls inside the window
+func (w BlackoutWindow) Contains(t time.Time) bool {
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return false
+	}
+
+	now := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	if start < end {
+		return now >= start && now < end
+	}
+	return now >= start || now < end
+}
+
+func (w BlackoutWindow) String() string {
+	return w.Start + "-" + w.End
+}
+
+// parseClock parses "HH:MM" into an offset from midnight
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// ActivityFunc reports whether other work is using the disk (a backup,
+// restore, or storage transfers) and describes it
+type ActivityFunc func() (busy bool, reason string)
+
+// inBlackout returns the first blackout window containing t
+func inBlackout(windows []BlackoutWindow, t time.Time) (BlackoutWindow, bool) {
+	for _, w := range windows {
+		if w.Contains(t) {
+			return w, true
+		}
+	}
+	return BlackoutWindow{}, false
+}
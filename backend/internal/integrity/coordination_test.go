@@ -0,0 +1,85 @@
+package integrity
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlackoutWindow_Contains(t *testing.T) {
+	at := func(hh, mm int) time.Time {
+		return time.Date(2024, 1, 1, hh, mm, 0, 0, time.Local)
+	}
+
+	day := BlackoutWindow{Start: "01:30", End: "04:00"}
+	assert.True(t, day.Contains(at(1, 30)))
+	assert.True(t, day.Contains(at(3, 59)))
+	assert.False(t, day.Contains(at(4, 0)))
+	assert.False(t, day.Contains(at(12, 0)))
+
+	overnight := BlackoutWindow{Start: "22:00", End: "06:00"}
+	assert.True(t, overnight.Contains(at(23, 0)))
+	assert.True(t, overnight.Contains(at(2, 0)))
+	assert.False(t, overnight.Contains(at(12, 0)))
+}
+
+func TestBlackoutWindow_Validate(t *testing.T) {
+	assert.NoError(t, BlackoutWindow{Start: "22:00", End: "06:00"}.Validate())
+	assert.Error(t, BlackoutWindow{Start: "25:00", End: "06:00"}.Validate())
+	assert.Error(t, BlackoutWindow{Start: "02:00", End: "02:00"}.Validate())
+
+	cfg := DefaultVerificationConfig()
+	cfg.BlackoutWindows = []BlackoutWindow{{Start: "2am", End: "4am"}}
+	assert.Error(t, cfg.Validate())
+}
+
+func TestManagedScheduledChecker_DefersWhileBusy(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupTestRepo(t, tmpDir, "testrepo")
+
+	msc, err := NewManagedScheduledChecker(tmpDir)
+	require.NoError(t, err)
+
+	var busy atomic.Bool
+	busy.Store(true)
+	msc.AddActivitySource(func() (bool, string) { return busy.Load(), "backup running" })
+
+	deferred, reason := msc.shouldDefer(time.Now())
+	assert.True(t, deferred)
+	assert.Equal(t, "backup running", reason)
+
+	busy.Store(false)
+	deferred, _ = msc.shouldDefer(time.Now())
+	assert.False(t, deferred)
+
+	now := time.Now()
+	cfg := DefaultVerificationConfig()
+	cfg.BlackoutWindows = []BlackoutWindow{{
+		Start: now.Add(-time.Minute).Format("15:04"),
+		End:   now.Add(time.Hour).Format("15:04"),
+	}}
+	require.NoError(t, msc.configManager.Update(cfg))
+
+	deferred, reason = msc.shouldDefer(now)
+	assert.True(t, deferred)
+	assert.Contains(t, reason, "blackout window")
+}
+
+func TestScheduledChecker_DeferredCheckDoesNotRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupTestRepo(t, tmpDir, "testrepo")
+
+	checker, err := NewChecker(tmpDir)
+	require.NoError(t, err)
+
+	sc := NewScheduledChecker(checker, "testrepo", time.Hour)
+	sc.SetDeferral(func(time.Time) (bool, string) { return true, "busy" }, time.Hour)
+	sc.Start()
+	time.Sleep(50 * time.Millisecond)
+	sc.Stop()
+
+	assert.Empty(t, checker.GetHistory(0), "deferred check should not have run")
+}
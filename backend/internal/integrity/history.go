@@ -11,6 +11,19 @@ func (c *Checker) addToHistory(result CheckResult) {
 	}
 }
 
+// replaceInHistory replaces the recorded result of the same check
+func (c *Checker) replaceInHistory(result CheckResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := len(c.checkHistory) - 1; i >= 0; i-- {
+		if c.checkHistory[i].Timestamp.Equal(result.Timestamp) && c.checkHistory[i].RepoPath == result.RepoPath {
+			c.checkHistory[i] = result
+			return
+		}
+	}
+}
+
 // GetHistory returns recent check results
 func (c *Checker) GetHistory(limit int) []CheckResult {
 	c.mu.RLock()
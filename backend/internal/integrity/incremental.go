@@ -0,0 +1,295 @@
+package integrity
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+// A full content check of a multi-terabyte repository can take hours. An
+// incremental check remembers when each file was last verified, only
+// re-hashes files that are new or haven't been verified for a while, and
+// persists its progress so an interrupted run resumes where it stopped.
+
+// IncrementalOptions controls an incremental check
+type IncrementalOptions struct {
+	// RecheckAfter re-verifies files last verified longer ago than this.
+	// Zero re-verifies only new files.
+	RecheckAfter time.Duration
+
+	// MaxBytesPerSecond limits read throughput (0 = unlimited)
+	MaxBytesPerSecond int64
+
+	// SaveEvery persists progress after this many verified files
+	SaveEvery int
+}
+
+// DefaultIncrementalOptions returns sensible defaults
+func DefaultIncrementalOptions() IncrementalOptions {
+	return IncrementalOptions{
+		RecheckAfter: 30 * 24 * time.Hour,
+		SaveEvery:    100,
+	}
+}
+
+// fileState records the last successful verification of a file
+type fileState struct {
+	Size       int64     `json:"size"`
+	VerifiedAt time.Time `json:"verifiedAt"`
+}
+
+// incrementalState is the persisted progress of incremental checks
+type incrementalState struct {
+	Files map[string]fileState `json:"files"` // keyed by path relative to the repo
+}
+
+// SetIncrementalOptions sets the options used by CheckTypeIncremental
+func (c *Checker) SetIncrementalOptions(opts IncrementalOptions) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.incremental = opts
+}
+
+func (c *Checker) statePath(repoName string) string {
+	return filepath.Join(c.basePath, ".airgapper-integrity-state-"+strings.ReplaceAll(repoName, string(filepath.Separator), "_")+".json")
+}
+
+// CheckIncremental verifies content-addressed files that are new or due for
+// re-verification. If ctx is cancelled the progress made so far is saved,
+// the result is marked Incomplete, and the next run continues from there.
+func (c *Checker) CheckIncremental(ctx context.Context, repoName string, opts IncrementalOptions) (*CheckResult, error) {
+	start := time.Now()
+	repoPath := filepath.Join(c.basePath, repoName)
+	result := &CheckResult{
+		Timestamp: start,
+		RepoPath:  repoPath,
+	}
+
+	if opts.SaveEvery <= 0 {
+		opts.SaveEvery = DefaultIncrementalOptions().SaveEvery
+	}
+
+	state := c.loadIncrementalState(repoName)
+	seen := make(map[string]bool, len(state.Files))
+	limiter := newRateLimiter(opts.MaxBytesPerSecond)
+	sinceSave := 0
+
+	for _, dir := range contentAddressedDirs {
+		err := filepath.Walk(filepath.Join(repoPath, dir), func(path string, info os.FileInfo, err error) error {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if err != nil {
+				if !os.IsNotExist(err) {
+					result.Errors = append(result.Errors, fmt.Sprintf("walk error: %v", err))
+				}
+				return nil
+			}
+			if info.IsDir() {
+				return nil
+			}
+
+			rel, _ := filepath.Rel(repoPath, path)
+			seen[rel] = true
+			result.TotalFiles++
+
+			if prev, ok := state.Files[rel]; ok && prev.Size == info.Size() &&
+				(opts.RecheckAfter <= 0 || start.Sub(prev.VerifiedAt) < opts.RecheckAfter) {
+				result.SkippedFiles++
+				return nil
+			}
+
+			actualHash, err := hashFileLimited(ctx, path, limiter)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				result.Errors = append(result.Errors, fmt.Sprintf("hash error %s: %v", rel, err))
+				return nil
+			}
+			result.CheckedFiles++
+
+			if actualHash != info.Name() {
+				result.CorruptFiles++
+				result.Errors = append(result.Errors,
+					fmt.Sprintf("CORRUPT: %s (expected hash doesn't match content)", rel))
+				result.CorruptPaths = append(result.CorruptPaths, filepath.ToSlash(rel))
+				delete(state.Files, rel)
+				return nil
+			}
+
+			state.Files[rel] = fileState{Size: info.Size(), VerifiedAt: time.Now()}
+			if sinceSave++; sinceSave >= opts.SaveEvery {
+				sinceSave = 0
+				if err := c.saveIncrementalState(repoName, state); err != nil {
+					logging.Debug("failed to save integrity progress", logging.Err(err))
+				}
+			}
+			return nil
+		})
+
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			result.Incomplete = true
+			break
+		}
+	}
+
+	// Forget files that no longer exist (only known after a complete walk)
+	if !result.Incomplete {
+		for rel := range state.Files {
+			if !seen[rel] {
+				delete(state.Files, rel)
+			}
+		}
+	}
+
+	if err := c.saveIncrementalState(repoName, state); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("save progress: %v", err))
+	}
+
+	result.Duration = time.Since(start).String()
+	result.Passed = result.CorruptFiles == 0 && result.MissingFiles == 0
+
+	c.addToHistory(*result)
+
+	return result, nil
+}
+
+// Rebaseline brings incremental progress up to date after a prune rewrote
+// the repository: the packs it wrote are verified, those it removed are
+// forgotten, and so are removed files on the bad-file list. Files verified
+// before the prune aren't read again.
+func (c *Checker) Rebaseline(ctx context.Context, repoName string) (*CheckResult, error) {
+	c.mu.RLock()
+	opts := c.incremental
+	c.mu.RUnlock()
+	opts.RecheckAfter = 0
+
+	result, err := c.CheckIncremental(ctx, repoName, opts)
+	if err != nil {
+		return nil, err
+	}
+	c.finishCheck(repoName, result)
+	result.CheckType = CheckTypeIncremental
+	return result, nil
+}
+
+// ResetIncrementalState discards incremental progress so the next
+// incremental check verifies every file
+func (c *Checker) ResetIncrementalState(repoName string) error {
+	err := os.Remove(c.statePath(repoName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (c *Checker) loadIncrementalState(repoName string) *incrementalState {
+	state := &incrementalState{}
+	data, err := os.ReadFile(c.statePath(repoName))
+	if err == nil {
+		if err := json.Unmarshal(data, state); err != nil {
+			logging.Debug("failed to parse integrity progress", logging.Err(err))
+		}
+	} else if !os.IsNotExist(err) {
+		logging.Debug("failed to read integrity progress", logging.Err(err))
+	}
+	if state.Files == nil {
+		state.Files = make(map[string]fileState)
+	}
+	return state
+}
+
+func (c *Checker) saveIncrementalState(repoName string, state *incrementalState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	path := c.statePath(repoName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// --- Rate limiting ---
+
+// rateLimiter spreads reads so throughput stays under a byte rate
+type rateLimiter struct {
+	bytesPerSecond int64
+	start          time.Time
+	bytes          int64
+}
+
+func newRateLimiter(bytesPerSecond int64) *rateLimiter {
+	return &rateLimiter{bytesPerSecond: bytesPerSecond, start: time.Now()}
+}
+
+// wait accounts for n bytes read and sleeps until they fit within the rate
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	if l.bytesPerSecond <= 0 {
+		return nil
+	}
+	l.bytes += int64(n)
+	due := l.start.Add(time.Duration(float64(l.bytes) / float64(l.bytesPerSecond) * float64(time.Second)))
+	delay := time.Until(due)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// limitedReader reads through a rate limiter
+type limitedReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rateLimiter
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	if err := lr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := lr.r.Read(p)
+	if n > 0 {
+		if waitErr := lr.limiter.wait(lr.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// hashFileLimited computes the SHA256 hash of a file under a rate limit
+func hashFileLimited(ctx context.Context, path string, limiter *rateLimiter) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, &limitedReader{ctx: ctx, r: f, limiter: limiter}); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,142 @@
+package integrity
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckIncremental_SkipsRecentlyVerified(t *testing.T) {
+	tmpDir := t.TempDir()
+	repoPath := setupPackRepo(t, tmpDir, "repo")
+
+	checker, err := NewChecker(tmpDir)
+	require.NoError(t, err)
+	opts := DefaultIncrementalOptions()
+
+	result, err := checker.CheckIncremental(context.Background(), "repo", opts)
+	require.NoError(t, err)
+	assert.True(t, result.Passed, "errors: %v", result.Errors)
+	assert.Equal(t, 6, result.CheckedFiles)
+	assert.Equal(t, 0, result.SkippedFiles)
+
+	// A new file is the only one verified on the next run
+	writeContentAddressed(t, filepath.Join(repoPath, "data"), fakePack([]byte("new"), 1), true)
+
+	result, err = checker.CheckIncremental(context.Background(), "repo", opts)
+	require.NoError(t, err)
+	assert.Equal(t, 7, result.TotalFiles)
+	assert.Equal(t, 1, result.CheckedFiles)
+	assert.Equal(t, 6, result.SkippedFiles)
+}
+
+func TestCheckIncremental_RecheckAfter(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupPackRepo(t, tmpDir, "repo")
+
+	checker, err := NewChecker(tmpDir)
+	require.NoError(t, err)
+
+	_, err = checker.CheckIncremental(context.Background(), "repo", DefaultIncrementalOptions())
+	require.NoError(t, err)
+
+	time.Sleep(10 * time.Millisecond)
+	result, err := checker.CheckIncremental(context.Background(), "repo", IncrementalOptions{RecheckAfter: time.Millisecond})
+	require.NoError(t, err)
+	assert.Equal(t, 6, result.CheckedFiles, "all files should be due for a recheck")
+}
+
+func TestCheckIncremental_ResumesAfterCancel(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupPackRepo(t, tmpDir, "repo")
+
+	checker, err := NewChecker(tmpDir)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := checker.CheckIncremental(ctx, "repo", DefaultIncrementalOptions())
+	require.NoError(t, err)
+	assert.True(t, result.Incomplete)
+	assert.Equal(t, 0, result.CheckedFiles)
+
+	// Progress survives a restart
+	checker2, err := NewChecker(tmpDir)
+	require.NoError(t, err)
+	result, err = checker2.CheckIncremental(context.Background(), "repo", DefaultIncrementalOptions())
+	require.NoError(t, err)
+	assert.False(t, result.Incomplete)
+	assert.Equal(t, 6, result.CheckedFiles)
+
+	result, err = checker2.RunCheck(context.Background(), CheckTypeIncremental, "repo", "")
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.CheckedFiles)
+	assert.Equal(t, CheckTypeIncremental, result.CheckType)
+}
+
+func TestCheckIncremental_CorruptFileRechecked(t *testing.T) {
+	tmpDir := t.TempDir()
+	repoPath := setupPackRepo(t, tmpDir, "repo")
+
+	checker, err := NewChecker(tmpDir)
+	require.NoError(t, err)
+
+	// Verify once, then corrupt a file keeping its size
+	_, err = checker.CheckIncremental(context.Background(), "repo", DefaultIncrementalOptions())
+	require.NoError(t, err)
+
+	entries, err := os.ReadDir(filepath.Join(repoPath, "keys"))
+	require.NoError(t, err)
+	keyPath := filepath.Join(repoPath, "keys", entries[0].Name())
+	require.NoError(t, os.WriteFile(keyPath, []byte("KEY"), 0644))
+
+	require.NoError(t, checker.ResetIncrementalState("repo"))
+	for i := 0; i < 2; i++ {
+		result, err := checker.CheckIncremental(context.Background(), "repo", DefaultIncrementalOptions())
+		require.NoError(t, err)
+		assert.False(t, result.Passed, "corrupt file must keep failing (run %d)", i)
+	}
+}
+
+func TestRebaseline(t *testing.T) {
+	tmpDir := t.TempDir()
+	repoPath := setupPackRepo(t, tmpDir, "repo")
+	checker, err := NewChecker(tmpDir)
+	require.NoError(t, err)
+	checker.SetIncrementalOptions(IncrementalOptions{RecheckAfter: time.Nanosecond})
+
+	// A pack on the bad-file list that the prune removes
+	rotten := writeContentAddressed(t, filepath.Join(repoPath, "data"), fakePack([]byte("old"), 1), true)
+	require.NoError(t, os.WriteFile(rotten, []byte("rotten"), 0644))
+	rel, _ := filepath.Rel(repoPath, rotten)
+	require.NoError(t, checker.ReportBadFile("repo", rel, BadFileSourceRead, ""))
+	_, err = checker.Rebaseline(context.Background(), "repo")
+	require.NoError(t, err)
+
+	// The prune repacks it
+	require.NoError(t, os.Remove(rotten))
+	writeContentAddressed(t, filepath.Join(repoPath, "data"), fakePack([]byte("repacked"), 1), true)
+
+	result, err := checker.Rebaseline(context.Background(), "repo")
+	require.NoError(t, err)
+	assert.True(t, result.Passed, "errors: %v", result.Errors)
+	assert.Equal(t, 1, result.CheckedFiles, "only the new pack is read")
+	assert.Empty(t, checker.BadFiles("repo"))
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(1000)
+	start := time.Now()
+	require.NoError(t, limiter.wait(context.Background(), 100))
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Error(t, limiter.wait(ctx, 10000))
+}
@@ -0,0 +1,273 @@
+package integrity
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+// Check types selectable via VerificationConfig.CheckType
+const (
+	// CheckTypeQuick compares file presence and hashes against a verification record
+	CheckTypeQuick = "quick"
+	// CheckTypeFull verifies every data blob's content against its name
+	CheckTypeFull = "full"
+	// CheckTypePacks additionally verifies index, snapshot and key files and
+	// the structure of every pack. Needs no repository password.
+	CheckTypePacks = "packs"
+	// CheckTypeRestic runs the packs check followed by restic's own check
+	// against the local repository. Needs the repository password.
+	CheckTypeRestic = "restic"
+	// CheckTypeIncremental verifies content only for files that are new or
+	// due for re-verification, resuming interrupted runs
+	CheckTypeIncremental = "incremental"
+)
+
+// contentAddressedDirs are the repository directories whose files are named
+// by the SHA-256 of their (encrypted) contents
+var contentAddressedDirs = []string{"data", "index", "snapshots", "keys"}
+
+// Pack header layout (see restic design doc): the last 4 bytes of a pack hold
+// the length of the encrypted header, which is IV + entries + MAC. Entries are
+// 37 bytes, or 41 bytes for compressed blobs.
+const (
+	packHeaderLengthSize = 4
+	packHeaderCryptoSize = 16 + 16 // IV + MAC
+	packEntrySize        = 37
+	packEntrySizeZ       = 41
+)
+
+// RepoChecker runs restic's consistency check against a repository on disk
+type RepoChecker interface {
+	Check(ctx context.Context, repoPath string) (string, error)
+}
+
+// resticRepoChecker checks a local repository with the restic CLI
+type resticRepoChecker struct {
+	password       string
+	readDataSubset string
+}
+
+// NewResticRepoChecker returns a RepoChecker that runs "restic check" with
+// the given repository password. readDataSubset (e.g. "5%") additionally
+// reads and decrypts that share of pack data; empty skips data reads.
+func NewResticRepoChecker(password, readDataSubset string) RepoChecker {
+	return &resticRepoChecker{password: password, readDataSubset: readDataSubset}
+}
+
+func (r *resticRepoChecker) Check(ctx context.Context, repoPath string) (string, error) {
+	return restic.NewClient(repoPath, r.password).CheckOutput(ctx, r.readDataSubset)
+}
+
+// SetRepoChecker enables CheckTypeRestic using the given checker
+func (c *Checker) SetRepoChecker(rc RepoChecker) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.repoChecker = rc
+}
+
+// RunCheck runs the check selected by checkType. An empty check type runs
+// a full check.
+func (c *Checker) RunCheck(ctx context.Context, checkType, repoName, snapshotID string) (*CheckResult, error) {
+	var result *CheckResult
+	var err error
+
+	switch checkType {
+	case CheckTypeQuick:
+		result, err = c.QuickCheck(repoName, snapshotID)
+	case CheckTypeFull, "":
+		checkType = CheckTypeFull
+		result, err = c.CheckDataIntegrity(repoName)
+	case CheckTypePacks:
+		result, err = c.CheckPacks(repoName)
+	case CheckTypeRestic:
+		result, err = c.CheckWithRestic(ctx, repoName)
+	case CheckTypeIncremental:
+		c.mu.RLock()
+		opts := c.incremental
+		c.mu.RUnlock()
+		result, err = c.CheckIncremental(ctx, repoName, opts)
+	default:
+		return nil, fmt.Errorf("unknown check type %q", checkType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.finishCheck(repoName, result)
+	result.CheckType = checkType
+	return result, nil
+}
+
+// finishCheck fails a check on the files found corrupt since the last one,
+// whatever its type, and adds the files it found corrupt to them
+func (c *Checker) finishCheck(repoName string, result *CheckResult) {
+	if c.checkBadFiles(repoName, result) {
+		c.replaceInHistory(*result)
+	}
+	for _, path := range result.CorruptPaths {
+		if err := c.ReportBadFile(repoName, path, BadFileSourceCheck, ""); err != nil {
+			logging.Warn("Failed to add a corrupt file to the bad-file list", logging.Err(err))
+		}
+	}
+}
+
+// CheckPacks verifies every content-addressed file (data, index, snapshots,
+// keys) against its name and checks that each pack has a well-formed header
+// trailer. This catches truncated or spliced packs that a host can detect
+// without the repository password.
+func (c *Checker) CheckPacks(repoName string) (*CheckResult, error) {
+	result := c.checkPacks(repoName)
+	c.addToHistory(*result)
+	return result, nil
+}
+
+func (c *Checker) checkPacks(repoName string) *CheckResult {
+	start := time.Now()
+	repoPath := filepath.Join(c.basePath, repoName)
+	result := &CheckResult{
+		Timestamp: start,
+		RepoPath:  repoPath,
+	}
+
+	if _, err := os.Stat(filepath.Join(repoPath, "config")); err != nil {
+		result.MissingFiles++
+		result.Errors = append(result.Errors, "config file missing or unreadable")
+	}
+
+	for _, dir := range contentAddressedDirs {
+		_ = filepath.Walk(filepath.Join(repoPath, dir), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				if !os.IsNotExist(err) {
+					result.Errors = append(result.Errors, fmt.Sprintf("walk error: %v", err))
+				}
+				return nil
+			}
+			if info.IsDir() {
+				return nil
+			}
+
+			result.TotalFiles++
+
+			actualHash, err := hashFile(path)
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("hash error %s/%s: %v", dir, info.Name(), err))
+				return nil
+			}
+			result.CheckedFiles++
+
+			rel, _ := filepath.Rel(repoPath, path)
+			if actualHash != info.Name() {
+				result.CorruptFiles++
+				result.Errors = append(result.Errors,
+					fmt.Sprintf("CORRUPT: %s/%s (expected hash doesn't match content)", dir, info.Name()))
+				result.CorruptPaths = append(result.CorruptPaths, filepath.ToSlash(rel))
+				return nil
+			}
+
+			if dir == "data" {
+				if err := checkPackStructure(path, info.Size()); err != nil {
+					result.CorruptFiles++
+					result.Errors = append(result.Errors,
+						fmt.Sprintf("CORRUPT: data/%s (%v)", info.Name(), err))
+				}
+			}
+			return nil
+		})
+	}
+
+	result.Duration = time.Since(start).String()
+	result.Passed = result.CorruptFiles == 0 && result.MissingFiles == 0
+	return result
+}
+
+// checkPackStructure validates the header length trailer of a pack file
+func checkPackStructure(path string, size int64) error {
+	if size < packHeaderLengthSize+packHeaderCryptoSize+packEntrySize {
+		return fmt.Errorf("pack too small: %d bytes", size)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	var trailer [packHeaderLengthSize]byte
+	if _, err := f.ReadAt(trailer[:], size-packHeaderLengthSize); err != nil && err != io.EOF {
+		return err
+	}
+	headerLen := int64(binary.LittleEndian.Uint32(trailer[:]))
+
+	if headerLen+packHeaderLengthSize > size {
+		return fmt.Errorf("header length %d exceeds pack size %d", headerLen, size)
+	}
+	if !validPackEntriesLength(headerLen - packHeaderCryptoSize) {
+		return fmt.Errorf("header length %d does not match any entry layout", headerLen)
+	}
+	return nil
+}
+
+// validPackEntriesLength reports whether n bytes can hold a whole number of
+// uncompressed and compressed header entries (at least one)
+func validPackEntriesLength(n int64) bool {
+	if n < packEntrySize {
+		return false
+	}
+	for z := int64(0); z*packEntrySizeZ <= n; z++ {
+		if (n-z*packEntrySizeZ)%packEntrySize == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckWithRestic runs the packs check and then restic's own check against
+// the local repository, merging both into a single result
+func (c *Checker) CheckWithRestic(ctx context.Context, repoName string) (*CheckResult, error) {
+	c.mu.RLock()
+	rc := c.repoChecker
+	c.mu.RUnlock()
+
+	if rc == nil {
+		return nil, fmt.Errorf("restic check requires the repository password; use the %q check instead", CheckTypePacks)
+	}
+
+	start := time.Now()
+	result := c.checkPacks(repoName)
+
+	output, err := rc.Check(ctx, result.RepoPath)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("restic check failed: %v", err))
+		for _, line := range resticCheckErrors(output) {
+			result.Errors = append(result.Errors, "restic: "+line)
+		}
+		result.CorruptFiles++
+	}
+
+	result.Duration = time.Since(start).String()
+	result.Passed = result.CorruptFiles == 0 && result.MissingFiles == 0
+	c.addToHistory(*result)
+	return result, nil
+}
+
+// resticCheckErrors extracts the error lines from restic check output
+func resticCheckErrors(output string) []string {
+	var errs []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		lower := strings.ToLower(line)
+		if strings.Contains(lower, "error") || strings.HasPrefix(lower, "fatal") {
+			errs = append(errs, line)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,148 @@
+package integrity
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeContentAddressed writes data under dir named by its SHA-256
+func writeContentAddressed(t *testing.T, dir string, data []byte, subdirs bool) string {
+	sum := sha256.Sum256(data)
+	name := hex.EncodeToString(sum[:])
+	if subdirs {
+		dir = filepath.Join(dir, name[:2])
+	}
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, data, 0644))
+	return path
+}
+
+// fakePack builds a pack with the given blob bytes and header entry count
+func fakePack(blob []byte, entries int) []byte {
+	headerLen := packHeaderCryptoSize + entries*packEntrySize
+	pack := append([]byte{}, blob...)
+	pack = append(pack, make([]byte, headerLen)...)
+	return binary.LittleEndian.AppendUint32(pack, uint32(headerLen))
+}
+
+func setupPackRepo(t *testing.T, basePath, repoName string) string {
+	repoPath := filepath.Join(basePath, repoName)
+	require.NoError(t, os.MkdirAll(repoPath, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "config"), []byte("config"), 0644))
+
+	for i := 0; i < 3; i++ {
+		writeContentAddressed(t, filepath.Join(repoPath, "data"), fakePack([]byte{byte(i), 1, 2, 3}, i+1), true)
+	}
+	writeContentAddressed(t, filepath.Join(repoPath, "index"), []byte("index"), false)
+	writeContentAddressed(t, filepath.Join(repoPath, "snapshots"), []byte("snapshot"), false)
+	writeContentAddressed(t, filepath.Join(repoPath, "keys"), []byte("key"), false)
+	return repoPath
+}
+
+func TestCheckPacks(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupPackRepo(t, tmpDir, "repo")
+
+	checker, err := NewChecker(tmpDir)
+	require.NoError(t, err)
+
+	result, err := checker.RunCheck(context.Background(), CheckTypePacks, "repo", "")
+	require.NoError(t, err)
+	assert.True(t, result.Passed, "errors: %v", result.Errors)
+	assert.Equal(t, 6, result.TotalFiles)
+	assert.Equal(t, CheckTypePacks, result.CheckType)
+}
+
+func TestCheckPacks_CorruptIndex(t *testing.T) {
+	tmpDir := t.TempDir()
+	repoPath := setupPackRepo(t, tmpDir, "repo")
+
+	entries, err := os.ReadDir(filepath.Join(repoPath, "index"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "index", entries[0].Name()), []byte("tampered"), 0644))
+
+	checker, err := NewChecker(tmpDir)
+	require.NoError(t, err)
+
+	result, err := checker.CheckPacks("repo")
+	require.NoError(t, err)
+	assert.False(t, result.Passed)
+	assert.Equal(t, 1, result.CorruptFiles)
+}
+
+func TestCheckPacks_BadHeader(t *testing.T) {
+	tmpDir := t.TempDir()
+	repoPath := setupPackRepo(t, tmpDir, "repo")
+
+	// Content-addressed correctly, but the header length points past the start
+	bad := binary.LittleEndian.AppendUint32(make([]byte, 80), 1000)
+	writeContentAddressed(t, filepath.Join(repoPath, "data"), bad, true)
+
+	checker, err := NewChecker(tmpDir)
+	require.NoError(t, err)
+
+	result, err := checker.CheckPacks("repo")
+	require.NoError(t, err)
+	assert.False(t, result.Passed)
+	assert.Equal(t, 1, result.CorruptFiles)
+}
+
+func TestValidPackEntriesLength(t *testing.T) {
+	assert.True(t, validPackEntriesLength(37))
+	assert.True(t, validPackEntriesLength(41))
+	assert.True(t, validPackEntriesLength(37*2+41))
+	assert.False(t, validPackEntriesLength(0))
+	assert.False(t, validPackEntriesLength(38))
+}
+
+type fakeRepoChecker struct {
+	output string
+	err    error
+}
+
+func (f *fakeRepoChecker) Check(ctx context.Context, repoPath string) (string, error) {
+	return f.output, f.err
+}
+
+func TestCheckWithRestic(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupPackRepo(t, tmpDir, "repo")
+
+	checker, err := NewChecker(tmpDir)
+	require.NoError(t, err)
+
+	_, err = checker.RunCheck(context.Background(), CheckTypeRestic, "repo", "")
+	assert.Error(t, err, "restic check should require a repo checker")
+
+	checker.SetRepoChecker(&fakeRepoChecker{output: "no errors were found\n"})
+	result, err := checker.RunCheck(context.Background(), CheckTypeRestic, "repo", "")
+	require.NoError(t, err)
+	assert.True(t, result.Passed, "errors: %v", result.Errors)
+
+	checker.SetRepoChecker(&fakeRepoChecker{
+		output: "check snapshots, trees and blobs\nerror for tree 1234: blob not found\nFatal: repository contains errors\n",
+		err:    errors.New("exit status 1"),
+	})
+	result, err = checker.RunCheck(context.Background(), CheckTypeRestic, "repo", "")
+	require.NoError(t, err)
+	assert.False(t, result.Passed)
+	assert.Contains(t, result.Errors, "restic: error for tree 1234: blob not found")
+}
+
+func TestRunCheck_UnknownType(t *testing.T) {
+	checker, err := NewChecker(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = checker.RunCheck(context.Background(), "bogus", "repo", "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,473 @@
+package integrity
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+// Blob checks prove the host still has the bytes; a restore test proves the
+// owner can actually get files back. It restores a random sample of small
+// files from a snapshot into a temp directory and compares them with the
+// source files and snapshot metadata.
+
+// RestoreTestConfig holds settings for scheduled restore tests (owner only)
+type RestoreTestConfig struct {
+	// Enabled controls whether scheduled restore tests run while serving
+	Enabled bool `json:"enabled"`
+
+	// Interval between restore tests (e.g., "24h", "168h" for weekly)
+	Interval string `json:"interval"`
+
+	// SampleSize is the number of files restored per test
+	SampleSize int `json:"sampleSize"`
+
+	// MaxFileBytes skips files larger than this when sampling
+	MaxFileBytes int64 `json:"maxFileBytes"`
+
+	// SnapshotID to test (uses latest if empty)
+	SnapshotID string `json:"snapshotId,omitempty"`
+
+	// CanaryFiles are absolute paths of files whose hashes are recorded
+	// after each backup and checked by every restore test
+	CanaryFiles []string `json:"canaryFiles,omitempty"`
+}
+
+// DefaultRestoreTestConfig returns sensible defaults
+func DefaultRestoreTestConfig() *RestoreTestConfig {
+	return &RestoreTestConfig{
+		Enabled:      false,
+		Interval:     "168h", // Weekly
+		SampleSize:   20,
+		MaxFileBytes: 10 << 20, // 10 MB
+	}
+}
+
+// ParseInterval parses the interval string into a time.Duration
+func (c *RestoreTestConfig) ParseInterval() (time.Duration, error) {
+	if c.Interval == "" {
+		return 168 * time.Hour, nil
+	}
+	return time.ParseDuration(c.Interval)
+}
+
+// Validate checks that the configuration is valid
+func (c *RestoreTestConfig) Validate() error {
+	if c.SampleSize < 0 {
+		return fmt.Errorf("sampleSize must not be negative")
+	}
+	if c.MaxFileBytes < 0 {
+		return fmt.Errorf("maxFileBytes must not be negative")
+	}
+	if c.Interval != "" {
+		d, err := time.ParseDuration(c.Interval)
+		if err != nil {
+			return fmt.Errorf("invalid interval: %w", err)
+		}
+		if d < time.Hour {
+			return fmt.Errorf("interval must be at least 1 hour")
+		}
+	}
+	for _, p := range c.CanaryFiles {
+		if !filepath.IsAbs(p) {
+			return fmt.Errorf("canary file %q must be an absolute path", p)
+		}
+	}
+	return nil
+}
+
+// RestoreTestRecord is the signed outcome of a restore test
+type RestoreTestRecord struct {
+	ID         string    `json:"id"`
+	SnapshotID string    `json:"snapshotId"`
+	Timestamp  time.Time `json:"timestamp"`
+	OwnerKeyID string    `json:"ownerKeyId,omitempty"`
+
+	FilesSampled  int   `json:"filesSampled"`
+	FilesVerified int   `json:"filesVerified"`
+	HashCompared  int   `json:"hashCompared"` // Verified against the unchanged source file
+	BytesRestored int64 `json:"bytesRestored"`
+
+	// Canary files restored and compared with their hashes at backup time
+	CanariesChecked  int `json:"canariesChecked,omitempty"`
+	CanariesVerified int `json:"canariesVerified,omitempty"`
+
+	Mismatches []string `json:"mismatches,omitempty"`
+	Errors     []string `json:"errors,omitempty"`
+	Duration   string   `json:"duration"`
+	Passed     bool     `json:"passed"`
+
+	// Owner signature over this record
+	Signature string `json:"signature,omitempty"`
+}
+
+// Hash computes the hash of the record for signing (excludes the signature)
+func (r *RestoreTestRecord) Hash() ([]byte, error) {
+	unsigned := *r
+	unsigned.Signature = ""
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(data)
+	return hash[:], nil
+}
+
+// Verify checks the record's signature against the owner's public key
+func (r *RestoreTestRecord) Verify(ownerPubKey []byte) bool {
+	sig, err := hex.DecodeString(r.Signature)
+	if err != nil || len(sig) == 0 {
+		return false
+	}
+	hash, err := r.Hash()
+	if err != nil {
+		return false
+	}
+	return crypto.Verify(ownerPubKey, hash, sig)
+}
+
+// SnapshotRestorer is the subset of restic operations a restore test needs
+type SnapshotRestorer interface {
+	ListFiles(ctx context.Context, snapshotID string) ([]restic.Node, error)
+	RestoreFiles(ctx context.Context, snapshotID, target string, paths []string) error
+}
+
+// RestoreTester runs restore tests and keeps their history
+type RestoreTester struct {
+	restorer    SnapshotRestorer
+	config      *RestoreTestConfig
+	canaries    *CanaryStore
+	historyPath string
+	privateKey  []byte
+	keyID       string
+
+	mu         sync.Mutex
+	history    []RestoreTestRecord
+	maxHistory int
+}
+
+// NewRestoreTester creates a restore tester. History is persisted in dataDir.
+func NewRestoreTester(restorer SnapshotRestorer, dataDir string, config *RestoreTestConfig) *RestoreTester {
+	if config == nil {
+		config = DefaultRestoreTestConfig()
+	}
+	t := &RestoreTester{
+		restorer:    restorer,
+		config:      config,
+		canaries:    NewCanaryStore(dataDir),
+		historyPath: filepath.Join(dataDir, "restore-tests.json"),
+		maxHistory:  100,
+	}
+	t.loadHistory()
+	return t
+}
+
+// SetSigningKey sets the owner key used to sign restore test records
+func (t *RestoreTester) SetSigningKey(privateKey []byte, keyID string) {
+	t.privateKey = privateKey
+	t.keyID = keyID
+}
+
+// Run performs a restore test. A failed test is reported through the record
+// (Passed=false); an error is returned only if the test could not be recorded.
+func (t *RestoreTester) Run(ctx context.Context) (*RestoreTestRecord, error) {
+	start := time.Now()
+	snapshotID := t.config.SnapshotID
+	if snapshotID == "" {
+		snapshotID = "latest"
+	}
+
+	record := &RestoreTestRecord{
+		ID:         newRecordID(),
+		SnapshotID: snapshotID,
+		Timestamp:  start,
+		OwnerKeyID: t.keyID,
+	}
+
+	t.test(ctx, record)
+	t.checkCanaries(ctx, record)
+
+	record.Duration = time.Since(start).String()
+	record.Passed = len(record.Errors) == 0 && len(record.Mismatches) == 0 && record.FilesVerified > 0
+
+	if t.privateKey != nil {
+		hash, err := record.Hash()
+		if err != nil {
+			return nil, err
+		}
+		sig, err := crypto.Sign(t.privateKey, hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign restore test record: %w", err)
+		}
+		record.Signature = hex.EncodeToString(sig)
+	}
+
+	if err := t.addToHistory(*record); err != nil {
+		return record, err
+	}
+	return record, nil
+}
+
+func (t *RestoreTester) test(ctx context.Context, record *RestoreTestRecord) {
+	nodes, err := t.restorer.ListFiles(ctx, record.SnapshotID)
+	if err != nil {
+		record.Errors = append(record.Errors, fmt.Sprintf("list snapshot: %v", err))
+		return
+	}
+
+	sample := sampleFiles(nodes, t.sampleSize(), t.config.MaxFileBytes)
+	record.FilesSampled = len(sample)
+	if len(sample) == 0 {
+		record.Errors = append(record.Errors, "snapshot contains no files eligible for a restore test")
+		return
+	}
+
+	target, err := os.MkdirTemp("", "airgapper-restore-test-")
+	if err != nil {
+		record.Errors = append(record.Errors, fmt.Sprintf("create temp dir: %v", err))
+		return
+	}
+	defer func() { _ = os.RemoveAll(target) }()
+
+	paths := make([]string, len(sample))
+	for i, n := range sample {
+		paths[i] = n.Path
+	}
+	if err := t.restorer.RestoreFiles(ctx, record.SnapshotID, target, paths); err != nil {
+		record.Errors = append(record.Errors, fmt.Sprintf("restore: %v", err))
+		return
+	}
+
+	for _, n := range sample {
+		verifyRestoredFile(record, n, filepath.Join(target, n.Path))
+	}
+}
+
+// verifyRestoredFile checks one restored file. If the source file still has
+// the snapshot's size and mtime its content hash must match; otherwise only
+// the restored size is checked against the snapshot metadata.
+func verifyRestoredFile(record *RestoreTestRecord, n restic.Node, restored string) {
+	info, err := os.Stat(restored)
+	if err != nil {
+		record.Mismatches = append(record.Mismatches, fmt.Sprintf("%s: not restored", n.Path))
+		return
+	}
+	record.BytesRestored += info.Size()
+
+	if info.Size() != n.Size {
+		record.Mismatches = append(record.Mismatches,
+			fmt.Sprintf("%s: restored size %d, snapshot size %d", n.Path, info.Size(), n.Size))
+		return
+	}
+
+	if src, err := os.Stat(n.Path); err == nil && src.Size() == n.Size && src.ModTime().Equal(n.ModTime) {
+		srcHash, srcErr := hashFile(n.Path)
+		restoredHash, restoredErr := hashFile(restored)
+		if srcErr != nil || restoredErr != nil {
+			record.Errors = append(record.Errors, fmt.Sprintf("%s: hash failed", n.Path))
+			return
+		}
+		if srcHash != restoredHash {
+			record.Mismatches = append(record.Mismatches, fmt.Sprintf("%s: content hash differs from source", n.Path))
+			return
+		}
+		record.HashCompared++
+	}
+
+	record.FilesVerified++
+}
+
+func (t *RestoreTester) sampleSize() int {
+	if t.config.SampleSize <= 0 {
+		return DefaultRestoreTestConfig().SampleSize
+	}
+	return t.config.SampleSize
+}
+
+// sampleFiles picks up to n random regular files no larger than maxBytes
+func sampleFiles(nodes []restic.Node, n int, maxBytes int64) []restic.Node {
+	var eligible []restic.Node
+	for _, node := range nodes {
+		if node.Type != "file" || (maxBytes > 0 && node.Size > maxBytes) {
+			continue
+		}
+		eligible = append(eligible, node)
+	}
+
+	// Partial Fisher-Yates shuffle
+	for i := 0; i < len(eligible) && i < n; i++ {
+		j := i + randIntn(len(eligible)-i)
+		eligible[i], eligible[j] = eligible[j], eligible[i]
+	}
+	if len(eligible) > n {
+		eligible = eligible[:n]
+	}
+	return eligible
+}
+
+func randIntn(n int) int {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0
+	}
+	return int(v.Int64())
+}
+
+func newRecordID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// --- History ---
+
+// History returns the most recent restore test records, newest last
+func (t *RestoreTester) History(limit int) []RestoreTestRecord {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if limit <= 0 || limit > len(t.history) {
+		limit = len(t.history)
+	}
+	result := make([]RestoreTestRecord, limit)
+	copy(result, t.history[len(t.history)-limit:])
+	return result
+}
+
+func (t *RestoreTester) addToHistory(record RestoreTestRecord) error {
+	t.mu.Lock()
+	t.history = append(t.history, record)
+	if len(t.history) > t.maxHistory {
+		t.history = t.history[len(t.history)-t.maxHistory:]
+	}
+	data, err := json.MarshalIndent(t.history, "", "  ")
+	t.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(t.historyPath, data, 0600)
+}
+
+func (t *RestoreTester) loadHistory() {
+	data, err := os.ReadFile(t.historyPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logging.Debug("failed to read restore test history", logging.Err(err))
+		}
+		return
+	}
+	if err := json.Unmarshal(data, &t.history); err != nil {
+		logging.Debug("failed to parse restore test history", logging.Err(err))
+	}
+}
+
+// --- Scheduling ---
+
+// ScheduledRestoreTester runs restore tests periodically
+type ScheduledRestoreTester struct {
+	tester   *RestoreTester
+	interval time.Duration
+	stopChan chan struct{}
+	running  bool
+	mu       sync.Mutex
+
+	// Callback for alerts
+	onFailure func(record *RestoreTestRecord)
+}
+
+// NewScheduledRestoreTester creates a scheduled restore tester
+func NewScheduledRestoreTester(tester *RestoreTester, interval time.Duration) *ScheduledRestoreTester {
+	return &ScheduledRestoreTester{
+		tester:   tester,
+		interval: interval,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// SetFailureCallback sets a callback to be called when a restore test fails
+func (s *ScheduledRestoreTester) SetFailureCallback(cb func(record *RestoreTestRecord)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onFailure = cb
+}
+
+// Start begins scheduled restore tests. The first test runs after one interval.
+func (s *ScheduledRestoreTester) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return
+	}
+	s.running = true
+
+	go s.run()
+}
+
+// Stop stops scheduled restore tests
+func (s *ScheduledRestoreTester) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	s.mu.Unlock()
+
+	close(s.stopChan)
+}
+
+func (s *ScheduledRestoreTester) run() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.runTest()
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+func (s *ScheduledRestoreTester) runTest() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	record, err := s.tester.Run(ctx)
+	if err != nil {
+		logging.Warn("Failed to record restore test", logging.Err(err))
+	}
+	if record == nil {
+		return
+	}
+
+	s.mu.Lock()
+	cb := s.onFailure
+	s.mu.Unlock()
+
+	if !record.Passed && cb != nil {
+		cb(record)
+	}
+}
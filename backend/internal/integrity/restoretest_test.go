@@ -0,0 +1,163 @@
+package integrity
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRestorer "restores" files by copying them from their source paths,
+// optionally corrupting them on the way
+type fakeRestorer struct {
+	nodes      []restic.Node
+	corrupt    bool
+	restoreErr error
+}
+
+func (f *fakeRestorer) ListFiles(ctx context.Context, snapshotID string) ([]restic.Node, error) {
+	return f.nodes, nil
+}
+
+func (f *fakeRestorer) RestoreFiles(ctx context.Context, snapshotID, target string, paths []string) error {
+	if f.restoreErr != nil {
+		return f.restoreErr
+	}
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if f.corrupt {
+			data[0] ^= 0xff
+		}
+		dst := filepath.Join(target, p)
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dst, data, 0644); err != nil {
+			return err
+		}
+		// Restic restores mtimes
+		info, err := os.Stat(p)
+		if err != nil {
+			return err
+		}
+		if err := os.Chtimes(dst, info.ModTime(), info.ModTime()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setupSourceFiles(t *testing.T, count int) []restic.Node {
+	dir := t.TempDir()
+	var nodes []restic.Node
+	for i := 0; i < count; i++ {
+		path := filepath.Join(dir, "file"+string(rune('a'+i)))
+		require.NoError(t, os.WriteFile(path, []byte("content "+path), 0644))
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		nodes = append(nodes, restic.Node{
+			Name:    info.Name(),
+			Type:    "file",
+			Path:    path,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+	nodes = append(nodes, restic.Node{Name: filepath.Base(dir), Type: "dir", Path: dir})
+	return nodes
+}
+
+func TestRestoreTester_Run(t *testing.T) {
+	restorer := &fakeRestorer{nodes: setupSourceFiles(t, 5)}
+	cfg := DefaultRestoreTestConfig()
+	cfg.SampleSize = 3
+
+	pub, priv, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+
+	tester := NewRestoreTester(restorer, t.TempDir(), cfg)
+	tester.SetSigningKey(priv, crypto.KeyID(pub))
+
+	record, err := tester.Run(context.Background())
+	require.NoError(t, err)
+
+	assert.True(t, record.Passed, "errors=%v mismatches=%v", record.Errors, record.Mismatches)
+	assert.Equal(t, 3, record.FilesSampled)
+	assert.Equal(t, 3, record.FilesVerified)
+	assert.Equal(t, 3, record.HashCompared)
+	assert.True(t, record.Verify(pub), "record signature should verify")
+
+	record.FilesVerified = 100
+	assert.False(t, record.Verify(pub), "tampered record should not verify")
+}
+
+func TestRestoreTester_Mismatch(t *testing.T) {
+	restorer := &fakeRestorer{nodes: setupSourceFiles(t, 2), corrupt: true}
+
+	tester := NewRestoreTester(restorer, t.TempDir(), nil)
+	record, err := tester.Run(context.Background())
+	require.NoError(t, err)
+
+	assert.False(t, record.Passed)
+	assert.Len(t, record.Mismatches, 2)
+}
+
+func TestRestoreTester_RestoreError(t *testing.T) {
+	restorer := &fakeRestorer{nodes: setupSourceFiles(t, 2), restoreErr: errors.New("boom")}
+
+	tester := NewRestoreTester(restorer, t.TempDir(), nil)
+	record, err := tester.Run(context.Background())
+	require.NoError(t, err)
+
+	assert.False(t, record.Passed)
+	require.Len(t, record.Errors, 1)
+	assert.Contains(t, record.Errors[0], "boom")
+}
+
+func TestRestoreTester_HistoryPersistence(t *testing.T) {
+	dataDir := t.TempDir()
+	restorer := &fakeRestorer{nodes: setupSourceFiles(t, 1)}
+
+	tester := NewRestoreTester(restorer, dataDir, nil)
+	for i := 0; i < 3; i++ {
+		_, err := tester.Run(context.Background())
+		require.NoError(t, err)
+	}
+
+	reloaded := NewRestoreTester(restorer, dataDir, nil)
+	assert.Len(t, reloaded.History(0), 3)
+	assert.Len(t, reloaded.History(2), 2)
+}
+
+func TestSampleFiles(t *testing.T) {
+	nodes := []restic.Node{
+		{Path: "/a", Type: "file", Size: 10},
+		{Path: "/b", Type: "file", Size: 1000},
+		{Path: "/c", Type: "dir"},
+		{Path: "/d", Type: "file", Size: 20},
+	}
+
+	sample := sampleFiles(nodes, 10, 100)
+	assert.Len(t, sample, 2, "should skip directories and oversized files")
+
+	sample = sampleFiles(nodes, 1, 0)
+	assert.Len(t, sample, 1)
+}
+
+func TestRestoreTestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultRestoreTestConfig().Validate())
+	assert.Error(t, (&RestoreTestConfig{Interval: "30m"}).Validate())
+	assert.Error(t, (&RestoreTestConfig{Interval: "bogus"}).Validate())
+	assert.Error(t, (&RestoreTestConfig{SampleSize: -1}).Validate())
+	assert.Error(t, (&RestoreTestConfig{CanaryFiles: []string{"notes.txt"}}).Validate())
+	assert.NoError(t, (&RestoreTestConfig{CanaryFiles: []string{"/home/alice/notes.txt"}}).Validate())
+}
@@ -1,8 +1,11 @@
 package integrity
 
 import (
+	"context"
 	"sync"
 	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
 )
 
 // ScheduledChecker runs periodic integrity checks
@@ -10,9 +13,18 @@ type ScheduledChecker struct {
 	checker  *Checker
 	repoName string
 	interval time.Duration
+
+	checkType  string
+	snapshotID string
+
+	// Deferral: checks wait retryAfter while shouldDefer reports busy
+	shouldDefer func(now time.Time) (bool, string)
+	retryAfter  time.Duration
+
 	stopChan chan struct{}
 	running  bool
 	mu       sync.Mutex
+	wg       sync.WaitGroup
 
 	// Callback for alerts
 	onCorruption func(result *CheckResult)
@@ -28,6 +40,27 @@ func NewScheduledChecker(checker *Checker, repoName string, interval time.Durati
 	}
 }
 
+// SetCheckType selects the check run on each tick (see CheckType constants).
+// The default is a full check; snapshotID is used by quick checks.
+func (sc *ScheduledChecker) SetCheckType(checkType, snapshotID string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.checkType = checkType
+	sc.snapshotID = snapshotID
+}
+
+// SetDeferral makes due checks wait while shouldDefer reports true, trying
+// again every retryAfter (DefaultDeferInterval if zero)
+func (sc *ScheduledChecker) SetDeferral(shouldDefer func(now time.Time) (bool, string), retryAfter time.Duration) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if retryAfter <= 0 {
+		retryAfter = DefaultDeferInterval
+	}
+	sc.shouldDefer = shouldDefer
+	sc.retryAfter = retryAfter
+}
+
 // SetCorruptionCallback sets a callback to be called when corruption is detected
 func (sc *ScheduledChecker) SetCorruptionCallback(cb func(result *CheckResult)) {
 	sc.mu.Lock()
@@ -45,10 +78,14 @@ func (sc *ScheduledChecker) Start() {
 	}
 	sc.running = true
 
-	go sc.run()
+	sc.wg.Add(1)
+	go func() {
+		defer sc.wg.Done()
+		sc.run()
+	}()
 }
 
-// Stop stops scheduled checking
+// Stop stops scheduled checking and waits for an in-progress check to finish
 func (sc *ScheduledChecker) Stop() {
 	sc.mu.Lock()
 	if !sc.running {
@@ -59,27 +96,62 @@ func (sc *ScheduledChecker) Stop() {
 	sc.mu.Unlock()
 
 	close(sc.stopChan)
+	sc.wg.Wait()
 }
 
 func (sc *ScheduledChecker) run() {
-	ticker := time.NewTicker(sc.interval)
-	defer ticker.Stop()
-
-	// Run initial check
-	sc.runCheck()
+	// Initial check is due immediately
+	timer := time.NewTimer(0)
+	defer timer.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-timer.C:
+			if deferred, reason, retry := sc.deferral(); deferred {
+				logging.Info("Deferring scheduled integrity check",
+					logging.String("reason", reason),
+					logging.String("retryIn", retry.String()))
+				timer.Reset(retry)
+				continue
+			}
 			sc.runCheck()
+			timer.Reset(sc.interval)
 		case <-sc.stopChan:
 			return
 		}
 	}
 }
 
+// deferral reports whether a due check should wait, why, and for how long
+func (sc *ScheduledChecker) deferral() (bool, string, time.Duration) {
+	sc.mu.Lock()
+	shouldDefer, retry := sc.shouldDefer, sc.retryAfter
+	sc.mu.Unlock()
+
+	if shouldDefer == nil {
+		return false, "", 0
+	}
+	deferred, reason := shouldDefer(time.Now())
+	return deferred, reason, retry
+}
+
 func (sc *ScheduledChecker) runCheck() {
-	result, err := sc.checker.CheckDataIntegrity(sc.repoName)
+	sc.mu.Lock()
+	checkType, snapshotID := sc.checkType, sc.snapshotID
+	sc.mu.Unlock()
+
+	// Cancel a long-running check on Stop; incremental checks resume later
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-sc.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	result, err := sc.checker.RunCheck(ctx, checkType, sc.repoName, snapshotID)
 	if err != nil {
 		return
 	}
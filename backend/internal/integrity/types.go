@@ -10,11 +10,15 @@ import (
 type CheckResult struct {
 	Timestamp    time.Time `json:"timestamp"`
 	RepoPath     string    `json:"repoPath"`
+	CheckType    string    `json:"checkType,omitempty"`
 	TotalFiles   int       `json:"totalFiles"`
 	CheckedFiles int       `json:"checkedFiles"`
 	CorruptFiles int       `json:"corruptFiles"`
 	MissingFiles int       `json:"missingFiles"`
+	SkippedFiles int       `json:"skippedFiles,omitempty"` // Incremental: verified recently
+	Incomplete   bool      `json:"incomplete,omitempty"`   // Incremental: interrupted, will resume
 	Errors       []string  `json:"errors,omitempty"`
+	CorruptPaths []string  `json:"corruptPaths,omitempty"` // Corrupt content-addressed files, relative to the repo
 	Duration     string    `json:"duration"`
 	Passed       bool      `json:"passed"`
 }
@@ -0,0 +1,190 @@
+// Package keyprotect recovers the repository password for a restore. Each
+// protection type is a mechanism: Shamir shares combined with the one the
+// peer releases, the password held on the owner's node behind consensus
+// approval, a copy escrowed with a cloud KMS, or a share sealed with a
+// passphrase the owner memorized and combined with the peer's. A vault can
+// have several in parallel; any one of them recovers the password.
+package keyprotect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/sss"
+)
+
+// Type is a key-protection mechanism
+type Type string
+
+// Protection types
+const (
+	// TypeShamir combines the owner's share with the one the peer releases
+	// on approval (SSS mode)
+	TypeShamir Type = "shamir"
+	// TypeConsensus uses the password held on the owner's node once enough
+	// key holders signed the request (consensus mode)
+	TypeConsensus Type = "consensus"
+	// TypeKMS decrypts a copy of the password escrowed with a cloud KMS key
+	TypeKMS Type = "kms"
+	// TypePassphrase opens the owner's share with a memorized passphrase
+	// and combines it with the one the peer releases on approval
+	TypePassphrase Type = "passphrase"
+)
+
+// Protection is one way to recover the repository password. Shamir and
+// consensus protections come from the vault's mode; KMS and passphrase
+// protections are configured at init and keep their sealed data here.
+type Protection struct {
+	Type Type    `json:"type"`
+	KMS  *KMSKey `json:"kms,omitempty"`
+	// Sealed is the password encrypted by the KMS key, or the owner's share
+	// sealed with the passphrase
+	Sealed []byte `json:"sealed,omitempty"`
+	// Salt and Iterations derive the key the passphrase seals with
+	Salt       []byte `json:"salt,omitempty"`
+	Iterations int    `json:"iterations,omitempty"`
+	// ShareIndex is the index of the sealed share (passphrase)
+	ShareIndex byte      `json:"share_index,omitempty"`
+	CreatedAt  time.Time `json:"created_at,omitzero"`
+}
+
+// Unlock is what a restore brings to recover the password with
+type Unlock struct {
+	// Password is the password held on this node (consensus)
+	Password []byte
+	// LocalShare is this node's share (shamir)
+	LocalShare *sss.Share
+	// PeerShare is the share an approved request released (shamir, passphrase)
+	PeerShare []byte
+	// Passphrase opens the sealed share (passphrase)
+	Passphrase string
+}
+
+// Mechanism recovers the repository password one way
+type Mechanism interface {
+	// Validate checks that p holds what the mechanism needs
+	Validate(p Protection) error
+	// Recover reconstructs the password from p and what in brings
+	Recover(ctx context.Context, p Protection, in Unlock) ([]byte, error)
+}
+
+var mechanisms = map[Type]Mechanism{
+	TypeShamir:     shamir{},
+	TypeConsensus:  consensus{},
+	TypeKMS:        kms{},
+	TypePassphrase: passphrase{},
+}
+
+// Types lists the protection types
+func Types() []Type {
+	return []Type{TypeShamir, TypeConsensus, TypeKMS, TypePassphrase}
+}
+
+// ParseType parses a protection type name
+func ParseType(s string) (Type, error) {
+	if _, ok := mechanisms[Type(s)]; ok {
+		return Type(s), nil
+	}
+	names := make([]string, 0, len(mechanisms))
+	for _, t := range Types() {
+		names = append(names, string(t))
+	}
+	return "", fmt.Errorf("unknown key protection %q (use %s)", s, strings.Join(names, ", "))
+}
+
+// Validate checks the protection's type and data
+func (p Protection) Validate() error {
+	m, ok := mechanisms[p.Type]
+	if !ok {
+		_, err := ParseType(string(p.Type))
+		return err
+	}
+	return m.Validate(p)
+}
+
+// String describes the protection, e.g. "kms (aws arn:aws:kms:...)"
+func (p Protection) String() string {
+	if p.KMS != nil {
+		return fmt.Sprintf("%s (%s %s)", p.Type, p.KMS.Provider, p.KMS.Key)
+	}
+	return string(p.Type)
+}
+
+// Recover tries each protection in order, or only those of type only if
+// it's set, and returns the password from the first that recovers it and
+// the type that did
+func Recover(ctx context.Context, protections []Protection, only Type, in Unlock) ([]byte, Type, error) {
+	var errs []error
+	for _, p := range protections {
+		if only != "" && p.Type != only {
+			continue
+		}
+		if err := p.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Type, err))
+			continue
+		}
+		password, err := mechanisms[p.Type].Recover(ctx, p, in)
+		if err == nil {
+			return password, p.Type, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", p.Type, err))
+	}
+	if len(errs) == 0 {
+		if only != "" {
+			return nil, "", fmt.Errorf("this vault has no %s key protection", only)
+		}
+		return nil, "", errors.New("this vault has no key protection to recover the password with")
+	}
+	return nil, "", fmt.Errorf("failed to recover the repository password: %w", errors.Join(errs...))
+}
+
+// peerIndex is the index of the peer's share in a 2-of-2 split, given ours
+func peerIndex(local byte) byte {
+	if local == 1 {
+		return 2
+	}
+	return 1
+}
+
+// combine reconstructs the password from our share and the peer's
+func combine(local sss.Share, peerShare []byte) ([]byte, error) {
+	if peerShare == nil {
+		return nil, errors.New("approved request missing share data")
+	}
+	password, err := sss.Combine([]sss.Share{
+		local,
+		{Index: peerIndex(local.Index), Data: peerShare},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconstruct password: %w", err)
+	}
+	return password, nil
+}
+
+// shamir combines this node's share with the peer's
+type shamir struct{}
+
+func (shamir) Validate(Protection) error { return nil }
+
+func (shamir) Recover(_ context.Context, _ Protection, in Unlock) ([]byte, error) {
+	if in.LocalShare == nil {
+		return nil, errors.New("no key share on this node")
+	}
+	return combine(*in.LocalShare, in.PeerShare)
+}
+
+// consensus uses the password held on this node; the request's approvals
+// are what protect it
+type consensus struct{}
+
+func (consensus) Validate(Protection) error { return nil }
+
+func (consensus) Recover(_ context.Context, _ Protection, in Unlock) ([]byte, error) {
+	if len(in.Password) == 0 {
+		return nil, errors.New("no repository password on this node")
+	}
+	return in.Password, nil
+}
@@ -0,0 +1,136 @@
+package keyprotect
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/sss"
+)
+
+// fakeKMS replaces runKMS with a "KMS" that XORs its input with a key byte,
+// printing base64 for the aws CLI like the real one, and records the
+// commands run
+func fakeKMS(t *testing.T, fail error) *[]*exec.Cmd {
+	t.Helper()
+	var cmds []*exec.Cmd
+	orig := runKMS
+	runKMS = func(cmd *exec.Cmd) ([]byte, error) {
+		cmds = append(cmds, cmd)
+		if fail != nil {
+			return nil, fail
+		}
+		in, err := io.ReadAll(cmd.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]byte, len(in))
+		for i, b := range in {
+			out[i] = b ^ 0x5a
+		}
+		if cmd.Args[0] == "aws" {
+			return []byte(base64.StdEncoding.EncodeToString(out) + "\n"), nil
+		}
+		return out, nil
+	}
+	t.Cleanup(func() { runKMS = orig })
+	return &cmds
+}
+
+func TestShamirAndConsensus(t *testing.T) {
+	shares, err := sss.Split([]byte("repo-password"), 2, 2)
+	require.NoError(t, err)
+
+	password, used, err := Recover(context.Background(), []Protection{{Type: TypeShamir}}, "",
+		Unlock{LocalShare: &shares[0], PeerShare: shares[1].Data})
+	require.NoError(t, err)
+	assert.Equal(t, "repo-password", string(password))
+	assert.Equal(t, TypeShamir, used)
+
+	_, _, err = Recover(context.Background(), []Protection{{Type: TypeShamir}}, "", Unlock{LocalShare: &shares[0]})
+	assert.ErrorContains(t, err, "missing share data")
+
+	password, used, err = Recover(context.Background(), []Protection{{Type: TypeConsensus}}, "", Unlock{Password: []byte("held")})
+	require.NoError(t, err)
+	assert.Equal(t, "held", string(password))
+	assert.Equal(t, TypeConsensus, used)
+}
+
+func TestKMS(t *testing.T) {
+	cmds := fakeKMS(t, nil)
+
+	for _, provider := range []string{KMSProviderAWS, KMSProviderGCP} {
+		p, err := NewKMS(context.Background(), KMSKey{Provider: provider, Key: "key-1"}, []byte("repo-password"))
+		require.NoError(t, err, provider)
+		require.NoError(t, p.Validate())
+		assert.NotEqual(t, []byte("repo-password"), p.Sealed)
+
+		password, used, err := Recover(context.Background(), []Protection{p}, "", Unlock{})
+		require.NoError(t, err, provider)
+		assert.Equal(t, "repo-password", string(password))
+		assert.Equal(t, TypeKMS, used)
+	}
+	require.Len(t, *cmds, 6, "encrypt, check and decrypt for each provider")
+	assert.Equal(t, []string{"aws", "kms", "encrypt", "--key-id", "key-1"}, (*cmds)[0].Args[:5])
+	assert.Equal(t, []string{"gcloud", "kms", "decrypt", "--key", "key-1"}, (*cmds)[5].Args[:5])
+
+	_, err := NewKMS(context.Background(), KMSKey{Provider: "azure", Key: "k"}, []byte("x"))
+	assert.ErrorContains(t, err, "unknown KMS provider")
+
+	fakeKMS(t, errors.New("AccessDeniedException"))
+	_, err = NewKMS(context.Background(), KMSKey{Provider: KMSProviderAWS, Key: "key-1"}, []byte("x"))
+	assert.ErrorContains(t, err, "AccessDeniedException")
+}
+
+func TestPassphrase(t *testing.T) {
+	shares, err := sss.Split([]byte("repo-password"), 2, 2)
+	require.NoError(t, err)
+
+	_, err = NewPassphrase("short", shares[0])
+	assert.Error(t, err)
+	p, err := NewPassphrase("correct horse battery", shares[0])
+	require.NoError(t, err)
+	require.NoError(t, p.Validate())
+	assert.Equal(t, shares[0].Index, p.ShareIndex)
+
+	password, _, err := Recover(context.Background(), []Protection{p}, "",
+		Unlock{Passphrase: "correct horse battery", PeerShare: shares[1].Data})
+	require.NoError(t, err)
+	assert.Equal(t, "repo-password", string(password))
+
+	_, _, err = Recover(context.Background(), []Protection{p}, "",
+		Unlock{Passphrase: "wrong horse battery", PeerShare: shares[1].Data})
+	assert.ErrorContains(t, err, "wrong passphrase")
+	_, _, err = Recover(context.Background(), []Protection{p}, "", Unlock{PeerShare: shares[1].Data})
+	assert.ErrorContains(t, err, "passphrase is needed")
+}
+
+func TestRecoverTriesEachProtection(t *testing.T) {
+	fakeKMS(t, nil)
+	escrow, err := NewKMS(context.Background(), KMSKey{Provider: KMSProviderGCP, Key: "key-1"}, []byte("repo-password"))
+	require.NoError(t, err)
+	protections := []Protection{{Type: TypeShamir}, escrow}
+
+	// No share on this node, so the escrowed copy is used
+	password, used, err := Recover(context.Background(), protections, "", Unlock{})
+	require.NoError(t, err)
+	assert.Equal(t, "repo-password", string(password))
+	assert.Equal(t, TypeKMS, used)
+
+	_, _, err = Recover(context.Background(), protections, TypeShamir, Unlock{})
+	assert.ErrorContains(t, err, "no key share")
+	_, _, err = Recover(context.Background(), protections, TypePassphrase, Unlock{})
+	assert.ErrorContains(t, err, "no passphrase key protection")
+	_, _, err = Recover(context.Background(), nil, "", Unlock{})
+	assert.Error(t, err)
+
+	_, err = ParseType("yubikey")
+	assert.Error(t, err)
+	assert.Error(t, Protection{Type: "yubikey"}.Validate())
+}
@@ -0,0 +1,153 @@
+package keyprotect
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// KMSTimeout bounds a single call to a KMS
+const KMSTimeout = time.Minute
+
+// KMS providers
+const (
+	KMSProviderAWS = "aws"
+	KMSProviderGCP = "gcp"
+)
+
+// KMSKey is the cloud KMS key the password is escrowed with. Whoever can
+// use the key to decrypt (per the cloud account's IAM policy) can recover
+// the password, so the escrow is only as strict as that policy.
+type KMSKey struct {
+	Provider string `json:"provider"`
+	// Key is the key's ARN or alias (aws), or its full resource name,
+	// projects/.../locations/.../keyRings/.../cryptoKeys/... (gcp)
+	Key string `json:"key"`
+}
+
+// kmsPlugin calls one provider's CLI, which brings its own credentials
+type kmsPlugin struct {
+	// tool is the executable called
+	tool string
+	// encrypt and decrypt return the tool's arguments; data is passed on
+	// stdin and read from stdout
+	encrypt, decrypt func(key string) []string
+	// base64 is set if the tool prints its output base64-encoded
+	base64 bool
+}
+
+var kmsPlugins = map[string]kmsPlugin{
+	KMSProviderAWS: {
+		tool: "aws",
+		encrypt: func(key string) []string {
+			return []string{"kms", "encrypt", "--key-id", key, "--plaintext", "fileb:///dev/stdin",
+				"--output", "text", "--query", "CiphertextBlob"}
+		},
+		decrypt: func(key string) []string {
+			return []string{"kms", "decrypt", "--key-id", key, "--ciphertext-blob", "fileb:///dev/stdin",
+				"--output", "text", "--query", "Plaintext"}
+		},
+		base64: true,
+	},
+	KMSProviderGCP: {
+		tool: "gcloud",
+		encrypt: func(key string) []string {
+			return []string{"kms", "encrypt", "--key", key, "--plaintext-file", "-", "--ciphertext-file", "-"}
+		},
+		decrypt: func(key string) []string {
+			return []string{"kms", "decrypt", "--key", key, "--ciphertext-file", "-", "--plaintext-file", "-"}
+		},
+	},
+}
+
+// runKMS runs a KMS command and returns its stdout; tests replace it
+var runKMS = func(cmd *exec.Cmd) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, errors.New(msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// Validate checks the provider and key
+func (k *KMSKey) Validate() error {
+	if k == nil {
+		return errors.New("no KMS key")
+	}
+	if _, ok := kmsPlugins[k.Provider]; !ok {
+		return fmt.Errorf("unknown KMS provider %q (use %s or %s)", k.Provider, KMSProviderAWS, KMSProviderGCP)
+	}
+	if k.Key == "" {
+		return errors.New("no KMS key given")
+	}
+	return nil
+}
+
+// call encrypts or decrypts data with the key
+func (k *KMSKey) call(ctx context.Context, decrypt bool, data []byte) ([]byte, error) {
+	p := kmsPlugins[k.Provider]
+	args := p.encrypt(k.Key)
+	if decrypt {
+		args = p.decrypt(k.Key)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, KMSTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, p.tool, args...)
+	cmd.Stdin = bytes.NewReader(data)
+	out, err := runKMS(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("%s kms failed: %w", p.tool, err)
+	}
+	if p.base64 {
+		if out, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(out))); err != nil {
+			return nil, fmt.Errorf("%s kms returned invalid output: %w", p.tool, err)
+		}
+	}
+	return out, nil
+}
+
+// NewKMS escrows password with the KMS key
+func NewKMS(ctx context.Context, key KMSKey, password []byte) (Protection, error) {
+	if err := key.Validate(); err != nil {
+		return Protection{}, err
+	}
+	sealed, err := key.call(ctx, false, password)
+	if err != nil {
+		return Protection{}, fmt.Errorf("failed to escrow the password: %w", err)
+	}
+	p := Protection{Type: TypeKMS, KMS: &key, Sealed: sealed, CreatedAt: time.Now().UTC()}
+
+	// Make sure the escrowed copy can be read back before relying on it
+	if got, err := key.call(ctx, true, sealed); err != nil || !bytes.Equal(got, password) {
+		if err == nil {
+			err = errors.New("decrypted password doesn't match")
+		}
+		return Protection{}, fmt.Errorf("failed to check the escrowed password: %w", err)
+	}
+	return p, nil
+}
+
+// kms decrypts the escrowed password
+type kms struct{}
+
+func (kms) Validate(p Protection) error {
+	if len(p.Sealed) == 0 {
+		return errors.New("no escrowed password")
+	}
+	return p.KMS.Validate()
+}
+
+func (kms) Recover(ctx context.Context, p Protection, _ Unlock) ([]byte, error) {
+	return p.KMS.call(ctx, true, p.Sealed)
+}
@@ -0,0 +1,93 @@
+package keyprotect
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/sss"
+)
+
+const (
+	// MinPassphraseLength is the shortest passphrase accepted
+	MinPassphraseLength = 12
+	// PassphraseIterations is the PBKDF2 iteration count for new protections
+	PassphraseIterations = 600_000
+)
+
+// passphraseCipher derives the AEAD a passphrase seals the share with
+func passphraseCipher(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// NewPassphrase seals the owner's share with passphrase, so this node
+// keeps only the sealed share and a restore needs both the passphrase and
+// the share the peer releases on approval
+func NewPassphrase(passphrase string, share sss.Share) (Protection, error) {
+	if len(passphrase) < MinPassphraseLength {
+		return Protection{}, fmt.Errorf("passphrase must be at least %d characters", MinPassphraseLength)
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return Protection{}, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	aead, err := passphraseCipher(passphrase, salt, PassphraseIterations)
+	if err != nil {
+		return Protection{}, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return Protection{}, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return Protection{
+		Type:       TypePassphrase,
+		Sealed:     aead.Seal(nonce, nonce, share.Data, []byte{share.Index}),
+		Salt:       salt,
+		Iterations: PassphraseIterations,
+		ShareIndex: share.Index,
+		CreatedAt:  time.Now().UTC(),
+	}, nil
+}
+
+// passphrase opens the sealed share and combines it with the peer's
+type passphrase struct{}
+
+func (passphrase) Validate(p Protection) error {
+	if len(p.Sealed) == 0 || len(p.Salt) == 0 || p.Iterations <= 0 || p.ShareIndex == 0 {
+		return errors.New("incomplete sealed share")
+	}
+	return nil
+}
+
+func (passphrase) Recover(_ context.Context, p Protection, in Unlock) ([]byte, error) {
+	if in.Passphrase == "" {
+		return nil, errors.New("a passphrase is needed")
+	}
+	aead, err := passphraseCipher(in.Passphrase, p.Salt, p.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.Sealed) < aead.NonceSize() {
+		return nil, errors.New("sealed share is truncated")
+	}
+	nonce, sealed := p.Sealed[:aead.NonceSize()], p.Sealed[aead.NonceSize():]
+	share, err := aead.Open(nil, nonce, sealed, []byte{p.ShareIndex})
+	if err != nil {
+		return nil, errors.New("wrong passphrase")
+	}
+	return combine(sss.Share{Index: p.ShareIndex, Data: share}, in.PeerShare)
+}
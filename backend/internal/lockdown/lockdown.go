@@ -0,0 +1,188 @@
+// Package lockdown is the panic button for a node suspected of being
+// compromised. A locked-down node refuses new restore and deletion
+// requests and every approval, and its storage is read-only. Anyone who
+// can reach the node can lock it down, but lifting the lockdown takes the
+// signatures of enough key holders, so a thief holding one key can't
+// undo it.
+//
+// The lockdown is kept in the config directory, so it survives restarts
+// and is seen by every command and the running server alike.
+package lockdown
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+// FileName is the lockdown's file in the config directory
+const FileName = "lockdown.json"
+
+// Lockdown describes a lockdown in force
+type Lockdown struct {
+	// ID is shared by every node the lockdown was propagated to, so one
+	// set of signatures lifts it everywhere
+	ID          string    `json:"id"`
+	Reason      string    `json:"reason,omitempty"`
+	TriggeredBy string    `json:"triggered_by,omitempty"`
+	Origin      string    `json:"origin,omitempty"` // Node the panic button was pressed on
+	Since       time.Time `json:"since"`
+}
+
+// New returns a lockdown with a new ID, triggered on node origin
+func New(reason, triggeredBy, origin string) (*Lockdown, error) {
+	idBytes := make([]byte, 8)
+	if _, err := rand.Read(idBytes); err != nil {
+		return nil, err
+	}
+	return &Lockdown{
+		ID:          hex.EncodeToString(idBytes),
+		Reason:      reason,
+		TriggeredBy: triggeredBy,
+		Origin:      origin,
+		Since:       time.Now().UTC(),
+	}, nil
+}
+
+// Path is the lockdown file in config directory dir
+func Path(dir string) string {
+	return filepath.Join(dir, FileName)
+}
+
+// Load returns the lockdown in force in config directory dir, or nil. An
+// unreadable lockdown file still means someone pressed the panic button,
+// so it counts as a lockdown.
+func Load(dir string) *Lockdown {
+	data, err := os.ReadFile(Path(dir))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	l := &Lockdown{}
+	if err == nil {
+		err = json.Unmarshal(data, l)
+	}
+	if err != nil {
+		logging.Warnf("[lockdown] failed to read lockdown: %v", err)
+		return &Lockdown{Reason: "unreadable lockdown file"}
+	}
+	return l
+}
+
+// Check returns a CodeLockedDown error if config directory dir is locked down
+func Check(dir string) error {
+	l := Load(dir)
+	if l == nil {
+		return nil
+	}
+	msg := "node is locked down"
+	if l.Reason != "" {
+		msg += ": " + l.Reason
+	}
+	return apperrors.New(apperrors.CodeLockedDown, msg+"; approvals and new requests are suspended until enough key holders lift it")
+}
+
+// Apply locks down config directory dir with l, unless a lockdown is
+// already in force. It returns the lockdown in force and whether it is l.
+func Apply(dir string, l *Lockdown) (*Lockdown, bool, error) {
+	if current := Load(dir); current != nil {
+		return current, false, nil
+	}
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return nil, false, err
+	}
+	if err := os.WriteFile(Path(dir), data, 0600); err != nil {
+		return nil, false, fmt.Errorf("failed to save lockdown: %w", err)
+	}
+	return l, true, nil
+}
+
+// Lift ends lockdown id in config directory dir. The signatures that allow
+// it must have been checked with Signers.Verify.
+func Lift(dir, id string) error {
+	current := Load(dir)
+	if current == nil {
+		return nil
+	}
+	if current.ID != id {
+		return apperrors.Newf(apperrors.CodeInvalidArgument, "lockdown %s is not in force here", id)
+	}
+	if err := os.Remove(Path(dir)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to lift lockdown: %w", err)
+	}
+	return nil
+}
+
+// LiftSignData is what key holders sign to lift lockdown id
+func LiftSignData(id string) []byte {
+	return []byte("airgapper lockdown lift\x00" + id)
+}
+
+// Signature is a key holder's signature lifting a lockdown
+type Signature struct {
+	KeyID     string `json:"key_id"`
+	Signature []byte `json:"signature"`
+}
+
+// Sign signs the lifting of lockdown id
+func Sign(id string, privateKey, publicKey []byte) (Signature, error) {
+	sig, err := crypto.Sign(privateKey, LiftSignData(id))
+	if err != nil {
+		return Signature{}, err
+	}
+	return Signature{KeyID: crypto.KeyID(publicKey), Signature: sig}, nil
+}
+
+// String formats s as keyID:hex, as given to 'airgapper panic lift --sig'
+func (s Signature) String() string {
+	return s.KeyID + ":" + hex.EncodeToString(s.Signature)
+}
+
+// ParseSignature parses a signature formatted by Signature.String
+func ParseSignature(s string) (Signature, error) {
+	keyID, sigHex, ok := strings.Cut(s, ":")
+	sig, err := hex.DecodeString(sigHex)
+	if !ok || keyID == "" || err != nil || len(sig) == 0 {
+		return Signature{}, apperrors.Newf(apperrors.CodeInvalidArgument, "invalid lift signature %q: want keyID:hex", s)
+	}
+	return Signature{KeyID: keyID, Signature: sig}, nil
+}
+
+// Signers are the keys whose signatures can lift a lockdown, and how many
+// of them it takes
+type Signers struct {
+	Keys      map[string][]byte // Key ID -> public key
+	Threshold int
+}
+
+// Verify checks that sigs lift lockdown id: each must be a valid signature
+// by one of the signers, and enough distinct signers must have signed
+func (s Signers) Verify(id string, sigs []Signature) error {
+	if len(s.Keys) == 0 {
+		return apperrors.New(apperrors.CodeFailedPrecondition, "no key holder keys are configured to lift the lockdown with; lift it on the node itself")
+	}
+	signed := make(map[string]bool)
+	for _, sig := range sigs {
+		pub, ok := s.Keys[sig.KeyID]
+		if !ok {
+			return apperrors.Newf(apperrors.CodeInvalidSignature, "key %s may not lift the lockdown", sig.KeyID)
+		}
+		if !crypto.Verify(pub, LiftSignData(id), sig.Signature) {
+			return apperrors.Newf(apperrors.CodeInvalidSignature, "invalid lift signature from key %s", sig.KeyID)
+		}
+		signed[sig.KeyID] = true
+	}
+	if len(signed) < s.Threshold {
+		return apperrors.Newf(apperrors.CodeInsufficientApprovals, "lifting the lockdown takes %d key holders' signatures, have %d", s.Threshold, len(signed))
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package lockdown
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+func TestApplyAndLift(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, Load(dir))
+	assert.NoError(t, Check(dir))
+
+	l, err := New("laptop stolen", "alice", "alice")
+	require.NoError(t, err)
+	current, applied, err := Apply(dir, l)
+	require.NoError(t, err)
+	assert.True(t, applied)
+	assert.Equal(t, l.ID, current.ID)
+	assert.Equal(t, apperrors.CodeLockedDown, apperrors.CodeOf(Check(dir)))
+
+	// A second panic, e.g. propagated back from a peer, keeps the first
+	other, err := New("again", "bob", "bob")
+	require.NoError(t, err)
+	current, applied, err = Apply(dir, other)
+	require.NoError(t, err)
+	assert.False(t, applied)
+	assert.Equal(t, l.ID, current.ID)
+
+	assert.Error(t, Lift(dir, other.ID))
+	require.NoError(t, Lift(dir, l.ID))
+	assert.Nil(t, Load(dir))
+	assert.NoError(t, Lift(dir, l.ID), "lifting twice is harmless")
+}
+
+func TestUnreadableLockdown(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(Path(dir), []byte("{"), 0600))
+
+	l := Load(dir)
+	require.NotNil(t, l, "an unreadable lockdown still locks the node down")
+	assert.Equal(t, apperrors.CodeLockedDown, apperrors.CodeOf(Check(dir)))
+}
+
+func TestVerifyLift(t *testing.T) {
+	keys := make(map[string][]byte)
+	var sigs []Signature
+	for range 3 {
+		pub, priv, err := crypto.GenerateKeyPair()
+		require.NoError(t, err)
+		keys[crypto.KeyID(pub)] = pub
+		sig, err := Sign("abcd", priv, pub)
+		require.NoError(t, err)
+		sigs = append(sigs, sig)
+	}
+	signers := Signers{Keys: keys, Threshold: 2}
+
+	assert.NoError(t, signers.Verify("abcd", sigs[:2]))
+	assert.Equal(t, apperrors.CodeInsufficientApprovals, apperrors.CodeOf(signers.Verify("abcd", sigs[:1])))
+	assert.Equal(t, apperrors.CodeInsufficientApprovals, apperrors.CodeOf(signers.Verify("abcd", []Signature{sigs[0], sigs[0]})),
+		"one key signing twice counts once")
+	assert.Equal(t, apperrors.CodeInvalidSignature, apperrors.CodeOf(signers.Verify("other", sigs[:2])),
+		"signatures lift only the lockdown they were made for")
+
+	stranger, strangerPriv, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+	sig, err := Sign("abcd", strangerPriv, stranger)
+	require.NoError(t, err)
+	assert.Equal(t, apperrors.CodeInvalidSignature, apperrors.CodeOf(signers.Verify("abcd", []Signature{sig, sigs[0]})))
+
+	parsed, err := ParseSignature(sigs[0].String())
+	require.NoError(t, err)
+	assert.Equal(t, sigs[0], parsed)
+	_, err = ParseSignature("nohex")
+	assert.Error(t, err)
+
+	assert.Error(t, Signers{}.Verify("abcd", sigs), "no keys to check against")
+}
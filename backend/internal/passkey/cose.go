@@ -0,0 +1,113 @@
+package passkey
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE algorithm identifiers of the supported credential keys, in the
+// order they are offered to authenticators
+const (
+	AlgES256 = -7
+	AlgEdDSA = -8
+	AlgRS256 = -257
+)
+
+// Algorithms are the COSE algorithms a passkey may use
+var Algorithms = []int{AlgES256, AlgEdDSA, AlgRS256}
+
+// COSE key parameters (RFC 9053)
+const (
+	coseKty    = 1
+	coseAlg    = 3
+	coseCrv    = -1 // EC2 and OKP
+	coseX      = -2 // EC2 and OKP
+	coseY      = -3 // EC2
+	coseRSAN   = -1
+	coseRSAE   = -2
+	ktyOKP     = 1
+	ktyEC2     = 2
+	ktyRSA     = 3
+	crvP256    = 1
+	crvEd25519 = 6
+)
+
+// ErrBadSignature means an assertion's signature doesn't verify
+var ErrBadSignature = errors.New("passkey signature does not verify")
+
+// publicKey is a credential's public key, decoded from its COSE form
+type publicKey struct {
+	alg int
+	key crypto.PublicKey
+}
+
+// parsePublicKey decodes a COSE_Key
+func parsePublicKey(cose []byte) (*publicKey, error) {
+	item, rest, err := decodeCBOR(cose)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: trailing bytes after public key", errCBOR)
+	}
+	m, ok := item.(map[any]any)
+	if !ok {
+		return nil, fmt.Errorf("public key is not a COSE key")
+	}
+	kty, _ := m[int64(coseKty)].(int64)
+	alg, _ := m[int64(coseAlg)].(int64)
+
+	switch {
+	case alg == AlgES256 && kty == ktyEC2:
+		x, _ := m[int64(coseX)].([]byte)
+		y, _ := m[int64(coseY)].([]byte)
+		if crv, _ := m[int64(coseCrv)].(int64); crv != crvP256 || len(x) != 32 || len(y) != 32 {
+			return nil, fmt.Errorf("ES256 key is not a P-256 point")
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if _, err := key.ECDH(); err != nil {
+			return nil, fmt.Errorf("ES256 key is not on the curve")
+		}
+		return &publicKey{alg: AlgES256, key: key}, nil
+	case alg == AlgEdDSA && kty == ktyOKP:
+		x, _ := m[int64(coseX)].([]byte)
+		if crv, _ := m[int64(coseCrv)].(int64); crv != crvEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("EdDSA key is not an Ed25519 key")
+		}
+		return &publicKey{alg: AlgEdDSA, key: ed25519.PublicKey(x)}, nil
+	case alg == AlgRS256 && kty == ktyRSA:
+		n, _ := m[int64(coseRSAN)].([]byte)
+		e, _ := m[int64(coseRSAE)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("RS256 key is too small or malformed")
+		}
+		exp := new(big.Int).SetBytes(e)
+		return &publicKey{alg: AlgRS256, key: &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}}, nil
+	}
+	return nil, fmt.Errorf("unsupported passkey algorithm %d", alg)
+}
+
+// verify checks sig over data
+func (k *publicKey) verify(data, sig []byte) error {
+	digest := sha256.Sum256(data)
+	var ok bool
+	switch key := k.key.(type) {
+	case *ecdsa.PublicKey:
+		ok = ecdsa.VerifyASN1(key, digest[:], sig)
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(key, data, sig)
+	case *rsa.PublicKey:
+		ok = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) == nil
+	}
+	if !ok {
+		return ErrBadSignature
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package passkey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+// FileName is the passkeys' file in the config directory
+const FileName = "passkeys.json"
+
+// EnrollmentTTL is how long an enrollment link stays usable
+const EnrollmentTTL = 15 * time.Minute
+
+// ErrEnrollmentInvalid means an enrollment token is unknown, used or expired
+var ErrEnrollmentInvalid = apperrors.New(apperrors.CodeUnauthenticated, "enrollment link is invalid or has expired; create a new one with 'airgapper passkey enroll'")
+
+// Credential is a passkey registered with this node
+type Credential struct {
+	ID   string `json:"id"` // base64url credential ID
+	Name string `json:"name"`
+	// KeyHolderID is the key holder the passkey approves as: this node's
+	// key, or empty on a node without one (where it releases the share)
+	KeyHolderID string     `json:"key_holder_id,omitempty"`
+	RPID        string     `json:"rp_id"`
+	Origin      string     `json:"origin"`
+	PublicKey   []byte     `json:"public_key"` // COSE_Key
+	SignCount   uint32     `json:"sign_count"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+}
+
+// enrollment is a one-time token letting a phone register a passkey. Only
+// its hash is kept.
+type enrollment struct {
+	TokenHash string    `json:"token_hash"`
+	Name      string    `json:"name"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type storeFile struct {
+	Credentials []Credential `json:"credentials,omitempty"`
+	Enrollments []enrollment `json:"enrollments,omitempty"`
+}
+
+// Store is the passkeys file of a config directory. It is safe for
+// concurrent use within a process.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore returns the passkeys of config directory dir
+func NewStore(dir string) *Store {
+	return &Store{path: filepath.Join(dir, FileName)}
+}
+
+// List returns the registered passkeys, oldest first
+func (s *Store) List() ([]Credential, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := s.load()
+	return f.Credentials, err
+}
+
+// Get returns the passkey with credential ID id
+func (s *Store) Get(id string) (*Credential, error) {
+	list, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		if list[i].ID == id {
+			return &list[i], nil
+		}
+	}
+	return nil, apperrors.ErrPasskeyNotFound
+}
+
+// Remove unregisters a passkey
+func (s *Store) Remove(id string) error {
+	return s.update(func(f *storeFile) error {
+		n := len(f.Credentials)
+		f.Credentials = slices.DeleteFunc(f.Credentials, func(c Credential) bool { return c.ID == id })
+		if len(f.Credentials) == n {
+			return apperrors.ErrPasskeyNotFound
+		}
+		return nil
+	})
+}
+
+// Enroll creates a one-time enrollment token for a passkey called name
+func (s *Store) Enroll(name string, now time.Time) (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := Encoding.EncodeToString(b)
+	err := s.update(func(f *storeFile) error {
+		f.Enrollments = append(f.Enrollments, enrollment{TokenHash: hashToken(token), Name: name, ExpiresAt: now.Add(EnrollmentTTL)})
+		return nil
+	})
+	return token, err
+}
+
+// CheckEnrollment reports whether token can still enroll a passkey
+func (s *Store) CheckEnrollment(token string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := s.load()
+	if err != nil {
+		return err
+	}
+	if findEnrollment(&f, token, now) < 0 {
+		return ErrEnrollmentInvalid
+	}
+	return nil
+}
+
+// Add registers cred under the enrollment token, which is used up
+func (s *Store) Add(token string, cred *Credential, now time.Time) error {
+	return s.update(func(f *storeFile) error {
+		i := findEnrollment(f, token, now)
+		if i < 0 {
+			return ErrEnrollmentInvalid
+		}
+		if slices.ContainsFunc(f.Credentials, func(c Credential) bool { return c.ID == cred.ID }) {
+			return apperrors.New(apperrors.CodeAlreadyExists, "passkey is already registered")
+		}
+		cred.Name, cred.CreatedAt = f.Enrollments[i].Name, now.UTC()
+		f.Enrollments = slices.Delete(f.Enrollments, i, i+1)
+		f.Credentials = append(f.Credentials, *cred)
+		return nil
+	})
+}
+
+// Used records a passkey's sign-in and its new signature counter
+func (s *Store) Used(id string, signCount uint32, now time.Time) error {
+	return s.update(func(f *storeFile) error {
+		for i := range f.Credentials {
+			if f.Credentials[i].ID == id {
+				at := now.UTC()
+				f.Credentials[i].SignCount, f.Credentials[i].LastUsedAt = signCount, &at
+				return nil
+			}
+		}
+		return apperrors.ErrPasskeyNotFound
+	})
+}
+
+// findEnrollment returns the index of token's live enrollment, or -1
+func findEnrollment(f *storeFile, token string, now time.Time) int {
+	hash := hashToken(token)
+	for i, e := range f.Enrollments {
+		if now.Before(e.ExpiresAt) && subtle.ConstantTimeCompare([]byte(hash), []byte(e.TokenHash)) == 1 {
+			return i
+		}
+	}
+	return -1
+}
+
+// update loads the file, applies fn and saves the result. Expired
+// enrollments are dropped on every update.
+func (s *Store) update(fn func(*storeFile) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := s.load()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	f.Enrollments = slices.DeleteFunc(f.Enrollments, func(e enrollment) bool { return !now.Before(e.ExpiresAt) })
+	if err := fn(&f); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save passkeys: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) load() (storeFile, error) {
+	var f storeFile
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return f, err
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return f, fmt.Errorf("failed to parse passkeys: %w", err)
+	}
+	return f, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,42 @@
+package passkey
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+func TestStore(t *testing.T) {
+	store := NewStore(t.TempDir())
+	now := time.Now()
+
+	token, err := store.Enroll("bob's phone", now)
+	require.NoError(t, err)
+	require.NoError(t, store.CheckEnrollment(token, now))
+	assert.ErrorIs(t, store.CheckEnrollment("guess", now), ErrEnrollmentInvalid)
+	assert.ErrorIs(t, store.CheckEnrollment(token, now.Add(EnrollmentTTL)), ErrEnrollmentInvalid, "enrollment links expire")
+
+	cred := &Credential{ID: "cred1", RPID: "bob-nas.local", Origin: "https://bob-nas.local:8082", PublicKey: []byte{1}}
+	require.NoError(t, store.Add(token, cred, now))
+	assert.ErrorIs(t, store.Add(token, &Credential{ID: "cred2"}, now), ErrEnrollmentInvalid, "an enrollment link registers one passkey")
+
+	got, err := store.Get("cred1")
+	require.NoError(t, err)
+	assert.Equal(t, "bob's phone", got.Name)
+	assert.Nil(t, got.LastUsedAt)
+
+	require.NoError(t, store.Used("cred1", 7, now))
+	got, err = store.Get("cred1")
+	require.NoError(t, err)
+	assert.Equal(t, uint32(7), got.SignCount)
+	assert.NotNil(t, got.LastUsedAt)
+
+	require.NoError(t, store.Remove("cred1"))
+	_, err = store.Get("cred1")
+	assert.Equal(t, apperrors.CodePasskeyNotFound, apperrors.CodeOf(err))
+	assert.Equal(t, apperrors.CodePasskeyNotFound, apperrors.CodeOf(store.Remove("cred1")))
+}
@@ -0,0 +1,262 @@
+// Package passkey lets a key holder approve requests from a phone. It is a
+// minimal WebAuthn relying party: it registers passkeys ("none"
+// attestation; the authenticator's make isn't checked) and verifies their
+// assertions, so a passkey can stand in for the key holder at this node.
+// The node still signs approvals with its own key.
+package passkey
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// Authenticator data flags
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttested     = 0x40
+)
+
+// Client data types
+const (
+	typeCreate = "webauthn.create"
+	typeGet    = "webauthn.get"
+)
+
+// ErrChallenge means the client answered a challenge this node didn't
+// issue, or one that was already used or has expired
+var ErrChallenge = errors.New("unknown or expired passkey challenge")
+
+// Encoding is how credential IDs and challenges are passed around: the
+// unpadded base64url WebAuthn uses
+var Encoding = base64.RawURLEncoding
+
+// RelyingParty is the site a passkey is registered for: the node's host
+// name, and the origin its approval page was served from. Each credential
+// keeps its own, so the page can be reached under several names.
+type RelyingParty struct {
+	ID     string
+	Origin string
+}
+
+// NewRelyingParty returns the relying party serving origin, e.g.
+// "https://bob-nas.local:8082"
+func NewRelyingParty(origin string) (RelyingParty, error) {
+	u, err := url.Parse(origin)
+	if err != nil || u.Hostname() == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return RelyingParty{}, fmt.Errorf("invalid approval page origin %q", origin)
+	}
+	return RelyingParty{ID: u.Hostname(), Origin: u.Scheme + "://" + u.Host}, nil
+}
+
+// NewChallenge returns a random challenge for a ceremony
+func NewChallenge() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return Encoding.EncodeToString(b), nil
+}
+
+// clientData is the part of the client's collected data that is checked
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// checkClientData checks the client data is of wantType, answers challenge
+// and comes from the relying party's origin
+func (rp RelyingParty) checkClientData(raw []byte, wantType, challenge string) error {
+	var cd clientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return fmt.Errorf("invalid client data: %w", err)
+	}
+	if cd.Type != wantType {
+		return fmt.Errorf("client data is for %q, not %q", cd.Type, wantType)
+	}
+	if subtle.ConstantTimeCompare([]byte(cd.Challenge), []byte(challenge)) != 1 {
+		return ErrChallenge
+	}
+	if cd.Origin != rp.Origin {
+		return fmt.Errorf("passkey used from %s, not %s", cd.Origin, rp.Origin)
+	}
+	return nil
+}
+
+// authData is parsed authenticator data
+type authData struct {
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	credentialID []byte
+	publicKey    []byte // COSE_Key, if attested credential data is present
+}
+
+// parseAuthData parses authenticator data, including the attested
+// credential data a registration carries. Extensions are ignored.
+func parseAuthData(data []byte) (*authData, error) {
+	if len(data) < 37 {
+		return nil, fmt.Errorf("authenticator data too short")
+	}
+	ad := &authData{
+		rpIDHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	if ad.flags&flagAttested == 0 {
+		return ad, nil
+	}
+
+	rest := data[37:]
+	if len(rest) < 18 {
+		return nil, fmt.Errorf("attested credential data too short")
+	}
+	idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if idLen == 0 || idLen > 1023 || len(rest) < idLen {
+		return nil, fmt.Errorf("invalid credential ID length %d", idLen)
+	}
+	ad.credentialID = rest[:idLen]
+	rest = rest[idLen:]
+	// The public key runs up to any extensions
+	_, after, err := decodeCBOR(rest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credential public key: %w", err)
+	}
+	ad.publicKey = rest[:len(rest)-len(after)]
+	return ad, nil
+}
+
+// check checks the data is for the relying party, with the user present
+// and verified
+func (ad *authData) check(rpID string) error {
+	hash := sha256.Sum256([]byte(rpID))
+	if !bytes.Equal(ad.rpIDHash, hash[:]) {
+		return fmt.Errorf("passkey is not registered for %s", rpID)
+	}
+	if ad.flags&flagUserPresent == 0 || ad.flags&flagUserVerified == 0 {
+		return fmt.Errorf("the authenticator did not verify the user")
+	}
+	return nil
+}
+
+// Registration is the browser's answer to a navigator.credentials.create()
+// call, with binary fields base64url-encoded
+type Registration struct {
+	ID                string `json:"id"`
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AttestationObject string `json:"attestationObject"`
+}
+
+// Register checks a registration answers challenge and returns the new
+// credential, not yet named or stored
+func (rp RelyingParty) Register(reg Registration, challenge string) (*Credential, error) {
+	clientDataJSON, err := Encoding.DecodeString(reg.ClientDataJSON)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client data encoding")
+	}
+	if err := rp.checkClientData(clientDataJSON, typeCreate, challenge); err != nil {
+		return nil, err
+	}
+	attestation, err := Encoding.DecodeString(reg.AttestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attestation encoding")
+	}
+	item, _, err := decodeCBOR(attestation)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := item.(map[any]any)
+	if !ok {
+		return nil, fmt.Errorf("attestation is not a map")
+	}
+	raw, _ := obj["authData"].([]byte)
+	ad, err := parseAuthData(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := ad.check(rp.ID); err != nil {
+		return nil, err
+	}
+	if ad.publicKey == nil {
+		return nil, fmt.Errorf("registration carries no credential")
+	}
+	if _, err := parsePublicKey(ad.publicKey); err != nil {
+		return nil, err
+	}
+	id := Encoding.EncodeToString(ad.credentialID)
+	if reg.ID != "" && reg.ID != id {
+		return nil, fmt.Errorf("registration ID doesn't match its credential")
+	}
+	return &Credential{
+		ID:        id,
+		RPID:      rp.ID,
+		Origin:    rp.Origin,
+		PublicKey: ad.publicKey,
+		SignCount: ad.signCount,
+	}, nil
+}
+
+// Assertion is the browser's answer to a navigator.credentials.get() call,
+// with binary fields base64url-encoded
+type Assertion struct {
+	ID                string `json:"id"`
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AuthenticatorData string `json:"authenticatorData"`
+	Signature         string `json:"signature"`
+}
+
+// Verify checks an assertion by the passkey answers challenge, returning
+// the authenticator's new signature counter. A counter that didn't move
+// forward means the passkey may have been cloned.
+func (cred *Credential) Verify(a Assertion, challenge string) (uint32, error) {
+	if a.ID != cred.ID {
+		return 0, fmt.Errorf("assertion is not by passkey %s", cred.ID)
+	}
+	rp := RelyingParty{ID: cred.RPID, Origin: cred.Origin}
+	clientDataJSON, err := Encoding.DecodeString(a.ClientDataJSON)
+	if err != nil {
+		return 0, fmt.Errorf("invalid client data encoding")
+	}
+	if err := rp.checkClientData(clientDataJSON, typeGet, challenge); err != nil {
+		return 0, err
+	}
+	rawAuthData, err := Encoding.DecodeString(a.AuthenticatorData)
+	if err != nil {
+		return 0, fmt.Errorf("invalid authenticator data encoding")
+	}
+	ad, err := parseAuthData(rawAuthData)
+	if err != nil {
+		return 0, err
+	}
+	if err := ad.check(rp.ID); err != nil {
+		return 0, err
+	}
+	sig, err := Encoding.DecodeString(a.Signature)
+	if err != nil {
+		return 0, fmt.Errorf("invalid signature encoding")
+	}
+
+	key, err := parsePublicKey(cred.PublicKey)
+	if err != nil {
+		return 0, err
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	if err := key.verify(append(rawAuthData, clientDataHash[:]...), sig); err != nil {
+		return 0, err
+	}
+	// Authenticators that don't count always report 0
+	if (ad.signCount != 0 || cred.SignCount != 0) && ad.signCount <= cred.SignCount {
+		return 0, fmt.Errorf("passkey signature counter went back (%d after %d); it may have been cloned", ad.signCount, cred.SignCount)
+	}
+	return ad.signCount, nil
+}
@@ -0,0 +1,233 @@
+package passkey
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodeCBOR encodes the values test authenticators produce
+func encodeCBOR(v any) []byte {
+	head := func(major byte, n uint64) []byte {
+		switch {
+		case n < 24:
+			return []byte{major<<5 | byte(n)}
+		case n < 1<<8:
+			return []byte{major<<5 | 24, byte(n)}
+		case n < 1<<16:
+			return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+		}
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(n))
+	}
+	switch v := v.(type) {
+	case int:
+		if v < 0 {
+			return head(1, uint64(-1-v))
+		}
+		return head(0, uint64(v))
+	case []byte:
+		return append(head(2, uint64(len(v))), v...)
+	case string:
+		return append(head(3, uint64(len(v))), v...)
+	case []any:
+		out := head(4, uint64(len(v)))
+		for _, item := range v {
+			out = append(out, encodeCBOR(item)...)
+		}
+		return out
+	case map[any]any:
+		out := head(5, uint64(len(v)))
+		for k, item := range v {
+			out = append(out, encodeCBOR(k)...)
+			out = append(out, encodeCBOR(item)...)
+		}
+		return out
+	case bool:
+		if v {
+			return []byte{0xf5}
+		}
+		return []byte{0xf4}
+	}
+	panic("unsupported CBOR value")
+}
+
+// authenticator is a software passkey
+type authenticator struct {
+	credentialID []byte
+	cose         []byte
+	sign         func(data []byte) []byte
+	counter      uint32
+	flags        byte
+}
+
+func newES256Authenticator(t *testing.T) *authenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	x, y := make([]byte, 32), make([]byte, 32)
+	key.X.FillBytes(x)
+	key.Y.FillBytes(y)
+	return &authenticator{
+		credentialID: []byte("es256-credential"),
+		cose:         encodeCBOR(map[any]any{coseKty: ktyEC2, coseAlg: AlgES256, coseCrv: crvP256, coseX: x, coseY: y}),
+		sign: func(data []byte) []byte {
+			digest := sha256.Sum256(data)
+			sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+			require.NoError(t, err)
+			return sig
+		},
+		flags: flagUserPresent | flagUserVerified,
+	}
+}
+
+func newEd25519Authenticator(t *testing.T) *authenticator {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return &authenticator{
+		credentialID: []byte("ed25519-credential"),
+		cose:         encodeCBOR(map[any]any{coseKty: ktyOKP, coseAlg: AlgEdDSA, coseCrv: crvEd25519, coseX: []byte(pub)}),
+		sign:         func(data []byte) []byte { return ed25519.Sign(priv, data) },
+		flags:        flagUserPresent | flagUserVerified,
+	}
+}
+
+func (a *authenticator) authData(rpID string, attested bool) []byte {
+	hash := sha256.Sum256([]byte(rpID))
+	flags := a.flags
+	if attested {
+		flags |= flagAttested
+	}
+	data := append(hash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.counter)
+	if attested {
+		data = append(data, make([]byte, 16)...) // AAGUID
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.cose...)
+	}
+	return data
+}
+
+func clientDataJSON(t *testing.T, typ, challenge, origin string) []byte {
+	data, err := json.Marshal(map[string]string{"type": typ, "challenge": challenge, "origin": origin})
+	require.NoError(t, err)
+	return data
+}
+
+func (a *authenticator) create(t *testing.T, rp RelyingParty, challenge string) Registration {
+	att := encodeCBOR(map[any]any{"fmt": "none", "attStmt": map[any]any{}, "authData": a.authData(rp.ID, true)})
+	return Registration{
+		ID:                Encoding.EncodeToString(a.credentialID),
+		ClientDataJSON:    Encoding.EncodeToString(clientDataJSON(t, typeCreate, challenge, rp.Origin)),
+		AttestationObject: Encoding.EncodeToString(att),
+	}
+}
+
+func (a *authenticator) get(t *testing.T, rp RelyingParty, challenge string) Assertion {
+	a.counter++
+	authData := a.authData(rp.ID, false)
+	cd := clientDataJSON(t, typeGet, challenge, rp.Origin)
+	hash := sha256.Sum256(cd)
+	return Assertion{
+		ID:                Encoding.EncodeToString(a.credentialID),
+		ClientDataJSON:    Encoding.EncodeToString(cd),
+		AuthenticatorData: Encoding.EncodeToString(authData),
+		Signature:         Encoding.EncodeToString(a.sign(append(authData, hash[:]...))),
+	}
+}
+
+func TestRegisterAndVerify(t *testing.T) {
+	rp, err := NewRelyingParty("https://bob-nas.local:8082/approve.html")
+	require.NoError(t, err)
+	assert.Equal(t, RelyingParty{ID: "bob-nas.local", Origin: "https://bob-nas.local:8082"}, rp)
+
+	for name, newAuthenticator := range map[string]func(*testing.T) *authenticator{
+		"ES256": newES256Authenticator,
+		"EdDSA": newEd25519Authenticator,
+	} {
+		t.Run(name, func(t *testing.T) {
+			a := newAuthenticator(t)
+			challenge, err := NewChallenge()
+			require.NoError(t, err)
+			cred, err := rp.Register(a.create(t, rp, challenge), challenge)
+			require.NoError(t, err)
+			assert.Equal(t, Encoding.EncodeToString(a.credentialID), cred.ID)
+			assert.Equal(t, rp.Origin, cred.Origin)
+
+			challenge, err = NewChallenge()
+			require.NoError(t, err)
+			count, err := cred.Verify(a.get(t, rp, challenge), challenge)
+			require.NoError(t, err)
+			assert.Equal(t, uint32(1), count)
+			cred.SignCount = count
+
+			replayed := a.get(t, rp, challenge)
+			_, err = cred.Verify(replayed, "another-challenge")
+			assert.ErrorIs(t, err, ErrChallenge)
+
+			tampered := a.get(t, rp, challenge)
+			tampered.Signature = Encoding.EncodeToString([]byte("not a signature"))
+			_, err = cred.Verify(tampered, challenge)
+			assert.ErrorIs(t, err, ErrBadSignature)
+
+			a.counter = 0
+			_, err = cred.Verify(a.get(t, rp, challenge), challenge)
+			assert.ErrorContains(t, err, "may have been cloned")
+		})
+	}
+
+	t.Run("rejected ceremonies", func(t *testing.T) {
+		a := newES256Authenticator(t)
+		_, err := rp.Register(a.create(t, rp, "issued"), "other")
+		assert.ErrorIs(t, err, ErrChallenge)
+
+		phishing := RelyingParty{ID: "bob-nas.local", Origin: "https://bob-nas.local:9999"}
+		_, err = rp.Register(a.create(t, phishing, "issued"), "issued")
+		assert.ErrorContains(t, err, "passkey used from")
+
+		other := RelyingParty{ID: "evil.example", Origin: rp.Origin}
+		_, err = rp.Register(a.create(t, other, "issued"), "issued")
+		assert.ErrorContains(t, err, "not registered for bob-nas.local")
+
+		a.flags = flagUserPresent
+		_, err = rp.Register(a.create(t, rp, "issued"), "issued")
+		assert.ErrorContains(t, err, "did not verify the user")
+	})
+
+	_, err = NewRelyingParty("bob-nas.local")
+	assert.Error(t, err)
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	item, rest, err := decodeCBOR(append(encodeCBOR(map[any]any{1: -7, "k": []any{"a", []byte{1}, true}}), 0xff))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xff}, rest)
+	assert.Equal(t, map[any]any{int64(1): int64(-7), "k": []any{"a", []byte{1}, true}}, item)
+
+	for name, data := range map[string][]byte{
+		"empty":             {},
+		"truncated string":  {0x45, 'a'},
+		"indefinite length": {0x5f},
+		"huge array":        {0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		"array key":         {0xa1, 0x80, 0x01},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := decodeCBOR(data)
+			assert.ErrorIs(t, err, errCBOR)
+		})
+	}
+
+	deep := make([]byte, 100)
+	for i := range deep {
+		deep[i] = 0x81
+	}
+	_, _, err = decodeCBOR(deep)
+	assert.ErrorContains(t, err, "nested too deeply")
+}
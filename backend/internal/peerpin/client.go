@@ -0,0 +1,85 @@
+package peerpin
+
+import (
+	"crypto/tls"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+)
+
+// Guard checks the identity of the peer at Address against the pin
+type Guard struct {
+	Store   *Store
+	Address string
+	// Key is the peer's public key as recorded when it joined, if known.
+	// It is the pin until the peer is first seen.
+	Key []byte
+}
+
+// Client returns a client like tracing.NewClient whose calls to the peer
+// are checked against the pin. A changed certificate fails the TLS
+// handshake, before anything is sent; a changed key fails the call. Once
+// a change is recorded, calls to the peer fail without being made.
+func (g *Guard) Client(timeout time.Duration) *http.Client {
+	var host string
+	if u, err := url.Parse(g.Address); err == nil {
+		host = u.Hostname()
+	}
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.TLSClientConfig = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if host != "" && cs.ServerName != host {
+				return nil
+			}
+			return g.check(Identity{TLSFingerprint: certFingerprint(&cs)})
+		},
+	}
+	return &http.Client{Timeout: timeout, Transport: &tracing.Transport{Base: &guardTransport{guard: g, base: base}}}
+}
+
+// check checks seen, seeding the pin with Key if there is none yet
+func (g *Guard) check(seen Identity) error {
+	if len(g.Key) > 0 {
+		if pin, err := g.Store.Get(); err == nil && pin == nil {
+			if err := g.Store.Check(g.Address, Identity{PublicKey: hex.EncodeToString(g.Key)}, time.Now()); err != nil {
+				return err
+			}
+		}
+	}
+	return g.Store.Check(g.Address, seen, time.Now())
+}
+
+// guardTransport checks the key announced on the peer's responses
+type guardTransport struct {
+	guard *Guard
+	base  http.RoundTripper
+}
+
+func (t *guardTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	address := strings.TrimSuffix(t.guard.Address, "/")
+	if !strings.HasPrefix(req.URL.String(), address+"/") && req.URL.String() != address {
+		return t.base.RoundTrip(req)
+	}
+	pin, err := t.guard.Store.Get()
+	if err != nil {
+		return nil, err
+	}
+	if err := pin.Err(); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.guard.check(Observe(resp)); err != nil {
+		_ = resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,266 @@
+// Package peerpin pins the configured peer's identity on first use: the
+// public key its API announces and, over HTTPS, the API's TLS certificate.
+// Every later call to the peer is checked against the pin. When either
+// changes, the change is recorded and every call to the peer fails until
+// the owner of this node accepts the new identity with 'airgapper peer
+// repin', after comparing it with the peer's operator.
+package peerpin
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/events"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+// FileName is the pin's file in the config directory
+const FileName = "peer-pin.json"
+
+// KeyHeader carries a node's public key (hex) on every API response
+const KeyHeader = "X-Airgapper-Public-Key"
+
+// Identity is what identifies a peer
+type Identity struct {
+	PublicKey      string `json:"public_key,omitempty"`      // Hex
+	TLSFingerprint string `json:"tls_fingerprint,omitempty"` // SHA-256 of the API's certificate, hex
+}
+
+// IsZero reports whether nothing identifies the peer: it announces no key
+// and serves plain HTTP
+func (id Identity) IsZero() bool {
+	return id == Identity{}
+}
+
+// Code is a short code for the identity, for operators to compare out of
+// band, e.g. "3f1a-9c2e-77d0-b415"
+func (id Identity) Code() string {
+	sum := sha256.Sum256([]byte(id.PublicKey + "|" + id.TLSFingerprint))
+	code := hex.EncodeToString(sum[:8])
+	return code[0:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:16]
+}
+
+// changed lists what of seen differs from the pinned identity. A key is
+// only compared when the response announces one, since error pages from a
+// proxy in front of the peer don't; a pinned certificate must always be
+// presented.
+func (id Identity) changed(seen Identity) []string {
+	var fields []string
+	if seen.PublicKey != "" && id.PublicKey != "" && seen.PublicKey != id.PublicKey {
+		fields = append(fields, "public key")
+	}
+	if id.TLSFingerprint != "" && seen.TLSFingerprint != id.TLSFingerprint {
+		fields = append(fields, "TLS certificate")
+	}
+	return fields
+}
+
+// Observe returns the identity resp's server presented
+func Observe(resp *http.Response) Identity {
+	seen := Identity{PublicKey: resp.Header.Get(KeyHeader)}
+	if resp.TLS != nil {
+		seen.TLSFingerprint = certFingerprint(resp.TLS)
+	}
+	return seen
+}
+
+func certFingerprint(cs *tls.ConnectionState) string {
+	if len(cs.PeerCertificates) == 0 {
+		return ""
+	}
+	return fingerprint(cs.PeerCertificates[0].Raw)
+}
+
+func fingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
+
+// Self returns this node's identity: publicKey and the first certificate
+// in certFile, if set
+func Self(publicKey []byte, certFile string) (Identity, error) {
+	self := Identity{PublicKey: hex.EncodeToString(publicKey)}
+	if certFile == "" {
+		return self, nil
+	}
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		return self, fmt.Errorf("failed to read TLS certificate: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return self, fmt.Errorf("%s has no PEM certificate", certFile)
+	}
+	self.TLSFingerprint = fingerprint(block.Bytes)
+	return self, nil
+}
+
+// Pin is the peer's pinned identity
+type Pin struct {
+	Address string `json:"address"` // Where the peer was when it was pinned
+	Identity
+	PinnedAt time.Time `json:"pinned_at"`
+	// Change is set once the peer presents a different identity, and stays
+	// set until the new one is accepted with Repin
+	Change *Change `json:"change,omitempty"`
+}
+
+// Change is an identity the peer presented that differs from its pin
+type Change struct {
+	Seen    Identity  `json:"seen"`
+	Fields  []string  `json:"fields"` // What changed, e.g. "public key"
+	Address string    `json:"address"`
+	SeenAt  time.Time `json:"seen_at"`
+}
+
+// Err is the error calls to the peer fail with while the change is unaccepted
+func (p *Pin) Err() error {
+	if p == nil || p.Change == nil {
+		return nil
+	}
+	return apperrors.Newf(apperrors.CodePeerIdentityChanged,
+		"PEER IDENTITY CHANGED: the peer at %s presented a different %s than the one pinned on %s. Someone may be impersonating it; no calls are made to it until you compare its identity with its operator and accept it with 'airgapper peer repin'",
+		p.Change.Address, strings.Join(p.Change.Fields, " and "), p.PinnedAt.Local().Format(time.DateOnly))
+}
+
+// Store is the pin file of a config directory. It is safe for concurrent
+// use within a process.
+type Store struct {
+	dir  string
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore returns the pin of config directory dir
+func NewStore(dir string) *Store {
+	return &Store{dir: dir, path: filepath.Join(dir, FileName)}
+}
+
+// Get returns the pin, or nil if the peer isn't pinned yet
+func (s *Store) Get() (*Pin, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Check checks seen, presented by the peer at address, against the pin. The
+// first identity seen is pinned, as are parts of it seen for the first
+// time, e.g. a certificate once the peer moves to HTTPS. A change is
+// recorded and published, and fails this and every later check.
+func (s *Store) Check(address string, seen Identity, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pin, err := s.load()
+	if err != nil {
+		return err
+	}
+	switch {
+	case pin == nil:
+		if seen.IsZero() {
+			return nil
+		}
+		logging.Info("Pinned the peer's identity",
+			logging.String("peer", address),
+			logging.String("code", seen.Code()))
+		return s.save(&Pin{Address: address, Identity: seen, PinnedAt: now.UTC()})
+	case pin.Change != nil:
+		return pin.Err()
+	}
+
+	if fields := pin.changed(seen); len(fields) > 0 {
+		pin.Change = &Change{Seen: seen, Fields: fields, Address: address, SeenAt: now.UTC()}
+		if err := s.save(pin); err != nil {
+			return err
+		}
+		events.Open(s.dir).Publish(events.Event{
+			Type:    events.PeerIdentityChanged,
+			Source:  events.SourcePeer,
+			Subject: address,
+			Message: fmt.Sprintf("The peer presented a different %s than the one pinned; calls to it are blocked until it is repinned", strings.Join(fields, " and ")),
+			Data:    map[string]string{"pinned": pin.Code(), "seen": seen.Code()},
+		})
+		logging.Error("Peer identity changed", logging.String("peer", address), logging.String("changed", strings.Join(fields, ", ")))
+		return pin.Err()
+	}
+
+	learned := false
+	if pin.PublicKey == "" && seen.PublicKey != "" {
+		pin.PublicKey, learned = seen.PublicKey, true
+	}
+	if pin.TLSFingerprint == "" && seen.TLSFingerprint != "" {
+		pin.TLSFingerprint, learned = seen.TLSFingerprint, true
+	}
+	if learned {
+		return s.save(pin)
+	}
+	return nil
+}
+
+// Repin accepts id, presented by the peer at address, as the peer's
+// identity, clearing any change
+func (s *Store) Repin(address string, id Identity, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, err := s.load()
+	if err != nil {
+		return err
+	}
+	if err := s.save(&Pin{Address: address, Identity: id, PinnedAt: now.UTC()}); err != nil {
+		return err
+	}
+	data := map[string]string{"pinned": id.Code()}
+	if old != nil {
+		data["previous"] = old.Code()
+	}
+	events.Open(s.dir).Publish(events.Event{
+		Type:    events.PeerRepinned,
+		Source:  events.SourcePeer,
+		Subject: address,
+		Message: "A new identity was accepted for the peer",
+		Data:    data,
+	})
+	return nil
+}
+
+func (s *Store) load() (*Pin, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var pin Pin
+	if err := json.Unmarshal(data, &pin); err != nil {
+		return nil, fmt.Errorf("failed to parse peer pin: %w", err)
+	}
+	return &pin, nil
+}
+
+func (s *Store) save(pin *Pin) error {
+	data, err := json.MarshalIndent(pin, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save peer pin: %w", err)
+	}
+	return nil
+}
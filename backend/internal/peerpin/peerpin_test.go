@@ -0,0 +1,112 @@
+package peerpin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/events"
+)
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir)
+	now := time.Now()
+	const addr = "https://bob:8081"
+
+	require.NoError(t, s.Check(addr, Identity{}, now), "nothing to pin over plain HTTP without a key")
+	pin, err := s.Get()
+	require.NoError(t, err)
+	assert.Nil(t, pin)
+
+	// First use pins the key, and later the certificate once one is seen
+	require.NoError(t, s.Check(addr, Identity{PublicKey: "aa"}, now))
+	require.NoError(t, s.Check(addr, Identity{PublicKey: "aa", TLSFingerprint: "f1"}, now))
+	require.NoError(t, s.Check(addr, Identity{TLSFingerprint: "f1"}, now), "a response without the key header, e.g. a proxy's error page")
+	pin, err = s.Get()
+	require.NoError(t, err)
+	assert.Equal(t, Identity{PublicKey: "aa", TLSFingerprint: "f1"}, pin.Identity)
+	assert.Nil(t, pin.Change)
+
+	err = s.Check(addr, Identity{PublicKey: "bb", TLSFingerprint: "f2"}, now)
+	assert.Equal(t, apperrors.CodePeerIdentityChanged, apperrors.CodeOf(err))
+	assert.ErrorContains(t, err, "different public key and TLS certificate")
+
+	// The change stays until repinned, even if the old identity comes back
+	assert.Error(t, s.Check(addr, Identity{PublicKey: "aa", TLSFingerprint: "f1"}, now))
+	assert.Error(t, s.Check(addr, Identity{PublicKey: "aa"}, now), "a pinned certificate must be presented")
+
+	list, _, err := events.Open(dir).Since(0, 0, events.Filter{Types: []string{"peer"}})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, events.PeerIdentityChanged, list[0].Type)
+	assert.Equal(t, addr, list[0].Subject)
+
+	require.NoError(t, s.Repin(addr, Identity{PublicKey: "bb", TLSFingerprint: "f2"}, now))
+	assert.NoError(t, s.Check(addr, Identity{PublicKey: "bb", TLSFingerprint: "f2"}, now))
+	pin, err = s.Get()
+	require.NoError(t, err)
+	assert.Nil(t, pin.Change)
+}
+
+func TestGuardClient(t *testing.T) {
+	var key atomic.Value
+	key.Store("aa")
+	var calls atomic.Int32
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set(KeyHeader, key.Load().(string))
+	}))
+	defer peer.Close()
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(KeyHeader, "cc")
+	}))
+	defer other.Close()
+
+	store := NewStore(t.TempDir())
+	client := (&Guard{Store: store, Address: peer.URL}).Client(5 * time.Second)
+	get := func(url string) error {
+		resp, err := client.Get(url)
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		return err
+	}
+
+	require.NoError(t, get(peer.URL+"/api/v1/capabilities"))
+	require.NoError(t, get(other.URL+"/"), "only the configured peer is checked")
+	pin, err := store.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "aa", pin.PublicKey)
+
+	key.Store("bb")
+	err = get(peer.URL + "/api/v1/capabilities")
+	assert.Equal(t, apperrors.CodePeerIdentityChanged, apperrors.CodeOf(err))
+	require.Equal(t, int32(2), calls.Load())
+	assert.Error(t, get(peer.URL+"/api/v1/capabilities"))
+	assert.Equal(t, int32(2), calls.Load(), "no calls are made while the change stands")
+}
+
+func TestGuardKnownKey(t *testing.T) {
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(KeyHeader, "bb")
+	}))
+	defer peer.Close()
+
+	// The key recorded when the peer joined is the pin, not the first seen
+	client := (&Guard{Store: NewStore(t.TempDir()), Address: peer.URL, Key: []byte{0xaa}}).Client(5 * time.Second)
+	_, err := client.Get(peer.URL + "/")
+	assert.Equal(t, apperrors.CodePeerIdentityChanged, apperrors.CodeOf(err))
+}
+
+func TestIdentityCode(t *testing.T) {
+	id := Identity{PublicKey: "aa", TLSFingerprint: "f1"}
+	assert.Regexp(t, `^[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}$`, id.Code())
+	assert.NotEqual(t, id.Code(), Identity{PublicKey: "aa"}.Code())
+}
@@ -0,0 +1,294 @@
+// Package peerqueue stores messages for the peer and forwards them when it
+// can be reached, so calls such as a released key share or a veto arrive
+// eventually even when the two nodes are rarely online at the same time.
+//
+// Messages are queued on disk before the first attempt and kept until the
+// peer accepts them. They are small JSON bodies sent whole: a message the
+// link drops mid-way is sent again, so each call must be safe to repeat.
+// Messages are delivered in the order they were queued; while the peer is
+// unreachable, later messages wait behind the first so that, say, an
+// approval never overtakes the request it approves.
+package peerqueue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Message kinds
+const (
+	KindRequest   = "request"   // A new restore request
+	KindApproval  = "approval"  // A released key share
+	KindVeto      = "veto"      // A veto of a request
+	KindExtension = "extension" // An extension asked for or approved
+)
+
+const (
+	// MaxAge is how long a message is retried before it is given up on
+	MaxAge = 7 * 24 * time.Hour
+
+	firstBackoff = 30 * time.Second
+	maxBackoff   = time.Hour
+)
+
+// ErrRejected marks a delivery the peer refused, which sending again won't
+// change. Senders wrap it; other errors are retried.
+var ErrRejected = errors.New("peer rejected the message")
+
+// Message is a call queued for the peer
+type Message struct {
+	ID            string          `json:"id"`
+	Kind          string          `json:"kind"`
+	Peer          string          `json:"peer"` // The peer's address
+	Path          string          `json:"path"` // Endpoint on the peer
+	Body          json.RawMessage `json:"body"`
+	RequestID     string          `json:"requestId,omitempty"`
+	CorrelationID string          `json:"correlationId,omitempty"`
+	QueuedAt      time.Time       `json:"queuedAt"`
+	Attempts      int             `json:"attempts,omitempty"`
+	NextAttempt   time.Time       `json:"nextAttempt,omitzero"`
+	LastError     string          `json:"lastError,omitempty"`
+	FailedAt      time.Time       `json:"failedAt,omitzero"` // Rejected or expired; no longer retried
+}
+
+// Failed reports whether the message was given up on
+func (m *Message) Failed() bool {
+	return !m.FailedAt.IsZero()
+}
+
+// Sender delivers a message to the peer
+type Sender func(ctx context.Context, m *Message) error
+
+// Queue persists the messages waiting for the peer
+type Queue struct {
+	path string
+	mu   sync.Mutex
+}
+
+// New returns a queue backed by the file at path
+func New(path string) *Queue {
+	return &Queue{path: path}
+}
+
+// Add queues a message, due at once
+func (q *Queue) Add(m *Message) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	all, err := q.load()
+	if err != nil {
+		return err
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	m.ID = hex.EncodeToString(id)
+	if m.QueuedAt.IsZero() {
+		m.QueuedAt = time.Now().UTC()
+	}
+	return q.save(append(all, *m))
+}
+
+// List returns every queued message, oldest first
+func (q *Queue) List() ([]Message, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.load()
+}
+
+// Deliver sends the messages due at now, oldest first, and removes each the
+// peer accepts. A rejected message, or one queued longer than MaxAge, is
+// kept as failed. When a send fails otherwise the peer is taken to be
+// unreachable: the message is retried with a growing backoff, and the rest
+// for that peer wait for the next delivery. With force, messages waiting
+// out a backoff are sent too, e.g. when someone asks for delivery now.
+//
+// The queue isn't locked while a message is sent, so messages can be
+// queued, say by the CLI while the daemon delivers, in the meantime.
+func (q *Queue) Deliver(ctx context.Context, now time.Time, send Sender, force bool) (delivered int, err error) {
+	blocked := map[string]bool{}
+	tried := map[string]bool{}
+	for {
+		m, err := q.next(now, force, blocked, tried)
+		if m == nil || err != nil {
+			return delivered, err
+		}
+		tried[m.ID] = true
+
+		m.Attempts++
+		sendErr := send(ctx, m)
+		switch {
+		case sendErr == nil:
+			delivered++
+			if err := q.remove(m.ID); err != nil {
+				return delivered, err
+			}
+			continue
+		case errors.Is(sendErr, ErrRejected):
+			m.FailedAt = now
+		default:
+			m.NextAttempt = now.Add(backoff(m.Attempts))
+			blocked[m.Peer] = true
+		}
+		m.LastError = sendErr.Error()
+		if err := q.update(m.ID, func(stored *Message) { *stored = *m }); err != nil {
+			return delivered, err
+		}
+	}
+}
+
+// next returns the first message to send, expiring those too old on the
+// way, or nil if none is due
+func (q *Queue) next(now time.Time, force bool, blocked, tried map[string]bool) (*Message, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	all, err := q.load()
+	if err != nil {
+		return nil, err
+	}
+	expired := false
+	var due *Message
+	for i := range all {
+		m := &all[i]
+		switch {
+		case m.Failed(), tried[m.ID], blocked[m.Peer]:
+		case now.Sub(m.QueuedAt) > MaxAge:
+			m.FailedAt = now
+			m.LastError = fmt.Sprintf("not delivered within %s: %s", MaxAge, m.LastError)
+			expired = true
+		case !force && now.Before(m.NextAttempt):
+			blocked[m.Peer] = true
+		default:
+			due = m
+		}
+		if due != nil {
+			break
+		}
+	}
+	if expired {
+		if err := q.save(all); err != nil {
+			return nil, err
+		}
+	}
+	if due == nil {
+		return nil, nil
+	}
+	m := *due
+	return &m, nil
+}
+
+// Acknowledge removes the messages the peer reports it has applied, say in
+// a sync bundle, and returns how many there were
+func (q *Queue) Acknowledge(ids []string) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	all, err := q.load()
+	if err != nil {
+		return 0, err
+	}
+	kept := slices.DeleteFunc(slices.Clone(all), func(m Message) bool { return slices.Contains(ids, m.ID) })
+	if len(kept) == len(all) {
+		return 0, nil
+	}
+	return len(all) - len(kept), q.save(kept)
+}
+
+// Retry makes a message due again, including one that failed
+func (q *Queue) Retry(id string) error {
+	return q.update(id, func(m *Message) {
+		m.NextAttempt, m.FailedAt = time.Time{}, time.Time{}
+		m.QueuedAt = time.Now().UTC()
+	})
+}
+
+// Drop removes a message without delivering it
+func (q *Queue) Drop(id string) error {
+	return q.remove(id)
+}
+
+func (q *Queue) remove(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	all, err := q.load()
+	if err != nil {
+		return err
+	}
+	for i := range all {
+		if all[i].ID == id {
+			return q.save(append(all[:i], all[i+1:]...))
+		}
+	}
+	return fmt.Errorf("no queued message %s", id)
+}
+
+func (q *Queue) update(id string, fn func(*Message)) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	all, err := q.load()
+	if err != nil {
+		return err
+	}
+	for i := range all {
+		if all[i].ID == id {
+			fn(&all[i])
+			return q.save(all)
+		}
+	}
+	return fmt.Errorf("no queued message %s", id)
+}
+
+// backoff is the wait before attempt n+1: 30s doubling to at most an hour
+func backoff(attempts int) time.Duration {
+	d := firstBackoff
+	for i := 1; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
+
+func (q *Queue) load() ([]Message, error) {
+	data, err := os.ReadFile(q.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read peer queue: %w", err)
+	}
+
+	var messages []Message
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("failed to parse peer queue: %w", err)
+	}
+	return messages, nil
+}
+
+// save writes the queue, which may hold a released key share, readable
+// only by its owner
+func (q *Queue) save(messages []Message) error {
+	data, err := json.MarshalIndent(messages, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize peer queue: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(q.path), 0700); err != nil {
+		return err
+	}
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to save peer queue: %w", err)
+	}
+	return os.Rename(tmp, q.path)
+}
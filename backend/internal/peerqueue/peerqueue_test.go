@@ -0,0 +1,145 @@
+package peerqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// peer records what it was sent, failing while down
+type peer struct {
+	down     bool
+	reject   string // Kind to reject
+	received []string
+}
+
+func (p *peer) send(ctx context.Context, m *Message) error {
+	switch {
+	case p.down:
+		return errors.New("connection refused")
+	case m.Kind == p.reject:
+		return fmt.Errorf("%w: 400 Bad Request", ErrRejected)
+	}
+	p.received = append(p.received, m.Kind+":"+m.RequestID)
+	return nil
+}
+
+func newQueue(t *testing.T) *Queue {
+	t.Helper()
+	return New(filepath.Join(t.TempDir(), "peer-queue.json"))
+}
+
+func add(t *testing.T, q *Queue, kind, requestID string, queuedAt time.Time) *Message {
+	t.Helper()
+	m := &Message{Kind: kind, Peer: "http://bob:8081", Path: "/x", Body: []byte(`{}`), RequestID: requestID, QueuedAt: queuedAt}
+	require.NoError(t, q.Add(m))
+	return m
+}
+
+func TestDeliverWaitsForThePeer(t *testing.T) {
+	q := newQueue(t)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	add(t, q, KindRequest, "r1", now)
+	add(t, q, KindApproval, "r1", now)
+
+	bob := &peer{down: true}
+	n, err := q.Deliver(context.Background(), now, bob.send, false)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	list, err := q.List()
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, 1, list[0].Attempts)
+	assert.Equal(t, now.Add(30*time.Second), list[0].NextAttempt)
+	assert.Equal(t, "connection refused", list[0].LastError)
+	assert.Zero(t, list[1].Attempts, "later messages wait behind the first")
+
+	// Not due yet unless forced; a second failure doubles the backoff
+	n, err = q.Deliver(context.Background(), now.Add(10*time.Second), bob.send, false)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	list, _ = q.List()
+	assert.Equal(t, 1, list[0].Attempts)
+	_, err = q.Deliver(context.Background(), now.Add(10*time.Second), bob.send, true)
+	require.NoError(t, err)
+	list, _ = q.List()
+	assert.Equal(t, 2, list[0].Attempts)
+	assert.Equal(t, now.Add(70*time.Second), list[0].NextAttempt)
+
+	bob.down = false
+	n, err = q.Deliver(context.Background(), now.Add(70*time.Second), bob.send, false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []string{"request:r1", "approval:r1"}, bob.received, "in the order queued")
+	list, _ = q.List()
+	assert.Empty(t, list)
+
+	info, err := os.Stat(q.path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "the queue may hold a key share")
+}
+
+func TestDeliverRejectedAndExpired(t *testing.T) {
+	q := newQueue(t)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	old := add(t, q, KindExtension, "r0", now.Add(-MaxAge-time.Hour))
+	veto := add(t, q, KindVeto, "r1", now)
+	add(t, q, KindRequest, "r2", now)
+
+	bob := &peer{reject: KindVeto}
+	n, err := q.Deliver(context.Background(), now, bob.send, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"request:r2"}, bob.received, "a rejection doesn't hold up the rest")
+
+	list, _ := q.List()
+	require.Len(t, list, 2)
+	assert.True(t, list[0].Failed())
+	assert.Zero(t, list[0].Attempts, "too old to send")
+	assert.Contains(t, list[0].LastError, "not delivered within")
+	assert.True(t, list[1].Failed())
+	assert.Contains(t, list[1].LastError, "400 Bad Request")
+
+	// Failed messages stay until retried or dropped
+	bob.reject = ""
+	n, _ = q.Deliver(context.Background(), now, bob.send, false)
+	assert.Zero(t, n)
+	require.NoError(t, q.Retry(veto.ID))
+	n, err = q.Deliver(context.Background(), time.Now(), bob.send, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "only the retried message")
+	require.NoError(t, q.Drop(old.ID))
+	list, _ = q.List()
+	assert.Empty(t, list)
+	assert.Error(t, q.Drop(old.ID))
+}
+
+func TestAcknowledge(t *testing.T) {
+	q := newQueue(t)
+	now := time.Now()
+	a := add(t, q, KindRequest, "r1", now)
+	b := add(t, q, KindApproval, "r1", now)
+
+	n, err := q.Acknowledge([]string{a.ID, "unknown"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	list, _ := q.List()
+	require.Len(t, list, 1)
+	assert.Equal(t, b.ID, list[0].ID)
+	n, err = q.Acknowledge([]string{a.ID})
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, backoff(1))
+	assert.Equal(t, 4*time.Minute, backoff(4))
+	assert.Equal(t, time.Hour, backoff(20))
+}
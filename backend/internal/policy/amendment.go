@@ -0,0 +1,173 @@
+package policy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+)
+
+// MirrorAmendment is an owner-signed amendment to a policy that allows the
+// host to mirror the owner's repositories to another host. The host can't
+// create one itself, so data never leaves the host without the owner's consent.
+type MirrorAmendment struct {
+	Version  int    `json:"version"`
+	ID       string `json:"id"`
+	PolicyID string `json:"policy_id"` // Policy being amended
+
+	// The mirror host
+	MirrorName string `json:"mirror_name"`
+	MirrorURL  string `json:"mirror_url"` // Storage endpoint blobs are pushed to
+
+	// Repositories that may be mirrored (empty = all)
+	Repos []string `json:"repos,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+
+	OwnerKeyID     string `json:"owner_key_id"`
+	OwnerSignature string `json:"owner_signature,omitempty"` // hex-encoded
+}
+
+// mirrorAmendmentSignData is the canonical data signed by the owner
+type mirrorAmendmentSignData struct {
+	Version    int      `json:"version"`
+	ID         string   `json:"id"`
+	PolicyID   string   `json:"policy_id"`
+	MirrorName string   `json:"mirror_name"`
+	MirrorURL  string   `json:"mirror_url"`
+	Repos      []string `json:"repos,omitempty"`
+	CreatedAt  int64    `json:"created_at"`
+	ExpiresAt  int64    `json:"expires_at"`
+	OwnerKeyID string   `json:"owner_key_id"`
+}
+
+// NewMirrorAmendment creates an unsigned mirror amendment to p
+func NewMirrorAmendment(p *Policy, mirrorName, mirrorURL string) *MirrorAmendment {
+	return &MirrorAmendment{
+		Version:    1,
+		ID:         generatePolicyID(),
+		PolicyID:   p.ID,
+		MirrorName: mirrorName,
+		MirrorURL:  mirrorURL,
+		CreatedAt:  time.Now(),
+		OwnerKeyID: p.OwnerKeyID,
+	}
+}
+
+// Hash creates a canonical hash of the amendment for signing
+func (a *MirrorAmendment) Hash() ([]byte, error) {
+	signData := mirrorAmendmentSignData{
+		Version:    a.Version,
+		ID:         a.ID,
+		PolicyID:   a.PolicyID,
+		MirrorName: a.MirrorName,
+		MirrorURL:  a.MirrorURL,
+		Repos:      a.Repos,
+		CreatedAt:  a.CreatedAt.Unix(),
+		OwnerKeyID: a.OwnerKeyID,
+	}
+	if !a.ExpiresAt.IsZero() {
+		signData.ExpiresAt = a.ExpiresAt.Unix()
+	}
+
+	jsonBytes, err := json.Marshal(signData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal amendment: %w", err)
+	}
+
+	hash := sha256.Sum256(jsonBytes)
+	return hash[:], nil
+}
+
+// SignAsOwner signs the amendment with the owner's key
+func (a *MirrorAmendment) SignAsOwner(privateKey []byte) error {
+	hash, err := a.Hash()
+	if err != nil {
+		return err
+	}
+
+	sig, err := crypto.Sign(privateKey, hash)
+	if err != nil {
+		return fmt.Errorf("failed to sign amendment: %w", err)
+	}
+
+	a.OwnerSignature = hex.EncodeToString(sig)
+	return nil
+}
+
+// Verify checks that the amendment amends p, is signed by p's owner and
+// hasn't expired
+func (a *MirrorAmendment) Verify(p *Policy) error {
+	if p == nil {
+		return errors.New("no policy to amend")
+	}
+	if a.PolicyID != p.ID {
+		return fmt.Errorf("amendment is for policy %s, not %s", a.PolicyID, p.ID)
+	}
+	if a.OwnerKeyID != p.OwnerKeyID {
+		return errors.New("amendment not issued by the policy owner")
+	}
+	if a.MirrorURL == "" {
+		return errors.New("amendment has no mirror URL")
+	}
+	if a.OwnerSignature == "" {
+		return errors.New("no owner signature")
+	}
+
+	pubKey, err := hex.DecodeString(p.OwnerPubKey)
+	if err != nil {
+		return fmt.Errorf("invalid owner public key: %w", err)
+	}
+
+	sig, err := hex.DecodeString(a.OwnerSignature)
+	if err != nil {
+		return fmt.Errorf("invalid owner signature encoding: %w", err)
+	}
+
+	hash, err := a.Hash()
+	if err != nil {
+		return err
+	}
+
+	if !crypto.Verify(pubKey, hash, sig) {
+		return errors.New("owner signature verification failed")
+	}
+
+	if !a.ExpiresAt.IsZero() && time.Now().After(a.ExpiresAt) {
+		return errors.New("amendment has expired")
+	}
+
+	return nil
+}
+
+// Covers returns true if the amendment allows mirroring repo
+func (a *MirrorAmendment) Covers(repo string) bool {
+	if len(a.Repos) == 0 {
+		return true
+	}
+	for _, r := range a.Repos {
+		if r == repo {
+			return true
+		}
+	}
+	return false
+}
+
+// ToJSON serializes the amendment to JSON
+func (a *MirrorAmendment) ToJSON() ([]byte, error) {
+	return json.MarshalIndent(a, "", "  ")
+}
+
+// MirrorAmendmentFromJSON deserializes a mirror amendment from JSON
+func MirrorAmendmentFromJSON(data []byte) (*MirrorAmendment, error) {
+	var a MirrorAmendment
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, fmt.Errorf("failed to parse amendment: %w", err)
+	}
+	return &a, nil
+}
@@ -0,0 +1,60 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+)
+
+func TestMirrorAmendment_SignAndVerify(t *testing.T) {
+	ownerPub, ownerPriv, _ := crypto.GenerateKeyPair()
+	hostPub, hostPriv, _ := crypto.GenerateKeyPair()
+
+	p := NewPolicy(
+		"Alice", crypto.KeyID(ownerPub), crypto.EncodePublicKey(ownerPub),
+		"Bob", crypto.KeyID(hostPub), crypto.EncodePublicKey(hostPub),
+	)
+
+	a := NewMirrorAmendment(p, "carol", "https://carol:8000")
+	a.Repos = []string{"repo1"}
+	assert.Error(t, a.Verify(p), "unsigned amendment must not verify")
+
+	// The host can't authorize mirroring on the owner's behalf
+	require.NoError(t, a.SignAsOwner(hostPriv))
+	assert.Error(t, a.Verify(p))
+
+	require.NoError(t, a.SignAsOwner(ownerPriv))
+	require.NoError(t, a.Verify(p))
+	assert.True(t, a.Covers("repo1"))
+	assert.False(t, a.Covers("repo2"))
+
+	// Round trip
+	data, err := a.ToJSON()
+	require.NoError(t, err)
+	parsed, err := MirrorAmendmentFromJSON(data)
+	require.NoError(t, err)
+	assert.NoError(t, parsed.Verify(p))
+
+	// Tampering breaks the signature
+	parsed.MirrorURL = "https://mallory:8000"
+	assert.Error(t, parsed.Verify(p))
+
+	// Amendments don't carry over to other policies
+	other := NewPolicy(p.OwnerName, p.OwnerKeyID, p.OwnerPubKey, p.HostName, p.HostKeyID, p.HostPubKey)
+	other.ID = "other"
+	assert.Error(t, a.Verify(other))
+}
+
+func TestMirrorAmendment_Expiry(t *testing.T) {
+	ownerPub, ownerPriv, _ := crypto.GenerateKeyPair()
+	p := NewPolicy("Alice", crypto.KeyID(ownerPub), crypto.EncodePublicKey(ownerPub), "Bob", "", "")
+
+	a := NewMirrorAmendment(p, "carol", "https://carol:8000")
+	a.ExpiresAt = time.Now().Add(-time.Hour)
+	require.NoError(t, a.SignAsOwner(ownerPriv))
+	assert.ErrorContains(t, a.Verify(p), "expired")
+}
@@ -0,0 +1,180 @@
+package policy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+)
+
+// ModeChange lets the storage server run in a weaker mode than the policy
+// demands (append-only disabled). It must be signed by both the owner and
+// the host, so a host can't unlock deletion by editing its local config.
+// It is bound to the exact policy revision it was signed against.
+type ModeChange struct {
+	Version    int    `json:"version"`
+	ID         string `json:"id"`
+	PolicyID   string `json:"policy_id"`
+	PolicyHash string `json:"policy_hash"` // hex-encoded Hash() of the policy revision
+
+	AppendOnly bool   `json:"append_only"` // Requested mode
+	Reason     string `json:"reason,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+
+	OwnerSignature string `json:"owner_signature,omitempty"` // hex-encoded
+	HostSignature  string `json:"host_signature,omitempty"`  // hex-encoded
+}
+
+// modeChangeSignData is the canonical data signed by both parties
+type modeChangeSignData struct {
+	Version    int    `json:"version"`
+	ID         string `json:"id"`
+	PolicyID   string `json:"policy_id"`
+	PolicyHash string `json:"policy_hash"`
+	AppendOnly bool   `json:"append_only"`
+	Reason     string `json:"reason,omitempty"`
+	CreatedAt  int64  `json:"created_at"`
+	ExpiresAt  int64  `json:"expires_at"`
+}
+
+// NewModeChange creates an unsigned request to change p's storage mode
+func NewModeChange(p *Policy, appendOnly bool, reason string) (*ModeChange, error) {
+	hash, err := p.HashHex()
+	if err != nil {
+		return nil, err
+	}
+	return &ModeChange{
+		Version:    1,
+		ID:         generatePolicyID(),
+		PolicyID:   p.ID,
+		PolicyHash: hash,
+		AppendOnly: appendOnly,
+		Reason:     reason,
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+// Hash creates a canonical hash of the mode change for signing
+func (m *ModeChange) Hash() ([]byte, error) {
+	signData := modeChangeSignData{
+		Version:    m.Version,
+		ID:         m.ID,
+		PolicyID:   m.PolicyID,
+		PolicyHash: m.PolicyHash,
+		AppendOnly: m.AppendOnly,
+		Reason:     m.Reason,
+		CreatedAt:  m.CreatedAt.Unix(),
+	}
+	if !m.ExpiresAt.IsZero() {
+		signData.ExpiresAt = m.ExpiresAt.Unix()
+	}
+
+	jsonBytes, err := json.Marshal(signData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal mode change: %w", err)
+	}
+
+	hash := sha256.Sum256(jsonBytes)
+	return hash[:], nil
+}
+
+// Sign signs the mode change as the given role ("owner" or "host")
+func (m *ModeChange) Sign(role string, privateKey []byte) error {
+	if err := validateRole(role); err != nil {
+		return err
+	}
+
+	hash, err := m.Hash()
+	if err != nil {
+		return err
+	}
+	sig, err := crypto.Sign(privateKey, hash)
+	if err != nil {
+		return fmt.Errorf("failed to sign mode change: %w", err)
+	}
+
+	if role == RoleOwner {
+		m.OwnerSignature = hex.EncodeToString(sig)
+	} else {
+		m.HostSignature = hex.EncodeToString(sig)
+	}
+	return nil
+}
+
+// IsFullySigned returns true if both parties have signed
+func (m *ModeChange) IsFullySigned() bool {
+	return m.OwnerSignature != "" && m.HostSignature != ""
+}
+
+// Verify checks that the mode change applies to p, carries valid owner and
+// host signatures from p's keys and hasn't expired
+func (m *ModeChange) Verify(p *Policy) error {
+	if p == nil {
+		return errors.New("no policy")
+	}
+	if m.PolicyID != p.ID {
+		return fmt.Errorf("mode change is for policy %s, not %s", m.PolicyID, p.ID)
+	}
+	policyHash, err := p.HashHex()
+	if err != nil {
+		return err
+	}
+	if m.PolicyHash != policyHash {
+		return errors.New("mode change was signed against a different policy revision")
+	}
+
+	hash, err := m.Hash()
+	if err != nil {
+		return err
+	}
+	if err := verifySignature(p.OwnerPubKey, m.OwnerSignature, hash, RoleOwner); err != nil {
+		return err
+	}
+	if err := verifySignature(p.HostPubKey, m.HostSignature, hash, RoleHost); err != nil {
+		return err
+	}
+
+	if !m.ExpiresAt.IsZero() && time.Now().After(m.ExpiresAt) {
+		return errors.New("mode change has expired")
+	}
+	return nil
+}
+
+// ToJSON serializes the mode change to JSON
+func (m *ModeChange) ToJSON() ([]byte, error) {
+	return json.MarshalIndent(m, "", "  ")
+}
+
+// ModeChangeFromJSON deserializes a mode change from JSON
+func ModeChangeFromJSON(data []byte) (*ModeChange, error) {
+	var m ModeChange
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse mode change: %w", err)
+	}
+	return &m, nil
+}
+
+// verifySignature checks a hex-encoded signature against a hex-encoded public key
+func verifySignature(pubKeyHex, sigHex string, hash []byte, role string) error {
+	if sigHex == "" {
+		return fmt.Errorf("no %s signature", role)
+	}
+	pubKey, err := hex.DecodeString(pubKeyHex)
+	if err != nil {
+		return fmt.Errorf("invalid %s public key: %w", role, err)
+	}
+	sig, err := hex.DecodeString(sigHex)
+	if err != nil {
+		return fmt.Errorf("invalid %s signature encoding: %w", role, err)
+	}
+	if !crypto.Verify(pubKey, hash, sig) {
+		return fmt.Errorf("%s signature verification failed", role)
+	}
+	return nil
+}
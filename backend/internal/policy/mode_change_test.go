@@ -0,0 +1,59 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModeChange_RequiresBothSignatures(t *testing.T) {
+	p, ownerPriv, hostPriv := signedTestPolicy(t)
+
+	mc, err := NewModeChange(p, false, "prune after migration")
+	require.NoError(t, err)
+
+	require.NoError(t, mc.Sign(RoleHost, hostPriv))
+	assert.ErrorContains(t, mc.Verify(p), "no owner signature", "the host can't unlock alone")
+
+	// Signing with the wrong key for the role fails verification
+	require.NoError(t, mc.Sign(RoleOwner, hostPriv))
+	assert.ErrorContains(t, mc.Verify(p), "owner signature verification failed")
+
+	require.NoError(t, mc.Sign(RoleOwner, ownerPriv))
+	require.NoError(t, mc.Verify(p))
+
+	data, err := mc.ToJSON()
+	require.NoError(t, err)
+	parsed, err := ModeChangeFromJSON(data)
+	require.NoError(t, err)
+	require.NoError(t, parsed.Verify(p))
+
+	// Tampering breaks both signatures
+	parsed.Reason = "something else"
+	assert.Error(t, parsed.Verify(p))
+}
+
+func TestModeChange_BoundToPolicyRevision(t *testing.T) {
+	p, ownerPriv, hostPriv := signedTestPolicy(t)
+
+	mc, err := NewModeChange(p, false, "")
+	require.NoError(t, err)
+	require.NoError(t, mc.Sign(RoleOwner, ownerPriv))
+	require.NoError(t, mc.Sign(RoleHost, hostPriv))
+
+	days := 60
+	next, err := p.Amend(Terms{RetentionDays: &days})
+	require.NoError(t, err)
+	require.NoError(t, next.SignAsOwner(ownerPriv))
+	require.NoError(t, next.SignAsHost(hostPriv))
+	assert.ErrorContains(t, mc.Verify(next), "different policy revision")
+
+	expired, err := NewModeChange(p, false, "")
+	require.NoError(t, err)
+	expired.ExpiresAt = time.Now().Add(-time.Hour)
+	require.NoError(t, expired.Sign(RoleOwner, ownerPriv))
+	require.NoError(t, expired.Sign(RoleHost, hostPriv))
+	assert.ErrorContains(t, expired.Verify(p), "expired")
+}
@@ -0,0 +1,380 @@
+package policy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+)
+
+// minPinPrefix is the shortest snapshot ID prefix a pin may use (restic's
+// short IDs are 8 hex characters)
+const minPinPrefix = 8
+
+// Pin marks a snapshot as non-deletable (a legal hold), regardless of
+// retention rules. Either party can set one; it is signed by the party that
+// set it with the key named in the policy.
+type Pin struct {
+	Version    int    `json:"version"`
+	ID         string `json:"id"`
+	PolicyID   string `json:"policy_id"`
+	SnapshotID string `json:"snapshot_id"` // Full ID or short prefix
+	Label      string `json:"label"`       // e.g. "tax year 2023"
+	SetBy      string `json:"set_by"`      // "owner" or "host"
+
+	CreatedAt time.Time `json:"created_at"`
+	Signature string    `json:"signature,omitempty"` // hex-encoded, by SetBy
+}
+
+// pinSignData is the canonical data signed by the party setting the pin
+type pinSignData struct {
+	Version    int    `json:"version"`
+	ID         string `json:"id"`
+	PolicyID   string `json:"policy_id"`
+	SnapshotID string `json:"snapshot_id"`
+	Label      string `json:"label"`
+	SetBy      string `json:"set_by"`
+	CreatedAt  int64  `json:"created_at"`
+}
+
+// NewPin creates an unsigned pin on a snapshot under policy p
+func NewPin(p *Policy, snapshotID, label, setBy string) (*Pin, error) {
+	if err := validateRole(setBy); err != nil {
+		return nil, err
+	}
+	snapshotID = strings.ToLower(strings.TrimSpace(snapshotID))
+	if len(snapshotID) < minPinPrefix {
+		return nil, fmt.Errorf("snapshot ID must be at least %d characters", minPinPrefix)
+	}
+	if strings.Trim(snapshotID, "0123456789abcdef") != "" {
+		return nil, fmt.Errorf("invalid snapshot ID %q", snapshotID)
+	}
+	if label == "" {
+		return nil, errors.New("pin label is required")
+	}
+
+	return &Pin{
+		Version:    1,
+		ID:         generatePolicyID(),
+		PolicyID:   p.ID,
+		SnapshotID: snapshotID,
+		Label:      label,
+		SetBy:      setBy,
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+// Hash creates a canonical hash of the pin for signing
+func (pin *Pin) Hash() ([]byte, error) {
+	jsonBytes, err := json.Marshal(pinSignData{
+		Version:    pin.Version,
+		ID:         pin.ID,
+		PolicyID:   pin.PolicyID,
+		SnapshotID: pin.SnapshotID,
+		Label:      pin.Label,
+		SetBy:      pin.SetBy,
+		CreatedAt:  pin.CreatedAt.Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal pin: %w", err)
+	}
+
+	hash := sha256.Sum256(jsonBytes)
+	return hash[:], nil
+}
+
+// Sign signs the pin as the party that set it
+func (pin *Pin) Sign(privateKey []byte) error {
+	hash, err := pin.Hash()
+	if err != nil {
+		return err
+	}
+	sig, err := crypto.Sign(privateKey, hash)
+	if err != nil {
+		return fmt.Errorf("failed to sign pin: %w", err)
+	}
+	pin.Signature = hex.EncodeToString(sig)
+	return nil
+}
+
+// Verify checks that the pin belongs to p and is signed by the party that set it
+func (pin *Pin) Verify(p *Policy) error {
+	if p == nil {
+		return errors.New("no policy")
+	}
+	if pin.PolicyID != p.ID {
+		return fmt.Errorf("pin is for policy %s, not %s", pin.PolicyID, p.ID)
+	}
+	if err := validateRole(pin.SetBy); err != nil {
+		return err
+	}
+
+	hash, err := pin.Hash()
+	if err != nil {
+		return err
+	}
+	return verifySignature(p.pubKeyFor(pin.SetBy), pin.Signature, hash, pin.SetBy)
+}
+
+// Covers returns true if the pin applies to the given snapshot ID. Either
+// may be a short prefix of the other.
+func (pin *Pin) Covers(snapshotID string) bool {
+	snapshotID = strings.ToLower(snapshotID)
+	if len(snapshotID) < minPinPrefix {
+		return false
+	}
+	return strings.HasPrefix(snapshotID, pin.SnapshotID) || strings.HasPrefix(pin.SnapshotID, snapshotID)
+}
+
+// ToJSON serializes the pin to JSON
+func (pin *Pin) ToJSON() ([]byte, error) {
+	return json.MarshalIndent(pin, "", "  ")
+}
+
+// PinFromJSON deserializes a pin from JSON
+func PinFromJSON(data []byte) (*Pin, error) {
+	var pin Pin
+	if err := json.Unmarshal(data, &pin); err != nil {
+		return nil, fmt.Errorf("failed to parse pin: %w", err)
+	}
+	return &pin, nil
+}
+
+// Unpin releases a pin. It is signed by the party requesting it; when that
+// isn't the party that set the pin, the setter must consent by countersigning.
+type Unpin struct {
+	Version     int    `json:"version"`
+	ID          string `json:"id"`
+	PinID       string `json:"pin_id"`
+	PolicyID    string `json:"policy_id"`
+	SnapshotID  string `json:"snapshot_id"`
+	RequestedBy string `json:"requested_by"`
+	Reason      string `json:"reason,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+
+	Signature        string `json:"signature,omitempty"`         // hex-encoded, by RequestedBy
+	ConsentSignature string `json:"consent_signature,omitempty"` // hex-encoded, by the pin's setter
+}
+
+// unpinSignData is the canonical data signed by both parties
+type unpinSignData struct {
+	Version     int    `json:"version"`
+	ID          string `json:"id"`
+	PinID       string `json:"pin_id"`
+	PolicyID    string `json:"policy_id"`
+	SnapshotID  string `json:"snapshot_id"`
+	RequestedBy string `json:"requested_by"`
+	Reason      string `json:"reason,omitempty"`
+	CreatedAt   int64  `json:"created_at"`
+}
+
+// NewUnpin creates an unsigned request to release pin
+func NewUnpin(pin *Pin, requestedBy, reason string) (*Unpin, error) {
+	if err := validateRole(requestedBy); err != nil {
+		return nil, err
+	}
+	return &Unpin{
+		Version:     1,
+		ID:          generatePolicyID(),
+		PinID:       pin.ID,
+		PolicyID:    pin.PolicyID,
+		SnapshotID:  pin.SnapshotID,
+		RequestedBy: requestedBy,
+		Reason:      reason,
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
+// Hash creates a canonical hash of the unpin request for signing
+func (u *Unpin) Hash() ([]byte, error) {
+	jsonBytes, err := json.Marshal(unpinSignData{
+		Version:     u.Version,
+		ID:          u.ID,
+		PinID:       u.PinID,
+		PolicyID:    u.PolicyID,
+		SnapshotID:  u.SnapshotID,
+		RequestedBy: u.RequestedBy,
+		Reason:      u.Reason,
+		CreatedAt:   u.CreatedAt.Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal unpin: %w", err)
+	}
+
+	hash := sha256.Sum256(jsonBytes)
+	return hash[:], nil
+}
+
+// RequiresConsent returns true if the pin was set by the other party
+func (u *Unpin) RequiresConsent(pin *Pin) bool {
+	return u.RequestedBy != pin.SetBy
+}
+
+// Sign signs the unpin request as role: the requester signs the request,
+// the pin's setter signs to consent
+func (u *Unpin) Sign(pin *Pin, role string, privateKey []byte) error {
+	if err := validateRole(role); err != nil {
+		return err
+	}
+	if role != u.RequestedBy && role != pin.SetBy {
+		return fmt.Errorf("%s neither requested the unpin nor set the pin", role)
+	}
+
+	hash, err := u.Hash()
+	if err != nil {
+		return err
+	}
+	sig, err := crypto.Sign(privateKey, hash)
+	if err != nil {
+		return fmt.Errorf("failed to sign unpin: %w", err)
+	}
+
+	if role == u.RequestedBy {
+		u.Signature = hex.EncodeToString(sig)
+	} else {
+		u.ConsentSignature = hex.EncodeToString(sig)
+	}
+	return nil
+}
+
+// Verify checks that the unpin releases pin under p, is signed by the
+// requester and, if the other party set the pin, carries its consent
+func (u *Unpin) Verify(p *Policy, pin *Pin) error {
+	if p == nil || pin == nil {
+		return errors.New("no policy or pin")
+	}
+	if u.PinID != pin.ID || u.SnapshotID != pin.SnapshotID || u.PolicyID != pin.PolicyID {
+		return fmt.Errorf("unpin does not match pin %s", pin.ID)
+	}
+	if u.PolicyID != p.ID {
+		return fmt.Errorf("unpin is for policy %s, not %s", u.PolicyID, p.ID)
+	}
+	if err := validateRole(u.RequestedBy); err != nil {
+		return err
+	}
+
+	hash, err := u.Hash()
+	if err != nil {
+		return err
+	}
+	if err := verifySignature(p.pubKeyFor(u.RequestedBy), u.Signature, hash, u.RequestedBy); err != nil {
+		return err
+	}
+	if !u.RequiresConsent(pin) {
+		return nil
+	}
+	if u.ConsentSignature == "" {
+		return fmt.Errorf("pin was set by the %s, whose consent is required", pin.SetBy)
+	}
+	return verifySignature(p.pubKeyFor(pin.SetBy), u.ConsentSignature, hash, pin.SetBy)
+}
+
+// ToJSON serializes the unpin request to JSON
+func (u *Unpin) ToJSON() ([]byte, error) {
+	return json.MarshalIndent(u, "", "  ")
+}
+
+// UnpinFromJSON deserializes an unpin request from JSON
+func UnpinFromJSON(data []byte) (*Unpin, error) {
+	var u Unpin
+	if err := json.Unmarshal(data, &u); err != nil {
+		return nil, fmt.Errorf("failed to parse unpin: %w", err)
+	}
+	return &u, nil
+}
+
+// pubKeyFor returns the public key of the party holding role
+func (p *Policy) pubKeyFor(role string) string {
+	if role == RoleHost {
+		return p.HostPubKey
+	}
+	return p.OwnerPubKey
+}
+
+// PinSet is a persisted collection of verified pins
+type PinSet struct {
+	Pins []*Pin `json:"pins"`
+}
+
+// LoadPinSet reads a pin set; a missing file is an empty set
+func LoadPinSet(path string) (*PinSet, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &PinSet{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pins: %w", err)
+	}
+
+	var set PinSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse pins: %w", err)
+	}
+	return &set, nil
+}
+
+// Save writes the pin set
+func (s *PinSet) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save pins: %w", err)
+	}
+	return nil
+}
+
+// Get returns the pin with the given ID, or nil
+func (s *PinSet) Get(id string) *Pin {
+	for _, pin := range s.Pins {
+		if pin.ID == id {
+			return pin
+		}
+	}
+	return nil
+}
+
+// Pinned returns the first pin covering snapshotID, or nil
+func (s *PinSet) Pinned(snapshotID string) *Pin {
+	for _, pin := range s.Pins {
+		if pin.Covers(snapshotID) {
+			return pin
+		}
+	}
+	return nil
+}
+
+// Add verifies pin against p and adds it
+func (s *PinSet) Add(p *Policy, pin *Pin) error {
+	if err := pin.Verify(p); err != nil {
+		return fmt.Errorf("pin verification failed: %w", err)
+	}
+	if s.Get(pin.ID) != nil {
+		return fmt.Errorf("pin %s already registered", pin.ID)
+	}
+	s.Pins = append(s.Pins, pin)
+	return nil
+}
+
+// Remove verifies u against p and removes the pin it releases
+func (s *PinSet) Remove(p *Policy, u *Unpin) (*Pin, error) {
+	for i, pin := range s.Pins {
+		if pin.ID != u.PinID {
+			continue
+		}
+		if err := u.Verify(p, pin); err != nil {
+			return nil, fmt.Errorf("unpin verification failed: %w", err)
+		}
+		s.Pins = append(s.Pins[:i], s.Pins[i+1:]...)
+		return pin, nil
+	}
+	return nil, fmt.Errorf("pin %s not found", u.PinID)
+}
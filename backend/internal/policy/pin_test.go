@@ -0,0 +1,88 @@
+package policy
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSnapshotID = "4f2a9c1be07d3316a8f0c2d5e9b47a61c3d8e2f0a1b5c7d9e4f6a8b0c2d4e6f8"
+
+func TestPin_SignedBySetter(t *testing.T) {
+	p, ownerPriv, hostPriv := signedTestPolicy(t)
+
+	_, err := NewPin(p, "4f2a", "too short", RoleOwner)
+	assert.Error(t, err)
+	_, err = NewPin(p, "not-a-snapshot-id", "tax year 2023", RoleOwner)
+	assert.Error(t, err)
+
+	pin, err := NewPin(p, testSnapshotID[:8], "tax year 2023", RoleHost)
+	require.NoError(t, err)
+	require.NoError(t, pin.Sign(ownerPriv))
+	assert.ErrorContains(t, pin.Verify(p), "host signature verification failed")
+	require.NoError(t, pin.Sign(hostPriv))
+	require.NoError(t, pin.Verify(p))
+
+	assert.True(t, pin.Covers(testSnapshotID))
+	assert.True(t, pin.Covers(testSnapshotID[:8]))
+	assert.False(t, pin.Covers(testSnapshotID[:4]))
+	assert.False(t, pin.Covers("00000000"))
+
+	pin.Label = "something else"
+	assert.Error(t, pin.Verify(p))
+}
+
+func TestUnpin_OtherPartysPinNeedsConsent(t *testing.T) {
+	p, ownerPriv, hostPriv := signedTestPolicy(t)
+
+	pin, err := NewPin(p, testSnapshotID, "pre-divorce archive", RoleOwner)
+	require.NoError(t, err)
+	require.NoError(t, pin.Sign(ownerPriv))
+
+	set := &PinSet{}
+	require.NoError(t, set.Add(p, pin))
+	assert.Error(t, set.Add(p, pin), "duplicate")
+	assert.Same(t, pin, set.Pinned(testSnapshotID[:12]))
+
+	u, err := NewUnpin(pin, RoleHost, "archive no longer needed")
+	require.NoError(t, err)
+	assert.True(t, u.RequiresConsent(pin))
+	require.NoError(t, u.Sign(pin, RoleHost, hostPriv))
+
+	_, err = set.Remove(p, u)
+	assert.ErrorContains(t, err, "consent is required")
+	assert.NotNil(t, set.Pinned(testSnapshotID))
+
+	require.NoError(t, u.Sign(pin, RoleOwner, ownerPriv))
+	removed, err := set.Remove(p, u)
+	require.NoError(t, err)
+	assert.Equal(t, pin.ID, removed.ID)
+	assert.Nil(t, set.Pinned(testSnapshotID))
+}
+
+func TestUnpin_OwnPinNeedsNoConsent(t *testing.T) {
+	p, _, hostPriv := signedTestPolicy(t)
+
+	pin, err := NewPin(p, testSnapshotID, "tax year 2023", RoleHost)
+	require.NoError(t, err)
+	require.NoError(t, pin.Sign(hostPriv))
+
+	set := &PinSet{}
+	require.NoError(t, set.Add(p, pin))
+	path := filepath.Join(t.TempDir(), "pins.json")
+	require.NoError(t, set.Save(path))
+
+	loaded, err := LoadPinSet(path)
+	require.NoError(t, err)
+	require.Len(t, loaded.Pins, 1)
+
+	u, err := NewUnpin(pin, RoleHost, "")
+	require.NoError(t, err)
+	assert.False(t, u.RequiresConsent(pin))
+	require.NoError(t, u.Sign(pin, RoleHost, hostPriv))
+	_, err = loaded.Remove(p, u)
+	require.NoError(t, err)
+	assert.Empty(t, loaded.Pins)
+}
@@ -42,6 +42,11 @@ type Policy struct {
 	// Human-readable policy name
 	Name string `json:"name,omitempty"`
 
+	// Amendment chain: each revision references the hash of the policy it
+	// replaces, so both signatures also commit to the prior terms
+	Revision     int    `json:"revision,omitempty"`      // 0 for the original policy
+	PreviousHash string `json:"previous_hash,omitempty"` // hex-encoded Hash() of the prior revision
+
 	// Parties involved
 	OwnerName   string `json:"owner_name"`
 	OwnerKeyID  string `json:"owner_key_id"`
@@ -107,6 +112,8 @@ type PolicySignData struct {
 	Version          int          `json:"version"`
 	ID               string       `json:"id"`
 	Name             string       `json:"name,omitempty"`
+	Revision         int          `json:"revision,omitempty"`
+	PreviousHash     string       `json:"previous_hash,omitempty"`
 	OwnerName        string       `json:"owner_name"`
 	OwnerKeyID       string       `json:"owner_key_id"`
 	OwnerPubKey      string       `json:"owner_public_key"`
@@ -157,6 +164,8 @@ func (p *Policy) Hash() ([]byte, error) {
 		Version:          p.Version,
 		ID:               p.ID,
 		Name:             p.Name,
+		Revision:         p.Revision,
+		PreviousHash:     p.PreviousHash,
 		OwnerName:        p.OwnerName,
 		OwnerKeyID:       p.OwnerKeyID,
 		OwnerPubKey:      p.OwnerPubKey,
@@ -0,0 +1,239 @@
+package policy
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Signer roles
+const (
+	RoleOwner = "owner"
+	RoleHost  = "host"
+)
+
+// AmendmentStatus tracks a proposed policy change
+type AmendmentStatus string
+
+const (
+	AmendmentPending    AmendmentStatus = "pending"
+	AmendmentApplied    AmendmentStatus = "applied"
+	AmendmentRejected   AmendmentStatus = "rejected"
+	AmendmentSuperseded AmendmentStatus = "superseded" // Another amendment to the same revision was applied first
+)
+
+// Terms are the negotiable parts of a policy. Nil fields are left unchanged.
+type Terms struct {
+	RetentionDays    *int          `json:"retention_days,omitempty"`
+	DeletionMode     *DeletionMode `json:"deletion_mode,omitempty"`
+	AppendOnlyLocked *bool         `json:"append_only_locked,omitempty"`
+	MaxStorageBytes  *int64        `json:"max_storage_bytes,omitempty"`
+}
+
+// IsEmpty returns true if no term is changed
+func (t Terms) IsEmpty() bool {
+	return t.RetentionDays == nil && t.DeletionMode == nil && t.AppendOnlyLocked == nil && t.MaxStorageBytes == nil
+}
+
+// Validate checks the changed terms
+func (t Terms) Validate() error {
+	if t.RetentionDays != nil && *t.RetentionDays < 0 {
+		return errors.New("retention days cannot be negative")
+	}
+	if t.MaxStorageBytes != nil && *t.MaxStorageBytes < 0 {
+		return errors.New("max storage bytes cannot be negative")
+	}
+	if t.DeletionMode != nil {
+		switch *t.DeletionMode {
+		case DeletionBothRequired, DeletionOwnerOnly, DeletionTimeLockOnly, DeletionNever:
+		default:
+			return fmt.Errorf("unknown deletion mode %q", *t.DeletionMode)
+		}
+	}
+	return nil
+}
+
+// HashHex returns the hex-encoded policy hash
+func (p *Policy) HashHex() (string, error) {
+	hash, err := p.Hash()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash), nil
+}
+
+// Amend creates an unsigned successor to p with the given terms changed.
+// The parties and policy ID carry over; the successor references p's hash.
+func (p *Policy) Amend(t Terms) (*Policy, error) {
+	if t.IsEmpty() {
+		return nil, errors.New("amendment changes no terms")
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+
+	prevHash, err := p.HashHex()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	next := *p
+	next.Revision = p.Revision + 1
+	next.PreviousHash = prevHash
+	next.CreatedAt = now
+	next.EffectiveAt = now
+	next.OwnerSignature = ""
+	next.HostSignature = ""
+
+	if t.RetentionDays != nil {
+		next.RetentionDays = *t.RetentionDays
+	}
+	if t.DeletionMode != nil {
+		next.DeletionMode = *t.DeletionMode
+	}
+	if t.AppendOnlyLocked != nil {
+		next.AppendOnlyLocked = *t.AppendOnlyLocked
+	}
+	if t.MaxStorageBytes != nil {
+		next.MaxStorageBytes = *t.MaxStorageBytes
+	}
+
+	return &next, nil
+}
+
+// VerifySuccessor checks that p is a fully signed amendment of prev: same
+// policy and parties, the next revision, and referencing prev's hash
+func (p *Policy) VerifySuccessor(prev *Policy) error {
+	if prev == nil {
+		return errors.New("no prior policy")
+	}
+	if p.ID != prev.ID {
+		return fmt.Errorf("revision is for policy %s, not %s", p.ID, prev.ID)
+	}
+	if p.OwnerKeyID != prev.OwnerKeyID || p.OwnerPubKey != prev.OwnerPubKey ||
+		p.HostKeyID != prev.HostKeyID || p.HostPubKey != prev.HostPubKey {
+		return errors.New("revision changes the parties")
+	}
+	if p.Revision != prev.Revision+1 {
+		return fmt.Errorf("expected revision %d, got %d", prev.Revision+1, p.Revision)
+	}
+
+	prevHash, err := prev.HashHex()
+	if err != nil {
+		return err
+	}
+	if p.PreviousHash != prevHash {
+		return errors.New("revision does not reference the prior policy")
+	}
+
+	return p.Verify()
+}
+
+// VerifyHistory checks a policy history, oldest first: every entry must be
+// fully signed and each one a valid successor of the one before it
+func VerifyHistory(history []*Policy) error {
+	for i, p := range history {
+		if i == 0 {
+			if err := p.Verify(); err != nil {
+				return fmt.Errorf("revision %d: %w", p.Revision, err)
+			}
+			continue
+		}
+		if err := p.VerifySuccessor(history[i-1]); err != nil {
+			return fmt.Errorf("revision %d: %w", p.Revision, err)
+		}
+	}
+	return nil
+}
+
+// Amendment is a proposed policy revision collecting both parties' signatures
+type Amendment struct {
+	ID         string          `json:"id"`
+	PolicyID   string          `json:"policy_id"`
+	BaseHash   string          `json:"base_hash"` // Hash of the revision being amended
+	ProposedBy string          `json:"proposed_by"`
+	Reason     string          `json:"reason,omitempty"`
+	Terms      Terms           `json:"terms"`
+	Proposed   *Policy         `json:"proposed"`
+	Status     AmendmentStatus `json:"status"`
+	CreatedAt  time.Time       `json:"created_at"`
+	ResolvedAt time.Time       `json:"resolved_at,omitempty"`
+	ResolvedBy string          `json:"resolved_by,omitempty"`
+}
+
+// NewAmendment proposes changing current's terms
+func NewAmendment(current *Policy, proposedBy, reason string, t Terms) (*Amendment, error) {
+	if current == nil {
+		return nil, errors.New("no policy to amend")
+	}
+	if err := validateRole(proposedBy); err != nil {
+		return nil, err
+	}
+
+	proposed, err := current.Amend(t)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Amendment{
+		ID:         generatePolicyID(),
+		PolicyID:   current.ID,
+		BaseHash:   proposed.PreviousHash,
+		ProposedBy: proposedBy,
+		Reason:     reason,
+		Terms:      t,
+		Proposed:   proposed,
+		Status:     AmendmentPending,
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+// AddSignature verifies and attaches a party's signature over the proposed revision
+func (a *Amendment) AddSignature(role, signature string) error {
+	if a.Status != AmendmentPending {
+		return fmt.Errorf("amendment is %s", a.Status)
+	}
+	if err := validateRole(role); err != nil {
+		return err
+	}
+
+	pubKeyHex := a.Proposed.OwnerPubKey
+	if role == RoleHost {
+		pubKeyHex = a.Proposed.HostPubKey
+	}
+	hash, err := a.Proposed.Hash()
+	if err != nil {
+		return err
+	}
+	if err := verifySignature(pubKeyHex, signature, hash, role); err != nil {
+		return err
+	}
+
+	if role == RoleOwner {
+		a.Proposed.OwnerSignature = signature
+	} else {
+		a.Proposed.HostSignature = signature
+	}
+	return nil
+}
+
+// AwaitingSignatures returns the roles that haven't signed yet
+func (a *Amendment) AwaitingSignatures() []string {
+	var roles []string
+	if a.Proposed.OwnerSignature == "" {
+		roles = append(roles, RoleOwner)
+	}
+	if a.Proposed.HostSignature == "" {
+		roles = append(roles, RoleHost)
+	}
+	return roles
+}
+
+func validateRole(role string) error {
+	if role != RoleOwner && role != RoleHost {
+		return fmt.Errorf("role must be %q or %q", RoleOwner, RoleHost)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package policy
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+)
+
+func signedTestPolicy(t *testing.T) (*Policy, []byte, []byte) {
+	t.Helper()
+	ownerPub, ownerPriv, _ := crypto.GenerateKeyPair()
+	hostPub, hostPriv, _ := crypto.GenerateKeyPair()
+	p := NewPolicy(
+		"Alice", crypto.KeyID(ownerPub), crypto.EncodePublicKey(ownerPub),
+		"Bob", crypto.KeyID(hostPub), crypto.EncodePublicKey(hostPub),
+	)
+	require.NoError(t, p.SignAsOwner(ownerPriv))
+	require.NoError(t, p.SignAsHost(hostPriv))
+	return p, ownerPriv, hostPriv
+}
+
+func sign(t *testing.T, p *Policy, priv []byte) string {
+	t.Helper()
+	hash, err := p.Hash()
+	require.NoError(t, err)
+	sig, err := crypto.Sign(priv, hash)
+	require.NoError(t, err)
+	return hex.EncodeToString(sig)
+}
+
+func TestPolicyAmend(t *testing.T) {
+	p, ownerPriv, hostPriv := signedTestPolicy(t)
+
+	_, err := p.Amend(Terms{})
+	assert.Error(t, err, "empty amendment")
+
+	negative := -1
+	_, err = p.Amend(Terms{RetentionDays: &negative})
+	assert.Error(t, err)
+
+	days := 90
+	next, err := p.Amend(Terms{RetentionDays: &days})
+	require.NoError(t, err)
+	assert.Equal(t, 1, next.Revision)
+	assert.Equal(t, 90, next.RetentionDays)
+	assert.Equal(t, 30, p.RetentionDays, "original is untouched")
+	assert.Empty(t, next.OwnerSignature)
+	assert.Error(t, next.VerifySuccessor(p), "unsigned")
+
+	require.NoError(t, next.SignAsOwner(ownerPriv))
+	require.NoError(t, next.SignAsHost(hostPriv))
+	require.NoError(t, next.VerifySuccessor(p))
+	require.NoError(t, VerifyHistory([]*Policy{p, next}))
+
+	// A revision must reference the exact prior policy
+	next.PreviousHash = "00"
+	require.NoError(t, next.SignAsOwner(ownerPriv))
+	require.NoError(t, next.SignAsHost(hostPriv))
+	assert.ErrorContains(t, next.VerifySuccessor(p), "prior policy")
+}
+
+func TestAmendment_Signatures(t *testing.T) {
+	p, ownerPriv, hostPriv := signedTestPolicy(t)
+
+	mode := DeletionNever
+	_, err := NewAmendment(p, "mallory", "", Terms{DeletionMode: &mode})
+	assert.Error(t, err)
+
+	a, err := NewAmendment(p, RoleHost, "archive forever", Terms{DeletionMode: &mode})
+	require.NoError(t, err)
+	assert.Equal(t, AmendmentPending, a.Status)
+	assert.Equal(t, []string{RoleOwner, RoleHost}, a.AwaitingSignatures())
+
+	// The owner can't sign as the host
+	assert.Error(t, a.AddSignature(RoleHost, sign(t, a.Proposed, ownerPriv)))
+
+	require.NoError(t, a.AddSignature(RoleHost, sign(t, a.Proposed, hostPriv)))
+	assert.Equal(t, []string{RoleOwner}, a.AwaitingSignatures())
+	require.NoError(t, a.AddSignature(RoleOwner, sign(t, a.Proposed, ownerPriv)))
+	assert.Empty(t, a.AwaitingSignatures())
+	assert.NoError(t, a.Proposed.VerifySuccessor(p))
+
+	a.Status = AmendmentApplied
+	assert.Error(t, a.AddSignature(RoleOwner, sign(t, a.Proposed, ownerPriv)))
+}
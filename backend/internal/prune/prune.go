@@ -0,0 +1,200 @@
+// Package prune carries out approved deletions on the owner's repository.
+//
+// A deletion only frees space once restic prunes the data the deleted
+// snapshots referenced. Prune takes an exclusive lock and rewrites much of
+// the repository, so the executor coordinates with the host around it:
+//
+//  1. maintenance: the host opens a prune window, in which only this
+//     repository accepts writes
+//  2. unlock: stale locks left by interrupted commands are removed, and the
+//     prune waits for no running command
+//  3. forget: the snapshots the deletion names are forgotten, for a prune
+//     deletion those its retention plan removes
+//  4. prune: restic prune runs, its output streamed as progress
+//  5. cleanup: the host closes the window, collecting what the prune left
+//     behind and re-baselining its anomaly and integrity checks
+//
+// Each stage is recorded on the deletion request as it starts, so the
+// request shows how far the deletion got and why it stopped.
+package prune
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+)
+
+// progressInterval is how often progress within a stage is recorded
+const progressInterval = 5 * time.Second
+
+// Host is the host storing the repository
+type Host interface {
+	// BeginPrune opens a prune window for the deletion
+	BeginPrune(ctx context.Context, deletionID string) error
+	// EndPrune closes the window, collecting garbage and re-baselining
+	EndPrune(ctx context.Context, deletionID string) (*storage.PruneResult, error)
+}
+
+// Executor carries out approved deletions
+type Executor struct {
+	Repo restic.Runner
+	// Host is the host storing the repository; nil if none coordinates
+	Host Host
+	// Record records progress on the deletion request
+	Record func(consent.PruneProgress)
+	// Output, if set, is called with each line of restic prune's output
+	Output func(line string)
+}
+
+// Check reports whether req can be carried out
+func Check(req *consent.DeletionRequest) error {
+	if req.Status != consent.StatusApproved {
+		return apperrors.ErrRequestNotApproved
+	}
+	if req.ExecutedAt != nil {
+		return fmt.Errorf("deletion %s was already carried out", req.ID)
+	}
+	switch req.DeletionType {
+	case consent.DeletionTypeSnapshot:
+		if len(req.SnapshotIDs) == 0 {
+			return fmt.Errorf("deletion %s names no snapshots", req.ID)
+		}
+	case consent.DeletionTypePrune:
+	default:
+		return fmt.Errorf("%s deletions can't be carried out by a prune", req.DeletionType)
+	}
+	return nil
+}
+
+// Run carries out req. The host's prune window is closed even if a stage
+// fails, so the host never stays read-only for a prune that stopped.
+func (e *Executor) Run(ctx context.Context, req *consent.DeletionRequest) (err error) {
+	if err := Check(req); err != nil {
+		return err
+	}
+
+	started := time.Now()
+	p := consent.PruneProgress{Status: consent.ProgressRunning, StartedAt: &started}
+	stage := func(name string) {
+		p.Stage, p.Message = name, ""
+		e.record(p)
+	}
+	defer func() {
+		finished := time.Now()
+		p.FinishedAt = &finished
+		p.Status = consent.ProgressCompleted
+		if err != nil {
+			p.Status, p.Error = consent.ProgressFailed, err.Error()
+		}
+		e.record(p)
+	}()
+
+	if e.Host != nil {
+		stage(consent.PruneStageMaintenance)
+		if err := e.Host.BeginPrune(ctx, req.ID); err != nil {
+			return fmt.Errorf("host did not open a prune window: %w", err)
+		}
+		defer func() {
+			stage(consent.PruneStageCleanup)
+			result, endErr := e.Host.EndPrune(context.WithoutCancel(ctx), req.ID)
+			if endErr != nil {
+				err = errors.Join(err, fmt.Errorf("host did not close the prune window: %w", endErr))
+				return
+			}
+			p.FreedBytes = result.FreedBytes
+			if result.Anomalies > 0 {
+				p.Message = fmt.Sprintf("the host found %d unexplained losses", result.Anomalies)
+			}
+		}()
+	}
+
+	stage(consent.PruneStageUnlock)
+	if err := e.unlock(ctx); err != nil {
+		return err
+	}
+
+	// A prune deletion requested from the retention policy names the
+	// snapshots the policy removed
+	if len(req.SnapshotIDs) > 0 {
+		stage(consent.PruneStageForget)
+		if err := e.forget(ctx, req.SnapshotIDs); err != nil {
+			return err
+		}
+	}
+
+	stage(consent.PruneStagePrune)
+	recorded := time.Now()
+	return e.Repo.Prune(ctx, func(line string) {
+		if e.Output != nil {
+			e.Output(line)
+		}
+		p.Message = line
+		if time.Since(recorded) >= progressInterval {
+			recorded = time.Now()
+			e.record(p)
+		}
+	})
+}
+
+// forget forgets those of ids still in the repository: a resumed deletion
+// may have forgotten some before it stopped
+func (e *Executor) forget(ctx context.Context, ids []string) error {
+	snapshots, err := e.Repo.SnapshotList(ctx)
+	if err != nil {
+		return err
+	}
+	var remaining []string
+	for _, id := range ids {
+		sel, err := restic.ParseSnapshotSelector(id)
+		if err != nil {
+			return err
+		}
+		if _, err := sel.Resolve(snapshots); err == nil {
+			remaining = append(remaining, id)
+		}
+	}
+	if len(remaining) == 0 {
+		return nil
+	}
+	return e.Repo.Forget(ctx, restic.ForgetOptions{SnapshotIDs: remaining})
+}
+
+// unlock removes stale locks, refusing to go on while a running command
+// holds one: prune needs the repository to itself
+func (e *Executor) unlock(ctx context.Context) error {
+	locks, err := e.Repo.Locks(ctx)
+	if err != nil {
+		return err
+	}
+	if len(locks) == 0 {
+		return nil
+	}
+	if err := e.Repo.Unlock(ctx, false); err != nil {
+		return err
+	}
+	if locks, err = e.Repo.Locks(ctx); err != nil {
+		return err
+	}
+	if len(locks) > 0 {
+		holders := make([]string, 0, len(locks))
+		for _, l := range locks {
+			holders = append(holders, fmt.Sprintf("%s (pid %d)", l.Hostname, l.PID))
+		}
+		return fmt.Errorf("repository is locked by a running command on %s; try again once it finishes", strings.Join(holders, ", "))
+	}
+	return nil
+}
+
+func (e *Executor) record(p consent.PruneProgress) {
+	if e.Record != nil {
+		p.UpdatedAt = time.Now()
+		e.Record(p)
+	}
+}
@@ -0,0 +1,137 @@
+package prune
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/storage"
+	"github.com/lcrostarosa/airgapper/backend/internal/testutil"
+)
+
+// fakeHost records the prune windows it opens and closes
+type fakeHost struct {
+	calls    []string
+	beginErr error
+	freed    int64
+}
+
+func (h *fakeHost) BeginPrune(ctx context.Context, deletionID string) error {
+	h.calls = append(h.calls, "begin "+deletionID)
+	return h.beginErr
+}
+
+func (h *fakeHost) EndPrune(ctx context.Context, deletionID string) (*storage.PruneResult, error) {
+	h.calls = append(h.calls, "end "+deletionID)
+	return &storage.PruneResult{Deletion: deletionID, FreedBytes: h.freed}, nil
+}
+
+func newRepo(t *testing.T, snapshots ...string) *testutil.FakeRestic {
+	t.Helper()
+	repo := testutil.NewFakeRestic()
+	require.NoError(t, repo.Init(context.Background()))
+	for _, id := range snapshots {
+		repo.Snapshots = append(repo.Snapshots, restic.Snapshot{ID: id, Time: time.Now()})
+	}
+	repo.Calls = nil
+	return repo
+}
+
+func approved(deletionType consent.DeletionType, snapshots ...string) *consent.DeletionRequest {
+	return &consent.DeletionRequest{ID: "del-1", DeletionType: deletionType, SnapshotIDs: snapshots, Status: consent.StatusApproved}
+}
+
+func TestRun(t *testing.T) {
+	repo := newRepo(t, "snap1", "snap2")
+	repo.PruneOutput = []string{"loading indexes...", "repacking packs"}
+	host := &fakeHost{freed: 4096}
+	var stages []string
+	var last consent.PruneProgress
+	var output []string
+	e := &Executor{
+		Repo: repo,
+		Host: host,
+		Record: func(p consent.PruneProgress) {
+			if len(stages) == 0 || stages[len(stages)-1] != p.Stage {
+				stages = append(stages, p.Stage)
+			}
+			last = p
+		},
+		Output: func(line string) { output = append(output, line) },
+	}
+
+	require.NoError(t, e.Run(context.Background(), approved(consent.DeletionTypeSnapshot, "snap1")))
+	assert.Equal(t, []string{"begin del-1", "end del-1"}, host.calls)
+	assert.Equal(t, []string{"locks", "snapshots", "forget snap1", "prune"}, repo.Calls)
+	assert.Len(t, repo.Snapshots, 1)
+	assert.Equal(t, repo.PruneOutput, output)
+	assert.Equal(t, []string{consent.PruneStageMaintenance, consent.PruneStageUnlock, consent.PruneStageForget, consent.PruneStagePrune, consent.PruneStageCleanup}, stages)
+	assert.Equal(t, consent.ProgressCompleted, last.Status)
+	assert.Equal(t, int64(4096), last.FreedBytes)
+	assert.NotNil(t, last.FinishedAt)
+
+	// Resuming doesn't forget what is already forgotten
+	repo.Calls = nil
+	require.NoError(t, e.Run(context.Background(), approved(consent.DeletionTypeSnapshot, "snap1")))
+	assert.Equal(t, []string{"locks", "snapshots", "prune"}, repo.Calls)
+}
+
+func TestRunClosesWindowOnFailure(t *testing.T) {
+	repo := newRepo(t)
+	host := &fakeHost{}
+	var last consent.PruneProgress
+	e := &Executor{Repo: repo, Host: host, Record: func(p consent.PruneProgress) { last = p }}
+
+	// A running command holds a fresh lock, which unlock leaves alone
+	repo.HeldLocks = []restic.Lock{{ID: "l1", Time: time.Now(), Hostname: "laptop", PID: 42}}
+	err := e.Run(context.Background(), approved(consent.DeletionTypePrune))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "laptop (pid 42)")
+	assert.Equal(t, []string{"begin del-1", "end del-1"}, host.calls, "the window is closed even though the prune never ran")
+	assert.NotContains(t, repo.Calls, "prune")
+	assert.Equal(t, consent.ProgressFailed, last.Status)
+	assert.Equal(t, consent.PruneStageCleanup, last.Stage)
+	assert.NotEmpty(t, last.Error)
+
+	// Stale locks are removed
+	repo.HeldLocks = []restic.Lock{{ID: "l2", Time: time.Now().Add(-time.Hour), Hostname: "laptop"}}
+	host.calls = nil
+	require.NoError(t, e.Run(context.Background(), approved(consent.DeletionTypePrune)))
+	assert.Empty(t, repo.HeldLocks)
+}
+
+func TestRunWithoutWindow(t *testing.T) {
+	host := &fakeHost{beginErr: errors.New("storage is already read-only for maintenance")}
+	repo := newRepo(t)
+	e := &Executor{Repo: repo, Host: host}
+	assert.Error(t, e.Run(context.Background(), approved(consent.DeletionTypePrune)))
+	assert.Equal(t, []string{"begin del-1"}, host.calls, "a window that never opened isn't closed")
+	assert.Empty(t, repo.Calls)
+
+	// Without a host the prune runs directly against the repository
+	e.Host = nil
+	require.NoError(t, e.Run(context.Background(), approved(consent.DeletionTypePrune)))
+	assert.Equal(t, []string{"locks", "prune"}, repo.Calls)
+}
+
+func TestCheck(t *testing.T) {
+	assert.NoError(t, Check(approved(consent.DeletionTypePrune)))
+	assert.NoError(t, Check(approved(consent.DeletionTypeSnapshot, "snap1")))
+	assert.Error(t, Check(approved(consent.DeletionTypeSnapshot)), "names no snapshots")
+	assert.Error(t, Check(approved(consent.DeletionTypeAll)))
+
+	pending := approved(consent.DeletionTypePrune)
+	pending.Status = consent.StatusPending
+	assert.Error(t, Check(pending))
+
+	executed := approved(consent.DeletionTypePrune)
+	now := time.Now()
+	executed.ExecutedAt = &now
+	assert.Error(t, Check(executed))
+}
@@ -0,0 +1,66 @@
+package prune
+
+import (
+	"context"
+	"slices"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/policy"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/scheduler"
+)
+
+// ErrNoRetention means no retention policy is configured, so there is
+// nothing to preview
+var ErrNoRetention = apperrors.New(apperrors.CodeFailedPrecondition,
+	"no retention policy is configured; set one with 'airgapper schedule --preset'")
+
+// ForgetOptions applies keep to the scheduled backups' snapshots
+func ForgetOptions(keep *scheduler.RetentionConfig) restic.ForgetOptions {
+	return restic.ForgetOptions{
+		KeepLast:    keep.KeepLast,
+		KeepHourly:  keep.KeepHourly,
+		KeepDaily:   keep.KeepDaily,
+		KeepWeekly:  keep.KeepWeekly,
+		KeepMonthly: keep.KeepMonthly,
+		KeepYearly:  keep.KeepYearly,
+		Tags:        []string{restic.TagAirgapper},
+	}
+}
+
+// Preview returns what applying keep would keep and remove, without
+// removing anything. Snapshots pinned in pins are kept whatever keep says.
+func Preview(ctx context.Context, repo restic.Runner, keep *scheduler.RetentionConfig, pins *policy.PinSet) (*consent.RetentionPlan, error) {
+	if keep.IsZero() {
+		return nil, ErrNoRetention
+	}
+	preview, err := repo.ForgetDryRun(ctx, ForgetOptions(keep))
+	if err != nil {
+		return nil, apperrors.Coded(apperrors.CodeInternal, err)
+	}
+
+	plan := &consent.RetentionPlan{
+		Policy: keep.String(),
+		Keep:   make([]consent.PlannedSnapshot, 0, len(preview.Keep)),
+		Remove: make([]consent.PlannedSnapshot, 0, len(preview.Remove)),
+	}
+	for _, s := range preview.Keep {
+		plan.Keep = append(plan.Keep, planned(s))
+	}
+	for _, s := range preview.Remove {
+		p := planned(s)
+		if pins != nil && pins.Pinned(s.ID) != nil {
+			p.Pinned = true
+			plan.Keep = append(plan.Keep, p)
+			continue
+		}
+		plan.Remove = append(plan.Remove, p)
+	}
+	slices.SortStableFunc(plan.Keep, func(a, b consent.PlannedSnapshot) int { return a.Time.Compare(b.Time) })
+	return plan, nil
+}
+
+func planned(s restic.Snapshot) consent.PlannedSnapshot {
+	return consent.PlannedSnapshot{ID: s.ID, Time: s.Time, Paths: s.Paths}
+}
@@ -0,0 +1,50 @@
+package prune
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	"github.com/lcrostarosa/airgapper/backend/internal/policy"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/scheduler"
+)
+
+func TestPreview(t *testing.T) {
+	ctx := context.Background()
+	repo := newRepo(t)
+	start := time.Now().Add(-4 * time.Hour)
+	for i, id := range []string{"a1b2c3d4", "b2c3d4e5", "c3d4e5f6", "d4e5f6a7"} {
+		repo.Snapshots = append(repo.Snapshots, restic.Snapshot{ID: id, Time: start.Add(time.Duration(i) * time.Hour), Tags: []string{restic.TagAirgapper}})
+	}
+	repo.Snapshots = append(repo.Snapshots, restic.Snapshot{ID: "manual", Time: start, Tags: []string{"manual"}})
+
+	_, err := Preview(ctx, repo, &scheduler.RetentionConfig{}, nil)
+	assert.ErrorIs(t, err, ErrNoRetention)
+
+	pins := &policy.PinSet{Pins: []*policy.Pin{{ID: "pin-1", SnapshotID: "a1b2c3d4"}}}
+	plan, err := Preview(ctx, repo, &scheduler.RetentionConfig{KeepLast: 2}, pins)
+	require.NoError(t, err)
+	assert.Equal(t, "2 last", plan.Policy)
+	assert.Equal(t, []string{"b2c3d4e5"}, plan.RemoveIDs())
+	require.Len(t, plan.Keep, 3, "the pinned snapshot is kept, the untagged one isn't considered")
+	assert.Equal(t, consent.PlannedSnapshot{ID: "a1b2c3d4", Time: repo.Snapshots[0].Time, Pinned: true}, plan.Keep[0])
+
+	assert.Len(t, repo.Snapshots, 5, "nothing is removed")
+	assert.Equal(t, []string{"forget --dry-run"}, repo.Calls)
+}
+
+func TestRunRetentionDeletion(t *testing.T) {
+	repo := newRepo(t, "snap1", "snap2", "snap3")
+	e := &Executor{Repo: repo}
+
+	// Exactly the snapshots the approvers saw are forgotten
+	require.NoError(t, e.Run(context.Background(), approved(consent.DeletionTypePrune, "snap1", "snap2")))
+	assert.Equal(t, []string{"locks", "snapshots", "forget snap1 snap2", "prune"}, repo.Calls)
+	require.Len(t, repo.Snapshots, 1)
+	assert.Equal(t, "snap3", repo.Snapshots[0].ID)
+}
@@ -0,0 +1,416 @@
+// Package recoverykit writes an owner's disaster recovery kit: printable
+// instructions (the repository, who holds which key share, and the exact
+// commands to recover with or without Airgapper), an encrypted archive of
+// the owner's state, and checksums to verify both.
+//
+// Neither file holds the password or a key share, so the instructions can
+// be printed and handed out. The archive is sealed with the password and
+// can only be opened once enough shares have been combined.
+package recoverykit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/genesis"
+	"github.com/lcrostarosa/airgapper/backend/internal/statebackup"
+)
+
+// Files written to the kit's directory
+const (
+	InstructionsFile = "RECOVERY.txt"
+	ArchiveFile      = "recovery-kit.enc"
+	ChecksumsFile    = "SHA256SUMS"
+)
+
+// StateFile records, in the config directory, where the kit was last
+// written and what it covered
+const StateFile = "recovery-kit.json"
+
+// Version is the kit format version
+const Version = 1
+
+// keyContext separates the archive key from other uses of the password
+const keyContext = "airgapper recovery kit v1\x00"
+
+// Roles of the people a recovery needs
+const (
+	RoleOwner     = "owner"
+	RoleHost      = "backup host"
+	RoleCustodian = "recovery custodian"
+	RoleKeyHolder = "key holder"
+)
+
+// Holder is someone holding part of what a recovery needs
+type Holder struct {
+	Index   byte   `json:"index,omitempty"` // Share index (SSS and recovery shares)
+	Name    string `json:"name"`
+	Role    string `json:"role"`
+	Contact string `json:"contact,omitempty"`
+	KeyID   string `json:"key_id,omitempty"` // Signing key (consensus key holders)
+}
+
+// Manifest describes the vault the kit recovers
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Name      string    `json:"name"`
+	RepoURL   string    `json:"repo_url"`
+	VaultID   string    `json:"vault_id,omitempty"`
+	Mode      string    `json:"mode"`
+
+	// Shares needed to reconstruct the password, out of TotalShares; zero
+	// when the password isn't split (consensus mode)
+	Threshold   int `json:"threshold,omitempty"`
+	TotalShares int `json:"total_shares,omitempty"`
+
+	Holders           []Holder `json:"holders"`
+	SecretFingerprint string   `json:"secret_fingerprint"`
+	PolicyHash        string   `json:"policy_hash"`
+
+	// Fingerprint covers the fields above except CreatedAt; the kit is
+	// out of date when the config's fingerprint changes
+	Fingerprint string `json:"fingerprint"`
+}
+
+// archive is the encrypted part of the kit
+type archive struct {
+	Manifest *Manifest            `json:"manifest"`
+	State    *statebackup.Archive `json:"state"`
+}
+
+// State records the last kit written
+type State struct {
+	Dir         string    `json:"dir"`
+	Fingerprint string    `json:"fingerprint"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// NewManifest describes cfg's vault as of now
+func NewManifest(cfg *config.Config, now time.Time) (*Manifest, error) {
+	if cfg.Password == "" {
+		return nil, errors.New("the recovery kit needs the repository password")
+	}
+	m := &Manifest{
+		Version:           Version,
+		CreatedAt:         now.UTC(),
+		Name:              cfg.Name,
+		RepoURL:           cfg.RepoURL,
+		SecretFingerprint: genesis.SecretFingerprint(cfg.Password),
+		PolicyHash:        genesis.PolicyHash(cfg),
+	}
+	if doc, err := genesis.Load(cfg); err != nil {
+		return nil, err
+	} else if doc != nil {
+		m.VaultID = doc.VaultID
+	}
+
+	if cfg.UsesConsensusMode() {
+		m.Mode = string(genesis.ModeConsensus)
+		for _, kh := range cfg.Consensus.KeyHolders {
+			role := RoleKeyHolder
+			if kh.IsOwner {
+				role = RoleOwner
+			}
+			m.Holders = append(m.Holders, Holder{Name: kh.Name, Role: role, Contact: kh.Address, KeyID: kh.ID})
+		}
+	} else {
+		recovery := cfg.Emergency.GetRecovery()
+		m.Mode = string(genesis.ModeSSS)
+		m.Threshold, m.TotalShares = recovery.GetThreshold(), recovery.GetTotalShares()
+		host := Holder{Index: 2, Name: "backup host", Role: RoleHost}
+		if cfg.Peer != nil {
+			host.Name, host.Contact = cfg.Peer.Name, cfg.Peer.Address
+		}
+		m.Holders = append(m.Holders, Holder{Index: cfg.ShareIndex, Name: cfg.Name, Role: RoleOwner}, host)
+
+		// Shares 3 and up went to recovery custodians, named or not
+		for index := 3; index <= m.TotalShares; index++ {
+			custodian := Holder{Index: byte(index), Name: fmt.Sprintf("Custodian %d", index-2), Role: RoleCustodian}
+			for _, c := range recovery.Custodians {
+				if int(c.ShareIndex) == index {
+					custodian.Name, custodian.Contact = c.Name, c.Contact
+				}
+			}
+			m.Holders = append(m.Holders, custodian)
+		}
+	}
+
+	m.Fingerprint = m.fingerprint()
+	return m, nil
+}
+
+func (m *Manifest) fingerprint() string {
+	c := *m
+	c.CreatedAt, c.Fingerprint = time.Time{}, ""
+	data, _ := json.Marshal(c)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Write writes cfg's kit to dir and records it in the config directory.
+// It returns the manifest written.
+func Write(cfg *config.Config, dir string) (*Manifest, error) {
+	now := time.Now()
+	m, err := NewManifest(cfg, now)
+	if err != nil {
+		return nil, err
+	}
+	state, err := statebackup.Build(cfg, now)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(archive{Manifest: m, State: state})
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := crypto.Seal([]byte(cfg.Password), keyContext, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt recovery kit: %w", err)
+	}
+
+	instructions := Instructions(m, checksum(sealed))
+	sums := fmt.Sprintf("%s  %s\n%s  %s\n", checksum(sealed), ArchiveFile, checksum([]byte(instructions)), InstructionsFile)
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create recovery kit directory: %w", err)
+	}
+	for name, content := range map[string][]byte{
+		ArchiveFile:      sealed,
+		InstructionsFile: []byte(instructions),
+		ChecksumsFile:    []byte(sums),
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0600); err != nil {
+			return nil, fmt.Errorf("failed to write recovery kit: %w", err)
+		}
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	st := State{Dir: abs, Fingerprint: m.Fingerprint, GeneratedAt: now.UTC()}
+	if err := st.save(cfg); err != nil {
+		return nil, fmt.Errorf("failed to record recovery kit: %w", err)
+	}
+	return m, nil
+}
+
+// Open decrypts a kit's archive, returning its manifest and the owner's
+// state
+func Open(sealed []byte, password string) (*Manifest, *statebackup.Archive, error) {
+	data, err := crypto.Open([]byte(password), keyContext, sealed)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt recovery kit: %w", err)
+	}
+	var a archive
+	if err := json.Unmarshal(data, &a); err != nil || a.Manifest == nil || a.State == nil {
+		return nil, nil, errors.New("invalid recovery kit archive")
+	}
+	if a.Manifest.Version != Version {
+		return nil, nil, fmt.Errorf("unsupported recovery kit version %d", a.Manifest.Version)
+	}
+	if err := a.State.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return a.Manifest, a.State, nil
+}
+
+// recoveryShares picks the share indexes to recover a lost owner node
+// with: shares held by others, up to the threshold
+func (m *Manifest) recoveryShares() []byte {
+	var others []byte
+	for _, h := range m.Holders {
+		if h.Index != 0 && h.Role != RoleOwner {
+			others = append(others, h.Index)
+		}
+	}
+	return others[:min(len(others), m.Threshold)]
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadState reads the record of the last kit written for cfg; it returns
+// nil, nil if no kit has been written
+func LoadState(cfg *config.Config) (*State, error) {
+	data, err := os.ReadFile(filepath.Join(cfg.ConfigDir, StateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var st State
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("invalid recovery kit record: %w", err)
+	}
+	return &st, nil
+}
+
+func (st *State) save(cfg *config.Config) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(cfg.ConfigDir, StateFile), data, 0600)
+}
+
+// Stale reports whether cfg's key material or policy has changed since
+// the kit was written. It returns false if no kit has been written.
+func Stale(cfg *config.Config) (bool, *State, error) {
+	st, err := LoadState(cfg)
+	if err != nil || st == nil {
+		return false, st, err
+	}
+	m, err := NewManifest(cfg, time.Now())
+	if err != nil {
+		return false, st, err
+	}
+	return m.Fingerprint != st.Fingerprint, st, nil
+}
+
+// Instructions renders the kit's printable instructions. archiveSum is the
+// SHA-256 of the encrypted archive.
+func Instructions(m *Manifest, archiveSum string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "AIRGAPPER RECOVERY KIT\n")
+	fmt.Fprintf(&b, "======================\n\n")
+	fmt.Fprintf(&b, "Owner:      %s\n", m.Name)
+	fmt.Fprintf(&b, "Generated:  %s\n", m.CreatedAt.Format(time.RFC3339))
+	if m.VaultID != "" {
+		fmt.Fprintf(&b, "Vault:      %s\n", m.VaultID)
+	}
+	fmt.Fprintf(&b, "Repository: %s\n", m.RepoURL)
+	fmt.Fprintf(&b, "Mode:       %s\n\n", m.Mode)
+
+	b.WriteString(`This kit holds no password and no key share: it can be printed and kept
+with your other papers. The repository is a standard restic repository
+(https://restic.net), readable without Airgapper once you have its password.
+
+`)
+
+	b.WriteString("WHO HOLDS WHAT\n")
+	if m.Threshold > 0 {
+		fmt.Fprintf(&b, "  Any %d of these %d key shares reconstruct the password:\n", m.Threshold, m.TotalShares)
+	} else {
+		b.WriteString("  The password is not split: it is kept only in the owner's config.json.\n" +
+			"  Keep a copy with 'airgapper export-keys' to recover without the owner node.\n")
+	}
+	for _, h := range m.Holders {
+		line := "  "
+		if h.Index != 0 {
+			line += fmt.Sprintf("Share %-3d %s (%s)", h.Index, h.Name, h.Role)
+		} else {
+			line += fmt.Sprintf("Key %s  %s (%s)", h.KeyID, h.Name, h.Role)
+		}
+		if h.Contact != "" {
+			line += "  " + h.Contact
+		}
+		b.WriteString(line + "\n")
+	}
+	if m.Mode == string(genesis.ModeConsensus) {
+		b.WriteString("  Key holders approve restores with their signing keys; they hold no part\n  of the password.\n")
+	}
+	b.WriteString("\n")
+
+	secretArgs, getPassword := "--password-file password.txt", "Put the password from 'airgapper export-keys' in password.txt."
+	if m.Threshold > 0 {
+		shares := m.recoveryShares()
+		var shareArgs, combineArgs []string
+		for _, index := range shares {
+			shareArgs = append(shareArgs, fmt.Sprintf("--share %d:<hex>", index))
+			combineArgs = append(combineArgs, fmt.Sprintf("%d:<hex>", index))
+		}
+		secretArgs = strings.Join(shareArgs, " ")
+		getPassword = "Combine the shares with the script at the end of this kit:\n\n" +
+			"       python3 combine.py " + strings.Join(combineArgs, " ") + " > password.txt"
+		if len(shares) < m.Threshold {
+			fmt.Fprintf(&b, "WARNING: others hold fewer than %d shares, so a lost owner node can't be\n"+
+				"recovered without the owner's share. Add recovery custodians.\n\n", m.Threshold)
+		}
+	}
+
+	b.WriteString(`RECOVER WITH AIRGAPPER
+  On a new machine:
+
+    airgapper self-restore --repo '` + m.RepoURL + `' ` + secretArgs + `
+
+  If the repository is gone too, rebuild the owner node from this kit:
+
+    airgapper self-restore --kit ` + ArchiveFile + ` ` + secretArgs + `
+
+RECOVER WITHOUT AIRGAPPER
+  1. ` + getPassword + `
+
+  2. Check the password belongs to this vault; this must print
+     ` + m.SecretFingerprint + `:
+
+       printf 'airgapper-genesis-secret-v1%s' "$(cat password.txt)" | sha256sum
+
+  3. Use the repository with plain restic:
+
+       export RESTIC_REPOSITORY='` + m.RepoURL + `'
+       export RESTIC_PASSWORD_FILE=password.txt
+       restic snapshots
+       restic restore latest --target /restore/path
+
+VERIFY THIS KIT
+  sha256sum -c ` + ChecksumsFile + `
+
+  ` + ArchiveFile + ` must have SHA-256
+  ` + archiveSum + `
+  Approval policy hash: ` + m.PolicyHash + `
+`)
+	if m.Threshold > 0 {
+		b.WriteString("\n" + combineScript)
+	}
+	return b.String()
+}
+
+// combineScript reconstructs the password from shares without Airgapper,
+// using the same GF(256) arithmetic as package sss
+const combineScript = `COMBINING SHARES (save everything below this line as combine.py)
+import sys
+
+def mul(a, b):
+    p = 0
+    while b:
+        if b & 1:
+            p ^= a
+        a <<= 1
+        if a & 0x100:
+            a ^= 0x11b  # x^8 + x^4 + x^3 + x + 1
+        b >>= 1
+    return p
+
+def inv(a):
+    r = 1
+    for _ in range(254):
+        r = mul(r, a)
+    return r
+
+shares = [(int(i), bytes.fromhex(h)) for i, h in (a.split(":") for a in sys.argv[1:])]
+secret = bytearray()
+for k in range(len(shares[0][1])):
+    y = 0
+    for i, (xi, di) in enumerate(shares):
+        basis = 1
+        for j, (xj, _) in enumerate(shares):
+            if i != j:
+                basis = mul(basis, mul(xj, inv(xi ^ xj)))
+        y ^= mul(di[k], basis)
+    secret.append(y)
+sys.stdout.write(secret.decode())
+`
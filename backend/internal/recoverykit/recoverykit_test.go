@@ -0,0 +1,145 @@
+package recoverykit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/emergency"
+	"github.com/lcrostarosa/airgapper/backend/internal/genesis"
+)
+
+func sssConfig(t *testing.T) *config.Config {
+	t.Helper()
+	cfg := &config.Config{
+		Name:       "alice",
+		Role:       config.RoleOwner,
+		RepoURL:    "rest:http://bob:8000/alice",
+		Password:   "secret",
+		LocalShare: []byte{1, 2, 3},
+		ShareIndex: 1,
+		Peer:       &config.PeerInfo{Name: "bob", Address: "http://bob:8081"},
+		Emergency:  emergency.NewConfig().WithRecovery(2, 4, []string{"carol"}),
+		ConfigDir:  t.TempDir(),
+	}
+	require.NoError(t, cfg.Save())
+	return cfg
+}
+
+func TestManifest(t *testing.T) {
+	cfg := sssConfig(t)
+
+	m, err := NewManifest(cfg, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "sss", m.Mode)
+	assert.Equal(t, 2, m.Threshold)
+	assert.Equal(t, 4, m.TotalShares)
+	assert.Equal(t, []Holder{
+		{Index: 1, Name: "alice", Role: RoleOwner},
+		{Index: 2, Name: "bob", Role: RoleHost, Contact: "http://bob:8081"},
+		{Index: 3, Name: "carol", Role: RoleCustodian},
+		{Index: 4, Name: "Custodian 2", Role: RoleCustodian},
+	}, m.Holders)
+	assert.Equal(t, []byte{2, 3}, m.recoveryShares())
+	assert.Equal(t, genesis.SecretFingerprint("secret"), m.SecretFingerprint)
+
+	// The fingerprint ignores when the manifest was made, but not the holders
+	again, err := NewManifest(cfg, m.CreatedAt.Add(1))
+	require.NoError(t, err)
+	assert.Equal(t, m.Fingerprint, again.Fingerprint)
+	cfg.Peer.Name = "dave"
+	again, err = NewManifest(cfg, m.CreatedAt)
+	require.NoError(t, err)
+	assert.NotEqual(t, m.Fingerprint, again.Fingerprint)
+}
+
+func TestWriteAndOpen(t *testing.T) {
+	cfg := sssConfig(t)
+	dir := filepath.Join(t.TempDir(), "kit")
+
+	m, err := Write(cfg, dir)
+	require.NoError(t, err)
+
+	instructions, err := os.ReadFile(filepath.Join(dir, InstructionsFile))
+	require.NoError(t, err)
+	sealed, err := os.ReadFile(filepath.Join(dir, ArchiveFile))
+	require.NoError(t, err)
+	sums, err := os.ReadFile(filepath.Join(dir, ChecksumsFile))
+	require.NoError(t, err)
+
+	text := string(instructions)
+	assert.Contains(t, text, "rest:http://bob:8000/alice")
+	assert.Contains(t, text, "bob (backup host)")
+	assert.Contains(t, text, "--share 2:<hex> --share 3:<hex>")
+	assert.Contains(t, text, "python3 combine.py 2:<hex> 3:<hex>")
+	assert.NotContains(t, text, "secret\n")
+	assert.NotContains(t, string(sealed), "alice")
+
+	sum := sha256.Sum256(sealed)
+	assert.Contains(t, text, hex.EncodeToString(sum[:]))
+	assert.Contains(t, string(sums), hex.EncodeToString(sum[:])+"  "+ArchiveFile)
+	sum = sha256.Sum256(instructions)
+	assert.Contains(t, string(sums), hex.EncodeToString(sum[:])+"  "+InstructionsFile)
+
+	_, _, err = Open(sealed, "wrong")
+	assert.Error(t, err)
+	opened, state, err := Open(sealed, "secret")
+	require.NoError(t, err)
+	assert.Equal(t, m.Fingerprint, opened.Fingerprint)
+	assert.Contains(t, string(state.Files["config.json"]), "alice")
+	assert.NotContains(t, string(state.Files["config.json"]), `"password"`)
+}
+
+func TestStale(t *testing.T) {
+	cfg := sssConfig(t)
+
+	stale, st, err := Stale(cfg)
+	require.NoError(t, err)
+	assert.False(t, stale)
+	assert.Nil(t, st, "no kit written yet")
+
+	dir := filepath.Join(t.TempDir(), "kit")
+	_, err = Write(cfg, dir)
+	require.NoError(t, err)
+	stale, st, err = Stale(cfg)
+	require.NoError(t, err)
+	assert.False(t, stale)
+	assert.Equal(t, dir, st.Dir)
+
+	cfg.Emergency.Recovery.Threshold = 3
+	stale, _, err = Stale(cfg)
+	require.NoError(t, err)
+	assert.True(t, stale, "a policy change makes the kit stale")
+}
+
+func TestConsensusInstructions(t *testing.T) {
+	cfg := sssConfig(t)
+	cfg.LocalShare, cfg.Emergency = nil, nil
+	cfg.Consensus = &config.ConsensusConfig{
+		Threshold: 2,
+		TotalKeys: 2,
+		KeyHolders: []config.KeyHolder{
+			{ID: "aaaa", Name: "alice", IsOwner: true},
+			{ID: "bbbb", Name: "bob", Address: "http://bob:8081"},
+		},
+	}
+
+	m, err := NewManifest(cfg, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "consensus", m.Mode)
+	assert.Zero(t, m.Threshold)
+
+	text := Instructions(m, "sum")
+	assert.Contains(t, text, "Key bbbb  bob (key holder)")
+	assert.Contains(t, text, "--password-file password.txt")
+	assert.NotContains(t, text, "combine.py")
+	assert.True(t, strings.HasSuffix(text, "Approval policy hash: "+m.PolicyHash+"\n"))
+}
@@ -0,0 +1,267 @@
+// Package repair tracks files the host found corrupt and repairs them from
+// the owner's side, where the source data still is.
+//
+// The host reports each corrupt file. On the next backup a damaged pack is
+// salvaged with 'restic repair packs', which keeps its intact blobs and
+// removes it, and a damaged index is rebuilt with 'restic repair index'.
+// The backup then re-reads every file, so the blobs that were lost are
+// uploaded again. Snapshot and key files can't be rebuilt from the source
+// data and are left for a person to deal with.
+package repair
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+// ErrNotRebuildable is recorded for corrupt files a backup can't replace
+var ErrNotRebuildable = errors.New("snapshot and key files can't be rebuilt from the source data")
+
+// Request is a corrupt file the host asked the owner to repair
+type Request struct {
+	Repo       string    `json:"repo"`
+	File       string    `json:"file"`   // Relative to the repository, e.g. data/ab/ab12...
+	Source     string    `json:"source"` // How the host found it: "read" or "check"
+	DetectedAt time.Time `json:"detectedAt"`
+	ReceivedAt time.Time `json:"receivedAt"`
+	Salvaged   bool      `json:"salvaged,omitempty"` // The repository no longer refers to the file; a backup will upload what it held
+	RepairedAt time.Time `json:"repairedAt,omitzero"`
+	LastError  string    `json:"lastError,omitempty"` // Why the last repair attempt failed
+}
+
+// Type is the repository directory the file is in, e.g. "data"
+func (r *Request) Type() string {
+	fileType, _, _ := strings.Cut(r.File, "/")
+	return fileType
+}
+
+// ID is the file's name, the hash of its intended content
+func (r *Request) ID() string {
+	return path.Base(r.File)
+}
+
+// Pending reports whether the file is yet to be repaired
+func (r *Request) Pending() bool {
+	return r.RepairedAt.IsZero()
+}
+
+// Validate checks a request received from the host
+func (r *Request) Validate() error {
+	switch r.Type() {
+	case "data", "index", "snapshots", "keys":
+	default:
+		return fmt.Errorf("invalid file %q", r.File)
+	}
+	if id := r.ID(); len(id) != 64 || strings.Trim(id, "0123456789abcdef") != "" {
+		return fmt.Errorf("invalid file %q", r.File)
+	}
+	return nil
+}
+
+// Store persists repair requests
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore returns a store backed by the file at path
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Add records requests, skipping files already awaiting repair, and
+// returns how many were new
+func (s *Store) Add(requests []Request) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all, err := s.load()
+	if err != nil {
+		return 0, err
+	}
+	added := 0
+	for _, r := range requests {
+		if find(all, r.File) >= 0 {
+			continue
+		}
+		if r.ReceivedAt.IsZero() {
+			r.ReceivedAt = time.Now().UTC()
+		}
+		r.Salvaged, r.RepairedAt, r.LastError = false, time.Time{}, ""
+		all = append(all, r)
+		added++
+	}
+	if added == 0 {
+		return 0, nil
+	}
+	return added, s.save(all)
+}
+
+// List returns every request, oldest first
+func (s *Store) List() ([]Request, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Pending returns the requests yet to be repaired, oldest first
+func (s *Store) Pending() ([]Request, error) {
+	all, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	var pending []Request
+	for _, r := range all {
+		if r.Pending() {
+			pending = append(pending, r)
+		}
+	}
+	return pending, nil
+}
+
+// update applies fn to the pending request for each of files
+func (s *Store) update(files []string, fn func(*Request)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all, err := s.load()
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if i := find(all, file); i >= 0 {
+			fn(&all[i])
+		}
+	}
+	return s.save(all)
+}
+
+// find returns the index of the pending request for file, or -1
+func find(requests []Request, file string) int {
+	for i := len(requests) - 1; i >= 0; i-- {
+		if requests[i].File == file && requests[i].Pending() {
+			return i
+		}
+	}
+	return -1
+}
+
+// Backup backs up like repo.Backup, first repairing what the host reported.
+// A repair that fails is recorded on its requests and the backup goes ahead
+// as usual; the repair is tried again next time.
+func Backup(ctx context.Context, s *Store, repo restic.Runner, paths, tags []string, excludes ...string) (*restic.BackupSummary, error) {
+	pending, err := s.Pending()
+	if err != nil {
+		logging.Warn("Failed to read pending repairs", logging.Err(err))
+	}
+
+	var packs, salvage, files, unrebuildable []string
+	rebuildIndex := false
+	for _, r := range pending {
+		switch {
+		case r.Type() != "data" && r.Type() != "index":
+			if r.LastError == "" {
+				unrebuildable = append(unrebuildable, r.File)
+			}
+			continue
+		case r.Salvaged:
+		case r.Type() == "data":
+			packs = append(packs, r.ID())
+			salvage = append(salvage, r.File)
+		default:
+			rebuildIndex = true
+			salvage = append(salvage, r.File)
+		}
+		files = append(files, r.File)
+	}
+	if len(unrebuildable) > 0 {
+		logging.Warn("Corrupt files on the host need attention", logging.String("files", strings.Join(unrebuildable, ", ")))
+		record(s, unrebuildable, func(r *Request) { r.LastError = ErrNotRebuildable.Error() })
+	}
+	if len(files) == 0 {
+		return repo.Backup(ctx, paths, tags, excludes...)
+	}
+
+	logging.Info("Repairing files the host found corrupt", logging.String("files", strings.Join(files, ", ")))
+	if err := salvageRepo(ctx, repo, packs, rebuildIndex); err != nil {
+		logging.Warn("Repair failed; backing up without it", logging.Err(err))
+		record(s, salvage, func(r *Request) { r.LastError = err.Error() })
+		return repo.Backup(ctx, paths, tags, excludes...)
+	}
+	// Salvaging removes a pack, so it is never retried once it succeeds
+	record(s, salvage, func(r *Request) { r.Salvaged, r.LastError = true, "" })
+
+	// An incomplete snapshot still re-uploaded the data it could read
+	summary, err := repo.Rebackup(ctx, paths, tags, excludes...)
+	if err != nil && !errors.Is(err, restic.ErrIncomplete) {
+		record(s, files, func(r *Request) { r.LastError = err.Error() })
+		return nil, err
+	}
+	now := time.Now().UTC()
+	record(s, files, func(r *Request) { r.RepairedAt, r.LastError = now, "" })
+	logging.Info("Repaired files the host found corrupt", logging.Int("files", len(files)))
+	return summary, err
+}
+
+// salvageRepo rebuilds the index and salvages the intact blobs of damaged
+// packs
+func salvageRepo(ctx context.Context, repo restic.Runner, packs []string, rebuildIndex bool) error {
+	if rebuildIndex {
+		if err := repo.RepairIndex(ctx); err != nil {
+			return err
+		}
+	}
+	if len(packs) > 0 {
+		return repo.RepairPacks(ctx, packs)
+	}
+	return nil
+}
+
+// record updates requests, logging rather than failing the backup if the
+// store can't be written
+func record(s *Store, files []string, fn func(*Request)) {
+	if err := s.update(files, fn); err != nil {
+		logging.Warn("Failed to record repairs", logging.Err(err))
+	}
+}
+
+func (s *Store) load() ([]Request, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read repair requests: %w", err)
+	}
+
+	var requests []Request
+	if err := json.Unmarshal(data, &requests); err != nil {
+		return nil, fmt.Errorf("failed to parse repair requests: %w", err)
+	}
+	return requests, nil
+}
+
+func (s *Store) save(requests []Request) error {
+	data, err := json.MarshalIndent(requests, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize repair requests: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save repair requests: %w", err)
+	}
+	return nil
+}
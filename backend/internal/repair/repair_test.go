@@ -0,0 +1,164 @@
+package repair
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/testutil"
+)
+
+var (
+	packID  = strings.Repeat("ab", 32)
+	indexID = strings.Repeat("cd", 32)
+	snapID  = strings.Repeat("ef", 32)
+)
+
+// failingRebackup is a repository whose backups after a repair fail
+type failingRebackup struct {
+	*testutil.FakeRestic
+}
+
+func (f *failingRebackup) Rebackup(ctx context.Context, paths, tags []string, excludes ...string) (*restic.BackupSummary, error) {
+	return nil, errors.New("connection reset")
+}
+
+func newRepo(t *testing.T) *testutil.FakeRestic {
+	t.Helper()
+	repo := testutil.NewFakeRestic()
+	require.NoError(t, repo.Init(context.Background()))
+	repo.Calls = nil
+	return repo
+}
+
+func TestValidate(t *testing.T) {
+	for _, file := range []string{"data/ab/" + packID, "index/" + indexID, "snapshots/" + snapID, "keys/" + snapID} {
+		r := Request{File: file}
+		assert.NoError(t, r.Validate(), file)
+	}
+	for _, file := range []string{"", "config", "locks/" + packID, "data/ab/xyz", "data/../../etc/passwd", "index/" + strings.ToUpper(indexID)} {
+		r := Request{File: file}
+		assert.Error(t, r.Validate(), file)
+	}
+}
+
+func TestStoreAddSkipsPendingFiles(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "repairs.json"))
+	pending, err := s.Pending()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	added, err := s.Add([]Request{{Repo: "alice", File: "data/ab/" + packID}, {Repo: "alice", File: "index/" + indexID}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, added)
+	added, err = s.Add([]Request{{Repo: "alice", File: "data/ab/" + packID}})
+	require.NoError(t, err)
+	assert.Zero(t, added, "a file awaiting repair is listed once")
+
+	pending, err = s.Pending()
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.False(t, pending[0].ReceivedAt.IsZero())
+}
+
+func TestBackupWithoutRepairs(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "repairs.json"))
+	repo := newRepo(t)
+
+	summary, err := Backup(context.Background(), s, repo, []string{"/docs"}, nil)
+	require.NoError(t, err)
+	assert.NotEmpty(t, summary.SnapshotID)
+	assert.Equal(t, []string{"backup /docs"}, repo.Calls)
+}
+
+func TestBackupRepairs(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "repairs.json"))
+	repo := newRepo(t)
+	_, err := s.Add([]Request{
+		{Repo: "alice", File: "data/ab/" + packID, Source: "read"},
+		{Repo: "alice", File: "index/" + indexID, Source: "check"},
+	})
+	require.NoError(t, err)
+
+	_, err = Backup(context.Background(), s, repo, []string{"/docs"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"repair-index", "repair-packs " + packID, "rebackup /docs"}, repo.Calls)
+
+	pending, err := s.Pending()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+	all, err := s.List()
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.False(t, all[0].RepairedAt.IsZero())
+
+	// Once repaired, the file can be reported again
+	added, err := s.Add([]Request{{Repo: "alice", File: "data/ab/" + packID}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+}
+
+func TestBackupGoesAheadWhenRepairFails(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "repairs.json"))
+	repo := newRepo(t)
+	_, err := s.Add([]Request{{Repo: "alice", File: "data/ab/" + packID}})
+	require.NoError(t, err)
+
+	repo.Err = errors.New("restic repair packs failed: locked")
+	summary, err := Backup(context.Background(), s, repo, []string{"/docs"}, nil)
+	require.NoError(t, err)
+	assert.NotEmpty(t, summary.SnapshotID)
+	assert.Equal(t, []string{"repair-packs " + packID, "backup /docs"}, repo.Calls)
+
+	pending, err := s.Pending()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Contains(t, pending[0].LastError, "locked")
+	assert.False(t, pending[0].Salvaged)
+}
+
+func TestBackupDoesNotSalvageTwice(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "repairs.json"))
+	repo := newRepo(t)
+	_, err := s.Add([]Request{{Repo: "alice", File: "data/ab/" + packID}})
+	require.NoError(t, err)
+
+	// The pack is salvaged, but the backup after it fails
+	_, err = Backup(context.Background(), s, &failingRebackup{repo}, []string{"/docs"}, nil)
+	require.Error(t, err)
+	pending, err := s.Pending()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.True(t, pending[0].Salvaged)
+
+	// The pack is gone, so the next run only backs up
+	repo.Calls = nil
+	_, err = Backup(context.Background(), s, repo, []string{"/docs"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"rebackup /docs"}, repo.Calls)
+	pending, err = s.Pending()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestBackupLeavesUnrebuildableFiles(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "repairs.json"))
+	repo := newRepo(t)
+	_, err := s.Add([]Request{{Repo: "alice", File: "snapshots/" + snapID}})
+	require.NoError(t, err)
+
+	_, err = Backup(context.Background(), s, repo, []string{"/docs"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"backup /docs"}, repo.Calls)
+
+	pending, err := s.Pending()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, ErrNotRebuildable.Error(), pending[0].LastError)
+}
@@ -0,0 +1,261 @@
+// Package replication mirrors the owner's snapshots to secondary repositories
+// (a second host or cloud storage) so every backup exists in two places.
+package replication
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+// statusFile is where per-target replication status is kept in the config dir
+const statusFile = "replication-status.json"
+
+// maxMissingListed caps how many missing snapshot IDs a status records
+const maxMissingListed = 20
+
+// Target is a secondary repository that snapshots are copied to
+type Target struct {
+	Name    string `json:"name"`
+	RepoURL string `json:"repo_url"`
+	// Password for the target repository; empty uses the primary's password
+	Password string `json:"password,omitempty"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// Validate checks that the target is usable
+func (t Target) Validate() error {
+	if t.Name == "" {
+		return errors.New("replication target name is required")
+	}
+	if t.RepoURL == "" {
+		return fmt.Errorf("replication target %q has no repository URL", t.Name)
+	}
+	return nil
+}
+
+// TargetStatus is the outcome of the last replication to a target
+type TargetStatus struct {
+	Name            string    `json:"name"`
+	RepoURL         string    `json:"repoUrl"`
+	LastRun         time.Time `json:"lastRun"`
+	LastSuccess     time.Time `json:"lastSuccess,omitempty"`
+	LastError       string    `json:"lastError,omitempty"`
+	SourceSnapshots int       `json:"sourceSnapshots"`
+	TargetSnapshots int       `json:"targetSnapshots"`
+	// Missing lists (up to maxMissingListed) source snapshots absent from the target
+	Missing      []string `json:"missing,omitempty"`
+	MissingCount int      `json:"missingCount"`
+	InSync       bool     `json:"inSync"`
+}
+
+// Repo identifies a restic repository
+type Repo struct {
+	URL      string
+	Password string
+}
+
+// Engine performs the repository operations replication needs
+type Engine interface {
+	// Copy ensures the target repository exists and copies new snapshots to it
+	Copy(ctx context.Context, from, to Repo) error
+	// Snapshots lists a repository's snapshots
+	Snapshots(ctx context.Context, repo Repo) ([]restic.Snapshot, error)
+}
+
+// resticEngine replicates with restic copy
+type resticEngine struct{}
+
+// NewResticEngine returns an Engine backed by the restic binary
+func NewResticEngine() Engine { return resticEngine{} }
+
+func (resticEngine) Copy(ctx context.Context, from, to Repo) error {
+	src := restic.NewClient(from.URL, from.Password)
+	dst := restic.NewClient(to.URL, to.Password)
+	if err := dst.InitCopyOf(ctx, src); err != nil {
+		return err
+	}
+	return dst.CopyFrom(ctx, src)
+}
+
+func (resticEngine) Snapshots(ctx context.Context, repo Repo) ([]restic.Snapshot, error) {
+	return restic.NewClient(repo.URL, repo.Password).SnapshotList(ctx)
+}
+
+// Replicator copies snapshots from the primary repository to each enabled
+// target and records whether the copies match
+type Replicator struct {
+	source     Repo
+	targets    []Target
+	engine     Engine
+	configDir  string
+	onDiverged func(status TargetStatus)
+
+	mu sync.Mutex
+}
+
+// NewReplicator creates a replicator for the primary repository
+func NewReplicator(source Repo, targets []Target, configDir string) *Replicator {
+	return &Replicator{
+		source:    source,
+		targets:   targets,
+		engine:    NewResticEngine(),
+		configDir: configDir,
+	}
+}
+
+// SetEngine replaces the engine used for repository operations
+func (r *Replicator) SetEngine(engine Engine) {
+	r.engine = engine
+}
+
+// SetDivergenceCallback sets a callback for targets that fail or fall out of sync
+func (r *Replicator) SetDivergenceCallback(cb func(status TargetStatus)) {
+	r.onDiverged = cb
+}
+
+// Run replicates to every enabled target and returns their status
+func (r *Replicator) Run(ctx context.Context) []TargetStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	statuses := LoadStatus(r.configDir)
+	results := make([]TargetStatus, 0, len(r.targets))
+
+	for _, target := range r.targets {
+		if !target.Enabled {
+			continue
+		}
+		status := r.replicate(ctx, target, statuses[target.Name])
+		statuses[target.Name] = status
+		results = append(results, status)
+
+		if !status.InSync {
+			logging.Error("Replication target diverged from primary repository",
+				logging.String("target", status.Name),
+				logging.Int("missing", status.MissingCount),
+				logging.String("error", status.LastError))
+			if r.onDiverged != nil {
+				r.onDiverged(status)
+			}
+		}
+	}
+
+	if err := saveStatus(r.configDir, statuses); err != nil {
+		logging.Warn("Failed to save replication status", logging.Err(err))
+	}
+	return results
+}
+
+// replicate copies to one target, then compares snapshot lists
+func (r *Replicator) replicate(ctx context.Context, target Target, prev TargetStatus) TargetStatus {
+	status := TargetStatus{
+		Name:        target.Name,
+		RepoURL:     target.RepoURL,
+		LastRun:     time.Now(),
+		LastSuccess: prev.LastSuccess,
+	}
+
+	dest := Repo{URL: target.RepoURL, Password: target.Password}
+	if dest.Password == "" {
+		dest.Password = r.source.Password
+	}
+
+	if err := r.engine.Copy(ctx, r.source, dest); err != nil {
+		status.LastError = err.Error()
+	}
+
+	sourceSnaps, err := r.engine.Snapshots(ctx, r.source)
+	if err != nil {
+		status.LastError = joinErr(status.LastError, "list primary snapshots: "+err.Error())
+		return status
+	}
+	targetSnaps, err := r.engine.Snapshots(ctx, dest)
+	if err != nil {
+		status.LastError = joinErr(status.LastError, "list target snapshots: "+err.Error())
+		return status
+	}
+
+	status.SourceSnapshots = len(sourceSnaps)
+	status.TargetSnapshots = len(targetSnaps)
+	missing := Diff(sourceSnaps, targetSnaps)
+	status.MissingCount = len(missing)
+	if len(missing) > maxMissingListed {
+		missing = missing[:maxMissingListed]
+	}
+	status.Missing = missing
+
+	status.InSync = status.LastError == "" && status.MissingCount == 0
+	if status.InSync {
+		status.LastSuccess = status.LastRun
+	}
+	return status
+}
+
+// Diff returns the short IDs of source snapshots that have no copy in target.
+// restic copy records the source snapshot ID as the copy's "original".
+func Diff(source, target []restic.Snapshot) []string {
+	copied := make(map[string]bool, len(target)*2)
+	for _, s := range target {
+		copied[s.ID] = true
+		if s.Original != "" {
+			copied[s.Original] = true
+		}
+	}
+
+	var missing []string
+	for _, s := range source {
+		if copied[s.ID] || (s.Original != "" && copied[s.Original]) {
+			continue
+		}
+		id := s.ShortID
+		if id == "" {
+			id = s.ID
+		}
+		missing = append(missing, id)
+	}
+	return missing
+}
+
+func joinErr(existing, next string) string {
+	if existing == "" {
+		return next
+	}
+	return existing + "; " + next
+}
+
+// LoadStatus reads the recorded status of each target, keyed by target name
+func LoadStatus(configDir string) map[string]TargetStatus {
+	statuses := make(map[string]TargetStatus)
+	data, err := os.ReadFile(filepath.Join(configDir, statusFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logging.Debug("failed to read replication status", logging.Err(err))
+		}
+		return statuses
+	}
+	if err := json.Unmarshal(data, &statuses); err != nil {
+		logging.Debug("failed to parse replication status", logging.Err(err))
+		return make(map[string]TargetStatus)
+	}
+	return statuses
+}
+
+func saveStatus(configDir string, statuses map[string]TargetStatus) error {
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(statuses, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(configDir, statusFile), data, 0600)
+}
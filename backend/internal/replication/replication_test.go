@@ -0,0 +1,120 @@
+package replication
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+// fakeEngine copies snapshots between in-memory repositories
+type fakeEngine struct {
+	repos   map[string][]restic.Snapshot
+	copyErr error
+	copied  []Repo
+}
+
+func (e *fakeEngine) Copy(ctx context.Context, from, to Repo) error {
+	e.copied = append(e.copied, to)
+	if e.copyErr != nil {
+		return e.copyErr
+	}
+	for _, s := range Diff(e.repos[from.URL], e.repos[to.URL]) {
+		e.repos[to.URL] = append(e.repos[to.URL], restic.Snapshot{ID: "copy-" + s, Original: s})
+	}
+	return nil
+}
+
+func (e *fakeEngine) Snapshots(ctx context.Context, repo Repo) ([]restic.Snapshot, error) {
+	return e.repos[repo.URL], nil
+}
+
+func snaps(ids ...string) []restic.Snapshot {
+	out := make([]restic.Snapshot, len(ids))
+	for i, id := range ids {
+		out[i] = restic.Snapshot{ID: id}
+	}
+	return out
+}
+
+func TestDiff(t *testing.T) {
+	source := snaps("a", "b", "c")
+	target := []restic.Snapshot{{ID: "x", Original: "a"}, {ID: "b"}}
+
+	assert.Equal(t, []string{"c"}, Diff(source, target))
+	assert.Empty(t, Diff(source, append(target, restic.Snapshot{ID: "y", Original: "c"})))
+	assert.Empty(t, Diff(nil, target))
+}
+
+func TestReplicator_Run(t *testing.T) {
+	dir := t.TempDir()
+	engine := &fakeEngine{repos: map[string][]restic.Snapshot{
+		"primary": snaps("a", "b"),
+	}}
+
+	r := NewReplicator(Repo{URL: "primary", Password: "pw"}, []Target{
+		{Name: "offsite", RepoURL: "offsite", Enabled: true},
+		{Name: "off", RepoURL: "unused", Enabled: false},
+	}, dir)
+	r.SetEngine(engine)
+
+	results := r.Run(context.Background())
+	require.Len(t, results, 1)
+	assert.True(t, results[0].InSync)
+	assert.Equal(t, 2, results[0].SourceSnapshots)
+	assert.Equal(t, 2, results[0].TargetSnapshots)
+	assert.False(t, results[0].LastSuccess.IsZero())
+
+	// Target inherits the primary password when none is configured
+	require.Len(t, engine.copied, 1)
+	assert.Equal(t, "pw", engine.copied[0].Password)
+
+	// Status is persisted for /api/status and 'replicate list'
+	status := LoadStatus(dir)
+	assert.True(t, status["offsite"].InSync)
+	assert.NotContains(t, status, "off")
+}
+
+func TestReplicator_Divergence(t *testing.T) {
+	dir := t.TempDir()
+	engine := &fakeEngine{repos: map[string][]restic.Snapshot{
+		"primary": snaps("a", "b", "c"),
+		"offsite": {{ID: "x", Original: "a"}},
+	}}
+
+	r := NewReplicator(Repo{URL: "primary"}, []Target{
+		{Name: "offsite", RepoURL: "offsite", Password: "other", Enabled: true},
+	}, dir)
+	r.SetEngine(engine)
+
+	// Successful run first so LastSuccess is kept across the failure
+	first := r.Run(context.Background())
+	require.True(t, first[0].InSync)
+	assert.Equal(t, "other", engine.copied[0].Password)
+
+	engine.repos["primary"] = append(engine.repos["primary"], restic.Snapshot{ID: "d", ShortID: "d"})
+	engine.copyErr = errors.New("connection refused")
+
+	var alerted []TargetStatus
+	r.SetDivergenceCallback(func(s TargetStatus) { alerted = append(alerted, s) })
+
+	results := r.Run(context.Background())
+	require.Len(t, results, 1)
+	assert.False(t, results[0].InSync)
+	assert.Equal(t, []string{"d"}, results[0].Missing)
+	assert.Equal(t, 1, results[0].MissingCount)
+	assert.Contains(t, results[0].LastError, "connection refused")
+	assert.Equal(t, first[0].LastSuccess.Unix(), results[0].LastSuccess.Unix())
+	require.Len(t, alerted, 1)
+	assert.Equal(t, "offsite", alerted[0].Name)
+}
+
+func TestTarget_Validate(t *testing.T) {
+	assert.NoError(t, Target{Name: "a", RepoURL: "b"}.Validate())
+	assert.Error(t, Target{RepoURL: "b"}.Validate())
+	assert.Error(t, Target{Name: "a"}.Validate())
+}
@@ -0,0 +1,96 @@
+package restic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// RepoConfig is a repository's config, as 'restic cat config' prints it
+type RepoConfig struct {
+	ID string `json:"id"`
+	// Version is the repository format: 2 supports compression
+	Version int `json:"version"`
+}
+
+// RepoConfig reads the repository's config
+func (c *Client) RepoConfig(ctx context.Context) (*RepoConfig, error) {
+	cmd := c.command(ctx, "cat", "config", "-r", c.RepoURL)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read repository config: %w", err)
+	}
+
+	var config RepoConfig
+	if err := json.Unmarshal(output, &config); err != nil || config.ID == "" {
+		return nil, fmt.Errorf("failed to parse repository config")
+	}
+	return &config, nil
+}
+
+// RepoID returns the repository's ID (from restic cat config)
+func (c *Client) RepoID(ctx context.Context) (string, error) {
+	config, err := c.RepoConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+	return config.ID, nil
+}
+
+// CacheDir returns restic's local cache directory for a repository:
+// $RESTIC_CACHE_DIR/<id>, or restic/<id> under the user cache directory
+func CacheDir(repoID string) (string, error) {
+	base := os.Getenv("RESTIC_CACHE_DIR")
+	if base == "" {
+		userCache, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(userCache, "restic")
+	}
+	return filepath.Join(base, repoID), nil
+}
+
+// CachedFile is a repository file restic keeps a verbatim copy of locally
+type CachedFile struct {
+	Type string // "data" or "index"
+	ID   string
+	Path string
+	Size int64
+}
+
+// CachedFiles lists the index and data (tree pack) files in a restic cache
+// directory. The copies are byte-for-byte what the repository stores.
+func CachedFiles(dir string) ([]CachedFile, error) {
+	var files []CachedFile
+	for _, fileType := range []string{"index", "data"} {
+		root := filepath.Join(dir, fileType)
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) && path == root {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() || len(d.Name()) != 64 {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if info.Size() > 0 {
+				files = append(files, CachedFile{Type: fileType, ID: d.Name(), Path: path, Size: info.Size()})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,64 @@
+package restic
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/clientid"
+)
+
+// WithIdentity sets the key REST requests are signed with and returns c
+func (c *Client) WithIdentity(privateKey []byte) *Client {
+	c.Identity = privateKey
+	return c
+}
+
+// env is the environment restic runs with: Airgapper's own, the extra
+// variables of c's options, the repository password and, for a REST
+// repository, credentials binding its writes to c's identity
+func (c *Client) env(extra ...string) []string {
+	env := append(os.Environ(), c.Options.Environ()...)
+	env = append(env, c.managedEnv()...)
+	return append(env, extra...)
+}
+
+// managedEnv are the variables Airgapper sets for c's repository
+func (c *Client) managedEnv() []string {
+	env := []string{"RESTIC_PASSWORD=" + c.Password}
+	if user, pass, ok := c.restCredentials(time.Now()); ok {
+		env = append(env, "RESTIC_REST_USERNAME="+user, "RESTIC_REST_PASSWORD="+pass)
+	}
+	return env
+}
+
+// command returns a restic command running with c's environment and the
+// global flags of its options
+func (c *Client) command(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "restic", append(c.Options.Args(), args...)...)
+	cmd.Env = c.env()
+	return cmd
+}
+
+// restCredentials signs the basic auth credentials for c's REST
+// repository. Credentials written into the URL are left to win.
+func (c *Client) restCredentials(now time.Time) (user, pass string, ok bool) {
+	raw, isREST := strings.CutPrefix(c.RepoURL, "rest:")
+	if len(c.Identity) == 0 || !isREST {
+		return "", "", false
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.User != nil {
+		return "", "", false
+	}
+	repo := path.Base(strings.TrimSuffix(u.Path, "/"))
+	if repo == "." || repo == "/" {
+		return "", "", false
+	}
+	user, pass, err = clientid.Sign(c.Identity, repo, now)
+	return user, pass, err == nil
+}
@@ -0,0 +1,94 @@
+package restic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StaleLockAge is how old a lock must be to count as stale. Running restic
+// commands refresh their locks every 5 minutes, so a lock this old was left
+// behind by an interrupted command; restic itself uses the same threshold.
+const StaleLockAge = 30 * time.Minute
+
+// Lock is a repository lock (from restic cat lock)
+type Lock struct {
+	ID        string    `json:"id"`
+	Time      time.Time `json:"time"`
+	Exclusive bool      `json:"exclusive"`
+	Hostname  string    `json:"hostname"`
+	Username  string    `json:"username,omitempty"`
+	PID       int       `json:"pid,omitempty"`
+}
+
+// Stale reports whether the lock is old enough to have been left behind
+func (l Lock) Stale(now time.Time) bool {
+	return now.Sub(l.Time) > StaleLockAge
+}
+
+// Locks lists the repository's locks
+func (c *Client) Locks(ctx context.Context) ([]Lock, error) {
+	output, err := c.output(ctx, "list", "locks")
+	if err != nil {
+		return nil, fmt.Errorf("restic list locks failed: %w", err)
+	}
+
+	var locks []Lock
+	for _, id := range strings.Fields(string(output)) {
+		data, err := c.output(ctx, "cat", "lock", id)
+		if err != nil {
+			// Removed since it was listed
+			continue
+		}
+		lock, err := parseLock(id, data)
+		if err != nil {
+			return nil, err
+		}
+		locks = append(locks, lock)
+	}
+	return locks, nil
+}
+
+// parseLock parses restic cat lock output
+func parseLock(id string, data []byte) (Lock, error) {
+	var lock Lock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return Lock{}, fmt.Errorf("failed to parse lock %s: %w", id, err)
+	}
+	lock.ID = id
+	return lock, nil
+}
+
+// Unlock removes stale locks, or every lock if removeAll is set
+func (c *Client) Unlock(ctx context.Context, removeAll bool) error {
+	args := []string{"unlock"}
+	if removeAll {
+		args = append(args, "--remove-all")
+	}
+	if _, err := c.output(ctx, args...); err != nil {
+		return fmt.Errorf("restic unlock failed: %w", err)
+	}
+	return nil
+}
+
+// output runs a restic command that doesn't lock the repository and
+// returns its output
+func (c *Client) output(ctx context.Context, args ...string) ([]byte, error) {
+	args = append(args, "-r", c.RepoURL, "--no-lock")
+	cmd := c.command(ctx, args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s", msg)
+		}
+		return nil, err
+	}
+	return output, nil
+}
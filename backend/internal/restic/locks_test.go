@@ -0,0 +1,25 @@
+package restic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLock(t *testing.T) {
+	data := []byte(`{"time":"2024-01-15T02:00:00.123456789Z","exclusive":true,"hostname":"laptop","username":"alice","pid":4242,"uid":1000,"gid":1000}`)
+	lock, err := parseLock("3f1a", data)
+	require.NoError(t, err)
+	assert.Equal(t, "3f1a", lock.ID)
+	assert.True(t, lock.Exclusive)
+	assert.Equal(t, "laptop", lock.Hostname)
+	assert.Equal(t, 4242, lock.PID)
+
+	assert.False(t, lock.Stale(lock.Time.Add(5*time.Minute)))
+	assert.True(t, lock.Stale(lock.Time.Add(StaleLockAge+time.Second)))
+
+	_, err = parseLock("3f1a", []byte("not json"))
+	assert.Error(t, err)
+}
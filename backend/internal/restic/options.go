@@ -0,0 +1,172 @@
+package restic
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Options are extra environment variables and global flags every restic
+// invocation runs with, e.g. for a proxy, an S3 backend's credentials or a
+// REST server behind a private CA
+type Options struct {
+	// Env are extra environment variables, e.g. RESTIC_CACHE_DIR,
+	// HTTPS_PROXY or AWS_ACCESS_KEY_ID. They override the process's own.
+	Env map[string]string `json:"env,omitempty"`
+	// CACert are files of root certificates to trust besides the system's (--cacert)
+	CACert []string `json:"cacert,omitempty"`
+	// InsecureTLS skips verifying the repository's TLS certificate (--insecure-tls)
+	InsecureTLS bool `json:"insecure_tls,omitempty"`
+	// PackSize is the target size of pack files in MiB, 4 to 128 (--pack-size)
+	PackSize int `json:"pack_size,omitempty"`
+	// Flags are other global flags, e.g. "--limit-upload=1024"
+	Flags []string `json:"flags,omitempty"`
+}
+
+// reservedEnv are the variables Airgapper sets itself, which Options can't
+// override
+var reservedEnv = []string{
+	"RESTIC_PASSWORD", "RESTIC_PASSWORD_FILE", "RESTIC_PASSWORD_COMMAND",
+	"RESTIC_REPOSITORY", "RESTIC_REPOSITORY_FILE",
+	"RESTIC_REST_USERNAME", "RESTIC_REST_PASSWORD",
+	"RESTIC_FROM_PASSWORD", "RESTIC_FROM_PASSWORD_FILE", "RESTIC_FROM_PASSWORD_COMMAND",
+	"RESTIC_FROM_REPOSITORY", "RESTIC_FROM_REPOSITORY_FILE",
+}
+
+// reservedFlags are the global flags Airgapper passes itself, which Options
+// can't add
+var reservedFlags = []string{
+	"--repo", "--repository-file", "--password-file", "--password-command",
+	"--insecure-no-password", "--key-hint",
+	"--from-repo", "--from-repository-file", "--from-password-file", "--from-password-command",
+	"--cacert", "--insecure-tls", "--pack-size", // Set by their own options
+}
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Validate checks the options
+func (o *Options) Validate() error {
+	if o == nil {
+		return nil
+	}
+	for name := range o.Env {
+		if !envNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid environment variable name %q", name)
+		}
+		if slices.Contains(reservedEnv, strings.ToUpper(name)) {
+			return fmt.Errorf("%s is set by Airgapper and can't be overridden", name)
+		}
+	}
+	for _, file := range o.CACert {
+		if file == "" {
+			return fmt.Errorf("empty cacert file")
+		}
+	}
+	if o.PackSize != 0 && (o.PackSize < 4 || o.PackSize > 128) {
+		return fmt.Errorf("pack_size %d must be between 4 and 128 MiB", o.PackSize)
+	}
+	for _, flag := range o.Flags {
+		name, _, _ := strings.Cut(flag, "=")
+		if !strings.HasPrefix(name, "--") || len(name) < 3 {
+			return fmt.Errorf("invalid flag %q: want --name or --name=value", flag)
+		}
+		if slices.Contains(reservedFlags, name) {
+			return fmt.Errorf("flag %s is set by Airgapper or its own option", name)
+		}
+	}
+	return nil
+}
+
+// Args are the global flags the options pass to restic
+func (o *Options) Args() []string {
+	if o == nil {
+		return nil
+	}
+	var args []string
+	for _, file := range o.CACert {
+		args = append(args, "--cacert", file)
+	}
+	if o.InsecureTLS {
+		args = append(args, "--insecure-tls")
+	}
+	if o.PackSize > 0 {
+		args = append(args, "--pack-size", strconv.Itoa(o.PackSize))
+	}
+	return append(args, o.Flags...)
+}
+
+// Environ returns the options' environment variables as KEY=value, sorted
+func (o *Options) Environ() []string {
+	if o == nil {
+		return nil
+	}
+	var env []string
+	for _, name := range slices.Sorted(maps.Keys(o.Env)) {
+		env = append(env, name+"="+o.Env[name])
+	}
+	return env
+}
+
+// Where an EnvVar's value comes from
+const (
+	EnvFromProcess   = "process"   // Airgapper's own environment
+	EnvFromConfig    = "config"    // The restic options in the config
+	EnvFromAirgapper = "airgapper" // Set by Airgapper for the repository
+)
+
+// EnvVar is a variable in restic's environment
+type EnvVar struct {
+	Name   string
+	Value  string
+	Source string
+}
+
+// envPrefixes are the prefixes of variables restic or its backends read
+var envPrefixes = []string{
+	"RESTIC_", "AWS_", "AZURE_", "B2_", "GOOGLE_", "GOOGLE_APPLICATION_CREDENTIALS",
+	"OS_", "ST_", "RCLONE_", "SSH_AUTH_SOCK", "SSL_CERT_",
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "TMPDIR", "XDG_CACHE_HOME",
+}
+
+// Environment describes the environment c runs restic with: the variables
+// from environ (usually os.Environ()) that restic or its backends read,
+// overridden by c's options and then by what Airgapper sets, sorted by
+// name. Secret values are included as they are.
+func (c *Client) Environment(environ []string) []EnvVar {
+	vars := map[string]EnvVar{}
+	for _, kv := range environ {
+		name, value, _ := strings.Cut(kv, "=")
+		if slices.ContainsFunc(envPrefixes, func(p string) bool { return strings.HasPrefix(strings.ToUpper(name), p) }) {
+			vars[name] = EnvVar{Name: name, Value: value, Source: EnvFromProcess}
+		}
+	}
+	for _, kv := range c.Options.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		vars[name] = EnvVar{Name: name, Value: value, Source: EnvFromConfig}
+	}
+	for _, kv := range c.managedEnv() {
+		name, value, _ := strings.Cut(kv, "=")
+		vars[name] = EnvVar{Name: name, Value: value, Source: EnvFromAirgapper}
+	}
+	return slices.SortedFunc(maps.Values(vars), func(a, b EnvVar) int { return strings.Compare(a.Name, b.Name) })
+}
+
+// WithOptions sets the extra environment and global flags restic runs
+// with and returns c
+func (c *Client) WithOptions(opts *Options) *Client {
+	c.Options = opts
+	return c
+}
+
+// CacheDir returns restic's local cache directory for a repository,
+// honouring a RESTIC_CACHE_DIR set in c's options
+func (c *Client) CacheDir(repoID string) (string, error) {
+	if c.Options != nil && c.Options.Env["RESTIC_CACHE_DIR"] != "" {
+		return filepath.Join(c.Options.Env["RESTIC_CACHE_DIR"], repoID), nil
+	}
+	return CacheDir(repoID)
+}
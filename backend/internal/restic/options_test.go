@@ -0,0 +1,87 @@
+package restic
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionsValidate(t *testing.T) {
+	var none *Options
+	assert.NoError(t, none.Validate())
+	assert.NoError(t, (&Options{
+		Env:      map[string]string{"HTTPS_PROXY": "http://proxy:3128", "AWS_ACCESS_KEY_ID": "AKIA"},
+		CACert:   []string{"/etc/ssl/private-ca.pem"},
+		PackSize: 64,
+		Flags:    []string{"--limit-upload=1024", "--no-cache"},
+	}).Validate())
+
+	for name, opts := range map[string]*Options{
+		"bad name":           {Env: map[string]string{"BAD-NAME": "x"}},
+		"password":           {Env: map[string]string{"RESTIC_PASSWORD": "x"}},
+		"lower-case managed": {Env: map[string]string{"restic_repository": "x"}},
+		"empty cacert":       {CACert: []string{""}},
+		"small pack":         {PackSize: 2},
+		"large pack":         {PackSize: 256},
+		"short flag":         {Flags: []string{"-v"}},
+		"repo flag":          {Flags: []string{"--repo=/elsewhere"}},
+		"password command":   {Flags: []string{"--password-command", "cat"}},
+		"own option":         {Flags: []string{"--insecure-tls"}},
+	} {
+		assert.Error(t, opts.Validate(), name)
+	}
+}
+
+func TestOptionsArgsAndEnviron(t *testing.T) {
+	var none *Options
+	assert.Empty(t, none.Args())
+	assert.Empty(t, none.Environ())
+
+	opts := &Options{
+		Env:         map[string]string{"HTTPS_PROXY": "http://proxy:3128", "AWS_REGION": "eu-west-1"},
+		CACert:      []string{"/ca1.pem", "/ca2.pem"},
+		InsecureTLS: true,
+		PackSize:    32,
+		Flags:       []string{"--limit-upload=1024"},
+	}
+	assert.Equal(t, []string{
+		"--cacert", "/ca1.pem", "--cacert", "/ca2.pem", "--insecure-tls", "--pack-size", "32", "--limit-upload=1024",
+	}, opts.Args())
+	assert.Equal(t, []string{"AWS_REGION=eu-west-1", "HTTPS_PROXY=http://proxy:3128"}, opts.Environ())
+
+	// Every invocation gets the flags before the command, and the
+	// environment with Airgapper's own variables winning
+	cmd := NewClient("/srv/restic", "pw").WithOptions(opts).command(context.Background(), "snapshots", "-r", "/srv/restic")
+	assert.Equal(t, []string{"restic", "--cacert", "/ca1.pem", "--cacert", "/ca2.pem", "--insecure-tls", "--pack-size", "32",
+		"--limit-upload=1024", "snapshots", "-r", "/srv/restic"}, cmd.Args)
+	assert.Contains(t, cmd.Env, "HTTPS_PROXY=http://proxy:3128")
+	assert.Greater(t, slices.Index(cmd.Env, "RESTIC_PASSWORD=pw"), slices.Index(cmd.Env, "AWS_REGION=eu-west-1"))
+}
+
+func TestEnvironment(t *testing.T) {
+	c := NewClient("/srv/restic", "pw").WithOptions(&Options{
+		Env: map[string]string{"HTTPS_PROXY": "http://proxy:3128", "RESTIC_CACHE_DIR": "/var/cache/restic"},
+	})
+	env := c.Environment([]string{
+		"HOME=/root",
+		"https_proxy=http://ignored:1",
+		"HTTPS_PROXY=http://old:1",
+		"AWS_SECRET_ACCESS_KEY=secret",
+		"RESTIC_PASSWORD=from-process",
+	})
+	assert.Equal(t, []EnvVar{
+		{Name: "AWS_SECRET_ACCESS_KEY", Value: "secret", Source: EnvFromProcess},
+		{Name: "HTTPS_PROXY", Value: "http://proxy:3128", Source: EnvFromConfig},
+		{Name: "RESTIC_CACHE_DIR", Value: "/var/cache/restic", Source: EnvFromConfig},
+		{Name: "RESTIC_PASSWORD", Value: "pw", Source: EnvFromAirgapper},
+		{Name: "https_proxy", Value: "http://ignored:1", Source: EnvFromProcess},
+	}, env)
+
+	dir, err := c.CacheDir("abc123")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("/var/cache/restic", "abc123"), dir)
+}
@@ -0,0 +1,45 @@
+package restic
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// PathError is a backup path that can't be backed up, and why
+type PathError struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// CheckPaths splits backup paths into those that exist and can be read, and
+// those that can't. Restic fails a whole backup over a missing path, so the
+// caller can leave the failed ones out instead.
+func CheckPaths(paths []string) (readable []string, failed []PathError) {
+	for _, p := range paths {
+		if err := checkPath(p); err != nil {
+			failed = append(failed, PathError{Path: p, Error: err.Error()})
+			continue
+		}
+		readable = append(readable, p)
+	}
+	return readable, failed
+}
+
+// checkPath checks path exists and, for a directory, can be listed
+func checkPath(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil || !info.IsDir() {
+		return err
+	}
+	if _, err := f.Readdirnames(1); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
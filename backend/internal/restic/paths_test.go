@@ -0,0 +1,34 @@
+package restic
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPaths(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(file, []byte("x"), 0600))
+	empty := filepath.Join(dir, "empty")
+	require.NoError(t, os.Mkdir(empty, 0700))
+	missing := filepath.Join(dir, "missing")
+
+	readable, failed := CheckPaths([]string{dir, file, missing, empty})
+	assert.Equal(t, []string{dir, file, empty}, readable)
+	require.Len(t, failed, 1)
+	assert.Equal(t, missing, failed[0].Path)
+	assert.Contains(t, failed[0].Error, "no such file or directory")
+
+	if os.Geteuid() != 0 {
+		locked := filepath.Join(dir, "locked")
+		require.NoError(t, os.Mkdir(locked, 0))
+		t.Cleanup(func() { _ = os.Chmod(locked, 0700) })
+		_, failed = CheckPaths([]string{locked})
+		require.Len(t, failed, 1)
+		assert.Contains(t, failed[0].Error, "permission denied")
+	}
+}
@@ -0,0 +1,53 @@
+package restic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Prune removes data no longer referenced by any snapshot, repacking packs
+// that are partly used. It takes an exclusive lock and rewrites much of the
+// repository, so it can run for a long time; progress, if set, is called
+// with each line of restic's output as it appears.
+func (c *Client) Prune(ctx context.Context, progress func(line string)) error {
+	cmd := c.command(ctx, "prune", "-r", c.RepoURL)
+	interruptOnCancel(cmd)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start restic prune: %w", err)
+	}
+
+	// Progress bars redraw their line with a carriage return
+	scanner := bufio.NewScanner(stdout)
+	scanner.Split(scanLines)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && progress != nil {
+			progress(line)
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("restic prune failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// scanLines splits output on newlines and carriage returns
+func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
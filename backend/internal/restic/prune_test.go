@@ -0,0 +1,19 @@
+package restic
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanLines(t *testing.T) {
+	scanner := bufio.NewScanner(strings.NewReader("loading indexes...\n[0:01] 50.00%\r[0:02] 100.00%\ndone"))
+	scanner.Split(scanLines)
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	assert.Equal(t, []string{"loading indexes...", "[0:01] 50.00%", "[0:02] 100.00%", "done"}, lines)
+}
@@ -0,0 +1,47 @@
+package restic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// A pack the host finds corrupt is repaired by the owner, who still has the
+// source data: 'restic repair packs' salvages the pack's intact blobs into
+// new packs and removes it, then a backup that re-reads every file uploads
+// what was lost. A damaged index is rebuilt from the packs.
+
+// RepairIndex rebuilds the repository index from the packs it holds
+func (c *Client) RepairIndex(ctx context.Context) error {
+	return c.repair(ctx, "repair", "index")
+}
+
+// RepairPacks salvages the intact blobs of the given packs into new packs
+// and removes the damaged packs
+func (c *Client) RepairPacks(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return errors.New("no packs specified for repair")
+	}
+	return c.repair(ctx, append([]string{"repair", "packs"}, ids...)...)
+}
+
+// Rebackup backs up paths like Backup, but re-reads every file rather than
+// trusting the parent snapshot, so data missing from the repository is
+// uploaded again
+func (c *Client) Rebackup(ctx context.Context, paths []string, tags []string, excludes ...string) (*BackupSummary, error) {
+	return c.backup(ctx, true, paths, tags, excludes)
+}
+
+func (c *Client) repair(ctx context.Context, args ...string) error {
+	cmd := c.command(ctx, append(args, "-r", c.RepoURL)...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("restic %s failed: %s", strings.Join(args[:2], " "), strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -2,19 +2,50 @@
 package restic
 
 import (
+	"bufio"
 	"bytes"
+	"cmp"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Client wraps restic operations
 type Client struct {
 	RepoURL  string
 	Password string
+	// Compression is the compression mode backups use; empty leaves
+	// restic's default (auto on version 2 repositories)
+	Compression string
+	// Identity is the Ed25519 private key REST requests are signed with,
+	// so a host requiring the owner's identity accepts their writes
+	Identity []byte
+	// Options are extra environment variables and global flags
+	Options *Options
+}
+
+// Compression modes of repository version 2 (restic 0.14+)
+const (
+	CompressionAuto = "auto"
+	CompressionMax  = "max"
+	CompressionOff  = "off"
+)
+
+// ValidateCompression checks a compression mode; empty is restic's default
+func ValidateCompression(mode string) error {
+	switch mode {
+	case "", CompressionAuto, CompressionMax, CompressionOff:
+		return nil
+	}
+	return fmt.Errorf("invalid compression %q (use %s, %s or %s)", mode, CompressionAuto, CompressionMax, CompressionOff)
 }
 
 // NewClient creates a new restic client
@@ -32,10 +63,28 @@ func NewClient(repoURL, password string) *Client {
 	}
 }
 
-// Init initializes a new restic repository
+// WithCompression sets the compression mode backups use and returns c
+func (c *Client) WithCompression(mode string) *Client {
+	c.Compression = mode
+	return c
+}
+
+// compressionArgs are the arguments passing c's compression mode to restic
+func (c *Client) compressionArgs() []string {
+	if c.Compression == "" {
+		return nil
+	}
+	return []string{"--compression", c.Compression}
+}
+
+// Init initializes a new restic repository. With a compression mode set,
+// it asks for repository version 2, which compression needs.
 func (c *Client) Init(ctx context.Context) error {
-	cmd := exec.CommandContext(ctx, "restic", "init", "-r", c.RepoURL)
-	cmd.Env = append(os.Environ(), "RESTIC_PASSWORD="+c.Password)
+	args := []string{"init", "-r", c.RepoURL}
+	if c.Compression != "" {
+		args = append(args, "--repository-version", "2")
+	}
+	cmd := c.command(ctx, args...)
 
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
@@ -51,48 +100,497 @@ func (c *Client) Init(ctx context.Context) error {
 	return nil
 }
 
-// Backup creates a backup of the specified paths
-func (c *Client) Backup(ctx context.Context, paths []string, tags []string) error {
+// Backup creates a backup of the specified paths, leaving out files matching
+// any of the exclude patterns, and returns restic's summary of the run
+func (c *Client) Backup(ctx context.Context, paths []string, tags []string, excludes ...string) (*BackupSummary, error) {
+	return c.backup(ctx, false, paths, tags, excludes)
+}
+
+func (c *Client) backup(ctx context.Context, force bool, paths, tags, excludes []string) (*BackupSummary, error) {
 	if len(paths) == 0 {
-		return errors.New("no paths specified for backup")
+		return nil, errors.New("no paths specified for backup")
 	}
 
-	args := []string{"backup", "-r", c.RepoURL}
+	// --verbose=2 reports every file, which is how the largest new ones are found
+	args := append([]string{"backup", "-r", c.RepoURL, "--json", "--verbose=2"}, c.compressionArgs()...)
+	if force {
+		args = append(args, "--force")
+	}
 
 	for _, tag := range tags {
 		args = append(args, "--tag", tag)
 	}
+	for _, pattern := range excludes {
+		args = append(args, "--exclude", pattern)
+	}
 
 	args = append(args, paths...)
 
-	cmd := exec.CommandContext(ctx, "restic", args...)
-	cmd.Env = append(os.Environ(), "RESTIC_PASSWORD="+c.Password)
-	cmd.Stdout = os.Stdout
+	cmd := c.command(ctx, args...)
 	cmd.Stderr = os.Stderr
+	interruptOnCancel(cmd)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
 
-	return cmd.Run()
+	summary, parseErr := parseBackupOutput(stdout)
+	return backupResult(summary, parseErr, cmd.Wait())
+}
+
+// ErrIncomplete is returned with the summary of a backup whose snapshot
+// was saved without some files restic couldn't read
+var ErrIncomplete = errors.New("restic couldn't read some files; the snapshot is incomplete")
+
+// incompleteExitCode is restic backup's exit code when it saved a snapshot
+// but couldn't read some source files
+const incompleteExitCode = 3
+
+// backupResult is the outcome of a finished restic backup, given its parsed
+// output and how it exited
+func backupResult(summary *BackupSummary, parseErr, waitErr error) (*BackupSummary, error) {
+	var exitErr *exec.ExitError
+	switch {
+	case waitErr == nil:
+		return summary, parseErr
+	case errors.As(waitErr, &exitErr) && exitErr.ExitCode() == incompleteExitCode && summary != nil:
+		return summary, ErrIncomplete
+	}
+	return nil, waitErr
+}
+
+// interruptGrace is how long an interrupted backup or prune gets to stop
+// before it is killed
+const interruptGrace = 30 * time.Second
+
+// interruptOnCancel makes cmd ask restic to stop (SIGINT) when its context
+// is done, as Ctrl+C would, rather than killing it: restic then removes its
+// lock, and a backup's uploaded data is reused by the next run
+func interruptOnCancel(cmd *exec.Cmd) {
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = interruptGrace
 }
 
-// Restore restores a snapshot to the target directory
+// BackupStdin backs up the contents of r as a single file named filename,
+// for data that isn't on the local filesystem (e.g. a container volume
+// exported as a tar stream)
+func (c *Client) BackupStdin(ctx context.Context, r io.Reader, filename string, tags []string) (*BackupSummary, error) {
+	if filename == "" {
+		return nil, errors.New("no file name specified for stdin backup")
+	}
+
+	args := append([]string{"backup", "-r", c.RepoURL, "--json", "--stdin", "--stdin-filename", filename}, c.compressionArgs()...)
+	for _, tag := range tags {
+		args = append(args, "--tag", tag)
+	}
+
+	cmd := c.command(ctx, args...)
+	cmd.Stdin = r
+	cmd.Stderr = os.Stderr
+	interruptOnCancel(cmd)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	summary, parseErr := parseBackupOutput(stdout)
+	return backupResult(summary, parseErr, cmd.Wait())
+}
+
+// LargestNewFiles is how many of the largest new files a BackupSummary lists
+const LargestNewFiles = 10
+
+// FileSize is a file and its size in bytes
+type FileSize struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// BackupSummary describes a backup run (from restic backup --json)
+type BackupSummary struct {
+	SnapshotID      string `json:"snapshot_id"`
+	FilesNew        int    `json:"files_new"`
+	FilesChanged    int    `json:"files_changed"`
+	FilesUnmodified int    `json:"files_unmodified"`
+	DirsNew         int    `json:"dirs_new"`
+	DirsChanged     int    `json:"dirs_changed"`
+	DataAdded       int64  `json:"data_added"`
+	// DataAddedPacked is what DataAdded took in the repository after
+	// compression (restic 0.17+; 0 from older versions)
+	DataAddedPacked     int64   `json:"data_added_packed"`
+	TotalFilesProcessed int     `json:"total_files_processed"`
+	TotalBytesProcessed int64   `json:"total_bytes_processed"`
+	TotalDuration       float64 `json:"total_duration"`
+
+	// LargestNew are the largest files new in this snapshot, largest first
+	LargestNew []FileSize `json:"largest_new,omitempty"`
+	// Skipped counts files restic couldn't read and left out of the snapshot
+	Skipped int `json:"skipped"`
+}
+
+// parseBackupOutput parses restic backup --json --verbose=2 output: progress
+// "status" lines, a "verbose_status" line per file or directory, "error"
+// lines for unreadable files and a final "summary"
+func parseBackupOutput(r io.Reader) (*BackupSummary, error) {
+	var summary *BackupSummary
+	var largest []FileSize
+	skipped := 0
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+
+		var entry struct {
+			MessageType string `json:"message_type"`
+			Action      string `json:"action"`
+			Item        string `json:"item"`
+			DataSize    int64  `json:"data_size"`
+		}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse restic backup output: %w", err)
+		}
+
+		switch entry.MessageType {
+		case "verbose_status":
+			if entry.Action == "new" && !strings.HasSuffix(entry.Item, "/") {
+				largest = addLargest(largest, FileSize{Path: entry.Item, Size: entry.DataSize})
+			}
+		case "error":
+			skipped++
+		case "summary":
+			summary = &BackupSummary{}
+			if err := json.Unmarshal(line, summary); err != nil {
+				return nil, fmt.Errorf("failed to parse restic backup summary: %w", err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read restic backup output: %w", err)
+	}
+	if summary == nil {
+		return nil, errors.New("restic reported no backup summary")
+	}
+
+	summary.LargestNew = largest
+	summary.Skipped = skipped
+	return summary, nil
+}
+
+// addLargest inserts f into files, kept sorted largest first and capped at
+// LargestNewFiles entries
+func addLargest(files []FileSize, f FileSize) []FileSize {
+	i, _ := slices.BinarySearchFunc(files, f, func(a, b FileSize) int { return cmp.Compare(b.Size, a.Size) })
+	if i >= LargestNewFiles {
+		return files
+	}
+	files = slices.Insert(files, i, f)
+	if len(files) > LargestNewFiles {
+		files = files[:LargestNewFiles]
+	}
+	return files
+}
+
+// Restore restores a snapshot to the target directory. snapshotID may be
+// a selector such as "latest:tag=documents" (see ParseSnapshotSelector).
 func (c *Client) Restore(ctx context.Context, snapshotID, target string) error {
-	if snapshotID == "" {
-		snapshotID = "latest"
+	snapshotID, err := c.ResolveSnapshot(ctx, snapshotID)
+	if err != nil {
+		return err
 	}
 
 	args := []string{"restore", "-r", c.RepoURL, snapshotID, "--target", target}
 
-	cmd := exec.CommandContext(ctx, "restic", args...)
-	cmd.Env = append(os.Environ(), "RESTIC_PASSWORD="+c.Password)
+	cmd := c.command(ctx, args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
 	return cmd.Run()
 }
 
-// Snapshots lists all snapshots
-func (c *Client) Snapshots(ctx context.Context) (string, error) {
-	cmd := exec.CommandContext(ctx, "restic", "snapshots", "-r", c.RepoURL)
-	cmd.Env = append(os.Environ(), "RESTIC_PASSWORD="+c.Password)
+// RestoreOptions controls a restore run
+type RestoreOptions struct {
+	Target string
+	// Includes limits the restore to these snapshot paths (empty = all)
+	Includes []string
+	// Excludes leaves these snapshot paths out
+	Excludes []string
+	// Overwrite is restic's --overwrite mode for files that already exist:
+	// "always", "if-changed", "if-newer" or "never" (empty = restic default)
+	Overwrite string
+	// LimitDownload caps restic's download rate from the repository in
+	// KiB/s (zero = no cap)
+	LimitDownload int
+	// Progress, if set, is called with restic's progress reports as the
+	// restore runs, and with its summary at the end
+	Progress func(RestoreStatus)
+}
+
+// RestoreStatus is restic's report of a restore's progress (from restic
+// restore --json)
+type RestoreStatus struct {
+	PercentDone   float64 `json:"percent_done"`
+	TotalFiles    int     `json:"total_files"`
+	FilesRestored int     `json:"files_restored"`
+	FilesSkipped  int     `json:"files_skipped"`
+	TotalBytes    int64   `json:"total_bytes"`
+	BytesRestored int64   `json:"bytes_restored"`
+	BytesSkipped  int64   `json:"bytes_skipped"`
+}
+
+// RestoreWith restores a snapshot with the given options
+func (c *Client) RestoreWith(ctx context.Context, snapshotID string, opts RestoreOptions) error {
+	snapshotID, err := c.ResolveSnapshot(ctx, snapshotID)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"restore", "-r", c.RepoURL, snapshotID, "--target", opts.Target}
+	for _, p := range opts.Includes {
+		args = append(args, "--include", p)
+	}
+	for _, p := range opts.Excludes {
+		args = append(args, "--exclude", p)
+	}
+	if opts.Overwrite != "" {
+		args = append(args, "--overwrite", opts.Overwrite)
+	}
+	if opts.LimitDownload > 0 {
+		args = append(args, "--limit-download", strconv.Itoa(opts.LimitDownload))
+	}
+	if opts.Progress != nil {
+		args = append(args, "--json")
+	}
+
+	cmd := c.command(ctx, args...)
+	interruptOnCancel(cmd)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if opts.Progress == nil {
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("restic restore failed: %s", strings.TrimSpace(stderr.String()))
+		}
+		return nil
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start restic restore: %w", err)
+	}
+	parseRestoreOutput(stdout, opts.Progress)
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("restic restore failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// parseRestoreOutput passes the status and summary lines of restic restore
+// --json output to progress
+func parseRestoreOutput(r io.Reader, progress func(RestoreStatus)) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var msg struct {
+			MessageType string `json:"message_type"`
+			RestoreStatus
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+		switch msg.MessageType {
+		case "status":
+			progress(msg.RestoreStatus)
+		case "summary":
+			msg.PercentDone = 1
+			progress(msg.RestoreStatus)
+		}
+	}
+	// Drain what's left so restic doesn't block writing to a full pipe
+	_, _ = io.Copy(io.Discard, r)
+}
+
+// mountUnmountGrace is how long restic gets to unmount after being asked to stop
+const mountUnmountGrace = 15 * time.Second
+
+// Mount serves snap read-only over FUSE at mountpoint until ctx is done,
+// then asks restic to unmount (SIGINT) and waits for it to exit. restic
+// can't mount a single snapshot, so the mount is filtered to the snapshots
+// sharing snap's host, paths and tags. snap appears under mountpoint/ids/<id>.
+func (c *Client) Mount(ctx context.Context, mountpoint string, snap Snapshot) error {
+	cmd := c.command(ctx, mountArgs(c.RepoURL, mountpoint, snap)...)
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = mountUnmountGrace
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		// WaitDelay expired: restic was killed, possibly leaving the mount behind
+		var exitErr *exec.ExitError
+		killed := errors.As(err, &exitErr) && exitErr.String() == "signal: killed"
+		if killed || errors.Is(err, exec.ErrWaitDelay) {
+			return fmt.Errorf("restic did not unmount %s in time (run 'fusermount -u %s')", mountpoint, mountpoint)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("restic mount failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// mountArgs returns the arguments of a restic mount filtered to snap's host,
+// paths and tags. An empty --tag matches only untagged snapshots.
+func mountArgs(repoURL, mountpoint string, snap Snapshot) []string {
+	args := []string{"mount", "-r", repoURL}
+	if snap.Hostname != "" {
+		args = append(args, "--host", snap.Hostname)
+	}
+	for _, p := range snap.Paths {
+		args = append(args, "--path", p)
+	}
+	args = append(args, "--tag", strings.Join(snap.Tags, ","))
+	return append(args, mountpoint)
+}
+
+// RestoreFiles restores only the given paths from a snapshot into target.
+// Paths are absolute paths as recorded in the snapshot.
+func (c *Client) RestoreFiles(ctx context.Context, snapshotID, target string, paths []string) error {
+	if len(paths) == 0 {
+		return errors.New("no paths specified for restore")
+	}
+	snapshotID, err := c.ResolveSnapshot(ctx, snapshotID)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"restore", "-r", c.RepoURL, snapshotID, "--target", target}
+	for _, p := range paths {
+		args = append(args, "--include", p)
+	}
+
+	cmd := c.command(ctx, args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("restic restore failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// Dump writes a snapshot's files to w as a tar archive. snapshotID may be
+// a selector, as for Restore.
+func (c *Client) Dump(ctx context.Context, snapshotID string, w io.Writer) error {
+	snapshotID, err := c.ResolveSnapshot(ctx, snapshotID)
+	if err != nil {
+		return err
+	}
+
+	cmd := c.command(ctx, "dump", "-r", c.RepoURL, "--archive", "tar", snapshotID, "/")
+	cmd.Stdout = w
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("restic dump failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// Node is a file or directory entry in a snapshot (from restic ls --json)
+type Node struct {
+	Name    string    `json:"name"`
+	Type    string    `json:"type"`
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+}
+
+// ListFiles lists the entries of a snapshot
+func (c *Client) ListFiles(ctx context.Context, snapshotID string) ([]Node, error) {
+	snapshotID, err := c.ResolveSnapshot(ctx, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := c.command(ctx, "ls", "-r", c.RepoURL, "--json", snapshotID)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("restic ls failed: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	return parseLsOutput(output)
+}
+
+// parseLsOutput parses restic ls --json output: one JSON object per line,
+// the first describing the snapshot and the rest describing nodes
+func parseLsOutput(output []byte) ([]Node, error) {
+	var nodes []Node
+	for _, line := range bytes.Split(output, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		var entry struct {
+			Node
+			StructType string `json:"struct_type"`
+		}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse restic ls output: %w", err)
+		}
+		if entry.StructType != "node" {
+			continue
+		}
+		nodes = append(nodes, entry.Node)
+	}
+	return nodes, nil
+}
+
+// RestoreSize returns how many bytes restoring a snapshot writes (restic
+// stats --mode restore-size)
+func (c *Client) RestoreSize(ctx context.Context, snapshotID string) (int64, error) {
+	output, err := c.output(ctx, "stats", "--json", "--mode", "restore-size", snapshotID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get restore size: %w", err)
+	}
+	var stats struct {
+		TotalSize int64 `json:"total_size"`
+	}
+	if err := json.Unmarshal(output, &stats); err != nil {
+		return 0, fmt.Errorf("failed to parse restic stats output: %w", err)
+	}
+	return stats.TotalSize, nil
+}
+
+// Snapshots lists all snapshots, or only those carrying every one of tags
+func (c *Client) Snapshots(ctx context.Context, tags ...string) (string, error) {
+	args := []string{"snapshots", "-r", c.RepoURL}
+	if len(tags) > 0 {
+		args = append(args, "--tag", strings.Join(tags, ","))
+	}
+	cmd := c.command(ctx, args...)
 
 	output, err := cmd.Output()
 	if err != nil {
@@ -102,16 +600,329 @@ func (c *Client) Snapshots(ctx context.Context) (string, error) {
 	return string(output), nil
 }
 
+// Snapshot is a snapshot entry (from restic snapshots --json)
+type Snapshot struct {
+	ID       string    `json:"id"`
+	ShortID  string    `json:"short_id"`
+	Time     time.Time `json:"time"`
+	Hostname string    `json:"hostname"`
+	Paths    []string  `json:"paths"`
+	Tags     []string  `json:"tags,omitempty"`
+	// Original is the ID of the snapshot this one was copied from
+	Original string `json:"original,omitempty"`
+}
+
+// SnapshotList lists all snapshots with their metadata
+func (c *Client) SnapshotList(ctx context.Context) ([]Snapshot, error) {
+	cmd := c.command(ctx, "snapshots", "-r", c.RepoURL, "--json")
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("restic snapshots failed: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	var snapshots []Snapshot
+	if err := json.Unmarshal(output, &snapshots); err != nil {
+		return nil, fmt.Errorf("failed to parse restic snapshots output: %w", err)
+	}
+	return snapshots, nil
+}
+
+// ResolveSnapshot returns the ID of the snapshot snapshotID selects. A plain
+// ID or "latest" is returned as is for restic to look up; other selectors
+// are resolved against the repository's snapshots.
+func (c *Client) ResolveSnapshot(ctx context.Context, snapshotID string) (string, error) {
+	sel, err := ParseSnapshotSelector(snapshotID)
+	if err != nil {
+		return "", err
+	}
+	if !sel.Filtered() {
+		return sel.ID, nil
+	}
+	snapshots, err := c.SnapshotList(ctx)
+	if err != nil {
+		return "", err
+	}
+	snap, err := sel.Resolve(snapshots)
+	if err != nil {
+		return "", err
+	}
+	return snap.ID, nil
+}
+
+// DiffStats counts what one side of a diff contributes (from restic diff --json)
+type DiffStats struct {
+	Files     int   `json:"files"`
+	Dirs      int   `json:"dirs"`
+	Others    int   `json:"others"`
+	DataBlobs int   `json:"data_blobs"`
+	TreeBlobs int   `json:"tree_blobs"`
+	Bytes     int64 `json:"bytes"`
+}
+
+// Diff is what changed between two snapshots. Directory paths end in "/".
+type Diff struct {
+	SourceSnapshot string    `json:"source_snapshot"`
+	TargetSnapshot string    `json:"target_snapshot"`
+	Added          []string  `json:"added"`
+	Removed        []string  `json:"removed"`
+	Modified       []string  `json:"modified"`
+	ChangedFiles   int       `json:"changed_files"`
+	AddedStats     DiffStats `json:"added_stats"`
+	RemovedStats   DiffStats `json:"removed_stats"`
+}
+
+// SizeDelta is the net change in stored bytes from source to target
+func (d *Diff) SizeDelta() int64 {
+	return d.AddedStats.Bytes - d.RemovedStats.Bytes
+}
+
+// Diff compares two snapshots
+func (c *Client) Diff(ctx context.Context, fromID, toID string) (*Diff, error) {
+	cmd := c.command(ctx, "diff", "-r", c.RepoURL, "--json", fromID, toID)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("restic diff failed: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	return parseDiffOutput(output)
+}
+
+// parseDiffOutput parses restic diff --json output: one "change" object per
+// changed path followed by a "statistics" object. A change's modifier is "+"
+// (added), "-" (removed), or a combination of "M" (content), "T" (type) and
+// "U" (metadata) for modified paths.
+func parseDiffOutput(output []byte) (*Diff, error) {
+	diff := &Diff{Added: []string{}, Removed: []string{}, Modified: []string{}}
+	for _, line := range bytes.Split(output, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		var entry struct {
+			MessageType    string    `json:"message_type"`
+			Path           string    `json:"path"`
+			Modifier       string    `json:"modifier"`
+			SourceSnapshot string    `json:"source_snapshot"`
+			TargetSnapshot string    `json:"target_snapshot"`
+			ChangedFiles   int       `json:"changed_files"`
+			Added          DiffStats `json:"added"`
+			Removed        DiffStats `json:"removed"`
+		}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse restic diff output: %w", err)
+		}
+
+		switch entry.MessageType {
+		case "change":
+			switch entry.Modifier {
+			case "+":
+				diff.Added = append(diff.Added, entry.Path)
+			case "-":
+				diff.Removed = append(diff.Removed, entry.Path)
+			default:
+				diff.Modified = append(diff.Modified, entry.Path)
+			}
+		case "statistics":
+			diff.SourceSnapshot = entry.SourceSnapshot
+			diff.TargetSnapshot = entry.TargetSnapshot
+			diff.ChangedFiles = entry.ChangedFiles
+			diff.AddedStats = entry.Added
+			diff.RemovedStats = entry.Removed
+		}
+	}
+	return diff, nil
+}
+
+// InitCopyOf initializes the repository with the chunker parameters of from,
+// so snapshots copied from it deduplicate. An existing repository is left alone.
+func (c *Client) InitCopyOf(ctx context.Context, from *Client) error {
+	cmd := c.command(ctx, "init", "-r", c.RepoURL,
+		"--from-repo", from.RepoURL, "--copy-chunker-params")
+	cmd.Env = c.env("RESTIC_FROM_PASSWORD=" + from.Password)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if strings.Contains(stderr.String(), "already initialized") {
+			return nil
+		}
+		return fmt.Errorf("restic init failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// CopyFrom copies snapshots from another repository into this one. Snapshots
+// that were already copied are skipped by restic.
+func (c *Client) CopyFrom(ctx context.Context, from *Client, snapshotIDs ...string) error {
+	args := []string{"copy", "-r", c.RepoURL, "--from-repo", from.RepoURL}
+	args = append(args, snapshotIDs...)
+
+	cmd := c.command(ctx, args...)
+	cmd.Env = c.env("RESTIC_FROM_PASSWORD=" + from.Password)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("restic copy failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// ForgetOptions selects the snapshots forget removes: the listed IDs, or
+// those not kept by the keep policy. At least one must be set.
+type ForgetOptions struct {
+	SnapshotIDs []string
+	KeepLast    int
+	KeepHourly  int
+	KeepDaily   int
+	KeepWeekly  int
+	KeepMonthly int
+	KeepYearly  int
+	// KeepTags keeps every snapshot carrying any of these tags
+	KeepTags []string
+	// Tags limits the policy to snapshots carrying every one of these
+	// tags, e.g. to keep more "documents" snapshots than others
+	Tags []string
+	// Prune also removes data no longer referenced by any snapshot
+	Prune bool
+}
+
+func (o ForgetOptions) args() ([]string, error) {
+	var args []string
+	for _, keep := range []struct {
+		flag string
+		n    int
+	}{
+		{"--keep-last", o.KeepLast},
+		{"--keep-hourly", o.KeepHourly},
+		{"--keep-daily", o.KeepDaily},
+		{"--keep-weekly", o.KeepWeekly},
+		{"--keep-monthly", o.KeepMonthly},
+		{"--keep-yearly", o.KeepYearly},
+	} {
+		if keep.n > 0 {
+			args = append(args, keep.flag, strconv.Itoa(keep.n))
+		}
+	}
+	for _, tag := range o.KeepTags {
+		args = append(args, "--keep-tag", tag)
+	}
+	if len(args) == 0 && len(o.SnapshotIDs) == 0 {
+		return nil, errors.New("forget needs snapshot IDs or a keep policy")
+	}
+	if len(o.Tags) > 0 {
+		args = append(args, "--tag", strings.Join(o.Tags, ","))
+	}
+	if o.Prune {
+		args = append(args, "--prune")
+	}
+	return append(args, o.SnapshotIDs...), nil
+}
+
+// Forget removes snapshots from the repository, and with Prune the data
+// only they referenced. Append-only hosts reject this.
+func (c *Client) Forget(ctx context.Context, opts ForgetOptions) error {
+	optArgs, err := opts.args()
+	if err != nil {
+		return err
+	}
+	args := append([]string{"forget", "-r", c.RepoURL}, optArgs...)
+
+	cmd := c.command(ctx, args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("restic forget failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// ForgetPreview is what a forget would keep and remove, oldest first
+type ForgetPreview struct {
+	Keep   []Snapshot `json:"keep"`
+	Remove []Snapshot `json:"remove"`
+}
+
+// ForgetDryRun returns what Forget would keep and remove with opts, without
+// removing anything
+func (c *Client) ForgetDryRun(ctx context.Context, opts ForgetOptions) (*ForgetPreview, error) {
+	opts.Prune = false
+	optArgs, err := opts.args()
+	if err != nil {
+		return nil, err
+	}
+	args := append([]string{"forget", "-r", c.RepoURL, "--dry-run", "--json"}, optArgs...)
+
+	cmd := c.command(ctx, args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("restic forget --dry-run failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return parseForgetPreview(output)
+}
+
+// parseForgetPreview parses restic forget --json output, a list of the
+// groups (by host and paths) the policy was applied to
+func parseForgetPreview(output []byte) (*ForgetPreview, error) {
+	var groups []ForgetPreview
+	if err := json.Unmarshal(output, &groups); err != nil {
+		return nil, fmt.Errorf("failed to parse restic forget output: %w", err)
+	}
+	preview := &ForgetPreview{Keep: []Snapshot{}, Remove: []Snapshot{}}
+	for _, g := range groups {
+		preview.Keep = append(preview.Keep, g.Keep...)
+		preview.Remove = append(preview.Remove, g.Remove...)
+	}
+	byTime := func(a, b Snapshot) int { return a.Time.Compare(b.Time) }
+	slices.SortFunc(preview.Keep, byTime)
+	slices.SortFunc(preview.Remove, byTime)
+	return preview, nil
+}
+
 // Check verifies repository integrity
 func (c *Client) Check(ctx context.Context) error {
-	cmd := exec.CommandContext(ctx, "restic", "check", "-r", c.RepoURL)
-	cmd.Env = append(os.Environ(), "RESTIC_PASSWORD="+c.Password)
+	cmd := c.command(ctx, "check", "-r", c.RepoURL)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
 	return cmd.Run()
 }
 
+// CheckOutput runs restic check and returns its combined output. If
+// readDataSubset is set (e.g. "10%" or "1/5"), that share of pack data is
+// also read and decrypted.
+func (c *Client) CheckOutput(ctx context.Context, readDataSubset string) (string, error) {
+	args := []string{"check", "-r", c.RepoURL}
+	if readDataSubset != "" {
+		args = append(args, "--read-data-subset", readDataSubset)
+	}
+
+	cmd := c.command(ctx, args...)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(output), fmt.Errorf("restic check failed: %w", err)
+	}
+	return string(output), nil
+}
+
 // IsInstalled checks if restic is available
 func IsInstalled() bool {
 	_, err := exec.LookPath("restic")
@@ -0,0 +1,37 @@
+package restic
+
+import (
+	"context"
+	"io"
+)
+
+// Runner is a restic repository as the rest of Airgapper uses it. Client
+// runs the restic binary; tests and alternative engines can substitute
+// their own implementation.
+type Runner interface {
+	Init(ctx context.Context) error
+	Backup(ctx context.Context, paths []string, tags []string, excludes ...string) (*BackupSummary, error)
+	BackupStdin(ctx context.Context, r io.Reader, filename string, tags []string) (*BackupSummary, error)
+	Restore(ctx context.Context, snapshotID, target string) error
+	SnapshotList(ctx context.Context) ([]Snapshot, error)
+	Diff(ctx context.Context, fromID, toID string) (*Diff, error)
+	Forget(ctx context.Context, opts ForgetOptions) error
+	ForgetDryRun(ctx context.Context, opts ForgetOptions) (*ForgetPreview, error)
+	Prune(ctx context.Context, progress func(line string)) error
+	Check(ctx context.Context) error
+	Locks(ctx context.Context) ([]Lock, error)
+	Unlock(ctx context.Context, removeAll bool) error
+	RepairIndex(ctx context.Context) error
+	RepairPacks(ctx context.Context, ids []string) error
+	Rebackup(ctx context.Context, paths []string, tags []string, excludes ...string) (*BackupSummary, error)
+}
+
+// RunnerFactory opens a Runner for a repository
+type RunnerFactory func(repoURL, password string) Runner
+
+// NewRunner returns a Runner that runs the restic binary
+func NewRunner(repoURL, password string) Runner {
+	return NewClient(repoURL, password)
+}
+
+var _ Runner = (*Client)(nil)
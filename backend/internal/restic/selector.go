@@ -0,0 +1,224 @@
+package restic
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SnapshotSelector picks the snapshot a restore request is for: a snapshot
+// ID, or the latest snapshot passing every filter. Selectors are written as
+// whitespace separated terms:
+//
+//	latest              the latest snapshot (the default)
+//	before:2024-06-01   the latest snapshot taken before a date (UTC) or RFC 3339 time
+//	tag=a,b             only snapshots tagged both a and b
+//	host=laptop         only snapshots taken on host laptop
+//
+// "latest:tag=a,b" is short for "tag=a,b latest". Filters can't narrow a
+// snapshot ID.
+type SnapshotSelector struct {
+	// ID is a snapshot ID, or "latest" for the latest matching snapshot
+	ID     string
+	Tags   []string
+	Host   string
+	Before time.Time
+}
+
+// tagSelector separates "latest" from the tags narrowing it in the short
+// form, e.g. "latest:tag=documents,photos"
+const tagSelector = ":tag="
+
+// beforePrefix starts the term selecting the latest snapshot before a time
+const beforePrefix = "before:"
+
+// ParseSnapshotSelector parses a snapshot selector. Empty means "latest".
+func ParseSnapshotSelector(s string) (SnapshotSelector, error) {
+	var sel SnapshotSelector
+	fail := func(format string, args ...any) (SnapshotSelector, error) {
+		return SnapshotSelector{}, fmt.Errorf("snapshot %q: %s", s, fmt.Sprintf(format, args...))
+	}
+	setBase := func(id string) bool {
+		if sel.ID != "" {
+			return false
+		}
+		sel.ID = id
+		return true
+	}
+
+	for _, term := range strings.Fields(s) {
+		if base, tags, ok := strings.Cut(term, tagSelector); ok {
+			if base != "" && base != "latest" {
+				return fail("only \"latest\" can be narrowed by tag")
+			}
+			if !setBase("latest") {
+				return fail("more than one snapshot given")
+			}
+			if err := sel.addTags(tags); err != nil {
+				return fail("%v", err)
+			}
+			continue
+		}
+
+		key, value, isFilter := strings.Cut(term, "=")
+		switch {
+		case isFilter && key == "tag":
+			if err := sel.addTags(value); err != nil {
+				return fail("%v", err)
+			}
+		case isFilter && key == "host":
+			if value == "" || sel.Host != "" {
+				return fail("host= needs exactly one host name")
+			}
+			sel.Host = value
+		case isFilter:
+			return fail("unknown filter %q (supported: tag=, host=)", key)
+		case strings.HasPrefix(term, beforePrefix):
+			before, err := parseBefore(strings.TrimPrefix(term, beforePrefix))
+			if err != nil {
+				return fail("%v", err)
+			}
+			if !setBase("latest") {
+				return fail("more than one snapshot given")
+			}
+			sel.Before = before
+		case strings.Contains(term, ":"):
+			return fail("unknown term %q", term)
+		default:
+			if !setBase(term) {
+				return fail("more than one snapshot given")
+			}
+		}
+	}
+
+	if sel.ID == "" {
+		sel.ID = "latest"
+	}
+	if sel.ID != "latest" && sel.Filtered() {
+		return fail("filters can't narrow a snapshot ID")
+	}
+	return sel, nil
+}
+
+// addTags adds the comma separated tags in list to the selector
+func (s *SnapshotSelector) addTags(list string) error {
+	for _, tag := range strings.Split(list, ",") {
+		if err := ValidateTag(tag); err != nil {
+			return err
+		}
+		s.Tags = append(s.Tags, tag)
+	}
+	return nil
+}
+
+// parseBefore parses a before: date as midnight UTC, or an RFC 3339 time
+func parseBefore(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q (want YYYY-MM-DD or RFC 3339)", value)
+}
+
+// formatBefore formats t as parseBefore reads it, as a date where it can
+func formatBefore(t time.Time) string {
+	if t.Equal(t.UTC().Truncate(24 * time.Hour)) {
+		return t.UTC().Format(time.DateOnly)
+	}
+	return t.Format(time.RFC3339)
+}
+
+// LatestWithTags returns the selector for the latest snapshot carrying
+// every one of tags, or plain "latest" without tags
+func LatestWithTags(tags []string) string {
+	return SnapshotSelector{ID: "latest", Tags: tags}.String()
+}
+
+// Filtered reports whether the selector needs the repository's snapshots
+// to resolve, rather than being an ID or "latest" restic looks up itself
+func (s SnapshotSelector) Filtered() bool {
+	return len(s.Tags) > 0 || s.Host != "" || !s.Before.IsZero()
+}
+
+// String formats the selector as ParseSnapshotSelector reads it, in the
+// short form where it can
+func (s SnapshotSelector) String() string {
+	if !s.Filtered() {
+		return s.ID
+	}
+	if s.Host == "" && s.Before.IsZero() {
+		return s.ID + tagSelector + strings.Join(s.Tags, ",")
+	}
+	var terms []string
+	if s.Host != "" {
+		terms = append(terms, "host="+s.Host)
+	}
+	if len(s.Tags) > 0 {
+		terms = append(terms, "tag="+strings.Join(s.Tags, ","))
+	}
+	if s.Before.IsZero() {
+		terms = append(terms, "latest")
+	} else {
+		terms = append(terms, beforePrefix+formatBefore(s.Before))
+	}
+	return strings.Join(terms, " ")
+}
+
+// Describe spells the selector out for someone approving a request for it,
+// e.g. "the latest snapshot tagged photos from host laptop"
+func (s SnapshotSelector) Describe() string {
+	if s.ID != "latest" {
+		return "snapshot " + s.ID
+	}
+	desc := "the latest snapshot"
+	if len(s.Tags) > 0 {
+		desc += " tagged " + strings.Join(s.Tags, " and ")
+	}
+	if s.Host != "" {
+		desc += " from host " + s.Host
+	}
+	if !s.Before.IsZero() {
+		desc += " taken before " + formatBefore(s.Before)
+	}
+	return desc
+}
+
+// Matches reports whether snap is one the selector can pick. Which of the
+// matching snapshots is the latest is up to the caller.
+func (s SnapshotSelector) Matches(snap Snapshot) bool {
+	if s.ID != "latest" {
+		return strings.HasPrefix(snap.ID, s.ID)
+	}
+	if s.Host != "" && snap.Hostname != s.Host {
+		return false
+	}
+	if !s.Before.IsZero() && !snap.Time.Before(s.Before) {
+		return false
+	}
+	return snap.HasTags(s.Tags)
+}
+
+// Resolve returns the snapshot the selector picks from snapshots: the one
+// with the ID, or the latest matching one
+func (s SnapshotSelector) Resolve(snapshots []Snapshot) (Snapshot, error) {
+	var picked *Snapshot
+	for i := range snapshots {
+		snap := &snapshots[i]
+		if !s.Matches(*snap) {
+			continue
+		}
+		if s.ID != "latest" {
+			return *snap, nil
+		}
+		// Of snapshots taken at the same time, the one listed last wins
+		if picked == nil || !snap.Time.Before(picked.Time) {
+			picked = snap
+		}
+	}
+	if picked == nil {
+		return Snapshot{}, fmt.Errorf("no snapshot matches %q", s.String())
+	}
+	return *picked, nil
+}
@@ -0,0 +1,87 @@
+package restic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSnapshotSelector(t *testing.T) {
+	june := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		in   string
+		want SnapshotSelector
+	}{
+		{"", SnapshotSelector{ID: "latest"}},
+		{"latest", SnapshotSelector{ID: "latest"}},
+		{"abc123", SnapshotSelector{ID: "abc123"}},
+		{"latest:tag=documents", SnapshotSelector{ID: "latest", Tags: []string{"documents"}}},
+		{":tag=documents,photos", SnapshotSelector{ID: "latest", Tags: []string{"documents", "photos"}}},
+		{"before:2024-06-01", SnapshotSelector{ID: "latest", Before: june}},
+		{"host=laptop latest", SnapshotSelector{ID: "latest", Host: "laptop"}},
+		{"tag=photos  host=laptop", SnapshotSelector{ID: "latest", Tags: []string{"photos"}, Host: "laptop"}},
+		{"host=laptop tag=photos before:2024-06-01T00:00:00Z", SnapshotSelector{ID: "latest", Tags: []string{"photos"}, Host: "laptop", Before: june}},
+	} {
+		got, err := ParseSnapshotSelector(tc.in)
+		require.NoError(t, err, tc.in)
+		assert.Equal(t, tc.want, got, tc.in)
+	}
+
+	for _, bad := range []string{
+		"abc123:tag=documents", "latest:tag=", "latest:tag=a,,b",
+		"abc123 tag=photos", "latest before:2024-06-01", "abc123 def456",
+		"before:June", "host=", "host=a host=b", "path=/home", "after:2024-06-01",
+	} {
+		_, err := ParseSnapshotSelector(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestSnapshotSelectorString(t *testing.T) {
+	assert.Equal(t, "latest:tag=documents,photos", LatestWithTags([]string{"documents", "photos"}))
+	assert.Equal(t, "latest", LatestWithTags(nil))
+
+	for _, in := range []string{
+		"latest", "abc123", "latest:tag=photos", "before:2024-06-01",
+		"host=laptop latest", "host=laptop tag=photos before:2024-06-01T12:30:00Z",
+	} {
+		sel, err := ParseSnapshotSelector(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, in, sel.String())
+	}
+
+	sel, err := ParseSnapshotSelector("before:2024-06-01 host=laptop tag=photos")
+	require.NoError(t, err)
+	assert.Equal(t, "the latest snapshot tagged photos from host laptop taken before 2024-06-01", sel.Describe())
+	assert.Equal(t, "snapshot abc123", SnapshotSelector{ID: "abc123"}.Describe())
+}
+
+func TestSnapshotSelectorResolve(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 12, 0, 0, 0, time.UTC) }
+	snapshots := []Snapshot{
+		{ID: "aaaa", Time: day(30), Hostname: "laptop", Tags: []string{"airgapper", "photos"}},
+		{ID: "bbbb", Time: day(31), Hostname: "desktop", Tags: []string{"airgapper", "photos"}},
+		{ID: "cccc", Time: day(29), Hostname: "laptop", Tags: []string{"airgapper"}},
+	}
+
+	resolve := func(in string) string {
+		t.Helper()
+		sel, err := ParseSnapshotSelector(in)
+		require.NoError(t, err, in)
+		snap, err := sel.Resolve(snapshots)
+		require.NoError(t, err, in)
+		return snap.ID
+	}
+	assert.Equal(t, "bbbb", resolve("latest"))
+	assert.Equal(t, "aaaa", resolve("host=laptop latest"))
+	assert.Equal(t, "cccc", resolve("before:2024-05-30"))
+	assert.Equal(t, "aaaa", resolve("tag=photos before:2024-05-31"))
+	assert.Equal(t, "cccc", resolve("cc"))
+
+	sel, err := ParseSnapshotSelector("tag=photos before:2024-05-30")
+	require.NoError(t, err)
+	_, err = sel.Resolve(snapshots)
+	assert.ErrorContains(t, err, "no snapshot matches")
+}
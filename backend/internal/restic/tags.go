@@ -0,0 +1,80 @@
+package restic
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// Tags Airgapper puts on the snapshots it creates
+const (
+	TagAirgapper = "airgapper"
+	TagScheduled = "scheduled"
+
+	// TagState marks snapshots holding an export of the owner's state
+	TagState = "airgapper-state"
+
+	// HostTagPrefix and OSTagPrefix prefix the tags naming the machine a
+	// snapshot was taken on and its operating system
+	HostTagPrefix = "host:"
+	OSTagPrefix   = "os:"
+)
+
+// ValidateTag checks tag can be passed to restic: restic splits tags on
+// commas, and a tag with spaces is hard to select again from a shell
+func ValidateTag(tag string) error {
+	if tag == "" {
+		return fmt.Errorf("tag is empty")
+	}
+	if strings.ContainsAny(tag, ", \t\n") {
+		return fmt.Errorf("tag %q must not contain commas or whitespace", tag)
+	}
+	return nil
+}
+
+// HostTags returns the tags naming this machine and its operating system
+func HostTags() []string {
+	tags := []string{OSTagPrefix + runtime.GOOS}
+	if hostname, err := os.Hostname(); err == nil && ValidateTag(hostname) == nil {
+		tags = append([]string{HostTagPrefix + hostname}, tags...)
+	}
+	return tags
+}
+
+// BackupTags returns the tags for a new snapshot: "airgapper", the host
+// tags, then extra in order without duplicates
+func BackupTags(extra ...string) []string {
+	tags := append([]string{TagAirgapper}, HostTags()...)
+	for _, tag := range extra {
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// HasTags reports whether the snapshot carries every one of tags
+func (s Snapshot) HasTags(tags []string) bool {
+	for _, tag := range tags {
+		if !slices.Contains(s.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterByTags returns the snapshots carrying every one of tags
+func FilterByTags(snapshots []Snapshot, tags []string) []Snapshot {
+	if len(tags) == 0 {
+		return snapshots
+	}
+	var filtered []Snapshot
+	for _, s := range snapshots {
+		if s.HasTags(tags) {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered
+}
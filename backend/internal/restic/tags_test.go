@@ -0,0 +1,37 @@
+package restic
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupTags(t *testing.T) {
+	tags := BackupTags("scheduled", "documents", "airgapper", "", "documents")
+	assert.Equal(t, TagAirgapper, tags[0])
+	assert.Contains(t, tags, OSTagPrefix+runtime.GOOS)
+	assert.Equal(t, []string{"scheduled", "documents"}, tags[len(tags)-2:], "extra tags follow, without duplicates")
+}
+
+func TestValidateTag(t *testing.T) {
+	assert.NoError(t, ValidateTag("documents"))
+	assert.NoError(t, ValidateTag("host:laptop"))
+	for _, bad := range []string{"", "a,b", "my docs"} {
+		assert.Error(t, ValidateTag(bad), bad)
+	}
+}
+
+func TestFilterByTags(t *testing.T) {
+	snapshots := []Snapshot{
+		{ID: "aaaa", Tags: []string{"airgapper", "documents"}},
+		{ID: "bbbb", Tags: []string{"airgapper"}},
+		{ID: "cccc", Tags: []string{"airgapper", "documents", "scheduled"}},
+	}
+	assert.Len(t, FilterByTags(snapshots, nil), 3)
+	got := FilterByTags(snapshots, []string{"documents"})
+	require.Len(t, got, 2)
+	assert.Equal(t, "cccc", got[1].ID)
+	assert.Len(t, FilterByTags(snapshots, []string{"documents", "scheduled"}), 1)
+}
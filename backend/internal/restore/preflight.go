@@ -0,0 +1,188 @@
+package restore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/backupreport"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+// CheckStatus is the outcome of a preflight check
+type CheckStatus string
+
+const (
+	CheckOK   CheckStatus = "ok"
+	CheckWarn CheckStatus = "warn"
+	CheckFail CheckStatus = "fail" // The restore doesn't run
+)
+
+// Preflight check names
+const (
+	CheckTarget     = "target"
+	CheckFreeSpace  = "free_space"
+	CheckBackupLoop = "backup_loop"
+	CheckExisting   = "existing_files"
+)
+
+// spaceMargin is the share of free space a restore should leave, below
+// which the free space check warns
+const spaceMargin = 0.05
+
+// PreflightCheck is the outcome of one check of a restore's target
+type PreflightCheck struct {
+	Name    string      `json:"name"`
+	Status  CheckStatus `json:"status"`
+	Message string      `json:"message"`
+}
+
+// Preflight is what checking a restore's target found, before anything is
+// written
+type Preflight struct {
+	Target     string `json:"target"`
+	SnapshotID string `json:"snapshot_id,omitempty"`
+	Strategy   string `json:"conflict"`
+	Files      int    `json:"files"`
+	Conflicts  int    `json:"conflicts"`
+	// RestoreBytes is the snapshot's restore size (restic stats), and
+	// NeededBytes the new space the restore takes after ReusedBytes
+	RestoreBytes int64            `json:"restore_bytes"`
+	NeededBytes  int64            `json:"needed_bytes"`
+	FreeBytes    int64            `json:"free_bytes"`
+	Checks       []PreflightCheck `json:"checks"`
+}
+
+// freeSpace returns the space available to an unprivileged user on the
+// filesystem holding path; tests replace it
+var freeSpace = func(path string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), nil
+}
+
+// NewPreflight checks the plan's target before it is restored: that it is
+// a directory, or can be made one; that its filesystem has room for
+// restoreBytes less what the plan reuses; that it isn't inside a path
+// backupPaths back up, where each backup would store the restored copy
+// again; and how many existing files the plan's strategy applies to.
+func NewPreflight(plan *Plan, restoreBytes int64, backupPaths []string) *Preflight {
+	p := &Preflight{
+		Target:       plan.Root,
+		Strategy:     string(plan.Strategy),
+		Files:        len(plan.Files),
+		Conflicts:    plan.Conflicts,
+		RestoreBytes: restoreBytes,
+		NeededBytes:  max(restoreBytes-plan.ReusedBytes, 0),
+	}
+
+	// The nearest existing directory is where the restore starts writing
+	dir := plan.Root
+	for {
+		info, err := os.Stat(dir)
+		if err == nil && !info.IsDir() {
+			p.add(CheckTarget, CheckFail, dir+" is not a directory")
+			return p
+		}
+		if err == nil || filepath.Dir(dir) == dir {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+	if dir == plan.Root {
+		p.add(CheckTarget, CheckOK, plan.Root+" exists")
+	} else {
+		p.add(CheckTarget, CheckOK, plan.Root+" will be created under "+dir)
+	}
+
+	p.checkSpace(dir)
+	p.checkLoop(backupPaths)
+	p.checkExisting(plan)
+	return p
+}
+
+func (p *Preflight) checkSpace(dir string) {
+	free, err := freeSpace(dir)
+	if err != nil {
+		p.add(CheckFreeSpace, CheckWarn, "could not check free space: "+err.Error())
+		return
+	}
+	p.FreeBytes = free
+	msg := fmt.Sprintf("needs %s, %s free", backupreport.FormatBytes(p.NeededBytes), backupreport.FormatBytes(free))
+	switch {
+	case p.NeededBytes > free:
+		p.add(CheckFreeSpace, CheckFail, msg)
+	case float64(free-p.NeededBytes) < spaceMargin*float64(free):
+		p.add(CheckFreeSpace, CheckWarn, msg+"; the disk will be nearly full")
+	default:
+		p.add(CheckFreeSpace, CheckOK, msg)
+	}
+}
+
+// checkLoop fails a target inside a backed-up path: the next backup
+// would store the restored copy too, and restoring that would nest it
+// deeper. Restoring in place puts files back where they came from.
+func (p *Preflight) checkLoop(backupPaths []string) {
+	if p.Target == "/" {
+		p.add(CheckBackupLoop, CheckOK, "restoring in place")
+		return
+	}
+	for _, path := range backupPaths {
+		path = filepath.Clean(path)
+		if within(p.Target, path) {
+			p.add(CheckBackupLoop, CheckFail, fmt.Sprintf("%s is inside backed-up path %s, so the next backup would store the restored copy again; restore outside it or in place", p.Target, path))
+			return
+		}
+	}
+	p.add(CheckBackupLoop, CheckOK, "target is outside the backed-up paths")
+}
+
+func (p *Preflight) checkExisting(plan *Plan) {
+	switch {
+	case plan.Conflicts == 0:
+		p.add(CheckExisting, CheckOK, "no files exist yet")
+	case plan.Strategy == StrategySkip:
+		p.add(CheckExisting, CheckWarn, fmt.Sprintf("%d files already exist and are left as they are, not restored; choose overwrite or keep-both to restore them", plan.Conflicts))
+	case plan.Strategy == StrategyOverwrite:
+		p.add(CheckExisting, CheckOK, fmt.Sprintf("%d existing files will be replaced", plan.Conflicts))
+	default:
+		p.add(CheckExisting, CheckOK, fmt.Sprintf("%d existing files are kept, with the snapshot's versions restored beside them", plan.Conflicts))
+	}
+}
+
+func (p *Preflight) add(name string, status CheckStatus, message string) {
+	p.Checks = append(p.Checks, PreflightCheck{Name: name, Status: status, Message: message})
+}
+
+// within reports whether path is dir or inside it
+func within(path, dir string) bool {
+	return path == dir || dir == "/" || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// Failed returns the checks that failed
+func (p *Preflight) Failed() []PreflightCheck {
+	var failed []PreflightCheck
+	for _, c := range p.Checks {
+		if c.Status == CheckFail {
+			failed = append(failed, c)
+		}
+	}
+	return failed
+}
+
+// Err returns an error naming the failed checks, or nil if none failed
+func (p *Preflight) Err() error {
+	failed := p.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(failed))
+	for i, c := range failed {
+		msgs[i] = c.Name + ": " + c.Message
+	}
+	return apperrors.New(apperrors.CodePreflightFailed, "restore preflight failed: "+strings.Join(msgs, "; "))
+}
@@ -0,0 +1,84 @@
+package restore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+// withFreeSpace makes every filesystem report free bytes available
+func withFreeSpace(t *testing.T, free int64) {
+	t.Helper()
+	orig := freeSpace
+	freeSpace = func(string) (int64, error) { return free, nil }
+	t.Cleanup(func() { freeSpace = orig })
+}
+
+func statuses(p *Preflight) map[string]CheckStatus {
+	m := make(map[string]CheckStatus)
+	for _, c := range p.Checks {
+		m[c.Name] = c.Status
+	}
+	return m
+}
+
+func TestPreflightFreeSpace(t *testing.T) {
+	root, nodes, _ := setup(t)
+	nodes[1].Size, nodes[2].Size = 600, 400
+
+	withFreeSpace(t, 700)
+	skip := NewPreflight(NewPlan(root, nodes, StrategySkip, ""), 1000, nil)
+	assert.Equal(t, int64(400), skip.NeededBytes, "a skipped file needs no space")
+	assert.Equal(t, CheckOK, statuses(skip)[CheckFreeSpace])
+	assert.NoError(t, skip.Err())
+
+	keepBoth := NewPreflight(NewPlan(root, nodes, StrategyKeepBoth, ""), 1000, nil)
+	assert.Equal(t, int64(1000), keepBoth.NeededBytes)
+	assert.Equal(t, CheckFail, statuses(keepBoth)[CheckFreeSpace])
+	assert.Equal(t, apperrors.CodePreflightFailed, apperrors.CodeOf(keepBoth.Err()))
+
+	withFreeSpace(t, 1010)
+	assert.Equal(t, CheckWarn, statuses(NewPreflight(NewPlan(root, nodes, StrategyKeepBoth, ""), 1000, nil))[CheckFreeSpace])
+}
+
+func TestPreflightBackupLoop(t *testing.T) {
+	withFreeSpace(t, 1<<30)
+	home := t.TempDir()
+	target := filepath.Join(home, "recovered")
+	nodes := []restic.Node{{Path: "/docs/a.txt", Type: "file"}}
+
+	p := NewPreflight(NewPlan(target, nodes, StrategySkip, ""), 10, []string{home + "/"})
+	assert.Equal(t, CheckFail, statuses(p)[CheckBackupLoop])
+	assert.ErrorContains(t, p.Err(), "inside backed-up path")
+
+	p = NewPreflight(NewPlan(target, nodes, StrategySkip, ""), 10, []string{home + "-other", filepath.Join(target, "sub")})
+	assert.Equal(t, CheckOK, statuses(p)[CheckBackupLoop], "a sibling, or a path inside the target, isn't a loop")
+	assert.Contains(t, p.Checks[0].Message, "will be created")
+
+	p = NewPreflight(NewPlan("/", nodes, StrategySkip, ""), 10, []string{"/"})
+	assert.Equal(t, CheckOK, statuses(p)[CheckBackupLoop], "in place restores to the original paths")
+}
+
+func TestPreflightTargetAndExistingFiles(t *testing.T) {
+	withFreeSpace(t, 1<<30)
+	root, nodes, _ := setup(t)
+
+	p := NewPreflight(NewPlan(root, nodes, StrategySkip, ""), 10, nil)
+	assert.Equal(t, CheckWarn, statuses(p)[CheckExisting], "existing files would be skipped silently")
+	assert.Equal(t, 1, p.Conflicts)
+	assert.NoError(t, p.Err())
+	p = NewPreflight(NewPlan(root, nodes, StrategyOverwrite, ""), 10, nil)
+	assert.Equal(t, CheckOK, statuses(p)[CheckExisting])
+
+	file := filepath.Join(root, "docs", "a.txt")
+	p = NewPreflight(NewPlan(filepath.Join(file, "sub"), nodes, StrategySkip, ""), 10, nil)
+	assert.Equal(t, CheckFail, statuses(p)[CheckTarget])
+	assert.Error(t, p.Err())
+	_, err := os.Stat(filepath.Join(file, "sub"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,277 @@
+// Package restore plans and applies restores into directories that may
+// already hold files, such as the original backup locations
+package restore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+// Strategy decides what happens when a restored file already exists
+type Strategy string
+
+const (
+	// StrategySkip leaves existing files untouched
+	StrategySkip Strategy = "skip"
+	// StrategyOverwrite replaces existing files with the snapshot's version
+	StrategyOverwrite Strategy = "overwrite"
+	// StrategyKeepBoth keeps existing files and restores the snapshot's
+	// version next to them with a suffix
+	StrategyKeepBoth Strategy = "keep-both"
+)
+
+// DefaultSuffix is appended to restored copies under StrategyKeepBoth
+const DefaultSuffix = ".restored"
+
+// ParseStrategy validates a strategy name
+func ParseStrategy(s string) (Strategy, error) {
+	switch Strategy(s) {
+	case StrategySkip, StrategyOverwrite, StrategyKeepBoth:
+		return Strategy(s), nil
+	}
+	return "", fmt.Errorf("unknown conflict strategy %q (use skip, overwrite or keep-both)", s)
+}
+
+// Action is what a restore does with one file
+type Action string
+
+const (
+	ActionRestore   Action = "restore"   // No conflict: written as is
+	ActionSkip      Action = "skip"      // Conflict: existing file kept
+	ActionOverwrite Action = "overwrite" // Conflict: existing file replaced
+	ActionKeepBoth  Action = "keep-both" // Conflict: restored beside the existing file
+)
+
+// FileResult is the outcome (or, before Apply, the plan) for one file
+type FileResult struct {
+	Path       string `json:"path"`
+	Action     Action `json:"action"`
+	RestoredAs string `json:"restored_as,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Plan is a restore of a snapshot into a root directory. Snapshot paths are
+// absolute, so a root of "/" restores to the original locations.
+type Plan struct {
+	Root      string
+	Strategy  Strategy
+	Files     []FileResult
+	Conflicts int
+
+	// ReusedBytes is how much of the snapshot needs no new disk space:
+	// the files skipped, and the space of the files overwritten
+	ReusedBytes int64
+
+	// LimitKiBps caps the restore's download rate in KiB/s (zero = no cap)
+	LimitKiBps int
+
+	// Progress, if set, is called with restic's progress reports
+	Progress func(restic.RestoreStatus)
+}
+
+// NewPlan checks which of the snapshot's files already exist under root
+// and decides what to do with each. Directories are never conflicts.
+func NewPlan(root string, nodes []restic.Node, strategy Strategy, suffix string) *Plan {
+	if suffix == "" {
+		suffix = DefaultSuffix
+	}
+
+	p := &Plan{Root: root, Strategy: strategy}
+	taken := make(map[string]bool)
+	for _, node := range nodes {
+		if node.Type == "dir" {
+			continue
+		}
+
+		result := FileResult{Path: node.Path, Action: ActionRestore}
+		dest := p.dest(node.Path)
+		if info, err := os.Lstat(dest); err == nil {
+			p.Conflicts++
+			switch strategy {
+			case StrategySkip:
+				result.Action = ActionSkip
+				p.ReusedBytes += node.Size
+			case StrategyOverwrite:
+				result.Action = ActionOverwrite
+				p.ReusedBytes += min(node.Size, info.Size())
+			case StrategyKeepBoth:
+				result.Action = ActionKeepBoth
+				result.RestoredAs = freeName(dest+suffix, taken)
+				taken[result.RestoredAs] = true
+			}
+		}
+		p.Files = append(p.Files, result)
+	}
+	return p
+}
+
+// dest is where a snapshot path lands under the plan's root
+func (p *Plan) dest(path string) string {
+	return filepath.Join(p.Root, path)
+}
+
+// freeName returns name, or name with "-2", "-3", ... if it is taken
+func freeName(name string, taken map[string]bool) string {
+	candidate := name
+	for i := 2; ; i++ {
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) && !taken[candidate] {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+}
+
+// Restorer runs restic restores
+type Restorer interface {
+	RestoreWith(ctx context.Context, snapshotID string, opts restic.RestoreOptions) error
+}
+
+// Apply carries out the plan. Skip and overwrite map onto restic's
+// --overwrite modes; keep-both restores conflicting files into a staging
+// directory and moves them next to the existing files.
+func (p *Plan) Apply(ctx context.Context, r Restorer, snapshotID string) error {
+	overwrite := "never"
+	if p.Strategy == StrategyOverwrite {
+		overwrite = "always"
+	}
+	if err := r.RestoreWith(ctx, snapshotID, restic.RestoreOptions{Target: p.Root, Overwrite: overwrite, LimitDownload: p.LimitKiBps, Progress: p.Progress}); err != nil {
+		return err
+	}
+	return p.keepBoth(ctx, r, snapshotID)
+}
+
+// Resume carries out the rest of a plan an earlier Apply didn't finish.
+// Files already restored intact are left alone (restic's if-changed mode,
+// restic 0.17 or later), the files that existed before the first attempt
+// are only replaced under StrategyOverwrite, and keep-both copies already
+// in place aren't restored again. Only the plan's conflicts are needed:
+// the files it restores as they are need no record.
+func (p *Plan) Resume(ctx context.Context, r Restorer, snapshotID string) error {
+	var excludes []string
+	if p.Strategy != StrategyOverwrite {
+		for _, f := range p.Files {
+			if f.Action == ActionSkip || f.Action == ActionKeepBoth {
+				excludes = append(excludes, f.Path)
+			}
+		}
+	}
+	if err := r.RestoreWith(ctx, snapshotID, restic.RestoreOptions{Target: p.Root, Excludes: excludes, Overwrite: "if-changed", LimitDownload: p.LimitKiBps, Progress: p.Progress}); err != nil {
+		return err
+	}
+	return p.keepBoth(ctx, r, snapshotID)
+}
+
+// Conflicting returns the plan's files that already existed
+func (p *Plan) Conflicting() []FileResult {
+	var conflicts []FileResult
+	for _, f := range p.Files {
+		if f.Action != ActionRestore {
+			conflicts = append(conflicts, f)
+		}
+	}
+	return conflicts
+}
+
+// keepBoth restores the conflicting files of a keep-both plan into a
+// staging directory and moves them next to the existing files, skipping
+// those already moved
+func (p *Plan) keepBoth(ctx context.Context, r Restorer, snapshotID string) error {
+	if p.Strategy != StrategyKeepBoth || p.Conflicts == 0 {
+		return nil
+	}
+
+	var includes []string
+	for _, f := range p.Files {
+		if f.Action == ActionKeepBoth && !exists(f.RestoredAs) {
+			includes = append(includes, f.Path)
+		}
+	}
+	if len(includes) == 0 {
+		return nil
+	}
+
+	staging, err := os.MkdirTemp("", "airgapper-restore-")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(staging) }()
+
+	if err := r.RestoreWith(ctx, snapshotID, restic.RestoreOptions{Target: staging, Includes: includes, LimitDownload: p.LimitKiBps}); err != nil {
+		return err
+	}
+
+	failed := 0
+	for i := range p.Files {
+		f := &p.Files[i]
+		if f.Action != ActionKeepBoth || exists(f.RestoredAs) {
+			continue
+		}
+		if err := moveFile(filepath.Join(staging, f.Path), f.RestoredAs); err != nil {
+			f.Error = err.Error()
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d conflicting files could not be restored beside the originals", failed)
+	}
+	return nil
+}
+
+// exists reports whether something is at path
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// moveFile renames src to dst, copying when they are on different filesystems
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
+
+// Counts tallies the plan's actions
+func (p *Plan) Counts() map[Action]int {
+	counts := make(map[Action]int)
+	for _, f := range p.Files {
+		counts[f.Action]++
+	}
+	return counts
+}
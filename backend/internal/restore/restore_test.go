@@ -0,0 +1,151 @@
+package restore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+// fakeRestorer writes snapshot files the way restic restore would
+type fakeRestorer struct {
+	files map[string]string
+	runs  []restic.RestoreOptions
+}
+
+func (f *fakeRestorer) RestoreWith(_ context.Context, _ string, opts restic.RestoreOptions) error {
+	f.runs = append(f.runs, opts)
+	for path, content := range f.files {
+		if len(opts.Includes) > 0 && !contains(opts.Includes, path) || contains(opts.Excludes, path) {
+			continue
+		}
+		dest := filepath.Join(opts.Target, path)
+		if _, err := os.Stat(dest); err == nil && opts.Overwrite == "never" {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dest, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func setup(t *testing.T) (string, []restic.Node, *fakeRestorer) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "docs"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "docs/a.txt"), []byte("local"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "docs/a.txt"+DefaultSuffix), []byte("older copy"), 0644))
+
+	nodes := []restic.Node{
+		{Path: "/docs", Type: "dir"},
+		{Path: "/docs/a.txt", Type: "file"},
+		{Path: "/docs/b.txt", Type: "file"},
+	}
+	r := &fakeRestorer{files: map[string]string{"/docs/a.txt": "snapshot a", "/docs/b.txt": "snapshot b"}}
+	return root, nodes, r
+}
+
+func read(t *testing.T, path string) string {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestPlanAndApply(t *testing.T) {
+	t.Run("skip", func(t *testing.T) {
+		root, nodes, r := setup(t)
+		plan := NewPlan(root, nodes, StrategySkip, "")
+		assert.Equal(t, 1, plan.Conflicts)
+		assert.Equal(t, []FileResult{
+			{Path: "/docs/a.txt", Action: ActionSkip},
+			{Path: "/docs/b.txt", Action: ActionRestore},
+		}, plan.Files)
+
+		require.NoError(t, plan.Apply(context.Background(), r, "abc"))
+		assert.Equal(t, "local", read(t, filepath.Join(root, "docs/a.txt")))
+		assert.Equal(t, "snapshot b", read(t, filepath.Join(root, "docs/b.txt")))
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		root, nodes, r := setup(t)
+		plan := NewPlan(root, nodes, StrategyOverwrite, "")
+		require.NoError(t, plan.Apply(context.Background(), r, "abc"))
+		assert.Equal(t, ActionOverwrite, plan.Files[0].Action)
+		assert.Equal(t, "snapshot a", read(t, filepath.Join(root, "docs/a.txt")))
+		assert.Equal(t, "always", r.runs[0].Overwrite)
+	})
+
+	t.Run("keep both", func(t *testing.T) {
+		root, nodes, r := setup(t)
+		plan := NewPlan(root, nodes, StrategyKeepBoth, "")
+		restoredAs := filepath.Join(root, "docs/a.txt"+DefaultSuffix+"-2")
+		assert.Equal(t, restoredAs, plan.Files[0].RestoredAs, "existing suffixed copy is not clobbered")
+
+		require.NoError(t, plan.Apply(context.Background(), r, "abc"))
+		assert.Equal(t, "local", read(t, filepath.Join(root, "docs/a.txt")))
+		assert.Equal(t, "older copy", read(t, filepath.Join(root, "docs/a.txt"+DefaultSuffix)))
+		assert.Equal(t, "snapshot a", read(t, restoredAs))
+		assert.Equal(t, "snapshot b", read(t, filepath.Join(root, "docs/b.txt")))
+		require.Len(t, r.runs, 2)
+		assert.Equal(t, []string{"/docs/a.txt"}, r.runs[1].Includes)
+	})
+
+	t.Run("bandwidth limit", func(t *testing.T) {
+		root, nodes, r := setup(t)
+		plan := NewPlan(root, nodes, StrategyKeepBoth, "")
+		plan.LimitKiBps = 10240
+		require.NoError(t, plan.Apply(context.Background(), r, "abc"))
+		require.Len(t, r.runs, 2)
+		for _, run := range r.runs {
+			assert.Equal(t, 10240, run.LimitDownload, "every restic run honors the limit")
+		}
+	})
+}
+
+func TestPlanResume(t *testing.T) {
+	root, nodes, r := setup(t)
+	plan := NewPlan(root, nodes, StrategyKeepBoth, "")
+	restoredAs := plan.Files[0].RestoredAs
+	// The first attempt was cut off halfway through b.txt
+	require.NoError(t, os.WriteFile(filepath.Join(root, "docs/b.txt"), []byte("snap"), 0644))
+
+	resumed := &Plan{Root: root, Strategy: plan.Strategy, Files: plan.Conflicting(), Conflicts: plan.Conflicts}
+	require.Equal(t, []FileResult{plan.Files[0]}, resumed.Files)
+	require.NoError(t, resumed.Resume(context.Background(), r, "abc"))
+	assert.Equal(t, "local", read(t, filepath.Join(root, "docs/a.txt")), "files there before the restore stay untouched")
+	assert.Equal(t, "snapshot b", read(t, filepath.Join(root, "docs/b.txt")))
+	assert.Equal(t, "snapshot a", read(t, restoredAs))
+	require.Len(t, r.runs, 2)
+	assert.Equal(t, "if-changed", r.runs[0].Overwrite)
+	assert.Equal(t, []string{"/docs/a.txt"}, r.runs[0].Excludes)
+
+	// Resuming again doesn't restore the keep-both copy a second time
+	require.NoError(t, resumed.Resume(context.Background(), r, "abc"))
+	assert.Len(t, r.runs, 3)
+}
+
+func TestParseStrategy(t *testing.T) {
+	s, err := ParseStrategy("keep-both")
+	require.NoError(t, err)
+	assert.Equal(t, StrategyKeepBoth, s)
+
+	_, err = ParseStrategy("merge")
+	assert.Error(t, err)
+}
@@ -0,0 +1,293 @@
+package restorejob
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/restore"
+)
+
+// Status is where a job is in its life
+type Status string
+
+const (
+	// StatusQueued jobs wait for a runner to pick them up, including jobs
+	// a shutdown interrupted
+	StatusQueued Status = "queued"
+	// StatusScheduled jobs wait for the approvers' restore window to open
+	StatusScheduled Status = "scheduled"
+	// StatusRunning jobs are restoring files
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+)
+
+// Finished reports whether a job in this status will not run again
+func (s Status) Finished() bool {
+	return s == StatusCompleted || s == StatusFailed || s == StatusCancelled
+}
+
+// claimed reports whether a runner is working on a job in this status
+func (s Status) claimed() bool {
+	return s == StatusScheduled || s == StatusRunning
+}
+
+// MaxFinished is how many finished jobs a Store keeps
+const MaxFinished = 50
+
+var (
+	// ErrJobNotFound is returned for unknown job IDs
+	ErrJobNotFound = apperrors.New(apperrors.CodeRestoreJobNotFound, "restore job not found")
+	// ErrJobFinished is returned when cancelling a job that already finished
+	ErrJobFinished = apperrors.New(apperrors.CodeRestoreJobFinished, "restore job already finished")
+)
+
+// Job is a restore of an approved request, run by the daemon (or by the
+// CLI in the foreground) and resumed where it stopped if the runner dies
+type Job struct {
+	ID        string           `json:"id"`
+	RequestID string           `json:"request_id"`
+	Target    string           `json:"target"`
+	Conflict  restore.Strategy `json:"conflict"`
+	Suffix    string           `json:"suffix,omitempty"`
+
+	Status Status `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Interrupted is set when a shutdown stopped the job, which resumes
+	// when a runner next picks it up
+	Interrupted bool `json:"interrupted,omitempty"`
+
+	// SnapshotID is the snapshot the request's selector resolved to when
+	// the job first ran, so every attempt restores the same snapshot
+	SnapshotID string `json:"snapshot_id,omitempty"`
+	// Preflight is what checking the target found before the first run
+	// restored anything; a failed check fails the job
+	Preflight *restore.Preflight `json:"preflight,omitempty"`
+	// Planned is set once the files to restore and their conflicts are known
+	Planned bool `json:"planned,omitempty"`
+	// Files is how many files the restore covers
+	Files int `json:"files"`
+	// Conflicts are the files that existed before the restore and what it
+	// does with each; a resumed attempt leaves them as the plan decided
+	Conflicts []restore.FileResult `json:"conflicts,omitempty"`
+	// Restored is how many files were restored
+	Restored    int     `json:"restored,omitempty"`
+	PercentDone float64 `json:"percent_done,omitempty"`
+	BytesDone   int64   `json:"bytes_done,omitempty"`
+	BytesTotal  int64   `json:"bytes_total,omitempty"`
+
+	// Attempts counts the runs, including resumed ones
+	Attempts int `json:"attempts"`
+	// Runner identifies the process working on the job, and Heartbeat when
+	// it last showed it still was
+	Runner    string    `json:"runner,omitempty"`
+	Heartbeat time.Time `json:"heartbeat,omitzero"`
+
+	CreatedAt  time.Time  `json:"created_at"`
+	StartsAt   *time.Time `json:"starts_at,omitempty"` // When a scheduled job's window opens
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Store persists restore jobs, oldest first. The daemon and CLI commands
+// share it: a job submitted by one is run and followed by the other.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore returns a store backed by the file at path
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// List returns every job, oldest first
+func (s *Store) List() ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Get returns a job
+func (s *Store) Get(id string) (*Job, error) {
+	jobs, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	if i := find(jobs, id); i >= 0 {
+		return &jobs[i], nil
+	}
+	return nil, ErrJobNotFound
+}
+
+// add records a new job, dropping the oldest finished jobs beyond
+// MaxFinished. check sees the jobs already stored and can refuse the new one.
+func (s *Store) add(job *Job, check func(jobs []Job) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs, err := s.load()
+	if err != nil {
+		return err
+	}
+	if err := check(jobs); err != nil {
+		return err
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	job.ID = hex.EncodeToString(id)
+	return s.save(prune(append(jobs, *job)))
+}
+
+// update applies fn to a job and returns the job as saved. If fn fails,
+// nothing is saved.
+func (s *Store) update(id string, fn func(*Job) error) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	i := find(jobs, id)
+	if i < 0 {
+		return nil, ErrJobNotFound
+	}
+	if err := fn(&jobs[i]); err != nil {
+		return nil, err
+	}
+	job := jobs[i]
+	return &job, s.save(jobs)
+}
+
+// Active returns the job for a request that hasn't finished, or nil if
+// there is none
+func (s *Store) Active(requestID string) (*Job, error) {
+	jobs, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobs {
+		if jobs[i].RequestID == requestID && !jobs[i].Status.Finished() {
+			return &jobs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// claim marks the oldest job waiting for a runner as taken by runner and
+// returns it: a queued job, or one whose runner hasn't shown a heartbeat
+// since staleBefore. If id is set, only that job is considered. It returns
+// nil if there is none.
+func (s *Store) claim(id, runner string, now, staleBefore time.Time) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobs {
+		j := &jobs[i]
+		if id != "" && j.ID != id {
+			continue
+		}
+		if j.Status != StatusQueued && !(j.Status.claimed() && j.Heartbeat.Before(staleBefore)) {
+			continue
+		}
+		if j.Status.claimed() {
+			// Its runner died without a chance to record it
+			j.Interrupted = true
+		}
+		j.Status = StatusRunning
+		j.Runner = runner
+		j.Heartbeat = now
+		j.Attempts++
+		job := *j
+		return &job, s.save(jobs)
+	}
+	return nil, nil
+}
+
+// find returns the index of the job with id, or -1
+func find(jobs []Job, id string) int {
+	for i := range jobs {
+		if jobs[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// prune drops the oldest finished jobs beyond MaxFinished
+func prune(jobs []Job) []Job {
+	finished := 0
+	for _, j := range jobs {
+		if j.Status.Finished() {
+			finished++
+		}
+	}
+	kept := jobs[:0]
+	for _, j := range jobs {
+		if j.Status.Finished() && finished > MaxFinished {
+			finished--
+			continue
+		}
+		kept = append(kept, j)
+	}
+	return kept
+}
+
+func (s *Store) load() ([]Job, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read restore jobs: %w", err)
+	}
+
+	var jobs []Job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to parse restore jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+func (s *Store) save(jobs []Job) error {
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize restore jobs: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	// Written to a temporary file and renamed, so a runner in another
+	// process never reads half a file
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".restore-jobs-*")
+	if err != nil {
+		return fmt.Errorf("failed to save restore jobs: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save restore jobs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package restorejob
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStore(t *testing.T) *Store {
+	t.Helper()
+	return NewStore(filepath.Join(t.TempDir(), "restore-jobs.json"))
+}
+
+func addJob(t *testing.T, s *Store, job Job) *Job {
+	t.Helper()
+	require.NoError(t, s.add(&job, func([]Job) error { return nil }))
+	return &job
+}
+
+func TestStoreClaim(t *testing.T) {
+	s := newStore(t)
+	now := time.Now().UTC()
+	first := addJob(t, s, Job{RequestID: "a", Status: StatusQueued})
+	second := addJob(t, s, Job{RequestID: "b", Status: StatusQueued})
+
+	claimed, err := s.claim("", "serve (pid 1)", now, now.Add(-staleAfter))
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, first.ID, claimed.ID, "oldest job first")
+	assert.Equal(t, StatusRunning, claimed.Status)
+	assert.Equal(t, "serve (pid 1)", claimed.Runner)
+	assert.Equal(t, 1, claimed.Attempts)
+	assert.False(t, claimed.Interrupted)
+
+	claimed, err = s.claim(first.ID, "cli (pid 2)", now, now.Add(-staleAfter))
+	require.NoError(t, err)
+	assert.Nil(t, claimed, "a job with a live runner isn't taken over")
+
+	claimed, err = s.claim("", "cli (pid 2)", now, now.Add(-staleAfter))
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, second.ID, claimed.ID)
+
+	// The first runner died: once its heartbeat is stale the job is resumed
+	later := now.Add(staleAfter + time.Second)
+	claimed, err = s.claim("", "serve (pid 3)", later, later.Add(-staleAfter))
+	require.NoError(t, err)
+	require.NotNil(t, claimed)
+	assert.Equal(t, first.ID, claimed.ID)
+	assert.True(t, claimed.Interrupted)
+	assert.Equal(t, 2, claimed.Attempts)
+}
+
+func TestStorePrunesFinishedJobs(t *testing.T) {
+	s := newStore(t)
+	queued := addJob(t, s, Job{RequestID: "queued", Status: StatusQueued})
+	for range MaxFinished + 5 {
+		addJob(t, s, Job{RequestID: "done", Status: StatusCompleted})
+	}
+
+	jobs, err := s.List()
+	require.NoError(t, err)
+	assert.Len(t, jobs, MaxFinished+1)
+	assert.Equal(t, queued.ID, jobs[0].ID, "unfinished jobs are kept")
+
+	active, err := s.Active("queued")
+	require.NoError(t, err)
+	require.NotNil(t, active)
+	assert.Equal(t, queued.ID, active.ID)
+	active, err = s.Active("done")
+	require.NoError(t, err)
+	assert.Nil(t, active)
+
+	_, err = s.Get("missing")
+	assert.ErrorIs(t, err, ErrJobNotFound)
+}
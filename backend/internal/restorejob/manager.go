@@ -0,0 +1,657 @@
+// Package restorejob runs the restores of approved requests as jobs.
+//
+// Restoring a large repository can take longer than the SSH session a CLI
+// command runs in. Jobs are kept in a store the serve daemon and CLI
+// commands share: the daemon runs them in the background, one at a time,
+// and records their progress, so the command that submitted a job can stop
+// following it without stopping the restore.
+//
+// A job also outlives its runner. Its first run plans the restore and
+// records the files that already existed; a job whose runner was shut down
+// or died is picked up again by the next runner and resumed from there,
+// leaving the files already restored alone (see restore.Plan.Resume).
+package restorejob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/restore"
+)
+
+const (
+	// pollInterval is how often a background runner looks for jobs
+	// submitted by other processes
+	pollInterval = 5 * time.Second
+	// heartbeatInterval is how often a running job's progress is saved
+	heartbeatInterval = 15 * time.Second
+	// staleAfter is how long a runner can go without a heartbeat before
+	// another runner takes its job over
+	staleAfter = 2 * time.Minute
+	// interruptWait is how long a drain waits for an interrupted job to stop
+	interruptWait = time.Minute
+)
+
+var (
+	// errCancelled stops a run whose job was cancelled
+	errCancelled = errors.New("restore job cancelled")
+	// errTakenOver stops a run whose job another runner took over
+	errTakenOver = errors.New("restore job was taken over by another runner")
+)
+
+// Requests looks up the restore requests jobs carry out, and records their
+// progress on them
+type Requests interface {
+	GetRequest(id string) (*consent.RestoreRequest, error)
+	CheckLockdown() error
+	RecordProgress(id string, p consent.RestoreProgress) (*consent.RestoreRequest, error)
+}
+
+// Repo is the repository jobs restore from
+type Repo interface {
+	restore.Restorer
+	ResolveSnapshot(ctx context.Context, snapshotID string) (string, error)
+	ListFiles(ctx context.Context, snapshotID string) ([]restic.Node, error)
+	RestoreSize(ctx context.Context, snapshotID string) (int64, error)
+}
+
+// Spec is a restore to submit
+type Spec struct {
+	RequestID string
+	// Target is the absolute directory to restore into; "/" restores each
+	// file to where it was backed up from
+	Target   string
+	Conflict restore.Strategy // Default: restore.StrategySkip
+	Suffix   string
+}
+
+// Manager submits restore jobs and runs them
+type Manager struct {
+	Store    *Store
+	Requests Requests
+	// Password reconstructs the repository password from what an approved
+	// request released
+	Password func(ctx context.Context, req *consent.RestoreRequest) ([]byte, error)
+	// Open opens the repository with a password
+	Open func(password []byte) Repo
+	// Report, if set, is called with each progress update recorded on a
+	// request, e.g. to pass it on to the peer
+	Report func(ctx context.Context, req *consent.RestoreRequest, p consent.RestoreProgress)
+	// Name identifies this process in the jobs it runs (default "airgapper")
+	Name string
+	// BackupPaths are the paths backups cover, which the preflight keeps
+	// restores out of
+	BackupPaths []string
+
+	mu      sync.Mutex
+	stop    chan struct{}
+	done    chan struct{}
+	wake    chan struct{}
+	cancel  context.CancelFunc            // Interrupts the background runner's job
+	running map[string]context.CancelFunc // Cancels each job this manager runs
+}
+
+// Check reports whether req can be restored now
+func Check(req *consent.RestoreRequest, now time.Time) error {
+	if req.IsKeyExport() {
+		return apperrors.Newf(apperrors.CodeWrongRequestPurpose, "request %s is a key export request (use 'airgapper export-keys')", req.ID)
+	}
+	if req.IsBrowse() {
+		return apperrors.Newf(apperrors.CodeWrongRequestPurpose, "request %s only allows browsing (use 'airgapper browse')", req.ID)
+	}
+	if req.Status != consent.StatusApproved {
+		return apperrors.Newf(apperrors.CodeRequestNotApproved, "request is not approved (status: %s)", req.Status)
+	}
+	return req.CheckExecutable(now)
+}
+
+// Submit queues a restore of an approved request. A request has at most
+// one unfinished job.
+func (m *Manager) Submit(spec Spec) (*Job, error) {
+	if spec.Conflict == "" {
+		spec.Conflict = restore.StrategySkip
+	}
+	if _, err := restore.ParseStrategy(string(spec.Conflict)); err != nil {
+		return nil, apperrors.Coded(apperrors.CodeInvalidArgument, err)
+	}
+	if !filepath.IsAbs(spec.Target) {
+		return nil, apperrors.New(apperrors.CodeInvalidArgument, "restore target must be an absolute path")
+	}
+	req, err := m.request(spec.RequestID)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &Job{
+		RequestID: req.ID,
+		Target:    filepath.Clean(spec.Target),
+		Conflict:  spec.Conflict,
+		Suffix:    spec.Suffix,
+		Status:    StatusQueued,
+		CreatedAt: time.Now().UTC(),
+	}
+	err = m.Store.add(job, func(jobs []Job) error {
+		for _, j := range jobs {
+			if j.RequestID == job.RequestID && !j.Status.Finished() {
+				return apperrors.Newf(apperrors.CodeRestoreJobActive, "request %s already has restore job %s (%s)", j.RequestID, j.ID, j.Status)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logging.Info("Restore job queued",
+		logging.String("job", job.ID),
+		logging.String("request", job.RequestID),
+		logging.String("target", job.Target))
+	m.signal()
+	return job, nil
+}
+
+// Run claims job id and runs it in the calling goroutine, returning the job
+// as it stands afterwards. Cancelling ctx interrupts the job, leaving it
+// queued for the next runner to resume.
+func (m *Manager) Run(ctx context.Context, id string) (*Job, error) {
+	now := time.Now().UTC()
+	job, err := m.Store.claim(id, m.runner(), now, now.Add(-staleAfter))
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		if job, err = m.Store.Get(id); err != nil {
+			return nil, err
+		}
+		if job.Status.Finished() {
+			return nil, ErrJobFinished
+		}
+		return nil, apperrors.Newf(apperrors.CodeRestoreJobActive, "restore job %s is being run by %s", id, job.Runner)
+	}
+	return m.run(ctx, job), nil
+}
+
+// Cancel cancels a job. A queued job is cancelled at once; a running one
+// stops when its runner sees the cancellation, at its next heartbeat if it
+// runs in another process.
+func (m *Manager) Cancel(id string) (*Job, error) {
+	job, err := m.Store.update(id, func(j *Job) error {
+		if j.Status.Finished() {
+			return ErrJobFinished
+		}
+		now := time.Now().UTC()
+		j.Status, j.FinishedAt = StatusCancelled, &now
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	cancel := m.running[id]
+	m.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	logging.Info("Restore job cancelled", logging.String("job", id), logging.String("request", job.RequestID))
+	return job, nil
+}
+
+// Start runs jobs in the background, oldest first and one at a time, until
+// Drain is called. Jobs that were interrupted, or whose runner died, are
+// resumed.
+func (m *Manager) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stop != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.stop, m.done, m.cancel = make(chan struct{}), make(chan struct{}), cancel
+	if m.wake == nil {
+		m.wake = make(chan struct{}, 1)
+	}
+	go m.loop(ctx, m.stop, m.done, m.wake)
+}
+
+// Drain stops running new jobs and waits for the running one to finish.
+// Once ctx is done it interrupts the job instead, which is queued again to
+// resume on the next start. It reports whether it interrupted a job.
+func (m *Manager) Drain(ctx context.Context) bool {
+	m.mu.Lock()
+	stop, done, cancel := m.stop, m.done, m.cancel
+	m.stop = nil
+	m.mu.Unlock()
+	if stop == nil {
+		return false
+	}
+
+	close(stop)
+	select {
+	case <-done:
+		return false
+	case <-ctx.Done():
+	}
+
+	logging.Warn("Interrupting the restore job in progress to shut down; it resumes on the next start")
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(interruptWait):
+		logging.Warn("The restore job did not stop in time")
+	}
+	return true
+}
+
+func (m *Manager) loop(ctx context.Context, stop, done, wake chan struct{}) {
+	defer close(done)
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		now := time.Now().UTC()
+		job, err := m.Store.claim("", m.runner(), now, now.Add(-staleAfter))
+		if err != nil {
+			logging.Warn("Failed to look for restore jobs", logging.Err(err))
+		}
+		if job != nil {
+			m.run(ctx, job)
+			continue
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-wake:
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// signal wakes the background runner for a new job
+func (m *Manager) signal() {
+	m.mu.Lock()
+	wake := m.wake
+	m.mu.Unlock()
+	if wake == nil {
+		return
+	}
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// runner identifies this process in the jobs it claims
+func (m *Manager) runner() string {
+	name := m.Name
+	if name == "" {
+		name = "airgapper"
+	}
+	return fmt.Sprintf("%s (pid %d)", name, os.Getpid())
+}
+
+// run carries out a claimed job and records how it ended
+func (m *Manager) run(ctx context.Context, job *Job) *Job {
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	m.mu.Lock()
+	if m.running == nil {
+		m.running = make(map[string]context.CancelFunc)
+	}
+	m.running[job.ID] = cancel
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		delete(m.running, job.ID)
+		m.mu.Unlock()
+	}()
+
+	if job.Interrupted {
+		logging.Info("Resuming restore job",
+			logging.String("job", job.ID),
+			logging.String("request", job.RequestID),
+			logging.Int("attempt", job.Attempts))
+	} else {
+		logging.Info("Starting restore job",
+			logging.String("job", job.ID),
+			logging.String("request", job.RequestID))
+	}
+
+	p := &progress{}
+	beats := make(chan struct{})
+	go m.heartbeat(jobCtx, cancel, job, p, beats)
+	req, err := m.execute(jobCtx, job, p)
+	cancel()
+	<-beats
+
+	return m.finish(ctx, job, req, p, err)
+}
+
+// Preflight checks a restore's target before it is submitted: it lists
+// the request's snapshot and checks the target as the job's first run
+// will, without writing anything
+func (m *Manager) Preflight(ctx context.Context, spec Spec) (*restore.Preflight, error) {
+	if spec.Conflict == "" {
+		spec.Conflict = restore.StrategySkip
+	}
+	if _, err := restore.ParseStrategy(string(spec.Conflict)); err != nil {
+		return nil, apperrors.Coded(apperrors.CodeInvalidArgument, err)
+	}
+	if !filepath.IsAbs(spec.Target) {
+		return nil, apperrors.New(apperrors.CodeInvalidArgument, "restore target must be an absolute path")
+	}
+	req, err := m.request(spec.RequestID)
+	if err != nil {
+		return nil, err
+	}
+	password, err := m.Password(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	repo := m.Open(password)
+	snapshotID, err := repo.ResolveSnapshot(ctx, req.SnapshotID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve snapshot: %w", err)
+	}
+	nodes, err := repo.ListFiles(ctx, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	size, err := repo.RestoreSize(ctx, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	p := restore.NewPreflight(restore.NewPlan(filepath.Clean(spec.Target), nodes, spec.Conflict, spec.Suffix), size, m.BackupPaths)
+	p.SnapshotID = snapshotID
+	return p, nil
+}
+
+// execute restores a claimed job's files, saving what a resumed attempt
+// needs as it learns it. It returns the request once it has checked it.
+func (m *Manager) execute(ctx context.Context, job *Job, p *progress) (*consent.RestoreRequest, error) {
+	req, err := m.request(job.RequestID)
+	if err != nil {
+		return nil, err
+	}
+	if req, err = m.waitForWindow(ctx, job, req); err != nil {
+		return req, err
+	}
+
+	password, err := m.Password(ctx, req)
+	if err != nil {
+		return req, err
+	}
+	repo := m.Open(password)
+
+	// Resolved once, so every attempt restores the same snapshot even if
+	// a backup lands meanwhile
+	if job.SnapshotID == "" {
+		if job.SnapshotID, err = repo.ResolveSnapshot(ctx, req.SnapshotID); err != nil {
+			return req, fmt.Errorf("failed to resolve snapshot: %w", err)
+		}
+	}
+
+	resume := job.Planned
+	plan := &restore.Plan{Root: job.Target, Strategy: job.Conflict, Files: job.Conflicts, Conflicts: len(job.Conflicts)}
+	if !resume {
+		nodes, err := repo.ListFiles(ctx, job.SnapshotID)
+		if err != nil {
+			return req, err
+		}
+		plan = restore.NewPlan(job.Target, nodes, job.Conflict, job.Suffix)
+		size, err := repo.RestoreSize(ctx, job.SnapshotID)
+		if err != nil {
+			return req, err
+		}
+		job.Preflight = restore.NewPreflight(plan, size, m.BackupPaths)
+		job.Preflight.SnapshotID = job.SnapshotID
+		if err := job.Preflight.Err(); err != nil {
+			return req, err
+		}
+		job.Files, job.Conflicts, job.Planned = len(plan.Files), plan.Conflicting(), true
+	}
+	plan.LimitKiBps = req.LimitKiBps()
+	plan.Progress = p.set
+
+	job.Status, job.StartsAt = StatusRunning, nil
+	if job.StartedAt == nil {
+		started := time.Now().UTC()
+		job.StartedAt = &started
+	}
+	if err := m.save(job); err != nil {
+		return req, err
+	}
+	m.report(ctx, req, consent.RestoreProgress{Status: consent.ProgressRunning, StartedAt: job.StartedAt, Files: job.Files})
+
+	logging.Info("Restoring",
+		logging.String("job", job.ID),
+		logging.String("snapshot", job.SnapshotID),
+		logging.String("target", job.Target),
+		logging.String("conflict", string(job.Conflict)),
+		logging.Int("conflicts", len(job.Conflicts)),
+		logging.Int("limitKiBps", plan.LimitKiBps),
+		logging.Bool("resumed", resume))
+	if resume {
+		err = plan.Resume(ctx, repo, job.SnapshotID)
+	} else {
+		err = plan.Apply(ctx, repo, job.SnapshotID)
+	}
+	job.Conflicts = plan.Conflicting()
+	job.Restored = job.Files - plan.Counts()[restore.ActionSkip]
+	return req, err
+}
+
+// waitForWindow waits until the start windows approvers set for req are all
+// open, with the job scheduled meanwhile, and returns the request as it
+// stands then
+func (m *Manager) waitForWindow(ctx context.Context, job *Job, req *consent.RestoreRequest) (*consent.RestoreRequest, error) {
+	now := time.Now()
+	start, err := req.NextStart(now)
+	if err != nil || !start.After(now) {
+		return req, err
+	}
+
+	job.Status, job.StartsAt = StatusScheduled, &start
+	if err := m.save(job); err != nil {
+		return req, err
+	}
+	logging.Info("Waiting for the approvers' restore window",
+		logging.String("job", job.ID),
+		logging.String("starts", start.Local().Format("2006-01-02 15:04")),
+		logging.String("in", start.Sub(now).Round(time.Minute).String()))
+	m.report(ctx, req, consent.RestoreProgress{Status: consent.ProgressScheduled, StartsAt: &start})
+
+	timer := time.NewTimer(time.Until(start))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return req, ctx.Err()
+	case <-timer.C:
+	}
+
+	// The request may have been vetoed, or the node locked down, meanwhile
+	return m.request(req.ID)
+}
+
+// request returns request id if it can be restored now
+func (m *Manager) request(id string) (*consent.RestoreRequest, error) {
+	req, err := m.Requests.GetRequest(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := Check(req, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := m.Requests.CheckLockdown(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// save records a running job's plan and state, unless it was cancelled or
+// taken over meanwhile
+func (m *Manager) save(job *Job) error {
+	_, err := m.Store.update(job.ID, func(j *Job) error {
+		if err := owned(j, job); err != nil {
+			return err
+		}
+		j.Status, j.StartsAt, j.StartedAt = job.Status, job.StartsAt, job.StartedAt
+		j.SnapshotID, j.Planned, j.Files, j.Conflicts = job.SnapshotID, job.Planned, job.Files, job.Conflicts
+		j.Preflight = job.Preflight
+		j.Heartbeat = time.Now().UTC()
+		return nil
+	})
+	return err
+}
+
+// owned checks stored job j is still job's to run
+func owned(j, job *Job) error {
+	switch {
+	case j.Runner != job.Runner:
+		return errTakenOver
+	case j.Status == StatusCancelled:
+		return errCancelled
+	}
+	return nil
+}
+
+// heartbeat saves a running job's progress, showing other runners it is
+// alive, and stops the job once it is cancelled or taken over
+func (m *Manager) heartbeat(ctx context.Context, cancel context.CancelFunc, job *Job, p *progress, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		_, err := m.Store.update(job.ID, func(j *Job) error {
+			if err := owned(j, job); err != nil {
+				return err
+			}
+			j.Heartbeat = time.Now().UTC()
+			p.apply(j)
+			return nil
+		})
+		switch {
+		case errors.Is(err, errCancelled), errors.Is(err, errTakenOver), errors.Is(err, ErrJobNotFound):
+			cancel()
+			return
+		case err != nil:
+			logging.Warn("Failed to save restore job progress", logging.String("job", job.ID), logging.Err(err))
+		}
+	}
+}
+
+// finish records how a run ended. A run stopped by ctx (a shutdown, or a
+// foreground runner exiting) is queued again to resume.
+func (m *Manager) finish(ctx context.Context, job *Job, req *consent.RestoreRequest, p *progress, runErr error) *Job {
+	interrupted := runErr != nil && ctx.Err() != nil
+	saved, err := m.Store.update(job.ID, func(j *Job) error {
+		if j.Runner != job.Runner {
+			return errTakenOver
+		}
+		now := time.Now().UTC()
+		p.apply(j)
+		j.Heartbeat = now
+		j.SnapshotID, j.Planned, j.Files, j.Conflicts = job.SnapshotID, job.Planned, job.Files, job.Conflicts
+		j.Preflight = job.Preflight
+		j.StartedAt = job.StartedAt
+		switch {
+		case runErr == nil:
+			j.Status, j.Error, j.Interrupted = StatusCompleted, "", false
+			j.Restored, j.PercentDone = job.Restored, 1
+			j.FinishedAt = &now
+		case j.Status == StatusCancelled:
+			j.FinishedAt = &now
+		case interrupted:
+			j.Status, j.Runner, j.Interrupted, j.StartsAt = StatusQueued, "", true, nil
+		default:
+			j.Status, j.Error = StatusFailed, runErr.Error()
+			j.FinishedAt = &now
+		}
+		return nil
+	})
+	if err != nil {
+		logging.Warn("Failed to record the end of a restore job", logging.String("job", job.ID), logging.Err(err))
+		if saved, err = m.Store.Get(job.ID); err != nil {
+			return job
+		}
+		return saved
+	}
+
+	id, request := logging.String("job", saved.ID), logging.String("request", saved.RequestID)
+	progress := consent.RestoreProgress{StartedAt: saved.StartedAt, FinishedAt: saved.FinishedAt, Files: saved.Files, Restored: saved.Restored}
+	switch saved.Status {
+	case StatusCompleted:
+		logging.Info("Restore job completed", id, request, logging.Int("files", saved.Files), logging.Int("restored", saved.Restored))
+		progress.Status = consent.ProgressCompleted
+	case StatusQueued:
+		logging.Warn("Restore job interrupted; it resumes when a runner next picks it up", id, request)
+		return saved
+	case StatusCancelled:
+		logging.Info("Restore job stopped after being cancelled", id, request)
+		progress.Status, progress.Error = consent.ProgressFailed, "cancelled"
+	default:
+		logging.Warn("Restore job failed", id, request, logging.String("error", saved.Error))
+		progress.Status, progress.Error = consent.ProgressFailed, saved.Error
+	}
+	if req != nil {
+		// The run may have outlived ctx, and its outcome should still be reported
+		m.report(context.WithoutCancel(ctx), req, progress)
+	}
+	return saved
+}
+
+// report records progress on the request and passes it on. Failures only
+// warn: progress is informational.
+func (m *Manager) report(ctx context.Context, req *consent.RestoreRequest, p consent.RestoreProgress) {
+	if _, err := m.Requests.RecordProgress(req.ID, p); err != nil {
+		logging.Warn("Failed to record restore progress", logging.Err(err))
+	}
+	if m.Report != nil {
+		m.Report(ctx, req, p)
+	}
+}
+
+// progress is the latest progress report restic made for a running job
+type progress struct {
+	mu     sync.Mutex
+	status restic.RestoreStatus
+	seen   bool
+}
+
+func (p *progress) set(s restic.RestoreStatus) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.status, p.seen = s, true
+}
+
+// apply copies the latest report onto a job
+func (p *progress) apply(j *Job) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.seen {
+		return
+	}
+	j.PercentDone = p.status.PercentDone
+	j.BytesDone = p.status.BytesRestored + p.status.BytesSkipped
+	j.BytesTotal = p.status.TotalBytes
+}
@@ -0,0 +1,278 @@
+package restorejob
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/restore"
+)
+
+// fakeRequests holds restore requests and the progress recorded on them
+type fakeRequests struct {
+	mu       sync.Mutex
+	requests map[string]*consent.RestoreRequest
+	progress []consent.RestoreProgress
+	lockdown error
+}
+
+func (f *fakeRequests) GetRequest(id string) (*consent.RestoreRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	req, ok := f.requests[id]
+	if !ok {
+		return nil, apperrors.ErrRequestNotFound
+	}
+	c := *req
+	return &c, nil
+}
+
+func (f *fakeRequests) CheckLockdown() error { return f.lockdown }
+
+func (f *fakeRequests) RecordProgress(id string, p consent.RestoreProgress) (*consent.RestoreRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.progress = append(f.progress, p)
+	return f.requests[id], nil
+}
+
+func (f *fakeRequests) statuses() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var statuses []string
+	for _, p := range f.progress {
+		statuses = append(statuses, p.Status)
+	}
+	return statuses
+}
+
+// fakeRepo is a repository with one snapshot; restores can be made to block
+// until they are interrupted
+type fakeRepo struct {
+	mu       sync.Mutex
+	nodes    []restic.Node
+	restores []restic.RestoreOptions
+	block    chan struct{} // If set, restores wait for it or the context
+	started  chan struct{}
+}
+
+func (f *fakeRepo) RestoreWith(ctx context.Context, snapshotID string, opts restic.RestoreOptions) error {
+	f.mu.Lock()
+	f.restores = append(f.restores, opts)
+	block, started := f.block, f.started
+	f.mu.Unlock()
+	if opts.Progress != nil {
+		opts.Progress(restic.RestoreStatus{PercentDone: 0.5, TotalBytes: 100, BytesRestored: 50})
+	}
+	if block == nil {
+		return nil
+	}
+	if started != nil {
+		close(started)
+	}
+	select {
+	case <-block:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *fakeRepo) ResolveSnapshot(ctx context.Context, snapshotID string) (string, error) {
+	return "abcd1234", nil
+}
+
+func (f *fakeRepo) ListFiles(ctx context.Context, snapshotID string) ([]restic.Node, error) {
+	return f.nodes, nil
+}
+
+func (f *fakeRepo) RestoreSize(ctx context.Context, snapshotID string) (int64, error) {
+	return 1024, nil
+}
+
+func newManager(t *testing.T, repo *fakeRepo, reqs ...*consent.RestoreRequest) (*Manager, *fakeRequests) {
+	t.Helper()
+	requests := &fakeRequests{requests: map[string]*consent.RestoreRequest{}}
+	for _, r := range reqs {
+		requests.requests[r.ID] = r
+	}
+	return &Manager{
+		Store:    newStore(t),
+		Requests: requests,
+		Password: func(context.Context, *consent.RestoreRequest) ([]byte, error) { return []byte("secret"), nil },
+		Open:     func([]byte) Repo { return repo },
+		Name:     "test",
+	}, requests
+}
+
+func approved(id string) *consent.RestoreRequest {
+	return &consent.RestoreRequest{ID: id, Status: consent.StatusApproved, SnapshotID: "latest"}
+}
+
+func TestSubmitChecksTheRequest(t *testing.T) {
+	pending := approved("pending")
+	pending.Status = consent.StatusPending
+	browse := approved("browse")
+	browse.Purpose = consent.PurposeBrowse
+	m, _ := newManager(t, &fakeRepo{}, approved("ok"), pending, browse)
+
+	_, err := m.Submit(Spec{RequestID: "ok", Target: "relative"})
+	assert.Equal(t, apperrors.CodeInvalidArgument, apperrors.CodeOf(err))
+	_, err = m.Submit(Spec{RequestID: "ok", Target: "/restore", Conflict: "merge"})
+	assert.Equal(t, apperrors.CodeInvalidArgument, apperrors.CodeOf(err))
+	_, err = m.Submit(Spec{RequestID: "pending", Target: "/restore"})
+	assert.Equal(t, apperrors.CodeRequestNotApproved, apperrors.CodeOf(err))
+	_, err = m.Submit(Spec{RequestID: "browse", Target: "/restore"})
+	assert.Equal(t, apperrors.CodeWrongRequestPurpose, apperrors.CodeOf(err))
+
+	job, err := m.Submit(Spec{RequestID: "ok", Target: "/restore/"})
+	require.NoError(t, err)
+	assert.Equal(t, StatusQueued, job.Status)
+	assert.Equal(t, "/restore", job.Target)
+	assert.Equal(t, restore.StrategySkip, job.Conflict)
+
+	_, err = m.Submit(Spec{RequestID: "ok", Target: "/elsewhere"})
+	assert.Equal(t, apperrors.CodeRestoreJobActive, apperrors.CodeOf(err), "one unfinished job per request")
+}
+
+func TestRunCompletesAJob(t *testing.T) {
+	target := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(target, "data"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(target, "data", "old.txt"), []byte("mine"), 0600))
+	repo := &fakeRepo{nodes: []restic.Node{
+		{Type: "dir", Path: "/data"},
+		{Type: "file", Path: "/data/old.txt"},
+		{Type: "file", Path: "/data/new.txt"},
+	}}
+	m, requests := newManager(t, repo, approved("req"))
+
+	job, err := m.Submit(Spec{RequestID: "req", Target: target})
+	require.NoError(t, err)
+	job, err = m.Run(context.Background(), job.ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, StatusCompleted, job.Status)
+	assert.Equal(t, "abcd1234", job.SnapshotID)
+	assert.Equal(t, 2, job.Files)
+	assert.Equal(t, 1, job.Restored, "the existing file is skipped")
+	assert.Equal(t, []restore.FileResult{{Path: "/data/old.txt", Action: restore.ActionSkip}}, job.Conflicts)
+	assert.Equal(t, 1.0, job.PercentDone)
+	assert.Equal(t, int64(50), job.BytesDone)
+	assert.NotNil(t, job.FinishedAt)
+	assert.Equal(t, []string{consent.ProgressRunning, consent.ProgressCompleted}, requests.statuses())
+
+	_, err = m.Run(context.Background(), job.ID)
+	assert.ErrorIs(t, err, ErrJobFinished)
+}
+
+func TestPreflight(t *testing.T) {
+	home := t.TempDir()
+	repo := &fakeRepo{nodes: []restic.Node{{Type: "file", Path: "/data/new.txt", Size: 1024}}}
+	m, _ := newManager(t, repo, approved("req"))
+	m.BackupPaths = []string{home}
+
+	outside := filepath.Join(t.TempDir(), "restore")
+	p, err := m.Preflight(context.Background(), Spec{RequestID: "req", Target: outside})
+	require.NoError(t, err)
+	assert.Equal(t, "abcd1234", p.SnapshotID)
+	assert.Equal(t, int64(1024), p.RestoreBytes)
+	assert.NoError(t, p.Err())
+	assert.NoFileExists(t, outside, "a preflight writes nothing")
+
+	// The job's first run checks the target too, and fails without
+	// restoring anything
+	job, err := m.Submit(Spec{RequestID: "req", Target: filepath.Join(home, "restore")})
+	require.NoError(t, err)
+	job, err = m.Run(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusFailed, job.Status)
+	assert.Contains(t, job.Error, "inside backed-up path")
+	require.NotNil(t, job.Preflight)
+	assert.NotEmpty(t, job.Preflight.Failed())
+	assert.Empty(t, repo.restores)
+}
+
+func TestDrainInterruptsAndTheNextRunResumes(t *testing.T) {
+	target := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(target, "old.txt"), []byte("mine"), 0600))
+	repo := &fakeRepo{
+		nodes:   []restic.Node{{Type: "file", Path: "/old.txt"}, {Type: "file", Path: "/big.iso"}},
+		block:   make(chan struct{}),
+		started: make(chan struct{}),
+	}
+	m, _ := newManager(t, repo, approved("req"))
+
+	job, err := m.Submit(Spec{RequestID: "req", Target: target})
+	require.NoError(t, err)
+	m.Start()
+	select {
+	case <-repo.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the job did not start")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.True(t, m.Drain(ctx), "the running job is interrupted")
+
+	job, err = m.Store.Get(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusQueued, job.Status)
+	assert.True(t, job.Interrupted)
+	assert.True(t, job.Planned)
+	assert.Empty(t, job.Runner)
+
+	// The next start resumes the plan: files restored intact are left alone
+	// and the existing file stays skipped
+	repo.mu.Lock()
+	repo.block, repo.started = nil, nil
+	repo.mu.Unlock()
+	job, err = m.Run(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCompleted, job.Status)
+	assert.False(t, job.Interrupted)
+	assert.Equal(t, 2, job.Attempts)
+	assert.Equal(t, 1, job.Restored)
+
+	require.Len(t, repo.restores, 2)
+	assert.Equal(t, "never", repo.restores[0].Overwrite)
+	assert.Equal(t, "if-changed", repo.restores[1].Overwrite)
+	assert.Equal(t, []string{"/old.txt"}, repo.restores[1].Excludes)
+}
+
+func TestCancel(t *testing.T) {
+	repo := &fakeRepo{nodes: []restic.Node{{Type: "file", Path: "/a"}}, block: make(chan struct{}), started: make(chan struct{})}
+	m, requests := newManager(t, repo, approved("req"))
+
+	job, err := m.Submit(Spec{RequestID: "req", Target: t.TempDir()})
+	require.NoError(t, err)
+	done := make(chan *Job)
+	go func() {
+		j, _ := m.Run(context.Background(), job.ID)
+		done <- j
+	}()
+	<-repo.started
+
+	_, err = m.Cancel(job.ID)
+	require.NoError(t, err)
+	job = <-done
+	assert.Equal(t, StatusCancelled, job.Status)
+	assert.NotNil(t, job.FinishedAt)
+	assert.Equal(t, []string{consent.ProgressRunning, consent.ProgressFailed}, requests.statuses())
+
+	_, err = m.Cancel(job.ID)
+	assert.ErrorIs(t, err, ErrJobFinished)
+
+	// A finished job no longer blocks a new one for the request
+	_, err = m.Submit(Spec{RequestID: "req", Target: "/restore"})
+	assert.NoError(t, err)
+}
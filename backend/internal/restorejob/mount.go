@@ -0,0 +1,60 @@
+package restorejob
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+// Mounter is the repository a mount serves snapshots from
+type Mounter interface {
+	SnapshotList(ctx context.Context) ([]restic.Snapshot, error)
+	Mount(ctx context.Context, mountpoint string, snap restic.Snapshot) error
+}
+
+// CheckMount reports whether req can be mounted now. Besides what Check
+// requires, the request must approve a whole snapshot, since a mount can't
+// be limited to some paths, and must not have expired.
+func CheckMount(req *consent.RestoreRequest, now time.Time) error {
+	if err := Check(req, now); err != nil {
+		return err
+	}
+	if len(req.Paths) > 0 {
+		return apperrors.Newf(apperrors.CodeWrongRequestPurpose, "request %s approves only some paths, which a mount can't keep to (use 'airgapper restore')", req.ID)
+	}
+	if now.After(req.ExpiresAt) {
+		return apperrors.ErrRequestExpired
+	}
+	return nil
+}
+
+// Mount serves the snapshot req approved read-only at mountpoint until ctx
+// is done, once CheckMount allows it
+func Mount(ctx context.Context, repo Mounter, req *consent.RestoreRequest, mountpoint string, now time.Time) error {
+	if err := CheckMount(req, now); err != nil {
+		return err
+	}
+	sel, err := restic.ParseSnapshotSelector(req.SnapshotID)
+	if err != nil {
+		return err
+	}
+	snapshots, err := repo.SnapshotList(ctx)
+	if err != nil {
+		return err
+	}
+	snap, err := sel.Resolve(snapshots)
+	if err != nil {
+		return err
+	}
+
+	logging.Info("Mounting snapshot",
+		logging.String("request", req.ID),
+		logging.String("snapshot", snap.ID),
+		logging.String("path", filepath.Join(mountpoint, "ids", snap.ID)))
+	return repo.Mount(ctx, mountpoint, snap)
+}
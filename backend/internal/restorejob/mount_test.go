@@ -0,0 +1,65 @@
+package restorejob
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+	"github.com/lcrostarosa/airgapper/backend/internal/testutil"
+)
+
+func TestMount(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	repo := testutil.NewFakeRestic()
+	repo.Snapshots = []restic.Snapshot{
+		{ID: "aaaa1111", Time: now.Add(-2 * time.Hour), Paths: []string{"/home"}},
+		{ID: "bbbb2222", Time: now.Add(-time.Hour), Paths: []string{"/home"}},
+	}
+	mountable := func(id string) *consent.RestoreRequest {
+		req := approved(id)
+		req.ExpiresAt = now.Add(time.Hour)
+		return req
+	}
+
+	pending := mountable("pending")
+	pending.Status = consent.StatusPending
+	browse := mountable("browse")
+	browse.Purpose = consent.PurposeBrowse
+	partial := mountable("partial")
+	partial.Paths = []string{"/home/alice/taxes"}
+	expired := mountable("expired")
+	expired.ExpiresAt = now.Add(-time.Minute)
+	cooling := mountable("cooling")
+	executableAt := now.Add(time.Hour)
+	cooling.ExecutableAt = &executableAt
+
+	for _, tc := range []struct {
+		req  *consent.RestoreRequest
+		code apperrors.Code
+	}{
+		{pending, apperrors.CodeRequestNotApproved},
+		{browse, apperrors.CodeWrongRequestPurpose},
+		{partial, apperrors.CodeWrongRequestPurpose},
+		{expired, apperrors.CodeRequestExpired},
+		{cooling, apperrors.CodeOf(cooling.CheckExecutable(now))},
+	} {
+		err := Mount(ctx, repo, tc.req, "/mnt", now)
+		require.Error(t, err, tc.req.ID)
+		assert.Equal(t, tc.code, apperrors.CodeOf(err), tc.req.ID)
+	}
+	assert.Empty(t, repo.Calls, "nothing is mounted without an approval")
+
+	// The approved snapshot is resolved once and mounted by its ID
+	require.NoError(t, Mount(ctx, repo, mountable("latest"), "/mnt", now))
+	pinned := mountable("pinned")
+	pinned.SnapshotID = "aaaa"
+	require.NoError(t, Mount(ctx, repo, pinned, "/mnt", now))
+	assert.Equal(t, []string{"snapshots", "mount /mnt bbbb2222", "snapshots", "mount /mnt aaaa1111"}, repo.Calls)
+}
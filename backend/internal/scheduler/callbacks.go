@@ -13,12 +13,20 @@ type SchedulerCallbacks struct {
 	// willRetry indicates if another attempt will be made
 	OnBackupFailure func(result *BackupResult)
 
+	// OnBackupInterrupted is called when a shutdown interrupts a backup
+	// attempt, which is run again on the next start
+	OnBackupInterrupted func(result *BackupResult)
+
 	// OnRetryExhausted is called when all retry attempts have failed
 	// results contains all failed attempts
 	OnRetryExhausted func(results []*BackupResult)
 
 	// OnScheduleChange is called when the schedule is updated via hot-reload
 	OnScheduleChange func(old, new *Schedule)
+
+	// OnFailureThreshold is called when consecutive failed runs reach the
+	// failure threshold. results contains the attempts of the latest run.
+	OnFailureThreshold func(health Health, results []*BackupResult)
 }
 
 // DefaultCallbacks returns empty callbacks (logging only, no notifications)
@@ -47,6 +55,13 @@ func (c *SchedulerCallbacks) callOnBackupFailure(result *BackupResult) {
 	}
 }
 
+// callOnBackupInterrupted safely calls the OnBackupInterrupted callback if set
+func (c *SchedulerCallbacks) callOnBackupInterrupted(result *BackupResult) {
+	if c != nil && c.OnBackupInterrupted != nil {
+		c.OnBackupInterrupted(result)
+	}
+}
+
 // callOnRetryExhausted safely calls the OnRetryExhausted callback if set
 func (c *SchedulerCallbacks) callOnRetryExhausted(results []*BackupResult) {
 	if c != nil && c.OnRetryExhausted != nil {
@@ -61,6 +76,13 @@ func (c *SchedulerCallbacks) callOnScheduleChange(old, new *Schedule) {
 	}
 }
 
+// callOnFailureThreshold safely calls the OnFailureThreshold callback if set
+func (c *SchedulerCallbacks) callOnFailureThreshold(health Health, results []*BackupResult) {
+	if c != nil && c.OnFailureThreshold != nil {
+		c.OnFailureThreshold(health, results)
+	}
+}
+
 // LoggingCallbacks returns callbacks that log events (for debugging)
 func LoggingCallbacks(logf func(format string, args ...interface{})) *SchedulerCallbacks {
 	return &SchedulerCallbacks{
@@ -83,6 +105,9 @@ func LoggingCallbacks(logf func(format string, args ...interface{})) *SchedulerC
 		OnScheduleChange: func(old, new *Schedule) {
 			logf("Schedule changed from %q to %q", old.Expression, new.Expression)
 		},
+		OnFailureThreshold: func(health Health, results []*BackupResult) {
+			logf("Backup job unhealthy: %d consecutive failed runs", health.ConsecutiveFailures)
+		},
 	}
 }
 
@@ -114,5 +139,10 @@ func ChainCallbacks(callbacks ...*SchedulerCallbacks) *SchedulerCallbacks {
 				c.callOnScheduleChange(old, new)
 			}
 		},
+		OnFailureThreshold: func(health Health, results []*BackupResult) {
+			for _, c := range callbacks {
+				c.callOnFailureThreshold(health, results)
+			}
+		},
 	}
 }
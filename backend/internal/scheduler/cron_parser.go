@@ -165,6 +165,7 @@ func parseFieldPart(part string, min, max int) ([]int, error) {
 // Supports:
 // - Simple: "hourly", "daily", "weekly"
 // - Intervals: "every 4h", "every 30m"
+// - At least every: "at-least 72h", "at-least 3d" (due from the last success)
 // - Cron (simple): "0 2 * * *" (single values only)
 // - Cron (enhanced): "0-30 2 * * *" (ranges, steps, lists)
 func ParseScheduleEnhanced(expr string) (*Schedule, error) {
@@ -217,6 +218,20 @@ func ParseScheduleEnhanced(expr string) (*Schedule, error) {
 		return s, nil
 	}
 
+	// Anacron-style format: "at-least 72h", "at-least 3d"
+	if strings.HasPrefix(expr, "at-least ") {
+		gapStr := strings.TrimSpace(strings.TrimPrefix(expr, "at-least "))
+		dur, err := parseAtLeastDuration(gapStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid at-least duration: %s", gapStr)
+		}
+		if dur < time.Hour {
+			return nil, fmt.Errorf("at-least duration must be at least 1 hour")
+		}
+		s.atLeast = dur
+		return s, nil
+	}
+
 	// Cron format: "minute hour dom month dow"
 	parts := strings.Fields(expr)
 	if len(parts) == 5 {
@@ -305,6 +320,18 @@ func parseIntervalDuration(s string) (time.Duration, error) {
 	return 0, fmt.Errorf("cannot parse duration: %s", s)
 }
 
+// parseAtLeastDuration accepts interval durations plus whole days ("3d")
+func parseAtLeastDuration(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil || days < 1 {
+			return 0, fmt.Errorf("cannot parse duration: %s", s)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return parseIntervalDuration(s)
+}
+
 // parseDuration is a simple duration parser (re-implementation to avoid import cycle)
 func parseDuration(s string) (time.Duration, error) {
 	return time.ParseDuration(s)
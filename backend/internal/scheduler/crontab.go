@@ -0,0 +1,299 @@
+package scheduler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CronJob is a restic backup found in a crontab
+type CronJob struct {
+	// Line is the crontab line number, from 1
+	Line     int
+	Schedule string
+	// Timezone is the job's CRON_TZ, empty for the system's timezone
+	Timezone string
+	Paths    []string
+	Exclude  []string
+	Tags     []string
+}
+
+// CrontabImport is what ParseCrontab found
+type CrontabImport struct {
+	Jobs []CronJob
+	// Retention is the keep policy of the first restic forget, if any
+	Retention *RetentionConfig
+	// Warnings describe restic commands or options that can't be imported
+	Warnings []string
+}
+
+// cronMacros maps crontab shorthands to the equivalent expressions
+var cronMacros = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+}
+
+// ParseCrontab finds the restic backup and forget commands in crontab
+// output (e.g. from crontab -l), converting each backup into a job with
+// its schedule, paths, excludes and tags
+func ParseCrontab(r io.Reader) (*CrontabImport, error) {
+	result := &CrontabImport{}
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	timezone := ""
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if tz, ok := strings.CutPrefix(line, "CRON_TZ="); ok {
+			// Applies to the jobs below it
+			timezone = strings.Trim(strings.TrimSpace(tz), `"'`)
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") || !strings.Contains(line, "restic") {
+			continue
+		}
+
+		schedule, command, err := splitCronLine(line)
+		if err != nil {
+			// Environment assignments and other non-job lines
+			continue
+		}
+		for _, args := range resticCommands(splitShell(command)) {
+			switch args[0] {
+			case "backup":
+				if schedule == "@reboot" {
+					result.Warnings = append(result.Warnings, fmt.Sprintf("line %d: @reboot has no schedule equivalent", lineNo))
+					continue
+				}
+				if _, err := ParseScheduleIn(schedule, timezone); err != nil {
+					result.Warnings = append(result.Warnings, fmt.Sprintf("line %d: %v", lineNo, err))
+					continue
+				}
+				job, warnings := parseResticBackup(args[1:])
+				job.Line, job.Schedule, job.Timezone = lineNo, schedule, timezone
+				for _, w := range warnings {
+					result.Warnings = append(result.Warnings, fmt.Sprintf("line %d: %s", lineNo, w))
+				}
+				result.Jobs = append(result.Jobs, job)
+			case "forget":
+				if result.Retention == nil {
+					if keep := parseResticForget(args[1:]); !keep.IsZero() {
+						result.Retention = keep
+					}
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read crontab: %w", err)
+	}
+	return result, nil
+}
+
+// splitCronLine splits a crontab line into its schedule and command
+func splitCronLine(line string) (schedule, command string, err error) {
+	if strings.HasPrefix(line, "@") {
+		macro, rest, _ := strings.Cut(line, " ")
+		if macro == "@reboot" {
+			return macro, strings.TrimSpace(rest), nil
+		}
+		expr, ok := cronMacros[macro]
+		if !ok {
+			return "", "", fmt.Errorf("unknown macro %s", macro)
+		}
+		return expr, strings.TrimSpace(rest), nil
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) < 6 {
+		return "", "", fmt.Errorf("not a cron job")
+	}
+	// The command is everything after the fifth field, spacing intact
+	rest := line
+	for range 5 {
+		rest = strings.TrimLeft(rest, " \t")
+		rest = rest[strings.IndexAny(rest, " \t"):]
+	}
+	return strings.Join(fields[:5], " "), strings.TrimSpace(rest), nil
+}
+
+// resticCommands returns the arguments of each restic command in a shell
+// command line, starting with the restic subcommand. Global options such
+// as -r are skipped.
+func resticCommands(words []string) [][]string {
+	var commands [][]string
+	for i := 0; i < len(words); i++ {
+		if words[i] != "restic" && !strings.HasSuffix(words[i], "/restic") {
+			continue
+		}
+		var args []string
+		for i++; i < len(words) && !isShellOperator(words[i]); i++ {
+			args = append(args, words[i])
+		}
+		if args = skipGlobalOptions(args); len(args) > 0 {
+			commands = append(commands, args)
+		}
+		i--
+	}
+	return commands
+}
+
+// globalOptionsWithValue are restic options before the subcommand that
+// take a value
+var globalOptionsWithValue = map[string]bool{
+	"-r": true, "--repo": true, "--password-file": true, "-p": true,
+	"--repository-file": true, "--password-command": true, "--cache-dir": true,
+	"--limit-upload": true, "--limit-download": true, "-o": true, "--option": true,
+}
+
+// skipGlobalOptions drops the options before the subcommand
+func skipGlobalOptions(args []string) []string {
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		if globalOptionsWithValue[args[0]] && len(args) > 1 {
+			args = args[1:]
+		}
+		args = args[1:]
+	}
+	return args
+}
+
+// parseResticBackup reads the paths and options of restic backup
+func parseResticBackup(args []string) (job CronJob, warnings []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !strings.HasPrefix(arg, "-") {
+			job.Paths = append(job.Paths, arg)
+			continue
+		}
+		takesValue := name == "--exclude" || name == "-e" || name == "--tag" || name == "--exclude-file" ||
+			name == "--iexclude" || name == "--files-from" || name == "--host" || name == "--exclude-larger-than"
+		if takesValue && !hasValue && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		switch name {
+		case "--exclude", "-e":
+			job.Exclude = append(job.Exclude, value)
+		case "--tag":
+			for _, tag := range strings.Split(value, ",") {
+				if tag != "" {
+					job.Tags = append(job.Tags, tag)
+				}
+			}
+		default:
+			warnings = append(warnings, "restic backup option "+name+" is not imported")
+		}
+	}
+	return job, warnings
+}
+
+// parseResticForget reads the keep policy of restic forget
+func parseResticForget(args []string) *RetentionConfig {
+	keep := &RetentionConfig{}
+	fields := map[string]*int{
+		"--keep-last":    &keep.KeepLast,
+		"-l":             &keep.KeepLast,
+		"--keep-hourly":  &keep.KeepHourly,
+		"-H":             &keep.KeepHourly,
+		"--keep-daily":   &keep.KeepDaily,
+		"-d":             &keep.KeepDaily,
+		"--keep-weekly":  &keep.KeepWeekly,
+		"-w":             &keep.KeepWeekly,
+		"--keep-monthly": &keep.KeepMonthly,
+		"-m":             &keep.KeepMonthly,
+		"--keep-yearly":  &keep.KeepYearly,
+		"-y":             &keep.KeepYearly,
+	}
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		dst, ok := fields[name]
+		if !ok {
+			continue
+		}
+		if !hasValue && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			*dst = n
+		}
+	}
+	return keep
+}
+
+// isShellOperator reports whether a word from splitShell ends a command
+func isShellOperator(word string) bool {
+	op := strings.TrimLeft(word, "0123456789")
+	return op != "" && strings.ContainsRune(";&|<>", rune(op[0]))
+}
+
+func isDigits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// splitShell splits a command line into words, honouring quotes and
+// backslash escapes, with unquoted ; & | < > as separate words
+func splitShell(s string) []string {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	flush := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' && i+1 < len(runes) {
+				i++
+				word.WriteRune(runes[i])
+			} else {
+				word.WriteRune(c)
+			}
+		case c == '\'' || c == '"':
+			quote, inWord = c, true
+		case c == '\\' && i+1 < len(runes):
+			i++
+			word.WriteRune(runes[i])
+			inWord = true
+		case c == ' ' || c == '\t':
+			flush()
+		case strings.ContainsRune(";&|<>", c):
+			op := string(c)
+			if (c == '<' || c == '>') && inWord && isDigits(word.String()) {
+				// A file descriptor, as in 2>&1
+				op = word.String() + op
+				word.Reset()
+				inWord = false
+			}
+			flush()
+			for i+1 < len(runes) && strings.ContainsRune("&|>", runes[i+1]) {
+				i++
+				op += string(runes[i])
+			}
+			words = append(words, op)
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+	flush()
+	return words
+}
@@ -0,0 +1,69 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCrontab(t *testing.T) {
+	crontab := `# m h dom mon dow command
+MAILTO=alice@example.com
+RESTIC_PASSWORD_FILE=/home/alice/.restic-pass
+
+30 3 * * * RESTIC_REPOSITORY=sftp:nas:/backup /usr/bin/restic -r sftp:nas:/backup backup --exclude-caches -e '*.iso' --exclude="/home/alice/VMs" --tag=home,nightly /home/alice "/srv/shared docs" >> /var/log/restic.log 2>&1 && restic forget --keep-daily 7 --keep-weekly=4 --prune
+@weekly restic backup /etc
+@reboot restic backup /var
+0 */6 * * * rsync -a /home /mnt
+`
+	imported, err := ParseCrontab(strings.NewReader(crontab))
+	require.NoError(t, err)
+	require.Len(t, imported.Jobs, 2)
+
+	job := imported.Jobs[0]
+	assert.Equal(t, 5, job.Line)
+	assert.Equal(t, "30 3 * * *", job.Schedule)
+	assert.Equal(t, []string{"/home/alice", "/srv/shared docs"}, job.Paths)
+	assert.Equal(t, []string{"*.iso", "/home/alice/VMs"}, job.Exclude)
+	assert.Equal(t, []string{"home", "nightly"}, job.Tags)
+
+	assert.Equal(t, "0 0 * * 0", imported.Jobs[1].Schedule)
+	assert.Equal(t, []string{"/etc"}, imported.Jobs[1].Paths)
+
+	require.NotNil(t, imported.Retention)
+	assert.Equal(t, RetentionConfig{KeepDaily: 7, KeepWeekly: 4}, *imported.Retention)
+
+	assert.Contains(t, imported.Warnings, "line 5: restic backup option --exclude-caches is not imported")
+	assert.Contains(t, imported.Warnings, "line 7: @reboot has no schedule equivalent")
+}
+
+func TestParseCrontab_Timezone(t *testing.T) {
+	crontab := `0 2 * * * restic backup /home
+CRON_TZ=Europe/Berlin
+0 3 * * * restic backup /etc
+CRON_TZ=Nowhere/Atlantis
+0 4 * * * restic backup /var
+`
+	imported, err := ParseCrontab(strings.NewReader(crontab))
+	require.NoError(t, err)
+	require.Len(t, imported.Jobs, 2)
+	assert.Empty(t, imported.Jobs[0].Timezone)
+	assert.Equal(t, "Europe/Berlin", imported.Jobs[1].Timezone)
+	require.Len(t, imported.Warnings, 1)
+	assert.Contains(t, imported.Warnings[0], "line 5: unknown timezone")
+}
+
+func TestParseCrontab_NoResticJobs(t *testing.T) {
+	imported, err := ParseCrontab(strings.NewReader("0 2 * * * rsync -a /home /mnt\n"))
+	require.NoError(t, err)
+	assert.Empty(t, imported.Jobs)
+	assert.Nil(t, imported.Retention)
+}
+
+func TestSplitShell(t *testing.T) {
+	assert.Equal(t,
+		[]string{"restic", "backup", "a b", `c"d`, "e f", ";", "echo", "2>&", "1"},
+		splitShell(`restic backup 'a b' "c\"d" e\ f; echo 2>&1`))
+}
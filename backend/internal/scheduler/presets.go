@@ -0,0 +1,109 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RetentionConfig is how many snapshots of each period scheduled backups
+// keep, as restic forget's --keep-* options. Zero fields keep nothing by
+// that rule; a zero config keeps everything.
+type RetentionConfig struct {
+	KeepLast    int `json:"keep_last,omitempty"`
+	KeepHourly  int `json:"keep_hourly,omitempty"`
+	KeepDaily   int `json:"keep_daily,omitempty"`
+	KeepWeekly  int `json:"keep_weekly,omitempty"`
+	KeepMonthly int `json:"keep_monthly,omitempty"`
+	KeepYearly  int `json:"keep_yearly,omitempty"`
+}
+
+// IsZero reports whether the config keeps everything (nil-safe)
+func (r *RetentionConfig) IsZero() bool {
+	return r == nil || *r == RetentionConfig{}
+}
+
+// String describes the config like restic's flags, e.g. "24 hourly, 30 daily"
+func (r *RetentionConfig) String() string {
+	if r.IsZero() {
+		return "keep everything"
+	}
+	var parts []string
+	for _, keep := range []struct {
+		period string
+		n      int
+	}{
+		{"last", r.KeepLast},
+		{"hourly", r.KeepHourly},
+		{"daily", r.KeepDaily},
+		{"weekly", r.KeepWeekly},
+		{"monthly", r.KeepMonthly},
+		{"yearly", r.KeepYearly},
+	} {
+		if keep.n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", keep.n, keep.period))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Preset is a ready-made schedule, exclude list and retention for a
+// common kind of machine
+type Preset struct {
+	Name        string
+	Description string
+	Schedule    string
+	Exclude     []string
+	Retention   RetentionConfig
+}
+
+// GitExcludes leave version control metadata out of backups; presets only
+// add them on request, since a repository's history may be the only copy
+var GitExcludes = []string{".git"}
+
+// cacheExcludes are caches and build output every preset leaves out
+var cacheExcludes = []string{
+	".cache",
+	"Library/Caches",
+	"AppData/Local/Temp",
+	"node_modules",
+	".Trash",
+	"*.tmp",
+	"*.swp",
+}
+
+// Presets are the built-in presets, in the order they are listed
+var Presets = []Preset{
+	{
+		Name:        "laptop",
+		Description: "Hourly while online, Time Machine style: 24 hourly, 30 daily and 52 weekly snapshots",
+		Schedule:    "hourly",
+		Exclude:     cacheExcludes,
+		Retention:   RetentionConfig{KeepHourly: 24, KeepDaily: 30, KeepWeekly: 52},
+	},
+	{
+		Name:        "server",
+		Description: "Nightly at 2 AM: 7 daily, 4 weekly and 12 monthly snapshots",
+		Schedule:    "0 2 * * *",
+		Exclude:     append([]string{"/tmp", "/var/tmp", "/var/cache", "/proc", "/sys", "/dev", "/run"}, cacheExcludes...),
+		Retention:   RetentionConfig{KeepDaily: 7, KeepWeekly: 4, KeepMonthly: 12},
+	},
+	{
+		Name:        "photos",
+		Description: "Daily for libraries that mostly grow: 14 daily, 12 monthly and 10 yearly snapshots",
+		Schedule:    "daily",
+		Exclude:     append([]string{".thumbnails", "Thumbs.db", ".DS_Store", "*.photoslibrary/resources/derivatives"}, cacheExcludes...),
+		Retention:   RetentionConfig{KeepDaily: 14, KeepMonthly: 12, KeepYearly: 10},
+	},
+}
+
+// FindPreset returns the named preset
+func FindPreset(name string) (Preset, error) {
+	var names []string
+	for _, p := range Presets {
+		if strings.EqualFold(p.Name, name) {
+			return p, nil
+		}
+		names = append(names, p.Name)
+	}
+	return Preset{}, fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(names, ", "))
+}
@@ -0,0 +1,34 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresets(t *testing.T) {
+	for _, p := range Presets {
+		t.Run(p.Name, func(t *testing.T) {
+			_, err := ParseSchedule(p.Schedule)
+			require.NoError(t, err)
+			assert.False(t, p.Retention.IsZero())
+			assert.Contains(t, p.Exclude, "node_modules")
+			assert.NotContains(t, p.Exclude, ".git", "git directories are only excluded on request")
+		})
+	}
+
+	p, err := FindPreset("Laptop")
+	require.NoError(t, err)
+	assert.Equal(t, "laptop", p.Name)
+	assert.Equal(t, "24 hourly, 30 daily, 52 weekly", p.Retention.String())
+
+	_, err = FindPreset("desktop")
+	assert.ErrorContains(t, err, "laptop, server, photos")
+}
+
+func TestRetentionConfigString(t *testing.T) {
+	var r *RetentionConfig
+	assert.Equal(t, "keep everything", r.String())
+	assert.Equal(t, "3 last, 12 monthly", (&RetentionConfig{KeepLast: 3, KeepMonthly: 12}).String())
+}
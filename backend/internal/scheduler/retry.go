@@ -1,6 +1,8 @@
 package scheduler
 
 import (
+	"context"
+	"fmt"
 	"math"
 	"time"
 )
@@ -73,6 +75,11 @@ type BackupResult struct {
 	Attempt int
 	// WillRetry indicates if another retry will be attempted
 	WillRetry bool
+	// CatchUp indicates the run made up for a slot missed while asleep, or
+	// for a backup interrupted by a shutdown
+	CatchUp bool
+	// Interrupted indicates a shutdown stopped the backup before it finished
+	Interrupted bool
 }
 
 // Duration returns how long the backup took
@@ -84,3 +91,116 @@ func (r *BackupResult) Duration() time.Duration {
 func (r *BackupResult) IsRetry() bool {
 	return r.Attempt > 1
 }
+
+// DefaultFailureThreshold is how many scheduled runs in a row may fail
+// before the backup job is reported unhealthy
+const DefaultFailureThreshold = 3
+
+// RetryConfig is the persisted form of a job's retry settings, with
+// durations written as strings (e.g. "5m", "1h")
+type RetryConfig struct {
+	// MaxAttempts includes the first attempt (1 = never retry)
+	MaxAttempts int    `json:"max_attempts,omitempty"`
+	BackoffBase string `json:"backoff_base,omitempty"`
+	BackoffCap  string `json:"backoff_cap,omitempty"`
+	// FailureThreshold is how many failed runs in a row mark the job
+	// unhealthy and send a notification (0 = DefaultFailureThreshold)
+	FailureThreshold int `json:"failure_threshold,omitempty"`
+}
+
+// Parse converts the config into a RetryStrategy. Unset fields fall back
+// to DefaultRetryStrategy; a nil config means no retries.
+func (c *RetryConfig) Parse() (*RetryStrategy, error) {
+	if c == nil {
+		return NoRetry(), nil
+	}
+	if c.MaxAttempts < 0 {
+		return nil, fmt.Errorf("max attempts cannot be negative")
+	}
+	if c.FailureThreshold < 0 {
+		return nil, fmt.Errorf("failure threshold cannot be negative")
+	}
+
+	// ShouldRetry stops once attempt reaches MaxRetries, so MaxRetries
+	// already counts the first attempt
+	r := DefaultRetryStrategy()
+	if c.MaxAttempts > 0 {
+		r.MaxRetries = c.MaxAttempts
+	}
+
+	fields := []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"backoff base", c.BackoffBase, &r.InitialDelay},
+		{"backoff cap", c.BackoffCap, &r.MaxDelay},
+	}
+	for _, f := range fields {
+		if f.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(f.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", f.name, f.value, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("%s must be positive", f.name)
+		}
+		*f.dst = d
+	}
+	if r.MaxDelay < r.InitialDelay {
+		return nil, fmt.Errorf("backoff cap (%s) is shorter than backoff base (%s)", r.MaxDelay, r.InitialDelay)
+	}
+	return r, nil
+}
+
+// Threshold returns the configured failure threshold or the default
+func (c *RetryConfig) Threshold() int {
+	if c == nil || c.FailureThreshold <= 0 {
+		return DefaultFailureThreshold
+	}
+	return c.FailureThreshold
+}
+
+// FormatRetry converts a RetryStrategy and threshold into the persisted form
+func FormatRetry(r *RetryStrategy, threshold int) RetryConfig {
+	if r == nil {
+		r = NoRetry()
+	}
+	attempts := r.MaxRetries
+	if attempts < 1 {
+		attempts = 1
+	}
+	return RetryConfig{
+		MaxAttempts:      attempts,
+		BackoffBase:      r.InitialDelay.String(),
+		BackoffCap:       r.MaxDelay.String(),
+		FailureThreshold: threshold,
+	}
+}
+
+// Do runs fn until it succeeds or the strategy gives up, waiting the
+// backoff delay between attempts. onFailure (optional) is told about each
+// failed attempt and whether another will follow.
+func (r *RetryStrategy) Do(ctx context.Context, fn func(attempt int) error, onFailure func(attempt int, err error, willRetry bool)) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(attempt)
+		if err == nil {
+			return nil
+		}
+		willRetry := r.ShouldRetry(attempt)
+		if onFailure != nil {
+			onFailure(attempt, err, willRetry)
+		}
+		if !willRetry {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(r.NextDelay(attempt)):
+		}
+	}
+}
@@ -1,10 +1,13 @@
 package scheduler
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDefaultRetryStrategy(t *testing.T) {
@@ -101,3 +104,48 @@ func TestBackupResult_IsRetry(t *testing.T) {
 	r2 := &BackupResult{Attempt: 2}
 	assert.True(t, r2.IsRetry(), "Attempt 2 should be a retry")
 }
+
+func TestRetryConfigParse(t *testing.T) {
+	r, err := (*RetryConfig)(nil).Parse()
+	require.NoError(t, err)
+	assert.False(t, r.ShouldRetry(1), "nil config should not retry")
+
+	r, err = (&RetryConfig{MaxAttempts: 5, BackoffBase: "30s", BackoffCap: "10m"}).Parse()
+	require.NoError(t, err)
+	assert.True(t, r.ShouldRetry(4))
+	assert.False(t, r.ShouldRetry(5), "five attempts in total")
+	assert.Equal(t, 30*time.Second, r.InitialDelay)
+	assert.Equal(t, 10*time.Minute, r.MaxDelay)
+	assert.Equal(t, 2.0, r.BackoffFactor)
+
+	_, err = (&RetryConfig{BackoffBase: "1h", BackoffCap: "5m"}).Parse()
+	assert.Error(t, err, "cap below base")
+	_, err = (&RetryConfig{BackoffBase: "soon"}).Parse()
+	assert.Error(t, err)
+	_, err = (&RetryConfig{MaxAttempts: -1}).Parse()
+	assert.Error(t, err)
+
+	assert.Equal(t, DefaultFailureThreshold, (*RetryConfig)(nil).Threshold())
+	assert.Equal(t, 5, (&RetryConfig{FailureThreshold: 5}).Threshold())
+}
+
+func TestRetryStrategy_Do(t *testing.T) {
+	r := &RetryStrategy{MaxRetries: 3, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, BackoffFactor: 2}
+
+	calls := 0
+	err := r.Do(context.Background(), func(attempt int) error {
+		calls++
+		if attempt < 2 {
+			return errors.New("transient")
+		}
+		return nil
+	}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	var retried []bool
+	err = r.Do(context.Background(), func(int) error { return errors.New("down") },
+		func(_ int, _ error, willRetry bool) { retried = append(retried, willRetry) })
+	assert.Error(t, err)
+	assert.Equal(t, []bool{true, true, false}, retried)
+}
@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -10,6 +11,10 @@ type Schedule struct {
 	// Expression is the original schedule expression (cron or simple)
 	Expression string
 
+	// location is the timezone cron fields are wall-clock times in (nil
+	// for the system's, taken from the times passed in)
+	location *time.Location
+
 	// Parsed cron fields (-1 means "any")
 	minute int // 0-59, -1 for any
 	hour   int // 0-23, -1 for any
@@ -20,6 +25,10 @@ type Schedule struct {
 	// For interval-based schedules
 	interval time.Duration
 
+	// For "at-least" schedules: the longest allowed gap between successful
+	// backups, measured from the last success rather than wall-clock slots
+	atLeast time.Duration
+
 	// Enhanced cron fields for ranges/steps/lists support
 	minuteField *CronField
 	hourField   *CronField
@@ -33,9 +42,14 @@ func (s *Schedule) IsInterval() bool {
 	return s.interval > 0
 }
 
+// IsAtLeast returns true if this is an anacron-style "at-least" schedule
+func (s *Schedule) IsAtLeast() bool {
+	return s.atLeast > 0
+}
+
 // IsCron returns true if this is a cron-based schedule
 func (s *Schedule) IsCron() bool {
-	return !s.IsInterval()
+	return !s.IsInterval() && !s.IsAtLeast()
 }
 
 // Interval returns the interval duration (0 if not interval-based)
@@ -43,11 +57,44 @@ func (s *Schedule) Interval() time.Duration {
 	return s.interval
 }
 
+// AtLeast returns the maximum gap between successful backups (0 if not an
+// "at-least" schedule)
+func (s *Schedule) AtLeast() time.Duration {
+	return s.atLeast
+}
+
 // String returns the schedule expression
 func (s *Schedule) String() string {
 	return s.Expression
 }
 
+// Location returns the timezone the schedule's times are in
+func (s *Schedule) Location() *time.Location {
+	if s.location == nil {
+		return time.Local
+	}
+	return s.location
+}
+
+// Timezone returns the name of the schedule's timezone, "Local" for the
+// system's
+func (s *Schedule) Timezone() string {
+	return s.Location().String()
+}
+
+// LoadTimezone returns the timezone named tz, an IANA name such as
+// "Europe/Berlin". Empty or "Local" is the system's timezone.
+func LoadTimezone(tz string) (*time.Location, error) {
+	if tz == "" || tz == "Local" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q (use an IANA name such as Europe/Berlin)", tz)
+	}
+	return loc, nil
+}
+
 // matches checks if a given time matches this schedule
 func (s *Schedule) matches(t time.Time) bool {
 	// Use enhanced fields if available
@@ -94,21 +141,136 @@ func (s *Schedule) matchesEnhanced(t time.Time) bool {
 	return true
 }
 
+// NextDue returns when the next backup is due given the current time and
+// the last successful backup (zero if none). "At-least" schedules are due
+// the gap after the last success, or right away if that has passed; other
+// schedules ignore lastSuccess and use NextRun.
+func (s *Schedule) NextDue(now, lastSuccess time.Time) time.Time {
+	if !s.IsAtLeast() {
+		return s.NextRun(now)
+	}
+	if lastSuccess.IsZero() {
+		return now
+	}
+	due := lastSuccess.Add(s.atLeast)
+	if due.Before(now) {
+		return now
+	}
+	return due
+}
+
 // NextRun calculates the next run time after 'after'.
 // Uses efficient field-jumping for cron schedules instead of minute-by-minute search.
+// For "at-least" schedules 'after' is taken as the last successful backup.
 func (s *Schedule) NextRun(after time.Time) time.Time {
 	// Interval-based: simple addition
 	if s.interval > 0 {
 		return after.Add(s.interval)
 	}
+	if s.atLeast > 0 {
+		return after.Add(s.atLeast)
+	}
 
-	// Use efficient algorithm if enhanced fields are available
-	if s.minuteField != nil {
-		return s.nextRunEfficient(after)
+	return s.nextCronRun(after)
+}
+
+// maxWallSkips bounds how many wall-clock matches nextCronRun looks at
+const maxWallSkips = 24 * 60
+
+// maxDSTShift is the most a clock is moved at once for DST
+const maxDSTShift = 2 * time.Hour
+
+// nextCronRun returns the first run of a cron schedule after 'after'. The
+// fields are matched against wall-clock times in the schedule's timezone,
+// worked out on a calendar without DST and only then turned into instants,
+// so a DST change neither skips nor repeats a run:
+//   - A time the clock jumps over (spring forward) runs when the clock
+//     jumps, if the schedule names its hour; every-hour schedules just
+//     carry on after the jump.
+//   - A time the clock passes twice (fall back) runs the first time only,
+//     if the schedule names its hour; every-hour schedules run on both
+//     passes, as elapsed time goes on.
+func (s *Schedule) nextCronRun(after time.Time) time.Time {
+	loc := s.location
+	if loc == nil {
+		loc = after.Location()
+	}
+	// Once the clock is set back, times before after's wall-clock time
+	// come again, so the search starts that far back
+	wall := wallClock(after.In(loc)).Add(-maxDSTShift)
+	var next time.Time
+	for range maxWallSkips {
+		// Use efficient algorithm if enhanced fields are available, and
+		// fall back to linear search for simple schedules
+		if s.minuteField != nil {
+			wall = s.nextRunEfficient(wall)
+		} else {
+			wall = s.nextRunLinear(wall)
+		}
+		if !s.matches(wall) {
+			break // No match within the search, e.g. "0 0 31 2 *"
+		}
+		if !next.IsZero() && wall.After(wallClock(next.In(loc)).Add(maxDSTShift)) {
+			break // Later wall-clock times can't come sooner
+		}
+		for _, t := range s.wallInstants(wall, loc) {
+			if t.After(after) && (next.IsZero() || t.Before(next)) {
+				next = t
+			}
+		}
+	}
+	if next.IsZero() {
+		// Fallback
+		return after.Add(24 * time.Hour)
+	}
+	return next
+}
+
+// fixedHour reports whether the schedule names the hours it runs at,
+// rather than running every hour
+func (s *Schedule) fixedHour() bool {
+	if s.hourField != nil {
+		return !s.hourField.Any
 	}
+	return s.hour != -1
+}
+
+// wallInstants returns when, in loc, the clock shows wall (a wall-clock
+// time in UTC): once normally, twice or once when the clock is set back,
+// and at the jump or never when it skips wall
+func (s *Schedule) wallInstants(wall time.Time, loc *time.Location) []time.Time {
+	// Offsets in effect a day either side cover any one DST change
+	_, before := wall.Add(-24 * time.Hour).In(loc).Zone()
+	_, later := wall.Add(24 * time.Hour).In(loc).Zone()
+
+	var instants []time.Time
+	for _, offset := range []int{before, later} {
+		t := wall.Add(-time.Duration(offset) * time.Second).In(loc)
+		if wallClock(t).Equal(wall) && (len(instants) == 0 || !instants[0].Equal(t)) {
+			instants = append(instants, t)
+		}
+	}
+
+	switch {
+	case len(instants) == 0 && s.fixedHour():
+		// Skipped: run when the clock jumps past wall
+		jumped, _ := wall.Add(-time.Duration(before) * time.Second).In(loc).ZoneBounds()
+		return []time.Time{jumped}
+	case len(instants) == 2 && s.fixedHour():
+		// Repeated: run on the first pass only
+		if instants[1].Before(instants[0]) {
+			instants[0] = instants[1]
+		}
+		return instants[:1]
+	case len(instants) == 2 && instants[1].Before(instants[0]):
+		instants[0], instants[1] = instants[1], instants[0]
+	}
+	return instants
+}
 
-	// Fall back to linear search for simple schedules
-	return s.nextRunLinear(after)
+// wallClock returns t's wall-clock time to the minute, as a UTC time
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
 }
 
 // nextRunLinear is the original O(minutes) algorithm
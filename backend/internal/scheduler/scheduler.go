@@ -2,6 +2,8 @@
 package scheduler
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -13,6 +15,7 @@ import (
 // Supports:
 // - Simple: "hourly", "daily", "weekly"
 // - Intervals: "every 4h", "every 30m"
+// - At least every: "at-least 72h" (due from the last successful backup)
 // - Cron (simple): "0 2 * * *" (single values only)
 // - Cron (enhanced): "0-30 2 * * *" (ranges, steps, lists)
 //
@@ -21,28 +24,71 @@ func ParseSchedule(expr string) (*Schedule, error) {
 	return ParseScheduleEnhanced(expr)
 }
 
+// ParseScheduleIn parses a schedule expression whose times are wall-clock
+// times in timezone tz (see LoadTimezone). Intervals and "at-least"
+// schedules measure elapsed time, so tz doesn't change them.
+func ParseScheduleIn(expr, tz string) (*Schedule, error) {
+	loc, err := LoadTimezone(tz)
+	if err != nil {
+		return nil, err
+	}
+	s, err := ParseScheduleEnhanced(expr)
+	if err != nil {
+		return nil, err
+	}
+	s.location = loc
+	return s, nil
+}
+
 // SchedulerConfig holds configuration for creating a new scheduler
 type SchedulerConfig struct {
 	// Schedule is the backup schedule
 	Schedule *Schedule
-	// BackupFunc is the function to call when running backups
-	BackupFunc func() error
+	// BackupFunc is the function to call when running backups. Its context
+	// is cancelled when a shutdown interrupts the backup (see Drain).
+	BackupFunc func(ctx context.Context) error
 	// Retry configures retry behavior (nil = no retries, backward compatible)
 	Retry *RetryStrategy
 	// Callbacks hooks for backup lifecycle events (nil = logging only)
 	Callbacks *SchedulerCallbacks
+	// Timing configures catch-up, jitter and clock jump handling
+	Timing Timing
+	// FailureThreshold is how many failed runs in a row mark the job
+	// unhealthy (0 = DefaultFailureThreshold)
+	FailureThreshold int
+	// StatePath persists the outcome of each run (empty = in memory only)
+	StatePath string
+	// Paths are recorded in the job state so `backup --retry-last` can
+	// re-run a failed scheduled backup
+	Paths []string
 }
 
 // Scheduler runs scheduled backups
 type Scheduler struct {
 	schedule   *Schedule
-	backupFunc func() error
+	backupFunc func(ctx context.Context) error
 	retry      *RetryStrategy
 	callbacks  *SchedulerCallbacks
-	stop       chan struct{}
-	wg         sync.WaitGroup
-	mu         sync.Mutex
-	running    bool
+	timing     Timing
+	threshold  int
+	statePath  string
+	paths      []string
+	failures   int
+	// lastGood is the last successful backup, including manual backups
+	// recorded in the job state; it drives "at-least" schedules
+	lastGood  time.Time
+	stop      chan struct{}
+	wg        sync.WaitGroup
+	mu        sync.Mutex
+	running   bool
+	backingUp bool
+	// runCtx is cancelled to interrupt the backup in progress on shutdown
+	runCtx       context.Context
+	cancelRun    context.CancelFunc
+	interrupting bool
+	// resume runs a backup as soon as the scheduler starts, because the
+	// last one was interrupted by a shutdown
+	resume     bool
 	lastRun    time.Time
 	lastError  error
 	history    []*BackupResult
@@ -52,24 +98,49 @@ type Scheduler struct {
 // NewScheduler creates a new scheduler.
 // This is the backward-compatible constructor.
 func NewScheduler(schedule *Schedule, backupFunc func() error) *Scheduler {
-	return &Scheduler{
+	s := &Scheduler{
 		schedule:   schedule,
-		backupFunc: backupFunc,
+		backupFunc: func(context.Context) error { return backupFunc() },
+		timing:     DefaultTiming(),
+		threshold:  DefaultFailureThreshold,
 		stop:       make(chan struct{}),
 		historyMax: 100,
 	}
+	s.runCtx, s.cancelRun = context.WithCancel(context.Background())
+	return s
 }
 
 // NewSchedulerWithConfig creates a scheduler with full configuration.
 func NewSchedulerWithConfig(config SchedulerConfig) *Scheduler {
-	return &Scheduler{
+	threshold := config.FailureThreshold
+	if threshold <= 0 {
+		threshold = DefaultFailureThreshold
+	}
+	s := &Scheduler{
 		schedule:   config.Schedule,
 		backupFunc: config.BackupFunc,
 		retry:      config.Retry,
 		callbacks:  config.Callbacks,
+		timing:     config.Timing,
+		threshold:  threshold,
+		statePath:  config.StatePath,
+		paths:      config.Paths,
 		stop:       make(chan struct{}),
 		historyMax: 100,
 	}
+	s.runCtx, s.cancelRun = context.WithCancel(context.Background())
+
+	// Carry the failure streak, and an interrupted backup, across restarts
+	if s.statePath != "" {
+		if st, err := LoadJobState(s.statePath); err != nil {
+			logging.Warn("Failed to load backup state", logging.Err(err))
+		} else {
+			s.failures = st.ConsecutiveFailures
+			s.lastGood = st.LastSuccess
+			s.resume = st.Interrupted
+		}
+	}
+	return s
 }
 
 // Start begins the scheduler
@@ -86,18 +157,53 @@ func (s *Scheduler) Start() {
 	go s.run()
 }
 
-// Stop stops the scheduler
+// Stop stops the scheduler, waiting for a backup in progress to finish
 func (s *Scheduler) Stop() {
+	s.Drain(context.Background())
+}
+
+// ErrInterrupted is the error of a backup interrupted by a shutdown
+var ErrInterrupted = errors.New("interrupted by shutdown")
+
+// interruptWait is how long Drain waits for an interrupted backup to stop
+const interruptWait = time.Minute
+
+// Drain stops the scheduler, letting a backup in progress finish until ctx
+// is done. A backup still running then is interrupted: its context is
+// cancelled, and the run is recorded in the job state so the next start
+// runs it again. Drain reports whether a backup was interrupted.
+func (s *Scheduler) Drain(ctx context.Context) bool {
 	s.mu.Lock()
 	if !s.running {
 		s.mu.Unlock()
-		return
+		return false
 	}
 	s.running = false
 	s.mu.Unlock()
 
 	close(s.stop)
-	s.wg.Wait()
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return false
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	s.interrupting = true
+	s.mu.Unlock()
+	logging.Warn("Interrupting the scheduled backup in progress to shut down")
+	s.cancelRun()
+	select {
+	case <-done:
+	case <-time.After(interruptWait):
+		logging.Warn("Scheduled backup did not stop in time")
+	}
+	return true
 }
 
 // Status returns scheduler status
@@ -108,15 +214,32 @@ func (s *Scheduler) Status() (lastRun time.Time, nextRun time.Time, lastError er
 	lastRun = s.lastRun
 	lastError = s.lastError
 	if s.running {
-		if lastRun.IsZero() {
+		switch {
+		case s.schedule.IsAtLeast():
+			nextRun = s.nextSlotLocked(time.Now())
+		case lastRun.IsZero():
 			nextRun = s.schedule.NextRun(time.Now())
-		} else {
+		default:
 			nextRun = s.schedule.NextRun(lastRun)
 		}
 	}
 	return
 }
 
+// LastSuccess returns when a backup last succeeded (zero if never)
+func (s *Scheduler) LastSuccess() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastGood
+}
+
+// BackupRunning reports whether a scheduled backup is in progress
+func (s *Scheduler) BackupRunning() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.backingUp
+}
+
 // GetHistory returns recent backup results
 func (s *Scheduler) GetHistory(limit int) []*BackupResult {
 	s.mu.Lock()
@@ -151,6 +274,47 @@ func (s *Scheduler) UpdateSchedule(schedule *Schedule) {
 	logging.Infof("Schedule updated from %q to %q", oldSchedule.Expression, schedule.Expression)
 }
 
+// UpdateTiming changes catch-up, jitter and clock jump handling (hot-reload).
+// It applies from the next planned run.
+func (s *Scheduler) UpdateTiming(timing Timing) {
+	s.mu.Lock()
+	s.timing = timing
+	s.mu.Unlock()
+}
+
+// GetTiming returns the current timing configuration
+func (s *Scheduler) GetTiming() Timing {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.timing
+}
+
+// UpdateRetry changes the retry strategy and failure threshold (hot-reload).
+// It applies from the next run.
+func (s *Scheduler) UpdateRetry(retry *RetryStrategy, threshold int) {
+	if threshold <= 0 {
+		threshold = DefaultFailureThreshold
+	}
+	s.mu.Lock()
+	s.retry = retry
+	s.threshold = threshold
+	s.mu.Unlock()
+}
+
+// GetRetry returns the current retry strategy and failure threshold
+func (s *Scheduler) GetRetry() (*RetryStrategy, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.retry, s.threshold
+}
+
+// Health reports whether scheduled backups are failing repeatedly
+func (s *Scheduler) Health() Health {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return healthFor(s.failures, s.threshold)
+}
+
 // GetSchedule returns the current schedule
 func (s *Scheduler) GetSchedule() *Schedule {
 	s.mu.Lock()
@@ -161,46 +325,132 @@ func (s *Scheduler) GetSchedule() *Schedule {
 func (s *Scheduler) run() {
 	defer s.wg.Done()
 
-	// Calculate first run
-	now := time.Now()
-	nextRun := s.schedule.NextRun(now)
+	// Wall-clock times (monotonic reading stripped): a slot must fire by the
+	// clock on the wall, even if the machine slept through the wait
+	if s.resume {
+		logging.Warn("The last scheduled backup was interrupted by a shutdown; running it again")
+		s.runBackupWithRetry(time.Now(), true)
+	}
 
-	logging.Infof("Scheduler started. Next backup at %s", nextRun.Format("2006-01-02 15:04:05"))
+	slot, due := s.plan(time.Now())
+	logging.Infof("Scheduler started. Next backup at %s", due.Format("2006-01-02 15:04:05"))
 
+	prev := time.Now()
 	for {
-		waitDuration := time.Until(nextRun)
-		if waitDuration < 0 {
-			waitDuration = time.Second
+		wait := due.Sub(time.Now().Round(0))
+		if wait > pollInterval {
+			wait = pollInterval
+		}
+		if wait < 0 {
+			wait = 0
 		}
 
 		select {
 		case <-s.stop:
 			logging.Info("Scheduler stopped")
 			return
-		case <-time.After(waitDuration):
-			s.runBackupWithRetry(nextRun)
+		case <-time.After(wait):
+		}
+
+		now := time.Now()
+		timing := s.GetTiming()
+		jump := clockJump(prev, now)
+		prev = now
+		if jump > timing.clockJumpThreshold() {
+			logging.Infof("Wall clock moved %s ahead of elapsed time (wake from sleep or clock change)", FormatDuration(jump))
+		} else if -jump > timing.clockJumpThreshold() {
+			// The clock was set back: re-plan so the slot isn't delayed or repeated
+			slot, due = s.plan(now)
+			logging.Infof("Wall clock set back by %s. Next backup at %s", FormatDuration(-jump), due.Format("2006-01-02 15:04:05"))
+			continue
+		}
+
+		decision := timing.decide(slot, due, now.Round(0))
+		if s.GetSchedule().IsAtLeast() && decision != decisionWait {
+			// An overdue at-least backup runs whenever the machine is up
+			decision = decisionRun
+		} else if decision == decisionWait && s.refreshLastSuccess() {
+			// A manual backup pushed the at-least deadline back
+			slot, due = s.plan(now)
+			continue
+		}
+
+		switch decision {
+		case decisionWait:
+			continue
+		case decisionRun:
+			s.runBackupWithRetry(slot, false)
+		case decisionCatchUp:
+			logging.Infof("Catching up backup missed at %s", slot.Format("2006-01-02 15:04:05"))
+			s.runBackupWithRetry(slot, true)
+		case decisionSkip:
+			logging.Warnf("Skipped backup missed at %s (outside the catch-up window)", slot.Format("2006-01-02 15:04:05"))
+		}
+
+		slot, due = s.plan(time.Now())
+		logging.Infof("Next backup at %s", due.Format("2006-01-02 15:04:05"))
+	}
+}
+
+// plan returns the next slot after now and when to run it (the slot plus
+// jitter), using the current schedule and timing
+func (s *Scheduler) plan(now time.Time) (slot, due time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	slot = s.nextSlotLocked(now).Round(0)
+	return slot, slot.Add(s.timing.jitter())
+}
 
-			// Get the schedule (may have been updated)
-			s.mu.Lock()
-			schedule := s.schedule
-			s.mu.Unlock()
+// overdueRetryInterval spaces out runs of an overdue "at-least" schedule
+// whose last run failed, so it doesn't retry back to back
+const overdueRetryInterval = time.Hour
 
-			nextRun = schedule.NextRun(time.Now())
-			logging.Infof("Next backup at %s", nextRun.Format("2006-01-02 15:04:05"))
+// nextSlotLocked returns the next slot after now. s.mu must be held.
+func (s *Scheduler) nextSlotLocked(now time.Time) time.Time {
+	slot := s.schedule.NextDue(now, s.lastGood)
+	if s.schedule.IsAtLeast() && s.lastError != nil {
+		if retryAt := s.lastRun.Add(overdueRetryInterval); slot.Before(retryAt) {
+			slot = retryAt
 		}
 	}
+	return slot
 }
 
-func (s *Scheduler) runBackupWithRetry(scheduledTime time.Time) {
+// refreshLastSuccess picks up backups recorded in the job state by other
+// processes (e.g. a manual backup) for "at-least" schedules, reporting
+// whether the last success moved forward
+func (s *Scheduler) refreshLastSuccess() bool {
+	if s.statePath == "" || !s.GetSchedule().IsAtLeast() {
+		return false
+	}
+	st, err := LoadJobState(s.statePath)
+	if err != nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !st.LastSuccess.After(s.lastGood) {
+		return false
+	}
+	s.lastGood = st.LastSuccess
+	s.lastError = nil
+	return true
+}
+
+func (s *Scheduler) runBackupWithRetry(scheduledTime time.Time, catchUp bool) {
 	var results []*BackupResult
+	retry, _ := s.GetRetry()
 	maxAttempts := 1
 
-	if s.retry != nil && s.retry.MaxRetries > 0 {
-		maxAttempts = s.retry.MaxRetries + 1
+	if retry != nil && retry.MaxRetries > 0 {
+		maxAttempts = retry.MaxRetries + 1
 	}
+	defer func() { s.finishRun(results) }()
 
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		result := s.runSingleBackup(scheduledTime, attempt, maxAttempts)
+		result := s.runSingleBackup(retry, scheduledTime, attempt, catchUp)
 		results = append(results, result)
 
 		// Record in history
@@ -213,7 +463,7 @@ func (s *Scheduler) runBackupWithRetry(scheduledTime time.Time) {
 		s.lastError = result.Error
 		s.mu.Unlock()
 
-		if result.Success {
+		if result.Success || result.Interrupted {
 			return
 		}
 
@@ -222,7 +472,7 @@ func (s *Scheduler) runBackupWithRetry(scheduledTime time.Time) {
 		}
 
 		// Wait before retry
-		delay := s.retry.NextDelay(attempt)
+		delay := retry.NextDelay(attempt)
 		logging.Infof("Retrying backup in %v (attempt %d/%d)", delay, attempt+1, maxAttempts)
 
 		select {
@@ -238,11 +488,54 @@ func (s *Scheduler) runBackupWithRetry(scheduledTime time.Time) {
 	}
 }
 
-func (s *Scheduler) runSingleBackup(scheduledTime time.Time, attempt, maxAttempts int) *BackupResult {
+// finishRun updates the failure streak and job state once a run's attempts
+// are over, notifying when the streak reaches the threshold
+func (s *Scheduler) finishRun(results []*BackupResult) {
+	if len(results) == 0 {
+		return
+	}
+	last := results[len(results)-1]
+
+	s.mu.Lock()
+	switch {
+	case last.Success:
+		s.failures = 0
+		s.lastGood = last.EndTime
+	case last.Interrupted:
+		// Not the backup's fault: it is run again on the next start
+	default:
+		s.failures++
+	}
+	health := healthFor(s.failures, s.threshold)
+	s.mu.Unlock()
+
+	if s.statePath != "" {
+		st, err := LoadJobState(s.statePath)
+		if err != nil {
+			logging.Warn("Failed to load backup state", logging.Err(err))
+			st = &JobState{}
+		}
+		st.Record(s.paths, len(results), last.Error, last.EndTime)
+		st.ConsecutiveFailures = health.ConsecutiveFailures
+		st.Manual = false
+		st.Interrupted = last.Interrupted
+		if err := st.Save(s.statePath); err != nil {
+			logging.Warn("Failed to save backup state", logging.Err(err))
+		}
+	}
+
+	if !last.Success && !last.Interrupted && health.ConsecutiveFailures == health.FailureThreshold {
+		logging.Warnf("Scheduled backups have failed %d times in a row", health.ConsecutiveFailures)
+		s.callbacks.callOnFailureThreshold(health, results)
+	}
+}
+
+func (s *Scheduler) runSingleBackup(retry *RetryStrategy, scheduledTime time.Time, attempt int, catchUp bool) *BackupResult {
 	result := &BackupResult{
 		ScheduledTime: scheduledTime,
 		StartTime:     time.Now(),
 		Attempt:       attempt,
+		CatchUp:       catchUp,
 	}
 
 	// Notify start
@@ -253,14 +546,25 @@ func (s *Scheduler) runSingleBackup(scheduledTime time.Time, attempt, maxAttempt
 	logging.Info("Running scheduled backup...")
 
 	// Run backup
-	err := s.backupFunc()
+	s.setBackingUp(true)
+	err := s.backupFunc(s.runCtx)
+	interrupted := s.setBackingUp(false)
+	if err != nil && interrupted {
+		err = ErrInterrupted
+	}
 	result.EndTime = time.Now()
 	result.Error = err
 	result.Success = err == nil
-	result.WillRetry = !result.Success && s.retry != nil && s.retry.ShouldRetry(attempt)
+	result.Interrupted = err != nil && interrupted
+	result.WillRetry = !result.Success && !result.Interrupted && retry.ShouldRetry(attempt)
 
 	// Notify completion
-	if result.Success {
+	if result.Interrupted {
+		if s.callbacks != nil {
+			s.callbacks.callOnBackupInterrupted(result)
+		}
+		logging.Warn("Scheduled backup interrupted by shutdown; it runs again on the next start")
+	} else if result.Success {
 		if s.callbacks != nil {
 			s.callbacks.callOnBackupSuccess(result)
 		}
@@ -275,6 +579,15 @@ func (s *Scheduler) runSingleBackup(scheduledTime time.Time, attempt, maxAttempt
 	return result
 }
 
+// setBackingUp marks a backup as running or not, reporting whether a
+// shutdown is interrupting it
+func (s *Scheduler) setBackingUp(v bool) (interrupting bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.backingUp = v
+	return s.interrupting
+}
+
 // FormatDuration formats a duration nicely
 func FormatDuration(d time.Duration) string {
 	if d < time.Minute {
@@ -1,6 +1,10 @@
 package scheduler
 
 import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -25,6 +29,10 @@ func TestParseSchedule(t *testing.T) {
 		{"cron specific", "30 14 1 * *", false},
 		{"invalid cron", "60 2 * * *", true},    // minute > 59
 		{"invalid interval", "every 30s", true}, // too short
+		{"at-least hours", "at-least 72h", false},
+		{"at-least days", "at-least 3d", false},
+		{"at-least too short", "at-least 30m", true},
+		{"at-least invalid", "at-least often", true},
 		{"invalid format", "sometimes", true},
 	}
 
@@ -86,6 +94,94 @@ func TestScheduleNextRun(t *testing.T) {
 	}
 }
 
+func TestScheduleTimezone(t *testing.T) {
+	sched, err := ParseScheduleIn("0 2 * * *", "Europe/Berlin")
+	require.NoError(t, err)
+	assert.Equal(t, "Europe/Berlin", sched.Timezone())
+
+	// 2 AM in Berlin, whatever the zone of the time passed in
+	next := sched.NextRun(time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC))
+	assert.True(t, next.Equal(time.Date(2024, 1, 16, 1, 0, 0, 0, time.UTC)), "NextRun = %s", next)
+
+	interval, err := ParseScheduleIn("every 4h", "Asia/Tokyo")
+	require.NoError(t, err)
+	now := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	assert.True(t, interval.NextRun(now).Equal(now.Add(4*time.Hour)), "intervals measure elapsed time")
+
+	local, err := ParseScheduleIn("daily", "")
+	require.NoError(t, err)
+	assert.Equal(t, "Local", local.Timezone())
+
+	_, err = ParseScheduleIn("daily", "Mars/Olympus_Mons")
+	assert.ErrorContains(t, err, "unknown timezone")
+}
+
+func TestScheduleDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	at := func(month time.Month, day, hour, min int, zone string) time.Time {
+		offset := map[string]int{"EST": -5, "EDT": -4}[zone]
+		return time.Date(2024, month, day, hour-offset, min, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name  string
+		expr  string
+		after time.Time
+		want  []time.Time
+	}{
+		{
+			// 2024-03-10: clocks jump from 2:00 EST to 3:00 EDT
+			name:  "skipped time runs at the jump",
+			expr:  "30 2 * * *",
+			after: at(3, 10, 0, 0, "EST"),
+			want:  []time.Time{at(3, 10, 3, 0, "EDT"), at(3, 11, 2, 30, "EDT")},
+		},
+		{
+			name:  "every-hour schedules carry on after the jump",
+			expr:  "30 * * * *",
+			after: at(3, 10, 1, 0, "EST"),
+			want:  []time.Time{at(3, 10, 1, 30, "EST"), at(3, 10, 3, 30, "EDT")},
+		},
+		{
+			// 2024-11-03: clocks go back from 2:00 EDT to 1:00 EST
+			name:  "repeated time runs once",
+			expr:  "30 1 * * *",
+			after: at(11, 3, 0, 0, "EDT"),
+			want:  []time.Time{at(11, 3, 1, 30, "EDT"), at(11, 4, 1, 30, "EST")},
+		},
+		{
+			name:  "every-hour schedules run on both passes",
+			expr:  "*/30 * * * *",
+			after: at(11, 3, 1, 0, "EDT"),
+			want: []time.Time{
+				at(11, 3, 1, 30, "EDT"), at(11, 3, 1, 0, "EST"),
+				at(11, 3, 1, 30, "EST"), at(11, 3, 2, 0, "EST"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sched, err := ParseScheduleIn(tt.expr, "America/New_York")
+			require.NoError(t, err)
+			after := tt.after
+			for _, want := range tt.want {
+				next := sched.NextRun(after)
+				assert.True(t, next.Equal(want), "NextRun(%s) = %s, want %s", after.In(ny), next.In(ny), want.In(ny))
+				after = next
+			}
+		})
+	}
+}
+
+func TestScheduleImpossibleDate(t *testing.T) {
+	sched, err := ParseScheduleIn("0 0 31 2 *", "UTC")
+	require.NoError(t, err)
+	now := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	assert.True(t, sched.NextRun(now).Equal(now.Add(24*time.Hour)))
+}
+
 func TestScheduleInterval(t *testing.T) {
 	now := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
 
@@ -180,3 +276,169 @@ func TestFormatDuration(t *testing.T) {
 		})
 	}
 }
+
+func TestSchedulerFailureThreshold(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "backup-state.json")
+	var mu sync.Mutex
+	fail := true
+	notified := 0
+
+	s := NewSchedulerWithConfig(SchedulerConfig{
+		Schedule: &Schedule{interval: time.Hour},
+		BackupFunc: func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if fail {
+				return errors.New("repository unreachable")
+			}
+			return nil
+		},
+		Callbacks: &SchedulerCallbacks{
+			OnFailureThreshold: func(Health, []*BackupResult) { notified++ },
+		},
+		FailureThreshold: 2,
+		StatePath:        statePath,
+		Paths:            []string{"/data"},
+	})
+
+	s.runBackupWithRetry(time.Now(), false)
+	assert.True(t, s.Health().Healthy)
+	s.runBackupWithRetry(time.Now(), false)
+	assert.False(t, s.Health().Healthy)
+	s.runBackupWithRetry(time.Now(), false)
+	assert.Equal(t, 1, notified, "notify once when the threshold is reached")
+
+	st, err := LoadJobState(statePath)
+	require.NoError(t, err)
+	assert.True(t, st.Failed())
+	assert.Equal(t, 3, st.ConsecutiveFailures)
+	assert.Equal(t, []string{"/data"}, st.Paths)
+
+	// The streak survives a restart
+	restarted := NewSchedulerWithConfig(SchedulerConfig{
+		Schedule:         &Schedule{interval: time.Hour},
+		FailureThreshold: 2,
+		StatePath:        statePath,
+	})
+	assert.Equal(t, 3, restarted.Health().ConsecutiveFailures)
+
+	mu.Lock()
+	fail = false
+	mu.Unlock()
+	s.runBackupWithRetry(time.Now(), false)
+	assert.Equal(t, Health{Healthy: true, FailureThreshold: 2}, s.Health())
+}
+
+func TestSchedulerDrain(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "backup-state.json")
+	started := make(chan struct{}, 1)
+	interrupted := 0
+
+	s := NewSchedulerWithConfig(SchedulerConfig{
+		Schedule: &Schedule{interval: time.Hour},
+		BackupFunc: func(ctx context.Context) error {
+			started <- struct{}{}
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		Callbacks: &SchedulerCallbacks{
+			OnBackupInterrupted: func(*BackupResult) { interrupted++ },
+			OnFailureThreshold:  func(Health, []*BackupResult) { t.Error("an interruption is not a failure") },
+		},
+		FailureThreshold: 1,
+		StatePath:        statePath,
+	})
+	s.resume = true // Run a backup as soon as the scheduler starts
+	s.Start()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.True(t, s.Drain(ctx))
+	assert.Equal(t, 1, interrupted)
+	assert.True(t, s.Health().Healthy)
+
+	st, err := LoadJobState(statePath)
+	require.NoError(t, err)
+	assert.True(t, st.Interrupted)
+	assert.Equal(t, ErrInterrupted.Error(), st.LastError, "backup --retry-last can re-run it")
+	assert.Zero(t, st.ConsecutiveFailures)
+
+	// The next start runs the backup again, and its success clears the mark
+	done := make(chan struct{})
+	restarted := NewSchedulerWithConfig(SchedulerConfig{
+		Schedule: &Schedule{interval: time.Hour},
+		BackupFunc: func(context.Context) error {
+			close(done)
+			return nil
+		},
+		StatePath: statePath,
+	})
+	restarted.Start()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("interrupted backup not resumed")
+	}
+	assert.False(t, restarted.Drain(context.Background()), "a finished backup isn't interrupted")
+	st, err = LoadJobState(statePath)
+	require.NoError(t, err)
+	assert.False(t, st.Interrupted)
+	assert.False(t, st.Failed())
+}
+
+func TestScheduleAtLeastNextDue(t *testing.T) {
+	sched, err := ParseSchedule("at-least 3d")
+	require.NoError(t, err)
+	assert.True(t, sched.IsAtLeast())
+	assert.False(t, sched.IsCron())
+	assert.Equal(t, 72*time.Hour, sched.AtLeast())
+
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, now, sched.NextDue(now, time.Time{}), "never backed up: due now")
+	assert.Equal(t, now.Add(24*time.Hour), sched.NextDue(now, now.Add(-48*time.Hour)))
+	assert.Equal(t, now, sched.NextDue(now, now.Add(-96*time.Hour)), "overdue: due now")
+
+	daily, err := ParseSchedule("daily")
+	require.NoError(t, err)
+	assert.Equal(t, daily.NextRun(now), daily.NextDue(now, now.Add(-96*time.Hour)), "slot schedules ignore the last success")
+}
+
+func TestSchedulerAtLeastPlan(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "backup-state.json")
+	now := time.Now().Round(0)
+
+	st := &JobState{}
+	st.Record([]string{"/data"}, 1, nil, now.Add(-10*time.Hour))
+	require.NoError(t, st.Save(statePath))
+
+	sched, err := ParseSchedule("at-least 24h")
+	require.NoError(t, err)
+	s := NewSchedulerWithConfig(SchedulerConfig{
+		Schedule:   sched,
+		BackupFunc: func(context.Context) error { return errors.New("offline") },
+		StatePath:  statePath,
+	})
+
+	slot, _ := s.plan(now)
+	assert.True(t, now.Add(14*time.Hour).Equal(slot), "due 24h after the last success, got %s", slot)
+
+	// A manual backup recorded by another process pushes the deadline back
+	st.Record([]string{"/data"}, 1, nil, now)
+	require.NoError(t, st.Save(statePath))
+	assert.True(t, s.refreshLastSuccess())
+	slot, _ = s.plan(now)
+	assert.True(t, now.Add(24*time.Hour).Equal(slot), "got %s", slot)
+
+	// Once overdue, a failed run is retried after overdueRetryInterval
+	// rather than back to back
+	overdue := NewSchedulerWithConfig(SchedulerConfig{
+		Schedule:   sched,
+		BackupFunc: func(context.Context) error { return errors.New("offline") },
+	})
+	slot, _ = overdue.plan(now)
+	assert.True(t, now.Equal(slot), "never backed up: due now")
+	overdue.runBackupWithRetry(now, false)
+	slot, _ = overdue.plan(time.Now())
+	assert.WithinDuration(t, time.Now().Add(overdueRetryInterval), slot, time.Minute)
+}
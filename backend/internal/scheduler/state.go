@@ -0,0 +1,90 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// JobState is the persisted outcome of the most recent backup run, shared
+// by the scheduler and manual backups so a failed run can be retried with
+// `airgapper backup --retry-last` and failure counts survive restarts
+type JobState struct {
+	Paths               []string  `json:"paths"`
+	LastAttempt         time.Time `json:"last_attempt"`
+	LastSuccess         time.Time `json:"last_success,omitempty"`
+	LastError           string    `json:"last_error,omitempty"`
+	Attempts            int       `json:"attempts"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	Manual              bool      `json:"manual,omitempty"`
+	// Interrupted is set when a shutdown interrupted the scheduled backup,
+	// which the scheduler then runs again when it next starts
+	Interrupted bool `json:"interrupted,omitempty"`
+}
+
+// LoadJobState reads the job state. A missing file yields an empty state.
+func LoadJobState(path string) (*JobState, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &JobState{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup state: %w", err)
+	}
+	var st JobState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("failed to parse backup state: %w", err)
+	}
+	return &st, nil
+}
+
+// Save writes the job state
+func (st *JobState) Save(path string) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// Record updates the state with the outcome of a run (after any retries)
+func (st *JobState) Record(paths []string, attempts int, err error, at time.Time) {
+	st.Paths = paths
+	st.LastAttempt = at
+	st.Attempts = attempts
+	st.Interrupted = false
+	if err == nil {
+		st.LastSuccess = at
+		st.LastError = ""
+		st.ConsecutiveFailures = 0
+		return
+	}
+	st.LastError = err.Error()
+	st.ConsecutiveFailures++
+}
+
+// Failed reports whether the most recent run failed
+func (st *JobState) Failed() bool {
+	return !st.LastAttempt.IsZero() && st.LastError != ""
+}
+
+// Health is the scheduled backup job's health as reported in /api/status
+type Health struct {
+	Healthy             bool
+	ConsecutiveFailures int
+	FailureThreshold    int
+}
+
+// healthFor reports a job unhealthy once failures reach the threshold
+func healthFor(failures, threshold int) Health {
+	return Health{
+		Healthy:             threshold <= 0 || failures < threshold,
+		ConsecutiveFailures: failures,
+		FailureThreshold:    threshold,
+	}
+}
@@ -0,0 +1,138 @@
+package scheduler
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Timing controls how the scheduler handles runs missed while the machine
+// slept, wall-clock jumps, and load synchronized across many owners.
+// The zero value skips missed runs and adds no jitter.
+type Timing struct {
+	// CatchUpWindow runs a missed backup on wake if the slot is at most
+	// this old (0 = skip missed runs and wait for the next slot)
+	CatchUpWindow time.Duration
+	// Jitter delays each run by a random amount up to this duration
+	Jitter time.Duration
+	// ClockJumpThreshold is how far the wall clock may drift from elapsed
+	// time before it's treated as a sleep or clock change
+	ClockJumpThreshold time.Duration
+}
+
+// DefaultTiming catches up backups missed within the last 12 hours, so a
+// laptop asleep at 2 AM backs up when it wakes rather than the next night
+func DefaultTiming() Timing {
+	return Timing{CatchUpWindow: 12 * time.Hour}
+}
+
+const (
+	// defaultClockJumpThreshold applies when Timing.ClockJumpThreshold is 0
+	defaultClockJumpThreshold = 2 * time.Minute
+
+	// pollInterval bounds how long the scheduler sleeps between wall-clock
+	// checks, so a wake from sleep is noticed promptly
+	pollInterval = time.Minute
+
+	// missedAfter is how late a run may start before it counts as missed
+	missedAfter = 2 * pollInterval
+)
+
+// TimingConfig is the persisted form of Timing, with durations written as
+// strings (e.g. "12h", "15m")
+type TimingConfig struct {
+	CatchUpWindow      string `json:"catch_up_window,omitempty"`
+	Jitter             string `json:"jitter,omitempty"`
+	ClockJumpThreshold string `json:"clock_jump_threshold,omitempty"`
+}
+
+// Parse converts the config into a Timing
+func (c *TimingConfig) Parse() (Timing, error) {
+	var t Timing
+	if c == nil {
+		return t, nil
+	}
+
+	fields := []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"catch-up window", c.CatchUpWindow, &t.CatchUpWindow},
+		{"jitter", c.Jitter, &t.Jitter},
+		{"clock jump threshold", c.ClockJumpThreshold, &t.ClockJumpThreshold},
+	}
+	for _, f := range fields {
+		if f.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(f.value)
+		if err != nil {
+			return t, fmt.Errorf("invalid %s %q: %w", f.name, f.value, err)
+		}
+		if d < 0 {
+			return t, fmt.Errorf("%s cannot be negative", f.name)
+		}
+		*f.dst = d
+	}
+	return t, nil
+}
+
+// FormatTiming converts a Timing into its persisted form
+func FormatTiming(t Timing) TimingConfig {
+	return TimingConfig{
+		CatchUpWindow:      t.CatchUpWindow.String(),
+		Jitter:             t.Jitter.String(),
+		ClockJumpThreshold: t.clockJumpThreshold().String(),
+	}
+}
+
+// clockJumpThreshold returns the configured threshold or the default
+func (t Timing) clockJumpThreshold() time.Duration {
+	if t.ClockJumpThreshold > 0 {
+		return t.ClockJumpThreshold
+	}
+	return defaultClockJumpThreshold
+}
+
+// jitter returns a random delay in [0, Jitter)
+func (t Timing) jitter() time.Duration {
+	if t.Jitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(t.Jitter)))
+}
+
+// runDecision is what the scheduler does about a slot at a given time
+type runDecision int
+
+const (
+	decisionWait    runDecision = iota // Slot not reached yet
+	decisionRun                        // Slot reached on time
+	decisionCatchUp                    // Slot missed, but within the catch-up window
+	decisionSkip                       // Slot missed and too old to catch up
+)
+
+// decide compares the wall-clock time with a slot (the scheduled time) and
+// when it's due (the slot plus jitter)
+func (t Timing) decide(slot, due, now time.Time) runDecision {
+	if now.Before(due) {
+		return decisionWait
+	}
+	if now.Sub(due) <= missedAfter {
+		return decisionRun
+	}
+	if t.CatchUpWindow > 0 && now.Sub(slot) <= t.CatchUpWindow {
+		return decisionCatchUp
+	}
+	return decisionSkip
+}
+
+// clockJump returns how far the wall clock moved beyond the monotonic time
+// elapsed since prev. It is positive after a suspend (the monotonic clock
+// stops while asleep) or a forward clock change, and negative when the
+// clock is set back.
+func clockJump(prev, now time.Time) time.Duration {
+	wall := now.Round(0).Sub(prev.Round(0))
+	return wall - now.Sub(prev)
+}
@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimingDecide(t *testing.T) {
+	slot := time.Date(2024, 1, 15, 2, 0, 0, 0, time.UTC)
+	due := slot.Add(10 * time.Minute) // jittered
+
+	catchUp := Timing{CatchUpWindow: 12 * time.Hour}
+	tests := []struct {
+		name   string
+		timing Timing
+		now    time.Time
+		want   runDecision
+	}{
+		{"before jitter", catchUp, slot.Add(5 * time.Minute), decisionWait},
+		{"on time", catchUp, due.Add(30 * time.Second), decisionRun},
+		{"woke at 8am", catchUp, slot.Add(6 * time.Hour), decisionCatchUp},
+		{"woke next evening", catchUp, slot.Add(18 * time.Hour), decisionSkip},
+		{"no catch-up window", Timing{}, slot.Add(6 * time.Hour), decisionSkip},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.timing.decide(slot, due, tt.now))
+		})
+	}
+}
+
+func TestTimingJitter(t *testing.T) {
+	assert.Zero(t, Timing{}.jitter())
+
+	timing := Timing{Jitter: 15 * time.Minute}
+	for i := 0; i < 100; i++ {
+		j := timing.jitter()
+		assert.GreaterOrEqual(t, j, time.Duration(0))
+		assert.Less(t, j, 15*time.Minute)
+	}
+}
+
+func TestClockJump(t *testing.T) {
+	prev := time.Now()
+	now := prev.Add(time.Minute)
+	assert.Zero(t, clockJump(prev, now), "monotonic and wall clocks agree")
+
+	// Stripping the monotonic reading makes the wall clock the only measure,
+	// as after a suspend where the monotonic clock didn't advance
+	assert.Zero(t, clockJump(prev.Round(0), now.Round(0)))
+}
+
+func TestTimingConfigParse(t *testing.T) {
+	timing, err := (&TimingConfig{CatchUpWindow: "6h", Jitter: "15m"}).Parse()
+	require.NoError(t, err)
+	assert.Equal(t, 6*time.Hour, timing.CatchUpWindow)
+	assert.Equal(t, 15*time.Minute, timing.Jitter)
+	assert.Equal(t, defaultClockJumpThreshold, timing.clockJumpThreshold())
+
+	_, err = (&TimingConfig{Jitter: "soon"}).Parse()
+	assert.Error(t, err)
+	_, err = (&TimingConfig{CatchUpWindow: "-1h"}).Parse()
+	assert.Error(t, err)
+
+	var nilConfig *TimingConfig
+	timing, err = nilConfig.Parse()
+	require.NoError(t, err)
+	assert.Equal(t, Timing{}, timing)
+}
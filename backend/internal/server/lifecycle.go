@@ -3,9 +3,12 @@ package server
 
 import (
 	"context"
+	"errors"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -20,6 +23,18 @@ type GracefulServer struct {
 	server       *http.Server
 	beforeStop   func()
 	shutdownHook func()
+	drain        func(ctx context.Context)
+	drainTimeout time.Duration
+	tlsCertFile  string
+	tlsKeyFile   string
+	extra        []Listener
+}
+
+// Listener is a server run alongside the main one on its own listener
+// (e.g. a Unix socket), and shut down with it
+type Listener struct {
+	Server   *http.Server
+	Listener net.Listener
 }
 
 // GracefulServerOptions configures a GracefulServer
@@ -28,52 +43,125 @@ type GracefulServerOptions struct {
 	BeforeStop func()
 	// ShutdownHook is called after server shutdown completes
 	ShutdownHook func()
+	// Drain finishes work in progress outside HTTP requests (e.g. a
+	// scheduled backup) during shutdown. It runs alongside the draining of
+	// in-flight requests and must return soon after ctx is done.
+	Drain func(ctx context.Context)
+	// DrainTimeout is how long in-flight requests and Drain get to finish
+	// before they are cut off (0 = ShutdownTimeout)
+	DrainTimeout time.Duration
+	// TLSCertFile and TLSKeyFile enable HTTPS when both are set
+	TLSCertFile string
+	TLSKeyFile  string
+	// Extra are served, without TLS, until the main server shuts down
+	Extra []Listener
 }
 
 // NewGracefulServer creates a server wrapper with graceful shutdown
 func NewGracefulServer(server *http.Server, opts *GracefulServerOptions) *GracefulServer {
-	gs := &GracefulServer{server: server}
+	gs := &GracefulServer{server: server, drainTimeout: ShutdownTimeout}
 	if opts != nil {
 		gs.beforeStop = opts.BeforeStop
 		gs.shutdownHook = opts.ShutdownHook
+		gs.drain = opts.Drain
+		if opts.DrainTimeout > 0 {
+			gs.drainTimeout = opts.DrainTimeout
+		}
+		gs.tlsCertFile = opts.TLSCertFile
+		gs.tlsKeyFile = opts.TLSKeyFile
+		gs.extra = opts.Extra
 	}
 	return gs
 }
 
 // ListenAndServe starts the server and handles graceful shutdown on SIGINT/SIGTERM.
+// A second signal cuts the shutdown's drain short.
 // This is a blocking call that returns when the server has been shut down.
 func (gs *GracefulServer) ListenAndServe() error {
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 
-	errCh := make(chan error, 1)
+	errCh := make(chan error, 1+len(gs.extra))
 	go func() {
-		if err := gs.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := gs.listen(); err != nil && err != http.ErrServerClosed {
 			errCh <- err
 		}
 	}()
+	for _, l := range gs.extra {
+		go func() {
+			if err := l.Server.Serve(l.Listener); err != nil && err != http.ErrServerClosed {
+				errCh <- err
+			}
+		}()
+	}
 
 	select {
 	case err := <-errCh:
 		logging.Error("Server error", logging.Err(err))
 		return err
 	case <-stop:
-		return gs.Shutdown()
 	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+			logging.Warn("Stopping now without waiting for work in progress")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return gs.shutdown(ctx)
+}
+
+func (gs *GracefulServer) listen() error {
+	if gs.tlsCertFile != "" && gs.tlsKeyFile != "" {
+		return gs.server.ListenAndServeTLS(gs.tlsCertFile, gs.tlsKeyFile)
+	}
+	return gs.server.ListenAndServe()
 }
 
 // Shutdown gracefully shuts down the server
 func (gs *GracefulServer) Shutdown() error {
+	return gs.shutdown(context.Background())
+}
+
+// shutdown stops accepting connections, then gives in-flight requests and
+// the drain function until the drain timeout, or until parent is done, to
+// finish. Requests still running then are cut off.
+func (gs *GracefulServer) shutdown(parent context.Context) error {
 	logging.Info("Shutting down...")
 
 	if gs.beforeStop != nil {
 		gs.beforeStop()
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	ctx, cancel := context.WithTimeout(parent, gs.drainTimeout)
 	defer cancel()
 
-	if err := gs.server.Shutdown(ctx); err != nil {
+	var wg sync.WaitGroup
+	if gs.drain != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gs.drain(ctx)
+		}()
+	}
+	err := gs.server.Shutdown(ctx)
+	for _, l := range gs.extra {
+		err = errors.Join(err, l.Server.Shutdown(ctx))
+	}
+	wg.Wait()
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		logging.Warn("Cutting off requests still in progress",
+			logging.Duration("drainTimeout", gs.drainTimeout))
+		err = gs.server.Close()
+		for _, l := range gs.extra {
+			err = errors.Join(err, l.Server.Close())
+		}
+	}
+	if err != nil {
 		return err
 	}
 
@@ -86,11 +174,13 @@ func (gs *GracefulServer) Shutdown() error {
 }
 
 // RunWithGracefulShutdown starts an HTTP server and handles shutdown signals.
-// beforeStop is called before shutdown begins (can be nil).
+// beforeStop is called before shutdown begins (can be nil), and in-flight
+// requests get drainTimeout to finish (0 = ShutdownTimeout).
 // This is a convenience function for simple use cases.
-func RunWithGracefulShutdown(server *http.Server, beforeStop func()) error {
+func RunWithGracefulShutdown(server *http.Server, drainTimeout time.Duration, beforeStop func()) error {
 	gs := NewGracefulServer(server, &GracefulServerOptions{
-		BeforeStop: beforeStop,
+		BeforeStop:   beforeStop,
+		DrainTimeout: drainTimeout,
 	})
 	return gs.ListenAndServe()
 }
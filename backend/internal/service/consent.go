@@ -1,11 +1,18 @@
 package service
 
 import (
-	"errors"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/lcrostarosa/airgapper/backend/internal/config"
 	"github.com/lcrostarosa/airgapper/backend/internal/consent"
 	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/genesis"
+	"github.com/lcrostarosa/airgapper/backend/internal/policy"
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
 )
 
 // ConsentService handles restore/deletion consent business logic
@@ -24,15 +31,227 @@ type CreateRestoreRequestParams struct {
 	SnapshotID string
 	Paths      []string
 	Reason     string
+
+	// TTL is how long the request stays pending, up to the configured
+	// limit; zero means consent.DefaultRequestTTL
+	TTL time.Duration
+
+	// CorrelationID, if set, becomes the request's correlation ID
+	CorrelationID string
+
+	// Signature is the requester's signature over the request. It is
+	// required in consensus mode, where every requester has a key.
+	Signature *consent.RequesterSignature
+
+	// RequesterContext describes the device the request was made from
+	RequesterContext *consent.RequesterContext
+
+	// SizeBytes is the size of the restore as the requester declared it
+	SizeBytes int64
+
+	// Purpose is what the request is for: empty for a restore,
+	// consent.PurposeExportKeys or consent.PurposeBrowse
+	Purpose string
+
+	// Template is the template the requester created the request from
+	Template string
 }
 
-// CreateRestoreRequest creates a new restore request
+// RequestSignatureMaxAge is how far a signed request's creation time may be
+// from now, limiting how long a captured request can be replayed
+const RequestSignatureMaxAge = 5 * time.Minute
+
+// CreateRestoreRequest creates a new restore request and applies the
+// approval rules to it. The snapshot defaults to "latest" (except for key
+// exports, which name none), which is what an unset snapshot must be signed
+// as, and may be narrowed by tag as in "latest:tag=documents". Requests for
+// a purpose this node doesn't know are refused rather than taken for
+// restores.
 func (s *ConsentService) CreateRestoreRequest(params CreateRestoreRequestParams) (*consent.RestoreRequest, error) {
-	snapshotID := params.SnapshotID
-	if snapshotID == "" {
-		snapshotID = "latest"
+	req, err := s.createRestoreRequest(params)
+	if err != nil {
+		return nil, err
+	}
+	return s.applyRules(req)
+}
+
+func (s *ConsentService) createRestoreRequest(params CreateRestoreRequestParams) (*consent.RestoreRequest, error) {
+	if !consent.ValidPurpose(params.Purpose) {
+		return nil, apperrors.Newf(apperrors.CodeInvalidArgument, "unknown request purpose %q", params.Purpose)
+	}
+	snapshotID := consent.NormalizeSnapshotID(params.Purpose, params.SnapshotID)
+	if params.Purpose != consent.PurposeExportKeys {
+		if _, err := restic.ParseSnapshotSelector(snapshotID); err != nil {
+			return nil, apperrors.New(apperrors.CodeInvalidArgument, err.Error())
+		}
+	}
+	if params.Purpose == consent.PurposeBrowse && params.TTL != 0 {
+		return nil, apperrors.Newf(apperrors.CodeInvalidArgument, "browse requests stay pending for %s and take no TTL", consent.BrowseRequestTTL)
+	}
+
+	if err := s.verifyRequesterSignature(snapshotID, params); err != nil {
+		return nil, err
+	}
+
+	quorum, err := s.cfg.Quorum()
+	if err != nil {
+		return nil, fmt.Errorf("invalid quorum rule: %w", err)
+	}
+	if params.SizeBytes < 0 {
+		return nil, apperrors.New(apperrors.CodeInvalidArgument, "size cannot be negative")
+	}
+	mgr := s.manager(params.CorrelationID).WithRequesterContext(params.RequesterContext).WithSize(params.SizeBytes).
+		WithPurpose(params.Purpose).WithTemplate(params.Template)
+	if params.Signature != nil {
+		mgr = mgr.WithRequesterSignature(params.Signature)
+	}
+	if params.TTL != 0 {
+		if err := s.checkTTL("TTL", params.TTL); err != nil {
+			return nil, err
+		}
+		mgr = mgr.WithTTL(params.TTL)
+	}
+	if quorum != nil {
+		return mgr.CreateRequestWithQuorum(s.cfg.Name, snapshotID, params.Reason, params.Paths, quorum)
 	}
-	return s.consentMgr.CreateRequest(s.cfg.Name, snapshotID, params.Reason, params.Paths)
+	if s.cfg.UsesConsensusMode() {
+		return mgr.CreateRequestWithConsensus(s.cfg.Name, snapshotID, params.Reason, params.Paths, s.cfg.RequiredApprovals())
+	}
+	return mgr.CreateRequest(s.cfg.Name, snapshotID, params.Reason, params.Paths)
+}
+
+// verifyRequesterSignature checks that a new request was signed by its
+// requester, this node's owner, so another device on the network can't
+// create requests in their name. Unsigned requests are only accepted
+// outside consensus mode, where requesters have no keys.
+func (s *ConsentService) verifyRequesterSignature(snapshotID string, params CreateRestoreRequestParams) error {
+	sig := params.Signature
+	if sig == nil {
+		if s.cfg.UsesConsensusMode() {
+			return apperrors.ErrRequestUnsigned
+		}
+		return nil
+	}
+
+	holder := s.cfg.GetKeyHolder(sig.KeyHolderID)
+	if holder == nil {
+		return apperrors.New(apperrors.CodeKeyHolderNotFound, "unknown key holder")
+	}
+	if holder.Name != s.cfg.Name {
+		return apperrors.Newf(apperrors.CodeInvalidSignature, "request must be signed by %s", s.cfg.Name)
+	}
+	if rc := params.RequesterContext; rc != nil && rc.KeyFingerprint != "" && rc.KeyFingerprint != crypto.KeyID(holder.PublicKey) {
+		return apperrors.Newf(apperrors.CodeInvalidSignature, "request claims key %s but was signed by %s", rc.KeyFingerprint, sig.KeyHolderID)
+	}
+	maxAge := RequestSignatureMaxAge + s.consentMgr.ClockSkewTolerance()
+	if age := time.Since(sig.CreatedAt); age > maxAge || age < -maxAge {
+		return apperrors.New(apperrors.CodeInvalidSignature, "request signature is too old (check the clocks)")
+	}
+
+	req := &consent.RestoreRequest{
+		ID:         sig.RequestID,
+		Requester:  s.cfg.Name,
+		SnapshotID: snapshotID,
+		Paths:      params.Paths,
+		Reason:     params.Reason,
+		CreatedAt:  sig.CreatedAt,
+		SizeBytes:  params.SizeBytes,
+		Purpose:    params.Purpose,
+		Template:   params.Template,
+
+		RequesterContext: params.RequesterContext,
+	}
+	valid, err := req.SignData(sig.KeyHolderID).Verify(holder.PublicKey, sig.Signature)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return apperrors.ErrInvalidSignature
+	}
+	return nil
+}
+
+// applyRules lets the approval rules deny or approve a new request before
+// it waits for manual approval, recording every decision they make
+func (s *ConsentService) applyRules(req *consent.RestoreRequest) (*consent.RestoreRequest, error) {
+	d := s.cfg.ApprovalRules.Evaluate(req, s.cfg.KnownKey, time.Now())
+	if !d.Decided() {
+		return req, nil
+	}
+	switch d.Decision {
+	case consent.RuleDeny:
+		if err := consent.Restores.Deny(s.consentMgr, req.ID, consent.RulesActor); err != nil {
+			return nil, err
+		}
+	case consent.RuleApprove:
+		if err := s.autoApprove(req); err != nil {
+			d.Decision = consent.RuleManual
+			d.Reason += "; could not approve: " + err.Error()
+		}
+	}
+	if err := s.consentMgr.RecordRuleDecision(d); err != nil {
+		return nil, err
+	}
+	return s.consentMgr.GetRequest(req.ID)
+}
+
+// autoApprove approves a request on the approval rules' say: with this
+// node's key as a key holder in consensus mode, otherwise by releasing the
+// local share
+func (s *ConsentService) autoApprove(req *consent.RestoreRequest) error {
+	if err := genesis.CheckConfig(s.cfg); err != nil {
+		return err
+	}
+	if !s.cfg.UsesConsensusMode() {
+		share, _, err := s.cfg.LoadShare()
+		if err != nil {
+			return apperrors.New(apperrors.CodeNoLocalShare, "no share available")
+		}
+		return s.consentMgr.Approve(req.ID, consent.RulesActor, share)
+	}
+
+	if s.cfg.PrivateKey == nil {
+		return apperrors.New(apperrors.CodeNoSigningKey, "this node has no signing key")
+	}
+	keyID := crypto.KeyID(s.cfg.PublicKey)
+	holder := s.cfg.GetKeyHolder(keyID)
+	if holder == nil {
+		return apperrors.New(apperrors.CodeKeyHolderNotFound, "this node is not a key holder")
+	}
+	challenge, err := s.consentMgr.IssueChallenge(req.ID, keyID)
+	if err != nil {
+		return err
+	}
+	signedAt := time.Now().Truncate(time.Second)
+	signature, err := req.ApprovalSignData(keyID, challenge.Nonce, signedAt).Sign(s.cfg.PrivateKey)
+	if err != nil {
+		return err
+	}
+	return s.consentMgr.AddSignature(req.ID, keyID, holder.Name, challenge.Nonce, signedAt, signature)
+}
+
+// checkTTL checks a requested TTL or extension is within the configured limit
+func (s *ConsentService) checkTTL(what string, d time.Duration) error {
+	limit, err := s.cfg.RequestTTLLimit()
+	if err != nil {
+		return err
+	}
+	if d <= 0 {
+		return apperrors.Newf(apperrors.CodeInvalidArgument, "%s must be positive", what)
+	}
+	if d > limit {
+		return apperrors.Newf(apperrors.CodeInvalidArgument, "%s %s exceeds this node's limit of %s", what, d, limit)
+	}
+	return nil
+}
+
+// manager returns the consent manager for creating requests with
+// correlationID, or with new IDs if it is empty
+func (s *ConsentService) manager(correlationID string) *consent.Manager {
+	if correlationID == "" {
+		return s.consentMgr
+	}
+	return s.consentMgr.WithCorrelationID(correlationID)
 }
 
 // ListPendingRequests returns all pending restore requests
@@ -46,20 +265,176 @@ func (s *ConsentService) GetRequest(id string) (*consent.RestoreRequest, error)
 }
 
 // ApproveRequest approves a restore request with the local share
-func (s *ConsentService) ApproveRequest(id string, share []byte) error {
+func (s *ConsentService) ApproveRequest(id string, share []byte, terms *consent.RestoreTerms) error {
+	if err := genesis.CheckConfig(s.cfg); err != nil {
+		return err
+	}
+	if terms != nil {
+		if err := terms.Validate(); err != nil {
+			return apperrors.Newf(apperrors.CodeInvalidArgument, "invalid restore terms: %v", err)
+		}
+	}
 	if share == nil {
 		localShare, _, err := s.cfg.LoadShare()
 		if err != nil {
-			return errors.New("no share available")
+			return apperrors.New(apperrors.CodeNoLocalShare, "no share available")
 		}
 		share = localShare
 	}
-	return s.consentMgr.Approve(id, s.cfg.Name, share)
+	return s.consentMgr.WithRestoreTerms(terms).Approve(id, s.cfg.Name, share)
+}
+
+// ApproveAsNode approves a restore request on behalf of this node's key
+// holder: signed with the node's key in consensus mode, or by releasing
+// the local share otherwise. It backs approvals made without the CLI, such
+// as from the passkey approval page.
+func (s *ConsentService) ApproveAsNode(id string) (*ApprovalProgress, error) {
+	if !s.cfg.UsesConsensusMode() && s.cfg.PrivateKey == nil {
+		if err := s.ApproveRequest(id, nil, nil); err != nil {
+			return nil, err
+		}
+		return s.GetApprovalProgress(id)
+	}
+	if s.cfg.PrivateKey == nil {
+		return nil, apperrors.New(apperrors.CodeFailedPrecondition, "this node has no signing key")
+	}
+
+	req, err := s.consentMgr.GetRequest(id)
+	if err != nil {
+		return nil, err
+	}
+	keyID := crypto.KeyID(s.cfg.PublicKey)
+	challenge, err := s.IssueChallenge(id, keyID)
+	if err != nil {
+		return nil, err
+	}
+	signedAt := time.Now().Truncate(time.Second)
+	signature, err := req.ApprovalSignData(keyID, challenge.Nonce, signedAt).Sign(s.cfg.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign request: %w", err)
+	}
+	return s.SignRequest(SignRequestParams{
+		RequestID:   id,
+		KeyHolderID: keyID,
+		Signature:   signature,
+		Nonce:       challenge.Nonce,
+		SignedAt:    signedAt,
+	})
 }
 
 // DenyRequest denies a restore request
 func (s *ConsentService) DenyRequest(id string) error {
-	return s.consentMgr.Deny(id, s.cfg.Name)
+	return consent.Restores.Deny(s.consentMgr, id, s.cfg.Name)
+}
+
+// RequestExtension asks for a pending request's expiry to be pushed back
+// by by, up to the configured limit
+func (s *ConsentService) RequestExtension(id string, by time.Duration, reason string) (*consent.Extension, error) {
+	if err := s.checkTTL("extension", by); err != nil {
+		return nil, err
+	}
+	return s.consentMgr.RequestExtension(id, by, reason)
+}
+
+// ApproveExtensionParams contains a key holder's signed approval of a
+// request's pending extension
+type ApproveExtensionParams struct {
+	RequestID   string
+	KeyHolderID string
+	// Signature is over the request's ExtensionSignData
+	Signature []byte
+	// Nonce is from a challenge this node issued to the key holder
+	Nonce string
+	// SignedAt is when the key holder signed, by their clock
+	SignedAt time.Time
+}
+
+// ApproveExtension approves a pending request's extension as a registered
+// key holder, who signs the new expiry like an approval of the request
+func (s *ConsentService) ApproveExtension(params ApproveExtensionParams) (*consent.RestoreRequest, error) {
+	holder := s.cfg.GetKeyHolder(params.KeyHolderID)
+	if holder == nil {
+		return nil, apperrors.New(apperrors.CodeKeyHolderNotFound, "unknown key holder")
+	}
+	if params.Nonce == "" {
+		return nil, apperrors.ErrChallengeInvalid
+	}
+	if err := genesis.CheckConfig(s.cfg); err != nil {
+		return nil, err
+	}
+	if err := s.checkSignedAt(params.SignedAt); err != nil {
+		return nil, err
+	}
+
+	req, err := s.consentMgr.GetRequest(params.RequestID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := req.ExtensionSignData(params.KeyHolderID, params.Nonce, params.SignedAt)
+	if err != nil {
+		return nil, err
+	}
+	valid, err := data.Verify(holder.PublicKey, params.Signature)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, apperrors.ErrInvalidSignature
+	}
+
+	return s.consentMgr.ApproveExtension(params.RequestID, params.KeyHolderID, holder.Name, params.Nonce, params.SignedAt, params.Signature)
+}
+
+// VetoRequestParams contains a key holder's signed veto of a request
+type VetoRequestParams struct {
+	RequestID   string
+	KeyHolderID string
+	Reason      string
+	// Signature is over the request's VetoSignData, which covers the reason
+	Signature []byte
+	// Nonce is from a challenge this node issued to the key holder
+	Nonce string
+	// SignedAt is when the key holder signed, by their clock
+	SignedAt time.Time
+}
+
+// VetoRequest stops a pending request, or an approved one still cooling
+// off, as a registered key holder, who signs the veto like an approval
+func (s *ConsentService) VetoRequest(params VetoRequestParams) (*consent.RestoreRequest, error) {
+	holder := s.cfg.GetKeyHolder(params.KeyHolderID)
+	if holder == nil {
+		return nil, apperrors.New(apperrors.CodeKeyHolderNotFound, "unknown key holder")
+	}
+	if params.Nonce == "" {
+		return nil, apperrors.ErrChallengeInvalid
+	}
+	if err := genesis.CheckConfig(s.cfg); err != nil {
+		return nil, err
+	}
+	if err := s.checkSignedAt(params.SignedAt); err != nil {
+		return nil, err
+	}
+
+	req, err := s.consentMgr.GetRequest(params.RequestID)
+	if err != nil {
+		return nil, err
+	}
+	data := req.VetoSignData(params.KeyHolderID, params.Nonce, params.Reason, params.SignedAt)
+	valid, err := data.Verify(holder.PublicKey, params.Signature)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, apperrors.ErrInvalidSignature
+	}
+
+	return s.consentMgr.Veto(params.RequestID, params.KeyHolderID, holder.Name, params.Nonce, params.Reason, params.SignedAt, params.Signature)
+}
+
+// ReportRestoreProgress records how far an approved request's restore has
+// got, as reported by the node executing it
+func (s *ConsentService) ReportRestoreProgress(id string, p consent.RestoreProgress) (*consent.RestoreRequest, error) {
+	return s.consentMgr.RecordProgress(id, p)
 }
 
 // SignRequestParams contains parameters for signing a request
@@ -67,14 +442,50 @@ type SignRequestParams struct {
 	RequestID   string
 	KeyHolderID string
 	Signature   []byte
+	// Nonce is the challenge issued by IssueChallenge that the signature covers
+	Nonce string
+	// SignedAt is when the key holder signed, by their clock; the signature
+	// covers it
+	SignedAt time.Time
+
+	// DelegationID, if set, approves with the delegating key holder's
+	// authority; KeyHolderID must be the delegate
+	DelegationID string
+
+	// Terms, if set, are conditions the restore must keep to
+	Terms *consent.RestoreTerms
+}
+
+// IssueChallenge issues a registered key holder a nonce to sign a
+// request's approval with
+func (s *ConsentService) IssueChallenge(requestID, keyHolderID string) (*consent.Challenge, error) {
+	if s.cfg.GetKeyHolder(keyHolderID) == nil {
+		return nil, apperrors.New(apperrors.CodeKeyHolderNotFound, "unknown key holder")
+	}
+	return s.consentMgr.IssueChallenge(requestID, keyHolderID)
 }
 
-// SignRequest adds a signature to a restore request (consensus mode)
+// SignRequest adds a signature to a restore request (consensus mode). The
+// signature must cover a challenge issued to the key holder.
 func (s *ConsentService) SignRequest(params SignRequestParams) (*ApprovalProgress, error) {
 	// Verify key holder exists
 	holder := s.cfg.GetKeyHolder(params.KeyHolderID)
 	if holder == nil {
-		return nil, errors.New("unknown key holder")
+		return nil, apperrors.New(apperrors.CodeKeyHolderNotFound, "unknown key holder")
+	}
+	if params.Nonce == "" {
+		return nil, apperrors.ErrChallengeInvalid
+	}
+	if err := genesis.CheckConfig(s.cfg); err != nil {
+		return nil, err
+	}
+	if err := s.checkSignedAt(params.SignedAt); err != nil {
+		return nil, err
+	}
+	if params.Terms != nil {
+		if err := params.Terms.Validate(); err != nil {
+			return nil, apperrors.Newf(apperrors.CodeInvalidArgument, "invalid restore terms: %v", err)
+		}
 	}
 
 	// Get the request
@@ -84,30 +495,270 @@ func (s *ConsentService) SignRequest(params SignRequestParams) (*ApprovalProgres
 	}
 
 	// Verify signature
-	valid, err := (&crypto.RestoreRequestSignData{
-		RequestID:   req.ID,
-		Requester:   req.Requester,
-		SnapshotID:  req.SnapshotID,
-		Reason:      req.Reason,
-		KeyHolderID: params.KeyHolderID,
-		Paths:       req.Paths,
-		CreatedAt:   req.CreatedAt.Unix(),
-	}).Verify(holder.PublicKey, params.Signature)
+	valid, err := req.ApprovalSignData(params.KeyHolderID, params.Nonce, params.SignedAt).Verify(holder.PublicKey, params.Signature)
 	if err != nil {
 		return nil, err
 	}
 	if !valid {
-		return nil, errors.New("invalid signature")
+		return nil, apperrors.ErrInvalidSignature
+	}
+
+	// A delegate approves with the delegating key holder's authority
+	authority := params.KeyHolderID
+	var delegation *consent.Delegation
+	if params.DelegationID != "" {
+		delegation, err = s.verifiedDelegation(params.DelegationID)
+		if err != nil {
+			return nil, err
+		}
+		if delegation.ToKeyHolderID != params.KeyHolderID {
+			return nil, fmt.Errorf("delegation %s was not made to %s", delegation.ID, params.KeyHolderID)
+		}
+		authority = delegation.FromKeyHolderID
+	}
+
+	// Under a quorum rule, a zero-weight key can't contribute an approval
+	if req.Quorum != nil && req.Quorum.Weight(authority) == 0 {
+		return nil, fmt.Errorf("key holder %s carries no weight in this request's quorum", authority)
 	}
 
 	// Add the signature
-	if err := s.consentMgr.AddSignature(params.RequestID, params.KeyHolderID, holder.Name, params.Signature); err != nil {
+	mgr := s.consentMgr.WithRestoreTerms(params.Terms)
+	if delegation != nil {
+		err = mgr.AddDelegatedSignature(params.RequestID, delegation.ID, holder.Name, params.Nonce, params.SignedAt, params.Signature)
+	} else {
+		err = mgr.AddSignature(params.RequestID, params.KeyHolderID, holder.Name, params.Nonce, params.SignedAt, params.Signature)
+	}
+	if err != nil {
 		return nil, err
 	}
 
 	return s.GetApprovalProgress(params.RequestID)
 }
 
+// checkSignedAt checks an approval's signed timestamp against this node's
+// clock, so the recorded time can be trusted. It may trail by up to the
+// challenge's lifetime, and differ by the clock skew tolerance.
+func (s *ConsentService) checkSignedAt(signedAt time.Time) error {
+	if signedAt.IsZero() {
+		return apperrors.New(apperrors.CodeInvalidArgument, "approval must carry the time it was signed")
+	}
+	tolerance := s.consentMgr.ClockSkewTolerance()
+	if skew := time.Since(signedAt); skew > consent.ChallengeTTL+tolerance || skew < -tolerance {
+		return apperrors.Newf(apperrors.CodeInvalidSignature, "approval was signed at %s, %s off this node's clock (tolerance %s); check the clocks",
+			signedAt.UTC().Format(time.RFC3339), skew.Abs().Round(time.Second), tolerance)
+	}
+	return nil
+}
+
+// --- Delegations ---
+
+// RegisterDelegation checks a delegation is between registered key holders
+// and signed by the delegating one, then records it
+func (s *ConsentService) RegisterDelegation(d *consent.Delegation) error {
+	if s.cfg.GetKeyHolder(d.ToKeyHolderID) == nil {
+		return apperrors.Newf(apperrors.CodeKeyHolderNotFound, "unknown key holder %s", d.ToKeyHolderID)
+	}
+	if err := s.verifyDelegationSignature(d); err != nil {
+		return err
+	}
+	return s.consentMgr.AddDelegation(d)
+}
+
+// RevokeDelegation ends a delegation early; the signature must be the
+// delegating key holder's over the delegation's revoke data
+func (s *ConsentService) RevokeDelegation(id string, signature []byte) (*consent.Delegation, error) {
+	d, err := s.consentMgr.GetDelegation(id)
+	if err != nil {
+		return nil, err
+	}
+	holder := s.cfg.GetKeyHolder(d.FromKeyHolderID)
+	if holder == nil {
+		return nil, apperrors.Newf(apperrors.CodeKeyHolderNotFound, "unknown key holder %s", d.FromKeyHolderID)
+	}
+	valid, err := d.RevokeSignData().Verify(holder.PublicKey, signature)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, apperrors.New(apperrors.CodeInvalidSignature, "invalid revocation signature")
+	}
+	return s.consentMgr.RevokeDelegation(id, signature)
+}
+
+// ListDelegations returns all delegations and their audit trail
+func (s *ConsentService) ListDelegations() ([]*consent.Delegation, []consent.DelegationEvent, error) {
+	return s.consentMgr.ListDelegations()
+}
+
+// verifiedDelegation loads a delegation and re-checks its signature, so a
+// tampered delegations file can't grant authority
+func (s *ConsentService) verifiedDelegation(id string) (*consent.Delegation, error) {
+	d, err := s.consentMgr.GetDelegation(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verifyDelegationSignature(d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (s *ConsentService) verifyDelegationSignature(d *consent.Delegation) error {
+	holder := s.cfg.GetKeyHolder(d.FromKeyHolderID)
+	if holder == nil {
+		return apperrors.Newf(apperrors.CodeKeyHolderNotFound, "unknown key holder %s", d.FromKeyHolderID)
+	}
+	valid, err := d.SignData().Verify(holder.PublicKey, d.Signature)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return apperrors.New(apperrors.CodeInvalidSignature, "invalid delegation signature")
+	}
+	return nil
+}
+
+// --- Social recovery ---
+
+// recoveryPolicy returns the social recovery configured at init
+func (s *ConsentService) recoveryPolicy() (*consent.RecoveryPolicy, error) {
+	if s.cfg.SocialRecovery == nil {
+		return nil, apperrors.New(apperrors.CodeFailedPrecondition, "social recovery is not configured")
+	}
+	return s.cfg.SocialRecovery, nil
+}
+
+// OpenRecovery checks a recovery names a registered key holder, was just
+// opened and is signed by a recovery contact, then records it. It becomes
+// usable after the configured waiting period.
+func (s *ConsentService) OpenRecovery(r *consent.Recovery) error {
+	policy, err := s.recoveryPolicy()
+	if err != nil {
+		return err
+	}
+	wait, err := policy.Wait()
+	if err != nil {
+		return err
+	}
+	holder := s.cfg.GetKeyHolder(r.MissingKeyHolderID)
+	if holder == nil {
+		return apperrors.Newf(apperrors.CodeKeyHolderNotFound, "unknown key holder %s", r.MissingKeyHolderID)
+	}
+	r.MissingKeyHolderName = holder.Name
+
+	// The waiting period runs from OpenedAt, so it can't be backdated
+	tolerance := s.consentMgr.ClockSkewTolerance()
+	if skew := time.Since(r.OpenedAt); skew > consent.ChallengeTTL+tolerance || skew < -tolerance {
+		return apperrors.Newf(apperrors.CodeInvalidArgument, "recovery was opened at %s, %s off this node's clock (tolerance %s); check the clocks",
+			r.OpenedAt.UTC().Format(time.RFC3339), skew.Abs().Round(time.Second), tolerance)
+	}
+	if len(r.Signatures) != 1 {
+		return apperrors.New(apperrors.CodeInvalidArgument, "recovery must be signed by the contact opening it")
+	}
+	if err := verifyRecoverySignature(policy, r, &r.Signatures[0]); err != nil {
+		return err
+	}
+	return s.consentMgr.OpenRecovery(r, wait)
+}
+
+// SignRecovery adds another recovery contact's signature to a recovery
+func (s *ConsentService) SignRecovery(id, contactID string, signature []byte) (*consent.Recovery, error) {
+	policy, err := s.recoveryPolicy()
+	if err != nil {
+		return nil, err
+	}
+	r, err := s.consentMgr.GetRecovery(id)
+	if err != nil {
+		return nil, err
+	}
+	sig := consent.RecoverySignature{ContactID: contactID, Signature: signature, SignedAt: time.Now()}
+	if err := verifyRecoverySignature(policy, r, &sig); err != nil {
+		return nil, err
+	}
+	return s.consentMgr.AddRecoverySignature(id, sig)
+}
+
+// CancelRecovery ends a recovery; the signature must be a registered key
+// holder's over the recovery's cancel data. The key holder it claims is
+// lost cancels it simply by showing they still have their key.
+func (s *ConsentService) CancelRecovery(id, keyHolderID string, signature []byte) (*consent.Recovery, error) {
+	r, err := s.consentMgr.GetRecovery(id)
+	if err != nil {
+		return nil, err
+	}
+	holder := s.cfg.GetKeyHolder(keyHolderID)
+	if holder == nil {
+		return nil, apperrors.Newf(apperrors.CodeKeyHolderNotFound, "unknown key holder %s", keyHolderID)
+	}
+	valid, err := r.CancelSignData().Verify(holder.PublicKey, signature)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, apperrors.New(apperrors.CodeInvalidSignature, "invalid cancellation signature")
+	}
+	return s.consentMgr.CancelRecovery(id, keyHolderID, signature)
+}
+
+// UseRecovery approves a restore request in place of the key holder a
+// recovery is for. Enough recovery contacts must have signed it, and its
+// waiting period passed.
+func (s *ConsentService) UseRecovery(recoveryID, requestID string) (*ApprovalProgress, error) {
+	policy, err := s.recoveryPolicy()
+	if err != nil {
+		return nil, err
+	}
+	r, err := s.consentMgr.GetRecovery(recoveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Re-check the signatures, so a tampered recoveries file can't approve
+	signed := 0
+	for i := range r.Signatures {
+		if verifyRecoverySignature(policy, r, &r.Signatures[i]) == nil {
+			signed++
+		}
+	}
+	if signed < policy.Threshold {
+		return nil, apperrors.Newf(apperrors.CodeRecoveryNotUsable, "recovery has %d of the %d contact signatures it needs", signed, policy.Threshold)
+	}
+
+	if err := s.consentMgr.UseRecovery(requestID, recoveryID); err != nil {
+		return nil, err
+	}
+	return s.GetApprovalProgress(requestID)
+}
+
+// GetRecovery returns a recovery by ID
+func (s *ConsentService) GetRecovery(id string) (*consent.Recovery, error) {
+	return s.consentMgr.GetRecovery(id)
+}
+
+// ListRecoveries returns all recoveries and their audit trail
+func (s *ConsentService) ListRecoveries() ([]*consent.Recovery, []consent.RecoveryEvent, error) {
+	return s.consentMgr.ListRecoveries()
+}
+
+// verifyRecoverySignature checks sig is a recovery contact's over r, and
+// fills in the contact's name
+func verifyRecoverySignature(policy *consent.RecoveryPolicy, r *consent.Recovery, sig *consent.RecoverySignature) error {
+	contact := policy.Contact(sig.ContactID)
+	if contact == nil {
+		return apperrors.Newf(apperrors.CodeInvalidSignature, "%s is not a recovery contact", sig.ContactID)
+	}
+	valid, err := r.SignData().Verify(contact.PublicKey, sig.Signature)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return apperrors.Newf(apperrors.CodeInvalidSignature, "invalid recovery signature from %s", contact.Name)
+	}
+	sig.ContactName = contact.Name
+	return nil
+}
+
 // ApprovalProgress represents the approval status of a request
 type ApprovalProgress struct {
 	Current    int
@@ -137,11 +788,31 @@ type CreateDeletionRequestParams struct {
 	Paths             []string
 	Reason            string
 	RequiredApprovals int
+
+	// Retention, if set, makes a prune request forgetting what it removes
+	Retention *consent.RetentionPlan
+
+	// CorrelationID, if set, becomes the request's correlation ID
+	CorrelationID string
 }
 
 // CreateDeletionRequest creates a new deletion request
 func (s *ConsentService) CreateDeletionRequest(params CreateDeletionRequestParams) (*consent.DeletionRequest, error) {
-	return s.consentMgr.CreateDeletionRequest(
+	quorum, err := s.cfg.Quorum()
+	if err != nil {
+		return nil, fmt.Errorf("invalid quorum rule: %w", err)
+	}
+	mgr := s.manager(params.CorrelationID).WithDeletionQuorum(quorum)
+	if plan := params.Retention; plan != nil {
+		if err := s.checkPins(consent.DeletionTypePrune, plan.RemoveIDs()); err != nil {
+			return nil, err
+		}
+		return mgr.CreateRetentionDeletion(s.cfg.Name, plan, params.Reason, params.RequiredApprovals)
+	}
+	if err := s.checkPins(params.DeletionType, params.SnapshotIDs); err != nil {
+		return nil, err
+	}
+	return mgr.CreateDeletionRequest(
 		s.cfg.Name,
 		params.DeletionType,
 		params.SnapshotIDs,
@@ -163,6 +834,10 @@ func (s *ConsentService) GetDeletionRequest(id string) (*consent.DeletionRequest
 
 // ApproveDeletion approves a deletion request
 func (s *ConsentService) ApproveDeletion(id, keyHolderID string, signature []byte) (*ApprovalProgress, error) {
+	if err := genesis.CheckConfig(s.cfg); err != nil {
+		return nil, err
+	}
+
 	// Get key holder name
 	keyHolderName := keyHolderID
 	if s.cfg.Consensus != nil {
@@ -174,14 +849,34 @@ func (s *ConsentService) ApproveDeletion(id, keyHolderID string, signature []byt
 		}
 	}
 
-	if err := s.consentMgr.ApproveDeletion(id, keyHolderID, keyHolderName, signature); err != nil {
+	// A pin may have been set after the request was made
+	req, err := s.consentMgr.GetDeletionRequest(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkPins(req.DeletionType, req.SnapshotIDs); err != nil {
+		return nil, err
+	}
+
+	// Under a quorum rule, a zero-weight key can't contribute an approval
+	if req.Quorum != nil && req.Quorum.Weight(keyHolderID) == 0 {
+		return nil, fmt.Errorf("key holder %s carries no weight in this request's quorum", keyHolderID)
+	}
+
+	if _, err := consent.Deletions.AddApproval(s.consentMgr, id, consent.Approval{
+		KeyHolderID:   keyHolderID,
+		KeyHolderName: keyHolderName,
+		Signature:     signature,
+		ApprovedAt:    time.Now(),
+	}); err != nil {
 		return nil, err
 	}
 
-	current, required, err := s.consentMgr.GetDeletionApprovalProgress(id)
+	progress, err := consent.Deletions.Progress(s.consentMgr, id)
 	if err != nil {
 		return nil, err
 	}
+	current, required := progress.Counts()
 
 	return &ApprovalProgress{
 		Current:    current,
@@ -190,7 +885,94 @@ func (s *ConsentService) ApproveDeletion(id, keyHolderID string, signature []byt
 	}, nil
 }
 
+// checkPins refuses deleting pinned snapshots, or the whole repository
+// while anything in it is pinned
+func (s *ConsentService) checkPins(deletionType consent.DeletionType, snapshotIDs []string) error {
+	pins, err := policy.LoadPinSet(s.cfg.PinsPath())
+	if err != nil {
+		return err
+	}
+	if deletionType == consent.DeletionTypeAll && len(pins.Pins) > 0 {
+		return apperrors.Newf(apperrors.CodeSnapshotPinned, "repository has %d pinned snapshot(s)", len(pins.Pins))
+	}
+	for _, id := range snapshotIDs {
+		if pin := pins.Pinned(id); pin != nil {
+			return apperrors.Newf(apperrors.CodeSnapshotPinned, "snapshot %s is pinned by the %s: %s", id, pin.SetBy, pin.Label)
+		}
+	}
+	return nil
+}
+
 // DenyDeletion denies a deletion request
 func (s *ConsentService) DenyDeletion(id string) error {
-	return s.consentMgr.DenyDeletion(id, s.cfg.Name)
+	return consent.Deletions.Deny(s.consentMgr, id, s.cfg.Name)
+}
+
+// --- Operations ---
+
+// CreateOperationParams contains parameters for requesting an operation
+type CreateOperationParams struct {
+	Type    string
+	Reason  string
+	Payload json.RawMessage
+
+	// CorrelationID, if set, becomes the request's correlation ID
+	CorrelationID string
+}
+
+// CreateOperation requests an operation of a registered type, needing as
+// many approvals as restores do
+func (s *ConsentService) CreateOperation(params CreateOperationParams) (consent.AnyOperation, error) {
+	return s.manager(params.CorrelationID).CreateOperation(params.Type, s.cfg.Name, params.Reason, params.Payload, s.cfg.RequiredApprovals())
+}
+
+// ListPendingOperations returns the pending operations of every type
+func (s *ConsentService) ListPendingOperations() ([]consent.AnyOperation, error) {
+	return s.consentMgr.ListPendingOperations()
+}
+
+// GetOperation returns a specific operation by ID
+func (s *ConsentService) GetOperation(id string) (consent.AnyOperation, error) {
+	return s.consentMgr.GetOperation(id)
+}
+
+// OperationSignData returns the data key holder keyHolderID signs to
+// approve an operation
+func (s *ConsentService) OperationSignData(id, keyHolderID string) (*crypto.OperationSignData, error) {
+	return s.consentMgr.OperationSignData(id, keyHolderID)
+}
+
+// ApproveOperation adds a key holder's approval to an operation, executing
+// it once enough approve. The signature must be the key holder's over the
+// operation's sign data, which binds its type's scope and payload.
+func (s *ConsentService) ApproveOperation(ctx context.Context, id, keyHolderID string, signature []byte) (consent.AnyOperation, error) {
+	holder := s.cfg.GetKeyHolder(keyHolderID)
+	if holder == nil {
+		return nil, apperrors.Newf(apperrors.CodeKeyHolderNotFound, "unknown key holder %s", keyHolderID)
+	}
+	if err := genesis.CheckConfig(s.cfg); err != nil {
+		return nil, err
+	}
+	data, err := s.consentMgr.OperationSignData(id, keyHolderID)
+	if err != nil {
+		return nil, err
+	}
+	valid, err := data.Verify(holder.PublicKey, signature)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, apperrors.ErrInvalidSignature
+	}
+	return s.consentMgr.ApproveOperation(ctx, id, keyHolderID, holder.Name, signature)
+}
+
+// ExecuteOperation retries an approved operation whose execution failed
+func (s *ConsentService) ExecuteOperation(ctx context.Context, id string) (consent.AnyOperation, error) {
+	return s.consentMgr.ExecuteOperation(ctx, id)
+}
+
+// DenyOperation denies an operation
+func (s *ConsentService) DenyOperation(id string) error {
+	return s.consentMgr.DenyOperation(id, s.cfg.Name)
 }
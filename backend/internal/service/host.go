@@ -1,10 +1,11 @@
 package service
 
 import (
-	"errors"
+	"time"
 
 	"github.com/lcrostarosa/airgapper/backend/internal/config"
 	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
 	"github.com/lcrostarosa/airgapper/backend/internal/storage"
 )
 
@@ -43,7 +44,7 @@ type HostInitResult struct {
 // Init initializes this node as a backup host
 func (s *HostService) Init(params HostInitParams) (*HostInitResult, error) {
 	if s.cfg.Name != "" {
-		return nil, errors.New("host already initialized")
+		return nil, apperrors.New(apperrors.CodeAlreadyInitialized, "host already initialized")
 	}
 
 	// Generate host's key pair
@@ -87,6 +88,13 @@ type StorageStatus struct {
 	DiskUsagePct   int
 	DiskFreeBytes  int64
 	DiskTotalBytes int64
+
+	PolicyMaxStorageBytes int64
+	EffectiveQuotaBytes   int64
+	QuotaUsagePct         int
+
+	// Maintenance is set while the server is read-only for maintenance
+	Maintenance *storage.Maintenance
 }
 
 // GetStorageStatus returns the current storage server status
@@ -109,13 +117,19 @@ func (s *HostService) GetStorageStatus() StorageStatus {
 		DiskUsagePct:   status.DiskUsagePct,
 		DiskFreeBytes:  status.DiskFreeBytes,
 		DiskTotalBytes: status.DiskTotalBytes,
+
+		PolicyMaxStorageBytes: status.PolicyMaxStorageBytes,
+		EffectiveQuotaBytes:   status.EffectiveQuotaBytes,
+		QuotaUsagePct:         status.QuotaUsagePct,
+
+		Maintenance: status.Maintenance,
 	}
 }
 
 // StartStorage starts the storage server
 func (s *HostService) StartStorage() error {
 	if s.storageServer == nil {
-		return errors.New("storage server not configured")
+		return apperrors.New(apperrors.CodeStorageNotConfigured, "storage server not configured")
 	}
 	s.storageServer.Start()
 	return nil
@@ -124,16 +138,31 @@ func (s *HostService) StartStorage() error {
 // StopStorage stops the storage server
 func (s *HostService) StopStorage() error {
 	if s.storageServer == nil {
-		return errors.New("storage server not configured")
+		return apperrors.New(apperrors.CodeStorageNotConfigured, "storage server not configured")
 	}
 	s.storageServer.Stop()
 	return nil
 }
 
+// SetStorageMaintenance puts the storage server in read-only maintenance
+// mode, with the expected end if known, or takes it out again
+func (s *HostService) SetStorageMaintenance(enabled bool, reason string, until time.Time) error {
+	if s.storageServer == nil {
+		return apperrors.New(apperrors.CodeStorageNotConfigured, "storage server not configured")
+	}
+	if !enabled {
+		return s.storageServer.EndMaintenance()
+	}
+	_, err := s.storageServer.SetMaintenance(reason, until)
+	return err
+}
+
 // ReceiveShare stores a key share received from the owner
 func (s *HostService) ReceiveShare(share []byte, shareIndex byte, repoURL, peerName string) error {
 	s.cfg.LocalShare = share
 	s.cfg.ShareIndex = shareIndex
+	// A new share invalidates the owner fingerprint recorded for the old one
+	s.cfg.ShareCheck = nil
 	s.cfg.RepoURL = repoURL
 	s.cfg.Peer = &config.PeerInfo{
 		Name: peerName,
@@ -1,7 +1,11 @@
 package service
 
 import (
+	"time"
+
 	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/peerpin"
+	"github.com/lcrostarosa/airgapper/backend/internal/replication"
 	"github.com/lcrostarosa/airgapper/backend/internal/scheduler"
 )
 
@@ -34,12 +38,15 @@ type SystemStatus struct {
 	Consensus       *ConsensusStatus
 	Mode            string
 	Scheduler       *SchedulerStatus
+	Replication     []ReplicationStatus
 }
 
 // PeerStatus represents peer information
 type PeerStatus struct {
 	Name    string
 	Address string
+	// IdentityChanged is set while the peer's identity differs from its pin
+	IdentityChanged bool
 }
 
 // ConsensusStatus represents consensus configuration
@@ -61,10 +68,30 @@ type KeyHolderStatus struct {
 type SchedulerStatus struct {
 	Enabled   bool
 	Schedule  string
+	Timezone  string
 	Paths     []string
 	LastRun   string
 	LastError string
 	NextRun   string
+
+	// Healthy is false once ConsecutiveFailures reaches FailureThreshold
+	Healthy             bool
+	ConsecutiveFailures int
+	FailureThreshold    int
+}
+
+// ReplicationStatus represents the state of a replication target
+type ReplicationStatus struct {
+	Name            string
+	RepoURL         string
+	Enabled         bool
+	LastRun         time.Time
+	LastSuccess     time.Time
+	LastError       string
+	SourceSnapshots int
+	TargetSnapshots int
+	Missing         int
+	InSync          bool
 }
 
 // GetSystemStatus returns the current system status
@@ -94,6 +121,9 @@ func (s *StatusService) GetSystemStatus(pendingCount int) *SystemStatus {
 			Name:    s.cfg.Peer.Name,
 			Address: s.cfg.Peer.Address,
 		}
+		if pin, err := peerpin.NewStore(s.cfg.ConfigDir).Get(); err == nil && pin.Err() != nil {
+			status.Peer.IdentityChanged = true
+		}
 	}
 
 	// Add consensus info
@@ -117,10 +147,15 @@ func (s *StatusService) GetSystemStatus(pendingCount int) *SystemStatus {
 	// Add scheduler info
 	if s.scheduler != nil {
 		lastRun, nextRun, lastErr := s.scheduler.Status()
+		health := s.scheduler.Health()
 		schedStatus := &SchedulerStatus{
-			Enabled:  true,
-			Schedule: s.cfg.BackupSchedule,
-			Paths:    s.cfg.BackupPaths,
+			Enabled:             true,
+			Schedule:            s.cfg.BackupSchedule,
+			Timezone:            s.scheduler.GetSchedule().Timezone(),
+			Paths:               s.cfg.BackupPaths,
+			Healthy:             health.Healthy,
+			ConsecutiveFailures: health.ConsecutiveFailures,
+			FailureThreshold:    health.FailureThreshold,
 		}
 		if !lastRun.IsZero() {
 			schedStatus.LastRun = lastRun.Format("2006-01-02T15:04:05Z07:00")
@@ -134,25 +169,60 @@ func (s *StatusService) GetSystemStatus(pendingCount int) *SystemStatus {
 		status.Scheduler = schedStatus
 	}
 
+	// Add replication info
+	if len(s.cfg.Replication) > 0 {
+		status.Replication = s.replicationStatus()
+	}
+
 	return status
 }
 
+// replicationStatus combines configured targets with their last recorded run
+func (s *StatusService) replicationStatus() []ReplicationStatus {
+	recorded := replication.LoadStatus(s.cfg.ConfigDir)
+	statuses := make([]ReplicationStatus, len(s.cfg.Replication))
+	for i, t := range s.cfg.Replication {
+		r := recorded[t.Name]
+		statuses[i] = ReplicationStatus{
+			Name:            t.Name,
+			RepoURL:         t.RepoURL,
+			Enabled:         t.Enabled,
+			LastRun:         r.LastRun,
+			LastSuccess:     r.LastSuccess,
+			LastError:       r.LastError,
+			SourceSnapshots: r.SourceSnapshots,
+			TargetSnapshots: r.TargetSnapshots,
+			Missing:         r.MissingCount,
+			InSync:          r.InSync,
+		}
+	}
+	return statuses
+}
+
 // GetScheduleInfo returns current schedule configuration
 type ScheduleInfo struct {
 	Schedule  string
+	Timezone  string
 	Paths     []string
 	Enabled   bool
 	LastRun   string
 	LastError string
 	NextRun   string
+	Timing    scheduler.TimingConfig
 }
 
 func (s *StatusService) GetScheduleInfo() *ScheduleInfo {
 	info := &ScheduleInfo{
 		Schedule: s.cfg.BackupSchedule,
+		Timezone: s.cfg.ScheduleTimezone(),
 		Paths:    s.cfg.BackupPaths,
 		Enabled:  s.scheduler != nil,
 	}
+	if s.cfg.BackupTiming != nil {
+		info.Timing = *s.cfg.BackupTiming
+	} else {
+		info.Timing = scheduler.FormatTiming(scheduler.DefaultTiming())
+	}
 
 	if s.scheduler != nil {
 		lastRun, nextRun, lastErr := s.scheduler.Status()
@@ -170,8 +240,27 @@ func (s *StatusService) GetScheduleInfo() *ScheduleInfo {
 	return info
 }
 
-// UpdateSchedule updates the backup schedule
-func (s *StatusService) UpdateSchedule(schedule string, paths []string) error {
+// UpdateSchedule updates the backup schedule. A non-nil timezone replaces
+// the one the schedule's times are in. A non-nil timing replaces the
+// catch-up, jitter and clock jump settings and applies to the running
+// scheduler immediately.
+func (s *StatusService) UpdateSchedule(schedule string, paths []string, timezone *string, timing *scheduler.TimingConfig) error {
+	if timezone != nil {
+		if _, err := scheduler.LoadTimezone(*timezone); err != nil {
+			return err
+		}
+		s.cfg.BackupTimezone = *timezone
+	}
+	if timing != nil {
+		parsed, err := timing.Parse()
+		if err != nil {
+			return err
+		}
+		s.cfg.BackupTiming = timing
+		if s.scheduler != nil {
+			s.scheduler.UpdateTiming(parsed)
+		}
+	}
 	return s.cfg.SetSchedule(schedule, paths)
 }
 
@@ -9,6 +9,8 @@ import (
 
 	"github.com/lcrostarosa/airgapper/backend/internal/config"
 	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/genesis"
 )
 
 // VaultService handles vault-related business logic
@@ -43,7 +45,7 @@ type InitResult struct {
 // Init initializes a new vault as the owner
 func (s *VaultService) Init(params InitParams) (*InitResult, error) {
 	if s.cfg.Name != "" {
-		return nil, errors.New("vault already initialized")
+		return nil, apperrors.New(apperrors.CodeAlreadyInitialized, "vault already initialized")
 	}
 
 	// Generate owner's key pair
@@ -87,6 +89,13 @@ func (s *VaultService) Init(params InitParams) (*InitResult, error) {
 	if err := s.cfg.Save(); err != nil {
 		return nil, err
 	}
+	doc, err := genesis.New(s.cfg, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := doc.Save(s.cfg); err != nil {
+		return nil, err
+	}
 
 	return &InitResult{
 		Name:      s.cfg.Name,
@@ -114,7 +123,12 @@ type RegisterKeyHolderResult struct {
 // RegisterKeyHolder adds a new key holder to the consensus scheme
 func (s *VaultService) RegisterKeyHolder(params RegisterKeyHolderParams) (*RegisterKeyHolderResult, error) {
 	if s.cfg.Consensus == nil {
-		return nil, errors.New("consensus mode not configured")
+		return nil, apperrors.New(apperrors.CodeConsensusNotConfigured, "consensus mode not configured")
+	}
+
+	// Refuse to change the key set of a vault whose config was tampered with
+	if err := genesis.CheckConfig(s.cfg); err != nil {
+		return nil, err
 	}
 
 	// Decode public key
@@ -152,7 +166,7 @@ func (s *VaultService) RegisterKeyHolder(params RegisterKeyHolderParams) (*Regis
 // GetKeyHolders returns all registered key holders
 func (s *VaultService) GetKeyHolders() ([]config.KeyHolder, error) {
 	if s.cfg.Consensus == nil {
-		return nil, errors.New("consensus mode not configured")
+		return nil, apperrors.New(apperrors.CodeConsensusNotConfigured, "consensus mode not configured")
 	}
 	return s.cfg.Consensus.KeyHolders, nil
 }
@@ -161,7 +175,7 @@ func (s *VaultService) GetKeyHolders() ([]config.KeyHolder, error) {
 func (s *VaultService) GetKeyHolder(id string) (*config.KeyHolder, error) {
 	holder := s.cfg.GetKeyHolder(id)
 	if holder == nil {
-		return nil, errors.New("key holder not found")
+		return nil, apperrors.ErrKeyHolderNotFound
 	}
 	return holder, nil
 }
@@ -177,7 +191,7 @@ type ConsensusInfo struct {
 // GetConsensusInfo returns consensus configuration
 func (s *VaultService) GetConsensusInfo() (*ConsensusInfo, error) {
 	if s.cfg.Consensus == nil {
-		return nil, errors.New("consensus mode not configured")
+		return nil, apperrors.New(apperrors.CodeConsensusNotConfigured, "consensus mode not configured")
 	}
 	return &ConsensusInfo{
 		Threshold:       s.cfg.Consensus.Threshold,
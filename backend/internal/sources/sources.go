@@ -0,0 +1,243 @@
+// Package sources dumps application databases before a backup, so restic
+// stores a consistent copy instead of database files that change while
+// they're read. Each source type is a plugin that turns the configured
+// database into a dump command (pg_dump, mysqldump, sqlite3 .backup).
+package sources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+// Type is the kind of database a source dumps
+type Type string
+
+// Source types
+const (
+	TypePostgres Type = "postgres"
+	TypeMySQL    Type = "mysql"
+	TypeSQLite   Type = "sqlite"
+)
+
+// DumpTimeout bounds a single source's dump
+const DumpTimeout = time.Hour
+
+// Source is a database dumped before each backup of the job it belongs to
+type Source struct {
+	Name string `json:"name"`
+	Type Type   `json:"type"`
+	// Database is the database name, or the database file for sqlite
+	Database string `json:"database"`
+	Host     string `json:"host,omitempty"`
+	Port     int    `json:"port,omitempty"`
+	User     string `json:"user,omitempty"`
+	// PasswordEnv names the environment variable holding the password, so
+	// it isn't stored in the config
+	PasswordEnv string `json:"password_env,omitempty"`
+	Enabled     bool   `json:"enabled"`
+}
+
+// plugin dumps one type of source
+type plugin struct {
+	// tool is the executable the dump runs
+	tool string
+	// file is the dump's file name within the source's directory
+	file string
+	// args returns the tool's arguments for dumping s to out
+	args func(s Source, out string) []string
+	// passwordVar is the environment variable the tool reads a password from
+	passwordVar string
+}
+
+var plugins = map[Type]plugin{
+	TypePostgres: {
+		tool: "pg_dump",
+		file: "database.pgdump",
+		args: func(s Source, out string) []string {
+			args := []string{"--format=custom", "--no-password", "--file=" + out}
+			if s.Host != "" {
+				args = append(args, "--host="+s.Host)
+			}
+			if s.Port != 0 {
+				args = append(args, "--port="+strconv.Itoa(s.Port))
+			}
+			if s.User != "" {
+				args = append(args, "--username="+s.User)
+			}
+			return append(args, "--", s.Database)
+		},
+		passwordVar: "PGPASSWORD",
+	},
+	TypeMySQL: {
+		tool: "mysqldump",
+		file: "database.sql",
+		args: func(s Source, out string) []string {
+			// --single-transaction gives InnoDB tables a consistent snapshot without locking
+			args := []string{"--single-transaction", "--routines", "--triggers", "--result-file=" + out}
+			if s.Host != "" {
+				args = append(args, "--host="+s.Host)
+			}
+			if s.Port != 0 {
+				args = append(args, "--port="+strconv.Itoa(s.Port))
+			}
+			if s.User != "" {
+				args = append(args, "--user="+s.User)
+			}
+			return append(args, "--", s.Database)
+		},
+		passwordVar: "MYSQL_PWD",
+	},
+	TypeSQLite: {
+		tool: "sqlite3",
+		file: "database.sqlite",
+		args: func(s Source, out string) []string {
+			// .backup copies through SQLite's online backup API, which is
+			// consistent even while the database is being written
+			return []string{"-bail", s.Database, ".backup " + sqliteQuote(out)}
+		},
+	},
+}
+
+// sqliteQuote quotes a path for a sqlite3 dot command
+func sqliteQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// Types returns the supported source types
+func Types() []Type {
+	types := make([]Type, 0, len(plugins))
+	for t := range plugins {
+		types = append(types, t)
+	}
+	slices.Sort(types)
+	return types
+}
+
+// namePattern keeps source names usable as directory names
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// Validate checks that the source is usable
+func (s Source) Validate() error {
+	if !namePattern.MatchString(s.Name) {
+		return fmt.Errorf("source name %q must be 1-64 letters, digits, '.', '_' or '-'", s.Name)
+	}
+	if _, ok := plugins[s.Type]; !ok {
+		return fmt.Errorf("source %q has unknown type %q (supported: %v)", s.Name, s.Type, Types())
+	}
+	if s.Database == "" {
+		return fmt.Errorf("source %q has no database", s.Name)
+	}
+	if s.Type != TypeSQLite && strings.HasPrefix(s.Database, "-") {
+		return fmt.Errorf("source %q has an invalid database name", s.Name)
+	}
+	if s.Port < 0 || s.Port > 65535 {
+		return fmt.Errorf("source %q has an invalid port %d", s.Name, s.Port)
+	}
+	return nil
+}
+
+// Tool returns the executable the source's dump runs
+func (s Source) Tool() string {
+	return plugins[s.Type].tool
+}
+
+// run executes a dump command, returning its combined output. Tests replace it.
+var run = func(cmd *exec.Cmd) ([]byte, error) {
+	return cmd.CombinedOutput()
+}
+
+// Dump writes the source's dump into dir/<name>/ and returns the
+// directory. An earlier dump there is replaced.
+func (s Source) Dump(ctx context.Context, dir string) (string, error) {
+	if err := s.Validate(); err != nil {
+		return "", err
+	}
+	p := plugins[s.Type]
+
+	out := filepath.Join(dir, s.Name)
+	if err := os.RemoveAll(out); err != nil {
+		return "", fmt.Errorf("failed to clear previous dump of %s: %w", s.Name, err)
+	}
+	if err := os.MkdirAll(out, 0700); err != nil {
+		return "", fmt.Errorf("failed to create dump directory for %s: %w", s.Name, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DumpTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, p.tool, p.args(s, filepath.Join(out, p.file))...)
+	cmd.Env = os.Environ()
+	if s.PasswordEnv != "" && p.passwordVar != "" {
+		password, ok := os.LookupEnv(s.PasswordEnv)
+		if !ok {
+			_ = os.RemoveAll(out)
+			return "", fmt.Errorf("source %s: environment variable %s is not set", s.Name, s.PasswordEnv)
+		}
+		cmd.Env = append(cmd.Env, p.passwordVar+"="+password)
+	}
+
+	if output, err := run(cmd); err != nil {
+		_ = os.RemoveAll(out)
+		msg := strings.TrimSpace(string(output))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("%s dump of %s failed: %s", p.tool, s.Name, msg)
+	}
+	return out, nil
+}
+
+// Dumps are the dumps made for one backup run
+type Dumps struct {
+	// Paths are the directories to back up, one per source
+	Paths []string
+	dir   string
+}
+
+// DumpAll dumps every enabled source into dir, for backing up alongside
+// the job's paths. If any dump fails, the others are removed and the error
+// returned: backing up without a database the job expects would look like
+// a good backup when it isn't.
+func DumpAll(ctx context.Context, srcs []Source, dir string) (*Dumps, error) {
+	d := &Dumps{dir: dir}
+	for _, s := range srcs {
+		if !s.Enabled {
+			continue
+		}
+		start := time.Now()
+		path, err := s.Dump(ctx, dir)
+		if err != nil {
+			d.Cleanup()
+			return nil, err
+		}
+		logging.Info("Dumped backup source",
+			logging.String("name", s.Name),
+			logging.String("type", string(s.Type)),
+			logging.Duration("took", time.Since(start)))
+		d.Paths = append(d.Paths, path)
+	}
+	return d, nil
+}
+
+// Cleanup removes the dumps once they are backed up
+func (d *Dumps) Cleanup() {
+	var errs []error
+	for _, p := range d.Paths {
+		errs = append(errs, os.RemoveAll(p))
+	}
+	if err := errors.Join(errs...); err != nil {
+		logging.Warn("Failed to remove database dumps", logging.String("dir", d.dir), logging.Err(err))
+	}
+	d.Paths = nil
+}
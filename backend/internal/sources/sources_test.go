@@ -0,0 +1,133 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRun replaces run for the test, writing the dump file named by the
+// command's arguments (or failing if fail is set) and recording the commands
+func fakeRun(t *testing.T, fail error) *[]*exec.Cmd {
+	t.Helper()
+	var cmds []*exec.Cmd
+	orig := run
+	run = func(cmd *exec.Cmd) ([]byte, error) {
+		cmds = append(cmds, cmd)
+		if fail != nil {
+			return []byte("connection refused"), fail
+		}
+		for _, arg := range cmd.Args {
+			for _, prefix := range []string{"--file=", "--result-file=", ".backup '"} {
+				if out, ok := strings.CutPrefix(arg, prefix); ok {
+					require.NoError(t, os.WriteFile(strings.TrimSuffix(out, "'"), []byte("dump"), 0600))
+				}
+			}
+		}
+		return nil, nil
+	}
+	t.Cleanup(func() { run = orig })
+	return &cmds
+}
+
+func TestValidate(t *testing.T) {
+	valid := Source{Name: "app", Type: TypePostgres, Database: "app"}
+	assert.NoError(t, valid.Validate())
+
+	for name, s := range map[string]Source{
+		"empty name":     {Type: TypePostgres, Database: "app"},
+		"path name":      {Name: "../app", Type: TypePostgres, Database: "app"},
+		"unknown type":   {Name: "app", Type: "oracle", Database: "app"},
+		"no database":    {Name: "app", Type: TypeMySQL},
+		"flag database":  {Name: "app", Type: TypeMySQL, Database: "--all-databases"},
+		"port too large": {Name: "app", Type: TypePostgres, Database: "app", Port: 70000},
+	} {
+		assert.Error(t, s.Validate(), name)
+	}
+}
+
+func TestDumpCommands(t *testing.T) {
+	cmds := fakeRun(t, nil)
+	dir := t.TempDir()
+	t.Setenv("APP_DB_PASSWORD", "hunter2")
+
+	pg := Source{Name: "pg", Type: TypePostgres, Database: "app", Host: "db", Port: 5433, User: "backup", PasswordEnv: "APP_DB_PASSWORD"}
+	out, err := pg.Dump(context.Background(), dir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "pg"), out)
+	assert.FileExists(t, filepath.Join(out, "database.pgdump"))
+
+	cmd := (*cmds)[0]
+	assert.Equal(t, "pg_dump", filepath.Base(cmd.Args[0]))
+	assert.Contains(t, cmd.Args, "--host=db")
+	assert.Contains(t, cmd.Args, "--port=5433")
+	assert.Contains(t, cmd.Args, "--username=backup")
+	assert.Equal(t, []string{"--", "app"}, cmd.Args[len(cmd.Args)-2:])
+	assert.Contains(t, cmd.Env, "PGPASSWORD=hunter2")
+
+	my := Source{Name: "my", Type: TypeMySQL, Database: "wiki", User: "backup", PasswordEnv: "APP_DB_PASSWORD"}
+	_, err = my.Dump(context.Background(), dir)
+	require.NoError(t, err)
+	cmd = (*cmds)[1]
+	assert.Equal(t, "mysqldump", filepath.Base(cmd.Args[0]))
+	assert.Contains(t, cmd.Args, "--single-transaction")
+	assert.Contains(t, cmd.Env, "MYSQL_PWD=hunter2")
+
+	lite := Source{Name: "lite", Type: TypeSQLite, Database: "/srv/it's.db"}
+	_, err = lite.Dump(context.Background(), dir)
+	require.NoError(t, err)
+	cmd = (*cmds)[2]
+	assert.Equal(t, "sqlite3", filepath.Base(cmd.Args[0]))
+	assert.Equal(t, "/srv/it's.db", cmd.Args[2])
+	assert.Equal(t, ".backup "+sqliteQuote(filepath.Join(dir, "lite", "database.sqlite")), cmd.Args[3])
+}
+
+func TestSQLiteQuote(t *testing.T) {
+	assert.Equal(t, `'/tmp/it''s.db'`, sqliteQuote("/tmp/it's.db"))
+}
+
+func TestDumpMissingPassword(t *testing.T) {
+	cmds := fakeRun(t, nil)
+	s := Source{Name: "pg", Type: TypePostgres, Database: "app", PasswordEnv: "AIRGAPPER_TEST_UNSET_PASSWORD"}
+	_, err := s.Dump(context.Background(), t.TempDir())
+	assert.ErrorContains(t, err, "AIRGAPPER_TEST_UNSET_PASSWORD")
+	assert.Empty(t, *cmds)
+}
+
+func TestDumpAll(t *testing.T) {
+	fakeRun(t, nil)
+	dir := t.TempDir()
+	srcs := []Source{
+		{Name: "a", Type: TypePostgres, Database: "a", Enabled: true},
+		{Name: "b", Type: TypeMySQL, Database: "b"},
+		{Name: "c", Type: TypeSQLite, Database: "/srv/c.db", Enabled: true},
+	}
+
+	dumps, err := DumpAll(context.Background(), srcs, dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "a"), filepath.Join(dir, "c")}, dumps.Paths)
+
+	paths := slices.Clone(dumps.Paths)
+	dumps.Cleanup()
+	for _, p := range paths {
+		assert.NoDirExists(t, p)
+	}
+}
+
+func TestDumpAllFailure(t *testing.T) {
+	fakeRun(t, errors.New("exit status 1"))
+	dir := t.TempDir()
+	srcs := []Source{{Name: "a", Type: TypePostgres, Database: "a", Enabled: true}}
+
+	_, err := DumpAll(context.Background(), srcs, dir)
+	assert.ErrorContains(t, err, "connection refused")
+	assert.NoDirExists(t, filepath.Join(dir, "a"))
+}
@@ -2,6 +2,7 @@
 package sss
 
 import (
+	"bytes"
 	"crypto/rand"
 	"errors"
 	"fmt"
@@ -108,6 +109,58 @@ func Combine(shares []Share) ([]byte, error) {
 	return secret, nil
 }
 
+// Derive returns the share at index of a 2-of-n split, given the secret
+// and one other share of it. With a threshold of 2 the secret and any
+// share determine every other share, so an owner holding the password
+// can work out what the host's share must be without storing it.
+func Derive(secret []byte, share Share, index byte) ([]byte, error) {
+	if share.Index == 0 || index == 0 {
+		return nil, errors.New("share index must be 1-255")
+	}
+	if len(share.Data) != len(secret) {
+		return nil, errors.New("share and secret must have the same length")
+	}
+
+	derived := make([]byte, len(secret))
+	for byteIdx := range secret {
+		points := [][2]byte{{0, secret[byteIdx]}, {share.Index, share.Data[byteIdx]}}
+		derived[byteIdx] = lagrangeInterpolate(points, index)
+	}
+	return derived, nil
+}
+
+// ShareAt returns the share at index of the split the given shares belong
+// to. It needs at least as many shares as the split's threshold, like
+// Combine, and lets a lost share be re-issued without re-splitting.
+func ShareAt(shares []Share, index byte) ([]byte, error) {
+	if len(shares) < 1 {
+		return nil, errors.New("need at least 1 share to reconstruct")
+	}
+	if index == 0 {
+		return nil, errors.New("share index must be 1-255")
+	}
+
+	shareLen := len(shares[0].Data)
+	for _, s := range shares {
+		if len(s.Data) != shareLen {
+			return nil, errors.New("all shares must have the same length")
+		}
+		if s.Index == index {
+			return bytes.Clone(s.Data), nil
+		}
+	}
+
+	share := make([]byte, shareLen)
+	points := make([][2]byte, len(shares))
+	for byteIdx := range share {
+		for i, s := range shares {
+			points[i] = [2]byte{s.Index, s.Data[byteIdx]}
+		}
+		share[byteIdx] = lagrangeInterpolate(points, index)
+	}
+	return share, nil
+}
+
 // evaluatePolynomial evaluates a polynomial in GF(256)
 func evaluatePolynomial(coefficients []byte, x byte) byte {
 	if x == 0 {
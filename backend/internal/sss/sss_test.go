@@ -111,6 +111,52 @@ func TestCombineWithMoreThanK(t *testing.T) {
 	assert.Equal(t, secret, result, "Reconstructed secret doesn't match")
 }
 
+func TestDerive(t *testing.T) {
+	secret := []byte("derive the other shares")
+
+	shares, err := Split(secret, 2, 4)
+	require.NoError(t, err, "Split failed")
+
+	for _, want := range shares[1:] {
+		derived, err := Derive(secret, shares[0], want.Index)
+		require.NoError(t, err, "Derive failed")
+		assert.Equal(t, want.Data, derived, "Derived share %d doesn't match", want.Index)
+	}
+
+	_, err = Derive(secret, Share{Index: 1, Data: []byte("short")}, 2)
+	assert.Error(t, err, "Expected error for mismatched lengths")
+	_, err = Derive(secret, shares[0], 0)
+	assert.Error(t, err, "Expected error for index 0")
+}
+
+func TestShareAt(t *testing.T) {
+	secret := []byte("re-issue a lost share")
+
+	shares, err := Split(secret, 3, 5)
+	require.NoError(t, err, "Split failed")
+
+	// Any 3 shares re-issue the other two
+	for _, want := range shares[3:] {
+		got, err := ShareAt(shares[:3], want.Index)
+		require.NoError(t, err, "ShareAt failed")
+		assert.Equal(t, want.Data, got, "Re-issued share %d doesn't match", want.Index)
+	}
+
+	got, err := ShareAt(shares[1:4], shares[1].Index)
+	require.NoError(t, err, "ShareAt failed")
+	assert.Equal(t, shares[1].Data, got, "A share already given is returned as is")
+
+	// Too few shares give a wrong share, as Combine gives a wrong secret
+	got, err = ShareAt(shares[:2], shares[4].Index)
+	require.NoError(t, err, "ShareAt failed")
+	assert.NotEqual(t, shares[4].Data, got)
+
+	_, err = ShareAt(shares[:3], 0)
+	assert.Error(t, err, "Expected error for index 0")
+	_, err = ShareAt(nil, 1)
+	assert.Error(t, err, "Expected error for no shares")
+}
+
 func TestSplitErrors(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -0,0 +1,228 @@
+// Package statebackup exports an owner's Airgapper state (its config,
+// restore and deletion requests, audit logs, genesis record and the rest of
+// ~/.airgapper) as one encrypted file that is backed up with each snapshot,
+// so a lost owner node can be rebuilt from the repository with 'airgapper
+// self-restore'.
+//
+// The export is sealed with the repository password. By policy it never
+// holds the secrets that must not sit in the repository they protect: the
+// password itself, the owner's key share and its private signing key.
+package statebackup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+)
+
+// FileName is the export's file in the config directory, and so its name
+// in each snapshot
+const FileName = "airgapper-state.enc"
+
+// Version is the export format version
+const Version = 1
+
+// keyContext separates the export key from other uses of the password
+const keyContext = "airgapper state export v1\x00"
+
+// configFile is the config's file in the config directory
+const configFile = "config.json"
+
+// ErrSecretsInExport is returned when an export would carry a secret the
+// policy keeps out of the repository
+var ErrSecretsInExport = errors.New("state export must not contain the password, key share or private key")
+
+// Archive is an export of an owner's state
+type Archive struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Name      string    `json:"name"`
+	RepoURL   string    `json:"repo_url"`
+
+	// Files maps slash-separated paths relative to the config directory to
+	// their contents
+	Files map[string][]byte `json:"files"`
+}
+
+// Path is where cfg's state export is written before each backup
+func Path(cfg *config.Config) string {
+	return filepath.Join(cfg.ConfigDir, FileName)
+}
+
+// StripSecrets returns a copy of cfg without the secrets the policy keeps
+// out of the repository
+func StripSecrets(cfg *config.Config) *config.Config {
+	c := *cfg
+	c.Password = ""
+	c.LocalShare = nil
+	c.PrivateKey = nil
+	return &c
+}
+
+// skip reports whether a config directory entry stays out of the export:
+// the legacy share file, database dumps (backed up as paths of their own)
+// and the export itself
+func skip(cfg *config.Config, rel string) bool {
+	return slices.Contains([]string{
+		FileName,
+		configFile,
+		filepath.Base(cfg.SharePath()),
+		filepath.Base(cfg.SourceDumpDir()),
+	}, rel)
+}
+
+// Build collects cfg's state. config.json is taken from cfg with its
+// secrets stripped; the other files are read from the config directory.
+func Build(cfg *config.Config, now time.Time) (*Archive, error) {
+	stripped, err := json.MarshalIndent(StripSecrets(cfg), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	a := &Archive{
+		Version:   Version,
+		CreatedAt: now.UTC(),
+		Name:      cfg.Name,
+		RepoURL:   cfg.RepoURL,
+		Files:     map[string][]byte{configFile: stripped},
+	}
+
+	err = filepath.WalkDir(cfg.ConfigDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(cfg.ConfigDir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if skip(cfg, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		a.Files[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state from %s: %w", cfg.ConfigDir, err)
+	}
+	return a, nil
+}
+
+// Seal serializes and encrypts the archive with the repository password
+func (a *Archive) Seal(password string) ([]byte, error) {
+	if err := a.checkSecrets(); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Seal([]byte(password), keyContext, data)
+}
+
+// Open decrypts and parses a sealed export
+func Open(sealed []byte, password string) (*Archive, error) {
+	data, err := crypto.Open([]byte(password), keyContext, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt state export: %w", err)
+	}
+	var a Archive
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, fmt.Errorf("invalid state export: %w", err)
+	}
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// Validate checks an export can be restored: a known version, a config,
+// and only paths inside the config directory
+func (a *Archive) Validate() error {
+	if a.Version != Version {
+		return fmt.Errorf("unsupported state export version %d", a.Version)
+	}
+	if _, ok := a.Files[configFile]; !ok {
+		return fmt.Errorf("state export has no %s", configFile)
+	}
+	for rel := range a.Files {
+		if !filepath.IsLocal(filepath.FromSlash(rel)) {
+			return fmt.Errorf("state export has an invalid path %q", rel)
+		}
+	}
+	return nil
+}
+
+// checkSecrets makes sure the exported config carries none of the secrets
+// StripSecrets removes
+func (a *Archive) checkSecrets() error {
+	var cfg config.Config
+	if err := json.Unmarshal(a.Files[configFile], &cfg); err != nil {
+		return fmt.Errorf("invalid exported %s: %w", configFile, err)
+	}
+	if cfg.Password != "" || cfg.LocalShare != nil || cfg.PrivateKey != nil {
+		return ErrSecretsInExport
+	}
+	return nil
+}
+
+// Write exports cfg's state to Path(cfg), sealed with cfg's password
+func Write(cfg *config.Config) (string, error) {
+	a, err := Build(cfg, time.Now())
+	if err != nil {
+		return "", err
+	}
+	sealed, err := a.Seal(cfg.Password)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt state export: %w", err)
+	}
+	path := Path(cfg)
+	if err := os.WriteFile(path, sealed, 0600); err != nil {
+		return "", fmt.Errorf("failed to write state export: %w", err)
+	}
+	return path, nil
+}
+
+// Restore writes the archive's files to dir and returns the restored
+// config with password put back. The caller re-creates the key share and
+// signing key, which the export never holds.
+func (a *Archive) Restore(dir, password string) (*config.Config, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	for rel, data := range a.Files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", rel, err)
+		}
+	}
+
+	cfg, err := config.Load(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load restored config: %w", err)
+	}
+	cfg.Password = password
+	if err := cfg.Save(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
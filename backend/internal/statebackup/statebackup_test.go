@@ -0,0 +1,125 @@
+package statebackup
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/config"
+)
+
+func ownerConfig(t *testing.T) *config.Config {
+	t.Helper()
+	cfg := &config.Config{
+		Name:        "alice",
+		Role:        config.RoleOwner,
+		RepoURL:     "rest:http://bob:8000/alice",
+		Password:    "secret",
+		LocalShare:  []byte{1, 2, 3},
+		ShareIndex:  1,
+		PrivateKey:  []byte("private"),
+		PublicKey:   []byte("public"),
+		BackupState: true,
+		ConfigDir:   t.TempDir(),
+	}
+	require.NoError(t, cfg.Save())
+
+	write := func(rel, data string) {
+		path := filepath.Join(cfg.ConfigDir, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+		require.NoError(t, os.WriteFile(path, []byte(data), 0600))
+	}
+	write("requests/abc.json", `{"id":"abc"}`)
+	write("override-audit.log", "audit\n")
+	write("share.key", "legacy share")
+	write("dumps/postgres/db.sql", "dump")
+	return cfg
+}
+
+func TestBuildStripsSecrets(t *testing.T) {
+	cfg := ownerConfig(t)
+
+	a, err := Build(cfg, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "alice", a.Name)
+	assert.Contains(t, a.Files, "requests/abc.json")
+	assert.Contains(t, a.Files, "override-audit.log")
+	assert.NotContains(t, a.Files, "share.key")
+	assert.NotContains(t, a.Files, "dumps/postgres/db.sql")
+
+	var exported config.Config
+	require.NoError(t, json.Unmarshal(a.Files["config.json"], &exported))
+	assert.Empty(t, exported.Password)
+	assert.Nil(t, exported.LocalShare)
+	assert.Nil(t, exported.PrivateKey)
+	assert.Equal(t, cfg.PublicKey, exported.PublicKey)
+	assert.Equal(t, byte(1), exported.ShareIndex)
+
+	// The in-memory config keeps its secrets
+	assert.Equal(t, "secret", cfg.Password)
+}
+
+func TestSealRefusesSecrets(t *testing.T) {
+	cfg := ownerConfig(t)
+	a, err := Build(cfg, time.Now())
+	require.NoError(t, err)
+
+	a.Files["config.json"], err = json.Marshal(cfg)
+	require.NoError(t, err)
+	_, err = a.Seal("secret")
+	assert.ErrorIs(t, err, ErrSecretsInExport)
+}
+
+func TestWriteAndRestore(t *testing.T) {
+	cfg := ownerConfig(t)
+
+	path, err := Write(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(cfg.ConfigDir, FileName), path)
+	sealed, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), "alice")
+
+	// A second export doesn't include the first
+	a, err := Build(cfg, time.Now())
+	require.NoError(t, err)
+	assert.NotContains(t, a.Files, FileName)
+
+	_, err = Open(sealed, "wrong")
+	assert.Error(t, err)
+
+	a, err = Open(sealed, "secret")
+	require.NoError(t, err)
+	dir := filepath.Join(t.TempDir(), ".airgapper")
+	restored, err := a.Restore(dir, "secret")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", restored.Password)
+	assert.Equal(t, cfg.RepoURL, restored.RepoURL)
+	assert.True(t, restored.BackupState)
+	assert.Nil(t, restored.LocalShare)
+
+	data, err := os.ReadFile(filepath.Join(dir, "requests", "abc.json"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"abc"}`, string(data))
+
+	loaded, err := config.Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", loaded.Password)
+}
+
+func TestOpenRejectsEscapingPaths(t *testing.T) {
+	cfg := ownerConfig(t)
+	a, err := Build(cfg, time.Now())
+	require.NoError(t, err)
+	a.Files["../outside"] = []byte("x")
+
+	sealed, err := a.Seal("secret")
+	require.NoError(t, err)
+	_, err = Open(sealed, "secret")
+	assert.ErrorContains(t, err, "invalid path")
+}
@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+// Silent data loss on the host is what Airgapper exists to catch. When a
+// reconcile finds a repository holding less than the server expects, with
+// no deletion through the server to explain it, the difference is recorded
+// as an anomaly, audited and passed to the anomaly notifier, which tells
+// the owner.
+
+// Kinds of anomaly
+const (
+	AnomalySnapshotsMissing = "snapshots_missing" // Snapshot files vanished
+	AnomalyDataMissing      = "data_missing"      // Data packs vanished
+	AnomalyConfigChanged    = "config_changed"    // The repository config was rewritten or removed
+	AnomalyRepoShrunk       = "repo_shrunk"       // The repository lost more than the alert threshold
+	AnomalyAuditChainReset  = "audit_chain_reset" // The audit chain went back to an earlier entry
+	AnomalyCorruptRead      = "corrupt_read"      // A file failed its hash check while being served
+)
+
+// DefaultShrinkAlertPct is how much of a repository can vanish unexplained
+// before it is reported as shrunk
+const DefaultShrinkAlertPct = 10
+
+// maxAnomalies bounds the anomaly log
+const maxAnomalies = 100
+
+// maxListedSnapshots bounds the snapshots named in one anomaly
+const maxListedSnapshots = 10
+
+// Anomaly is an unexplained change to stored data
+type Anomaly struct {
+	Kind       string    `json:"kind"`
+	Repo       string    `json:"repo,omitempty"`
+	Detail     string    `json:"detail"`
+	DetectedAt time.Time `json:"detectedAt"`
+}
+
+func newAnomaly(kind, repo, format string, args ...any) Anomaly {
+	return Anomaly{Kind: kind, Repo: repo, Detail: fmt.Sprintf(format, args...), DetectedAt: timeNow().UTC()}
+}
+
+// String describes the anomaly in one line
+func (a Anomaly) String() string {
+	if a.Repo == "" {
+		return a.Detail
+	}
+	return a.Repo + ": " + a.Detail
+}
+
+// anomalyLog guards the persisted anomaly log
+type anomalyLog struct {
+	mu          sync.Mutex
+	notify      func(context.Context, []Anomaly)
+	corruptRead func(repo, file string)
+}
+
+func (s *Server) anomaliesPath() string {
+	return filepath.Join(s.basePath, ".airgapper-anomalies.json")
+}
+
+// SetAnomalyNotifier sets a callback invoked with the anomalies each
+// reconcile finds
+func (s *Server) SetAnomalyNotifier(fn func(context.Context, []Anomaly)) {
+	s.anomalies.mu.Lock()
+	defer s.anomalies.mu.Unlock()
+	s.anomalies.notify = fn
+}
+
+// compareRepo returns what repo lost compared to what the server expected
+func (s *Server) compareRepo(repo string, expected, actual *repoState) []Anomaly {
+	var found []Anomaly
+
+	var missing []string
+	for name := range expected.Snapshots {
+		if _, ok := actual.Snapshots[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if n := len(missing); n > 0 {
+		sort.Strings(missing)
+		names := strings.Join(missing[:min(n, maxListedSnapshots)], ", ")
+		if n > maxListedSnapshots {
+			names += fmt.Sprintf(" and %d more", n-maxListedSnapshots)
+		}
+		found = append(found, newAnomaly(AnomalySnapshotsMissing, repo,
+			"%d snapshot files disappeared: %s", n, names))
+	}
+
+	if lost := expected.DataFiles - actual.DataFiles; lost > 0 {
+		found = append(found, newAnomaly(AnomalyDataMissing, repo,
+			"%d of %d data files disappeared", lost, expected.DataFiles))
+	}
+
+	if expected.ConfigHash != "" && actual.ConfigHash != expected.ConfigHash {
+		detail := "repository config was modified"
+		if actual.ConfigHash == "" {
+			detail = "repository config was removed"
+		}
+		found = append(found, newAnomaly(AnomalyConfigChanged, repo, "%s", detail))
+	}
+
+	if lost := expected.Bytes - actual.Bytes; expected.Bytes > 0 && lost*100 > expected.Bytes*int64(s.shrinkAlertPct) {
+		found = append(found, newAnomaly(AnomalyRepoShrunk, repo,
+			"shrank by %d%% (%d of %d bytes) without a deletion through the server",
+			lost*100/expected.Bytes, lost, expected.Bytes))
+	}
+	return found
+}
+
+// reportAnomalies logs, audits and records anomalies, then notifies
+func (s *Server) reportAnomalies(anomalies []Anomaly) {
+	if len(anomalies) == 0 {
+		return
+	}
+	for _, a := range anomalies {
+		logging.Error("Storage anomaly detected",
+			logging.String("kind", a.Kind),
+			logging.String("repo", a.Repo),
+			logging.String("detail", a.Detail))
+		s.audit(context.Background(), "ANOMALY", a.Repo, a.Kind+": "+a.Detail, true, "")
+	}
+
+	s.anomalies.mu.Lock()
+	recorded := append(s.loadAnomalies(), anomalies...)
+	if len(recorded) > maxAnomalies {
+		recorded = recorded[len(recorded)-maxAnomalies:]
+	}
+	s.saveAnomalies(recorded)
+	notify := s.anomalies.notify
+	s.anomalies.mu.Unlock()
+	s.publishAnomalies(anomalies)
+
+	if notify != nil {
+		notify(context.Background(), slices.Clone(anomalies))
+	}
+}
+
+// Anomalies returns the recorded anomalies, oldest first
+func (s *Server) Anomalies() []Anomaly {
+	s.anomalies.mu.Lock()
+	defer s.anomalies.mu.Unlock()
+	return s.loadAnomalies()
+}
+
+func (s *Server) loadAnomalies() []Anomaly {
+	data, err := os.ReadFile(s.anomaliesPath())
+	if err != nil {
+		return nil
+	}
+	var log []Anomaly
+	if err := json.Unmarshal(data, &log); err != nil {
+		logging.Warnf("[storage] failed to parse anomaly log: %v", err)
+	}
+	return log
+}
+
+func (s *Server) saveAnomalies(log []Anomaly) {
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		logging.Warnf("[storage] failed to serialize anomaly log: %v", err)
+		return
+	}
+	if err := os.WriteFile(s.anomaliesPath(), data, 0600); err != nil {
+		logging.Warnf("[storage] failed to save anomaly log: %v", err)
+	}
+}
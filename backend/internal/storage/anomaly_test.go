@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageAnomalies(t *testing.T) {
+	tmpDir := t.TempDir()
+	s, err := NewServer(Config{BasePath: tmpDir})
+	require.NoError(t, err)
+	s.Start()
+	handler := s.Handler()
+
+	var notified [][]Anomaly
+	s.SetAnomalyNotifier(func(_ context.Context, anomalies []Anomaly) {
+		notified = append(notified, anomalies)
+	})
+
+	do := func(method, path string, data []byte) {
+		t.Helper()
+		req := httptest.NewRequest(method, path, bytes.NewReader(data))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, "%s %s: %s", method, path, w.Body.String())
+	}
+	kinds := func(anomalies []Anomaly) []string {
+		var k []string
+		for _, a := range anomalies {
+			k = append(k, a.Kind)
+		}
+		return k
+	}
+
+	do(http.MethodPost, "/repo/", nil)
+	do(http.MethodPost, "/repo/config", []byte("config v1"))
+	putData := func(data []byte) string {
+		t.Helper()
+		sum := sha256.Sum256(data)
+		name := hex.EncodeToString(sum[:])
+		do(http.MethodPost, "/repo/data/"+name, data)
+		return name
+	}
+	dataA := putData(bytes.Repeat([]byte("a"), 1000))
+	dataB := putData(bytes.Repeat([]byte("b"), 1000))
+	do(http.MethodPost, "/repo/snapshots/1111", make([]byte, 10))
+	do(http.MethodPost, "/repo/snapshots/2222", make([]byte, 10))
+
+	// Deletions through the server and a clean walk are not anomalies
+	do(http.MethodDelete, "/repo/snapshots/1111", nil)
+	do(http.MethodDelete, "/repo/data/"+dataA, nil)
+	s.reconcileUsage()
+	assert.Empty(t, notified)
+	assert.Empty(t, s.Anomalies())
+
+	// Files disappearing behind the server's back are
+	repoDir := filepath.Join(tmpDir, "repo")
+	require.NoError(t, os.Remove(filepath.Join(repoDir, "snapshots", "2222")))
+	require.NoError(t, os.Remove(filepath.Join(repoDir, "data", dataB[:2], dataB)))
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "config"), []byte("config v2"), 0600))
+	s.reconcileUsage()
+
+	require.Len(t, notified, 1)
+	assert.ElementsMatch(t, []string{AnomalySnapshotsMissing, AnomalyDataMissing, AnomalyConfigChanged, AnomalyRepoShrunk},
+		kinds(notified[0]))
+	for _, a := range notified[0] {
+		assert.Equal(t, "repo", a.Repo)
+		assert.False(t, a.DetectedAt.IsZero())
+	}
+	assert.Equal(t, notified[0], s.Anomalies())
+	assert.Equal(t, notified[0], s.VaultMetadata().Anomalies)
+
+	// The new state is adopted, so the loss is only reported once
+	s.reconcileUsage()
+	assert.Len(t, notified, 1)
+}
+
+func TestStorageAnomalies_ShrinkThreshold(t *testing.T) {
+	tmpDir := t.TempDir()
+	s, err := NewServer(Config{BasePath: tmpDir, ShrinkAlertPct: 50})
+	require.NoError(t, err)
+	s.Start()
+	handler := s.Handler()
+
+	for _, path := range []string{"/repo/", "/repo/keys/aaaa", "/repo/keys/bbbb"} {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(make([]byte, 100)))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	// Losing less than the threshold goes unreported
+	require.NoError(t, os.Truncate(filepath.Join(tmpDir, "repo", "keys", "aaaa"), 40))
+	s.reconcileUsage()
+	assert.Empty(t, s.Anomalies())
+
+	require.NoError(t, os.RemoveAll(filepath.Join(tmpDir, "repo")))
+	s.reconcileUsage()
+	anomalies := s.Anomalies()
+	require.Len(t, anomalies, 1)
+	assert.Equal(t, AnomalyRepoShrunk, anomalies[0].Kind)
+}
+
+func TestStorageAnomalies_PersistedBetweenRestarts(t *testing.T) {
+	tmpDir := t.TempDir()
+	s, err := NewServer(Config{BasePath: tmpDir})
+	require.NoError(t, err)
+	s.Start()
+	handler := s.Handler()
+
+	for _, path := range []string{"/repo/", "/repo/snapshots/1111"} {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(make([]byte, 10)))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+	s.Stop()
+
+	// A snapshot removed while the server was down is caught by the first
+	// walk after it starts
+	require.NoError(t, os.Remove(filepath.Join(tmpDir, "repo", "snapshots", "1111")))
+	s, err = NewServer(Config{BasePath: tmpDir})
+	require.NoError(t, err)
+	s.reconcileUsage()
+	anomalies := s.Anomalies()
+	require.NotEmpty(t, anomalies)
+	assert.Equal(t, AnomalySnapshotsMissing, anomalies[0].Kind)
+	assert.Contains(t, anomalies[0].Detail, "1111")
+}
@@ -1,11 +1,14 @@
 package storage
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
 )
 
 func (s *Server) auditLogPath() string {
@@ -39,19 +42,34 @@ func (s *Server) saveAuditLog() {
 	}
 }
 
-func (s *Server) audit(operation, path, details string, success bool, errMsg string) {
+// audit records an operation, tagged with the correlation ID of the request
+// in ctx, if any
+func (s *Server) audit(ctx context.Context, operation, path, details string, success bool, errMsg string) {
+	correlationID := tracing.ID(ctx)
+	defer logAudit(ctx, operation, path, details, success, errMsg)
+	if strings.HasSuffix(operation, "_DENIED") {
+		s.publishDenied(ctx, operation, path, details)
+	}
+
+	entry := AuditEntry{
+		Timestamp:     timeNow(),
+		Operation:     operation,
+		Path:          path,
+		Details:       details,
+		Success:       success,
+		Error:         errMsg,
+		CorrelationID: correlationID,
+	}
+	if fn := s.auditHandler.Load(); fn != nil {
+		defer (*fn)(entry)
+	}
+
 	// Use cryptographic audit chain if enabled
 	if s.auditChain != nil {
-		_, err := s.auditChain.Record(operation, path, details, success, errMsg)
+		_, err := s.auditChain.RecordCorrelated(correlationID, operation, path, details, success, errMsg)
 		if err != nil {
 			logging.Warnf("[storage] failed to record to audit chain: %v", err)
 		}
-		// Still log to stdout
-		if success {
-			logging.Debugf("[storage-audit] %s %s %s", operation, path, details)
-		} else {
-			logging.Warnf("[storage-audit] %s %s FAILED: %s", operation, path, errMsg)
-		}
 		return
 	}
 
@@ -59,15 +77,6 @@ func (s *Server) audit(operation, path, details string, success bool, errMsg str
 	s.auditMu.Lock()
 	defer s.auditMu.Unlock()
 
-	entry := AuditEntry{
-		Timestamp: timeNow(),
-		Operation: operation,
-		Path:      path,
-		Details:   details,
-		Success:   success,
-		Error:     errMsg,
-	}
-
 	s.auditLog = append(s.auditLog, entry)
 
 	// Trim if too large
@@ -77,12 +86,20 @@ func (s *Server) audit(operation, path, details string, success bool, errMsg str
 
 	// Persist
 	s.saveAuditLog()
+}
+
+// SetAuditHandler sets a function called with each audited operation, after
+// it is recorded. It must not block.
+func (s *Server) SetAuditHandler(fn func(AuditEntry)) {
+	s.auditHandler.Store(&fn)
+}
 
-	// Also log to stdout
+// logAudit also logs each audited operation to stdout
+func logAudit(ctx context.Context, operation, path, details string, success bool, errMsg string) {
 	if success {
-		logging.Debugf("[storage-audit] %s %s %s", operation, path, details)
+		logging.Debug("[storage-audit] "+operation, logging.String("path", path), logging.String("details", details), tracing.Field(ctx))
 	} else {
-		logging.Warnf("[storage-audit] %s %s FAILED: %s", operation, path, errMsg)
+		logging.Warn("[storage-audit] "+operation+" FAILED", logging.String("path", path), logging.String("error", errMsg), tracing.Field(ctx))
 	}
 }
 
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Responses are gzip compressed when the server has compression enabled and
+// the client asks for it with Accept-Encoding, as restic's HTTP client does.
+// Only gzip is offered; the standard library has no zstd encoder.
+const (
+	// CompressMinSize is the smallest body worth compressing
+	CompressMinSize = 1024
+	// CompressMaxSize is the largest file compressed; larger files are
+	// streamed as they are rather than buffered
+	CompressMaxSize = 32 << 20
+)
+
+// compressible reports whether a file of fileType and size may be sent
+// compressed. Data packs never are: restic already compresses and encrypts
+// them, so they wouldn't shrink, and restic reads them in ranges.
+func (s *Server) compressible(r *http.Request, fileType string, size int64) bool {
+	return s.compress && fileType != "data" && r.Header.Get("Range") == "" &&
+		size >= CompressMinSize && size <= CompressMaxSize
+}
+
+// writeBody writes data as the response body, gzip compressed when the
+// server compresses, the client accepts gzip and doing so saves bytes
+func (s *Server) writeBody(w http.ResponseWriter, r *http.Request, data []byte) {
+	if s.compress {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if len(data) >= CompressMinSize && acceptsGzip(r) {
+			if compressed := gzipBytes(data); len(compressed) < len(data) {
+				w.Header().Set("Content-Encoding", "gzip")
+				data = compressed
+			}
+		}
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	_, _ = w.Write(data)
+}
+
+func gzipBytes(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write(data)
+	_ = zw.Close()
+	return buf.Bytes()
+}
+
+// acceptsGzip reports whether the request's Accept-Encoding allows gzip,
+// by name or through "*"
+func acceptsGzip(r *http.Request) bool {
+	gzipOK, starOK := -1, -1
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		ok := 1
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				ok = 0
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "gzip":
+			gzipOK = ok
+		case "*":
+			starOK = ok
+		}
+	}
+	if gzipOK >= 0 {
+		return gzipOK == 1
+	}
+	return starOK == 1
+}
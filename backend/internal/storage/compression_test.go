@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCompressingServer(t *testing.T, compress bool) http.Handler {
+	t.Helper()
+	s, err := NewServer(Config{BasePath: t.TempDir(), AppendOnly: true, Compress: compress})
+	require.NoError(t, err)
+	s.Start()
+	handler := s.Handler()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/testrepo/", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	return handler
+}
+
+func upload(t *testing.T, handler http.Handler, path string, data []byte) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func get(handler http.Handler, path, acceptEncoding string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func gunzip(t *testing.T, data []byte) []byte {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	plain, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	return plain
+}
+
+func TestCompressedResponses(t *testing.T) {
+	handler := newCompressingServer(t, true)
+	index := []byte(strings.Repeat(`{"packs":[{"id":"abc","blobs":[]}]}`, 100))
+	name := strings.Repeat("ab", 32)
+	upload(t, handler, "/testrepo/index/"+name, index)
+
+	w := get(handler, "/testrepo/index/"+name, "gzip, deflate")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Contains(t, w.Header().Values("Vary"), "Accept-Encoding")
+	assert.Less(t, w.Body.Len(), len(index))
+	assert.Equal(t, index, gunzip(t, w.Body.Bytes()))
+
+	// Clients that don't ask for gzip get the file as it is
+	for _, accept := range []string{"", "identity", "gzip;q=0, *"} {
+		w = get(handler, "/testrepo/index/"+name, accept)
+		assert.Empty(t, w.Header().Get("Content-Encoding"), accept)
+		assert.Equal(t, index, w.Body.Bytes(), accept)
+	}
+
+	// Listings are compressed once they're large enough
+	for i := range 40 {
+		sum := sha256.Sum256([]byte{byte(i)})
+		upload(t, handler, "/testrepo/snapshots/"+hex.EncodeToString(sum[:]), []byte("snapshot"))
+	}
+	w = get(handler, "/testrepo/snapshots/", "gzip")
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Contains(t, string(gunzip(t, w.Body.Bytes())), `"size":8`)
+}
+
+func TestCompressionSkipsDataAndIncompressibleFiles(t *testing.T) {
+	handler := newCompressingServer(t, true)
+	random := make([]byte, 4096)
+	_, err := rand.Read(random)
+	require.NoError(t, err)
+
+	sum := sha256.Sum256(random)
+	dataName := hex.EncodeToString(sum[:])
+	upload(t, handler, "/testrepo/data/"+dataName, random)
+	w := get(handler, "/testrepo/data/"+dataName, "gzip")
+	assert.Empty(t, w.Header().Get("Content-Encoding"), "data packs are never compressed")
+	assert.Equal(t, random, w.Body.Bytes())
+
+	// Encrypted metadata doesn't shrink, so it's sent as it is
+	name := strings.Repeat("cd", 32)
+	upload(t, handler, "/testrepo/snapshots/"+name, random)
+	w = get(handler, "/testrepo/snapshots/"+name, "gzip")
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, random, w.Body.Bytes())
+}
+
+func TestCompressionDisabled(t *testing.T) {
+	handler := newCompressingServer(t, false)
+	index := []byte(strings.Repeat("compressible ", 200))
+	name := strings.Repeat("ef", 32)
+	upload(t, handler, "/testrepo/index/"+name, index)
+
+	w := get(handler, "/testrepo/index/"+name, "gzip")
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Empty(t, w.Header().Get("Vary"))
+	assert.Equal(t, index, w.Body.Bytes())
+}
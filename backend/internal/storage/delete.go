@@ -17,6 +17,11 @@ func (s *Server) checkDeleteAllowedWithTicket(filePath, snapshotID, ticketID str
 		return false, "delete not allowed in append-only mode"
 	}
 
+	// Pins hold snapshots regardless of tickets or retention
+	if allowed, reason := s.checkPinned(filePath); !allowed {
+		return false, reason
+	}
+
 	// Check if ticket system requires tickets for this deletion
 	if s.ticketManager != nil && s.verificationConfig != nil && s.verificationConfig.IsTicketsEnabled() {
 		// Determine if this is a snapshot deletion that requires a ticket
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/events"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+)
+
+// SetEventLog sets the activity feed that refused writes and deletions,
+// and anomalies, are published to
+func (s *Server) SetEventLog(l *events.Log) {
+	s.eventLog.Store(l)
+}
+
+// publishDenied records a refused write or deletion in the activity feed
+func (s *Server) publishDenied(ctx context.Context, operation, path, reason string) {
+	// Paths are reported within the storage directory
+	subject, err := filepath.Rel(s.basePath, path)
+	if err != nil {
+		subject = path
+	}
+	data := map[string]string{"operation": operation}
+	if id := tracing.ID(ctx); id != "" {
+		data["correlation_id"] = id
+	}
+	s.eventLog.Load().Publish(events.Event{
+		Type:    events.StorageWriteDenied,
+		Source:  events.SourceStorage,
+		Subject: subject,
+		Message: strings.ToLower(strings.TrimSuffix(operation, "_DENIED")) + " refused: " + reason,
+		Data:    data,
+	})
+}
+
+// publishAnomalies records anomalies in the activity feed
+func (s *Server) publishAnomalies(anomalies []Anomaly) {
+	l := s.eventLog.Load()
+	for _, a := range anomalies {
+		l.Publish(events.Event{
+			Type:    events.StorageAnomaly,
+			Source:  events.SourceStorage,
+			Subject: a.Repo,
+			Message: a.Detail,
+			Data:    map[string]string{"kind": a.Kind},
+		})
+	}
+}
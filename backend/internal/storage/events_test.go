@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/events"
+)
+
+func TestStorageServer_DeniedWriteEvents(t *testing.T) {
+	s, err := NewServer(Config{BasePath: t.TempDir(), AppendOnly: true, QuotaBytes: 100})
+	require.NoError(t, err)
+	s.Start()
+	log := events.Open(t.TempDir())
+	s.SetEventLog(log)
+	handler := s.Handler()
+
+	req := httptest.NewRequest(http.MethodPost, "/testrepo/", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	data := make([]byte, 200)
+	req = httptest.NewRequest(http.MethodPost, "/testrepo/keys/big", bytes.NewReader(data))
+	req.ContentLength = int64(len(data))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusInsufficientStorage, w.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/testrepo/keys/small", bytes.NewReader([]byte("small")))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	req = httptest.NewRequest(http.MethodDelete, "/testrepo/keys/small", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	list, _, err := log.Since(0, 0, events.Filter{})
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	for _, e := range list {
+		assert.Equal(t, events.StorageWriteDenied, e.Type)
+		assert.Equal(t, events.SourceStorage, e.Source)
+	}
+	assert.Equal(t, "testrepo/keys/big", list[0].Subject)
+	assert.Equal(t, "WRITE_DENIED", list[0].Data["operation"])
+	assert.Contains(t, list[0].Message, "write refused: ")
+	assert.Equal(t, "DELETE_DENIED", list[1].Data["operation"])
+	assert.Contains(t, list[1].Message, "delete refused: ")
+}
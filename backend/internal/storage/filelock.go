@@ -0,0 +1,41 @@
+package storage
+
+import "sync"
+
+// pathLocks serializes writes to the same file while letting writes to
+// different files run concurrently
+type pathLocks struct {
+	mu    sync.Mutex
+	locks map[string]*pathLock
+}
+
+type pathLock struct {
+	sync.Mutex
+	waiters int
+}
+
+// lock locks path and returns the function that unlocks it
+func (p *pathLocks) lock(path string) func() {
+	p.mu.Lock()
+	if p.locks == nil {
+		p.locks = make(map[string]*pathLock)
+	}
+	l := p.locks[path]
+	if l == nil {
+		l = &pathLock{}
+		p.locks[path] = l
+	}
+	l.waiters++
+	p.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		p.mu.Lock()
+		l.waiters--
+		if l.waiters == 0 {
+			delete(p.locks, path)
+		}
+		p.mu.Unlock()
+	}
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathLocks(t *testing.T) {
+	var locks pathLocks
+
+	unlockA := locks.lock("a")
+	// Another path isn't blocked
+	unlockB := locks.lock("b")
+	unlockB()
+
+	acquired := make(chan struct{})
+	go func() {
+		unlock := locks.lock("a")
+		close(acquired)
+		unlock()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second lock of the same path acquired while held")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlockA()
+	<-acquired
+
+	// Many goroutines take turns on one path
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	inside := 0
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := locks.lock("c")
+			defer unlock()
+			mu.Lock()
+			inside++
+			assert.Equal(t, 1, inside)
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			inside--
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.Empty(t, locks.locks, "unused locks are dropped")
+}
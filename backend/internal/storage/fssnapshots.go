@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/fssnap"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+// With filesystem snapshots configured, the server snapshots the storage
+// dataset once a backup window is over - no snapshot file has been
+// uploaded for the quiet period - and before it opens the prune window of
+// an approved deletion. A failed snapshot is audited; a prune doesn't
+// start without one.
+
+// takeFSSnapshot takes a filesystem snapshot; tests replace it
+var takeFSSnapshot = fssnap.Take
+
+// fsSnapshotsEnabled reports whether filesystem snapshots are configured
+func (s *Server) fsSnapshotsEnabled() bool {
+	return s.fsSnapshots != nil && s.fsSnapshots.Enabled
+}
+
+// backupWritten notes a new snapshot file, restic's last write of a
+// backup, and (re)starts the wait for the backup window to end
+func (s *Server) backupWritten() {
+	if !s.fsSnapshotsEnabled() {
+		return
+	}
+	s.fsSnapMu.Lock()
+	defer s.fsSnapMu.Unlock()
+	quiet := s.fsSnapshots.GetQuiet()
+	if s.fsSnapTimer != nil {
+		s.fsSnapTimer.Reset(quiet)
+		return
+	}
+	s.fsSnapTimer = time.AfterFunc(quiet, func() {
+		s.fsSnapMu.Lock()
+		s.fsSnapTimer = nil
+		s.fsSnapMu.Unlock()
+		_, _ = s.TakeFSSnapshot(context.Background(), fssnap.ReasonBackup)
+	})
+}
+
+// stopFSSnapshots cancels a pending backup window snapshot
+func (s *Server) stopFSSnapshots() {
+	s.fsSnapMu.Lock()
+	defer s.fsSnapMu.Unlock()
+	if s.fsSnapTimer != nil {
+		s.fsSnapTimer.Stop()
+		s.fsSnapTimer = nil
+	}
+}
+
+// TakeFSSnapshot snapshots the storage dataset for reason, destroying the
+// oldest snapshots beyond the retention count, and audits it
+func (s *Server) TakeFSSnapshot(ctx context.Context, reason string) (*fssnap.Snapshot, error) {
+	if !s.fsSnapshotsEnabled() {
+		return nil, fmt.Errorf("filesystem snapshots are not configured")
+	}
+	snap, destroyed, err := takeFSSnapshot(ctx, s.fsSnapshots, reason, timeNow())
+	if snap == nil {
+		s.audit(ctx, "FS_SNAPSHOT", s.fsSnapshots.Dataset, reason, false, err.Error())
+		return nil, err
+	}
+	details := fmt.Sprintf("%s snapshot %s", reason, snap.Name)
+	if len(destroyed) > 0 {
+		details += "; destroyed " + strings.Join(destroyed, ", ")
+	}
+	s.audit(ctx, "FS_SNAPSHOT", s.fsSnapshots.Dataset, details, true, "")
+	if err != nil {
+		logging.Warn("Failed to destroy old filesystem snapshots", logging.String("dataset", s.fsSnapshots.Dataset), logging.Err(err))
+		s.audit(ctx, "FS_SNAPSHOT_PRUNE", s.fsSnapshots.Dataset, "", false, err.Error())
+	}
+	return snap, nil
+}
+
+// FSSnapshots lists the filesystem snapshots taken of the storage
+func (s *Server) FSSnapshots(ctx context.Context) ([]fssnap.Snapshot, error) {
+	if !s.fsSnapshotsEnabled() {
+		return nil, nil
+	}
+	return fssnap.List(ctx, s.fsSnapshots)
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/fssnap"
+)
+
+// fakeFSSnapshots replaces taking a snapshot with recording its reason,
+// failing while *fail is set
+func fakeFSSnapshots(t *testing.T) (reasons func() []string, fail *bool) {
+	t.Helper()
+	var mu sync.Mutex
+	var taken []string
+	fail = new(bool)
+	orig := takeFSSnapshot
+	takeFSSnapshot = func(ctx context.Context, c *fssnap.Config, reason string, now time.Time) (*fssnap.Snapshot, []string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if *fail {
+			return nil, nil, errors.New("dataset is busy")
+		}
+		taken = append(taken, reason)
+		return &fssnap.Snapshot{Name: fssnap.Prefix + reason, Reason: reason, CreatedAt: now}, nil, nil
+	}
+	t.Cleanup(func() { takeFSSnapshot = orig })
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, taken...)
+	}, fail
+}
+
+func TestFSSnapshotAfterBackupWindow(t *testing.T) {
+	reasons, _ := fakeFSSnapshots(t)
+	s, err := NewServer(Config{BasePath: t.TempDir(), FSSnapshots: &fssnap.Config{
+		Enabled: true, Type: fssnap.TypeZFS, Dataset: "tank/backups", Quiet: "200ms",
+	}})
+	require.NoError(t, err)
+	s.Start()
+	defer s.Stop()
+	require.Equal(t, http.StatusOK, send(s, http.MethodPost, "/testrepo/", nil))
+	assert.Equal(t, "zfs", s.Status().FSSnapshots)
+
+	// Data uploads don't end a backup; snapshot files do, and the window
+	// stays open while more arrive
+	storeBlob(t, s, "data", 512)
+	storeBlob(t, s, "snapshots", 128)
+	storeBlob(t, s, "snapshots", 128)
+	assert.Empty(t, reasons())
+	assert.Eventually(t, func() bool { return len(reasons()) == 1 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{fssnap.ReasonBackup}, reasons())
+	assert.Equal(t, "FS_SNAPSHOT", lastAudit(t, s).Operation)
+}
+
+func TestFSSnapshotBeforeDeletion(t *testing.T) {
+	reasons, fail := fakeFSSnapshots(t)
+	s, err := NewServer(Config{BasePath: t.TempDir(), FSSnapshots: &fssnap.Config{
+		Enabled: true, Type: fssnap.TypeZFS, Dataset: "tank/backups",
+	}})
+	require.NoError(t, err)
+	s.Start()
+	require.Equal(t, http.StatusOK, send(s, http.MethodPost, "/testrepo/", nil))
+	ctx := context.Background()
+
+	*fail = true
+	_, err = s.BeginPrune(ctx, "testrepo", "del-1")
+	assert.ErrorContains(t, err, "without a filesystem snapshot")
+	assert.Nil(t, s.Maintenance())
+	entry := lastAudit(t, s)
+	assert.Equal(t, "FS_SNAPSHOT", entry.Operation)
+	assert.False(t, entry.Success)
+
+	*fail = false
+	_, err = s.BeginPrune(ctx, "testrepo", "del-1")
+	require.NoError(t, err)
+	_, err = s.BeginPrune(ctx, "testrepo", "del-1")
+	require.NoError(t, err, "resuming takes no second snapshot")
+	assert.Equal(t, []string{fssnap.ReasonDeletion}, reasons())
+}
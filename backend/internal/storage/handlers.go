@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
@@ -9,14 +10,25 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
 )
 
 func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 	s.mu.Lock()
 	s.requestCount++
+	s.inFlight++
 	running := s.running
 	s.mu.Unlock()
 
+	defer func() {
+		s.mu.Lock()
+		s.inFlight--
+		s.lastRequest = timeNow()
+		s.noteContact(s.lastRequest)
+		s.mu.Unlock()
+	}()
+
 	if !running {
 		http.Error(w, "Storage server not running", http.StatusServiceUnavailable)
 		return
@@ -39,6 +51,20 @@ func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Only repositories the host provisioned, written only by their owner
+	if s.rejectUnprovisioned(w, r, repo) || s.rejectUnknownWriter(w, r, repo) {
+		return
+	}
+
+	// In maintenance mode only reads (and restic's lock files) get through
+	var kind string
+	if len(parts) > 1 {
+		kind = parts[1]
+	}
+	if s.rejectMaintenanceWrite(w, r, repo, kind) {
+		return
+	}
+
 	// Handle different path patterns
 	if len(parts) == 1 || (len(parts) == 2 && parts[1] == "") {
 		// /{repo}/ - Repository root
@@ -127,12 +153,6 @@ func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request, repo strin
 		_, _ = w.Write(data)
 
 	case http.MethodPost:
-		// Config can only be created once
-		if _, err := os.Stat(configPath); err == nil {
-			http.Error(w, "Config already exists", http.StatusForbidden)
-			return
-		}
-
 		// Ensure repo directory exists
 		if err := os.MkdirAll(repoPath, 0755); err != nil {
 			http.Error(w, "Failed to create repository", http.StatusInternalServerError)
@@ -145,29 +165,51 @@ func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request, repo strin
 			return
 		}
 
-		if err := os.WriteFile(configPath, data, 0644); err != nil {
+		// Config can only be created once: of racing writers, only the
+		// one creating the file wins
+		file, err := os.OpenFile(configPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if os.IsExist(err) {
+			http.Error(w, "Config already exists", http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to write config", http.StatusInternalServerError)
+			return
+		}
+		_, err = file.Write(data)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(configPath)
 			http.Error(w, "Failed to write config", http.StatusInternalServerError)
 			return
 		}
+		s.trackConfig(repo, data)
 		w.WriteHeader(http.StatusOK)
 
 	case http.MethodDelete:
 		allowed, reason := s.checkDeleteAllowed(configPath)
 		if !allowed {
-			s.audit("DELETE_DENIED", configPath, reason, false, reason)
+			s.audit(r.Context(), "DELETE_DENIED", configPath, reason, false, reason)
 			http.Error(w, reason, http.StatusForbidden)
 			return
 		}
-		if err := os.Remove(configPath); err != nil {
+		info, err := os.Stat(configPath)
+		if err == nil {
+			err = os.Remove(configPath)
+		}
+		if err != nil {
 			if os.IsNotExist(err) {
 				http.Error(w, "Config not found", http.StatusNotFound)
 				return
 			}
-			s.audit("DELETE", configPath, "", false, err.Error())
+			s.audit(r.Context(), "DELETE", configPath, "", false, err.Error())
 			http.Error(w, "Failed to delete config", http.StatusInternalServerError)
 			return
 		}
-		s.audit("DELETE", configPath, "config deleted", true, "")
+		s.trackDelete(repo, "config", "", info.Size())
+		s.audit(r.Context(), "DELETE", configPath, "config deleted", true, "")
 		w.WriteHeader(http.StatusOK)
 
 	default:
@@ -196,7 +238,7 @@ func (s *Server) handleList(w http.ResponseWriter, r *http.Request, repo, fileTy
 			if err != nil {
 				return nil
 			}
-			if !info.IsDir() {
+			if !info.IsDir() && !isTempFile(info.Name()) {
 				files = append(files, fileEntry{name: info.Name(), size: info.Size()})
 			}
 			return nil
@@ -215,7 +257,7 @@ func (s *Server) handleList(w http.ResponseWriter, r *http.Request, repo, fileTy
 		}
 
 		for _, entry := range entries {
-			if entry.IsDir() {
+			if entry.IsDir() || isTempFile(entry.Name()) {
 				continue
 			}
 			info, err := entry.Info()
@@ -227,15 +269,17 @@ func (s *Server) handleList(w http.ResponseWriter, r *http.Request, repo, fileTy
 	}
 
 	// Build JSON response
-	w.Header().Set("Content-Type", "application/vnd.x.restic.rest.v2")
-	_, _ = fmt.Fprint(w, "[")
+	var body bytes.Buffer
+	body.WriteString("[")
 	for i, f := range files {
 		if i > 0 {
-			_, _ = fmt.Fprint(w, ",")
+			body.WriteString(",")
 		}
-		_, _ = fmt.Fprintf(w, `{"name":%q,"size":%d}`, f.name, f.size)
+		_, _ = fmt.Fprintf(&body, `{"name":%q,"size":%d}`, f.name, f.size)
 	}
-	_, _ = fmt.Fprint(w, "]")
+	body.WriteString("]")
+	w.Header().Set("Content-Type", "application/vnd.x.restic.rest.v2")
+	s.writeBody(w, r, body.Bytes())
 }
 
 func (s *Server) handleFile(w http.ResponseWriter, r *http.Request, repo, fileType, fileName string) {
@@ -281,11 +325,47 @@ func (s *Server) handleFile(w http.ResponseWriter, r *http.Request, repo, fileTy
 		defer func() { _ = file.Close() }()
 
 		info, _ := file.Stat()
+		verify := s.verifiesReads(r, fileType)
 		w.Header().Set("Content-Type", "application/octet-stream")
+		if s.compressible(r, fileType, info.Size()) {
+			data, err := io.ReadAll(file)
+			if err != nil {
+				http.Error(w, "Failed to read file", http.StatusInternalServerError)
+				return
+			}
+			if verify && !s.checkRead(data, repo, fileType, fileName) {
+				http.Error(w, "File is corrupt", http.StatusInternalServerError)
+				return
+			}
+			s.writeBody(w, r, data)
+			return
+		}
+		if verify {
+			s.serveVerified(w, file, info.Size(), repo, fileType, fileName)
+			return
+		}
 		w.Header().Set("Content-Length", fmt.Sprintf("%d", info.Size()))
 		_, _ = io.Copy(w, file)
 
 	case http.MethodPost:
+		// Writes to the same file take turns; each writes its own temp file
+		unlock := s.writeLocks.lock(filePath)
+		defer unlock()
+
+		// Data blobs are named by their content hash, so one that is
+		// already stored is identical and isn't written again, unless it
+		// has since been corrupted
+		repairing := false
+		if fileType == "data" {
+			if _, err := os.Stat(filePath); err == nil {
+				if !isCorrupt(fileType, fileName, filePath) {
+					http.Error(w, "Blob already exists", http.StatusConflict)
+					return
+				}
+				repairing = true
+			}
+		}
+
 		contentLength := r.ContentLength
 		if contentLength < 0 {
 			contentLength = 0 // Unknown size
@@ -293,18 +373,16 @@ func (s *Server) handleFile(w http.ResponseWriter, r *http.Request, repo, fileTy
 
 		// Check system disk space first
 		if ok, reason := s.checkDiskSpace(contentLength); !ok {
-			s.audit("WRITE_DENIED", filePath, reason, false, reason)
+			s.audit(r.Context(), "WRITE_DENIED", filePath, reason, false, reason)
 			http.Error(w, reason, http.StatusInsufficientStorage)
 			return
 		}
 
-		// Check per-repo quota
-		if s.quotaBytes > 0 && contentLength > 0 {
-			currentUsed := s.calculateUsedSpace()
-			if currentUsed+contentLength > s.quotaBytes {
-				http.Error(w, "Storage quota exceeded", http.StatusInsufficientStorage)
-				return
-			}
+		// Check the local quota and the policy's storage limit
+		if ok, reason := s.checkQuota(contentLength); !ok {
+			s.audit(r.Context(), "WRITE_DENIED", filePath, reason, false, reason)
+			http.Error(w, reason, http.StatusInsufficientStorage)
+			return
 		}
 
 		// Ensure directory exists
@@ -314,16 +392,25 @@ func (s *Server) handleFile(w http.ResponseWriter, r *http.Request, repo, fileTy
 			return
 		}
 
-		// Write to temp file first, then rename (atomic write)
-		tmpPath := filePath + ".tmp"
-		file, err := os.Create(tmpPath)
+		// Write to a temp file of our own first, then rename (atomic write)
+		file, err := os.CreateTemp(dir, fileName+".*"+tempSuffix)
 		if err != nil {
 			http.Error(w, "Failed to create file", http.StatusInternalServerError)
 			return
 		}
+		tmpPath := file.Name()
+
+		// A body of unknown length passed the quota check above as empty,
+		// so the quota is enforced while streaming too: reading one byte
+		// past what is left means the upload doesn't fit
+		body := io.Reader(r.Body)
+		remaining, quotaReason := s.remainingQuota()
+		if remaining >= 0 {
+			body = io.LimitReader(r.Body, remaining+1)
+		}
 
 		hash := sha256.New()
-		written, err := io.Copy(io.MultiWriter(file, hash), r.Body)
+		written, err := io.Copy(io.MultiWriter(file, hash), body)
 		_ = file.Close()
 
 		if err != nil {
@@ -331,16 +418,64 @@ func (s *Server) handleFile(w http.ResponseWriter, r *http.Request, repo, fileTy
 			http.Error(w, "Failed to write file", http.StatusInternalServerError)
 			return
 		}
+		if remaining >= 0 && written > remaining {
+			_ = os.Remove(tmpPath)
+			s.audit(r.Context(), "WRITE_DENIED", filePath, quotaReason, false, quotaReason)
+			http.Error(w, quotaReason, http.StatusInsufficientStorage)
+			return
+		}
+		sum := hash.Sum(nil)
 
 		// For data blobs, verify the hash matches the filename
 		if fileType == "data" {
 			expectedHash := fileName
-			actualHash := hex.EncodeToString(hash.Sum(nil))
+			actualHash := hex.EncodeToString(sum)
 			if actualHash != expectedHash {
 				_ = os.Remove(tmpPath)
 				http.Error(w, "Hash mismatch", http.StatusBadRequest)
 				return
 			}
+		} else if sameContent(filePath, sum) {
+			_ = os.Remove(tmpPath)
+			http.Error(w, "File already exists", http.StatusConflict)
+			return
+		}
+
+		// A rewritten file replaces the old one's size
+		var replaced int64
+		info, err := os.Stat(filePath)
+		existed := err == nil
+		if existed {
+			replaced = info.Size()
+		}
+
+		// Append-only mode never replaces a stored file either, except a
+		// corrupt one; locks come and go with each restic command
+		if existed && s.appendOnly && fileType != "locks" && !repairing {
+			if !isCorrupt(fileType, fileName, filePath) {
+				_ = os.Remove(tmpPath)
+				s.audit(r.Context(), "WRITE_DENIED", filePath, "overwrite not allowed in append-only mode", false, "overwrite not allowed in append-only mode")
+				http.Error(w, "File already exists", http.StatusConflict)
+				return
+			}
+			repairing = true
+		}
+
+		// An immutable file is only replaced when it is corrupt
+		if existed && s.immutableFile(fileType) {
+			if !repairing && !isCorrupt(fileType, fileName, filePath) {
+				_ = os.Remove(tmpPath)
+				s.audit(r.Context(), "WRITE_DENIED", filePath, "file is immutable", false, "file is immutable")
+				http.Error(w, "File is immutable", http.StatusForbidden)
+				return
+			}
+			repairing = true
+			if err := setImmutable(false, filePath); err != nil {
+				_ = os.Remove(tmpPath)
+				s.audit(r.Context(), "WRITE", filePath, "", false, err.Error())
+				http.Error(w, "Failed to finalize file", http.StatusInternalServerError)
+				return
+			}
 		}
 
 		// Rename temp file to final name
@@ -349,39 +484,110 @@ func (s *Server) handleFile(w http.ResponseWriter, r *http.Request, repo, fileTy
 			http.Error(w, "Failed to finalize file", http.StatusInternalServerError)
 			return
 		}
-
-		s.mu.Lock()
-		s.totalBytes += written
-		s.mu.Unlock()
+		if s.immutableFile(fileType) {
+			if err := setImmutable(true, filePath); err != nil {
+				logging.Warn("Failed to make file immutable", logging.String("path", filePath), logging.Err(err))
+				s.audit(r.Context(), "IMMUTABLE", filePath, "", false, err.Error())
+			}
+		}
+		s.trackWrite(repo, fileType, fileName, written, replaced, existed)
+		if repairing {
+			s.auditRepair(r.Context(), filePath, fmt.Sprintf("%s/%s replaced by a verified upload", fileType, fileName))
+		}
 
 		// Audit file creation for snapshots (to track what backups exist)
 		if fileType == "snapshots" {
-			s.audit("SNAPSHOT_CREATE", filePath, fmt.Sprintf("snapshot %s created (%d bytes)", fileName, written), true, "")
+			s.audit(r.Context(), "SNAPSHOT_CREATE", filePath, fmt.Sprintf("snapshot %s created (%d bytes)", fileName, written), true, "")
+
+			s.mu.Lock()
+			s.recordUsage(timeNow())
+			s.mu.Unlock()
+			s.backupWritten()
 		}
 
 		w.WriteHeader(http.StatusOK)
 
 	case http.MethodDelete:
-		allowed, reason := s.checkDeleteAllowed(filePath)
-		if !allowed {
-			s.audit("DELETE_DENIED", filePath, reason, false, reason)
-			http.Error(w, reason, http.StatusForbidden)
-			return
+		// Restic removes its locks when each command finishes and 'restic
+		// unlock' removes stale ones, so lock deletions are allowed even in
+		// append-only mode. A lock holds no backup data.
+		repairing := false
+		if fileType != "locks" {
+			allowed, reason := s.checkDeleteAllowed(filePath)
+			if !allowed {
+				// Removing a corrupt file loses nothing
+				if !isCorrupt(fileType, fileName, filePath) {
+					s.audit(r.Context(), "DELETE_DENIED", filePath, reason, false, reason)
+					http.Error(w, reason, http.StatusForbidden)
+					return
+				}
+				repairing = true
+			}
 		}
 
-		if err := os.Remove(filePath); err != nil {
+		// The attribute is only lifted for an approved deletion's prune, or
+		// to remove a corrupt file
+		locked := s.immutableFile(fileType)
+		if locked && !repairing && !s.inPruneWindow(repo) {
+			if isCorrupt(fileType, fileName, filePath) {
+				repairing = true
+			} else {
+				reason := "file is immutable; it can only be deleted by the prune of an approved deletion"
+				s.audit(r.Context(), "DELETE_DENIED", filePath, reason, false, reason)
+				http.Error(w, reason, http.StatusForbidden)
+				return
+			}
+		}
+
+		info, err := os.Stat(filePath)
+		if err == nil && locked {
+			err = setImmutable(false, filePath)
+		}
+		if err == nil {
+			if err = os.Remove(filePath); err != nil && locked {
+				_ = setImmutable(true, filePath)
+			}
+		}
+		if err != nil {
 			if os.IsNotExist(err) {
 				http.Error(w, "File not found", http.StatusNotFound)
 				return
 			}
-			s.audit("DELETE", filePath, "", false, err.Error())
+			s.audit(r.Context(), "DELETE", filePath, "", false, err.Error())
 			http.Error(w, "Failed to delete file", http.StatusInternalServerError)
 			return
 		}
-		s.audit("DELETE", filePath, fmt.Sprintf("%s/%s deleted", fileType, fileName), true, "")
+		s.trackDelete(repo, fileType, fileName, info.Size())
+		if repairing {
+			s.auditRepair(r.Context(), filePath, fmt.Sprintf("corrupt %s/%s deleted", fileType, fileName))
+		} else {
+			s.audit(r.Context(), "DELETE", filePath, fmt.Sprintf("%s/%s deleted", fileType, fileName), true, "")
+		}
 		w.WriteHeader(http.StatusOK)
 
 	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
 }
+
+// tempSuffix ends the names of files still being written
+const tempSuffix = ".tmp"
+
+// isTempFile reports whether name is a file still being written
+func isTempFile(name string) bool {
+	return strings.HasSuffix(name, tempSuffix)
+}
+
+// sameContent reports whether the file at path exists with the SHA-256 sum
+func sameContent(path string, sum []byte) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = f.Close() }()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return false
+	}
+	return bytes.Equal(hash.Sum(nil), sum)
+}
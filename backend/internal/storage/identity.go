@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/clientid"
+)
+
+// With RequireIdentity set, every request that writes - creating the
+// repository, uploading its config, any file, a lock - must carry the
+// owner's signed identity (see package clientid), checked against the
+// owner's key in the policy, or the key the host was given for its owner
+// until there is a policy. Someone who only knows the storage URL can then
+// still read the encrypted repository, but can't add to it or create its
+// config before the owner does. Reads are left alone.
+
+// ownerIdentityKey is the key writes are checked against, or nil if no
+// owner key is known yet
+func (s *Server) ownerIdentityKey() []byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.policy != nil {
+		if key, err := hex.DecodeString(s.policy.OwnerPubKey); err == nil && len(key) > 0 {
+			return key
+		}
+	}
+	return s.ownerKey
+}
+
+// checkWriter returns why a write to repo by r isn't the owner's, or nil.
+// A repository provisioned for a key is written only by that key.
+func (s *Server) checkWriter(r *http.Request, repo string) error {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return nil
+	}
+	var key []byte
+	if info := s.repoInfo(repo); info != nil && info.OwnerPubKey != "" {
+		key, _ = hex.DecodeString(info.OwnerPubKey)
+	} else if s.requireIdentity {
+		key = s.ownerIdentityKey()
+	} else {
+		return nil
+	}
+	if key == nil {
+		return errors.New("no owner key is known to check writes against; agree a policy first")
+	}
+	keyID, err := clientid.Verify(r, repo, key, timeNow())
+	if err != nil && keyID != "" && !errors.Is(err, clientid.ErrUnknownKey) {
+		return fmt.Errorf("key %s: %w", keyID, err)
+	}
+	return err
+}
+
+// rejectUnknownWriter refuses a write that isn't signed by the owner,
+// returning true if it did
+func (s *Server) rejectUnknownWriter(w http.ResponseWriter, r *http.Request, repo string) bool {
+	err := s.checkWriter(r, repo)
+	if err == nil {
+		return false
+	}
+	reason := err.Error()
+	s.audit(r.Context(), "WRITE_DENIED", filepath.Join(s.basePath, filepath.FromSlash(r.URL.Path)), reason, false, reason)
+	w.Header().Set("WWW-Authenticate", `Basic realm="airgapper storage"`)
+	http.Error(w, reason, http.StatusUnauthorized)
+	return true
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/clientid"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/policy"
+)
+
+func TestStorageServer_RequireIdentity(t *testing.T) {
+	ownerPub, ownerPriv, _ := crypto.GenerateKeyPair()
+	hostPub, hostPriv, _ := crypto.GenerateKeyPair()
+	_, strangerPriv, _ := crypto.GenerateKeyPair()
+
+	p := policy.NewPolicy(
+		"TestOwner", crypto.KeyID(ownerPub), crypto.EncodePublicKey(ownerPub),
+		"TestHost", crypto.KeyID(hostPub), crypto.EncodePublicKey(hostPub),
+	)
+	require.NoError(t, p.SignAsOwner(ownerPriv))
+	require.NoError(t, p.SignAsHost(hostPriv))
+
+	s, err := NewServer(Config{BasePath: t.TempDir(), AppendOnly: true, Policy: p, RequireIdentity: true})
+	require.NoError(t, err)
+	s.Start()
+	handler := s.Handler()
+
+	lockName := "feedfacefeedfacefeedfacefeedfacefeedfacefeedfacefeedfacefeedface"
+	do := func(method, path string, signer []byte, signedFor string) int {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte("data")))
+		if signer != nil {
+			user, pass, err := clientid.Sign(signer, signedFor, timeNow())
+			require.NoError(t, err)
+			req.SetBasicAuth(user, pass)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/alice/", nil, ""), "anonymous repository creation")
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/alice/", strangerPriv, "alice"), "someone else's key")
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/alice/", ownerPriv, "bob"), "signed for another repository")
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/alice/", ownerPriv, "alice"))
+
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/alice/config", nil, ""), "racing the config")
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/alice/config", ownerPriv, "alice"))
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/alice/locks/"+lockName, strangerPriv, "alice"))
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/alice/locks/"+lockName, ownerPriv, "alice"))
+
+	// Reads need no identity
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/alice/config", nil, ""))
+	assert.True(t, s.Status().RequireIdentity)
+
+	denied := 0
+	for _, e := range s.GetAuditLog(0) {
+		if e.Operation == "WRITE_DENIED" {
+			denied++
+		}
+	}
+	assert.Equal(t, 5, denied)
+}
+
+func TestStorageServer_RequireIdentityWithoutOwnerKey(t *testing.T) {
+	ownerPub, ownerPriv, _ := crypto.GenerateKeyPair()
+
+	s, err := NewServer(Config{BasePath: t.TempDir(), RequireIdentity: true})
+	require.NoError(t, err)
+	s.Start()
+	req := httptest.NewRequest(http.MethodPost, "/alice/", nil)
+	user, pass, _ := clientid.Sign(ownerPriv, "alice", timeNow())
+	req.SetBasicAuth(user, pass)
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "no owner key")
+
+	// The host's pinned owner key serves until there is a policy
+	s, err = NewServer(Config{BasePath: t.TempDir(), RequireIdentity: true, OwnerPublicKey: ownerPub})
+	require.NoError(t, err)
+	s.Start()
+	w = httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// Append-only mode is enforced by the server, so anything that writes to
+// the disk around it, such as a compromised storage user, another process
+// or a bug, can still change or delete backups. The immutable tier adds the
+// filesystem's own protection: each data and snapshot file gets the
+// immutable attribute (chattr +i on Linux, chflags uchg on macOS and
+// FreeBSD) once it is complete, and until the attribute is cleared nothing
+// can modify, rename or delete the file. The server clears it itself only
+// to delete a file during the prune window of an approved deletion, or to
+// replace a file proven corrupt; the attribute is set again if the delete
+// fails.
+
+// immutableTypes are the file types made immutable once complete. Index
+// files are rewritten by every prune and locks by every command, so they
+// stay mutable.
+var immutableTypes = []string{"data", "snapshots"}
+
+// immutableBatch is how many files one attribute command is given
+const immutableBatch = 200
+
+// attrTool sets and clears the immutable attribute with the platform's tool
+type attrTool struct {
+	tool       string
+	set, clear string
+}
+
+var attrTools = map[string]attrTool{
+	"linux":   {tool: "chattr", set: "+i", clear: "-i"},
+	"darwin":  {tool: "chflags", set: "uchg", clear: "nouchg"},
+	"freebsd": {tool: "chflags", set: "uchg", clear: "nouchg"},
+}
+
+// runAttr runs an attribute command; tests replace it
+var runAttr = func(cmd *exec.Cmd) error {
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return errors.New(msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// setImmutable sets, or clears, the immutable attribute of paths
+func setImmutable(on bool, paths ...string) error {
+	t, ok := attrTools[runtime.GOOS]
+	if !ok {
+		return fmt.Errorf("immutable files aren't supported on %s", runtime.GOOS)
+	}
+	flag := t.clear
+	if on {
+		flag = t.set
+	}
+	for batch := range slices.Chunk(paths, immutableBatch) {
+		args := append([]string{flag}, batch...)
+		if err := runAttr(exec.Command(t.tool, args...)); err != nil {
+			return fmt.Errorf("%s %s failed: %w", t.tool, flag, err)
+		}
+	}
+	return nil
+}
+
+// checkImmutableSupport sets and clears the attribute on a probe file, so a
+// filesystem or process that can't (Linux needs CAP_LINUX_IMMUTABLE) is
+// found at startup rather than at the first upload
+func checkImmutableSupport(basePath string) error {
+	probe := filepath.Join(basePath, ".airgapper-immutable-probe")
+	if err := os.WriteFile(probe, nil, 0600); err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(probe) }()
+	if err := setImmutable(true, probe); err != nil {
+		return fmt.Errorf("can't make files immutable under %s (on Linux this needs root or CAP_LINUX_IMMUTABLE, and a filesystem such as ext4 or XFS): %w", basePath, err)
+	}
+	return setImmutable(false, probe)
+}
+
+// immutableFile reports whether a file of fileType is made immutable
+func (s *Server) immutableFile(fileType string) bool {
+	return s.immutable && slices.Contains(immutableTypes, fileType)
+}
+
+// lockExisting makes the complete data and snapshot files already stored
+// immutable, e.g. when the tier is turned on for existing repositories
+func (s *Server) lockExisting() (int, error) {
+	var paths []string
+	entries, err := os.ReadDir(s.basePath)
+	if err != nil {
+		return 0, err
+	}
+	for _, e := range entries {
+		if !e.IsDir() || !isValidRepoName(e.Name()) {
+			continue
+		}
+		for _, fileType := range immutableTypes {
+			_ = filepath.WalkDir(filepath.Join(s.basePath, e.Name(), fileType), func(path string, d os.DirEntry, err error) error {
+				if err == nil && !d.IsDir() && !isTempFile(d.Name()) {
+					paths = append(paths, path)
+				}
+				return nil
+			})
+		}
+	}
+	return len(paths), setImmutable(true, paths...)
+}
+
+// inPruneWindow reports whether an approved deletion's prune window is open
+// for repo
+func (s *Server) inPruneWindow(repo string) bool {
+	m := s.Maintenance()
+	return m != nil && m.Prune == repo && m.Deletion != ""
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAttrs replaces the attribute tool with one recording which files are
+// immutable, since setting the real attribute needs root
+func fakeAttrs(t *testing.T) map[string]bool {
+	t.Helper()
+	if _, ok := attrTools[runtime.GOOS]; !ok {
+		t.Skipf("immutable files aren't supported on %s", runtime.GOOS)
+	}
+	locked := map[string]bool{}
+	orig := runAttr
+	runAttr = func(cmd *exec.Cmd) error {
+		on := cmd.Args[1] == attrTools[runtime.GOOS].set
+		for _, path := range cmd.Args[2:] {
+			locked[path] = on
+		}
+		return nil
+	}
+	t.Cleanup(func() { runAttr = orig })
+	return locked
+}
+
+func TestImmutableTier(t *testing.T) {
+	locked := fakeAttrs(t)
+	base := t.TempDir()
+	existing := filepath.Join(base, "testrepo", "snapshots", "aaaa")
+	require.NoError(t, os.MkdirAll(filepath.Dir(existing), 0700))
+	require.NoError(t, os.WriteFile(existing, []byte("snapshot"), 0600))
+
+	s, err := NewServer(Config{BasePath: base, Immutable: true})
+	require.NoError(t, err)
+	s.Start()
+	assert.True(t, locked[existing], "files already stored are locked at startup")
+	assert.False(t, locked[filepath.Join(base, ".airgapper-immutable-probe")], "the probe is cleared")
+	assert.True(t, s.Status().Immutable)
+
+	name := storeBlob(t, s, "data", 1024)
+	dataPath := filepath.Join(base, "testrepo", "data", name[:2], name)
+	assert.True(t, locked[dataPath])
+	require.Equal(t, http.StatusOK, send(s, http.MethodPost, "/testrepo/keys/k1", []byte("key")))
+	assert.NotContains(t, locked, filepath.Join(base, "testrepo", "keys", "k1"), "only data and snapshots are locked")
+
+	assert.Equal(t, http.StatusForbidden, send(s, http.MethodPost, "/testrepo/snapshots/aaaa", []byte("rewritten")))
+	assert.Equal(t, http.StatusForbidden, send(s, http.MethodDelete, "/testrepo/data/"+name, nil))
+	assert.Equal(t, "DELETE_DENIED", lastAudit(t, s).Operation)
+	assert.FileExists(t, dataPath)
+	assert.True(t, locked[dataPath])
+
+	// The prune of an approved deletion lifts the attribute to delete
+	ctx := context.Background()
+	_, err = s.BeginPrune(ctx, "testrepo", "del-1")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, send(s, http.MethodDelete, "/testrepo/data/"+name, nil))
+	assert.NoFileExists(t, dataPath)
+	assert.False(t, locked[dataPath])
+	_, err = s.EndPrune(ctx, "testrepo", "del-1")
+	require.NoError(t, err)
+}
+
+func TestImmutableTierRepairsCorruptFiles(t *testing.T) {
+	locked := fakeAttrs(t)
+	s, err := NewServer(Config{BasePath: t.TempDir(), Immutable: true})
+	require.NoError(t, err)
+	s.Start()
+	require.Equal(t, http.StatusOK, send(s, http.MethodPost, "/testrepo/", nil))
+
+	name := storeBlob(t, s, "data", 1024)
+	dataPath := filepath.Join(s.basePath, "testrepo", "data", name[:2], name)
+	corrupt(t, dataPath)
+	assert.Equal(t, http.StatusOK, send(s, http.MethodDelete, "/testrepo/data/"+name, nil), "a corrupt file can be removed")
+	assert.NoFileExists(t, dataPath)
+	assert.False(t, locked[dataPath])
+}
+
+func TestImmutableTierUnsupported(t *testing.T) {
+	fakeAttrs(t)
+	runAttr = func(*exec.Cmd) error { return os.ErrPermission }
+	_, err := NewServer(Config{BasePath: t.TempDir(), Immutable: true})
+	assert.ErrorContains(t, err, "can't make files immutable")
+}
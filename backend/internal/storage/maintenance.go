@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+// In maintenance mode the storage server is read-only: reads are served,
+// writes are refused with 503 and a Retry-After, so restic backs off rather
+// than failing on a host migrating disks, running a scrub or about to be
+// decommissioned. Lock files are still accepted because restic writes one
+// even to read a repository.
+//
+// The mode is kept in .airgapper-maintenance.json, so it survives restarts
+// and can be switched by a command while the server runs.
+//
+// A lockdown (see package lockdown) freezes the storage the same way, but
+// only lifting the lockdown unfreezes it: ending maintenance is refused.
+
+// DefaultMaintenanceRetryAfter is the Retry-After sent when maintenance
+// has no announced end
+const DefaultMaintenanceRetryAfter = 10 * time.Minute
+
+// Maintenance describes read-only maintenance mode
+type Maintenance struct {
+	Reason string    `json:"reason,omitempty"`
+	Since  time.Time `json:"since"`
+	Until  time.Time `json:"until,omitempty"` // Expected end, if announced
+
+	// Lockdown is the ID of the lockdown that froze the storage, if any
+	Lockdown string `json:"lockdown,omitempty"`
+
+	// Prune is the repository being pruned, which alone still accepts
+	// writes, and Deletion the deletion request the prune carries out
+	Prune    string `json:"prune,omitempty"`
+	Deletion string `json:"deletion,omitempty"`
+	// PruneBytes is the pruned repository's size when the window opened
+	PruneBytes int64 `json:"pruneBytes,omitempty"`
+}
+
+// RetryAfter is how long a client should wait before writing again
+func (m *Maintenance) RetryAfter(now time.Time) time.Duration {
+	if wait := m.Until.Sub(now); wait > 0 {
+		return wait
+	}
+	return DefaultMaintenanceRetryAfter
+}
+
+// maintenanceState caches the maintenance file, reloaded when it changes
+type maintenanceState struct {
+	mu      sync.Mutex
+	current *Maintenance
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+func (s *Server) maintenancePath() string {
+	return filepath.Join(s.basePath, ".airgapper-maintenance.json")
+}
+
+// Maintenance returns the maintenance mode in force, or nil when the server
+// accepts writes
+func (s *Server) Maintenance() *Maintenance {
+	ms := &s.maintenance
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	info, err := os.Stat(s.maintenancePath())
+	exists := err == nil
+	if exists == ms.exists && (!exists || info.ModTime().Equal(ms.modTime) && info.Size() == ms.size) {
+		return ms.current
+	}
+
+	ms.exists, ms.current = exists, nil
+	if !exists {
+		return nil
+	}
+	ms.modTime, ms.size = info.ModTime(), info.Size()
+
+	m := &Maintenance{}
+	data, err := os.ReadFile(s.maintenancePath())
+	if err == nil {
+		err = json.Unmarshal(data, m)
+	}
+	if err != nil {
+		// An unreadable file still means someone wanted the server read-only
+		logging.Warnf("[storage] failed to read maintenance mode: %v", err)
+		m = &Maintenance{Reason: "unreadable maintenance file", Since: info.ModTime()}
+	}
+	ms.current = m
+	return m
+}
+
+// SetMaintenance makes the server read-only until EndMaintenance. until is
+// the expected end (zero if unknown), which clients are told to wait for.
+func (s *Server) SetMaintenance(reason string, until time.Time) (*Maintenance, error) {
+	if err := s.checkNotFrozen(); err != nil {
+		return nil, err
+	}
+	m := &Maintenance{Reason: reason, Since: timeNow().UTC()}
+	if !until.IsZero() {
+		m.Until = until.UTC()
+	}
+	if err := s.saveMaintenance(m); err != nil {
+		return nil, err
+	}
+	s.audit(context.Background(), "MAINTENANCE_START", "", "Storage read-only for maintenance: "+reason, true, "")
+	return m, nil
+}
+
+func (s *Server) saveMaintenance(m *Maintenance) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.maintenancePath(), data, 0600); err != nil {
+		return fmt.Errorf("failed to save maintenance mode: %w", err)
+	}
+	return nil
+}
+
+// checkNotFrozen refuses to change maintenance mode while a lockdown froze
+// the storage
+func (s *Server) checkNotFrozen() error {
+	if m := s.Maintenance(); m != nil && m.Lockdown != "" {
+		return fmt.Errorf("storage is frozen by lockdown %s; it accepts writes again once the lockdown is lifted", m.Lockdown)
+	}
+	return nil
+}
+
+// EndMaintenance makes the server accept writes again
+func (s *Server) EndMaintenance() error {
+	if err := s.checkNotFrozen(); err != nil {
+		return err
+	}
+	return s.endMaintenance()
+}
+
+func (s *Server) endMaintenance() error {
+	if err := os.Remove(s.maintenancePath()); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to end maintenance mode: %w", err)
+	}
+	s.audit(context.Background(), "MAINTENANCE_END", "", "Storage accepting writes again", true, "")
+	return nil
+}
+
+// Freeze makes the server read-only for lockdown lockdownID, until
+// Unfreeze is called with the same ID
+func (s *Server) Freeze(lockdownID, reason string) error {
+	if m := s.Maintenance(); m != nil && m.Lockdown == lockdownID {
+		return nil
+	}
+	m := &Maintenance{Reason: "locked down", Since: timeNow().UTC(), Lockdown: lockdownID}
+	if reason != "" {
+		m.Reason += ": " + reason
+	}
+	if err := s.saveMaintenance(m); err != nil {
+		return err
+	}
+	s.audit(context.Background(), "LOCKDOWN_FREEZE", "", "Storage frozen by lockdown "+lockdownID, true, "")
+	return nil
+}
+
+// Unfreeze makes the server accept writes again if lockdown lockdownID
+// froze it
+func (s *Server) Unfreeze(lockdownID string) error {
+	m := s.Maintenance()
+	if m == nil || m.Lockdown != lockdownID {
+		return nil
+	}
+	return s.endMaintenance()
+}
+
+// rejectMaintenanceWrite refuses a write while the server is in maintenance
+// mode, reporting whether it did
+func (s *Server) rejectMaintenanceWrite(w http.ResponseWriter, r *http.Request, repo, fileType string) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead || fileType == "locks" {
+		return false
+	}
+	m := s.Maintenance()
+	if m == nil || (m.Prune != "" && m.Prune == repo) {
+		return false
+	}
+	retryAfter := int(m.RetryAfter(timeNow()).Seconds())
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	msg := "Storage is read-only for maintenance"
+	if m.Reason != "" {
+		msg += ": " + m.Reason
+	}
+	http.Error(w, msg, http.StatusServiceUnavailable)
+	return true
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceMode(t *testing.T) {
+	tmpDir := t.TempDir()
+	s, err := NewServer(Config{BasePath: tmpDir, AppendOnly: true})
+	require.NoError(t, err)
+	s.Start()
+	handler := s.Handler()
+
+	do := func(method, path string, data []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader(data))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/testrepo/", nil).Code)
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/testrepo/keys/aaaa", []byte("key")).Code)
+	assert.Nil(t, s.Maintenance())
+	assert.Nil(t, s.Status().Maintenance)
+
+	_, err = s.SetMaintenance("moving disks", time.Time{})
+	require.NoError(t, err)
+	require.NotNil(t, s.Status().Maintenance)
+	assert.Equal(t, "moving disks", s.Status().Maintenance.Reason)
+
+	// Reads still work
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/testrepo/keys/aaaa", nil).Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodHead, "/testrepo/keys/aaaa", nil).Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/testrepo/keys/", nil).Code)
+
+	// Writes are refused with a Retry-After
+	w := do(http.MethodPost, "/testrepo/keys/bbbb", []byte("key"))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "600", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "moving disks")
+	assert.Equal(t, http.StatusServiceUnavailable, do(http.MethodPost, "/other/", nil).Code)
+	assert.Equal(t, http.StatusServiceUnavailable, do(http.MethodPost, "/testrepo/config", []byte("cfg")).Code)
+
+	// Restic locks the repository even to read it
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/testrepo/locks/cccc", []byte("lock")).Code)
+
+	// The mode lives on disk, so another process (the CLI) can end it
+	other, err := NewServer(Config{BasePath: tmpDir})
+	require.NoError(t, err)
+	require.NotNil(t, other.Maintenance())
+	require.NoError(t, other.EndMaintenance())
+	assert.Nil(t, s.Maintenance())
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/testrepo/keys/bbbb", []byte("key")).Code)
+	require.NoError(t, s.EndMaintenance(), "ending twice is not an error")
+}
+
+func TestMaintenanceRetryAfter(t *testing.T) {
+	now := time.Now()
+	m := &Maintenance{Until: now.Add(2 * time.Hour)}
+	assert.Equal(t, 2*time.Hour, m.RetryAfter(now))
+
+	// Overrunning maintenance falls back to the default
+	assert.Equal(t, DefaultMaintenanceRetryAfter, m.RetryAfter(now.Add(3*time.Hour)))
+	assert.Equal(t, DefaultMaintenanceRetryAfter, (&Maintenance{}).RetryAfter(now))
+}
+
+func TestMaintenanceUnreadableFile(t *testing.T) {
+	s, err := NewServer(Config{BasePath: t.TempDir()})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(s.maintenancePath(), []byte("{not json"), 0600))
+
+	m := s.Maintenance()
+	require.NotNil(t, m, "a damaged file still keeps the server read-only")
+	assert.Contains(t, m.Reason, "unreadable")
+}
+
+func TestLockdownFreeze(t *testing.T) {
+	s, err := NewServer(Config{BasePath: t.TempDir()})
+	require.NoError(t, err)
+
+	require.NoError(t, s.Freeze("abcd", "laptop stolen"))
+	m := s.Maintenance()
+	require.NotNil(t, m)
+	assert.Equal(t, "abcd", m.Lockdown)
+	assert.Contains(t, m.Reason, "laptop stolen")
+
+	// Only lifting the lockdown unfreezes the storage
+	assert.Error(t, s.EndMaintenance())
+	_, err = s.SetMaintenance("moving disks", time.Time{})
+	assert.Error(t, err)
+	require.NoError(t, s.Unfreeze("other"))
+	assert.NotNil(t, s.Maintenance())
+	require.NoError(t, s.Unfreeze("abcd"))
+	assert.Nil(t, s.Maintenance())
+
+	// Unfreezing leaves ordinary maintenance alone
+	_, err = s.SetMaintenance("moving disks", time.Time{})
+	require.NoError(t, err)
+	require.NoError(t, s.Unfreeze("abcd"))
+	assert.NotNil(t, s.Maintenance())
+}
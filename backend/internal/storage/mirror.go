@@ -0,0 +1,531 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/policy"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+)
+
+// A host can mirror the repositories it stores to another host it trusts so
+// a single site failure doesn't destroy the only copy. Mirroring is
+// append-only (nothing is ever deleted on the mirror) and only allowed while
+// the owner has signed a policy amendment naming the mirror.
+
+// mirrorTypes lists repository file types in push order: a mirror never
+// holds an index or snapshot that references data it doesn't have yet.
+// Locks are transient and never mirrored.
+var mirrorTypes = []string{"keys", "data", "index", "snapshots"}
+
+func (s *Server) mirrorsPath() string {
+	return filepath.Join(s.basePath, ".airgapper-mirrors.json")
+}
+
+// loadMirrorAmendments loads amendments from disk, dropping any that no
+// longer verify against the current policy
+func (s *Server) loadMirrorAmendments() {
+	data, err := os.ReadFile(s.mirrorsPath())
+	if err != nil {
+		return
+	}
+
+	var amendments []*policy.MirrorAmendment
+	if err := json.Unmarshal(data, &amendments); err != nil {
+		logging.Warnf("[storage] failed to parse mirror amendments: %v", err)
+		return
+	}
+
+	for _, a := range amendments {
+		if err := a.Verify(s.policy); err != nil {
+			logging.Warnf("[storage] ignoring mirror amendment %s: %v", a.ID, err)
+			continue
+		}
+		s.mirrors = append(s.mirrors, a)
+	}
+}
+
+// AddMirrorAmendment registers an owner-signed amendment allowing this host
+// to mirror to another host. It replaces an earlier amendment for the same URL.
+func (s *Server) AddMirrorAmendment(a *policy.MirrorAmendment) error {
+	if a == nil {
+		return fmt.Errorf("amendment cannot be nil")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.policy == nil {
+		return fmt.Errorf("mirroring requires a signed policy")
+	}
+	if err := a.Verify(s.policy); err != nil {
+		return fmt.Errorf("amendment verification failed: %w", err)
+	}
+
+	mirrors := make([]*policy.MirrorAmendment, 0, len(s.mirrors)+1)
+	for _, m := range s.mirrors {
+		if m.MirrorURL != a.MirrorURL {
+			mirrors = append(mirrors, m)
+		}
+	}
+	mirrors = append(mirrors, a)
+
+	data, err := json.MarshalIndent(mirrors, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize amendments: %w", err)
+	}
+	if err := os.WriteFile(s.mirrorsPath(), data, 0600); err != nil {
+		return fmt.Errorf("failed to save amendments: %w", err)
+	}
+
+	s.mirrors = mirrors
+	s.audit(context.Background(), "MIRROR_AUTHORIZED", "", fmt.Sprintf("Mirror %s (%s) authorized by amendment %s", a.MirrorName, a.MirrorURL, a.ID), true, "")
+	return nil
+}
+
+// MirrorAmendments returns the registered mirror amendments
+func (s *Server) MirrorAmendments() []*policy.MirrorAmendment {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]*policy.MirrorAmendment(nil), s.mirrors...)
+}
+
+// mirrorAuthorization returns a currently valid amendment for the mirror URL
+func (s *Server) mirrorAuthorization(mirrorURL string) (*policy.MirrorAmendment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, a := range s.mirrors {
+		if strings.TrimSuffix(a.MirrorURL, "/") != mirrorURL {
+			continue
+		}
+		if err := a.Verify(s.policy); err != nil {
+			return nil, fmt.Errorf("mirror %s not authorized: %w", mirrorURL, err)
+		}
+		return a, nil
+	}
+	return nil, fmt.Errorf("mirror %s not authorized: no owner-signed amendment", mirrorURL)
+}
+
+// --- Sync client ---
+
+// SyncResult describes one mirror sync
+type SyncResult struct {
+	MirrorURL     string    `json:"mirrorUrl"`
+	Timestamp     time.Time `json:"timestamp"`
+	Duration      string    `json:"duration"`
+	Repos         int       `json:"repos"`
+	PushedFiles   int       `json:"pushedFiles"`
+	PushedBytes   int64     `json:"pushedBytes"`
+	PresentFiles  int       `json:"presentFiles"` // Already on the mirror
+	SkippedFiles  int       `json:"skippedFiles"` // Synced by an earlier run
+	CorruptFiles  int       `json:"corruptFiles"` // Not pushed: content doesn't match name
+	Errors        []string  `json:"errors,omitempty"`
+	Authorization string    `json:"authorization"` // Amendment ID
+}
+
+// mirrorState records files already pushed to a mirror, so each sync only
+// considers files added since the last one
+type mirrorState struct {
+	LastSync time.Time        `json:"lastSync"`
+	Synced   map[string]int64 `json:"synced"` // "repo/type/name" -> size
+}
+
+// Syncer pushes new repository files from a storage server to a mirror
+// host speaking the same storage protocol
+type Syncer struct {
+	server    *Server
+	mirrorURL string
+	client    *http.Client
+	mu        sync.Mutex
+}
+
+// NewSyncer creates a syncer from server to the storage endpoint at mirrorURL
+func NewSyncer(server *Server, mirrorURL string) *Syncer {
+	return &Syncer{
+		server:    server,
+		mirrorURL: strings.TrimSuffix(mirrorURL, "/"),
+		client:    tracing.NewClient(10 * time.Minute),
+	}
+}
+
+// SetHTTPClient replaces the HTTP client used to reach the mirror
+func (sy *Syncer) SetHTTPClient(client *http.Client) {
+	sy.client = client
+}
+
+func (sy *Syncer) statePath() string {
+	name := strings.NewReplacer("://", "_", "/", "_", ":", "_").Replace(sy.mirrorURL)
+	return filepath.Join(sy.server.basePath, ".airgapper-mirror-state-"+name+".json")
+}
+
+// Sync pushes files the mirror doesn't have yet. Local files are hashed
+// before sending so corruption is never propagated, and the mirror verifies
+// data blobs again on receipt.
+func (sy *Syncer) Sync(ctx context.Context) (*SyncResult, error) {
+	sy.mu.Lock()
+	defer sy.mu.Unlock()
+
+	// One correlation ID covers the sync's audit entries and its requests to the mirror
+	ctx, _ = tracing.Ensure(ctx)
+	start := time.Now()
+	result := &SyncResult{MirrorURL: sy.mirrorURL, Timestamp: start}
+
+	auth, err := sy.server.mirrorAuthorization(sy.mirrorURL)
+	if err != nil {
+		sy.server.audit(ctx, "MIRROR_DENIED", "", err.Error(), false, err.Error())
+		return nil, err
+	}
+	result.Authorization = auth.ID
+
+	state := sy.loadState()
+
+	entries, err := os.ReadDir(sy.server.basePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories: %w", err)
+	}
+
+	for _, entry := range entries {
+		repo := entry.Name()
+		if !entry.IsDir() || !isValidRepoName(repo) || !auth.Covers(repo) {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(sy.server.basePath, repo, "config")); err != nil {
+			continue // Not a restic repository
+		}
+
+		result.Repos++
+		if err := sy.syncRepo(ctx, repo, state, result); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", repo, err))
+			if ctx.Err() != nil {
+				break
+			}
+		}
+	}
+
+	state.LastSync = start
+	if err := sy.saveState(state); err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("save sync state: %v", err))
+	}
+
+	result.Duration = time.Since(start).String()
+	sy.server.audit(ctx, "MIRROR_SYNC", "", fmt.Sprintf("Pushed %d files (%d bytes) to %s",
+		result.PushedFiles, result.PushedBytes, sy.mirrorURL), len(result.Errors) == 0, strings.Join(result.Errors, "; "))
+
+	return result, nil
+}
+
+func (sy *Syncer) syncRepo(ctx context.Context, repo string, state *mirrorState, result *SyncResult) error {
+	repoPath := filepath.Join(sy.server.basePath, repo)
+
+	// Create the repository and its config on the mirror if needed
+	if err := sy.request(ctx, http.MethodPost, "/"+repo+"/", nil); err != nil {
+		return fmt.Errorf("create repository: %w", err)
+	}
+	if err := sy.request(ctx, http.MethodHead, "/"+repo+"/config", nil); err != nil {
+		data, readErr := os.ReadFile(filepath.Join(repoPath, "config"))
+		if readErr != nil {
+			return fmt.Errorf("read config: %w", readErr)
+		}
+		if err := sy.request(ctx, http.MethodPost, "/"+repo+"/config", data); err != nil {
+			return fmt.Errorf("push config: %w", err)
+		}
+	}
+
+	for _, fileType := range mirrorTypes {
+		local, err := listLocalFiles(filepath.Join(repoPath, fileType))
+		if err != nil {
+			return fmt.Errorf("list %s: %w", fileType, err)
+		}
+
+		// Only files not pushed by an earlier sync need the mirror's listing
+		var pending []string
+		for name, size := range local {
+			if synced, ok := state.Synced[repo+"/"+fileType+"/"+name]; ok && synced == size {
+				result.SkippedFiles++
+				continue
+			}
+			pending = append(pending, name)
+		}
+		if len(pending) == 0 {
+			continue
+		}
+
+		remote, err := sy.listRemote(ctx, repo, fileType)
+		if err != nil {
+			return fmt.Errorf("list mirror %s: %w", fileType, err)
+		}
+
+		for _, name := range pending {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			key := repo + "/" + fileType + "/" + name
+			size := local[name]
+
+			if remoteSize, ok := remote[name]; ok && remoteSize == size {
+				result.PresentFiles++
+				state.Synced[key] = size
+				continue
+			}
+
+			path := localFilePath(repoPath, fileType, name)
+			data, err := os.ReadFile(path)
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("read %s: %v", key, err))
+				continue
+			}
+			sum := sha256.Sum256(data)
+			if hex.EncodeToString(sum[:]) != name {
+				result.CorruptFiles++
+				result.Errors = append(result.Errors, fmt.Sprintf("CORRUPT: %s not pushed (hash doesn't match content)", key))
+				continue
+			}
+
+			err = sy.request(ctx, http.MethodPost, "/"+repo+"/"+fileType+"/"+name, data)
+			if errors.Is(err, errAlreadyPresent) {
+				result.PresentFiles++
+				state.Synced[key] = size
+				continue
+			}
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("push %s: %v", key, err))
+				continue
+			}
+			result.PushedFiles++
+			result.PushedBytes += size
+			state.Synced[key] = size
+		}
+	}
+
+	return nil
+}
+
+// listRemote returns the mirror's files of a type with their sizes
+func (sy *Syncer) listRemote(ctx context.Context, repo, fileType string) (map[string]int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sy.mirrorURL+"/"+repo+"/"+fileType+"/", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.x.restic.rest.v2")
+
+	resp, err := sy.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("mirror returned %s", resp.Status)
+	}
+
+	var files []struct {
+		Name string `json:"name"`
+		Size int64  `json:"size"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&files); err != nil {
+		return nil, fmt.Errorf("invalid listing: %w", err)
+	}
+
+	remote := make(map[string]int64, len(files))
+	for _, f := range files {
+		remote[f.Name] = f.Size
+	}
+	return remote, nil
+}
+
+// errAlreadyPresent is returned when the mirror already has a pushed file
+var errAlreadyPresent = errors.New("already on the mirror")
+
+// request sends a request to the mirror and fails on a non-2xx status
+func (sy *Syncer) request(ctx context.Context, method, path string, body []byte) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, sy.mirrorURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+
+	resp, err := sy.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode == http.StatusConflict {
+		return errAlreadyPresent
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("mirror returned %s", resp.Status)
+	}
+	return nil
+}
+
+// listLocalFiles returns file names and sizes under dir (data is sharded
+// into subdirectories)
+func listLocalFiles(dir string) (map[string]int64, error) {
+	files := make(map[string]int64)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || isTempFile(info.Name()) {
+			return nil
+		}
+		files[info.Name()] = info.Size()
+		return nil
+	})
+	return files, err
+}
+
+// localFilePath mirrors the layout used by handleFile
+func localFilePath(repoPath, fileType, name string) string {
+	if fileType == "data" && len(name) >= 2 {
+		return filepath.Join(repoPath, fileType, name[:2], name)
+	}
+	return filepath.Join(repoPath, fileType, name)
+}
+
+func (sy *Syncer) loadState() *mirrorState {
+	state := &mirrorState{}
+	if data, err := os.ReadFile(sy.statePath()); err == nil {
+		if err := json.Unmarshal(data, state); err != nil {
+			logging.Warnf("[storage] failed to parse mirror state: %v", err)
+		}
+	}
+	if state.Synced == nil {
+		state.Synced = make(map[string]int64)
+	}
+	return state
+}
+
+func (sy *Syncer) saveState(state *mirrorState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	path := sy.statePath()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// --- Scheduled sync ---
+
+// MirrorScheduler runs a Syncer periodically
+type MirrorScheduler struct {
+	syncer   *Syncer
+	interval time.Duration
+
+	stopChan chan struct{}
+	running  bool
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+}
+
+// NewMirrorScheduler creates a scheduler syncing every interval
+func NewMirrorScheduler(syncer *Syncer, interval time.Duration) *MirrorScheduler {
+	return &MirrorScheduler{
+		syncer:   syncer,
+		interval: interval,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start begins periodic syncing, starting with an immediate sync
+func (ms *MirrorScheduler) Start() {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.running {
+		return
+	}
+	ms.running = true
+
+	ms.wg.Add(1)
+	go func() {
+		defer ms.wg.Done()
+		ms.run()
+	}()
+}
+
+// Stop stops syncing and waits for an in-progress sync to be cancelled
+func (ms *MirrorScheduler) Stop() {
+	ms.mu.Lock()
+	if !ms.running {
+		ms.mu.Unlock()
+		return
+	}
+	ms.running = false
+	ms.mu.Unlock()
+
+	close(ms.stopChan)
+	ms.wg.Wait()
+}
+
+func (ms *MirrorScheduler) run() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-ms.stopChan
+		cancel()
+	}()
+
+	ticker := time.NewTicker(ms.interval)
+	defer ticker.Stop()
+
+	for {
+		ms.syncOnce(ctx)
+		select {
+		case <-ticker.C:
+		case <-ms.stopChan:
+			return
+		}
+	}
+}
+
+func (ms *MirrorScheduler) syncOnce(ctx context.Context) {
+	result, err := ms.syncer.Sync(ctx)
+	if err != nil {
+		logging.Error("Mirror sync failed", logging.String("mirror", ms.syncer.mirrorURL), logging.Err(err))
+		return
+	}
+	if len(result.Errors) > 0 {
+		logging.Error("Mirror sync finished with errors",
+			logging.String("mirror", result.MirrorURL),
+			logging.Int("pushed", result.PushedFiles),
+			logging.Int("errors", len(result.Errors)))
+		for _, e := range result.Errors {
+			logging.Error("  " + e)
+		}
+		return
+	}
+	logging.Info("Mirror sync complete",
+		logging.String("mirror", result.MirrorURL),
+		logging.Int("pushed", result.PushedFiles),
+		logging.Int64("bytes", result.PushedBytes))
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/policy"
+)
+
+// signedPolicy returns a fully signed policy and the owner's private key
+func signedPolicy(t *testing.T) (*policy.Policy, []byte) {
+	t.Helper()
+	ownerPub, ownerPriv, _ := crypto.GenerateKeyPair()
+	hostPub, hostPriv, _ := crypto.GenerateKeyPair()
+	p := policy.NewPolicy(
+		"Alice", crypto.KeyID(ownerPub), crypto.EncodePublicKey(ownerPub),
+		"Bob", crypto.KeyID(hostPub), crypto.EncodePublicKey(hostPub),
+	)
+	require.NoError(t, p.SignAsOwner(ownerPriv))
+	require.NoError(t, p.SignAsHost(hostPriv))
+	return p, ownerPriv
+}
+
+// writeRepoFile stores content under its hash the way handleFile does
+func writeRepoFile(t *testing.T, repoPath, fileType string, content []byte) string {
+	t.Helper()
+	sum := sha256.Sum256(content)
+	name := hex.EncodeToString(sum[:])
+	path := localFilePath(repoPath, fileType, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, content, 0644))
+	return name
+}
+
+func setupMirror(t *testing.T) (*Server, *httptest.Server, string, []byte) {
+	t.Helper()
+	p, ownerPriv := signedPolicy(t)
+
+	src, err := NewServer(Config{BasePath: t.TempDir(), AppendOnly: true, Policy: p})
+	require.NoError(t, err)
+
+	mirrorPath := t.TempDir()
+	dst, err := NewServer(Config{BasePath: mirrorPath, AppendOnly: true})
+	require.NoError(t, err)
+	dst.Start()
+	t.Cleanup(dst.Stop)
+
+	ts := httptest.NewServer(dst.Handler())
+	t.Cleanup(ts.Close)
+
+	repoPath := filepath.Join(src.basePath, "repo1")
+	require.NoError(t, os.MkdirAll(repoPath, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "config"), []byte("config"), 0644))
+
+	return src, ts, mirrorPath, ownerPriv
+}
+
+func authorize(t *testing.T, s *Server, url string, ownerPriv []byte) {
+	t.Helper()
+	a := policy.NewMirrorAmendment(s.GetPolicy(), "carol", url)
+	require.NoError(t, a.SignAsOwner(ownerPriv))
+	require.NoError(t, s.AddMirrorAmendment(a))
+}
+
+func TestSyncer_RequiresAmendment(t *testing.T) {
+	src, ts, _, _ := setupMirror(t)
+
+	_, err := NewSyncer(src, ts.URL).Sync(context.Background())
+	assert.ErrorContains(t, err, "not authorized")
+
+	// An amendment signed by someone other than the owner is rejected
+	_, otherPriv, _ := crypto.GenerateKeyPair()
+	a := policy.NewMirrorAmendment(src.GetPolicy(), "carol", ts.URL)
+	require.NoError(t, a.SignAsOwner(otherPriv))
+	assert.Error(t, src.AddMirrorAmendment(a))
+}
+
+func TestSyncer_PushesNewFiles(t *testing.T) {
+	src, ts, mirrorPath, ownerPriv := setupMirror(t)
+	authorize(t, src, ts.URL+"/", ownerPriv)
+
+	repoPath := filepath.Join(src.basePath, "repo1")
+	dataName := writeRepoFile(t, repoPath, "data", []byte("pack"))
+	snapName := writeRepoFile(t, repoPath, "snapshots", []byte("snapshot"))
+	writeRepoFile(t, repoPath, "locks", []byte("lock"))
+
+	syncer := NewSyncer(src, ts.URL)
+	result, err := syncer.Sync(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, 1, result.Repos)
+	assert.Equal(t, 2, result.PushedFiles, "locks are not mirrored")
+
+	mirrorRepo := filepath.Join(mirrorPath, "repo1")
+	assert.FileExists(t, filepath.Join(mirrorRepo, "config"))
+	assert.FileExists(t, localFilePath(mirrorRepo, "data", dataName))
+	assert.FileExists(t, localFilePath(mirrorRepo, "snapshots", snapName))
+	assert.NoFileExists(t, filepath.Join(mirrorRepo, "locks", "lock"))
+
+	// Only files added since the last sync are considered
+	writeRepoFile(t, repoPath, "index", []byte("index"))
+	result, err = syncer.Sync(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.PushedFiles)
+	assert.Equal(t, 2, result.SkippedFiles)
+
+	// A fresh syncer finds the files already present on the mirror
+	require.NoError(t, os.Remove(syncer.statePath()))
+	result, err = NewSyncer(src, ts.URL).Sync(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.PushedFiles)
+	assert.Equal(t, 3, result.PresentFiles)
+}
+
+func TestSyncer_DoesNotPushCorruptFiles(t *testing.T) {
+	src, ts, mirrorPath, ownerPriv := setupMirror(t)
+	authorize(t, src, ts.URL, ownerPriv)
+
+	repoPath := filepath.Join(src.basePath, "repo1")
+	name := writeRepoFile(t, repoPath, "index", []byte("index"))
+	require.NoError(t, os.WriteFile(localFilePath(repoPath, "index", name), []byte("rotted"), 0644))
+
+	result, err := NewSyncer(src, ts.URL).Sync(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.CorruptFiles)
+	assert.Equal(t, 0, result.PushedFiles)
+	assert.NoFileExists(t, localFilePath(filepath.Join(mirrorPath, "repo1"), "index", name))
+}
+
+func TestMirrorAmendments_Persist(t *testing.T) {
+	src, ts, _, ownerPriv := setupMirror(t)
+	authorize(t, src, ts.URL, ownerPriv)
+	authorize(t, src, ts.URL, ownerPriv) // replaces the first
+
+	reloaded, err := NewServer(Config{BasePath: src.basePath, Policy: src.GetPolicy()})
+	require.NoError(t, err)
+	require.Len(t, reloaded.MirrorAmendments(), 1)
+	assert.Equal(t, ts.URL, reloaded.MirrorAmendments()[0].MirrorURL)
+}
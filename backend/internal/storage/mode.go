@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/policy"
+)
+
+func (s *Server) modeChangePath() string {
+	return filepath.Join(s.basePath, ".airgapper-mode-change.json")
+}
+
+// enforcePolicyMode never lets the server run weaker than the policy
+// demands: a host can't disable append-only under a locking policy by
+// editing its config. Only a mode change signed by both the owner and the
+// host for the active policy revision unlocks it; otherwise the server is
+// escalated back to append-only.
+func (s *Server) enforcePolicyMode() {
+	if s.policy == nil || !s.policy.AppendOnlyLocked || s.appendOnly {
+		return
+	}
+
+	mc, err := s.loadModeChange()
+	if err == nil && !mc.AppendOnly {
+		logging.Warnf("[storage] append-only disabled under policy %s by mode change %s", s.policy.ID, mc.ID)
+		s.audit(context.Background(), "MODE_UNLOCKED", "", fmt.Sprintf("Started without append-only under mode change %s", mc.ID), true, "")
+		return
+	}
+
+	reason := "no mode change signed by owner and host"
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		reason = err.Error()
+	}
+	s.appendOnly = true
+	logging.Warnf("[storage] policy %s locks append-only; ignoring configured mode (%s)", s.policy.ID, reason)
+	s.audit(context.Background(), "APPEND_ONLY_ENFORCED", "", fmt.Sprintf("Configured without append-only, escalated by policy %s", s.policy.ID), false, reason)
+}
+
+// loadModeChange returns the registered mode change if it verifies against
+// the active policy
+func (s *Server) loadModeChange() (*policy.ModeChange, error) {
+	data, err := os.ReadFile(s.modeChangePath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mode change: %w", err)
+	}
+
+	mc, err := policy.ModeChangeFromJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := mc.Verify(s.policy); err != nil {
+		return nil, fmt.Errorf("mode change %s rejected: %w", mc.ID, err)
+	}
+	return mc, nil
+}
+
+// ApplyModeChange registers a mode change signed by both parties. Unlocking
+// takes effect immediately and lets later starts run without append-only;
+// a change back to append-only re-locks the server and removes the unlock.
+func (s *Server) ApplyModeChange(mc *policy.ModeChange) error {
+	if mc == nil {
+		return fmt.Errorf("mode change cannot be nil")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.policy == nil {
+		return fmt.Errorf("mode changes require a signed policy")
+	}
+	if err := mc.Verify(s.policy); err != nil {
+		s.audit(context.Background(), "MODE_CHANGE_DENIED", "", fmt.Sprintf("Mode change %s", mc.ID), false, err.Error())
+		return fmt.Errorf("mode change verification failed: %w", err)
+	}
+
+	if mc.AppendOnly {
+		if err := os.Remove(s.modeChangePath()); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove mode change: %w", err)
+		}
+		s.appendOnly = true
+		s.audit(context.Background(), "MODE_CHANGE", "", fmt.Sprintf("Append-only re-enabled by mode change %s (signed by owner and host)", mc.ID), true, "")
+		return nil
+	}
+
+	data, err := mc.ToJSON()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.modeChangePath(), data, 0600); err != nil {
+		return fmt.Errorf("failed to save mode change: %w", err)
+	}
+
+	s.appendOnly = false
+	s.audit(context.Background(), "MODE_CHANGE", "", fmt.Sprintf("Append-only disabled by mode change %s (signed by owner and host): %s", mc.ID, mc.Reason), true, "")
+	return nil
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/policy"
+)
+
+func TestPolicyMode_AppendOnlyLock(t *testing.T) {
+	ownerPub, ownerPriv, _ := crypto.GenerateKeyPair()
+	hostPub, hostPriv, _ := crypto.GenerateKeyPair()
+	p := policy.NewPolicy(
+		"Alice", crypto.KeyID(ownerPub), crypto.EncodePublicKey(ownerPub),
+		"Bob", crypto.KeyID(hostPub), crypto.EncodePublicKey(hostPub),
+	)
+	require.NoError(t, p.SignAsOwner(ownerPriv))
+	require.NoError(t, p.SignAsHost(hostPriv))
+	require.True(t, p.AppendOnlyLocked)
+
+	basePath := t.TempDir()
+	s, err := NewServer(Config{BasePath: basePath, AppendOnly: true})
+	require.NoError(t, err)
+	require.NoError(t, s.SetPolicy(p))
+
+	// Turning append-only off in the local config isn't enough
+	s, err = NewServer(Config{BasePath: basePath, AppendOnly: false})
+	require.NoError(t, err)
+	assert.True(t, s.Status().AppendOnly)
+
+	// A mode change signed only by the host is rejected
+	mc, err := policy.NewModeChange(p, false, "migrating")
+	require.NoError(t, err)
+	require.NoError(t, mc.Sign(policy.RoleHost, hostPriv))
+	assert.Error(t, s.ApplyModeChange(mc))
+	assert.True(t, s.Status().AppendOnly)
+
+	require.NoError(t, mc.Sign(policy.RoleOwner, ownerPriv))
+	require.NoError(t, s.ApplyModeChange(mc))
+	assert.False(t, s.Status().AppendOnly)
+
+	s, err = NewServer(Config{BasePath: basePath, AppendOnly: false})
+	require.NoError(t, err)
+	assert.False(t, s.Status().AppendOnly, "signed unlock persists across restarts")
+
+	// Re-locking removes the unlock
+	relock, err := policy.NewModeChange(p, true, "done")
+	require.NoError(t, err)
+	require.NoError(t, relock.Sign(policy.RoleOwner, ownerPriv))
+	require.NoError(t, relock.Sign(policy.RoleHost, hostPriv))
+	require.NoError(t, s.ApplyModeChange(relock))
+	assert.True(t, s.Status().AppendOnly)
+
+	s, err = NewServer(Config{BasePath: basePath, AppendOnly: false})
+	require.NoError(t, err)
+	assert.True(t, s.Status().AppendOnly)
+
+	var ops []string
+	for _, e := range s.GetAuditLog(0) {
+		ops = append(ops, e.Operation)
+	}
+	assert.Contains(t, ops, "MODE_CHANGE_DENIED")
+	assert.Contains(t, ops, "MODE_CHANGE")
+	assert.Contains(t, ops, "APPEND_ONLY_ENFORCED")
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/policy"
+)
+
+func (s *Server) pinsPath() string {
+	return filepath.Join(s.basePath, ".airgapper-pins.json")
+}
+
+// Pins returns the registered snapshot pins
+func (s *Server) Pins() ([]*policy.Pin, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set, err := policy.LoadPinSet(s.pinsPath())
+	if err != nil {
+		return nil, err
+	}
+	return set.Pins, nil
+}
+
+// PinSnapshot registers a pin signed by the party that set it. A pinned
+// snapshot can't be deleted, whatever the retention rules say.
+func (s *Server) PinSnapshot(pin *policy.Pin) error {
+	if pin == nil {
+		return fmt.Errorf("pin cannot be nil")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.policy == nil {
+		return fmt.Errorf("pins require a signed policy")
+	}
+
+	set, err := policy.LoadPinSet(s.pinsPath())
+	if err != nil {
+		return err
+	}
+	if err := set.Add(s.policy, pin); err != nil {
+		s.audit(context.Background(), "PIN_DENIED", "", fmt.Sprintf("Pin %s on snapshot %s", pin.ID, pin.SnapshotID), false, err.Error())
+		return err
+	}
+	if err := set.Save(s.pinsPath()); err != nil {
+		return err
+	}
+
+	s.audit(context.Background(), "PIN", "", fmt.Sprintf("Snapshot %s pinned by %s: %s", pin.SnapshotID, pin.SetBy, pin.Label), true, "")
+	return nil
+}
+
+// UnpinSnapshot releases a pin. Releasing a pin the other party set needs
+// that party's consent signature on the unpin.
+func (s *Server) UnpinSnapshot(u *policy.Unpin) (*policy.Pin, error) {
+	if u == nil {
+		return nil, fmt.Errorf("unpin cannot be nil")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.policy == nil {
+		return nil, fmt.Errorf("pins require a signed policy")
+	}
+
+	set, err := policy.LoadPinSet(s.pinsPath())
+	if err != nil {
+		return nil, err
+	}
+	pin, err := set.Remove(s.policy, u)
+	if err != nil {
+		s.audit(context.Background(), "UNPIN_DENIED", "", fmt.Sprintf("Unpin %s of pin %s", u.ID, u.PinID), false, err.Error())
+		return nil, err
+	}
+	if err := set.Save(s.pinsPath()); err != nil {
+		return nil, err
+	}
+
+	s.audit(context.Background(), "UNPIN", "", fmt.Sprintf("Snapshot %s unpinned by %s (%s)", pin.SnapshotID, u.RequestedBy, pin.Label), true, "")
+	return pin, nil
+}
+
+// checkPinned refuses deletion of pinned snapshots, and of the repository
+// config while any snapshot is pinned (removing it would destroy the
+// repository along with the pinned snapshots)
+func (s *Server) checkPinned(filePath string) (bool, string) {
+	s.mu.RLock()
+	set, err := policy.LoadPinSet(s.pinsPath())
+	s.mu.RUnlock()
+	if err != nil {
+		return false, "cannot read snapshot pins: " + err.Error()
+	}
+	if len(set.Pins) == 0 {
+		return true, ""
+	}
+
+	if filepath.Base(filePath) == "config" {
+		return false, "repository has pinned snapshots"
+	}
+	if !strings.Contains(filePath, "/snapshots/") {
+		return true, ""
+	}
+	if pin := set.Pinned(filepath.Base(filePath)); pin != nil {
+		return false, fmt.Sprintf("snapshot is pinned by %s: %s", pin.SetBy, pin.Label)
+	}
+	return true, ""
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/policy"
+)
+
+func TestSnapshotPins_BlockDeletion(t *testing.T) {
+	ownerPub, ownerPriv, _ := crypto.GenerateKeyPair()
+	hostPub, hostPriv, _ := crypto.GenerateKeyPair()
+	p := policy.NewPolicy(
+		"Alice", crypto.KeyID(ownerPub), crypto.EncodePublicKey(ownerPub),
+		"Bob", crypto.KeyID(hostPub), crypto.EncodePublicKey(hostPub),
+	)
+	p.RetentionDays = 0
+	p.DeletionMode = policy.DeletionTimeLockOnly
+	p.AppendOnlyLocked = false
+	require.NoError(t, p.SignAsOwner(ownerPriv))
+	require.NoError(t, p.SignAsHost(hostPriv))
+
+	basePath := t.TempDir()
+	s, err := NewServer(Config{BasePath: basePath, Policy: p})
+	require.NoError(t, err)
+	s.Start()
+
+	snapshotID := "4f2a9c1be07d3316a8f0c2d5e9b47a61c3d8e2f0a1b5c7d9e4f6a8b0c2d4e6f8"
+	snapshots := filepath.Join(basePath, "repo", "snapshots")
+	require.NoError(t, os.MkdirAll(snapshots, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(snapshots, snapshotID), []byte("snap"), 0644))
+
+	pin, err := policy.NewPin(p, snapshotID[:8], "tax year 2023", policy.RoleOwner)
+	require.NoError(t, err)
+	require.NoError(t, pin.Sign(hostPriv))
+	assert.Error(t, s.PinSnapshot(pin), "signed with the wrong party's key")
+	require.NoError(t, pin.Sign(ownerPriv))
+	require.NoError(t, s.PinSnapshot(pin))
+
+	del := func(path string) int {
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodDelete, path, nil))
+		return w.Code
+	}
+	assert.Equal(t, http.StatusForbidden, del("/repo/snapshots/"+snapshotID))
+	assert.Equal(t, http.StatusForbidden, del("/repo/config"), "repository config is held too")
+	assert.FileExists(t, filepath.Join(snapshots, snapshotID))
+
+	// The host can't release the owner's pin without the owner's consent
+	u, err := policy.NewUnpin(pin, policy.RoleHost, "no longer needed")
+	require.NoError(t, err)
+	require.NoError(t, u.Sign(pin, policy.RoleHost, hostPriv))
+	_, err = s.UnpinSnapshot(u)
+	assert.Error(t, err)
+
+	pins, err := s.Pins()
+	require.NoError(t, err)
+	assert.Len(t, pins, 1)
+
+	require.NoError(t, u.Sign(pin, policy.RoleOwner, ownerPriv))
+	_, err = s.UnpinSnapshot(u)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, del("/repo/snapshots/"+snapshotID))
+	assert.NoFileExists(t, filepath.Join(snapshots, snapshotID))
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -60,12 +61,24 @@ func (s *Server) SetPolicy(p *policy.Policy) error {
 		}
 	}
 
+	return s.setPolicyLocked(context.Background(), p, fmt.Sprintf("Policy %s set (retention: %d days)", p.ID, p.RetentionDays))
+}
+
+// setPolicyLocked persists p as the active policy, recording the policy it
+// replaces in the history. Callers must hold s.mu.
+func (s *Server) setPolicyLocked(ctx context.Context, p *policy.Policy, details string) error {
 	// Persist to disk
 	data, err := p.ToJSON()
 	if err != nil {
 		return fmt.Errorf("failed to serialize policy: %w", err)
 	}
 
+	if s.policy != nil {
+		if err := s.appendPolicyHistory(s.policy); err != nil {
+			return err
+		}
+	}
+
 	if err := os.WriteFile(s.policyPath(), data, 0600); err != nil {
 		return fmt.Errorf("failed to save policy: %w", err)
 	}
@@ -73,7 +86,7 @@ func (s *Server) SetPolicy(p *policy.Policy) error {
 	s.policy = p
 
 	// Log the policy change
-	s.audit("POLICY_SET", "", fmt.Sprintf("Policy %s set (retention: %d days)", p.ID, p.RetentionDays), true, "")
+	s.audit(ctx, "POLICY_SET", "", details, true, "")
 
 	// If policy locks append-only mode, enforce it
 	if p.AppendOnlyLocked {
@@ -0,0 +1,255 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+	"github.com/lcrostarosa/airgapper/backend/internal/policy"
+)
+
+// Policies are renegotiated through amendments: either party proposes new
+// terms, both sign the resulting revision (which references the hash of the
+// policy it replaces) and the host applies it once fully signed. Every
+// replaced policy is kept, so the history forms a verifiable signed chain.
+
+// ErrAmendmentNotFound is returned for unknown amendment IDs
+var ErrAmendmentNotFound = apperrors.New(apperrors.CodeAmendmentNotFound, "amendment not found")
+
+func (s *Server) policyHistoryPath() string {
+	return filepath.Join(s.basePath, ".airgapper-policy-history.json")
+}
+
+func (s *Server) policyAmendmentsPath() string {
+	return filepath.Join(s.basePath, ".airgapper-policy-amendments.json")
+}
+
+// SetAmendmentNotifier sets a callback invoked whenever an amendment is
+// proposed or signed and still awaits the counterparty's signature
+func (s *Server) SetAmendmentNotifier(fn func(context.Context, *policy.Amendment)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.amendmentNotifier = fn
+}
+
+// PolicyHistory returns every policy this server has enforced, oldest first,
+// ending with the active one
+func (s *Server) PolicyHistory() ([]*policy.Policy, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	history, err := s.loadPolicyHistory()
+	if err != nil {
+		return nil, err
+	}
+	if s.policy != nil {
+		history = append(history, s.policy)
+	}
+	return history, nil
+}
+
+// PolicyAmendments returns all proposed amendments, oldest first
+func (s *Server) PolicyAmendments() ([]*policy.Amendment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.loadPolicyAmendments()
+}
+
+// ProposePolicyAmendment records a proposal to change the active policy's terms
+func (s *Server) ProposePolicyAmendment(ctx context.Context, proposedBy, reason string, terms policy.Terms) (*policy.Amendment, error) {
+	s.mu.Lock()
+
+	if s.policy == nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("no policy to amend")
+	}
+
+	a, err := policy.NewAmendment(s.policy, proposedBy, reason, terms)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+
+	amendments, err := s.loadPolicyAmendments()
+	if err == nil {
+		err = s.savePolicyAmendments(append(amendments, a))
+	}
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+
+	s.audit(ctx, "POLICY_AMENDMENT_PROPOSED", "", fmt.Sprintf("Amendment %s to policy %s proposed by %s", a.ID, a.PolicyID, proposedBy), true, "")
+	notify := s.amendmentNotifier
+	s.mu.Unlock()
+
+	if notify != nil {
+		notify(ctx, a)
+	}
+	return a, nil
+}
+
+// SignPolicyAmendment attaches a party's signature to a pending amendment.
+// Once both parties have signed, the revision replaces the active policy and
+// any other pending amendments to the same revision are superseded.
+func (s *Server) SignPolicyAmendment(ctx context.Context, id, role, signature string) (*policy.Amendment, error) {
+	s.mu.Lock()
+
+	amendments, err := s.loadPolicyAmendments()
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	a := findAmendment(amendments, id)
+	if a == nil {
+		s.mu.Unlock()
+		return nil, ErrAmendmentNotFound
+	}
+
+	if err := a.AddSignature(role, signature); err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+
+	if a.Proposed.IsFullySigned() {
+		if err := a.Proposed.VerifySuccessor(s.policy); err != nil {
+			s.mu.Unlock()
+			return nil, fmt.Errorf("amendment no longer applies to the active policy: %w", err)
+		}
+		details := fmt.Sprintf("Policy %s amended to revision %d by amendment %s (retention: %d days, deletion: %s)",
+			a.PolicyID, a.Proposed.Revision, a.ID, a.Proposed.RetentionDays, a.Proposed.DeletionMode)
+		if err := s.setPolicyLocked(ctx, a.Proposed, details); err != nil {
+			s.mu.Unlock()
+			return nil, err
+		}
+
+		now := timeNow()
+		a.Status = policy.AmendmentApplied
+		a.ResolvedAt = now
+		a.ResolvedBy = role
+		for _, other := range amendments {
+			if other != a && other.Status == policy.AmendmentPending && other.BaseHash == a.BaseHash {
+				other.Status = policy.AmendmentSuperseded
+				other.ResolvedAt = now
+			}
+		}
+		logging.Infof("[storage] Policy %s amended to revision %d", a.PolicyID, a.Proposed.Revision)
+	}
+
+	if err := s.savePolicyAmendments(amendments); err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+
+	notify := s.amendmentNotifier
+	s.mu.Unlock()
+
+	if notify != nil && a.Status == policy.AmendmentPending {
+		notify(ctx, a)
+	}
+	return a, nil
+}
+
+// RejectPolicyAmendment declines a pending amendment
+func (s *Server) RejectPolicyAmendment(ctx context.Context, id, role string) (*policy.Amendment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	amendments, err := s.loadPolicyAmendments()
+	if err != nil {
+		return nil, err
+	}
+	a := findAmendment(amendments, id)
+	if a == nil {
+		return nil, ErrAmendmentNotFound
+	}
+	if a.Status != policy.AmendmentPending {
+		return nil, fmt.Errorf("amendment is %s", a.Status)
+	}
+	if role != policy.RoleOwner && role != policy.RoleHost {
+		return nil, fmt.Errorf("role must be %q or %q", policy.RoleOwner, policy.RoleHost)
+	}
+
+	a.Status = policy.AmendmentRejected
+	a.ResolvedAt = timeNow()
+	a.ResolvedBy = role
+	if err := s.savePolicyAmendments(amendments); err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, "POLICY_AMENDMENT_REJECTED", "", fmt.Sprintf("Amendment %s rejected by %s", a.ID, role), true, "")
+	return a, nil
+}
+
+func findAmendment(amendments []*policy.Amendment, id string) *policy.Amendment {
+	for _, a := range amendments {
+		if a.ID == id {
+			return a
+		}
+	}
+	return nil
+}
+
+func (s *Server) loadPolicyHistory() ([]*policy.Policy, error) {
+	data, err := os.ReadFile(s.policyHistoryPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy history: %w", err)
+	}
+
+	var history []*policy.Policy
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("failed to parse policy history: %w", err)
+	}
+	return history, nil
+}
+
+// appendPolicyHistory records a replaced policy. Callers must hold s.mu.
+func (s *Server) appendPolicyHistory(p *policy.Policy) error {
+	history, err := s.loadPolicyHistory()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(append(history, p), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize policy history: %w", err)
+	}
+	if err := os.WriteFile(s.policyHistoryPath(), data, 0600); err != nil {
+		return fmt.Errorf("failed to save policy history: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) loadPolicyAmendments() ([]*policy.Amendment, error) {
+	data, err := os.ReadFile(s.policyAmendmentsPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy amendments: %w", err)
+	}
+
+	var amendments []*policy.Amendment
+	if err := json.Unmarshal(data, &amendments); err != nil {
+		return nil, fmt.Errorf("failed to parse policy amendments: %w", err)
+	}
+	return amendments, nil
+}
+
+func (s *Server) savePolicyAmendments(amendments []*policy.Amendment) error {
+	data, err := json.MarshalIndent(amendments, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize policy amendments: %w", err)
+	}
+	if err := os.WriteFile(s.policyAmendmentsPath(), data, 0600); err != nil {
+		return fmt.Errorf("failed to save policy amendments: %w", err)
+	}
+	return nil
+}
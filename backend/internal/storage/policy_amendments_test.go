@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/policy"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
+)
+
+func signAmendment(t *testing.T, a *policy.Amendment, priv []byte) string {
+	t.Helper()
+	hash, err := a.Proposed.Hash()
+	require.NoError(t, err)
+	sig, err := crypto.Sign(priv, hash)
+	require.NoError(t, err)
+	return hex.EncodeToString(sig)
+}
+
+func TestPolicyAmendment_AppliedOnceFullySigned(t *testing.T) {
+	ownerPub, ownerPriv, _ := crypto.GenerateKeyPair()
+	hostPub, hostPriv, _ := crypto.GenerateKeyPair()
+	p := policy.NewPolicy(
+		"Alice", crypto.KeyID(ownerPub), crypto.EncodePublicKey(ownerPub),
+		"Bob", crypto.KeyID(hostPub), crypto.EncodePublicKey(hostPub),
+	)
+	require.NoError(t, p.SignAsOwner(ownerPriv))
+	require.NoError(t, p.SignAsHost(hostPriv))
+
+	s, err := NewServer(Config{BasePath: t.TempDir(), Policy: p})
+	require.NoError(t, err)
+
+	var notified, correlations []string
+	s.SetAmendmentNotifier(func(ctx context.Context, a *policy.Amendment) {
+		notified = append(notified, a.ID)
+		correlations = append(correlations, tracing.ID(ctx))
+	})
+
+	ctx := context.Background()
+	days := 90
+	a, err := s.ProposePolicyAmendment(tracing.WithID(ctx, "propose-1"), policy.RoleOwner, "longer retention", policy.Terms{RetentionDays: &days})
+	require.NoError(t, err)
+	quota := int64(1 << 30)
+	competing, err := s.ProposePolicyAmendment(ctx, policy.RoleHost, "cap usage", policy.Terms{MaxStorageBytes: &quota})
+	require.NoError(t, err)
+	assert.Len(t, notified, 2)
+	assert.Equal(t, []string{"propose-1", ""}, correlations, "notices carry the proposing request's ID")
+	for _, e := range s.GetAuditLog(0) {
+		if e.Operation == "POLICY_AMENDMENT_PROPOSED" {
+			assert.Equal(t, "propose-1", e.CorrelationID)
+			break
+		}
+	}
+
+	// One signature isn't enough
+	a, err = s.SignPolicyAmendment(ctx, a.ID, policy.RoleOwner, signAmendment(t, a, ownerPriv))
+	require.NoError(t, err)
+	assert.Equal(t, policy.AmendmentPending, a.Status)
+	assert.Equal(t, 30, s.GetPolicy().RetentionDays)
+
+	a, err = s.SignPolicyAmendment(ctx, a.ID, policy.RoleHost, signAmendment(t, a, hostPriv))
+	require.NoError(t, err)
+	assert.Equal(t, policy.AmendmentApplied, a.Status)
+	assert.Equal(t, 90, s.GetPolicy().RetentionDays)
+	assert.Equal(t, 1, s.GetPolicy().Revision)
+
+	// The competing proposal amended the old revision
+	amendments, err := s.PolicyAmendments()
+	require.NoError(t, err)
+	require.Len(t, amendments, 2)
+	assert.Equal(t, competing.ID, amendments[1].ID)
+	assert.Equal(t, policy.AmendmentSuperseded, amendments[1].Status)
+	_, err = s.SignPolicyAmendment(ctx, competing.ID, policy.RoleHost, signAmendment(t, competing, hostPriv))
+	assert.Error(t, err)
+
+	history, err := s.PolicyHistory()
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.NoError(t, policy.VerifyHistory(history))
+
+	// The amended policy survives a restart
+	reloaded, err := NewServer(Config{BasePath: s.basePath})
+	require.NoError(t, err)
+	assert.Equal(t, 90, reloaded.GetPolicy().RetentionDays)
+}
+
+func TestPolicyAmendment_Reject(t *testing.T) {
+	ctx := context.Background()
+	p, _ := signedPolicy(t)
+	s, err := NewServer(Config{BasePath: t.TempDir(), Policy: p})
+	require.NoError(t, err)
+
+	_, err = s.SignPolicyAmendment(ctx, "missing", policy.RoleOwner, "00")
+	assert.ErrorIs(t, err, ErrAmendmentNotFound)
+
+	mode := policy.DeletionNever
+	a, err := s.ProposePolicyAmendment(ctx, policy.RoleHost, "", policy.Terms{DeletionMode: &mode})
+	require.NoError(t, err)
+
+	a, err = s.RejectPolicyAmendment(ctx, a.ID, policy.RoleOwner)
+	require.NoError(t, err)
+	assert.Equal(t, policy.AmendmentRejected, a.Status)
+
+	_, err = s.RejectPolicyAmendment(ctx, a.ID, policy.RoleOwner)
+	assert.Error(t, err)
+}
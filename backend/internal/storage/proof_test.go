@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/verification"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Prove(t *testing.T) {
+	tmpDir := t.TempDir()
+	s, err := NewServer(Config{BasePath: tmpDir})
+	require.NoError(t, err)
+
+	hostPub, hostPriv, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+
+	pack := []byte("an encrypted pack file stored on the host")
+	sum := sha256.Sum256(pack)
+	packID := hex.EncodeToString(sum[:])
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "repo", "data", packID[:2]), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "repo", "data", packID[:2], packID), pack, 0600))
+
+	c, err := verification.NewProofChallenge("repo", []verification.ProofRange{
+		{Type: "data", ID: packID, Offset: 3, Length: 9},
+		{Type: "data", ID: packID, Offset: int64(len(pack)) - 2, Length: 4},
+		{Type: "index", ID: strings.Repeat("cd", 32), Offset: 0, Length: 8},
+	})
+	require.NoError(t, err)
+
+	proof, err := s.Prove(context.Background(), c, hostPriv, hostPub)
+	require.NoError(t, err)
+	require.Len(t, proof.Results, 3)
+	assert.Equal(t, verification.ProofHash(c.Nonce, pack[3:12]), proof.Results[0].Hash)
+	assert.Equal(t, "range beyond end of file", proof.Results[1].Error)
+	assert.Equal(t, "file not found", proof.Results[2].Error)
+
+	read := func(r verification.ProofRange) ([]byte, error) {
+		return pack[r.Offset : r.Offset+r.Length], nil
+	}
+	c.Ranges, proof.Results = c.Ranges[:1], proof.Results[:1]
+	assert.ErrorContains(t, c.Verify(proof, hostPub, read), "invalid host signature", "results were trimmed after signing")
+
+	t.Run("invalid repo", func(t *testing.T) {
+		c, err := verification.NewProofChallenge("../etc", []verification.ProofRange{{Type: "data", ID: packID, Length: 1}})
+		require.NoError(t, err)
+		_, err = s.Prove(context.Background(), c, hostPriv, hostPub)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/fssnap"
+)
+
+// After an approved deletion the owner prunes its repository: restic takes
+// an exclusive lock, repacks partly used packs and removes the rest, which
+// is IO heavy and can take hours. For its duration the host opens a prune
+// window, maintenance mode in which only the repository being pruned
+// accepts writes, so other writers back off instead of competing for the
+// disk. Closing the window collects what an interrupted prune left behind
+// and re-baselines what the server expects the repository to hold.
+
+// PruneResult is what closing a prune window found
+type PruneResult struct {
+	Repo             string `json:"repo"`
+	Deletion         string `json:"deletion"`
+	Bytes            int64  `json:"bytes"`            // The repository's size after the prune
+	FreedBytes       int64  `json:"freedBytes"`       // How much smaller it is than when the window opened
+	TempFilesRemoved int    `json:"tempFilesRemoved"` // Partial uploads left behind
+	Anomalies        int    `json:"anomalies"`        // Losses the prune doesn't explain
+}
+
+// BeginPrune opens a prune window for repo, carrying out deletion request
+// deletionID. Opening the window again for the same prune, e.g. when the
+// owner resumes it, returns the one already open.
+func (s *Server) BeginPrune(ctx context.Context, repo, deletionID string) (*Maintenance, error) {
+	if !isValidRepoName(repo) {
+		return nil, fmt.Errorf("invalid repository name %q", repo)
+	}
+	if deletionID == "" {
+		return nil, fmt.Errorf("a prune needs the deletion request it carries out")
+	}
+	if _, err := os.Stat(filepath.Join(s.basePath, repo)); err != nil {
+		return nil, fmt.Errorf("repository %s not found", repo)
+	}
+	if m := s.Maintenance(); m != nil {
+		if m.Prune == repo && m.Deletion == deletionID {
+			return m, nil
+		}
+		return nil, fmt.Errorf("storage is already read-only for maintenance: %s", m.Reason)
+	}
+
+	// A filesystem snapshot first, as the last undo for the deletion
+	if s.fsSnapshotsEnabled() {
+		if _, err := s.TakeFSSnapshot(ctx, fssnap.ReasonDeletion); err != nil {
+			return nil, fmt.Errorf("refusing to prune without a filesystem snapshot: %w", err)
+		}
+	}
+
+	m := &Maintenance{
+		Reason:     "pruning " + repo,
+		Since:      timeNow().UTC(),
+		Prune:      repo,
+		Deletion:   deletionID,
+		PruneBytes: s.repoBytes(repo),
+	}
+	if err := s.saveMaintenance(m); err != nil {
+		return nil, err
+	}
+	s.audit(ctx, "PRUNE_START", filepath.Join(s.basePath, repo), "prune window opened for deletion "+deletionID, true, "")
+	return m, nil
+}
+
+// EndPrune closes the prune window for repo: it removes partial uploads
+// left in the repository, takes stock of it as the new baseline (reporting
+// any loss the prune's deletions through the server don't explain) and
+// accepts writes again
+func (s *Server) EndPrune(ctx context.Context, repo, deletionID string) (*PruneResult, error) {
+	m := s.Maintenance()
+	if m == nil || m.Prune != repo || m.Deletion != deletionID {
+		return nil, fmt.Errorf("no prune window is open for deletion %s of %s", deletionID, repo)
+	}
+	repoPath := filepath.Join(s.basePath, repo)
+
+	// Nothing else writes to the repository while the window is open, and
+	// the prune is over, so any file still being written was abandoned
+	removed := 0
+	_ = filepath.Walk(repoPath, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && isTempFile(info.Name()) && os.Remove(path) == nil {
+			removed++
+		}
+		return nil
+	})
+
+	actual := scanRepo(repoPath)
+	u := &s.usage
+	u.mu.Lock()
+	var anomalies []Anomaly
+	if expected := u.repos[repo]; expected != nil {
+		anomalies = s.compareRepo(repo, expected, actual)
+	}
+	u.repos[repo] = actual
+	u.changes[repo]++
+	u.dirty = true
+	s.saveRepoUsageLocked()
+	u.mu.Unlock()
+	s.reportAnomalies(anomalies)
+
+	result := &PruneResult{
+		Repo:             repo,
+		Deletion:         deletionID,
+		Bytes:            actual.Bytes,
+		FreedBytes:       max(m.PruneBytes-actual.Bytes, 0),
+		TempFilesRemoved: removed,
+		Anomalies:        len(anomalies),
+	}
+	if err := s.endMaintenance(); err != nil {
+		return nil, err
+	}
+	s.audit(ctx, "PRUNE_END", repoPath, fmt.Sprintf("prune window closed for deletion %s: %d bytes freed, %d partial uploads removed, took %s",
+		deletionID, result.FreedBytes, removed, timeNow().Sub(m.Since).Round(time.Second)), true, "")
+	return result, nil
+}
+
+// repoBytes returns the size the server expects repo to have
+func (s *Server) repoBytes(repo string) int64 {
+	s.usage.mu.Lock()
+	defer s.usage.mu.Unlock()
+	if st := s.usage.repos[repo]; st != nil {
+		return st.Bytes
+	}
+	return 0
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneWindow(t *testing.T) {
+	s, err := NewServer(Config{BasePath: t.TempDir()})
+	require.NoError(t, err)
+	s.Start()
+	for _, repo := range []string{"/alice/", "/bob/"} {
+		require.Equal(t, http.StatusOK, send(s, http.MethodPost, repo, nil))
+	}
+	name := storeBlobIn(t, s, "alice", "data", 4096)
+	ctx := context.Background()
+
+	_, err = s.BeginPrune(ctx, "carol", "del-1")
+	assert.Error(t, err, "no such repository")
+	m, err := s.BeginPrune(ctx, "alice", "del-1")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", m.Prune)
+	_, err = s.BeginPrune(ctx, "alice", "del-1")
+	assert.NoError(t, err, "the same prune can resume")
+	_, err = s.BeginPrune(ctx, "bob", "del-2")
+	assert.Error(t, err, "one maintenance at a time")
+
+	// Only the pruned repository accepts writes
+	assert.Equal(t, http.StatusServiceUnavailable, send(s, http.MethodPost, "/bob/keys/k", []byte("key")))
+	assert.Equal(t, http.StatusOK, send(s, http.MethodDelete, "/alice/data/"+name, nil))
+	partial := filepath.Join(s.basePath, "alice", "data", name[:2], name+".123"+tempSuffix)
+	require.NoError(t, os.WriteFile(partial, []byte("half a pack"), 0600))
+
+	_, err = s.EndPrune(ctx, "alice", "del-2")
+	assert.Error(t, err, "not the open prune")
+	result, err := s.EndPrune(ctx, "alice", "del-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(4096), result.FreedBytes)
+	assert.Equal(t, 1, result.TempFilesRemoved)
+	assert.Zero(t, result.Anomalies, "deletions through the server explain the loss")
+	assert.NoFileExists(t, partial)
+
+	assert.Nil(t, s.Maintenance())
+	assert.Equal(t, http.StatusOK, send(s, http.MethodPost, "/bob/keys/k", []byte("key")))
+	assert.Equal(t, "PRUNE_END", lastAudit(t, s).Operation)
+}
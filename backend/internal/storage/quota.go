@@ -2,23 +2,60 @@ package storage
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 	"syscall"
 )
 
-func (s *Server) calculateUsedSpace() int64 {
-	var total int64
-	_ = filepath.Walk(s.basePath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
-		if !info.IsDir() {
-			total += info.Size()
-		}
-		return nil
-	})
-	return total
+// effectiveQuota returns the storage limit in force: the stricter of the
+// local quota and the active policy's MaxStorageBytes (0 = unlimited).
+// fromPolicy reports whether the policy is the binding limit.
+func (s *Server) effectiveQuota() (limit int64, fromPolicy bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.effectiveQuotaLocked()
+}
+
+// effectiveQuotaLocked is effectiveQuota for callers holding s.mu
+func (s *Server) effectiveQuotaLocked() (limit int64, fromPolicy bool) {
+	limit = s.quotaBytes
+	if s.policy != nil && s.policy.MaxStorageBytes > 0 && (limit == 0 || s.policy.MaxStorageBytes < limit) {
+		return s.policy.MaxStorageBytes, true
+	}
+	return limit, false
+}
+
+// checkQuota returns false with a reason if writing bytesToWrite would
+// exceed the effective quota
+func (s *Server) checkQuota(bytesToWrite int64) (bool, string) {
+	limit, fromPolicy := s.effectiveQuota()
+	if limit == 0 {
+		return true, ""
+	}
+
+	used := s.calculateUsedSpace()
+	if used+bytesToWrite <= limit {
+		return true, ""
+	}
+	return false, quotaExceeded(used, bytesToWrite, limit, fromPolicy)
+}
+
+// remainingQuota returns how many more bytes the effective quota allows
+// and the reason to give once an upload streams past them; remaining is -1
+// if there is no limit
+func (s *Server) remainingQuota() (remaining int64, reason string) {
+	limit, fromPolicy := s.effectiveQuota()
+	if limit == 0 {
+		return -1, ""
+	}
+	used := s.calculateUsedSpace()
+	remaining = max(limit-used, 0)
+	return remaining, quotaExceeded(used, remaining+1, limit, fromPolicy)
+}
+
+func quotaExceeded(used, requested, limit int64, fromPolicy bool) string {
+	if fromPolicy {
+		return fmt.Sprintf("policy storage limit exceeded: %d bytes used, %d requested, policy allows %d", used, requested, limit)
+	}
+	return "Storage quota exceeded"
 }
 
 // getDiskUsage returns total bytes, free bytes, and usage percentage for the disk
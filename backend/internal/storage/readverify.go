@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+)
+
+// Scrubs verify stored files on a schedule, so a blob that rots between
+// them is only noticed at the next one. With read verification on, the
+// server also hashes each content-addressed file as it serves it. A file
+// that doesn't match its name is reported the moment a restore touches it:
+// as an anomaly, and to the corrupt-read handler, which queues it for the
+// integrity checker.
+
+// verifiedTypes are the file types named by the SHA-256 of their content
+var verifiedTypes = []string{"data", "index", "snapshots", "keys"}
+
+// verifyHoldback is how much of a verified file is held back until its hash
+// is known, so a client is never sent the whole of a corrupt file
+const verifyHoldback = 64 * 1024
+
+// SetCorruptReadHandler sets a callback invoked with each file that fails
+// its hash check while being served. file is relative to the repository.
+func (s *Server) SetCorruptReadHandler(fn func(repo, file string)) {
+	s.anomalies.mu.Lock()
+	defer s.anomalies.mu.Unlock()
+	s.anomalies.corruptRead = fn
+}
+
+// verifiesReads reports whether a GET of the file type is hashed as it is
+// served
+func (s *Server) verifiesReads(r *http.Request, fileType string) bool {
+	return s.verifyReads && slices.Contains(verifiedTypes, fileType) && r.Header.Get("Range") == ""
+}
+
+// serveVerified streams a file while hashing it, holding back its end until
+// the hash matches its name. On a mismatch the response is aborted, so the
+// client sees a failed read rather than corrupt data.
+func (s *Server) serveVerified(w http.ResponseWriter, file *os.File, size int64, repo, fileType, fileName string) {
+	hold := min(size, verifyHoldback)
+	hash := sha256.New()
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", size))
+	if _, err := io.CopyN(io.MultiWriter(w, hash), file, size-hold); err != nil {
+		return
+	}
+	tail := make([]byte, hold)
+	if _, err := io.ReadFull(file, tail); err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	hash.Write(tail)
+
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != fileName {
+		s.reportCorruptRead(repo, fileType, fileName, actual)
+		if hold == size {
+			// Nothing is sent yet, so the client can be told why
+			w.Header().Del("Content-Length")
+			http.Error(w, "File is corrupt", http.StatusInternalServerError)
+			return
+		}
+		panic(http.ErrAbortHandler)
+	}
+	_, _ = w.Write(tail)
+}
+
+// checkRead reports whether a file read whole matches its name, reporting
+// it if not
+func (s *Server) checkRead(data []byte, repo, fileType, fileName string) bool {
+	sum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(sum[:]); actual != fileName {
+		s.reportCorruptRead(repo, fileType, fileName, actual)
+		return false
+	}
+	return true
+}
+
+// reportCorruptRead records a file that failed its hash check as an
+// anomaly and passes it to the corrupt-read handler
+func (s *Server) reportCorruptRead(repo, fileType, fileName, actual string) {
+	file := fileType + "/" + fileName
+	if fileType == "data" {
+		file = fileType + "/" + fileName[:2] + "/" + fileName
+	}
+
+	s.reportAnomalies([]Anomaly{newAnomaly(AnomalyCorruptRead, repo,
+		"%s failed its hash check when read (its content hashes to %s)", file, actual)})
+
+	s.anomalies.mu.Lock()
+	handler := s.anomalies.corruptRead
+	s.anomalies.mu.Unlock()
+	if handler != nil {
+		handler(repo, file)
+	}
+}
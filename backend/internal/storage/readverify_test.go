@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newVerifyingServer returns a running server with a repository and the
+// files its corrupt-read handler was given
+func newVerifyingServer(t *testing.T, verify bool) (*Server, *[]string) {
+	t.Helper()
+	s, err := NewServer(Config{BasePath: t.TempDir(), AppendOnly: true, VerifyReads: verify})
+	require.NoError(t, err)
+	s.Start()
+	var reported []string
+	s.SetCorruptReadHandler(func(repo, file string) {
+		reported = append(reported, repo+":"+file)
+	})
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/testrepo/", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	return s, &reported
+}
+
+// storeBlob uploads random content of the given size, returning its name
+func storeBlob(t *testing.T, s *Server, fileType string, size int) string {
+	t.Helper()
+	return storeBlobIn(t, s, "testrepo", fileType, size)
+}
+
+// storeBlobIn uploads random content of the given size to repo, returning
+// its name
+func storeBlobIn(t *testing.T, s *Server, repo, fileType string, size int) string {
+	t.Helper()
+	data := make([]byte, size)
+	_, _ = rand.Read(data)
+	sum := sha256.Sum256(data)
+	name := hex.EncodeToString(sum[:])
+	upload(t, s.Handler(), "/"+repo+"/"+fileType+"/"+name, data)
+	return name
+}
+
+// corrupt flips a byte in the middle of a stored file
+func corrupt(t *testing.T, path string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[len(data)/2] ^= 0xff
+	require.NoError(t, os.WriteFile(path, data, 0600))
+}
+
+func TestVerifyReadsServesIntactFiles(t *testing.T) {
+	s, reported := newVerifyingServer(t, true)
+	for _, size := range []int{100, 3 * verifyHoldback} {
+		name := storeBlob(t, s, "data", size)
+		w := get(s.Handler(), "/testrepo/data/"+name, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		sum := sha256.Sum256(w.Body.Bytes())
+		assert.Equal(t, name, hex.EncodeToString(sum[:]))
+	}
+	assert.Empty(t, *reported)
+	assert.Empty(t, s.Anomalies())
+}
+
+func TestVerifyReadsRefusesSmallCorruptFile(t *testing.T) {
+	s, reported := newVerifyingServer(t, true)
+	name := storeBlob(t, s, "index", 100)
+	corrupt(t, filepath.Join(s.basePath, "testrepo", "index", name))
+
+	w := get(s.Handler(), "/testrepo/index/"+name, "")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, []string{"testrepo:index/" + name}, *reported)
+
+	anomalies := s.Anomalies()
+	require.Len(t, anomalies, 1)
+	assert.Equal(t, AnomalyCorruptRead, anomalies[0].Kind)
+	assert.Equal(t, "testrepo", anomalies[0].Repo)
+	assert.Contains(t, anomalies[0].Detail, "index/"+name+" failed its hash check")
+}
+
+func TestVerifyReadsAbortsLargeCorruptBlob(t *testing.T) {
+	s, reported := newVerifyingServer(t, true)
+	name := storeBlob(t, s, "data", 3*verifyHoldback)
+	corrupt(t, filepath.Join(s.basePath, "testrepo", "data", name[:2], name))
+
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/testrepo/data/" + name)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	assert.Error(t, err, "the client sees a failed read")
+	assert.Less(t, len(body), 3*verifyHoldback, "the end of the blob is held back")
+
+	assert.Equal(t, []string{"testrepo:data/" + name[:2] + "/" + name}, *reported)
+	require.Len(t, s.Anomalies(), 1)
+}
+
+func TestVerifyReadsOff(t *testing.T) {
+	s, reported := newVerifyingServer(t, false)
+	name := storeBlob(t, s, "data", 100)
+	corrupt(t, filepath.Join(s.basePath, "testrepo", "data", name[:2], name))
+
+	w := get(s.Handler(), "/testrepo/data/"+name, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, *reported)
+}
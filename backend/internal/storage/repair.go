@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// A corrupt pack or index holds nothing worth keeping, so repairing it is
+// allowed even where the file could otherwise not be touched: the owner may
+// delete it despite append-only mode or the policy ('restic repair packs'
+// removes a damaged pack once its intact blobs are salvaged, and 'restic
+// repair index' a damaged index), and a data blob may be uploaded again
+// over it. Snapshot and key files can't be rebuilt, so they stay protected.
+// Each repair is recorded in the audit chain.
+
+// repairableTypes are the file types a corrupt copy of may be replaced
+var repairableTypes = []string{"data", "index"}
+
+// isCorrupt reports whether a data or index file's content no longer hashes
+// to its name. A file that can't be read, or isn't named by a hash, isn't
+// known to be corrupt.
+func isCorrupt(fileType, fileName, filePath string) bool {
+	if !slices.Contains(repairableTypes, fileType) || !isHashName(fileName) {
+		return false
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = f.Close() }()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return false
+	}
+	return hex.EncodeToString(hash.Sum(nil)) != fileName
+}
+
+// isHashName reports whether name is a hex SHA-256, as restic names files
+func isHashName(name string) bool {
+	_, err := hex.DecodeString(name)
+	return err == nil && len(name) == 2*sha256.Size && strings.ToLower(name) == name
+}
+
+// auditRepair records the repair of a corrupt file
+func (s *Server) auditRepair(ctx context.Context, filePath, details string) {
+	s.audit(ctx, "REPAIR", filePath, details, true, "")
+}
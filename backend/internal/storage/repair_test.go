@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// send makes a request to the server, returning its status
+func send(s *Server, method, path string, body []byte) int {
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, httptest.NewRequest(method, path, bytes.NewReader(body)))
+	return w.Code
+}
+
+// lastAudit returns the latest audit entry
+func lastAudit(t *testing.T, s *Server) AuditEntry {
+	t.Helper()
+	entries := s.GetAuditLog(1)
+	require.Len(t, entries, 1)
+	return entries[0]
+}
+
+func TestDeleteCorruptFileInAppendOnlyMode(t *testing.T) {
+	s, _ := newVerifyingServer(t, false)
+	intact := storeBlob(t, s, "index", 100)
+	rotten := storeBlob(t, s, "data", 100)
+	rottenPath := filepath.Join(s.basePath, "testrepo", "data", rotten[:2], rotten)
+	corrupt(t, rottenPath)
+
+	assert.Equal(t, http.StatusForbidden, send(s, http.MethodDelete, "/testrepo/index/"+intact, nil))
+	assert.Equal(t, "DELETE_DENIED", lastAudit(t, s).Operation)
+
+	assert.Equal(t, http.StatusOK, send(s, http.MethodDelete, "/testrepo/data/"+rotten, nil))
+	assert.NoFileExists(t, rottenPath)
+	entry := lastAudit(t, s)
+	assert.Equal(t, "REPAIR", entry.Operation)
+	assert.Equal(t, "corrupt data/"+rotten+" deleted", entry.Details)
+}
+
+func TestUploadReplacesCorruptBlob(t *testing.T) {
+	s, _ := newVerifyingServer(t, false)
+	data := make([]byte, 100)
+	_, _ = rand.Read(data)
+	sum := sha256.Sum256(data)
+	name := hex.EncodeToString(sum[:])
+	path := "/testrepo/data/" + name
+	upload(t, s.Handler(), path, data)
+
+	assert.Equal(t, http.StatusConflict, send(s, http.MethodPost, path, data), "an intact blob isn't written again")
+
+	stored := filepath.Join(s.basePath, "testrepo", "data", name[:2], name)
+	corrupt(t, stored)
+	assert.Equal(t, http.StatusBadRequest, send(s, http.MethodPost, path, []byte("not the blob")))
+	assert.Equal(t, http.StatusOK, send(s, http.MethodPost, path, data))
+
+	got, err := os.ReadFile(stored)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+	entry := lastAudit(t, s)
+	assert.Equal(t, "REPAIR", entry.Operation)
+	assert.Equal(t, "data/"+name+" replaced by a verified upload", entry.Details)
+}
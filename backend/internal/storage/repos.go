@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+// With RequireProvisioning set, a repository exists only once the host
+// provisions it (POST /api/v1/storage/repos, or 'airgapper storage repos
+// add'); any request for a name it hasn't provisioned is refused with 403,
+// so whoever finds the storage URL can't create repositories or race the
+// owner to a new one's config. Repositories already on disk when the
+// setting is turned on are adopted as provisioned.
+//
+// A repository can also name the key allowed to write to it: its writes
+// must then carry that key's signed identity (see package clientid),
+// whether or not RequireIdentity is set.
+//
+// The registry is kept in .airgapper-repos.json next to the repositories,
+// and reloaded when it changes, so a command can provision a repository
+// while the server runs.
+
+// ErrRepoProvisioned is returned when provisioning a repository twice
+var ErrRepoProvisioned = errors.New("repository is already provisioned")
+
+// RepoInfo is what the host records of a provisioned repository
+type RepoInfo struct {
+	Name          string    `json:"name"`
+	OwnerKeyID    string    `json:"owner_key_id,omitempty"`
+	OwnerPubKey   string    `json:"owner_public_key,omitempty"` // hex-encoded Ed25519; writes must be signed by it
+	ProvisionedAt time.Time `json:"provisioned_at"`
+	Adopted       bool      `json:"adopted,omitempty"` // On disk before provisioning was required
+
+	Initialized bool `json:"initialized,omitempty"` // restic config uploaded; reported, not stored
+}
+
+// repoRegistry caches the registry file, reloaded when it changes
+type repoRegistry struct {
+	mu      sync.Mutex
+	repos   map[string]*RepoInfo
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+func (s *Server) reposPath() string {
+	return filepath.Join(s.basePath, ".airgapper-repos.json")
+}
+
+// provisionedReposLocked returns the registry, reloading it if the file changed.
+// Callers hold s.repos.mu.
+func (s *Server) provisionedReposLocked() map[string]*RepoInfo {
+	rr := &s.repos
+	info, err := os.Stat(s.reposPath())
+	exists := err == nil
+	if rr.repos != nil && exists == rr.exists && (!exists || info.ModTime().Equal(rr.modTime) && info.Size() == rr.size) {
+		return rr.repos
+	}
+
+	rr.exists, rr.repos = exists, make(map[string]*RepoInfo)
+	if !exists {
+		return rr.repos
+	}
+	rr.modTime, rr.size = info.ModTime(), info.Size()
+	data, err := os.ReadFile(s.reposPath())
+	if err == nil {
+		err = json.Unmarshal(data, &rr.repos)
+	}
+	if err != nil {
+		logging.Warnf("[storage] failed to read provisioned repositories: %v", err)
+	}
+	return rr.repos
+}
+
+// saveReposLocked writes the registry. Callers hold s.repos.mu.
+func (s *Server) saveReposLocked(repos map[string]*RepoInfo) error {
+	data, err := json.MarshalIndent(repos, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.reposPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to save provisioned repositories: %w", err)
+	}
+	if err := os.Rename(tmp, s.reposPath()); err != nil {
+		return fmt.Errorf("failed to save provisioned repositories: %w", err)
+	}
+	s.repos.repos = nil // Reload, picking up the new file's size and time
+	return nil
+}
+
+// repoInfo returns what the host recorded of repo, or nil if it isn't
+// provisioned
+func (s *Server) repoInfo(repo string) *RepoInfo {
+	s.repos.mu.Lock()
+	defer s.repos.mu.Unlock()
+	return s.provisionedReposLocked()[repo]
+}
+
+// ProvisionRepo lets the owner create repository name. With ownerKey set,
+// only writes signed by it are accepted.
+func (s *Server) ProvisionRepo(name string, ownerKey []byte) (*RepoInfo, error) {
+	if !isValidRepoName(name) || strings.HasPrefix(name, ".airgapper-") {
+		return nil, fmt.Errorf("invalid repository name %q", name)
+	}
+	info := &RepoInfo{Name: name, ProvisionedAt: timeNow().UTC()}
+	if len(ownerKey) > 0 {
+		if len(ownerKey) != ed25519.PublicKeySize {
+			return nil, errors.New("owner key must be a 32-byte Ed25519 public key")
+		}
+		info.OwnerKeyID = crypto.KeyID(ownerKey)
+		info.OwnerPubKey = hex.EncodeToString(ownerKey)
+	}
+
+	s.repos.mu.Lock()
+	defer s.repos.mu.Unlock()
+	repos := s.provisionedReposLocked()
+	if _, ok := repos[name]; ok {
+		return nil, fmt.Errorf("%w: %s", ErrRepoProvisioned, name)
+	}
+	updated := maps.Clone(repos)
+	updated[name] = info
+	if err := s.saveReposLocked(updated); err != nil {
+		return nil, err
+	}
+
+	details := "Repository " + name + " provisioned"
+	if info.OwnerKeyID != "" {
+		details += " for key " + info.OwnerKeyID
+	}
+	s.audit(context.Background(), "REPO_PROVISIONED", filepath.Join(s.basePath, name), details, true, "")
+	return info, nil
+}
+
+// ProvisionedRepos lists the provisioned repositories by name
+func (s *Server) ProvisionedRepos() []RepoInfo {
+	s.repos.mu.Lock()
+	repos := s.provisionedReposLocked()
+	list := make([]RepoInfo, 0, len(repos))
+	for _, info := range repos {
+		list = append(list, *info)
+	}
+	s.repos.mu.Unlock()
+
+	for i := range list {
+		_, err := os.Stat(filepath.Join(s.basePath, list[i].Name, "config"))
+		list[i].Initialized = err == nil
+	}
+	slices.SortFunc(list, func(a, b RepoInfo) int { return strings.Compare(a.Name, b.Name) })
+	return list
+}
+
+// adoptExistingRepos provisions the repositories already on disk, so
+// requiring provisioning doesn't lock their owners out
+func (s *Server) adoptExistingRepos() error {
+	entries, err := os.ReadDir(s.basePath)
+	if err != nil {
+		return err
+	}
+	s.repos.mu.Lock()
+	defer s.repos.mu.Unlock()
+	repos := s.provisionedReposLocked()
+	updated := maps.Clone(repos)
+	adopted := 0
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() || strings.HasPrefix(name, ".airgapper-") || !isValidRepoName(name) || updated[name] != nil {
+			continue
+		}
+		updated[name] = &RepoInfo{Name: name, ProvisionedAt: timeNow().UTC(), Adopted: true}
+		adopted++
+	}
+	if adopted == 0 {
+		return nil
+	}
+	logging.Infof("[storage] Adopted %d existing repositories as provisioned", adopted)
+	return s.saveReposLocked(updated)
+}
+
+// rejectUnprovisioned refuses a request for a repository the host hasn't
+// provisioned, returning true if it did
+func (s *Server) rejectUnprovisioned(w http.ResponseWriter, r *http.Request, repo string) bool {
+	if !s.requireProvisioning || s.repoInfo(repo) != nil {
+		return false
+	}
+	reason := fmt.Sprintf("repository %s is not provisioned on this host", repo)
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		s.audit(r.Context(), "WRITE_DENIED", filepath.Join(s.basePath, repo), reason, false, reason)
+	}
+	http.Error(w, reason, http.StatusForbidden)
+	return true
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/clientid"
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+)
+
+func TestStorageServer_RequireProvisioning(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "legacy", "data"), 0755))
+
+	s, err := NewServer(Config{BasePath: dir, AppendOnly: true, RequireProvisioning: true})
+	require.NoError(t, err)
+	s.Start()
+	handler := s.Handler()
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, path, bytes.NewReader([]byte("data"))))
+		return w
+	}
+
+	w := do(http.MethodPost, "/alice/")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "not provisioned")
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/alice/config").Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodHead, "/alice/config").Code)
+	assert.NoDirExists(t, filepath.Join(dir, "alice"))
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/legacy/").Code, "repositories on disk are adopted")
+
+	info, err := s.ProvisionRepo("alice", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", info.Name)
+	_, err = s.ProvisionRepo("alice", nil)
+	assert.ErrorIs(t, err, ErrRepoProvisioned)
+	_, err = s.ProvisionRepo("../etc", nil)
+	assert.Error(t, err)
+
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/alice/").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/alice/config").Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/alice/config").Code, "the config is written once")
+
+	repos := s.ProvisionedRepos()
+	require.Len(t, repos, 2)
+	assert.Equal(t, "alice", repos[0].Name)
+	assert.True(t, repos[0].Initialized)
+	assert.True(t, repos[1].Adopted)
+	assert.True(t, s.Status().RequireProvisioning)
+
+	// A second server on the same storage, e.g. a command, sees the same
+	// registry, and the running server sees what it provisions
+	other, err := NewServer(Config{BasePath: dir})
+	require.NoError(t, err)
+	_, err = other.ProvisionRepo("bob", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/bob/").Code)
+}
+
+func TestStorageServer_RepoOwnerKey(t *testing.T) {
+	ownerPub, ownerPriv, _ := crypto.GenerateKeyPair()
+	_, strangerPriv, _ := crypto.GenerateKeyPair()
+
+	s, err := NewServer(Config{BasePath: t.TempDir(), AppendOnly: true})
+	require.NoError(t, err)
+	s.Start()
+	info, err := s.ProvisionRepo("alice", ownerPub)
+	require.NoError(t, err)
+	assert.Equal(t, crypto.KeyID(ownerPub), info.OwnerKeyID)
+	_, err = s.ProvisionRepo("short", []byte("key"))
+	assert.Error(t, err)
+
+	do := func(method, path string, signer []byte) int {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte("data")))
+		if signer != nil {
+			user, pass, err := clientid.Sign(signer, "alice", timeNow())
+			require.NoError(t, err)
+			req.SetBasicAuth(user, pass)
+		}
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// The repository's key is required even without RequireIdentity
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/alice/", nil))
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/alice/", strangerPriv))
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/alice/", ownerPriv))
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/alice/config", ownerPriv))
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/alice/config", nil))
+
+	// Other repositories are unaffected
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/other/", nil))
+}
@@ -4,15 +4,20 @@
 package storage
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/lcrostarosa/airgapper/backend/internal/events"
+	"github.com/lcrostarosa/airgapper/backend/internal/fssnap"
 	"github.com/lcrostarosa/airgapper/backend/internal/logging"
 	"github.com/lcrostarosa/airgapper/backend/internal/policy"
+	"github.com/lcrostarosa/airgapper/backend/internal/tracing"
 	"github.com/lcrostarosa/airgapper/backend/internal/verification"
 )
 
@@ -27,6 +32,9 @@ type AuditEntry struct {
 	Details   string    `json:"details,omitempty"`
 	Success   bool      `json:"success"`
 	Error     string    `json:"error,omitempty"`
+
+	// CorrelationID ties the entry to the peer request that caused it
+	CorrelationID string `json:"correlation_id,omitempty"`
 }
 
 // DefaultMaxDiskUsagePct is the default max disk usage (95%)
@@ -38,13 +46,34 @@ type Server struct {
 	appendOnly      bool
 	quotaBytes      int64 // 0 = unlimited per-repo
 	maxDiskUsagePct int   // Max system disk usage percentage
+	compress        bool  // Gzip metadata responses for clients that accept it
+	verifyReads     bool  // Hash content-addressed files as they are served
+	immutable       bool  // Set the immutable attribute on complete data and snapshot files
+	requireIdentity bool  // Refuse writes not signed by the owner's key
+	ownerKey        []byte
+	fsSnapshots     *fssnap.Config
+	fsSnapMu        sync.Mutex
+	fsSnapTimer     *time.Timer // Fires once the backup window is over
 	mu              sync.RWMutex
+	writeLocks      pathLocks // Serializes writes to the same file
 	running         bool
 	startTime       time.Time
 
 	// Policy enforcement
 	policy *policy.Policy
 
+	// Owner-signed amendments allowing mirroring to other hosts
+	mirrors []*policy.MirrorAmendment
+
+	// Called when a policy amendment awaits the counterparty's signature
+	amendmentNotifier func(context.Context, *policy.Amendment)
+
+	// Activity feed for refused writes and anomalies (optional)
+	eventLog atomic.Pointer[events.Log]
+
+	// Called with each audited operation, e.g. to forward it to syslog (optional)
+	auditHandler atomic.Pointer[func(AuditEntry)]
+
 	// Audit logging (legacy)
 	auditLog        []AuditEntry
 	auditMu         sync.RWMutex
@@ -55,9 +84,23 @@ type Server struct {
 	auditChain         *verification.AuditChain
 	ticketManager      *verification.TicketManager
 
+	// What each repository should hold, and unexplained losses from it
+	usage          repoUsage
+	anomalies      anomalyLog
+	shrinkAlertPct int
+
+	// Read-only maintenance mode
+	maintenance maintenanceState
+
+	// Repositories the host provisioned, and whether only they are served
+	repos               repoRegistry
+	requireProvisioning bool
+
 	// Stats
-	totalBytes   int64
 	requestCount int64
+	inFlight     int64
+	lastRequest  time.Time
+	contactSaved time.Time // Last time lastRequest was persisted
 }
 
 // Config for creating a new storage server
@@ -67,13 +110,22 @@ type Config struct {
 	QuotaBytes      int64          // Per-repo quota (0 = unlimited)
 	Policy          *policy.Policy // Optional policy for enforcement
 	MaxDiskUsagePct int            // Max disk usage percentage (0 = use default 95%)
+	Compress        bool           // Gzip metadata responses for clients that accept it
+	VerifyReads     bool           // Hash content-addressed files as they are served
+	Immutable       bool           // Set the immutable attribute on complete data and snapshot files
+	RequireIdentity bool           // Refuse writes not signed by the owner's key (see package clientid)
+	FSSnapshots     *fssnap.Config // ZFS or Btrfs snapshots of the storage dataset (optional)
+	ShrinkAlertPct  int            // Unexplained loss reported as an anomaly (0 = use default 10%)
+
+	// Refuse requests for repositories the host hasn't provisioned
+	RequireProvisioning bool
 
 	// Verification features (optional)
 	Verification   *verification.VerificationSystemConfig
 	HostKeyID      string // Host key ID for signing audit entries
 	HostPrivateKey []byte // Host private key for signatures
 	HostPublicKey  []byte // Host public key for verification
-	OwnerPublicKey []byte // Owner public key for ticket verification
+	OwnerPublicKey []byte // Owner public key for ticket verification, and writes until a policy names one
 }
 
 // NewServer creates a new storage server
@@ -87,6 +139,10 @@ func NewServer(cfg Config) (*Server, error) {
 		return nil, fmt.Errorf("failed to create storage directory: %w", err)
 	}
 
+	if cfg.ShrinkAlertPct <= 0 || cfg.ShrinkAlertPct > 100 {
+		cfg.ShrinkAlertPct = DefaultShrinkAlertPct
+	}
+
 	maxDiskPct := cfg.MaxDiskUsagePct
 	if maxDiskPct <= 0 || maxDiskPct > 100 {
 		maxDiskPct = DefaultMaxDiskUsagePct
@@ -97,15 +153,50 @@ func NewServer(cfg Config) (*Server, error) {
 		appendOnly:         cfg.AppendOnly,
 		quotaBytes:         cfg.QuotaBytes,
 		maxDiskUsagePct:    maxDiskPct,
+		compress:           cfg.Compress,
+		verifyReads:        cfg.VerifyReads,
+		immutable:          cfg.Immutable,
+		requireIdentity:    cfg.RequireIdentity,
+		ownerKey:           cfg.OwnerPublicKey,
+		fsSnapshots:        cfg.FSSnapshots,
+		shrinkAlertPct:     cfg.ShrinkAlertPct,
 		policy:             cfg.Policy,
 		maxAuditEntries:    10000, // Keep last 10k audit entries
 		verificationConfig: cfg.Verification,
 	}
 
+	if s.immutable {
+		if err := checkImmutableSupport(s.basePath); err != nil {
+			return nil, err
+		}
+		n, err := s.lockExisting()
+		if err != nil {
+			return nil, fmt.Errorf("failed to make stored files immutable: %w", err)
+		}
+		logging.Infof("[storage] Immutable tier enabled (%d files immutable)", n)
+	}
+
+	if s.fsSnapshotsEnabled() {
+		if err := s.fsSnapshots.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid filesystem snapshot settings: %w", err)
+		}
+		logging.Infof("[storage] %s snapshots of %s enabled (keeping %d)", s.fsSnapshots.Type, s.fsSnapshots.Dataset, s.fsSnapshots.GetKeep())
+	}
+
+	if cfg.RequireProvisioning {
+		s.requireProvisioning = true
+		if err := s.adoptExistingRepos(); err != nil {
+			return nil, fmt.Errorf("failed to adopt existing repositories: %w", err)
+		}
+	}
+
+	s.loadRepoUsage()
+
 	// Load policy from disk if exists and not provided in config
 	if s.policy == nil {
 		s.loadPolicy()
 	}
+	s.loadMirrorAmendments()
 
 	// Load audit log from disk
 	s.loadAuditLog()
@@ -115,9 +206,17 @@ func NewServer(cfg Config) (*Server, error) {
 		logging.Warnf("[storage] verification initialization failed: %v", err)
 	}
 
+	// Never run weaker than the policy demands
+	s.enforcePolicyMode()
+
 	return s, nil
 }
 
+// AuditChainDir is where the audit chain of storage at basePath is kept
+func AuditChainDir(basePath string) string {
+	return filepath.Join(basePath, ".airgapper-verification", "audit")
+}
+
 // initVerification initializes verification features based on config.
 func (s *Server) initVerification(cfg Config) error {
 	if cfg.Verification == nil || !cfg.Verification.Enabled {
@@ -130,7 +229,7 @@ func (s *Server) initVerification(cfg Config) error {
 	if cfg.Verification.IsAuditChainEnabled() {
 		signEntries := cfg.Verification.AuditChain.SignEntries
 		chain, err := verification.NewAuditChain(
-			filepath.Join(verifyPath, "audit"),
+			AuditChainDir(cfg.BasePath),
 			cfg.HostKeyID,
 			cfg.HostPrivateKey,
 			cfg.HostPublicKey,
@@ -183,6 +282,9 @@ func (s *Server) Start() {
 
 // Stop marks the server as stopped
 func (s *Server) Stop() {
+	s.stopBackgroundChecks()
+	s.stopFSSnapshots()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.running = false
@@ -190,19 +292,35 @@ func (s *Server) Stop() {
 
 // Status returns the current server status
 type Status struct {
-	Running         bool      `json:"running"`
-	StartTime       time.Time `json:"startTime,omitempty"`
-	BasePath        string    `json:"basePath"`
-	AppendOnly      bool      `json:"appendOnly"`
-	QuotaBytes      int64     `json:"quotaBytes,omitempty"`
-	UsedBytes       int64     `json:"usedBytes"`
-	RequestCount    int64     `json:"requestCount"`
-	HasPolicy       bool      `json:"hasPolicy"`
-	PolicyID        string    `json:"policyId,omitempty"`
-	MaxDiskUsagePct int       `json:"maxDiskUsagePct"`
-	DiskUsagePct    int       `json:"diskUsagePct"`
-	DiskFreeBytes   int64     `json:"diskFreeBytes"`
-	DiskTotalBytes  int64     `json:"diskTotalBytes"`
+	Running         bool             `json:"running"`
+	StartTime       time.Time        `json:"startTime,omitempty"`
+	BasePath        string           `json:"basePath"`
+	AppendOnly      bool             `json:"appendOnly"`
+	VerifyReads     bool             `json:"verifyReads,omitempty"`
+	Immutable       bool             `json:"immutable,omitempty"`
+	RequireIdentity bool             `json:"requireIdentity,omitempty"` // Writes must be signed by the owner
+	FSSnapshots     string           `json:"fsSnapshots,omitempty"`     // Filesystem snapshotted, if any
+	QuotaBytes      int64            `json:"quotaBytes,omitempty"`
+	UsedBytes       int64            `json:"usedBytes"`
+	RepoUsedBytes   map[string]int64 `json:"repoUsedBytes,omitempty"` // UsedBytes by repository
+	Maintenance     *Maintenance     `json:"maintenance,omitempty"`   // Set while read-only for maintenance
+	RequestCount    int64            `json:"requestCount"`
+	InFlight        int64            `json:"inFlight"`
+	LastRequestTime time.Time        `json:"lastRequestTime,omitempty"`
+	HasPolicy       bool             `json:"hasPolicy"`
+	PolicyID        string           `json:"policyId,omitempty"`
+	MaxDiskUsagePct int              `json:"maxDiskUsagePct"`
+	DiskUsagePct    int              `json:"diskUsagePct"`
+	DiskFreeBytes   int64            `json:"diskFreeBytes"`
+	DiskTotalBytes  int64            `json:"diskTotalBytes"`
+
+	// Storage limit agreed in the policy, and the stricter of it and QuotaBytes
+	PolicyMaxStorageBytes int64 `json:"policyMaxStorageBytes,omitempty"`
+	EffectiveQuotaBytes   int64 `json:"effectiveQuotaBytes,omitempty"`
+	QuotaUsagePct         int   `json:"quotaUsagePct,omitempty"` // UsedBytes as a percentage of EffectiveQuotaBytes
+
+	// Only repositories the host provisioned are served
+	RequireProvisioning bool `json:"requireProvisioning,omitempty"`
 }
 
 func (s *Server) Status() Status {
@@ -211,29 +329,58 @@ func (s *Server) Status() Status {
 
 	used := s.calculateUsedSpace()
 	diskTotal, diskFree, diskUsedPct := s.getDiskUsage()
+	var fsSnapshotType string
+	if s.fsSnapshotsEnabled() {
+		fsSnapshotType = s.fsSnapshots.Type
+	}
 
 	status := Status{
 		Running:         s.running,
 		StartTime:       s.startTime,
 		BasePath:        s.basePath,
 		AppendOnly:      s.appendOnly,
+		VerifyReads:     s.verifyReads,
+		Immutable:       s.immutable,
+		RequireIdentity: s.requireIdentity,
+		FSSnapshots:     fsSnapshotType,
 		QuotaBytes:      s.quotaBytes,
 		UsedBytes:       used,
+		RepoUsedBytes:   s.RepoUsage(),
+		Maintenance:     s.Maintenance(),
 		RequestCount:    s.requestCount,
+		InFlight:        s.inFlight,
+		LastRequestTime: s.lastRequest,
 		HasPolicy:       s.policy != nil,
 		MaxDiskUsagePct: s.maxDiskUsagePct,
 		DiskUsagePct:    diskUsedPct,
 		DiskFreeBytes:   diskFree,
 		DiskTotalBytes:  diskTotal,
+
+		RequireProvisioning: s.requireProvisioning,
 	}
 
 	if s.policy != nil {
 		status.PolicyID = s.policy.ID
+		status.PolicyMaxStorageBytes = s.policy.MaxStorageBytes
+	}
+
+	status.EffectiveQuotaBytes, _ = s.effectiveQuotaLocked()
+	if status.EffectiveQuotaBytes > 0 {
+		status.QuotaUsagePct = int(used * 100 / status.EffectiveQuotaBytes)
 	}
 
 	return status
 }
 
+// ActiveWithin reports whether a request is in progress or one finished
+// within the given window. A restic backup or restore is a burst of
+// requests, so recent activity means one is probably still running.
+func (s *Server) ActiveWithin(window time.Duration) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.inFlight > 0 || (!s.lastRequest.IsZero() && timeNow().Sub(s.lastRequest) < window)
+}
+
 // --- Verification Component Accessors ---
 
 // AuditChain returns the audit chain (may be nil if not enabled).
@@ -264,6 +411,6 @@ func WithLogging(handler http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		handler.ServeHTTP(w, r)
-		logging.Debugf("[storage] %s %s %v", r.Method, r.URL.Path, time.Since(start))
+		logging.Debugf("[storage] %s %s %v %s=%s", r.Method, r.URL.Path, time.Since(start), tracing.LogKey, tracing.ID(r.Context()))
 	})
 }
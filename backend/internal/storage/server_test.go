@@ -9,6 +9,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -286,6 +287,36 @@ func TestStorageServer_KeysOperations(t *testing.T) {
 	})
 }
 
+func TestStorageServer_LocksOperations(t *testing.T) {
+	tmpDir := t.TempDir()
+	s, err := NewServer(Config{
+		BasePath:   tmpDir,
+		AppendOnly: true,
+	})
+	require.NoError(t, err, "Failed to create server")
+	s.Start()
+
+	handler := s.Handler()
+
+	req := httptest.NewRequest(http.MethodPost, "/testrepo/", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	lockName := "feedfacefeedfacefeedfacefeedfacefeedfacefeedfacefeedfacefeedface"
+
+	req = httptest.NewRequest(http.MethodPost, "/testrepo/locks/"+lockName, bytes.NewReader([]byte(`{"time":"2024-01-01T00:00:00Z"}`)))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// Restic removes its locks even from an append-only repository
+	req = httptest.NewRequest(http.MethodDelete, "/testrepo/locks/"+lockName, nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NoFileExists(t, filepath.Join(tmpDir, "testrepo", "locks", lockName))
+}
+
 func TestStorageServer_Quota(t *testing.T) {
 	tmpDir := t.TempDir()
 	s, err := NewServer(Config{
@@ -331,9 +362,80 @@ func TestStorageServer_Quota(t *testing.T) {
 		assert.Equal(t, http.StatusOK, w.Code, "Expected status 200: %s", w.Body.String())
 	})
 
+	// A chunked body has no Content-Length to check up front
+	t.Run("chunked upload exceeds quota", func(t *testing.T) {
+		var encoding []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding = r.TransferEncoding
+			handler.ServeHTTP(w, r)
+		}))
+		defer srv.Close()
+		post := func(name string, data []byte) *http.Response {
+			req, err := http.NewRequest(http.MethodPost, srv.URL+"/testrepo/keys/"+name, io.MultiReader(bytes.NewReader(data)))
+			require.NoError(t, err)
+			require.Equal(t, int64(0), req.ContentLength, "the length is unknown")
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+			return resp
+		}
+
+		used := s.Status().UsedBytes
+		resp := post("chunkedkey", largeData)
+		assert.Equal(t, []string{"chunked"}, encoding)
+		assert.Equal(t, http.StatusInsufficientStorage, resp.StatusCode)
+		assert.Equal(t, used, s.Status().UsedBytes)
+		entries, err := os.ReadDir(filepath.Join(tmpDir, "testrepo", "keys"))
+		require.NoError(t, err)
+		for _, e := range entries {
+			assert.NotContains(t, e.Name(), "chunkedkey", "nothing is left behind")
+		}
+
+		assert.Equal(t, http.StatusOK, post("chunkedsmall", smallData).StatusCode)
+	})
+
 	_ = hashHex // Silence unused warning
 }
 
+func TestStorageServer_PolicyQuota(t *testing.T) {
+	ownerPub, ownerPriv, _ := crypto.GenerateKeyPair()
+	hostPub, hostPriv, _ := crypto.GenerateKeyPair()
+	p := policy.NewPolicy(
+		"Alice", crypto.KeyID(ownerPub), crypto.EncodePublicKey(ownerPub),
+		"Bob", crypto.KeyID(hostPub), crypto.EncodePublicKey(hostPub),
+	)
+	p.MaxStorageBytes = 100
+	require.NoError(t, p.SignAsOwner(ownerPriv))
+	require.NoError(t, p.SignAsHost(hostPriv))
+
+	s, err := NewServer(Config{BasePath: t.TempDir(), QuotaBytes: 1 << 20, Policy: p})
+	require.NoError(t, err)
+	s.Start()
+
+	handler := s.Handler()
+	upload := func(name string, size int) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/testrepo/keys/"+name, bytes.NewReader(make([]byte, size)))
+		req.ContentLength = int64(size)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// The policy limit is stricter than the local quota
+	w := upload("big", 200)
+	assert.Equal(t, http.StatusInsufficientStorage, w.Code)
+	assert.Contains(t, w.Body.String(), "policy storage limit")
+
+	assert.Equal(t, http.StatusOK, upload("small", 60).Code)
+	assert.Equal(t, http.StatusInsufficientStorage, upload("more", 60).Code)
+
+	status := s.Status()
+	assert.Equal(t, int64(100), status.PolicyMaxStorageBytes)
+	assert.Equal(t, int64(100), status.EffectiveQuotaBytes)
+	assert.Equal(t, int64(60), status.UsedBytes)
+	assert.Equal(t, 60, status.QuotaUsagePct)
+}
+
 func TestStorageServer_NotRunning(t *testing.T) {
 	tmpDir := t.TempDir()
 	s, err := NewServer(Config{
@@ -476,6 +578,25 @@ func TestStorageServer_Status(t *testing.T) {
 	assert.False(t, status.Running, "Server should not be running after Stop()")
 }
 
+func TestStorageServer_ActiveWithin(t *testing.T) {
+	s, err := NewServer(Config{BasePath: t.TempDir()})
+	require.NoError(t, err, "Failed to create server")
+	s.Start()
+
+	assert.False(t, s.ActiveWithin(time.Minute), "no requests yet")
+
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/testrepo/", nil))
+
+	assert.True(t, s.ActiveWithin(time.Minute), "request just finished")
+	assert.Equal(t, int64(0), s.Status().InFlight, "no request in flight")
+
+	origNow := timeNow
+	defer func() { timeNow = origNow }()
+	timeNow = func() time.Time { return origNow().Add(2 * time.Minute) }
+	assert.False(t, s.ActiveWithin(time.Minute), "last request is outside the window")
+}
+
 func TestIsValidRepoName(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -776,3 +897,157 @@ func TestStorageServer_PolicyPersistence(t *testing.T) {
 
 // Silence unused variable warnings
 var _ = time.Now
+
+func TestStorageServer_ConcurrentUploads(t *testing.T) {
+	tmpDir := t.TempDir()
+	s, err := NewServer(Config{BasePath: tmpDir, AppendOnly: true})
+	require.NoError(t, err)
+	s.Start()
+	handler := s.Handler()
+
+	req := httptest.NewRequest(http.MethodPost, "/testrepo/", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	t.Run("same blob", func(t *testing.T) {
+		data := bytes.Repeat([]byte("concurrent blob "), 4096)
+		hash := sha256.Sum256(data)
+		hashHex := hex.EncodeToString(hash[:])
+
+		const writers = 8
+		codes := make([]int, writers)
+		var wg sync.WaitGroup
+		for i := range writers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req := httptest.NewRequest(http.MethodPost, "/testrepo/data/"+hashHex, bytes.NewReader(data))
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, req)
+				codes[i] = w.Code
+			}()
+		}
+		wg.Wait()
+
+		var stored int
+		for _, code := range codes {
+			require.Contains(t, []int{http.StatusOK, http.StatusConflict}, code)
+			if code == http.StatusOK {
+				stored++
+			}
+		}
+		assert.Equal(t, 1, stored, "exactly one writer should store the blob")
+
+		got, err := os.ReadFile(filepath.Join(tmpDir, "testrepo", "data", hashHex[:2], hashHex))
+		require.NoError(t, err)
+		assert.Equal(t, data, got)
+
+		entries, err := os.ReadDir(filepath.Join(tmpDir, "testrepo", "data", hashHex[:2]))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "no temp files should be left behind")
+	})
+
+	t.Run("different contents of one file", func(t *testing.T) {
+		name := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+		contents := make([][]byte, 8)
+		for i := range contents {
+			contents[i] = bytes.Repeat([]byte{byte('a' + i)}, 64<<10)
+		}
+
+		var wg sync.WaitGroup
+		for _, data := range contents {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req := httptest.NewRequest(http.MethodPost, "/testrepo/index/"+name, bytes.NewReader(data))
+				handler.ServeHTTP(httptest.NewRecorder(), req)
+			}()
+		}
+		wg.Wait()
+
+		// Whichever writer finished last, the file is one writer's content whole
+		got, err := os.ReadFile(filepath.Join(tmpDir, "testrepo", "index", name))
+		require.NoError(t, err)
+		assert.Contains(t, contents, got)
+
+		entries, err := os.ReadDir(filepath.Join(tmpDir, "testrepo", "index"))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "no temp files should be left behind")
+	})
+
+	t.Run("racing configs", func(t *testing.T) {
+		contents := make([][]byte, 8)
+		for i := range contents {
+			contents[i] = bytes.Repeat([]byte{byte('a' + i)}, 1024)
+		}
+
+		codes := make([]int, len(contents))
+		var wg sync.WaitGroup
+		for i, data := range contents {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req := httptest.NewRequest(http.MethodPost, "/racerepo/config", bytes.NewReader(data))
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, req)
+				codes[i] = w.Code
+			}()
+		}
+		wg.Wait()
+
+		// Exactly one writer creates the config; the rest are refused
+		// rather than overwriting it
+		winner := -1
+		for i, code := range codes {
+			require.Contains(t, []int{http.StatusOK, http.StatusForbidden}, code)
+			if code == http.StatusOK {
+				require.Equal(t, -1, winner, "only one writer may create the config")
+				winner = i
+			}
+		}
+		require.NotEqual(t, -1, winner)
+		got, err := os.ReadFile(filepath.Join(tmpDir, "racerepo", "config"))
+		require.NoError(t, err)
+		assert.Equal(t, contents[winner], got)
+	})
+}
+
+func TestStorageServer_DuplicateUploads(t *testing.T) {
+	tmpDir := t.TempDir()
+	s, err := NewServer(Config{BasePath: tmpDir, AppendOnly: true})
+	require.NoError(t, err)
+	s.Start()
+	handler := s.Handler()
+
+	req := httptest.NewRequest(http.MethodPost, "/testrepo/", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	post := func(path string, data []byte) int {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	data := []byte("duplicate blob")
+	hash := sha256.Sum256(data)
+	hashHex := hex.EncodeToString(hash[:])
+	require.Equal(t, http.StatusOK, post("/testrepo/data/"+hashHex, data))
+	usedBytes := s.Status().UsedBytes
+	assert.Equal(t, http.StatusConflict, post("/testrepo/data/"+hashHex, data))
+	assert.Equal(t, usedBytes, s.Status().UsedBytes, "a duplicate blob isn't counted again")
+
+	name := "fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210"
+	require.Equal(t, http.StatusOK, post("/testrepo/snapshots/"+name, []byte("v1")))
+	assert.Equal(t, http.StatusConflict, post("/testrepo/snapshots/"+name, []byte("v1")))
+	assert.Equal(t, http.StatusConflict, post("/testrepo/snapshots/"+name, []byte("v2")), "append-only never overwrites")
+	stored, err := os.ReadFile(filepath.Join(tmpDir, "testrepo", "snapshots", name))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v1"), stored)
+
+	// Temp files never show up in listings
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "testrepo", "snapshots", name+".123.tmp"), []byte("partial"), 0600))
+	req = httptest.NewRequest(http.MethodGet, "/testrepo/snapshots/", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.NotContains(t, w.Body.String(), ".tmp")
+}
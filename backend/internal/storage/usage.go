@@ -0,0 +1,357 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+// The server keeps track of what each repository should hold as it writes
+// and deletes files: its size, its snapshots, how many data packs and the
+// hash of its config. Quota checks and Status use the running counts, so
+// uploads don't walk the whole tree. The state is persisted in
+// .airgapper-repo-usage.json and reconciled with a walk in the background.
+// A reconcile finding less than the server expects means files were lost
+// or removed behind its back, which is reported as an anomaly (anomaly.go).
+
+// DefaultCheckInterval is how often repositories are walked to reconcile
+// used space and look for anomalies
+const DefaultCheckInterval = time.Hour
+
+// usageSaveInterval is how often changed counts are persisted after writes;
+// anything lost in a crash is corrected by the next reconcile. Deletions
+// are saved at once so they aren't later mistaken for losses.
+const usageSaveInterval = time.Minute
+
+// repoUsage tracks what each repository should hold
+type repoUsage struct {
+	mu    sync.Mutex
+	repos map[string]*repoState
+	// changes counts writes to each repository, so a reconcile can tell
+	// whether one happened while it walked the repository
+	changes map[string]uint64
+	// auditSequence is the audit chain's last sequence number seen
+	auditSequence uint64
+	dirty         bool
+	savedAt       time.Time
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// repoState is what a repository holds
+type repoState struct {
+	Bytes int64 `json:"bytes"`
+	// Snapshots maps snapshot file names to their sizes
+	Snapshots map[string]int64 `json:"snapshots,omitempty"`
+	DataFiles int              `json:"dataFiles"`
+	// ConfigHash is the SHA-256 of the repository config, which restic
+	// writes once and never changes
+	ConfigHash string `json:"configHash,omitempty"`
+}
+
+// repoUsageFile is persisted in .airgapper-repo-usage.json
+type repoUsageFile struct {
+	Repos         map[string]*repoState `json:"repos"`
+	AuditSequence uint64                `json:"auditSequence,omitempty"`
+}
+
+func (s *Server) repoUsagePath() string {
+	return filepath.Join(s.basePath, ".airgapper-repo-usage.json")
+}
+
+// loadRepoUsage loads the persisted state, taking stock from scratch when
+// there is none yet
+func (s *Server) loadRepoUsage() {
+	s.usage.repos = make(map[string]*repoState)
+	s.usage.changes = make(map[string]uint64)
+
+	data, err := os.ReadFile(s.repoUsagePath())
+	if err == nil {
+		var f repoUsageFile
+		if err := json.Unmarshal(data, &f); err == nil {
+			for repo, st := range f.Repos {
+				if st != nil {
+					s.usage.repos[repo] = st
+				}
+			}
+			s.usage.auditSequence = f.AuditSequence
+			return
+		}
+		logging.Warnf("[storage] failed to parse repository usage, recounting: %v", err)
+	}
+	s.reconcileUsage()
+}
+
+// repoLocked returns the state of repo, creating it. Callers hold s.usage.mu.
+func (u *repoUsage) repoLocked(repo string) *repoState {
+	st := u.repos[repo]
+	if st == nil {
+		st = &repoState{}
+		u.repos[repo] = st
+	}
+	u.changes[repo]++
+	u.dirty = true
+	return st
+}
+
+// trackWrite records a file of size bytes written to repo, replacing a
+// file of replaced bytes if existed
+func (s *Server) trackWrite(repo, fileType, name string, size, replaced int64, existed bool) {
+	u := &s.usage
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	st := u.repoLocked(repo)
+	st.Bytes += size - replaced
+	switch fileType {
+	case "snapshots":
+		if st.Snapshots == nil {
+			st.Snapshots = make(map[string]int64)
+		}
+		st.Snapshots[name] = size
+	case "data":
+		if !existed {
+			st.DataFiles++
+		}
+	}
+	if timeNow().Sub(u.savedAt) >= usageSaveInterval {
+		s.saveRepoUsageLocked()
+	}
+}
+
+// trackConfig records the config written to repo
+func (s *Server) trackConfig(repo string, data []byte) {
+	u := &s.usage
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	st := u.repoLocked(repo)
+	st.Bytes += int64(len(data))
+	st.ConfigHash = configHash(data)
+	s.saveRepoUsageLocked()
+}
+
+// trackDelete records a file of size bytes deleted from repo ("config" for
+// the repository config)
+func (s *Server) trackDelete(repo, fileType, name string, size int64) {
+	u := &s.usage
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	st := u.repoLocked(repo)
+	st.Bytes -= size
+	switch fileType {
+	case "config":
+		st.ConfigHash = ""
+	case "snapshots":
+		delete(st.Snapshots, name)
+	case "data":
+		st.DataFiles--
+	case "locks":
+		// Restic removes its locks after every command; they don't matter
+		// to anomaly checks, so the next save can wait
+		return
+	}
+	s.saveRepoUsageLocked()
+}
+
+// calculateUsedSpace returns the bytes stored across all repositories. The
+// server's own bookkeeping (.airgapper-* files) doesn't count towards quotas.
+func (s *Server) calculateUsedSpace() int64 {
+	s.usage.mu.Lock()
+	defer s.usage.mu.Unlock()
+	var total int64
+	for _, st := range s.usage.repos {
+		total += st.Bytes
+	}
+	return total
+}
+
+// RepoUsage returns the bytes stored in each repository
+func (s *Server) RepoUsage() map[string]int64 {
+	s.usage.mu.Lock()
+	defer s.usage.mu.Unlock()
+	usage := make(map[string]int64, len(s.usage.repos))
+	for repo, st := range s.usage.repos {
+		usage[repo] = st.Bytes
+	}
+	return usage
+}
+
+// reconcileUsage walks every repository and adopts what it finds,
+// reporting anything missing compared to what the server expected. A
+// repository written to during its walk keeps its running state; the next
+// reconcile catches it.
+func (s *Server) reconcileUsage() {
+	entries, err := os.ReadDir(s.basePath)
+	if err != nil {
+		logging.Warnf("[storage] failed to recount used space: %v", err)
+		return
+	}
+
+	u := &s.usage
+	var anomalies []Anomaly
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		repo := e.Name()
+		if !e.IsDir() || strings.HasPrefix(repo, ".airgapper-") {
+			continue
+		}
+		seen[repo] = true
+
+		u.mu.Lock()
+		before := u.changes[repo]
+		u.mu.Unlock()
+
+		actual := scanRepo(filepath.Join(s.basePath, repo))
+
+		u.mu.Lock()
+		if u.changes[repo] == before {
+			if expected := u.repos[repo]; expected != nil {
+				anomalies = append(anomalies, s.compareRepo(repo, expected, actual)...)
+			}
+			u.repos[repo] = actual
+			u.dirty = true
+		}
+		u.mu.Unlock()
+	}
+
+	u.mu.Lock()
+	for repo, expected := range u.repos {
+		if !seen[repo] {
+			anomalies = append(anomalies, s.compareRepo(repo, expected, &repoState{})...)
+			delete(u.repos, repo)
+			delete(u.changes, repo)
+			u.dirty = true
+		}
+	}
+	if s.auditChain != nil {
+		seq := s.auditChain.GetSequence()
+		if seq < u.auditSequence {
+			anomalies = append(anomalies, newAnomaly(AnomalyAuditChainReset, "",
+				"audit chain is at entry %d after reaching entry %d", seq, u.auditSequence))
+		}
+		if seq != u.auditSequence {
+			u.auditSequence = seq
+			u.dirty = true
+		}
+	}
+	s.saveRepoUsageLocked()
+	u.mu.Unlock()
+
+	s.reportAnomalies(anomalies)
+}
+
+// scanRepo takes stock of the repository in dir, leaving out files still
+// being written
+func scanRepo(dir string) *repoState {
+	st := &repoState{}
+	_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || isTempFile(info.Name()) {
+			return nil
+		}
+		st.Bytes += info.Size()
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return nil
+		}
+		switch fileType, _, _ := strings.Cut(filepath.ToSlash(rel), "/"); fileType {
+		case "config":
+			if data, err := os.ReadFile(path); err == nil {
+				st.ConfigHash = configHash(data)
+			}
+		case "snapshots":
+			if st.Snapshots == nil {
+				st.Snapshots = make(map[string]int64)
+			}
+			st.Snapshots[info.Name()] = info.Size()
+		case "data":
+			st.DataFiles++
+		}
+		return nil
+	})
+	return st
+}
+
+func configHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// saveRepoUsageLocked persists changed state. Callers hold s.usage.mu.
+func (s *Server) saveRepoUsageLocked() {
+	u := &s.usage
+	if !u.dirty {
+		return
+	}
+	data, err := json.MarshalIndent(repoUsageFile{Repos: u.repos, AuditSequence: u.auditSequence}, "", "  ")
+	if err != nil {
+		logging.Warnf("[storage] failed to serialize repository usage: %v", err)
+		return
+	}
+	if err := os.WriteFile(s.repoUsagePath(), data, 0600); err != nil {
+		logging.Warnf("[storage] failed to save repository usage: %v", err)
+		return
+	}
+	u.dirty = false
+	u.savedAt = timeNow()
+}
+
+// StartBackgroundChecks walks the repositories now and every interval until
+// the server is stopped, reconciling used space and reporting anomalies.
+// The first walk catches changes made while the server wasn't running.
+func (s *Server) StartBackgroundChecks(interval time.Duration) {
+	u := &s.usage
+	u.mu.Lock()
+	if u.stop != nil {
+		u.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	u.stop = stop
+	u.mu.Unlock()
+
+	u.wg.Add(1)
+	go func() {
+		defer u.wg.Done()
+		s.reconcileUsage()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.reconcileUsage()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// stopBackgroundChecks stops the background walks and saves the state
+func (s *Server) stopBackgroundChecks() {
+	u := &s.usage
+	u.mu.Lock()
+	stop := u.stop
+	u.stop = nil
+	u.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		u.wg.Wait()
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	s.saveRepoUsageLocked()
+}
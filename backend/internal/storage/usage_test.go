@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepoUsage(t *testing.T) {
+	tmpDir := t.TempDir()
+	s, err := NewServer(Config{BasePath: tmpDir})
+	require.NoError(t, err)
+	s.Start()
+	handler := s.Handler()
+
+	do := func(method, path string, data []byte) {
+		t.Helper()
+		req := httptest.NewRequest(method, path, bytes.NewReader(data))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, "%s %s: %s", method, path, w.Body.String())
+	}
+
+	for _, repo := range []string{"alpha", "beta"} {
+		do(http.MethodPost, "/"+repo+"/", nil)
+	}
+	do(http.MethodPost, "/alpha/config", make([]byte, 100))
+	do(http.MethodPost, "/alpha/keys/aaaa", make([]byte, 50))
+	do(http.MethodPost, "/beta/keys/bbbb", make([]byte, 30))
+	assert.Equal(t, map[string]int64{"alpha": 150, "beta": 30}, s.RepoUsage())
+	assert.Equal(t, int64(180), s.Status().UsedBytes)
+
+	// A rewrite replaces the old size, a delete subtracts it
+	do(http.MethodPost, "/alpha/keys/aaaa", make([]byte, 20))
+	do(http.MethodDelete, "/beta/keys/bbbb", nil)
+	do(http.MethodDelete, "/alpha/config", nil)
+	assert.Equal(t, map[string]int64{"alpha": 20, "beta": 0}, s.RepoUsage())
+
+	// The counts survive a restart without a recount
+	s.Stop()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "alpha", "keys", "cccc"), make([]byte, 500), 0600))
+	s, err = NewServer(Config{BasePath: tmpDir})
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), s.calculateUsedSpace())
+
+	// A reconcile picks up changes made behind the server's back
+	require.NoError(t, os.RemoveAll(filepath.Join(tmpDir, "beta")))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "alpha", "keys", "dddd.123.tmp"), make([]byte, 9), 0600))
+	s.reconcileUsage()
+	assert.Equal(t, map[string]int64{"alpha": 520}, s.RepoUsage())
+}
+
+func TestRepoUsageRecountsWithoutSavedCounts(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "repo", "data", "ab"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "repo", "config"), make([]byte, 10), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "repo", "data", "ab", "abcd"), make([]byte, 1000), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".airgapper-policy.json"), make([]byte, 400), 0600))
+
+	s, err := NewServer(Config{BasePath: tmpDir})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"repo": 1010}, s.RepoUsage())
+	assert.FileExists(t, s.repoUsagePath())
+}
@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+// maxUsageSamples bounds the size history (one sample per day)
+const maxUsageSamples = 365
+
+// UsageSample is the stored size on one day
+type UsageSample struct {
+	Date      string `json:"date"` // YYYY-MM-DD
+	UsedBytes int64  `json:"usedBytes"`
+	Snapshots int    `json:"snapshots"`
+}
+
+// usageHistory is persisted in .airgapper-usage.json
+type usageHistory struct {
+	Samples     []UsageSample `json:"samples"`
+	LastContact time.Time     `json:"lastContact,omitempty"`
+}
+
+// VaultMetadata is what a host can see about the vault it stores without
+// decrypting anything
+type VaultMetadata struct {
+	UsedBytes    int64         `json:"usedBytes"`
+	Snapshots    int           `json:"snapshots"`
+	LastSnapshot time.Time     `json:"lastSnapshot,omitempty"`
+	Growth       []UsageSample `json:"growth"`
+
+	// Last request from the owner's restic, and whole days since then
+	// (-1 if the owner has never connected)
+	LastOwnerContact time.Time `json:"lastOwnerContact,omitempty"`
+	DaysSinceContact int       `json:"daysSinceContact"`
+
+	// Unexplained losses of stored data, oldest first
+	Anomalies []Anomaly `json:"anomalies,omitempty"`
+}
+
+func (s *Server) usagePath() string {
+	return filepath.Join(s.basePath, ".airgapper-usage.json")
+}
+
+func (s *Server) loadUsageHistory() *usageHistory {
+	h := &usageHistory{}
+	data, err := os.ReadFile(s.usagePath())
+	if err != nil {
+		return h
+	}
+	if err := json.Unmarshal(data, h); err != nil {
+		logging.Warnf("[storage] failed to parse usage history: %v", err)
+	}
+	return h
+}
+
+func (s *Server) saveUsageHistory(h *usageHistory) {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		logging.Warnf("[storage] failed to serialize usage history: %v", err)
+		return
+	}
+	if err := os.WriteFile(s.usagePath(), data, 0600); err != nil {
+		logging.Warnf("[storage] failed to save usage history: %v", err)
+	}
+}
+
+// recordUsage samples today's size and snapshot count, replacing an
+// earlier sample from the same day. contact, if later than the stored
+// one, becomes the last owner contact. Callers hold s.mu.
+func (s *Server) recordUsage(contact time.Time) *usageHistory {
+	h := s.loadUsageHistory()
+	sample := UsageSample{
+		Date:      timeNow().Format("2006-01-02"),
+		UsedBytes: s.calculateUsedSpace(),
+		Snapshots: s.countSnapshots(),
+	}
+	if n := len(h.Samples); n > 0 && h.Samples[n-1].Date == sample.Date {
+		h.Samples[n-1] = sample
+	} else {
+		h.Samples = append(h.Samples, sample)
+	}
+	if len(h.Samples) > maxUsageSamples {
+		h.Samples = h.Samples[len(h.Samples)-maxUsageSamples:]
+	}
+	if contact.After(h.LastContact) {
+		h.LastContact = contact
+	}
+	s.saveUsageHistory(h)
+	return h
+}
+
+// contactSaveInterval is how often request times are persisted as the
+// last owner contact; days since contact doesn't need more precision
+const contactSaveInterval = time.Hour
+
+// noteContact persists the last owner contact, at most once per
+// contactSaveInterval. Callers hold s.mu.
+func (s *Server) noteContact(at time.Time) {
+	if at.Sub(s.contactSaved) < contactSaveInterval {
+		return
+	}
+	s.contactSaved = at
+	h := s.loadUsageHistory()
+	if at.After(h.LastContact) {
+		h.LastContact = at
+		s.saveUsageHistory(h)
+	}
+}
+
+// countSnapshots counts the snapshot files across all repositories
+func (s *Server) countSnapshots() int {
+	count := 0
+	s.walkSnapshots(func(os.FileInfo) { count++ })
+	return count
+}
+
+// walkSnapshots calls fn for each stored snapshot file
+func (s *Server) walkSnapshots(fn func(os.FileInfo)) {
+	repos, err := os.ReadDir(s.basePath)
+	if err != nil {
+		return
+	}
+	for _, repo := range repos {
+		if !repo.IsDir() || strings.HasPrefix(repo.Name(), ".airgapper-") {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(s.basePath, repo.Name(), "snapshots"))
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if e.IsDir() || isTempFile(e.Name()) {
+				continue
+			}
+			if info, err := e.Info(); err == nil {
+				fn(info)
+			}
+		}
+	}
+}
+
+// lastSnapshotTime returns when the latest snapshot was written, from the
+// audit trail of snapshot writes, or from the files when it has none
+func (s *Server) lastSnapshotTime() time.Time {
+	if s.auditChain != nil {
+		if entries := s.auditChain.GetEntries(1, 0, "SNAPSHOT_CREATE"); len(entries) > 0 {
+			return entries[0].Timestamp
+		}
+	} else {
+		s.auditMu.RLock()
+		for i := len(s.auditLog) - 1; i >= 0; i-- {
+			if e := s.auditLog[i]; e.Operation == "SNAPSHOT_CREATE" && e.Success {
+				s.auditMu.RUnlock()
+				return e.Timestamp
+			}
+		}
+		s.auditMu.RUnlock()
+	}
+
+	var latest time.Time
+	s.walkSnapshots(func(info os.FileInfo) {
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	})
+	return latest
+}
+
+// VaultMetadata returns the host's view of the stored vault: size history,
+// snapshot count, the last snapshot write and the last owner contact
+func (s *Server) VaultMetadata() VaultMetadata {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h := s.recordUsage(s.lastRequest)
+	latest := h.Samples[len(h.Samples)-1]
+
+	meta := VaultMetadata{
+		UsedBytes:        latest.UsedBytes,
+		Snapshots:        latest.Snapshots,
+		LastSnapshot:     s.lastSnapshotTime(),
+		Growth:           h.Samples,
+		LastOwnerContact: h.LastContact,
+		DaysSinceContact: -1,
+		Anomalies:        s.Anomalies(),
+	}
+	if !h.LastContact.IsZero() {
+		meta.DaysSinceContact = int(timeNow().Sub(h.LastContact) / (24 * time.Hour))
+	}
+	return meta
+}
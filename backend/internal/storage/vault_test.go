@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultMetadata(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	tmpDir := t.TempDir()
+	s, err := NewServer(Config{BasePath: tmpDir})
+	require.NoError(t, err)
+	s.Start()
+
+	meta := s.VaultMetadata()
+	assert.Zero(t, meta.Snapshots)
+	assert.True(t, meta.LastSnapshot.IsZero())
+	assert.Equal(t, -1, meta.DaysSinceContact, "owner has never connected")
+
+	handler := s.Handler()
+	do := func(method, path string, body []byte) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, path, bytes.NewReader(body)))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	do(http.MethodPost, "/repo/?create=true", nil)
+	do(http.MethodPost, "/repo/snapshots/aaaa1111", []byte("encrypted snapshot"))
+
+	now = now.Add(24 * time.Hour)
+	do(http.MethodPost, "/repo/snapshots/bbbb2222", []byte("another encrypted snapshot"))
+	snapshotAt := now
+
+	now = now.Add(3*24*time.Hour + time.Hour)
+	meta = s.VaultMetadata()
+	assert.Equal(t, 2, meta.Snapshots)
+	assert.True(t, meta.LastSnapshot.Equal(snapshotAt))
+	assert.Equal(t, 3, meta.DaysSinceContact)
+	assert.Positive(t, meta.UsedBytes)
+
+	require.Len(t, meta.Growth, 3)
+	assert.Equal(t, "2026-03-10", meta.Growth[0].Date)
+	assert.Equal(t, 1, meta.Growth[0].Snapshots)
+	assert.Equal(t, 2, meta.Growth[1].Snapshots)
+	assert.Greater(t, meta.Growth[1].UsedBytes, meta.Growth[0].UsedBytes)
+
+	// The history and last contact survive a restart
+	s2, err := NewServer(Config{BasePath: tmpDir})
+	require.NoError(t, err)
+	meta2 := s2.VaultMetadata()
+	assert.Len(t, meta2.Growth, 3)
+	assert.Equal(t, 3, meta2.DaysSinceContact)
+}
@@ -0,0 +1,298 @@
+// Package syncbundle carries calls between two nodes with no network path
+// between them, on a file moved by hand (a USB stick, say).
+//
+// A bundle holds the calls queued for the peer (see peerqueue), the IDs of
+// the peer's calls this node has applied, and the head of this node's
+// audit chain. It is signed with the exporting node's Ed25519 key and
+// encrypted with a passphrase both sides know: the key is PBKDF2-SHA256 of
+// the passphrase, the contents are sealed with AES-256-GCM, and the clear
+// header is additional data. Importing a bundle twice, or an older one
+// after a newer, applies nothing twice.
+package syncbundle
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/peerqueue"
+)
+
+const (
+	// Magic starts every bundle
+	Magic = "airgapper-sync/v1\n"
+	// MinPassphraseLength is the shortest passphrase accepted
+	MinPassphraseLength = 12
+	// Iterations is the PBKDF2 iteration count for new bundles
+	Iterations = 600_000
+	// maxHeaderSize bounds the header line read before anything is checked
+	maxHeaderSize = 4 << 10
+	// maxSize bounds the sealed contents
+	maxSize = 64 << 20
+	// maxApplied is how many applied call IDs the state remembers
+	maxApplied = 1000
+)
+
+// ErrWrongPassphrase is returned when a bundle doesn't open with the
+// passphrase given
+var ErrWrongPassphrase = errors.New("wrong passphrase, or the bundle was modified")
+
+// Header describes a bundle; it is stored in the clear
+type Header struct {
+	From       string    `json:"from"` // The exporting node's name
+	CreatedAt  time.Time `json:"created_at"`
+	Salt       []byte    `json:"salt"`
+	Iterations int       `json:"iterations"`
+}
+
+// AuditHead is the latest entry of a node's audit chain
+type AuditHead struct {
+	Sequence uint64 `json:"sequence"`
+	Hash     string `json:"hash"`
+}
+
+// Contents is what a bundle carries
+type Contents struct {
+	From      string              `json:"from"`
+	PublicKey []byte              `json:"public_key"` // Verifies the signature
+	CreatedAt time.Time           `json:"created_at"`
+	Messages  []peerqueue.Message `json:"messages"`
+	Acks      []string            `json:"acks,omitempty"` // IDs of the peer's calls applied here
+	AuditHead *AuditHead          `json:"audit_head,omitempty"`
+}
+
+// KeyID identifies the key the bundle is signed with
+func (c *Contents) KeyID() string {
+	return crypto.KeyID(c.PublicKey)
+}
+
+// envelope is the sealed part of a bundle
+type envelope struct {
+	Contents  json.RawMessage `json:"contents"`
+	Signature []byte          `json:"signature"`
+}
+
+// aead derives the bundle's cipher from passphrase
+func (h *Header) aead(passphrase string) (cipher.AEAD, error) {
+	if h.Iterations <= 0 || len(h.Salt) == 0 {
+		return nil, errors.New("bundle header is invalid")
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, h.Salt, h.Iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Write signs c with privateKey, whose public half is stored with it, and
+// writes it to w encrypted with passphrase
+func Write(w io.Writer, passphrase string, c *Contents, privateKey []byte) error {
+	if len(passphrase) < MinPassphraseLength {
+		return fmt.Errorf("bundle passphrase must be at least %d characters", MinPassphraseLength)
+	}
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return errors.New("this node has no signing key")
+	}
+	c.PublicKey = ed25519.PrivateKey(privateKey).Public().(ed25519.PublicKey)
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now().UTC()
+	}
+	contents, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	signature, err := crypto.Sign(privateKey, contents)
+	if err != nil {
+		return err
+	}
+	sealed, err := json.Marshal(envelope{Contents: contents, Signature: signature})
+	if err != nil {
+		return err
+	}
+
+	h := Header{From: c.From, CreatedAt: c.CreatedAt, Salt: make([]byte, 16), Iterations: Iterations}
+	if _, err := rand.Read(h.Salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+	aead, err := h.aead(passphrase)
+	if err != nil {
+		return err
+	}
+	header, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	header = append(header, '\n')
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	if _, err := io.WriteString(w, Magic); err != nil {
+		return err
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err = w.Write(aead.Seal(nonce, nonce, sealed, header))
+	return err
+}
+
+// Read decrypts a bundle with passphrase and checks it is signed by the key
+// it carries. Whether that key is the peer's is for the caller to decide.
+func Read(r io.Reader, passphrase string) (*Contents, error) {
+	br := bufio.NewReader(io.LimitReader(r, maxSize))
+	magic := make([]byte, len(Magic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != Magic {
+		return nil, errors.New("not an airgapper sync bundle")
+	}
+	header, err := readLine(br)
+	if err != nil {
+		return nil, err
+	}
+	var h Header
+	if err := json.Unmarshal(header, &h); err != nil {
+		return nil, fmt.Errorf("bundle header is invalid: %w", err)
+	}
+	aead, err := h.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := io.ReadAll(br)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("bundle is truncated")
+	}
+	data, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], header)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("bundle is invalid: %w", err)
+	}
+	var c Contents
+	if err := json.Unmarshal(env.Contents, &c); err != nil {
+		return nil, fmt.Errorf("bundle is invalid: %w", err)
+	}
+	if len(c.PublicKey) != ed25519.PublicKeySize || !crypto.Verify(c.PublicKey, env.Contents, env.Signature) {
+		return nil, errors.New("bundle signature is invalid")
+	}
+	return &c, nil
+}
+
+// readLine reads the header line, bounded by maxHeaderSize
+func readLine(br *bufio.Reader) ([]byte, error) {
+	var line []byte
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return nil, errors.New("bundle is truncated")
+		}
+		if b == '\n' {
+			return append(line, b), nil
+		}
+		if len(line) >= maxHeaderSize {
+			return nil, errors.New("bundle header is too long")
+		}
+		line = append(line, b)
+	}
+}
+
+// State is what a node remembers of the bundles it imported
+type State struct {
+	// Applied are the IDs of the peer's calls applied here, newest last;
+	// they are acknowledged in the bundles this node exports
+	Applied []string `json:"applied,omitempty"`
+	// PeerAuditHead is the peer's audit chain head as of its last bundle
+	PeerAuditHead *AuditHead `json:"peer_audit_head,omitempty"`
+	LastImport    time.Time  `json:"last_import,omitzero"`
+}
+
+// WasApplied reports whether the peer's call id was applied here
+func (s *State) WasApplied(id string) bool {
+	return slices.Contains(s.Applied, id)
+}
+
+// MarkApplied records the peer's call id as applied, forgetting the oldest
+// beyond maxApplied
+func (s *State) MarkApplied(id string) {
+	if s.WasApplied(id) {
+		return
+	}
+	s.Applied = append(s.Applied, id)
+	if len(s.Applied) > maxApplied {
+		s.Applied = s.Applied[len(s.Applied)-maxApplied:]
+	}
+}
+
+// CheckAuditHead compares the peer's audit chain head with the one its
+// last bundle carried, and returns an error if the chain went backwards or
+// was rewritten. head replaces the recorded one either way.
+func (s *State) CheckAuditHead(head *AuditHead) error {
+	prev := s.PeerAuditHead
+	if head == nil {
+		return nil
+	}
+	s.PeerAuditHead = head
+	switch {
+	case prev == nil:
+		return nil
+	case head.Sequence < prev.Sequence:
+		return fmt.Errorf("peer's audit chain went back from entry %d to %d", prev.Sequence, head.Sequence)
+	case head.Sequence == prev.Sequence && head.Hash != prev.Hash:
+		return fmt.Errorf("peer's audit chain entry %d changed from %s to %s", head.Sequence, prev.Hash, head.Hash)
+	}
+	return nil
+}
+
+// LoadState reads the state at path; a missing file is an empty state
+func LoadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse sync state: %w", err)
+	}
+	return &s, nil
+}
+
+// Save writes the state to path
+func (s *State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize sync state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save sync state: %w", err)
+	}
+	return nil
+}
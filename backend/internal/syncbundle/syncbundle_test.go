@@ -0,0 +1,92 @@
+package syncbundle
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/lcrostarosa/airgapper/backend/internal/peerqueue"
+)
+
+const passphrase = "correct horse battery staple"
+
+func bundle(t *testing.T) ([]byte, []byte) {
+	t.Helper()
+	pub, priv, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+	c := &Contents{
+		From:      "bob",
+		Messages:  []peerqueue.Message{{ID: "m1", Kind: peerqueue.KindApproval, Path: "/x", Body: []byte(`{"share":"c2VjcmV0"}`)}},
+		Acks:      []string{"a1"},
+		AuditHead: &AuditHead{Sequence: 7, Hash: "abc"},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, passphrase, c, priv))
+	return buf.Bytes(), pub
+}
+
+func TestRoundTrip(t *testing.T) {
+	data, pub := bundle(t)
+	assert.NotContains(t, string(data), "c2VjcmV0", "the share is encrypted")
+
+	c, err := Read(bytes.NewReader(data), passphrase)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", c.From)
+	assert.Equal(t, crypto.KeyID(pub), c.KeyID())
+	require.Len(t, c.Messages, 1)
+	assert.JSONEq(t, `{"share":"c2VjcmV0"}`, string(c.Messages[0].Body))
+	assert.Equal(t, []string{"a1"}, c.Acks)
+	assert.Equal(t, uint64(7), c.AuditHead.Sequence)
+}
+
+func TestReadRejects(t *testing.T) {
+	data, _ := bundle(t)
+
+	_, err := Read(bytes.NewReader(data), "not the passphrase")
+	assert.ErrorIs(t, err, ErrWrongPassphrase)
+
+	tampered := bytes.Clone(data)
+	tampered[len(tampered)-5] ^= 1
+	_, err = Read(bytes.NewReader(tampered), passphrase)
+	assert.ErrorIs(t, err, ErrWrongPassphrase)
+
+	renamed := bytes.Replace(data, []byte(`"from":"bob"`), []byte(`"from":"eve"`), 1)
+	_, err = Read(bytes.NewReader(renamed), passphrase)
+	assert.ErrorIs(t, err, ErrWrongPassphrase, "the header is authenticated")
+
+	_, err = Read(bytes.NewReader([]byte("PK\x03\x04")), passphrase)
+	assert.ErrorContains(t, err, "not an airgapper sync bundle")
+
+	assert.ErrorContains(t, Write(&bytes.Buffer{}, "short", &Contents{}, nil), "at least")
+}
+
+func TestState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sync-state.json")
+	s, err := LoadState(path)
+	require.NoError(t, err)
+
+	s.MarkApplied("m1")
+	s.MarkApplied("m1")
+	assert.Equal(t, []string{"m1"}, s.Applied)
+	for i := range maxApplied {
+		s.MarkApplied(fmt.Sprint(i))
+	}
+	assert.Len(t, s.Applied, maxApplied)
+	assert.False(t, s.WasApplied("m1"), "the oldest are forgotten")
+
+	require.NoError(t, s.CheckAuditHead(&AuditHead{Sequence: 5, Hash: "h5"}))
+	require.NoError(t, s.CheckAuditHead(nil))
+	require.NoError(t, s.CheckAuditHead(&AuditHead{Sequence: 6, Hash: "h6"}))
+	assert.ErrorContains(t, s.CheckAuditHead(&AuditHead{Sequence: 6, Hash: "other"}), "changed")
+	assert.ErrorContains(t, s.CheckAuditHead(&AuditHead{Sequence: 2, Hash: "h2"}), "went back")
+
+	require.NoError(t, s.Save(path))
+	loaded, err := LoadState(path)
+	require.NoError(t, err)
+	assert.Equal(t, &AuditHead{Sequence: 2, Hash: "h2"}, loaded.PeerAuditHead)
+}
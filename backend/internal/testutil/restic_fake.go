@@ -0,0 +1,340 @@
+package testutil
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+// FakeRestic is an in-memory restic.Runner for unit tests. Backups add a
+// snapshot of the given paths, forget removes them, and every call is
+// recorded. Set Err to make the next call fail.
+type FakeRestic struct {
+	mu sync.Mutex
+
+	// Initialized is set by Init
+	Initialized bool
+	// Snapshots in the repository, oldest first
+	Snapshots []restic.Snapshot
+	// Restores records snapshot ID -> target of each restore
+	Restores map[string]string
+	// Excludes are the exclude patterns of the last backup
+	Excludes []string
+	// HeldLocks are the repository's locks
+	HeldLocks []restic.Lock
+	// DiffResult is returned by Diff
+	DiffResult *restic.Diff
+	// PruneOutput are the lines Prune reports as progress
+	PruneOutput []string
+	// Summary is returned by Backup, with SnapshotID set to the new snapshot
+	Summary restic.BackupSummary
+	// Calls records each call as "method arg..."
+	Calls []string
+	// Err, if set, is returned by the next call, which then has no effect
+	Err error
+}
+
+var _ restic.Runner = (*FakeRestic)(nil)
+
+// NewFakeRestic returns an empty, uninitialized fake repository
+func NewFakeRestic() *FakeRestic {
+	return &FakeRestic{Restores: map[string]string{}}
+}
+
+// Factory returns a restic.RunnerFactory that always opens this fake
+func (f *FakeRestic) Factory() restic.RunnerFactory {
+	return func(repoURL, password string) restic.Runner { return f }
+}
+
+// call records a call and returns the injected error, if any
+func (f *FakeRestic) call(method string, args ...string) error {
+	f.Calls = append(f.Calls, strings.TrimSpace(method+" "+strings.Join(args, " ")))
+	err := f.Err
+	f.Err = nil
+	return err
+}
+
+// Init marks the repository initialized
+func (f *FakeRestic) Init(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("init"); err != nil {
+		return err
+	}
+	f.Initialized = true
+	return nil
+}
+
+// Backup adds a snapshot of paths; excludes are recorded in Excludes
+func (f *FakeRestic) Backup(ctx context.Context, paths []string, tags []string, excludes ...string) (*restic.BackupSummary, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("backup", paths...); err != nil {
+		return nil, err
+	}
+	if !f.Initialized {
+		return nil, errors.New("repository does not exist")
+	}
+	if len(paths) == 0 {
+		return nil, errors.New("no paths specified for backup")
+	}
+	f.Excludes = slices.Clone(excludes)
+	return f.addSnapshot(paths, tags), nil
+}
+
+// BackupStdin reads r and adds a snapshot of /filename
+func (f *FakeRestic) BackupStdin(ctx context.Context, r io.Reader, filename string, tags []string) (*restic.BackupSummary, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("backup-stdin", filename); err != nil {
+		return nil, err
+	}
+	if !f.Initialized {
+		return nil, errors.New("repository does not exist")
+	}
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return nil, err
+	}
+	return f.addSnapshot([]string{"/" + filename}, tags), nil
+}
+
+// addSnapshot adds a snapshot of paths and returns its summary
+func (f *FakeRestic) addSnapshot(paths, tags []string) *restic.BackupSummary {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%d %v", len(f.Snapshots), paths))
+	id := hex.EncodeToString(sum[:])
+	f.Snapshots = append(f.Snapshots, restic.Snapshot{
+		ID:       id,
+		ShortID:  id[:8],
+		Time:     time.Now(),
+		Hostname: "fake",
+		Paths:    slices.Clone(paths),
+		Tags:     slices.Clone(tags),
+	})
+
+	summary := f.Summary
+	summary.SnapshotID = id
+	summary.LargestNew = slices.Clone(f.Summary.LargestNew)
+	return &summary
+}
+
+// Restore records the restore of an existing snapshot ("latest" or an ID prefix)
+func (f *FakeRestic) Restore(ctx context.Context, snapshotID, target string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("restore", snapshotID, target); err != nil {
+		return err
+	}
+	snap, err := f.find(snapshotID)
+	if err != nil {
+		return err
+	}
+	f.Restores[snap.ID] = target
+	return nil
+}
+
+// SnapshotList returns the snapshots
+func (f *FakeRestic) SnapshotList(ctx context.Context) ([]restic.Snapshot, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("snapshots"); err != nil {
+		return nil, err
+	}
+	return slices.Clone(f.Snapshots), nil
+}
+
+// Mount records a mount of an existing snapshot and returns at once, as if
+// it were unmounted straight away
+func (f *FakeRestic) Mount(ctx context.Context, mountpoint string, snap restic.Snapshot) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("mount", mountpoint, snap.ID); err != nil {
+		return err
+	}
+	_, err := f.find(snap.ID)
+	return err
+}
+
+// Diff returns DiffResult for two existing snapshots
+func (f *FakeRestic) Diff(ctx context.Context, fromID, toID string) (*restic.Diff, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("diff", fromID, toID); err != nil {
+		return nil, err
+	}
+	for _, id := range []string{fromID, toID} {
+		if _, err := f.find(id); err != nil {
+			return nil, err
+		}
+	}
+	if f.DiffResult == nil {
+		return &restic.Diff{SourceSnapshot: fromID, TargetSnapshot: toID}, nil
+	}
+	return f.DiffResult, nil
+}
+
+// Forget removes the listed snapshots, or of those carrying opts.Tags all
+// but the newest KeepLast and those with any of KeepTags. The other keep
+// rules aren't modelled.
+func (f *FakeRestic) Forget(ctx context.Context, opts restic.ForgetOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("forget", opts.SnapshotIDs...); err != nil {
+		return err
+	}
+	for _, id := range opts.SnapshotIDs {
+		snap, err := f.find(id)
+		if err != nil {
+			return err
+		}
+		f.Snapshots = slices.DeleteFunc(f.Snapshots, func(s restic.Snapshot) bool { return s.ID == snap.ID })
+	}
+	if opts.KeepLast == 0 {
+		return nil
+	}
+	_, remove := f.forgetPlan(opts)
+	f.Snapshots = slices.DeleteFunc(f.Snapshots, func(s restic.Snapshot) bool {
+		return slices.ContainsFunc(remove, func(r restic.Snapshot) bool { return r.ID == s.ID })
+	})
+	return nil
+}
+
+// ForgetDryRun returns what Forget would keep and remove by the keep rules
+// Forget models
+func (f *FakeRestic) ForgetDryRun(ctx context.Context, opts restic.ForgetOptions) (*restic.ForgetPreview, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("forget --dry-run"); err != nil {
+		return nil, err
+	}
+	keep, remove := f.forgetPlan(opts)
+	return &restic.ForgetPreview{Keep: keep, Remove: remove}, nil
+}
+
+// forgetPlan splits the snapshots carrying opts.Tags into the newest
+// KeepLast and those with any of KeepTags, and the rest, oldest first.
+// f.mu must be held.
+func (f *FakeRestic) forgetPlan(opts restic.ForgetOptions) (keep, remove []restic.Snapshot) {
+	keep, remove = []restic.Snapshot{}, []restic.Snapshot{}
+	newer := 0
+	for i := len(f.Snapshots) - 1; i >= 0; i-- {
+		snap := f.Snapshots[i]
+		if !snap.HasTags(opts.Tags) {
+			continue
+		}
+		newer++
+		if newer <= opts.KeepLast || slices.ContainsFunc(opts.KeepTags, func(tag string) bool { return slices.Contains(snap.Tags, tag) }) {
+			keep = append([]restic.Snapshot{snap}, keep...)
+		} else {
+			remove = append([]restic.Snapshot{snap}, remove...)
+		}
+	}
+	return keep, remove
+}
+
+// Prune reports each of PruneOutput as progress
+func (f *FakeRestic) Prune(ctx context.Context, progress func(line string)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("prune"); err != nil {
+		return err
+	}
+	if !f.Initialized {
+		return errors.New("repository does not exist")
+	}
+	for _, line := range f.PruneOutput {
+		if progress != nil {
+			progress(line)
+		}
+	}
+	return nil
+}
+
+// Check succeeds once the repository is initialized
+func (f *FakeRestic) Check(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("check"); err != nil {
+		return err
+	}
+	if !f.Initialized {
+		return errors.New("repository does not exist")
+	}
+	return nil
+}
+
+// Locks returns HeldLocks
+func (f *FakeRestic) Locks(ctx context.Context) ([]restic.Lock, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("locks"); err != nil {
+		return nil, err
+	}
+	return slices.Clone(f.HeldLocks), nil
+}
+
+// Unlock removes stale locks, or all of them if removeAll is set
+func (f *FakeRestic) Unlock(ctx context.Context, removeAll bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("unlock", fmt.Sprint(removeAll)); err != nil {
+		return err
+	}
+	now := time.Now()
+	f.HeldLocks = slices.DeleteFunc(f.HeldLocks, func(l restic.Lock) bool { return removeAll || l.Stale(now) })
+	return nil
+}
+
+// RepairIndex records the call
+func (f *FakeRestic) RepairIndex(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.call("repair-index")
+}
+
+// RepairPacks records the call
+func (f *FakeRestic) RepairPacks(ctx context.Context, ids []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("repair-packs", ids...); err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return errors.New("no packs specified for repair")
+	}
+	return nil
+}
+
+// Rebackup adds a snapshot of paths like Backup
+func (f *FakeRestic) Rebackup(ctx context.Context, paths []string, tags []string, excludes ...string) (*restic.BackupSummary, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.call("rebackup", paths...); err != nil {
+		return nil, err
+	}
+	if !f.Initialized {
+		return nil, errors.New("repository does not exist")
+	}
+	if len(paths) == 0 {
+		return nil, errors.New("no paths specified for backup")
+	}
+	f.Excludes = slices.Clone(excludes)
+	return f.addSnapshot(paths, tags), nil
+}
+
+// find resolves "latest", optionally narrowed by tag, or a snapshot ID prefix
+func (f *FakeRestic) find(id string) (restic.Snapshot, error) {
+	sel, err := restic.ParseSnapshotSelector(id)
+	if err != nil || id == "" {
+		return restic.Snapshot{}, fmt.Errorf("no matching ID found for prefix %q", id)
+	}
+	return sel.Resolve(f.Snapshots)
+}
@@ -1,10 +1,14 @@
 package testutil
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
 )
 
 func TestGetTestSeed(t *testing.T) {
@@ -295,3 +299,52 @@ func TestCombinations(t *testing.T) {
 	combos = combinations(5, 3)
 	assert.Len(t, combos, 10, "C(5,3) = 10")
 }
+
+func TestFakeRestic(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFakeRestic()
+
+	_, err := fake.Backup(ctx, []string{"/docs"}, nil)
+	assert.Error(t, err, "backup before init")
+	require.NoError(t, fake.Init(ctx))
+	fake.Summary = restic.BackupSummary{FilesNew: 2}
+	for i := 0; i < 3; i++ {
+		summary, err := fake.Backup(ctx, []string{"/docs"}, []string{"daily"})
+		require.NoError(t, err)
+		assert.Equal(t, fake.Snapshots[i].ID, summary.SnapshotID)
+		assert.Equal(t, 2, summary.FilesNew)
+	}
+	require.Len(t, fake.Snapshots, 3)
+
+	require.NoError(t, fake.Restore(ctx, "latest", "/restore"))
+	assert.Equal(t, "/restore", fake.Restores[fake.Snapshots[2].ID])
+	assert.Error(t, fake.Restore(ctx, "ffffffff", "/restore"))
+
+	require.NoError(t, fake.Forget(ctx, restic.ForgetOptions{SnapshotIDs: []string{fake.Snapshots[0].ShortID}}))
+	require.NoError(t, fake.Forget(ctx, restic.ForgetOptions{KeepLast: 1}))
+	snapshots, err := fake.SnapshotList(ctx)
+	require.NoError(t, err)
+	assert.Len(t, snapshots, 1)
+
+	// Tagged selection and retention
+	_, err = fake.Backup(ctx, []string{"/docs"}, []string{"documents", "keep"})
+	require.NoError(t, err)
+	_, err = fake.Backup(ctx, []string{"/docs"}, []string{"documents"})
+	require.NoError(t, err)
+	_, err = fake.Backup(ctx, []string{"/photos"}, []string{"photos"})
+	require.NoError(t, err)
+	require.NoError(t, fake.Restore(ctx, "latest:tag=documents", "/tagged"))
+	assert.Equal(t, "/tagged", fake.Restores[fake.Snapshots[2].ID])
+	assert.Error(t, fake.Restore(ctx, "latest:tag=music", "/tagged"))
+
+	require.NoError(t, fake.Forget(ctx, restic.ForgetOptions{KeepLast: 1, KeepTags: []string{"keep"}, Tags: []string{"documents"}}))
+	require.Len(t, fake.Snapshots, 4, "only documents snapshots are considered, and tagged ones kept")
+	require.NoError(t, fake.Forget(ctx, restic.ForgetOptions{KeepLast: 1, Tags: []string{"documents"}}))
+	require.Len(t, fake.Snapshots, 3)
+	assert.Equal(t, []string{"photos"}, fake.Snapshots[2].Tags)
+
+	fake.Err = errors.New("repository is locked")
+	assert.EqualError(t, fake.Check(ctx), "repository is locked")
+	assert.NoError(t, fake.Check(ctx), "the injected error is returned once")
+	assert.Contains(t, fake.Calls, "backup /docs")
+}
@@ -0,0 +1,127 @@
+// Package tracing carries a correlation ID across peer HTTP calls so the
+// owner's and host's logs and audit entries for one consent operation can be
+// matched up. The ID travels in the X-Airgapper-Request-ID header and in the
+// request context on each side.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/logging"
+)
+
+// Header carries the correlation ID on peer requests and API responses
+const Header = "X-Airgapper-Request-ID"
+
+// LogKey is the log field name for correlation IDs
+const LogKey = "correlationID"
+
+// maxIDLength bounds IDs accepted from peers, which end up in logs and audit entries
+const maxIDLength = 64
+
+type contextKey struct{}
+
+// NewID returns a new random correlation ID
+func NewID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand doesn't fail on supported platforms, but an ID
+		// that is merely unlikely to collide still beats none
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Valid reports whether id is acceptable as a correlation ID: 1-64
+// characters of letters, digits, '-', '_' and '.'
+func Valid(id string) bool {
+	if id == "" || len(id) > maxIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-' || c == '_' || c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// WithID returns ctx carrying correlation ID id
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// ID returns the correlation ID carried by ctx, or ""
+func ID(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Ensure returns ctx and its correlation ID, adding a new ID if ctx has none
+func Ensure(ctx context.Context) (context.Context, string) {
+	if id := ID(ctx); id != "" {
+		return ctx, id
+	}
+	id := NewID()
+	return WithID(ctx, id), id
+}
+
+// Field returns the log field for ctx's correlation ID, or a no-op field
+// if ctx has none
+func Field(ctx context.Context) zap.Field {
+	id := ID(ctx)
+	if id == "" {
+		return zap.Skip()
+	}
+	return logging.String(LogKey, id)
+}
+
+// Middleware adopts the caller's correlation ID, or starts a new one, for
+// each request. The ID is put in the request context and echoed in the
+// response header, so it is also returned with error responses.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(Header)
+		if !Valid(id) {
+			id = NewID()
+		}
+		w.Header().Set(Header, id)
+		next.ServeHTTP(w, r.WithContext(WithID(r.Context(), id)))
+	})
+}
+
+// Transport sets the correlation ID header on outgoing requests whose
+// context carries one
+type Transport struct {
+	// Base makes the requests; http.DefaultTransport if nil
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if id := ID(req.Context()); id != "" && req.Header.Get(Header) == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(Header, id)
+	}
+	return base.RoundTrip(req)
+}
+
+// NewClient returns an HTTP client for peer calls that propagates
+// correlation IDs from request contexts
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: &Transport{}}
+}
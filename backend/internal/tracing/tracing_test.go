@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValid(t *testing.T) {
+	assert.True(t, Valid(NewID()))
+	assert.True(t, Valid("req-2024.01_a"))
+	assert.False(t, Valid(""))
+	assert.False(t, Valid("has space"))
+	assert.False(t, Valid("line\nbreak"))
+	assert.False(t, Valid(strings.Repeat("a", maxIDLength+1)))
+}
+
+func TestEnsure(t *testing.T) {
+	ctx, id := Ensure(context.Background())
+	assert.NotEmpty(t, id)
+	assert.Equal(t, id, ID(ctx))
+
+	_, again := Ensure(ctx)
+	assert.Equal(t, id, again, "an existing ID is kept")
+}
+
+func TestMiddleware(t *testing.T) {
+	var seen string
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = ID(r.Context())
+	}))
+
+	t.Run("adopts caller ID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(Header, "abc123")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, "abc123", seen)
+		assert.Equal(t, "abc123", rec.Header().Get(Header))
+	})
+
+	t.Run("replaces missing or invalid ID", func(t *testing.T) {
+		for _, given := range []string{"", "bad id"} {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(Header, given)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			assert.True(t, Valid(seen))
+			assert.NotEqual(t, given, seen)
+			assert.Equal(t, seen, rec.Header().Get(Header))
+		}
+	})
+}
+
+func TestClientPropagatesID(t *testing.T) {
+	var got string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(Header)
+	}))
+	defer ts.Close()
+	client := NewClient(0)
+
+	req, err := http.NewRequestWithContext(WithID(context.Background(), "op-1"), http.MethodGet, ts.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, "op-1", got)
+
+	req, err = http.NewRequest(http.MethodGet, ts.URL, nil)
+	require.NoError(t, err)
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Empty(t, got, "no header without an ID in the context")
+}
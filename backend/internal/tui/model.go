@@ -0,0 +1,415 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+)
+
+// Tabs
+const (
+	tabRequests = iota
+	tabBackups
+	tabStatus
+)
+
+var tabNames = []string{"Requests", "Backups", "Status"}
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	activeTab     = lipgloss.NewStyle().Bold(true).Reverse(true).Padding(0, 1)
+	inactiveTab   = lipgloss.NewStyle().Padding(0, 1)
+	selectedStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	dimStyle      = lipgloss.NewStyle().Faint(true)
+	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	warnStyle     = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("11"))
+)
+
+// request is a pending restore or deletion request in the requests list
+type request struct {
+	deletion bool
+	restore  *consent.RestoreRequest
+	del      *consent.DeletionRequest
+}
+
+func (r request) id() string {
+	if r.deletion {
+		return r.del.ID
+	}
+	return r.restore.ID
+}
+
+// action is an approve or deny waiting for confirmation
+type action struct {
+	approve bool
+	req     request
+}
+
+type loadedMsg struct {
+	data *Data
+	err  error
+}
+
+type actionMsg struct {
+	message string
+	err     error
+}
+
+// Model is the bubbletea model of the TUI
+type Model struct {
+	src     Source
+	data    *Data
+	err     error
+	loading bool
+
+	tab     int
+	cursor  int // Selected request, or first visible backup
+	confirm *action
+	message string
+	height  int
+}
+
+// New creates the TUI model
+func New(src Source) Model {
+	return Model{src: src, loading: true}
+}
+
+// Init starts loading the data
+func (m Model) Init() tea.Cmd {
+	return m.load()
+}
+
+func (m Model) load() tea.Cmd {
+	return func() tea.Msg {
+		data, err := m.src.Load()
+		return loadedMsg{data: data, err: err}
+	}
+}
+
+// requests lists pending restores, then deletions
+func (m Model) requests() []request {
+	if m.data == nil {
+		return nil
+	}
+	reqs := make([]request, 0, len(m.data.Restores)+len(m.data.Deletions))
+	for _, r := range m.data.Restores {
+		reqs = append(reqs, request{restore: r})
+	}
+	for _, d := range m.data.Deletions {
+		reqs = append(reqs, request{deletion: true, del: d})
+	}
+	return reqs
+}
+
+// Update handles key presses and loaded data
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+
+	case loadedMsg:
+		m.loading = false
+		m.err = msg.err
+		if msg.err == nil {
+			m.data = msg.data
+		}
+		m.cursor = m.clampCursor(m.cursor)
+
+	case actionMsg:
+		if msg.err != nil {
+			m.message = errorStyle.Render("Error: " + msg.err.Error())
+		} else {
+			m.message = msg.message
+		}
+		m.loading = true
+		return m, m.load()
+
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+	if key == "ctrl+c" {
+		return m, tea.Quit
+	}
+
+	if m.confirm != nil {
+		switch key {
+		case "y", "Y":
+			a := *m.confirm
+			m.confirm = nil
+			return m, m.perform(a)
+		case "n", "N", "esc":
+			m.confirm = nil
+			m.message = "Cancelled"
+		}
+		return m, nil
+	}
+
+	switch key {
+	case "q", "esc":
+		return m, tea.Quit
+	case "tab", "right", "l":
+		m.tab = (m.tab + 1) % len(tabNames)
+		m.cursor = 0
+	case "shift+tab", "left", "h":
+		m.tab = (m.tab + len(tabNames) - 1) % len(tabNames)
+		m.cursor = 0
+	case "1", "2", "3":
+		m.tab = int(key[0] - '1')
+		m.cursor = 0
+	case "up", "k":
+		m.cursor = m.clampCursor(m.cursor - 1)
+	case "down", "j":
+		m.cursor = m.clampCursor(m.cursor + 1)
+	case "r":
+		m.loading = true
+		m.message = ""
+		return m, m.load()
+	case "a", "d":
+		reqs := m.requests()
+		if m.tab == tabRequests && m.cursor < len(reqs) {
+			m.confirm = &action{approve: key == "a", req: reqs[m.cursor]}
+		}
+	}
+	return m, nil
+}
+
+func (m Model) clampCursor(c int) int {
+	n := 0
+	switch m.tab {
+	case tabRequests:
+		n = len(m.requests())
+	case tabBackups:
+		if m.data != nil {
+			n = len(m.data.Backups)
+		}
+	}
+	if c >= n {
+		c = n - 1
+	}
+	if c < 0 {
+		c = 0
+	}
+	return c
+}
+
+// perform carries out a confirmed action
+func (m Model) perform(a action) tea.Cmd {
+	src := m.src
+	return func() tea.Msg {
+		id := a.req.id()
+		var (
+			result string
+			err    error
+		)
+		switch {
+		case a.approve && a.req.deletion:
+			result, err = src.ApproveDeletion(id)
+		case a.approve:
+			result, err = src.ApproveRestore(id)
+		case a.req.deletion:
+			err = src.DenyDeletion(id)
+			result = "Denied deletion " + id
+		default:
+			err = src.DenyRestore(id)
+			result = "Denied request " + id
+		}
+		return actionMsg{message: result, err: err}
+	}
+}
+
+// View renders the TUI
+func (m Model) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("airgapper") + "  ")
+	for i, name := range tabNames {
+		label := fmt.Sprintf("%d %s", i+1, name)
+		if i == tabRequests {
+			label += fmt.Sprintf(" (%d)", len(m.requests()))
+		}
+		if i == m.tab {
+			b.WriteString(activeTab.Render(label))
+		} else {
+			b.WriteString(inactiveTab.Render(label))
+		}
+	}
+	b.WriteString("\n\n")
+
+	switch {
+	case m.err != nil:
+		b.WriteString(errorStyle.Render("Error: "+m.err.Error()) + "\n")
+	case m.data == nil:
+		b.WriteString(dimStyle.Render("Loading...") + "\n")
+	case m.tab == tabRequests:
+		m.viewRequests(&b)
+	case m.tab == tabBackups:
+		m.viewBackups(&b)
+	default:
+		m.viewStatus(&b)
+	}
+
+	b.WriteString("\n")
+	switch {
+	case m.confirm != nil:
+		verb := "Deny"
+		if m.confirm.approve {
+			verb = "Approve"
+		}
+		kind := "request"
+		if m.confirm.req.deletion {
+			kind = "deletion"
+		}
+		b.WriteString(warnStyle.Render(fmt.Sprintf("%s %s %s? (y/n)", verb, kind, m.confirm.req.id())) + "\n")
+	case m.message != "":
+		b.WriteString(m.message + "\n")
+	}
+	if m.loading && m.data != nil {
+		b.WriteString(dimStyle.Render("Refreshing...") + "\n")
+	}
+
+	help := "tab/1-3 switch  ↑/↓ move  r refresh  q quit"
+	if m.tab == tabRequests {
+		help = "a approve  d deny  " + help
+	}
+	b.WriteString(dimStyle.Render(help))
+	return b.String()
+}
+
+func (m Model) viewRequests(b *strings.Builder) {
+	reqs := m.requests()
+	if len(reqs) == 0 {
+		b.WriteString(dimStyle.Render("No pending requests") + "\n")
+		return
+	}
+
+	for i, r := range reqs {
+		line := requestLine(r)
+		if i == m.cursor {
+			b.WriteString(selectedStyle.Render("> "+line) + "\n")
+		} else {
+			b.WriteString("  " + line + "\n")
+		}
+	}
+
+	b.WriteString("\n")
+	for _, f := range requestDetails(reqs[m.cursor]) {
+		fmt.Fprintf(b, "  %-10s %s\n", f.Label+":", f.Value)
+	}
+}
+
+func requestLine(r request) string {
+	if r.deletion {
+		d := r.del
+		return fmt.Sprintf("deletion  %s  %-8s from %s  (%d/%d approvals)",
+			d.ID, d.DeletionType, d.Requester, len(d.Approvals), d.RequiredApprovals)
+	}
+	req := r.restore
+	purpose := "restore"
+	switch {
+	case req.IsKeyExport():
+		purpose = "EXPORT KEYS"
+	case req.IsBrowse():
+		purpose = "browse"
+	}
+	line := fmt.Sprintf("%-8s  %s  %-8s from %s", purpose, req.ID, req.SnapshotID, req.Requester)
+	if req.RequiredApprovals > 0 {
+		line += fmt.Sprintf("  (%d/%d approvals)", len(req.Approvals), req.RequiredApprovals)
+	}
+	return line
+}
+
+func requestDetails(r request) []Field {
+	if r.deletion {
+		d := r.del
+		fields := []Field{
+			{"Reason", d.Reason},
+			{"Expires", d.ExpiresAt.Local().Format("2006-01-02 15:04")},
+		}
+		if len(d.SnapshotIDs) > 0 {
+			fields = append(fields, Field{"Snapshots", strings.Join(d.SnapshotIDs, ", ")})
+		}
+		if len(d.Paths) > 0 {
+			fields = append(fields, Field{"Paths", strings.Join(d.Paths, ", ")})
+		}
+		return fields
+	}
+
+	req := r.restore
+	paths := "all paths"
+	switch {
+	case req.HasSealedPaths():
+		paths = fmt.Sprintf("%d private path(s)", len(req.Paths))
+	case len(req.Paths) > 0:
+		paths = strings.Join(req.Paths, ", ")
+	}
+	fields := []Field{
+		{"Reason", req.Reason},
+		{"Paths", paths},
+		{"Device", req.RequesterContext.String()},
+		{"Expires", req.ExpiresAt.Local().Format("2006-01-02 15:04")},
+	}
+	if req.Quorum != nil {
+		fields = append(fields, Field{"Quorum", req.Quorum.String()})
+	}
+	for _, a := range req.Approvals {
+		name := a.KeyHolderName
+		if a.OnBehalfOf != "" {
+			name += " for " + a.OnBehalfOf
+		}
+		fields = append(fields, Field{"Approved", name + " at " + a.ApprovedAt.Local().Format("2006-01-02 15:04")})
+	}
+	for _, t := range req.Terms {
+		fields = append(fields, Field{"Terms", t.SetBy + ": " + t.String()})
+	}
+	if p := req.Progress; p != nil {
+		fields = append(fields, Field{"Restore", p.Status + " as of " + p.UpdatedAt.Local().Format("2006-01-02 15:04")})
+	}
+	return fields
+}
+
+func (m Model) viewBackups(b *strings.Builder) {
+	if len(m.data.Backups) == 0 {
+		note := m.data.BackupsNote
+		if note == "" {
+			note = "No backups yet"
+		}
+		b.WriteString(dimStyle.Render(note) + "\n")
+		return
+	}
+
+	// Leave room for the header and footer
+	visible := len(m.data.Backups)
+	if m.height > 8 && visible > m.height-8 {
+		visible = m.height - 8
+	}
+	start := m.cursor
+	if start+visible > len(m.data.Backups) {
+		start = len(m.data.Backups) - visible
+	}
+
+	for _, bk := range m.data.Backups[start : start+visible] {
+		fmt.Fprintf(b, "  %s  %s  %s\n", bk.Time.Local().Format("2006-01-02 15:04"), bk.ID, bk.Detail)
+	}
+	if visible < len(m.data.Backups) {
+		b.WriteString(dimStyle.Render(fmt.Sprintf("  %d-%d of %d", start+1, start+visible, len(m.data.Backups))) + "\n")
+	}
+}
+
+func (m Model) viewStatus(b *strings.Builder) {
+	width := 0
+	for _, f := range m.data.Status {
+		width = max(width, len(f.Label))
+	}
+	for _, f := range m.data.Status {
+		fmt.Fprintf(b, "  %-*s  %s\n", width, f.Label, f.Value)
+	}
+}
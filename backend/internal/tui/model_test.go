@@ -0,0 +1,166 @@
+package tui
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+)
+
+type fakeSource struct {
+	data     *Data
+	approved []string
+	denied   []string
+	fail     error
+}
+
+func (f *fakeSource) Load() (*Data, error) { return f.data, nil }
+
+func (f *fakeSource) ApproveRestore(id string) (string, error) {
+	f.approved = append(f.approved, id)
+	return "approved " + id, f.fail
+}
+
+func (f *fakeSource) DenyRestore(id string) error {
+	f.denied = append(f.denied, id)
+	return f.fail
+}
+
+func (f *fakeSource) ApproveDeletion(id string) (string, error) {
+	f.approved = append(f.approved, "deletion:"+id)
+	return "approved deletion " + id, f.fail
+}
+
+func (f *fakeSource) DenyDeletion(id string) error {
+	f.denied = append(f.denied, "deletion:"+id)
+	return f.fail
+}
+
+func newFakeSource() *fakeSource {
+	now := time.Now()
+	return &fakeSource{data: &Data{
+		Restores: []*consent.RestoreRequest{
+			{ID: "r1", Requester: "alice", SnapshotID: "latest", Reason: "lost files", ExpiresAt: now.Add(time.Hour)},
+		},
+		Deletions: []*consent.DeletionRequest{
+			{ID: "d1", Requester: "alice", DeletionType: consent.DeletionTypePrune, Reason: "cleanup", RequiredApprovals: 2, ExpiresAt: now.Add(time.Hour)},
+		},
+		Backups: []Backup{{Time: now, ID: "abc123", Detail: "laptop  /home"}},
+		Status:  []Field{{Label: "Name", Value: "alice"}},
+	}}
+}
+
+func key(s string) tea.KeyMsg {
+	switch s {
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	case "tab":
+		return tea.KeyMsg{Type: tea.KeyTab}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+// press sends keys to the model, running any commands they produce
+func press(t *testing.T, m Model, keys ...string) Model {
+	t.Helper()
+	for _, k := range keys {
+		next, cmd := m.Update(key(k))
+		m = next.(Model)
+		m = drain(m, cmd)
+	}
+	return m
+}
+
+// drain runs commands until they stop producing messages for the model
+func drain(m Model, cmd tea.Cmd) Model {
+	for cmd != nil {
+		msg := cmd()
+		switch msg.(type) {
+		case loadedMsg, actionMsg:
+		default:
+			return m
+		}
+		next, nextCmd := m.Update(msg)
+		m, cmd = next.(Model), nextCmd
+	}
+	return m
+}
+
+func loaded(t *testing.T, src Source) Model {
+	t.Helper()
+	m := New(src)
+	m = drain(m, m.Init())
+	require.NotNil(t, m.data)
+	return m
+}
+
+func TestApproveNeedsConfirmation(t *testing.T) {
+	src := newFakeSource()
+	m := loaded(t, src)
+
+	m = press(t, m, "a")
+	assert.Contains(t, m.View(), "Approve request r1? (y/n)")
+	assert.Empty(t, src.approved)
+
+	m = press(t, m, "n")
+	assert.Empty(t, src.approved)
+	assert.Nil(t, m.confirm)
+
+	m = press(t, m, "a", "y")
+	assert.Equal(t, []string{"r1"}, src.approved)
+	assert.Contains(t, m.View(), "approved r1")
+}
+
+func TestDenyDeletion(t *testing.T) {
+	src := newFakeSource()
+	m := loaded(t, src)
+
+	m = press(t, m, "down", "d")
+	assert.Contains(t, m.View(), "Deny deletion d1? (y/n)")
+	m = press(t, m, "y")
+	assert.Equal(t, []string{"deletion:d1"}, src.denied)
+	assert.Contains(t, m.View(), "Denied deletion d1")
+}
+
+func TestActionErrorIsShown(t *testing.T) {
+	src := newFakeSource()
+	src.fail = errors.New("no private key found")
+	m := loaded(t, src)
+
+	m = press(t, m, "a", "y")
+	assert.Contains(t, m.View(), "Error: no private key found")
+}
+
+func TestTabs(t *testing.T) {
+	m := loaded(t, newFakeSource())
+	assert.Contains(t, m.View(), "lost files")
+
+	m = press(t, m, "tab")
+	assert.Contains(t, m.View(), "abc123")
+
+	// Approve only acts on the requests tab
+	m = press(t, m, "a")
+	assert.Nil(t, m.confirm)
+
+	m = press(t, m, "3")
+	assert.Contains(t, m.View(), "alice")
+
+	m = press(t, m, "1", "down", "down", "down")
+	assert.Equal(t, 1, m.cursor, "cursor stays on the last request")
+}
+
+func TestNoPendingRequests(t *testing.T) {
+	m := loaded(t, &fakeSource{data: &Data{BackupsNote: "Hosts can't list snapshots"}})
+	assert.Contains(t, m.View(), "No pending requests")
+
+	m = press(t, m, "a")
+	assert.Nil(t, m.confirm)
+
+	m = press(t, m, "2")
+	assert.Contains(t, m.View(), "Hosts can't list snapshots")
+}
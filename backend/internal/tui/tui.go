@@ -0,0 +1,52 @@
+// Package tui is the interactive terminal view of pending approvals,
+// backup history and storage status
+package tui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/consent"
+)
+
+// Data is everything the TUI shows, loaded in one go on start and refresh
+type Data struct {
+	Restores  []*consent.RestoreRequest
+	Deletions []*consent.DeletionRequest
+	Backups   []Backup
+	Status    []Field
+
+	// BackupsNote explains an empty backup list, e.g. for hosts who can't
+	// read the encrypted snapshots
+	BackupsNote string
+}
+
+// Backup is one entry in the backup history
+type Backup struct {
+	Time   time.Time
+	ID     string
+	Detail string
+}
+
+// Field is a labelled line on the status tab
+type Field struct {
+	Label string
+	Value string
+}
+
+// Source loads the TUI's data and carries out approvals. Approve returns a
+// short description of the outcome, e.g. the approval progress.
+type Source interface {
+	Load() (*Data, error)
+	ApproveRestore(id string) (string, error)
+	DenyRestore(id string) error
+	ApproveDeletion(id string) (string, error)
+	DenyDeletion(id string) error
+}
+
+// Run shows the TUI until the user quits
+func Run(src Source) error {
+	_, err := tea.NewProgram(New(src), tea.WithAltScreen()).Run()
+	return err
+}
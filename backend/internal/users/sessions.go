@@ -0,0 +1,211 @@
+package users
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+// Login sessions let the web UI sign in with a name and password once and
+// then send a cookie, rather than keep an API token. Like tokens, only
+// hashes of session tokens are kept.
+
+// SessionsFileName is the login sessions' file in the config directory
+const SessionsFileName = "sessions.json"
+
+const (
+	// SessionIdleTimeout ends a session that hasn't been used for this long
+	SessionIdleTimeout = 12 * time.Hour
+	// SessionMaxAge ends a session this long after login however it's used
+	SessionMaxAge = 7 * 24 * time.Hour
+
+	// sessionTouchInterval is how stale a session's last use may be before
+	// it is saved again, so every call doesn't rewrite the file
+	sessionTouchInterval = time.Minute
+)
+
+// Session is a user's login from a browser
+type Session struct {
+	ID         string    `json:"id"` // Public ID, for listing and revoking
+	TokenHash  string    `json:"token_hash"`
+	CSRFToken  string    `json:"csrf_token"` // Sent back in X-CSRF-Token on every change
+	User       string    `json:"user"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	RemoteAddr string    `json:"remote_addr,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+// ExpiresAt is when the session ends unless it is used again
+func (s *Session) ExpiresAt() time.Time {
+	idle := s.LastSeenAt.Add(SessionIdleTimeout)
+	if limit := s.CreatedAt.Add(SessionMaxAge); limit.Before(idle) {
+		return limit
+	}
+	return idle
+}
+
+// CheckCSRF reports whether token is the session's CSRF token
+func (s *Session) CheckCSRF(token string) bool {
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.CSRFToken)) == 1
+}
+
+// Login checks a user's password and starts a session for them, returning
+// it and its token for the session cookie
+func (s *Store) Login(name, password, userAgent, remoteAddr string) (*Session, string, error) {
+	if _, err := s.AuthenticatePassword(name, password); err != nil {
+		return nil, "", err
+	}
+
+	token, err := randomHex(32)
+	if err != nil {
+		return nil, "", err
+	}
+	id, err := randomHex(8)
+	if err != nil {
+		return nil, "", err
+	}
+	csrf, err := randomHex(32)
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now().UTC()
+	session := Session{
+		ID:         id,
+		TokenHash:  hashToken(token),
+		CSRFToken:  csrf,
+		User:       name,
+		UserAgent:  userAgent,
+		RemoteAddr: remoteAddr,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+	err = s.updateSessions(func(list []Session) ([]Session, error) {
+		return append(list, session), nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return &session, token, nil
+}
+
+// AuthenticateSession returns the live session a session token belongs to
+// and its user, recording that it was used
+func (s *Store) AuthenticateSession(token string) (*Session, *User, error) {
+	hash := hashToken(token)
+	var session *Session
+	err := s.updateSessions(func(list []Session) ([]Session, error) {
+		for i := range list {
+			if subtle.ConstantTimeCompare([]byte(list[i].TokenHash), []byte(hash)) == 1 {
+				if now := time.Now().UTC(); now.Sub(list[i].LastSeenAt) > sessionTouchInterval {
+					list[i].LastSeenAt = now
+				}
+				session = &list[i]
+				return list, nil
+			}
+		}
+		return nil, apperrors.ErrUnauthenticated
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	user, err := s.Get(session.User)
+	if err != nil {
+		return nil, nil, apperrors.ErrUnauthenticated
+	}
+	return session, user, nil
+}
+
+// Sessions returns the live sessions of the user called name, or of every
+// user if name is empty, most recently used first
+func (s *Store) Sessions(name string) ([]Session, error) {
+	var sessions []Session
+	err := s.updateSessions(func(list []Session) ([]Session, error) {
+		for _, session := range list {
+			if name == "" || session.User == name {
+				sessions = append(sessions, session)
+			}
+		}
+		return list, nil
+	})
+	slices.SortFunc(sessions, func(a, b Session) int { return b.LastSeenAt.Compare(a.LastSeenAt) })
+	return sessions, err
+}
+
+// RevokeSession ends the session with public ID id
+func (s *Store) RevokeSession(id string) error {
+	return s.updateSessions(func(list []Session) ([]Session, error) {
+		i := slices.IndexFunc(list, func(session Session) bool { return session.ID == id })
+		if i < 0 {
+			return nil, apperrors.ErrSessionNotFound
+		}
+		return slices.Delete(list, i, i+1), nil
+	})
+}
+
+// revokeUserSessions ends every session of the user called name
+func (s *Store) revokeUserSessions(name string) error {
+	return s.updateSessions(func(list []Session) ([]Session, error) {
+		return slices.DeleteFunc(list, func(session Session) bool { return session.User == name }), nil
+	})
+}
+
+// updateSessions loads the live sessions, applies fn and saves the result.
+// Expired sessions are dropped on every update.
+func (s *Store) updateSessions(fn func([]Session) ([]Session, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := filepath.Join(filepath.Dir(s.path), SessionsFileName)
+	var list []Session
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &list); err != nil {
+			return fmt.Errorf("failed to parse sessions: %w", err)
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	now := time.Now()
+	live := slices.DeleteFunc(slices.Clone(list), func(session Session) bool { return !now.Before(session.ExpiresAt()) })
+	updated, err := fn(live)
+	if err != nil {
+		return err
+	}
+	if len(updated) == 0 && len(list) == 0 {
+		return nil
+	}
+	out, err := json.MarshalIndent(updated, "", "  ")
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(out, data) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, out, 0600); err != nil {
+		return fmt.Errorf("failed to save sessions: %w", err)
+	}
+	return nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
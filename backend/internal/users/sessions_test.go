@@ -0,0 +1,63 @@
+package users
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+func TestSessions(t *testing.T) {
+	s := NewStore(t.TempDir())
+	_, err := s.Add("alice", RoleAdmin, "correct horse")
+	require.NoError(t, err)
+	_, err = s.Add("bob", RoleViewer, "battery staple")
+	require.NoError(t, err)
+
+	_, _, err = s.Login("alice", "wrong horse", "", "")
+	assert.Equal(t, apperrors.CodeUnauthenticated, apperrors.CodeOf(err))
+
+	session, token, err := s.Login("alice", "correct horse", "Firefox", "192.0.2.1")
+	require.NoError(t, err)
+	assert.NotEmpty(t, session.CSRFToken)
+	assert.True(t, session.CheckCSRF(session.CSRFToken))
+	assert.False(t, session.CheckCSRF(""))
+
+	got, user, err := s.AuthenticateSession(token)
+	require.NoError(t, err)
+	assert.Equal(t, session.ID, got.ID)
+	assert.Equal(t, RoleAdmin, user.Role)
+	_, _, err = s.AuthenticateSession("wrong")
+	assert.Error(t, err)
+
+	_, bobToken, err := s.Login("bob", "battery staple", "", "")
+	require.NoError(t, err)
+	all, err := s.Sessions("")
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+	own, err := s.Sessions("bob")
+	require.NoError(t, err)
+	require.Len(t, own, 1)
+
+	// Changing a password ends the user's sessions
+	require.NoError(t, s.SetPassword("bob", "new battery"))
+	_, _, err = s.AuthenticateSession(bobToken)
+	assert.Error(t, err)
+
+	require.NoError(t, s.RevokeSession(session.ID))
+	_, _, err = s.AuthenticateSession(token)
+	assert.Error(t, err)
+	assert.Equal(t, apperrors.CodeSessionNotFound, apperrors.CodeOf(s.RevokeSession(session.ID)))
+}
+
+func TestSessionExpiry(t *testing.T) {
+	now := time.Now()
+	fresh := Session{CreatedAt: now, LastSeenAt: now}
+	assert.Equal(t, now.Add(SessionIdleTimeout), fresh.ExpiresAt())
+
+	old := Session{CreatedAt: now.Add(-SessionMaxAge + time.Hour), LastSeenAt: now}
+	assert.Equal(t, now.Add(time.Hour), old.ExpiresAt(), "sessions end at the max age however they're used")
+}
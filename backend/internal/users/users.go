@@ -0,0 +1,365 @@
+// Package users keeps a node's local user accounts for the API, so that on
+// a shared node, such as a family NAS running the host role, one person
+// can approve restores while others only view status.
+//
+// Accounts are kept in the config directory. While there are none the API
+// is open, as it always was; once one exists every call must authenticate.
+// The first account must be an admin, and the last admin can't be removed
+// or demoted, so the node can't be locked out of its own API.
+package users
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+// FileName is the accounts' file in the config directory
+const FileName = "users.json"
+
+// Role decides what a user may do through the API
+type Role string
+
+// Roles, from least to most privileged
+const (
+	// RoleViewer can read status, requests and history
+	RoleViewer Role = "viewer"
+	// RoleApprover can also approve, deny and veto requests
+	RoleApprover Role = "approver"
+	// RoleAdmin can do anything, including managing users
+	RoleAdmin Role = "admin"
+)
+
+// Roles lists every role, least privileged first
+var Roles = []Role{RoleViewer, RoleApprover, RoleAdmin}
+
+// ParseRole validates a role name
+func ParseRole(s string) (Role, error) {
+	r := Role(s)
+	if !slices.Contains(Roles, r) {
+		return "", apperrors.Newf(apperrors.CodeInvalidArgument, "unknown role %q (use viewer, approver or admin)", s)
+	}
+	return r, nil
+}
+
+// Allows reports whether r has at least the privileges of required
+func (r Role) Allows(required Role) bool {
+	return slices.Index(Roles, r) >= slices.Index(Roles, required)
+}
+
+// User is a local account. Only hashes of its password and token are kept.
+type User struct {
+	Name         string    `json:"name"`
+	Role         Role      `json:"role"`
+	PasswordHash string    `json:"password_hash,omitempty"` // Hex PBKDF2-SHA256 of the password
+	Salt         string    `json:"salt,omitempty"`
+	TokenHash    string    `json:"token_hash,omitempty"` // Hex SHA-256 of the API token
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// HasPassword reports whether the user can log in with a password
+func (u *User) HasPassword() bool { return u.PasswordHash != "" }
+
+// HasToken reports whether the user has an API token
+func (u *User) HasToken() bool { return u.TokenHash != "" }
+
+const (
+	pbkdf2Iterations = 600_000
+	minPasswordLen   = 8
+)
+
+var namePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{0,63}$`)
+
+// Store is the accounts file of a config directory. It is safe for
+// concurrent use within a process.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore returns the accounts of config directory dir
+func NewStore(dir string) *Store {
+	return &Store{path: filepath.Join(dir, FileName)}
+}
+
+// List returns every account, sorted by name
+func (s *Store) List() ([]User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Enabled reports whether any accounts exist, and so whether the API
+// requires authentication
+func (s *Store) Enabled() (bool, error) {
+	list, err := s.List()
+	return len(list) > 0, err
+}
+
+// Get returns the account called name
+func (s *Store) Get(name string) (*User, error) {
+	list, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		if list[i].Name == name {
+			return &list[i], nil
+		}
+	}
+	return nil, apperrors.ErrUserNotFound
+}
+
+// Add creates an account with a password, which may be empty for
+// token-only accounts, and returns its new API token. The first account
+// must be an admin.
+func (s *Store) Add(name string, role Role, password string) (string, error) {
+	if !namePattern.MatchString(name) {
+		return "", apperrors.Newf(apperrors.CodeInvalidArgument, "invalid user name %q: use letters, digits, '.', '_' or '-'", name)
+	}
+	if _, err := ParseRole(string(role)); err != nil {
+		return "", err
+	}
+
+	var token string
+	err := s.update(func(list []User) ([]User, error) {
+		for _, u := range list {
+			if u.Name == name {
+				return nil, apperrors.ErrUserExists
+			}
+		}
+		if len(list) == 0 && role != RoleAdmin {
+			return nil, apperrors.New(apperrors.CodeInvalidArgument, "the first user must be an admin, so the node can't be locked out")
+		}
+		now := time.Now().UTC()
+		u := User{Name: name, Role: role, CreatedAt: now, UpdatedAt: now}
+		if password != "" {
+			if err := u.setPassword(password); err != nil {
+				return nil, err
+			}
+		}
+		var err error
+		if token, err = u.newToken(); err != nil {
+			return nil, err
+		}
+		return append(list, u), nil
+	})
+	return token, err
+}
+
+// Remove deletes the account called name and ends its sessions
+func (s *Store) Remove(name string) error {
+	err := s.update(func(list []User) ([]User, error) {
+		i := slices.IndexFunc(list, func(u User) bool { return u.Name == name })
+		if i < 0 {
+			return nil, apperrors.ErrUserNotFound
+		}
+		if list[i].Role == RoleAdmin && countAdmins(list) == 1 && len(list) > 1 {
+			return nil, apperrors.ErrLastAdmin
+		}
+		return slices.Delete(list, i, i+1), nil
+	})
+	if err != nil {
+		return err
+	}
+	return s.revokeUserSessions(name)
+}
+
+// SetRole changes the role of the account called name
+func (s *Store) SetRole(name string, role Role) error {
+	if _, err := ParseRole(string(role)); err != nil {
+		return err
+	}
+	return s.modify(name, func(list []User, u *User) error {
+		if u.Role == RoleAdmin && role != RoleAdmin && countAdmins(list) == 1 {
+			return apperrors.ErrLastAdmin
+		}
+		u.Role = role
+		return nil
+	})
+}
+
+// SetPassword changes the password of the account called name and ends
+// its sessions
+func (s *Store) SetPassword(name, password string) error {
+	err := s.modify(name, func(_ []User, u *User) error {
+		return u.setPassword(password)
+	})
+	if err != nil {
+		return err
+	}
+	return s.revokeUserSessions(name)
+}
+
+// IssueToken gives the account called name a new API token, replacing
+// its old one, and returns it
+func (s *Store) IssueToken(name string) (string, error) {
+	var token string
+	err := s.modify(name, func(_ []User, u *User) error {
+		var err error
+		token, err = u.newToken()
+		return err
+	})
+	return token, err
+}
+
+// AuthenticateToken returns the account an API token belongs to
+func (s *Store) AuthenticateToken(token string) (*User, error) {
+	list, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	hash := hashToken(token)
+	for i := range list {
+		if list[i].HasToken() && subtle.ConstantTimeCompare([]byte(list[i].TokenHash), []byte(hash)) == 1 {
+			return &list[i], nil
+		}
+	}
+	return nil, apperrors.ErrUnauthenticated
+}
+
+// AuthenticatePassword returns the account called name if password is its
+// password
+func (s *Store) AuthenticatePassword(name, password string) (*User, error) {
+	u, err := s.Get(name)
+	if err != nil || !u.HasPassword() {
+		return nil, apperrors.ErrUnauthenticated
+	}
+	salt, err := hex.DecodeString(u.Salt)
+	if err != nil {
+		return nil, apperrors.ErrUnauthenticated
+	}
+	hash, err := hashPassword(password, salt)
+	if err != nil || subtle.ConstantTimeCompare([]byte(hash), []byte(u.PasswordHash)) != 1 {
+		return nil, apperrors.ErrUnauthenticated
+	}
+	return u, nil
+}
+
+// modify applies fn to the account called name and saves it
+func (s *Store) modify(name string, fn func(list []User, u *User) error) error {
+	return s.update(func(list []User) ([]User, error) {
+		i := slices.IndexFunc(list, func(u User) bool { return u.Name == name })
+		if i < 0 {
+			return nil, apperrors.ErrUserNotFound
+		}
+		if err := fn(list, &list[i]); err != nil {
+			return nil, err
+		}
+		list[i].UpdatedAt = time.Now().UTC()
+		return list, nil
+	})
+}
+
+// update loads the accounts, applies fn and saves the result
+func (s *Store) update(fn func([]User) ([]User, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := s.load()
+	if err != nil {
+		return err
+	}
+	if list, err = fn(list); err != nil {
+		return err
+	}
+	slices.SortFunc(list, func(a, b User) int {
+		switch {
+		case a.Name < b.Name:
+			return -1
+		case a.Name > b.Name:
+			return 1
+		}
+		return 0
+	})
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to save users: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) load() ([]User, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []User
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse users: %w", err)
+	}
+	return list, nil
+}
+
+func countAdmins(list []User) int {
+	n := 0
+	for _, u := range list {
+		if u.Role == RoleAdmin {
+			n++
+		}
+	}
+	return n
+}
+
+func (u *User) setPassword(password string) error {
+	if len(password) < minPasswordLen {
+		return apperrors.Newf(apperrors.CodeInvalidArgument, "password must be at least %d characters", minPasswordLen)
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	hash, err := hashPassword(password, salt)
+	if err != nil {
+		return err
+	}
+	u.Salt = hex.EncodeToString(salt)
+	u.PasswordHash = hash
+	return nil
+}
+
+// newToken gives u a new random API token and returns it
+func (u *User) newToken() (string, error) {
+	b, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+	token := "agt_" + b
+	u.TokenHash = hashToken(token)
+	return token, nil
+}
+
+func hashPassword(password string, salt []byte) (string, error) {
+	key, err := pbkdf2.Key(sha256.New, password, salt, pbkdf2Iterations, 32)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
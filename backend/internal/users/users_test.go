@@ -0,0 +1,101 @@
+package users
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apperrors "github.com/lcrostarosa/airgapper/backend/internal/errors"
+)
+
+func TestAddAndAuthenticate(t *testing.T) {
+	dir := t.TempDir()
+	s := NewStore(dir)
+	enabled, err := s.Enabled()
+	require.NoError(t, err)
+	assert.False(t, enabled)
+
+	_, err = s.Add("kid", RoleViewer, "")
+	assert.Error(t, err, "the first user must be an admin")
+
+	token, err := s.Add("alice", RoleAdmin, "correct horse")
+	require.NoError(t, err)
+	enabled, err = s.Enabled()
+	require.NoError(t, err)
+	assert.True(t, enabled)
+
+	info, err := os.Stat(filepath.Join(dir, FileName))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	u, err := s.AuthenticateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", u.Name)
+	_, err = s.AuthenticateToken("agt_wrong")
+	assert.Equal(t, apperrors.CodeUnauthenticated, apperrors.CodeOf(err))
+
+	u, err = s.AuthenticatePassword("alice", "correct horse")
+	require.NoError(t, err)
+	assert.Equal(t, RoleAdmin, u.Role)
+	_, err = s.AuthenticatePassword("alice", "wrong horse")
+	assert.Equal(t, apperrors.CodeUnauthenticated, apperrors.CodeOf(err))
+
+	_, err = s.Add("alice", RoleViewer, "")
+	assert.Equal(t, apperrors.CodeUserExists, apperrors.CodeOf(err))
+	_, err = s.Add("bad name", RoleViewer, "")
+	assert.Error(t, err)
+	_, err = s.Add("bob", RoleViewer, "short")
+	assert.Error(t, err)
+
+	// A token-only account can't log in with a password
+	_, err = s.Add("bob", RoleApprover, "")
+	require.NoError(t, err)
+	_, err = s.AuthenticatePassword("bob", "")
+	assert.Error(t, err)
+
+	// Rotating a token revokes the old one
+	newToken, err := s.IssueToken("alice")
+	require.NoError(t, err)
+	_, err = s.AuthenticateToken(token)
+	assert.Error(t, err)
+	_, err = s.AuthenticateToken(newToken)
+	assert.NoError(t, err)
+}
+
+func TestLastAdmin(t *testing.T) {
+	s := NewStore(t.TempDir())
+	_, err := s.Add("alice", RoleAdmin, "")
+	require.NoError(t, err)
+	_, err = s.Add("bob", RoleViewer, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, apperrors.CodeLastAdmin, apperrors.CodeOf(s.SetRole("alice", RoleApprover)))
+	assert.Equal(t, apperrors.CodeLastAdmin, apperrors.CodeOf(s.Remove("alice")))
+
+	require.NoError(t, s.SetRole("bob", RoleAdmin))
+	require.NoError(t, s.SetRole("alice", RoleApprover))
+	require.NoError(t, s.Remove("alice"))
+	assert.Equal(t, apperrors.CodeUserNotFound, apperrors.CodeOf(s.Remove("alice")))
+
+	// Removing the only account turns authentication off again
+	require.NoError(t, s.Remove("bob"))
+	enabled, err := s.Enabled()
+	require.NoError(t, err)
+	assert.False(t, enabled)
+}
+
+func TestRoleAllows(t *testing.T) {
+	assert.True(t, RoleAdmin.Allows(RoleApprover))
+	assert.True(t, RoleApprover.Allows(RoleViewer))
+	assert.False(t, RoleViewer.Allows(RoleApprover))
+	assert.False(t, Role("").Allows(RoleViewer))
+
+	_, err := ParseRole("owner")
+	assert.Error(t, err)
+	r, err := ParseRole("approver")
+	require.NoError(t, err)
+	assert.Equal(t, RoleApprover, r)
+}
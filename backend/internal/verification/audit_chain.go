@@ -25,6 +25,8 @@ type ChainedAuditEntry struct {
 	Details   string    `json:"details,omitempty"`
 	Success   bool      `json:"success"`
 	Error     string    `json:"error,omitempty"`
+	// CorrelationID ties the entry to the peer request that caused it
+	CorrelationID string `json:"correlation_id,omitempty"`
 
 	// Chaining fields
 	PreviousHash  string `json:"previous_hash"`  // SHA256 of previous entry
@@ -161,22 +163,29 @@ func (ac *AuditChain) save() error {
 
 // Record adds a new entry to the audit chain.
 func (ac *AuditChain) Record(operation, path, details string, success bool, errMsg string) (*ChainedAuditEntry, error) {
+	return ac.RecordCorrelated("", operation, path, details, success, errMsg)
+}
+
+// RecordCorrelated adds a new entry to the audit chain tagged with the
+// correlation ID of the request that caused it.
+func (ac *AuditChain) RecordCorrelated(correlationID, operation, path, details string, success bool, errMsg string) (*ChainedAuditEntry, error) {
 	ac.mu.Lock()
 	defer ac.mu.Unlock()
 
 	ac.sequence++
 
 	entry := ChainedAuditEntry{
-		ID:           generateEntryID(ac.sequence),
-		Sequence:     ac.sequence,
-		Timestamp:    time.Now(),
-		Operation:    operation,
-		Path:         path,
-		Details:      details,
-		Success:      success,
-		Error:        errMsg,
-		PreviousHash: ac.lastHash,
-		HostKeyID:    ac.hostKeyID,
+		ID:            generateEntryID(ac.sequence),
+		Sequence:      ac.sequence,
+		Timestamp:     time.Now(),
+		Operation:     operation,
+		Path:          path,
+		Details:       details,
+		Success:       success,
+		Error:         errMsg,
+		CorrelationID: correlationID,
+		PreviousHash:  ac.lastHash,
+		HostKeyID:     ac.hostKeyID,
 	}
 
 	// Compute content hash (excluding signature)
@@ -216,27 +225,30 @@ func (ac *AuditChain) Record(operation, path, details string, success bool, errM
 func (ac *AuditChain) computeContentHash(entry *ChainedAuditEntry) (string, error) {
 	// Create canonical structure for hashing (excludes signature)
 	hashData := struct {
-		ID           string `json:"id"`
-		Sequence     uint64 `json:"sequence"`
-		Timestamp    int64  `json:"timestamp"`
-		Operation    string `json:"operation"`
-		Path         string `json:"path"`
-		Details      string `json:"details"`
-		Success      bool   `json:"success"`
-		Error        string `json:"error"`
-		PreviousHash string `json:"previous_hash"`
-		HostKeyID    string `json:"host_key_id"`
+		ID        string `json:"id"`
+		Sequence  uint64 `json:"sequence"`
+		Timestamp int64  `json:"timestamp"`
+		Operation string `json:"operation"`
+		Path      string `json:"path"`
+		Details   string `json:"details"`
+		Success   bool   `json:"success"`
+		Error     string `json:"error"`
+		// Omitted when empty so entries from before correlation IDs still verify
+		CorrelationID string `json:"correlation_id,omitempty"`
+		PreviousHash  string `json:"previous_hash"`
+		HostKeyID     string `json:"host_key_id"`
 	}{
-		ID:           entry.ID,
-		Sequence:     entry.Sequence,
-		Timestamp:    entry.Timestamp.Unix(),
-		Operation:    entry.Operation,
-		Path:         entry.Path,
-		Details:      entry.Details,
-		Success:      entry.Success,
-		Error:        entry.Error,
-		PreviousHash: entry.PreviousHash,
-		HostKeyID:    entry.HostKeyID,
+		ID:            entry.ID,
+		Sequence:      entry.Sequence,
+		Timestamp:     entry.Timestamp.Unix(),
+		Operation:     entry.Operation,
+		Path:          entry.Path,
+		Details:       entry.Details,
+		Success:       entry.Success,
+		Error:         entry.Error,
+		CorrelationID: entry.CorrelationID,
+		PreviousHash:  entry.PreviousHash,
+		HostKeyID:     entry.HostKeyID,
 	}
 
 	data, err := json.Marshal(hashData)
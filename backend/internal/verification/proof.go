@@ -0,0 +1,188 @@
+package verification
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+)
+
+// Limits on a proof-of-storage challenge, so answering one stays cheap
+const (
+	MaxProofRanges      = 64
+	MaxProofRangeLength = 1 << 20 // 1 MiB
+)
+
+// proofFileID matches restic file names (SHA-256 of the file contents)
+var proofFileID = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// ProofRange is a byte range of a repository file the host must prove it
+// still holds. Type is the restic file type: "data" (pack files) or "index".
+type ProofRange struct {
+	Type   string `json:"type"`
+	ID     string `json:"id"`
+	Offset int64  `json:"offset"`
+	Length int64  `json:"length"`
+}
+
+// ProofChallenge asks the host to hash random ranges of stored files. The
+// nonce is mixed into every hash so answers can't be precomputed.
+type ProofChallenge struct {
+	Repo   string       `json:"repo"`
+	Nonce  string       `json:"nonce"` // hex-encoded, 32 random bytes
+	Ranges []ProofRange `json:"ranges"`
+}
+
+// ProofResult is the host's answer for one range
+type ProofResult struct {
+	ProofRange
+	Hash  string `json:"hash,omitempty"` // hex SHA-256 of nonce || range bytes
+	Error string `json:"error,omitempty"`
+}
+
+// StorageProof is the host's signed answer to a challenge
+type StorageProof struct {
+	Repo      string        `json:"repo"`
+	Nonce     string        `json:"nonce"`
+	Results   []ProofResult `json:"results"`
+	HostKeyID string        `json:"host_key_id"`
+	CreatedAt time.Time     `json:"created_at"`
+	Signature string        `json:"signature"`
+}
+
+// NewProofChallenge creates a challenge with a fresh random nonce
+func NewProofChallenge(repo string, ranges []ProofRange) (*ProofChallenge, error) {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	c := &ProofChallenge{Repo: repo, Nonce: hex.EncodeToString(nonce), Ranges: ranges}
+	return c, c.Validate()
+}
+
+// Validate checks a challenge is well formed and within the limits
+func (c *ProofChallenge) Validate() error {
+	if c.Repo == "" {
+		return errors.New("repository name required")
+	}
+	if nonce, err := hex.DecodeString(c.Nonce); err != nil || len(nonce) < 16 {
+		return errors.New("nonce must be at least 16 hex-encoded bytes")
+	}
+	if len(c.Ranges) == 0 || len(c.Ranges) > MaxProofRanges {
+		return fmt.Errorf("challenge must have 1-%d ranges", MaxProofRanges)
+	}
+	for i, r := range c.Ranges {
+		if r.Type != "data" && r.Type != "index" {
+			return fmt.Errorf("range %d: type must be data or index", i)
+		}
+		if !proofFileID.MatchString(r.ID) {
+			return fmt.Errorf("range %d: invalid file ID", i)
+		}
+		if r.Offset < 0 || r.Length <= 0 || r.Length > MaxProofRangeLength {
+			return fmt.Errorf("range %d: length must be 1-%d bytes at a non-negative offset", i, MaxProofRangeLength)
+		}
+	}
+	return nil
+}
+
+// ProofHash hashes a range's bytes with the challenge nonce
+func ProofHash(nonce string, data []byte) string {
+	h := sha256.New()
+	h.Write([]byte(nonce))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// computeProofHash creates a deterministic hash of the proof content
+func computeProofHash(p *StorageProof) ([]byte, error) {
+	hashData := struct {
+		Repo      string        `json:"repo"`
+		Nonce     string        `json:"nonce"`
+		Results   []ProofResult `json:"results"`
+		HostKeyID string        `json:"host_key_id"`
+		CreatedAt int64         `json:"created_at"`
+	}{
+		Repo:      p.Repo,
+		Nonce:     p.Nonce,
+		Results:   p.Results,
+		HostKeyID: p.HostKeyID,
+		CreatedAt: p.CreatedAt.Unix(),
+	}
+
+	data, err := json.Marshal(hashData)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(data)
+	return hash[:], nil
+}
+
+// Sign signs the proof with the host's key
+func (p *StorageProof) Sign(hostPrivateKey, hostPublicKey []byte) error {
+	p.HostKeyID = crypto.KeyID(hostPublicKey)
+	hash, err := computeProofHash(p)
+	if err != nil {
+		return err
+	}
+	sig, err := crypto.Sign(hostPrivateKey, hash)
+	if err != nil {
+		return fmt.Errorf("failed to sign proof: %w", err)
+	}
+	p.Signature = hex.EncodeToString(sig)
+	return nil
+}
+
+// RangeReader reads the owner's own copy of a challenged range
+type RangeReader func(r ProofRange) ([]byte, error)
+
+// Verify checks the proof answers this challenge, is signed by the host
+// and that every hash matches the owner's own copy of the range.
+func (c *ProofChallenge) Verify(p *StorageProof, hostPublicKey []byte, read RangeReader) error {
+	if p.HostKeyID != crypto.KeyID(hostPublicKey) {
+		return fmt.Errorf("proof signed by unknown key %s", p.HostKeyID)
+	}
+	hash, err := computeProofHash(p)
+	if err != nil {
+		return err
+	}
+	sig, err := hex.DecodeString(p.Signature)
+	if err != nil || !crypto.Verify(hostPublicKey, hash, sig) {
+		return errors.New("invalid host signature on proof")
+	}
+	if p.Repo != c.Repo || p.Nonce != c.Nonce {
+		return errors.New("proof does not answer this challenge")
+	}
+	if len(p.Results) != len(c.Ranges) {
+		return fmt.Errorf("proof has %d results for %d ranges", len(p.Results), len(c.Ranges))
+	}
+
+	var failures []string
+	for i, want := range c.Ranges {
+		got := p.Results[i]
+		if got.ProofRange != want {
+			return fmt.Errorf("result %d answers a different range", i)
+		}
+		if got.Error != "" {
+			failures = append(failures, fmt.Sprintf("%s/%s: host error: %s", want.Type, want.ID[:12], got.Error))
+			continue
+		}
+		data, err := read(want)
+		if err != nil {
+			return fmt.Errorf("failed to read local copy of %s/%s: %w", want.Type, want.ID[:12], err)
+		}
+		if got.Hash != ProofHash(c.Nonce, data) {
+			failures = append(failures, fmt.Sprintf("%s/%s: hash mismatch at offset %d", want.Type, want.ID[:12], want.Offset))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d ranges failed: %s", len(failures), len(c.Ranges), strings.Join(failures, "; "))
+	}
+	return nil
+}
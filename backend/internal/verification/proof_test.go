@@ -0,0 +1,125 @@
+package verification
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProofChallenge_Validate(t *testing.T) {
+	id := strings.Repeat("ab", 32)
+
+	_, err := NewProofChallenge("repo", []ProofRange{{Type: "data", ID: id, Offset: 0, Length: 100}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		r     ProofRange
+		valid bool
+	}{
+		{"index file", ProofRange{Type: "index", ID: id, Offset: 10, Length: 1}, true},
+		{"other type", ProofRange{Type: "keys", ID: id, Length: 1}, false},
+		{"short id", ProofRange{Type: "data", ID: "abcd", Length: 1}, false},
+		{"path in id", ProofRange{Type: "data", ID: "../" + id[3:], Length: 1}, false},
+		{"negative offset", ProofRange{Type: "data", ID: id, Offset: -1, Length: 1}, false},
+		{"empty range", ProofRange{Type: "data", ID: id}, false},
+		{"too long", ProofRange{Type: "data", ID: id, Length: MaxProofRangeLength + 1}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &ProofChallenge{Repo: "repo", Nonce: strings.Repeat("00", 32), Ranges: []ProofRange{tt.r}}
+			if tt.valid {
+				assert.NoError(t, c.Validate())
+			} else {
+				assert.Error(t, c.Validate())
+			}
+		})
+	}
+
+	c := &ProofChallenge{Repo: "repo", Nonce: "abcd", Ranges: []ProofRange{{Type: "data", ID: id, Length: 1}}}
+	assert.Error(t, c.Validate(), "nonce too short")
+}
+
+func TestProofChallenge_Verify(t *testing.T) {
+	hostPub, hostPriv, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+	otherPub, otherPriv, err := crypto.GenerateKeyPair()
+	require.NoError(t, err)
+
+	files := map[string][]byte{
+		strings.Repeat("aa", 32): []byte("encrypted pack contents"),
+		strings.Repeat("bb", 32): []byte("encrypted index contents"),
+	}
+	read := func(r ProofRange) ([]byte, error) {
+		data, ok := files[r.ID]
+		if !ok || r.Offset+r.Length > int64(len(data)) {
+			return nil, errors.New("not found")
+		}
+		return data[r.Offset : r.Offset+r.Length], nil
+	}
+
+	c, err := NewProofChallenge("repo", []ProofRange{
+		{Type: "data", ID: strings.Repeat("aa", 32), Offset: 10, Length: 4},
+		{Type: "index", ID: strings.Repeat("bb", 32), Offset: 0, Length: 9},
+	})
+	require.NoError(t, err)
+
+	answer := func(files map[string][]byte) *StorageProof {
+		p := &StorageProof{Repo: c.Repo, Nonce: c.Nonce, CreatedAt: time.Now()}
+		for _, r := range c.Ranges {
+			data := files[r.ID][r.Offset : r.Offset+r.Length]
+			p.Results = append(p.Results, ProofResult{ProofRange: r, Hash: ProofHash(c.Nonce, data)})
+		}
+		return p
+	}
+
+	t.Run("valid proof", func(t *testing.T) {
+		p := answer(files)
+		require.NoError(t, p.Sign(hostPriv, hostPub))
+		assert.NoError(t, c.Verify(p, hostPub, read))
+	})
+
+	t.Run("wrong data", func(t *testing.T) {
+		p := answer(map[string][]byte{
+			strings.Repeat("aa", 32): []byte("something else entirely"),
+			strings.Repeat("bb", 32): files[strings.Repeat("bb", 32)],
+		})
+		require.NoError(t, p.Sign(hostPriv, hostPub))
+		err := c.Verify(p, hostPub, read)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "1 of 2 ranges failed")
+	})
+
+	t.Run("host error", func(t *testing.T) {
+		p := answer(files)
+		p.Results[1].Hash, p.Results[1].Error = "", "file not found"
+		require.NoError(t, p.Sign(hostPriv, hostPub))
+		assert.ErrorContains(t, c.Verify(p, hostPub, read), "file not found")
+	})
+
+	t.Run("signed by another key", func(t *testing.T) {
+		p := answer(files)
+		require.NoError(t, p.Sign(otherPriv, otherPub))
+		assert.ErrorContains(t, c.Verify(p, hostPub, read), "unknown key")
+	})
+
+	t.Run("tampered after signing", func(t *testing.T) {
+		p := answer(files)
+		require.NoError(t, p.Sign(hostPriv, hostPub))
+		p.Results[0].Offset = 0
+		assert.ErrorContains(t, c.Verify(p, hostPub, read), "invalid host signature")
+	})
+
+	t.Run("replayed for another challenge", func(t *testing.T) {
+		p := answer(files)
+		require.NoError(t, p.Sign(hostPriv, hostPub))
+		other, err := NewProofChallenge("repo", c.Ranges)
+		require.NoError(t, err)
+		assert.ErrorContains(t, other.Verify(p, hostPub, read), "does not answer")
+	})
+}
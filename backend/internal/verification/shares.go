@@ -0,0 +1,174 @@
+package verification
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Share checks let the owner and host prove to each other that they still
+// hold the key shares they agreed on, without sending them. Each side keys
+// an HMAC of a fresh nonce with its share's fingerprint; the other side
+// recomputes it from the fingerprint it recorded (or, for the owner, from
+// the host share it can derive from the password).
+const (
+	// ShareCheckInterval is how often the owner re-checks shares
+	ShareCheckInterval = 30 * 24 * time.Hour
+
+	// ShareCheckMaxSkew is how old a challenge may be when the host answers it
+	ShareCheckMaxSkew = 5 * time.Minute
+)
+
+// ErrShareMismatch means a peer's share is not the one recorded for it, so
+// the two shares would not combine to the repository password
+var ErrShareMismatch = errors.New("shares mismatch")
+
+// ShareFingerprint identifies a share without revealing it: the SHA-256 of
+// a domain tag, the share index and the share data
+func ShareFingerprint(index byte, share []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte("airgapper-share-check-v1"))
+	h.Write([]byte{index})
+	h.Write(share)
+	return h.Sum(nil)
+}
+
+// shareProof is the HMAC a side sends to prove it holds the share behind
+// fingerprint
+func shareProof(fingerprint []byte, role string, index byte, nonce string, at time.Time) string {
+	mac := hmac.New(sha256.New, fingerprint)
+	mac.Write([]byte(role))
+	mac.Write([]byte{index})
+	mac.Write([]byte(nonce))
+	mac.Write([]byte(strconv.FormatInt(at.Unix(), 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ShareChallenge is the owner's half of a share check
+type ShareChallenge struct {
+	OwnerIndex byte      `json:"owner_index"`
+	Nonce      string    `json:"nonce"` // hex-encoded, 32 random bytes
+	Time       time.Time `json:"time"`
+	OwnerProof string    `json:"owner_proof"`
+	// OwnerFingerprint enrolls the owner's share with a host that has not
+	// recorded it yet. It is only sent on the first check.
+	OwnerFingerprint string `json:"owner_fingerprint,omitempty"`
+}
+
+// ShareAnswer is the host's half of a share check
+type ShareAnswer struct {
+	HostIndex byte   `json:"host_index"`
+	HostProof string `json:"host_proof"`
+	// OwnerEnrolled is false when the host has no fingerprint for the
+	// owner's share, so the owner should retry with one
+	OwnerEnrolled bool `json:"owner_enrolled"`
+	// OwnerVerified reports whether the owner's proof matched
+	OwnerVerified bool `json:"owner_verified"`
+}
+
+// NewShareChallenge creates a challenge proving the owner holds share.
+// With enroll set, the share's fingerprint is included for the host to record.
+func NewShareChallenge(index byte, share []byte, enroll bool) (*ShareChallenge, error) {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	c := &ShareChallenge{
+		OwnerIndex: index,
+		Nonce:      hex.EncodeToString(nonce),
+		Time:       time.Now().UTC().Truncate(time.Second),
+	}
+	fingerprint := ShareFingerprint(index, share)
+	c.OwnerProof = shareProof(fingerprint, "owner", index, c.Nonce, c.Time)
+	if enroll {
+		c.OwnerFingerprint = hex.EncodeToString(fingerprint)
+	}
+	return c, nil
+}
+
+// Validate checks a challenge is well formed and recent
+func (c *ShareChallenge) Validate(now time.Time) error {
+	if c.OwnerIndex == 0 {
+		return errors.New("owner share index required")
+	}
+	if nonce, err := hex.DecodeString(c.Nonce); err != nil || len(nonce) < 16 {
+		return errors.New("nonce must be at least 16 hex-encoded bytes")
+	}
+	if age := now.Sub(c.Time); age > ShareCheckMaxSkew || age < -ShareCheckMaxSkew {
+		return fmt.Errorf("challenge time is %s away from ours", age.Round(time.Second))
+	}
+	if c.OwnerFingerprint != "" {
+		if fp, err := hex.DecodeString(c.OwnerFingerprint); err != nil || len(fp) != sha256.Size {
+			return errors.New("invalid owner fingerprint")
+		}
+	}
+	return nil
+}
+
+// VerifyOwner checks the owner's proof against the fingerprint the host recorded
+func (c *ShareChallenge) VerifyOwner(ownerFingerprint []byte) error {
+	want := shareProof(ownerFingerprint, "owner", c.OwnerIndex, c.Nonce, c.Time)
+	if !hmac.Equal([]byte(want), []byte(c.OwnerProof)) {
+		return fmt.Errorf("%w: owner's share %d is not the one recorded at enrollment", ErrShareMismatch, c.OwnerIndex)
+	}
+	return nil
+}
+
+// Answer proves to the owner that the host holds share
+func (c *ShareChallenge) Answer(index byte, share []byte, ownerEnrolled, ownerVerified bool) *ShareAnswer {
+	return &ShareAnswer{
+		HostIndex:     index,
+		HostProof:     shareProof(ShareFingerprint(index, share), "host", index, c.Nonce, c.Time),
+		OwnerEnrolled: ownerEnrolled,
+		OwnerVerified: ownerVerified,
+	}
+}
+
+// VerifyHost checks the host's proof against the fingerprint the owner
+// expects for the host's share
+func (c *ShareChallenge) VerifyHost(a *ShareAnswer, hostFingerprint []byte) error {
+	want := shareProof(hostFingerprint, "host", a.HostIndex, c.Nonce, c.Time)
+	if !hmac.Equal([]byte(want), []byte(a.HostProof)) {
+		return fmt.Errorf("%w: host's share %d would not combine with ours to the repository password", ErrShareMismatch, a.HostIndex)
+	}
+	if !a.OwnerVerified {
+		return fmt.Errorf("%w: host did not accept our share %d", ErrShareMismatch, c.OwnerIndex)
+	}
+	return nil
+}
+
+// ShareCheckState is the outcome of the last share check, kept by both
+// sides. On the host, PeerFingerprint is the owner's share fingerprint
+// recorded at enrollment; on the owner it is the host's, recorded at init.
+type ShareCheckState struct {
+	PeerFingerprint string    `json:"peer_fingerprint,omitempty"`
+	LastChecked     time.Time `json:"last_checked,omitempty"`
+	LastVerified    time.Time `json:"last_verified,omitempty"`
+	LastError       string    `json:"last_error,omitempty"`
+}
+
+// Record stores the result of a check made at now
+func (s *ShareCheckState) Record(now time.Time, err error) {
+	s.LastChecked = now
+	if err != nil {
+		s.LastError = err.Error()
+		return
+	}
+	s.LastVerified = now
+	s.LastError = ""
+}
+
+// Due reports whether a check is due (nil-safe: a never-checked pair is due)
+func (s *ShareCheckState) Due(now time.Time, interval time.Duration) bool {
+	return s == nil || s.LastChecked.IsZero() || now.Sub(s.LastChecked) >= interval
+}
+
+// Mismatched reports whether the last check found mismatched shares (nil-safe)
+func (s *ShareCheckState) Mismatched() bool {
+	return s != nil && s.LastError != ""
+}
@@ -0,0 +1,83 @@
+package verification
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/sss"
+)
+
+func TestShareCheck(t *testing.T) {
+	shares, err := sss.Split([]byte("0123456789abcdef0123456789abcdef"), 2, 2)
+	require.NoError(t, err)
+	owner, host := shares[0], shares[1]
+	ownerFP := ShareFingerprint(owner.Index, owner.Data)
+	hostFP := ShareFingerprint(host.Index, host.Data)
+
+	c, err := NewShareChallenge(owner.Index, owner.Data, true)
+	require.NoError(t, err)
+	require.NoError(t, c.Validate(time.Now()))
+	assert.NotContains(t, c.OwnerProof, string(owner.Data))
+
+	// Host side: owner's proof matches the enrolled fingerprint
+	require.NoError(t, c.VerifyOwner(ownerFP))
+	a := c.Answer(host.Index, host.Data, true, true)
+
+	// Owner side: host's proof matches
+	require.NoError(t, c.VerifyHost(a, hostFP))
+
+	// A host holding a different share fails
+	wrong := c.Answer(host.Index, []byte("not the share at all, not at all"), true, true)
+	assert.ErrorIs(t, c.VerifyHost(wrong, hostFP), ErrShareMismatch)
+
+	// An owner whose share changed fails on the host
+	assert.ErrorIs(t, c.VerifyOwner(hostFP), ErrShareMismatch)
+
+	// A host that rejected the owner fails the check even with a good proof
+	assert.ErrorIs(t, c.VerifyHost(c.Answer(host.Index, host.Data, true, false), hostFP), ErrShareMismatch)
+
+	// A proof for another nonce doesn't answer this challenge
+	other, err := NewShareChallenge(owner.Index, owner.Data, false)
+	require.NoError(t, err)
+	assert.Empty(t, other.OwnerFingerprint)
+	assert.ErrorIs(t, c.VerifyHost(other.Answer(host.Index, host.Data, true, true), hostFP), ErrShareMismatch)
+}
+
+func TestShareChallengeValidate(t *testing.T) {
+	c, err := NewShareChallenge(1, []byte("share"), false)
+	require.NoError(t, err)
+
+	assert.Error(t, c.Validate(time.Now().Add(time.Hour)), "stale challenge")
+
+	bad := *c
+	bad.Nonce = "00"
+	assert.Error(t, bad.Validate(time.Now()))
+
+	bad = *c
+	bad.OwnerIndex = 0
+	assert.Error(t, bad.Validate(time.Now()))
+
+	bad = *c
+	bad.OwnerFingerprint = "zz"
+	assert.Error(t, bad.Validate(time.Now()))
+}
+
+func TestShareCheckState(t *testing.T) {
+	var s *ShareCheckState
+	now := time.Now()
+	assert.True(t, s.Due(now, ShareCheckInterval))
+	assert.False(t, s.Mismatched())
+
+	s = &ShareCheckState{}
+	s.Record(now, nil)
+	assert.False(t, s.Due(now.Add(time.Hour), ShareCheckInterval))
+	assert.True(t, s.Due(now.Add(ShareCheckInterval), ShareCheckInterval))
+	assert.Equal(t, now, s.LastVerified)
+
+	s.Record(now.Add(time.Hour), ErrShareMismatch)
+	assert.True(t, s.Mismatched())
+	assert.Equal(t, now, s.LastVerified)
+}
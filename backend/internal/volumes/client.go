@@ -0,0 +1,236 @@
+package volumes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// requestTimeout bounds API calls other than the export stream
+const requestTimeout = 30 * time.Second
+
+// helperMount is where the helper container mounts the exported volume
+const helperMount = "/volume"
+
+// Client talks to the Docker-compatible API on a unix socket
+type Client struct {
+	socket string
+	http   *http.Client
+}
+
+var _ Engine = (*Client)(nil)
+
+// NewClient returns a client for the engine listening on socket
+func NewClient(socket string) *Client {
+	socket = strings.TrimPrefix(socket, "unix://")
+	return &Client{
+		socket: socket,
+		http: &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}},
+	}
+}
+
+// apiError is the engine's error body
+type apiError struct {
+	Message string `json:"message"`
+}
+
+// do sends a request to the engine and returns the response if its status
+// is one of ok. Other responses are closed and returned as errors.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body any, ok ...int) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	u := url.URL{Scheme: "http", Host: "engine", Path: path, RawQuery: query.Encode()}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("container engine at %s: %w", c.socket, err)
+	}
+	for _, status := range ok {
+		if resp.StatusCode == status {
+			return resp, nil
+		}
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var apiErr apiError
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&apiErr); err != nil || apiErr.Message == "" {
+		apiErr.Message = resp.Status
+	}
+	return nil, &statusError{status: resp.StatusCode, message: apiErr.Message}
+}
+
+// statusError is an error response from the engine
+type statusError struct {
+	status  int
+	message string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("container engine: %s", e.message)
+}
+
+// Volumes lists the named volumes
+func (c *Client) Volumes(ctx context.Context) ([]Volume, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	resp, err := c.do(ctx, http.MethodGet, "/volumes", nil, nil, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var body struct {
+		Volumes []struct {
+			Name       string            `json:"Name"`
+			Driver     string            `json:"Driver"`
+			Mountpoint string            `json:"Mountpoint"`
+			Labels     map[string]string `json:"Labels"`
+		} `json:"Volumes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to parse volume list: %w", err)
+	}
+
+	vols := make([]Volume, 0, len(body.Volumes))
+	for _, v := range body.Volumes {
+		vols = append(vols, Volume{Name: v.Name, Driver: v.Driver, Mountpoint: v.Mountpoint, Labels: v.Labels})
+	}
+	return vols, nil
+}
+
+// Export creates a stopped container of image with the volume mounted
+// read-only and streams the volume's contents out of it as a tar archive.
+// The image is pulled if it isn't present. Closing the stream removes the
+// container.
+func (c *Client) Export(ctx context.Context, volume, image string) (io.ReadCloser, error) {
+	id, err := c.createHelper(ctx, volume, image)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(ctx, http.MethodGet, "/containers/"+id+"/archive", url.Values{"path": {helperMount + "/."}}, nil, http.StatusOK)
+	if err != nil {
+		c.removeHelper(id)
+		return nil, fmt.Errorf("failed to export volume: %w", err)
+	}
+	return &export{ReadCloser: resp.Body, remove: func() { c.removeHelper(id) }}, nil
+}
+
+// createHelper creates the export container, pulling the image if needed
+func (c *Client) createHelper(ctx context.Context, volume, image string) (string, error) {
+	create := func() (string, error) {
+		ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+		defer cancel()
+
+		body := map[string]any{
+			"Image":  image,
+			"Cmd":    []string{"true"},
+			"Labels": map[string]string{"airgapper.helper": "volume-export"},
+			"HostConfig": map[string]any{
+				"Binds":       []string{volume + ":" + helperMount + ":ro"},
+				"NetworkMode": "none",
+			},
+		}
+		resp, err := c.do(ctx, http.MethodPost, "/containers/create", nil, body, http.StatusCreated)
+		if err != nil {
+			return "", err
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		var created struct {
+			ID string `json:"Id"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&created); err != nil || created.ID == "" {
+			return "", fmt.Errorf("failed to parse created container")
+		}
+		return created.ID, nil
+	}
+
+	id, err := create()
+	var se *statusError
+	if errors.As(err, &se) && se.status == http.StatusNotFound {
+		if err := c.pull(ctx, image); err != nil {
+			return "", err
+		}
+		id, err = create()
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to create helper container: %w", err)
+	}
+	return id, nil
+}
+
+// pull pulls an image, reading the progress stream to the end
+func (c *Client) pull(ctx context.Context, image string) error {
+	resp, err := c.do(ctx, http.MethodPost, "/images/create", url.Values{"fromImage": {image}}, nil, http.StatusOK)
+	if err != nil {
+		return fmt.Errorf("failed to pull %s: %w", image, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// Pull failures arrive as an error message in the progress stream
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var msg struct {
+			Error string `json:"error"`
+		}
+		if err := dec.Decode(&msg); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to pull %s: %w", image, err)
+		}
+		if msg.Error != "" {
+			return fmt.Errorf("failed to pull %s: %s", image, msg.Error)
+		}
+	}
+}
+
+// removeHelper removes an export container. It uses its own context so the
+// container is removed even when the backup was cancelled.
+func (c *Client) removeHelper(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	resp, err := c.do(ctx, http.MethodDelete, "/containers/"+id, url.Values{"force": {"true"}}, nil, http.StatusNoContent, http.StatusNotFound)
+	if err == nil {
+		_ = resp.Body.Close()
+	}
+}
+
+// export is an export stream that removes its container when closed
+type export struct {
+	io.ReadCloser
+	remove func()
+}
+
+func (e *export) Close() error {
+	err := e.ReadCloser.Close()
+	e.remove()
+	return err
+}
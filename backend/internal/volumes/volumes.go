@@ -0,0 +1,259 @@
+// Package volumes backs up Docker and Podman named volumes. Volumes are
+// listed through the engine's API socket (Podman serves the same
+// Docker-compatible API) and each selected volume is stored as its own
+// snapshot, tagged with the volume's name, either by reading its directory
+// on the host or by exporting it through a temporary helper container.
+package volumes
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+// Mode is how a volume's data is read
+type Mode string
+
+// Modes
+const (
+	// ModePath backs up the volume's mountpoint on the host. It needs read
+	// access to the engine's data directory (usually root).
+	ModePath Mode = "path"
+	// ModeArchive exports the volume as a tar stream through a temporary
+	// helper container, for engines whose data directory isn't readable
+	// (rootless Podman, Docker Desktop, remote sockets)
+	ModeArchive Mode = "archive"
+)
+
+// DefaultHelperImage is the image the archive mode's temporary containers use
+const DefaultHelperImage = "docker.io/library/busybox:latest"
+
+// ExcludeLabel, set to "true" on a volume, leaves it out of backups
+// whatever the include patterns say
+const ExcludeLabel = "airgapper.exclude"
+
+// TagPrefix prefixes the volume name in each volume snapshot's tags
+const TagPrefix = "volume:"
+
+// Config selects the volumes backed up with each scheduled backup
+type Config struct {
+	Enabled bool `json:"enabled"`
+	// Socket is the engine's API socket (default: DefaultSocket)
+	Socket string `json:"socket,omitempty"`
+	Mode   Mode   `json:"mode,omitempty"`
+	// HelperImage is the image used in archive mode (default: DefaultHelperImage)
+	HelperImage string `json:"helper_image,omitempty"`
+	// Include and Exclude are glob patterns matched against volume names.
+	// No includes selects every volume; excludes win over includes.
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+	// Tags are added to every volume snapshot
+	Tags []string `json:"tags,omitempty"`
+	// Concurrency is how many volumes are backed up at once (default 1).
+	// Restic takes a shared lock for backups, so several can run against
+	// the same repository.
+	Concurrency int `json:"concurrency,omitempty"`
+}
+
+// Validate checks that the config is usable
+func (c *Config) Validate() error {
+	switch c.Mode {
+	case "", ModePath, ModeArchive:
+	default:
+		return fmt.Errorf("unknown volume backup mode %q (supported: %s, %s)", c.Mode, ModePath, ModeArchive)
+	}
+	if c.Socket != "" && !filepath.IsAbs(strings.TrimPrefix(c.Socket, "unix://")) {
+		return fmt.Errorf("volume socket %q must be an absolute path", c.Socket)
+	}
+	for _, p := range append(slices.Clone(c.Include), c.Exclude...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid volume pattern %q: %w", p, err)
+		}
+	}
+	for _, tag := range c.Tags {
+		if err := restic.ValidateTag(tag); err != nil {
+			return fmt.Errorf("volume tags: %w", err)
+		}
+	}
+	if c.Concurrency < 0 {
+		return fmt.Errorf("volume concurrency must not be negative")
+	}
+	return nil
+}
+
+// GetMode returns the configured mode, defaulting to ModePath
+func (c *Config) GetMode() Mode {
+	if c.Mode == "" {
+		return ModePath
+	}
+	return c.Mode
+}
+
+// GetConcurrency returns the configured concurrency, at least 1
+func (c *Config) GetConcurrency() int {
+	return max(c.Concurrency, 1)
+}
+
+// GetHelperImage returns the configured helper image, or DefaultHelperImage
+func (c *Config) GetHelperImage() string {
+	if c.HelperImage == "" {
+		return DefaultHelperImage
+	}
+	return c.HelperImage
+}
+
+// GetSocket returns the configured socket, or DefaultSocket
+func (c *Config) GetSocket() string {
+	if c.Socket == "" {
+		return DefaultSocket()
+	}
+	return strings.TrimPrefix(c.Socket, "unix://")
+}
+
+// Selected reports whether the volume is backed up: its name matches the
+// include patterns and none of the exclude patterns, and it isn't labelled
+// with ExcludeLabel
+func (c *Config) Selected(v Volume) bool {
+	match := func(patterns []string) bool {
+		return slices.ContainsFunc(patterns, func(p string) bool {
+			ok, _ := path.Match(p, v.Name)
+			return ok
+		})
+	}
+	if v.Labels[ExcludeLabel] == "true" || match(c.Exclude) {
+		return false
+	}
+	return len(c.Include) == 0 || match(c.Include)
+}
+
+// DefaultSocket finds the engine's API socket: DOCKER_HOST if it names a
+// unix socket, then Docker's socket, then rootless and rootful Podman's
+func DefaultSocket() string {
+	if host, ok := strings.CutPrefix(os.Getenv("DOCKER_HOST"), "unix://"); ok {
+		return host
+	}
+	candidates := []string{"/var/run/docker.sock"}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		candidates = append(candidates, filepath.Join(dir, "podman", "podman.sock"))
+	}
+	candidates = append(candidates, "/run/podman/podman.sock")
+	for _, c := range candidates {
+		if _, err := os.Stat(c); err == nil {
+			return c
+		}
+	}
+	return candidates[0]
+}
+
+// Volume is a named volume
+type Volume struct {
+	Name       string            `json:"name"`
+	Driver     string            `json:"driver"`
+	Mountpoint string            `json:"mountpoint"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+// Engine is the container engine API the backup needs. Client implements it.
+type Engine interface {
+	// Volumes lists the named volumes
+	Volumes(ctx context.Context) ([]Volume, error)
+	// Export streams the volume's contents as a tar archive, using a
+	// temporary container of image. Closing the stream removes the container.
+	Export(ctx context.Context, volume, image string) (io.ReadCloser, error)
+}
+
+// Select lists the engine's volumes selected by the config, sorted by name
+func Select(ctx context.Context, engine Engine, cfg *Config) ([]Volume, error) {
+	all, err := engine.Volumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var selected []Volume
+	for _, v := range all {
+		if cfg.Selected(v) {
+			selected = append(selected, v)
+		}
+	}
+	slices.SortFunc(selected, func(a, b Volume) int { return strings.Compare(a.Name, b.Name) })
+	return selected, nil
+}
+
+// Result is the outcome of backing up one volume
+type Result struct {
+	Volume Volume
+	// Paths are the paths the volume's snapshot stores
+	Paths   []string
+	Summary *restic.BackupSummary
+	Err     error
+}
+
+// archiveName is the file an exported volume is stored as
+func archiveName(volume string) string {
+	return path.Join("volumes", volume+".tar")
+}
+
+// Backup backs up each volume as its own snapshot, tagged with tags and
+// TagPrefix+name, up to the config's concurrency at once. A failed volume
+// doesn't stop the others; check each result's Err. Results are in the
+// order of vols.
+func Backup(ctx context.Context, repo restic.Runner, engine Engine, cfg *Config, vols []Volume, tags []string) []Result {
+	results := make([]Result, len(vols))
+	sem := make(chan struct{}, cfg.GetConcurrency())
+	var wg sync.WaitGroup
+	for i, v := range vols {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			results[i] = backupVolume(ctx, repo, engine, cfg, v, tags)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func backupVolume(ctx context.Context, repo restic.Runner, engine Engine, cfg *Config, v Volume, tags []string) Result {
+	volTags := append(slices.Clone(tags), TagPrefix+v.Name)
+	r := Result{Volume: v}
+	if cfg.GetMode() == ModeArchive {
+		r.Paths = []string{"/" + archiveName(v.Name)}
+		r.Summary, r.Err = backupArchive(ctx, repo, engine, cfg.GetHelperImage(), v.Name, volTags)
+	} else {
+		r.Paths = []string{v.Mountpoint}
+		r.Summary, r.Err = backupPath(ctx, repo, v, volTags)
+	}
+	if r.Err != nil {
+		r.Err = fmt.Errorf("volume %s: %w", v.Name, r.Err)
+	}
+	return r
+}
+
+func backupPath(ctx context.Context, repo restic.Runner, v Volume, tags []string) (*restic.BackupSummary, error) {
+	if v.Mountpoint == "" {
+		return nil, fmt.Errorf("the %q driver has no local mountpoint; use archive mode", v.Driver)
+	}
+	if _, err := os.Stat(v.Mountpoint); err != nil {
+		return nil, fmt.Errorf("can't read mountpoint (run as root or use archive mode): %w", err)
+	}
+	return repo.Backup(ctx, []string{v.Mountpoint}, tags)
+}
+
+func backupArchive(ctx context.Context, repo restic.Runner, engine Engine, image, volume string, tags []string) (*restic.BackupSummary, error) {
+	archive, err := engine.Export(ctx, volume, image)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := repo.BackupStdin(ctx, archive, archiveName(volume), tags)
+	if closeErr := archive.Close(); err == nil && closeErr != nil {
+		return nil, closeErr
+	}
+	return summary, err
+}
@@ -0,0 +1,264 @@
+package volumes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcrostarosa/airgapper/backend/internal/restic"
+)
+
+// fakeEngine serves the parts of the Docker API the client uses on a unix
+// socket. The helper image must be pulled before containers can be created.
+type fakeEngine struct {
+	mu         sync.Mutex
+	volumes    []map[string]any
+	pulled     bool
+	containers map[string]string // id -> volume
+	removed    []string
+}
+
+func startEngine(t *testing.T, vols ...map[string]any) (*fakeEngine, string) {
+	t.Helper()
+	e := &fakeEngine{volumes: vols, containers: map[string]string{}}
+
+	socket := filepath.Join(t.TempDir(), "engine.sock")
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	srv := httptest.NewUnstartedServer(e)
+	srv.Listener = l
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return e, socket
+}
+
+func (e *fakeEngine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/volumes":
+		_ = json.NewEncoder(w).Encode(map[string]any{"Volumes": e.volumes})
+	case r.Method == http.MethodPost && r.URL.Path == "/images/create":
+		e.pulled = true
+		_, _ = io.WriteString(w, `{"status":"Pulling"}`+"\n"+`{"status":"Done"}`+"\n")
+	case r.Method == http.MethodPost && r.URL.Path == "/containers/create":
+		if !e.pulled {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"message":"No such image"}`)
+			return
+		}
+		var body struct {
+			HostConfig struct{ Binds []string }
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		id := "c" + string(rune('0'+len(e.containers)))
+		e.containers[id] = strings.Split(body.HostConfig.Binds[0], ":")[0]
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, `{"Id":"`+id+`"}`)
+	case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/archive"):
+		id := strings.Split(r.URL.Path, "/")[2]
+		_, _ = io.WriteString(w, "tar of "+e.containers[id])
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/containers/"):
+		e.removed = append(e.removed, strings.TrimPrefix(r.URL.Path, "/containers/"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// fakeRepo records backups; other restic.Runner methods aren't used
+type fakeRepo struct {
+	restic.Runner
+	mu      sync.Mutex
+	backups []fakeBackup
+	err     error
+	// If set, backups wait for it; running counts those waiting
+	block   chan struct{}
+	running chan struct{}
+}
+
+type fakeBackup struct {
+	paths []string
+	tags  []string
+	data  string
+}
+
+func (f *fakeRepo) Backup(ctx context.Context, paths, tags []string, excludes ...string) (*restic.BackupSummary, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.block != nil {
+		f.running <- struct{}{}
+		<-f.block
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.backups = append(f.backups, fakeBackup{paths: paths, tags: tags})
+	return &restic.BackupSummary{SnapshotID: "snap"}, nil
+}
+
+func (f *fakeRepo) BackupStdin(ctx context.Context, r io.Reader, filename string, tags []string) (*restic.BackupSummary, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.backups = append(f.backups, fakeBackup{paths: []string{"/" + filename}, tags: tags, data: string(data)})
+	return &restic.BackupSummary{SnapshotID: "snap"}, nil
+}
+
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, (&Config{}).Validate())
+	assert.NoError(t, (&Config{Mode: ModeArchive, Socket: "unix:///run/docker.sock"}).Validate())
+	assert.Error(t, (&Config{Mode: "rsync"}).Validate())
+	assert.Error(t, (&Config{Socket: "docker.sock"}).Validate())
+	assert.Error(t, (&Config{Include: []string{"["}}).Validate())
+	assert.NoError(t, (&Config{Tags: []string{"databases"}}).Validate())
+	assert.Error(t, (&Config{Tags: []string{"a,b"}}).Validate())
+	assert.Error(t, (&Config{Concurrency: -1}).Validate())
+}
+
+func TestSelected(t *testing.T) {
+	cfg := &Config{Include: []string{"app_*", "db"}, Exclude: []string{"*_cache"}}
+	assert.True(t, cfg.Selected(Volume{Name: "app_data"}))
+	assert.True(t, cfg.Selected(Volume{Name: "db"}))
+	assert.False(t, cfg.Selected(Volume{Name: "app_cache"}))
+	assert.False(t, cfg.Selected(Volume{Name: "other"}))
+
+	all := &Config{}
+	assert.True(t, all.Selected(Volume{Name: "other"}))
+	assert.False(t, all.Selected(Volume{Name: "other", Labels: map[string]string{ExcludeLabel: "true"}}))
+}
+
+func TestClientVolumes(t *testing.T) {
+	_, socket := startEngine(t,
+		map[string]any{"Name": "b", "Driver": "local", "Mountpoint": "/var/lib/docker/volumes/b/_data"},
+		map[string]any{"Name": "a", "Driver": "local", "Labels": map[string]string{"x": "y"}},
+		map[string]any{"Name": "skip", "Driver": "local", "Labels": map[string]string{ExcludeLabel: "true"}},
+	)
+
+	vols, err := Select(context.Background(), NewClient("unix://"+socket), &Config{})
+	require.NoError(t, err)
+	require.Len(t, vols, 2)
+	assert.Equal(t, "a", vols[0].Name)
+	assert.Equal(t, "y", vols[0].Labels["x"])
+	assert.Equal(t, "/var/lib/docker/volumes/b/_data", vols[1].Mountpoint)
+}
+
+func TestClientExport(t *testing.T) {
+	engine, socket := startEngine(t)
+	client := NewClient(socket)
+
+	archive, err := client.Export(context.Background(), "app_data", DefaultHelperImage)
+	require.NoError(t, err)
+	assert.True(t, engine.pulled, "missing image should be pulled")
+
+	data, err := io.ReadAll(archive)
+	require.NoError(t, err)
+	assert.Equal(t, "tar of app_data", string(data))
+	require.NoError(t, archive.Close())
+	assert.Equal(t, []string{"c0"}, engine.removed)
+}
+
+func TestClientEngineError(t *testing.T) {
+	_, err := NewClient(filepath.Join(t.TempDir(), "missing.sock")).Volumes(context.Background())
+	assert.ErrorContains(t, err, "container engine")
+}
+
+func TestBackupPathMode(t *testing.T) {
+	repo := &fakeRepo{}
+	dir := t.TempDir()
+	vols := []Volume{{Name: "app", Mountpoint: dir}, {Name: "gone", Mountpoint: filepath.Join(dir, "gone")}}
+
+	results := Backup(context.Background(), repo, nil, &Config{}, vols, []string{"airgapper"})
+	require.Len(t, results, 2)
+	require.NoError(t, results[0].Err)
+	assert.Equal(t, []string{dir}, results[0].Paths)
+	assert.ErrorContains(t, results[1].Err, "volume gone")
+
+	require.Len(t, repo.backups, 1)
+	assert.Equal(t, []string{"airgapper", "volume:app"}, repo.backups[0].tags)
+}
+
+func TestBackupConcurrency(t *testing.T) {
+	repo := &fakeRepo{block: make(chan struct{}), running: make(chan struct{}, 3)}
+	var vols []Volume
+	for _, name := range []string{"a", "b", "c"} {
+		vols = append(vols, Volume{Name: name, Mountpoint: t.TempDir()})
+	}
+
+	done := make(chan []Result)
+	go func() { done <- Backup(context.Background(), repo, nil, &Config{Concurrency: 2}, vols, nil) }()
+	<-repo.running
+	<-repo.running
+	select {
+	case <-repo.running:
+		t.Fatal("more backups ran at once than the concurrency allows")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(repo.block)
+
+	results := <-done
+	require.Len(t, results, 3)
+	for i, r := range results {
+		require.NoError(t, r.Err)
+		assert.Equal(t, vols[i].Name, r.Volume.Name, "results are in the order of the volumes")
+	}
+	assert.Len(t, repo.backups, 3)
+}
+
+func TestBackupArchiveMode(t *testing.T) {
+	_, socket := startEngine(t)
+	repo := &fakeRepo{}
+	cfg := &Config{Mode: ModeArchive}
+
+	results := Backup(context.Background(), repo, NewClient(socket), cfg, []Volume{{Name: "app"}}, nil)
+	require.NoError(t, results[0].Err)
+	assert.Equal(t, []string{"/volumes/app.tar"}, results[0].Paths)
+	require.Len(t, repo.backups, 1)
+	assert.Equal(t, []string{"/volumes/app.tar"}, repo.backups[0].paths)
+	assert.Equal(t, []string{"volume:app"}, repo.backups[0].tags)
+	assert.Equal(t, "tar of app", repo.backups[0].data)
+}
+
+func TestBackupArchiveFailure(t *testing.T) {
+	engine, socket := startEngine(t)
+	repo := &fakeRepo{err: errors.New("repository locked")}
+
+	results := Backup(context.Background(), repo, NewClient(socket), &Config{Mode: ModeArchive}, []Volume{{Name: "app"}}, nil)
+	assert.ErrorContains(t, results[0].Err, "repository locked")
+	assert.Len(t, engine.removed, 1, "helper container should be removed after a failed backup")
+}
+
+func TestDefaultSocket(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "unix:///custom/docker.sock")
+	assert.Equal(t, "/custom/docker.sock", DefaultSocket())
+
+	t.Setenv("DOCKER_HOST", "")
+	runtime := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", runtime)
+	if _, err := os.Stat("/var/run/docker.sock"); err == nil {
+		t.Skip("docker socket present")
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(runtime, "podman"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(runtime, "podman", "podman.sock"), nil, 0600))
+	assert.Equal(t, filepath.Join(runtime, "podman", "podman.sock"), DefaultSocket())
+}
@@ -0,0 +1,93 @@
+// Airgapper notification link page.
+// Shows what a notification's approve or deny link would do, and does it
+// once confirmed. The node signs approvals with its own key; the link's
+// token only says which request and action it is for.
+"use strict";
+
+const ACTIONS = "/api/v1/actions";
+
+function $(id) {
+  return document.getElementById(id);
+}
+
+function show(id, visible) {
+  $(id).hidden = !visible;
+}
+
+function showError(err) {
+  $("error").textContent = err ? err.message || String(err) : "";
+  show("error", !!err);
+}
+
+async function post(path, token) {
+  const resp = await fetch(ACTIONS + path, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    credentials: "omit",
+    body: JSON.stringify({ token }),
+  });
+  const data = await resp.json().catch(() => ({}));
+  if (!resp.ok) throw new Error(data.message || `Request failed (${resp.status})`);
+  return data;
+}
+
+function showRequest(data) {
+  const req = data.request;
+  const verb = data.action === "approve" ? "Approve" : "Deny";
+  $("question").textContent = `${verb} ${req.requester}'s request?`;
+  const rows = [
+    ["Reason", req.reason],
+    ["Snapshot", req.snapshot_id],
+    ["Paths", (req.paths || []).join(", ") || "everything"],
+    ["From", req.requester_context ? req.requester_context.hostname : ""],
+    ["Approvals", req.required_approvals ? `${req.approvals} of ${req.required_approvals}` : ""],
+    ["Request expires", new Date(req.expires_at).toLocaleString()],
+    ["Link expires", new Date(data.expires_at).toLocaleString()],
+  ];
+  const dl = $("details");
+  for (const [label, value] of rows) {
+    if (!value) continue;
+    const dt = document.createElement("dt");
+    dt.textContent = label;
+    const dd = document.createElement("dd");
+    dd.textContent = value;
+    dl.append(dt, dd);
+  }
+  const button = $("confirm");
+  button.textContent = verb;
+  if (data.action === "deny") button.className = "deny";
+  show("request", true);
+}
+
+async function confirmAction(token) {
+  showError(null);
+  $("confirm").disabled = true;
+  try {
+    const result = await post("/confirm", token);
+    show("request", false);
+    if (result.status === "denied") {
+      $("notice").textContent = "Request denied.";
+    } else if (result.complete) {
+      $("notice").textContent = "Request approved.";
+    } else {
+      $("notice").textContent = `Approved; ${result.approvals} of ${result.required} approvals so far.`;
+    }
+    show("notice", true);
+  } catch (err) {
+    showError(err);
+  }
+}
+
+function init() {
+  const token = new URLSearchParams(location.hash.slice(1)).get("token");
+  // Keep the token out of the history
+  history.replaceState(null, "", location.pathname);
+  if (!token) {
+    showError(new Error("This page opens from a notification's approve or deny link."));
+    return;
+  }
+  $("confirm").addEventListener("click", () => confirmAction(token));
+  post("", token).then(showRequest).catch(showError);
+}
+
+init();
@@ -0,0 +1,258 @@
+// Airgapper built-in dashboard.
+// Talks to the Connect-RPC services using the Connect JSON protocol
+// (POST /api/v1/<package>.<Service>/<Method> with a JSON body). Once the
+// node has user accounts, the user signs in with their name and password;
+// the session cookie then goes with every call, and the session's CSRF
+// token with every change.
+"use strict";
+
+const API_PREFIX = "/api/v1";
+const CSRF_KEY = "airgapper-dashboard-csrf";
+
+async function api(method, path, body) {
+  const headers = { "Content-Type": "application/json" };
+  const csrf = sessionStorage.getItem(CSRF_KEY);
+  if (csrf) headers["X-CSRF-Token"] = csrf;
+  const resp = await fetch(API_PREFIX + path, {
+    method,
+    headers,
+    credentials: "same-origin",
+    body: method === "GET" ? undefined : JSON.stringify(body || {}),
+  });
+  const data = await resp.json().catch(() => ({}));
+  if (!resp.ok) {
+    const err = new Error(data.message || `${path} failed (${resp.status})`);
+    err.status = resp.status;
+    throw err;
+  }
+  return data;
+}
+
+function rpc(service, method, body) {
+  return api("POST", `/airgapper.v1.${service}/${method}`, body);
+}
+
+function $(id) {
+  return document.getElementById(id);
+}
+
+function showError(err) {
+  const el = $("error");
+  if (!err) {
+    el.hidden = true;
+    el.textContent = "";
+    return;
+  }
+  el.hidden = false;
+  el.textContent = err.message || String(err);
+}
+
+function fillList(id, rows) {
+  const dl = $(id);
+  dl.replaceChildren();
+  for (const [label, value, cls] of rows) {
+    const dt = document.createElement("dt");
+    dt.textContent = label;
+    const dd = document.createElement("dd");
+    dd.textContent = value === undefined || value === null || value === "" ? "—" : String(value);
+    if (cls) dd.className = cls;
+    dl.append(dt, dd);
+  }
+}
+
+function formatBytes(value) {
+  const n = Number(value || 0);
+  if (n <= 0) return "0 B";
+  const units = ["B", "KB", "MB", "GB", "TB", "PB"];
+  const i = Math.min(Math.floor(Math.log(n) / Math.log(1024)), units.length - 1);
+  return `${(n / Math.pow(1024, i)).toFixed(i === 0 ? 0 : 1)} ${units[i]}`;
+}
+
+function formatTime(value) {
+  if (!value) return "";
+  const d = new Date(value);
+  return isNaN(d) ? value : d.toLocaleString();
+}
+
+function enumLabel(value, prefix) {
+  if (!value) return "";
+  return String(value).replace(prefix, "").toLowerCase();
+}
+
+async function loadStatus() {
+  const s = await rpc("HealthService", "GetStatus");
+  $("node-name").textContent = s.name ? `${s.name} (${enumLabel(s.role, "ROLE_")})` : "not initialized";
+  const rows = [
+    ["Name", s.name],
+    ["Role", enumLabel(s.role, "ROLE_")],
+    ["Mode", enumLabel(s.mode, "OPERATION_MODE_")],
+    ["Repository", s.repoUrl],
+    ["Key share", s.hasShare ? `index ${s.shareIndex || 0}` : "none"],
+    ["Pending requests", s.pendingRequests || 0],
+    ["Backup paths", (s.backupPaths || []).join(", ")],
+  ];
+  if (s.peer) rows.push(["Peer", s.peer.address ? `${s.peer.name} (${s.peer.address})` : s.peer.name]);
+  if (s.consensus) rows.push(["Consensus", `${s.consensus.threshold}-of-${s.consensus.totalKeys}`]);
+  fillList("status-list", rows);
+}
+
+async function loadApprovals() {
+  const { requests = [] } = await rpc("RestoreRequestService", "ListRequests");
+  const table = $("approvals-table");
+  const tbody = table.querySelector("tbody");
+  tbody.replaceChildren();
+  $("approvals-empty").hidden = requests.length > 0;
+  table.hidden = requests.length === 0;
+
+  for (const req of requests) {
+    const tr = document.createElement("tr");
+    for (const value of [req.id, req.requester, req.snapshotId, req.reason, formatTime(req.expiresAt)]) {
+      const td = document.createElement("td");
+      td.textContent = value || "";
+      tr.append(td);
+    }
+    const actions = document.createElement("td");
+    actions.className = "actions";
+    actions.append(
+      actionButton("Approve", "", () => rpc("RestoreRequestService", "ApproveRequest", { id: req.id })),
+      document.createTextNode(" "),
+      actionButton("Deny", "deny", () => rpc("RestoreRequestService", "DenyRequest", { id: req.id })),
+    );
+    tr.append(actions);
+    tbody.append(tr);
+  }
+}
+
+function actionButton(label, cls, action) {
+  const btn = document.createElement("button");
+  btn.type = "button";
+  btn.textContent = label;
+  if (cls) btn.className = cls;
+  btn.addEventListener("click", async () => {
+    btn.disabled = true;
+    try {
+      await action();
+      showError(null);
+      await refresh();
+    } catch (err) {
+      showError(err);
+      btn.disabled = false;
+    }
+  });
+  return btn;
+}
+
+async function loadSchedule() {
+  const s = await rpc("ScheduleService", "GetSchedule");
+  fillList("schedule-list", [
+    ["Schedule", s.schedule || "not configured"],
+    ["Paths", (s.paths || []).join(", ")],
+    ["Scheduler", s.enabled ? "running" : "not running", s.enabled ? "ok" : ""],
+    ["Last error", s.lastError, s.lastError ? "bad" : ""],
+  ]);
+  const form = $("schedule-form");
+  if (document.activeElement?.form !== form) {
+    form.schedule.value = s.schedule || "";
+    form.paths.value = (s.paths || []).join(", ");
+  }
+}
+
+async function loadStorage() {
+  const s = await rpc("StorageService", "GetStorageStatus");
+  if (!s.configured) {
+    fillList("storage-list", [["Storage", "not configured on this node"]]);
+    return;
+  }
+  fillList("storage-list", [
+    ["Server", s.running ? "running" : "stopped", s.running ? "ok" : "bad"],
+    ["Path", s.basePath],
+    ["Append-only", s.appendOnly ? "yes" : "no"],
+    ["Used", formatBytes(s.usedBytes)],
+    ["Quota", Number(s.quotaBytes || 0) > 0 ? formatBytes(s.quotaBytes) : "unlimited"],
+    ["Disk", `${s.diskUsagePct || 0}% used, ${formatBytes(s.diskFreeBytes)} free`, (s.diskUsagePct || 0) >= 90 ? "bad" : ""],
+    ["Policy", s.hasPolicy ? s.policyId : "none"],
+    ["Requests served", s.requestCount || 0],
+  ]);
+}
+
+async function refresh() {
+  if (!$("login").hidden) return;
+  const results = await Promise.allSettled([loadStatus(), loadApprovals(), loadSchedule(), loadStorage()]);
+  const failed = results.find((r) => r.status === "rejected");
+  if (failed && failed.reason.status === 401) {
+    // The session ended, e.g. it expired or was revoked
+    showLogin();
+    return;
+  }
+  showError(failed ? failed.reason : null);
+}
+
+function showDashboard(session) {
+  $("login").hidden = true;
+  for (const el of document.querySelectorAll(".dashboard")) el.hidden = false;
+  $("logout").hidden = !session.auth_required;
+  $("user-name").textContent = session.user ? session.user.name : "";
+}
+
+function showLogin() {
+  sessionStorage.removeItem(CSRF_KEY);
+  for (const el of document.querySelectorAll(".dashboard")) el.hidden = true;
+  $("logout").hidden = true;
+  $("user-name").textContent = "";
+  $("login").hidden = false;
+}
+
+async function start() {
+  try {
+    const session = await api("GET", "/session");
+    if (session.csrf_token) sessionStorage.setItem(CSRF_KEY, session.csrf_token);
+    showDashboard(session);
+    await refresh();
+  } catch (err) {
+    if (err.status === 401) {
+      // Not signed in yet
+      showLogin();
+      return;
+    }
+    showError(err);
+  }
+}
+
+$("login-form").addEventListener("submit", async (event) => {
+  event.preventDefault();
+  const form = event.target;
+  try {
+    const session = await api("POST", "/session/login", { name: form.user.value.trim(), password: form.password.value });
+    sessionStorage.setItem(CSRF_KEY, session.csrf_token);
+    form.reset();
+    showError(null);
+    showDashboard(session);
+    await refresh();
+  } catch (err) {
+    showError(err);
+  }
+});
+
+$("logout").addEventListener("click", async () => {
+  await api("POST", "/session/logout").catch(() => {});
+  showError(null);
+  showLogin();
+});
+
+$("schedule-form").addEventListener("submit", async (event) => {
+  event.preventDefault();
+  const form = event.target;
+  const paths = form.paths.value.split(",").map((p) => p.trim()).filter(Boolean);
+  try {
+    await rpc("ScheduleService", "UpdateSchedule", { schedule: form.schedule.value.trim(), paths });
+    showError(null);
+    await refresh();
+  } catch (err) {
+    showError(err);
+  }
+});
+
+$("refresh").addEventListener("click", refresh);
+
+start();
+setInterval(refresh, 15000);
@@ -0,0 +1,217 @@
+// Airgapper mobile approval page.
+// Signs the key holder in with a passkey (WebAuthn), then lists pending
+// restore requests to approve or deny. The node signs approvals with its
+// own key; the passkey only proves who is asking.
+"use strict";
+
+const APPROVALS = "/api/v1/approvals";
+const CSRF_KEY = "airgapper-approval-csrf";
+
+function $(id) {
+  return document.getElementById(id);
+}
+
+function show(id, visible) {
+  $(id).hidden = !visible;
+}
+
+function showError(err) {
+  $("error").textContent = err ? err.message || String(err) : "";
+  show("error", !!err);
+}
+
+function showNotice(text) {
+  $("notice").textContent = text || "";
+  show("notice", !!text);
+}
+
+// WebAuthn passes binary data as ArrayBuffers; the API as base64url
+function toBase64url(buffer) {
+  let s = "";
+  for (const b of new Uint8Array(buffer)) s += String.fromCharCode(b);
+  return btoa(s).replace(/\+/g, "-").replace(/\//g, "_").replace(/=+$/, "");
+}
+
+function fromBase64url(text) {
+  const s = atob(text.replace(/-/g, "+").replace(/_/g, "/"));
+  return Uint8Array.from(s, (c) => c.charCodeAt(0));
+}
+
+async function api(method, path, body) {
+  const headers = { "Content-Type": "application/json" };
+  const csrf = sessionStorage.getItem(CSRF_KEY);
+  if (csrf) headers["X-CSRF-Token"] = csrf;
+  const resp = await fetch(APPROVALS + path, {
+    method,
+    headers,
+    credentials: "same-origin",
+    body: method === "GET" ? undefined : JSON.stringify(body || {}),
+  });
+  const data = await resp.json().catch(() => ({}));
+  if (!resp.ok) {
+    const err = new Error(data.message || `${path} failed (${resp.status})`);
+    err.status = resp.status;
+    throw err;
+  }
+  return data;
+}
+
+async function enroll(token) {
+  showError(null);
+  const opts = await api("POST", "/enroll/options", { token });
+  const credential = await navigator.credentials.create({
+    publicKey: {
+      ...opts,
+      challenge: fromBase64url(opts.challenge),
+      user: { ...opts.user, id: fromBase64url(opts.user.id) },
+      excludeCredentials: opts.excludeCredentials.map((c) => ({ ...c, id: fromBase64url(c.id) })),
+    },
+  });
+  await api("POST", "/enroll", {
+    token,
+    credential: {
+      id: credential.id,
+      clientDataJSON: toBase64url(credential.response.clientDataJSON),
+      attestationObject: toBase64url(credential.response.attestationObject),
+    },
+  });
+  history.replaceState(null, "", location.pathname);
+  show("enroll", false);
+  showNotice("Passkey registered. Sign in with it to approve requests.");
+  show("signin", true);
+}
+
+async function signIn() {
+  showError(null);
+  const opts = await api("POST", "/login/options");
+  const assertion = await navigator.credentials.get({
+    publicKey: {
+      ...opts,
+      challenge: fromBase64url(opts.challenge),
+      allowCredentials: opts.allowCredentials.map((c) => ({ ...c, id: fromBase64url(c.id) })),
+    },
+  });
+  const session = await api("POST", "/login", {
+    credential: {
+      id: assertion.id,
+      clientDataJSON: toBase64url(assertion.response.clientDataJSON),
+      authenticatorData: toBase64url(assertion.response.authenticatorData),
+      signature: toBase64url(assertion.response.signature),
+    },
+  });
+  sessionStorage.setItem(CSRF_KEY, session.csrf_token);
+  showNotice(null);
+  await loadRequests();
+}
+
+async function signOut() {
+  await api("POST", "/logout").catch(() => {});
+  sessionStorage.removeItem(CSRF_KEY);
+  show("requests", false);
+  show("signout", false);
+  show("signin", true);
+}
+
+function requestCard(req) {
+  const card = document.createElement("section");
+  card.className = "request";
+  const dl = document.createElement("dl");
+  const rows = [
+    ["Requester", req.requester],
+    ["Reason", req.reason],
+    ["Snapshot", req.snapshot_id],
+    ["Paths", (req.paths || []).join(", ") || "everything"],
+    ["From", req.requester_context ? req.requester_context.hostname : ""],
+    ["Approvals", req.required_approvals ? `${req.approvals} of ${req.required_approvals}` : ""],
+    ["Expires", new Date(req.expires_at).toLocaleString()],
+  ];
+  for (const [label, value] of rows) {
+    if (!value) continue;
+    const dt = document.createElement("dt");
+    dt.textContent = label;
+    const dd = document.createElement("dd");
+    dd.textContent = value;
+    dl.append(dt, dd);
+  }
+
+  const buttons = document.createElement("div");
+  buttons.className = "buttons";
+  for (const [action, label] of [["approve", "Approve"], ["deny", "Deny"]]) {
+    const button = document.createElement("button");
+    button.type = "button";
+    button.textContent = label;
+    if (action === "deny") button.className = "deny";
+    button.addEventListener("click", () => decide(req, action, card));
+    buttons.append(button);
+  }
+  card.append(dl, buttons);
+  return card;
+}
+
+async function decide(req, action, card) {
+  const verb = action === "approve" ? "Approve" : "Deny";
+  if (!confirm(`${verb} ${req.requester}'s request?\n\n${req.reason}`)) return;
+  showError(null);
+  for (const b of card.querySelectorAll("button")) b.disabled = true;
+  try {
+    const result = await api("POST", `/requests/${encodeURIComponent(req.id)}/${action}`);
+    if (action === "deny") {
+      showNotice("Request denied.");
+    } else if (result.complete) {
+      showNotice("Request approved.");
+    } else {
+      showNotice(`Approved; ${result.approvals} of ${result.required} approvals so far.`);
+    }
+    await loadRequests();
+  } catch (err) {
+    for (const b of card.querySelectorAll("button")) b.disabled = false;
+    handleError(err);
+  }
+}
+
+async function loadRequests() {
+  const data = await api("GET", "/requests");
+  const list = $("requests-list");
+  list.replaceChildren(...data.requests.map(requestCard));
+  show("requests-empty", data.requests.length === 0);
+  show("signin", false);
+  show("requests", true);
+  show("signout", true);
+}
+
+function handleError(err) {
+  if (err.status === 401) {
+    sessionStorage.removeItem(CSRF_KEY);
+    show("requests", false);
+    show("signout", false);
+    show("signin", true);
+  }
+  showError(err);
+}
+
+function init() {
+  if (!window.PublicKeyCredential) {
+    showError(new Error("This browser doesn't support passkeys, or the page isn't served over https."));
+    return;
+  }
+  $("signin-button").addEventListener("click", () => signIn().catch(handleError));
+  $("signout").addEventListener("click", () => signOut());
+
+  const token = new URLSearchParams(location.hash.slice(1)).get("enroll");
+  if (token) {
+    $("enroll-button").addEventListener("click", () => enroll(token).catch(showError));
+    show("enroll", true);
+    return;
+  }
+  loadRequests().catch((err) => {
+    if (err.status === 401) {
+      // Not signed in yet
+      sessionStorage.removeItem(CSRF_KEY);
+      show("signin", true);
+      return;
+    }
+    handleError(err);
+  });
+}
+
+init();
@@ -0,0 +1,75 @@
+// Package webui embeds the built-in web dashboard served by the API server.
+// The dashboard is a dependency-free HTML/JS page that talks to the
+// Connect-RPC endpoints using the Connect JSON protocol, so nodes running on
+// a NAS or headless box get a usable UI without a separate frontend server.
+package webui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// indexFile is served for the root path and for unknown paths (SPA fallback)
+const indexFile = "index.html"
+
+// FS returns the embedded dashboard assets rooted at the static directory
+func FS() fs.FS {
+	sub, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		// The static directory is embedded at compile time, so this cannot fail
+		panic(err)
+	}
+	return sub
+}
+
+// Handler returns an http.Handler that serves the embedded dashboard.
+// Requests for paths that don't match an embedded asset fall back to
+// index.html so client-side navigation keeps working on reload.
+func Handler() http.Handler {
+	assets := FS()
+	fileServer := http.FileServer(http.FS(assets))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		name := strings.TrimPrefix(path.Clean(r.URL.Path), "/")
+		if name == "" || !exists(assets, name) {
+			serveIndex(w, r, assets)
+			return
+		}
+
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		fileServer.ServeHTTP(w, r)
+	})
+}
+
+func serveIndex(w http.ResponseWriter, r *http.Request, assets fs.FS) {
+	data, err := fs.ReadFile(assets, indexFile)
+	if err != nil {
+		http.Error(w, "Web UI not available", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("X-Frame-Options", "DENY")
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, _ = w.Write(data)
+}
+
+func exists(assets fs.FS, name string) bool {
+	info, err := fs.Stat(assets, name)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,47 @@
+package webui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	h := Handler()
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		wantStatus  int
+		wantType    string
+		wantContain string
+	}{
+		{"root serves index", http.MethodGet, "/", http.StatusOK, "text/html", "<title>Airgapper</title>"},
+		{"index offers a login", http.MethodGet, "/", http.StatusOK, "text/html", `id="login-form"`},
+		{"script asset", http.MethodGet, "/app.js", http.StatusOK, "javascript", "RestoreRequestService"},
+		{"stylesheet asset", http.MethodGet, "/style.css", http.StatusOK, "text/css", ""},
+		{"approval page", http.MethodGet, "/approve.html", http.StatusOK, "text/html", "approve.js"},
+		{"notification link page", http.MethodGet, "/action.html", http.StatusOK, "text/html", "action.js"},
+		{"unknown path falls back to index", http.MethodGet, "/dashboard/approvals", http.StatusOK, "text/html", "<title>Airgapper</title>"},
+		{"post not allowed", http.MethodPost, "/", http.StatusMethodNotAllowed, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantType != "" {
+				assert.Contains(t, rec.Header().Get("Content-Type"), tt.wantType)
+			}
+			if tt.wantContain != "" {
+				assert.Contains(t, rec.Body.String(), tt.wantContain)
+			}
+		})
+	}
+}
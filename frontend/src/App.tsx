@@ -1,9 +1,12 @@
-import { useState } from "react";
+import { useEffect, useState } from "react";
 import type { VaultConfig, Step } from "./types";
 import { Welcome } from "./components/Welcome";
 import { InitVault } from "./components/InitVault";
 import { HostSetup } from "./components/HostSetup";
 import { Dashboard } from "./components/Dashboard";
+import { Login } from "./components/Login";
+import { getSession, logout, UNAUTHENTICATED_EVENT } from "./lib/client";
+import type { SessionStatus } from "./lib/client";
 
 const STORAGE_KEY = "airgapper_vault";
 
@@ -22,6 +25,22 @@ function loadSavedConfig(): VaultConfig | null {
 function App() {
   const [step, setStep] = useState<Step>("welcome");
   const [config, setConfig] = useState<VaultConfig | null>(loadSavedConfig);
+  // undefined while checking, null when the node wants a login
+  const [session, setSession] = useState<SessionStatus | null | undefined>(undefined);
+
+  useEffect(() => {
+    getSession()
+      .then(setSession)
+      .catch(() => setSession({ auth_required: false }));
+    const onUnauthenticated = () => setSession(null);
+    window.addEventListener(UNAUTHENTICATED_EVENT, onUnauthenticated);
+    return () => window.removeEventListener(UNAUTHENTICATED_EVENT, onUnauthenticated);
+  }, []);
+
+  const handleLogout = async () => {
+    await logout();
+    setSession(null);
+  };
 
   const handleComplete = (newConfig: VaultConfig) => {
     setConfig(newConfig);
@@ -40,16 +59,27 @@ function App() {
   return (
     <div className="min-h-screen bg-gray-900 text-gray-100">
       <div className="container mx-auto px-4 py-8">
-        {step === "welcome" && (
+        {session === null && <Login onLogin={setSession} />}
+        {session?.user && (
+          <div className="flex justify-end items-center gap-3 text-sm text-gray-400 mb-4">
+            <span>
+              {session.user.name} ({session.user.role})
+            </span>
+            <button onClick={handleLogout} className="text-blue-400 hover:text-blue-300">
+              Log out
+            </button>
+          </div>
+        )}
+        {session && step === "welcome" && (
           <Welcome onNavigate={setStep} hasVault={config !== null} />
         )}
-        {step === "init" && (
+        {session && step === "init" && (
           <InitVault onComplete={handleComplete} onNavigate={setStep} />
         )}
-        {step === "join" && (
+        {session && step === "join" && (
           <HostSetup onComplete={handleComplete} onNavigate={setStep} />
         )}
-        {step === "dashboard" && config && (
+        {session && step === "dashboard" && config && (
           <Dashboard config={config} onClear={handleClear} />
         )}
       </div>
@@ -0,0 +1,66 @@
+import { useState } from "react";
+import type { FormEvent } from "react";
+import { login } from "../lib/client";
+import type { SessionStatus } from "../lib/client";
+import { Alert, FormInput } from "./ui";
+
+interface LoginProps {
+  onLogin: (status: SessionStatus) => void;
+}
+
+export function Login({ onLogin }: LoginProps) {
+  const [name, setName] = useState("");
+  const [password, setPassword] = useState("");
+  const [error, setError] = useState<string | null>(null);
+  const [loading, setLoading] = useState(false);
+
+  const handleSubmit = async (e: FormEvent) => {
+    e.preventDefault();
+    setError(null);
+    setLoading(true);
+    try {
+      onLogin(await login(name, password));
+    } catch (err) {
+      setError(err instanceof Error ? err.message : "Login failed");
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <div className="max-w-sm mx-auto mt-16">
+      <div className="text-center mb-8">
+        <div className="text-5xl mb-4">🔐</div>
+        <h1 className="text-2xl font-bold">Log in to Airgapper</h1>
+        <p className="text-gray-400 text-sm mt-2">
+          This node has user accounts. Ask its admin if you don't have one.
+        </p>
+      </div>
+      <form onSubmit={handleSubmit} className="bg-gray-800 rounded-lg p-6 space-y-4">
+        {error && <Alert variant="error">{error}</Alert>}
+        <FormInput
+          label="User name"
+          value={name}
+          onChange={(e) => setName(e.target.value)}
+          autoComplete="username"
+          required
+        />
+        <FormInput
+          label="Password"
+          type="password"
+          value={password}
+          onChange={(e) => setPassword(e.target.value)}
+          autoComplete="current-password"
+          required
+        />
+        <button
+          type="submit"
+          disabled={loading}
+          className="w-full bg-blue-600 hover:bg-blue-700 disabled:opacity-50 rounded-lg py-3 font-medium transition-colors"
+        >
+          {loading ? "Logging in..." : "Log in"}
+        </button>
+      </form>
+    </div>
+  );
+}
@@ -33,6 +33,35 @@ export function DashboardRequests({ config, pendingRequests }: RequestsTabProps)
                   <div className="text-sm text-gray-400">
                     From: {request.requester}
                   </div>
+                  {request.purpose === "export-keys" && (
+                    <div className="text-sm font-semibold text-red-400">
+                      Key export: approving releases the raw repository password
+                    </div>
+                  )}
+                  {request.purpose === "browse" && (
+                    <div className="text-sm text-gray-400">
+                      Browse: lists the snapshot's contents, metadata only
+                    </div>
+                  )}
+                  {request.template && (
+                    <div className="text-xs text-gray-500">
+                      Template: {request.template}
+                    </div>
+                  )}
+                  {request.requesterContext ? (
+                    <div className="text-xs text-gray-500">
+                      Device: {request.requesterContext.hostname || "unknown host"}
+                      {request.requesterContext.os && ` (${request.requesterContext.os})`}
+                      {request.requesterContext.version && ` · v${request.requesterContext.version}`}
+                      {request.requesterContext.sourceIp && ` · ${request.requesterContext.sourceIp}`}
+                      {request.requesterContext.keyFingerprint &&
+                        ` · key ${request.requesterContext.keyFingerprint}`}
+                    </div>
+                  ) : (
+                    <div className="text-xs text-yellow-400">
+                      No device information: check this request with the requester
+                    </div>
+                  )}
                 </div>
                 <span
                   className={`text-xs px-2 py-1 rounded ${
@@ -69,6 +98,31 @@ export function DashboardRequests({ config, pendingRequests }: RequestsTabProps)
                 </div>
               )}
 
+              {(request.terms || []).map((terms, i) => (
+                <div key={i} className="text-xs text-gray-400 mb-1">
+                  Terms from {terms.setBy || "an approver"}:
+                  {terms.window && ` start ${terms.window}`}
+                  {terms.limitKibps ? ` · limit ${(terms.limitKibps / 1024).toFixed(1)} MB/s` : ""}
+                </div>
+              ))}
+
+              {request.progress && (
+                <div
+                  className={`text-sm mb-3 ${
+                    request.progress.status === "failed" ? "text-red-400" : "text-gray-300"
+                  }`}
+                >
+                  Restore {request.progress.status}
+                  {request.progress.status === "scheduled" &&
+                    request.progress.startsAt &&
+                    ` to start ${new Date(request.progress.startsAt).toLocaleString()}`}
+                  {request.progress.files
+                    ? ` · ${request.progress.restored || 0}/${request.progress.files} files`
+                    : ""}
+                  {request.progress.error && `: ${request.progress.error}`}
+                </div>
+              )}
+
               <div className="text-xs text-gray-500">
                 ID: {request.id} | Expires:{" "}
                 {new Date(request.expiresAt).toLocaleString()}
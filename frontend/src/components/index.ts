@@ -2,3 +2,4 @@ export { Welcome } from "./Welcome";
 export { InitVault } from "./InitVault";
 export { JoinVault } from "./JoinVault";
 export { Dashboard } from "./Dashboard";
+export { Login } from "./Login";
@@ -12,7 +12,7 @@ import type { Message } from "@bufbuild/protobuf";
  * Describes the file airgapper/v1/common.proto.
  */
 export const file_airgapper_v1_common: GenFile = /*@__PURE__*/
-  fileDesc("ChlhaXJnYXBwZXIvdjEvY29tbW9uLnByb3RvEgxhaXJnYXBwZXIudjEiMAoNU3RhdHVzTWVzc2FnZRIOCgZzdGF0dXMYASABKAkSDwoHbWVzc2FnZRgCIAEoCSI7CgtFcnJvckRldGFpbBIMCgRjb2RlGAEgASgJEg8KB21lc3NhZ2UYAiABKAkSDQoFZmllbGQYAyABKAkifgoIQXBwcm92YWwSFQoNa2V5X2hvbGRlcl9pZBgBIAEoCRIXCg9rZXlfaG9sZGVyX25hbWUYAiABKAkSEQoJc2lnbmF0dXJlGAMgASgJEi8KC2FwcHJvdmVkX2F0GAQgASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcCJuChBBcHByb3ZhbFByb2dyZXNzEg4KBnN0YXR1cxgBIAEoCRIZChFjdXJyZW50X2FwcHJvdmFscxgCIAEoBRIaChJyZXF1aXJlZF9hcHByb3ZhbHMYAyABKAUSEwoLaXNfYXBwcm92ZWQYBCABKAgiiwEKCUtleUhvbGRlchIKCgJpZBgBIAEoCRIMCgRuYW1lGAIgASgJEhIKCnB1YmxpY19rZXkYAyABKAkSDwoHYWRkcmVzcxgEIAEoCRItCglqb2luZWRfYXQYBSABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wEhAKCGlzX293bmVyGAYgASgIIn4KDUNvbnNlbnN1c0luZm8SEQoJdGhyZXNob2xkGAEgASgFEhIKCnRvdGFsX2tleXMYAiABKAUSLAoLa2V5X2hvbGRlcnMYAyADKAsyFy5haXJnYXBwZXIudjEuS2V5SG9sZGVyEhgKEHJlcXVpcmVfYXBwcm92YWwYBCABKAgiJQoEUGVlchIMCgRuYW1lGAEgASgJEg8KB2FkZHJlc3MYAiABKAkqOwoEUm9sZRIUChBST0xFX1VOU1BFQ0lGSUVEEAASDgoKUk9MRV9PV05FUhABEg0KCVJPTEVfSE9TVBACKp8BCg1SZXF1ZXN0U3RhdHVzEh4KGlJFUVVFU1RfU1RBVFVTX1VOU1BFQ0lGSUVEEAASGgoWUkVRVUVTVF9TVEFUVVNfUEVORElORxABEhsKF1JFUVVFU1RfU1RBVFVTX0FQUFJPVkVEEAISGQoVUkVRVUVTVF9TVEFUVVNfREVOSUVEEAMSGgoWUkVRVUVTVF9TVEFUVVNfRVhQSVJFRBAEKpEBCgxEZWxldGlvblR5cGUSHQoZREVMRVRJT05fVFlQRV9VTlNQRUNJRklFRBAAEhoKFkRFTEVUSU9OX1RZUEVfU05BUFNIT1QQARIWChJERUxFVElPTl9UWVBFX1BBVEgQAhIXChNERUxFVElPTl9UWVBFX1BSVU5FEAMSFQoRREVMRVRJT05fVFlQRV9BTEwQBCqnAQoMRGVsZXRpb25Nb2RlEh0KGURFTEVUSU9OX01PREVfVU5TUEVDSUZJRUQQABIfChtERUxFVElPTl9NT0RFX0JPVEhfUkVRVUlSRUQQARIcChhERUxFVElPTl9NT0RFX09XTkVSX09OTFkQAhIgChxERUxFVElPTl9NT0RFX1RJTUVfTE9DS19PTkxZEAMSFwoTREVMRVRJT05fTU9ERV9ORVZFUhAEKn4KDU9wZXJhdGlvbk1vZGUSHgoaT1BFUkFUSU9OX01PREVfVU5TUEVDSUZJRUQQABIXChNPUEVSQVRJT05fTU9ERV9OT05FEAESFgoST1BFUkFUSU9OX01PREVfU1NTEAISHAoYT1BFUkFUSU9OX01PREVfQ09OU0VOU1VTEAMqUgoJQ2hlY2tUeXBlEhoKFkNIRUNLX1RZUEVfVU5TUEVDSUZJRUQQABIUChBDSEVDS19UWVBFX1FVSUNLEAESEwoPQ0hFQ0tfVFlQRV9GVUxMEAJiBnByb3RvMw", [file_google_protobuf_timestamp]);
+  fileDesc("ChlhaXJnYXBwZXIvdjEvY29tbW9uLnByb3RvEgxhaXJnYXBwZXIudjEiMAoNU3RhdHVzTWVzc2FnZRIOCgZzdGF0dXMYASABKAkSDwoHbWVzc2FnZRgCIAEoCSI7CgtFcnJvckRldGFpbBIMCgRjb2RlGAEgASgJEg8KB21lc3NhZ2UYAiABKAkSDQoFZmllbGQYAyABKAki6QEKCEFwcHJvdmFsEhUKDWtleV9ob2xkZXJfaWQYASABKAkSFwoPa2V5X2hvbGRlcl9uYW1lGAIgASgJEhEKCXNpZ25hdHVyZRgDIAEoCRIvCgthcHByb3ZlZF9hdBgEIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXASFAoMb25fYmVoYWxmX29mGAUgASgJEhUKDWRlbGVnYXRpb25faWQYBiABKAkSDQoFbm9uY2UYByABKAkSLQoJc2lnbmVkX2F0GAggASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcCJuChBBcHByb3ZhbFByb2dyZXNzEg4KBnN0YXR1cxgBIAEoCRIZChFjdXJyZW50X2FwcHJvdmFscxgCIAEoBRIaChJyZXF1aXJlZF9hcHByb3ZhbHMYAyABKAUSEwoLaXNfYXBwcm92ZWQYBCABKAgiiwEKCUtleUhvbGRlchIKCgJpZBgBIAEoCRIMCgRuYW1lGAIgASgJEhIKCnB1YmxpY19rZXkYAyABKAkSDwoHYWRkcmVzcxgEIAEoCRItCglqb2luZWRfYXQYBSABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wEhAKCGlzX293bmVyGAYgASgIIn4KDUNvbnNlbnN1c0luZm8SEQoJdGhyZXNob2xkGAEgASgFEhIKCnRvdGFsX2tleXMYAiABKAUSLAoLa2V5X2hvbGRlcnMYAyADKAsyFy5haXJnYXBwZXIudjEuS2V5SG9sZGVyEhgKEHJlcXVpcmVfYXBwcm92YWwYBCABKAgiPwoEUGVlchIMCgRuYW1lGAEgASgJEg8KB2FkZHJlc3MYAiABKAkSGAoQaWRlbnRpdHlfY2hhbmdlZBgDIAEoCCo7CgRSb2xlEhQKEFJPTEVfVU5TUEVDSUZJRUQQABIOCgpST0xFX09XTkVSEAESDQoJUk9MRV9IT1NUEAIqugEKDVJlcXVlc3RTdGF0dXMSHgoaUkVRVUVTVF9TVEFUVVNfVU5TUEVDSUZJRUQQABIaChZSRVFVRVNUX1NUQVRVU19QRU5ESU5HEAESGwoXUkVRVUVTVF9TVEFUVVNfQVBQUk9WRUQQAhIZChVSRVFVRVNUX1NUQVRVU19ERU5JRUQQAxIaChZSRVFVRVNUX1NUQVRVU19FWFBJUkVEEAQSGQoVUkVRVUVTVF9TVEFUVVNfVkVUT0VEEAUqkQEKDERlbGV0aW9uVHlwZRIdChlERUxFVElPTl9UWVBFX1VOU1BFQ0lGSUVEEAASGgoWREVMRVRJT05fVFlQRV9TTkFQU0hPVBABEhYKEkRFTEVUSU9OX1RZUEVfUEFUSBACEhcKE0RFTEVUSU9OX1RZUEVfUFJVTkUQAxIVChFERUxFVElPTl9UWVBFX0FMTBAEKqcBCgxEZWxldGlvbk1vZGUSHQoZREVMRVRJT05fTU9ERV9VTlNQRUNJRklFRBAAEh8KG0RFTEVUSU9OX01PREVfQk9USF9SRVFVSVJFRBABEhwKGERFTEVUSU9OX01PREVfT1dORVJfT05MWRACEiAKHERFTEVUSU9OX01PREVfVElNRV9MT0NLX09OTFkQAxIXChNERUxFVElPTl9NT0RFX05FVkVSEAQqfgoNT3BlcmF0aW9uTW9kZRIeChpPUEVSQVRJT05fTU9ERV9VTlNQRUNJRklFRBAAEhcKE09QRVJBVElPTl9NT0RFX05PTkUQARIWChJPUEVSQVRJT05fTU9ERV9TU1MQAhIcChhPUEVSQVRJT05fTU9ERV9DT05TRU5TVVMQAypSCglDaGVja1R5cGUSGgoWQ0hFQ0tfVFlQRV9VTlNQRUNJRklFRBAAEhQKEENIRUNLX1RZUEVfUVVJQ0sQARITCg9DSEVDS19UWVBFX0ZVTEwQAmIGcHJvdG8z", [file_google_protobuf_timestamp]);
 
 /**
  * StatusMessage is a simple status response
@@ -94,6 +94,32 @@ export type Approval = Message<"airgapper.v1.Approval"> & {
    * @generated from field: google.protobuf.Timestamp approved_at = 4;
    */
   approvedAt?: Timestamp;
+
+  /**
+   * Key holder whose delegated authority was used
+   *
+   * @generated from field: string on_behalf_of = 5;
+   */
+  onBehalfOf: string;
+
+  /**
+   * @generated from field: string delegation_id = 6;
+   */
+  delegationId: string;
+
+  /**
+   * Challenge the approval signed
+   *
+   * @generated from field: string nonce = 7;
+   */
+  nonce: string;
+
+  /**
+   * When the key holder signed, by their clock
+   *
+   * @generated from field: google.protobuf.Timestamp signed_at = 8;
+   */
+  signedAt?: Timestamp;
 };
 
 /**
@@ -236,6 +262,13 @@ export type Peer = Message<"airgapper.v1.Peer"> & {
    * @generated from field: string address = 2;
    */
   address: string;
+
+  /**
+   * Presented a different key or TLS certificate than pinned; calls to it are blocked until repinned
+   *
+   * @generated from field: bool identity_changed = 3;
+   */
+  identityChanged: boolean;
 };
 
 /**
@@ -303,6 +336,11 @@ export enum RequestStatus {
    * @generated from enum value: REQUEST_STATUS_EXPIRED = 4;
    */
   EXPIRED = 4,
+
+  /**
+   * @generated from enum value: REQUEST_STATUS_VETOED = 5;
+   */
+  VETOED = 5,
 }
 
 /**
@@ -14,7 +14,7 @@ import type { Message } from "@bufbuild/protobuf";
  * Describes the file airgapper/v1/deletions.proto.
  */
 export const file_airgapper_v1_deletions: GenFile = /*@__PURE__*/
-  fileDesc("ChxhaXJnYXBwZXIvdjEvZGVsZXRpb25zLnByb3RvEgxhaXJnYXBwZXIudjEi6QMKD0RlbGV0aW9uUmVxdWVzdBIKCgJpZBgBIAEoCRIRCglyZXF1ZXN0ZXIYAiABKAkSMQoNZGVsZXRpb25fdHlwZRgDIAEoDjIaLmFpcmdhcHBlci52MS5EZWxldGlvblR5cGUSFAoMc25hcHNob3RfaWRzGAQgAygJEg0KBXBhdGhzGAUgAygJEg4KBnJlYXNvbhgGIAEoCRIrCgZzdGF0dXMYByABKA4yGy5haXJnYXBwZXIudjEuUmVxdWVzdFN0YXR1cxIuCgpjcmVhdGVkX2F0GAggASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcBIuCgpleHBpcmVzX2F0GAkgASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcBIvCgthcHByb3ZlZF9hdBgKIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXASLwoLZXhlY3V0ZWRfYXQYCyABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wEhoKEnJlcXVpcmVkX2FwcHJvdmFscxgMIAEoBRIZChFjdXJyZW50X2FwcHJvdmFscxgNIAEoBRIpCglhcHByb3ZhbHMYDiADKAsyFi5haXJnYXBwZXIudjEuQXBwcm92YWwiSgoUTGlzdERlbGV0aW9uc1JlcXVlc3QSMgoNc3RhdHVzX2ZpbHRlchgBIAEoDjIbLmFpcmdhcHBlci52MS5SZXF1ZXN0U3RhdHVzIkkKFUxpc3REZWxldGlvbnNSZXNwb25zZRIwCglkZWxldGlvbnMYASADKAsyHS5haXJnYXBwZXIudjEuRGVsZXRpb25SZXF1ZXN0IiAKEkdldERlbGV0aW9uUmVxdWVzdBIKCgJpZBgBIAEoCSJGChNHZXREZWxldGlvblJlc3BvbnNlEi8KCGRlbGV0aW9uGAEgASgLMh0uYWlyZ2FwcGVyLnYxLkRlbGV0aW9uUmVxdWVzdCKbAQoVQ3JlYXRlRGVsZXRpb25SZXF1ZXN0EjEKDWRlbGV0aW9uX3R5cGUYASABKA4yGi5haXJnYXBwZXIudjEuRGVsZXRpb25UeXBlEhQKDHNuYXBzaG90X2lkcxgCIAMoCRINCgVwYXRocxgDIAMoCRIOCgZyZWFzb24YBCABKAkSGgoScmVxdWlyZWRfYXBwcm92YWxzGAUgASgFImQKFkNyZWF0ZURlbGV0aW9uUmVzcG9uc2USCgoCaWQYASABKAkSDgoGc3RhdHVzGAIgASgJEi4KCmV4cGlyZXNfYXQYAyABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wIk4KFkFwcHJvdmVEZWxldGlvblJlcXVlc3QSCgoCaWQYASABKAkSFQoNa2V5X2hvbGRlcl9pZBgCIAEoCRIRCglzaWduYXR1cmUYAyABKAkidQoXQXBwcm92ZURlbGV0aW9uUmVzcG9uc2USDgoGc3RhdHVzGAEgASgJEhkKEWN1cnJlbnRfYXBwcm92YWxzGAIgASgFEhoKEnJlcXVpcmVkX2FwcHJvdmFscxgDIAEoBRITCgtpc19hcHByb3ZlZBgEIAEoCCIhChNEZW55RGVsZXRpb25SZXF1ZXN0EgoKAmlkGAEgASgJIiYKFERlbnlEZWxldGlvblJlc3BvbnNlEg4KBnN0YXR1cxgBIAEoCTLTAwoPRGVsZXRpb25TZXJ2aWNlElgKDUxpc3REZWxldGlvbnMSIi5haXJnYXBwZXIudjEuTGlzdERlbGV0aW9uc1JlcXVlc3QaIy5haXJnYXBwZXIudjEuTGlzdERlbGV0aW9uc1Jlc3BvbnNlElIKC0dldERlbGV0aW9uEiAuYWlyZ2FwcGVyLnYxLkdldERlbGV0aW9uUmVxdWVzdBohLmFpcmdhcHBlci52MS5HZXREZWxldGlvblJlc3BvbnNlElsKDkNyZWF0ZURlbGV0aW9uEiMuYWlyZ2FwcGVyLnYxLkNyZWF0ZURlbGV0aW9uUmVxdWVzdBokLmFpcmdhcHBlci52MS5DcmVhdGVEZWxldGlvblJlc3BvbnNlEl4KD0FwcHJvdmVEZWxldGlvbhIkLmFpcmdhcHBlci52MS5BcHByb3ZlRGVsZXRpb25SZXF1ZXN0GiUuYWlyZ2FwcGVyLnYxLkFwcHJvdmVEZWxldGlvblJlc3BvbnNlElUKDERlbnlEZWxldGlvbhIhLmFpcmdhcHBlci52MS5EZW55RGVsZXRpb25SZXF1ZXN0GiIuYWlyZ2FwcGVyLnYxLkRlbnlEZWxldGlvblJlc3BvbnNlYgZwcm90bzM", [file_airgapper_v1_common, file_google_protobuf_timestamp]);
+  fileDesc("ChxhaXJnYXBwZXIvdjEvZGVsZXRpb25zLnByb3RvEgxhaXJnYXBwZXIudjEimQQKD0RlbGV0aW9uUmVxdWVzdBIKCgJpZBgBIAEoCRIRCglyZXF1ZXN0ZXIYAiABKAkSMQoNZGVsZXRpb25fdHlwZRgDIAEoDjIaLmFpcmdhcHBlci52MS5EZWxldGlvblR5cGUSFAoMc25hcHNob3RfaWRzGAQgAygJEg0KBXBhdGhzGAUgAygJEg4KBnJlYXNvbhgGIAEoCRIrCgZzdGF0dXMYByABKA4yGy5haXJnYXBwZXIudjEuUmVxdWVzdFN0YXR1cxIuCgpjcmVhdGVkX2F0GAggASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcBIuCgpleHBpcmVzX2F0GAkgASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcBIvCgthcHByb3ZlZF9hdBgKIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXASLwoLZXhlY3V0ZWRfYXQYCyABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wEhoKEnJlcXVpcmVkX2FwcHJvdmFscxgMIAEoBRIZChFjdXJyZW50X2FwcHJvdmFscxgNIAEoBRIpCglhcHByb3ZhbHMYDiADKAsyFi5haXJnYXBwZXIudjEuQXBwcm92YWwSLgoJcmV0ZW50aW9uGA8gASgLMhsuYWlyZ2FwcGVyLnYxLlJldGVudGlvblBsYW4iewoNUmV0ZW50aW9uUGxhbhIOCgZwb2xpY3kYASABKAkSKwoEa2VlcBgCIAMoCzIdLmFpcmdhcHBlci52MS5QbGFubmVkU25hcHNob3QSLQoGcmVtb3ZlGAMgAygLMh0uYWlyZ2FwcGVyLnYxLlBsYW5uZWRTbmFwc2hvdCJmCg9QbGFubmVkU25hcHNob3QSCgoCaWQYASABKAkSKAoEdGltZRgCIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXASDQoFcGF0aHMYAyADKAkSDgoGcGlubmVkGAQgASgIIkoKFExpc3REZWxldGlvbnNSZXF1ZXN0EjIKDXN0YXR1c19maWx0ZXIYASABKA4yGy5haXJnYXBwZXIudjEuUmVxdWVzdFN0YXR1cyJJChVMaXN0RGVsZXRpb25zUmVzcG9uc2USMAoJZGVsZXRpb25zGAEgAygLMh0uYWlyZ2FwcGVyLnYxLkRlbGV0aW9uUmVxdWVzdCIgChJHZXREZWxldGlvblJlcXVlc3QSCgoCaWQYASABKAkiRgoTR2V0RGVsZXRpb25SZXNwb25zZRIvCghkZWxldGlvbhgBIAEoCzIdLmFpcmdhcHBlci52MS5EZWxldGlvblJlcXVlc3QitAEKFUNyZWF0ZURlbGV0aW9uUmVxdWVzdBIxCg1kZWxldGlvbl90eXBlGAEgASgOMhouYWlyZ2FwcGVyLnYxLkRlbGV0aW9uVHlwZRIUCgxzbmFwc2hvdF9pZHMYAiADKAkSDQoFcGF0aHMYAyADKAkSDgoGcmVhc29uGAQgASgJEhoKEnJlcXVpcmVkX2FwcHJvdmFscxgFIAEoBRIXCg9hcHBseV9yZXRlbnRpb24YBiABKAgiZAoWQ3JlYXRlRGVsZXRpb25SZXNwb25zZRIKCgJpZBgBIAEoCRIOCgZzdGF0dXMYAiABKAkSLgoKZXhwaXJlc19hdBgDIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXAiTgoWQXBwcm92ZURlbGV0aW9uUmVxdWVzdBIKCgJpZBgBIAEoCRIVCg1rZXlfaG9sZGVyX2lkGAIgASgJEhEKCXNpZ25hdHVyZRgDIAEoCSJ1ChdBcHByb3ZlRGVsZXRpb25SZXNwb25zZRIOCgZzdGF0dXMYASABKAkSGQoRY3VycmVudF9hcHByb3ZhbHMYAiABKAUSGgoScmVxdWlyZWRfYXBwcm92YWxzGAMgASgFEhMKC2lzX2FwcHJvdmVkGAQgASgIIiEKE0RlbnlEZWxldGlvblJlcXVlc3QSCgoCaWQYASABKAkiJgoURGVueURlbGV0aW9uUmVzcG9uc2USDgoGc3RhdHVzGAEgASgJMtMDCg9EZWxldGlvblNlcnZpY2USWAoNTGlzdERlbGV0aW9ucxIiLmFpcmdhcHBlci52MS5MaXN0RGVsZXRpb25zUmVxdWVzdBojLmFpcmdhcHBlci52MS5MaXN0RGVsZXRpb25zUmVzcG9uc2USUgoLR2V0RGVsZXRpb24SIC5haXJnYXBwZXIudjEuR2V0RGVsZXRpb25SZXF1ZXN0GiEuYWlyZ2FwcGVyLnYxLkdldERlbGV0aW9uUmVzcG9uc2USWwoOQ3JlYXRlRGVsZXRpb24SIy5haXJnYXBwZXIudjEuQ3JlYXRlRGVsZXRpb25SZXF1ZXN0GiQuYWlyZ2FwcGVyLnYxLkNyZWF0ZURlbGV0aW9uUmVzcG9uc2USXgoPQXBwcm92ZURlbGV0aW9uEiQuYWlyZ2FwcGVyLnYxLkFwcHJvdmVEZWxldGlvblJlcXVlc3QaJS5haXJnYXBwZXIudjEuQXBwcm92ZURlbGV0aW9uUmVzcG9uc2USVQoMRGVueURlbGV0aW9uEiEuYWlyZ2FwcGVyLnYxLkRlbnlEZWxldGlvblJlcXVlc3QaIi5haXJnYXBwZXIudjEuRGVueURlbGV0aW9uUmVzcG9uc2ViBnByb3RvMw", [file_airgapper_v1_common, file_google_protobuf_timestamp]);
 
 /**
  * DeletionRequest represents a request to delete data
@@ -91,6 +91,14 @@ export type DeletionRequest = Message<"airgapper.v1.DeletionRequest"> & {
    * @generated from field: repeated airgapper.v1.Approval approvals = 14;
    */
   approvals: Approval[];
+
+  /**
+   * What the retention policy kept and removed when the request was
+   * created, for a request applying it
+   *
+   * @generated from field: airgapper.v1.RetentionPlan retention = 15;
+   */
+  retention?: RetentionPlan;
 };
 
 /**
@@ -100,6 +108,73 @@ export type DeletionRequest = Message<"airgapper.v1.DeletionRequest"> & {
 export const DeletionRequestSchema: GenMessage<DeletionRequest> = /*@__PURE__*/
   messageDesc(file_airgapper_v1_deletions, 0);
 
+/**
+ * RetentionPlan is what a retention policy keeps and removes
+ *
+ * @generated from message airgapper.v1.RetentionPlan
+ */
+export type RetentionPlan = Message<"airgapper.v1.RetentionPlan"> & {
+  /**
+   * e.g. "7 daily, 4 weekly"
+   *
+   * @generated from field: string policy = 1;
+   */
+  policy: string;
+
+  /**
+   * @generated from field: repeated airgapper.v1.PlannedSnapshot keep = 2;
+   */
+  keep: PlannedSnapshot[];
+
+  /**
+   * @generated from field: repeated airgapper.v1.PlannedSnapshot remove = 3;
+   */
+  remove: PlannedSnapshot[];
+};
+
+/**
+ * Describes the message airgapper.v1.RetentionPlan.
+ * Use `create(RetentionPlanSchema)` to create a new message.
+ */
+export const RetentionPlanSchema: GenMessage<RetentionPlan> = /*@__PURE__*/
+  messageDesc(file_airgapper_v1_deletions, 1);
+
+/**
+ * PlannedSnapshot is a snapshot in a RetentionPlan
+ *
+ * @generated from message airgapper.v1.PlannedSnapshot
+ */
+export type PlannedSnapshot = Message<"airgapper.v1.PlannedSnapshot"> & {
+  /**
+   * @generated from field: string id = 1;
+   */
+  id: string;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp time = 2;
+   */
+  time?: Timestamp;
+
+  /**
+   * @generated from field: repeated string paths = 3;
+   */
+  paths: string[];
+
+  /**
+   * Kept only because it is pinned
+   *
+   * @generated from field: bool pinned = 4;
+   */
+  pinned: boolean;
+};
+
+/**
+ * Describes the message airgapper.v1.PlannedSnapshot.
+ * Use `create(PlannedSnapshotSchema)` to create a new message.
+ */
+export const PlannedSnapshotSchema: GenMessage<PlannedSnapshot> = /*@__PURE__*/
+  messageDesc(file_airgapper_v1_deletions, 2);
+
 /**
  * @generated from message airgapper.v1.ListDeletionsRequest
  */
@@ -117,7 +192,7 @@ export type ListDeletionsRequest = Message<"airgapper.v1.ListDeletionsRequest">
  * Use `create(ListDeletionsRequestSchema)` to create a new message.
  */
 export const ListDeletionsRequestSchema: GenMessage<ListDeletionsRequest> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_deletions, 1);
+  messageDesc(file_airgapper_v1_deletions, 3);
 
 /**
  * @generated from message airgapper.v1.ListDeletionsResponse
@@ -134,7 +209,7 @@ export type ListDeletionsResponse = Message<"airgapper.v1.ListDeletionsResponse"
  * Use `create(ListDeletionsResponseSchema)` to create a new message.
  */
 export const ListDeletionsResponseSchema: GenMessage<ListDeletionsResponse> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_deletions, 2);
+  messageDesc(file_airgapper_v1_deletions, 4);
 
 /**
  * @generated from message airgapper.v1.GetDeletionRequest
@@ -151,7 +226,7 @@ export type GetDeletionRequest = Message<"airgapper.v1.GetDeletionRequest"> & {
  * Use `create(GetDeletionRequestSchema)` to create a new message.
  */
 export const GetDeletionRequestSchema: GenMessage<GetDeletionRequest> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_deletions, 3);
+  messageDesc(file_airgapper_v1_deletions, 5);
 
 /**
  * @generated from message airgapper.v1.GetDeletionResponse
@@ -168,7 +243,7 @@ export type GetDeletionResponse = Message<"airgapper.v1.GetDeletionResponse"> &
  * Use `create(GetDeletionResponseSchema)` to create a new message.
  */
 export const GetDeletionResponseSchema: GenMessage<GetDeletionResponse> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_deletions, 4);
+  messageDesc(file_airgapper_v1_deletions, 6);
 
 /**
  * @generated from message airgapper.v1.CreateDeletionRequest
@@ -198,6 +273,14 @@ export type CreateDeletionRequest = Message<"airgapper.v1.CreateDeletionRequest"
    * @generated from field: int32 required_approvals = 5;
    */
   requiredApprovals: number;
+
+  /**
+   * Forget what the configured retention policy removes, previewed now and
+   * attached for approvers to see (prune deletions only)
+   *
+   * @generated from field: bool apply_retention = 6;
+   */
+  applyRetention: boolean;
 };
 
 /**
@@ -205,7 +288,7 @@ export type CreateDeletionRequest = Message<"airgapper.v1.CreateDeletionRequest"
  * Use `create(CreateDeletionRequestSchema)` to create a new message.
  */
 export const CreateDeletionRequestSchema: GenMessage<CreateDeletionRequest> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_deletions, 5);
+  messageDesc(file_airgapper_v1_deletions, 7);
 
 /**
  * @generated from message airgapper.v1.CreateDeletionResponse
@@ -232,7 +315,7 @@ export type CreateDeletionResponse = Message<"airgapper.v1.CreateDeletionRespons
  * Use `create(CreateDeletionResponseSchema)` to create a new message.
  */
 export const CreateDeletionResponseSchema: GenMessage<CreateDeletionResponse> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_deletions, 6);
+  messageDesc(file_airgapper_v1_deletions, 8);
 
 /**
  * @generated from message airgapper.v1.ApproveDeletionRequest
@@ -261,7 +344,7 @@ export type ApproveDeletionRequest = Message<"airgapper.v1.ApproveDeletionReques
  * Use `create(ApproveDeletionRequestSchema)` to create a new message.
  */
 export const ApproveDeletionRequestSchema: GenMessage<ApproveDeletionRequest> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_deletions, 7);
+  messageDesc(file_airgapper_v1_deletions, 9);
 
 /**
  * @generated from message airgapper.v1.ApproveDeletionResponse
@@ -293,7 +376,7 @@ export type ApproveDeletionResponse = Message<"airgapper.v1.ApproveDeletionRespo
  * Use `create(ApproveDeletionResponseSchema)` to create a new message.
  */
 export const ApproveDeletionResponseSchema: GenMessage<ApproveDeletionResponse> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_deletions, 8);
+  messageDesc(file_airgapper_v1_deletions, 10);
 
 /**
  * @generated from message airgapper.v1.DenyDeletionRequest
@@ -310,7 +393,7 @@ export type DenyDeletionRequest = Message<"airgapper.v1.DenyDeletionRequest"> &
  * Use `create(DenyDeletionRequestSchema)` to create a new message.
  */
 export const DenyDeletionRequestSchema: GenMessage<DenyDeletionRequest> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_deletions, 9);
+  messageDesc(file_airgapper_v1_deletions, 11);
 
 /**
  * @generated from message airgapper.v1.DenyDeletionResponse
@@ -327,7 +410,7 @@ export type DenyDeletionResponse = Message<"airgapper.v1.DenyDeletionResponse">
  * Use `create(DenyDeletionResponseSchema)` to create a new message.
  */
 export const DenyDeletionResponseSchema: GenMessage<DenyDeletionResponse> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_deletions, 10);
+  messageDesc(file_airgapper_v1_deletions, 12);
 
 /**
  * DeletionService handles deletion request management
@@ -14,7 +14,7 @@ import type { Message } from "@bufbuild/protobuf";
  * Describes the file airgapper/v1/health.proto.
  */
 export const file_airgapper_v1_health: GenFile = /*@__PURE__*/
-  fileDesc("ChlhaXJnYXBwZXIvdjEvaGVhbHRoLnByb3RvEgxhaXJnYXBwZXIudjEiDgoMQ2hlY2tSZXF1ZXN0Ih8KDUNoZWNrUmVzcG9uc2USDgoGc3RhdHVzGAEgASgJIhIKEEdldFN0YXR1c1JlcXVlc3QisQEKDVNjaGVkdWxlckluZm8SDwoHZW5hYmxlZBgBIAEoCBIQCghzY2hlZHVsZRgCIAEoCRINCgVwYXRocxgDIAMoCRIsCghsYXN0X3J1bhgEIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXASLAoIbmV4dF9ydW4YBSABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wEhIKCmxhc3RfZXJyb3IYBiABKAki2gIKEUdldFN0YXR1c1Jlc3BvbnNlEgwKBG5hbWUYASABKAkSIAoEcm9sZRgCIAEoDjISLmFpcmdhcHBlci52MS5Sb2xlEhAKCHJlcG9fdXJsGAMgASgJEhEKCWhhc19zaGFyZRgEIAEoCBITCgtzaGFyZV9pbmRleBgFIAEoBRIYChBwZW5kaW5nX3JlcXVlc3RzGAYgASgFEhQKDGJhY2t1cF9wYXRocxgHIAMoCRIpCgRtb2RlGAggASgOMhsuYWlyZ2FwcGVyLnYxLk9wZXJhdGlvbk1vZGUSIAoEcGVlchgJIAEoCzISLmFpcmdhcHBlci52MS5QZWVyEi4KCWNvbnNlbnN1cxgKIAEoCzIbLmFpcmdhcHBlci52MS5Db25zZW5zdXNJbmZvEi4KCXNjaGVkdWxlchgLIAEoCzIbLmFpcmdhcHBlci52MS5TY2hlZHVsZXJJbmZvMp8BCg1IZWFsdGhTZXJ2aWNlEkAKBUNoZWNrEhouYWlyZ2FwcGVyLnYxLkNoZWNrUmVxdWVzdBobLmFpcmdhcHBlci52MS5DaGVja1Jlc3BvbnNlEkwKCUdldFN0YXR1cxIeLmFpcmdhcHBlci52MS5HZXRTdGF0dXNSZXF1ZXN0Gh8uYWlyZ2FwcGVyLnYxLkdldFN0YXR1c1Jlc3BvbnNlYgZwcm90bzM", [file_airgapper_v1_common, file_google_protobuf_timestamp]);
+  fileDesc("ChlhaXJnYXBwZXIvdjEvaGVhbHRoLnByb3RvEgxhaXJnYXBwZXIudjEiDgoMQ2hlY2tSZXF1ZXN0Ih8KDUNoZWNrUmVzcG9uc2USDgoGc3RhdHVzGAEgASgJIhIKEEdldFN0YXR1c1JlcXVlc3QijQIKDVNjaGVkdWxlckluZm8SDwoHZW5hYmxlZBgBIAEoCBIQCghzY2hlZHVsZRgCIAEoCRINCgVwYXRocxgDIAMoCRIsCghsYXN0X3J1bhgEIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXASLAoIbmV4dF9ydW4YBSABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wEhIKCmxhc3RfZXJyb3IYBiABKAkSDwoHaGVhbHRoeRgHIAEoCBIcChRjb25zZWN1dGl2ZV9mYWlsdXJlcxgIIAEoBRIZChFmYWlsdXJlX3RocmVzaG9sZBgJIAEoBRIQCgh0aW1lem9uZRgKIAEoCSKcAgoVUmVwbGljYXRpb25UYXJnZXRJbmZvEgwKBG5hbWUYASABKAkSEAoIcmVwb191cmwYAiABKAkSDwoHZW5hYmxlZBgDIAEoCBIsCghsYXN0X3J1bhgEIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXASMAoMbGFzdF9zdWNjZXNzGAUgASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcBISCgpsYXN0X2Vycm9yGAYgASgJEhgKEHNvdXJjZV9zbmFwc2hvdHMYByABKAUSGAoQdGFyZ2V0X3NuYXBzaG90cxgIIAEoBRIZChFtaXNzaW5nX3NuYXBzaG90cxgJIAEoBRIPCgdpbl9zeW5jGAogASgIIrkEChFHZXRTdGF0dXNSZXNwb25zZRIMCgRuYW1lGAEgASgJEiAKBHJvbGUYAiABKA4yEi5haXJnYXBwZXIudjEuUm9sZRIQCghyZXBvX3VybBgDIAEoCRIRCgloYXNfc2hhcmUYBCABKAgSEwoLc2hhcmVfaW5kZXgYBSABKAUSGAoQcGVuZGluZ19yZXF1ZXN0cxgGIAEoBRIUCgxiYWNrdXBfcGF0aHMYByADKAkSKQoEbW9kZRgIIAEoDjIbLmFpcmdhcHBlci52MS5PcGVyYXRpb25Nb2RlEiAKBHBlZXIYCSABKAsyEi5haXJnYXBwZXIudjEuUGVlchIuCgljb25zZW5zdXMYCiABKAsyGy5haXJnYXBwZXIudjEuQ29uc2Vuc3VzSW5mbxIuCglzY2hlZHVsZXIYCyABKAsyGy5haXJnYXBwZXIudjEuU2NoZWR1bGVySW5mbxI4CgtyZXBsaWNhdGlvbhgMIAMoCzIjLmFpcmdhcHBlci52MS5SZXBsaWNhdGlvblRhcmdldEluZm8SLwoLc2VydmVyX3RpbWUYDSABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wEhkKEXN0b3JhZ2VfcmVhZF9vbmx5GA4gASgIEiIKGnN0b3JhZ2VfbWFpbnRlbmFuY2VfcmVhc29uGA8gASgJEhMKC2FwaV92ZXJzaW9uGBAgASgJEh4KFnN1cHBvcnRlZF9hcGlfdmVyc2lvbnMYESADKAkynwEKDUhlYWx0aFNlcnZpY2USQAoFQ2hlY2sSGi5haXJnYXBwZXIudjEuQ2hlY2tSZXF1ZXN0GhsuYWlyZ2FwcGVyLnYxLkNoZWNrUmVzcG9uc2USTAoJR2V0U3RhdHVzEh4uYWlyZ2FwcGVyLnYxLkdldFN0YXR1c1JlcXVlc3QaHy5haXJnYXBwZXIudjEuR2V0U3RhdHVzUmVzcG9uc2ViBnByb3RvMw", [file_airgapper_v1_common, file_google_protobuf_timestamp]);
 
 /**
  * @generated from message airgapper.v1.CheckRequest
@@ -96,6 +96,30 @@ export type SchedulerInfo = Message<"airgapper.v1.SchedulerInfo"> & {
    * @generated from field: string last_error = 6;
    */
   lastError: string;
+
+  /**
+   * False once consecutive failed runs reach failure_threshold
+   *
+   * @generated from field: bool healthy = 7;
+   */
+  healthy: boolean;
+
+  /**
+   * @generated from field: int32 consecutive_failures = 8;
+   */
+  consecutiveFailures: number;
+
+  /**
+   * @generated from field: int32 failure_threshold = 9;
+   */
+  failureThreshold: number;
+
+  /**
+   * IANA timezone the schedule's times are in, "Local" for the system's
+   *
+   * @generated from field: string timezone = 10;
+   */
+  timezone: string;
 };
 
 /**
@@ -105,6 +129,73 @@ export type SchedulerInfo = Message<"airgapper.v1.SchedulerInfo"> & {
 export const SchedulerInfoSchema: GenMessage<SchedulerInfo> = /*@__PURE__*/
   messageDesc(file_airgapper_v1_health, 3);
 
+/**
+ * ReplicationTargetInfo contains the status of a secondary repository that
+ * snapshots are copied to
+ *
+ * @generated from message airgapper.v1.ReplicationTargetInfo
+ */
+export type ReplicationTargetInfo = Message<"airgapper.v1.ReplicationTargetInfo"> & {
+  /**
+   * @generated from field: string name = 1;
+   */
+  name: string;
+
+  /**
+   * @generated from field: string repo_url = 2;
+   */
+  repoUrl: string;
+
+  /**
+   * @generated from field: bool enabled = 3;
+   */
+  enabled: boolean;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp last_run = 4;
+   */
+  lastRun?: Timestamp;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp last_success = 5;
+   */
+  lastSuccess?: Timestamp;
+
+  /**
+   * @generated from field: string last_error = 6;
+   */
+  lastError: string;
+
+  /**
+   * @generated from field: int32 source_snapshots = 7;
+   */
+  sourceSnapshots: number;
+
+  /**
+   * @generated from field: int32 target_snapshots = 8;
+   */
+  targetSnapshots: number;
+
+  /**
+   * @generated from field: int32 missing_snapshots = 9;
+   */
+  missingSnapshots: number;
+
+  /**
+   * false when the copy diverged from the primary
+   *
+   * @generated from field: bool in_sync = 10;
+   */
+  inSync: boolean;
+};
+
+/**
+ * Describes the message airgapper.v1.ReplicationTargetInfo.
+ * Use `create(ReplicationTargetInfoSchema)` to create a new message.
+ */
+export const ReplicationTargetInfoSchema: GenMessage<ReplicationTargetInfo> = /*@__PURE__*/
+  messageDesc(file_airgapper_v1_health, 4);
+
 /**
  * @generated from message airgapper.v1.GetStatusResponse
  */
@@ -163,6 +254,43 @@ export type GetStatusResponse = Message<"airgapper.v1.GetStatusResponse"> & {
    * @generated from field: airgapper.v1.SchedulerInfo scheduler = 11;
    */
   scheduler?: SchedulerInfo;
+
+  /**
+   * @generated from field: repeated airgapper.v1.ReplicationTargetInfo replication = 12;
+   */
+  replication: ReplicationTargetInfo[];
+
+  /**
+   * The server's clock, so peers can detect clock skew
+   *
+   * @generated from field: google.protobuf.Timestamp server_time = 13;
+   */
+  serverTime?: Timestamp;
+
+  /**
+   * Whether this node's storage server is in read-only maintenance mode
+   *
+   * @generated from field: bool storage_read_only = 14;
+   */
+  storageReadOnly: boolean;
+
+  /**
+   * @generated from field: string storage_maintenance_reason = 15;
+   */
+  storageMaintenanceReason: string;
+
+  /**
+   * The API version this node serves and every version it accepts, as
+   * GET /api/v1/version reports them
+   *
+   * @generated from field: string api_version = 16;
+   */
+  apiVersion: string;
+
+  /**
+   * @generated from field: repeated string supported_api_versions = 17;
+   */
+  supportedApiVersions: string[];
 };
 
 /**
@@ -170,7 +298,7 @@ export type GetStatusResponse = Message<"airgapper.v1.GetStatusResponse"> & {
  * Use `create(GetStatusResponseSchema)` to create a new message.
  */
 export const GetStatusResponseSchema: GenMessage<GetStatusResponse> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_health, 4);
+  messageDesc(file_airgapper_v1_health, 5);
 
 /**
  * HealthService provides health and status endpoints
@@ -14,7 +14,7 @@ import type { Message } from "@bufbuild/protobuf";
  * Describes the file airgapper/v1/requests.proto.
  */
 export const file_airgapper_v1_requests: GenFile = /*@__PURE__*/
-  fileDesc("ChthaXJnYXBwZXIvdjEvcmVxdWVzdHMucHJvdG8SDGFpcmdhcHBlci52MSL9AgoOUmVzdG9yZVJlcXVlc3QSCgoCaWQYASABKAkSEQoJcmVxdWVzdGVyGAIgASgJEhMKC3NuYXBzaG90X2lkGAMgASgJEg0KBXBhdGhzGAQgAygJEg4KBnJlYXNvbhgFIAEoCRIrCgZzdGF0dXMYBiABKA4yGy5haXJnYXBwZXIudjEuUmVxdWVzdFN0YXR1cxIuCgpjcmVhdGVkX2F0GAcgASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcBIuCgpleHBpcmVzX2F0GAggASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcBIvCgthcHByb3ZlZF9hdBgJIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXASEwoLYXBwcm92ZWRfYnkYCiABKAkSGgoScmVxdWlyZWRfYXBwcm92YWxzGAsgASgFEikKCWFwcHJvdmFscxgMIAMoCzIWLmFpcmdhcHBlci52MS5BcHByb3ZhbCJJChNMaXN0UmVxdWVzdHNSZXF1ZXN0EjIKDXN0YXR1c19maWx0ZXIYASABKA4yGy5haXJnYXBwZXIudjEuUmVxdWVzdFN0YXR1cyJGChRMaXN0UmVxdWVzdHNSZXNwb25zZRIuCghyZXF1ZXN0cxgBIAMoCzIcLmFpcmdhcHBlci52MS5SZXN0b3JlUmVxdWVzdCIfChFHZXRSZXF1ZXN0UmVxdWVzdBIKCgJpZBgBIAEoCSJDChJHZXRSZXF1ZXN0UmVzcG9uc2USLQoHcmVxdWVzdBgBIAEoCzIcLmFpcmdhcHBlci52MS5SZXN0b3JlUmVxdWVzdCJKChRDcmVhdGVSZXF1ZXN0UmVxdWVzdBITCgtzbmFwc2hvdF9pZBgBIAEoCRINCgVwYXRocxgCIAMoCRIOCgZyZWFzb24YAyABKAkiYwoVQ3JlYXRlUmVxdWVzdFJlc3BvbnNlEgoKAmlkGAEgASgJEg4KBnN0YXR1cxgCIAEoCRIuCgpleHBpcmVzX2F0GAMgASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcCJHChVBcHByb3ZlUmVxdWVzdFJlcXVlc3QSCgoCaWQYASABKAkSDQoFc2hhcmUYAiABKAwSEwoLc2hhcmVfaW5kZXgYAyABKAUiOQoWQXBwcm92ZVJlcXVlc3RSZXNwb25zZRIOCgZzdGF0dXMYASABKAkSDwoHbWVzc2FnZRgCIAEoCSJKChJTaWduUmVxdWVzdFJlcXVlc3QSCgoCaWQYASABKAkSFQoNa2V5X2hvbGRlcl9pZBgCIAEoCRIRCglzaWduYXR1cmUYAyABKAkicQoTU2lnblJlcXVlc3RSZXNwb25zZRIOCgZzdGF0dXMYASABKAkSGQoRY3VycmVudF9hcHByb3ZhbHMYAiABKAUSGgoScmVxdWlyZWRfYXBwcm92YWxzGAMgASgFEhMKC2lzX2FwcHJvdmVkGAQgASgIIiAKEkRlbnlSZXF1ZXN0UmVxdWVzdBIKCgJpZBgBIAEoCSIlChNEZW55UmVxdWVzdFJlc3BvbnNlEg4KBnN0YXR1cxgBIAEoCTKeBAoVUmVzdG9yZVJlcXVlc3RTZXJ2aWNlElUKDExpc3RSZXF1ZXN0cxIhLmFpcmdhcHBlci52MS5MaXN0UmVxdWVzdHNSZXF1ZXN0GiIuYWlyZ2FwcGVyLnYxLkxpc3RSZXF1ZXN0c1Jlc3BvbnNlEk8KCkdldFJlcXVlc3QSHy5haXJnYXBwZXIudjEuR2V0UmVxdWVzdFJlcXVlc3QaIC5haXJnYXBwZXIudjEuR2V0UmVxdWVzdFJlc3BvbnNlElgKDUNyZWF0ZVJlcXVlc3QSIi5haXJnYXBwZXIudjEuQ3JlYXRlUmVxdWVzdFJlcXVlc3QaIy5haXJnYXBwZXIudjEuQ3JlYXRlUmVxdWVzdFJlc3BvbnNlElsKDkFwcHJvdmVSZXF1ZXN0EiMuYWlyZ2FwcGVyLnYxLkFwcHJvdmVSZXF1ZXN0UmVxdWVzdBokLmFpcmdhcHBlci52MS5BcHByb3ZlUmVxdWVzdFJlc3BvbnNlElIKC1NpZ25SZXF1ZXN0EiAuYWlyZ2FwcGVyLnYxLlNpZ25SZXF1ZXN0UmVxdWVzdBohLmFpcmdhcHBlci52MS5TaWduUmVxdWVzdFJlc3BvbnNlElIKC0RlbnlSZXF1ZXN0EiAuYWlyZ2FwcGVyLnYxLkRlbnlSZXF1ZXN0UmVxdWVzdBohLmFpcmdhcHBlci52MS5EZW55UmVxdWVzdFJlc3BvbnNlYgZwcm90bzM", [file_airgapper_v1_common, file_google_protobuf_timestamp]);
+  fileDesc("ChthaXJnYXBwZXIvdjEvcmVxdWVzdHMucHJvdG8SDGFpcmdhcHBlci52MSKvBgoOUmVzdG9yZVJlcXVlc3QSCgoCaWQYASABKAkSEQoJcmVxdWVzdGVyGAIgASgJEhMKC3NuYXBzaG90X2lkGAMgASgJEg0KBXBhdGhzGAQgAygJEg4KBnJlYXNvbhgFIAEoCRIrCgZzdGF0dXMYBiABKA4yGy5haXJnYXBwZXIudjEuUmVxdWVzdFN0YXR1cxIuCgpjcmVhdGVkX2F0GAcgASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcBIuCgpleHBpcmVzX2F0GAggASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcBIvCgthcHByb3ZlZF9hdBgJIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXASEwoLYXBwcm92ZWRfYnkYCiABKAkSGgoScmVxdWlyZWRfYXBwcm92YWxzGAsgASgFEikKCWFwcHJvdmFscxgMIAMoCzIWLmFpcmdhcHBlci52MS5BcHByb3ZhbBIxCgpleHRlbnNpb25zGA0gAygLMh0uYWlyZ2FwcGVyLnYxLkV4cGlyeUV4dGVuc2lvbhIxCg1leGVjdXRhYmxlX2F0GA4gASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcBIlCh1jb29saW5nX29mZl9yZW1haW5pbmdfc2Vjb25kcxgPIAEoAxIRCgl2ZXRvZWRfYnkYECABKAkSLQoJdmV0b2VkX2F0GBEgASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcBITCgt2ZXRvX3JlYXNvbhgSIAEoCRI5ChFyZXF1ZXN0ZXJfY29udGV4dBgTIAEoCzIeLmFpcmdhcHBlci52MS5SZXF1ZXN0ZXJDb250ZXh0EhIKCnNpemVfYnl0ZXMYFCABKAMSKQoFdGVybXMYFSADKAsyGi5haXJnYXBwZXIudjEuUmVzdG9yZVRlcm1zEi8KCHByb2dyZXNzGBYgASgLMh0uYWlyZ2FwcGVyLnYxLlJlc3RvcmVQcm9ncmVzcxIPCgdwdXJwb3NlGBcgASgJEhAKCHRlbXBsYXRlGBggASgJIm0KEFJlcXVlc3RlckNvbnRleHQSEAoIaG9zdG5hbWUYASABKAkSCgoCb3MYAiABKAkSDwoHdmVyc2lvbhgDIAEoCRIXCg9rZXlfZmluZ2VycHJpbnQYBCABKAkSEQoJc291cmNlX2lwGAUgASgJIkkKE0xpc3RSZXF1ZXN0c1JlcXVlc3QSMgoNc3RhdHVzX2ZpbHRlchgBIAEoDjIbLmFpcmdhcHBlci52MS5SZXF1ZXN0U3RhdHVzIkYKFExpc3RSZXF1ZXN0c1Jlc3BvbnNlEi4KCHJlcXVlc3RzGAEgAygLMhwuYWlyZ2FwcGVyLnYxLlJlc3RvcmVSZXF1ZXN0Ih8KEUdldFJlcXVlc3RSZXF1ZXN0EgoKAmlkGAEgASgJIkMKEkdldFJlcXVlc3RSZXNwb25zZRItCgdyZXF1ZXN0GAEgASgLMhwuYWlyZ2FwcGVyLnYxLlJlc3RvcmVSZXF1ZXN0Iq8CChRDcmVhdGVSZXF1ZXN0UmVxdWVzdBITCgtzbmFwc2hvdF9pZBgBIAEoCRINCgVwYXRocxgCIAMoCRIOCgZyZWFzb24YAyABKAkSCgoCaWQYBCABKAkSLgoKY3JlYXRlZF9hdBgFIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXASFQoNa2V5X2hvbGRlcl9pZBgGIAEoCRIRCglzaWduYXR1cmUYByABKAkSCwoDdHRsGAggASgJEjkKEXJlcXVlc3Rlcl9jb250ZXh0GAkgASgLMh4uYWlyZ2FwcGVyLnYxLlJlcXVlc3RlckNvbnRleHQSEgoKc2l6ZV9ieXRlcxgKIAEoAxIPCgdwdXJwb3NlGAsgASgJEhAKCHRlbXBsYXRlGAwgASgJImMKFUNyZWF0ZVJlcXVlc3RSZXNwb25zZRIKCgJpZBgBIAEoCRIOCgZzdGF0dXMYAiABKAkSLgoKZXhwaXJlc19hdBgDIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXAicgoVQXBwcm92ZVJlcXVlc3RSZXF1ZXN0EgoKAmlkGAEgASgJEg0KBXNoYXJlGAIgASgMEhMKC3NoYXJlX2luZGV4GAMgASgFEikKBXRlcm1zGAQgASgLMhouYWlyZ2FwcGVyLnYxLlJlc3RvcmVUZXJtcyI5ChZBcHByb3ZlUmVxdWVzdFJlc3BvbnNlEg4KBnN0YXR1cxgBIAEoCRIPCgdtZXNzYWdlGAIgASgJIjoKFUlzc3VlQ2hhbGxlbmdlUmVxdWVzdBIKCgJpZBgBIAEoCRIVCg1rZXlfaG9sZGVyX2lkGAIgASgJIlcKFklzc3VlQ2hhbGxlbmdlUmVzcG9uc2USDQoFbm9uY2UYASABKAkSLgoKZXhwaXJlc19hdBgCIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXAiygEKElNpZ25SZXF1ZXN0UmVxdWVzdBIKCgJpZBgBIAEoCRIVCg1rZXlfaG9sZGVyX2lkGAIgASgJEhEKCXNpZ25hdHVyZRgDIAEoCRIVCg1kZWxlZ2F0aW9uX2lkGAQgASgJEg0KBW5vbmNlGAUgASgJEi0KCXNpZ25lZF9hdBgGIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXASKQoFdGVybXMYByABKAsyGi5haXJnYXBwZXIudjEuUmVzdG9yZVRlcm1zInEKE1NpZ25SZXF1ZXN0UmVzcG9uc2USDgoGc3RhdHVzGAEgASgJEhkKEWN1cnJlbnRfYXBwcm92YWxzGAIgASgFEhoKEnJlcXVpcmVkX2FwcHJvdmFscxgDIAEoBRITCgtpc19hcHByb3ZlZBgEIAEoCCIgChJEZW55UmVxdWVzdFJlcXVlc3QSCgoCaWQYASABKAkiJQoTRGVueVJlcXVlc3RSZXNwb25zZRIOCgZzdGF0dXMYASABKAkirAEKD0V4cGlyeUV4dGVuc2lvbhIRCglleHRlbmRfYnkYASABKAkSDgoGcmVhc29uGAIgASgJEjAKDHJlcXVlc3RlZF9hdBgDIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXASEwoLYXBwcm92ZWRfYnkYBCABKAkSLwoLYXBwcm92ZWRfYXQYBSABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wIkgKF1JlcXVlc3RFeHRlbnNpb25SZXF1ZXN0EgoKAmlkGAEgASgJEhEKCWV4dGVuZF9ieRgCIAEoCRIOCgZyZWFzb24YAyABKAkiTAoYUmVxdWVzdEV4dGVuc2lvblJlc3BvbnNlEjAKCWV4dGVuc2lvbhgBIAEoCzIdLmFpcmdhcHBlci52MS5FeHBpcnlFeHRlbnNpb24ijQEKF0FwcHJvdmVFeHRlbnNpb25SZXF1ZXN0EgoKAmlkGAEgASgJEhUKDWtleV9ob2xkZXJfaWQYAiABKAkSEQoJc2lnbmF0dXJlGAMgASgJEg0KBW5vbmNlGAQgASgJEi0KCXNpZ25lZF9hdBgFIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXAiSgoYQXBwcm92ZUV4dGVuc2lvblJlc3BvbnNlEi4KCmV4cGlyZXNfYXQYASABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wIpgBChJWZXRvUmVxdWVzdFJlcXVlc3QSCgoCaWQYASABKAkSFQoNa2V5X2hvbGRlcl9pZBgCIAEoCRIOCgZyZWFzb24YAyABKAkSEQoJc2lnbmF0dXJlGAQgASgJEg0KBW5vbmNlGAUgASgJEi0KCXNpZ25lZF9hdBgGIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXAiJQoTVmV0b1JlcXVlc3RSZXNwb25zZRIOCgZzdGF0dXMYASABKAkiVwoMUmVzdG9yZVRlcm1zEg4KBndpbmRvdxgBIAEoCRISCgp1dGNfb2Zmc2V0GAIgASgFEhMKC2xpbWl0X2tpYnBzGAMgASgFEg4KBnNldF9ieRgEIAEoCSKRAgoPUmVzdG9yZVByb2dyZXNzEg4KBnN0YXR1cxgBIAEoCRItCglzdGFydHNfYXQYAiABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wEi4KCnN0YXJ0ZWRfYXQYAyABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wEi8KC2ZpbmlzaGVkX2F0GAQgASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcBINCgVmaWxlcxgFIAEoBRIQCghyZXN0b3JlZBgGIAEoBRINCgVlcnJvchgHIAEoCRIuCgp1cGRhdGVkX2F0GAggASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcCJbChxSZXBvcnRSZXN0b3JlUHJvZ3Jlc3NSZXF1ZXN0EgoKAmlkGAEgASgJEi8KCHByb2dyZXNzGAIgASgLMh0uYWlyZ2FwcGVyLnYxLlJlc3RvcmVQcm9ncmVzcyIvCh1SZXBvcnRSZXN0b3JlUHJvZ3Jlc3NSZXNwb25zZRIOCgZzdGF0dXMYASABKAkyhwgKFVJlc3RvcmVSZXF1ZXN0U2VydmljZRJVCgxMaXN0UmVxdWVzdHMSIS5haXJnYXBwZXIudjEuTGlzdFJlcXVlc3RzUmVxdWVzdBoiLmFpcmdhcHBlci52MS5MaXN0UmVxdWVzdHNSZXNwb25zZRJPCgpHZXRSZXF1ZXN0Eh8uYWlyZ2FwcGVyLnYxLkdldFJlcXVlc3RSZXF1ZXN0GiAuYWlyZ2FwcGVyLnYxLkdldFJlcXVlc3RSZXNwb25zZRJYCg1DcmVhdGVSZXF1ZXN0EiIuYWlyZ2FwcGVyLnYxLkNyZWF0ZVJlcXVlc3RSZXF1ZXN0GiMuYWlyZ2FwcGVyLnYxLkNyZWF0ZVJlcXVlc3RSZXNwb25zZRJbCg5BcHByb3ZlUmVxdWVzdBIjLmFpcmdhcHBlci52MS5BcHByb3ZlUmVxdWVzdFJlcXVlc3QaJC5haXJnYXBwZXIudjEuQXBwcm92ZVJlcXVlc3RSZXNwb25zZRJbCg5Jc3N1ZUNoYWxsZW5nZRIjLmFpcmdhcHBlci52MS5Jc3N1ZUNoYWxsZW5nZVJlcXVlc3QaJC5haXJnYXBwZXIudjEuSXNzdWVDaGFsbGVuZ2VSZXNwb25zZRJSCgtTaWduUmVxdWVzdBIgLmFpcmdhcHBlci52MS5TaWduUmVxdWVzdFJlcXVlc3QaIS5haXJnYXBwZXIudjEuU2lnblJlcXVlc3RSZXNwb25zZRJSCgtEZW55UmVxdWVzdBIgLmFpcmdhcHBlci52MS5EZW55UmVxdWVzdFJlcXVlc3QaIS5haXJnYXBwZXIudjEuRGVueVJlcXVlc3RSZXNwb25zZRJhChBSZXF1ZXN0RXh0ZW5zaW9uEiUuYWlyZ2FwcGVyLnYxLlJlcXVlc3RFeHRlbnNpb25SZXF1ZXN0GiYuYWlyZ2FwcGVyLnYxLlJlcXVlc3RFeHRlbnNpb25SZXNwb25zZRJhChBBcHByb3ZlRXh0ZW5zaW9uEiUuYWlyZ2FwcGVyLnYxLkFwcHJvdmVFeHRlbnNpb25SZXF1ZXN0GiYuYWlyZ2FwcGVyLnYxLkFwcHJvdmVFeHRlbnNpb25SZXNwb25zZRJSCgtWZXRvUmVxdWVzdBIgLmFpcmdhcHBlci52MS5WZXRvUmVxdWVzdFJlcXVlc3QaIS5haXJnYXBwZXIudjEuVmV0b1JlcXVlc3RSZXNwb25zZRJwChVSZXBvcnRSZXN0b3JlUHJvZ3Jlc3MSKi5haXJnYXBwZXIudjEuUmVwb3J0UmVzdG9yZVByb2dyZXNzUmVxdWVzdBorLmFpcmdhcHBlci52MS5SZXBvcnRSZXN0b3JlUHJvZ3Jlc3NSZXNwb25zZWIGcHJvdG8z", [file_airgapper_v1_common, file_google_protobuf_timestamp]);
 
 /**
  * RestoreRequest represents a request to restore data
@@ -81,6 +81,85 @@ export type RestoreRequest = Message<"airgapper.v1.RestoreRequest"> & {
    * @generated from field: repeated airgapper.v1.Approval approvals = 12;
    */
   approvals: Approval[];
+
+  /**
+   * @generated from field: repeated airgapper.v1.ExpiryExtension extensions = 13;
+   */
+  extensions: ExpiryExtension[];
+
+  /**
+   * Set while an approved request is cooling off: when it can be executed
+   * and how many seconds are left until then
+   *
+   * @generated from field: google.protobuf.Timestamp executable_at = 14;
+   */
+  executableAt?: Timestamp;
+
+  /**
+   * @generated from field: int64 cooling_off_remaining_seconds = 15;
+   */
+  coolingOffRemainingSeconds: bigint;
+
+  /**
+   * Set once a key holder vetoed the request
+   *
+   * @generated from field: string vetoed_by = 16;
+   */
+  vetoedBy: string;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp vetoed_at = 17;
+   */
+  vetoedAt?: Timestamp;
+
+  /**
+   * @generated from field: string veto_reason = 18;
+   */
+  vetoReason: string;
+
+  /**
+   * The device the request was made from, for approvers to check
+   *
+   * @generated from field: airgapper.v1.RequesterContext requester_context = 19;
+   */
+  requesterContext?: RequesterContext;
+
+  /**
+   * Size of the restore as the requester declared it; 0 if not given
+   *
+   * @generated from field: int64 size_bytes = 20;
+   */
+  sizeBytes: bigint;
+
+  /**
+   * Terms approvers attached to their approvals, all of which the restore
+   * honors
+   *
+   * @generated from field: repeated airgapper.v1.RestoreTerms terms = 21;
+   */
+  terms: RestoreTerms[];
+
+  /**
+   * Progress of the restore, once it is scheduled or running
+   *
+   * @generated from field: airgapper.v1.RestoreProgress progress = 22;
+   */
+  progress?: RestoreProgress;
+
+  /**
+   * What approving the request releases: empty for a restore, "export-keys"
+   * for the raw repository password or "browse" for a snapshot's listing
+   *
+   * @generated from field: string purpose = 23;
+   */
+  purpose: string;
+
+  /**
+   * The template the request was created from, if any
+   *
+   * @generated from field: string template = 24;
+   */
+  template: string;
 };
 
 /**
@@ -90,6 +169,53 @@ export type RestoreRequest = Message<"airgapper.v1.RestoreRequest"> & {
 export const RestoreRequestSchema: GenMessage<RestoreRequest> = /*@__PURE__*/
   messageDesc(file_airgapper_v1_requests, 0);
 
+/**
+ * RequesterContext describes the device a restore request was made from.
+ * The requester's signature covers all but source_ip, which approvals
+ * cover too.
+ *
+ * @generated from message airgapper.v1.RequesterContext
+ */
+export type RequesterContext = Message<"airgapper.v1.RequesterContext"> & {
+  /**
+   * @generated from field: string hostname = 1;
+   */
+  hostname: string;
+
+  /**
+   * @generated from field: string os = 2;
+   */
+  os: string;
+
+  /**
+   * Airgapper version
+   *
+   * @generated from field: string version = 3;
+   */
+  version: string;
+
+  /**
+   * Key ID of the requester's signing key
+   *
+   * @generated from field: string key_fingerprint = 4;
+   */
+  keyFingerprint: string;
+
+  /**
+   * The requester's address as seen by the node the request was made on
+   *
+   * @generated from field: string source_ip = 5;
+   */
+  sourceIp: string;
+};
+
+/**
+ * Describes the message airgapper.v1.RequesterContext.
+ * Use `create(RequesterContextSchema)` to create a new message.
+ */
+export const RequesterContextSchema: GenMessage<RequesterContext> = /*@__PURE__*/
+  messageDesc(file_airgapper_v1_requests, 1);
+
 /**
  * @generated from message airgapper.v1.ListRequestsRequest
  */
@@ -107,7 +233,7 @@ export type ListRequestsRequest = Message<"airgapper.v1.ListRequestsRequest"> &
  * Use `create(ListRequestsRequestSchema)` to create a new message.
  */
 export const ListRequestsRequestSchema: GenMessage<ListRequestsRequest> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_requests, 1);
+  messageDesc(file_airgapper_v1_requests, 2);
 
 /**
  * @generated from message airgapper.v1.ListRequestsResponse
@@ -124,7 +250,7 @@ export type ListRequestsResponse = Message<"airgapper.v1.ListRequestsResponse">
  * Use `create(ListRequestsResponseSchema)` to create a new message.
  */
 export const ListRequestsResponseSchema: GenMessage<ListRequestsResponse> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_requests, 2);
+  messageDesc(file_airgapper_v1_requests, 3);
 
 /**
  * @generated from message airgapper.v1.GetRequestRequest
@@ -141,7 +267,7 @@ export type GetRequestRequest = Message<"airgapper.v1.GetRequestRequest"> & {
  * Use `create(GetRequestRequestSchema)` to create a new message.
  */
 export const GetRequestRequestSchema: GenMessage<GetRequestRequest> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_requests, 3);
+  messageDesc(file_airgapper_v1_requests, 4);
 
 /**
  * @generated from message airgapper.v1.GetRequestResponse
@@ -158,7 +284,7 @@ export type GetRequestResponse = Message<"airgapper.v1.GetRequestResponse"> & {
  * Use `create(GetRequestResponseSchema)` to create a new message.
  */
 export const GetRequestResponseSchema: GenMessage<GetRequestResponse> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_requests, 4);
+  messageDesc(file_airgapper_v1_requests, 5);
 
 /**
  * @generated from message airgapper.v1.CreateRequestRequest
@@ -178,6 +304,70 @@ export type CreateRequestRequest = Message<"airgapper.v1.CreateRequestRequest">
    * @generated from field: string reason = 3;
    */
   reason: string;
+
+  /**
+   * The requester's signature over the request (required in consensus
+   * mode). The requester chooses the ID and creation time it signs.
+   *
+   * @generated from field: string id = 4;
+   */
+  id: string;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp created_at = 5;
+   */
+  createdAt?: Timestamp;
+
+  /**
+   * @generated from field: string key_holder_id = 6;
+   */
+  keyHolderId: string;
+
+  /**
+   * Hex encoded
+   *
+   * @generated from field: string signature = 7;
+   */
+  signature: string;
+
+  /**
+   * How long the request stays pending, as a duration such as "48h", up to
+   * the node's limit. Empty for the default of 24 hours.
+   *
+   * @generated from field: string ttl = 8;
+   */
+  ttl: string;
+
+  /**
+   * The device the request is made from. source_ip is ignored: the node
+   * records the address the call came from.
+   *
+   * @generated from field: airgapper.v1.RequesterContext requester_context = 9;
+   */
+  requesterContext?: RequesterContext;
+
+  /**
+   * Size of the restore in bytes, signed along with the request. The
+   * node's approval rules may approve small single-path restores by it.
+   *
+   * @generated from field: int64 size_bytes = 10;
+   */
+  sizeBytes: bigint;
+
+  /**
+   * What the request is for, signed along with it: empty for a restore,
+   * "export-keys" or "browse". Nodes refuse purposes they don't know.
+   *
+   * @generated from field: string purpose = 11;
+   */
+  purpose: string;
+
+  /**
+   * The template the request was created from, if any, signed along with it
+   *
+   * @generated from field: string template = 12;
+   */
+  template: string;
 };
 
 /**
@@ -185,7 +375,7 @@ export type CreateRequestRequest = Message<"airgapper.v1.CreateRequestRequest">
  * Use `create(CreateRequestRequestSchema)` to create a new message.
  */
 export const CreateRequestRequestSchema: GenMessage<CreateRequestRequest> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_requests, 5);
+  messageDesc(file_airgapper_v1_requests, 6);
 
 /**
  * @generated from message airgapper.v1.CreateRequestResponse
@@ -212,7 +402,7 @@ export type CreateRequestResponse = Message<"airgapper.v1.CreateRequestResponse"
  * Use `create(CreateRequestResponseSchema)` to create a new message.
  */
 export const CreateRequestResponseSchema: GenMessage<CreateRequestResponse> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_requests, 6);
+  messageDesc(file_airgapper_v1_requests, 7);
 
 /**
  * @generated from message airgapper.v1.ApproveRequestRequest
@@ -234,6 +424,13 @@ export type ApproveRequestRequest = Message<"airgapper.v1.ApproveRequestRequest"
    * @generated from field: int32 share_index = 3;
    */
   shareIndex: number;
+
+  /**
+   * Conditions the restore must keep to, if any
+   *
+   * @generated from field: airgapper.v1.RestoreTerms terms = 4;
+   */
+  terms?: RestoreTerms;
 };
 
 /**
@@ -241,7 +438,7 @@ export type ApproveRequestRequest = Message<"airgapper.v1.ApproveRequestRequest"
  * Use `create(ApproveRequestRequestSchema)` to create a new message.
  */
 export const ApproveRequestRequestSchema: GenMessage<ApproveRequestRequest> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_requests, 7);
+  messageDesc(file_airgapper_v1_requests, 8);
 
 /**
  * @generated from message airgapper.v1.ApproveRequestResponse
@@ -263,7 +460,51 @@ export type ApproveRequestResponse = Message<"airgapper.v1.ApproveRequestRespons
  * Use `create(ApproveRequestResponseSchema)` to create a new message.
  */
 export const ApproveRequestResponseSchema: GenMessage<ApproveRequestResponse> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_requests, 8);
+  messageDesc(file_airgapper_v1_requests, 9);
+
+/**
+ * @generated from message airgapper.v1.IssueChallengeRequest
+ */
+export type IssueChallengeRequest = Message<"airgapper.v1.IssueChallengeRequest"> & {
+  /**
+   * @generated from field: string id = 1;
+   */
+  id: string;
+
+  /**
+   * @generated from field: string key_holder_id = 2;
+   */
+  keyHolderId: string;
+};
+
+/**
+ * Describes the message airgapper.v1.IssueChallengeRequest.
+ * Use `create(IssueChallengeRequestSchema)` to create a new message.
+ */
+export const IssueChallengeRequestSchema: GenMessage<IssueChallengeRequest> = /*@__PURE__*/
+  messageDesc(file_airgapper_v1_requests, 10);
+
+/**
+ * @generated from message airgapper.v1.IssueChallengeResponse
+ */
+export type IssueChallengeResponse = Message<"airgapper.v1.IssueChallengeResponse"> & {
+  /**
+   * @generated from field: string nonce = 1;
+   */
+  nonce: string;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp expires_at = 2;
+   */
+  expiresAt?: Timestamp;
+};
+
+/**
+ * Describes the message airgapper.v1.IssueChallengeResponse.
+ * Use `create(IssueChallengeResponseSchema)` to create a new message.
+ */
+export const IssueChallengeResponseSchema: GenMessage<IssueChallengeResponse> = /*@__PURE__*/
+  messageDesc(file_airgapper_v1_requests, 11);
 
 /**
  * @generated from message airgapper.v1.SignRequestRequest
@@ -285,6 +526,34 @@ export type SignRequestRequest = Message<"airgapper.v1.SignRequestRequest"> & {
    * @generated from field: string signature = 3;
    */
   signature: string;
+
+  /**
+   * Approve with the authority delegated to key_holder_id
+   *
+   * @generated from field: string delegation_id = 4;
+   */
+  delegationId: string;
+
+  /**
+   * Challenge from IssueChallenge covered by the signature
+   *
+   * @generated from field: string nonce = 5;
+   */
+  nonce: string;
+
+  /**
+   * When the key holder signed, covered by the signature
+   *
+   * @generated from field: google.protobuf.Timestamp signed_at = 6;
+   */
+  signedAt?: Timestamp;
+
+  /**
+   * Conditions the restore must keep to, if any
+   *
+   * @generated from field: airgapper.v1.RestoreTerms terms = 7;
+   */
+  terms?: RestoreTerms;
 };
 
 /**
@@ -292,7 +561,7 @@ export type SignRequestRequest = Message<"airgapper.v1.SignRequestRequest"> & {
  * Use `create(SignRequestRequestSchema)` to create a new message.
  */
 export const SignRequestRequestSchema: GenMessage<SignRequestRequest> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_requests, 9);
+  messageDesc(file_airgapper_v1_requests, 12);
 
 /**
  * @generated from message airgapper.v1.SignRequestResponse
@@ -324,7 +593,7 @@ export type SignRequestResponse = Message<"airgapper.v1.SignRequestResponse"> &
  * Use `create(SignRequestResponseSchema)` to create a new message.
  */
 export const SignRequestResponseSchema: GenMessage<SignRequestResponse> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_requests, 10);
+  messageDesc(file_airgapper_v1_requests, 13);
 
 /**
  * @generated from message airgapper.v1.DenyRequestRequest
@@ -341,7 +610,7 @@ export type DenyRequestRequest = Message<"airgapper.v1.DenyRequestRequest"> & {
  * Use `create(DenyRequestRequestSchema)` to create a new message.
  */
 export const DenyRequestRequestSchema: GenMessage<DenyRequestRequest> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_requests, 11);
+  messageDesc(file_airgapper_v1_requests, 14);
 
 /**
  * @generated from message airgapper.v1.DenyRequestResponse
@@ -358,7 +627,361 @@ export type DenyRequestResponse = Message<"airgapper.v1.DenyRequestResponse"> &
  * Use `create(DenyRequestResponseSchema)` to create a new message.
  */
 export const DenyRequestResponseSchema: GenMessage<DenyRequestResponse> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_requests, 12);
+  messageDesc(file_airgapper_v1_requests, 15);
+
+/**
+ * ExpiryExtension is a requester's ask for more time to collect approvals
+ *
+ * @generated from message airgapper.v1.ExpiryExtension
+ */
+export type ExpiryExtension = Message<"airgapper.v1.ExpiryExtension"> & {
+  /**
+   * Duration such as "12h"
+   *
+   * @generated from field: string extend_by = 1;
+   */
+  extendBy: string;
+
+  /**
+   * @generated from field: string reason = 2;
+   */
+  reason: string;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp requested_at = 3;
+   */
+  requestedAt?: Timestamp;
+
+  /**
+   * Empty while awaiting approval
+   *
+   * @generated from field: string approved_by = 4;
+   */
+  approvedBy: string;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp approved_at = 5;
+   */
+  approvedAt?: Timestamp;
+};
+
+/**
+ * Describes the message airgapper.v1.ExpiryExtension.
+ * Use `create(ExpiryExtensionSchema)` to create a new message.
+ */
+export const ExpiryExtensionSchema: GenMessage<ExpiryExtension> = /*@__PURE__*/
+  messageDesc(file_airgapper_v1_requests, 16);
+
+/**
+ * @generated from message airgapper.v1.RequestExtensionRequest
+ */
+export type RequestExtensionRequest = Message<"airgapper.v1.RequestExtensionRequest"> & {
+  /**
+   * @generated from field: string id = 1;
+   */
+  id: string;
+
+  /**
+   * Duration such as "12h", up to the node's limit
+   *
+   * @generated from field: string extend_by = 2;
+   */
+  extendBy: string;
+
+  /**
+   * @generated from field: string reason = 3;
+   */
+  reason: string;
+};
+
+/**
+ * Describes the message airgapper.v1.RequestExtensionRequest.
+ * Use `create(RequestExtensionRequestSchema)` to create a new message.
+ */
+export const RequestExtensionRequestSchema: GenMessage<RequestExtensionRequest> = /*@__PURE__*/
+  messageDesc(file_airgapper_v1_requests, 17);
+
+/**
+ * @generated from message airgapper.v1.RequestExtensionResponse
+ */
+export type RequestExtensionResponse = Message<"airgapper.v1.RequestExtensionResponse"> & {
+  /**
+   * @generated from field: airgapper.v1.ExpiryExtension extension = 1;
+   */
+  extension?: ExpiryExtension;
+};
+
+/**
+ * Describes the message airgapper.v1.RequestExtensionResponse.
+ * Use `create(RequestExtensionResponseSchema)` to create a new message.
+ */
+export const RequestExtensionResponseSchema: GenMessage<RequestExtensionResponse> = /*@__PURE__*/
+  messageDesc(file_airgapper_v1_requests, 18);
+
+/**
+ * @generated from message airgapper.v1.ApproveExtensionRequest
+ */
+export type ApproveExtensionRequest = Message<"airgapper.v1.ApproveExtensionRequest"> & {
+  /**
+   * @generated from field: string id = 1;
+   */
+  id: string;
+
+  /**
+   * @generated from field: string key_holder_id = 2;
+   */
+  keyHolderId: string;
+
+  /**
+   * The key holder's signature over the request ID and the expiry the
+   * extension moves it to, with a nonce from IssueChallenge, as for
+   * SignRequest
+   *
+   * Hex encoded
+   *
+   * @generated from field: string signature = 3;
+   */
+  signature: string;
+
+  /**
+   * @generated from field: string nonce = 4;
+   */
+  nonce: string;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp signed_at = 5;
+   */
+  signedAt?: Timestamp;
+};
+
+/**
+ * Describes the message airgapper.v1.ApproveExtensionRequest.
+ * Use `create(ApproveExtensionRequestSchema)` to create a new message.
+ */
+export const ApproveExtensionRequestSchema: GenMessage<ApproveExtensionRequest> = /*@__PURE__*/
+  messageDesc(file_airgapper_v1_requests, 19);
+
+/**
+ * @generated from message airgapper.v1.ApproveExtensionResponse
+ */
+export type ApproveExtensionResponse = Message<"airgapper.v1.ApproveExtensionResponse"> & {
+  /**
+   * @generated from field: google.protobuf.Timestamp expires_at = 1;
+   */
+  expiresAt?: Timestamp;
+};
+
+/**
+ * Describes the message airgapper.v1.ApproveExtensionResponse.
+ * Use `create(ApproveExtensionResponseSchema)` to create a new message.
+ */
+export const ApproveExtensionResponseSchema: GenMessage<ApproveExtensionResponse> = /*@__PURE__*/
+  messageDesc(file_airgapper_v1_requests, 20);
+
+/**
+ * @generated from message airgapper.v1.VetoRequestRequest
+ */
+export type VetoRequestRequest = Message<"airgapper.v1.VetoRequestRequest"> & {
+  /**
+   * @generated from field: string id = 1;
+   */
+  id: string;
+
+  /**
+   * @generated from field: string key_holder_id = 2;
+   */
+  keyHolderId: string;
+
+  /**
+   * @generated from field: string reason = 3;
+   */
+  reason: string;
+
+  /**
+   * The key holder's signature over the request ID and the reason, with a
+   * nonce from IssueChallenge, as for SignRequest
+   *
+   * Hex encoded
+   *
+   * @generated from field: string signature = 4;
+   */
+  signature: string;
+
+  /**
+   * @generated from field: string nonce = 5;
+   */
+  nonce: string;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp signed_at = 6;
+   */
+  signedAt?: Timestamp;
+};
+
+/**
+ * Describes the message airgapper.v1.VetoRequestRequest.
+ * Use `create(VetoRequestRequestSchema)` to create a new message.
+ */
+export const VetoRequestRequestSchema: GenMessage<VetoRequestRequest> = /*@__PURE__*/
+  messageDesc(file_airgapper_v1_requests, 21);
+
+/**
+ * @generated from message airgapper.v1.VetoRequestResponse
+ */
+export type VetoRequestResponse = Message<"airgapper.v1.VetoRequestResponse"> & {
+  /**
+   * @generated from field: string status = 1;
+   */
+  status: string;
+};
+
+/**
+ * Describes the message airgapper.v1.VetoRequestResponse.
+ * Use `create(VetoRequestResponseSchema)` to create a new message.
+ */
+export const VetoRequestResponseSchema: GenMessage<VetoRequestResponse> = /*@__PURE__*/
+  messageDesc(file_airgapper_v1_requests, 22);
+
+/**
+ * RestoreTerms are conditions an approver attaches to their approval, so a
+ * large restore doesn't saturate their uplink
+ *
+ * @generated from message airgapper.v1.RestoreTerms
+ */
+export type RestoreTerms = Message<"airgapper.v1.RestoreTerms"> & {
+  /**
+   * Daily window of the approver's local time the restore may only start
+   * in, such as "22:00-06:00"; empty for any time
+   *
+   * @generated from field: string window = 1;
+   */
+  window: string;
+
+  /**
+   * The approver's offset from UTC in seconds
+   *
+   * @generated from field: int32 utc_offset = 2;
+   */
+  utcOffset: number;
+
+  /**
+   * Download cap in KiB/s; 0 for none
+   *
+   * @generated from field: int32 limit_kibps = 3;
+   */
+  limitKibps: number;
+
+  /**
+   * Set by the node: the approver
+   *
+   * @generated from field: string set_by = 4;
+   */
+  setBy: string;
+};
+
+/**
+ * Describes the message airgapper.v1.RestoreTerms.
+ * Use `create(RestoreTermsSchema)` to create a new message.
+ */
+export const RestoreTermsSchema: GenMessage<RestoreTerms> = /*@__PURE__*/
+  messageDesc(file_airgapper_v1_requests, 23);
+
+/**
+ * RestoreProgress is how far an approved request's restore has got
+ *
+ * @generated from message airgapper.v1.RestoreProgress
+ */
+export type RestoreProgress = Message<"airgapper.v1.RestoreProgress"> & {
+  /**
+   * "scheduled", "running", "completed" or "failed"
+   *
+   * @generated from field: string status = 1;
+   */
+  status: string;
+
+  /**
+   * When a scheduled restore will start
+   *
+   * @generated from field: google.protobuf.Timestamp starts_at = 2;
+   */
+  startsAt?: Timestamp;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp started_at = 3;
+   */
+  startedAt?: Timestamp;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp finished_at = 4;
+   */
+  finishedAt?: Timestamp;
+
+  /**
+   * @generated from field: int32 files = 5;
+   */
+  files: number;
+
+  /**
+   * @generated from field: int32 restored = 6;
+   */
+  restored: number;
+
+  /**
+   * @generated from field: string error = 7;
+   */
+  error: string;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp updated_at = 8;
+   */
+  updatedAt?: Timestamp;
+};
+
+/**
+ * Describes the message airgapper.v1.RestoreProgress.
+ * Use `create(RestoreProgressSchema)` to create a new message.
+ */
+export const RestoreProgressSchema: GenMessage<RestoreProgress> = /*@__PURE__*/
+  messageDesc(file_airgapper_v1_requests, 24);
+
+/**
+ * @generated from message airgapper.v1.ReportRestoreProgressRequest
+ */
+export type ReportRestoreProgressRequest = Message<"airgapper.v1.ReportRestoreProgressRequest"> & {
+  /**
+   * @generated from field: string id = 1;
+   */
+  id: string;
+
+  /**
+   * @generated from field: airgapper.v1.RestoreProgress progress = 2;
+   */
+  progress?: RestoreProgress;
+};
+
+/**
+ * Describes the message airgapper.v1.ReportRestoreProgressRequest.
+ * Use `create(ReportRestoreProgressRequestSchema)` to create a new message.
+ */
+export const ReportRestoreProgressRequestSchema: GenMessage<ReportRestoreProgressRequest> = /*@__PURE__*/
+  messageDesc(file_airgapper_v1_requests, 25);
+
+/**
+ * @generated from message airgapper.v1.ReportRestoreProgressResponse
+ */
+export type ReportRestoreProgressResponse = Message<"airgapper.v1.ReportRestoreProgressResponse"> & {
+  /**
+   * @generated from field: string status = 1;
+   */
+  status: string;
+};
+
+/**
+ * Describes the message airgapper.v1.ReportRestoreProgressResponse.
+ * Use `create(ReportRestoreProgressResponseSchema)` to create a new message.
+ */
+export const ReportRestoreProgressResponseSchema: GenMessage<ReportRestoreProgressResponse> = /*@__PURE__*/
+  messageDesc(file_airgapper_v1_requests, 26);
 
 /**
  * RestoreRequestService handles restore request management
@@ -406,6 +1029,17 @@ export const RestoreRequestService: GenService<{
     input: typeof ApproveRequestRequestSchema;
     output: typeof ApproveRequestResponseSchema;
   },
+  /**
+   * IssueChallenge issues a key holder a nonce to sign their approval, or
+   * veto, with
+   *
+   * @generated from rpc airgapper.v1.RestoreRequestService.IssueChallenge
+   */
+  issueChallenge: {
+    methodKind: "unary";
+    input: typeof IssueChallengeRequestSchema;
+    output: typeof IssueChallengeResponseSchema;
+  },
   /**
    * SignRequest signs a restore request (consensus mode)
    *
@@ -426,6 +1060,46 @@ export const RestoreRequestService: GenService<{
     input: typeof DenyRequestRequestSchema;
     output: typeof DenyRequestResponseSchema;
   },
+  /**
+   * RequestExtension asks for a pending request's expiry to be pushed back
+   *
+   * @generated from rpc airgapper.v1.RestoreRequestService.RequestExtension
+   */
+  requestExtension: {
+    methodKind: "unary";
+    input: typeof RequestExtensionRequestSchema;
+    output: typeof RequestExtensionResponseSchema;
+  },
+  /**
+   * ApproveExtension approves a request's pending extension as a key holder
+   *
+   * @generated from rpc airgapper.v1.RestoreRequestService.ApproveExtension
+   */
+  approveExtension: {
+    methodKind: "unary";
+    input: typeof ApproveExtensionRequestSchema;
+    output: typeof ApproveExtensionResponseSchema;
+  },
+  /**
+   * VetoRequest stops a pending request, or an approved one still cooling off
+   *
+   * @generated from rpc airgapper.v1.RestoreRequestService.VetoRequest
+   */
+  vetoRequest: {
+    methodKind: "unary";
+    input: typeof VetoRequestRequestSchema;
+    output: typeof VetoRequestResponseSchema;
+  },
+  /**
+   * ReportRestoreProgress records how far an approved request's restore has got
+   *
+   * @generated from rpc airgapper.v1.RestoreRequestService.ReportRestoreProgress
+   */
+  reportRestoreProgress: {
+    methodKind: "unary";
+    input: typeof ReportRestoreProgressRequestSchema;
+    output: typeof ReportRestoreProgressResponseSchema;
+  },
 }> = /*@__PURE__*/
   serviceDesc(file_airgapper_v1_requests, 0);
 
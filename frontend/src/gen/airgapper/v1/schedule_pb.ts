@@ -12,7 +12,7 @@ import type { Message } from "@bufbuild/protobuf";
  * Describes the file airgapper/v1/schedule.proto.
  */
 export const file_airgapper_v1_schedule: GenFile = /*@__PURE__*/
-  fileDesc("ChthaXJnYXBwZXIvdjEvc2NoZWR1bGUucHJvdG8SDGFpcmdhcHBlci52MSIUChJHZXRTY2hlZHVsZVJlcXVlc3QitwEKE0dldFNjaGVkdWxlUmVzcG9uc2USEAoIc2NoZWR1bGUYASABKAkSDQoFcGF0aHMYAiADKAkSDwoHZW5hYmxlZBgDIAEoCBIsCghsYXN0X3J1bhgEIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXASLAoIbmV4dF9ydW4YBSABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wEhIKCmxhc3RfZXJyb3IYBiABKAkiOAoVVXBkYXRlU2NoZWR1bGVSZXF1ZXN0EhAKCHNjaGVkdWxlGAEgASgJEg0KBXBhdGhzGAIgAygJIk8KFlVwZGF0ZVNjaGVkdWxlUmVzcG9uc2USDgoGc3RhdHVzGAEgASgJEg8KB21lc3NhZ2UYAiABKAkSFAoMaG90X3JlbG9hZGVkGAMgASgIIigKF0dldEJhY2t1cEhpc3RvcnlSZXF1ZXN0Eg0KBWxpbWl0GAEgASgFIvgBCgxCYWNrdXBSZXN1bHQSMgoOc2NoZWR1bGVkX3RpbWUYASABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wEi4KCnN0YXJ0X3RpbWUYAiABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wEiwKCGVuZF90aW1lGAMgASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcBITCgtkdXJhdGlvbl9tcxgEIAEoAxIPCgdzdWNjZXNzGAUgASgIEg8KB2F0dGVtcHQYBiABKAUSEAoIaXNfcmV0cnkYByABKAgSDQoFZXJyb3IYCCABKAkiVgoYR2V0QmFja3VwSGlzdG9yeVJlc3BvbnNlEisKB2hpc3RvcnkYASADKAsyGi5haXJnYXBwZXIudjEuQmFja3VwUmVzdWx0Eg0KBWNvdW50GAIgASgFMqUCCg9TY2hlZHVsZVNlcnZpY2USUgoLR2V0U2NoZWR1bGUSIC5haXJnYXBwZXIudjEuR2V0U2NoZWR1bGVSZXF1ZXN0GiEuYWlyZ2FwcGVyLnYxLkdldFNjaGVkdWxlUmVzcG9uc2USWwoOVXBkYXRlU2NoZWR1bGUSIy5haXJnYXBwZXIudjEuVXBkYXRlU2NoZWR1bGVSZXF1ZXN0GiQuYWlyZ2FwcGVyLnYxLlVwZGF0ZVNjaGVkdWxlUmVzcG9uc2USYQoQR2V0QmFja3VwSGlzdG9yeRIlLmFpcmdhcHBlci52MS5HZXRCYWNrdXBIaXN0b3J5UmVxdWVzdBomLmFpcmdhcHBlci52MS5HZXRCYWNrdXBIaXN0b3J5UmVzcG9uc2ViBnByb3RvMw", [file_google_protobuf_timestamp]);
+  fileDesc("ChthaXJnYXBwZXIvdjEvc2NoZWR1bGUucHJvdG8SDGFpcmdhcHBlci52MSIUChJHZXRTY2hlZHVsZVJlcXVlc3Qi9wEKE0dldFNjaGVkdWxlUmVzcG9uc2USEAoIc2NoZWR1bGUYASABKAkSDQoFcGF0aHMYAiADKAkSDwoHZW5hYmxlZBgDIAEoCBIsCghsYXN0X3J1bhgEIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXASLAoIbmV4dF9ydW4YBSABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wEhIKCmxhc3RfZXJyb3IYBiABKAkSLAoGdGltaW5nGAcgASgLMhwuYWlyZ2FwcGVyLnYxLlNjaGVkdWxlVGltaW5nEhAKCHRpbWV6b25lGAggASgJIlcKDlNjaGVkdWxlVGltaW5nEhcKD2NhdGNoX3VwX3dpbmRvdxgBIAEoCRIOCgZqaXR0ZXIYAiABKAkSHAoUY2xvY2tfanVtcF90aHJlc2hvbGQYAyABKAkiigEKFVVwZGF0ZVNjaGVkdWxlUmVxdWVzdBIQCghzY2hlZHVsZRgBIAEoCRINCgVwYXRocxgCIAMoCRIsCgZ0aW1pbmcYAyABKAsyHC5haXJnYXBwZXIudjEuU2NoZWR1bGVUaW1pbmcSFQoIdGltZXpvbmUYBCABKAlIAIgBAUILCglfdGltZXpvbmUiTwoWVXBkYXRlU2NoZWR1bGVSZXNwb25zZRIOCgZzdGF0dXMYASABKAkSDwoHbWVzc2FnZRgCIAEoCRIUCgxob3RfcmVsb2FkZWQYAyABKAgiKAoXR2V0QmFja3VwSGlzdG9yeVJlcXVlc3QSDQoFbGltaXQYASABKAUiigIKDEJhY2t1cFJlc3VsdBIyCg5zY2hlZHVsZWRfdGltZRgBIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXASLgoKc3RhcnRfdGltZRgCIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXASLAoIZW5kX3RpbWUYAyABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wEhMKC2R1cmF0aW9uX21zGAQgASgDEg8KB3N1Y2Nlc3MYBSABKAgSDwoHYXR0ZW1wdBgGIAEoBRIQCghpc19yZXRyeRgHIAEoCBINCgVlcnJvchgIIAEoCRIQCghjYXRjaF91cBgJIAEoCCJWChhHZXRCYWNrdXBIaXN0b3J5UmVzcG9uc2USKwoHaGlzdG9yeRgBIAMoCzIaLmFpcmdhcHBlci52MS5CYWNrdXBSZXN1bHQSDQoFY291bnQYAiABKAUypQIKD1NjaGVkdWxlU2VydmljZRJSCgtHZXRTY2hlZHVsZRIgLmFpcmdhcHBlci52MS5HZXRTY2hlZHVsZVJlcXVlc3QaIS5haXJnYXBwZXIudjEuR2V0U2NoZWR1bGVSZXNwb25zZRJbCg5VcGRhdGVTY2hlZHVsZRIjLmFpcmdhcHBlci52MS5VcGRhdGVTY2hlZHVsZVJlcXVlc3QaJC5haXJnYXBwZXIudjEuVXBkYXRlU2NoZWR1bGVSZXNwb25zZRJhChBHZXRCYWNrdXBIaXN0b3J5EiUuYWlyZ2FwcGVyLnYxLkdldEJhY2t1cEhpc3RvcnlSZXF1ZXN0GiYuYWlyZ2FwcGVyLnYxLkdldEJhY2t1cEhpc3RvcnlSZXNwb25zZWIGcHJvdG8z", [file_google_protobuf_timestamp]);
 
 /**
  * @generated from message airgapper.v1.GetScheduleRequest
@@ -60,6 +60,18 @@ export type GetScheduleResponse = Message<"airgapper.v1.GetScheduleResponse"> &
    * @generated from field: string last_error = 6;
    */
   lastError: string;
+
+  /**
+   * @generated from field: airgapper.v1.ScheduleTiming timing = 7;
+   */
+  timing?: ScheduleTiming;
+
+  /**
+   * IANA timezone the schedule's times are in, "Local" for the system's
+   *
+   * @generated from field: string timezone = 8;
+   */
+  timezone: string;
 };
 
 /**
@@ -69,6 +81,42 @@ export type GetScheduleResponse = Message<"airgapper.v1.GetScheduleResponse"> &
 export const GetScheduleResponseSchema: GenMessage<GetScheduleResponse> = /*@__PURE__*/
   messageDesc(file_airgapper_v1_schedule, 1);
 
+/**
+ * ScheduleTiming controls missed-run catch-up, jitter and clock jump
+ * handling. Durations are Go duration strings (e.g. "12h", "15m").
+ *
+ * @generated from message airgapper.v1.ScheduleTiming
+ */
+export type ScheduleTiming = Message<"airgapper.v1.ScheduleTiming"> & {
+  /**
+   * Run a missed backup on wake if it's at most this late ("0" = skip)
+   *
+   * @generated from field: string catch_up_window = 1;
+   */
+  catchUpWindow: string;
+
+  /**
+   * Random delay up to this added to each run
+   *
+   * @generated from field: string jitter = 2;
+   */
+  jitter: string;
+
+  /**
+   * Wall-clock drift treated as a sleep or clock change
+   *
+   * @generated from field: string clock_jump_threshold = 3;
+   */
+  clockJumpThreshold: string;
+};
+
+/**
+ * Describes the message airgapper.v1.ScheduleTiming.
+ * Use `create(ScheduleTimingSchema)` to create a new message.
+ */
+export const ScheduleTimingSchema: GenMessage<ScheduleTiming> = /*@__PURE__*/
+  messageDesc(file_airgapper_v1_schedule, 2);
+
 /**
  * @generated from message airgapper.v1.UpdateScheduleRequest
  */
@@ -82,6 +130,20 @@ export type UpdateScheduleRequest = Message<"airgapper.v1.UpdateScheduleRequest"
    * @generated from field: repeated string paths = 2;
    */
   paths: string[];
+
+  /**
+   * Unset leaves timing unchanged
+   *
+   * @generated from field: airgapper.v1.ScheduleTiming timing = 3;
+   */
+  timing?: ScheduleTiming;
+
+  /**
+   * IANA timezone; unset leaves it unchanged, "" for the system's
+   *
+   * @generated from field: optional string timezone = 4;
+   */
+  timezone?: string;
 };
 
 /**
@@ -89,7 +151,7 @@ export type UpdateScheduleRequest = Message<"airgapper.v1.UpdateScheduleRequest"
  * Use `create(UpdateScheduleRequestSchema)` to create a new message.
  */
 export const UpdateScheduleRequestSchema: GenMessage<UpdateScheduleRequest> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_schedule, 2);
+  messageDesc(file_airgapper_v1_schedule, 3);
 
 /**
  * @generated from message airgapper.v1.UpdateScheduleResponse
@@ -116,7 +178,7 @@ export type UpdateScheduleResponse = Message<"airgapper.v1.UpdateScheduleRespons
  * Use `create(UpdateScheduleResponseSchema)` to create a new message.
  */
 export const UpdateScheduleResponseSchema: GenMessage<UpdateScheduleResponse> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_schedule, 3);
+  messageDesc(file_airgapper_v1_schedule, 4);
 
 /**
  * @generated from message airgapper.v1.GetBackupHistoryRequest
@@ -135,7 +197,7 @@ export type GetBackupHistoryRequest = Message<"airgapper.v1.GetBackupHistoryRequ
  * Use `create(GetBackupHistoryRequestSchema)` to create a new message.
  */
 export const GetBackupHistoryRequestSchema: GenMessage<GetBackupHistoryRequest> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_schedule, 4);
+  messageDesc(file_airgapper_v1_schedule, 5);
 
 /**
  * BackupResult represents a single backup execution result
@@ -182,6 +244,13 @@ export type BackupResult = Message<"airgapper.v1.BackupResult"> & {
    * @generated from field: string error = 8;
    */
   error: string;
+
+  /**
+   * Made up for a slot missed while asleep
+   *
+   * @generated from field: bool catch_up = 9;
+   */
+  catchUp: boolean;
 };
 
 /**
@@ -189,7 +258,7 @@ export type BackupResult = Message<"airgapper.v1.BackupResult"> & {
  * Use `create(BackupResultSchema)` to create a new message.
  */
 export const BackupResultSchema: GenMessage<BackupResult> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_schedule, 5);
+  messageDesc(file_airgapper_v1_schedule, 6);
 
 /**
  * @generated from message airgapper.v1.GetBackupHistoryResponse
@@ -211,7 +280,7 @@ export type GetBackupHistoryResponse = Message<"airgapper.v1.GetBackupHistoryRes
  * Use `create(GetBackupHistoryResponseSchema)` to create a new message.
  */
 export const GetBackupHistoryResponseSchema: GenMessage<GetBackupHistoryResponse> = /*@__PURE__*/
-  messageDesc(file_airgapper_v1_schedule, 6);
+  messageDesc(file_airgapper_v1_schedule, 7);
 
 /**
  * ScheduleService handles backup scheduling
@@ -12,7 +12,7 @@ import type { Message } from "@bufbuild/protobuf";
  * Describes the file airgapper/v1/storage.proto.
  */
 export const file_airgapper_v1_storage: GenFile = /*@__PURE__*/
-  fileDesc("ChphaXJnYXBwZXIvdjEvc3RvcmFnZS5wcm90bxIMYWlyZ2FwcGVyLnYxIhkKF0dldFN0b3JhZ2VTdGF0dXNSZXF1ZXN0IuUCChhHZXRTdG9yYWdlU3RhdHVzUmVzcG9uc2USEgoKY29uZmlndXJlZBgBIAEoCBIPCgdydW5uaW5nGAIgASgIEi4KCnN0YXJ0X3RpbWUYAyABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wEhEKCWJhc2VfcGF0aBgEIAEoCRITCgthcHBlbmRfb25seRgFIAEoCBITCgtxdW90YV9ieXRlcxgGIAEoAxISCgp1c2VkX2J5dGVzGAcgASgDEhUKDXJlcXVlc3RfY291bnQYCCABKAMSEgoKaGFzX3BvbGljeRgJIAEoCBIRCglwb2xpY3lfaWQYCiABKAkSGgoSbWF4X2Rpc2tfdXNhZ2VfcGN0GAsgASgFEhYKDmRpc2tfdXNhZ2VfcGN0GAwgASgFEhcKD2Rpc2tfZnJlZV9ieXRlcxgNIAEoAxIYChBkaXNrX3RvdGFsX2J5dGVzGA4gASgDIhUKE1N0YXJ0U3RvcmFnZVJlcXVlc3QiJgoUU3RhcnRTdG9yYWdlUmVzcG9uc2USDgoGc3RhdHVzGAEgASgJIhQKElN0b3BTdG9yYWdlUmVxdWVzdCIlChNTdG9wU3RvcmFnZVJlc3BvbnNlEg4KBnN0YXR1cxgBIAEoCTKeAgoOU3RvcmFnZVNlcnZpY2USYQoQR2V0U3RvcmFnZVN0YXR1cxIlLmFpcmdhcHBlci52MS5HZXRTdG9yYWdlU3RhdHVzUmVxdWVzdBomLmFpcmdhcHBlci52MS5HZXRTdG9yYWdlU3RhdHVzUmVzcG9uc2USVQoMU3RhcnRTdG9yYWdlEiEuYWlyZ2FwcGVyLnYxLlN0YXJ0U3RvcmFnZVJlcXVlc3QaIi5haXJnYXBwZXIudjEuU3RhcnRTdG9yYWdlUmVzcG9uc2USUgoLU3RvcFN0b3JhZ2USIC5haXJnYXBwZXIudjEuU3RvcFN0b3JhZ2VSZXF1ZXN0GiEuYWlyZ2FwcGVyLnYxLlN0b3BTdG9yYWdlUmVzcG9uc2ViBnByb3RvMw", [file_google_protobuf_timestamp]);
+  fileDesc("ChphaXJnYXBwZXIvdjEvc3RvcmFnZS5wcm90bxIMYWlyZ2FwcGVyLnYxIhkKF0dldFN0b3JhZ2VTdGF0dXNSZXF1ZXN0ItwEChhHZXRTdG9yYWdlU3RhdHVzUmVzcG9uc2USEgoKY29uZmlndXJlZBgBIAEoCBIPCgdydW5uaW5nGAIgASgIEi4KCnN0YXJ0X3RpbWUYAyABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wEhEKCWJhc2VfcGF0aBgEIAEoCRITCgthcHBlbmRfb25seRgFIAEoCBITCgtxdW90YV9ieXRlcxgGIAEoAxISCgp1c2VkX2J5dGVzGAcgASgDEhUKDXJlcXVlc3RfY291bnQYCCABKAMSEgoKaGFzX3BvbGljeRgJIAEoCBIRCglwb2xpY3lfaWQYCiABKAkSGgoSbWF4X2Rpc2tfdXNhZ2VfcGN0GAsgASgFEhYKDmRpc2tfdXNhZ2VfcGN0GAwgASgFEhcKD2Rpc2tfZnJlZV9ieXRlcxgNIAEoAxIYChBkaXNrX3RvdGFsX2J5dGVzGA4gASgDEiAKGHBvbGljeV9tYXhfc3RvcmFnZV9ieXRlcxgPIAEoAxIdChVlZmZlY3RpdmVfcXVvdGFfYnl0ZXMYECABKAMSFwoPcXVvdGFfdXNhZ2VfcGN0GBEgASgFEhEKCXJlYWRfb25seRgSIAEoCBIaChJtYWludGVuYW5jZV9yZWFzb24YEyABKAkSNQoRbWFpbnRlbmFuY2Vfc2luY2UYFCABKAsyGi5nb29nbGUucHJvdG9idWYuVGltZXN0YW1wEjUKEW1haW50ZW5hbmNlX3VudGlsGBUgASgLMhouZ29vZ2xlLnByb3RvYnVmLlRpbWVzdGFtcCIVChNTdGFydFN0b3JhZ2VSZXF1ZXN0IiYKFFN0YXJ0U3RvcmFnZVJlc3BvbnNlEg4KBnN0YXR1cxgBIAEoCSIUChJTdG9wU3RvcmFnZVJlcXVlc3QiJQoTU3RvcFN0b3JhZ2VSZXNwb25zZRIOCgZzdGF0dXMYASABKAkiagocU2V0U3RvcmFnZU1haW50ZW5hbmNlUmVxdWVzdBIPCgdlbmFibGVkGAEgASgIEg4KBnJlYXNvbhgCIAEoCRIpCgV1bnRpbBgDIAEoCzIaLmdvb2dsZS5wcm90b2J1Zi5UaW1lc3RhbXAiLwodU2V0U3RvcmFnZU1haW50ZW5hbmNlUmVzcG9uc2USDgoGc3RhdHVzGAEgASgJMpADCg5TdG9yYWdlU2VydmljZRJhChBHZXRTdG9yYWdlU3RhdHVzEiUuYWlyZ2FwcGVyLnYxLkdldFN0b3JhZ2VTdGF0dXNSZXF1ZXN0GiYuYWlyZ2FwcGVyLnYxLkdldFN0b3JhZ2VTdGF0dXNSZXNwb25zZRJVCgxTdGFydFN0b3JhZ2USIS5haXJnYXBwZXIudjEuU3RhcnRTdG9yYWdlUmVxdWVzdBoiLmFpcmdhcHBlci52MS5TdGFydFN0b3JhZ2VSZXNwb25zZRJSCgtTdG9wU3RvcmFnZRIgLmFpcmdhcHBlci52MS5TdG9wU3RvcmFnZVJlcXVlc3QaIS5haXJnYXBwZXIudjEuU3RvcFN0b3JhZ2VSZXNwb25zZRJwChVTZXRTdG9yYWdlTWFpbnRlbmFuY2USKi5haXJnYXBwZXIudjEuU2V0U3RvcmFnZU1haW50ZW5hbmNlUmVxdWVzdBorLmFpcmdhcHBlci52MS5TZXRTdG9yYWdlTWFpbnRlbmFuY2VSZXNwb25zZWIGcHJvdG8z", [file_google_protobuf_timestamp]);
 
 /**
  * @generated from message airgapper.v1.GetStorageStatusRequest
@@ -100,6 +100,49 @@ export type GetStorageStatusResponse = Message<"airgapper.v1.GetStorageStatusRes
    * @generated from field: int64 disk_total_bytes = 14;
    */
   diskTotalBytes: bigint;
+
+  /**
+   * Storage limit from the signed policy (0 = none)
+   *
+   * @generated from field: int64 policy_max_storage_bytes = 15;
+   */
+  policyMaxStorageBytes: bigint;
+
+  /**
+   * Stricter of quota_bytes and policy_max_storage_bytes (0 = unlimited)
+   *
+   * @generated from field: int64 effective_quota_bytes = 16;
+   */
+  effectiveQuotaBytes: bigint;
+
+  /**
+   * @generated from field: int32 quota_usage_pct = 17;
+   */
+  quotaUsagePct: number;
+
+  /**
+   * Read-only maintenance mode: reads are served, writes get 503
+   *
+   * @generated from field: bool read_only = 18;
+   */
+  readOnly: boolean;
+
+  /**
+   * @generated from field: string maintenance_reason = 19;
+   */
+  maintenanceReason: string;
+
+  /**
+   * @generated from field: google.protobuf.Timestamp maintenance_since = 20;
+   */
+  maintenanceSince?: Timestamp;
+
+  /**
+   * Expected end of maintenance, if announced
+   *
+   * @generated from field: google.protobuf.Timestamp maintenance_until = 21;
+   */
+  maintenanceUntil?: Timestamp;
 };
 
 /**
@@ -169,6 +212,52 @@ export type StopStorageResponse = Message<"airgapper.v1.StopStorageResponse"> &
 export const StopStorageResponseSchema: GenMessage<StopStorageResponse> = /*@__PURE__*/
   messageDesc(file_airgapper_v1_storage, 5);
 
+/**
+ * @generated from message airgapper.v1.SetStorageMaintenanceRequest
+ */
+export type SetStorageMaintenanceRequest = Message<"airgapper.v1.SetStorageMaintenanceRequest"> & {
+  /**
+   * @generated from field: bool enabled = 1;
+   */
+  enabled: boolean;
+
+  /**
+   * @generated from field: string reason = 2;
+   */
+  reason: string;
+
+  /**
+   * Expected end of maintenance, sent to clients as Retry-After
+   *
+   * @generated from field: google.protobuf.Timestamp until = 3;
+   */
+  until?: Timestamp;
+};
+
+/**
+ * Describes the message airgapper.v1.SetStorageMaintenanceRequest.
+ * Use `create(SetStorageMaintenanceRequestSchema)` to create a new message.
+ */
+export const SetStorageMaintenanceRequestSchema: GenMessage<SetStorageMaintenanceRequest> = /*@__PURE__*/
+  messageDesc(file_airgapper_v1_storage, 6);
+
+/**
+ * @generated from message airgapper.v1.SetStorageMaintenanceResponse
+ */
+export type SetStorageMaintenanceResponse = Message<"airgapper.v1.SetStorageMaintenanceResponse"> & {
+  /**
+   * @generated from field: string status = 1;
+   */
+  status: string;
+};
+
+/**
+ * Describes the message airgapper.v1.SetStorageMaintenanceResponse.
+ * Use `create(SetStorageMaintenanceResponseSchema)` to create a new message.
+ */
+export const SetStorageMaintenanceResponseSchema: GenMessage<SetStorageMaintenanceResponse> = /*@__PURE__*/
+  messageDesc(file_airgapper_v1_storage, 7);
+
 /**
  * StorageService handles storage server management
  *
@@ -205,6 +294,17 @@ export const StorageService: GenService<{
     input: typeof StopStorageRequestSchema;
     output: typeof StopStorageResponseSchema;
   },
+  /**
+   * SetStorageMaintenance puts the storage server in or out of read-only
+   * maintenance mode
+   *
+   * @generated from rpc airgapper.v1.StorageService.SetStorageMaintenance
+   */
+  setStorageMaintenance: {
+    methodKind: "unary";
+    input: typeof SetStorageMaintenanceRequestSchema;
+    output: typeof SetStorageMaintenanceResponseSchema;
+  },
 }> = /*@__PURE__*/
   serviceDesc(file_airgapper_v1_storage, 0);
 
@@ -7,8 +7,11 @@
  * - Browser-compatible (no proxy required)
  */
 
-import { createClient } from "@connectrpc/connect";
+import { timestampFromDate } from "@bufbuild/protobuf/wkt";
+import { Code, ConnectError, createClient } from "@connectrpc/connect";
+import type { Interceptor } from "@connectrpc/connect";
 import { createConnectTransport } from "@connectrpc/connect-web";
+import { signRestoreRequest, toHex } from "./crypto";
 
 // Import generated service definitions
 import { HealthService } from "../gen/airgapper/v1/health_pb";
@@ -99,9 +102,90 @@ export type {
 // API base URL from environment or default
 const API_BASE = import.meta.env.VITE_API_URL || "http://localhost:8081";
 
+// The login session's CSRF token, sent on every call so changes made with
+// the session cookie are accepted
+let csrfToken = "";
+
+/** Event dispatched when the node wants the user to log in */
+export const UNAUTHENTICATED_EVENT = "airgapper:unauthenticated";
+
+/**
+ * Sends the session's CSRF token, and asks the app to show the login form
+ * when the node rejects a call for want of credentials
+ */
+const authInterceptor: Interceptor = (next) => async (req) => {
+  if (csrfToken) {
+    req.header.set("X-CSRF-Token", csrfToken);
+  }
+  try {
+    return await next(req);
+  } catch (err) {
+    if (err instanceof ConnectError && err.code === Code.Unauthenticated) {
+      window.dispatchEvent(new Event(UNAUTHENTICATED_EVENT));
+    }
+    throw err;
+  }
+};
+
+/** The login status reported by GET /api/v1/session */
+export interface SessionStatus {
+  auth_required: boolean;
+  user?: { name: string; role: string };
+  csrf_token?: string;
+}
+
+function sessionCall(method: "GET" | "POST", path: string, body?: unknown): Promise<Response> {
+  return fetch(`${API_BASE}/api/v1${path}`, {
+    method,
+    credentials: "include",
+    headers: {
+      "Content-Type": "application/json",
+      ...(csrfToken ? { "X-CSRF-Token": csrfToken } : {}),
+    },
+    body: body === undefined ? undefined : JSON.stringify(body),
+  });
+}
+
+/**
+ * Returns the login status, or null if the node has user accounts and
+ * nobody is logged in
+ */
+export async function getSession(): Promise<SessionStatus | null> {
+  const res = await sessionCall("GET", "/session");
+  if (res.status === 401) {
+    return null;
+  }
+  if (!res.ok) {
+    throw new Error(`Session check failed: ${res.status}`);
+  }
+  const status: SessionStatus = await res.json();
+  csrfToken = status.csrf_token ?? "";
+  return status;
+}
+
+/** Logs in with a user name and password */
+export async function login(name: string, password: string): Promise<SessionStatus> {
+  const res = await sessionCall("POST", "/session/login", { name, password });
+  const body = await res.json();
+  if (!res.ok) {
+    throw new Error(body.message ?? `Login failed: ${res.status}`);
+  }
+  csrfToken = body.csrf_token ?? "";
+  return body;
+}
+
+/** Ends the current session */
+export async function logout(): Promise<void> {
+  await sessionCall("POST", "/session/logout");
+  csrfToken = "";
+}
+
 // Create the Connect transport
 const transport = createConnectTransport({
-  baseUrl: API_BASE,
+  baseUrl: `${API_BASE}/api/v1`,
+  interceptors: [authInterceptor],
+  // Send the login session cookie
+  fetch: (input, init) => fetch(input, { ...init, credentials: "include" }),
 });
 
 // ============================================================================
@@ -196,17 +280,48 @@ export async function getRequest(id: string) {
 }
 
 /**
- * Create a restore request
+ * Create a restore request. In consensus mode the requester must sign it
+ * with their key. ttl, e.g. "72h", overrides the default 24 hours.
  */
 export async function createRequest(
   snapshotId: string,
   reason: string,
-  paths?: string[]
+  paths?: string[],
+  signer?: { requester: string; keyHolderId: string; privateKeyHex: string },
+  ttl?: string
 ) {
+  if (!signer) {
+    return requestsClient.createRequest({
+      snapshotId,
+      reason,
+      paths: paths || [],
+      ttl,
+    });
+  }
+
+  const id = toHex(crypto.getRandomValues(new Uint8Array(8)));
+  const createdAt = new Date();
+  createdAt.setMilliseconds(0);
+  const snapshot = snapshotId || "latest";
+  const signature = await signRestoreRequest(
+    signer.privateKeyHex,
+    id,
+    signer.requester,
+    snapshot,
+    reason,
+    signer.keyHolderId,
+    paths || [],
+    createdAt.getTime() / 1000
+  );
   return requestsClient.createRequest({
-    snapshotId,
+    snapshotId: snapshot,
     reason,
     paths: paths || [],
+    id,
+    createdAt: timestampFromDate(createdAt),
+    keyHolderId: signer.keyHolderId,
+    signature,
+    ttl,
   });
 }
 
@@ -218,14 +333,30 @@ export async function approveRequest(id: string) {
 }
 
 /**
- * Sign a restore request (consensus mode)
+ * Get a challenge nonce to sign a restore request's approval with
+ */
+export async function issueChallenge(id: string, keyHolderId: string) {
+  return requestsClient.issueChallenge({ id, keyHolderId });
+}
+
+/**
+ * Sign a restore request (consensus mode). The signature must cover a
+ * nonce from issueChallenge and signedAt, to the second.
  */
 export async function signRequest(
   id: string,
   keyHolderId: string,
-  signature: string
+  signature: string,
+  nonce: string,
+  signedAt: Date
 ) {
-  return requestsClient.signRequest({ id, keyHolderId, signature });
+  return requestsClient.signRequest({
+    id,
+    keyHolderId,
+    signature,
+    nonce,
+    signedAt: timestampFromDate(signedAt),
+  });
 }
 
 /**
@@ -235,6 +366,34 @@ export async function denyRequest(id: string) {
   return requestsClient.denyRequest({ id });
 }
 
+/**
+ * Ask for a pending restore request's expiry to be pushed back, e.g. "12h"
+ */
+export async function requestExtension(id: string, extendBy: string, reason?: string) {
+  return requestsClient.requestExtension({ id, extendBy, reason });
+}
+
+/**
+ * Approve a restore request's pending extension as a key holder. The
+ * signature (see signExtensionApproval) must cover a nonce from
+ * issueChallenge and signedAt, to the second.
+ */
+export async function approveExtension(
+  id: string,
+  keyHolderId: string,
+  signature: string,
+  nonce: string,
+  signedAt: Date
+) {
+  return requestsClient.approveExtension({
+    id,
+    keyHolderId,
+    signature,
+    nonce,
+    signedAt: timestampFromDate(signedAt),
+  });
+}
+
 /**
  * Initialize a new vault
  */
@@ -438,6 +438,116 @@ describe("Ed25519 Crypto Utilities", () => {
       expect(isValid).toBe(true);
     });
 
+    it("should bind the signature to the challenge nonce", async () => {
+      const keyPair = await generateKeyPair();
+
+      const signature = await signRestoreRequest(
+        keyPair.privateKey,
+        testRequest.requestId,
+        testRequest.requester,
+        testRequest.snapshotId,
+        testRequest.reason,
+        testRequest.keyHolderId,
+        testRequest.paths,
+        testRequest.createdAtUnix,
+        "nonce-1"
+      );
+
+      const verifyWith = (nonce?: string) =>
+        verifyRestoreRequestSignature(
+          keyPair.publicKey,
+          signature,
+          testRequest.requestId,
+          testRequest.requester,
+          testRequest.snapshotId,
+          testRequest.reason,
+          testRequest.keyHolderId,
+          testRequest.paths,
+          testRequest.createdAtUnix,
+          nonce
+        );
+
+      expect(await verifyWith("nonce-1")).toBe(true);
+      expect(await verifyWith("nonce-2")).toBe(false);
+      expect(await verifyWith()).toBe(false);
+    });
+
+    it("should bind the signature to the signing time", async () => {
+      const keyPair = await generateKeyPair();
+      const signedAtUnix = 1700000100;
+
+      const signature = await signRestoreRequest(
+        keyPair.privateKey,
+        testRequest.requestId,
+        testRequest.requester,
+        testRequest.snapshotId,
+        testRequest.reason,
+        testRequest.keyHolderId,
+        testRequest.paths,
+        testRequest.createdAtUnix,
+        "nonce-1",
+        signedAtUnix
+      );
+
+      const verifyAt = (signedAt?: number) =>
+        verifyRestoreRequestSignature(
+          keyPair.publicKey,
+          signature,
+          testRequest.requestId,
+          testRequest.requester,
+          testRequest.snapshotId,
+          testRequest.reason,
+          testRequest.keyHolderId,
+          testRequest.paths,
+          testRequest.createdAtUnix,
+          "nonce-1",
+          signedAt
+        );
+
+      expect(await verifyAt(signedAtUnix)).toBe(true);
+      expect(await verifyAt(signedAtUnix + 1)).toBe(false);
+      expect(await verifyAt()).toBe(false);
+    });
+
+    it("should bind the signature to the requester context", async () => {
+      const keyPair = await generateKeyPair();
+      const context = { hostname: "alice-laptop", os: "linux/amd64", sourceIp: "192.168.1.20" };
+
+      const signature = await signRestoreRequest(
+        keyPair.privateKey,
+        testRequest.requestId,
+        testRequest.requester,
+        testRequest.snapshotId,
+        testRequest.reason,
+        testRequest.keyHolderId,
+        testRequest.paths,
+        testRequest.createdAtUnix,
+        "nonce-1",
+        undefined,
+        context
+      );
+
+      const verifyWith = (ctx?: typeof context) =>
+        verifyRestoreRequestSignature(
+          keyPair.publicKey,
+          signature,
+          testRequest.requestId,
+          testRequest.requester,
+          testRequest.snapshotId,
+          testRequest.reason,
+          testRequest.keyHolderId,
+          testRequest.paths,
+          testRequest.createdAtUnix,
+          "nonce-1",
+          undefined,
+          ctx
+        );
+
+      expect(await verifyWith(context)).toBe(true);
+      expect(await verifyWith({ ...context, sourceIp: "203.0.113.9" })).toBe(false);
+      expect(await verifyWith()).toBe(false);
+    });
+
     it("should return hex signature", async () => {
       const keyPair = await generateKeyPair();
 
@@ -139,7 +139,21 @@ export async function keyId(publicKeyHex: string): Promise<string> {
 }
 
 /**
- * Create canonical hash of restore request data for signing
+ * The device a restore request was made from. The requester signs all but
+ * sourceIp; approvals cover sourceIp too.
+ */
+export interface RequesterContextSignData {
+  hostname?: string;
+  os?: string;
+  version?: string;
+  keyFingerprint?: string;
+  sourceIp?: string;
+}
+
+/**
+ * Create canonical hash of restore request data for signing. Approvals
+ * include the nonce of a challenge issued by the verifying node and the
+ * time they were signed.
  */
 export async function hashRestoreRequest(
   requestId: string,
@@ -148,7 +162,10 @@ export async function hashRestoreRequest(
   reason: string,
   keyHolderId: string,
   paths: string[],
-  createdAtUnix: number
+  createdAtUnix: number,
+  nonce?: string,
+  signedAtUnix?: number,
+  context?: RequesterContextSignData
 ): Promise<Uint8Array> {
   // Sort paths for canonical ordering
   const sortedPaths = [...paths].sort();
@@ -161,6 +178,14 @@ export async function hashRestoreRequest(
     reason,
     created_at: createdAtUnix,
     key_holder_id: keyHolderId,
+    // Omitted when empty, as the backend does
+    ...(nonce ? { nonce } : {}),
+    ...(signedAtUnix ? { signed_at: signedAtUnix } : {}),
+    ...(context?.hostname ? { requester_hostname: context.hostname } : {}),
+    ...(context?.os ? { requester_os: context.os } : {}),
+    ...(context?.version ? { requester_version: context.version } : {}),
+    ...(context?.keyFingerprint ? { requester_key_fingerprint: context.keyFingerprint } : {}),
+    ...(context?.sourceIp ? { source_ip: context.sourceIp } : {}),
   };
 
   // Create canonical JSON
@@ -184,7 +209,10 @@ export async function signRestoreRequest(
   reason: string,
   keyHolderId: string,
   paths: string[],
-  createdAtUnix: number
+  createdAtUnix: number,
+  nonce?: string,
+  signedAtUnix?: number,
+  context?: RequesterContextSignData
 ): Promise<string> {
   const hash = await hashRestoreRequest(
     requestId,
@@ -193,11 +221,38 @@ export async function signRestoreRequest(
     reason,
     keyHolderId,
     paths,
-    createdAtUnix
+    createdAtUnix,
+    nonce,
+    signedAtUnix,
+    context
   );
   return sign(privateKeyHex, hash);
 }
 
+/**
+ * Sign the approval of a restore request's pending extension: the expiry
+ * it moves the request to, with a challenge nonce and the signing time
+ */
+export async function signExtensionApproval(
+  privateKeyHex: string,
+  requestId: string,
+  keyHolderId: string,
+  expiresAtUnix: number,
+  nonce: string,
+  signedAtUnix: number
+): Promise<string> {
+  const data = {
+    request_id: requestId,
+    key_holder_id: keyHolderId,
+    expires_at: expiresAtUnix,
+    nonce,
+    signed_at: signedAtUnix,
+  };
+  const jsonBytes = new TextEncoder().encode(JSON.stringify(data));
+  const hash = await crypto.subtle.digest("SHA-256", jsonBytes.buffer as ArrayBuffer);
+  return sign(privateKeyHex, new Uint8Array(hash));
+}
+
 /**
  * Verify a restore request signature
  */
@@ -210,7 +265,10 @@ export async function verifyRestoreRequestSignature(
   reason: string,
   keyHolderId: string,
   paths: string[],
-  createdAtUnix: number
+  createdAtUnix: number,
+  nonce?: string,
+  signedAtUnix?: number,
+  context?: RequesterContextSignData
 ): Promise<boolean> {
   const hash = await hashRestoreRequest(
     requestId,
@@ -219,7 +277,10 @@ export async function verifyRestoreRequestSignature(
     reason,
     keyHolderId,
     paths,
-    createdAtUnix
+    createdAtUnix,
+    nonce,
+    signedAtUnix,
+    context
   );
   return verify(publicKeyHex, hash, signatureHex);
 }
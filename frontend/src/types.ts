@@ -65,6 +65,39 @@ export interface PendingRequest {
   approvedBy?: string;
   requiredApprovals?: number;
   approvals?: Approval[];
+  requesterContext?: RequesterContext;
+  terms?: RestoreTerms[];
+  progress?: RestoreProgress;
+  purpose?: "" | "export-keys" | "browse"; // Empty for a restore
+  template?: string;
+}
+
+/** Conditions an approver attached to their approval of a restore */
+export interface RestoreTerms {
+  window?: string; // Daily start window in the approver's time, e.g. "22:00-06:00"
+  utcOffset?: number; // Seconds
+  limitKibps?: number; // Download cap in KiB/s
+  setBy?: string;
+}
+
+/** How far an approved request's restore has got */
+export interface RestoreProgress {
+  status: "scheduled" | "running" | "completed" | "failed";
+  startsAt?: string;
+  startedAt?: string;
+  finishedAt?: string;
+  files?: number;
+  restored?: number;
+  error?: string;
+}
+
+/** The device a restore request was made from */
+export interface RequesterContext {
+  hostname?: string;
+  os?: string;
+  version?: string; // Airgapper version
+  keyFingerprint?: string;
+  sourceIp?: string;
 }
 
 export type Step = "welcome" | "init" | "join" | "dashboard";